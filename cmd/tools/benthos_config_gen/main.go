@@ -164,7 +164,7 @@ func envify(rootPath string, conf interface{}, paths map[string]string) (newConf
 		var valStr string
 		switch t := from.(type) {
 		case string:
-			if t == "," {
+			if t == "," || t == "-" {
 				valStr = `"` + t + `"`
 			} else {
 				valStr = t
@@ -1,89 +1,119 @@
 module github.com/Jeffail/benthos/v3
 
 require (
-	cloud.google.com/go v0.63.0 // indirect
-	cloud.google.com/go/pubsub v1.6.1
+	cloud.google.com/go/pubsub v1.25.1
+	cloud.google.com/go/storage v1.23.0
 	github.com/Azure/azure-pipeline-go v0.2.3 // indirect
 	github.com/Azure/azure-sdk-for-go v45.1.0+incompatible
 	github.com/Azure/azure-storage-blob-go v0.10.0
+	github.com/Azure/azure-storage-queue-go v0.0.0-20191125232315-636801874cdd
 	github.com/Azure/go-amqp v0.13.1
 	github.com/Azure/go-autorest/autorest v0.11.3 // indirect
-	github.com/Azure/go-autorest/autorest/adal v0.9.2 // indirect
+	github.com/Azure/go-autorest/autorest/adal v0.9.2
 	github.com/Azure/go-autorest/autorest/to v0.4.0 // indirect
 	github.com/ClickHouse/clickhouse-go v1.4.3
 	github.com/Jeffail/gabs/v2 v2.6.0
 	github.com/OneOfOne/xxhash v1.2.8
-	github.com/Shopify/sarama v1.27.0
+	github.com/Shopify/sarama v1.37.2
+	github.com/andybalholm/brotli v1.0.4
+	github.com/apache/pulsar-client-go v0.3.0
 	github.com/armon/go-radix v1.0.0
 	github.com/aws/aws-lambda-go v1.19.1
-	github.com/aws/aws-sdk-go v1.34.5
+	github.com/aws/aws-sdk-go v1.35.20
 	github.com/benhoyt/goawk v1.6.1
 	github.com/bradfitz/gomemcache v0.0.0-20190913173617-a41fca850d0b
 	github.com/cenkalti/backoff/v4 v4.0.2
-	github.com/clbanning/mxj v1.8.4
 	github.com/colinmarc/hdfs v1.1.3
+	github.com/denisenkom/go-mssqldb v0.9.0
 	github.com/dgraph-io/ristretto v0.0.3
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/dlclark/regexp2 v1.12.0 // indirect
 	github.com/dnaeon/go-vcr v1.0.1 // indirect
+	github.com/dop251/goja v0.0.0-20201221183957-6b6d5e2b5d80
+	github.com/eclipse/paho.golang v0.10.0
 	github.com/eclipse/paho.mqtt.golang v1.2.0
 	github.com/edsrzf/mmap-go v1.0.0
 	github.com/fatih/color v1.9.0
+	github.com/frankban/quicktest v1.10.0 // indirect
+	github.com/fsnotify/fsnotify v1.4.9
+	github.com/fxamacker/cbor/v2 v2.2.0
+	github.com/go-kit/kit v0.10.0 // indirect
+	github.com/go-mysql-org/go-mysql v1.1.2
 	github.com/go-redis/redis/v7 v7.4.0
+	github.com/go-sourcemap/sourcemap v2.1.4+incompatible // indirect
 	github.com/go-sql-driver/mysql v1.5.0
+	github.com/gocql/gocql v1.7.0
 	github.com/gofrs/uuid v3.3.0+incompatible
-	github.com/golang/protobuf v1.4.2
-	github.com/google/go-cmp v0.5.1
+	github.com/golang/protobuf v1.5.2
+	github.com/golang/snappy v0.0.4
+	github.com/google/go-cmp v0.5.8
 	github.com/google/gofuzz v1.1.0
 	github.com/gopherjs/gopherjs v0.0.0-20181103185306-d547d1d9531e // indirect
-	github.com/gorilla/mux v1.7.4
+	github.com/gorilla/mux v1.8.0
 	github.com/gorilla/websocket v1.4.2
 	github.com/hashicorp/golang-lru v0.5.3 // indirect
 	github.com/influxdata/go-syslog/v3 v3.0.0
 	github.com/itchyny/gojq v0.10.0
 	github.com/jhump/protoreflect v1.7.0
-	github.com/jmespath/go-jmespath v0.3.0
-	github.com/klauspost/compress v1.10.11 // indirect
+	github.com/jmespath/go-jmespath v0.4.0
+	github.com/klauspost/compress v1.16.7
 	github.com/lib/pq v1.8.0
 	github.com/linkedin/goavro/v2 v2.9.8
 	github.com/mailru/easyjson v0.7.3 // indirect
-	github.com/mattn/go-colorable v0.1.7 // indirect
 	github.com/microcosm-cc/bluemonday v1.0.4
 	github.com/nats-io/jwt v1.0.1 // indirect
 	github.com/nats-io/nats-streaming-server v0.16.1-0.20190905144423-ed7405a40a25 // indirect
 	github.com/nats-io/nats.go v1.10.0
 	github.com/nats-io/nkeys v0.2.0 // indirect
 	github.com/nats-io/stan.go v0.7.0
+	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
 	github.com/nsqio/go-nsq v1.0.8
 	github.com/olivere/elastic/v7 v7.0.19
 	github.com/opentracing/opentracing-go v1.2.0
 	github.com/ory/dockertest/v3 v3.6.0
+	github.com/oschwald/maxminddb-golang v1.8.0
 	github.com/patrobinson/gokini v0.1.0
 	github.com/pebbe/zmq4 v1.2.1
-	github.com/prometheus/client_golang v1.7.1
-	github.com/prometheus/common v0.12.0 // indirect
+	github.com/pierrec/lz4 v2.5.2+incompatible
+	github.com/pkg/sftp v1.13.0
+	github.com/prometheus/client_golang v1.13.0
 	github.com/quipo/dependencysolver v0.0.0-20170801134659-2b009cb4ddcc
 	github.com/quipo/statsd v0.0.0-20180118161217-3d6a5565f314
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/satori/go.uuid v1.2.0 // indirect
+	github.com/shopspring/decimal v0.0.0-20180709203117-cd690d0c9e24
+	github.com/siddontang/go-log v0.0.0-20180807004314-8d05993dda07
 	github.com/smira/go-statsd v1.3.1
 	github.com/spf13/cast v1.3.1
 	github.com/streadway/amqp v1.0.0
-	github.com/stretchr/testify v1.6.1
+	github.com/stretchr/testify v1.8.0
+	github.com/tetratelabs/wazero v1.0.0
 	github.com/tilinna/z85 v1.0.0
 	github.com/trivago/grok v1.0.0
 	github.com/trivago/tgo v1.0.5 // indirect
 	github.com/uber/jaeger-client-go v2.25.0+incompatible
 	github.com/uber/jaeger-lib v2.2.0+incompatible // indirect
-	github.com/urfave/cli/v2 v2.2.0
+	github.com/urfave/cli/v2 v2.11.0
+	github.com/vmihailenco/msgpack/v5 v5.0.0
 	github.com/xdg/scram v0.0.0-20180814205039-7eeb5667e42c
+	github.com/xdg/stringprep v1.0.0 // indirect
 	github.com/xeipuuv/gojsonpointer v0.0.0-20190905194746-02993c407bfb // indirect
 	github.com/xeipuuv/gojsonschema v1.2.0
+	github.com/xitongsys/parquet-go v1.5.4
+	go.mongodb.org/mongo-driver v1.4.6
 	go.uber.org/atomic v1.6.0 // indirect
-	golang.org/x/crypto v0.0.0-20200728195943-123391ffb6de // indirect
-	golang.org/x/net v0.0.0-20200813134508-3edf25e44fcc // indirect
-	golang.org/x/sync v0.0.0-20200625203802-6e8e738ad208
-	golang.org/x/sys v0.0.0-20200814200057-3d37ad5750ed // indirect
-	golang.org/x/tools v0.0.0-20200814230902-9882f1d1823d // indirect
-	google.golang.org/genproto v0.0.0-20200815001618-f69a88009b70 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
+	golang.org/x/crypto v0.0.0-20220722155217-630584e8d5aa
+	golang.org/x/net v0.0.0-20220927171203-f486391704dc
+	golang.org/x/oauth2 v0.0.0-20220622183110-fd043fe589d2
+	golang.org/x/sync v0.0.0-20220923202941-7f9b1623fab7
+	google.golang.org/api v0.93.0
+	google.golang.org/genproto v0.0.0-20220822174746-9e6da59bd2fc
+	google.golang.org/grpc v1.48.0
+	google.golang.org/protobuf v1.28.1
+	gopkg.in/inf.v0 v0.9.1
+	gopkg.in/jcmturner/gokrb5.v7 v7.5.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
+	modernc.org/sqlite v1.11.2
 	nanomsg.org/go-mangos v1.4.0
 )
 
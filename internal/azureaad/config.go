@@ -0,0 +1,84 @@
+package azureaad
+
+import (
+	"context"
+
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+)
+
+const aadEndpoint = "https://login.microsoftonline.com/"
+
+// Config holds configuration for authenticating with Azure Active Directory.
+type Config struct {
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+	TenantID     string `json:"tenant_id" yaml:"tenant_id"`
+	ClientID     string `json:"client_id" yaml:"client_id"`
+	ClientSecret string `json:"client_secret" yaml:"client_secret"`
+}
+
+// NewConfig returns a Config with default values.
+func NewConfig() Config {
+	return Config{
+		Enabled:      false,
+		TenantID:     "",
+		ClientID:     "",
+		ClientSecret: "",
+	}
+}
+
+// FieldSpec returns a spec for an aad config field, where the description
+// explains what Azure Active Directory authentication replaces.
+func FieldSpec(description string) docs.FieldSpec {
+	return docs.FieldAdvanced("aad", description).WithChildren(
+		docs.FieldCommon("enabled", "Whether to authenticate with Azure Active Directory."),
+		docs.FieldCommon("tenant_id", "The tenant ID of the service principal."),
+		docs.FieldCommon("client_id", "The client ID of the service principal, or of a user assigned managed identity."),
+		docs.FieldCommon("client_secret", "The client secret of the service principal. When empty a managed identity is used instead. It is recommended that you use environment variables to populate this field.", "${AZURE_CLIENT_SECRET}"),
+	)
+}
+
+//------------------------------------------------------------------------------
+
+// TokenSource obtains tokens for a resource from Azure Active Directory,
+// refreshing them before they expire.
+type TokenSource struct {
+	spt *adal.ServicePrincipalToken
+}
+
+// NewTokenSource creates a TokenSource for a resource, which authenticates as
+// a service principal when a client secret is configured and as a managed
+// identity otherwise.
+func NewTokenSource(conf Config, resource string) (*TokenSource, error) {
+	var spt *adal.ServicePrincipalToken
+	var err error
+	if conf.ClientSecret != "" {
+		var oauthConf *adal.OAuthConfig
+		if oauthConf, err = adal.NewOAuthConfig(aadEndpoint, conf.TenantID); err != nil {
+			return nil, err
+		}
+		spt, err = adal.NewServicePrincipalToken(*oauthConf, conf.ClientID, conf.ClientSecret, resource)
+	} else {
+		var endpoint string
+		if endpoint, err = adal.GetMSIEndpoint(); err != nil {
+			return nil, err
+		}
+		if conf.ClientID != "" {
+			spt, err = adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(endpoint, resource, conf.ClientID)
+		} else {
+			spt, err = adal.NewServicePrincipalTokenFromMSI(endpoint, resource)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &TokenSource{spt: spt}, nil
+}
+
+// Token returns a fresh token.
+func (t *TokenSource) Token(ctx context.Context) (adal.Token, error) {
+	if err := t.spt.EnsureFreshWithContext(ctx); err != nil {
+		return adal.Token{}, err
+	}
+	return t.spt.Token(), nil
+}
@@ -0,0 +1,4 @@
+// Package azureaad provides configuration fields for authenticating with Azure
+// Active Directory, using either a service principal or a managed identity, and
+// obtains the OAuth tokens that authorize requests to Azure services.
+package azureaad
@@ -0,0 +1,331 @@
+package azureamqp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/internal/azureaad"
+	"github.com/Jeffail/benthos/v3/lib/log"
+)
+
+const (
+	tokenTypeSAS = "servicebus.windows.net:sastoken"
+	tokenTypeJWT = "jwt"
+
+	sasTokenLifetime = time.Hour
+)
+
+//------------------------------------------------------------------------------
+
+type token struct {
+	value     string
+	tokenType string
+	expires   time.Time
+}
+
+type tokenProvider interface {
+	getToken(ctx context.Context, audience string) (token, error)
+}
+
+// sasTokenProvider creates shared access signature tokens from a key.
+type sasTokenProvider struct {
+	keyName string
+	key     string
+}
+
+func (s sasTokenProvider) getToken(ctx context.Context, audience string) (token, error) {
+	expires := time.Now().Add(sasTokenLifetime)
+	return token{
+		value:     sasToken(audience, s.keyName, s.key, expires),
+		tokenType: tokenTypeSAS,
+		expires:   expires,
+	}, nil
+}
+
+func sasToken(resource, keyName, key string, expires time.Time) string {
+	encodedResource := url.QueryEscape(resource)
+	expiry := strconv.FormatInt(expires.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(encodedResource + "\n" + expiry))
+	sig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return fmt.Sprintf(
+		"SharedAccessSignature sr=%s&sig=%s&se=%s&skn=%s",
+		encodedResource, url.QueryEscape(sig), expiry, url.QueryEscape(keyName),
+	)
+}
+
+// aadTokenProvider obtains tokens from Azure Active Directory.
+type aadTokenProvider struct {
+	source *azureaad.TokenSource
+}
+
+func newAADTokenProvider(conf AADConfig, resource string) (*aadTokenProvider, error) {
+	source, err := azureaad.NewTokenSource(conf, resource)
+	if err != nil {
+		return nil, err
+	}
+	return &aadTokenProvider{source: source}, nil
+}
+
+func (a *aadTokenProvider) getToken(ctx context.Context, audience string) (token, error) {
+	t, err := a.source.Token(ctx)
+	if err != nil {
+		return token{}, err
+	}
+	return token{
+		value:     t.AccessToken,
+		tokenType: tokenTypeJWT,
+		expires:   t.Expires(),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Client is an AMQP connection to an Azure namespace that is authorized to
+// access a single entity, such as an event hub or a queue. Authorization is
+// refreshed in the background until the client is closed.
+type Client struct {
+	conn    *amqp.Client
+	session *amqp.Session
+
+	namespace string
+	entity    string
+	audience  string
+	provider  tokenProvider
+
+	rpcMut sync.Mutex
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	log log.Modular
+}
+
+// Dial connects to the namespace of a config and authorizes access to an
+// entity. When the entity is empty the entity path of the connection string is
+// used instead. The aadResource is the resource that tokens are requested for
+// when authenticating with Azure Active Directory.
+func Dial(ctx context.Context, conf Config, entity, aadResource string, log log.Modular) (*Client, error) {
+	c := &Client{
+		namespace: conf.Namespace,
+		entity:    entity,
+		closeChan: make(chan struct{}),
+		log:       log,
+	}
+
+	if conf.ConnectionString != "" {
+		connStr, err := parseConnectionString(conf.ConnectionString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse connection string: %v", err)
+		}
+		if c.namespace == "" {
+			c.namespace = connStr.Namespace
+		}
+		if c.entity == "" {
+			c.entity = connStr.EntityPath
+		}
+		c.provider = sasTokenProvider{keyName: connStr.KeyName, key: connStr.Key}
+	}
+	if conf.AAD.Enabled {
+		var err error
+		if c.provider, err = newAADTokenProvider(conf.AAD, aadResource); err != nil {
+			return nil, fmt.Errorf("failed to create Azure Active Directory token provider: %v", err)
+		}
+	}
+
+	if c.namespace == "" {
+		return nil, errors.New("a namespace or connection string must be specified")
+	}
+	if c.entity == "" {
+		return nil, errors.New("an entity name must be specified, either explicitly or as the EntityPath of a connection string")
+	}
+	if c.provider == nil {
+		return nil, errors.New("either a connection string or Azure Active Directory authentication must be specified")
+	}
+	c.audience = fmt.Sprintf("sb://%v/%v", c.namespace, c.entity)
+
+	var err error
+	if c.conn, err = amqp.Dial(
+		"amqps://"+c.namespace,
+		amqp.ConnSASLAnonymous(),
+		amqp.ConnConnectTimeout(time.Second*30),
+	); err != nil {
+		return nil, err
+	}
+	if c.session, err = c.conn.NewSession(); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+
+	expires, err := c.authorize(ctx)
+	if err != nil {
+		c.conn.Close()
+		return nil, fmt.Errorf("failed to authorize access to '%v': %w", c.entity, err)
+	}
+
+	go c.refreshLoop(expires)
+	return c, nil
+}
+
+// Namespace returns the fully qualified namespace of the client.
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// Entity returns the name of the entity that the client is authorized for.
+func (c *Client) Entity() string {
+	return c.entity
+}
+
+// Session returns the AMQP session of the client.
+func (c *Client) Session() *amqp.Session {
+	return c.session
+}
+
+// SecurityToken returns a token for the entity, which some management
+// operations require in addition to the connection being authorized.
+func (c *Client) SecurityToken(ctx context.Context) (string, error) {
+	t, err := c.provider.getToken(ctx, c.audience)
+	if err != nil {
+		return "", err
+	}
+	return t.value, nil
+}
+
+// authorize puts a token for the entity to the claims based security node of
+// the namespace, returning the time at which it expires.
+func (c *Client) authorize(ctx context.Context) (time.Time, error) {
+	t, err := c.provider.getToken(ctx, c.audience)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if _, err = c.RPC(ctx, "$cbs", &amqp.Message{
+		Value: t.value,
+		ApplicationProperties: map[string]interface{}{
+			"operation":  "put-token",
+			"type":       t.tokenType,
+			"name":       c.audience,
+			"expiration": strconv.FormatInt(t.expires.Unix(), 10),
+		},
+	}); err != nil {
+		return time.Time{}, err
+	}
+	return t.expires, nil
+}
+
+func (c *Client) refreshLoop(expires time.Time) {
+	for {
+		wait := time.Until(expires) - time.Minute*5
+		if wait < time.Second*10 {
+			wait = time.Second * 10
+		}
+		select {
+		case <-time.After(wait):
+		case <-c.closeChan:
+			return
+		}
+
+		ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+		newExpires, err := c.authorize(ctx)
+		done()
+		if err != nil {
+			c.log.Errorf("Failed to refresh authorization of '%v': %v\n", c.entity, err)
+			continue
+		}
+		expires = newExpires
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func randomID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func intProperty(props map[string]interface{}, keys ...string) (int64, bool) {
+	for _, k := range keys {
+		switch v := props[k].(type) {
+		case int32:
+			return int64(v), true
+		case int64:
+			return v, true
+		case int:
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+// RPC sends a request message to a node of the namespace and returns the
+// response, or an error if the response status indicates a failure.
+func (c *Client) RPC(ctx context.Context, address string, msg *amqp.Message) (*amqp.Message, error) {
+	c.rpcMut.Lock()
+	defer c.rpcMut.Unlock()
+
+	replyTo := "benthos-" + randomID()
+
+	sender, err := c.session.NewSender(amqp.LinkTargetAddress(address))
+	if err != nil {
+		return nil, err
+	}
+	defer sender.Close(context.Background())
+
+	receiver, err := c.session.NewReceiver(
+		amqp.LinkSourceAddress(address),
+		amqp.LinkTargetAddress(replyTo),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer receiver.Close(context.Background())
+
+	if msg.Properties == nil {
+		msg.Properties = &amqp.MessageProperties{}
+	}
+	msg.Properties.MessageID = randomID()
+	msg.Properties.ReplyTo = replyTo
+
+	if err = sender.Send(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	res, err := receiver.Receive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res.Accept(ctx)
+
+	if status, ok := intProperty(res.ApplicationProperties, "status-code", "statusCode"); ok && (status < 200 || status >= 300) {
+		description, _ := res.ApplicationProperties["status-description"].(string)
+		if description == "" {
+			description, _ = res.ApplicationProperties["statusDescription"].(string)
+		}
+		return nil, fmt.Errorf("request to '%v' failed with status %v: %v", address, status, description)
+	}
+	return res, nil
+}
+
+// Close the client and its underlying connection.
+func (c *Client) Close(ctx context.Context) error {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+	})
+	return c.conn.Close()
+}
+
+//------------------------------------------------------------------------------
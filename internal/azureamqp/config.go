@@ -0,0 +1,87 @@
+package azureamqp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Jeffail/benthos/v3/internal/azureaad"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+)
+
+// Resources that tokens are requested for when authenticating with Azure
+// Active Directory.
+const (
+	EventHubsResource  = "https://eventhubs.azure.net/"
+	ServiceBusResource = "https://servicebus.azure.net/"
+)
+
+// AADConfig holds configuration for authenticating with Azure Active
+// Directory.
+type AADConfig = azureaad.Config
+
+// Config holds configuration for connecting to an Azure AMQP based service.
+type Config struct {
+	Namespace        string    `json:"namespace" yaml:"namespace"`
+	ConnectionString string    `json:"connection_string" yaml:"connection_string"`
+	AAD              AADConfig `json:"aad" yaml:"aad"`
+}
+
+// NewConfig returns a Config with default values.
+func NewConfig() Config {
+	return Config{
+		Namespace:        "",
+		ConnectionString: "",
+		AAD:              azureaad.NewConfig(),
+	}
+}
+
+// FieldSpecs returns specs for the common connection fields.
+func FieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldCommon("namespace", "The fully qualified namespace to connect to. This field can be left empty when a connection string is provided.", "foo.servicebus.windows.net"),
+		docs.FieldCommon("connection_string", "A connection string containing a shared access key, which can be obtained from the Azure portal. It is recommended that you use environment variables to populate this field.", "${AZURE_CONNECTION_STRING}"),
+		azureaad.FieldSpec("Authenticate with Azure Active Directory instead of a shared access key, using either a service principal or, when a client secret is not provided, a managed identity."),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// connectionString is the parsed form of a shared access key connection
+// string.
+type connectionString struct {
+	Namespace  string
+	KeyName    string
+	Key        string
+	EntityPath string
+}
+
+func parseConnectionString(str string) (connectionString, error) {
+	var c connectionString
+	for _, pair := range strings.Split(str, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return c, fmt.Errorf("invalid connection string segment: %v", pair)
+		}
+		switch strings.ToLower(kv[0]) {
+		case "endpoint":
+			c.Namespace = strings.TrimSuffix(strings.TrimPrefix(kv[1], "sb://"), "/")
+		case "sharedaccesskeyname":
+			c.KeyName = kv[1]
+		case "sharedaccesskey":
+			c.Key = kv[1]
+		case "entitypath":
+			c.EntityPath = kv[1]
+		}
+	}
+	if c.Namespace == "" {
+		return c, errors.New("connection string is missing an endpoint")
+	}
+	if c.KeyName == "" || c.Key == "" {
+		return c, errors.New("connection string is missing a shared access key")
+	}
+	return c, nil
+}
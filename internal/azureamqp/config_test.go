@@ -0,0 +1,53 @@
+package azureamqp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConnectionString(t *testing.T) {
+	c, err := parseConnectionString("Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=abc=;EntityPath=bar")
+	require.NoError(t, err)
+	assert.Equal(t, connectionString{
+		Namespace:  "foo.servicebus.windows.net",
+		KeyName:    "RootManageSharedAccessKey",
+		Key:        "abc=",
+		EntityPath: "bar",
+	}, c)
+
+	c, err = parseConnectionString("Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=baz;SharedAccessKey=abc;")
+	require.NoError(t, err)
+	assert.Equal(t, "", c.EntityPath)
+
+	for _, str := range []string{
+		"",
+		"SharedAccessKeyName=baz;SharedAccessKey=abc",
+		"Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=baz",
+		"Endpoint=sb://foo.servicebus.windows.net/;nope",
+	} {
+		_, err = parseConnectionString(str)
+		assert.Error(t, err, str)
+	}
+}
+
+func TestSASToken(t *testing.T) {
+	expires := time.Unix(1600000000, 0)
+	token := sasToken("sb://foo.servicebus.windows.net/bar", "baz", "key", expires)
+
+	require.True(t, strings.HasPrefix(token, "SharedAccessSignature "))
+	values, err := url.ParseQuery(strings.TrimPrefix(token, "SharedAccessSignature "))
+	require.NoError(t, err)
+
+	assert.Equal(t, "sb://foo.servicebus.windows.net/bar", values.Get("sr"))
+	assert.Equal(t, "1600000000", values.Get("se"))
+	assert.Equal(t, "baz", values.Get("skn"))
+	assert.NotEmpty(t, values.Get("sig"))
+
+	assert.Equal(t, token, sasToken("sb://foo.servicebus.windows.net/bar", "baz", "key", expires))
+	assert.NotEqual(t, token, sasToken("sb://foo.servicebus.windows.net/bar", "baz", "other", expires))
+}
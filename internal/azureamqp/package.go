@@ -0,0 +1,5 @@
+// Package azureamqp provides configuration fields and a client for connecting
+// to Azure services that are accessed via AMQP 1.0, such as Event Hubs and
+// Service Bus, including claims based authorization with either shared access
+// signatures or Azure Active Directory.
+package azureamqp
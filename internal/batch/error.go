@@ -82,3 +82,41 @@ func (e *Error) Error() string {
 func (e *Error) Unwrap() error {
 	return e.err
 }
+
+// FailedIndexes returns the indexes of the messages of a batch that failed to
+// be sent along with their errors. The granular errors of a batch Error are
+// only used when they reference the batch that was sent, which might not be the
+// case when an output modified it, and when none of the messages are marked as
+// failed all of them are returned with the batch-wide error.
+func FailedIndexes(msg types.Message, err error) ([]int, []error) {
+	partErrs := make([]error, msg.Len())
+	for i := range partErrs {
+		partErrs[i] = err
+	}
+	if bErr, ok := err.(*Error); ok && bErr.IndexedErrors() > 0 {
+		indexed := make([]error, 0, msg.Len())
+		bErr.WalkParts(func(_ int, _ types.Part, pErr error) bool {
+			indexed = append(indexed, pErr)
+			return true
+		})
+		if len(indexed) == msg.Len() {
+			partErrs = indexed
+		}
+	}
+
+	var indexes []int
+	var errs []error
+	for i, pErr := range partErrs {
+		if pErr != nil {
+			indexes = append(indexes, i)
+			errs = append(errs, pErr)
+		}
+	}
+	if len(indexes) == 0 {
+		for i := range partErrs {
+			indexes = append(indexes, i)
+			errs = append(errs, err)
+		}
+	}
+	return indexes, errs
+}
@@ -22,7 +22,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/Jeffail/benthos/v3/internal/useragent"
 	"github.com/OneOfOne/xxhash"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/tilinna/z85"
@@ -820,18 +822,32 @@ var _ = RegisterMethod(
 		"parse_csv", "",
 	).InCategory(
 		MethodCategoryParsing,
-		"Attempts to parse a string into an array of objects by following the CSV format described in RFC 4180. The first line is assumed to be a header row, which determines the keys of values in each object.",
+		"Attempts to parse a string into an array of objects by following the CSV format described in RFC 4180. The first line is assumed to be a header row, which determines the keys of values in each object. An optional argument sets the delimiter of fields, which must be a single character and defaults to a comma.",
 		NewExampleSpec("",
 			`root.orders = this.orders.parse_csv()`,
 			`{"orders":"foo,bar\nfoo 1,bar 1\nfoo 2,bar 2"}`,
 			`{"orders":[{"bar":"bar 1","foo":"foo 1"},{"bar":"bar 2","foo":"foo 2"}]}`,
 		),
+		NewExampleSpec("",
+			`root.orders = this.orders.parse_csv("\t")`,
+			`{"orders":"foo\tbar\nfoo 1\tbar 1\nfoo 2\tbar 2"}`,
+			`{"orders":[{"bar":"bar 1","foo":"foo 1"},{"bar":"bar 2","foo":"foo 2"}]}`,
+		),
 	),
-	false, parseCSVMethod,
-	ExpectNArgs(0),
+	true, parseCSVMethod,
+	ExpectOneOrZeroArgs(),
+	ExpectStringArg(0),
 )
 
-func parseCSVMethod(target Function, _ ...interface{}) (Function, error) {
+func parseCSVMethod(target Function, args ...interface{}) (Function, error) {
+	delim := ','
+	if len(args) > 0 {
+		delimStr := args[0].(string)
+		var size int
+		if delim, size = utf8.DecodeRuneInString(delimStr); size == 0 || size != len(delimStr) {
+			return nil, fmt.Errorf("delimiter must be a single character, received: %q", delimStr)
+		}
+	}
 	return simpleMethod(target, func(v interface{}, ctx FunctionContext) (interface{}, error) {
 		var csvBytes []byte
 		switch t := v.(type) {
@@ -844,6 +860,7 @@ func parseCSVMethod(target Function, _ ...interface{}) (Function, error) {
 		}
 
 		r := csv.NewReader(bytes.NewReader(csvBytes))
+		r.Comma = delim
 		strRecords, err := r.ReadAll()
 		if err != nil {
 			return nil, err
@@ -959,6 +976,121 @@ func parseTimestampMethod(target Function, args ...interface{}) (Function, error
 
 //------------------------------------------------------------------------------
 
+var _ = RegisterMethod(
+	NewMethodSpec(
+		"parse_url", "",
+	).InCategory(
+		MethodCategoryParsing,
+		"Attempts to parse a URL string into an object containing its scheme, username, host, port, path, query and fragment. Query parameters with a single value are added as strings, and parameters with multiple values as arrays of strings.",
+		NewExampleSpec("",
+			`root.url = this.url.parse_url()`,
+			`{"url":"https://example.com:8080/foo/bar?id=5&tag=a&tag=b#top"}`,
+			`{"url":{"fragment":"top","host":"example.com","path":"/foo/bar","port":"8080","query":{"id":"5","tag":["a","b"]},"raw_query":"id=5&tag=a&tag=b","scheme":"https","username":""}}`,
+		),
+		NewExampleSpec("",
+			`root.domain = this.url.parse_url().host`,
+			`{"url":"http://user@foo.example.com/index.html"}`,
+			`{"domain":"foo.example.com"}`,
+		),
+	),
+	false, parseURLMethod,
+	ExpectNArgs(0),
+)
+
+func parseURLMethod(target Function, _ ...interface{}) (Function, error) {
+	return simpleMethod(target, func(v interface{}, ctx FunctionContext) (interface{}, error) {
+		var str string
+		switch t := v.(type) {
+		case string:
+			str = t
+		case []byte:
+			str = string(t)
+		default:
+			return nil, NewTypeError(v, ValueString)
+		}
+		u, err := url.Parse(str)
+		if err != nil {
+			return nil, err
+		}
+
+		query := map[string]interface{}{}
+		for k, values := range u.Query() {
+			if len(values) == 1 {
+				query[k] = values[0]
+				continue
+			}
+			vs := make([]interface{}, len(values))
+			for i, v := range values {
+				vs[i] = v
+			}
+			query[k] = vs
+		}
+
+		return map[string]interface{}{
+			"scheme":    u.Scheme,
+			"username":  u.User.Username(),
+			"host":      u.Hostname(),
+			"port":      u.Port(),
+			"path":      u.Path,
+			"query":     query,
+			"raw_query": u.RawQuery,
+			"fragment":  u.Fragment,
+		}, nil
+	}), nil
+}
+
+//------------------------------------------------------------------------------
+
+var _ = RegisterMethod(
+	NewMethodSpec(
+		"parse_user_agent", "",
+	).InCategory(
+		MethodCategoryParsing,
+		"Attempts to parse a User-Agent string into an object containing the name and version of the browser and operating system, the type of device (`desktop`, `mobile`, `tablet`, `bot` or `other`) and whether the agent is a bot. Parsing is based on the tokens commonly found within User-Agent strings rather than a database of agents, and browsers or operating systems that aren't recognised are named `Other`.",
+		NewExampleSpec("",
+			`root.agent = this.user_agent.parse_user_agent()`,
+			`{"user_agent":"Mozilla/5.0 (iPhone; CPU iPhone OS 14_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.0.3 Mobile/15E148 Safari/604.1"}`,
+			`{"agent":{"bot":false,"browser":{"name":"Safari","version":"14.0.3"},"device":"mobile","os":{"name":"iOS","version":"14.4"}}}`,
+		),
+		NewExampleSpec("",
+			`root.is_bot = this.user_agent.parse_user_agent().bot`,
+			`{"user_agent":"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"}`,
+			`{"is_bot":true}`,
+		),
+	),
+	false, parseUserAgentMethod,
+	ExpectNArgs(0),
+)
+
+func parseUserAgentMethod(target Function, _ ...interface{}) (Function, error) {
+	return simpleMethod(target, func(v interface{}, ctx FunctionContext) (interface{}, error) {
+		var str string
+		switch t := v.(type) {
+		case string:
+			str = t
+		case []byte:
+			str = string(t)
+		default:
+			return nil, NewTypeError(v, ValueString)
+		}
+		ua := useragent.Parse(str)
+		return map[string]interface{}{
+			"browser": map[string]interface{}{
+				"name":    ua.Browser.Name,
+				"version": ua.Browser.Version,
+			},
+			"os": map[string]interface{}{
+				"name":    ua.OS.Name,
+				"version": ua.OS.Version,
+			},
+			"device": ua.Device,
+			"bot":    ua.Bot,
+		}, nil
+	}), nil
+}
+
+//------------------------------------------------------------------------------
+
 var _ = RegisterMethod(
 	NewMethodSpec(
 		"quote", "",
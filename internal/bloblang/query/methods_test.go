@@ -104,6 +104,14 @@ func TestMethods(t *testing.T) {
 			),
 			output: `[{"bar":"bar 1","foo":"foo 1"},{"bar":"bar 2","foo":"foo 2"}]`,
 		},
+		"check parse csv delimiter": {
+			input: methods(
+				literalFn("foo\tbar\nfoo 1\tbar, 1"),
+				method("parse_csv", "\t"),
+				method("string"),
+			),
+			output: `[{"bar":"bar, 1","foo":"foo 1"}]`,
+		},
 		"check parse csv error 1": {
 			input: methods(
 				literalFn("foo,bar,baz\n1,2,3,4"),
@@ -1084,6 +1092,57 @@ func TestMethods(t *testing.T) {
 			),
 			err: `failed to parse value as JSON: invalid character 'o' in literal null (expecting 'u')`,
 		},
+		"check parse url": {
+			input: methods(
+				literalFn("http://foo.com/bar?a=1&b=2&b=3"),
+				method("parse_url"),
+			),
+			output: map[string]interface{}{
+				"scheme":   "http",
+				"username": "",
+				"host":     "foo.com",
+				"port":     "",
+				"path":     "/bar",
+				"query": map[string]interface{}{
+					"a": "1",
+					"b": []interface{}{"2", "3"},
+				},
+				"raw_query": "a=1&b=2&b=3",
+				"fragment":  "",
+			},
+		},
+		"check parse url invalid": {
+			input: methods(
+				literalFn("http://foo.com/%zz"),
+				method("parse_url"),
+			),
+			err: `parse "http://foo.com/%zz": invalid URL escape "%zz"`,
+		},
+		"check parse user agent": {
+			input: methods(
+				literalFn("curl/7.68.0"),
+				method("parse_user_agent"),
+			),
+			output: map[string]interface{}{
+				"browser": map[string]interface{}{
+					"name":    "curl",
+					"version": "7.68.0",
+				},
+				"os": map[string]interface{}{
+					"name":    "Other",
+					"version": "",
+				},
+				"device": "other",
+				"bot":    false,
+			},
+		},
+		"check parse user agent not string": {
+			input: methods(
+				literalFn(int64(5)),
+				method("parse_user_agent"),
+			),
+			err: `expected string value, found number: 5`,
+		},
 		"check parse timestamp unix": {
 			input: methods(
 				literalFn("2020-08-14T11:45:26.371Z"),
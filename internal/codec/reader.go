@@ -0,0 +1,416 @@
+// Package codec provides the means of consuming a stream of bytes, such as the
+// contents of a file, as a series of message parts.
+package codec
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/internal/parquet"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// ReaderDocs is a markdown description of the codecs that a Reader supports.
+const ReaderDocs = "The way in which the bytes of a data source should be converted into discrete messages, codecs are useful for specifying how large files or continuous streams of data might be processed in small chunks rather than loading it all in memory. It's possible to consume lines using a custom delimiter with the `delim:x` codec, where x is the character sequence custom delimiter, or a regular expression with the `regex:x` codec, where x is an expression that matches delimiters. The `chunker:x` codec consumes chunks of x bytes. The `csv` codec consumes each row of a CSV document as a JSON object, where the keys are the values of the first row. Binary frames that are prefixed with their length can be consumed with the `length-prefixed` codec, where the length is a 32-bit big-endian unsigned integer, and with the `protobuf-delimited` codec, where the length is a varint as written by protobuf delimited writers. The `parquet` codec consumes each row of a Parquet file as a JSON object, which requires the whole file to be read into memory. Codecs can be chained with `/`, for example a gzip compressed file of lines can be consumed with the codec `gzip/lines`."
+
+// ReaderConfig is a general configuration struct that covers all reader
+// codecs.
+type ReaderConfig struct {
+	MaxScanTokenSize int
+}
+
+// ReaderAckFn is a function provided to a reader codec that it should call once
+// the underlying io.ReadCloser is fully consumed and all messages derived from
+// it have been acknowledged, along with the first error of their
+// acknowledgements, if any.
+type ReaderAckFn func(context.Context, error) error
+
+// Reader is a codec type that reads message parts from a source.
+type Reader interface {
+	// Next returns the parts of the next message along with a function to
+	// acknowledge them with, or io.EOF once the source is fully consumed.
+	Next(context.Context) ([]types.Part, ReaderAckFn, error)
+
+	// Close the reader and the underlying source.
+	Close(context.Context) error
+}
+
+// ReaderConstructor creates a reader from an io.ReadCloser.
+type ReaderConstructor func(r io.ReadCloser, fn ReaderAckFn) (Reader, error)
+
+// GetReader returns a constructor that creates reader codecs of the codec
+// name, which may consist of decompression codecs followed by a codec that
+// splits the decompressed bytes into messages, delimited with `/`.
+func GetReader(codec string, conf ReaderConfig) (ReaderConstructor, error) {
+	names := strings.Split(codec, "/")
+	for i, name := range names[:len(names)-1] {
+		if name != "gzip" {
+			return nil, fmt.Errorf("codec %v at position %v is not a decompression codec", name, i)
+		}
+	}
+
+	var ctor func(r io.Reader) func() ([]byte, error)
+	switch name := names[len(names)-1]; {
+	case name == "all-bytes" || name == "gzip":
+		if name == "gzip" {
+			names = append(names, "all-bytes")
+		}
+		ctor = allBytesReader
+	case name == "lines":
+		ctor = func(r io.Reader) func() ([]byte, error) {
+			return linesReader(conf, r, bufio.ScanLines)
+		}
+	case strings.HasPrefix(name, "delim:"):
+		delim := []byte(strings.TrimPrefix(name, "delim:"))
+		if len(delim) == 0 {
+			return nil, errors.New("custom delimiter codec requires a non-empty delimiter")
+		}
+		ctor = func(r io.Reader) func() ([]byte, error) {
+			return linesReader(conf, r, delimSplitter(delim))
+		}
+	case strings.HasPrefix(name, "regex:"):
+		expr, err := regexp.Compile(strings.TrimPrefix(name, "regex:"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile regex delimiter: %v", err)
+		}
+		ctor = func(r io.Reader) func() ([]byte, error) {
+			return linesReader(conf, r, regexSplitter(expr))
+		}
+	case strings.HasPrefix(name, "chunker:"):
+		size, err := strconv.ParseUint(strings.TrimPrefix(name, "chunker:"), 10, 0)
+		if err != nil || size == 0 {
+			return nil, fmt.Errorf("chunker codec requires a positive chunk size: %v", name)
+		}
+		ctor = func(r io.Reader) func() ([]byte, error) {
+			return chunkReader(r, int(size))
+		}
+	case name == "csv":
+		ctor = csvReader
+	case name == "length-prefixed":
+		ctor = func(r io.Reader) func() ([]byte, error) {
+			return framesReader(conf, r, func(r *bufio.Reader) (uint64, error) {
+				var prefix [4]byte
+				if _, err := io.ReadFull(r, prefix[:]); err != nil {
+					return 0, err
+				}
+				return uint64(binary.BigEndian.Uint32(prefix[:])), nil
+			})
+		}
+	case name == "protobuf-delimited":
+		ctor = func(r io.Reader) func() ([]byte, error) {
+			return framesReader(conf, r, func(r *bufio.Reader) (uint64, error) {
+				return binary.ReadUvarint(r)
+			})
+		}
+	case name == "tar":
+		ctor = tarReader
+	case name == "parquet":
+		ctor = parquetReader
+	default:
+		return nil, fmt.Errorf("codec was not recognised: %v", name)
+	}
+
+	decompressors := names[:len(names)-1]
+	return func(r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+		var src io.Reader = r
+		closers := []io.Closer{r}
+		for range decompressors {
+			gr, err := gzip.NewReader(src)
+			if err != nil {
+				r.Close()
+				return nil, fmt.Errorf("failed to read gzip header: %v", err)
+			}
+			src = gr
+			closers = append(closers, gr)
+		}
+		return &partReader{
+			next:    ctor(src),
+			closers: closers,
+			acks:    &ackTracker{fn: fn},
+		}, nil
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func allBytesReader(r io.Reader) func() ([]byte, error) {
+	consumed := false
+	return func() ([]byte, error) {
+		if consumed {
+			return nil, io.EOF
+		}
+		consumed = true
+		return ioutil.ReadAll(r)
+	}
+}
+
+// linesReader returns the non-empty tokens of a scanner.
+func linesReader(conf ReaderConfig, r io.Reader, split bufio.SplitFunc) func() ([]byte, error) {
+	scanner := bufio.NewScanner(r)
+	if conf.MaxScanTokenSize > 0 && conf.MaxScanTokenSize != bufio.MaxScanTokenSize {
+		scanner.Buffer([]byte{}, conf.MaxScanTokenSize)
+	}
+	scanner.Split(split)
+	return func() ([]byte, error) {
+		for scanner.Scan() {
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			return append([]byte(nil), scanner.Bytes()...), nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+}
+
+func delimSplitter(delim []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.Index(data, delim); i >= 0 {
+			return i + len(delim), data[0:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// regexSplitter splits data by the matches of a regular expression. Matches that
+// end with the data are only used at the end of the stream, as they might
+// continue with the bytes that follow.
+func regexSplitter(expr *regexp.Regexp) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if loc := expr.FindIndex(data); loc != nil && loc[1] > 0 && (loc[1] < len(data) || atEOF) {
+			return loc[1], data[0:loc[0]], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// chunkReader returns consecutive chunks of a fixed size, where the last chunk
+// may be smaller.
+func chunkReader(r io.Reader, size int) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		chunk := make([]byte, size)
+		n, err := io.ReadFull(r, chunk)
+		if err == io.ErrUnexpectedEOF {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return chunk[:n], nil
+	}
+}
+
+// csvReader returns each record of a CSV document following the first as a
+// JSON object, where the keys are the fields of the first record.
+func csvReader(r io.Reader) func() ([]byte, error) {
+	cr := csv.NewReader(r)
+	var headers []string
+	return func() ([]byte, error) {
+		if headers == nil {
+			var err error
+			if headers, err = cr.Read(); err != nil {
+				return nil, err
+			}
+		}
+		record, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		obj := make(map[string]string, len(headers))
+		for i, h := range headers {
+			obj[h] = record[i]
+		}
+		return json.Marshal(obj)
+	}
+}
+
+// framesReader returns frames that are each prefixed with their length, which
+// is read with the provided function.
+func framesReader(conf ReaderConfig, r io.Reader, readLen func(r *bufio.Reader) (uint64, error)) func() ([]byte, error) {
+	br := bufio.NewReader(r)
+	return func() ([]byte, error) {
+		size, err := readLen(br)
+		if err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = errors.New("stream ended within a frame length prefix")
+			}
+			return nil, err
+		}
+		if conf.MaxScanTokenSize > 0 && size > uint64(conf.MaxScanTokenSize) {
+			return nil, fmt.Errorf("frame of %v bytes exceeds the maximum of %v bytes", size, conf.MaxScanTokenSize)
+		}
+		frame := make([]byte, size)
+		if _, err = io.ReadFull(br, frame); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				err = fmt.Errorf("stream ended within a frame of %v bytes", size)
+			}
+			return nil, err
+		}
+		return frame, nil
+	}
+}
+
+// tarReader returns the contents of each regular file of a tar archive.
+func tarReader(r io.Reader) func() ([]byte, error) {
+	tr := tar.NewReader(r)
+	return func() ([]byte, error) {
+		for {
+			hdr, err := tr.Next()
+			if err != nil {
+				return nil, err
+			}
+			if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+				return ioutil.ReadAll(tr)
+			}
+		}
+	}
+}
+
+// parquetReader returns each row of a Parquet file as a JSON object, reading
+// the file one row group at a time. The file is read fully into memory as
+// Parquet metadata is stored at the end of a file.
+func parquetReader(r io.Reader) func() ([]byte, error) {
+	var pr *parquet.Reader
+	var rows [][]byte
+	return func() ([]byte, error) {
+		if pr == nil {
+			b, err := ioutil.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			if pr, err = parquet.NewReader(b); err != nil {
+				return nil, err
+			}
+		}
+		for len(rows) == 0 {
+			var err error
+			if rows, err = pr.NextRowGroup(); err != nil {
+				return nil, err
+			}
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row, nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ackTracker calls an acknowledgement function once a source is fully
+// consumed and all pending acknowledgements are resolved.
+type ackTracker struct {
+	mut      sync.Mutex
+	pending  int
+	consumed bool
+	called   bool
+	err      error
+	fn       ReaderAckFn
+}
+
+func (a *ackTracker) track() ReaderAckFn {
+	a.mut.Lock()
+	a.pending++
+	a.mut.Unlock()
+
+	var once sync.Once
+	return func(ctx context.Context, err error) error {
+		var ackErr error
+		once.Do(func() {
+			a.mut.Lock()
+			a.pending--
+			if err != nil && a.err == nil {
+				a.err = err
+			}
+			ackErr = a.callIfDone(ctx)
+		})
+		return ackErr
+	}
+}
+
+func (a *ackTracker) finish(ctx context.Context) error {
+	a.mut.Lock()
+	a.consumed = true
+	return a.callIfDone(ctx)
+}
+
+// callIfDone must be called with the mutex held, which it releases.
+func (a *ackTracker) callIfDone(ctx context.Context) error {
+	if !a.consumed || a.pending > 0 || a.called {
+		a.mut.Unlock()
+		return nil
+	}
+	a.called = true
+	err := a.err
+	a.mut.Unlock()
+	return a.fn(ctx, err)
+}
+
+//------------------------------------------------------------------------------
+
+type partReader struct {
+	next    func() ([]byte, error)
+	closers []io.Closer
+	closed  bool
+	acks    *ackTracker
+}
+
+func (p *partReader) Next(ctx context.Context) ([]types.Part, ReaderAckFn, error) {
+	b, err := p.next()
+	if err != nil {
+		if err == io.EOF {
+			// The source is closed before acknowledging it so that it can be
+			// removed by the acknowledgement.
+			p.Close(ctx)
+			p.acks.finish(ctx)
+		}
+		return nil, nil, err
+	}
+	return []types.Part{message.NewPart(b)}, p.acks.track(), nil
+}
+
+func (p *partReader) Close(ctx context.Context) error {
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	var err error
+	for i := len(p.closers) - 1; i >= 0; i-- {
+		if cerr := p.closers[i].Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,181 @@
+package codec
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/parquet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, codec string, input []byte) ([]string, []ReaderAckFn, *[]error) {
+	t.Helper()
+
+	ctor, err := GetReader(codec, ReaderConfig{MaxScanTokenSize: 1000})
+	require.NoError(t, err)
+
+	var acked []error
+	r, err := ctor(ioutil.NopCloser(bytes.NewReader(input)), func(ctx context.Context, err error) error {
+		acked = append(acked, err)
+		return nil
+	})
+	require.NoError(t, err)
+	defer r.Close(context.Background())
+
+	var parts []string
+	var ackFns []ReaderAckFn
+	for {
+		p, ackFn, err := r.Next(context.Background())
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		for _, part := range p {
+			parts = append(parts, string(part.Get()))
+		}
+		ackFns = append(ackFns, ackFn)
+	}
+	return parts, ackFns, &acked
+}
+
+func TestReaderCodecs(t *testing.T) {
+	gzipBytes := func(b []byte) []byte {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write(b)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	for _, f := range []struct{ name, content string }{
+		{"a.txt", "foo"},
+		{"b.txt", "bar"},
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: f.name, Mode: 0600, Size: int64(len(f.content)), Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(f.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "dir/", Mode: 0700, Typeflag: tar.TypeDir}))
+	require.NoError(t, tw.Close())
+
+	var framesBuf, pbBuf bytes.Buffer
+	for _, frame := range []string{"foo", "", "bar baz"} {
+		var prefix [4]byte
+		binary.BigEndian.PutUint32(prefix[:], uint32(len(frame)))
+		framesBuf.Write(prefix[:])
+		framesBuf.WriteString(frame)
+
+		varint := make([]byte, binary.MaxVarintLen64)
+		pbBuf.Write(varint[:binary.PutUvarint(varint, uint64(len(frame)))])
+		pbBuf.WriteString(frame)
+	}
+
+	pqEnc, err := parquet.NewEncoder(nil, "snappy")
+	require.NoError(t, err)
+	pqBytes, err := pqEnc.Encode([][]byte{[]byte(`{"a":1,"b":"foo"}`), []byte(`{"a":2}`)})
+	require.NoError(t, err)
+
+	tests := []struct {
+		codec    string
+		input    []byte
+		expected []string
+	}{
+		{"all-bytes", []byte("foo\nbar"), []string{"foo\nbar"}},
+		{"lines", []byte("foo\r\n\nbar\nbaz"), []string{"foo", "bar", "baz"}},
+		{"delim:||", []byte("foo||bar||||baz||"), []string{"foo", "bar", "baz"}},
+		{"tar", tarBuf.Bytes(), []string{"foo", "bar"}},
+		{"gzip", gzipBytes([]byte("foo\nbar")), []string{"foo\nbar"}},
+		{"gzip/lines", gzipBytes([]byte("foo\nbar")), []string{"foo", "bar"}},
+		{"gzip/tar", gzipBytes(tarBuf.Bytes()), []string{"foo", "bar"}},
+		{"regex:\\|+", []byte("foo|bar|||baz|"), []string{"foo", "bar", "baz"}},
+		{"regex:\\d{2}", []byte("foo12bar34baz"), []string{"foo", "bar", "baz"}},
+		{"chunker:3", []byte("foobarba"), []string{"foo", "bar", "ba"}},
+		{"csv", []byte("a,b\n1,foo\n2,\"bar,baz\"\n"), []string{`{"a":"1","b":"foo"}`, `{"a":"2","b":"bar,baz"}`}},
+		{"gzip/csv", gzipBytes([]byte("a\nfoo")), []string{`{"a":"foo"}`}},
+		{"length-prefixed", framesBuf.Bytes(), []string{"foo", "", "bar baz"}},
+		{"protobuf-delimited", pbBuf.Bytes(), []string{"foo", "", "bar baz"}},
+		{"parquet", pqBytes, []string{`{"a":1,"b":"foo"}`, `{"a":2,"b":null}`}},
+		{"gzip/parquet", gzipBytes(pqBytes), []string{`{"a":1,"b":"foo"}`, `{"a":2,"b":null}`}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.codec, func(t *testing.T) {
+			parts, _, _ := readAll(t, test.codec, test.input)
+			assert.Equal(t, test.expected, parts)
+		})
+	}
+}
+
+func TestReaderCodecErrors(t *testing.T) {
+	for _, codec := range []string{"nope", "lines/gzip", "delim:", "tar/lines", "regex:(", "chunker:0", "chunker:nope"} {
+		_, err := GetReader(codec, ReaderConfig{})
+		assert.Error(t, err, codec)
+	}
+
+	ctor, err := GetReader("gzip/lines", ReaderConfig{})
+	require.NoError(t, err)
+	_, err = ctor(ioutil.NopCloser(bytes.NewReader([]byte("not gzip"))), func(context.Context, error) error {
+		return nil
+	})
+	assert.Error(t, err)
+
+	for _, test := range []struct {
+		codec string
+		input []byte
+	}{
+		{"length-prefixed", []byte{0, 0}},
+		{"length-prefixed", []byte{0, 0, 0, 5, 'f', 'o'}},
+		{"length-prefixed", []byte{0, 1, 0, 0}},
+		{"protobuf-delimited", []byte{0x85}},
+		{"protobuf-delimited", []byte{5, 'f', 'o'}},
+		{"csv", []byte("a,b\n1,2,3\n")},
+		{"parquet", []byte("not parquet")},
+	} {
+		ctor, err := GetReader(test.codec, ReaderConfig{MaxScanTokenSize: 1000})
+		require.NoError(t, err)
+
+		r, err := ctor(ioutil.NopCloser(bytes.NewReader(test.input)), func(context.Context, error) error {
+			return nil
+		})
+		require.NoError(t, err)
+
+		_, _, err = r.Next(context.Background())
+		assert.Error(t, err, test.codec)
+		assert.NotEqual(t, io.EOF, err, test.codec)
+	}
+}
+
+func TestReaderAcks(t *testing.T) {
+	ctx := context.Background()
+
+	parts, ackFns, acked := readAll(t, "lines", []byte("foo\nbar\nbaz"))
+	require.Len(t, parts, 3)
+
+	require.NoError(t, ackFns[2](ctx, nil))
+	require.NoError(t, ackFns[0](ctx, nil))
+	assert.Empty(t, *acked)
+
+	require.NoError(t, ackFns[1](ctx, nil))
+	assert.Equal(t, []error{nil}, *acked)
+
+	_, ackFns, acked = readAll(t, "lines", []byte("foo\nbar"))
+	errNope := errors.New("nope")
+	require.NoError(t, ackFns[0](ctx, errNope))
+	require.NoError(t, ackFns[0](ctx, nil))
+	assert.Empty(t, *acked)
+	require.NoError(t, ackFns[1](ctx, nil))
+	assert.Equal(t, []error{errNope}, *acked)
+}
@@ -0,0 +1,165 @@
+// Package cuckoo implements a cuckoo filter, which is a probabilistic set of
+// hashes that supports deletion and stores a 16 bit fingerprint for each hash.
+package cuckoo
+
+const (
+	bucketSize = 4
+	maxKicks   = 500
+)
+
+type bucket [bucketSize]uint16
+
+func (b *bucket) insert(fp uint16) bool {
+	for i, v := range b {
+		if v == 0 {
+			b[i] = fp
+			return true
+		}
+	}
+	return false
+}
+
+func (b *bucket) contains(fp uint16) bool {
+	for _, v := range b {
+		if v == fp {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *bucket) remove(fp uint16) bool {
+	for i, v := range b {
+		if v == fp {
+			b[i] = 0
+			return true
+		}
+	}
+	return false
+}
+
+// victim is a fingerprint that could not be placed after relocating others,
+// which is kept aside until space is made for it.
+type victim struct {
+	index uint32
+	fp    uint16
+	set   bool
+}
+
+// Filter is a cuckoo filter with a fixed capacity. The false positive rate of
+// lookups is roughly 0.012%. A Filter is not safe for concurrent use.
+type Filter struct {
+	buckets []bucket
+	mask    uint32
+	count   int
+	kick    int
+	victim  victim
+}
+
+// New creates a filter that can hold at least capacity hashes.
+func New(capacity int) *Filter {
+	// Aim for a load factor of at most 85%, beyond which inserts become likely
+	// to fail.
+	minBuckets := uint32(capacity*100/85/bucketSize) + 1
+	n := uint32(1)
+	for n < minBuckets {
+		n <<= 1
+	}
+	return &Filter{
+		buckets: make([]bucket, n),
+		mask:    n - 1,
+	}
+}
+
+func fingerprint(h uint64) uint16 {
+	fp := uint16(h >> 48)
+	if fp == 0 {
+		fp = 1
+	}
+	return fp
+}
+
+func (f *Filter) altIndex(i uint32, fp uint16) uint32 {
+	return (i ^ (uint32(fp) * 0x5bd1e995)) & f.mask
+}
+
+func (f *Filter) indexes(h uint64) (uint16, uint32, uint32) {
+	fp := fingerprint(h)
+	i1 := uint32(h) & f.mask
+	return fp, i1, f.altIndex(i1, fp)
+}
+
+// Count returns the number of hashes within the filter.
+func (f *Filter) Count() int {
+	return f.count
+}
+
+// Lookup returns true if the hash is possibly within the filter, and false if
+// it is definitely not.
+func (f *Filter) Lookup(h uint64) bool {
+	fp, i1, i2 := f.indexes(h)
+	if f.victim.set && f.victim.fp == fp && (f.victim.index == i1 || f.victim.index == i2) {
+		return true
+	}
+	return f.buckets[i1].contains(fp) || f.buckets[i2].contains(fp)
+}
+
+// Insert adds a hash to the filter, returning false if the filter is full.
+func (f *Filter) Insert(h uint64) bool {
+	if f.victim.set {
+		return false
+	}
+	fp, i1, i2 := f.indexes(h)
+	f.count++
+	if f.buckets[i1].insert(fp) || f.buckets[i2].insert(fp) {
+		return true
+	}
+
+	// Relocate existing fingerprints to their alternative buckets until one
+	// has space, cycling through the slots of each bucket that we evict from.
+	i := i2
+	for n := 0; n < maxKicks; n++ {
+		f.kick = (f.kick + 1) % bucketSize
+		fp, f.buckets[i][f.kick] = f.buckets[i][f.kick], fp
+		i = f.altIndex(i, fp)
+		if f.buckets[i].insert(fp) {
+			return true
+		}
+	}
+
+	f.victim = victim{index: i, fp: fp, set: true}
+	return true
+}
+
+// Delete removes a hash from the filter, returning false if it was not found.
+// Deleting a hash that was never inserted may remove a different hash with the
+// same fingerprint.
+func (f *Filter) Delete(h uint64) bool {
+	fp, i1, i2 := f.indexes(h)
+	switch {
+	case f.buckets[i1].remove(fp), f.buckets[i2].remove(fp):
+	case f.victim.set && f.victim.fp == fp && (f.victim.index == i1 || f.victim.index == i2):
+		f.victim = victim{}
+		f.count--
+		return true
+	default:
+		return false
+	}
+	f.count--
+
+	if f.victim.set {
+		v := f.victim
+		f.victim = victim{}
+		f.count--
+		f.insertFingerprint(v.fp, v.index)
+	}
+	return true
+}
+
+func (f *Filter) insertFingerprint(fp uint16, i uint32) {
+	f.count++
+	if f.buckets[i].insert(fp) || f.buckets[f.altIndex(i, fp)].insert(fp) {
+		return
+	}
+	f.victim = victim{index: i, fp: fp, set: true}
+}
@@ -0,0 +1,69 @@
+package cuckoo
+
+import (
+	"testing"
+
+	"github.com/OneOfOne/xxhash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hashOf(i int) uint64 {
+	return xxhash.Checksum64([]byte{byte(i), byte(i >> 8), byte(i >> 16), byte(i >> 24)})
+}
+
+func TestFilterInsertLookupDelete(t *testing.T) {
+	f := New(10000)
+
+	for i := 0; i < 10000; i++ {
+		require.True(t, f.Insert(hashOf(i)), i)
+	}
+	assert.Equal(t, 10000, f.Count())
+
+	for i := 0; i < 10000; i++ {
+		require.True(t, f.Lookup(hashOf(i)), i)
+	}
+
+	falsePositives := 0
+	for i := 10000; i < 110000; i++ {
+		if f.Lookup(hashOf(i)) {
+			falsePositives++
+		}
+	}
+	assert.True(t, falsePositives < 100, falsePositives)
+
+	for i := 0; i < 5000; i++ {
+		require.True(t, f.Delete(hashOf(i)), i)
+	}
+	assert.Equal(t, 5000, f.Count())
+
+	for i := 5000; i < 10000; i++ {
+		require.True(t, f.Lookup(hashOf(i)), i)
+	}
+	assert.False(t, f.Delete(hashOf(200000)))
+}
+
+func TestFilterFull(t *testing.T) {
+	f := New(8)
+
+	inserted := 0
+	for i := 0; i < 1000; i++ {
+		if !f.Insert(hashOf(i)) {
+			break
+		}
+		inserted++
+	}
+	require.True(t, inserted >= 8)
+	require.True(t, inserted < 1000)
+	assert.Equal(t, inserted, f.Count())
+
+	for i := 0; i < inserted; i++ {
+		require.True(t, f.Lookup(hashOf(i)), i)
+	}
+
+	for i := 0; i < inserted; i++ {
+		require.True(t, f.Delete(hashOf(i)), i)
+	}
+	assert.Equal(t, 0, f.Count())
+	assert.True(t, f.Insert(hashOf(5000)))
+}
@@ -0,0 +1,39 @@
+package grpcconf
+
+import (
+	"fmt"
+)
+
+// Codec is a gRPC codec that passes frames through as they are, which allows
+// components to call and serve methods without generated code. Frames are
+// marshalled and unmarshalled as a *[]byte.
+type Codec struct{}
+
+// Marshal returns the bytes of a frame.
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("expected frame of type *[]byte, received %T", v)
+	}
+	return *b, nil
+}
+
+// Unmarshal copies the bytes of a frame.
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("expected frame of type *[]byte, received %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// Name returns the name of the codec.
+func (Codec) Name() string {
+	return "proto"
+}
+
+// String returns the name of the codec.
+func (c Codec) String() string {
+	return c.Name()
+}
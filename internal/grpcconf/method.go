@@ -0,0 +1,61 @@
+package grpcconf
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+)
+
+// ParseMethod parses the name of a method in the form /package.Service/Method,
+// where the leading slash is optional, and returns the service name, method
+// name and full method name used within requests.
+func ParseMethod(method string) (service, name, fullMethod string, err error) {
+	trimmed := strings.TrimPrefix(method, "/")
+	i := strings.LastIndex(trimmed, "/")
+	if i <= 0 || i == len(trimmed)-1 {
+		return "", "", "", fmt.Errorf("method '%v' must be in the form /package.Service/Method", method)
+	}
+	service, name = trimmed[:i], trimmed[i+1:]
+	return service, name, "/" + trimmed, nil
+}
+
+// LoadMethod returns the descriptor of a method from a file containing a
+// serialised FileDescriptorSet, which can be generated with protoc using the
+// flags --include_imports and --descriptor_set_out.
+func LoadMethod(descriptorSetPath, method string) (*desc.MethodDescriptor, error) {
+	service, name, _, err := ParseMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	if len(descriptorSetPath) == 0 {
+		return nil, errors.New("a descriptor set must be specified")
+	}
+
+	setBytes, err := ioutil.ReadFile(descriptorSetPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %v", err)
+	}
+	var set dpb.FileDescriptorSet
+	if err = proto.Unmarshal(setBytes, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set: %v", err)
+	}
+	files, err := desc.CreateFileDescriptorsFromSet(&set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set: %v", err)
+	}
+
+	for _, f := range files {
+		if s := f.FindService(service); s != nil {
+			if m := s.FindMethodByName(name); m != nil {
+				return m, nil
+			}
+			return nil, fmt.Errorf("method '%v' not found within service '%v'", name, service)
+		}
+	}
+	return nil, fmt.Errorf("service '%v' not found within descriptor set", service)
+}
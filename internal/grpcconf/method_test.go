@@ -0,0 +1,75 @@
+package grpcconf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMethod(t *testing.T) {
+	service, name, fullMethod, err := ParseMethod("/foo.v1.Bar/Baz")
+	require.NoError(t, err)
+	assert.Equal(t, "foo.v1.Bar", service)
+	assert.Equal(t, "Baz", name)
+	assert.Equal(t, "/foo.v1.Bar/Baz", fullMethod)
+
+	_, _, fullMethod, err = ParseMethod("foo.v1.Bar/Baz")
+	require.NoError(t, err)
+	assert.Equal(t, "/foo.v1.Bar/Baz", fullMethod)
+
+	for _, method := range []string{"", "/", "foo", "/foo/", "//foo"} {
+		_, _, _, err = ParseMethod(method)
+		assert.Error(t, err, method)
+	}
+}
+
+func TestLoadMethod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_grpcconf_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{
+			"foo.proto": `
+syntax = "proto3";
+package foo.v1;
+import "google/protobuf/empty.proto";
+message Event { string id = 1; }
+service Bar {
+  rpc Baz(Event) returns (google.protobuf.Empty);
+  rpc BazStream(stream Event) returns (google.protobuf.Empty);
+}`,
+		}),
+	}
+	files, err := parser.ParseFiles("foo.proto")
+	require.NoError(t, err)
+
+	setBytes, err := proto.Marshal(desc.ToFileDescriptorSet(files...))
+	require.NoError(t, err)
+	setPath := filepath.Join(dir, "foo.protoset")
+	require.NoError(t, ioutil.WriteFile(setPath, setBytes, 0644))
+
+	m, err := LoadMethod(setPath, "/foo.v1.Bar/BazStream")
+	require.NoError(t, err)
+	assert.True(t, m.IsClientStreaming())
+	assert.Equal(t, "foo.v1.Event", m.GetInputType().GetFullyQualifiedName())
+
+	_, err = LoadMethod(setPath, "/foo.v1.Bar/Nope")
+	assert.Error(t, err)
+
+	_, err = LoadMethod(setPath, "/foo.v1.Nope/Baz")
+	assert.Error(t, err)
+
+	_, err = LoadMethod("", "/foo.v1.Bar/Baz")
+	assert.Error(t, err)
+
+	_, err = LoadMethod(filepath.Join(dir, "nope.protoset"), "/foo.v1.Bar/Baz")
+	assert.Error(t, err)
+}
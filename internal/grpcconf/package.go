@@ -0,0 +1,3 @@
+// Package grpcconf provides codecs and method descriptor helpers that are
+// shared by the gRPC components.
+package grpcconf
@@ -0,0 +1,144 @@
+package mqttconf
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// ErrNoBrokers is returned when a dial is attempted without any broker URLs.
+var ErrNoBrokers = errors.New("no broker URLs were provided")
+
+// ParseURLs expands a list of broker URLs, where any item containing commas is
+// split into multiple URLs, and validates each of them.
+func ParseURLs(rawURLs []string) ([]*url.URL, error) {
+	var urls []*url.URL
+	for _, u := range rawURLs {
+		for _, splitURL := range strings.Split(u, ",") {
+			if len(splitURL) == 0 {
+				continue
+			}
+			parsed, err := url.Parse(splitURL)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse broker URL '%v': %w", splitURL, err)
+			}
+			switch parsed.Scheme {
+			case "tcp", "mqtt", "ssl", "tls", "tcps", "mqtts", "ws", "wss":
+			default:
+				return nil, fmt.Errorf("broker URL scheme '%v' is not supported", parsed.Scheme)
+			}
+			urls = append(urls, parsed)
+		}
+	}
+	if len(urls) == 0 {
+		return nil, ErrNoBrokers
+	}
+	return urls, nil
+}
+
+func isSecure(u *url.URL) bool {
+	switch u.Scheme {
+	case "ssl", "tls", "tcps", "mqtts", "wss":
+		return true
+	}
+	return false
+}
+
+func hostWithPort(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	if isSecure(u) {
+		return net.JoinHostPort(u.Hostname(), "8883")
+	}
+	return net.JoinHostPort(u.Hostname(), "1883")
+}
+
+// Dial attempts to open a network connection to each broker URL in turn and
+// returns the first one that succeeds. Brokers with a secure URL scheme
+// (ssl://, tls://, tcps://, mqtts:// or wss://) are connected to over TLS using
+// the provided config, which may be nil in order to use system defaults.
+// Brokers with a ws:// or wss:// URL scheme are connected to over a WebSocket,
+// where the provided headers are added to the upgrade request.
+func Dial(ctx context.Context, urls []*url.URL, tlsConf *tls.Config, headers http.Header) (net.Conn, error) {
+	if len(urls) == 0 {
+		return nil, ErrNoBrokers
+	}
+
+	var err error
+	for _, u := range urls {
+		var conn net.Conn
+		if conn, err = dialURL(ctx, u, tlsConf, headers); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+func dialURL(ctx context.Context, u *url.URL, tlsConf *tls.Config, headers http.Header) (net.Conn, error) {
+	if u.Scheme == "ws" || u.Scheme == "wss" {
+		return dialWebsocket(ctx, u, tlsConf, headers)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", hostWithPort(u))
+	if err != nil || !isSecure(u) {
+		return conn, err
+	}
+
+	if tlsConf == nil {
+		tlsConf = &tls.Config{}
+	} else {
+		tlsConf = tlsConf.Clone()
+	}
+	if tlsConf.ServerName == "" {
+		tlsConf.ServerName = u.Hostname()
+	}
+
+	tlsConn := tls.Client(conn, tlsConf)
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = tlsConn.SetDeadline(deadline)
+	}
+	if err = tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = tlsConn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+func dialWebsocket(ctx context.Context, u *url.URL, tlsConf *tls.Config, headers http.Header) (net.Conn, error) {
+	origin := "http://" + u.Host
+	if u.Scheme == "wss" {
+		origin = "https://" + u.Host
+	}
+
+	config, err := websocket.NewConfig(u.String(), origin)
+	if err != nil {
+		return nil, err
+	}
+	config.Protocol = []string{"mqtt"}
+	config.TlsConfig = tlsConf
+	config.Header = headers
+
+	dialer := &net.Dialer{}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+	config.Dialer = dialer
+
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	conn.PayloadType = websocket.BinaryFrame
+	return conn, nil
+}
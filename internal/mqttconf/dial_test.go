@@ -0,0 +1,83 @@
+package mqttconf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func TestParseURLs(t *testing.T) {
+	urls, err := ParseURLs([]string{"tcp://foo:1883,tcp://bar:1883", "", "mqtt://baz"})
+	require.NoError(t, err)
+
+	var hosts []string
+	for _, u := range urls {
+		hosts = append(hosts, u.Host)
+	}
+	assert.Equal(t, []string{"foo:1883", "bar:1883", "baz"}, hosts)
+
+	urls, err = ParseURLs([]string{"ssl://foo", "mqtts://bar:9000"})
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	assert.Equal(t, "foo:8883", hostWithPort(urls[0]))
+	assert.Equal(t, "bar:9000", hostWithPort(urls[1]))
+
+	urls, err = ParseURLs([]string{"ws://foo/mqtt", "wss://bar/mqtt"})
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	assert.False(t, isSecure(urls[0]))
+	assert.True(t, isSecure(urls[1]))
+
+	_, err = ParseURLs([]string{"http://foo"})
+	assert.Error(t, err)
+
+	_, err = ParseURLs(nil)
+	assert.Equal(t, ErrNoBrokers, err)
+}
+
+func TestDialWebsocket(t *testing.T) {
+	headerChan := make(chan http.Header, 1)
+	server := httptest.NewServer(websocket.Server{
+		Handshake: func(conf *websocket.Config, req *http.Request) error {
+			headerChan <- req.Header
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			ws.PayloadType = websocket.BinaryFrame
+			buf := make([]byte, 5)
+			n, _ := ws.Read(buf)
+			_, _ = ws.Write(buf[:n])
+		},
+	})
+	defer server.Close()
+
+	u, err := url.Parse(strings.Replace(server.URL, "http://", "ws://", 1) + "/mqtt")
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	conn, err := Dial(ctx, []*url.URL{u}, nil, http.Header{"X-Foo": []string{"bar"}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	headers := <-headerChan
+	assert.Equal(t, "bar", headers.Get("X-Foo"))
+	assert.Equal(t, "mqtt", headers.Get("Sec-Websocket-Protocol"))
+
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 5)
+	_, err = conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+}
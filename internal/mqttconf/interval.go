@@ -0,0 +1,27 @@
+package mqttconf
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// IntervalSeconds parses a duration string into the whole number of seconds
+// used by MQTT v5 interval properties. An empty string is treated as zero.
+func IntervalSeconds(durationStr string) (uint32, error) {
+	if durationStr == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(durationStr)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("interval '%v' must not be negative", durationStr)
+	}
+	secs := d / time.Second
+	if secs > math.MaxUint32 {
+		return 0, fmt.Errorf("interval '%v' exceeds the maximum of %v seconds", durationStr, uint32(math.MaxUint32))
+	}
+	return uint32(secs), nil
+}
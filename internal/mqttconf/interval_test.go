@@ -0,0 +1,37 @@
+package mqttconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntervalSeconds(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		output uint32
+		errs   bool
+	}{
+		"empty":     {input: "", output: 0},
+		"zero":      {input: "0s", output: 0},
+		"minutes":   {input: "2m", output: 120},
+		"truncated": {input: "1500ms", output: 1},
+		"negative":  {input: "-1s", errs: true},
+		"invalid":   {input: "nope", errs: true},
+		"too large": {input: "2000000h", errs: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			res, err := IntervalSeconds(test.input)
+			if test.errs {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.output, res)
+		})
+	}
+}
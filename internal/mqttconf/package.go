@@ -0,0 +1,3 @@
+// Package mqttconf provides configuration fields and connection utilities that
+// are shared by the MQTT components.
+package mqttconf
@@ -0,0 +1,52 @@
+package mqttconf
+
+import (
+	"errors"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+)
+
+// Will holds MQTT last will and testament configuration.
+type Will struct {
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+	QoS      uint8  `json:"qos" yaml:"qos"`
+	Retained bool   `json:"retained" yaml:"retained"`
+	Topic    string `json:"topic" yaml:"topic"`
+	Payload  string `json:"payload" yaml:"payload"`
+}
+
+// EmptyWill returns a Will configuration with default values.
+func EmptyWill() Will {
+	return Will{
+		Enabled:  false,
+		QoS:      0,
+		Retained: false,
+		Topic:    "",
+		Payload:  "",
+	}
+}
+
+// Validate returns an error if an enabled Will configuration is invalid.
+func (w Will) Validate() error {
+	if !w.Enabled {
+		return nil
+	}
+	if w.QoS > 2 {
+		return errors.New("invalid will qos value, must be 0, 1 or 2")
+	}
+	if len(w.Topic) == 0 {
+		return errors.New("a will topic must be specified when a will is enabled")
+	}
+	return nil
+}
+
+// WillFieldSpec returns a spec for a common MQTT will field.
+func WillFieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced("will", "Set a last will message that the broker publishes on behalf of this client when the connection is lost unexpectedly, allowing other consumers to detect when Benthos disconnects.").WithChildren(
+		docs.FieldCommon("enabled", "Whether to enable the last will message."),
+		docs.FieldCommon("qos", "The QoS value to publish the will message with.").HasOptions("0", "1", "2"),
+		docs.FieldCommon("retained", "Whether the will message should be retained on the topic."),
+		docs.FieldCommon("topic", "The topic to publish the will message to."),
+		docs.FieldCommon("payload", "The payload of the will message."),
+	)
+}
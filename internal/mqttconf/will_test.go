@@ -0,0 +1,44 @@
+package mqttconf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWillValidate(t *testing.T) {
+	tests := map[string]struct {
+		will   Will
+		errStr string
+	}{
+		"disabled": {
+			will: EmptyWill(),
+		},
+		"disabled ignores invalid fields": {
+			will: Will{QoS: 5},
+		},
+		"enabled": {
+			will: Will{Enabled: true, QoS: 1, Topic: "foo", Payload: "bar"},
+		},
+		"enabled without topic": {
+			will:   Will{Enabled: true, QoS: 1},
+			errStr: "a will topic must be specified when a will is enabled",
+		},
+		"enabled with bad qos": {
+			will:   Will{Enabled: true, QoS: 3, Topic: "foo"},
+			errStr: "invalid will qos value, must be 0, 1 or 2",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			err := test.will.Validate()
+			if test.errStr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.errStr)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Package parquet provides the means of encoding JSON documents as the rows of
+// a Parquet file, and of decoding the rows of a Parquet file as JSON documents.
+package parquet
+
+//------------------------------------------------------------------------------
+
+// FieldConfig describes a field of a Parquet schema, which is either a column
+// of a primitive type or a group of child fields.
+type FieldConfig struct {
+	Name     string        `json:"name" yaml:"name"`
+	Type     string        `json:"type" yaml:"type"`
+	Required bool          `json:"required" yaml:"required"`
+	Repeated bool          `json:"repeated" yaml:"repeated"`
+	Fields   []FieldConfig `json:"fields" yaml:"fields"`
+}
+
+// FieldTypes are the primitive types that a field can have.
+var FieldTypes = []string{
+	"BOOLEAN", "INT32", "INT64", "FLOAT", "DOUBLE", "BYTE_ARRAY", "UTF8",
+}
+
+// CompressionTypes are the compression codecs that an encoder can apply to the
+// pages of a Parquet file.
+var CompressionTypes = []string{"uncompressed", "snappy", "gzip", "zstd"}
+
+//------------------------------------------------------------------------------
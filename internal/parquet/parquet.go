@@ -0,0 +1,245 @@
+// +build !wasm
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+
+	pq "github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/schema"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+//------------------------------------------------------------------------------
+
+// Encoder writes lists of JSON documents as the rows of Parquet files.
+type Encoder struct {
+	schema      string
+	compression pq.CompressionCodec
+}
+
+// NewEncoder creates an encoder with a schema of fields and a compression
+// codec. If no fields are provided the schema of each file is inferred from its
+// documents.
+func NewEncoder(fields []FieldConfig, compression string) (*Encoder, error) {
+	e := &Encoder{}
+	switch compression {
+	case "uncompressed":
+		e.compression = pq.CompressionCodec_UNCOMPRESSED
+	case "snappy":
+		e.compression = pq.CompressionCodec_SNAPPY
+	case "gzip":
+		e.compression = pq.CompressionCodec_GZIP
+	case "zstd":
+		e.compression = pq.CompressionCodec_ZSTD
+	default:
+		return nil, fmt.Errorf("compression type not recognised: %v", compression)
+	}
+	if len(fields) > 0 {
+		var err error
+		if e.schema, err = schemaJSON(fields); err != nil {
+			return nil, fmt.Errorf("failed to parse schema: %w", err)
+		}
+	}
+	return e, nil
+}
+
+// Encode returns a Parquet file with a row for each JSON document.
+func (e *Encoder) Encode(docs [][]byte) ([]byte, error) {
+	schemaStr := e.schema
+	if schemaStr == "" {
+		values := make([]interface{}, len(docs))
+		for i, doc := range docs {
+			dec := json.NewDecoder(bytes.NewReader(doc))
+			dec.UseNumber()
+			if err := dec.Decode(&values[i]); err != nil {
+				return nil, fmt.Errorf("failed to parse document %v: %w", i, err)
+			}
+		}
+		fields, err := InferFields(values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to infer schema: %w", err)
+		}
+		if schemaStr, err = schemaJSON(fields); err != nil {
+			return nil, fmt.Errorf("failed to infer schema: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	pw, err := writer.NewJSONWriterFromWriter(schemaStr, &buf, 1)
+	if err != nil {
+		return nil, err
+	}
+	pw.CompressionType = e.compression
+	for i, doc := range docs {
+		if !json.Valid(doc) {
+			return nil, fmt.Errorf("document %v is not valid JSON", i)
+		}
+		if err = pw.Write(string(doc)); err != nil {
+			return nil, fmt.Errorf("failed to write document %v: %w", i, err)
+		}
+	}
+	if err = pw.WriteStop(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// Reader reads the rows of a Parquet file as JSON documents, one row group at a
+// time.
+type Reader struct {
+	pr       *reader.ParquetReader
+	sh       *schema.SchemaHandler
+	rootPath string
+	rowGroup int
+}
+
+// NewReader creates a reader of the rows of a Parquet file.
+func NewReader(b []byte) (r *Reader, err error) {
+	// The parquet library panics on some malformed files.
+	defer func() {
+		if rec := recover(); rec != nil {
+			r, err = nil, fmt.Errorf("failed to read parquet file: %v", rec)
+		}
+	}()
+
+	pr, err := reader.NewParquetReader(&bytesFile{Reader: bytes.NewReader(b), b: b}, nil, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read parquet file: %w", err)
+	}
+	return &Reader{
+		pr:       pr,
+		sh:       pr.SchemaHandler,
+		rootPath: pr.SchemaHandler.GetRootInName(),
+	}, nil
+}
+
+// NextRowGroup returns the rows of the next row group of the file as JSON
+// documents, or io.EOF once all row groups have been read.
+func (r *Reader) NextRowGroup() (docs [][]byte, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			docs, err = nil, fmt.Errorf("failed to read row group: %v", rec)
+		}
+	}()
+
+	groups := r.pr.Footer.GetRowGroups()
+	for r.rowGroup < len(groups) {
+		numRows := int(groups[r.rowGroup].GetNumRows())
+		r.rowGroup++
+		if numRows == 0 {
+			continue
+		}
+
+		rows, err := r.pr.ReadByNumber(numRows)
+		if err != nil {
+			return nil, err
+		}
+		docs = make([][]byte, len(rows))
+		for i, row := range rows {
+			if docs[i], err = json.Marshal(r.jsonValue(reflect.ValueOf(row), r.rootPath)); err != nil {
+				return nil, err
+			}
+		}
+		return docs, nil
+	}
+	return nil, io.EOF
+}
+
+// Close the reader.
+func (r *Reader) Close() error {
+	r.pr.ReadStop()
+	return r.pr.PFile.Close()
+}
+
+// bytesFile is a read only source.ParquetFile of a file that is held in
+// memory.
+type bytesFile struct {
+	*bytes.Reader
+	b []byte
+}
+
+func (f *bytesFile) Open(string) (source.ParquetFile, error) {
+	return &bytesFile{Reader: bytes.NewReader(f.b), b: f.b}, nil
+}
+
+func (f *bytesFile) Create(string) (source.ParquetFile, error) {
+	return nil, errors.New("parquet file is read only")
+}
+
+func (f *bytesFile) Write([]byte) (int, error) {
+	return 0, errors.New("parquet file is read only")
+}
+
+func (f *bytesFile) Close() error {
+	return nil
+}
+
+// jsonValue converts a value read from the file into a JSON value, where path
+// is the internal schema path of the value, which is used in order to restore
+// the original names of fields.
+func (r *Reader) jsonValue(v reflect.Value, path string) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return r.jsonValue(v.Elem(), path)
+	case reflect.Struct:
+		obj := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			inName := v.Type().Field(i).Name
+			childPath := path + "." + inName
+			name := inName
+			if idx, ok := r.sh.MapIndex[childPath]; ok {
+				name = r.sh.Infos[idx].ExName
+			}
+			obj[name] = r.jsonValue(v.Field(i), childPath)
+		}
+		return obj
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		elemPath := path
+		if idx, ok := r.sh.MapIndex[path]; ok && r.sh.SchemaElements[idx].GetConvertedType() == pq.ConvertedType_LIST {
+			elemPath = path + ".List.Element"
+		}
+		arr := make([]interface{}, v.Len())
+		for i := range arr {
+			arr[i] = r.jsonValue(v.Index(i), elemPath)
+		}
+		return arr
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		obj := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			obj[fmt.Sprintf("%v", iter.Key().Interface())] = r.jsonValue(iter.Value(), path+".Key_value.Value")
+		}
+		return obj
+	case reflect.Float32, reflect.Float64:
+		// JSON has no representation of NaN or infinite numbers.
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil
+		}
+		return v.Interface()
+	case reflect.Invalid:
+		return nil
+	}
+	return v.Interface()
+}
+
+//------------------------------------------------------------------------------
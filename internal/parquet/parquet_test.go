@@ -0,0 +1,118 @@
+// +build !wasm
+
+package parquet
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAllRows(t *testing.T, b []byte) []string {
+	t.Helper()
+
+	r, err := NewReader(b)
+	require.NoError(t, err)
+	defer r.Close()
+
+	var rows []string
+	for {
+		docs, err := r.NextRowGroup()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		for _, doc := range docs {
+			rows = append(rows, string(doc))
+		}
+	}
+	return rows
+}
+
+func TestEncodeInferredSchema(t *testing.T) {
+	e, err := NewEncoder(nil, "snappy")
+	require.NoError(t, err)
+
+	b, err := e.Encode([][]byte{
+		[]byte(`{"id":1,"name":"foo","score":1.5,"tags":["a","b"],"user":{"first-name":"bar","active":true},"empty":{}}`),
+		[]byte(`{"id":2,"score":3,"tags":[],"user":{"first-name":"baz"}}`),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"id":1,"name":"foo","score":1.5,"tags":["a","b"],"user":{"active":true,"first-name":"bar"}}`,
+		`{"id":2,"name":null,"score":3,"tags":[],"user":{"active":null,"first-name":"baz"}}`,
+	}, readAllRows(t, b))
+}
+
+func TestEncodeDeclaredSchema(t *testing.T) {
+	fields := []FieldConfig{
+		{Name: "id", Type: "INT64", Required: true},
+		{Name: "count", Type: "INT32"},
+		{Name: "ratio", Type: "FLOAT"},
+		{Name: "items", Repeated: true, Fields: []FieldConfig{
+			{Name: "sku", Type: "UTF8"},
+			{Name: "qty", Type: "INT64"},
+		}},
+	}
+	for _, compression := range CompressionTypes {
+		compression := compression
+		t.Run(compression, func(t *testing.T) {
+			e, err := NewEncoder(fields, compression)
+			require.NoError(t, err)
+
+			b, err := e.Encode([][]byte{
+				[]byte(`{"id":1,"count":5,"ratio":0.5,"items":[{"sku":"foo","qty":2},{"sku":"bar"}],"ignored":"nope"}`),
+				[]byte(`{"id":2}`),
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{
+				`{"count":5,"id":1,"items":[{"qty":2,"sku":"foo"},{"qty":null,"sku":"bar"}],"ratio":0.5}`,
+				`{"count":null,"id":2,"items":null,"ratio":null}`,
+			}, readAllRows(t, b))
+		})
+	}
+}
+
+func TestSchemaErrors(t *testing.T) {
+	tests := map[string][]FieldConfig{
+		"no type":        {{Name: "foo"}},
+		"unknown type":   {{Name: "foo", Type: "NOPE"}},
+		"empty name":     {{Type: "UTF8"}},
+		"invalid name":   {{Name: "foo,bar", Type: "UTF8"}},
+		"group type":     {{Name: "foo", Type: "UTF8", Fields: []FieldConfig{{Name: "bar", Type: "UTF8"}}}},
+		"conflict names": {{Name: "foo", Type: "UTF8"}, {Name: "Foo", Type: "UTF8"}},
+	}
+	for name, fields := range tests {
+		_, err := NewEncoder(fields, "snappy")
+		assert.Error(t, err, name)
+	}
+
+	_, err := NewEncoder(nil, "nope")
+	assert.Error(t, err)
+}
+
+func TestInferFieldErrors(t *testing.T) {
+	e, err := NewEncoder(nil, "snappy")
+	require.NoError(t, err)
+
+	for _, docs := range [][][]byte{
+		{[]byte(`not json`)},
+		{[]byte(`["not an object"]`)},
+		{[]byte(`{"a":1}`), []byte(`{"a":"foo"}`)},
+		{[]byte(`{"a":{"b":1}}`), []byte(`{"a":2}`)},
+		{[]byte(`{"a":[[1]]}`)},
+		{},
+	} {
+		_, err := e.Encode(docs)
+		assert.Error(t, err)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	_, err := NewReader([]byte("not a parquet file"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+// +build wasm
+
+package parquet
+
+import (
+	"errors"
+)
+
+//------------------------------------------------------------------------------
+
+var errNoWASM = errors.New("parquet files are not supported in WASM builds")
+
+// Encoder is not supported in WASM builds.
+type Encoder struct{}
+
+// NewEncoder returns an error as it is not supported in WASM builds.
+func NewEncoder(fields []FieldConfig, compression string) (*Encoder, error) {
+	return nil, errNoWASM
+}
+
+// Encode returns an error as it is not supported in WASM builds.
+func (e *Encoder) Encode(docs [][]byte) ([]byte, error) {
+	return nil, errNoWASM
+}
+
+// Reader is not supported in WASM builds.
+type Reader struct{}
+
+// NewReader returns an error as it is not supported in WASM builds.
+func NewReader(b []byte) (*Reader, error) {
+	return nil, errNoWASM
+}
+
+// NextRowGroup returns an error as it is not supported in WASM builds.
+func (r *Reader) NextRowGroup() ([][]byte, error) {
+	return nil, errNoWASM
+}
+
+// Close does nothing as it is not supported in WASM builds.
+func (r *Reader) Close() error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
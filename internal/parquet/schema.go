@@ -0,0 +1,243 @@
+// +build !wasm
+
+package parquet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xitongsys/parquet-go/common"
+)
+
+//------------------------------------------------------------------------------
+
+// schemaItem is the JSON representation of a schema understood by
+// parquet-go.
+type schemaItem struct {
+	Tag    string
+	Fields []*schemaItem `json:",omitempty"`
+}
+
+// schemaJSON returns the parquet-go JSON schema of a list of root fields.
+func schemaJSON(fields []FieldConfig) (string, error) {
+	root := &schemaItem{Tag: "name=parquet_go_root, repetitiontype=REQUIRED"}
+	var err error
+	if root.Fields, err = fieldItems(fields); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(root)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func fieldItems(fields []FieldConfig) ([]*schemaItem, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("at least one field must be specified")
+	}
+	items := make([]*schemaItem, 0, len(fields))
+	seen := map[string]string{}
+	for _, f := range fields {
+		if f.Name == "" || strings.ContainsAny(f.Name, ",=") {
+			return nil, fmt.Errorf("field name '%v' must be non-empty and must not contain ',' or '='", f.Name)
+		}
+		inName := common.StringToVariableName(f.Name)
+		if other, exists := seen[inName]; exists {
+			return nil, fmt.Errorf("field names '%v' and '%v' conflict", other, f.Name)
+		}
+		seen[inName] = f.Name
+
+		item, err := fieldItem(f, "name="+f.Name)
+		if err != nil {
+			return nil, fmt.Errorf("field '%v': %w", f.Name, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func fieldItem(f FieldConfig, nameTag string) (*schemaItem, error) {
+	repetition := "OPTIONAL"
+	if f.Required {
+		repetition = "REQUIRED"
+	}
+	if f.Repeated {
+		element := f
+		element.Required, element.Repeated = false, false
+		elementItem, err := fieldItem(element, "name=element, inname=Element")
+		if err != nil {
+			return nil, err
+		}
+		return &schemaItem{
+			Tag:    nameTag + ", type=LIST, repetitiontype=" + repetition,
+			Fields: []*schemaItem{elementItem},
+		}, nil
+	}
+
+	if len(f.Fields) > 0 {
+		if f.Type != "" {
+			return nil, errors.New("a type must not be specified for a field with child fields")
+		}
+		children, err := fieldItems(f.Fields)
+		if err != nil {
+			return nil, err
+		}
+		return &schemaItem{
+			Tag:    nameTag + ", repetitiontype=" + repetition,
+			Fields: children,
+		}, nil
+	}
+
+	switch f.Type {
+	case "BOOLEAN", "INT32", "INT64", "FLOAT", "DOUBLE", "BYTE_ARRAY", "UTF8":
+	case "":
+		return nil, errors.New("a type or child fields must be specified")
+	default:
+		return nil, fmt.Errorf("type not recognised: %v", f.Type)
+	}
+	return &schemaItem{
+		Tag: nameTag + ", type=" + f.Type + ", repetitiontype=" + repetition,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// InferFields returns the fields of a schema that covers a list of JSON
+// objects. Integers are inferred as INT64 columns, other numbers as DOUBLE
+// columns, strings as UTF8 columns, objects as groups and arrays as repeated
+// fields. Fields that are only ever null are inferred as UTF8 columns, objects
+// that are always empty are omitted, and all inferred fields are optional.
+func InferFields(docs []interface{}) ([]FieldConfig, error) {
+	root := &inferredField{}
+	for i, doc := range docs {
+		if _, ok := doc.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("document %v is not a JSON object", i)
+		}
+		if err := root.add(doc); err != nil {
+			return nil, fmt.Errorf("document %v: %w", i, err)
+		}
+	}
+	return root.fieldConfig("").Fields, nil
+}
+
+type inferredField struct {
+	Type     string
+	Repeated bool
+	Fields   map[string]*inferredField
+}
+
+func (f *inferredField) add(v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		f.Repeated = true
+		for _, e := range t {
+			if _, nested := e.([]interface{}); nested {
+				return errors.New("nested arrays are not supported")
+			}
+			if err := f.add(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		if f.Type != "" {
+			return fmt.Errorf("an object conflicts with type %v", f.Type)
+		}
+		if f.Fields == nil {
+			f.Fields = map[string]*inferredField{}
+		}
+		for k, cv := range t {
+			child, exists := f.Fields[k]
+			if !exists {
+				child = &inferredField{}
+				f.Fields[k] = child
+			}
+			if err := child.add(cv); err != nil {
+				return fmt.Errorf("field '%v': %w", k, err)
+			}
+		}
+		return nil
+	}
+
+	var vType string
+	switch t := v.(type) {
+	case bool:
+		vType = "BOOLEAN"
+	case json.Number:
+		vType = "DOUBLE"
+		if _, err := t.Int64(); err == nil {
+			vType = "INT64"
+		}
+	case float64:
+		vType = "DOUBLE"
+		if t == float64(int64(t)) {
+			vType = "INT64"
+		}
+	case string:
+		vType = "UTF8"
+	default:
+		return fmt.Errorf("value of type %T is not supported", v)
+	}
+
+	if f.Fields != nil {
+		return fmt.Errorf("type %v conflicts with an object", vType)
+	}
+	switch {
+	case f.Type == "" || f.Type == vType:
+		f.Type = vType
+	case (f.Type == "INT64" && vType == "DOUBLE") || (f.Type == "DOUBLE" && vType == "INT64"):
+		f.Type = "DOUBLE"
+	default:
+		return fmt.Errorf("type %v conflicts with type %v", vType, f.Type)
+	}
+	return nil
+}
+
+// empty returns true for objects that only contain empty objects.
+func (f *inferredField) empty() bool {
+	if f.Fields == nil {
+		return false
+	}
+	for _, child := range f.Fields {
+		if !child.empty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *inferredField) fieldConfig(name string) FieldConfig {
+	conf := FieldConfig{
+		Name:     name,
+		Type:     f.Type,
+		Repeated: f.Repeated,
+	}
+	if f.Fields == nil {
+		if conf.Type == "" {
+			conf.Type = "UTF8"
+		}
+		return conf
+	}
+	names := make([]string, 0, len(f.Fields))
+	for k, child := range f.Fields {
+		// Parquet does not support empty groups, and so objects that are
+		// always empty are omitted.
+		if child.empty() {
+			continue
+		}
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		conf.Fields = append(conf.Fields, f.Fields[k].fieldConfig(k))
+	}
+	return conf
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,26 @@
+// +build !wasm
+
+package pulsarconf
+
+import (
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+// ClientOptions returns options for creating a Pulsar client.
+func ClientOptions(url string, auth Auth, tlsConf TLS, logger log.Modular) pulsar.ClientOptions {
+	opts := pulsar.ClientOptions{
+		URL:                        url,
+		ConnectionTimeout:          time.Second * 5,
+		TLSTrustCertsFilePath:      tlsConf.RootCAsFile,
+		TLSAllowInsecureConnection: tlsConf.SkipCertVerify,
+		TLSValidateHostname:        tlsConf.ValidateHostname,
+		Logger:                     NewLogger(logger),
+	}
+	if auth.Token != "" {
+		opts.Authentication = pulsar.NewAuthenticationToken(auth.Token)
+	}
+	return opts
+}
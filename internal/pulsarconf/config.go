@@ -0,0 +1,50 @@
+package pulsarconf
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+)
+
+// Auth holds Pulsar authentication configuration.
+type Auth struct {
+	Token string `json:"token" yaml:"token"`
+}
+
+// NewAuth returns an Auth configuration with default values.
+func NewAuth() Auth {
+	return Auth{
+		Token: "",
+	}
+}
+
+// AuthFieldSpec returns a spec for a common Pulsar auth field.
+func AuthFieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced("auth", "Optional configuration of Pulsar authentication methods.").WithChildren(
+		docs.FieldCommon("token", "A JWT token used to authenticate with the cluster. It is recommended that you use environment variables to populate this field.", "${PULSAR_TOKEN}"),
+	)
+}
+
+// TLS holds Pulsar TLS configuration. TLS is enabled by using a pulsar+ssl://
+// URL, these fields customise how the connection is verified.
+type TLS struct {
+	RootCAsFile      string `json:"root_cas_file" yaml:"root_cas_file"`
+	SkipCertVerify   bool   `json:"skip_cert_verify" yaml:"skip_cert_verify"`
+	ValidateHostname bool   `json:"validate_hostname" yaml:"validate_hostname"`
+}
+
+// NewTLS returns a TLS configuration with default values.
+func NewTLS() TLS {
+	return TLS{
+		RootCAsFile:      "",
+		SkipCertVerify:   false,
+		ValidateHostname: false,
+	}
+}
+
+// TLSFieldSpec returns a spec for a common Pulsar TLS field.
+func TLSFieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced("tls", "Custom TLS settings for connections made with a `pulsar+ssl://` URL.").WithChildren(
+		docs.FieldCommon("root_cas_file", "The path of a root certificate authority file to use."),
+		docs.FieldCommon("skip_cert_verify", "Whether to skip server side certificate verification."),
+		docs.FieldAdvanced("validate_hostname", "Whether to verify that the hostname of the broker matches its certificate."),
+	)
+}
@@ -0,0 +1,55 @@
+// +build !wasm
+
+package pulsarconf
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	plog "github.com/apache/pulsar-client-go/pulsar/log"
+)
+
+// logger adapts a Benthos logger to the logging interface of the Pulsar
+// client, implementing both plog.Logger and plog.Entry.
+type logger struct {
+	l log.Modular
+}
+
+// NewLogger returns a Pulsar client logger that writes to a Benthos logger.
+func NewLogger(l log.Modular) plog.Logger {
+	return &logger{l: l}
+}
+
+func (p *logger) with(fields plog.Fields) *logger {
+	strFields := make(map[string]string, len(fields))
+	for k, v := range fields {
+		strFields[k] = fmt.Sprintf("%v", v)
+	}
+	return &logger{l: p.l.WithFields(strFields)}
+}
+
+func (p *logger) SubLogger(fields plog.Fields) plog.Logger {
+	return p.with(fields)
+}
+
+func (p *logger) WithFields(fields plog.Fields) plog.Entry {
+	return p.with(fields)
+}
+
+func (p *logger) WithField(name string, value interface{}) plog.Entry {
+	return p.with(plog.Fields{name: value})
+}
+
+func (p *logger) WithError(err error) plog.Entry {
+	return p.with(plog.Fields{"error": err})
+}
+
+func (p *logger) Debug(args ...interface{}) { p.l.Debugln(fmt.Sprint(args...)) }
+func (p *logger) Info(args ...interface{})  { p.l.Infoln(fmt.Sprint(args...)) }
+func (p *logger) Warn(args ...interface{})  { p.l.Warnln(fmt.Sprint(args...)) }
+func (p *logger) Error(args ...interface{}) { p.l.Errorln(fmt.Sprint(args...)) }
+
+func (p *logger) Debugf(format string, args ...interface{}) { p.l.Debugf(format+"\n", args...) }
+func (p *logger) Infof(format string, args ...interface{})  { p.l.Infof(format+"\n", args...) }
+func (p *logger) Warnf(format string, args ...interface{})  { p.l.Warnf(format+"\n", args...) }
+func (p *logger) Errorf(format string, args ...interface{}) { p.l.Errorf(format+"\n", args...) }
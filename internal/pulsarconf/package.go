@@ -0,0 +1,3 @@
+// Package pulsarconf provides configuration fields and client helpers that are
+// shared by the Pulsar components.
+package pulsarconf
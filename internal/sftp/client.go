@@ -0,0 +1,177 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/pkg/sftp"
+)
+
+//------------------------------------------------------------------------------
+
+// ErrClosed is returned by requests made once a client has been closed or its
+// connection has been lost.
+var ErrClosed = errors.New("sftp: client is closed")
+
+// posixRenameExt is the OpenSSH extension for renaming a file over any file
+// that already exists at the target path.
+const posixRenameExt = "posix-rename@openssh.com"
+
+// Client is a client of a remote SFTP server, which is safe to use from
+// multiple goroutines. The errors of requests that fail because the connection
+// to the server is lost wrap ErrClosed.
+type Client struct {
+	client  *sftp.Client
+	closeFn func() error
+	lost    int32
+}
+
+// NewClientPipe starts an SFTP session over a reader and writer of the server,
+// where closeFn is called in order to close the underlying connection once the
+// client is closed.
+func NewClientPipe(r io.Reader, w io.WriteCloser, closeFn func() error) (*Client, error) {
+	c := &Client{closeFn: closeFn}
+	client, err := sftp.NewClientPipe(&lostReader{r: r, c: c}, &lostWriter{w: w, c: c})
+	if err != nil {
+		closeFn()
+		return nil, err
+	}
+	c.client = client
+	return c, nil
+}
+
+// lostReader marks the client as disconnected once reading from the server
+// fails, which happens before the failure is reported to pending requests.
+type lostReader struct {
+	r io.Reader
+	c *Client
+}
+
+func (l *lostReader) Read(b []byte) (int, error) {
+	n, err := l.r.Read(b)
+	if err != nil {
+		atomic.StoreInt32(&l.c.lost, 1)
+	}
+	return n, err
+}
+
+// lostWriter marks the client as disconnected once writing to the server
+// fails.
+type lostWriter struct {
+	w io.WriteCloser
+	c *Client
+}
+
+func (l *lostWriter) Write(b []byte) (int, error) {
+	n, err := l.w.Write(b)
+	if err != nil {
+		atomic.StoreInt32(&l.c.lost, 1)
+	}
+	return n, err
+}
+
+func (l *lostWriter) Close() error {
+	atomic.StoreInt32(&l.c.lost, 1)
+	return l.w.Close()
+}
+
+// wrapErr wraps an error with ErrClosed when the connection has been lost.
+func (c *Client) wrapErr(err error) error {
+	if err != nil && atomic.LoadInt32(&c.lost) == 1 {
+		return fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// Stat returns the attributes of a file.
+func (c *Client) Stat(p string) (os.FileInfo, error) {
+	info, err := c.client.Stat(p)
+	return info, c.wrapErr(err)
+}
+
+// ReadDir returns the entries of a directory.
+func (c *Client) ReadDir(p string) ([]os.FileInfo, error) {
+	entries, err := c.client.ReadDir(p)
+	return entries, c.wrapErr(err)
+}
+
+// Open opens a file for reading.
+func (c *Client) Open(p string) (*File, error) {
+	f, err := c.client.Open(p)
+	if err != nil {
+		return nil, c.wrapErr(err)
+	}
+	return &File{c: c, f: f}, nil
+}
+
+// Create creates a file for writing, or truncates it if the file exists.
+func (c *Client) Create(p string) (*File, error) {
+	f, err := c.client.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return nil, c.wrapErr(err)
+	}
+	return &File{c: c, f: f}, nil
+}
+
+// Remove removes a file or an empty directory.
+func (c *Client) Remove(p string) error {
+	return c.wrapErr(c.client.Remove(p))
+}
+
+// MkdirAll creates a directory along with any parents that do not exist.
+func (c *Client) MkdirAll(p string) error {
+	return c.wrapErr(c.client.MkdirAll(p))
+}
+
+// Rename renames a file, replacing any file that exists at the new path. When
+// the server supports the OpenSSH posix-rename extension the replacement is
+// atomic, otherwise the existing file is removed before renaming.
+func (c *Client) Rename(oldPath, newPath string) error {
+	if _, exists := c.client.HasExtension(posixRenameExt); exists {
+		return c.wrapErr(c.client.PosixRename(oldPath, newPath))
+	}
+	if err := c.client.Remove(newPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return c.wrapErr(err)
+	}
+	return c.wrapErr(c.client.Rename(oldPath, newPath))
+}
+
+// Close closes the client, any pending requests are failed.
+func (c *Client) Close() error {
+	atomic.StoreInt32(&c.lost, 1)
+	err := c.closeFn()
+	c.client.Close()
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// File is an open remote file.
+type File struct {
+	c *Client
+	f *sftp.File
+}
+
+// Read reads up to len(b) bytes from the file.
+func (f *File) Read(b []byte) (int, error) {
+	n, err := f.f.Read(b)
+	return n, f.c.wrapErr(err)
+}
+
+// Write writes the contents of b to the file.
+func (f *File) Write(b []byte) (int, error) {
+	n, err := f.f.Write(b)
+	return n, f.c.wrapErr(err)
+}
+
+// Close closes the handle of the file.
+func (f *File) Close() error {
+	return f.c.wrapErr(f.f.Close())
+}
+
+//------------------------------------------------------------------------------
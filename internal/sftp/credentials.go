@@ -0,0 +1,139 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+//------------------------------------------------------------------------------
+
+// Credentials contains the configuration fields for authenticating with an SSH
+// server.
+type Credentials struct {
+	Username       string `json:"username" yaml:"username"`
+	Password       string `json:"password" yaml:"password"`
+	PrivateKeyFile string `json:"private_key_file" yaml:"private_key_file"`
+	PrivateKeyPass string `json:"private_key_pass" yaml:"private_key_pass"`
+}
+
+// NewCredentials returns a Credentials configuration with default values.
+func NewCredentials() Credentials {
+	return Credentials{
+		Username:       "",
+		Password:       "",
+		PrivateKeyFile: "",
+		PrivateKeyPass: "",
+	}
+}
+
+// CredentialsFieldSpec returns a spec for the common credentials field of the
+// SFTP components.
+func CredentialsFieldSpec() docs.FieldSpec {
+	return docs.FieldCommon("credentials", "The credentials to authenticate with the server.").WithChildren(
+		docs.FieldCommon("username", "The username to authenticate with."),
+		docs.FieldCommon("password", "A password to authenticate with."),
+		docs.FieldCommon("private_key_file", "The path of a private key file to authenticate with."),
+		docs.FieldCommon("private_key_pass", "The passphrase of the private key file, if it is encrypted."),
+	)
+}
+
+// KnownHostsFieldSpec returns a spec for the common known hosts field of the
+// SFTP components.
+func KnownHostsFieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced(
+		"known_hosts_file",
+		"The path of an OpenSSH known hosts file to verify the key of the server with. When empty the key of the server is not verified.",
+		"/home/benthos/.ssh/known_hosts",
+	)
+}
+
+//------------------------------------------------------------------------------
+
+// ClientConfig returns an SSH client configuration for the credentials, where
+// the key of the server is verified with a known hosts file when one is
+// specified.
+func (c Credentials) ClientConfig(knownHostsFile string) (*ssh.ClientConfig, error) {
+	if len(c.Username) == 0 {
+		return nil, errors.New("a username must be specified")
+	}
+
+	var auth []ssh.AuthMethod
+	if len(c.PrivateKeyFile) > 0 {
+		keyBytes, err := ioutil.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %v", err)
+		}
+		var signer ssh.Signer
+		if len(c.PrivateKeyPass) > 0 {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(keyBytes, []byte(c.PrivateKeyPass))
+		} else {
+			signer, err = ssh.ParsePrivateKey(keyBytes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %v", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if len(c.Password) > 0 {
+		auth = append(auth, ssh.Password(c.Password))
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("either a password or a private key file must be specified")
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if len(knownHostsFile) > 0 {
+		var err error
+		if hostKeyCallback, err = knownhosts.New(knownHostsFile); err != nil {
+			return nil, fmt.Errorf("failed to read known hosts file: %v", err)
+		}
+	}
+
+	return &ssh.ClientConfig{
+		User:            c.Username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         time.Second * 30,
+	}, nil
+}
+
+// Dial connects to an SSH server and starts an SFTP session. Closing the
+// returned client closes the SSH connection.
+func Dial(address string, conf *ssh.ClientConfig) (*Client, error) {
+	conn, err := ssh.Dial("tcp", address, conf)
+	if err != nil {
+		return nil, err
+	}
+	session, err := conn.NewSession()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	closeFn := func() error {
+		session.Close()
+		return conn.Close()
+	}
+	w, err := session.StdinPipe()
+	if err != nil {
+		closeFn()
+		return nil, err
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		closeFn()
+		return nil, err
+	}
+	if err = session.RequestSubsystem("sftp"); err != nil {
+		closeFn()
+		return nil, err
+	}
+	return NewClientPipe(r, w, closeFn)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,5 @@
+// Package sftp provides a client of the SSH File Transfer Protocol, which is
+// used to list, read, write and rename the files of a remote host, along with
+// configuration fields for establishing the SSH connection that are shared by
+// the SFTP components.
+package sftp
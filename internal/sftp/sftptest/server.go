@@ -0,0 +1,165 @@
+// Package sftptest provides an SFTP server of a local directory for testing
+// the SFTP client and the components that use it.
+package sftptest
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/internal/sftp"
+	pkgsftp "github.com/pkg/sftp"
+)
+
+//------------------------------------------------------------------------------
+
+// extensionsMut protects the extensions advertised by servers, which are set
+// globally by the server package, until the version of a session is exchanged.
+var extensionsMut sync.Mutex
+
+// NewClient starts a server of a local directory and returns a client that is
+// connected to it, the server stops once the client is closed. When
+// posixRename is false the server does not advertise the OpenSSH posix-rename
+// extension, and renames fail when a file exists at the new path.
+func NewClient(root string, posixRename bool) (*sftp.Client, error) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	server := pkgsftp.NewRequestServer(&pipeConn{r: serverR, w: serverW}, handlers(&fs{root: root}))
+	go func() {
+		serverR.CloseWithError(server.Serve())
+		serverW.Close()
+	}()
+
+	extensionsMut.Lock()
+	defer extensionsMut.Unlock()
+
+	if !posixRename {
+		if err := pkgsftp.SetSFTPExtensions("hardlink@openssh.com"); err != nil {
+			return nil, err
+		}
+		defer pkgsftp.SetSFTPExtensions("hardlink@openssh.com", "posix-rename@openssh.com")
+	}
+	return sftp.NewClientPipe(clientR, clientW, func() error {
+		return clientR.Close()
+	})
+}
+
+type pipeConn struct {
+	r *io.PipeReader
+	w *io.PipeWriter
+}
+
+func (p *pipeConn) Read(b []byte) (int, error) {
+	return p.r.Read(b)
+}
+
+func (p *pipeConn) Write(b []byte) (int, error) {
+	return p.w.Write(b)
+}
+
+func (p *pipeConn) Close() error {
+	p.r.Close()
+	return p.w.Close()
+}
+
+//------------------------------------------------------------------------------
+
+// fs serves the files of a local directory, where remote paths are relative to
+// the directory.
+type fs struct {
+	root string
+}
+
+func handlers(f *fs) pkgsftp.Handlers {
+	return pkgsftp.Handlers{
+		FileGet:  f,
+		FilePut:  f,
+		FileCmd:  f,
+		FileList: f,
+	}
+}
+
+func (f *fs) local(p string) string {
+	return filepath.Join(f.root, filepath.FromSlash(path.Clean("/"+p)))
+}
+
+func (f *fs) Fileread(r *pkgsftp.Request) (io.ReaderAt, error) {
+	return os.Open(f.local(r.Filepath))
+}
+
+func (f *fs) Filewrite(r *pkgsftp.Request) (io.WriterAt, error) {
+	flags := os.O_WRONLY
+	pflags := r.Pflags()
+	if pflags.Creat {
+		flags |= os.O_CREATE
+	}
+	if pflags.Trunc {
+		flags |= os.O_TRUNC
+	}
+	return os.OpenFile(f.local(r.Filepath), flags, 0644)
+}
+
+func (f *fs) Filecmd(r *pkgsftp.Request) error {
+	switch r.Method {
+	case "Remove":
+		return os.Remove(f.local(r.Filepath))
+	case "Rmdir":
+		return os.Remove(f.local(r.Filepath))
+	case "Mkdir":
+		return os.Mkdir(f.local(r.Filepath), 0755)
+	case "Setstat":
+		return nil
+	case "Rename":
+		if _, err := os.Stat(f.local(r.Target)); err == nil {
+			return os.ErrExist
+		}
+		return os.Rename(f.local(r.Filepath), f.local(r.Target))
+	}
+	return pkgsftp.ErrSSHFxOpUnsupported
+}
+
+// PosixRename renames a file over any file that exists at the new path.
+func (f *fs) PosixRename(r *pkgsftp.Request) error {
+	return os.Rename(f.local(r.Filepath), f.local(r.Target))
+}
+
+func (f *fs) Filelist(r *pkgsftp.Request) (pkgsftp.ListerAt, error) {
+	switch r.Method {
+	case "List":
+		dir, err := os.Open(f.local(r.Filepath))
+		if err != nil {
+			return nil, err
+		}
+		defer dir.Close()
+		entries, err := dir.Readdir(-1)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt(entries), nil
+	case "Stat", "Lstat":
+		info, err := os.Stat(f.local(r.Filepath))
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{info}, nil
+	}
+	return nil, pkgsftp.ErrSSHFxOpUnsupported
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(entries []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(entries, l[offset:])
+	if n < len(entries) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+//------------------------------------------------------------------------------
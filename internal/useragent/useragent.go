@@ -0,0 +1,166 @@
+// Package useragent extracts the browser, operating system and device type
+// from User-Agent strings of HTTP requests.
+//
+// Parsing is based on the product tokens and comments commonly found within
+// the User-Agent strings of browsers, crawlers and command line tools, and
+// doesn't rely on a database of known agents, therefore the results for rare
+// agents are best effort.
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Other is the name given to browsers and operating systems that aren't
+// recognised.
+const Other = "Other"
+
+// Device types.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceOther   = "other"
+)
+
+// Product is the name and version of a browser or operating system.
+type Product struct {
+	Name    string
+	Version string
+}
+
+// UserAgent is the parsed form of a User-Agent string.
+type UserAgent struct {
+	Browser Product
+	OS      Product
+	Device  string
+	Bot     bool
+}
+
+//------------------------------------------------------------------------------
+
+type browserRule struct {
+	name  string
+	token *regexp.Regexp
+}
+
+func productToken(token string) *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[\s;(])` + token + `/([\w.]+)`)
+}
+
+// browserRules are checked in order, where browsers that include the tokens
+// of other browsers, such as Edge including Chrome and Safari, come first.
+var browserRules = []browserRule{
+	{"Edge", productToken(`(?:Edge|Edg|EdgA|EdgiOS)`)},
+	{"Opera", productToken(`(?:OPR|OPiOS|Opera)`)},
+	{"Samsung Internet", productToken(`SamsungBrowser`)},
+	{"Yandex Browser", productToken(`YaBrowser`)},
+	{"Vivaldi", productToken(`Vivaldi`)},
+	{"Firefox", productToken(`(?:Firefox|FxiOS)`)},
+	{"Chrome", productToken(`(?:CriOS|Chrome|Chromium)`)},
+	{"Safari", regexp.MustCompile(`Version/([\w.]+).*Safari/`)},
+	{"Internet Explorer", regexp.MustCompile(`(?:MSIE ([\w.]+)|Trident/.*rv:([\w.]+))`)},
+	{"curl", productToken(`curl`)},
+	{"Wget", productToken(`Wget`)},
+	{"Python Requests", productToken(`python-requests`)},
+	{"Go HTTP Client", productToken(`Go-http-client`)},
+}
+
+var (
+	botRegexp       = regexp.MustCompile(`(?i)([\w-]*(?:bot|crawler|spider|slurp|crawl)[\w-]*)(?:/([\w.]+))?`)
+	windowsRegexp   = regexp.MustCompile(`Windows NT ([\d.]+)`)
+	windowsPhone    = regexp.MustCompile(`Windows Phone(?: OS)? ([\d.]+)`)
+	androidRegexp   = regexp.MustCompile(`Android(?: ([\d.]+))?`)
+	iosRegexp       = regexp.MustCompile(`(?:iPhone|CPU) OS ([\d_]+)`)
+	macRegexp       = regexp.MustCompile(`Mac OS X(?: ([\d_.]+))?`)
+	chromeOSRegexp  = regexp.MustCompile(`CrOS \S+ ([\d.]+)`)
+	windowsVersions = map[string]string{
+		"10.0": "10",
+		"6.3":  "8.1",
+		"6.2":  "8",
+		"6.1":  "7",
+		"6.0":  "Vista",
+		"5.2":  "XP",
+		"5.1":  "XP",
+	}
+)
+
+func firstMatch(submatches []string) string {
+	for _, s := range submatches[1:] {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func parseBrowser(s string) Product {
+	for _, rule := range browserRules {
+		if m := rule.token.FindStringSubmatch(s); m != nil {
+			return Product{Name: rule.name, Version: firstMatch(m)}
+		}
+	}
+	return Product{Name: Other}
+}
+
+func parseOS(s string) Product {
+	if m := windowsPhone.FindStringSubmatch(s); m != nil {
+		return Product{Name: "Windows Phone", Version: m[1]}
+	}
+	if m := windowsRegexp.FindStringSubmatch(s); m != nil {
+		version, exists := windowsVersions[m[1]]
+		if !exists {
+			version = m[1]
+		}
+		return Product{Name: "Windows", Version: version}
+	}
+	if m := androidRegexp.FindStringSubmatch(s); m != nil {
+		return Product{Name: "Android", Version: m[1]}
+	}
+	if m := iosRegexp.FindStringSubmatch(s); m != nil {
+		return Product{Name: "iOS", Version: strings.Replace(m[1], "_", ".", -1)}
+	}
+	if m := macRegexp.FindStringSubmatch(s); m != nil {
+		return Product{Name: "macOS", Version: strings.Replace(m[1], "_", ".", -1)}
+	}
+	if m := chromeOSRegexp.FindStringSubmatch(s); m != nil {
+		return Product{Name: "Chrome OS", Version: m[1]}
+	}
+	if strings.Contains(s, "Linux") || strings.Contains(s, "X11") {
+		return Product{Name: "Linux"}
+	}
+	return Product{Name: Other}
+}
+
+func parseDevice(s string, os Product) string {
+	switch {
+	case strings.Contains(s, "iPad") || strings.Contains(s, "Tablet"):
+		return DeviceTablet
+	case strings.Contains(s, "Mobi") || strings.Contains(s, "iPhone") || os.Name == "Windows Phone":
+		return DeviceMobile
+	case os.Name == "Android":
+		// Android tablets omit the Mobile token.
+		return DeviceTablet
+	case os.Name == "Windows" || os.Name == "macOS" || os.Name == "Linux" || os.Name == "Chrome OS":
+		return DeviceDesktop
+	}
+	return DeviceOther
+}
+
+// Parse a User-Agent string.
+func Parse(s string) UserAgent {
+	ua := UserAgent{
+		Browser: parseBrowser(s),
+		OS:      parseOS(s),
+	}
+	if m := botRegexp.FindStringSubmatch(s); m != nil {
+		ua.Bot = true
+		ua.Device = DeviceBot
+		ua.Browser = Product{Name: m[1], Version: m[2]}
+		return ua
+	}
+	ua.Device = parseDevice(s, ua.OS)
+	return ua
+}
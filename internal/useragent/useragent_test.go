@@ -0,0 +1,82 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]UserAgent{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/89.0.4389.90 Safari/537.36": {
+			Browser: Product{"Chrome", "89.0.4389.90"},
+			OS:      Product{"Windows", "10"},
+			Device:  DeviceDesktop,
+		},
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/89.0.4389.90 Safari/537.36 Edg/89.0.774.57": {
+			Browser: Product{"Edge", "89.0.774.57"},
+			OS:      Product{"Windows", "10"},
+			Device:  DeviceDesktop,
+		},
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.0.3 Safari/605.1.15": {
+			Browser: Product{"Safari", "14.0.3"},
+			OS:      Product{"macOS", "10.15.7"},
+			Device:  DeviceDesktop,
+		},
+		"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:86.0) Gecko/20100101 Firefox/86.0": {
+			Browser: Product{"Firefox", "86.0"},
+			OS:      Product{"Linux", ""},
+			Device:  DeviceDesktop,
+		},
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 14_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.0.3 Mobile/15E148 Safari/604.1": {
+			Browser: Product{"Safari", "14.0.3"},
+			OS:      Product{"iOS", "14.4"},
+			Device:  DeviceMobile,
+		},
+		"Mozilla/5.0 (iPad; CPU OS 12_5_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/87.0.4280.77 Mobile/15E148 Safari/604.1": {
+			Browser: Product{"Chrome", "87.0.4280.77"},
+			OS:      Product{"iOS", "12.5.1"},
+			Device:  DeviceTablet,
+		},
+		"Mozilla/5.0 (Linux; Android 10; SM-G973F) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/13.2 Chrome/83.0.4103.106 Mobile Safari/537.36": {
+			Browser: Product{"Samsung Internet", "13.2"},
+			OS:      Product{"Android", "10"},
+			Device:  DeviceMobile,
+		},
+		"Mozilla/5.0 (Linux; Android 9; SM-T820) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/88.0.4324.152 Safari/537.36": {
+			Browser: Product{"Chrome", "88.0.4324.152"},
+			OS:      Product{"Android", "9"},
+			Device:  DeviceTablet,
+		},
+		"Mozilla/5.0 (Windows NT 6.1; WOW64; Trident/7.0; rv:11.0) like Gecko": {
+			Browser: Product{"Internet Explorer", "11.0"},
+			OS:      Product{"Windows", "7"},
+			Device:  DeviceDesktop,
+		},
+		"Mozilla/5.0 (X11; CrOS x86_64 13597.84.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/88.0.4324.186 Safari/537.36": {
+			Browser: Product{"Chrome", "88.0.4324.186"},
+			OS:      Product{"Chrome OS", "13597.84.0"},
+			Device:  DeviceDesktop,
+		},
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)": {
+			Browser: Product{"Googlebot", "2.1"},
+			OS:      Product{Other, ""},
+			Device:  DeviceBot,
+			Bot:     true,
+		},
+		"curl/7.68.0": {
+			Browser: Product{"curl", "7.68.0"},
+			OS:      Product{Other, ""},
+			Device:  DeviceOther,
+		},
+		"": {
+			Browser: Product{Other, ""},
+			OS:      Product{Other, ""},
+			Device:  DeviceOther,
+		},
+	}
+
+	for input, exp := range tests {
+		assert.Equal(t, exp, Parse(input), input)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
@@ -16,9 +18,13 @@ import (
 // Try is a broker that implements types.Consumer and attempts to send each
 // message to a single output, but on failure will attempt the next output in
 // the list.
+//
+// When an output rejects a batch with errors for individual messages only the
+// failed messages are sent to the next output.
 type Try struct {
 	stats         metrics.Type
 	outputsPrefix string
+	errorMetaKey  string
 
 	maxInFlight  int
 	transactions <-chan types.Transaction
@@ -76,6 +82,14 @@ func (t *Try) WithOutputMetricsPrefix(prefix string) *Try {
 	return t
 }
 
+// WithErrorMetadataKey sets a metadata key that is added to messages sent to
+// an output after the previous output failed, containing the error that caused
+// the failure.
+func (t *Try) WithErrorMetadataKey(key string) *Try {
+	t.errorMetaKey = key
+	return t
+}
+
 // Consume assigns a new messages channel for the broker to read.
 func (t *Try) Consume(ts <-chan types.Transaction) error {
 	if t.transactions != nil {
@@ -100,11 +114,30 @@ func (t *Try) Connected() bool {
 
 //------------------------------------------------------------------------------
 
+// failedParts returns the messages of a payload that failed to be sent with an
+// error, annotated with their errors when an error metadata key is set.
+func (t *Try) failedParts(payload types.Message, err error) types.Message {
+	indexes, errs := batch.FailedIndexes(payload, err)
+	if len(indexes) == payload.Len() && t.errorMetaKey == "" {
+		return payload
+	}
+	next := message.New(nil)
+	for i, index := range indexes {
+		part := payload.Get(index).Copy()
+		if t.errorMetaKey != "" {
+			part.Metadata().Set(t.errorMetaKey, errs[i].Error())
+		}
+		next.Append(part)
+	}
+	return next
+}
+
 // loop is an internal loop that brokers incoming messages to many outputs.
 func (t *Try) loop() {
 	var (
 		wg        = sync.WaitGroup{}
 		mMsgsRcvd = t.stats.GetCounter("count")
+		mSuccs    = []metrics.StatCounter{}
 		mErrs     = []metrics.StatCounter{}
 	)
 
@@ -117,6 +150,7 @@ func (t *Try) loop() {
 	}()
 
 	for i := range t.outputs {
+		mSuccs = append(mSuccs, t.stats.GetCounter(fmt.Sprintf("%v.%v.success", t.outputsPrefix, i)))
 		mErrs = append(mErrs, t.stats.GetCounter(fmt.Sprintf("%v.%v.failed", t.outputsPrefix, i)))
 	}
 
@@ -136,38 +170,35 @@ func (t *Try) loop() {
 			}
 			mMsgsRcvd.Incr(1)
 
+			payload := tran.Payload
 			rChan := make(chan types.Response)
-			select {
-			case t.outputTsChans[0] <- types.NewTransaction(tran.Payload, rChan):
-			case <-t.ctx.Done():
-				return
-			}
 
 			var res types.Response
 			var lOpen bool
 
-		triesLoop:
-			for i := 1; i <= len(t.outputTsChans); i++ {
+			for i, outputTsChan := range t.outputTsChans {
+				select {
+				case outputTsChan <- types.NewTransaction(payload, rChan):
+				case <-t.ctx.Done():
+					return
+				}
 				select {
 				case res, lOpen = <-rChan:
 					if !lOpen {
 						return
 					}
-					if res.Error() != nil {
-						mErrs[i-1].Incr(1)
-					} else {
-						break triesLoop
-					}
 				case <-t.ctx.Done():
 					return
 				}
 
-				if i < len(t.outputTsChans) {
-					select {
-					case t.outputTsChans[i] <- types.NewTransaction(tran.Payload, rChan):
-					case <-t.ctx.Done():
-						return
-					}
+				err := res.Error()
+				if err == nil {
+					mSuccs[i].Incr(1)
+					break
+				}
+				mErrs[i].Incr(1)
+				if i < len(t.outputTsChans)-1 {
+					payload = t.failedParts(payload, err)
 				}
 			}
 			select {
@@ -8,10 +8,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //------------------------------------------------------------------------------
@@ -393,4 +396,77 @@ func TestTryAllFailParallel(t *testing.T) {
 	}
 }
 
+func TestTryBatchErrorMetadata(t *testing.T) {
+	mockOutputs := []*MockOutputType{{}, {}}
+	outputs := []types.Output{mockOutputs[0], mockOutputs[1]}
+
+	stats := metrics.NewLocal()
+	oTM, err := NewTry(outputs, stats)
+	require.NoError(t, err)
+	oTM.WithOutputMetricsPrefix("fallback.outputs").WithErrorMetadataKey("fallback_error")
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, oTM.Consume(readChan))
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+	select {
+	case readChan <- types.NewTransaction(msg, resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+
+	var ts types.Transaction
+	select {
+	case ts = <-mockOutputs[0].TChan:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker propagate")
+	}
+	assert.Equal(t, 3, ts.Payload.Len())
+
+	bErr := batch.NewError(ts.Payload, errors.New("nope"))
+	bErr.Failed(1, errors.New("bar failed"))
+	bErr.Failed(2, errors.New("baz failed"))
+	select {
+	case ts.ResponseChan <- response.NewError(bErr):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out responding to broker")
+	}
+
+	select {
+	case ts = <-mockOutputs[1].TChan:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker propagate")
+	}
+	require.Equal(t, 2, ts.Payload.Len())
+	assert.Equal(t, "bar", string(ts.Payload.Get(0).Get()))
+	assert.Equal(t, "bar failed", ts.Payload.Get(0).Metadata().Get("fallback_error"))
+	assert.Equal(t, "baz", string(ts.Payload.Get(1).Get()))
+	assert.Equal(t, "baz failed", ts.Payload.Get(1).Metadata().Get("fallback_error"))
+
+	// The original batch must not be modified.
+	assert.Equal(t, "", msg.Get(1).Metadata().Get("fallback_error"))
+
+	select {
+	case ts.ResponseChan <- response.NewAck():
+	case <-time.After(time.Second):
+		t.Fatal("Timed out responding to broker")
+	}
+
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for response")
+	}
+
+	oTM.CloseAsync()
+	require.NoError(t, oTM.WaitForClose(time.Second*10))
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(1), counters["fallback.outputs.0.failed"])
+	assert.Equal(t, int64(0), counters["fallback.outputs.0.success"])
+	assert.Equal(t, int64(1), counters["fallback.outputs.1.success"])
+}
+
 //------------------------------------------------------------------------------
@@ -51,6 +51,7 @@ type SanitisedConfig struct {
 	Buffer             interface{} `json:"buffer" yaml:"buffer"`
 	Pipeline           interface{} `json:"pipeline" yaml:"pipeline"`
 	Output             interface{} `json:"output" yaml:"output"`
+	DeadLetter         interface{} `json:"dead_letter,omitempty" yaml:"dead_letter,omitempty"`
 	Manager            interface{} `json:"resources" yaml:"resources"`
 	Logger             interface{} `json:"logger" yaml:"logger"`
 	Metrics            interface{} `json:"metrics" yaml:"metrics"`
@@ -90,6 +91,12 @@ func (c Type) sanitised(skipDeprecated bool) (*SanitisedConfig, error) {
 		return nil, err
 	}
 
+	var deadConf interface{}
+	deadConf, err = c.DeadLetter.Sanitised()
+	if err != nil {
+		return nil, err
+	}
+
 	var bufConf interface{}
 	bufConf, err = c.Buffer.Sanitised(skipDeprecated)
 	if err != nil {
@@ -120,6 +127,7 @@ func (c Type) sanitised(skipDeprecated bool) (*SanitisedConfig, error) {
 		Buffer:             bufConf,
 		Pipeline:           pipeConf,
 		Output:             outConf,
+		DeadLetter:         deadConf,
 		Manager:            mgrConf,
 		Logger:             c.Logger,
 		Metrics:            metConf,
@@ -128,3 +128,14 @@ func TestExampleGen(t *testing.T) {
 		t.Errorf("Unexpected conf value: %v != %v", act, exp)
 	}
 }
+
+func TestEnvConfigLints(t *testing.T) {
+	conf := New()
+	lints, err := Read("../../config/env/default.yaml", true, &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lint := range lints {
+		t.Error(lint)
+	}
+}
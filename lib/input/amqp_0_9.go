@@ -21,6 +21,10 @@ message brokers, including RabbitMQ.`,
 TLS is automatic when connecting to an ` + "`amqps`" + ` URL, but custom
 settings can be enabled in the ` + "`tls`" + ` section.
 
+Messages that fail to be delivered are requeued immediately, unless the failure
+requests a delay before redelivery, in which case the message is held until the
+delay has passed before it's requeued.
+
 ### Metadata
 
 This input adds the following metadata fields to each message:
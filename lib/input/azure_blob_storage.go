@@ -0,0 +1,95 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/codec"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAzureBlobStorage] = TypeSpec{
+		constructor: NewAzureBlobStorage,
+		Summary: `
+Downloads blobs within an Azure Blob Storage container, optionally filtered by a
+prefix. If a storage queue has been configured then only blobs referenced by
+the events of the queue will be downloaded.`,
+		Description: `
+If a queue is not specified the blobs of the container are listed and consumed
+in lexicographic order of their names, one page of blobs at a time, and once all
+blobs have been consumed this input shuts down.
+
+The contents of each blob are converted into messages with the ` + "`codec`" + `,
+and when ` + "`delete_objects`" + ` is enabled each blob is deleted from the
+container once all of its messages have been acknowledged.
+
+### Events
+
+In order to consume new blobs as they are written to a container you can
+[subscribe a storage queue](https://docs.microsoft.com/en-us/azure/storage/blobs/storage-blob-event-quickstart)
+to the ` + "`Microsoft.Storage.BlobCreated`" + ` events of the storage account
+with Event Grid, and set the ` + "`queue.name`" + ` field to the name of that
+queue. Events of other types, and of blobs in other containers when
+` + "`container`" + ` is set, are deleted from the queue and ignored.
+
+An event is not deleted from the queue until all messages of its blob have been
+sent onwards, which ensures at-least-once delivery, but if a blob takes longer
+to process than the ` + "`queue.visibility_timeout`" + ` then it might be
+processed multiple times.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- blob_storage_key
+- blob_storage_container
+- blob_storage_last_modified
+- blob_storage_last_modified_unix
+- blob_storage_content_type
+- blob_storage_content_encoding
+- All user defined metadata
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		Beta: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("storage_account", "The storage account to download blobs from."),
+			docs.FieldCommon("storage_access_key", "The storage account access key."),
+			docs.FieldCommon("container", "The container to consume from. This field is optional when a queue is set, in which case events of any container are consumed."),
+			docs.FieldCommon("prefix", "An optional path prefix, if set only blobs with the prefix are consumed. This field is ignored when a queue is set."),
+			docs.FieldCommon("codec", codec.ReaderDocs).HasOptions(
+				"all-bytes", "lines", "delim:x", "regex:x", "chunker:x", "csv", "length-prefixed", "protobuf-delimited", "tar", "parquet", "gzip", "gzip/lines", "gzip/tar",
+			),
+			docs.FieldAdvanced("max_buffer", "The largest message that can be read with the `lines`, `delim:x` and `regex:x` codecs, and the largest frame that can be read with the `length-prefixed` and `protobuf-delimited` codecs."),
+			docs.FieldAdvanced("delete_objects", "Whether to delete downloaded blobs from the container once all of their messages have been acknowledged."),
+			docs.FieldCommon("queue", "An optional storage queue of the storage account that receives blob created events, which controls the blobs that are downloaded.").WithChildren(
+				docs.FieldCommon("name", "The name of the queue."),
+				docs.FieldAdvanced("visibility_timeout", "The period of time that consumed events are hidden from other consumers of the queue, which should be longer than it takes to process a blob."),
+			),
+			docs.FieldAdvanced("timeout", "The period of time to wait before abandoning a request and trying again."),
+		},
+		Categories: []Category{
+			CategoryServices,
+			CategoryAzure,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAzureBlobStorage creates a new Azure Blob Storage input type.
+func NewAzureBlobStorage(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	r, err := reader.NewAzureBlobStorage(conf.AzureBlobStorage, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeAzureBlobStorage, true, reader.NewAsyncPreserver(r), log, stats)
+}
+
+//------------------------------------------------------------------------------
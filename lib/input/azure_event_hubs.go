@@ -0,0 +1,101 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAzureEventHubs] = TypeSpec{
+		constructor: NewAzureEventHubs,
+		Summary: `
+Consumes the partitions of an Azure Event Hub as a consumer group.`,
+		Description: `
+This input connects to Event Hubs natively over AMQP, authenticating either with
+a shared access key connection string or with Azure Active Directory using a
+service principal or managed identity. When the ` + "`event_hub`" + ` field is
+empty the ` + "`EntityPath`" + ` of the connection string is used instead.
+
+All partitions of the event hub are consumed in parallel unless a subset is
+specified with the ` + "`partitions`" + ` field. Since messages are processed in
+parallel the resulting order of messages is only guaranteed within a partition.
+
+### Checkpointing
+
+When ` + "`checkpoint.container`" + ` is set the offsets of acknowledged
+messages are stored in Azure Blob Storage at the rate of
+` + "`checkpoint.commit_period`" + `, with a blob per partition named
+` + "`<namespace>/<event_hub>/<consumer_group>/<partition_id>`" + `, and
+consumption resumes from these offsets when the input is restarted. Partitions
+without a checkpoint are consumed from either the oldest or latest message
+according to ` + "`start_from_oldest`" + `.
+
+Partitions are not balanced between consumers, and therefore running multiple
+instances of this input with the same consumer group results in duplicate
+messages unless each instance is configured with a distinct set of
+` + "`partitions`" + `.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- azure_event_hubs_partition_id
+- azure_event_hubs_partition_key
+- azure_event_hubs_offset
+- azure_event_hubs_sequence_number
+- azure_event_hubs_enqueued_time_unix
+- All application properties
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.AzureEventHubs, conf.AzureEventHubs.Batching)
+		},
+		Beta: true,
+		FieldSpecs: append(
+			append(azureamqp.FieldSpecs(),
+				docs.FieldCommon("event_hub", "The name of the event hub to consume from."),
+				docs.FieldCommon("consumer_group", "The consumer group to consume as."),
+				docs.FieldAdvanced("partitions", "An optional list of partition IDs to consume, when empty all partitions of the event hub are consumed. Each item of the list can also be a comma separated list of partitions.", []string{"0", "1"}),
+				docs.FieldCommon("start_from_oldest", "Whether to consume from the oldest message of a partition when a checkpoint does not yet exist for it."),
+				docs.FieldCommon("checkpoint", "Configuration for storing the offsets of consumed partitions in Azure Blob Storage.").WithChildren(
+					docs.FieldCommon("storage_account", "The storage account to store checkpoints in."),
+					docs.FieldCommon("storage_access_key", "The access key of the storage account."),
+					docs.FieldCommon("container", "The container to store checkpoints in, when empty checkpoints are not stored."),
+					docs.FieldAdvanced("commit_period", "The period of time between each commit of the offsets of acknowledged messages."),
+				),
+			),
+			batch.FieldSpec(),
+		),
+		Categories: []Category{
+			CategoryServices,
+			CategoryAzure,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAzureEventHubs creates a new Azure Event Hubs input type.
+func NewAzureEventHubs(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	var a reader.Async
+	var err error
+	if a, err = reader.NewAzureEventHubs(conf.AzureEventHubs, log, stats); err != nil {
+		return nil, err
+	}
+	if a, err = reader.NewAsyncBatcher(conf.AzureEventHubs.Batching, a, mgr, log, stats); err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeAzureEventHubs, true, reader.NewAsyncPreserver(a), log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,101 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAzureServiceBus] = TypeSpec{
+		constructor: NewAzureServiceBus,
+		Summary: `
+Receives messages from an Azure Service Bus queue or topic subscription.`,
+		Description: `
+This input connects to Service Bus natively over AMQP, authenticating either
+with a shared access key connection string or with Azure Active Directory using
+a service principal or managed identity. Either a ` + "`queue`" + `, or a
+` + "`topic`" + ` and ` + "`subscription`" + `, must be specified unless the
+` + "`EntityPath`" + ` of the connection string identifies the entity to consume
+from. The dead-letter queue of an entity can be consumed by appending
+` + "`/$DeadLetterQueue`" + ` to its queue or subscription name.
+
+Messages are received in peek-lock mode, where each message is locked for the
+duration configured on the entity. Messages are completed once they have been
+successfully processed and delivered, and when delivery fails they are either
+abandoned, which makes them available for redelivery and increments their
+delivery count, or moved to the dead-letter queue of the entity according to
+` + "`nack_action`" + `.
+
+The locks of messages are renewed every ` + "`lock_renewal_period`" + ` for
+as long as they are being processed, which must be shorter than the lock
+duration of the entity. Messages that are not acknowledged before their lock
+expires are redelivered, which is always the case for messages held longer
+than the lock duration when ` + "`lock_renewal_period`" + ` is empty. Prefetched
+messages are locked from the moment they are received but aren't renewed until
+they are read, and therefore ` + "`prefetch_count`" + ` should be kept low
+when processing is slow.
+
+### Sessions
+
+Session enabled entities can only be consumed with
+` + "`session.enabled`" + ` set to ` + "`true`" + `, where messages are
+received from the session identified by ` + "`session.session_id`" + `, or
+from the next available session when it is empty. Messages of a session are
+received in order and exclusively by a single consumer.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- azure_service_bus_message_id
+- azure_service_bus_correlation_id
+- azure_service_bus_session_id
+- azure_service_bus_content_type
+- azure_service_bus_delivery_count
+- azure_service_bus_sequence_number
+- azure_service_bus_enqueued_time_unix
+- All application properties
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		Beta: true,
+		FieldSpecs: append(
+			azureamqp.FieldSpecs(),
+			docs.FieldCommon("queue", "The name of a queue to consume from."),
+			docs.FieldCommon("topic", "The name of a topic to consume from."),
+			docs.FieldCommon("subscription", "The name of the subscription of the topic to consume from."),
+			docs.FieldAdvanced("session", "Configuration for consuming from a session enabled entity.").WithChildren(
+				docs.FieldCommon("enabled", "Whether to consume from a session."),
+				docs.FieldCommon("session_id", "The ID of the session to consume from, when empty the next available session is consumed."),
+			),
+			docs.FieldCommon("nack_action", "The action to take on messages that fail to be delivered.").HasOptions("abandon", "dead_letter"),
+			docs.FieldAdvanced("prefetch_count", "The maximum number of messages to receive ahead of them being read. Messages are locked from the moment they are received."),
+			docs.FieldAdvanced("lock_renewal_period", "The period at which the locks of messages being processed are renewed, which must be shorter than the lock duration of the entity. When empty locks are not renewed.", "10s", "1m"),
+		),
+		Categories: []Category{
+			CategoryServices,
+			CategoryAzure,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAzureServiceBus creates a new Azure Service Bus input type.
+func NewAzureServiceBus(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	a, err := reader.NewAzureServiceBus(conf.AzureServiceBus, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeAzureServiceBus, true, a, log, stats)
+}
+
+//------------------------------------------------------------------------------
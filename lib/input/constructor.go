@@ -79,140 +79,185 @@ var Constructors = map[string]TypeSpec{}
 
 // String constants representing each input type.
 const (
-	TypeAMQP            = "amqp"
-	TypeAMQP09          = "amqp_0_9"
-	TypeAMQP1           = "amqp_1"
-	TypeBloblang        = "bloblang"
-	TypeBroker          = "broker"
-	TypeCSVFile         = "csv"
-	TypeDynamic         = "dynamic"
-	TypeFile            = "file"
-	TypeFiles           = "files"
-	TypeGCPPubSub       = "gcp_pubsub"
-	TypeHDFS            = "hdfs"
-	TypeHTTPClient      = "http_client"
-	TypeHTTPServer      = "http_server"
-	TypeInproc          = "inproc"
-	TypeKafka           = "kafka"
-	TypeKafkaBalanced   = "kafka_balanced"
-	TypeKinesis         = "kinesis"
-	TypeKinesisBalanced = "kinesis_balanced"
-	TypeMQTT            = "mqtt"
-	TypeNanomsg         = "nanomsg"
-	TypeNATS            = "nats"
-	TypeNATSStream      = "nats_stream"
-	TypeNSQ             = "nsq"
-	TypeReadUntil       = "read_until"
-	TypeRedisList       = "redis_list"
-	TypeRedisPubSub     = "redis_pubsub"
-	TypeRedisStreams    = "redis_streams"
-	TypeResource        = "resource"
-	TypeS3              = "s3"
-	TypeSequence        = "sequence"
-	TypeSocket          = "socket"
-	TypeSocketServer    = "socket_server"
-	TypeSQS             = "sqs"
-	TypeSTDIN           = "stdin"
-	TypeTCP             = "tcp"
-	TypeTCPServer       = "tcp_server"
-	TypeUDPServer       = "udp_server"
-	TypeWebsocket       = "websocket"
-	TypeZMQ4            = "zmq4"
+	TypeAMQP                = "amqp"
+	TypeAMQP09              = "amqp_0_9"
+	TypeAMQP1               = "amqp_1"
+	TypeAzureBlobStorage    = "azure_blob_storage"
+	TypeAzureEventHubs      = "azure_event_hubs"
+	TypeAzureServiceBus     = "azure_service_bus"
+	TypeBloblang            = "bloblang"
+	TypeBroker              = "broker"
+	TypeCSVFile             = "csv"
+	TypeDynamic             = "dynamic"
+	TypeFile                = "file"
+	TypeFiles               = "files"
+	TypeGCPCloudStorage     = "gcp_cloud_storage"
+	TypeGCPPubSub           = "gcp_pubsub"
+	TypeGenerate            = "generate"
+	TypeGRPCServer          = "grpc_server"
+	TypeHDFS                = "hdfs"
+	TypeHTTPClient          = "http_client"
+	TypeHTTPServer          = "http_server"
+	TypeInproc              = "inproc"
+	TypeKafka               = "kafka"
+	TypeKafkaBalanced       = "kafka_balanced"
+	TypeKinesis             = "kinesis"
+	TypeKinesisBalanced     = "kinesis_balanced"
+	TypeMongoDBChangeStream = "mongodb_changestream"
+	TypeMQTT                = "mqtt"
+	TypeMQTT5               = "mqtt_5"
+	TypeMySQLCDC            = "mysql_cdc"
+	TypeNanomsg             = "nanomsg"
+	TypeNATS                = "nats"
+	TypeNATSStream          = "nats_stream"
+	TypeNSQ                 = "nsq"
+	TypePostgresCDC         = "postgres_cdc"
+	TypePulsar              = "pulsar"
+	TypeReadUntil           = "read_until"
+	TypeRedisList           = "redis_list"
+	TypeRedisPubSub         = "redis_pubsub"
+	TypeRedisStreams        = "redis_streams"
+	TypeResource            = "resource"
+	TypeS3                  = "s3"
+	TypeSequence            = "sequence"
+	TypeSFTP                = "sftp"
+	TypeSocket              = "socket"
+	TypeSocketServer        = "socket_server"
+	TypeSQLSelect           = "sql_select"
+	TypeSQS                 = "sqs"
+	TypeSSE                 = "sse"
+	TypeSTDIN               = "stdin"
+	TypeSubprocess          = "subprocess"
+	TypeTCP                 = "tcp"
+	TypeTCPServer           = "tcp_server"
+	TypeUDPServer           = "udp_server"
+	TypeWebsocket           = "websocket"
+	TypeZMQ4                = "zmq4"
 )
 
 //------------------------------------------------------------------------------
 
 // Config is the all encompassing configuration struct for all input types.
 type Config struct {
-	Type            string                       `json:"type" yaml:"type"`
-	AMQP            reader.AMQPConfig            `json:"amqp" yaml:"amqp"`
-	AMQP09          reader.AMQP09Config          `json:"amqp_0_9" yaml:"amqp_0_9"`
-	AMQP1           reader.AMQP1Config           `json:"amqp_1" yaml:"amqp_1"`
-	Bloblang        BloblangConfig               `json:"bloblang" yaml:"bloblang"`
-	Broker          BrokerConfig                 `json:"broker" yaml:"broker"`
-	CSVFile         CSVFileConfig                `json:"csv" yaml:"csv"`
-	Dynamic         DynamicConfig                `json:"dynamic" yaml:"dynamic"`
-	File            FileConfig                   `json:"file" yaml:"file"`
-	Files           reader.FilesConfig           `json:"files" yaml:"files"`
-	GCPPubSub       reader.GCPPubSubConfig       `json:"gcp_pubsub" yaml:"gcp_pubsub"`
-	HDFS            reader.HDFSConfig            `json:"hdfs" yaml:"hdfs"`
-	HTTPClient      HTTPClientConfig             `json:"http_client" yaml:"http_client"`
-	HTTPServer      HTTPServerConfig             `json:"http_server" yaml:"http_server"`
-	Inproc          InprocConfig                 `json:"inproc" yaml:"inproc"`
-	Kafka           reader.KafkaConfig           `json:"kafka" yaml:"kafka"`
-	KafkaBalanced   reader.KafkaBalancedConfig   `json:"kafka_balanced" yaml:"kafka_balanced"`
-	Kinesis         reader.KinesisConfig         `json:"kinesis" yaml:"kinesis"`
-	KinesisBalanced reader.KinesisBalancedConfig `json:"kinesis_balanced" yaml:"kinesis_balanced"`
-	MQTT            reader.MQTTConfig            `json:"mqtt" yaml:"mqtt"`
-	Nanomsg         reader.ScaleProtoConfig      `json:"nanomsg" yaml:"nanomsg"`
-	NATS            reader.NATSConfig            `json:"nats" yaml:"nats"`
-	NATSStream      reader.NATSStreamConfig      `json:"nats_stream" yaml:"nats_stream"`
-	NSQ             reader.NSQConfig             `json:"nsq" yaml:"nsq"`
-	Plugin          interface{}                  `json:"plugin,omitempty" yaml:"plugin,omitempty"`
-	ReadUntil       ReadUntilConfig              `json:"read_until" yaml:"read_until"`
-	RedisList       reader.RedisListConfig       `json:"redis_list" yaml:"redis_list"`
-	RedisPubSub     reader.RedisPubSubConfig     `json:"redis_pubsub" yaml:"redis_pubsub"`
-	RedisStreams    reader.RedisStreamsConfig    `json:"redis_streams" yaml:"redis_streams"`
-	Resource        string                       `json:"resource" yaml:"resource"`
-	S3              reader.AmazonS3Config        `json:"s3" yaml:"s3"`
-	Sequence        SequenceConfig               `json:"sequence" yaml:"sequence"`
-	Socket          SocketConfig                 `json:"socket" yaml:"socket"`
-	SocketServer    SocketServerConfig           `json:"socket_server" yaml:"socket_server"`
-	SQS             reader.AmazonSQSConfig       `json:"sqs" yaml:"sqs"`
-	STDIN           STDINConfig                  `json:"stdin" yaml:"stdin"`
-	TCP             TCPConfig                    `json:"tcp" yaml:"tcp"`
-	TCPServer       TCPServerConfig              `json:"tcp_server" yaml:"tcp_server"`
-	UDPServer       UDPServerConfig              `json:"udp_server" yaml:"udp_server"`
-	Websocket       reader.WebsocketConfig       `json:"websocket" yaml:"websocket"`
-	ZMQ4            *reader.ZMQ4Config           `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
-	Processors      []processor.Config           `json:"processors" yaml:"processors"`
+	Type                string                           `json:"type" yaml:"type"`
+	AMQP                reader.AMQPConfig                `json:"amqp" yaml:"amqp"`
+	AMQP09              reader.AMQP09Config              `json:"amqp_0_9" yaml:"amqp_0_9"`
+	AMQP1               reader.AMQP1Config               `json:"amqp_1" yaml:"amqp_1"`
+	AzureBlobStorage    reader.AzureBlobStorageConfig    `json:"azure_blob_storage" yaml:"azure_blob_storage"`
+	AzureEventHubs      reader.AzureEventHubsConfig      `json:"azure_event_hubs" yaml:"azure_event_hubs"`
+	AzureServiceBus     reader.AzureServiceBusConfig     `json:"azure_service_bus" yaml:"azure_service_bus"`
+	Bloblang            BloblangConfig                   `json:"bloblang" yaml:"bloblang"`
+	Broker              BrokerConfig                     `json:"broker" yaml:"broker"`
+	CSVFile             CSVFileConfig                    `json:"csv" yaml:"csv"`
+	Dynamic             DynamicConfig                    `json:"dynamic" yaml:"dynamic"`
+	File                FileConfig                       `json:"file" yaml:"file"`
+	Files               reader.FilesConfig               `json:"files" yaml:"files"`
+	GCPCloudStorage     reader.GCPCloudStorageConfig     `json:"gcp_cloud_storage" yaml:"gcp_cloud_storage"`
+	GCPPubSub           reader.GCPPubSubConfig           `json:"gcp_pubsub" yaml:"gcp_pubsub"`
+	Generate            GenerateConfig                   `json:"generate" yaml:"generate"`
+	GRPCServer          reader.GRPCServerConfig          `json:"grpc_server" yaml:"grpc_server"`
+	HDFS                reader.HDFSConfig                `json:"hdfs" yaml:"hdfs"`
+	HTTPClient          HTTPClientConfig                 `json:"http_client" yaml:"http_client"`
+	HTTPServer          HTTPServerConfig                 `json:"http_server" yaml:"http_server"`
+	Inproc              InprocConfig                     `json:"inproc" yaml:"inproc"`
+	Kafka               reader.KafkaConfig               `json:"kafka" yaml:"kafka"`
+	KafkaBalanced       reader.KafkaBalancedConfig       `json:"kafka_balanced" yaml:"kafka_balanced"`
+	Kinesis             reader.KinesisConfig             `json:"kinesis" yaml:"kinesis"`
+	KinesisBalanced     reader.KinesisBalancedConfig     `json:"kinesis_balanced" yaml:"kinesis_balanced"`
+	MongoDBChangeStream reader.MongoDBChangeStreamConfig `json:"mongodb_changestream" yaml:"mongodb_changestream"`
+	MQTT                reader.MQTTConfig                `json:"mqtt" yaml:"mqtt"`
+	MQTT5               reader.MQTT5Config               `json:"mqtt_5" yaml:"mqtt_5"`
+	MySQLCDC            reader.MySQLCDCConfig            `json:"mysql_cdc" yaml:"mysql_cdc"`
+	Nanomsg             reader.ScaleProtoConfig          `json:"nanomsg" yaml:"nanomsg"`
+	NATS                reader.NATSConfig                `json:"nats" yaml:"nats"`
+	NATSStream          reader.NATSStreamConfig          `json:"nats_stream" yaml:"nats_stream"`
+	NSQ                 reader.NSQConfig                 `json:"nsq" yaml:"nsq"`
+	PostgresCDC         reader.PostgresCDCConfig         `json:"postgres_cdc" yaml:"postgres_cdc"`
+	Pulsar              reader.PulsarConfig              `json:"pulsar" yaml:"pulsar"`
+	Plugin              interface{}                      `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	ReadUntil           ReadUntilConfig                  `json:"read_until" yaml:"read_until"`
+	RedisList           reader.RedisListConfig           `json:"redis_list" yaml:"redis_list"`
+	RedisPubSub         reader.RedisPubSubConfig         `json:"redis_pubsub" yaml:"redis_pubsub"`
+	RedisStreams        reader.RedisStreamsConfig        `json:"redis_streams" yaml:"redis_streams"`
+	Resource            string                           `json:"resource" yaml:"resource"`
+	S3                  reader.AmazonS3Config            `json:"s3" yaml:"s3"`
+	Sequence            SequenceConfig                   `json:"sequence" yaml:"sequence"`
+	SFTP                reader.SFTPConfig                `json:"sftp" yaml:"sftp"`
+	Socket              SocketConfig                     `json:"socket" yaml:"socket"`
+	SocketServer        SocketServerConfig               `json:"socket_server" yaml:"socket_server"`
+	SQLSelect           reader.SQLSelectConfig           `json:"sql_select" yaml:"sql_select"`
+	SQS                 reader.AmazonSQSConfig           `json:"sqs" yaml:"sqs"`
+	SSE                 reader.SSEConfig                 `json:"sse" yaml:"sse"`
+	STDIN               STDINConfig                      `json:"stdin" yaml:"stdin"`
+	Subprocess          reader.SubprocessConfig          `json:"subprocess" yaml:"subprocess"`
+	TCP                 TCPConfig                        `json:"tcp" yaml:"tcp"`
+	TCPServer           TCPServerConfig                  `json:"tcp_server" yaml:"tcp_server"`
+	UDPServer           UDPServerConfig                  `json:"udp_server" yaml:"udp_server"`
+	Websocket           reader.WebsocketConfig           `json:"websocket" yaml:"websocket"`
+	ZMQ4                *reader.ZMQ4Config               `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
+	Processors          []processor.Config               `json:"processors" yaml:"processors"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Type:            "stdin",
-		AMQP:            reader.NewAMQPConfig(),
-		AMQP09:          reader.NewAMQP09Config(),
-		AMQP1:           reader.NewAMQP1Config(),
-		Bloblang:        NewBloblangConfig(),
-		Broker:          NewBrokerConfig(),
-		CSVFile:         NewCSVFileConfig(),
-		Dynamic:         NewDynamicConfig(),
-		File:            NewFileConfig(),
-		Files:           reader.NewFilesConfig(),
-		GCPPubSub:       reader.NewGCPPubSubConfig(),
-		HDFS:            reader.NewHDFSConfig(),
-		HTTPClient:      NewHTTPClientConfig(),
-		HTTPServer:      NewHTTPServerConfig(),
-		Inproc:          NewInprocConfig(),
-		Kafka:           reader.NewKafkaConfig(),
-		KafkaBalanced:   reader.NewKafkaBalancedConfig(),
-		Kinesis:         reader.NewKinesisConfig(),
-		KinesisBalanced: reader.NewKinesisBalancedConfig(),
-		MQTT:            reader.NewMQTTConfig(),
-		Nanomsg:         reader.NewScaleProtoConfig(),
-		NATS:            reader.NewNATSConfig(),
-		NATSStream:      reader.NewNATSStreamConfig(),
-		NSQ:             reader.NewNSQConfig(),
-		Plugin:          nil,
-		ReadUntil:       NewReadUntilConfig(),
-		RedisList:       reader.NewRedisListConfig(),
-		RedisPubSub:     reader.NewRedisPubSubConfig(),
-		RedisStreams:    reader.NewRedisStreamsConfig(),
-		Resource:        "",
-		S3:              reader.NewAmazonS3Config(),
-		Sequence:        NewSequenceConfig(),
-		Socket:          NewSocketConfig(),
-		SocketServer:    NewSocketServerConfig(),
-		SQS:             reader.NewAmazonSQSConfig(),
-		STDIN:           NewSTDINConfig(),
-		TCP:             NewTCPConfig(),
-		TCPServer:       NewTCPServerConfig(),
-		UDPServer:       NewUDPServerConfig(),
-		Websocket:       reader.NewWebsocketConfig(),
-		ZMQ4:            reader.NewZMQ4Config(),
-		Processors:      []processor.Config{},
+		Type:                "stdin",
+		AMQP:                reader.NewAMQPConfig(),
+		AMQP09:              reader.NewAMQP09Config(),
+		AMQP1:               reader.NewAMQP1Config(),
+		AzureBlobStorage:    reader.NewAzureBlobStorageConfig(),
+		AzureEventHubs:      reader.NewAzureEventHubsConfig(),
+		AzureServiceBus:     reader.NewAzureServiceBusConfig(),
+		Bloblang:            NewBloblangConfig(),
+		Broker:              NewBrokerConfig(),
+		CSVFile:             NewCSVFileConfig(),
+		Dynamic:             NewDynamicConfig(),
+		File:                NewFileConfig(),
+		Files:               reader.NewFilesConfig(),
+		GCPCloudStorage:     reader.NewGCPCloudStorageConfig(),
+		GCPPubSub:           reader.NewGCPPubSubConfig(),
+		Generate:            NewGenerateConfig(),
+		GRPCServer:          reader.NewGRPCServerConfig(),
+		HDFS:                reader.NewHDFSConfig(),
+		HTTPClient:          NewHTTPClientConfig(),
+		HTTPServer:          NewHTTPServerConfig(),
+		Inproc:              NewInprocConfig(),
+		Kafka:               reader.NewKafkaConfig(),
+		KafkaBalanced:       reader.NewKafkaBalancedConfig(),
+		Kinesis:             reader.NewKinesisConfig(),
+		KinesisBalanced:     reader.NewKinesisBalancedConfig(),
+		MongoDBChangeStream: reader.NewMongoDBChangeStreamConfig(),
+		MQTT:                reader.NewMQTTConfig(),
+		MQTT5:               reader.NewMQTT5Config(),
+		MySQLCDC:            reader.NewMySQLCDCConfig(),
+		Nanomsg:             reader.NewScaleProtoConfig(),
+		NATS:                reader.NewNATSConfig(),
+		NATSStream:          reader.NewNATSStreamConfig(),
+		NSQ:                 reader.NewNSQConfig(),
+		PostgresCDC:         reader.NewPostgresCDCConfig(),
+		Pulsar:              reader.NewPulsarConfig(),
+		Plugin:              nil,
+		ReadUntil:           NewReadUntilConfig(),
+		RedisList:           reader.NewRedisListConfig(),
+		RedisPubSub:         reader.NewRedisPubSubConfig(),
+		RedisStreams:        reader.NewRedisStreamsConfig(),
+		Resource:            "",
+		S3:                  reader.NewAmazonS3Config(),
+		Sequence:            NewSequenceConfig(),
+		SFTP:                reader.NewSFTPConfig(),
+		Socket:              NewSocketConfig(),
+		SocketServer:        NewSocketServerConfig(),
+		SQLSelect:           reader.NewSQLSelectConfig(),
+		SQS:                 reader.NewAmazonSQSConfig(),
+		SSE:                 reader.NewSSEConfig(),
+		STDIN:               NewSTDINConfig(),
+		Subprocess:          reader.NewSubprocessConfig(),
+		TCP:                 NewTCPConfig(),
+		TCPServer:           NewTCPServerConfig(),
+		UDPServer:           NewUDPServerConfig(),
+		Websocket:           reader.NewWebsocketConfig(),
+		ZMQ4:                reader.NewZMQ4Config(),
+		Processors:          []processor.Config{},
 	}
 }
 
@@ -1,9 +1,12 @@
 package input
 
 import (
+	"context"
+	"errors"
 	"io"
 	"os"
 
+	"github.com/Jeffail/benthos/v3/internal/codec"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -18,8 +21,37 @@ func init() {
 		constructor: NewFile,
 		Summary: `
 Reads a file, where each line is processed as an individual message.`,
+		Description: `
+### Watch Mode
+
+When ` + "`watch.enabled`" + ` is set the ` + "`path`" + ` is a
+[glob pattern](https://golang.org/pkg/path/filepath/#Match) and the input tails
+all files that match it indefinitely, rather than closing once a file has been
+read. New files that match the pattern are discovered as they are created.
+Changes are detected with inotify on Linux, and the equivalent notifications
+of other platforms, and files are also polled at the rate of
+` + "`watch.poll_interval`" + `.
+
+When a file is rotated by moving it to another path it is read until its end
+before reading the file that takes its place, and when a file is truncated it
+is read again from the start.
+
+The read offsets of files can be written to the cache ` + "`watch.cache`" + `
+once their lines have been acknowledged, in which case a restarted input
+resumes reading each file from its offset, which may result in duplicate
+lines. Without a cache all matching files are read from the start each time
+the input is started.
+
+Messages read in watch mode have the metadata field ` + "`path`" + `, which is
+the path of the file that the message was read from.
+
+### Codecs
+
+When the field ` + "`codec`" + ` is set the file is converted into messages
+with the codec, and the fields ` + "`multipart`" + ` and ` + "`delimiter`" + `
+are ignored. Codecs are not supported in watch mode.`,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("path", "A path pointing to a file on disk."),
+			docs.FieldCommon("path", "A path pointing to a file on disk, or a glob pattern of files when the watch mode is enabled.", "/var/log/app.log", "/var/log/*/*.log"),
 			docs.FieldCommon("multipart", `
 If set `+"`true`"+` each line is read as a message part, and an empty line
 indicates the end of a message batch, and only then is the batch flushed
@@ -28,6 +60,14 @@ downstream.`),
 			docs.FieldCommon("delimiter", `
 A string that indicates the end of a message within the target file. If left
 empty then line feed (\n) is used.`),
+			docs.FieldAdvanced("codec", codec.ReaderDocs).HasOptions(
+				"lines", "delim:x", "regex:x", "chunker:x", "csv", "length-prefixed", "protobuf-delimited", "all-bytes", "tar", "parquet", "gzip/lines",
+			),
+			docs.FieldCommon("watch", "Allows you to configure the input to tail the files that match the path rather than read a single file.").WithChildren(
+				docs.FieldCommon("enabled", "Whether the watch mode is enabled."),
+				docs.FieldAdvanced("poll_interval", "The period of time between each poll of files for changes and new files, and between each write of read offsets to the cache."),
+				docs.FieldCommon("cache", "The name of a [cache resource](/docs/components/caches/about) to store the read offsets of files in."),
+			),
 		},
 		Categories: []Category{
 			CategoryLocal,
@@ -39,10 +79,12 @@ empty then line feed (\n) is used.`),
 
 // FileConfig contains configuration values for the File input type.
 type FileConfig struct {
-	Path      string `json:"path" yaml:"path"`
-	Multipart bool   `json:"multipart" yaml:"multipart"`
-	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
-	Delim     string `json:"delimiter" yaml:"delimiter"`
+	Path      string                 `json:"path" yaml:"path"`
+	Multipart bool                   `json:"multipart" yaml:"multipart"`
+	MaxBuffer int                    `json:"max_buffer" yaml:"max_buffer"`
+	Delim     string                 `json:"delimiter" yaml:"delimiter"`
+	Codec     string                 `json:"codec" yaml:"codec"`
+	Watch     reader.FileWatchConfig `json:"watch" yaml:"watch"`
 }
 
 // NewFileConfig creates a new FileConfig with default values.
@@ -52,6 +94,8 @@ func NewFileConfig() FileConfig {
 		Multipart: false,
 		MaxBuffer: 1000000,
 		Delim:     "",
+		Codec:     "",
+		Watch:     reader.NewFileWatchConfig(),
 	}
 }
 
@@ -59,6 +103,20 @@ func NewFileConfig() FileConfig {
 
 // NewFile creates a new File input type.
 func NewFile(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	if conf.File.Watch.Enabled {
+		if len(conf.File.Codec) > 0 {
+			return nil, errors.New("codecs are not supported in watch mode")
+		}
+		w, err := reader.NewFileWatch(
+			conf.File.Path, conf.File.Delim, conf.File.MaxBuffer, conf.File.Multipart,
+			conf.File.Watch, mgr, log, stats,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return NewAsyncReader(TypeFile, true, reader.NewAsyncPreserver(w), log, stats)
+	}
+
 	file, err := os.Open(conf.File.Path)
 	if err != nil {
 		return nil, err
@@ -68,26 +126,41 @@ func NewFile(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type
 		delim = "\n"
 	}
 
-	rdr, err := reader.NewLines(
-		func() (io.Reader, error) {
-			// Swap so this only works once since we don't want to read the file
-			// multiple times.
-			if file == nil {
-				return nil, io.EOF
-			}
-			sendFile := file
-			file = nil
-			return sendFile, nil
-		},
-		func() {},
-		reader.OptLinesSetDelimiter(delim),
-		reader.OptLinesSetMaxBuffer(conf.File.MaxBuffer),
-		reader.OptLinesSetMultipart(conf.File.Multipart),
-	)
+	handleCtor := func() (io.Reader, error) {
+		// Swap so this only works once since we don't want to read the file
+		// multiple times.
+		if file == nil {
+			return nil, io.EOF
+		}
+		sendFile := file
+		file = nil
+		return sendFile, nil
+	}
+
+	var rdr reader.Async
+	if len(conf.File.Codec) > 0 {
+		rdr, err = reader.NewCodecStream(
+			conf.File.Codec, conf.File.MaxBuffer,
+			func(ctx context.Context) (io.Reader, error) {
+				return handleCtor()
+			},
+			func(ctx context.Context) {},
+		)
+	} else {
+		rdr, err = reader.NewLines(
+			handleCtor,
+			func() {},
+			reader.OptLinesSetDelimiter(delim),
+			reader.OptLinesSetMaxBuffer(conf.File.MaxBuffer),
+			reader.OptLinesSetMultipart(conf.File.Multipart),
+		)
+	}
 	if err != nil {
+		if file != nil {
+			file.Close()
+		}
 		return nil, err
 	}
-
 	return NewAsyncReader(TypeFile, true, reader.NewAsyncPreserver(rdr), log, stats)
 }
 
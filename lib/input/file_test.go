@@ -160,3 +160,64 @@ func TestFileMultiPart(t *testing.T) {
 		t.Error("Timed out waiting for channel close")
 	}
 }
+
+func TestFileCodec(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "benthos_file_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	tmpfile.Write([]byte("id,name\n1,foo\n2,bar\n"))
+
+	conf := NewConfig()
+	conf.File.Path = tmpfile.Name()
+	conf.File.Codec = "csv"
+
+	conf.File.Watch.Enabled = true
+	if _, err = NewFile(conf, nil, log.Noop(), metrics.DudType{}); err == nil {
+		t.Error("Expected error from codec in watch mode")
+	}
+	conf.File.Watch.Enabled = false
+
+	f, err := NewFile(conf, nil, log.Noop(), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		f.CloseAsync()
+		if err := f.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	for _, msg := range []string{`{"id":"1","name":"foo"}`, `{"id":"2","name":"bar"}`} {
+		var ts types.Transaction
+		var open bool
+		select {
+		case ts, open = <-f.TransactionChan():
+			if !open {
+				t.Fatal("channel closed early")
+			} else if res := string(ts.Payload.Get(0).Get()); res != msg {
+				t.Errorf("Wrong result, %v != %v", res, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+		select {
+		case ts.ResponseChan <- response.NewAck():
+		case <-time.After(time.Second):
+			t.Error("Timed out waiting for response")
+		}
+	}
+
+	select {
+	case _, open := <-f.TransactionChan():
+		if open {
+			t.Error("Channel not closed at end of messages")
+		}
+	case <-time.After(time.Second):
+		t.Error("Timed out waiting for channel close")
+	}
+}
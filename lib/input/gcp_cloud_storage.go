@@ -0,0 +1,96 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/codec"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeGCPCloudStorage] = TypeSpec{
+		constructor: NewGCPCloudStorage,
+		Summary: `
+Downloads objects within a GCP Cloud Storage bucket, optionally filtered by a
+prefix. If a Cloud Pub/Sub subscription has been configured then only objects
+referenced by the notifications of the subscription will be downloaded.`,
+		Description: `
+If a subscription is not specified the objects of the bucket are listed and
+consumed in lexicographic order of their names, and once all objects have been
+consumed this input shuts down.
+
+The contents of each object are converted into messages with the
+` + "`codec`" + `, and when ` + "`delete_objects`" + ` is enabled each object
+is deleted from the bucket once all of its messages have been acknowledged.
+
+### Notifications
+
+In order to consume new objects as they are written to a bucket you can
+configure the bucket to publish
+[object finalize notifications](https://cloud.google.com/storage/docs/pubsub-notifications)
+to a Cloud Pub/Sub topic, and set the ` + "`pubsub`" + ` fields to a
+subscription of that topic. Notifications of other event types, and of objects
+in other buckets when ` + "`bucket`" + ` is set, are acknowledged and ignored.
+
+A notification is not acknowledged until all messages of its object have been
+sent onwards, and is therefore redelivered if Benthos fails before that point.
+
+### Credentials
+
+By default Benthos will use the
+[application default credentials](https://cloud.google.com/docs/authentication/production)
+of the environment when connecting to GCP services.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- gcs_key
+- gcs_bucket
+- gcs_last_modified
+- gcs_last_modified_unix
+- gcs_content_type
+- gcs_content_encoding
+- All user defined metadata
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		Beta: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("bucket", "The bucket to consume from. This field is optional when a subscription is set, in which case notifications of any bucket are consumed."),
+			docs.FieldCommon("prefix", "An optional path prefix, if set only objects with the prefix are consumed. This field is ignored when a subscription is set."),
+			docs.FieldCommon("codec", codec.ReaderDocs).HasOptions(
+				"all-bytes", "lines", "delim:x", "regex:x", "chunker:x", "csv", "length-prefixed", "protobuf-delimited", "tar", "parquet", "gzip", "gzip/lines", "gzip/tar",
+			),
+			docs.FieldAdvanced("max_buffer", "The largest message that can be read with the `lines`, `delim:x` and `regex:x` codecs, and the largest frame that can be read with the `length-prefixed` and `protobuf-delimited` codecs."),
+			docs.FieldAdvanced("delete_objects", "Whether to delete downloaded objects from the bucket once all of their messages have been acknowledged."),
+			docs.FieldCommon("pubsub", "An optional Cloud Pub/Sub subscription that receives notifications of objects written to the bucket, which controls the objects that are downloaded.").WithChildren(
+				docs.FieldCommon("project", "The project ID of the subscription."),
+				docs.FieldCommon("subscription", "The ID of the subscription."),
+			),
+		},
+		Categories: []Category{
+			CategoryServices,
+			CategoryGCP,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewGCPCloudStorage creates a new GCP Cloud Storage input type.
+func NewGCPCloudStorage(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	r, err := reader.NewGCPCloudStorage(conf.GCPCloudStorage, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeGCPCloudStorage, true, reader.NewAsyncPreserver(r), log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -25,11 +25,47 @@ This input adds the following metadata fields to each message:
 
 ` + "``` text" + `
 - gcp_pubsub_publish_time_unix
+- gcp_pubsub_ordering_key
+- gcp_pubsub_delivery_attempt
+- gcp_pubsub_dead_letter_topic
+- gcp_pubsub_max_delivery_attempts
 - All message attributes
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+The field ` + "`gcp_pubsub_ordering_key`" + ` is only set for messages that were
+published with an ordering key, and ` + "`gcp_pubsub_delivery_attempt`" + ` is
+only set when the subscription has a
+[dead-letter policy](https://cloud.google.com/pubsub/docs/dead-letter-topics).
+The dead-letter topic and maximum delivery attempts of the policy are added as
+the fields ` + "`gcp_pubsub_dead_letter_topic`" + ` and
+` + "`gcp_pubsub_max_delivery_attempts`" + `, which can be compared with the
+delivery attempt in order to detect the final attempt of a message. Reading the
+policy requires permission to get the subscription, and without it these fields
+are omitted. Messages that are forwarded to a dead-letter topic retain their
+original attributes, and are given attributes describing the source
+subscription, all of which are added as metadata when consuming from a
+dead-letter subscription.
+
+### Exactly-Once Delivery
+
+When the subscription has
+[exactly-once delivery](https://cloud.google.com/pubsub/docs/exactly-once-delivery)
+enabled the acknowledgement of each message is confirmed with Pub/Sub, and
+acknowledgements that fail are logged as errors. A message is only guaranteed
+not to be redelivered once its acknowledgement has succeeded.
+
+### Ordering
+
+When consuming from a subscription with
+[message ordering](https://cloud.google.com/pubsub/docs/ordering) enabled
+messages of the same ordering key are delivered in order. However, in order to
+ensure that they are also processed and acknowledged in order the field
+` + "`max_outstanding_messages`" + ` should be set to ` + "`1`" + `, as
+otherwise a message that fails to be delivered may be redelivered after
+subsequent messages of the same key.`,
 		Categories: []Category{
 			CategoryServices,
 			CategoryGCP,
@@ -0,0 +1,250 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/robfig/cron/v3"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeGenerate] = TypeSpec{
+		constructor: func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+			b, err := newGenerate(conf.Generate)
+			if err != nil {
+				return nil, err
+			}
+			return NewAsyncReader(TypeGenerate, true, b, log, stats)
+		},
+		Beta: true,
+		Summary: `
+Generates messages at a given interval or on a cron schedule using a
+[Bloblang](/docs/guides/bloblang/about) mapping executed without a context.
+This allows you to generate messages for heartbeats, synthetic load or for
+testing your pipeline configs.`,
+		Description: `
+### Scheduling
+
+The ` + "`interval`" + ` field accepts either a duration string such as
+` + "`5s`" + `, in which case the first message is emitted instantly and each
+message afterwards is emitted once the interval has passed, or a cron
+expression such as ` + "`0 */5 * * * *`" + `, in which case each message is
+emitted at the next time that matches the schedule.
+
+Cron expressions support an optional seconds field followed by the standard
+minutes, hours, day of month, month and day of week fields, as well as the
+descriptors ` + "`@yearly`, `@monthly`, `@weekly`, `@daily`, `@hourly` and `@every <duration>`" + `.
+Expressions are evaluated in the local timezone unless prefixed with
+` + "`TZ=<location>`" + `, where the location is a name from the IANA Time
+Zone database, e.g. ` + "`TZ=Europe/London 0 30 9 * * MON-FRI`" + `.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon(
+				"mapping", "A [bloblang](/docs/guides/bloblang/about) mapping to use for generating messages.",
+				`root = "hello world"`,
+				`root = {"test":"message","id":uuid_v4()}`,
+			),
+			docs.FieldCommon(
+				"interval", "The time interval at which messages should be generated, expressed either as a duration string or as a cron expression. If set to an empty string messages will be generated as fast as downstream services can process them.",
+				"5s", "1m", "@every 1s", "0 */5 * * * *", "TZ=Europe/London 0 30 9 * * MON-FRI",
+			),
+			docs.FieldCommon("count", "An optional number of messages to generate, if set above 0 the specified number of messages is generated and then the input will shut down."),
+		},
+		Categories: []Category{
+			CategoryUtility,
+		},
+		Footnotes: `
+## Examples
+
+### Heartbeats
+
+Emit a heartbeat document every minute on the minute, containing a counter and
+a timestamp:
+
+` + "```yaml" + `
+input:
+  generate:
+    interval: '0 * * * * *'
+    mapping: |
+      root.type = "heartbeat"
+      root.host = hostname()
+      root.sequence = count("heartbeats")
+      root.sent_at = timestamp_utc()
+` + "```" + `
+
+### Synthetic Load
+
+Generate random documents as fast as downstream services can process them:
+
+` + "```yaml" + `
+input:
+  generate:
+    interval: ""
+    mapping: |
+      root.id = uuid_v4()
+      root.user_id = random_int() % 1000
+      root.type = if random_int() % 2 == 0 { "click" } else { "view" }
+      root.ts = timestamp_unix_nano()
+` + "```" + ``,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GenerateConfig contains configuration for the Generate input type.
+type GenerateConfig struct {
+	Mapping  string `json:"mapping" yaml:"mapping"`
+	Interval string `json:"interval" yaml:"interval"`
+	Count    int    `json:"count" yaml:"count"`
+}
+
+// NewGenerateConfig creates a new GenerateConfig with default values.
+func NewGenerateConfig() GenerateConfig {
+	return GenerateConfig{
+		Mapping:  "",
+		Interval: "1s",
+		Count:    0,
+	}
+}
+
+// Generate executes a bloblang mapping with an empty context each time this
+// input is read from. An interval period or cron expression determines how
+// often a message is generated.
+type Generate struct {
+	remaining   int32
+	firstIsFree bool
+
+	exec     *mapping.Executor
+	timer    *time.Ticker
+	schedule cron.Schedule
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+// cronParser parses cron expressions with an optional seconds field.
+var cronParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// newGenerate creates a new generate input reader type.
+func newGenerate(conf GenerateConfig) (*Generate, error) {
+	var timer *time.Ticker
+	var schedule cron.Schedule
+	if len(conf.Interval) > 0 {
+		if duration, err := time.ParseDuration(conf.Interval); err == nil {
+			if duration <= 0 {
+				return nil, errors.New("interval must be greater than zero")
+			}
+			timer = time.NewTicker(duration)
+		} else if schedule, err = cronParser.Parse(conf.Interval); err != nil {
+			return nil, fmt.Errorf("failed to parse interval as a duration or a cron expression: %w", err)
+		}
+	}
+	exec, err := bloblang.NewMapping("", conf.Mapping)
+	if err != nil {
+		if perr, ok := err.(*parser.Error); ok {
+			return nil, fmt.Errorf("failed to parse mapping: %v", perr.ErrorAtPosition([]rune(conf.Mapping)))
+		}
+		return nil, fmt.Errorf("failed to parse mapping: %v", err)
+	}
+	remaining := int32(conf.Count)
+	if remaining <= 0 {
+		remaining = -1
+	}
+	return &Generate{
+		exec:        exec,
+		remaining:   remaining,
+		timer:       timer,
+		schedule:    schedule,
+		firstIsFree: schedule == nil,
+		closeChan:   make(chan struct{}),
+	}, nil
+}
+
+// ConnectWithContext establishes a Generate reader.
+func (b *Generate) ConnectWithContext(ctx context.Context) error {
+	return nil
+}
+
+// waitForSchedule blocks until the next time that matches the cron schedule.
+func (b *Generate) waitForSchedule(ctx context.Context) error {
+	now := time.Now()
+	timer := time.NewTimer(b.schedule.Next(now).Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-b.closeChan:
+		return types.ErrTypeClosed
+	case <-ctx.Done():
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+// ReadWithContext a new bloblang generated message.
+func (b *Generate) ReadWithContext(ctx context.Context) (types.Message, reader.AsyncAckFn, error) {
+	if atomic.LoadInt32(&b.remaining) >= 0 {
+		if atomic.AddInt32(&b.remaining, -1) < 0 {
+			return nil, nil, types.ErrTypeClosed
+		}
+	}
+
+	if b.schedule != nil {
+		if err := b.waitForSchedule(ctx); err != nil {
+			return nil, nil, err
+		}
+	} else if !b.firstIsFree && b.timer != nil {
+		select {
+		case <-b.timer.C:
+		case <-b.closeChan:
+			return nil, nil, types.ErrTypeClosed
+		case <-ctx.Done():
+			return nil, nil, types.ErrTimeout
+		}
+	}
+
+	b.firstIsFree = false
+	p, err := b.exec.MapPart(0, message.New(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+	if p == nil {
+		return nil, nil, types.ErrTimeout
+	}
+
+	msg := message.New(nil)
+	msg.Append(p)
+
+	return msg, func(context.Context, types.Response) error { return nil }, nil
+}
+
+// CloseAsync shuts down the generate reader.
+func (b *Generate) CloseAsync() {
+	b.closeOnce.Do(func() {
+		if b.timer != nil {
+			b.timer.Stop()
+		}
+		close(b.closeChan)
+	})
+}
+
+// WaitForClose blocks until the generate input has closed down.
+func (b *Generate) WaitForClose(timeout time.Duration) error {
+	return nil
+}
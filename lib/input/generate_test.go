@@ -0,0 +1,89 @@
+package input
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateConfigErrors(t *testing.T) {
+	for _, interval := range []string{"nope", "0s", "* * *", "TZ=Nope/Nope * * * * *"} {
+		conf := NewGenerateConfig()
+		conf.Mapping = `root = "hello world"`
+		conf.Interval = interval
+
+		_, err := newGenerate(conf)
+		assert.Error(t, err, interval)
+	}
+
+	conf := NewGenerateConfig()
+	conf.Mapping = `root = `
+	_, err := newGenerate(conf)
+	assert.Error(t, err)
+}
+
+func TestGenerateCronExpressions(t *testing.T) {
+	for _, interval := range []string{"@every 1s", "*/5 * * * * *", "0 * * * *", "@hourly", "TZ=Europe/London 0 30 9 * * MON-FRI"} {
+		conf := NewGenerateConfig()
+		conf.Mapping = `root = "hello world"`
+		conf.Interval = interval
+
+		b, err := newGenerate(conf)
+		require.NoError(t, err, interval)
+		assert.NotNil(t, b.schedule, interval)
+		assert.False(t, b.firstIsFree, interval)
+		b.CloseAsync()
+	}
+}
+
+func TestGenerateCronSchedule(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*1500)
+	defer done()
+
+	conf := NewGenerateConfig()
+	conf.Mapping = `root = count("generate_cron")`
+	conf.Interval = "@every 1s"
+
+	b, err := newGenerate(conf)
+	require.NoError(t, err)
+	require.NoError(t, b.ConnectWithContext(ctx))
+
+	// The first message waits for the schedule.
+	m, _, err := b.ReadWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, m.Len())
+	assert.Equal(t, "1", string(m.Get(0).Get()))
+
+	b.CloseAsync()
+
+	_, _, err = b.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrTypeClosed, err)
+}
+
+func TestGenerateCloseInterval(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	conf := NewGenerateConfig()
+	conf.Mapping = `root = "hello world"`
+	conf.Interval = "1h"
+
+	b, err := newGenerate(conf)
+	require.NoError(t, err)
+	require.NoError(t, b.ConnectWithContext(ctx))
+
+	_, _, err = b.ReadWithContext(ctx)
+	require.NoError(t, err)
+
+	go func() {
+		<-time.After(time.Millisecond * 10)
+		b.CloseAsync()
+	}()
+
+	_, _, err = b.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrTypeClosed, err)
+}
@@ -0,0 +1,81 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeGRPCServer] = TypeSpec{
+		constructor: NewGRPCServer,
+		Summary: `
+Serves a gRPC method and consumes the requests made to it.`,
+		Description: `
+The method is served without generated code, and therefore any method can be
+served by specifying its name and the kind of RPC it is with ` + "`rpc_type`" + `.
+Requests may be encoded as protobuf or, when the client uses the content subtype
+` + "`json`" + ` (` + "`application/grpc+json`" + `), as JSON documents.
+
+When a ` + "`descriptor_set`" + ` is provided the requests are parsed as the
+input type of the method and converted into JSON documents, and requests that
+fail to parse are rejected with the code ` + "`INVALID_ARGUMENT`" + `. Otherwise
+the requests are consumed as they are.
+
+### Responses
+
+Each request is responded to with an empty message once it has been
+successfully delivered. For ` + "`unary`" + ` and ` + "`client_stream`" + `
+methods a single response is sent once all requests of the call have been
+delivered, and for ` + "`bidi_stream`" + ` methods a response is sent for each
+request. When a request fails to be delivered the call ends with the code
+` + "`UNAVAILABLE`" + `, and when it is not delivered within the ` + "`timeout`" + `
+the call ends with the code ` + "`DEADLINE_EXCEEDED`" + `.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- grpc_method
+- grpc_content_subtype
+- All metadata of the call
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		Beta: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("address", "The address to listen on."),
+			docs.FieldCommon("method", "The full name of the method to serve.", "/benthos.v1.Ingest/Send"),
+			docs.FieldCommon("rpc_type", "The kind of RPC of the method.").HasOptions("unary", "client_stream", "bidi_stream"),
+			docs.FieldCommon(
+				"descriptor_set", "An optional path to a file containing a serialised `FileDescriptorSet` that describes the method, which can be generated with `protoc --include_imports --descriptor_set_out`.",
+				"./protos/ingest.protoset",
+			),
+			docs.FieldAdvanced("timeout", "The maximum period of time to wait for a request to be delivered before the call is ended."),
+			docs.FieldAdvanced("cert_file", "An optional certificate file for serving with TLS."),
+			docs.FieldAdvanced("key_file", "An optional key file for serving with TLS."),
+		},
+		Categories: []Category{
+			CategoryNetwork,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewGRPCServer creates a new GRPCServer input type.
+func NewGRPCServer(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	g, err := reader.NewGRPCServer(conf.GRPCServer, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeGRPCServer, true, g, log, stats)
+}
+
+//------------------------------------------------------------------------------
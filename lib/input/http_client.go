@@ -2,6 +2,7 @@ package input
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"sync"
@@ -29,12 +30,25 @@ A string that indicates the end of a message within the stream. If left empty
 then line feed (\n) is used.`).HasType("string"),
 	}
 
+	paginationSpecs := docs.FieldSpecs{
+		docs.FieldCommon("mode", "The strategy used for finding the next page of a crawl.").HasOptions("none", "link_header", "json_path", "offset").HasType("string"),
+		docs.FieldCommon("json_path", "With the mode `json_path` a [dot path](/docs/configuration/field_paths) to the URL of the next page within the response body. With the mode `offset` an optional path to the array of items within the response body, where an empty array or a missing value indicates the final page.", "links.next", "data").HasType("string"),
+		docs.FieldAdvanced("offset_param", "The query parameter to set the offset of each page with when using the mode `offset`.").HasType("string"),
+		docs.FieldAdvanced("offset_start", "The offset of the first page of a crawl when using the mode `offset`.").HasType("number"),
+		docs.FieldAdvanced("offset_increment", "The amount to increment the offset by for each page when using the mode `offset`, which is usually the number of items per page.").HasType("number"),
+		docs.FieldAdvanced("max_pages", "The maximum number of pages to request for each crawl, or zero for no limit.").HasType("number"),
+		docs.FieldCommon("interval", "An optional period to wait after the final page of a crawl before beginning the next crawl from the first page.", "60s", "1h").HasType("string"),
+	}
+
 	specs := append(client.FieldSpecs(),
 		docs.FieldCommon("payload", "An optional payload to deliver for each request."),
 		docs.FieldAdvanced("drop_empty_bodies", "Whether empty payloads received from the target server should be dropped."),
 		docs.FieldCommon(
 			"stream", "Allows you to set streaming mode, where requests are kept open and messages are processed line-by-line.",
 		).WithChildren(streamSpecs...),
+		docs.FieldAdvanced(
+			"pagination", "Allows you to crawl the pages of a paginated API, beginning from the configured URL.",
+		).WithChildren(paginationSpecs...),
 	)
 	return specs
 }
@@ -53,7 +67,37 @@ interpolations described [here](/docs/configuration/interpolation#bloblang-queri
 If you enable streaming then Benthos will consume the body of the response as a
 line delimited feed of message parts. Each part is read as an individual message
 unless multipart is set to true, in which case an empty line indicates the end
-of a message.`,
+of a message.
+
+### Pagination
+
+Paginated APIs can be crawled by setting a ` + "`pagination.mode`" + `, where each
+response is a page of the crawl that begins from the configured URL. When the
+final page is reached the crawl begins again from the first page, optionally
+after waiting for the ` + "`pagination.interval`" + `. The following modes are
+supported:
+
+- ` + "`link_header`" + ` requests the link with the relation type ` + "`next`" + `
+  of each [` + "`Link`" + ` header](https://tools.ietf.org/html/rfc8288), and
+  ends the crawl when it is absent.
+- ` + "`json_path`" + ` requests the URL found at the ` + "`pagination.json_path`" + `
+  of each response body, and ends the crawl when it is absent or empty.
+- ` + "`offset`" + ` sets the query parameter ` + "`pagination.offset_param`" + `
+  of each request, beginning at ` + "`pagination.offset_start`" + ` and
+  incremented by ` + "`pagination.offset_increment`" + ` for each page, and ends
+  the crawl when a response body is empty or an empty JSON array. If a
+  ` + "`pagination.json_path`" + ` is set then it is the array found at that path
+  that is checked instead.
+
+The number of the page within the crawl, starting from 1, is added to messages
+as the metadata field ` + "`http_client_page`" + `. Pagination cannot be used
+in streaming mode.
+
+### OAuth2
+
+The field ` + "`oauth2`" + ` enables authenticating requests with access tokens
+obtained via the OAuth2 client credentials flow, where tokens are cached and
+refreshed automatically once they expire.`,
 		FieldSpecs: httpClientSpecs(),
 		Categories: []Category{
 			CategoryNetwork,
@@ -76,9 +120,10 @@ type StreamConfig struct {
 // HTTPClientConfig contains configuration for the HTTPClient output type.
 type HTTPClientConfig struct {
 	client.Config   `json:",inline" yaml:",inline"`
-	Payload         string       `json:"payload" yaml:"payload"`
-	DropEmptyBodies bool         `json:"drop_empty_bodies" yaml:"drop_empty_bodies"`
-	Stream          StreamConfig `json:"stream" yaml:"stream"`
+	Payload         string                            `json:"payload" yaml:"payload"`
+	DropEmptyBodies bool                              `json:"drop_empty_bodies" yaml:"drop_empty_bodies"`
+	Stream          StreamConfig                      `json:"stream" yaml:"stream"`
+	Pagination      reader.HTTPClientPaginationConfig `json:"pagination" yaml:"pagination"`
 }
 
 // NewHTTPClientConfig creates a new HTTPClientConfig with default values.
@@ -97,6 +142,7 @@ func NewHTTPClientConfig() HTTPClientConfig {
 			MaxBuffer: 1000000,
 			Delim:     "",
 		},
+		Pagination: reader.NewHTTPClientPaginationConfig(),
 	}
 }
 
@@ -118,6 +164,9 @@ func NewHTTPClient(conf Config, mgr types.Manager, log log.Modular, stats metric
 	}
 
 	if h.conf.HTTPClient.Stream.Enabled {
+		if mode := h.conf.HTTPClient.Pagination.Mode; mode != "" && mode != "none" {
+			return nil, errors.New("pagination cannot be used in streaming mode")
+		}
 		// Timeout should be left at zero if we are streaming.
 		h.conf.HTTPClient.Timeout = ""
 	}
@@ -139,6 +188,7 @@ func NewHTTPClient(conf Config, mgr types.Manager, log log.Modular, stats metric
 		hc, err := reader.NewHTTPClient(
 			h.payload, h.client,
 			reader.HTTPClientOptSetDropEmpty(h.conf.HTTPClient.DropEmptyBodies),
+			reader.HTTPClientOptSetPagination(h.conf.HTTPClient.Pagination),
 		)
 		if err != nil {
 			return nil, err
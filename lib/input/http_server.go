@@ -1,7 +1,11 @@
 package input
 
 import (
+	"bufio"
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"fmt"
 	"io"
@@ -72,7 +76,12 @@ a single message.
 If the request contains a multipart ` + "`content-type`" + ` header as per
 [rfc1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html) then the
 multiple parts are consumed as a batch of messages, where each body part is a
-message of the batch.
+message of the batch. This includes ` + "`multipart/form-data`" + ` requests,
+such as file uploads from HTML forms, where each form field or file is a message
+of the batch.
+
+Request bodies with a ` + "`Content-Encoding`" + ` header of ` + "`gzip`" + ` or
+` + "`deflate`" + ` are decompressed before being consumed.
 
 #### ` + "`ws_path` (defaults to `/post/ws`)" + `
 
@@ -86,17 +95,34 @@ established.
 It's also possible to specify a ` + "`ws_rate_limit_message`" + `, which is a
 static payload to be sent to clients that have triggered the servers rate limit.
 
+#### ` + "`ws_stream_path` (disabled by default)" + `
+
+Creates a websocket connection that receives messages consumed by this input,
+where each message part is sent over the socket once the message has been
+successfully delivered by the pipeline. Payloads received on the socket are
+ignored.
+
+Clients that are unable to keep up with the stream of messages will have
+messages dropped rather than block the input.
+
 ### Metadata
 
 This input adds the following metadata fields to each message:
 
 ` + "``` text" + `
 - http_server_user_agent
+- http_server_verb
+- http_server_request_path
 - All headers (only first values are taken)
 - All query parameters
 - All cookies
+- http_server_form_name (multipart/form-data only)
+- http_server_form_filename (multipart/form-data file uploads only)
 ` + "```" + `
 
+The parts of multipart requests also have the headers of their body part added
+as metadata, replacing any request headers of the same name.
+
 You can access these metadata fields using
 [function interpolation](/docs/configuration/interpolation#metadata).`,
 		FieldSpecs: docs.FieldSpecs{
@@ -105,6 +131,7 @@ You can access these metadata fields using
 			docs.FieldCommon("ws_path", "The endpoint path to create websocket connections from."),
 			docs.FieldAdvanced("ws_welcome_message", "An optional message to deliver to fresh websocket connections."),
 			docs.FieldAdvanced("ws_rate_limit_message", "An optional message to delivery to websocket connections that are rate limited."),
+			docs.FieldAdvanced("ws_stream_path", "An optional endpoint path to create websocket connections from that stream messages consumed by this input back to clients."),
 			docs.FieldCommon("timeout", "Timeout for requests. If a consumed messages takes longer than this to be delivered the connection is closed, but the message may still be delivered."),
 			docs.FieldCommon("rate_limit", "An optional [rate limit](/docs/components/rate_limits/about) to throttle requests by."),
 			docs.FieldAdvanced("cert_file", "Only valid with a custom `address`."),
@@ -150,6 +177,7 @@ type HTTPServerConfig struct {
 	WSPath             string                   `json:"ws_path" yaml:"ws_path"`
 	WSWelcomeMessage   string                   `json:"ws_welcome_message" yaml:"ws_welcome_message"`
 	WSRateLimitMessage string                   `json:"ws_rate_limit_message" yaml:"ws_rate_limit_message"`
+	WSStreamPath       string                   `json:"ws_stream_path" yaml:"ws_stream_path"`
 	Timeout            string                   `json:"timeout" yaml:"timeout"`
 	RateLimit          string                   `json:"rate_limit" yaml:"rate_limit"`
 	CertFile           string                   `json:"cert_file" yaml:"cert_file"`
@@ -165,6 +193,7 @@ func NewHTTPServerConfig() HTTPServerConfig {
 		WSPath:             "/post/ws",
 		WSWelcomeMessage:   "",
 		WSRateLimitMessage: "",
+		WSStreamPath:       "",
 		Timeout:            "5s",
 		RateLimit:          "",
 		CertFile:           "",
@@ -199,6 +228,9 @@ type HTTPServer struct {
 	handlerWG    sync.WaitGroup
 	transactions chan types.Transaction
 
+	streamMut     sync.Mutex
+	streamClients map[chan [][]byte]struct{}
+
 	closeChan  chan struct{}
 	closedChan chan struct{}
 
@@ -219,6 +251,8 @@ type HTTPServer struct {
 	mWSSucc        metrics.StatCounter
 	mAsyncErr      metrics.StatCounter
 	mAsyncSucc     metrics.StatCounter
+	mStreamCount   metrics.StatCounter
+	mStreamDropped metrics.StatCounter
 }
 
 // NewHTTPServer creates a new HTTPServer input type.
@@ -258,6 +292,7 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 		timeout:         timeout,
 		responseHeaders: map[string]field.Expression{},
 		transactions:    make(chan types.Transaction),
+		streamClients:   map[chan [][]byte]struct{}{},
 		closeChan:       make(chan struct{}),
 		closedChan:      make(chan struct{}),
 
@@ -275,6 +310,8 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 		mWSSucc:        stats.GetCounter("ws.send.success"),
 		mAsyncErr:      stats.GetCounter("send.async_error"),
 		mAsyncSucc:     stats.GetCounter("send.async_success"),
+		mStreamCount:   stats.GetCounter("ws.stream.count"),
+		mStreamDropped: stats.GetCounter("ws.stream.dropped"),
 	}
 
 	var err error
@@ -289,6 +326,7 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 
 	postHdlr := httputil.GzipHandler(h.postHandler)
 	wsHdlr := httputil.GzipHandler(h.wsHandler)
+	wsStreamHdlr := httputil.GzipHandler(h.wsStreamHandler)
 	if mux != nil {
 		if len(h.conf.HTTPServer.Path) > 0 {
 			mux.HandleFunc(h.conf.HTTPServer.Path, postHdlr)
@@ -296,6 +334,9 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 		if len(h.conf.HTTPServer.WSPath) > 0 {
 			mux.HandleFunc(h.conf.HTTPServer.WSPath, wsHdlr)
 		}
+		if len(h.conf.HTTPServer.WSStreamPath) > 0 {
+			mux.HandleFunc(h.conf.HTTPServer.WSStreamPath, wsStreamHdlr)
+		}
 	} else {
 		if len(h.conf.HTTPServer.Path) > 0 {
 			mgr.RegisterEndpoint(
@@ -307,6 +348,11 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 				h.conf.HTTPServer.WSPath, "Post messages via websocket into Benthos.", wsHdlr,
 			)
 		}
+		if len(h.conf.HTTPServer.WSStreamPath) > 0 {
+			mgr.RegisterEndpoint(
+				h.conf.HTTPServer.WSStreamPath, "Stream messages consumed by Benthos via websocket.", wsStreamHdlr,
+			)
+		}
 	}
 
 	go h.loop()
@@ -315,9 +361,37 @@ func NewHTTPServer(conf Config, mgr types.Manager, log log.Modular, stats metric
 
 //------------------------------------------------------------------------------
 
+// decodeRequestBody returns a reader of the request body that decompresses it
+// according to the Content-Encoding header of the request. Encodings that
+// aren't recognised are left untouched.
+func decodeRequestBody(r *http.Request) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r.Body)
+	case "deflate":
+		// The deflate encoding is meant to be zlib wrapped, but some clients
+		// send raw deflate data, which we detect from the zlib header.
+		br := bufio.NewReader(r.Body)
+		header, err := br.Peek(2)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	}
+	return r.Body, nil
+}
+
 func extractMessageFromRequest(r *http.Request) (types.Message, error) {
 	msg := message.New(nil)
 
+	body, err := decodeRequestBody(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode body: %v", err)
+	}
+
 	contentType := r.Header.Get("Content-Type")
 	if contentType == "" {
 		contentType = "application/octet-stream"
@@ -328,8 +402,9 @@ func extractMessageFromRequest(r *http.Request) (types.Message, error) {
 		return nil, err
 	}
 
+	var partHeaders []textproto.MIMEHeader
 	if strings.HasPrefix(mediaType, "multipart/") {
-		mr := multipart.NewReader(r.Body, params["boundary"])
+		mr := multipart.NewReader(body, params["boundary"])
 		for {
 			var p *multipart.Part
 			if p, err = mr.NextPart(); err != nil {
@@ -344,10 +419,24 @@ func extractMessageFromRequest(r *http.Request) (types.Message, error) {
 				return nil, err
 			}
 			msg.Append(message.NewPart(msgBytes))
+
+			header := textproto.MIMEHeader{}
+			for k, v := range p.Header {
+				header[k] = v
+			}
+			if mediaType == "multipart/form-data" {
+				if name := p.FormName(); len(name) > 0 {
+					header["http_server_form_name"] = []string{name}
+				}
+				if filename := p.FileName(); len(filename) > 0 {
+					header["http_server_form_filename"] = []string{filename}
+				}
+			}
+			partHeaders = append(partHeaders, header)
 		}
 	} else {
 		var msgBytes []byte
-		if msgBytes, err = ioutil.ReadAll(r.Body); err != nil {
+		if msgBytes, err = ioutil.ReadAll(body); err != nil {
 			return nil, err
 		}
 		msg.Append(message.NewPart(msgBytes))
@@ -355,6 +444,8 @@ func extractMessageFromRequest(r *http.Request) (types.Message, error) {
 
 	meta := metadata.New(nil)
 	meta.Set("http_server_user_agent", r.UserAgent())
+	meta.Set("http_server_verb", r.Method)
+	meta.Set("http_server_request_path", r.URL.Path)
 	for k, v := range r.Header {
 		if len(v) > 0 {
 			meta.Set(k, v[0])
@@ -370,6 +461,16 @@ func extractMessageFromRequest(r *http.Request) (types.Message, error) {
 	}
 	message.SetAllMetadata(msg, meta)
 
+	for i, header := range partHeaders {
+		partMeta := meta.Copy()
+		for k, v := range header {
+			if len(v) > 0 {
+				partMeta.Set(k, v[0])
+			}
+		}
+		msg.Get(i).SetMetadata(partMeta)
+	}
+
 	// Try to either extract parent span from headers, or create a new one.
 	carrier := opentracing.HTTPHeadersCarrier(r.Header)
 	if clientSpanContext, serr := opentracing.GlobalTracer().Extract(opentracing.HTTPHeaders, carrier); serr == nil {
@@ -445,6 +546,7 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 		tTaken := time.Since(msg.CreatedAt()).Nanoseconds()
 		h.mLatency.Timing(tTaken)
 		h.mSucc.Incr(1)
+		h.broadcast(msg)
 	case <-time.After(h.timeout):
 		h.mTimeout.Incr(1)
 		http.Error(w, "Request timed out", http.StatusRequestTimeout)
@@ -459,6 +561,7 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 				h.mLatency.Timing(tTaken)
 				h.mAsyncSucc.Incr(1)
 				h.mSucc.Incr(1)
+				h.broadcast(msg)
 			}
 		}()
 		return
@@ -580,6 +683,8 @@ func (h *HTTPServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 
 		meta := msg.Get(0).Metadata()
 		meta.Set("http_server_user_agent", r.UserAgent())
+		meta.Set("http_server_verb", r.Method)
+		meta.Set("http_server_request_path", r.URL.Path)
 		for k, v := range r.Header {
 			if len(v) > 0 {
 				meta.Set(k, v[0])
@@ -617,6 +722,7 @@ func (h *HTTPServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 				h.mLatency.Timing(tTaken)
 				h.mWSSucc.Incr(1)
 				h.mSucc.Incr(1)
+				h.broadcast(msg)
 				msgBytes = nil
 				throt.Reset()
 			}
@@ -636,6 +742,86 @@ func (h *HTTPServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// broadcast sends the payloads of a delivered message to each client connected
+// to the websocket stream endpoint. Clients that aren't keeping up have the
+// message dropped.
+func (h *HTTPServer) broadcast(msg types.Message) {
+	h.streamMut.Lock()
+	defer h.streamMut.Unlock()
+
+	if len(h.streamClients) == 0 {
+		return
+	}
+	payloads := message.GetAllBytes(msg)
+	for c := range h.streamClients {
+		select {
+		case c <- payloads:
+		default:
+			h.mStreamDropped.Incr(1)
+		}
+	}
+}
+
+func (h *HTTPServer) wsStreamHandler(w http.ResponseWriter, r *http.Request) {
+	h.handlerWG.Add(1)
+	defer h.handlerWG.Done()
+
+	upgrader := websocket.Upgrader{}
+
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.log.Warnf("Websocket request failed: %v\n", err)
+		return
+	}
+	defer ws.Close()
+
+	h.mStreamCount.Incr(1)
+
+	payloadsChan := make(chan [][]byte, 64)
+	h.streamMut.Lock()
+	h.streamClients[payloadsChan] = struct{}{}
+	h.streamMut.Unlock()
+	defer func() {
+		h.streamMut.Lock()
+		delete(h.streamClients, payloadsChan)
+		h.streamMut.Unlock()
+	}()
+
+	// Payloads sent by the client are discarded, but we need to read them in
+	// order to process control messages and notice when the client leaves.
+	clientClosed := make(chan struct{})
+	go func() {
+		defer close(clientClosed)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if welMsg := h.conf.HTTPServer.WSWelcomeMessage; len(welMsg) > 0 {
+		if err = ws.WriteMessage(websocket.BinaryMessage, []byte(welMsg)); err != nil {
+			h.log.Errorf("Failed to send welcome message: %v\n", err)
+		}
+	}
+
+	for {
+		select {
+		case payloads := <-payloadsChan:
+			for _, payload := range payloads {
+				if err = ws.WriteMessage(websocket.BinaryMessage, payload); err != nil {
+					h.log.Debugf("Failed to stream message over websocket: %v\n", err)
+					return
+				}
+			}
+		case <-clientClosed:
+			return
+		case <-h.closeChan:
+			return
+		}
+	}
+}
+
 //------------------------------------------------------------------------------
 
 func (h *HTTPServer) loop() {
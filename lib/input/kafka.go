@@ -17,15 +17,47 @@ func init() {
 	Constructors[TypeKafka] = TypeSpec{
 		constructor: NewKafka,
 		Summary: `
-Connects to a Kafka broker and consumes a topic and partition.`,
+Connects to a Kafka broker and consumes a topic and a static list of partitions.`,
 		Description: `
-Offsets are managed within kafka as per the consumer group. Only one partition
-per input is supported, if you wish to balance partitions across a consumer
-group look at the ` + "`kafka_balanced`" + ` input type instead.
+Offsets are managed within kafka as per the consumer group. Partitions are
+statically assigned to this input, if you wish to balance partitions across a
+consumer group look at the ` + "`kafka_balanced`" + ` input type instead.
 
-Use the ` + "`batching`" + ` fields to configure an optional
-[batching policy](/docs/configuration/batching#batch-policy). Any other batching
-mechanism will stall with this input due its sequential transaction model.
+### Offset Seeking
+
+The field ` + "`start_offset`" + ` can be used in order to begin consuming
+from an explicit position within each partition, which is useful for backfill
+jobs and deterministic replays. It can be set to either ` + "`oldest`" + `,
+` + "`newest`" + `, a numerical offset, or an RFC 3339 timestamp, in which case
+consumption begins from the earliest message produced at or after that time.
+When set any offsets stored for the consumer group are ignored on connection.
+
+If the field ` + "`consumer_group`" + ` is empty then offsets are neither
+fetched from nor committed to Kafka, and consumption always begins at the
+` + "`start_offset`" + `, or the offset determined by
+` + "`start_from_oldest`" + ` when it is not set.
+
+### Ordering and Parallelism
+
+Partitions consumed by this input are processed in parallel allowing it to
+utilise <= N pipeline processing threads and parallel outputs where N is the
+number of partitions consumed. Messages of each partition are dispatched in
+order.
+
+The field ` + "`checkpoint_limit`" + ` determines how many messages (or
+batches) of a single partition may be processed at the same time. With the
+default of 1 the next message of a partition is only dispatched once the prior
+message has been acknowledged, which preserves ordering within each partition.
+Increasing the limit improves throughput at the cost of that ordering, as
+messages of a partition may then be delivered out of order, particularly when
+they are retried. Offsets are only ever committed once all prior messages of
+the partition have been acknowledged, and therefore delivery remains
+at-least-once regardless of the limit.
+
+The ` + "`batching`" + ` fields allow you to configure a
+[batching policy](/docs/configuration/batching#batch-policy) which will be
+applied per partition. Any other batching mechanism will stall with this input
+unless the ` + "`checkpoint_limit`" + ` is increased accordingly.
 
 ### Metadata
 
@@ -56,10 +88,13 @@ You can access these metadata fields using
 			tls.FieldSpec(),
 			sasl.FieldSpec(),
 			docs.FieldCommon("topic", "A topic to consume from."),
-			docs.FieldCommon("partition", "A partition to consume from."),
-			docs.FieldCommon("consumer_group", "An identifier for the consumer group of the connection."),
+			docs.FieldCommon("partition", "A partition to consume from, this is ignored when the field `partitions` is set."),
+			docs.FieldAdvanced("partitions", "An optional list of partitions to consume from, overriding the field `partition`.", []int32{0, 1, 2}),
+			docs.FieldCommon("consumer_group", "An identifier for the consumer group of the connection, used to store and fetch offsets. If left empty offsets are not stored."),
 			docs.FieldCommon("client_id", "An identifier for the client connection."),
 			docs.FieldAdvanced("start_from_oldest", "If an offset is not found for a topic parition, determines whether to consume from the oldest available offset, otherwise messages are consumed from the latest offset."),
+			docs.FieldAdvanced("start_offset", "An optional explicit offset to begin consuming each partition from, which is either `oldest`, `newest`, a numerical offset, or an RFC 3339 timestamp. When set any offsets stored for the consumer group are ignored.", "oldest", "1234", "2020-09-14T10:00:00Z"),
+			docs.FieldAdvanced("checkpoint_limit", "The maximum number of messages of the same partition that can be processed at a given time. Increasing this limit enables parallel processing of messages within a partition at the cost of their ordering."),
 			docs.FieldAdvanced("commit_period", "The period of time between each commit of the current partition offsets. Offsets are always committed during shutdown."),
 			docs.FieldAdvanced("max_processing_period", "A maximum estimate for the time taken to process a message, this is used for tuning consumer group synchronization."),
 			docs.FieldAdvanced("fetch_buffer_cap", "The maximum number of unprocessed messages to fetch at a given time."),
@@ -85,13 +120,7 @@ func NewKafka(conf Config, mgr types.Manager, log log.Modular, stats metrics.Typ
 	if err != nil {
 		return nil, err
 	}
-	var kb reader.Type = k
-	if !conf.Kafka.Batching.IsNoop() {
-		if kb, err = reader.NewSyncBatcher(conf.Kafka.Batching, k, mgr, log, stats); err != nil {
-			return nil, err
-		}
-	}
-	return NewReader(TypeKafka, reader.NewPreserver(kb), log, stats)
+	return NewAsyncReader(TypeKafka, true, reader.NewAsyncPreserver(k), log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -25,7 +25,25 @@ table name. Offsets will then be tracked per ` + "`client_id`" + ` per
 
 Use the ` + "`batching`" + ` fields to configure an optional
 [batching policy](/docs/configuration/batching#batch-policy). Any other batching
-mechanism will stall with this input due its sequential transaction model.`,
+mechanism will stall with this input due its sequential transaction model.
+
+### Enhanced Fan-Out
+
+When ` + "`enhanced_fan_out.enabled`" + ` is set to ` + "`true`" + ` this input
+registers itself as an
+[enhanced fan-out consumer](https://docs.aws.amazon.com/streams/latest/dev/enhanced-consumers.html)
+of the stream, named after the ` + "`client_id`" + `, and records are pushed to
+it by Kinesis rather than being polled for. This gives each consumer dedicated
+read throughput and lowers latency, but is charged separately by AWS.
+
+In this mode all shards of the stream are consumed in parallel and the field
+` + "`shard`" + ` is ignored. The shards of the stream are listed periodically
+according to ` + "`enhanced_fan_out.shard_discovery_period`" + ` in order to
+handle resharding, and the children of a closed shard are only consumed once
+all records of their parents have been read. Since messages are processed in
+parallel the resulting order of messages is only guaranteed within a shard,
+although unlike polling any batching mechanism can be used. Offsets are tracked in DynamoDB
+using the same table schema as when polling.`,
 		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
 			return sanitiseWithBatch(conf.Kinesis, conf.Kinesis.Batching)
 		},
@@ -40,6 +58,10 @@ mechanism will stall with this input due its sequential transaction model.`,
 			}, session.FieldSpecs()...),
 			docs.FieldAdvanced("timeout", "The period of time to wait before abandoning a request and trying again."),
 			docs.FieldAdvanced("limit", "The maximum number of messages to consume from each request."),
+			docs.FieldAdvanced("enhanced_fan_out", "Configuration for consuming the stream as an enhanced fan-out consumer.").WithChildren(
+				docs.FieldCommon("enabled", "Whether to consume the stream as an enhanced fan-out consumer."),
+				docs.FieldAdvanced("shard_discovery_period", "The period between listings of the shards of the stream, which are used in order to detect resharding."),
+			),
 			batch.FieldSpec(),
 		),
 		Categories: []Category{
@@ -53,6 +75,9 @@ mechanism will stall with this input due its sequential transaction model.`,
 
 // NewKinesis creates a new AWS Kinesis input type.
 func NewKinesis(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	if conf.Kinesis.EnhancedFanOut.Enabled {
+		return newKinesisEnhancedFanOut(conf, mgr, log, stats)
+	}
 	k, err := reader.NewKinesis(conf.Kinesis, log, stats)
 	if err != nil {
 		return nil, err
@@ -70,4 +95,16 @@ func NewKinesis(conf Config, mgr types.Manager, log log.Modular, stats metrics.T
 	)
 }
 
+func newKinesisEnhancedFanOut(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	var k reader.Async
+	var err error
+	if k, err = reader.NewKinesisEnhancedFanOut(conf.Kinesis, log, stats); err != nil {
+		return nil, err
+	}
+	if k, err = reader.NewAsyncBatcher(conf.Kinesis.Batching, k, mgr, log, stats); err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeKinesis, true, reader.NewAsyncPreserver(k), log, stats)
+}
+
 //------------------------------------------------------------------------------
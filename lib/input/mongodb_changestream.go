@@ -0,0 +1,97 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeMongoDBChangeStream] = TypeSpec{
+		constructor: NewMongoDBChangeStream,
+		Summary: `
+Consumes the events of a MongoDB change stream of a collection, a database or
+an entire cluster.`,
+		Description: `
+Change streams are only available from replica sets and sharded clusters, and
+the user of the ` + "`url`" + ` requires the ` + "`find`" + ` and
+` + "`changeStream`" + ` privileges on the collections being watched. When
+neither a ` + "`database`" + ` nor a ` + "`collection`" + ` is specified the
+changes of all databases of the cluster are consumed.
+
+Documents are emitted as [relaxed extended JSON](https://docs.mongodb.com/manual/reference/mongodb-extended-json/),
+where the value emitted for each event depends on the ` + "`payload`" + ` field:
+
+- ` + "`full_document`" + `: The full document of inserts and replacements,
+  the current version of the document of updates, and the key of the document
+  of deletes.
+- ` + "`delta`" + `: The full document of inserts and replacements, the
+  ` + "`updateDescription`" + ` of updates consisting of the updated and removed
+  fields, and the key of the document of deletes.
+- ` + "`change_event`" + `: The entire change event.
+
+Events that do not concern a document, such as a collection being dropped, are
+emitted as the entire change event regardless of the ` + "`payload`" + `.
+
+### Delivery Guarantees
+
+The resume token of the latest event of which it and all prior events have
+been acknowledged is written to the cache ` + "`checkpoint_cache`" + ` at the
+rate of ` + "`commit_period`" + `. When the input is restarted consumption
+resumes after that event, which may result in duplicate events. Without a
+cache consumption begins from the current time each time the input is started.
+
+Events are retained by the server within the oplog, and consumption cannot
+resume from an event that is no longer within the oplog.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- mongodb_changestream_operation
+- mongodb_changestream_database
+- mongodb_changestream_collection
+- mongodb_changestream_document_key
+- mongodb_changestream_cluster_time_unix
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		Beta: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "A [connection string](https://docs.mongodb.com/manual/reference/connection-string/) of the deployment to connect to.", "mongodb://localhost:27017/?replicaSet=rs0", "mongodb+srv://cluster0.example.com"),
+			docs.FieldCommon("username", "A username to authenticate with, which overrides any username of the `url`."),
+			docs.FieldCommon("password", "A password to authenticate with."),
+			docs.FieldCommon("database", "The database to consume the changes of, when empty the changes of all databases are consumed."),
+			docs.FieldCommon("collection", "The collection of the database to consume the changes of, when empty the changes of all collections of the database are consumed."),
+			docs.FieldCommon("payload", "The value to emit for each event.").HasOptions("full_document", "delta", "change_event"),
+			docs.FieldAdvanced("batch_size", "The maximum number of events to obtain from the server in each batch."),
+			docs.FieldCommon("checkpoint_cache", "The name of a [cache resource](/docs/components/caches/about) to store the resume token of acknowledged events in."),
+			docs.FieldAdvanced("checkpoint_key", "The key to store the resume token of acknowledged events under within the cache."),
+			docs.FieldAdvanced("commit_period", "The period of time between each write of the resume token of acknowledged events to the cache."),
+			tls.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewMongoDBChangeStream creates a new MongoDB change stream input type.
+func NewMongoDBChangeStream(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	m, err := reader.NewMongoDBChangeStream(conf.MongoDBChangeStream, mgr, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeMongoDBChangeStream, true, reader.NewAsyncPreserver(m), log, stats)
+}
+
+//------------------------------------------------------------------------------
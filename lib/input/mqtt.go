@@ -2,10 +2,12 @@ package input
 
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/mqttconf"
 	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
 )
 
 //------------------------------------------------------------------------------
@@ -16,6 +18,12 @@ func init() {
 		Summary: `
 Subscribe to topics on MQTT brokers.`,
 		Description: `
+Messages are only acknowledged to the broker once they have been successfully
+delivered by Benthos, and the number of messages that can be pending
+acknowledgement at any given time is limited by the field
+` + "`max_in_flight`" + `. Pending messages are delivered concurrently and
+therefore the order in which messages are consumed is not guaranteed.
+
 ### Metadata
 
 This input adds the following metadata fields to each message:
@@ -29,16 +37,59 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+### Shared Subscriptions
+
+When the field ` + "`shared_subscription_group`" + ` is set each topic is
+subscribed to as a shared subscription of the form
+` + "`$share/<group>/<topic>`" + `. The broker then distributes each message of
+a topic to only one of the clients subscribed with the same group, allowing
+multiple Benthos instances to horizontally scale consumption without receiving
+duplicate messages. Each instance must be configured with a unique
+` + "`client_id`" + `.
+
+The distribution of messages amongst group members is managed entirely by the
+broker, and as instances join or leave a group the broker rebalances
+subsequent messages across the remaining members. Messages that were in flight
+to an instance that disconnects without acknowledging them may be redelivered
+to another member of the group, depending on the broker implementation, and
+therefore downstream processing should tolerate duplicates. Shared
+subscriptions are an MQTT v5 feature that many brokers also support for MQTT
+3.1.1 clients.
+
+### Persistent Sessions
+
+When ` + "`clean_session`" + ` is set to ` + "`false`" + ` the broker retains the
+subscriptions of the ` + "`client_id`" + ` when Benthos disconnects, and queues
+QoS 1 and 2 messages published to those subscriptions until it reconnects,
+at which point they are delivered. In order to also recover the state of
+messages that were in flight when Benthos was shut down the field
+` + "`store_path`" + ` can be set to a directory where this state is persisted
+between restarts.`,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs."),
+			docs.FieldCommon("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs. Brokers that require TLS should use the `ssl://` or `tls://` URL schemes, and brokers that are exposed over a WebSocket should use the `ws://` or `wss://` URL schemes.", []string{"tcp://localhost:1883"}, []string{"ssl://localhost:8883"}, []string{"wss://localhost:443/mqtt"}),
 			docs.FieldCommon("topics", "A list of topics to consume from."),
+			docs.FieldAdvanced("shared_subscription_group", "An optional group name, when set all topics are consumed as shared subscriptions within this group so that each message is delivered to only one member of the group. The name must not contain the characters `/`, `+` or `#`.", "benthos_group"),
 			docs.FieldCommon("client_id", "An identifier for the client connection."),
 			docs.FieldAdvanced("qos", "The level of delivery guarantee to enforce.").HasOptions("0", "1", "2"),
-			docs.FieldAdvanced("clean_session", "Set whether the connection is non-persistent."),
+			docs.FieldAdvanced("clean_session", "Set whether the connection is non-persistent. Set this to `false` in order to resume the session of the `client_id` when reconnecting, see [persistent sessions](#persistent-sessions)."),
 			docs.FieldAdvanced("user", "A username to assume for the connection."),
 			docs.FieldAdvanced("password", "A password to provide for the connection."),
+			docs.FieldAdvanced("max_in_flight", "The maximum number of messages that can be pending acknowledgement at any given time. Increase this to improve throughput."),
+			docs.FieldAdvanced("http_headers", "A map of HTTP headers to add to the upgrade request when connecting to a broker over a WebSocket with the `ws://` or `wss://` URL schemes.", map[string]string{
+				"Authorization": "Bearer foo",
+			}),
+			docs.FieldAdvanced("store_path", "An optional directory in which the state of in flight QoS 1 and 2 messages is persisted, allowing it to be recovered after Benthos is restarted. When left empty the state is kept in memory only.", "./mqtt_store"),
 			docs.FieldDeprecated("stale_connection_timeout"),
+			docs.FieldAdvanced("reconnect", "Control the exponential backoff applied between attempts to connect to the broker. Each reconnect attempt increments the metric `reconnect.attempt`, and each failed reconnect attempt increments `reconnect.error`.").WithChildren(
+				docs.FieldAdvanced("initial_interval", "The initial period to wait between connection attempts."),
+				docs.FieldAdvanced("max_interval", "The maximum period to wait between connection attempts."),
+				docs.FieldAdvanced("jitter", "A randomization factor between 0 and 1 applied to each interval in order to avoid many clients reconnecting in lockstep."),
+				docs.FieldAdvanced("max_retries", "The maximum number of consecutive connection attempts to retry before giving up and reporting the input as disconnected. If set to zero there is no discrete limit."),
+			),
+			mqttconf.WillFieldSpec(),
+			tls.FieldSpec(),
 		},
 		Categories: []Category{
 			CategoryServices,
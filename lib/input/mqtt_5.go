@@ -0,0 +1,93 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeMQTT5] = TypeSpec{
+		constructor: NewMQTT5,
+		Beta:        true,
+		Summary: `
+Subscribe to topics on MQTT brokers using the MQTT v5 protocol.`,
+		Description: `
+Messages are only acknowledged to the broker once they have been successfully
+delivered by Benthos, and acknowledgements are sent in the order in which
+messages were received.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- mqtt_qos
+- mqtt_retained
+- mqtt_topic
+- mqtt_message_id
+- mqtt_content_type
+- mqtt_response_topic
+- mqtt_correlation_data
+- mqtt_message_expiry
+` + "```" + `
+
+Fields that are not set on the received message are omitted. Any user
+properties of the message are also added as metadata fields, where the key of
+each field is the name of the property.
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+### Shared Subscriptions
+
+When the field ` + "`shared_subscription_group`" + ` is set each topic is
+subscribed to as a shared subscription of the form
+` + "`$share/<group>/<topic>`" + `, and the broker distributes each message to
+only one member of the group. The rebalancing semantics are the same as for the
+[` + "`mqtt`" + ` input](/docs/components/inputs/mqtt#shared-subscriptions).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs. Brokers that require TLS should use the `ssl://` or `tls://` URL schemes, and brokers that are exposed over a WebSocket should use the `ws://` or `wss://` URL schemes.", []string{"tcp://localhost:1883"}, []string{"ssl://localhost:8883"}, []string{"wss://localhost:443/mqtt"}),
+			docs.FieldCommon("topics", "A list of topics to consume from."),
+			docs.FieldAdvanced("shared_subscription_group", "An optional group name, when set all topics are consumed as shared subscriptions within this group so that each message is delivered to only one member of the group. The name must not contain the characters `/`, `+` or `#`.", "benthos_group"),
+			docs.FieldCommon("client_id", "An identifier for the client connection."),
+			docs.FieldAdvanced("qos", "The level of delivery guarantee to enforce.").HasOptions("0", "1", "2"),
+			docs.FieldAdvanced("clean_start", "Whether the broker should discard any existing session for this client ID when connecting."),
+			docs.FieldAdvanced("session_expiry_interval", "The period of time that the broker should retain the session of this client after it disconnects. A value of zero indicates that the session ends when the connection is closed.", "0s", "1h"),
+			docs.FieldAdvanced("topic_alias_maximum", "The maximum number of topic aliases that the broker is permitted to use when sending messages to this client, zero disables topic aliases."),
+			docs.FieldAdvanced("keepalive", "The maximum period of time permitted between packets sent to the broker before the connection is considered lost."),
+			docs.FieldAdvanced("user", "A username to assume for the connection."),
+			docs.FieldAdvanced("password", "A password to provide for the connection."),
+			docs.FieldAdvanced("http_headers", "A map of HTTP headers to add to the upgrade request when connecting to a broker over a WebSocket with the `ws://` or `wss://` URL schemes.", map[string]string{
+				"Authorization": "Bearer foo",
+			}),
+			tls.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewMQTT5 creates a new MQTT5 input type.
+func NewMQTT5(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	m, err := reader.NewMQTT5(conf.MQTT5, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(
+		TypeMQTT5,
+		true,
+		reader.NewAsyncPreserver(m),
+		log, stats,
+	)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,119 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeMySQLCDC] = TypeSpec{
+		constructor: NewMySQLCDC,
+		Summary: `
+Consumes row level changes from the binary log of a MySQL or MariaDB server.`,
+		Description: `
+This input connects to the server as a replica and streams the events of its
+binary log, which must be enabled with ` + "`binlog_format`" + ` set to
+` + "`ROW`" + `, and ideally ` + "`binlog_row_image`" + ` set to
+` + "`FULL`" + `. The user of the ` + "`dsn`" + ` requires the
+` + "`REPLICATION SLAVE`" + `, ` + "`REPLICATION CLIENT`" + ` and
+` + "`SELECT`" + ` privileges, and the ` + "`server_id`" + ` must be unique
+amongst all replicas of the server. Changes of all tables are consumed unless
+a list of ` + "`tables`" + ` is specified.
+
+Each change is emitted as a JSON document of the form:
+
+` + "```json" + `
+{
+  "operation": "update",
+  "schema": "shop",
+  "table": "users",
+  "primary_key": ["id"],
+  "gtid": "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+  "binlog_file": "mysql-bin.000003",
+  "binlog_position": 4630,
+  "new": {"id": 1, "name": "foo"},
+  "old": {"id": 1, "name": "bar"}
+}
+` + "```" + `
+
+Where ` + "`operation`" + ` is one of ` + "`insert`" + `, ` + "`update`" + `,
+` + "`delete`" + ` or ` + "`read`" + `, and the ` + "`binlog_position`" + `
+is the position following the transaction of the change. Column names and
+primary keys are obtained from the information schema of the server when a
+table is first encountered and after its schema changes.
+
+### Snapshots
+
+When ` + "`snapshot`" + ` is ` + "`true`" + ` and there is no checkpoint to
+resume from, the rows of each table are first read within a consistent
+snapshot and emitted as ` + "`read`" + ` operations, followed by the changes
+made since the snapshot began. Changes made while the snapshot is being read
+may therefore be emitted twice. All tables outside of the system schemas are
+read unless a list of ` + "`tables`" + ` is specified.
+
+### Delivery Guarantees
+
+The position of the latest transaction of which all changes have been
+acknowledged is written to the cache ` + "`checkpoint_cache`" + ` at the
+rate of ` + "`commit_period`" + `. When the input is restarted consumption
+resumes from that position, which may result in duplicate changes. Positions
+are tracked by GTID when ` + "`gtid_mode`" + ` is enabled or when consuming
+from MariaDB, which allows consumption to resume from a different server of a
+replication topology, and otherwise by binary log file and position. Without a
+cache consumption begins from the current end of the binary log each time the
+input is started.
+
+Binary log files are purged by the server according to its retention
+settings, and consumption cannot resume from a position that has been purged.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- mysql_cdc_operation
+- mysql_cdc_schema
+- mysql_cdc_table
+- mysql_cdc_binlog_file
+- mysql_cdc_binlog_position
+- mysql_cdc_gtid
+- mysql_cdc_timestamp_unix
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		Beta: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("dsn", "A [data source name](https://github.com/go-sql-driver/mysql#dsn-data-source-name) to connect to the server with.", "foouser:foopass@tcp(localhost:3306)/"),
+			docs.FieldCommon("server_id", "A server ID to identify this input as a replica of the server, which must be unique amongst all replicas of the server."),
+			docs.FieldCommon("tables", "A list of tables of the form `schema.table` to consume changes of, when empty changes of all tables are consumed.", []string{"shop.users", "shop.orders"}),
+			docs.FieldCommon("snapshot", "Whether to read the existing rows of tables before consuming changes when there is no checkpoint to resume from."),
+			docs.FieldCommon("checkpoint_cache", "The name of a [cache resource](/docs/components/caches/about) to store the position of acknowledged changes in."),
+			docs.FieldAdvanced("checkpoint_key", "The key to store the position of acknowledged changes under within the cache."),
+			docs.FieldAdvanced("commit_period", "The period of time between each write of the position of acknowledged changes to the cache."),
+			tls.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewMySQLCDC creates a new MySQL change data capture input type.
+func NewMySQLCDC(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	m, err := reader.NewMySQLCDC(conf.MySQLCDC, mgr, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeMySQLCDC, true, reader.NewAsyncPreserver(m), log, stats)
+}
+
+//------------------------------------------------------------------------------
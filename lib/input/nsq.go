@@ -24,6 +24,8 @@ Subscribe to an NSQ instance topic and channel.`,
 			docs.FieldCommon("nsqd_tcp_addresses", "A list of nsqd addresses to connect to."),
 			docs.FieldCommon("lookupd_http_addresses", "A list of nsqlookupd addresses to connect to."),
 			tls.FieldSpec(),
+			docs.FieldAdvanced("auth_secret", "An optional secret for NSQ authentication (requires nsqd 0.2.29+)."),
+			docs.FieldAdvanced("sample_rate", "An optional percentage between 1 and 99 of the messages of the channel to receive, where zero disables sampling (requires nsqd 0.2.25+)."),
 			docs.FieldCommon("topic", "The topic to consume from."),
 			docs.FieldCommon("channel", "The channel to consume from."),
 			docs.FieldCommon("user_agent", "A user agent to assume when connecting."),
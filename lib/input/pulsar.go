@@ -0,0 +1,74 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/pulsarconf"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypePulsar] = TypeSpec{
+		constructor: NewPulsar,
+		Summary: `
+Reads messages from an Apache Pulsar subscription.`,
+		Description: `
+Messages are acknowledged once they have been successfully processed and
+delivered, and are negatively acknowledged (and therefore redelivered by the
+broker) when delivery fails.
+
+### Subscription Types
+
+The field ` + "`subscription_type`" + ` determines how messages of the
+subscription are distributed amongst consumers. When running multiple Benthos
+instances with the ` + "`exclusive`" + ` or ` + "`failover`" + ` types only one
+instance receives messages at a time, whereas ` + "`shared`" + ` and
+` + "`key_shared`" + ` distribute messages across all instances, with the latter
+guaranteeing that messages of the same key are delivered to the same instance.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- pulsar_key
+- pulsar_topic
+- pulsar_publish_time_unix
+- pulsar_event_time_unix
+- pulsar_redelivery_count
+- All message properties
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		Beta: true,
+		Categories: []Category{
+			CategoryServices,
+		},
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "A URL to connect to, use a `pulsar+ssl://` scheme in order to connect with TLS.", "pulsar://localhost:6650", "pulsar+ssl://pulsar.example.com:6651"),
+			docs.FieldCommon("topics", "A list of topics to consume from. Each item of the list can also be a comma separated list of topics.", []string{"persistent://public/default/foo"}),
+			docs.FieldCommon("subscription_name", "The name of the subscription to consume from."),
+			docs.FieldCommon("subscription_type", "The type of the subscription.").HasOptions("exclusive", "shared", "key_shared", "failover"),
+			pulsarconf.AuthFieldSpec(),
+			pulsarconf.TLSFieldSpec(),
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewPulsar creates a new Pulsar input type.
+func NewPulsar(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	p, err := reader.NewPulsar(conf.Pulsar, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypePulsar, true, reader.NewAsyncPreserver(p), log, stats)
+}
+
+//------------------------------------------------------------------------------
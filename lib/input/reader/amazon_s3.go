@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,6 +18,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
 	"github.com/Jeffail/gabs/v2"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -38,6 +40,10 @@ type AmazonS3Config struct {
 	sess.Config        `json:",inline" yaml:",inline"`
 	Bucket             string                  `json:"bucket" yaml:"bucket"`
 	Prefix             string                  `json:"prefix" yaml:"prefix"`
+	Glob               string                  `json:"glob" yaml:"glob"`
+	StartAfter         string                  `json:"start_after" yaml:"start_after"`
+	CheckpointCache    string                  `json:"checkpoint_cache" yaml:"checkpoint_cache"`
+	CheckpointKey      string                  `json:"checkpoint_key" yaml:"checkpoint_key"`
 	Retries            int                     `json:"retries" yaml:"retries"`
 	ForcePathStyleURLs bool                    `json:"force_path_style_urls" yaml:"force_path_style_urls"`
 	DownloadManager    S3DownloadManagerConfig `json:"download_manager" yaml:"download_manager"`
@@ -58,6 +64,10 @@ func NewAmazonS3Config() AmazonS3Config {
 		Config:             sess.NewConfig(),
 		Bucket:             "",
 		Prefix:             "",
+		Glob:               "",
+		StartAfter:         "",
+		CheckpointCache:    "",
+		CheckpointKey:      "s3",
 		Retries:            3,
 		ForcePathStyleURLs: false,
 		DownloadManager: S3DownloadManagerConfig{
@@ -82,6 +92,94 @@ type objKey struct {
 	s3Bucket  string
 	attempts  int
 	sqsHandle *sqs.DeleteMessageBatchRequestEntry
+
+	// The checkpoint index of the object when listing the bucket.
+	index int
+}
+
+// s3GlobToRegexp converts a glob pattern for object keys into a regular
+// expression, where `*` and `?` do not match the path separator `/` but `**`
+// matches any number of directories, and also returns the literal prefix of the
+// pattern.
+func s3GlobToRegexp(pattern string) (*regexp.Regexp, string, error) {
+	var buf strings.Builder
+	buf.WriteString("^")
+
+	prefix, literal := "", true
+	inAlternation := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				buf.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				buf.WriteString(".*")
+				i++
+			} else {
+				buf.WriteString("[^/]*")
+			}
+		case '?':
+			buf.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, "", fmt.Errorf("unterminated character class in glob pattern: %v", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			buf.WriteString("[" + class + "]")
+			i += end + 1
+		case '{':
+			if inAlternation {
+				return nil, "", fmt.Errorf("nested alternations are not supported in glob pattern: %v", pattern)
+			}
+			inAlternation = true
+			buf.WriteString("(?:")
+		case '}':
+			if !inAlternation {
+				return nil, "", fmt.Errorf("unexpected '}' in glob pattern: %v", pattern)
+			}
+			inAlternation = false
+			buf.WriteString(")")
+		case ',':
+			if inAlternation {
+				buf.WriteString("|")
+			} else {
+				buf.WriteString(",")
+			}
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				c = pattern[i]
+			}
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+			if literal {
+				prefix += string(c)
+			}
+			continue
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+			if literal {
+				prefix += string(c)
+			}
+			continue
+		}
+		literal = false
+	}
+	if inAlternation {
+		return nil, "", fmt.Errorf("unterminated alternation in glob pattern: %v", pattern)
+	}
+	buf.WriteString("$")
+
+	re, err := regexp.Compile(buf.String())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to compile glob pattern: %v", err)
+	}
+	return re, prefix, nil
 }
 
 // AmazonS3 is a benthos reader.Type implementation that reads messages from an
@@ -99,6 +197,22 @@ type AmazonS3 struct {
 
 	readMethod func() (types.Part, objKey, error)
 
+	glob       *regexp.Regexp
+	listPrefix string
+	listAfter  string
+	listToken  *string
+	listDone   bool
+
+	cache     types.Cache
+	cpMut     sync.Mutex
+	cp        *checkpoint.Type
+	cpIndex   int
+	pruned    int
+	positions map[int]string
+	position  string
+	commitMut sync.Mutex
+	committed string
+
 	session    *session.Session
 	s3         *s3.S3
 	downloader *s3manager.Downloader
@@ -110,10 +224,24 @@ type AmazonS3 struct {
 }
 
 // NewAmazonS3 creates a new Amazon S3 bucket reader.Type.
+//
+// Deprecated: Use NewAmazonS3WithManager instead, which is able to access the
+// cache resource of a checkpoint.
 func NewAmazonS3(
 	conf AmazonS3Config,
 	log log.Modular,
 	stats metrics.Type,
+) (*AmazonS3, error) {
+	return NewAmazonS3WithManager(conf, types.NoopMgr(), log, stats)
+}
+
+// NewAmazonS3WithManager creates a new Amazon S3 bucket reader.Type with
+// access to the resources of a manager.
+func NewAmazonS3WithManager(
+	conf AmazonS3Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
 ) (*AmazonS3, error) {
 	if len(conf.SQSURL) > 0 && conf.SQSBodyPath == "Records.s3.object.key" {
 		log.Warnf("It looks like a deprecated SQS Body path is configured: 'Records.s3.object.key', you might not receive S3 items unless you update to the new syntax 'Records.*.s3.object.key'")
@@ -133,15 +261,44 @@ func NewAmazonS3(
 	if conf.MaxBatchCount < 1 {
 		return nil, fmt.Errorf("max_batch_count '%v' must be > 0", conf.MaxBatchCount)
 	}
+	if len(conf.CheckpointCache) > 0 {
+		if len(conf.SQSURL) > 0 {
+			return nil, errors.New("a checkpoint cache cannot be used when consuming objects from SQS")
+		}
+		if len(conf.CheckpointKey) == 0 {
+			return nil, errors.New("a checkpoint key must be specified")
+		}
+	}
 	s := &AmazonS3{
 		conf:          conf,
 		sqsBodyPath:   conf.SQSBodyPath,
 		sqsEnvPath:    conf.SQSEnvelopePath,
 		sqsBucketPath: conf.SQSBucketPath,
+		listPrefix:    conf.Prefix,
+		listAfter:     conf.StartAfter,
+		cp:            checkpoint.New(0),
+		positions:     map[int]string{},
 		log:           log,
 		stats:         stats,
 		timeout:       timeout,
 	}
+	if len(conf.Glob) > 0 {
+		var globPrefix string
+		var err error
+		if s.glob, globPrefix, err = s3GlobToRegexp(conf.Glob); err != nil {
+			return nil, err
+		}
+		// Narrow the listing of objects down to the literal prefix of the glob.
+		if strings.HasPrefix(globPrefix, conf.Prefix) {
+			s.listPrefix = globPrefix
+		}
+	}
+	if len(conf.CheckpointCache) > 0 {
+		var err error
+		if s.cache, err = mgr.GetCache(conf.CheckpointCache); err != nil {
+			return nil, fmt.Errorf("failed to obtain cache '%v': %v", conf.CheckpointCache, err)
+		}
+	}
 	if conf.DownloadManager.Enabled {
 		s.readMethod = s.readFromMgr
 	} else {
@@ -156,6 +313,15 @@ func (a *AmazonS3) Connect() error {
 	return a.ConnectWithContext(context.Background())
 }
 
+// matchesKey returns whether an object key is within the prefix and matches the
+// glob pattern.
+func (a *AmazonS3) matchesKey(key string) bool {
+	if !strings.HasPrefix(key, a.conf.Prefix) {
+		return false
+	}
+	return a.glob == nil || a.glob.MatchString(key)
+}
+
 // ConnectWithContext attempts to establish a connection to the target S3 bucket
 // and any relevant queues used to traverse the objects (SQS, etc).
 func (a *AmazonS3) ConnectWithContext(ctx context.Context) error {
@@ -177,25 +343,17 @@ func (a *AmazonS3) ConnectWithContext(ctx context.Context) error {
 	dler := s3manager.NewDownloader(sess)
 
 	if len(a.conf.SQSURL) == 0 {
-		listInput := &s3.ListObjectsInput{
-			Bucket: aws.String(a.conf.Bucket),
-		}
-		if len(a.conf.Prefix) > 0 {
-			listInput.Prefix = aws.String(a.conf.Prefix)
-		}
-		err := sThree.ListObjectsPagesWithContext(ctx, listInput,
-			func(page *s3.ListObjectsOutput, isLastPage bool) bool {
-				for _, obj := range page.Contents {
-					a.targetKeys = append(a.targetKeys, objKey{
-						s3Key:    *obj.Key,
-						attempts: a.conf.Retries,
-					})
-				}
-				return true
-			},
-		)
-		if err != nil {
-			return fmt.Errorf("failed to list objects: %v", err)
+		if a.cache != nil {
+			data, err := a.cache.Get(a.conf.CheckpointKey)
+			if err == nil {
+				a.listAfter = string(data)
+				a.committed = a.listAfter
+			} else if err != types.ErrKeyNotFound {
+				return fmt.Errorf("failed to read checkpoint: %w", err)
+			}
+		}
+		if len(a.listAfter) > 0 {
+			a.log.Infof("Listing Amazon S3 objects after key: %s\n", a.listAfter)
 		}
 	} else {
 		sqsSess := sess.Copy()
@@ -226,6 +384,10 @@ func digStrsFromSlices(slice []interface{}) []string {
 	return strs
 }
 
+// errS3TestEvent is returned when an SQS message is a test event sent by S3
+// when a bucket notification is configured.
+var errS3TestEvent = errors.New("received S3 test event")
+
 type objTarget struct {
 	key    string
 	bucket string
@@ -261,6 +423,10 @@ func (a *AmazonS3) parseItemPaths(sqsMsg *string) ([]objTarget, error) {
 		}
 	}
 
+	if event, _ := gObj.Path("Event").Data().(string); event == "s3:TestEvent" {
+		return nil, errS3TestEvent
+	}
+
 	var buckets []string
 	switch t := gObj.Path(a.sqsBucketPath).Data().(type) {
 	case string:
@@ -273,7 +439,7 @@ func (a *AmazonS3) parseItemPaths(sqsMsg *string) ([]objTarget, error) {
 
 	switch t := gObj.Path(a.sqsBodyPath).Data().(type) {
 	case string:
-		if strings.HasPrefix(t, a.conf.Prefix) {
+		if a.matchesKey(t) {
 			bucket := ""
 			if len(buckets) > 0 {
 				bucket = buckets[0]
@@ -284,30 +450,26 @@ func (a *AmazonS3) parseItemPaths(sqsMsg *string) ([]objTarget, error) {
 			})
 		}
 	case []interface{}:
-		newTargets := []string{}
 		strs := digStrsFromSlices(t)
-		for _, p := range strs {
-			if strings.HasPrefix(p, a.conf.Prefix) {
-				newTargets = append(newTargets, p)
-			}
+		if len(strs) == 0 {
+			return nil, errors.New("no items found in SQS message at specified path")
 		}
-		if len(newTargets) > 0 {
-			for i, target := range newTargets {
-				bucket := ""
-				if len(buckets) > i {
-					bucket = buckets[i]
-				}
-				decodedTarget, err := url.QueryUnescape(target)
-				if err != nil {
-					return nil, fmt.Errorf("failed to decode S3 path: %v", err)
-				}
-				items = append(items, objTarget{
-					key:    decodedTarget,
-					bucket: bucket,
-				})
+		for i, target := range strs {
+			decodedTarget, err := url.QueryUnescape(target)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode S3 path: %v", err)
 			}
-		} else {
-			return nil, errors.New("no items found in SQS message at specified path")
+			if !a.matchesKey(decodedTarget) {
+				continue
+			}
+			bucket := ""
+			if len(buckets) > i {
+				bucket = buckets[i]
+			}
+			items = append(items, objTarget{
+				key:    decodedTarget,
+				bucket: bucket,
+			})
 		}
 	default:
 		return nil, errors.New("no items found in SQS message at specified path")
@@ -351,7 +513,7 @@ func (a *AmazonS3) deleteObjects(keys []objKey) {
 
 	deleteHandles := []*sqs.DeleteMessageBatchRequestEntry{}
 	for _, key := range keys {
-		if a.conf.DeleteObjects {
+		if a.conf.DeleteObjects && len(key.s3Key) > 0 {
 			bucket := a.conf.Bucket
 			if len(key.s3Bucket) > 0 {
 				bucket = key.s3Bucket
@@ -423,12 +585,23 @@ messageLoop:
 		}
 
 		items, err := a.parseItemPaths(sqsMsg.Body)
+		if err == errS3TestEvent {
+			a.log.Debugln("Deleting S3 test event from SQS")
+			a.deleteObjects([]objKey{{sqsHandle: msgHandle}})
+			continue messageLoop
+		}
 		if err != nil {
 			addDudFn(sqsMsg)
 			a.log.Errorf("SQS error: %v\n", err)
 			continue messageLoop
 		}
 
+		if len(items) == 0 {
+			// None of the objects are of interest and so the message is done.
+			a.deleteObjects([]objKey{{sqsHandle: msgHandle}})
+			continue messageLoop
+		}
+
 		for _, item := range items {
 			a.targetKeys = append(a.targetKeys, objKey{
 				s3Key:    item.key,
@@ -461,6 +634,118 @@ messageLoop:
 	return nil
 }
 
+// listObjects lists the next page of objects from the bucket, and adds those
+// that match to the target keys.
+func (a *AmazonS3) listObjects(ctx context.Context) error {
+	input := &s3.ListObjectsV2Input{
+		Bucket:            aws.String(a.conf.Bucket),
+		ContinuationToken: a.listToken,
+	}
+	if len(a.listPrefix) > 0 {
+		input.Prefix = aws.String(a.listPrefix)
+	}
+	if a.listToken == nil && len(a.listAfter) > 0 {
+		input.StartAfter = aws.String(a.listAfter)
+	}
+
+	page, err := a.s3.ListObjectsV2WithContext(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to list objects: %v", err)
+	}
+	if aws.BoolValue(page.IsTruncated) && page.NextContinuationToken != nil {
+		a.listToken = page.NextContinuationToken
+	} else {
+		a.listDone = true
+	}
+
+	var lastKey string
+	var lastMatched bool
+	for _, obj := range page.Contents {
+		lastKey = aws.StringValue(obj.Key)
+		if lastMatched = a.matchesKey(lastKey); lastMatched {
+			a.targetKeys = append(a.targetKeys, objKey{
+				s3Key:    lastKey,
+				attempts: a.conf.Retries,
+				index:    a.track(lastKey),
+			})
+		}
+	}
+
+	// When the final object of the page did not match it must still progress
+	// the checkpoint, otherwise the same objects are listed again on restart.
+	if len(lastKey) > 0 && !lastMatched {
+		if err := a.resolve(a.track(lastKey)); err != nil {
+			a.log.Errorf("Failed to commit checkpoint: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// populateTargetKeys attempts to obtain more target keys, either by reading
+// SQS events or by listing the next page of objects from the bucket.
+func (a *AmazonS3) populateTargetKeys(ctx context.Context) error {
+	if a.sqs != nil {
+		return a.readSQSEvents()
+	}
+	for len(a.targetKeys) == 0 {
+		if a.listDone {
+			// If we aren't using SQS but exhausted our targets we are done.
+			return types.ErrTypeClosed
+		}
+		if err := a.listObjects(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// track adds the key of a listed object that is pending acknowledgement and
+// returns its checkpoint index.
+func (a *AmazonS3) track(key string) int {
+	a.cpMut.Lock()
+	defer a.cpMut.Unlock()
+
+	a.cpIndex++
+	a.cp.MustTrack(a.cpIndex)
+	a.positions[a.cpIndex] = key
+	return a.cpIndex
+}
+
+// resolve marks a listed object as acknowledged and commits the key of the
+// highest object of which it and all prior objects have been acknowledged to
+// the checkpoint cache.
+func (a *AmazonS3) resolve(index int) error {
+	if index == 0 {
+		return nil
+	}
+
+	a.cpMut.Lock()
+	highest := a.cp.MustResolve(index)
+	for ; a.pruned < highest; a.pruned++ {
+		if key, exists := a.positions[a.pruned+1]; exists {
+			a.position = key
+			delete(a.positions, a.pruned+1)
+		}
+	}
+	key := a.position
+	a.cpMut.Unlock()
+
+	if a.cache == nil {
+		return nil
+	}
+
+	a.commitMut.Lock()
+	defer a.commitMut.Unlock()
+	if key == "" || key == a.committed {
+		return nil
+	}
+	if err := a.cache.Set(a.conf.CheckpointKey, []byte(key)); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	a.committed = key
+	return nil
+}
+
 func (a *AmazonS3) pushReadKey(key objKey) {
 	a.readKeys = append(a.readKeys, key)
 }
@@ -486,13 +771,8 @@ func (a *AmazonS3) ReadWithContext(ctx context.Context) (types.Message, AsyncAck
 	}
 
 	if len(a.targetKeys) == 0 {
-		if a.sqs != nil {
-			if err := a.readSQSEvents(); err != nil {
-				return nil, nil, err
-			}
-		} else {
-			// If we aren't using SQS but exhausted our targets we are done.
-			return nil, nil, types.ErrTypeClosed
+		if err := a.populateTargetKeys(ctx); err != nil {
+			return nil, nil, err
 		}
 	}
 	if len(a.targetKeys) == 0 {
@@ -510,14 +790,14 @@ func (a *AmazonS3) ReadWithContext(ctx context.Context) (types.Message, AsyncAck
 	return msg, func(rctx context.Context, res types.Response) error {
 		if res.Error() == nil {
 			a.deleteObjects([]objKey{obj})
+			return a.resolve(obj.index)
+		}
+		if len(a.conf.SQSURL) == 0 {
+			a.targetKeysMut.Lock()
+			a.targetKeys = append(a.targetKeys, obj)
+			a.targetKeysMut.Unlock()
 		} else {
-			if len(a.conf.SQSURL) == 0 {
-				a.targetKeysMut.Lock()
-				a.targetKeys = append(a.readKeys, obj)
-				a.targetKeysMut.Unlock()
-			} else {
-				a.rejectObjects([]objKey{obj})
-			}
+			a.rejectObjects([]objKey{obj})
 		}
 		return nil
 	}, nil
@@ -535,13 +815,8 @@ func (a *AmazonS3) Read() (types.Message, error) {
 	timeoutAt := time.Now().Add(a.timeout)
 
 	if len(a.targetKeys) == 0 {
-		if a.sqs != nil {
-			if err := a.readSQSEvents(); err != nil {
-				return nil, err
-			}
-		} else {
-			// If we aren't using SQS but exhausted our targets we are done.
-			return nil, types.ErrTypeClosed
+		if err := a.populateTargetKeys(context.Background()); err != nil {
+			return nil, err
 		}
 	}
 	if len(a.targetKeys) == 0 {
@@ -604,6 +879,9 @@ func (a *AmazonS3) read() (types.Part, objKey, error) {
 			// Remove the target file from our list.
 			a.popTargetKey()
 			a.log.Errorf("Failed to download file '%s' from bucket '%s' after '%v' attempts: %v\n", target.s3Key, bucket, a.conf.Retries, err)
+			if rerr := a.resolve(target.index); rerr != nil {
+				a.log.Errorf("Failed to commit checkpoint: %v\n", rerr)
+			}
 		} else {
 			a.targetKeys[0] = target
 			return nil, objKey{}, fmt.Errorf("failed to download file '%s' from bucket '%s': %v", target.s3Key, bucket, err)
@@ -653,6 +931,9 @@ func (a *AmazonS3) readFromMgr() (types.Part, objKey, error) {
 			// Remove the target file from our list.
 			a.popTargetKey()
 			a.log.Errorf("Failed to download file '%s' from bucket '%s' after '%v' attempts: %v\n", target.s3Key, bucket, a.conf.Retries, err)
+			if rerr := a.resolve(target.index); rerr != nil {
+				a.log.Errorf("Failed to commit checkpoint: %v\n", rerr)
+			}
 		} else {
 			a.targetKeys[0] = target
 			return nil, objKey{}, fmt.Errorf("failed to download file '%s' from bucket '%s': %v", target.s3Key, bucket, err)
@@ -674,6 +955,11 @@ func (a *AmazonS3) readFromMgr() (types.Part, objKey, error) {
 func (a *AmazonS3) Acknowledge(err error) error {
 	if err == nil {
 		a.deleteObjects(a.readKeys)
+		for _, key := range a.readKeys {
+			if rerr := a.resolve(key.index); rerr != nil {
+				a.log.Errorf("Failed to commit checkpoint: %v\n", rerr)
+			}
+		}
 	} else {
 		if a.sqs == nil {
 			a.targetKeysMut.Lock()
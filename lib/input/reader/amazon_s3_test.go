@@ -0,0 +1,265 @@
+package reader
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3GlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		prefix   string
+		matches  []string
+		excludes []string
+	}{
+		{
+			pattern:  "logs/*.json",
+			prefix:   "logs/",
+			matches:  []string{"logs/foo.json", "logs/.json"},
+			excludes: []string{"logs/foo/bar.json", "logs/foo.jsonl", "other/logs/foo.json"},
+		},
+		{
+			pattern:  "events/2020-*/**/*.gz",
+			prefix:   "events/2020-",
+			matches:  []string{"events/2020-01/b.gz", "events/2020-01/a/b.gz", "events/2020-01/a/b/c.gz"},
+			excludes: []string{"events/2020-01/b.tar", "events/2021-01/a/b.gz"},
+		},
+		{
+			pattern:  "data/{foo,bar}/?[0-9].csv",
+			prefix:   "data/",
+			matches:  []string{"data/foo/a1.csv", "data/bar/b2.csv"},
+			excludes: []string{"data/baz/a1.csv", "data/foo/ab.csv", "data/foo//1.csv"},
+		},
+		{
+			pattern:  "data/[!a]\\*.csv",
+			prefix:   "data/",
+			matches:  []string{"data/b*.csv"},
+			excludes: []string{"data/a*.csv", "data/bc.csv"},
+		},
+		{
+			pattern: "exact.txt",
+			prefix:  "exact.txt",
+			matches: []string{"exact.txt"},
+		},
+	}
+
+	for _, test := range tests {
+		re, prefix, err := s3GlobToRegexp(test.pattern)
+		require.NoError(t, err, test.pattern)
+		assert.Equal(t, test.prefix, prefix, test.pattern)
+		for _, m := range test.matches {
+			assert.True(t, re.MatchString(m), "%v: %v", test.pattern, m)
+		}
+		for _, m := range test.excludes {
+			assert.False(t, re.MatchString(m), "%v: %v", test.pattern, m)
+		}
+	}
+
+	for _, pattern := range []string{"foo[", "{foo", "foo}", "{a,{b}}"} {
+		_, _, err := s3GlobToRegexp(pattern)
+		assert.Error(t, err, pattern)
+	}
+}
+
+func TestAmazonS3ConfigErrors(t *testing.T) {
+	conf := NewAmazonS3Config()
+	conf.Bucket = "foo"
+	conf.Glob = "foo["
+	_, err := NewAmazonS3WithManager(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Glob = ""
+	conf.CheckpointCache = "foocache"
+	_, err = NewAmazonS3WithManager(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.SQSURL = "http://localhost/queue"
+	_, err = NewAmazonS3WithManager(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestAmazonS3ParseItemPaths(t *testing.T) {
+	conf := NewAmazonS3Config()
+	conf.Bucket = "foo"
+	conf.Glob = "logs/**/*.json"
+
+	a, err := NewAmazonS3WithManager(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	body := `{"Records":[
+		{"s3":{"object":{"key":"logs/a/b.json"}}},
+		{"s3":{"object":{"key":"logs/a/b.txt"}}},
+		{"s3":{"object":{"key":"logs/c+d%3D.json"}}}
+	]}`
+	items, err := a.parseItemPaths(&body)
+	require.NoError(t, err)
+	assert.Equal(t, []objTarget{
+		{key: "logs/a/b.json"},
+		{key: "logs/c d=.json"},
+	}, items)
+
+	body = `{"Service":"Amazon S3","Event":"s3:TestEvent","Time":"2020-01-01T00:00:00.000Z","Bucket":"foo"}`
+	_, err = a.parseItemPaths(&body)
+	assert.Equal(t, errS3TestEvent, err)
+}
+
+//------------------------------------------------------------------------------
+
+type fakeS3ListResult struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key string
+	}
+}
+
+// startFakeS3 serves a bucket of objects, listing at most two objects per page
+// and counting the number of objects downloaded.
+func startFakeS3(t *testing.T, objects map[string]string) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var keys []string
+	for k := range objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var downloaded []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/bucket")
+		if path == "" || path == "/" {
+			query := r.URL.Query()
+			after := query.Get("start-after")
+			if token := query.Get("continuation-token"); token != "" {
+				after = token
+			}
+			var res fakeS3ListResult
+			for _, k := range keys {
+				if k <= after || !strings.HasPrefix(k, query.Get("prefix")) {
+					continue
+				}
+				if len(res.Contents) == 2 {
+					res.IsTruncated = true
+					res.NextContinuationToken = res.Contents[1].Key
+					break
+				}
+				res.Contents = append(res.Contents, struct{ Key string }{Key: k})
+			}
+			w.Header().Set("Content-Type", "application/xml")
+			require.NoError(t, xml.NewEncoder(w).Encode(res))
+			return
+		}
+		key := strings.TrimPrefix(path, "/")
+		body, exists := objects[key]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		downloaded = append(downloaded, key)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &downloaded
+}
+
+func newFakeS3Reader(t *testing.T, server *httptest.Server, mgr types.Manager, glob string) *AmazonS3 {
+	t.Helper()
+
+	conf := NewAmazonS3Config()
+	conf.Bucket = "bucket"
+	conf.Endpoint = server.URL
+	conf.Region = "us-east-1"
+	conf.Credentials.ID = "foo"
+	conf.Credentials.Secret = "bar"
+	conf.ForcePathStyleURLs = true
+	conf.DownloadManager.Enabled = false
+	conf.Glob = glob
+	conf.CheckpointCache = "foocache"
+
+	a, err := NewAmazonS3WithManager(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, a.ConnectWithContext(context.Background()))
+	return a
+}
+
+func TestAmazonS3ListCheckpoint(t *testing.T) {
+	server, downloaded := startFakeS3(t, map[string]string{
+		"a/1.json": "foo",
+		"a/2.txt":  "nope",
+		"a/3.json": "bar",
+		"a/4.json": "baz",
+		"a/5.txt":  "nope",
+		"a/6.txt":  "nope",
+		"b/7.json": "nope",
+	})
+
+	memCache, err := cache.NewMemory(cache.NewConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	mgr := &fakeCacheMgr{caches: map[string]types.Cache{"foocache": memCache}}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	a := newFakeS3Reader(t, server, mgr, "a/*.json")
+
+	var ackFns []AsyncAckFn
+	for _, exp := range []string{"foo", "bar"} {
+		msg, ackFn, err := a.ReadWithContext(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(msg.Get(0).Get()))
+		ackFns = append(ackFns, ackFn)
+	}
+
+	// Only the keys of objects of which all prior objects are acknowledged are
+	// committed.
+	require.NoError(t, ackFns[1](ctx, response.NewAck()))
+	_, err = memCache.Get("s3")
+	assert.Equal(t, types.ErrKeyNotFound, err)
+
+	require.NoError(t, ackFns[0](ctx, response.NewAck()))
+	data, err := memCache.Get("s3")
+	require.NoError(t, err)
+	assert.Equal(t, "a/3.json", string(data))
+
+	// Restarting resumes the listing after the checkpoint.
+	a = newFakeS3Reader(t, server, mgr, "a/*.json")
+
+	msg, ackFn, err := a.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "baz", string(msg.Get(0).Get()))
+
+	// Rejected objects are consumed again.
+	require.NoError(t, ackFn(ctx, response.NewError(errors.New("nope"))))
+	msg, ackFn, err = a.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "baz", string(msg.Get(0).Get()))
+	require.NoError(t, ackFn(ctx, response.NewAck()))
+
+	_, _, err = a.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrTypeClosed, err)
+
+	// The final page of unmatched objects also progresses the checkpoint.
+	data, err = memCache.Get("s3")
+	require.NoError(t, err)
+	assert.Equal(t, "a/6.txt", string(data))
+
+	assert.Equal(t, []string{"a/1.json", "a/3.json", "a/4.json", "a/4.json"}, *downloaded)
+}
@@ -2,12 +2,15 @@ package reader
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/aws/aws-sdk-go/aws"
@@ -26,6 +29,7 @@ type AmazonSQSConfig struct {
 	Timeout             string `json:"timeout" yaml:"timeout"`
 	MaxNumberOfMessages int64  `json:"max_number_of_messages" yaml:"max_number_of_messages"`
 	DeleteMessage       bool   `json:"delete_message" yaml:"delete_message"`
+	VisibilityTimeout   string `json:"visibility_timeout" yaml:"visibility_timeout"`
 }
 
 // NewAmazonSQSConfig creates a new Config with default values.
@@ -36,11 +40,31 @@ func NewAmazonSQSConfig() AmazonSQSConfig {
 		Timeout:             "5s",
 		MaxNumberOfMessages: 1,
 		DeleteMessage:       true,
+		VisibilityTimeout:   "",
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// The maximum visibility timeout of an SQS message in seconds.
+const sqsMaxVisibilityTimeout = 43200
+
+// sqsRedeliveryVisibility returns the visibility timeout in seconds of
+// messages that failed with an error. Messages are made visible again
+// immediately unless the error requests a redelivery delay, in which case the
+// visibility timeout is the delay rounded up to the nearest second.
+func sqsRedeliveryVisibility(err error) int64 {
+	delay, ok := response.RedeliveryDelay(err)
+	if !ok || delay <= 0 {
+		return 0
+	}
+	visibility := int64((delay + time.Second - 1) / time.Second)
+	if visibility > sqsMaxVisibilityTimeout {
+		visibility = sqsMaxVisibilityTimeout
+	}
+	return visibility
+}
+
 // AmazonSQS is a benthos reader.Type implementation that reads messages from an
 // Amazon SQS queue.
 type AmazonSQS struct {
@@ -52,6 +76,11 @@ type AmazonSQS struct {
 	sqs     *sqs.SQS
 	timeout time.Duration
 
+	visibilityTimeout time.Duration
+
+	closer    sync.Once
+	closeChan chan struct{}
+
 	log   log.Modular
 	stats metrics.Type
 }
@@ -69,12 +98,24 @@ func NewAmazonSQS(
 			return nil, fmt.Errorf("failed to parse timeout string: %v", err)
 		}
 	}
+	var visibilityTimeout time.Duration
+	if tout := conf.VisibilityTimeout; len(tout) > 0 {
+		var err error
+		if visibilityTimeout, err = time.ParseDuration(tout); err != nil {
+			return nil, fmt.Errorf("failed to parse visibility timeout string: %v", err)
+		}
+		if visibilityTimeout < time.Second {
+			return nil, errors.New("visibility timeout must be at least one second")
+		}
+	}
 	return &AmazonSQS{
-		conf:           conf,
-		log:            log,
-		stats:          stats,
-		timeout:        timeout,
-		pendingHandles: map[string]string{},
+		conf:              conf,
+		log:               log,
+		stats:             stats,
+		timeout:           timeout,
+		visibilityTimeout: visibilityTimeout,
+		pendingHandles:    map[string]string{},
+		closeChan:         make(chan struct{}),
 	}, nil
 }
 
@@ -109,6 +150,15 @@ func addSQSMetadata(p types.Part, sqsMsg *sqs.Message) {
 	if rCountStr := sqsMsg.Attributes["ApproximateReceiveCount"]; rCountStr != nil {
 		meta.Set("sqs_approximate_receive_count", *rCountStr)
 	}
+	if groupID := sqsMsg.Attributes["MessageGroupId"]; groupID != nil {
+		meta.Set("sqs_message_group_id", *groupID)
+	}
+	if dedupeID := sqsMsg.Attributes["MessageDeduplicationId"]; dedupeID != nil {
+		meta.Set("sqs_message_deduplication_id", *dedupeID)
+	}
+	if seq := sqsMsg.Attributes["SequenceNumber"]; seq != nil {
+		meta.Set("sqs_sequence_number", *seq)
+	}
 	for k, v := range sqsMsg.MessageAttributes {
 		if v.StringValue != nil {
 			meta.Set(k, *v.StringValue)
@@ -125,13 +175,17 @@ func (a *AmazonSQS) ReadWithContext(ctx context.Context) (types.Message, AsyncAc
 	msg := message.New(nil)
 	pendingHandles := map[string]string{}
 
-	output, err := a.sqs.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+	input := &sqs.ReceiveMessageInput{
 		QueueUrl:              aws.String(a.conf.URL),
 		MaxNumberOfMessages:   aws.Int64(a.conf.MaxNumberOfMessages),
 		WaitTimeSeconds:       aws.Int64(int64(a.timeout.Seconds())),
 		AttributeNames:        []*string{aws.String("All")},
 		MessageAttributeNames: []*string{aws.String("All")},
-	})
+	}
+	if a.visibilityTimeout > 0 {
+		input.VisibilityTimeout = aws.Int64(int64(a.visibilityTimeout.Seconds()))
+	}
+	output, err := a.sqs.ReceiveMessageWithContext(ctx, input)
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == request.CanceledErrorCode {
 			return nil, nil, types.ErrTimeout
@@ -153,7 +207,14 @@ func (a *AmazonSQS) ReadWithContext(ctx context.Context) (types.Message, AsyncAc
 		return nil, nil, types.ErrTimeout
 	}
 
+	stopHeartbeat := func() {}
+	if a.visibilityTimeout > 0 {
+		stopHeartbeat = a.startVisibilityHeartbeat(pendingHandles)
+	}
+
 	return msg, func(rctx context.Context, res types.Response) error {
+		stopHeartbeat()
+
 		// TODO: Replace this with a background process for batching these
 		// requests up more.
 		if res.Error() == nil {
@@ -187,6 +248,7 @@ func (a *AmazonSQS) ReadWithContext(ctx context.Context) (types.Message, AsyncAc
 				}
 			}
 		} else {
+			visibility := sqsRedeliveryVisibility(res.Error())
 			for len(pendingHandles) > 0 {
 				input := sqs.ChangeMessageVisibilityBatchInput{
 					QueueUrl: aws.String(a.conf.URL),
@@ -197,7 +259,7 @@ func (a *AmazonSQS) ReadWithContext(ctx context.Context) (types.Message, AsyncAc
 					input.Entries = append(input.Entries, &sqs.ChangeMessageVisibilityBatchRequestEntry{
 						Id:                aws.String(k),
 						ReceiptHandle:     aws.String(v),
-						VisibilityTimeout: aws.Int64(0),
+						VisibilityTimeout: aws.Int64(visibility),
 					})
 					delete(pendingHandles, k)
 					if len(input.Entries) == 10 {
@@ -300,6 +362,7 @@ func (a *AmazonSQS) Acknowledge(err error) error {
 			}
 		}
 	} else {
+		visibility := sqsRedeliveryVisibility(err)
 		for len(a.pendingHandles) > 0 {
 			input := sqs.ChangeMessageVisibilityBatchInput{
 				QueueUrl: aws.String(a.conf.URL),
@@ -310,7 +373,7 @@ func (a *AmazonSQS) Acknowledge(err error) error {
 				input.Entries = append(input.Entries, &sqs.ChangeMessageVisibilityBatchRequestEntry{
 					Id:                aws.String(k),
 					ReceiptHandle:     aws.String(v),
-					VisibilityTimeout: aws.Int64(0),
+					VisibilityTimeout: aws.Int64(visibility),
 				})
 				delete(a.pendingHandles, k)
 				if len(input.Entries) == 10 {
@@ -330,8 +393,88 @@ func (a *AmazonSQS) Acknowledge(err error) error {
 	return nil
 }
 
+// startVisibilityHeartbeat periodically extends the visibility timeout of a
+// set of received messages until the returned func is called, which blocks
+// until the heartbeat has stopped.
+func (a *AmazonSQS) startVisibilityHeartbeat(pendingHandles map[string]string) func() {
+	handles := make(map[string]string, len(pendingHandles))
+	for k, v := range pendingHandles {
+		handles[k] = v
+	}
+
+	stopChan, stoppedChan := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stoppedChan)
+
+		ticker := time.NewTicker(a.visibilityTimeout / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-stopChan:
+				return
+			case <-a.closeChan:
+				return
+			}
+
+			ctx, done := context.WithTimeout(context.Background(), a.visibilityTimeout/2)
+			err := a.extendVisibility(ctx, handles)
+			done()
+			if err != nil {
+				a.log.Errorf("Failed to extend consumed SQS message visibility: %v\n", err)
+			}
+		}
+	}()
+
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			close(stopChan)
+			<-stoppedChan
+		})
+	}
+}
+
+func (a *AmazonSQS) extendVisibility(ctx context.Context, handles map[string]string) error {
+	input := sqs.ChangeMessageVisibilityBatchInput{
+		QueueUrl: aws.String(a.conf.URL),
+	}
+	flush := func() error {
+		response, err := a.sqs.ChangeMessageVisibilityBatchWithContext(ctx, &input)
+		if err != nil {
+			return err
+		}
+		for _, fail := range response.Failed {
+			a.log.Errorf("Failed to extend consumed SQS message '%v' visibility, response code: %v\n", *fail.Id, *fail.Code)
+		}
+		input.Entries = nil
+		return nil
+	}
+
+	for k, v := range handles {
+		input.Entries = append(input.Entries, &sqs.ChangeMessageVisibilityBatchRequestEntry{
+			Id:                aws.String(k),
+			ReceiptHandle:     aws.String(v),
+			VisibilityTimeout: aws.Int64(int64(a.visibilityTimeout.Seconds())),
+		})
+		if len(input.Entries) == 10 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(input.Entries) > 0 {
+		return flush()
+	}
+	return nil
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (a *AmazonSQS) CloseAsync() {
+	a.closer.Do(func() {
+		close(a.closeChan)
+	})
 }
 
 // WaitForClose will block until either the reader is closed or a specified
@@ -0,0 +1,45 @@
+package reader
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmazonSQSRedeliveryVisibility(t *testing.T) {
+	tests := map[string]struct {
+		err error
+		exp int64
+	}{
+		"no delay": {
+			err: errors.New("nope"),
+			exp: 0,
+		},
+		"zero delay": {
+			err: response.NewDelayedError(errors.New("nope"), 0),
+			exp: 0,
+		},
+		"whole seconds": {
+			err: response.NewDelayedError(errors.New("nope"), time.Minute),
+			exp: 60,
+		},
+		"rounded up": {
+			err: response.NewDelayedError(errors.New("nope"), 1500*time.Millisecond),
+			exp: 2,
+		},
+		"capped": {
+			err: response.NewDelayedError(errors.New("nope"), time.Hour*24),
+			exp: 43200,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.exp, sqsRedeliveryVisibility(test.err))
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 	"github.com/streadway/amqp"
@@ -270,7 +271,15 @@ func (a *AMQP09) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn
 		}
 		addPart(data)
 		return msg, func(actx context.Context, res types.Response) error {
-			if res.Error() != nil {
+			if err := res.Error(); err != nil {
+				// When a delay before redelivery is requested the message is
+				// held until the delay has passed before it's requeued.
+				if delay, ok := response.RedeliveryDelay(err); ok && delay > 0 {
+					select {
+					case <-time.After(delay):
+					case <-actx.Done():
+					}
+				}
 				return data.Nack(false, true)
 			}
 			return data.Ack(false)
@@ -0,0 +1,108 @@
+package reader
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+type fakeAcknowledger struct {
+	mut     sync.Mutex
+	acked   bool
+	nacked  bool
+	requeue bool
+	at      time.Time
+}
+
+func (f *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.acked, f.at = true, time.Now()
+	return nil
+}
+
+func (f *fakeAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.nacked, f.requeue, f.at = true, requeue, time.Now()
+	return nil
+}
+
+func (f *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return f.Nack(tag, false, requeue)
+}
+
+func TestAMQP09RejectRedeliveryDelay(t *testing.T) {
+	outConf := output.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+type: reject
+reject:
+  message: 'nope: ${! content() }'
+  redelivery_delay: 200ms
+`), &outConf))
+
+	out, err := output.New(outConf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, out.Consume(tChan))
+	defer func() {
+		out.CloseAsync()
+		assert.NoError(t, out.WaitForClose(time.Second))
+	}()
+
+	deliveries := make(chan amqp.Delivery, 1)
+	acker := &fakeAcknowledger{}
+	deliveries <- amqp.Delivery{
+		Acknowledger: acker,
+		DeliveryTag:  1,
+		Body:         []byte("hello world"),
+	}
+
+	in, err := NewAMQP09(NewAMQP09Config(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	in.conn = &amqp.Connection{}
+	in.consumerChan = deliveries
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, ackFn, err := in.ReadWithContext(ctx)
+	require.NoError(t, err)
+
+	resChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(msg, resChan):
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	var res types.Response
+	select {
+	case res = <-resChan:
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+	require.EqualError(t, res.Error(), "nope: hello world")
+	assert.Equal(t, int64(1), sqsRedeliveryVisibility(res.Error()))
+
+	start := time.Now()
+	require.NoError(t, ackFn(ctx, res))
+
+	acker.mut.Lock()
+	defer acker.mut.Unlock()
+	assert.False(t, acker.acked)
+	assert.True(t, acker.nacked)
+	assert.True(t, acker.requeue)
+	assert.GreaterOrEqual(t, int64(acker.at.Sub(start)), int64(time.Millisecond*200))
+}
@@ -3,6 +3,7 @@ package reader
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"sync"
 	"time"
 
@@ -10,7 +11,9 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/amqp/link"
 	"github.com/Jeffail/benthos/v3/lib/util/amqp/sasl"
 	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 )
@@ -19,19 +22,33 @@ import (
 
 // AMQP1Config contains configuration for the AMQP1 input type.
 type AMQP1Config struct {
-	URL           string      `json:"url" yaml:"url"`
-	SourceAddress string      `json:"source_address" yaml:"source_address"`
-	TLS           btls.Config `json:"tls" yaml:"tls"`
-	SASL          sasl.Config `json:"sasl" yaml:"sasl"`
+	URL                string      `json:"url" yaml:"url"`
+	SourceAddress      string      `json:"source_address" yaml:"source_address"`
+	ContainerID        string      `json:"container_id" yaml:"container_id"`
+	LinkName           string      `json:"link_name" yaml:"link_name"`
+	Credit             int         `json:"credit" yaml:"credit"`
+	SenderSettleMode   string      `json:"sender_settle_mode" yaml:"sender_settle_mode"`
+	ReceiverSettleMode string      `json:"receiver_settle_mode" yaml:"receiver_settle_mode"`
+	Durability         string      `json:"durability" yaml:"durability"`
+	ExpiryPolicy       string      `json:"expiry_policy" yaml:"expiry_policy"`
+	TLS                btls.Config `json:"tls" yaml:"tls"`
+	SASL               sasl.Config `json:"sasl" yaml:"sasl"`
 }
 
 // NewAMQP1Config creates a new AMQP1Config with default values.
 func NewAMQP1Config() AMQP1Config {
 	return AMQP1Config{
-		URL:           "",
-		SourceAddress: "",
-		TLS:           btls.NewConfig(),
-		SASL:          sasl.NewConfig(),
+		URL:                "",
+		SourceAddress:      "",
+		ContainerID:        "",
+		LinkName:           "",
+		Credit:             10,
+		SenderSettleMode:   "",
+		ReceiverSettleMode: "",
+		Durability:         "none",
+		ExpiryPolicy:       "session-end",
+		TLS:                btls.NewConfig(),
+		SASL:               sasl.NewConfig(),
 	}
 }
 
@@ -43,7 +60,8 @@ type AMQP1 struct {
 	session  *amqp.Session
 	receiver *amqp.Receiver
 
-	tlsConf *tls.Config
+	tlsConf  *tls.Config
+	linkOpts []amqp.LinkOption
 
 	conf  AMQP1Config
 	stats metrics.Type
@@ -59,8 +77,30 @@ func NewAMQP1(conf AMQP1Config, log log.Modular, stats metrics.Type) (*AMQP1, er
 		stats: stats,
 		log:   log,
 	}
+	if _, err := conf.SASL.ToOptFns(); err != nil {
+		return nil, err
+	}
+	if conf.Credit < 1 {
+		return nil, errors.New("credit must be at least 1")
+	}
+	var err error
+	if a.linkOpts, err = link.SettleModes(conf.SenderSettleMode, conf.ReceiverSettleMode); err != nil {
+		return nil, err
+	}
+	durability, expiryPolicy, err := link.Terminus(conf.Durability, conf.ExpiryPolicy)
+	if err != nil {
+		return nil, err
+	}
+	a.linkOpts = append(a.linkOpts,
+		amqp.LinkSourceAddress(conf.SourceAddress),
+		amqp.LinkCredit(uint32(conf.Credit)),
+		amqp.LinkSourceDurability(durability),
+		amqp.LinkSourceExpiryPolicy(expiryPolicy),
+	)
+	if len(conf.LinkName) > 0 {
+		a.linkOpts = append(a.linkOpts, amqp.LinkName(conf.LinkName))
+	}
 	if conf.TLS.Enabled {
-		var err error
 		if a.tlsConf, err = conf.TLS.Get(); err != nil {
 			return nil, err
 		}
@@ -86,17 +126,13 @@ func (a *AMQP1) ConnectWithContext(ctx context.Context) error {
 		err      error
 	)
 
-	opts, err := a.conf.SASL.ToOptFns()
-	if err != nil {
-		return err
-	}
-	if a.conf.TLS.Enabled {
-		opts = append(opts, amqp.ConnTLS(true))
-		opts = append(opts, amqp.ConnTLSConfig(a.tlsConf))
+	var opts []amqp.ConnOption
+	if len(a.conf.ContainerID) > 0 {
+		opts = append(opts, amqp.ConnContainerID(a.conf.ContainerID))
 	}
 
 	// Create client
-	if client, err = amqp.Dial(a.conf.URL, opts...); err != nil {
+	if client, err = a.conf.SASL.Dial(a.conf.URL, a.tlsConf, opts...); err != nil {
 		return err
 	}
 
@@ -107,10 +143,7 @@ func (a *AMQP1) ConnectWithContext(ctx context.Context) error {
 	}
 
 	// Create a receiver
-	if receiver, err = session.NewReceiver(
-		amqp.LinkSourceAddress(a.conf.SourceAddress),
-		amqp.LinkCredit(10),
-	); err != nil {
+	if receiver, err = session.NewReceiver(a.linkOpts...); err != nil {
 		session.Close(context.Background())
 		client.Close()
 		return err
@@ -194,7 +227,15 @@ func (a *AMQP1) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn,
 	msg.Append(part)
 
 	return msg, func(ctx context.Context, res types.Response) error {
-		if res.Error() != nil {
+		if err := res.Error(); err != nil {
+			// When a delay before redelivery is requested the message is held
+			// until the delay has passed before it's released.
+			if delay, ok := response.RedeliveryDelay(err); ok && delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+				}
+			}
 			return amqpMsg.Modify(ctx, true, false, amqpMsg.Annotations)
 		}
 		return amqpMsg.Accept(ctx)
@@ -0,0 +1,387 @@
+// +build !wasm
+
+package reader
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/azure-storage-queue-go/azqueue"
+	"github.com/Jeffail/benthos/v3/internal/codec"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// azureBlobTarget is a blob to be consumed along with a function to call once
+// all of its messages have been acknowledged, or when it is not consumed.
+type azureBlobTarget struct {
+	container string
+	name      string
+	ackFn     func(ctx context.Context, err error)
+}
+
+// azureBlobReading is a blob that is being consumed with a codec.
+type azureBlobReading struct {
+	target   azureBlobTarget
+	download *azblob.DownloadResponse
+	reader   codec.Reader
+}
+
+type azureBlobEvent struct {
+	EventType string `json:"eventType"`
+	Type      string `json:"type"`
+	Subject   string `json:"subject"`
+}
+
+// azureBlobEventTarget returns the blob referenced by an Event Grid event that
+// has been delivered to a storage queue, or false if the event is not of a new
+// blob. The events can either be a single event or an array of events, and
+// either plain JSON or base64 encoded JSON.
+func azureBlobEventTarget(text string) (container, name string, ok bool) {
+	body := []byte(text)
+	if decoded, err := base64.StdEncoding.DecodeString(text); err == nil {
+		body = decoded
+	}
+
+	var event azureBlobEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		var events []azureBlobEvent
+		if err := json.Unmarshal(body, &events); err != nil || len(events) != 1 {
+			return "", "", false
+		}
+		event = events[0]
+	}
+
+	if eventType := event.EventType + event.Type; eventType != "Microsoft.Storage.BlobCreated" {
+		return "", "", false
+	}
+	subject := strings.TrimPrefix(event.Subject, "/blobServices/default/containers/")
+	if len(subject) == len(event.Subject) {
+		return "", "", false
+	}
+	if i := strings.Index(subject, "/blobs/"); i > 0 {
+		return subject[:i], subject[i+len("/blobs/"):], true
+	}
+	return "", "", false
+}
+
+//------------------------------------------------------------------------------
+
+// AzureBlobStorage is a benthos reader.Async implementation that consumes
+// blobs from an Azure Blob Storage container, either by listing the container
+// or by consuming blob created events from an Azure Storage Queue.
+type AzureBlobStorage struct {
+	conf      AzureBlobStorageConfig
+	codecCtor codec.ReaderConstructor
+
+	timeout           time.Duration
+	visibilityTimeout time.Duration
+
+	service  azblob.ServiceURL
+	messages *azqueue.MessagesURL
+
+	// Accessed only by ReadWithContext.
+	marker  azblob.Marker
+	pending []azureBlobTarget
+	current *azureBlobReading
+
+	mut    sync.Mutex
+	closed bool
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewAzureBlobStorage creates a new Azure Blob Storage input type.
+func NewAzureBlobStorage(conf AzureBlobStorageConfig, log log.Modular, stats metrics.Type) (*AzureBlobStorage, error) {
+	if len(conf.StorageAccount) == 0 {
+		return nil, errors.New("invalid azure storage account")
+	}
+	if len(conf.Container) == 0 && len(conf.Queue.Name) == 0 {
+		return nil, errors.New("either a container or a queue must be specified")
+	}
+
+	a := &AzureBlobStorage{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+
+	var err error
+	if a.codecCtor, err = codec.GetReader(conf.Codec, codec.ReaderConfig{
+		MaxScanTokenSize: conf.MaxBuffer,
+	}); err != nil {
+		return nil, err
+	}
+	if tout := conf.Timeout; len(tout) > 0 {
+		if a.timeout, err = time.ParseDuration(tout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+		}
+	}
+	if len(conf.Queue.Name) > 0 {
+		if a.visibilityTimeout, err = time.ParseDuration(conf.Queue.VisibilityTimeout); err != nil {
+			return nil, fmt.Errorf("failed to parse queue visibility timeout: %v", err)
+		}
+	}
+
+	var blobCredential azblob.Credential
+	var queueCredential azqueue.Credential
+	if len(conf.StorageAccessKey) == 0 {
+		blobCredential = azblob.NewAnonymousCredential()
+		queueCredential = azqueue.NewAnonymousCredential()
+	} else {
+		if blobCredential, err = azblob.NewSharedKeyCredential(conf.StorageAccount, conf.StorageAccessKey); err != nil {
+			return nil, fmt.Errorf("invalid azure storage account credentials: %v", err)
+		}
+		if queueCredential, err = azqueue.NewSharedKeyCredential(conf.StorageAccount, conf.StorageAccessKey); err != nil {
+			return nil, fmt.Errorf("invalid azure storage account credentials: %v", err)
+		}
+	}
+
+	blobURL, _ := url.Parse(fmt.Sprintf("https://%s.blob.core.windows.net", conf.StorageAccount))
+	a.service = azblob.NewServiceURL(*blobURL, azblob.NewPipeline(blobCredential, azblob.PipelineOptions{}))
+	if len(conf.Queue.Name) > 0 {
+		queueURL, _ := url.Parse(fmt.Sprintf("https://%s.queue.core.windows.net/%s", conf.StorageAccount, conf.Queue.Name))
+		messages := azqueue.NewQueueURL(*queueURL, azqueue.NewPipeline(queueCredential, azqueue.PipelineOptions{})).NewMessagesURL()
+		a.messages = &messages
+	}
+	return a, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext attempts to establish a connection to the target storage
+// account.
+func (a *AzureBlobStorage) ConnectWithContext(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if a.closed {
+		return types.ErrTypeClosed
+	}
+	if a.messages != nil {
+		a.log.Infof("Downloading Azure Blob Storage blobs from events of queue: %v\n", a.conf.Queue.Name)
+	} else {
+		a.log.Infof("Downloading Azure Blob Storage blobs from container: %v\n", a.conf.Container)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// listTargets queues the next page of blobs from the listing of the container.
+func (a *AzureBlobStorage) listTargets(ctx context.Context) error {
+	if !a.marker.NotDone() {
+		return types.ErrTypeClosed
+	}
+
+	listCtx, done := context.WithTimeout(ctx, a.timeout)
+	defer done()
+
+	res, err := a.service.NewContainerURL(a.conf.Container).ListBlobsFlatSegment(listCtx, a.marker, azblob.ListBlobsSegmentOptions{
+		Prefix: a.conf.Prefix,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list blobs: %v", err)
+	}
+	for _, item := range res.Segment.BlobItems {
+		a.pending = append(a.pending, azureBlobTarget{
+			container: a.conf.Container,
+			name:      item.Name,
+			ackFn:     func(context.Context, error) {},
+		})
+	}
+	a.marker = res.NextMarker
+	return nil
+}
+
+// dequeueTargets queues the blobs referenced by the next messages of the
+// storage queue.
+func (a *AzureBlobStorage) dequeueTargets(ctx context.Context) error {
+	dequeueCtx, done := context.WithTimeout(ctx, a.timeout)
+	defer done()
+
+	res, err := a.messages.Dequeue(dequeueCtx, azqueue.QueueMaxMessagesDequeue, a.visibilityTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to dequeue messages: %v", err)
+	}
+	for i := int32(0); i < res.NumMessages(); i++ {
+		msg := res.Message(i)
+		msgURL := a.messages.NewMessageIDURL(msg.ID)
+
+		container, name, ok := azureBlobEventTarget(msg.Text)
+		if !ok || (len(a.conf.Container) > 0 && container != a.conf.Container) {
+			if _, err := msgURL.Delete(ctx, msg.PopReceipt); err != nil {
+				a.log.Errorf("Failed to delete queue message: %v\n", err)
+			}
+			continue
+		}
+
+		a.pending = append(a.pending, azureBlobTarget{
+			container: container,
+			name:      name,
+			ackFn: func(ctx context.Context, err error) {
+				if err != nil {
+					// Make the message visible again so that it is redelivered.
+					_, err = msgURL.Update(ctx, msg.PopReceipt, 0, msg.Text)
+				} else {
+					_, err = msgURL.Delete(ctx, msg.PopReceipt)
+				}
+				if err != nil {
+					a.log.Errorf("Failed to acknowledge queue message: %v\n", err)
+				}
+			},
+		})
+	}
+	if len(a.pending) == 0 {
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+		}
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+// blobAckFn returns a function that is called once all messages of a blob are
+// acknowledged, which deletes the blob if configured to do so.
+func (a *AzureBlobStorage) blobAckFn(target azureBlobTarget) codec.ReaderAckFn {
+	return func(ctx context.Context, err error) error {
+		if err == nil && a.conf.DeleteObjects {
+			blobURL := a.service.NewContainerURL(target.container).NewBlobURL(target.name)
+			if _, err = blobURL.Delete(ctx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{}); err != nil {
+				if serr, ok := err.(azblob.StorageError); !ok || serr.ServiceCode() != azblob.ServiceCodeBlobNotFound {
+					target.ackFn(ctx, err)
+					return fmt.Errorf("failed to delete blob %v: %v", target.name, err)
+				}
+				err = nil
+			}
+		}
+		target.ackFn(ctx, err)
+		return nil
+	}
+}
+
+// openNext opens the next pending blob, listing the container or dequeuing
+// messages when there are none pending.
+func (a *AzureBlobStorage) openNext(ctx context.Context) error {
+	for {
+		for len(a.pending) == 0 {
+			var err error
+			if a.messages != nil {
+				err = a.dequeueTargets(ctx)
+			} else {
+				err = a.listTargets(ctx)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		target := a.pending[0]
+		blobURL := a.service.NewContainerURL(target.container).NewBlobURL(target.name)
+
+		downloadCtx, done := context.WithTimeout(ctx, a.timeout)
+		download, err := blobURL.Download(downloadCtx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false)
+		done()
+		if err != nil {
+			if serr, ok := err.(azblob.StorageError); ok && serr.ServiceCode() == azblob.ServiceCodeBlobNotFound {
+				a.pending = a.pending[1:]
+				target.ackFn(ctx, nil)
+				continue
+			}
+			return fmt.Errorf("failed to download blob %v: %v", target.name, err)
+		}
+		a.pending = a.pending[1:]
+
+		body := download.Body(azblob.RetryReaderOptions{MaxRetryRequests: 3})
+		r, err := a.codecCtor(body, a.blobAckFn(target))
+		if err != nil {
+			a.log.Errorf("Failed to read blob %v: %v\n", target.name, err)
+			target.ackFn(ctx, nil)
+			continue
+		}
+		a.current = &azureBlobReading{target: target, download: download, reader: r}
+		return nil
+	}
+}
+
+// ReadWithContext attempts to read a new message from the blobs of the target
+// container.
+func (a *AzureBlobStorage) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	a.mut.Lock()
+	closed := a.closed
+	a.mut.Unlock()
+	if closed {
+		return nil, nil, types.ErrTypeClosed
+	}
+
+	for {
+		if a.current == nil {
+			if err := a.openNext(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		parts, codecAckFn, err := a.current.reader.Next(ctx)
+		if err != nil {
+			target := a.current.target
+			a.current.reader.Close(ctx)
+			a.current = nil
+			if err != io.EOF {
+				a.log.Errorf("Failed to read blob %v: %v\n", target.name, err)
+			}
+			continue
+		}
+
+		download := a.current.download
+		msg := message.New(nil)
+		for _, p := range parts {
+			meta := p.Metadata()
+			for k, v := range download.NewMetadata() {
+				meta.Set(k, v)
+			}
+			meta.Set("blob_storage_key", a.current.target.name).
+				Set("blob_storage_container", a.current.target.container).
+				Set("blob_storage_last_modified", download.LastModified().Format(time.RFC3339)).
+				Set("blob_storage_last_modified_unix", strconv.FormatInt(download.LastModified().Unix(), 10)).
+				Set("blob_storage_content_type", download.ContentType())
+			if ce := download.ContentEncoding(); len(ce) > 0 {
+				meta.Set("blob_storage_content_encoding", ce)
+			}
+			msg.Append(p)
+		}
+		return msg, func(ctx context.Context, res types.Response) error {
+			return codecAckFn(ctx, res.Error())
+		}, nil
+	}
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (a *AzureBlobStorage) CloseAsync() {
+	a.mut.Lock()
+	a.closed = true
+	a.mut.Unlock()
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (a *AzureBlobStorage) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
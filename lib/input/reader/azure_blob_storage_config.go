@@ -0,0 +1,45 @@
+package reader
+
+//------------------------------------------------------------------------------
+
+// AzureBlobStorageQueueConfig contains configuration fields for consuming blob
+// created events from an Azure Storage Queue.
+type AzureBlobStorageQueueConfig struct {
+	Name              string `json:"name" yaml:"name"`
+	VisibilityTimeout string `json:"visibility_timeout" yaml:"visibility_timeout"`
+}
+
+// AzureBlobStorageConfig contains configuration fields for the Azure Blob
+// Storage input type.
+type AzureBlobStorageConfig struct {
+	StorageAccount   string                      `json:"storage_account" yaml:"storage_account"`
+	StorageAccessKey string                      `json:"storage_access_key" yaml:"storage_access_key"`
+	Container        string                      `json:"container" yaml:"container"`
+	Prefix           string                      `json:"prefix" yaml:"prefix"`
+	Codec            string                      `json:"codec" yaml:"codec"`
+	MaxBuffer        int                         `json:"max_buffer" yaml:"max_buffer"`
+	DeleteObjects    bool                        `json:"delete_objects" yaml:"delete_objects"`
+	Queue            AzureBlobStorageQueueConfig `json:"queue" yaml:"queue"`
+	Timeout          string                      `json:"timeout" yaml:"timeout"`
+}
+
+// NewAzureBlobStorageConfig creates a new AzureBlobStorageConfig with default
+// values.
+func NewAzureBlobStorageConfig() AzureBlobStorageConfig {
+	return AzureBlobStorageConfig{
+		StorageAccount:   "",
+		StorageAccessKey: "",
+		Container:        "",
+		Prefix:           "",
+		Codec:            "all-bytes",
+		MaxBuffer:        1000000,
+		DeleteObjects:    false,
+		Queue: AzureBlobStorageQueueConfig{
+			Name:              "",
+			VisibilityTimeout: "30s",
+		},
+		Timeout: "5s",
+	}
+}
+
+//------------------------------------------------------------------------------
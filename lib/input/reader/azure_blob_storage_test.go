@@ -0,0 +1,108 @@
+// +build !wasm
+
+package reader
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAzureBlobStorageConfigErrors(t *testing.T) {
+	conf := NewAzureBlobStorageConfig()
+	conf.Container = "foo"
+	_, err := NewAzureBlobStorage(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.StorageAccount = "bar"
+	conf.Container = ""
+	_, err = NewAzureBlobStorage(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Queue.Name = "baz"
+	conf.Queue.VisibilityTimeout = "nope"
+	_, err = NewAzureBlobStorage(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Queue.VisibilityTimeout = "1m"
+	conf.StorageAccessKey = "not base64"
+	_, err = NewAzureBlobStorage(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.StorageAccessKey = base64.StdEncoding.EncodeToString([]byte("key"))
+	_, err = NewAzureBlobStorage(conf, log.Noop(), metrics.Noop())
+	assert.NoError(t, err)
+}
+
+func TestAzureBlobStorageEventTarget(t *testing.T) {
+	created := `{
+  "topic": "/subscriptions/foo/resourceGroups/bar/providers/Microsoft.Storage/storageAccounts/baz",
+  "subject": "/blobServices/default/containers/things/blobs/foo/bar.json",
+  "eventType": "Microsoft.Storage.BlobCreated",
+  "data": {"url": "https://baz.blob.core.windows.net/things/foo/bar.json"}
+}`
+
+	tests := []struct {
+		name      string
+		text      string
+		container string
+		blob      string
+		ok        bool
+	}{
+		{
+			name:      "event grid schema",
+			text:      created,
+			container: "things",
+			blob:      "foo/bar.json",
+			ok:        true,
+		},
+		{
+			name:      "base64 encoded",
+			text:      base64.StdEncoding.EncodeToString([]byte(created)),
+			container: "things",
+			blob:      "foo/bar.json",
+			ok:        true,
+		},
+		{
+			name:      "array of events",
+			text:      "[" + created + "]",
+			container: "things",
+			blob:      "foo/bar.json",
+			ok:        true,
+		},
+		{
+			name:      "cloud events schema",
+			text:      `{"type":"Microsoft.Storage.BlobCreated","subject":"/blobServices/default/containers/things/blobs/baz.txt"}`,
+			container: "things",
+			blob:      "baz.txt",
+			ok:        true,
+		},
+		{
+			name: "blob deleted",
+			text: `{"eventType":"Microsoft.Storage.BlobDeleted","subject":"/blobServices/default/containers/things/blobs/baz.txt"}`,
+		},
+		{
+			name: "bad subject",
+			text: `{"eventType":"Microsoft.Storage.BlobCreated","subject":"/containers/things/baz.txt"}`,
+		},
+		{
+			name: "not json",
+			text: `hello world`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			container, blob, ok := azureBlobEventTarget(test.text)
+			assert.Equal(t, test.ok, ok)
+			if test.ok {
+				assert.Equal(t, test.container, container)
+				assert.Equal(t, test.blob, blob)
+			}
+		})
+	}
+}
@@ -0,0 +1,591 @@
+// +build !wasm
+
+package reader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
+	"github.com/cenkalti/backoff/v4"
+)
+
+//------------------------------------------------------------------------------
+
+// The maximum number of messages that may be received from a partition ahead
+// of them being read.
+const azureEventHubsLinkCredit = 100
+
+// azureEventHubsPosition is the position of a message within a partition, which
+// is also the format of checkpoints stored in Azure Blob Storage.
+type azureEventHubsPosition struct {
+	Offset         string `json:"offset"`
+	SequenceNumber int64  `json:"sequence_number"`
+}
+
+// azureEventHubsPartition tracks the positions of messages consumed from a
+// partition that are pending acknowledgement.
+type azureEventHubsPartition struct {
+	mut       sync.Mutex
+	cp        *checkpoint.Type
+	index     int
+	pruned    int
+	positions map[int]azureEventHubsPosition
+
+	position  azureEventHubsPosition
+	committed azureEventHubsPosition
+}
+
+func newAzureEventHubsPartition(position azureEventHubsPosition) *azureEventHubsPartition {
+	return &azureEventHubsPartition{
+		cp:        checkpoint.New(0),
+		positions: map[int]azureEventHubsPosition{},
+		position:  position,
+		committed: position,
+	}
+}
+
+func (p *azureEventHubsPartition) track(position azureEventHubsPosition) int {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	p.index++
+	p.cp.MustTrack(p.index)
+	p.positions[p.index] = position
+	return p.index
+}
+
+func (p *azureEventHubsPartition) resolve(index int) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	highest := p.cp.MustResolve(index)
+	if highest <= p.pruned {
+		return
+	}
+	p.position = p.positions[highest]
+	for ; p.pruned < highest; p.pruned++ {
+		delete(p.positions, p.pruned+1)
+	}
+}
+
+// pendingCommit returns the highest acknowledged position if it has not yet
+// been committed.
+func (p *azureEventHubsPartition) pendingCommit() (azureEventHubsPosition, bool) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.position, p.position != p.committed
+}
+
+func (p *azureEventHubsPartition) setCommitted(position azureEventHubsPosition) {
+	p.mut.Lock()
+	p.committed = position
+	p.mut.Unlock()
+}
+
+//------------------------------------------------------------------------------
+
+func azureEventHubsMessageToPart(partitionID string, m *amqp.Message) (types.Part, azureEventHubsPosition) {
+	var body []byte
+	if len(m.Data) > 0 {
+		body = bytes.Join(m.Data, nil)
+	} else {
+		switch v := m.Value.(type) {
+		case nil:
+		case []byte:
+			body = v
+		case string:
+			body = []byte(v)
+		default:
+			body = []byte(fmt.Sprintf("%v", v))
+		}
+	}
+
+	part := message.NewPart(body)
+	meta := part.Metadata()
+	for k, v := range m.ApplicationProperties {
+		meta.Set(k, fmt.Sprintf("%v", v))
+	}
+
+	var pos azureEventHubsPosition
+	pos.Offset, _ = m.Annotations["x-opt-offset"].(string)
+	pos.SequenceNumber, _ = m.Annotations["x-opt-sequence-number"].(int64)
+
+	meta.Set("azure_event_hubs_partition_id", partitionID)
+	meta.Set("azure_event_hubs_offset", pos.Offset)
+	meta.Set("azure_event_hubs_sequence_number", strconv.FormatInt(pos.SequenceNumber, 10))
+	if key, ok := m.Annotations["x-opt-partition-key"].(string); ok {
+		meta.Set("azure_event_hubs_partition_key", key)
+	}
+	if enqueued, ok := m.Annotations["x-opt-enqueued-time"].(time.Time); ok {
+		meta.Set("azure_event_hubs_enqueued_time_unix", strconv.FormatInt(enqueued.Unix(), 10))
+	}
+	return part, pos
+}
+
+func azureEventHubsPartitionIDs(v interface{}) ([]string, error) {
+	props, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected management response type: %T", v)
+	}
+	switch ids := props["partition_ids"].(type) {
+	case []string:
+		return ids, nil
+	case []interface{}:
+		strIDs := make([]string, 0, len(ids))
+		for _, id := range ids {
+			strIDs = append(strIDs, fmt.Sprintf("%v", id))
+		}
+		return strIDs, nil
+	}
+	return nil, errors.New("management response did not contain partition IDs")
+}
+
+func isAzureAMQPConnErr(err error) bool {
+	return errors.Is(err, amqp.ErrConnClosed) || errors.Is(err, amqp.ErrSessionClosed)
+}
+
+//------------------------------------------------------------------------------
+
+// AzureEventHubs is a benthos reader.Async implementation that consumes the
+// partitions of an Azure Event Hub with a consumer group.
+type AzureEventHubs struct {
+	conf AzureEventHubsConfig
+
+	client    *azureamqp.Client
+	clientMut sync.Mutex
+
+	container  *azblob.ContainerURL
+	blobPrefix string
+
+	connected bool
+	closed    bool
+	connMut   sync.Mutex
+
+	partitionsMut sync.Mutex
+	partitions    map[string]*azureEventHubsPartition
+	partitionsWG  sync.WaitGroup
+
+	msgChan      chan asyncMessage
+	commitPeriod time.Duration
+
+	ctx        context.Context
+	done       func()
+	closedChan chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewAzureEventHubs creates a new Azure Event Hubs reader.
+func NewAzureEventHubs(
+	conf AzureEventHubsConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*AzureEventHubs, error) {
+	if conf.ConsumerGroup == "" {
+		return nil, errors.New("a consumer group must be specified")
+	}
+	a := AzureEventHubs{
+		conf:       conf,
+		partitions: map[string]*azureEventHubsPartition{},
+		msgChan:    make(chan asyncMessage),
+		closedChan: make(chan struct{}),
+		log:        log,
+		stats:      stats,
+	}
+	a.ctx, a.done = context.WithCancel(context.Background())
+
+	var err error
+	if a.commitPeriod, err = time.ParseDuration(conf.Checkpoint.CommitPeriod); err != nil {
+		return nil, fmt.Errorf("failed to parse commit period string: %v", err)
+	}
+
+	if conf.Checkpoint.Container != "" {
+		if conf.Checkpoint.StorageAccount == "" || conf.Checkpoint.StorageAccessKey == "" {
+			return nil, errors.New("a storage account and access key must be specified in order to store checkpoints")
+		}
+		credential, err := azblob.NewSharedKeyCredential(conf.Checkpoint.StorageAccount, conf.Checkpoint.StorageAccessKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid azure storage account credentials: %v", err)
+		}
+		containerURL, err := url.Parse(fmt.Sprintf("https://%s.blob.core.windows.net/%s", conf.Checkpoint.StorageAccount, conf.Checkpoint.Container))
+		if err != nil {
+			return nil, fmt.Errorf("invalid azure storage container: %v", err)
+		}
+		container := azblob.NewContainerURL(*containerURL, azblob.NewPipeline(credential, azblob.PipelineOptions{}))
+		a.container = &container
+	}
+	return &a, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (a *AzureEventHubs) getClient(ctx context.Context) (*azureamqp.Client, error) {
+	a.clientMut.Lock()
+	defer a.clientMut.Unlock()
+
+	if a.client != nil {
+		return a.client, nil
+	}
+	client, err := azureamqp.Dial(ctx, a.conf.Config, a.conf.EventHub, azureamqp.EventHubsResource, a.log)
+	if err != nil {
+		return nil, err
+	}
+	a.client = client
+	return client, nil
+}
+
+// resetClient closes a client that has failed so that the next call to
+// getClient establishes a new connection.
+func (a *AzureEventHubs) resetClient(client *azureamqp.Client) {
+	a.clientMut.Lock()
+	defer a.clientMut.Unlock()
+
+	if a.client == client {
+		a.client = nil
+		client.Close(context.Background())
+	}
+}
+
+func (a *AzureEventHubs) getPartitionIDs(ctx context.Context, client *azureamqp.Client) ([]string, error) {
+	token, err := client.SecurityToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.RPC(ctx, "$management", &amqp.Message{
+		ApplicationProperties: map[string]interface{}{
+			"operation":      "READ",
+			"name":           client.Entity(),
+			"type":           "com.microsoft:eventhub",
+			"security_token": token,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return azureEventHubsPartitionIDs(res.Value)
+}
+
+// ConnectWithContext attempts to connect to the event hub and begins consuming
+// its partitions.
+func (a *AzureEventHubs) ConnectWithContext(ctx context.Context) error {
+	a.connMut.Lock()
+	defer a.connMut.Unlock()
+
+	if a.closed {
+		return types.ErrTypeClosed
+	}
+	if a.connected {
+		return nil
+	}
+
+	client, err := a.getClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	var partitionIDs []string
+	for _, p := range a.conf.Partitions {
+		for _, splitPartitions := range strings.Split(p, ",") {
+			if trimmed := strings.TrimSpace(splitPartitions); len(trimmed) > 0 {
+				partitionIDs = append(partitionIDs, trimmed)
+			}
+		}
+	}
+	if len(partitionIDs) == 0 {
+		if partitionIDs, err = a.getPartitionIDs(ctx, client); err != nil {
+			a.resetClient(client)
+			return fmt.Errorf("failed to obtain partitions of event hub: %w", err)
+		}
+	}
+
+	a.blobPrefix = fmt.Sprintf("%v/%v/%v/", client.Namespace(), client.Entity(), a.conf.ConsumerGroup)
+
+	positions := make(map[string]*azureEventHubsPosition, len(partitionIDs))
+	for _, id := range partitionIDs {
+		if positions[id], err = a.getCheckpoint(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	for id, pos := range positions {
+		offset := "@latest"
+		if a.conf.StartFromOldest {
+			offset = "-1"
+		}
+		var partition *azureEventHubsPartition
+		if pos != nil {
+			offset = pos.Offset
+			partition = newAzureEventHubsPartition(*pos)
+		} else {
+			partition = newAzureEventHubsPartition(azureEventHubsPosition{})
+		}
+
+		a.partitionsMut.Lock()
+		a.partitions[id] = partition
+		a.partitionsMut.Unlock()
+
+		a.partitionsWG.Add(1)
+		go a.partitionLoop(id, partition, offset)
+	}
+
+	go a.loop()
+	a.connected = true
+
+	a.log.Infof("Receiving Azure Event Hubs messages from event hub '%v' partitions %v as consumer group '%v'\n", client.Entity(), partitionIDs, a.conf.ConsumerGroup)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (a *AzureEventHubs) checkpointBlob(partitionID string) azblob.BlockBlobURL {
+	return a.container.NewBlockBlobURL(a.blobPrefix + partitionID)
+}
+
+// getCheckpoint returns the stored position of a partition, or nil if a
+// position has not yet been stored.
+func (a *AzureEventHubs) getCheckpoint(ctx context.Context, partitionID string) (*azureEventHubsPosition, error) {
+	if a.container == nil {
+		return nil, nil
+	}
+	res, err := a.checkpointBlob(partitionID).Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false)
+	if err != nil {
+		if serr, ok := err.(azblob.StorageError); ok && serr.Response() != nil && serr.Response().StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint of partition '%v': %w", partitionID, err)
+	}
+	body := res.Body(azblob.RetryReaderOptions{})
+	defer body.Close()
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint of partition '%v': %w", partitionID, err)
+	}
+	var pos azureEventHubsPosition
+	if err = json.Unmarshal(data, &pos); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint of partition '%v': %w", partitionID, err)
+	}
+	return &pos, nil
+}
+
+func (a *AzureEventHubs) commit() {
+	if a.container == nil {
+		return
+	}
+
+	a.partitionsMut.Lock()
+	partitions := make(map[string]*azureEventHubsPartition, len(a.partitions))
+	for id, p := range a.partitions {
+		partitions[id] = p
+	}
+	a.partitionsMut.Unlock()
+
+	for id, p := range partitions {
+		pos, pending := p.pendingCommit()
+		if !pending {
+			continue
+		}
+		data, _ := json.Marshal(pos)
+		if _, err := a.checkpointBlob(id).Upload(
+			context.Background(), bytes.NewReader(data), azblob.BlobHTTPHeaders{ContentType: "application/json"},
+			azblob.Metadata{}, azblob.BlobAccessConditions{},
+		); err != nil {
+			a.log.Errorf("Failed to commit offset of partition '%v': %v\n", id, err)
+			continue
+		}
+		p.setCommitted(pos)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// consume receives messages from a partition after an offset for as long as
+// the link remains open, returning the offset of the last message received.
+func (a *AzureEventHubs) consume(partitionID string, partition *azureEventHubsPartition, offset string) (string, error) {
+	client, err := a.getClient(a.ctx)
+	if err != nil {
+		return offset, err
+	}
+
+	receiver, err := client.Session().NewReceiver(
+		amqp.LinkSourceAddress(fmt.Sprintf("%v/ConsumerGroups/%v/Partitions/%v", client.Entity(), a.conf.ConsumerGroup, partitionID)),
+		amqp.LinkSelectorFilter(fmt.Sprintf("amqp.annotation.x-opt-offset > '%v'", offset)),
+		amqp.LinkCredit(azureEventHubsLinkCredit),
+	)
+	if err != nil {
+		if isAzureAMQPConnErr(err) {
+			a.resetClient(client)
+		}
+		return offset, err
+	}
+	defer receiver.Close(context.Background())
+
+	for {
+		aMsg, err := receiver.Receive(a.ctx)
+		if err != nil {
+			if isAzureAMQPConnErr(err) {
+				a.resetClient(client)
+			}
+			return offset, err
+		}
+		// Progress is tracked with checkpoints and so messages are settled
+		// as soon as they are received.
+		aMsg.Accept(a.ctx)
+
+		part, pos := azureEventHubsMessageToPart(partitionID, aMsg)
+		offset = pos.Offset
+
+		msg := message.New(nil)
+		msg.Append(part)
+
+		index := partition.track(pos)
+		select {
+		case a.msgChan <- asyncMessage{
+			msg: msg,
+			ackFn: func(ctx context.Context, res types.Response) error {
+				if res.Error() == nil {
+					partition.resolve(index)
+				}
+				return nil
+			},
+		}:
+		case <-a.ctx.Done():
+			return offset, a.ctx.Err()
+		}
+	}
+}
+
+func (a *AzureEventHubs) partitionLoop(partitionID string, partition *azureEventHubsPartition, offset string) {
+	defer a.partitionsWG.Done()
+
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = time.Millisecond * 500
+	boff.MaxInterval = time.Second * 30
+	boff.MaxElapsedTime = 0
+
+	for {
+		nextOffset, err := a.consume(partitionID, partition, offset)
+		if a.ctx.Err() != nil {
+			return
+		}
+		if nextOffset != offset {
+			boff.Reset()
+			offset = nextOffset
+		}
+		a.log.Errorf("Failed to consume partition '%v': %v\n", partitionID, err)
+		select {
+		case <-time.After(boff.NextBackOff()):
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+func (a *AzureEventHubs) loop() {
+	defer func() {
+		a.partitionsWG.Wait()
+		a.commit()
+
+		a.clientMut.Lock()
+		if a.client != nil {
+			a.client.Close(context.Background())
+			a.client = nil
+		}
+		a.clientMut.Unlock()
+
+		close(a.msgChan)
+		close(a.closedChan)
+	}()
+
+	commitTicker := time.NewTicker(a.commitPeriod)
+	defer commitTicker.Stop()
+
+	for {
+		select {
+		case <-commitTicker.C:
+			a.commit()
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ReadWithContext attempts to read a new message from the event hub.
+func (a *AzureEventHubs) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	a.connMut.Lock()
+	connected := a.connected || a.closed
+	a.connMut.Unlock()
+
+	if !connected {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	select {
+	case m, open := <-a.msgChan:
+		if !open {
+			return nil, nil, types.ErrTypeClosed
+		}
+		return m.msg, m.ackFn, nil
+	case <-ctx.Done():
+	}
+	return nil, nil, types.ErrTimeout
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (a *AzureEventHubs) CloseAsync() {
+	a.connMut.Lock()
+	defer a.connMut.Unlock()
+
+	if a.closed {
+		return
+	}
+	a.closed = true
+	a.done()
+
+	// Without a connection the background loop was never started.
+	if !a.connected {
+		a.clientMut.Lock()
+		if a.client != nil {
+			a.client.Close(context.Background())
+			a.client = nil
+		}
+		a.clientMut.Unlock()
+
+		close(a.msgChan)
+		close(a.closedChan)
+	}
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (a *AzureEventHubs) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-a.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,50 @@
+package reader
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+)
+
+//------------------------------------------------------------------------------
+
+// AzureEventHubsCheckpointConfig contains configuration fields for storing the
+// offsets of consumed partitions in Azure Blob Storage.
+type AzureEventHubsCheckpointConfig struct {
+	StorageAccount   string `json:"storage_account" yaml:"storage_account"`
+	StorageAccessKey string `json:"storage_access_key" yaml:"storage_access_key"`
+	Container        string `json:"container" yaml:"container"`
+	CommitPeriod     string `json:"commit_period" yaml:"commit_period"`
+}
+
+// AzureEventHubsConfig contains configuration fields for the AzureEventHubs
+// input type.
+type AzureEventHubsConfig struct {
+	azureamqp.Config `json:",inline" yaml:",inline"`
+	EventHub         string                         `json:"event_hub" yaml:"event_hub"`
+	ConsumerGroup    string                         `json:"consumer_group" yaml:"consumer_group"`
+	Partitions       []string                       `json:"partitions" yaml:"partitions"`
+	StartFromOldest  bool                           `json:"start_from_oldest" yaml:"start_from_oldest"`
+	Checkpoint       AzureEventHubsCheckpointConfig `json:"checkpoint" yaml:"checkpoint"`
+	Batching         batch.PolicyConfig             `json:"batching" yaml:"batching"`
+}
+
+// NewAzureEventHubsConfig creates a new AzureEventHubsConfig with default
+// values.
+func NewAzureEventHubsConfig() AzureEventHubsConfig {
+	return AzureEventHubsConfig{
+		Config:          azureamqp.NewConfig(),
+		EventHub:        "",
+		ConsumerGroup:   "$Default",
+		Partitions:      []string{},
+		StartFromOldest: true,
+		Checkpoint: AzureEventHubsCheckpointConfig{
+			StorageAccount:   "",
+			StorageAccessKey: "",
+			Container:        "",
+			CommitPeriod:     "1s",
+		},
+		Batching: batch.NewPolicyConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,88 @@
+// +build !wasm
+
+package reader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureEventHubsMessageToPart(t *testing.T) {
+	m := amqp.NewMessage([]byte("hello world"))
+	m.ApplicationProperties = map[string]interface{}{
+		"foo": "bar",
+		"baz": int64(10),
+	}
+	m.Annotations = amqp.Annotations{
+		"x-opt-offset":          "4096",
+		"x-opt-sequence-number": int64(42),
+		"x-opt-partition-key":   "key",
+		"x-opt-enqueued-time":   time.Unix(1600000000, 0),
+	}
+
+	part, pos := azureEventHubsMessageToPart("3", m)
+	assert.Equal(t, azureEventHubsPosition{Offset: "4096", SequenceNumber: 42}, pos)
+	assert.Equal(t, "hello world", string(part.Get()))
+
+	meta := map[string]string{}
+	part.Metadata().Iter(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+	assert.Equal(t, map[string]string{
+		"foo":                                 "bar",
+		"baz":                                 "10",
+		"azure_event_hubs_partition_id":       "3",
+		"azure_event_hubs_offset":             "4096",
+		"azure_event_hubs_sequence_number":    "42",
+		"azure_event_hubs_partition_key":      "key",
+		"azure_event_hubs_enqueued_time_unix": "1600000000",
+	}, meta)
+}
+
+func TestAzureEventHubsPartitionIDs(t *testing.T) {
+	ids, err := azureEventHubsPartitionIDs(map[string]interface{}{
+		"partition_ids": []string{"0", "1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1"}, ids)
+
+	ids, err = azureEventHubsPartitionIDs(map[string]interface{}{
+		"partition_ids": []interface{}{"0", "1", "2"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1", "2"}, ids)
+
+	_, err = azureEventHubsPartitionIDs(map[string]interface{}{})
+	assert.Error(t, err)
+
+	_, err = azureEventHubsPartitionIDs("nope")
+	assert.Error(t, err)
+}
+
+func TestAzureEventHubsPartitionCheckpoint(t *testing.T) {
+	p := newAzureEventHubsPartition(azureEventHubsPosition{Offset: "10", SequenceNumber: 1})
+
+	_, pending := p.pendingCommit()
+	assert.False(t, pending)
+
+	first := p.track(azureEventHubsPosition{Offset: "20", SequenceNumber: 2})
+	second := p.track(azureEventHubsPosition{Offset: "30", SequenceNumber: 3})
+
+	p.resolve(second)
+	_, pending = p.pendingCommit()
+	assert.False(t, pending)
+
+	p.resolve(first)
+	pos, pending := p.pendingCommit()
+	require.True(t, pending)
+	assert.Equal(t, azureEventHubsPosition{Offset: "30", SequenceNumber: 3}, pos)
+
+	p.setCommitted(pos)
+	_, pending = p.pendingCommit()
+	assert.False(t, pending)
+}
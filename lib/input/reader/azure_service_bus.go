@@ -0,0 +1,349 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	azureServiceBusSessionFilterName = "com.microsoft:session-filter"
+	azureServiceBusSessionFilterCode = 0x00000137000000C
+	azureServiceBusDeadLetter        = "com.microsoft:dead-letter"
+	azureServiceBusRenewLock         = "com.microsoft:renew-lock"
+	azureServiceBusRenewSessionLock  = "com.microsoft:renew-session-lock"
+)
+
+func azureServiceBusMessageToPart(m *amqp.Message) types.Part {
+	part := message.NewPart(m.GetData())
+	meta := part.Metadata()
+	for k, v := range m.ApplicationProperties {
+		meta.Set(k, fmt.Sprintf("%v", v))
+	}
+
+	if m.Properties != nil {
+		if m.Properties.MessageID != nil {
+			meta.Set("azure_service_bus_message_id", fmt.Sprintf("%v", m.Properties.MessageID))
+		}
+		if m.Properties.CorrelationID != nil {
+			meta.Set("azure_service_bus_correlation_id", fmt.Sprintf("%v", m.Properties.CorrelationID))
+		}
+		if m.Properties.GroupID != "" {
+			meta.Set("azure_service_bus_session_id", m.Properties.GroupID)
+		}
+		if m.Properties.ContentType != "" {
+			meta.Set("azure_service_bus_content_type", m.Properties.ContentType)
+		}
+	}
+	if m.Header != nil {
+		meta.Set("azure_service_bus_delivery_count", strconv.FormatUint(uint64(m.Header.DeliveryCount), 10))
+	}
+	if seq, ok := m.Annotations["x-opt-sequence-number"].(int64); ok {
+		meta.Set("azure_service_bus_sequence_number", strconv.FormatInt(seq, 10))
+	}
+	if enqueued, ok := m.Annotations["x-opt-enqueued-time"].(time.Time); ok {
+		meta.Set("azure_service_bus_enqueued_time_unix", strconv.FormatInt(enqueued.Unix(), 10))
+	}
+	return part
+}
+
+// azureServiceBusLockToken returns the lock token of a message received in
+// peek-lock mode. The delivery tag of a message is its lock token encoded in
+// the byte order of a .NET GUID, which differs from an AMQP UUID for the first
+// three groups.
+func azureServiceBusLockToken(m *amqp.Message) (amqp.UUID, error) {
+	if token, ok := m.Annotations["x-opt-lock-token"].(amqp.UUID); ok {
+		return token, nil
+	}
+
+	var token amqp.UUID
+	if len(m.DeliveryTag) != len(token) {
+		return token, fmt.Errorf("delivery tag has unexpected length %v", len(m.DeliveryTag))
+	}
+	copy(token[:], m.DeliveryTag)
+	token[0], token[1], token[2], token[3] = token[3], token[2], token[1], token[0]
+	token[4], token[5] = token[5], token[4]
+	token[6], token[7] = token[7], token[6]
+	return token, nil
+}
+
+// azureServiceBusRenewLockRequest returns a management request that renews the
+// lock of a message, or the lock of its session when sessions are enabled as
+// messages of a session are locked along with the session.
+func azureServiceBusRenewLockRequest(m *amqp.Message, session bool) (*amqp.Message, error) {
+	if session {
+		if m.Properties == nil || m.Properties.GroupID == "" {
+			return nil, errors.New("message does not have a session ID")
+		}
+		return &amqp.Message{
+			ApplicationProperties: map[string]interface{}{
+				"operation": azureServiceBusRenewSessionLock,
+			},
+			Value: map[string]interface{}{
+				"session-id": m.Properties.GroupID,
+			},
+		}, nil
+	}
+
+	token, err := azureServiceBusLockToken(m)
+	if err != nil {
+		return nil, err
+	}
+	return &amqp.Message{
+		ApplicationProperties: map[string]interface{}{
+			"operation": azureServiceBusRenewLock,
+		},
+		Value: map[string]interface{}{
+			"lock-tokens": []amqp.UUID{token},
+		},
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// AzureServiceBus is a benthos reader.Async implementation that receives
+// messages from an Azure Service Bus queue or subscription in peek-lock mode.
+type AzureServiceBus struct {
+	conf   AzureServiceBusConfig
+	entity string
+
+	lockRenewalPeriod time.Duration
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	client   *azureamqp.Client
+	receiver *amqp.Receiver
+	m        sync.RWMutex
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewAzureServiceBus creates a new Azure Service Bus reader.
+func NewAzureServiceBus(
+	conf AzureServiceBusConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*AzureServiceBus, error) {
+	a := AzureServiceBus{
+		conf:      conf,
+		closeChan: make(chan struct{}),
+		log:       log,
+		stats:     stats,
+	}
+
+	if conf.Queue != "" && conf.Topic != "" {
+		return nil, errors.New("cannot specify both a queue and a topic")
+	}
+	if conf.Topic != "" {
+		if conf.Subscription == "" {
+			return nil, errors.New("a subscription must be specified when consuming from a topic")
+		}
+		a.entity = conf.Topic + "/Subscriptions/" + conf.Subscription
+	} else {
+		a.entity = conf.Queue
+	}
+
+	switch conf.NackAction {
+	case "abandon", "dead_letter":
+	default:
+		return nil, fmt.Errorf("nack action not recognised: %v", conf.NackAction)
+	}
+	if conf.PrefetchCount < 1 {
+		return nil, errors.New("prefetch count must be greater than zero")
+	}
+	if conf.LockRenewalPeriod != "" {
+		var err error
+		if a.lockRenewalPeriod, err = time.ParseDuration(conf.LockRenewalPeriod); err != nil {
+			return nil, fmt.Errorf("failed to parse lock renewal period: %v", err)
+		}
+		if a.lockRenewalPeriod < time.Second {
+			return nil, errors.New("lock renewal period must be at least one second")
+		}
+	}
+	return &a, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext establishes a connection to Azure Service Bus.
+func (a *AzureServiceBus) ConnectWithContext(ctx context.Context) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.client != nil {
+		return nil
+	}
+
+	client, err := azureamqp.Dial(ctx, a.conf.Config, a.entity, azureamqp.ServiceBusResource, a.log)
+	if err != nil {
+		return err
+	}
+
+	opts := []amqp.LinkOption{
+		amqp.LinkSourceAddress(client.Entity()),
+		amqp.LinkCredit(uint32(a.conf.PrefetchCount)),
+		amqp.LinkSenderSettle(amqp.ModeUnsettled),
+		amqp.LinkReceiverSettle(amqp.ModeFirst),
+	}
+	if a.conf.Session.Enabled {
+		// A nil session ID requests the next available session.
+		var sessionID interface{}
+		if a.conf.Session.SessionID != "" {
+			sessionID = a.conf.Session.SessionID
+		}
+		opts = append(opts, amqp.LinkSourceFilter(azureServiceBusSessionFilterName, azureServiceBusSessionFilterCode, sessionID))
+	}
+
+	receiver, err := client.Session().NewReceiver(opts...)
+	if err != nil {
+		client.Close(context.Background())
+		return err
+	}
+
+	a.client = client
+	a.receiver = receiver
+
+	a.log.Infof("Receiving Azure Service Bus messages from: %v\n", client.Entity())
+	return nil
+}
+
+// disconnect safely closes a connection to Azure Service Bus.
+func (a *AzureServiceBus) disconnect(ctx context.Context) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.client == nil {
+		return nil
+	}
+	if err := a.receiver.Close(ctx); err != nil {
+		a.log.Errorf("Failed to cleanly close receiver: %v\n", err)
+	}
+	if err := a.client.Close(ctx); err != nil {
+		a.log.Errorf("Failed to cleanly close client: %v\n", err)
+	}
+	a.client = nil
+	a.receiver = nil
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// ReadWithContext attempts to receive a new message from Azure Service Bus.
+func (a *AzureServiceBus) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	a.m.RLock()
+	client, r := a.client, a.receiver
+	a.m.RUnlock()
+
+	if r == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	sbMsg, err := r.Receive(ctx)
+	if err != nil {
+		if ctx.Err() != nil || err == amqp.ErrTimeout {
+			return nil, nil, types.ErrTimeout
+		}
+		a.log.Errorf("Lost connection due to: %v\n", err)
+		a.disconnect(context.Background())
+		return nil, nil, types.ErrNotConnected
+	}
+
+	msg := message.New(nil)
+	msg.Append(azureServiceBusMessageToPart(sbMsg))
+
+	stopRenewal := func() {}
+	if a.lockRenewalPeriod > 0 {
+		stopRenewal = a.startLockRenewal(client, sbMsg)
+	}
+
+	return msg, func(ctx context.Context, res types.Response) error {
+		stopRenewal()
+		if res.Error() == nil {
+			return sbMsg.Accept(ctx)
+		}
+		if a.conf.NackAction == "dead_letter" {
+			return sbMsg.Reject(ctx, &amqp.Error{
+				Condition:   azureServiceBusDeadLetter,
+				Description: res.Error().Error(),
+				Info: map[string]interface{}{
+					"DeadLetterReason":           "ProcessingFailed",
+					"DeadLetterErrorDescription": res.Error().Error(),
+				},
+			})
+		}
+		return sbMsg.Modify(ctx, true, false, nil)
+	}, nil
+}
+
+// startLockRenewal periodically renews the lock of a received message until
+// the returned func is called, which blocks until renewals have stopped.
+func (a *AzureServiceBus) startLockRenewal(client *azureamqp.Client, sbMsg *amqp.Message) func() {
+	stopChan, stoppedChan := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stoppedChan)
+
+		ticker := time.NewTicker(a.lockRenewalPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-stopChan:
+				return
+			case <-a.closeChan:
+				return
+			}
+
+			req, err := azureServiceBusRenewLockRequest(sbMsg, a.conf.Session.Enabled)
+			if err != nil {
+				a.log.Errorf("Unable to renew lock of consumed Azure Service Bus message: %v\n", err)
+				return
+			}
+
+			ctx, done := context.WithTimeout(context.Background(), a.lockRenewalPeriod)
+			_, err = client.RPC(ctx, client.Entity()+"/$management", req)
+			done()
+			if err != nil {
+				a.log.Errorf("Failed to renew lock of consumed Azure Service Bus message: %v\n", err)
+			}
+		}
+	}()
+
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			close(stopChan)
+			<-stoppedChan
+		})
+	}
+}
+
+// CloseAsync shuts down the Azure Service Bus input and stops processing
+// requests.
+func (a *AzureServiceBus) CloseAsync() {
+	a.closeOnce.Do(func() {
+		close(a.closeChan)
+	})
+	a.disconnect(context.Background())
+}
+
+// WaitForClose blocks until the Azure Service Bus input has closed down.
+func (a *AzureServiceBus) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,47 @@
+package reader
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+)
+
+//------------------------------------------------------------------------------
+
+// AzureServiceBusSessionConfig contains configuration fields for receiving
+// messages from a session of a session enabled entity.
+type AzureServiceBusSessionConfig struct {
+	Enabled   bool   `json:"enabled" yaml:"enabled"`
+	SessionID string `json:"session_id" yaml:"session_id"`
+}
+
+// AzureServiceBusConfig contains configuration fields for the AzureServiceBus
+// input type.
+type AzureServiceBusConfig struct {
+	azureamqp.Config  `json:",inline" yaml:",inline"`
+	Queue             string                       `json:"queue" yaml:"queue"`
+	Topic             string                       `json:"topic" yaml:"topic"`
+	Subscription      string                       `json:"subscription" yaml:"subscription"`
+	Session           AzureServiceBusSessionConfig `json:"session" yaml:"session"`
+	NackAction        string                       `json:"nack_action" yaml:"nack_action"`
+	PrefetchCount     int                          `json:"prefetch_count" yaml:"prefetch_count"`
+	LockRenewalPeriod string                       `json:"lock_renewal_period" yaml:"lock_renewal_period"`
+}
+
+// NewAzureServiceBusConfig creates a new AzureServiceBusConfig with default
+// values.
+func NewAzureServiceBusConfig() AzureServiceBusConfig {
+	return AzureServiceBusConfig{
+		Config:       azureamqp.NewConfig(),
+		Queue:        "",
+		Topic:        "",
+		Subscription: "",
+		Session: AzureServiceBusSessionConfig{
+			Enabled:   false,
+			SessionID: "",
+		},
+		NackAction:        "abandon",
+		PrefetchCount:     10,
+		LockRenewalPeriod: "30s",
+	}
+}
+
+//------------------------------------------------------------------------------
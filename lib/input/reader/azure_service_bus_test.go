@@ -0,0 +1,120 @@
+package reader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureServiceBusMessageToPart(t *testing.T) {
+	m := amqp.NewMessage([]byte("hello world"))
+	m.ApplicationProperties = map[string]interface{}{
+		"foo": "bar",
+	}
+	m.Properties = &amqp.MessageProperties{
+		MessageID:     "1",
+		CorrelationID: "2",
+		GroupID:       "session",
+		ContentType:   "text/plain",
+	}
+	m.Header = &amqp.MessageHeader{
+		DeliveryCount: 3,
+	}
+	m.Annotations = amqp.Annotations{
+		"x-opt-sequence-number": int64(42),
+		"x-opt-enqueued-time":   time.Unix(1600000000, 0),
+	}
+
+	part := azureServiceBusMessageToPart(m)
+	assert.Equal(t, "hello world", string(part.Get()))
+
+	meta := map[string]string{}
+	part.Metadata().Iter(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+	assert.Equal(t, map[string]string{
+		"foo":                                  "bar",
+		"azure_service_bus_message_id":         "1",
+		"azure_service_bus_correlation_id":     "2",
+		"azure_service_bus_session_id":         "session",
+		"azure_service_bus_content_type":       "text/plain",
+		"azure_service_bus_delivery_count":     "3",
+		"azure_service_bus_sequence_number":    "42",
+		"azure_service_bus_enqueued_time_unix": "1600000000",
+	}, meta)
+}
+
+func TestAzureServiceBusConfigValidation(t *testing.T) {
+	conf := NewAzureServiceBusConfig()
+	conf.Topic = "foo"
+	conf.Subscription = "bar"
+
+	a, err := NewAzureServiceBus(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, "foo/Subscriptions/bar", a.entity)
+
+	for _, fn := range []func(c *AzureServiceBusConfig){
+		func(c *AzureServiceBusConfig) { c.Subscription = "" },
+		func(c *AzureServiceBusConfig) { c.Queue = "baz" },
+		func(c *AzureServiceBusConfig) { c.NackAction = "nope" },
+		func(c *AzureServiceBusConfig) { c.PrefetchCount = 0 },
+		func(c *AzureServiceBusConfig) { c.LockRenewalPeriod = "nope" },
+		func(c *AzureServiceBusConfig) { c.LockRenewalPeriod = "10ms" },
+	} {
+		badConf := conf
+		fn(&badConf)
+		_, err = NewAzureServiceBus(badConf, log.Noop(), metrics.Noop())
+		assert.Error(t, err)
+	}
+}
+
+func TestAzureServiceBusRenewLockRequest(t *testing.T) {
+	m := amqp.NewMessage([]byte("hello world"))
+	m.DeliveryTag = []byte{
+		0x33, 0x22, 0x11, 0x00, 0x55, 0x44, 0x77, 0x66,
+		0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+	}
+	m.Properties = &amqp.MessageProperties{
+		GroupID: "session",
+	}
+
+	req, err := azureServiceBusRenewLockRequest(m, false)
+	require.NoError(t, err)
+	assert.Equal(t, "com.microsoft:renew-lock", req.ApplicationProperties["operation"])
+	assert.Equal(t, map[string]interface{}{
+		"lock-tokens": []amqp.UUID{{
+			0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77,
+			0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+		}},
+	}, req.Value)
+
+	token := amqp.UUID{0x01, 0x02, 0x03}
+	m.Annotations = amqp.Annotations{
+		"x-opt-lock-token": token,
+	}
+	req, err = azureServiceBusRenewLockRequest(m, false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"lock-tokens": []amqp.UUID{token},
+	}, req.Value)
+
+	req, err = azureServiceBusRenewLockRequest(m, true)
+	require.NoError(t, err)
+	assert.Equal(t, "com.microsoft:renew-session-lock", req.ApplicationProperties["operation"])
+	assert.Equal(t, map[string]interface{}{
+		"session-id": "session",
+	}, req.Value)
+
+	m = amqp.NewMessage([]byte("hello world"))
+	m.DeliveryTag = []byte("nope")
+	_, err = azureServiceBusRenewLockRequest(m, false)
+	assert.Error(t, err)
+	_, err = azureServiceBusRenewLockRequest(m, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,173 @@
+package reader
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/codec"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+type codecStreamResult struct {
+	parts []types.Part
+	ackFn codec.ReaderAckFn
+	err   error
+}
+
+// CodecStream is a reader implementation that continuously reads messages from
+// an io.Reader type with a codec.
+type CodecStream struct {
+	handleCtor func(ctx context.Context) (io.Reader, error)
+	onClose    func(ctx context.Context)
+	codecCtor  codec.ReaderConstructor
+
+	mut        sync.Mutex
+	reader     codec.Reader
+	shutdownFn func()
+	resChan    chan codecStreamResult
+}
+
+// NewCodecStream creates a new reader input type able to create a feed of
+// messages from an io.Reader using a codec, where the codec name is one of
+// those supported by the codec package and maxBuffer is the largest message
+// that can be read with codecs that buffer messages.
+//
+// Callers must provide a constructor function for the target io.Reader, which
+// is called on start up and again each time a reader is exhausted. If the
+// constructor is called but there is no more content to create a Reader for
+// then the error `io.EOF` should be returned and the CodecStream will close.
+//
+// Callers must also provide an onClose function, which will be called if the
+// CodecStream has been instructed to shut down. This function should unblock
+// any blocked Read calls.
+func NewCodecStream(
+	codecName string,
+	maxBuffer int,
+	handleCtor func(ctx context.Context) (io.Reader, error),
+	onClose func(ctx context.Context),
+) (*CodecStream, error) {
+	ctor, err := codec.GetReader(codecName, codec.ReaderConfig{
+		MaxScanTokenSize: maxBuffer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CodecStream{
+		handleCtor: handleCtor,
+		onClose:    onClose,
+		codecCtor:  ctor,
+		shutdownFn: func() {},
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (r *CodecStream) closeReader() {
+	if r.reader != nil {
+		r.reader.Close(context.Background())
+		r.reader = nil
+	}
+	r.shutdownFn()
+}
+
+// ConnectWithContext attempts to create a new codec reader for an io.Reader.
+func (r *CodecStream) ConnectWithContext(ctx context.Context) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.closeReader()
+
+	handle, err := r.handleCtor(ctx)
+	if err != nil {
+		if err == io.EOF {
+			return types.ErrTypeClosed
+		}
+		return err
+	}
+
+	rc, ok := handle.(io.ReadCloser)
+	if !ok {
+		rc = ioutil.NopCloser(handle)
+	}
+	reader, err := r.codecCtor(rc, func(context.Context, error) error {
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	readerCtx, shutdownFn := context.WithCancel(context.Background())
+	resChan := make(chan codecStreamResult)
+
+	go func() {
+		defer func() {
+			shutdownFn()
+			close(resChan)
+		}()
+		for {
+			parts, ackFn, err := reader.Next(readerCtx)
+			if err == io.EOF {
+				return
+			}
+			select {
+			case resChan <- codecStreamResult{parts: parts, ackFn: ackFn, err: err}:
+			case <-readerCtx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	r.reader = reader
+	r.resChan = resChan
+	r.shutdownFn = shutdownFn
+	return nil
+}
+
+// ReadWithContext attempts to read a new message from the io.Reader.
+func (r *CodecStream) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	r.mut.Lock()
+	resChan := r.resChan
+	r.mut.Unlock()
+
+	select {
+	case res, open := <-resChan:
+		if !open {
+			return nil, nil, types.ErrNotConnected
+		}
+		if res.err != nil {
+			return nil, nil, res.err
+		}
+		msg := message.New(nil)
+		msg.Append(res.parts...)
+		return msg, func(rctx context.Context, rres types.Response) error {
+			return res.ackFn(rctx, rres.Error())
+		}, nil
+	case <-ctx.Done():
+	}
+	return nil, nil, types.ErrTimeout
+}
+
+// CloseAsync shuts down the reader input and stops processing requests.
+func (r *CodecStream) CloseAsync() {
+	go func() {
+		r.mut.Lock()
+		r.onClose(context.Background())
+		r.closeReader()
+		r.mut.Unlock()
+	}()
+}
+
+// WaitForClose blocks until the reader input has closed down.
+func (r *CodecStream) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
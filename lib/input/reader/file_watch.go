@@ -0,0 +1,583 @@
+package reader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
+)
+
+//------------------------------------------------------------------------------
+
+// FileWatchConfig contains configuration fields for the watch mode of the file
+// input type.
+type FileWatchConfig struct {
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+	PollInterval string `json:"poll_interval" yaml:"poll_interval"`
+	Cache        string `json:"cache" yaml:"cache"`
+}
+
+// NewFileWatchConfig creates a new FileWatchConfig with default values.
+func NewFileWatchConfig() FileWatchConfig {
+	return FileWatchConfig{
+		Enabled:      false,
+		PollInterval: "1s",
+		Cache:        "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// fileWatchOffset is the committed read offset of a file, which is stored in
+// the cache as JSON.
+type fileWatchOffset struct {
+	ID     string `json:"id,omitempty"`
+	Offset int64  `json:"offset"`
+}
+
+// fileWatchReadSize is the number of bytes read from a file at a time.
+const fileWatchReadSize = 64 * 1024
+
+// tailedFile is a file being tailed.
+type tailedFile struct {
+	path string
+	key  string
+	id   string
+	f    *os.File
+	info os.FileInfo
+
+	// The offset within the file of the end of buf.
+	readOffset int64
+	buf        []byte
+	bufPos     int
+
+	// A file is drained once it is no longer found at its path, it is read
+	// until the end and then closed.
+	draining  bool
+	exhausted bool
+
+	// Guarded by the cpMut of the FileWatch.
+	cp        *checkpoint.Type
+	committed int64
+	dirty     bool
+}
+
+func (t *tailedFile) close() {
+	if !t.exhausted {
+		t.f.Close()
+		t.exhausted = true
+	}
+}
+
+// FileWatch is a reader implementation that tails the files that match a glob
+// pattern, following them as they are rotated or truncated.
+type FileWatch struct {
+	pattern      string
+	delim        []byte
+	maxBuffer    int
+	multipart    bool
+	pollInterval time.Duration
+	cache        types.Cache
+
+	// Accessed only by ReadWithContext.
+	notifier *fileNotifier
+	files    []*tailedFile
+	cursor   int
+	lastScan time.Time
+	readBuf  []byte
+
+	// Guards the checkpoints of files and the files that own each key of the
+	// cache.
+	cpMut  sync.Mutex
+	owners map[string]*tailedFile
+
+	closeOnce  sync.Once
+	closed     chan struct{}
+	commitDone chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewFileWatch creates a new FileWatch reader, which tails the files that match
+// a glob pattern and emits a message for each delimited line.
+func NewFileWatch(
+	pattern, delim string,
+	maxBuffer int,
+	multipart bool,
+	conf FileWatchConfig,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (*FileWatch, error) {
+	if len(pattern) == 0 {
+		return nil, fmt.Errorf("a path must be specified")
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("failed to parse path pattern: %v", err)
+	}
+	if len(delim) == 0 {
+		delim = "\n"
+	}
+	if maxBuffer <= 0 {
+		return nil, fmt.Errorf("max_buffer must be greater than zero")
+	}
+
+	w := &FileWatch{
+		pattern:    pattern,
+		delim:      []byte(delim),
+		maxBuffer:  maxBuffer,
+		multipart:  multipart,
+		readBuf:    make([]byte, fileWatchReadSize),
+		owners:     map[string]*tailedFile{},
+		closed:     make(chan struct{}),
+		commitDone: make(chan struct{}),
+		log:        log,
+		stats:      stats,
+	}
+
+	var err error
+	if w.pollInterval, err = time.ParseDuration(conf.PollInterval); err != nil {
+		return nil, fmt.Errorf("failed to parse poll interval: %v", err)
+	}
+	if len(conf.Cache) > 0 {
+		if w.cache, err = mgr.GetCache(conf.Cache); err != nil {
+			return nil, fmt.Errorf("failed to obtain cache '%v': %v", conf.Cache, err)
+		}
+	}
+	return w, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext begins watching for files.
+func (w *FileWatch) ConnectWithContext(ctx context.Context) error {
+	select {
+	case <-w.closed:
+		return types.ErrTypeClosed
+	default:
+	}
+	if w.notifier != nil {
+		return nil
+	}
+
+	notifier, err := newFileNotifier(w.log)
+	if err != nil {
+		return err
+	}
+	w.notifier = notifier
+
+	go w.commitLoop()
+	w.log.Infof("Watching files matching: %v\n", w.pattern)
+	return nil
+}
+
+// commitLoop periodically writes the offsets of acknowledged lines to the
+// cache until the reader is closed.
+func (w *FileWatch) commitLoop() {
+	defer close(w.commitDone)
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.commit()
+		case <-w.closed:
+			w.commit()
+			return
+		}
+	}
+}
+
+// commit writes the offsets of files that have progressed to the cache.
+func (w *FileWatch) commit() {
+	if w.cache == nil {
+		return
+	}
+
+	offsets := map[string]fileWatchOffset{}
+	w.cpMut.Lock()
+	for key, t := range w.owners {
+		if t.dirty {
+			offsets[key] = fileWatchOffset{ID: t.id, Offset: t.committed}
+			t.dirty = false
+		}
+	}
+	w.cpMut.Unlock()
+
+	for key, offset := range offsets {
+		offsetBytes, err := json.Marshal(offset)
+		if err == nil {
+			err = w.cache.Set(key, offsetBytes)
+		}
+		if err != nil {
+			w.log.Errorf("Failed to commit offset of file %v: %v\n", key, err)
+		}
+	}
+}
+
+// loadOffset returns the committed offset of a file from the cache, or zero
+// if there is no offset or the offset belongs to a different file.
+func (w *FileWatch) loadOffset(key, id string, size int64) int64 {
+	if w.cache == nil {
+		return 0
+	}
+	offsetBytes, err := w.cache.Get(key)
+	if err != nil {
+		if err != types.ErrKeyNotFound {
+			w.log.Errorf("Failed to obtain offset of file %v: %v\n", key, err)
+		}
+		return 0
+	}
+	var offset fileWatchOffset
+	if err = json.Unmarshal(offsetBytes, &offset); err != nil {
+		w.log.Errorf("Failed to parse offset of file %v: %v\n", key, err)
+		return 0
+	}
+	if len(offset.ID) > 0 && len(id) > 0 && offset.ID != id {
+		return 0
+	}
+	if offset.Offset > size {
+		return 0
+	}
+	return offset.Offset
+}
+
+//------------------------------------------------------------------------------
+
+// open begins tailing a file.
+func (w *FileWatch) open(p string, info os.FileInfo, useCache bool) {
+	key, err := filepath.Abs(p)
+	if err != nil {
+		key = p
+	}
+	id := fileID(info)
+
+	var offset int64
+	if useCache {
+		offset = w.loadOffset(key, id, info.Size())
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		w.log.Errorf("Failed to open file %v: %v\n", p, err)
+		return
+	}
+	if offset > 0 {
+		if _, err = f.Seek(offset, io.SeekStart); err != nil {
+			w.log.Errorf("Failed to seek file %v: %v\n", p, err)
+			f.Close()
+			return
+		}
+	}
+
+	t := &tailedFile{
+		path:       p,
+		key:        key,
+		id:         id,
+		f:          f,
+		info:       info,
+		readOffset: offset,
+		cp:         checkpoint.New(int(offset)),
+		committed:  offset,
+	}
+	w.cpMut.Lock()
+	w.owners[key] = t
+	w.cpMut.Unlock()
+	w.files = append(w.files, t)
+
+	if offset > 0 {
+		w.log.Debugf("Tailing file %v from offset %v\n", p, offset)
+	} else {
+		w.log.Debugf("Tailing file %v\n", p)
+	}
+}
+
+// disown prevents the offsets of a file from being committed.
+func (w *FileWatch) disown(t *tailedFile) {
+	w.cpMut.Lock()
+	if w.owners[t.key] == t {
+		delete(w.owners, t.key)
+	}
+	w.cpMut.Unlock()
+}
+
+// scan detects files that have been rotated or truncated, and discovers new
+// files that match the pattern.
+func (w *FileWatch) scan() {
+	w.lastScan = time.Now()
+
+	tailing := map[string]struct{}{}
+	truncated := map[string]struct{}{}
+	for _, t := range w.files {
+		if t.draining || t.exhausted {
+			continue
+		}
+		info, err := os.Stat(t.path)
+		switch {
+		case err != nil, !os.SameFile(info, t.info):
+			// The file was removed or rotated, and another file may take its
+			// place.
+			w.log.Debugf("File %v was moved or removed\n", t.path)
+			t.draining = true
+			w.disown(t)
+		case info.Size() < t.readOffset:
+			w.log.Infof("File %v was truncated, reading from the start\n", t.path)
+			t.close()
+			w.disown(t)
+			truncated[t.path] = struct{}{}
+		default:
+			tailing[t.path] = struct{}{}
+		}
+	}
+
+	matches, _ := filepath.Glob(w.pattern)
+	dirs := map[string]struct{}{
+		fileWatchBaseDir(w.pattern): {},
+	}
+	for _, p := range matches {
+		dirs[filepath.Dir(p)] = struct{}{}
+		if _, exists := tailing[p]; exists {
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		// A file that was rotated to another path that matches the pattern
+		// continues to be tailed from its current offset.
+		if t := w.findDraining(info); t != nil {
+			w.log.Debugf("Following file %v moved to %v\n", t.path, p)
+			key, err := filepath.Abs(p)
+			if err != nil {
+				key = p
+			}
+			t.path, t.key, t.draining = p, key, false
+			w.cpMut.Lock()
+			w.owners[key] = t
+			t.dirty = true
+			w.cpMut.Unlock()
+			tailing[p] = struct{}{}
+			continue
+		}
+
+		_, wasTruncated := truncated[p]
+		w.open(p, info, !wasTruncated)
+		tailing[p] = struct{}{}
+	}
+	for dir := range dirs {
+		w.notifier.Add(dir)
+	}
+
+	files := w.files[:0]
+	for _, t := range w.files {
+		if !t.exhausted {
+			files = append(files, t)
+		}
+	}
+	w.files = files
+}
+
+func (w *FileWatch) findDraining(info os.FileInfo) *tailedFile {
+	for _, t := range w.files {
+		if t.draining && !t.exhausted && os.SameFile(t.info, info) {
+			return t
+		}
+	}
+	return nil
+}
+
+// fileWatchBaseDir returns the deepest directory of a pattern that does not
+// contain a pattern.
+func fileWatchBaseDir(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, `*?[`) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+//------------------------------------------------------------------------------
+
+// cut removes the next message from the buffer of a file, returning the
+// message and the offset of its end within the file. When final is true the
+// remainder of the buffer is consumed regardless of delimiters.
+func (w *FileWatch) cut(t *tailedFile, final bool) (types.Message, int64) {
+	msg := message.New(nil)
+	pos := t.bufPos
+	complete := false
+	for {
+		rest := t.buf[pos:]
+		var line []byte
+		if i := bytes.Index(rest, w.delim); i >= 0 && i <= w.maxBuffer {
+			line, pos = rest[:i], pos+i+len(w.delim)
+		} else if len(rest) >= w.maxBuffer {
+			// Lines longer than the buffer are split.
+			line, pos = rest[:w.maxBuffer], pos+w.maxBuffer
+		} else if final && len(rest) > 0 {
+			line, pos = rest, len(t.buf)
+		} else {
+			break
+		}
+		if len(line) > 0 {
+			msg.Append(message.NewPart(append([]byte(nil), line...)))
+			if !w.multipart {
+				complete = true
+				break
+			}
+		} else if w.multipart && msg.Len() > 0 {
+			// An empty line terminates the parts of a batch.
+			complete = true
+			break
+		}
+	}
+	if msg.Len() == 0 {
+		// Empty lines are skipped.
+		t.bufPos = pos
+		return nil, 0
+	}
+	if !complete && !final {
+		return nil, 0
+	}
+	t.bufPos = pos
+	return msg, t.readOffset - int64(len(t.buf)-pos)
+}
+
+// readFile returns the next message of a file, or nil if the file does not
+// currently have a complete message.
+func (w *FileWatch) readFile(t *tailedFile) (types.Message, int64) {
+	for {
+		if msg, end := w.cut(t, false); msg != nil {
+			return msg, end
+		}
+		if t.bufPos > 0 {
+			t.buf = append(t.buf[:0], t.buf[t.bufPos:]...)
+			t.bufPos = 0
+		}
+		n, err := t.f.Read(w.readBuf)
+		if n > 0 {
+			t.buf = append(t.buf, w.readBuf[:n]...)
+			t.readOffset += int64(n)
+			continue
+		}
+		if err != nil && err != io.EOF {
+			w.log.Errorf("Failed to read file %v: %v\n", t.path, err)
+			t.draining = true
+			w.disown(t)
+		}
+		if !t.draining {
+			return nil, 0
+		}
+		msg, end := w.cut(t, true)
+		if msg == nil {
+			t.close()
+		}
+		return msg, end
+	}
+}
+
+// ReadWithContext attempts to read a new line from the tailed files.
+func (w *FileWatch) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	select {
+	case <-w.closed:
+		return nil, nil, types.ErrTypeClosed
+	default:
+	}
+	if w.notifier == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	for {
+		if time.Since(w.lastScan) >= w.pollInterval {
+			w.scan()
+		}
+
+		for n := 0; n < len(w.files); n++ {
+			t := w.files[(w.cursor+n)%len(w.files)]
+			if t.exhausted {
+				continue
+			}
+			if msg, end := w.readFile(t); msg != nil {
+				w.cursor = (w.cursor + n) % len(w.files)
+				return w.track(t, msg, end), w.ackFn(t, end), nil
+			}
+		}
+
+		select {
+		case <-w.notifier.Events():
+			w.lastScan = time.Time{}
+		case <-time.After(w.pollInterval):
+		case <-ctx.Done():
+			return nil, nil, types.ErrTimeout
+		case <-w.closed:
+			return nil, nil, types.ErrTypeClosed
+		}
+	}
+}
+
+func (w *FileWatch) track(t *tailedFile, msg types.Message, end int64) types.Message {
+	w.cpMut.Lock()
+	t.cp.MustTrack(int(end))
+	w.cpMut.Unlock()
+
+	msg.Iter(func(i int, p types.Part) error {
+		p.Metadata().Set("path", t.path)
+		return nil
+	})
+	return msg
+}
+
+func (w *FileWatch) ackFn(t *tailedFile, end int64) AsyncAckFn {
+	return func(ctx context.Context, res types.Response) error {
+		w.cpMut.Lock()
+		defer w.cpMut.Unlock()
+		if offset := int64(t.cp.MustResolve(int(end))); offset > t.committed {
+			t.committed = offset
+			t.dirty = true
+		}
+		return nil
+	}
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (w *FileWatch) CloseAsync() {
+	w.closeOnce.Do(func() {
+		close(w.closed)
+		if w.notifier == nil {
+			close(w.commitDone)
+		} else {
+			w.notifier.Close()
+		}
+	})
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (w *FileWatch) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-w.commitDone:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	for _, t := range w.files {
+		t.close()
+	}
+	w.files = nil
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,18 @@
+// +build !windows,!plan9
+
+package reader
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// fileID returns an identifier that is unique to a file while it exists,
+// consisting of its device and inode numbers.
+func fileID(info os.FileInfo) string {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return strconv.FormatUint(uint64(stat.Dev), 10) + ":" + strconv.FormatUint(uint64(stat.Ino), 10)
+	}
+	return ""
+}
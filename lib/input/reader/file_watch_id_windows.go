@@ -0,0 +1,13 @@
+// +build windows plan9
+
+package reader
+
+import (
+	"os"
+)
+
+// fileID returns an empty identifier, as files can only be identified by their
+// path on this platform.
+func fileID(info os.FileInfo) string {
+	return ""
+}
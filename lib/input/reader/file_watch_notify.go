@@ -0,0 +1,80 @@
+// +build !wasm
+
+package reader
+
+import (
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/fsnotify/fsnotify"
+)
+
+//------------------------------------------------------------------------------
+
+// fileNotifier signals changes to the contents of watched directories, which
+// uses inotify on Linux and the equivalent notifications of other platforms.
+type fileNotifier struct {
+	watcher *fsnotify.Watcher
+	events  chan struct{}
+	watched map[string]struct{}
+	log     log.Modular
+}
+
+func newFileNotifier(log log.Modular) (*fileNotifier, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	n := &fileNotifier{
+		watcher: watcher,
+		events:  make(chan struct{}, 1),
+		watched: map[string]struct{}{},
+		log:     log,
+	}
+	go n.loop()
+	return n, nil
+}
+
+func (n *fileNotifier) loop() {
+	for {
+		select {
+		case _, open := <-n.watcher.Events:
+			if !open {
+				return
+			}
+			select {
+			case n.events <- struct{}{}:
+			default:
+			}
+		case err, open := <-n.watcher.Errors:
+			if !open {
+				return
+			}
+			n.log.Warnf("File notifications failed: %v\n", err)
+		}
+	}
+}
+
+// Add begins watching a directory, directories that cannot be watched are
+// instead only polled.
+func (n *fileNotifier) Add(dir string) {
+	if _, exists := n.watched[dir]; exists {
+		return
+	}
+	if err := n.watcher.Add(dir); err != nil {
+		n.log.Debugf("Failed to watch directory %v: %v\n", dir, err)
+		return
+	}
+	n.watched[dir] = struct{}{}
+}
+
+// Events returns a channel that receives a value after changes have occurred
+// within the watched directories.
+func (n *fileNotifier) Events() <-chan struct{} {
+	return n.events
+}
+
+// Close stops watching all directories.
+func (n *fileNotifier) Close() error {
+	return n.watcher.Close()
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,32 @@
+// +build wasm
+
+package reader
+
+import (
+	"github.com/Jeffail/benthos/v3/lib/log"
+)
+
+//------------------------------------------------------------------------------
+
+// fileNotifier does not signal changes when built for WASM, where files are
+// only polled.
+type fileNotifier struct{}
+
+func newFileNotifier(log log.Modular) (*fileNotifier, error) {
+	return &fileNotifier{}, nil
+}
+
+// Add does nothing.
+func (n *fileNotifier) Add(dir string) {}
+
+// Events returns a channel that never receives a value.
+func (n *fileNotifier) Events() <-chan struct{} {
+	return nil
+}
+
+// Close does nothing.
+func (n *fileNotifier) Close() error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
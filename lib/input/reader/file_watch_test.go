@@ -0,0 +1,228 @@
+package reader
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFileWatchTest(t *testing.T, pattern string, multipart bool, mgr types.Manager, cacheName string) *FileWatch {
+	t.Helper()
+
+	conf := NewFileWatchConfig()
+	conf.Enabled = true
+	conf.PollInterval = "10ms"
+	conf.Cache = cacheName
+
+	w, err := NewFileWatch(pattern, "", 1000, multipart, conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, w.ConnectWithContext(context.Background()))
+	return w
+}
+
+func appendFileWatchTest(t *testing.T, p, content string) {
+	t.Helper()
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func readFileWatchTest(t *testing.T, w *FileWatch, n int) ([]string, []AsyncAckFn) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	var lines []string
+	var ackFns []AsyncAckFn
+	for i := 0; i < n; i++ {
+		msg, ackFn, err := w.ReadWithContext(ctx)
+		require.NoError(t, err)
+		msg.Iter(func(i int, p types.Part) error {
+			lines = append(lines, string(p.Get()))
+			return nil
+		})
+		ackFns = append(ackFns, ackFn)
+	}
+	return lines, ackFns
+}
+
+func assertFileWatchEmpty(t *testing.T, w *FileWatch) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer done()
+	_, _, err := w.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrTimeout, err)
+}
+
+func TestFileWatchConfigErrors(t *testing.T) {
+	conf := NewFileWatchConfig()
+	_, err := NewFileWatch("", "", 1000, false, conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	_, err = NewFileWatch("/var/log/[*.log", "", 1000, false, conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.PollInterval = "nope"
+	_, err = NewFileWatch("/var/log/*.log", "", 1000, false, conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.PollInterval = "1s"
+	conf.Cache = "nope"
+	_, err = NewFileWatch("/var/log/*.log", "", 1000, false, conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestFileWatchTail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_watch_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	aPath := filepath.Join(dir, "a.log")
+	appendFileWatchTest(t, aPath, "foo\n\nbar\npart")
+	appendFileWatchTest(t, filepath.Join(dir, "a.txt"), "nope\n")
+
+	w := newFileWatchTest(t, filepath.Join(dir, "*.log"), false, types.NoopMgr(), "")
+	defer w.CloseAsync()
+
+	lines, _ := readFileWatchTest(t, w, 2)
+	assert.Equal(t, []string{"foo", "bar"}, lines)
+	assertFileWatchEmpty(t, w)
+
+	appendFileWatchTest(t, aPath, "ial\n")
+	lines, _ = readFileWatchTest(t, w, 1)
+	assert.Equal(t, []string{"partial"}, lines)
+
+	bPath := filepath.Join(dir, "b.log")
+	appendFileWatchTest(t, bPath, "baz\n")
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+	msg, _, err := w.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "baz", string(msg.Get(0).Get()))
+	assert.Equal(t, bPath, msg.Get(0).Metadata().Get("path"))
+}
+
+func TestFileWatchRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_watch_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	aPath := filepath.Join(dir, "a.log")
+	appendFileWatchTest(t, aPath, "1\n2")
+
+	w := newFileWatchTest(t, filepath.Join(dir, "*.log"), false, types.NoopMgr(), "")
+	defer w.CloseAsync()
+
+	lines, _ := readFileWatchTest(t, w, 1)
+	assert.Equal(t, []string{"1"}, lines)
+
+	// A rotated file is read until its end before the file that replaces it.
+	rotatedPath := filepath.Join(dir, "a.log.1")
+	require.NoError(t, os.Rename(aPath, rotatedPath))
+	appendFileWatchTest(t, rotatedPath, "3\n4")
+	appendFileWatchTest(t, aPath, "five\n")
+
+	lines, _ = readFileWatchTest(t, w, 3)
+	assert.Equal(t, []string{"23", "4", "five"}, lines)
+
+	// A truncated file is read again from the start.
+	require.NoError(t, ioutil.WriteFile(aPath, []byte("6\n"), 0644))
+	lines, _ = readFileWatchTest(t, w, 1)
+	assert.Equal(t, []string{"6"}, lines)
+	assertFileWatchEmpty(t, w)
+}
+
+func TestFileWatchMultipart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_watch_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	aPath := filepath.Join(dir, "a.log")
+	appendFileWatchTest(t, aPath, "a\nb\n\nc\n")
+
+	w := newFileWatchTest(t, aPath, true, types.NoopMgr(), "")
+	defer w.CloseAsync()
+
+	lines, _ := readFileWatchTest(t, w, 1)
+	assert.Equal(t, []string{"a", "b"}, lines)
+	assertFileWatchEmpty(t, w)
+
+	appendFileWatchTest(t, aPath, "d\n\n")
+	lines, _ = readFileWatchTest(t, w, 1)
+	assert.Equal(t, []string{"c", "d"}, lines)
+}
+
+func TestFileWatchOffsets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_watch_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	aPath := filepath.Join(dir, "a.log")
+	appendFileWatchTest(t, aPath, "foo\nbar\nbaz\n")
+
+	memCache, err := cache.NewMemory(cache.NewConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	mgr := &fakeCacheMgr{caches: map[string]types.Cache{"foocache": memCache}}
+
+	w := newFileWatchTest(t, filepath.Join(dir, "*.log"), false, mgr, "foocache")
+
+	lines, ackFns := readFileWatchTest(t, w, 3)
+	assert.Equal(t, []string{"foo", "bar", "baz"}, lines)
+
+	// Only the offsets of lines of which all prior lines are acknowledged are
+	// committed.
+	require.NoError(t, ackFns[0](context.Background(), response.NewAck()))
+	require.NoError(t, ackFns[2](context.Background(), response.NewAck()))
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+
+	w = newFileWatchTest(t, filepath.Join(dir, "*.log"), false, mgr, "foocache")
+	defer w.CloseAsync()
+
+	lines, _ = readFileWatchTest(t, w, 2)
+	assert.Equal(t, []string{"bar", "baz"}, lines)
+	assertFileWatchEmpty(t, w)
+}
+
+func TestFileWatchCut(t *testing.T) {
+	w := &FileWatch{delim: []byte("||"), maxBuffer: 4}
+
+	f := &tailedFile{buf: []byte("foo||||barbazqux||ab"), readOffset: 120}
+	var lines []string
+	var ends []int64
+	for {
+		msg, end := w.cut(f, false)
+		if msg == nil {
+			break
+		}
+		lines = append(lines, string(message.GetAllBytes(msg)[0]))
+		ends = append(ends, end)
+	}
+
+	// Lines longer than the buffer are split, and the final line is left
+	// until it is terminated.
+	assert.Equal(t, []string{"foo", "barb", "azqu", "x"}, lines)
+	assert.Equal(t, []int64{105, 111, 115, 118}, ends)
+
+	msg, end := w.cut(f, true)
+	require.NotNil(t, msg)
+	assert.Equal(t, "ab", string(msg.Get(0).Get()))
+	assert.Equal(t, int64(120), end)
+}
@@ -0,0 +1,400 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/storage"
+	"github.com/Jeffail/benthos/v3/internal/codec"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"google.golang.org/api/iterator"
+)
+
+//------------------------------------------------------------------------------
+
+// GCPCloudStoragePubSubConfig contains configuration fields for consuming
+// object notifications from a GCP Cloud Pub/Sub subscription.
+type GCPCloudStoragePubSubConfig struct {
+	Project      string `json:"project" yaml:"project"`
+	Subscription string `json:"subscription" yaml:"subscription"`
+}
+
+// GCPCloudStorageConfig contains configuration fields for the GCP Cloud
+// Storage input type.
+type GCPCloudStorageConfig struct {
+	Bucket        string                      `json:"bucket" yaml:"bucket"`
+	Prefix        string                      `json:"prefix" yaml:"prefix"`
+	Codec         string                      `json:"codec" yaml:"codec"`
+	MaxBuffer     int                         `json:"max_buffer" yaml:"max_buffer"`
+	DeleteObjects bool                        `json:"delete_objects" yaml:"delete_objects"`
+	PubSub        GCPCloudStoragePubSubConfig `json:"pubsub" yaml:"pubsub"`
+}
+
+// NewGCPCloudStorageConfig creates a new GCPCloudStorageConfig with default
+// values.
+func NewGCPCloudStorageConfig() GCPCloudStorageConfig {
+	return GCPCloudStorageConfig{
+		Bucket:        "",
+		Prefix:        "",
+		Codec:         "all-bytes",
+		MaxBuffer:     1000000,
+		DeleteObjects: false,
+		PubSub: GCPCloudStoragePubSubConfig{
+			Project:      "",
+			Subscription: "",
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// gcsObjectTarget is an object to be consumed along with a function to call
+// once all of its messages have been acknowledged, or when it is not consumed.
+type gcsObjectTarget struct {
+	bucket string
+	name   string
+	attrs  *storage.ObjectAttrs
+	ackFn  func(err error)
+}
+
+// gcsReading is an object that is being consumed with a codec.
+type gcsReading struct {
+	target gcsObjectTarget
+	reader codec.Reader
+}
+
+// gcsNotificationTarget returns the object referenced by a Cloud Storage
+// notification, or false if the notification is not of a new object.
+func gcsNotificationTarget(attributes map[string]string) (bucket, name string, ok bool) {
+	if attributes["eventType"] != "OBJECT_FINALIZE" {
+		return "", "", false
+	}
+	bucket, name = attributes["bucketId"], attributes["objectId"]
+	return bucket, name, len(bucket) > 0 && len(name) > 0
+}
+
+// GCPCloudStorage is a benthos reader.Async implementation that consumes
+// objects from a GCP Cloud Storage bucket, either by listing the bucket or by
+// consuming object notifications from a Cloud Pub/Sub subscription.
+type GCPCloudStorage struct {
+	conf      GCPCloudStorageConfig
+	codecCtor codec.ReaderConstructor
+
+	// Accessed only by ReadWithContext.
+	objects  *storage.ObjectIterator
+	lastName string
+	current  *gcsReading
+
+	mut         sync.Mutex
+	client      *storage.Client
+	notifsChan  chan *pubsub.Message
+	readCtx     context.Context
+	closeReadFn context.CancelFunc
+	closed      bool
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewGCPCloudStorage creates a new GCP Cloud Storage input type.
+func NewGCPCloudStorage(conf GCPCloudStorageConfig, log log.Modular, stats metrics.Type) (*GCPCloudStorage, error) {
+	if len(conf.Bucket) == 0 && len(conf.PubSub.Subscription) == 0 {
+		return nil, errors.New("either a bucket or a pubsub subscription must be specified")
+	}
+	if len(conf.PubSub.Subscription) > 0 && len(conf.PubSub.Project) == 0 {
+		return nil, errors.New("a pubsub project must be specified along with a subscription")
+	}
+	g := &GCPCloudStorage{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	var err error
+	if g.codecCtor, err = codec.GetReader(conf.Codec, codec.ReaderConfig{
+		MaxScanTokenSize: conf.MaxBuffer,
+	}); err != nil {
+		return nil, err
+	}
+	g.readCtx, g.closeReadFn = context.WithCancel(context.Background())
+	return g, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext attempts to establish a connection to the target bucket
+// and, if configured, the notification subscription.
+func (g *GCPCloudStorage) ConnectWithContext(ctx context.Context) error {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	if g.closed {
+		return types.ErrTypeClosed
+	}
+	if g.client != nil {
+		return nil
+	}
+
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if len(g.conf.PubSub.Subscription) == 0 {
+		g.client = client
+		g.objects = client.Bucket(g.conf.Bucket).Objects(g.readCtx, &storage.Query{
+			Prefix: g.conf.Prefix,
+		})
+		g.log.Infof("Downloading GCP Cloud Storage objects from bucket: %v\n", g.conf.Bucket)
+		return nil
+	}
+
+	psClient, err := pubsub.NewClient(context.Background(), g.conf.PubSub.Project)
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	notifsChan := make(chan *pubsub.Message)
+	go func() {
+		sub := psClient.Subscription(g.conf.PubSub.Subscription)
+		rerr := sub.Receive(g.readCtx, func(ctx context.Context, m *pubsub.Message) {
+			select {
+			case notifsChan <- m:
+			case <-ctx.Done():
+				m.Nack()
+			}
+		})
+		if rerr != nil && rerr != context.Canceled {
+			g.log.Errorf("Subscription error: %v\n", rerr)
+		}
+		g.mut.Lock()
+		client.Close()
+		psClient.Close()
+		g.client, g.notifsChan = nil, nil
+		close(notifsChan)
+		g.mut.Unlock()
+	}()
+
+	g.client, g.notifsChan = client, notifsChan
+	g.log.Infof("Downloading GCP Cloud Storage objects from notifications of subscription: %v\n", g.conf.PubSub.Subscription)
+	return nil
+}
+
+// lostConnection closes a client that is no longer able to list the bucket so
+// that the listing is restarted once reconnected, and returns
+// types.ErrNotConnected.
+func (g *GCPCloudStorage) lostConnection(client *storage.Client) error {
+	g.mut.Lock()
+	if g.client == client {
+		g.client.Close()
+		g.client = nil
+	}
+	g.mut.Unlock()
+	return types.ErrNotConnected
+}
+
+//------------------------------------------------------------------------------
+
+// nextTarget returns the next object to consume, either from the listing of
+// the bucket or from the notification subscription.
+func (g *GCPCloudStorage) nextTarget(ctx context.Context, client *storage.Client, notifsChan chan *pubsub.Message) (gcsObjectTarget, error) {
+	if notifsChan == nil {
+		if g.objects == nil {
+			return gcsObjectTarget{}, types.ErrTypeClosed
+		}
+		attrs, err := g.objects.Next()
+		for err == nil && len(g.lastName) > 0 && attrs.Name <= g.lastName {
+			// Objects are listed in lexicographical order, and therefore when
+			// the listing is restarted we skip those already consumed.
+			attrs, err = g.objects.Next()
+		}
+		if err == iterator.Done {
+			g.objects = nil
+			return gcsObjectTarget{}, types.ErrTypeClosed
+		}
+		if err != nil {
+			g.log.Errorf("Failed to list objects: %v\n", err)
+			return gcsObjectTarget{}, g.lostConnection(client)
+		}
+		g.lastName = attrs.Name
+		return gcsObjectTarget{
+			bucket: attrs.Bucket,
+			name:   attrs.Name,
+			attrs:  attrs,
+			ackFn:  func(error) {},
+		}, nil
+	}
+
+	for {
+		var notif *pubsub.Message
+		var open bool
+		select {
+		case notif, open = <-notifsChan:
+		case <-ctx.Done():
+			return gcsObjectTarget{}, types.ErrTimeout
+		}
+		if !open {
+			return gcsObjectTarget{}, types.ErrNotConnected
+		}
+
+		bucket, name, ok := gcsNotificationTarget(notif.Attributes)
+		if !ok {
+			notif.Ack()
+			continue
+		}
+		if len(g.conf.Bucket) > 0 && bucket != g.conf.Bucket {
+			notif.Ack()
+			continue
+		}
+
+		attrs, err := client.Bucket(bucket).Object(name).Attrs(ctx)
+		if err != nil {
+			if err == storage.ErrObjectNotExist {
+				notif.Ack()
+				continue
+			}
+			notif.Nack()
+			return gcsObjectTarget{}, err
+		}
+		return gcsObjectTarget{
+			bucket: bucket,
+			name:   name,
+			attrs:  attrs,
+			ackFn: func(err error) {
+				if err != nil {
+					notif.Nack()
+				} else {
+					notif.Ack()
+				}
+			},
+		}, nil
+	}
+}
+
+// objectAckFn returns a function that is called once all messages of an object
+// are acknowledged, which deletes the object if configured to do so.
+func (g *GCPCloudStorage) objectAckFn(client *storage.Client, target gcsObjectTarget) codec.ReaderAckFn {
+	return func(ctx context.Context, err error) error {
+		if err == nil && g.conf.DeleteObjects {
+			if err = client.Bucket(target.bucket).Object(target.name).Delete(ctx); err != nil && err != storage.ErrObjectNotExist {
+				target.ackFn(err)
+				return fmt.Errorf("failed to delete object %v: %v", target.name, err)
+			}
+			err = nil
+		}
+		target.ackFn(err)
+		return nil
+	}
+}
+
+// openNext opens the next object to consume with the codec.
+func (g *GCPCloudStorage) openNext(ctx context.Context, client *storage.Client, notifsChan chan *pubsub.Message) error {
+	for {
+		target, err := g.nextTarget(ctx, client, notifsChan)
+		if err != nil {
+			return err
+		}
+
+		obj, err := client.Bucket(target.bucket).Object(target.name).NewReader(g.readCtx)
+		if err != nil {
+			if err == storage.ErrObjectNotExist {
+				target.ackFn(nil)
+				continue
+			}
+			target.ackFn(err)
+			return err
+		}
+
+		r, err := g.codecCtor(obj, g.objectAckFn(client, target))
+		if err != nil {
+			g.log.Errorf("Failed to read object %v: %v\n", target.name, err)
+			target.ackFn(nil)
+			continue
+		}
+		g.current = &gcsReading{target: target, reader: r}
+		return nil
+	}
+}
+
+// ReadWithContext attempts to read a new message from the objects of the
+// target bucket.
+func (g *GCPCloudStorage) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	g.mut.Lock()
+	client, notifsChan, closed := g.client, g.notifsChan, g.closed
+	g.mut.Unlock()
+	if closed {
+		return nil, nil, types.ErrTypeClosed
+	}
+	if client == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	for {
+		if g.current == nil {
+			if err := g.openNext(ctx, client, notifsChan); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		parts, codecAckFn, err := g.current.reader.Next(ctx)
+		if err != nil {
+			target := g.current.target
+			g.current.reader.Close(ctx)
+			g.current = nil
+			if err != io.EOF {
+				g.log.Errorf("Failed to read object %v: %v\n", target.name, err)
+			}
+			continue
+		}
+
+		attrs := g.current.target.attrs
+		msg := message.New(nil)
+		for _, p := range parts {
+			meta := p.Metadata()
+			for k, v := range attrs.Metadata {
+				meta.Set(k, v)
+			}
+			meta.Set("gcs_key", attrs.Name).
+				Set("gcs_bucket", attrs.Bucket).
+				Set("gcs_last_modified", attrs.Updated.Format(time.RFC3339)).
+				Set("gcs_last_modified_unix", strconv.FormatInt(attrs.Updated.Unix(), 10)).
+				Set("gcs_content_type", attrs.ContentType)
+			if len(attrs.ContentEncoding) > 0 {
+				meta.Set("gcs_content_encoding", attrs.ContentEncoding)
+			}
+			msg.Append(p)
+		}
+		return msg, func(ctx context.Context, res types.Response) error {
+			return codecAckFn(ctx, res.Error())
+		}, nil
+	}
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (g *GCPCloudStorage) CloseAsync() {
+	g.mut.Lock()
+	g.closed = true
+	g.closeReadFn()
+	if g.notifsChan == nil && g.client != nil {
+		g.client.Close()
+		g.client = nil
+	}
+	g.mut.Unlock()
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (g *GCPCloudStorage) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,80 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCPCloudStorageConfigErrors(t *testing.T) {
+	conf := NewGCPCloudStorageConfig()
+	_, err := NewGCPCloudStorage(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.PubSub.Subscription = "foo"
+	_, err = NewGCPCloudStorage(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.PubSub.Project = "bar"
+	conf.Codec = "nope"
+	_, err = NewGCPCloudStorage(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Codec = "lines"
+	_, err = NewGCPCloudStorage(conf, log.Noop(), metrics.Noop())
+	assert.NoError(t, err)
+}
+
+func TestGCPCloudStorageNotificationTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes map[string]string
+		bucket     string
+		object     string
+		ok         bool
+	}{
+		{
+			name: "finalize",
+			attributes: map[string]string{
+				"eventType": "OBJECT_FINALIZE",
+				"bucketId":  "foo",
+				"objectId":  "bar/baz.json",
+			},
+			bucket: "foo",
+			object: "bar/baz.json",
+			ok:     true,
+		},
+		{
+			name: "delete",
+			attributes: map[string]string{
+				"eventType": "OBJECT_DELETE",
+				"bucketId":  "foo",
+				"objectId":  "bar/baz.json",
+			},
+		},
+		{
+			name: "missing object",
+			attributes: map[string]string{
+				"eventType": "OBJECT_FINALIZE",
+				"bucketId":  "foo",
+			},
+		},
+		{
+			name: "no attributes",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			bucket, object, ok := gcsNotificationTarget(test.attributes)
+			assert.Equal(t, test.ok, ok)
+			if test.ok {
+				assert.Equal(t, test.bucket, bucket)
+				assert.Equal(t, test.object, object)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package reader
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"sync"
 	"time"
@@ -50,6 +51,7 @@ type GCPPubSub struct {
 	conf GCPPubSubConfig
 
 	subscription *pubsub.Subscription
+	deadLetter   *pubsub.DeadLetterPolicy
 	msgsChan     chan *pubsub.Message
 	closeFunc    context.CancelFunc
 	subMut       sync.Mutex
@@ -86,7 +88,7 @@ func (c *GCPPubSub) Connect() error {
 
 // ConnectWithContext attempts to establish a connection to the target
 // subscription.
-func (c *GCPPubSub) ConnectWithContext(ignored context.Context) error {
+func (c *GCPPubSub) ConnectWithContext(ctx context.Context) error {
 	c.subMut.Lock()
 	defer c.subMut.Unlock()
 	if c.subscription != nil {
@@ -97,6 +99,15 @@ func (c *GCPPubSub) ConnectWithContext(ignored context.Context) error {
 	sub.ReceiveSettings.MaxOutstandingMessages = c.conf.MaxOutstandingMessages
 	sub.ReceiveSettings.MaxOutstandingBytes = c.conf.MaxOutstandingBytes
 
+	// The dead-letter policy is only added as metadata, and therefore failing
+	// to obtain it (usually due to permissions) isn't fatal.
+	c.deadLetter = nil
+	if subConf, err := sub.Config(ctx); err != nil {
+		c.log.Warnf("Failed to obtain subscription config, dead-letter metadata will not be added: %v\n", err)
+	} else {
+		c.deadLetter = subConf.DeadLetterPolicy
+	}
+
 	subCtx, cancel := context.WithCancel(context.Background())
 	msgsChan := make(chan *pubsub.Message, c.conf.MaxBatchCount)
 
@@ -126,10 +137,56 @@ func (c *GCPPubSub) ConnectWithContext(ignored context.Context) error {
 	return nil
 }
 
+func gcpPubSubMessageToPart(gmsg *pubsub.Message, deadLetter *pubsub.DeadLetterPolicy) types.Part {
+	part := message.NewPart(gmsg.Data)
+	part.SetMetadata(metadata.New(gmsg.Attributes))
+	meta := part.Metadata()
+	meta.Set("gcp_pubsub_publish_time_unix", strconv.FormatInt(gmsg.PublishTime.Unix(), 10))
+	if gmsg.OrderingKey != "" {
+		meta.Set("gcp_pubsub_ordering_key", gmsg.OrderingKey)
+	}
+	if gmsg.DeliveryAttempt != nil {
+		meta.Set("gcp_pubsub_delivery_attempt", strconv.Itoa(*gmsg.DeliveryAttempt))
+	}
+	if deadLetter != nil && deadLetter.DeadLetterTopic != "" {
+		meta.Set("gcp_pubsub_dead_letter_topic", deadLetter.DeadLetterTopic)
+		meta.Set("gcp_pubsub_max_delivery_attempts", strconv.Itoa(deadLetter.MaxDeliveryAttempts))
+	}
+	return part
+}
+
+// gcpPubSubAck acknowledges or rejects a message and waits for the result. When
+// exactly-once delivery is enabled on the subscription a successful ack
+// guarantees that the message will not be redelivered, otherwise the result is
+// immediately successful.
+func gcpPubSubAck(ctx context.Context, gmsg *pubsub.Message, ack bool) error {
+	var res *pubsub.AckResult
+	if ack {
+		res = gmsg.AckWithResult()
+	} else {
+		res = gmsg.NackWithResult()
+	}
+	if res == nil {
+		return nil
+	}
+
+	status, err := res.Get(ctx)
+	if err == nil && status != pubsub.AcknowledgeStatusSuccess {
+		err = fmt.Errorf("unexpected status code: %v", status)
+	}
+	if err != nil {
+		if ack {
+			return fmt.Errorf("failed to ack message %v: %v", gmsg.ID, err)
+		}
+		return fmt.Errorf("failed to nack message %v: %v", gmsg.ID, err)
+	}
+	return nil
+}
+
 // ReadWithContext attempts to read a new message from the target subscription.
 func (c *GCPPubSub) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
 	c.subMut.Lock()
-	msgsChan := c.msgsChan
+	msgsChan, deadLetter := c.msgsChan, c.deadLetter
 	c.subMut.Unlock()
 	if msgsChan == nil {
 		return nil, nil, types.ErrNotConnected
@@ -148,25 +205,17 @@ func (c *GCPPubSub) ReadWithContext(ctx context.Context) (types.Message, AsyncAc
 		return nil, nil, types.ErrNotConnected
 	}
 
-	part := message.NewPart(gmsg.Data)
-	part.SetMetadata(metadata.New(gmsg.Attributes))
-	part.Metadata().Set("gcp_pubsub_publish_time_unix", strconv.FormatInt(gmsg.PublishTime.Unix(), 10))
-	msg.Append(part)
+	msg.Append(gcpPubSubMessageToPart(gmsg, deadLetter))
 
 	return msg, func(ctx context.Context, res types.Response) error {
-		if res.Error() != nil {
-			gmsg.Nack()
-		} else {
-			gmsg.Ack()
-		}
-		return nil
+		return gcpPubSubAck(ctx, gmsg, res.Error() == nil)
 	}, nil
 }
 
 // Read attempts to read a new message from the target subscription.
 func (c *GCPPubSub) Read() (types.Message, error) {
 	c.subMut.Lock()
-	msgsChan := c.msgsChan
+	msgsChan, deadLetter := c.msgsChan, c.deadLetter
 	c.subMut.Unlock()
 	if msgsChan == nil {
 		return nil, types.ErrNotConnected
@@ -179,10 +228,7 @@ func (c *GCPPubSub) Read() (types.Message, error) {
 		return nil, types.ErrNotConnected
 	}
 	c.pendingMsgs = append(c.pendingMsgs, gmsg)
-	part := message.NewPart(gmsg.Data)
-	part.SetMetadata(metadata.New(gmsg.Attributes))
-	part.Metadata().Set("gcp_pubsub_publish_time_unix", strconv.FormatInt(gmsg.PublishTime.Unix(), 10))
-	msg.Append(part)
+	msg.Append(gcpPubSubMessageToPart(gmsg, deadLetter))
 
 batchLoop:
 	for msg.Len() < c.conf.MaxBatchCount {
@@ -196,10 +242,7 @@ batchLoop:
 			return nil, types.ErrNotConnected
 		}
 		c.pendingMsgs = append(c.pendingMsgs, gmsg)
-		part := message.NewPart(gmsg.Data)
-		part.SetMetadata(metadata.New(gmsg.Attributes))
-		part.Metadata().Set("gcp_pubsub_publish_time_unix", strconv.FormatInt(gmsg.PublishTime.Unix(), 10))
-		msg.Append(part)
+		msg.Append(gcpPubSubMessageToPart(gmsg, deadLetter))
 	}
 
 	return msg, nil
@@ -208,15 +251,14 @@ batchLoop:
 // Acknowledge confirms whether or not our unacknowledged messages have been
 // successfully propagated or not.
 func (c *GCPPubSub) Acknowledge(err error) error {
+	var ackErr error
 	for _, msg := range c.pendingMsgs {
-		if err == nil {
-			msg.Ack()
-		} else {
-			msg.Nack()
+		if aErr := gcpPubSubAck(context.Background(), msg, err == nil); aErr != nil && ackErr == nil {
+			ackErr = aErr
 		}
 	}
 	c.pendingMsgs = nil
-	return nil
+	return ackErr
 }
 
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
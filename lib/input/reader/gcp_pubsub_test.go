@@ -0,0 +1,111 @@
+package reader
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCPPubSubMessageToPart(t *testing.T) {
+	deliveryAttempt := 3
+	part := gcpPubSubMessageToPart(&pubsub.Message{
+		Data:            []byte("hello world"),
+		Attributes:      map[string]string{"foo": "bar"},
+		PublishTime:     time.Unix(1600000000, 0),
+		OrderingKey:     "baz",
+		DeliveryAttempt: &deliveryAttempt,
+	}, &pubsub.DeadLetterPolicy{
+		DeadLetterTopic:     "projects/foo/topics/dead",
+		MaxDeliveryAttempts: 5,
+	})
+
+	assert.Equal(t, "hello world", string(part.Get()))
+	assert.Equal(t, "bar", part.Metadata().Get("foo"))
+	assert.Equal(t, "1600000000", part.Metadata().Get("gcp_pubsub_publish_time_unix"))
+	assert.Equal(t, "baz", part.Metadata().Get("gcp_pubsub_ordering_key"))
+	assert.Equal(t, "3", part.Metadata().Get("gcp_pubsub_delivery_attempt"))
+	assert.Equal(t, "projects/foo/topics/dead", part.Metadata().Get("gcp_pubsub_dead_letter_topic"))
+	assert.Equal(t, "5", part.Metadata().Get("gcp_pubsub_max_delivery_attempts"))
+
+	part = gcpPubSubMessageToPart(&pubsub.Message{
+		Data: []byte("hello world"),
+	}, nil)
+	assert.Equal(t, "", part.Metadata().Get("gcp_pubsub_ordering_key"))
+	assert.Equal(t, "", part.Metadata().Get("gcp_pubsub_delivery_attempt"))
+	assert.Equal(t, "", part.Metadata().Get("gcp_pubsub_dead_letter_topic"))
+	assert.Equal(t, "", part.Metadata().Get("gcp_pubsub_max_delivery_attempts"))
+}
+
+func TestGCPPubSubExactlyOnceAndDeadLetter(t *testing.T) {
+	srv := pstest.NewServer()
+	defer srv.Close()
+
+	prevHost, hadHost := os.LookupEnv("PUBSUB_EMULATOR_HOST")
+	require.NoError(t, os.Setenv("PUBSUB_EMULATOR_HOST", srv.Addr))
+	defer func() {
+		if hadHost {
+			os.Setenv("PUBSUB_EMULATOR_HOST", prevHost)
+		} else {
+			os.Unsetenv("PUBSUB_EMULATOR_HOST")
+		}
+	}()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	client, err := pubsub.NewClient(ctx, "foo")
+	require.NoError(t, err)
+	defer client.Close()
+
+	topic, err := client.CreateTopic(ctx, "in")
+	require.NoError(t, err)
+	deadTopic, err := client.CreateTopic(ctx, "dead")
+	require.NoError(t, err)
+
+	_, err = client.CreateSubscription(ctx, "sub", pubsub.SubscriptionConfig{
+		Topic:                     topic,
+		EnableExactlyOnceDelivery: true,
+		DeadLetterPolicy: &pubsub.DeadLetterPolicy{
+			DeadLetterTopic:     deadTopic.String(),
+			MaxDeliveryAttempts: 5,
+		},
+	})
+	require.NoError(t, err)
+
+	conf := NewGCPPubSubConfig()
+	conf.ProjectID = "foo"
+	conf.SubscriptionID = "sub"
+
+	r, err := NewGCPPubSub(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, r.ConnectWithContext(ctx))
+	defer func() {
+		r.CloseAsync()
+		assert.NoError(t, r.WaitForClose(time.Second))
+	}()
+
+	_, err = topic.Publish(ctx, &pubsub.Message{Data: []byte("hello world")}).Get(ctx)
+	require.NoError(t, err)
+
+	msg, ackFn, err := r.ReadWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, msg.Len())
+
+	part := msg.Get(0)
+	assert.Equal(t, "hello world", string(part.Get()))
+	assert.Equal(t, "projects/foo/topics/dead", part.Metadata().Get("gcp_pubsub_dead_letter_topic"))
+	assert.Equal(t, "5", part.Metadata().Get("gcp_pubsub_max_delivery_attempts"))
+
+	require.NoError(t, ackFn(ctx, response.NewAck()))
+	assert.Len(t, srv.Messages(), 1)
+	assert.Equal(t, 1, srv.Messages()[0].Acks)
+}
@@ -0,0 +1,343 @@
+package reader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/grpcconf"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//------------------------------------------------------------------------------
+
+// GRPCServerConfig contains configuration fields for the GRPCServer input
+// type.
+type GRPCServerConfig struct {
+	Address       string `json:"address" yaml:"address"`
+	Method        string `json:"method" yaml:"method"`
+	RPCType       string `json:"rpc_type" yaml:"rpc_type"`
+	DescriptorSet string `json:"descriptor_set" yaml:"descriptor_set"`
+	Timeout       string `json:"timeout" yaml:"timeout"`
+	CertFile      string `json:"cert_file" yaml:"cert_file"`
+	KeyFile       string `json:"key_file" yaml:"key_file"`
+}
+
+// NewGRPCServerConfig creates a new GRPCServerConfig with default values.
+func NewGRPCServerConfig() GRPCServerConfig {
+	return GRPCServerConfig{
+		Address:       "0.0.0.0:50051",
+		Method:        "",
+		RPCType:       "unary",
+		DescriptorSet: "",
+		Timeout:       "5s",
+		CertFile:      "",
+		KeyFile:       "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type grpcServerTransaction struct {
+	msg     types.Message
+	resChan chan error
+}
+
+// GRPCServer is an input type that serves a gRPC method and emits the requests
+// made to it as messages.
+type GRPCServer struct {
+	conf       GRPCServerConfig
+	fullMethod string
+	method     *desc.MethodDescriptor
+	timeout    time.Duration
+	creds      credentials.TransportCredentials
+
+	serverMut sync.Mutex
+	server    *grpc.Server
+	serveDone chan struct{}
+
+	transactions chan grpcServerTransaction
+	closeOnce    sync.Once
+	closed       chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewGRPCServer creates a new GRPCServer input type.
+func NewGRPCServer(conf GRPCServerConfig, log log.Modular, stats metrics.Type) (*GRPCServer, error) {
+	g := &GRPCServer{
+		conf:         conf,
+		transactions: make(chan grpcServerTransaction),
+		closed:       make(chan struct{}),
+		log:          log,
+		stats:        stats,
+	}
+
+	var err error
+	if _, _, g.fullMethod, err = grpcconf.ParseMethod(conf.Method); err != nil {
+		return nil, err
+	}
+	switch conf.RPCType {
+	case "unary", "client_stream", "bidi_stream":
+	default:
+		return nil, fmt.Errorf("rpc type not recognised: %v", conf.RPCType)
+	}
+	if len(conf.DescriptorSet) > 0 {
+		if g.method, err = grpcconf.LoadMethod(conf.DescriptorSet, conf.Method); err != nil {
+			return nil, err
+		}
+		if g.method.IsClientStreaming() != (conf.RPCType != "unary") ||
+			g.method.IsServerStreaming() != (conf.RPCType == "bidi_stream") {
+			return nil, fmt.Errorf("method '%v' does not match the rpc type %v", conf.Method, conf.RPCType)
+		}
+	}
+	if g.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %v", err)
+	}
+	if len(conf.CertFile) > 0 || len(conf.KeyFile) > 0 {
+		if g.creds, err = credentials.NewServerTLSFromFile(conf.CertFile, conf.KeyFile); err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %v", err)
+		}
+	}
+	return g, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext begins serving the gRPC method.
+func (g *GRPCServer) ConnectWithContext(ctx context.Context) error {
+	g.serverMut.Lock()
+	defer g.serverMut.Unlock()
+
+	select {
+	case <-g.closed:
+		return types.ErrTypeClosed
+	default:
+	}
+	if g.server != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", g.conf.Address)
+	if err != nil {
+		return err
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.CustomCodec(grpcconf.Codec{}),
+	}
+	if g.creds != nil {
+		opts = append(opts, grpc.Creds(g.creds))
+	}
+	server := grpc.NewServer(opts...)
+
+	service, name, _, _ := grpcconf.ParseMethod(g.conf.Method)
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: service,
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    name,
+			Handler:       g.handle,
+			ClientStreams: g.conf.RPCType != "unary",
+			ServerStreams: g.conf.RPCType == "bidi_stream",
+		}},
+	}, g)
+
+	g.server = server
+	g.serveDone = make(chan struct{})
+	go func() {
+		defer close(g.serveDone)
+		if err := server.Serve(listener); err != nil {
+			g.log.Errorf("Server error: %v\n", err)
+		}
+	}()
+
+	g.log.Infof("Serving gRPC method %v at address: %v\n", g.fullMethod, listener.Addr())
+	return nil
+}
+
+// handle consumes the requests of a call until the client has finished
+// sending them, each request is responded to once the message it results in
+// has been acknowledged.
+func (g *GRPCServer) handle(_ interface{}, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+
+	subtype := "proto"
+	if s, ok := grpc.ServerTransportStreamFromContext(ctx).(interface{ ContentSubtype() string }); ok && s.ContentSubtype() != "" {
+		subtype = s.ContentSubtype()
+	}
+	if subtype != "proto" && subtype != "json" {
+		return status.Errorf(codes.InvalidArgument, "content subtype not supported: %v", subtype)
+	}
+
+	response := []byte{}
+	if subtype == "json" {
+		response = []byte("{}")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for {
+		var frame []byte
+		if err := stream.RecvMsg(&frame); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		part, err := g.decode(frame, subtype == "json")
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "failed to parse request: %v", err)
+		}
+		meta := part.Metadata()
+		for k, v := range md {
+			if len(v) > 0 && !strings.HasPrefix(k, ":") {
+				meta.Set(k, v[0])
+			}
+		}
+		meta.Set("grpc_method", g.fullMethod)
+		meta.Set("grpc_content_subtype", subtype)
+
+		msg := message.New(nil)
+		msg.Append(part)
+		if err = g.deliver(ctx, msg); err != nil {
+			return err
+		}
+
+		if g.conf.RPCType == "bidi_stream" {
+			if err = stream.SendMsg(&response); err != nil {
+				return err
+			}
+		}
+		if g.conf.RPCType == "unary" {
+			break
+		}
+	}
+
+	if g.conf.RPCType == "bidi_stream" {
+		return nil
+	}
+	return stream.SendMsg(&response)
+}
+
+// decode creates a message part from a request, which when a descriptor set
+// is provided is parsed as the input type of the method and converted into a
+// JSON document.
+func (g *GRPCServer) decode(frame []byte, isJSON bool) (types.Part, error) {
+	if g.method == nil {
+		return message.NewPart(frame), nil
+	}
+
+	msg := dynamic.NewMessage(g.method.GetInputType())
+	var err error
+	if isJSON {
+		err = msg.UnmarshalJSON(frame)
+	} else {
+		err = msg.Unmarshal(frame)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if frame, err = msg.MarshalJSON(); err != nil {
+		return nil, err
+	}
+	return message.NewPart(frame), nil
+}
+
+// deliver sends a message through the pipeline and waits for it to be
+// acknowledged.
+func (g *GRPCServer) deliver(ctx context.Context, msg types.Message) error {
+	resChan := make(chan error, 1)
+	select {
+	case g.transactions <- grpcServerTransaction{msg: msg, resChan: resChan}:
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-g.closed:
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+
+	timeout := time.NewTimer(g.timeout)
+	defer timeout.Stop()
+
+	select {
+	case err := <-resChan:
+		if err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		return nil
+	case <-timeout.C:
+		return status.Error(codes.DeadlineExceeded, "timed out waiting for message to be delivered")
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-g.closed:
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ReadWithContext attempts to read a new message from the requests being
+// served.
+func (g *GRPCServer) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	select {
+	case tr := <-g.transactions:
+		return tr.msg, func(rctx context.Context, res types.Response) error {
+			select {
+			case tr.resChan <- res.Error():
+			default:
+			}
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, types.ErrTimeout
+	case <-g.closed:
+		return nil, nil, types.ErrTypeClosed
+	}
+}
+
+// CloseAsync shuts down the server and stops processing requests.
+func (g *GRPCServer) CloseAsync() {
+	g.closeOnce.Do(func() {
+		close(g.closed)
+
+		g.serverMut.Lock()
+		if g.server != nil {
+			g.server.Stop()
+		}
+		g.serverMut.Unlock()
+	})
+}
+
+// WaitForClose blocks until the server has closed down.
+func (g *GRPCServer) WaitForClose(timeout time.Duration) error {
+	g.serverMut.Lock()
+	serveDone := g.serveDone
+	g.serverMut.Unlock()
+
+	if serveDone == nil {
+		return nil
+	}
+	select {
+	case <-serveDone:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
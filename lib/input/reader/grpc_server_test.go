@@ -0,0 +1,222 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/grpcconf"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func writeGRPCServerTestDescriptorSet(t *testing.T, dir string) (string, []*desc.FileDescriptor) {
+	t.Helper()
+
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{
+			"ingest.proto": `
+syntax = "proto3";
+package benthos.test;
+message Event { string id = 1; int64 count = 2; }
+message Ack {}
+service Ingest {
+  rpc Send(Event) returns (Ack);
+  rpc SendStream(stream Event) returns (Ack);
+}`,
+		}),
+	}
+	files, err := parser.ParseFiles("ingest.proto")
+	require.NoError(t, err)
+
+	setBytes, err := proto.Marshal(desc.ToFileDescriptorSet(files...))
+	require.NoError(t, err)
+	setPath := filepath.Join(dir, "ingest.protoset")
+	require.NoError(t, ioutil.WriteFile(setPath, setBytes, 0644))
+	return setPath, files
+}
+
+func freeGRPCServerTestAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return addr
+}
+
+func TestGRPCServerConfigErrors(t *testing.T) {
+	conf := NewGRPCServerConfig()
+	_, err := NewGRPCServer(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Method = "/benthos.test.Ingest/Send"
+	conf.RPCType = "nope"
+	_, err = NewGRPCServer(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	dir, err := ioutil.TempDir("", "benthos_grpc_server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf.RPCType = "client_stream"
+	conf.DescriptorSet, _ = writeGRPCServerTestDescriptorSet(t, dir)
+	_, err = NewGRPCServer(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.RPCType = "unary"
+	conf.Timeout = "nope"
+	_, err = NewGRPCServer(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestGRPCServerUnary(t *testing.T) {
+	conf := NewGRPCServerConfig()
+	conf.Address = freeGRPCServerTestAddress(t)
+	conf.Method = "/benthos.test.Ingest/Send"
+
+	g, err := NewGRPCServer(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, g.ConnectWithContext(context.Background()))
+	defer func() {
+		g.CloseAsync()
+		assert.NoError(t, g.WaitForClose(time.Second))
+	}()
+
+	conn, err := grpc.Dial(conf.Address, grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.ForceCodec(grpcconf.Codec{})))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	go func() {
+		msg, ackFn, err := g.ReadWithContext(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "foo", string(msg.Get(0).Get()))
+		assert.Equal(t, "bar", msg.Get(0).Metadata().Get("baz"))
+		assert.Equal(t, "/benthos.test.Ingest/Send", msg.Get(0).Metadata().Get("grpc_method"))
+		assert.Equal(t, "proto", msg.Get(0).Metadata().Get("grpc_content_subtype"))
+		assert.NoError(t, ackFn(ctx, response.NewAck()))
+
+		_, ackFn, err = g.ReadWithContext(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, ackFn(ctx, response.NewError(errors.New("nope"))))
+	}()
+
+	req, res := []byte("foo"), []byte{}
+	callCtx := metadata.AppendToOutgoingContext(ctx, "baz", "bar")
+	require.NoError(t, conn.Invoke(callCtx, conf.Method, &req, &res))
+	assert.Empty(t, res)
+
+	err = conn.Invoke(ctx, conf.Method, &req, &res)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	err = conn.Invoke(ctx, "/benthos.test.Ingest/Nope", &req, &res)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestGRPCServerClientStreamDescriptor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_grpc_server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setPath, files := writeGRPCServerTestDescriptorSet(t, dir)
+
+	conf := NewGRPCServerConfig()
+	conf.Address = freeGRPCServerTestAddress(t)
+	conf.Method = "benthos.test.Ingest/SendStream"
+	conf.RPCType = "client_stream"
+	conf.DescriptorSet = setPath
+
+	g, err := NewGRPCServer(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, g.ConnectWithContext(context.Background()))
+	defer func() {
+		g.CloseAsync()
+		assert.NoError(t, g.WaitForClose(time.Second))
+	}()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	go func() {
+		for _, exp := range []string{`{"id":"foo","count":"1"}`, `{"id":"bar"}`, `{"id":"baz"}`} {
+			msg, ackFn, err := g.ReadWithContext(ctx)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.JSONEq(t, exp, string(msg.Get(0).Get()))
+			assert.NoError(t, ackFn(ctx, response.NewAck()))
+		}
+	}()
+
+	// Requests encoded as protobuf.
+	conn, err := grpc.Dial(conf.Address, grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.ForceCodec(grpcconf.Codec{})))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true}, "/benthos.test.Ingest/SendStream")
+	require.NoError(t, err)
+
+	event := dynamic.NewMessage(files[0].FindMessage("benthos.test.Event"))
+	event.SetFieldByName("id", "foo")
+	event.SetFieldByName("count", int64(1))
+	req, err := event.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(&req))
+
+	event.SetFieldByName("id", "bar")
+	event.SetFieldByName("count", int64(0))
+	req, err = event.Marshal()
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(&req))
+
+	require.NoError(t, stream.CloseSend())
+	var res []byte
+	require.NoError(t, stream.RecvMsg(&res))
+
+	// Requests encoded as JSON.
+	jsonConn, err := grpc.Dial(conf.Address, grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.ForceCodec(grpcconf.Codec{}), grpc.CallContentSubtype("json")))
+	require.NoError(t, err)
+	defer jsonConn.Close()
+
+	stream, err = jsonConn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true}, "/benthos.test.Ingest/SendStream")
+	require.NoError(t, err)
+
+	req = []byte(`{"id":"baz"}`)
+	require.NoError(t, stream.SendMsg(&req))
+	require.NoError(t, stream.CloseSend())
+	require.NoError(t, stream.RecvMsg(&res))
+	assert.Equal(t, "{}", string(res))
+
+	// Requests that do not match the input type are rejected.
+	stream, err = jsonConn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true}, "/benthos.test.Ingest/SendStream")
+	require.NoError(t, err)
+
+	req = []byte(`{"nope":"baz"}`)
+	require.NoError(t, stream.SendMsg(&req))
+	require.NoError(t, stream.CloseSend())
+	err = stream.RecvMsg(&res)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
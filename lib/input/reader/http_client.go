@@ -2,15 +2,48 @@ package reader
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/lib/util/http/client"
+	"github.com/Jeffail/gabs/v2"
 )
 
 //------------------------------------------------------------------------------
 
+// HTTPClientPaginationConfig contains fields for crawling the pages of a
+// paginated API.
+type HTTPClientPaginationConfig struct {
+	Mode            string `json:"mode" yaml:"mode"`
+	JSONPath        string `json:"json_path" yaml:"json_path"`
+	OffsetParam     string `json:"offset_param" yaml:"offset_param"`
+	OffsetStart     int    `json:"offset_start" yaml:"offset_start"`
+	OffsetIncrement int    `json:"offset_increment" yaml:"offset_increment"`
+	MaxPages        int    `json:"max_pages" yaml:"max_pages"`
+	Interval        string `json:"interval" yaml:"interval"`
+}
+
+// NewHTTPClientPaginationConfig creates a new HTTPClientPaginationConfig with
+// default values.
+func NewHTTPClientPaginationConfig() HTTPClientPaginationConfig {
+	return HTTPClientPaginationConfig{
+		Mode:            "none",
+		JSONPath:        "",
+		OffsetParam:     "offset",
+		OffsetStart:     0,
+		OffsetIncrement: 100,
+		MaxPages:        0,
+		Interval:        "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
 // HTTPClient is a reader that continuously polls an HTTP endpoint, providing an
 // optional payload each time.
 type HTTPClient struct {
@@ -18,6 +51,13 @@ type HTTPClient struct {
 	client  *client.Type
 
 	dropEmptyBodies bool
+
+	pagination HTTPClientPaginationConfig
+	interval   time.Duration
+	nextURL    string
+	offset     int
+	page       int
+	nextCrawl  time.Time
 }
 
 // HTTPClientOptFunc changes the behaviour of an HTTPClient reader.
@@ -31,21 +71,58 @@ func HTTPClientOptSetDropEmpty(dropEmpty bool) HTTPClientOptFunc {
 	}
 }
 
+// HTTPClientOptSetPagination sets the strategy used for crawling the pages of
+// a paginated API.
+func HTTPClientOptSetPagination(conf HTTPClientPaginationConfig) HTTPClientOptFunc {
+	return func(h *HTTPClient) {
+		h.pagination = conf
+	}
+}
+
 // NewHTTPClient creates a new HTTPClient reader type.
 func NewHTTPClient(payload types.Message, httpClient *client.Type, opts ...HTTPClientOptFunc) (*HTTPClient, error) {
 	h := &HTTPClient{
 		payload:         payload,
 		client:          httpClient,
 		dropEmptyBodies: true,
+		pagination:      NewHTTPClientPaginationConfig(),
 	}
 
 	for _, opt := range opts {
 		opt(h)
 	}
 
+	switch h.pagination.Mode {
+	case "", "none", "link_header":
+	case "json_path":
+		if len(h.pagination.JSONPath) == 0 {
+			return nil, fmt.Errorf("a json_path must be specified with pagination mode %v", h.pagination.Mode)
+		}
+	case "offset":
+		if len(h.pagination.OffsetParam) == 0 {
+			return nil, fmt.Errorf("an offset_param must be specified with pagination mode %v", h.pagination.Mode)
+		}
+		if h.pagination.OffsetIncrement <= 0 {
+			return nil, fmt.Errorf("offset_increment must be greater than zero, got %v", h.pagination.OffsetIncrement)
+		}
+	default:
+		return nil, fmt.Errorf("pagination mode not recognised: %v", h.pagination.Mode)
+	}
+	if len(h.pagination.Interval) > 0 {
+		var err error
+		if h.interval, err = time.ParseDuration(h.pagination.Interval); err != nil {
+			return nil, fmt.Errorf("failed to parse pagination interval: %v", err)
+		}
+	}
+	h.offset = h.pagination.OffsetStart
+
 	return h, nil
 }
 
+func (h *HTTPClient) paginated() bool {
+	return h.pagination.Mode != "" && h.pagination.Mode != "none"
+}
+
 //------------------------------------------------------------------------------
 
 // Connect establishes a connection.
@@ -62,29 +139,197 @@ func (h *HTTPClient) ConnectWithContext(ctx context.Context) (err error) {
 
 // ReadWithContext a new HTTPClient message.
 func (h *HTTPClient) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
-	res, err := h.client.Do(h.payload)
-	if err != nil {
-		if strings.Contains(err.Error(), "(Client.Timeout exceeded while awaiting headers)") {
-			err = types.ErrTimeout
+	if !h.paginated() {
+		res, err := h.client.Do(h.payload)
+		if err != nil {
+			return nil, nil, h.readErr(err)
+		}
+		return h.parse(res)
+	}
+
+	if h.page == 0 && !h.nextCrawl.IsZero() {
+		if wait := time.Until(h.nextCrawl); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return nil, nil, types.ErrTimeout
+			}
 		}
+	}
+
+	reqURL := h.nextURL
+	if h.pagination.Mode == "offset" {
+		var err error
+		if reqURL, err = h.offsetURL(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	res, err := h.client.DoWithURL(reqURL, h.payload)
+	if err != nil {
+		return nil, nil, h.readErr(err)
+	}
+
+	msg, err := h.client.ParseResponse(res)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	var msg types.Message
-	if msg, err = h.client.ParseResponse(res); err != nil {
+	h.page++
+	page := strconv.Itoa(h.page)
+	msg.Iter(func(i int, p types.Part) error {
+		p.Metadata().Set("http_client_page", page)
+		return nil
+	})
+
+	if next, ok := h.nextPage(res, msg); ok && (h.pagination.MaxPages <= 0 || h.page < h.pagination.MaxPages) {
+		h.nextURL = next
+	} else {
+		h.nextURL = ""
+		h.offset = h.pagination.OffsetStart
+		h.page = 0
+		h.nextCrawl = time.Now().Add(h.interval)
+	}
+
+	return h.filter(msg)
+}
+
+func (h *HTTPClient) readErr(err error) error {
+	if strings.Contains(err.Error(), "(Client.Timeout exceeded while awaiting headers)") {
+		err = types.ErrTimeout
+	}
+	return err
+}
+
+func (h *HTTPClient) parse(res *http.Response) (types.Message, AsyncAckFn, error) {
+	msg, err := h.client.ParseResponse(res)
+	if err != nil {
 		return nil, nil, err
 	}
+	return h.filter(msg)
+}
 
+func (h *HTTPClient) filter(msg types.Message) (types.Message, AsyncAckFn, error) {
 	if msg.Len() == 0 {
 		return nil, nil, types.ErrTimeout
 	}
 	if msg.Len() == 1 && msg.Get(0).IsEmpty() && h.dropEmptyBodies {
 		return nil, nil, types.ErrTimeout
 	}
-
 	return msg, noopAsyncAckFn, nil
 }
 
+//------------------------------------------------------------------------------
+
+// offsetURL returns the URL of the current page in offset mode, which is the
+// URL of the previous page or the configured URL with the offset query
+// parameter set.
+func (h *HTTPClient) offsetURL() (string, error) {
+	base := h.nextURL
+	if len(base) == 0 {
+		req, err := h.client.CreateRequest(h.payload)
+		if err != nil {
+			return "", err
+		}
+		base = req.URL.String()
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse page URL: %v", err)
+	}
+	query := u.Query()
+	query.Set(h.pagination.OffsetParam, strconv.Itoa(h.offset))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// nextPage determines the URL of the page following a response, returning
+// false if the response is the final page.
+func (h *HTTPClient) nextPage(res *http.Response, msg types.Message) (string, bool) {
+	var reqURL *url.URL
+	if res.Request != nil {
+		reqURL = res.Request.URL
+	}
+
+	switch h.pagination.Mode {
+	case "link_header":
+		for _, link := range res.Header["Link"] {
+			if next := parseNextLink(link); len(next) > 0 {
+				return resolvePageURL(reqURL, next)
+			}
+		}
+	case "json_path":
+		if msg.Len() == 0 {
+			return "", false
+		}
+		jObj, err := msg.Get(0).JSON()
+		if err != nil {
+			return "", false
+		}
+		if next, _ := gabs.Wrap(jObj).Path(h.pagination.JSONPath).Data().(string); len(next) > 0 {
+			return resolvePageURL(reqURL, next)
+		}
+	case "offset":
+		if msg.Len() == 0 || msg.Get(0).IsEmpty() {
+			return "", false
+		}
+		jObj, err := msg.Get(0).JSON()
+		if err == nil {
+			if len(h.pagination.JSONPath) > 0 {
+				jObj = gabs.Wrap(jObj).Path(h.pagination.JSONPath).Data()
+			}
+			if items, ok := jObj.([]interface{}); ok && len(items) == 0 {
+				return "", false
+			} else if !ok && len(h.pagination.JSONPath) > 0 {
+				return "", false
+			}
+		}
+		if reqURL == nil {
+			return "", false
+		}
+		h.offset += h.pagination.OffsetIncrement
+		return reqURL.String(), true
+	}
+	return "", false
+}
+
+// parseNextLink returns the target of a link with the relation type next from
+// the value of a Link header as per RFC 8288.
+func parseNextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		segments := strings.Split(link, ";")
+		target := strings.TrimSpace(segments[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range segments[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || !strings.EqualFold(strings.TrimSpace(kv[0]), "rel") {
+				continue
+			}
+			for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(kv[1]), `"`)) {
+				if strings.EqualFold(rel, "next") {
+					return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func resolvePageURL(base *url.URL, ref string) (string, bool) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	return u.String(), true
+}
+
+//------------------------------------------------------------------------------
+
 // CloseAsync shuts down the HTTPClient input and stops processing requests.
 func (h *HTTPClient) CloseAsync() {
 }
@@ -0,0 +1,165 @@
+package reader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHTTPClientTestReader(t *testing.T, url string, conf HTTPClientPaginationConfig) *HTTPClient {
+	t.Helper()
+
+	cConf := client.NewConfig()
+	cConf.URL = url
+	cConf.Verb = "GET"
+	cConf.Retry = "1ms"
+
+	httpClient, err := client.New(cConf)
+	require.NoError(t, err)
+
+	h, err := NewHTTPClient(nil, httpClient, HTTPClientOptSetPagination(conf))
+	require.NoError(t, err)
+	return h
+}
+
+func readHTTPClientTestPages(t *testing.T, h *HTTPClient, n int) (pages []string, pageNums []string) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	for len(pages) < n {
+		msg, _, err := h.ReadWithContext(ctx)
+		if err == types.ErrTimeout {
+			require.NoError(t, ctx.Err())
+			continue
+		}
+		require.NoError(t, err)
+		pages = append(pages, string(msg.Get(0).Get()))
+		pageNums = append(pageNums, msg.Get(0).Metadata().Get("http_client_page"))
+	}
+	return
+}
+
+func TestHTTPClientPaginationConfigErrors(t *testing.T) {
+	httpClient, err := client.New(client.NewConfig())
+	require.NoError(t, err)
+
+	for _, conf := range []HTTPClientPaginationConfig{
+		{Mode: "nope"},
+		{Mode: "json_path"},
+		{Mode: "offset"},
+		{Mode: "offset", OffsetParam: "offset"},
+		{Mode: "link_header", Interval: "nope"},
+	} {
+		_, err := NewHTTPClient(nil, httpClient, HTTPClientOptSetPagination(conf))
+		assert.Error(t, err, conf.Mode)
+	}
+}
+
+func TestHTTPClientPaginationLinkHeader(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Add("Link", fmt.Sprintf(`<https://example.com/first>; rel="first", </items?page=%v>; rel="next last"`, page+1))
+		}
+		fmt.Fprintf(w, "page %v", page)
+	}))
+	defer ts.Close()
+
+	conf := NewHTTPClientPaginationConfig()
+	conf.Mode = "link_header"
+
+	h := newHTTPClientTestReader(t, ts.URL+"/items", conf)
+	pages, pageNums := readHTTPClientTestPages(t, h, 5)
+	assert.Equal(t, []string{"page 0", "page 1", "page 2", "page 0", "page 1"}, pages)
+	assert.Equal(t, []string{"1", "2", "3", "1", "2"}, pageNums)
+}
+
+func TestHTTPClientPaginationJSONPath(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Write([]byte(`{"items":[1],"links":{"next":"?cursor=a"}}`))
+		case "a":
+			w.Write([]byte(`{"items":[2],"links":{"next":"` + "http://" + r.Host + `/items?cursor=b"}}`))
+		default:
+			w.Write([]byte(`{"items":[3],"links":{"next":""}}`))
+		}
+	}))
+	defer ts.Close()
+
+	conf := NewHTTPClientPaginationConfig()
+	conf.Mode = "json_path"
+	conf.JSONPath = "links.next"
+
+	h := newHTTPClientTestReader(t, ts.URL+"/items", conf)
+	pages, _ := readHTTPClientTestPages(t, h, 4)
+	assert.Equal(t, []string{
+		`{"items":[1],"links":{"next":"?cursor=a"}}`,
+		`{"items":[2],"links":{"next":"` + ts.URL + `/items?cursor=b"}}`,
+		`{"items":[3],"links":{"next":""}}`,
+		`{"items":[1],"links":{"next":"?cursor=a"}}`,
+	}, pages)
+}
+
+func TestHTTPClientPaginationOffset(t *testing.T) {
+	var offsets []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("start")
+		offsets = append(offsets, offset+":"+r.URL.Query().Get("limit"))
+		if offset == "20" {
+			w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		fmt.Fprintf(w, `{"data":[%v]}`, offset)
+	}))
+	defer ts.Close()
+
+	conf := NewHTTPClientPaginationConfig()
+	conf.Mode = "offset"
+	conf.JSONPath = "data"
+	conf.OffsetParam = "start"
+	conf.OffsetIncrement = 10
+
+	h := newHTTPClientTestReader(t, ts.URL+"/items?limit=10", conf)
+	pages, _ := readHTTPClientTestPages(t, h, 4)
+	assert.Equal(t, []string{`{"data":[0]}`, `{"data":[10]}`, `{"data":[]}`, `{"data":[0]}`}, pages)
+	assert.Equal(t, []string{"0:10", "10:10", "20:10", "0:10"}, offsets)
+}
+
+func TestHTTPClientPaginationMaxPagesInterval(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "" {
+			t.Error("Missing offset parameter")
+		}
+		w.Write([]byte(`[` + r.URL.Query().Get("offset") + `]`))
+	}))
+	defer ts.Close()
+
+	conf := NewHTTPClientPaginationConfig()
+	conf.Mode = "offset"
+	conf.OffsetStart = 5
+	conf.OffsetIncrement = 1
+	conf.MaxPages = 2
+	conf.Interval = "100ms"
+
+	h := newHTTPClientTestReader(t, ts.URL+"/items", conf)
+
+	pages, _ := readHTTPClientTestPages(t, h, 2)
+	assert.Equal(t, []string{"[5]", "[6]"}, pages)
+
+	start := time.Now()
+	pages, _ = readHTTPClientTestPages(t, h, 2)
+	assert.Equal(t, []string{"[5]", "[6]"}, pages)
+	assert.True(t, time.Since(start) >= time.Millisecond*100)
+}
@@ -13,7 +13,9 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
 	"github.com/Jeffail/benthos/v3/lib/util/kafka/sasl"
 	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 	"github.com/Shopify/sarama"
@@ -27,11 +29,14 @@ type KafkaConfig struct {
 	ClientID            string   `json:"client_id" yaml:"client_id"`
 	ConsumerGroup       string   `json:"consumer_group" yaml:"consumer_group"`
 	CommitPeriod        string   `json:"commit_period" yaml:"commit_period"`
+	CheckpointLimit     int      `json:"checkpoint_limit" yaml:"checkpoint_limit"`
 	MaxProcessingPeriod string   `json:"max_processing_period" yaml:"max_processing_period"`
 	FetchBufferCap      int      `json:"fetch_buffer_cap" yaml:"fetch_buffer_cap"`
 	Topic               string   `json:"topic" yaml:"topic"`
 	Partition           int32    `json:"partition" yaml:"partition"`
+	Partitions          []int32  `json:"partitions" yaml:"partitions"`
 	StartFromOldest     bool     `json:"start_from_oldest" yaml:"start_from_oldest"`
+	StartOffset         string   `json:"start_offset" yaml:"start_offset"`
 	TargetVersion       string   `json:"target_version" yaml:"target_version"`
 	// TODO: V4 Remove this.
 	MaxBatchCount int                `json:"max_batch_count" yaml:"max_batch_count"`
@@ -49,11 +54,14 @@ func NewKafkaConfig() KafkaConfig {
 		ClientID:            "benthos_kafka_input",
 		ConsumerGroup:       "benthos_consumer_group",
 		CommitPeriod:        "1s",
+		CheckpointLimit:     1,
 		MaxProcessingPeriod: "100ms",
 		FetchBufferCap:      256,
 		Topic:               "benthos_stream",
 		Partition:           0,
+		Partitions:          []int32{},
 		StartFromOldest:     true,
+		StartOffset:         "",
 		TargetVersion:       sarama.V1_0_0_0.String(),
 		MaxBatchCount:       1,
 		TLS:                 btls.NewConfig(),
@@ -64,26 +72,84 @@ func NewKafkaConfig() KafkaConfig {
 
 //------------------------------------------------------------------------------
 
+// kafkaStartOffset describes an explicit position within a partition to begin
+// consuming from.
+type kafkaStartOffset struct {
+	offset    int64
+	timestamp *time.Time
+}
+
+// parseKafkaStartOffset parses a start offset, which is either `oldest`,
+// `newest`, a numerical offset or an RFC 3339 timestamp. An empty string
+// results in a nil offset.
+func parseKafkaStartOffset(str string) (*kafkaStartOffset, error) {
+	switch str {
+	case "":
+		return nil, nil
+	case "oldest":
+		return &kafkaStartOffset{offset: sarama.OffsetOldest}, nil
+	case "newest":
+		return &kafkaStartOffset{offset: sarama.OffsetNewest}, nil
+	}
+	if offset, err := strconv.ParseInt(str, 10, 64); err == nil {
+		if offset < 0 {
+			return nil, fmt.Errorf("start offset '%v' must not be negative", str)
+		}
+		return &kafkaStartOffset{offset: offset}, nil
+	}
+	ts, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return nil, fmt.Errorf("start offset '%v' must be either 'oldest', 'newest', a numerical offset or an RFC 3339 timestamp", str)
+	}
+	return &kafkaStartOffset{timestamp: &ts}, nil
+}
+
+// resolve returns the offset of a partition described by the start offset.
+func (s *kafkaStartOffset) resolve(client sarama.Client, topic string, partition int32) (int64, error) {
+	if s.timestamp == nil {
+		return s.offset, nil
+	}
+	offset, err := client.GetOffset(topic, partition, s.timestamp.UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		// No messages were produced after the timestamp.
+		return sarama.OffsetNewest, nil
+	}
+	return offset, nil
+}
+
+//------------------------------------------------------------------------------
+
 // Kafka is an input type that reads from a Kafka instance.
 type Kafka struct {
-	client       sarama.Client
-	coordinator  *sarama.Broker
-	partConsumer sarama.PartitionConsumer
-	version      sarama.KafkaVersion
+	client        sarama.Client
+	coordinator   *sarama.Broker
+	partConsumers map[int32]sarama.PartitionConsumer
+	msgChan       chan asyncMessage
+	connDone      chan struct{}
+	version       sarama.KafkaVersion
 
-	tlsConf *tls.Config
+	tlsConf     *tls.Config
+	partitions  []int32
+	startOffset *kafkaStartOffset
 
-	sMut sync.Mutex
+	sMut      sync.Mutex
+	commitMut sync.Mutex
 
-	offsetLastCommitted time.Time
-	commitPeriod        time.Duration
-	maxProcPeriod       time.Duration
+	commitPeriod  time.Duration
+	maxProcPeriod time.Duration
 
 	mRcvErr metrics.StatCounter
 
-	offsetCommitted int64
-	offsetCommit    int64
-	offset          int64
+	offsetMut           sync.Mutex
+	offsetLastCommitted time.Time
+	offsetCommitted     map[int32]int64
+	offsetCommit        map[int32]int64
+
+	pendingMut  sync.Mutex
+	pendingAcks []AsyncAckFn
 
 	addresses []string
 	conf      KafkaConfig
@@ -100,13 +166,14 @@ func NewKafka(
 	conf KafkaConfig, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (*Kafka, error) {
 	k := Kafka{
-		offset:     0,
-		conf:       conf,
-		stats:      stats,
-		mRcvErr:    stats.GetCounter("recv.error"),
-		log:        log,
-		mgr:        mgr,
-		closedChan: make(chan struct{}),
+		offsetCommitted: map[int32]int64{},
+		offsetCommit:    map[int32]int64{},
+		conf:            conf,
+		stats:           stats,
+		mRcvErr:         stats.GetCounter("recv.error"),
+		log:             log,
+		mgr:             mgr,
+		closedChan:      make(chan struct{}),
 	}
 
 	if conf.TLS.Enabled {
@@ -121,6 +188,27 @@ func NewKafka(
 		return nil, err
 	}
 
+	if k.startOffset, err = parseKafkaStartOffset(conf.StartOffset); err != nil {
+		return nil, err
+	}
+
+	if conf.CheckpointLimit < 1 {
+		return nil, fmt.Errorf("checkpoint limit must be at least 1, received %v", conf.CheckpointLimit)
+	}
+
+	if len(conf.Partitions) > 0 {
+		seen := map[int32]struct{}{}
+		for _, p := range conf.Partitions {
+			if _, exists := seen[p]; exists {
+				return nil, fmt.Errorf("partition %v is listed more than once", p)
+			}
+			seen[p] = struct{}{}
+			k.partitions = append(k.partitions, p)
+		}
+	} else {
+		k.partitions = []int32{conf.Partition}
+	}
+
 	for _, addr := range conf.Addresses {
 		for _, splitAddr := range strings.Split(addr, ",") {
 			if trimmed := strings.TrimSpace(splitAddr); len(trimmed) > 0 {
@@ -147,26 +235,25 @@ func NewKafka(
 
 //------------------------------------------------------------------------------
 
-// closeClients closes the kafka clients, this interrupts loop() out of the read
-// block.
+// closeClients closes the kafka clients, this interrupts the partition loops
+// out of their read blocks.
 func (k *Kafka) closeClients() {
 	k.commit()
 
 	k.sMut.Lock()
 	defer k.sMut.Unlock()
 
-	if k.partConsumer != nil {
-		// NOTE: Needs draining before destroying.
-		k.partConsumer.AsyncClose()
-		defer func() {
-			// Drain both channels
-			for range k.partConsumer.Messages() {
-			}
-			for range k.partConsumer.Errors() {
-			}
-
-			k.partConsumer = nil
-		}()
+	if k.partConsumers != nil {
+		close(k.connDone)
+		for _, partConsumer := range k.partConsumers {
+			partConsumer.AsyncClose()
+		}
+		// NOTE: The merged message channel is closed once all partition loops
+		// have finished draining their consumers.
+		for range k.msgChan {
+		}
+		k.partConsumers = nil
+		k.msgChan = nil
 	}
 	if k.coordinator != nil {
 		k.coordinator.Close()
@@ -224,9 +311,12 @@ func (k *Kafka) ConnectWithContext(ctx context.Context) error {
 		return err
 	}
 
-	k.coordinator, err = k.client.Coordinator(k.conf.ConsumerGroup)
-	if err != nil {
-		return err
+	// Without a consumer group offsets are neither fetched nor committed.
+	if k.conf.ConsumerGroup != "" {
+		k.coordinator, err = k.client.Coordinator(k.conf.ConsumerGroup)
+		if err != nil {
+			return err
+		}
 	}
 
 	var consumer sarama.Consumer
@@ -235,26 +325,99 @@ func (k *Kafka) ConnectWithContext(ctx context.Context) error {
 		return err
 	}
 
-	offsetReq := sarama.OffsetFetchRequest{}
-	offsetReq.ConsumerGroup = k.conf.ConsumerGroup
-	offsetReq.AddPartition(k.conf.Topic, k.conf.Partition)
+	storedOffsets := map[int32]int64{}
+	if k.coordinator != nil && k.startOffset == nil {
+		offsetReq := sarama.OffsetFetchRequest{}
+		offsetReq.ConsumerGroup = k.conf.ConsumerGroup
+		for _, p := range k.partitions {
+			offsetReq.AddPartition(k.conf.Topic, p)
+		}
 
-	if offsetRes, err := k.coordinator.FetchOffset(&offsetReq); err == nil {
-		offsetBlock := offsetRes.Blocks[k.conf.Topic][k.conf.Partition]
-		if offsetBlock.Err == sarama.ErrNoError {
-			k.offset = offsetBlock.Offset
+		if offsetRes, err := k.coordinator.FetchOffset(&offsetReq); err == nil {
+			for _, p := range k.partitions {
+				offsetBlock := offsetRes.GetBlock(k.conf.Topic, p)
+				if offsetBlock == nil {
+					k.log.Errorf("Failed to acquire offset of partition %v: block missing\n", p)
+				} else if offsetBlock.Err == sarama.ErrNoError {
+					storedOffsets[p] = offsetBlock.Offset
+				} else {
+					k.log.Errorf("Failed to acquire offset of partition %v: %v\n", p, offsetBlock.Err)
+				}
+				k.log.Debugf("Acquired stored offset of partition %v: %v\n", p, storedOffsets[p])
+			}
 		} else {
-			k.log.Errorf("Failed to acquire offset: %v\n", offsetBlock.Err)
+			k.log.Errorf("Failed to acquire offset from coordinator: %v\n", err)
 		}
-		k.log.Debugf("Acquired stored offset: %v\n", k.offset)
-	} else {
-		k.log.Errorf("Failed to acquire offset from coordinator: %v\n", err)
 	}
 
-	var partConsumer sarama.PartitionConsumer
-	partConsumer, err = consumer.ConsumePartition(
-		k.conf.Topic, k.conf.Partition, k.offset,
-	)
+	partConsumers := make(map[int32]sarama.PartitionConsumer, len(k.partitions))
+	defer func() {
+		if err != nil {
+			for _, partConsumer := range partConsumers {
+				partConsumer.Close()
+			}
+		}
+	}()
+
+	for _, p := range k.partitions {
+		var partConsumer sarama.PartitionConsumer
+		if partConsumer, err = k.consumePartition(consumer, p, storedOffsets[p]); err != nil {
+			return err
+		}
+		partConsumers[p] = partConsumer
+	}
+
+	msgChan := make(chan asyncMessage)
+	connDone := make(chan struct{})
+
+	var loopsWG sync.WaitGroup
+	for p, partConsumer := range partConsumers {
+		var batchPolicy *batch.Policy
+		if batchPolicy, err = batch.NewPolicy(k.conf.Batching, k.mgr, k.log, k.stats); err != nil {
+			err = fmt.Errorf("failed to initialise batch policy: %v", err)
+			return err
+		}
+		loopsWG.Add(1)
+		go func(p int32, partConsumer sarama.PartitionConsumer, batchPolicy *batch.Policy) {
+			defer loopsWG.Done()
+			defer batchPolicy.CloseAsync()
+			k.partitionLoop(p, partConsumer, batchPolicy, msgChan, connDone)
+		}(p, partConsumer, batchPolicy)
+		go func(partConsumer sarama.PartitionConsumer) {
+			for err := range partConsumer.Errors() {
+				if err != nil {
+					k.log.Errorf("Kafka message recv error: %v\n", err)
+					k.mRcvErr.Incr(1)
+				}
+			}
+		}(partConsumer)
+	}
+	go func() {
+		loopsWG.Wait()
+		close(msgChan)
+	}()
+
+	k.partConsumers = partConsumers
+	k.msgChan = msgChan
+	k.connDone = connDone
+	k.log.Infof("Receiving Kafka messages from addresses: %s\n", k.addresses)
+	return err
+}
+
+// consumePartition creates a partition consumer starting from the configured
+// start offset, or otherwise from the stored offset of the consumer group.
+func (k *Kafka) consumePartition(
+	consumer sarama.Consumer, partition int32, storedOffset int64,
+) (sarama.PartitionConsumer, error) {
+	if k.startOffset != nil {
+		offset, err := k.startOffset.resolve(k.client, k.conf.Topic, partition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve start offset of partition %v: %w", partition, err)
+		}
+		return consumer.ConsumePartition(k.conf.Topic, partition, offset)
+	}
+
+	partConsumer, err := consumer.ConsumePartition(k.conf.Topic, partition, storedOffset)
 	if err != nil {
 		offsetTarget := sarama.OffsetOldest
 		if !k.conf.StartFromOldest {
@@ -266,119 +429,239 @@ func (k *Kafka) ConnectWithContext(ctx context.Context) error {
 
 		k.log.Warnf(
 			"Attempting to obtain offset for topic %s, partition %v\n",
-			k.conf.Topic, k.conf.Partition,
+			k.conf.Topic, partition,
 		)
 
 		// Get the new offset target
-		if k.offset, err = k.client.GetOffset(
-			k.conf.Topic, k.conf.Partition, offsetTarget,
+		var offset int64
+		if offset, err = k.client.GetOffset(
+			k.conf.Topic, partition, offsetTarget,
 		); err == nil {
 			partConsumer, err = consumer.ConsumePartition(
-				k.conf.Topic, k.conf.Partition, k.offset,
+				k.conf.Topic, partition, offset,
 			)
 		}
 	}
-	if err != nil {
-		return err
-	}
-
-	k.partConsumer = partConsumer
-	k.log.Infof("Receiving Kafka messages from addresses: %s\n", k.addresses)
+	return partConsumer, err
+}
 
-	go func() {
-		for err := range partConsumer.Errors() {
-			if err != nil {
-				k.log.Errorf("Kafka message recv error: %v\n", err)
-				k.mRcvErr.Incr(1)
-			}
+// partitionLoop consumes messages of a single partition, batches them and
+// dispatches the batches in order to the merged message channel. Up to the
+// checkpoint limit of batches are permitted to be in flight for the partition
+// at any given time, and offsets are only ever committed once all prior
+// batches of the partition have been acknowledged.
+func (k *Kafka) partitionLoop(
+	partition int32,
+	partConsumer sarama.PartitionConsumer,
+	batchPolicy *batch.Policy,
+	msgChan chan<- asyncMessage,
+	connDone <-chan struct{},
+) {
+	defer func() {
+		// NOTE: Needs draining before destroying.
+		for range partConsumer.Messages() {
 		}
 	}()
 
-	return err
-}
-
-// Read attempts to read a message from a Kafka topic.
-func (k *Kafka) Read() (types.Message, error) {
-	return k.ReadNextWithContext(context.Background())
-}
+	// Offsets are tracked as the offset of the next message to consume, which
+	// is also the offset we commit.
+	checkpointer := checkpoint.New(0)
+	inFlight := make(chan struct{}, k.conf.CheckpointLimit)
+
+	var latestOffset int64
+	var nextTimedBatchChan <-chan time.Time
+	flushBatch := func() bool {
+		nextTimedBatchChan = nil
+		msg := batchPolicy.Flush()
+		if msg == nil {
+			return true
+		}
 
-// ReadNextWithContext attempts to read a message from a Kafka topic.
-func (k *Kafka) ReadNextWithContext(ctx context.Context) (types.Message, error) {
-	var partConsumer sarama.PartitionConsumer
+		select {
+		case inFlight <- struct{}{}:
+		case <-connDone:
+			return false
+		}
 
-	k.sMut.Lock()
-	partConsumer = k.partConsumer
-	k.sMut.Unlock()
+		offset := latestOffset + 1
+		k.offsetMut.Lock()
+		trackErr := checkpointer.Track(int(offset))
+		k.offsetMut.Unlock()
+		if trackErr != nil {
+			k.log.Errorf("Failed to track offset %v of partition %v: %v\n", offset, partition, trackErr)
+			<-inFlight
+			return false
+		}
 
-	if partConsumer == nil {
-		return nil, types.ErrNotConnected
+		select {
+		case msgChan <- asyncMessage{
+			msg: msg,
+			ackFn: func(ctx context.Context, res types.Response) error {
+				if res.Error() == nil {
+					k.offsetMut.Lock()
+					if highest, err := checkpointer.Resolve(int(offset)); err == nil {
+						k.offsetCommit[partition] = int64(highest)
+					}
+					due := time.Since(k.offsetLastCommitted) >= k.commitPeriod
+					k.offsetMut.Unlock()
+					<-inFlight
+					if due {
+						return k.commit()
+					}
+				}
+				return nil
+			},
+		}:
+		case <-connDone:
+			return false
+		}
+		return true
 	}
 
-	hwm := partConsumer.HighWaterMarkOffset()
-
-	msg := message.New(nil)
+	for {
+		if nextTimedBatchChan == nil {
+			if tNext := batchPolicy.UntilNext(); tNext >= 0 {
+				nextTimedBatchChan = time.After(tNext)
+			}
+		}
+		select {
+		case <-nextTimedBatchChan:
+			if !flushBatch() {
+				return
+			}
+		case data, open := <-partConsumer.Messages():
+			if !open {
+				return
+			}
+			latestOffset = data.Offset
+			part := message.NewPart(data.Value)
 
-	addPart := func(data *sarama.ConsumerMessage) {
-		k.offset = data.Offset + 1
-		part := message.NewPart(data.Value)
+			meta := part.Metadata()
+			for _, hdr := range data.Headers {
+				meta.Set(string(hdr.Key), string(hdr.Value))
+			}
 
-		meta := part.Metadata()
-		for _, hdr := range data.Headers {
-			meta.Set(string(hdr.Key), string(hdr.Value))
-		}
+			lag := partConsumer.HighWaterMarkOffset() - data.Offset
+			if lag < 0 {
+				lag = 0
+			}
 
-		lag := hwm - data.Offset
-		if lag < 0 {
-			lag = 0
+			meta.Set("kafka_key", string(data.Key))
+			meta.Set("kafka_partition", strconv.Itoa(int(data.Partition)))
+			meta.Set("kafka_topic", data.Topic)
+			meta.Set("kafka_offset", strconv.FormatInt(data.Offset, 10))
+			meta.Set("kafka_lag", strconv.FormatInt(lag, 10))
+			meta.Set("kafka_timestamp_unix", strconv.FormatInt(data.Timestamp.Unix(), 10))
+
+			if batchPolicy.Add(part) {
+				if !flushBatch() {
+					return
+				}
+			}
+		case <-connDone:
+			return
 		}
+	}
+}
 
-		meta.Set("kafka_key", string(data.Key))
-		meta.Set("kafka_partition", strconv.Itoa(int(data.Partition)))
-		meta.Set("kafka_topic", data.Topic)
-		meta.Set("kafka_offset", strconv.FormatInt(data.Offset, 10))
-		meta.Set("kafka_lag", strconv.FormatInt(lag, 10))
-		meta.Set("kafka_timestamp_unix", strconv.FormatInt(data.Timestamp.Unix(), 10))
+// ReadWithContext attempts to read a message from a Kafka topic.
+func (k *Kafka) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	k.sMut.Lock()
+	msgChan := k.msgChan
+	k.sMut.Unlock()
 
-		msg.Append(part)
+	if msgChan == nil {
+		return nil, nil, types.ErrNotConnected
 	}
 
 	select {
-	case data, open := <-partConsumer.Messages():
+	case m, open := <-msgChan:
 		if !open {
-			return nil, types.ErrTypeClosed
+			return nil, nil, types.ErrTypeClosed
 		}
-		addPart(data)
+		return m.msg, m.ackFn, nil
 	case <-ctx.Done():
-		return nil, types.ErrTimeout
 	}
+	return nil, nil, types.ErrTimeout
+}
 
-	if msg.Len() == 0 {
-		return nil, types.ErrTimeout
+// Read attempts to read a message from a Kafka topic.
+//
+// Deprecated: Use ReadWithContext instead, messages read with this method are
+// only acknowledged by a subsequent call to Acknowledge.
+func (k *Kafka) Read() (types.Message, error) {
+	return k.ReadNextWithContext(context.Background())
+}
+
+// ReadNextWithContext attempts to read a message from a Kafka topic.
+//
+// Deprecated: Use ReadWithContext instead, messages read with this method are
+// only acknowledged by a subsequent call to AcknowledgeWithContext.
+func (k *Kafka) ReadNextWithContext(ctx context.Context) (types.Message, error) {
+	msg, ackFn, err := k.ReadWithContext(ctx)
+	if err != nil {
+		return nil, err
 	}
+	k.pendingMut.Lock()
+	k.pendingAcks = append(k.pendingAcks, ackFn)
+	k.pendingMut.Unlock()
 	return msg, nil
 }
 
-// Acknowledge instructs whether the current offset should be committed.
+// Acknowledge instructs whether all messages read since the last successful
+// acknowledgement should be committed.
+//
+// Deprecated: Use the acknowledgement function returned by ReadWithContext
+// instead.
 func (k *Kafka) Acknowledge(err error) error {
 	return k.AcknowledgeWithContext(context.Background(), err)
 }
 
-// AcknowledgeWithContext instructs whether the current offset should be
-// committed.
+// AcknowledgeWithContext instructs whether all messages read since the last
+// successful acknowledgement should be committed. When err is non-nil the
+// messages remain pending until a later successful acknowledgement, and
+// therefore this reader should be wrapped with a Preserver in order to resend
+// them.
+//
+// Deprecated: Use the acknowledgement function returned by ReadWithContext
+// instead.
 func (k *Kafka) AcknowledgeWithContext(ctx context.Context, err error) error {
-	if err == nil {
-		k.offsetCommit = k.offset
-	}
-
-	if time.Since(k.offsetLastCommitted) < k.commitPeriod {
+	if err != nil {
 		return nil
 	}
 
-	return k.commit()
+	k.pendingMut.Lock()
+	pending := k.pendingAcks
+	k.pendingAcks = nil
+	k.pendingMut.Unlock()
+
+	var ackErr error
+	for _, ackFn := range pending {
+		if aErr := ackFn(ctx, response.NewAck()); aErr != nil && ackErr == nil {
+			ackErr = aErr
+		}
+	}
+	return ackErr
 }
 
 func (k *Kafka) commit() error {
-	if k.offsetCommit == k.offsetCommitted {
+	if k.conf.ConsumerGroup == "" {
+		return nil
+	}
+
+	k.commitMut.Lock()
+	defer k.commitMut.Unlock()
+
+	k.offsetMut.Lock()
+	pending := map[int32]int64{}
+	for p, offset := range k.offsetCommit {
+		if committed, exists := k.offsetCommitted[p]; !exists || committed != offset {
+			pending[p] = offset
+		}
+	}
+	k.offsetMut.Unlock()
+
+	if len(pending) == 0 {
 		return nil
 	}
 
@@ -394,13 +677,19 @@ func (k *Kafka) commit() error {
 
 	commitReq := sarama.OffsetCommitRequest{}
 	commitReq.ConsumerGroup = k.conf.ConsumerGroup
-	commitReq.AddBlock(k.conf.Topic, k.conf.Partition, k.offset, 0, "")
+	for p, offset := range pending {
+		commitReq.AddBlock(k.conf.Topic, p, offset, 0, 0, "")
+	}
 
 	commitRes, err := coordinator.CommitOffset(&commitReq)
 	if err == nil {
-		err = commitRes.Errors[k.conf.Topic][k.conf.Partition]
-		if err == sarama.ErrNoError {
-			err = nil
+		for p := range pending {
+			if err = commitRes.Errors[k.conf.Topic][p]; err == sarama.ErrNoError {
+				err = nil
+			}
+			if err != nil {
+				break
+			}
 		}
 	}
 
@@ -427,8 +716,12 @@ func (k *Kafka) commit() error {
 			}
 		}
 	} else {
-		k.offsetCommitted = k.offsetCommit
+		k.offsetMut.Lock()
+		for p, offset := range pending {
+			k.offsetCommitted[p] = offset
+		}
 		k.offsetLastCommitted = time.Now()
+		k.offsetMut.Unlock()
 	}
 
 	return nil
@@ -0,0 +1,220 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaStartOffsetParse(t *testing.T) {
+	ts, err := time.Parse(time.RFC3339, "2020-09-14T10:00:00Z")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		input  string
+		output *kafkaStartOffset
+		errs   bool
+	}{
+		"empty":     {input: "", output: nil},
+		"oldest":    {input: "oldest", output: &kafkaStartOffset{offset: sarama.OffsetOldest}},
+		"newest":    {input: "newest", output: &kafkaStartOffset{offset: sarama.OffsetNewest}},
+		"numeric":   {input: "1234", output: &kafkaStartOffset{offset: 1234}},
+		"timestamp": {input: "2020-09-14T10:00:00Z", output: &kafkaStartOffset{timestamp: &ts}},
+		"negative":  {input: "-5", errs: true},
+		"garbage":   {input: "yesterday", errs: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			res, err := parseKafkaStartOffset(test.input)
+			if test.errs {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.output, res)
+		})
+	}
+}
+
+func TestKafkaPartitions(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.Partition = 3
+
+	k, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, []int32{3}, k.partitions)
+
+	conf.Partitions = []int32{0, 2, 1}
+	k, err = NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, []int32{0, 2, 1}, k.partitions)
+
+	conf.Partitions = []int32{0, 1, 0}
+	_, err = NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+type fakePartitionConsumer struct {
+	sarama.PartitionConsumer
+	msgs chan *sarama.ConsumerMessage
+}
+
+func (f *fakePartitionConsumer) Messages() <-chan *sarama.ConsumerMessage {
+	return f.msgs
+}
+
+func (f *fakePartitionConsumer) HighWaterMarkOffset() int64 {
+	return 10
+}
+
+func TestKafkaCheckpointLimit(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.ConsumerGroup = ""
+	conf.CheckpointLimit = 0
+
+	_, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.CheckpointLimit = 2
+	k, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	batchPolicy, err := batch.NewPolicy(conf.Batching, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	partConsumer := &fakePartitionConsumer{
+		msgs: make(chan *sarama.ConsumerMessage, 3),
+	}
+	for i := 0; i < 3; i++ {
+		partConsumer.msgs <- &sarama.ConsumerMessage{
+			Topic:     "foo",
+			Partition: 1,
+			Offset:    int64(i),
+			Value:     []byte(strconv.Itoa(i)),
+		}
+	}
+	close(partConsumer.msgs)
+
+	msgChan := make(chan asyncMessage)
+	connDone := make(chan struct{})
+	loopDone := make(chan struct{})
+	go func() {
+		k.partitionLoop(1, partConsumer, batchPolicy, msgChan, connDone)
+		close(loopDone)
+	}()
+
+	readMsg := func(exp string) AsyncAckFn {
+		t.Helper()
+		select {
+		case m := <-msgChan:
+			assert.Equal(t, exp, string(m.msg.Get(0).Get()))
+			assert.Equal(t, "1", m.msg.Get(0).Metadata().Get("kafka_partition"))
+			return m.ackFn
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		return nil
+	}
+
+	committed := func() int64 {
+		k.offsetMut.Lock()
+		defer k.offsetMut.Unlock()
+		return k.offsetCommit[1]
+	}
+
+	ackFirst := readMsg("0")
+	ackSecond := readMsg("1")
+
+	select {
+	case <-msgChan:
+		t.Fatal("received message beyond checkpoint limit")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	require.NoError(t, ackSecond(context.Background(), response.NewAck()))
+	assert.Equal(t, int64(0), committed())
+
+	ackThird := readMsg("2")
+
+	require.NoError(t, ackFirst(context.Background(), response.NewAck()))
+	assert.Equal(t, int64(2), committed())
+
+	require.NoError(t, ackThird(context.Background(), response.NewAck()))
+	assert.Equal(t, int64(3), committed())
+
+	close(connDone)
+	select {
+	case <-loopDone:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestKafkaLegacyReadAcknowledge(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.ConsumerGroup = ""
+
+	k, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	_, err = k.Read()
+	assert.Equal(t, types.ErrNotConnected, err)
+
+	batchPolicy, err := batch.NewPolicy(conf.Batching, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	partConsumer := &fakePartitionConsumer{
+		msgs: make(chan *sarama.ConsumerMessage, 2),
+	}
+	for i := 0; i < 2; i++ {
+		partConsumer.msgs <- &sarama.ConsumerMessage{
+			Topic:     "foo",
+			Partition: 1,
+			Offset:    int64(i),
+			Value:     []byte(strconv.Itoa(i)),
+		}
+	}
+	close(partConsumer.msgs)
+
+	msgChan := make(chan asyncMessage)
+	connDone := make(chan struct{})
+	k.msgChan = msgChan
+	go k.partitionLoop(1, partConsumer, batchPolicy, msgChan, connDone)
+	defer close(connDone)
+
+	committed := func() int64 {
+		k.offsetMut.Lock()
+		defer k.offsetMut.Unlock()
+		return k.offsetCommit[1]
+	}
+
+	msg, err := k.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "0", string(msg.Get(0).Get()))
+
+	require.NoError(t, k.Acknowledge(errors.New("nope")))
+	assert.Equal(t, int64(0), committed())
+
+	require.NoError(t, k.Acknowledge(nil))
+	assert.Equal(t, int64(1), committed())
+
+	msg, err = k.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(msg.Get(0).Get()))
+
+	require.NoError(t, k.Acknowledge(nil))
+	assert.Equal(t, int64(2), committed())
+}
@@ -25,15 +25,16 @@ import (
 // KinesisConfig is configuration values for the input type.
 type KinesisConfig struct {
 	sess.Config     `json:",inline" yaml:",inline"`
-	Limit           int64              `json:"limit" yaml:"limit"`
-	Stream          string             `json:"stream" yaml:"stream"`
-	Shard           string             `json:"shard" yaml:"shard"`
-	DynamoDBTable   string             `json:"dynamodb_table" yaml:"dynamodb_table"`
-	ClientID        string             `json:"client_id" yaml:"client_id"`
-	CommitPeriod    string             `json:"commit_period" yaml:"commit_period"`
-	StartFromOldest bool               `json:"start_from_oldest" yaml:"start_from_oldest"`
-	Timeout         string             `json:"timeout" yaml:"timeout"`
-	Batching        batch.PolicyConfig `json:"batching" yaml:"batching"`
+	Limit           int64                       `json:"limit" yaml:"limit"`
+	Stream          string                      `json:"stream" yaml:"stream"`
+	Shard           string                      `json:"shard" yaml:"shard"`
+	DynamoDBTable   string                      `json:"dynamodb_table" yaml:"dynamodb_table"`
+	ClientID        string                      `json:"client_id" yaml:"client_id"`
+	CommitPeriod    string                      `json:"commit_period" yaml:"commit_period"`
+	StartFromOldest bool                        `json:"start_from_oldest" yaml:"start_from_oldest"`
+	Timeout         string                      `json:"timeout" yaml:"timeout"`
+	EnhancedFanOut  KinesisEnhancedFanOutConfig `json:"enhanced_fan_out" yaml:"enhanced_fan_out"`
+	Batching        batch.PolicyConfig          `json:"batching" yaml:"batching"`
 }
 
 // NewKinesisConfig creates a new Config with default values.
@@ -50,6 +51,7 @@ func NewKinesisConfig() KinesisConfig {
 		CommitPeriod:    "1s",
 		StartFromOldest: true,
 		Timeout:         "5s",
+		EnhancedFanOut:  NewKinesisEnhancedFanOutConfig(),
 		Batching:        batchConf,
 	}
 }
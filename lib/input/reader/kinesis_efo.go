@@ -0,0 +1,665 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/cenkalti/backoff/v4"
+)
+
+//------------------------------------------------------------------------------
+
+// KinesisEnhancedFanOutConfig contains configuration fields for consuming a
+// Kinesis stream as an enhanced fan-out consumer.
+type KinesisEnhancedFanOutConfig struct {
+	Enabled              bool   `json:"enabled" yaml:"enabled"`
+	ShardDiscoveryPeriod string `json:"shard_discovery_period" yaml:"shard_discovery_period"`
+}
+
+// NewKinesisEnhancedFanOutConfig creates a new KinesisEnhancedFanOutConfig with
+// default values.
+func NewKinesisEnhancedFanOutConfig() KinesisEnhancedFanOutConfig {
+	return KinesisEnhancedFanOutConfig{
+		Enabled:              false,
+		ShardDiscoveryPeriod: "1m",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// kinesisEFOShard tracks the sequence numbers of records consumed from a shard
+// that are pending acknowledgement.
+type kinesisEFOShard struct {
+	mut       sync.Mutex
+	cp        *checkpoint.Type
+	index     int
+	pruned    int
+	sequences map[int]string
+
+	sequence  string
+	committed string
+}
+
+func newKinesisEFOShard(sequence string) *kinesisEFOShard {
+	return &kinesisEFOShard{
+		cp:        checkpoint.New(0),
+		sequences: map[int]string{},
+		sequence:  sequence,
+		committed: sequence,
+	}
+}
+
+func (s *kinesisEFOShard) track(sequence string) int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.index++
+	s.cp.MustTrack(s.index)
+	s.sequences[s.index] = sequence
+	return s.index
+}
+
+func (s *kinesisEFOShard) resolve(index int) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	highest := s.cp.MustResolve(index)
+	if highest <= s.pruned {
+		return
+	}
+	s.sequence = s.sequences[highest]
+	for ; s.pruned < highest; s.pruned++ {
+		delete(s.sequences, s.pruned+1)
+	}
+}
+
+// pendingCommit returns the highest acknowledged sequence number if it has not
+// yet been committed.
+func (s *kinesisEFOShard) pendingCommit() (string, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.sequence, s.sequence != s.committed
+}
+
+func (s *kinesisEFOShard) setCommitted(sequence string) {
+	s.mut.Lock()
+	s.committed = sequence
+	s.mut.Unlock()
+}
+
+//------------------------------------------------------------------------------
+
+// KinesisEnhancedFanOut is a benthos reader.Async implementation that consumes
+// all shards of an Amazon Kinesis stream as an enhanced fan-out consumer.
+type KinesisEnhancedFanOut struct {
+	conf KinesisConfig
+
+	kinesis     kinesisiface.KinesisAPI
+	dynamo      dynamodbiface.DynamoDBAPI
+	streamARN   string
+	consumerARN string
+	closed      bool
+	connMut     sync.Mutex
+
+	discoverMut sync.Mutex
+
+	shardsMut sync.Mutex
+	shards    map[string]*kinesisEFOShard
+	finished  map[string]struct{}
+	shardsWG  sync.WaitGroup
+
+	msgChan chan asyncMessage
+
+	namespace       string
+	commitPeriod    time.Duration
+	discoveryPeriod time.Duration
+	timeout         time.Duration
+
+	ctx        context.Context
+	done       func()
+	closedChan chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewKinesisEnhancedFanOut creates a new Amazon Kinesis enhanced fan-out
+// reader.
+func NewKinesisEnhancedFanOut(
+	conf KinesisConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*KinesisEnhancedFanOut, error) {
+	if conf.Stream == "" {
+		return nil, errors.New("a stream must be specified")
+	}
+	if conf.ClientID == "" {
+		return nil, errors.New("a client_id must be specified in order to register a consumer")
+	}
+	k := KinesisEnhancedFanOut{
+		conf:       conf,
+		shards:     map[string]*kinesisEFOShard{},
+		finished:   map[string]struct{}{},
+		msgChan:    make(chan asyncMessage),
+		namespace:  fmt.Sprintf("%v-%v", conf.ClientID, conf.Stream),
+		closedChan: make(chan struct{}),
+		log:        log,
+		stats:      stats,
+	}
+	k.ctx, k.done = context.WithCancel(context.Background())
+
+	var err error
+	if k.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout string: %v", err)
+	}
+	if k.commitPeriod, err = time.ParseDuration(conf.CommitPeriod); err != nil {
+		return nil, fmt.Errorf("failed to parse commit period string: %v", err)
+	}
+	if k.discoveryPeriod, err = time.ParseDuration(conf.EnhancedFanOut.ShardDiscoveryPeriod); err != nil {
+		return nil, fmt.Errorf("failed to parse shard discovery period string: %v", err)
+	}
+	return &k, nil
+}
+
+//------------------------------------------------------------------------------
+
+// registerConsumer registers the client ID as a consumer of the stream, or
+// obtains the existing registration, and blocks until it is active.
+func (k *KinesisEnhancedFanOut) registerConsumer(ctx context.Context) (string, error) {
+	res, err := k.kinesis.RegisterStreamConsumerWithContext(ctx, &kinesis.RegisterStreamConsumerInput{
+		ConsumerName: aws.String(k.conf.ClientID),
+		StreamARN:    aws.String(k.streamARN),
+	})
+	var consumerARN, status string
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != kinesis.ErrCodeResourceInUseException {
+			return "", fmt.Errorf("failed to register stream consumer: %w", err)
+		}
+	} else {
+		consumerARN = aws.StringValue(res.Consumer.ConsumerARN)
+		status = aws.StringValue(res.Consumer.ConsumerStatus)
+	}
+
+	for status != kinesis.ConsumerStatusActive {
+		dres, err := k.kinesis.DescribeStreamConsumerWithContext(ctx, &kinesis.DescribeStreamConsumerInput{
+			ConsumerName: aws.String(k.conf.ClientID),
+			StreamARN:    aws.String(k.streamARN),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to describe stream consumer: %w", err)
+		}
+		consumerARN = aws.StringValue(dres.ConsumerDescription.ConsumerARN)
+		if status = aws.StringValue(dres.ConsumerDescription.ConsumerStatus); status == kinesis.ConsumerStatusActive {
+			break
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return "", types.ErrTimeout
+		}
+	}
+	return consumerARN, nil
+}
+
+// ConnectWithContext attempts to register as a consumer of the target Kinesis
+// stream and begins consuming its shards.
+func (k *KinesisEnhancedFanOut) ConnectWithContext(ctx context.Context) error {
+	k.connMut.Lock()
+	defer k.connMut.Unlock()
+
+	if k.closed {
+		return types.ErrTypeClosed
+	}
+	if k.consumerARN != "" {
+		return nil
+	}
+
+	sess, err := k.conf.GetSession()
+	if err != nil {
+		return err
+	}
+
+	k.kinesis = kinesis.New(sess)
+	if len(k.conf.DynamoDBTable) > 0 {
+		k.dynamo = dynamodb.New(sess)
+	}
+
+	sres, err := k.kinesis.DescribeStreamSummaryWithContext(ctx, &kinesis.DescribeStreamSummaryInput{
+		StreamName: aws.String(k.conf.Stream),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe stream: %w", err)
+	}
+	k.streamARN = aws.StringValue(sres.StreamDescriptionSummary.StreamARN)
+
+	if k.consumerARN, err = k.registerConsumer(ctx); err != nil {
+		return err
+	}
+
+	go k.loop()
+
+	k.log.Infof("Receiving Amazon Kinesis messages from stream '%v' as enhanced fan-out consumer: %v\n", k.conf.Stream, k.conf.ClientID)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// kinesisReadyShards returns the shards of a listing that are ready to be
+// consumed, which are those not already being consumed and not finished, with
+// parent shards that are either finished or no longer exist.
+func kinesisReadyShards(
+	listed []*kinesis.Shard,
+	consuming map[string]*kinesisEFOShard,
+	finished map[string]struct{},
+) []*kinesis.Shard {
+	exists := map[string]struct{}{}
+	for _, s := range listed {
+		exists[aws.StringValue(s.ShardId)] = struct{}{}
+	}
+
+	parentDone := func(id *string) bool {
+		if id == nil {
+			return true
+		}
+		if _, ok := finished[*id]; ok {
+			return true
+		}
+		_, ok := exists[*id]
+		return !ok
+	}
+
+	var ready []*kinesis.Shard
+	for _, s := range listed {
+		id := aws.StringValue(s.ShardId)
+		if _, ok := consuming[id]; ok {
+			continue
+		}
+		if _, ok := finished[id]; ok {
+			continue
+		}
+		if parentDone(s.ParentShardId) && parentDone(s.AdjacentParentShardId) {
+			ready = append(ready, s)
+		}
+	}
+	return ready
+}
+
+func (k *KinesisEnhancedFanOut) listShards(ctx context.Context) ([]*kinesis.Shard, error) {
+	var shards []*kinesis.Shard
+	input := &kinesis.ListShardsInput{
+		StreamName: aws.String(k.conf.Stream),
+	}
+	for {
+		res, err := k.kinesis.ListShardsWithContext(ctx, input, request.WithResponseReadTimeout(k.timeout))
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, res.Shards...)
+		if res.NextToken == nil {
+			return shards, nil
+		}
+		input = &kinesis.ListShardsInput{
+			NextToken: res.NextToken,
+		}
+	}
+}
+
+// discoverShards lists the shards of the stream and begins consuming any that
+// are ready. Shards that are discovered after the initial listing are the
+// result of resharding and are always consumed from their oldest record.
+func (k *KinesisEnhancedFanOut) discoverShards(initial bool) error {
+	k.discoverMut.Lock()
+	defer k.discoverMut.Unlock()
+
+	listed, err := k.listShards(k.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list shards: %w", err)
+	}
+
+	k.shardsMut.Lock()
+	ready := kinesisReadyShards(listed, k.shards, k.finished)
+	k.shardsMut.Unlock()
+
+	for _, s := range ready {
+		shardID := aws.StringValue(s.ShardId)
+		sequence, err := k.getCheckpoint(shardID)
+		if err != nil {
+			return err
+		}
+
+		startingPos := &kinesis.StartingPosition{
+			Type: aws.String(kinesis.ShardIteratorTypeTrimHorizon),
+		}
+		if len(sequence) > 0 {
+			startingPos = &kinesis.StartingPosition{
+				Type:           aws.String(kinesis.ShardIteratorTypeAfterSequenceNumber),
+				SequenceNumber: aws.String(sequence),
+			}
+		} else if initial && !k.conf.StartFromOldest {
+			startingPos.Type = aws.String(kinesis.ShardIteratorTypeLatest)
+		}
+
+		var endingSequence string
+		if s.SequenceNumberRange != nil {
+			endingSequence = aws.StringValue(s.SequenceNumberRange.EndingSequenceNumber)
+		}
+
+		shard := newKinesisEFOShard(sequence)
+		k.shardsMut.Lock()
+		k.shards[shardID] = shard
+		k.shardsMut.Unlock()
+
+		k.shardsWG.Add(1)
+		go k.shardLoop(shardID, shard, startingPos, endingSequence)
+	}
+	return nil
+}
+
+func (k *KinesisEnhancedFanOut) getCheckpoint(shardID string) (string, error) {
+	if k.dynamo == nil {
+		return "", nil
+	}
+	resp, err := k.dynamo.GetItemWithContext(
+		k.ctx,
+		&dynamodb.GetItemInput{
+			TableName:      aws.String(k.conf.DynamoDBTable),
+			ConsistentRead: aws.Bool(true),
+			Key: map[string]*dynamodb.AttributeValue{
+				"namespace": {
+					S: aws.String(k.namespace),
+				},
+				"shard_id": {
+					S: aws.String(shardID),
+				},
+			},
+		},
+		request.WithResponseReadTimeout(k.timeout),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to access dynamodb table '%s': %w", k.conf.DynamoDBTable, err)
+	}
+	if seqAttr := resp.Item["sequence"]; seqAttr != nil && seqAttr.S != nil {
+		return *seqAttr.S, nil
+	}
+	return "", nil
+}
+
+func (k *KinesisEnhancedFanOut) commit() {
+	if k.dynamo == nil {
+		return
+	}
+
+	k.shardsMut.Lock()
+	shards := make(map[string]*kinesisEFOShard, len(k.shards))
+	for id, s := range k.shards {
+		shards[id] = s
+	}
+	k.shardsMut.Unlock()
+
+	for id, s := range shards {
+		sequence, pending := s.pendingCommit()
+		if !pending {
+			continue
+		}
+		if _, err := k.dynamo.PutItemWithContext(
+			aws.BackgroundContext(),
+			&dynamodb.PutItemInput{
+				TableName: aws.String(k.conf.DynamoDBTable),
+				Item: map[string]*dynamodb.AttributeValue{
+					"namespace": {
+						S: aws.String(k.namespace),
+					},
+					"shard_id": {
+						S: aws.String(id),
+					},
+					"sequence": {
+						S: aws.String(sequence),
+					},
+				},
+			},
+			request.WithResponseReadTimeout(k.timeout),
+		); err != nil {
+			k.log.Errorf("Failed to commit sequence of shard '%v': %v\n", id, err)
+			continue
+		}
+		s.setCommitted(sequence)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// subscribe consumes a single subscription of a shard, which lasts for at most
+// five minutes, returning the sequence to continue from or an empty string if
+// the shard has been closed and fully consumed.
+func (k *KinesisEnhancedFanOut) subscribe(
+	shardID string,
+	shard *kinesisEFOShard,
+	startingPos *kinesis.StartingPosition,
+) (string, error) {
+	res, err := k.kinesis.SubscribeToShardWithContext(k.ctx, &kinesis.SubscribeToShardInput{
+		ConsumerARN:      aws.String(k.consumerARN),
+		ShardId:          aws.String(shardID),
+		StartingPosition: startingPos,
+	})
+	if err != nil {
+		return aws.StringValue(startingPos.SequenceNumber), err
+	}
+
+	stream := res.GetStream()
+	defer stream.Close()
+
+	continuation := aws.StringValue(startingPos.SequenceNumber)
+	for event := range stream.Events() {
+		e, ok := event.(*kinesis.SubscribeToShardEvent)
+		if !ok {
+			continue
+		}
+
+		msg := message.New(nil)
+		var lastSequence string
+		for _, rec := range e.Records {
+			if rec.Data == nil {
+				continue
+			}
+			part := message.NewPart(rec.Data)
+			part.Metadata().Set("kinesis_shard", shardID)
+			part.Metadata().Set("kinesis_stream", k.conf.Stream)
+			msg.Append(part)
+			lastSequence = aws.StringValue(rec.SequenceNumber)
+		}
+
+		if msg.Len() > 0 {
+			index := shard.track(lastSequence)
+			select {
+			case k.msgChan <- asyncMessage{
+				msg: msg,
+				ackFn: func(ctx context.Context, res types.Response) error {
+					if res.Error() == nil {
+						shard.resolve(index)
+					}
+					return nil
+				},
+			}:
+			case <-k.ctx.Done():
+				return "", k.ctx.Err()
+			}
+		}
+
+		if e.ContinuationSequenceNumber == nil {
+			return "", nil
+		}
+		continuation = *e.ContinuationSequenceNumber
+	}
+	if err := stream.Err(); err != nil {
+		return continuation, err
+	}
+	return continuation, nil
+}
+
+func (k *KinesisEnhancedFanOut) shardLoop(
+	shardID string,
+	shard *kinesisEFOShard,
+	startingPos *kinesis.StartingPosition,
+	endingSequence string,
+) {
+	defer k.shardsWG.Done()
+
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = time.Millisecond * 500
+	boff.MaxInterval = time.Second * 30
+	boff.MaxElapsedTime = 0
+
+	for {
+		continuation, err := k.subscribe(shardID, shard, startingPos)
+		if k.ctx.Err() != nil {
+			return
+		}
+		if err == nil && continuation == "" {
+			break
+		}
+		if err != nil {
+			if endingSequence != "" && continuation == endingSequence {
+				break
+			}
+			k.log.Errorf("Failed to consume shard '%v': %v\n", shardID, err)
+			select {
+			case <-time.After(boff.NextBackOff()):
+			case <-k.ctx.Done():
+				return
+			}
+		} else {
+			boff.Reset()
+		}
+		if continuation != "" {
+			startingPos = &kinesis.StartingPosition{
+				Type:           aws.String(kinesis.ShardIteratorTypeAfterSequenceNumber),
+				SequenceNumber: aws.String(continuation),
+			}
+		}
+	}
+
+	k.log.Infof("Finished consuming closed shard '%v'\n", shardID)
+	k.shardsMut.Lock()
+	k.finished[shardID] = struct{}{}
+	k.shardsMut.Unlock()
+
+	// Begin consuming the children of this shard without waiting for the next
+	// discovery period.
+	if err := k.discoverShards(false); err != nil && k.ctx.Err() == nil {
+		k.log.Errorf("Failed to discover shards: %v\n", err)
+	}
+}
+
+func (k *KinesisEnhancedFanOut) loop() {
+	defer func() {
+		k.shardsWG.Wait()
+		k.commit()
+		close(k.msgChan)
+		close(k.closedChan)
+	}()
+
+	initial := true
+	for initial {
+		if err := k.discoverShards(true); err != nil {
+			if k.ctx.Err() != nil {
+				return
+			}
+			k.log.Errorf("Failed to discover shards: %v\n", err)
+			select {
+			case <-time.After(time.Second):
+			case <-k.ctx.Done():
+				return
+			}
+			continue
+		}
+		initial = false
+	}
+
+	commitTicker := time.NewTicker(k.commitPeriod)
+	defer commitTicker.Stop()
+
+	discoveryTicker := time.NewTicker(k.discoveryPeriod)
+	defer discoveryTicker.Stop()
+
+	for {
+		select {
+		case <-commitTicker.C:
+			k.commit()
+		case <-discoveryTicker.C:
+			if err := k.discoverShards(false); err != nil && k.ctx.Err() == nil {
+				k.log.Errorf("Failed to discover shards: %v\n", err)
+			}
+		case <-k.ctx.Done():
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ReadWithContext attempts to read a new message from the stream.
+func (k *KinesisEnhancedFanOut) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	k.connMut.Lock()
+	connected := k.consumerARN != "" || k.closed
+	k.connMut.Unlock()
+
+	if !connected {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	select {
+	case m, open := <-k.msgChan:
+		if !open {
+			return nil, nil, types.ErrTypeClosed
+		}
+		return m.msg, m.ackFn, nil
+	case <-ctx.Done():
+	}
+	return nil, nil, types.ErrTimeout
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (k *KinesisEnhancedFanOut) CloseAsync() {
+	k.connMut.Lock()
+	defer k.connMut.Unlock()
+
+	if k.closed {
+		return
+	}
+	k.closed = true
+	k.done()
+
+	// Without a connection the background loop was never started.
+	if k.consumerARN == "" {
+		close(k.msgChan)
+		close(k.closedChan)
+	}
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (k *KinesisEnhancedFanOut) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-k.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
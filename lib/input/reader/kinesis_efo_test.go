@@ -0,0 +1,114 @@
+package reader
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKinesisReadyShards(t *testing.T) {
+	shard := func(id string, parents ...string) *kinesis.Shard {
+		s := &kinesis.Shard{ShardId: aws.String(id)}
+		if len(parents) > 0 {
+			s.ParentShardId = aws.String(parents[0])
+		}
+		if len(parents) > 1 {
+			s.AdjacentParentShardId = aws.String(parents[1])
+		}
+		return s
+	}
+
+	listed := []*kinesis.Shard{
+		shard("a"),
+		shard("b"),
+		shard("c", "a"),
+		shard("d", "a"),
+		shard("e", "b", "c"),
+		shard("f", "expired"),
+	}
+
+	tests := []struct {
+		name      string
+		consuming []string
+		finished  []string
+		expected  []string
+	}{
+		{
+			name:     "initial",
+			expected: []string{"a", "b", "f"},
+		},
+		{
+			name:      "parents consuming",
+			consuming: []string{"a", "b", "f"},
+		},
+		{
+			name:      "parent finished",
+			consuming: []string{"b"},
+			finished:  []string{"a", "f"},
+			expected:  []string{"c", "d"},
+		},
+		{
+			name:      "one of two parents finished",
+			consuming: []string{"c", "d"},
+			finished:  []string{"a", "b", "f"},
+		},
+		{
+			name:      "both parents finished",
+			consuming: []string{"d"},
+			finished:  []string{"a", "b", "c", "f"},
+			expected:  []string{"e"},
+		},
+	}
+
+	for _, test := range tests {
+		consuming := map[string]*kinesisEFOShard{}
+		for _, id := range test.consuming {
+			consuming[id] = newKinesisEFOShard("")
+		}
+		finished := map[string]struct{}{}
+		for _, id := range test.finished {
+			finished[id] = struct{}{}
+		}
+
+		var ready []string
+		for _, s := range kinesisReadyShards(listed, consuming, finished) {
+			ready = append(ready, *s.ShardId)
+		}
+		sort.Strings(ready)
+		assert.Equal(t, test.expected, ready, test.name)
+	}
+}
+
+func TestKinesisEFOShardCheckpoint(t *testing.T) {
+	s := newKinesisEFOShard("10")
+
+	_, pending := s.pendingCommit()
+	assert.False(t, pending)
+
+	first := s.track("11")
+	second := s.track("12")
+	third := s.track("13")
+
+	s.resolve(second)
+	seq, pending := s.pendingCommit()
+	assert.False(t, pending)
+	assert.Equal(t, "10", seq)
+
+	s.resolve(first)
+	seq, pending = s.pendingCommit()
+	assert.True(t, pending)
+	assert.Equal(t, "12", seq)
+
+	s.setCommitted(seq)
+	_, pending = s.pendingCommit()
+	assert.False(t, pending)
+
+	s.resolve(third)
+	seq, pending = s.pendingCommit()
+	assert.True(t, pending)
+	assert.Equal(t, "13", seq)
+	assert.Empty(t, s.sequences)
+}
@@ -0,0 +1,551 @@
+package reader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
+	"github.com/Jeffail/benthos/v3/lib/util/mongodb"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//------------------------------------------------------------------------------
+
+// MongoDBChangeStreamConfig contains configuration fields for the
+// MongoDBChangeStream input type.
+type MongoDBChangeStreamConfig struct {
+	URL             string      `json:"url" yaml:"url"`
+	Username        string      `json:"username" yaml:"username"`
+	Password        string      `json:"password" yaml:"password"`
+	Database        string      `json:"database" yaml:"database"`
+	Collection      string      `json:"collection" yaml:"collection"`
+	Payload         string      `json:"payload" yaml:"payload"`
+	BatchSize       int         `json:"batch_size" yaml:"batch_size"`
+	CheckpointCache string      `json:"checkpoint_cache" yaml:"checkpoint_cache"`
+	CheckpointKey   string      `json:"checkpoint_key" yaml:"checkpoint_key"`
+	CommitPeriod    string      `json:"commit_period" yaml:"commit_period"`
+	TLS             btls.Config `json:"tls" yaml:"tls"`
+}
+
+// NewMongoDBChangeStreamConfig creates a new MongoDBChangeStreamConfig with
+// default values.
+func NewMongoDBChangeStreamConfig() MongoDBChangeStreamConfig {
+	return MongoDBChangeStreamConfig{
+		URL:             "",
+		Username:        "",
+		Password:        "",
+		Database:        "",
+		Collection:      "",
+		Payload:         "full_document",
+		BatchSize:       100,
+		CheckpointCache: "",
+		CheckpointKey:   "mongodb_changestream",
+		CommitPeriod:    "1s",
+		TLS:             btls.NewConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MongoDBChangeStream is a benthos reader.Async implementation that consumes
+// the events of a MongoDB change stream.
+type MongoDBChangeStream struct {
+	conf       MongoDBChangeStreamConfig
+	clientOpts *options.ClientOptions
+	cache      types.Cache
+
+	client         *mongo.Client
+	maxWireVersion int32
+	closed         bool
+	connMut        sync.Mutex
+
+	// The resume token of the last event read from the change stream, which
+	// is encoded as BSON.
+	readToken []byte
+
+	cpMut     sync.Mutex
+	cp        *checkpoint.Type
+	index     int
+	pruned    int
+	positions map[int][]byte
+	tracked   []byte
+	position  []byte
+
+	commitMut sync.Mutex
+	committed []byte
+
+	msgChan chan asyncMessage
+
+	commitPeriod time.Duration
+
+	ctx        context.Context
+	done       func()
+	closedChan chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewMongoDBChangeStream creates a new MongoDB change stream reader.
+func NewMongoDBChangeStream(
+	conf MongoDBChangeStreamConfig,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (*MongoDBChangeStream, error) {
+	if conf.URL == "" {
+		return nil, errors.New("a url must be specified")
+	}
+	if conf.Collection != "" && conf.Database == "" {
+		return nil, errors.New("a database must be specified with a collection")
+	}
+	switch conf.Payload {
+	case "full_document", "delta", "change_event":
+	default:
+		return nil, fmt.Errorf("unrecognised payload: %v", conf.Payload)
+	}
+	if conf.BatchSize < 1 {
+		return nil, errors.New("batch size must be greater than zero")
+	}
+	if conf.CheckpointCache != "" && conf.CheckpointKey == "" {
+		return nil, errors.New("a checkpoint key must be specified")
+	}
+
+	m := &MongoDBChangeStream{
+		conf:       conf,
+		cp:         checkpoint.New(0),
+		positions:  map[int][]byte{},
+		msgChan:    make(chan asyncMessage),
+		closedChan: make(chan struct{}),
+		log:        log,
+		stats:      stats,
+	}
+	m.ctx, m.done = context.WithCancel(context.Background())
+
+	var err error
+	if m.commitPeriod, err = time.ParseDuration(conf.CommitPeriod); err != nil {
+		return nil, fmt.Errorf("failed to parse commit period string: %v", err)
+	}
+	if conf.CheckpointCache != "" {
+		if m.cache, err = mgr.GetCache(conf.CheckpointCache); err != nil {
+			return nil, fmt.Errorf("failed to obtain cache '%v': %v", conf.CheckpointCache, err)
+		}
+	}
+	if m.clientOpts, err = mongodb.ClientOptions(conf.URL, conf.Username, conf.Password, conf.TLS); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext connects to the deployment, opens the change stream from
+// the last committed resume token, and begins consuming events.
+func (m *MongoDBChangeStream) ConnectWithContext(ctx context.Context) error {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.closed {
+		return types.ErrTypeClosed
+	}
+	if m.client != nil {
+		return nil
+	}
+
+	token, err := m.loadCheckpoint()
+	if err != nil {
+		return err
+	}
+	m.readToken = token
+	m.tracked, m.position, m.committed = token, token, token
+
+	client, err := mongo.Connect(ctx, m.clientOpts)
+	if err != nil {
+		return err
+	}
+
+	var hello struct {
+		MaxWireVersion int32 `bson:"maxWireVersion"`
+	}
+	if err = client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello); err != nil {
+		client.Disconnect(context.Background())
+		return err
+	}
+	m.maxWireVersion = hello.MaxWireVersion
+
+	cs, err := m.open(ctx, client)
+	if err != nil {
+		client.Disconnect(context.Background())
+		return err
+	}
+
+	if token != nil {
+		m.log.Infof("Receiving MongoDB change stream events from checkpoint\n")
+	} else {
+		m.log.Infof("Receiving MongoDB change stream events\n")
+	}
+
+	m.client = client
+	go m.loop(cs)
+	return nil
+}
+
+// loadCheckpoint reads the resume token that was last committed to the cache,
+// returning nil when there isn't one.
+func (m *MongoDBChangeStream) loadCheckpoint() ([]byte, error) {
+	if m.cache == nil {
+		return nil, nil
+	}
+	data, err := m.cache.Get(m.conf.CheckpointKey)
+	if err != nil {
+		if err == types.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err = bson.Raw(data).Validate(); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	return data, nil
+}
+
+// streamOptions returns the options of the change stream, resuming after the
+// last event read when there is one.
+func (m *MongoDBChangeStream) streamOptions() *options.ChangeStreamOptions {
+	opts := options.ChangeStream().SetBatchSize(int32(m.conf.BatchSize))
+	if m.conf.Payload == "full_document" {
+		opts.SetFullDocument(options.UpdateLookup)
+	}
+	if m.readToken != nil {
+		// Streams can only be started after an invalidate event as of 4.2.
+		if m.maxWireVersion >= 8 {
+			opts.SetStartAfter(bson.Raw(m.readToken))
+		} else {
+			opts.SetResumeAfter(bson.Raw(m.readToken))
+		}
+	}
+	return opts
+}
+
+// open opens the change stream of the configured collection, database or
+// cluster.
+func (m *MongoDBChangeStream) open(ctx context.Context, client *mongo.Client) (*mongo.ChangeStream, error) {
+	var cs *mongo.ChangeStream
+	var err error
+	switch {
+	case m.conf.Collection != "":
+		cs, err = client.Database(m.conf.Database).Collection(m.conf.Collection).Watch(ctx, mongo.Pipeline{}, m.streamOptions())
+	case m.conf.Database != "":
+		cs, err = client.Database(m.conf.Database).Watch(ctx, mongo.Pipeline{}, m.streamOptions())
+	default:
+		cs, err = client.Watch(ctx, mongo.Pipeline{}, m.streamOptions())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open change stream: %w", err)
+	}
+	return cs, nil
+}
+
+//------------------------------------------------------------------------------
+
+// track adds events that are pending acknowledgement with the resume token
+// that follows them, returning the index of each event. A token without
+// events is resolved immediately.
+func (m *MongoDBChangeStream) track(token []byte, events int) []int {
+	m.cpMut.Lock()
+	defer m.cpMut.Unlock()
+
+	indexes := make([]int, events)
+	for i := range indexes {
+		m.index++
+		m.cp.MustTrack(m.index)
+		m.positions[m.index] = m.tracked
+		indexes[i] = m.index
+	}
+	if events == 0 {
+		m.index++
+		m.cp.MustTrack(m.index)
+		m.positions[m.index] = token
+		m.resolveLocked(m.index)
+	} else {
+		m.positions[m.index] = token
+	}
+	m.tracked = token
+	return indexes
+}
+
+func (m *MongoDBChangeStream) resolveLocked(index int) {
+	highest := m.cp.MustResolve(index)
+	if highest <= m.pruned {
+		return
+	}
+	if token := m.positions[highest]; token != nil {
+		m.position = token
+	}
+	for ; m.pruned < highest; m.pruned++ {
+		delete(m.positions, m.pruned+1)
+	}
+}
+
+func (m *MongoDBChangeStream) resolve(index int) {
+	m.cpMut.Lock()
+	m.resolveLocked(index)
+	m.cpMut.Unlock()
+}
+
+// commit writes the resume token of the latest fully acknowledged event to
+// the checkpoint cache.
+func (m *MongoDBChangeStream) commit() {
+	if m.cache == nil {
+		return
+	}
+
+	m.commitMut.Lock()
+	defer m.commitMut.Unlock()
+
+	m.cpMut.Lock()
+	token := m.position
+	m.cpMut.Unlock()
+
+	if token == nil || bytes.Equal(token, m.committed) {
+		return
+	}
+	if err := m.cache.Set(m.conf.CheckpointKey, token); err != nil {
+		m.log.Errorf("Failed to write checkpoint: %v\n", err)
+		return
+	}
+	m.committed = token
+}
+
+//------------------------------------------------------------------------------
+
+// eventPayload returns the value of an event to emit according to the
+// configured payload.
+func (m *MongoDBChangeStream) eventPayload(event bson.Raw, operation string) bson.Raw {
+	if m.conf.Payload == "change_event" {
+		return event
+	}
+	document := func(key string) bson.Raw {
+		doc, _ := event.Lookup(key).DocumentOK()
+		return doc
+	}
+	switch operation {
+	case "insert", "replace":
+		if doc := document("fullDocument"); doc != nil {
+			return doc
+		}
+	case "update":
+		if m.conf.Payload == "delta" {
+			return document("updateDescription")
+		}
+		// The document may have been deleted since it was updated.
+		if doc := document("fullDocument"); doc != nil {
+			return doc
+		}
+		return document("documentKey")
+	case "delete":
+		return document("documentKey")
+	}
+	return event
+}
+
+func (m *MongoDBChangeStream) eventMessage(event bson.Raw) (types.Message, error) {
+	operation, _ := event.Lookup("operationType").StringValueOK()
+	data, err := bson.MarshalExtJSON(m.eventPayload(event, operation), false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	part := message.NewPart(data)
+	meta := part.Metadata()
+	meta.Set("mongodb_changestream_operation", operation)
+	if ns, ok := event.Lookup("ns").DocumentOK(); ok {
+		if db, ok := ns.Lookup("db").StringValueOK(); ok {
+			meta.Set("mongodb_changestream_database", db)
+		}
+		if coll, ok := ns.Lookup("coll").StringValueOK(); ok {
+			meta.Set("mongodb_changestream_collection", coll)
+		}
+	}
+	if key, ok := event.Lookup("documentKey").DocumentOK(); ok {
+		if keyData, err := bson.MarshalExtJSON(key, false, false); err == nil {
+			meta.Set("mongodb_changestream_document_key", string(keyData))
+		}
+	}
+	if ts := event.Lookup("clusterTime"); ts.Type == bsontype.Timestamp {
+		t, _ := ts.Timestamp()
+		meta.Set("mongodb_changestream_cluster_time_unix", strconv.FormatUint(uint64(t), 10))
+	}
+
+	msg := message.New(nil)
+	msg.Append(part)
+	return msg, nil
+}
+
+// handleEvent sends an event of the change stream.
+func (m *MongoDBChangeStream) handleEvent(event bson.Raw) error {
+	id, ok := event.Lookup("_id").DocumentOK()
+	if !ok {
+		return errors.New("change stream event does not contain a resume token")
+	}
+	msg, err := m.eventMessage(event)
+	if err != nil {
+		return err
+	}
+	token := append([]byte(nil), id...)
+	m.readToken = token
+
+	index := m.track(token, 1)[0]
+	select {
+	case m.msgChan <- asyncMessage{
+		msg: msg,
+		ackFn: func(ctx context.Context, res types.Response) error {
+			if res.Error() == nil {
+				m.resolve(index)
+			}
+			return nil
+		},
+	}:
+	case <-m.ctx.Done():
+		return m.ctx.Err()
+	}
+	return nil
+}
+
+// handleToken tracks a resume token that follows the last event read, such as
+// the resume token that follows a batch of events.
+func (m *MongoDBChangeStream) handleToken(token bson.Raw) {
+	if token == nil || bytes.Equal(token, m.readToken) {
+		return
+	}
+	m.readToken = append([]byte(nil), token...)
+	m.track(m.readToken, 0)
+}
+
+// stream consumes the events of a change stream until an error occurs.
+func (m *MongoDBChangeStream) stream(cs *mongo.ChangeStream) error {
+	defer cs.Close(context.Background())
+	for {
+		if cs.TryNext(m.ctx) {
+			if err := m.handleEvent(cs.Current); err != nil {
+				return err
+			}
+		} else if err := cs.Err(); err != nil {
+			return err
+		} else if cs.ID() == 0 {
+			return errors.New("change stream was invalidated")
+		}
+		m.handleToken(cs.ResumeToken())
+	}
+}
+
+func (m *MongoDBChangeStream) loop(cs *mongo.ChangeStream) {
+	defer func() {
+		m.commit()
+		m.client.Disconnect(context.Background())
+		close(m.msgChan)
+		close(m.closedChan)
+	}()
+
+	go func() {
+		commitTicker := time.NewTicker(m.commitPeriod)
+		defer commitTicker.Stop()
+		for {
+			select {
+			case <-commitTicker.C:
+				m.commit()
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		err := m.stream(cs)
+		if m.ctx.Err() != nil {
+			return
+		}
+		m.log.Errorf("Failed to consume change stream: %v\n", err)
+		for {
+			select {
+			case <-time.After(time.Second):
+			case <-m.ctx.Done():
+				return
+			}
+			if cs, err = m.open(m.ctx, m.client); err == nil {
+				break
+			}
+			if m.ctx.Err() != nil {
+				return
+			}
+			m.log.Errorf("Failed to reopen change stream: %v\n", err)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ReadWithContext attempts to read a new event from the change stream.
+func (m *MongoDBChangeStream) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	m.connMut.Lock()
+	connected := m.client != nil || m.closed
+	m.connMut.Unlock()
+
+	if !connected {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	select {
+	case msg, open := <-m.msgChan:
+		if !open {
+			return nil, nil, types.ErrTypeClosed
+		}
+		return msg.msg, msg.ackFn, nil
+	case <-ctx.Done():
+	}
+	return nil, nil, types.ErrTimeout
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (m *MongoDBChangeStream) CloseAsync() {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.closed {
+		return
+	}
+	m.closed = true
+	m.done()
+
+	// Without a connection the background loop was never started.
+	if m.client == nil {
+		close(m.msgChan)
+		close(m.closedChan)
+	}
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (m *MongoDBChangeStream) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-m.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -2,16 +2,21 @@ package reader
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/mqttconf"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/cenkalti/backoff/v4"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
@@ -19,45 +24,115 @@ import (
 
 // MQTTConfig contains configuration fields for the MQTT input type.
 type MQTTConfig struct {
-	URLs                   []string `json:"urls" yaml:"urls"`
-	QoS                    uint8    `json:"qos" yaml:"qos"`
-	Topics                 []string `json:"topics" yaml:"topics"`
-	ClientID               string   `json:"client_id" yaml:"client_id"`
-	CleanSession           bool     `json:"clean_session" yaml:"clean_session"`
-	User                   string   `json:"user" yaml:"user"`
-	Password               string   `json:"password" yaml:"password"`
-	StaleConnectionTimeout string   `json:"stale_connection_timeout" yaml:"stale_connection_timeout"`
+	URLs                    []string            `json:"urls" yaml:"urls"`
+	QoS                     uint8               `json:"qos" yaml:"qos"`
+	Topics                  []string            `json:"topics" yaml:"topics"`
+	SharedSubscriptionGroup string              `json:"shared_subscription_group" yaml:"shared_subscription_group"`
+	ClientID                string              `json:"client_id" yaml:"client_id"`
+	CleanSession            bool                `json:"clean_session" yaml:"clean_session"`
+	User                    string              `json:"user" yaml:"user"`
+	Password                string              `json:"password" yaml:"password"`
+	MaxInFlight             int                 `json:"max_in_flight" yaml:"max_in_flight"`
+	HTTPHeaders             map[string]string   `json:"http_headers" yaml:"http_headers"`
+	StorePath               string              `json:"store_path" yaml:"store_path"`
+	StaleConnectionTimeout  string              `json:"stale_connection_timeout" yaml:"stale_connection_timeout"`
+	Reconnect               MQTTReconnectConfig `json:"reconnect" yaml:"reconnect"`
+	Will                    mqttconf.Will       `json:"will" yaml:"will"`
+	TLS                     btls.Config         `json:"tls" yaml:"tls"`
 }
 
 // NewMQTTConfig creates a new MQTTConfig with default values.
 func NewMQTTConfig() MQTTConfig {
 	return MQTTConfig{
-		URLs:                   []string{"tcp://localhost:1883"},
-		QoS:                    1,
-		Topics:                 []string{"benthos_topic"},
-		ClientID:               "benthos_input",
-		CleanSession:           true,
-		User:                   "",
-		Password:               "",
-		StaleConnectionTimeout: "",
+		URLs:                    []string{"tcp://localhost:1883"},
+		QoS:                     1,
+		Topics:                  []string{"benthos_topic"},
+		SharedSubscriptionGroup: "",
+		ClientID:                "benthos_input",
+		CleanSession:            true,
+		User:                    "",
+		Password:                "",
+		MaxInFlight:             10,
+		HTTPHeaders:             map[string]string{},
+		StorePath:               "",
+		StaleConnectionTimeout:  "",
+		Reconnect:               NewMQTTReconnectConfig(),
+		Will:                    mqttconf.EmptyWill(),
+		TLS:                     btls.NewConfig(),
 	}
 }
 
+// MQTTReconnectConfig contains configuration fields for the backoff applied
+// between attempts to connect to an MQTT broker.
+type MQTTReconnectConfig struct {
+	InitialInterval string  `json:"initial_interval" yaml:"initial_interval"`
+	MaxInterval     string  `json:"max_interval" yaml:"max_interval"`
+	Jitter          float64 `json:"jitter" yaml:"jitter"`
+	MaxRetries      uint64  `json:"max_retries" yaml:"max_retries"`
+}
+
+// NewMQTTReconnectConfig creates a new MQTTReconnectConfig with default
+// values.
+func NewMQTTReconnectConfig() MQTTReconnectConfig {
+	return MQTTReconnectConfig{
+		InitialInterval: "500ms",
+		MaxInterval:     "30s",
+		Jitter:          0.5,
+		MaxRetries:      0,
+	}
+}
+
+func (c MQTTReconnectConfig) getCtor() (func() backoff.BackOff, error) {
+	initInterval, err := time.ParseDuration(c.InitialInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reconnect initial interval: %v", err)
+	}
+	maxInterval, err := time.ParseDuration(c.MaxInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reconnect max interval: %v", err)
+	}
+	if c.Jitter < 0 || c.Jitter > 1 {
+		return nil, fmt.Errorf("invalid reconnect jitter '%v', must be between 0 and 1", c.Jitter)
+	}
+	return func() backoff.BackOff {
+		boff := backoff.NewExponentialBackOff()
+		boff.InitialInterval = initInterval
+		boff.MaxInterval = maxInterval
+		boff.RandomizationFactor = c.Jitter
+		boff.MaxElapsedTime = 0
+		if c.MaxRetries > 0 {
+			return backoff.WithMaxRetries(boff, c.MaxRetries)
+		}
+		return boff
+	}, nil
+}
+
 //------------------------------------------------------------------------------
 
 // MQTT is an input type that reads MQTT Pub/Sub messages.
 type MQTT struct {
-	client  mqtt.Client
-	msgChan chan mqtt.Message
-	cMut    sync.Mutex
+	client        mqtt.Client
+	msgChan       chan mqtt.Message
+	closeConnDone func()
+	cMut          sync.Mutex
 
 	staleConnectionTimeout time.Duration
+	reconnectBackoff       func() backoff.BackOff
+	tlsConf                *tls.Config
+	headers                http.Header
 
 	conf MQTTConfig
 
 	interruptChan chan struct{}
+	interruptOnce sync.Once
+
+	urls   []string
+	topics []string
+
+	connectAttempted bool
 
-	urls []string
+	mReconnectAttempt metrics.StatCounter
+	mReconnectErr     metrics.StatCounter
 
 	stats metrics.Type
 	log   log.Modular
@@ -68,19 +143,52 @@ func NewMQTT(
 	conf MQTTConfig, log log.Modular, stats metrics.Type,
 ) (*MQTT, error) {
 	m := &MQTT{
-		conf:          conf,
-		interruptChan: make(chan struct{}),
-		stats:         stats,
-		log:           log,
+		conf:              conf,
+		interruptChan:     make(chan struct{}),
+		stats:             stats,
+		log:               log,
+		mReconnectAttempt: stats.GetCounter("reconnect.attempt"),
+		mReconnectErr:     stats.GetCounter("reconnect.error"),
 	}
 
+	var err error
 	if len(conf.StaleConnectionTimeout) > 0 {
-		var err error
 		if m.staleConnectionTimeout, err = time.ParseDuration(conf.StaleConnectionTimeout); err != nil {
 			return nil, fmt.Errorf("unable to parse stale connection timeout duration string: %w", err)
 		}
 	}
 
+	if m.reconnectBackoff, err = conf.Reconnect.getCtor(); err != nil {
+		return nil, err
+	}
+
+	if conf.TLS.Enabled {
+		if m.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.StorePath != "" && conf.CleanSession {
+		log.Warnln("A store_path has been configured with clean_session enabled, messages published whilst disconnected will not be recovered.")
+	}
+
+	if conf.MaxInFlight < 1 {
+		return nil, fmt.Errorf("max_in_flight must be at least 1, got %v", conf.MaxInFlight)
+	}
+
+	if err = conf.Will.Validate(); err != nil {
+		return nil, err
+	}
+
+	if m.topics, err = mqttSharedTopics(conf.SharedSubscriptionGroup, conf.Topics); err != nil {
+		return nil, err
+	}
+
+	m.headers = http.Header{}
+	for k, v := range conf.HTTPHeaders {
+		m.headers.Add(k, v)
+	}
+
 	for _, u := range conf.URLs {
 		for _, splitURL := range strings.Split(u, ",") {
 			if len(splitURL) > 0 {
@@ -92,6 +200,26 @@ func NewMQTT(
 	return m, nil
 }
 
+// mqttSharedTopics returns the list of topics to subscribe to, where each topic
+// is converted into a shared subscription of the form $share/<group>/<topic>
+// when a group is specified.
+func mqttSharedTopics(group string, topics []string) ([]string, error) {
+	if group == "" {
+		return topics, nil
+	}
+	if strings.ContainsAny(group, "/+#") {
+		return nil, fmt.Errorf("shared subscription group '%v' must not contain the characters '/', '+' or '#'", group)
+	}
+	shared := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if strings.HasPrefix(topic, "$share/") {
+			return nil, fmt.Errorf("topic '%v' is already a shared subscription and cannot be combined with a shared subscription group", topic)
+		}
+		shared = append(shared, "$share/"+group+"/"+topic)
+	}
+	return shared, nil
+}
+
 //------------------------------------------------------------------------------
 
 // Connect establishes a connection to an MQTT server.
@@ -111,7 +239,18 @@ func (m *MQTT) ConnectWithContext(ctx context.Context) error {
 	var msgMut sync.Mutex
 	msgChan := make(chan mqtt.Message)
 
+	// Closed once the client has disconnected in order to release any
+	// handlers that are blocked on messages of this connection.
+	connDone := make(chan struct{})
+	var connDoneOnce sync.Once
+	closeConnDone := func() {
+		connDoneOnce.Do(func() {
+			close(connDone)
+		})
+	}
+
 	closeMsgChan := func() bool {
+		closeConnDone()
 		msgMut.Lock()
 		chanOpen := msgChan != nil
 		if chanOpen {
@@ -122,8 +261,52 @@ func (m *MQTT) ConnectWithContext(ctx context.Context) error {
 		return chanOpen
 	}
 
+	// The client acknowledges a message with the broker as soon as our handler
+	// returns, therefore the handler blocks until the message has been
+	// acknowledged downstream, and the number of blocked handlers is bounded by
+	// the in flight window. Handlers are released without an acknowledgement
+	// only once the client has disconnected, at which point the broker can no
+	// longer receive it.
+	inFlight := make(chan struct{}, m.conf.MaxInFlight)
+	msgHandler := func(c mqtt.Client, msg mqtt.Message) {
+		select {
+		case inFlight <- struct{}{}:
+		case <-connDone:
+			return
+		}
+		defer func() {
+			<-inFlight
+		}()
+
+		deferred := &mqttDeferredAck{
+			Message: msg,
+			ackChan: make(chan struct{}),
+		}
+
+		sent := false
+		msgMut.Lock()
+		if msgChan != nil {
+			select {
+			case msgChan <- deferred:
+				sent = true
+			case <-connDone:
+			}
+		}
+		msgMut.Unlock()
+		if !sent {
+			return
+		}
+
+		select {
+		case <-deferred.ackChan:
+		case <-connDone:
+		}
+	}
+
 	conf := mqtt.NewClientOptions().
 		SetAutoReconnect(false).
+		// Handlers must run asynchronously as they block until acknowledged.
+		SetOrderMatters(false).
 		SetClientID(m.conf.ClientID).
 		SetCleanSession(m.conf.CleanSession).
 		SetConnectionLostHandler(func(client mqtt.Client, reason error) {
@@ -131,18 +314,13 @@ func (m *MQTT) ConnectWithContext(ctx context.Context) error {
 			closeMsgChan()
 			m.log.Errorf("Connection lost due to: %v\n", reason)
 		}).
+		// When resuming a persistent session the broker may deliver queued
+		// messages before our subscriptions are re-established, and these are
+		// routed to the default handler.
+		SetDefaultPublishHandler(msgHandler).
 		SetOnConnectHandler(func(c mqtt.Client) {
-			for _, topic := range m.conf.Topics {
-				tok := c.Subscribe(topic, byte(m.conf.QoS), func(c mqtt.Client, msg mqtt.Message) {
-					msgMut.Lock()
-					if msgChan != nil {
-						select {
-						case msgChan <- msg:
-						case <-m.interruptChan:
-						}
-					}
-					msgMut.Unlock()
-				})
+			for _, topic := range m.topics {
+				tok := c.Subscribe(topic, byte(m.conf.QoS), msgHandler)
 				tok.Wait()
 				if err := tok.Error(); err != nil {
 					m.log.Errorf("Failed to subscribe to topic '%v': %v\n", topic, err)
@@ -160,19 +338,64 @@ func (m *MQTT) ConnectWithContext(ctx context.Context) error {
 		conf.SetPassword(m.conf.Password)
 	}
 
+	if m.conf.StorePath != "" {
+		conf = conf.SetStore(mqtt.NewFileStore(m.conf.StorePath))
+	}
+
+	if m.conf.Will.Enabled {
+		conf = conf.SetWill(m.conf.Will.Topic, m.conf.Will.Payload, m.conf.Will.QoS, m.conf.Will.Retained)
+	}
+
+	if m.tlsConf != nil {
+		conf.SetTLSConfig(m.tlsConf)
+	}
+
+	if len(m.headers) > 0 {
+		conf.SetHTTPHeaders(m.headers)
+	}
+
 	for _, u := range m.urls {
 		conf = conf.AddBroker(u)
 	}
 
 	client := mqtt.NewClient(conf)
 
-	tok := client.Connect()
-	tok.Wait()
-	if err := tok.Error(); err != nil {
-		return err
+	boff := m.reconnectBackoff()
+	for {
+		// Every attempt other than the very first is counted as a reconnect.
+		isReconnect := m.connectAttempted
+		m.connectAttempted = true
+		if isReconnect {
+			m.mReconnectAttempt.Incr(1)
+		}
+
+		tok := client.Connect()
+		tok.Wait()
+		err := tok.Error()
+		if err == nil {
+			break
+		}
+		if isReconnect {
+			m.mReconnectErr.Incr(1)
+		}
+
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			m.log.Errorf("Giving up on connecting to MQTT broker after %v retries: %v\n", m.conf.Reconnect.MaxRetries, err)
+			return types.ErrNotConnected
+		}
+		m.log.Warnf("Failed to connect to MQTT broker, retrying in %v: %v\n", wait, err)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		case <-m.interruptChan:
+			return types.ErrTypeClosed
+		}
 	}
 
-	m.log.Infof("Receiving MQTT messages from topics: %v\n", m.conf.Topics)
+	m.log.Infof("Receiving MQTT messages from topics: %v\n", m.topics)
 
 	if m.staleConnectionTimeout == 0 {
 		go func() {
@@ -194,6 +417,7 @@ func (m *MQTT) ConnectWithContext(ctx context.Context) error {
 
 	m.client = client
 	m.msgChan = msgChan
+	m.closeConnDone = closeConnDone
 	return nil
 }
 
@@ -220,6 +444,7 @@ func (m *MQTT) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn,
 		m.log.Errorln("Stale connection timeout triggered, re-establishing connection to broker.")
 		m.cMut.Lock()
 		m.client.Disconnect(0)
+		m.closeConnDone()
 		m.msgChan = nil
 		m.client = nil
 		m.cMut.Unlock()
@@ -268,11 +493,17 @@ func (m *MQTT) Acknowledge(err error) error {
 
 // CloseAsync shuts down the MQTT input and stops processing requests.
 func (m *MQTT) CloseAsync() {
+	// The interrupt is signalled before acquiring the lock in order to abort
+	// any pending reconnect attempts.
+	m.interruptOnce.Do(func() {
+		close(m.interruptChan)
+	})
+
 	m.cMut.Lock()
 	if m.client != nil {
 		m.client.Disconnect(0)
+		m.closeConnDone()
 		m.client = nil
-		close(m.interruptChan)
 	}
 	m.cMut.Unlock()
 }
@@ -283,3 +514,20 @@ func (m *MQTT) WaitForClose(timeout time.Duration) error {
 }
 
 //------------------------------------------------------------------------------
+
+// mqttDeferredAck wraps a message received by the MQTT client so that calling
+// Ack releases the handler that received it, allowing the client to send the
+// acknowledgement to the broker.
+type mqttDeferredAck struct {
+	mqtt.Message
+	ackOnce sync.Once
+	ackChan chan struct{}
+}
+
+func (d *mqttDeferredAck) Ack() {
+	d.ackOnce.Do(func() {
+		close(d.ackChan)
+	})
+}
+
+//------------------------------------------------------------------------------
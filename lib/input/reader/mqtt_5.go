@@ -0,0 +1,326 @@
+package reader
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/mqttconf"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/eclipse/paho.golang/paho"
+)
+
+//------------------------------------------------------------------------------
+
+// MQTT5Config contains configuration fields for the MQTT5 input type.
+type MQTT5Config struct {
+	URLs                    []string          `json:"urls" yaml:"urls"`
+	QoS                     uint8             `json:"qos" yaml:"qos"`
+	Topics                  []string          `json:"topics" yaml:"topics"`
+	SharedSubscriptionGroup string            `json:"shared_subscription_group" yaml:"shared_subscription_group"`
+	ClientID                string            `json:"client_id" yaml:"client_id"`
+	CleanStart              bool              `json:"clean_start" yaml:"clean_start"`
+	SessionExpiryInterval   string            `json:"session_expiry_interval" yaml:"session_expiry_interval"`
+	TopicAliasMaximum       uint16            `json:"topic_alias_maximum" yaml:"topic_alias_maximum"`
+	KeepAlive               string            `json:"keepalive" yaml:"keepalive"`
+	User                    string            `json:"user" yaml:"user"`
+	Password                string            `json:"password" yaml:"password"`
+	HTTPHeaders             map[string]string `json:"http_headers" yaml:"http_headers"`
+	TLS                     btls.Config       `json:"tls" yaml:"tls"`
+}
+
+// NewMQTT5Config creates a new MQTT5Config with default values.
+func NewMQTT5Config() MQTT5Config {
+	return MQTT5Config{
+		URLs:                    []string{"tcp://localhost:1883"},
+		QoS:                     1,
+		Topics:                  []string{"benthos_topic"},
+		SharedSubscriptionGroup: "",
+		ClientID:                "benthos_input",
+		CleanStart:              true,
+		SessionExpiryInterval:   "0s",
+		TopicAliasMaximum:       0,
+		KeepAlive:               "30s",
+		User:                    "",
+		Password:                "",
+		HTTPHeaders:             map[string]string{},
+		TLS:                     btls.NewConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MQTT5 is an input type that reads messages from MQTT brokers using the
+// MQTT v5 protocol.
+type MQTT5 struct {
+	client  *paho.Client
+	msgChan chan *paho.Publish
+	cMut    sync.Mutex
+
+	conf   MQTT5Config
+	urls   []*url.URL
+	topics []string
+
+	tlsConf       *tls.Config
+	headers       http.Header
+	sessionExpiry uint32
+	keepAlive     uint16
+
+	interruptChan chan struct{}
+	closeOnce     sync.Once
+
+	stats metrics.Type
+	log   log.Modular
+}
+
+// NewMQTT5 creates a new MQTT5 input type.
+func NewMQTT5(
+	conf MQTT5Config, log log.Modular, stats metrics.Type,
+) (*MQTT5, error) {
+	m := &MQTT5{
+		conf:          conf,
+		interruptChan: make(chan struct{}),
+		stats:         stats,
+		log:           log,
+	}
+
+	var err error
+	if m.urls, err = mqttconf.ParseURLs(conf.URLs); err != nil {
+		return nil, err
+	}
+	m.headers = http.Header{}
+	for k, v := range conf.HTTPHeaders {
+		m.headers.Add(k, v)
+	}
+	if m.topics, err = mqttSharedTopics(conf.SharedSubscriptionGroup, conf.Topics); err != nil {
+		return nil, err
+	}
+	if conf.TLS.Enabled {
+		if m.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+	if m.sessionExpiry, err = mqttconf.IntervalSeconds(conf.SessionExpiryInterval); err != nil {
+		return nil, fmt.Errorf("unable to parse session expiry interval: %w", err)
+	}
+	var keepAlive uint32
+	if keepAlive, err = mqttconf.IntervalSeconds(conf.KeepAlive); err != nil {
+		return nil, fmt.Errorf("unable to parse keepalive: %w", err)
+	}
+	if keepAlive > math.MaxUint16 {
+		return nil, fmt.Errorf("keepalive '%v' exceeds the maximum of %v seconds", conf.KeepAlive, math.MaxUint16)
+	}
+	m.keepAlive = uint16(keepAlive)
+
+	return m, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a connection to an MQTT server.
+func (m *MQTT5) Connect() error {
+	return m.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext establishes a connection to an MQTT server.
+func (m *MQTT5) ConnectWithContext(ctx context.Context) error {
+	m.cMut.Lock()
+	defer m.cMut.Unlock()
+
+	if m.client != nil {
+		return nil
+	}
+
+	conn, err := mqttconf.Dial(ctx, m.urls, m.tlsConf, m.headers)
+	if err != nil {
+		return err
+	}
+
+	var msgMut sync.Mutex
+	msgChan := make(chan *paho.Publish)
+
+	// Topic aliases are assigned by the broker for the lifetime of a
+	// connection, the first publish of an alias carries the topic and later
+	// publishes carry only the alias. Publishes are routed sequentially and so
+	// the aliases don't need a lock.
+	topicAliases := map[uint16]string{}
+
+	closeMsgChan := func() bool {
+		msgMut.Lock()
+		chanOpen := msgChan != nil
+		if chanOpen {
+			close(msgChan)
+			msgChan = nil
+		}
+		msgMut.Unlock()
+		return chanOpen
+	}
+
+	client := paho.NewClient(paho.ClientConfig{
+		Conn: conn,
+		Router: paho.NewSingleHandlerRouter(func(p *paho.Publish) {
+			if p.Properties != nil && p.Properties.TopicAlias != nil {
+				if p.Topic != "" {
+					topicAliases[*p.Properties.TopicAlias] = p.Topic
+				} else {
+					p.Topic = topicAliases[*p.Properties.TopicAlias]
+				}
+			}
+
+			msgMut.Lock()
+			if msgChan != nil {
+				select {
+				case msgChan <- p:
+				case <-m.interruptChan:
+				}
+			}
+			msgMut.Unlock()
+		}),
+		EnableManualAcknowledgment: true,
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			if closeMsgChan() {
+				reason := strconv.Itoa(int(d.ReasonCode))
+				if d.Properties != nil && d.Properties.ReasonString != "" {
+					reason = d.Properties.ReasonString
+				}
+				m.log.Errorf("Server initiated disconnect: %v\n", reason)
+			}
+		},
+		OnClientError: func(err error) {
+			if closeMsgChan() {
+				m.log.Errorf("Connection lost due to: %v\n", err)
+			}
+		},
+	})
+
+	connPacket := &paho.Connect{
+		ClientID:   m.conf.ClientID,
+		CleanStart: m.conf.CleanStart,
+		KeepAlive:  m.keepAlive,
+		Properties: &paho.ConnectProperties{
+			SessionExpiryInterval: &m.sessionExpiry,
+			TopicAliasMaximum:     &m.conf.TopicAliasMaximum,
+		},
+	}
+	if m.conf.User != "" {
+		connPacket.UsernameFlag = true
+		connPacket.Username = m.conf.User
+	}
+	if m.conf.Password != "" {
+		connPacket.PasswordFlag = true
+		connPacket.Password = []byte(m.conf.Password)
+	}
+
+	if _, err = client.Connect(ctx, connPacket); err != nil {
+		return err
+	}
+
+	sub := &paho.Subscribe{
+		Subscriptions: map[string]paho.SubscribeOptions{},
+	}
+	for _, topic := range m.topics {
+		sub.Subscriptions[topic] = paho.SubscribeOptions{
+			QoS: m.conf.QoS,
+		}
+	}
+	if _, err = client.Subscribe(ctx, sub); err != nil {
+		_ = client.Disconnect(&paho.Disconnect{})
+		return fmt.Errorf("failed to subscribe to topics '%v': %w", m.topics, err)
+	}
+
+	m.log.Infof("Receiving MQTT v5 messages from topics: %v\n", m.topics)
+
+	m.client = client
+	m.msgChan = msgChan
+	return nil
+}
+
+// ReadWithContext attempts to read a new message from an MQTT broker.
+func (m *MQTT5) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	m.cMut.Lock()
+	msgChan := m.msgChan
+	client := m.client
+	m.cMut.Unlock()
+
+	if msgChan == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	select {
+	case pub, open := <-msgChan:
+		if !open {
+			m.cMut.Lock()
+			m.msgChan = nil
+			m.client = nil
+			m.cMut.Unlock()
+			return nil, nil, types.ErrNotConnected
+		}
+
+		msg := message.New([][]byte{pub.Payload})
+
+		meta := msg.Get(0).Metadata()
+		meta.Set("mqtt_qos", strconv.Itoa(int(pub.QoS)))
+		meta.Set("mqtt_retained", strconv.FormatBool(pub.Retain))
+		meta.Set("mqtt_topic", pub.Topic)
+		meta.Set("mqtt_message_id", strconv.Itoa(int(pub.PacketID)))
+		if props := pub.Properties; props != nil {
+			if props.ContentType != "" {
+				meta.Set("mqtt_content_type", props.ContentType)
+			}
+			if props.ResponseTopic != "" {
+				meta.Set("mqtt_response_topic", props.ResponseTopic)
+			}
+			if len(props.CorrelationData) > 0 {
+				meta.Set("mqtt_correlation_data", string(props.CorrelationData))
+			}
+			if props.MessageExpiry != nil {
+				meta.Set("mqtt_message_expiry", strconv.FormatUint(uint64(*props.MessageExpiry), 10))
+			}
+			for _, prop := range props.User {
+				meta.Set(prop.Key, prop.Value)
+			}
+		}
+
+		return msg, func(ctx context.Context, res types.Response) error {
+			if res.Error() == nil {
+				return client.Ack(pub)
+			}
+			return nil
+		}, nil
+	case <-ctx.Done():
+	case <-m.interruptChan:
+		return nil, nil, types.ErrTypeClosed
+	}
+	return nil, nil, types.ErrTimeout
+}
+
+// CloseAsync shuts down the MQTT5 input and stops processing requests.
+func (m *MQTT5) CloseAsync() {
+	m.closeOnce.Do(func() {
+		close(m.interruptChan)
+	})
+
+	m.cMut.Lock()
+	if m.client != nil {
+		_ = m.client.Disconnect(&paho.Disconnect{})
+		m.client = nil
+	}
+	m.cMut.Unlock()
+}
+
+// WaitForClose blocks until the MQTT5 input has closed down.
+func (m *MQTT5) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,150 @@
+package reader
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runMQTT5TestBroker accepts a single connection and responds to it as a
+// broker would, sending the provided publishes once a subscription is made.
+func runMQTT5TestBroker(t *testing.T, pubs []*packets.Publish) (string, <-chan *packets.Connect, <-chan uint16) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	connects := make(chan *packets.Connect, 1)
+	pubacks := make(chan uint16, len(pubs))
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			cp, err := packets.ReadPacket(conn)
+			if err != nil {
+				return
+			}
+			switch p := cp.Content.(type) {
+			case *packets.Connect:
+				connects <- p
+				_, _ = (&packets.Connack{Properties: &packets.Properties{}}).WriteTo(conn)
+			case *packets.Subscribe:
+				_, _ = (&packets.Suback{PacketID: p.PacketID, Reasons: []byte{packets.SubackGrantedQoS1}}).WriteTo(conn)
+				for _, pub := range pubs {
+					_, _ = pub.WriteTo(conn)
+				}
+			case *packets.Puback:
+				pubacks <- p.PacketID
+			case *packets.Pingreq:
+				_, _ = (&packets.Pingresp{}).WriteTo(conn)
+			case *packets.Disconnect:
+				return
+			}
+		}
+	}()
+
+	return "tcp://" + ln.Addr().String(), connects, pubacks
+}
+
+func TestMQTT5Metadata(t *testing.T) {
+	alias, expiry := uint16(1), uint32(60)
+	url, connects, pubacks := runMQTT5TestBroker(t, []*packets.Publish{
+		{
+			QoS:      1,
+			PacketID: 1,
+			Topic:    "foo/bar",
+			Payload:  []byte("hello"),
+			Properties: &packets.Properties{
+				TopicAlias:    &alias,
+				MessageExpiry: &expiry,
+				ContentType:   "text/plain",
+				User: []packets.User{
+					{Key: "foo", Value: "bar"},
+					{Key: "baz", Value: "qux"},
+				},
+			},
+		},
+		{
+			QoS:      1,
+			PacketID: 2,
+			Payload:  []byte("world"),
+			Properties: &packets.Properties{
+				TopicAlias: &alias,
+			},
+		},
+	})
+
+	conf := NewMQTT5Config()
+	conf.URLs = []string{url}
+	conf.Topics = []string{"foo/#"}
+	conf.TopicAliasMaximum = 10
+
+	m, err := NewMQTT5(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		m.CloseAsync()
+		require.NoError(t, m.WaitForClose(time.Second))
+	}()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, m.ConnectWithContext(ctx))
+
+	connect := <-connects
+	require.NotNil(t, connect.Properties.TopicAliasMaximum)
+	assert.Equal(t, uint16(10), *connect.Properties.TopicAliasMaximum)
+
+	msg, ackFn, err := m.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(msg.Get(0).Get()))
+
+	meta := msg.Get(0).Metadata()
+	assert.Equal(t, "foo/bar", meta.Get("mqtt_topic"))
+	assert.Equal(t, "1", meta.Get("mqtt_qos"))
+	assert.Equal(t, "60", meta.Get("mqtt_message_expiry"))
+	assert.Equal(t, "text/plain", meta.Get("mqtt_content_type"))
+	assert.Equal(t, "bar", meta.Get("foo"))
+	assert.Equal(t, "qux", meta.Get("baz"))
+
+	require.NoError(t, ackFn(ctx, response.NewAck()))
+	select {
+	case id := <-pubacks:
+		assert.Equal(t, uint16(1), id)
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for puback")
+	}
+
+	msg, _, err = m.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(msg.Get(0).Get()))
+
+	meta = msg.Get(0).Metadata()
+	assert.Equal(t, "foo/bar", meta.Get("mqtt_topic"))
+	assert.Equal(t, "", meta.Get("mqtt_message_expiry"))
+	assert.Equal(t, "", meta.Get("foo"))
+}
+
+func TestMQTT5CloseBeforeConnect(t *testing.T) {
+	m, err := NewMQTT5(NewMQTT5Config(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	m.CloseAsync()
+	m.CloseAsync()
+	require.NoError(t, m.WaitForClose(time.Second))
+}
@@ -1,14 +1,17 @@
 package reader
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/ory/dockertest/v3"
@@ -37,6 +40,199 @@ func sendMQTTMsg(c mqtt.Client, topic, msg string) error {
 	return mtok.Error()
 }
 
+func TestMQTTSharedTopics(t *testing.T) {
+	topics, err := mqttSharedTopics("", []string{"foo", "bar/+"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := []string{"foo", "bar/+"}, topics; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong topics: %v != %v", act, exp)
+	}
+
+	if topics, err = mqttSharedTopics("baz", []string{"foo", "bar/+"}); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := []string{"$share/baz/foo", "$share/baz/bar/+"}, topics; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong topics: %v != %v", act, exp)
+	}
+
+	if _, err = mqttSharedTopics("baz/buz", []string{"foo"}); err == nil {
+		t.Error("Expected error from invalid group name")
+	}
+	if _, err = mqttSharedTopics("baz", []string{"$share/buz/foo"}); err == nil {
+		t.Error("Expected error from already shared topic")
+	}
+}
+
+type fakeMQTTMessage struct {
+	duplicate bool
+	qos       byte
+	retained  bool
+	topic     string
+	messageID uint16
+	payload   []byte
+	acked     bool
+}
+
+func (f *fakeMQTTMessage) Duplicate() bool   { return f.duplicate }
+func (f *fakeMQTTMessage) Qos() byte         { return f.qos }
+func (f *fakeMQTTMessage) Retained() bool    { return f.retained }
+func (f *fakeMQTTMessage) Topic() string     { return f.topic }
+func (f *fakeMQTTMessage) MessageID() uint16 { return f.messageID }
+func (f *fakeMQTTMessage) Payload() []byte   { return f.payload }
+func (f *fakeMQTTMessage) Ack()              { f.acked = true }
+
+func TestMQTTReadMetadata(t *testing.T) {
+	m, err := NewMQTT(NewMQTTConfig(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgChan := make(chan mqtt.Message, 1)
+	m.msgChan = msgChan
+
+	fakeMsg := &fakeMQTTMessage{
+		duplicate: true,
+		qos:       2,
+		retained:  true,
+		topic:     "foo/bar",
+		messageID: 42,
+		payload:   []byte("hello world"),
+	}
+	msgChan <- fakeMsg
+
+	msg, ackFn, err := m.ReadWithContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp, act := "hello world", string(msg.Get(0).Get()); exp != act {
+		t.Errorf("Wrong payload: %v != %v", act, exp)
+	}
+
+	expMeta := map[string]string{
+		"mqtt_duplicate":  "true",
+		"mqtt_qos":        "2",
+		"mqtt_retained":   "true",
+		"mqtt_topic":      "foo/bar",
+		"mqtt_message_id": "42",
+	}
+	actMeta := map[string]string{}
+	msg.Get(0).Metadata().Iter(func(k, v string) error {
+		actMeta[k] = v
+		return nil
+	})
+	if !reflect.DeepEqual(expMeta, actMeta) {
+		t.Errorf("Wrong metadata: %v != %v", actMeta, expMeta)
+	}
+
+	if err = ackFn(context.Background(), response.NewAck()); err != nil {
+		t.Error(err)
+	}
+	if !fakeMsg.acked {
+		t.Error("Expected message to be acknowledged")
+	}
+}
+
+func TestMQTTDeferredAck(t *testing.T) {
+	fakeMsg := &fakeMQTTMessage{topic: "foo"}
+	deferred := &mqttDeferredAck{
+		Message: fakeMsg,
+		ackChan: make(chan struct{}),
+	}
+
+	if exp, act := "foo", deferred.Topic(); exp != act {
+		t.Errorf("Wrong topic: %v != %v", act, exp)
+	}
+
+	select {
+	case <-deferred.ackChan:
+		t.Fatal("Ack channel closed before acknowledgement")
+	default:
+	}
+
+	deferred.Ack()
+	deferred.Ack()
+
+	select {
+	case <-deferred.ackChan:
+	default:
+		t.Error("Expected ack channel to be closed")
+	}
+	if fakeMsg.acked {
+		t.Error("Expected underlying message to be acknowledged by the client rather than directly")
+	}
+}
+
+func TestMQTTMaxInFlightError(t *testing.T) {
+	conf := NewMQTTConfig()
+	conf.MaxInFlight = 0
+	if _, err := NewMQTT(conf, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from zero max in flight")
+	}
+}
+
+func TestMQTTReconnectConfigErrors(t *testing.T) {
+	conf := NewMQTTConfig()
+	conf.Reconnect.InitialInterval = "nope"
+	if _, err := NewMQTT(conf, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad initial interval")
+	}
+
+	conf = NewMQTTConfig()
+	conf.Reconnect.Jitter = 1.5
+	if _, err := NewMQTT(conf, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad jitter")
+	}
+}
+
+func TestMQTTReconnectMaxRetries(t *testing.T) {
+	conf := NewMQTTConfig()
+	conf.URLs = []string{"tcp://localhost:1"}
+	conf.Reconnect.InitialInterval = "1ms"
+	conf.Reconnect.MaxInterval = "1ms"
+	conf.Reconnect.MaxRetries = 3
+
+	stats := metrics.NewLocal()
+	m, err := NewMQTT(conf, log.Noop(), stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.ConnectWithContext(context.Background()); err != types.ErrNotConnected {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrNotConnected)
+	}
+
+	counters := stats.GetCounters()
+	if exp, act := int64(3), counters["reconnect.attempt"]; exp != act {
+		t.Errorf("Wrong count of reconnect attempts: %v != %v", act, exp)
+	}
+	if exp, act := int64(3), counters["reconnect.error"]; exp != act {
+		t.Errorf("Wrong count of reconnect errors: %v != %v", act, exp)
+	}
+}
+
+func TestMQTTReconnectInterrupted(t *testing.T) {
+	conf := NewMQTTConfig()
+	conf.URLs = []string{"tcp://localhost:1"}
+	conf.Reconnect.InitialInterval = "1h"
+	conf.Reconnect.MaxInterval = "1h"
+
+	m, err := NewMQTT(conf, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		<-time.After(time.Millisecond * 100)
+		m.CloseAsync()
+	}()
+
+	if err = m.ConnectWithContext(context.Background()); err != types.ErrTypeClosed {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrTypeClosed)
+	}
+}
+
 func TestMQTTIntegration(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
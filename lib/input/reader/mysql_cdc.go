@@ -0,0 +1,907 @@
+package reader
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	gmysql "github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofrs/uuid"
+	gmlog "github.com/siddontang/go-log/log"
+)
+
+//------------------------------------------------------------------------------
+
+// MySQLCDCConfig contains configuration fields for the MySQLCDC input type.
+type MySQLCDCConfig struct {
+	DSN             string      `json:"dsn" yaml:"dsn"`
+	ServerID        uint32      `json:"server_id" yaml:"server_id"`
+	Tables          []string    `json:"tables" yaml:"tables"`
+	Snapshot        bool        `json:"snapshot" yaml:"snapshot"`
+	CheckpointCache string      `json:"checkpoint_cache" yaml:"checkpoint_cache"`
+	CheckpointKey   string      `json:"checkpoint_key" yaml:"checkpoint_key"`
+	CommitPeriod    string      `json:"commit_period" yaml:"commit_period"`
+	TLS             btls.Config `json:"tls" yaml:"tls"`
+}
+
+// NewMySQLCDCConfig creates a new MySQLCDCConfig with default values.
+func NewMySQLCDCConfig() MySQLCDCConfig {
+	return MySQLCDCConfig{
+		DSN:             "",
+		ServerID:        1001,
+		Tables:          []string{},
+		Snapshot:        false,
+		CheckpointCache: "",
+		CheckpointKey:   "mysql_cdc",
+		CommitPeriod:    "1s",
+		TLS:             btls.NewConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// mysqlCDCPosition is a position of the binary log from which consumption can
+// be resumed, which follows a transaction and identifies it by both the
+// position within the binary log files and the GTIDs of all transactions up to
+// and including it.
+type mysqlCDCPosition struct {
+	File     string `json:"file,omitempty"`
+	Position uint32 `json:"position,omitempty"`
+	GTIDSet  string `json:"gtid_set,omitempty"`
+}
+
+// Used to register the TLS configuration of each reader with a unique name.
+var mysqlCDCTLSConfigs int64
+
+// The replication client logs to stdout by default, which would otherwise be
+// interleaved with messages written to stdout, and errors are returned to the
+// reader regardless.
+var mysqlCDCDiscardLogsOnce sync.Once
+
+// MySQLCDC is a benthos reader.Async implementation that consumes the row
+// based changes of the binary log of a MySQL or MariaDB server.
+type MySQLCDC struct {
+	conf   MySQLCDCConfig
+	tables map[string]struct{}
+	cache  types.Cache
+
+	dsnConf    *mysql.Config
+	binlogConf replication.BinlogSyncerConfig
+
+	db      *sql.DB
+	closed  bool
+	connMut sync.Mutex
+
+	mariaDB  bool
+	useGTID  bool
+	snapshot bool
+	schemas  map[string]*mysqlCDCTable
+
+	// The position following the last transaction read from the binary log.
+	readPos mysqlCDCPosition
+
+	// The state of the transaction currently being read.
+	file    string
+	gtid    string
+	inTxn   bool
+	changes []mysqlCDCChange
+
+	cpMut     sync.Mutex
+	cp        *checkpoint.Type
+	index     int
+	pruned    int
+	positions map[int]*mysqlCDCPosition
+	tracked   *mysqlCDCPosition
+	position  *mysqlCDCPosition
+
+	commitMut sync.Mutex
+	committed *mysqlCDCPosition
+
+	msgChan chan asyncMessage
+
+	commitPeriod time.Duration
+
+	ctx        context.Context
+	done       func()
+	closedChan chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewMySQLCDC creates a new MySQL change data capture reader.
+func NewMySQLCDC(
+	conf MySQLCDCConfig,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (*MySQLCDC, error) {
+	if conf.DSN == "" {
+		return nil, errors.New("a dsn must be specified")
+	}
+	if conf.ServerID == 0 {
+		return nil, errors.New("server id must be greater than zero")
+	}
+	if conf.CheckpointCache != "" && conf.CheckpointKey == "" {
+		return nil, errors.New("a checkpoint key must be specified")
+	}
+
+	m := &MySQLCDC{
+		conf:       conf,
+		tables:     map[string]struct{}{},
+		schemas:    map[string]*mysqlCDCTable{},
+		cp:         checkpoint.New(0),
+		positions:  map[int]*mysqlCDCPosition{},
+		msgChan:    make(chan asyncMessage),
+		closedChan: make(chan struct{}),
+		log:        log,
+		stats:      stats,
+	}
+	m.ctx, m.done = context.WithCancel(context.Background())
+
+	mysqlCDCDiscardLogsOnce.Do(func() {
+		gmlog.SetDefaultLogger(gmlog.NewDefault(&gmlog.NullHandler{}))
+	})
+
+	for _, t := range conf.Tables {
+		if parts := strings.SplitN(t, ".", 2); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("table '%v' must be of the form schema.table", t)
+		}
+		m.tables[t] = struct{}{}
+	}
+
+	var err error
+	if m.commitPeriod, err = time.ParseDuration(conf.CommitPeriod); err != nil {
+		return nil, fmt.Errorf("failed to parse commit period string: %v", err)
+	}
+	if conf.CheckpointCache != "" {
+		if m.cache, err = mgr.GetCache(conf.CheckpointCache); err != nil {
+			return nil, fmt.Errorf("failed to obtain cache '%v': %v", conf.CheckpointCache, err)
+		}
+	}
+
+	if m.dsnConf, err = mysql.ParseDSN(conf.DSN); err != nil {
+		return nil, fmt.Errorf("failed to parse dsn: %v", err)
+	}
+	m.binlogConf = replication.BinlogSyncerConfig{
+		ServerID: conf.ServerID,
+		Host:     m.dsnConf.Addr,
+		User:     m.dsnConf.User,
+		Password: m.dsnConf.Passwd,
+
+		// Values are decoded in UTC in order to match those of snapshots, and
+		// decimals are decoded without loss of precision.
+		TimestampStringLocation: time.UTC,
+		UseDecimal:              true,
+
+		// Reconnects are handled by the reader, which resumes from the
+		// position following the last transaction read.
+		DisableRetrySync: true,
+	}
+	if m.dsnConf.Net != "unix" {
+		host, port, err := net.SplitHostPort(m.dsnConf.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse dsn address: %v", err)
+		}
+		p, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse dsn port: %v", err)
+		}
+		m.binlogConf.Host, m.binlogConf.Port = host, uint16(p)
+	}
+	if conf.TLS.Enabled {
+		if m.binlogConf.TLSConfig, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+		m.dsnConf.TLSConfig = "benthos_mysql_cdc_" + strconv.FormatInt(atomic.AddInt64(&mysqlCDCTLSConfigs, 1), 10)
+		if err = mysql.RegisterTLSConfig(m.dsnConf.TLSConfig, m.binlogConf.TLSConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	// Values are queried as text in UTC in order to match those decoded from
+	// the binary log.
+	m.dsnConf.ParseTime = false
+	if m.dsnConf.Params == nil {
+		m.dsnConf.Params = map[string]string{}
+	}
+	m.dsnConf.Params["time_zone"] = "'+00:00'"
+	return m, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext connects to the database, determines the position of
+// the binary log to consume from, and begins consuming changes.
+func (m *MySQLCDC) ConnectWithContext(ctx context.Context) error {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.closed {
+		return types.ErrTypeClosed
+	}
+	if m.db != nil {
+		return nil
+	}
+
+	connector, err := mysql.NewConnector(m.dsnConf)
+	if err != nil {
+		return err
+	}
+	db := sql.OpenDB(connector)
+	if err = m.init(ctx, db); err != nil {
+		db.Close()
+		return err
+	}
+
+	if m.useGTID {
+		m.log.Infof("Receiving MySQL changes from GTID set '%v'\n", m.readPos.GTIDSet)
+	} else {
+		m.log.Infof("Receiving MySQL changes from binlog file '%v' at position %v\n", m.readPos.File, m.readPos.Position)
+	}
+
+	m.db = db
+	go m.loop()
+	return nil
+}
+
+func (m *MySQLCDC) init(ctx context.Context, db *sql.DB) error {
+	var version, format string
+	if err := db.QueryRowContext(
+		ctx, "SELECT VERSION(), @@GLOBAL.binlog_format",
+	).Scan(&version, &format); err != nil {
+		return fmt.Errorf("failed to query binlog configuration: %w", err)
+	}
+	if format != "ROW" {
+		return fmt.Errorf("binlog_format must be ROW, found: %v", format)
+	}
+	m.mariaDB = strings.Contains(version, "MariaDB")
+	m.binlogConf.Flavor = gmysql.MySQLFlavor
+	if m.mariaDB {
+		m.binlogConf.Flavor = gmysql.MariaDBFlavor
+	}
+
+	// MariaDB always identifies transactions with GTIDs.
+	gtidMode := m.mariaDB
+	if !m.mariaDB {
+		var mode string
+		if err := db.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_mode").Scan(&mode); err != nil {
+			return fmt.Errorf("failed to query gtid mode: %w", err)
+		}
+		gtidMode = mode == "ON"
+	}
+
+	pos, err := m.loadCheckpoint()
+	if err != nil {
+		return err
+	}
+	if pos != nil {
+		m.tracked, m.position, m.committed = pos, pos, pos
+	} else {
+		m.snapshot = m.conf.Snapshot
+		if pos, err = m.currentPosition(ctx, db, gtidMode); err != nil {
+			return err
+		}
+	}
+
+	// Consumption is resumed by GTID when a set is known, otherwise the
+	// position within the binary log files is used.
+	m.useGTID = gtidMode && pos.GTIDSet != ""
+	if !m.useGTID && pos.File == "" {
+		return errors.New("checkpoint does not contain a binlog position")
+	}
+	if m.useGTID {
+		if _, err = gmysql.ParseGTIDSet(m.binlogConf.Flavor, pos.GTIDSet); err != nil {
+			return fmt.Errorf("failed to parse gtid set: %w", err)
+		}
+	}
+	m.readPos = *pos
+	return nil
+}
+
+// loadCheckpoint reads the position that was last committed to the cache,
+// returning nil when there isn't one.
+func (m *MySQLCDC) loadCheckpoint() (*mysqlCDCPosition, error) {
+	if m.cache == nil {
+		return nil, nil
+	}
+	data, err := m.cache.Get(m.conf.CheckpointKey)
+	if err != nil {
+		if err == types.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var pos mysqlCDCPosition
+	if err = json.Unmarshal(data, &pos); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	return &pos, nil
+}
+
+// currentPosition queries the position at the end of the binary log.
+func (m *MySQLCDC) currentPosition(ctx context.Context, db *sql.DB, gtidMode bool) (*mysqlCDCPosition, error) {
+	rows, err := db.QueryContext(ctx, "SHOW MASTER STATUS")
+	if err != nil {
+		// Renamed as of MySQL 8.4.
+		if rows, err = db.QueryContext(ctx, "SHOW BINARY LOG STATUS"); err != nil {
+			return nil, fmt.Errorf("failed to query binlog position: %w", err)
+		}
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err == nil {
+			err = errors.New("binary logging is not enabled")
+		}
+		return nil, fmt.Errorf("failed to query binlog position: %w", err)
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]sql.RawBytes, len(cols))
+	dests := make([]interface{}, len(cols))
+	for i := range values {
+		dests[i] = &values[i]
+	}
+	if err = rows.Scan(dests...); err != nil {
+		return nil, err
+	}
+	pos := &mysqlCDCPosition{File: string(values[0])}
+	if len(values) > 1 {
+		p, err := strconv.ParseUint(string(values[1]), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse binlog position: %w", err)
+		}
+		pos.Position = uint32(p)
+	}
+	rows.Close()
+
+	if gtidMode {
+		query := "SELECT @@GLOBAL.gtid_executed"
+		if m.mariaDB {
+			query = "SELECT @@GLOBAL.gtid_binlog_pos"
+		}
+		if err = db.QueryRowContext(ctx, query).Scan(&pos.GTIDSet); err != nil {
+			return nil, fmt.Errorf("failed to query gtid position: %w", err)
+		}
+		pos.GTIDSet = strings.ReplaceAll(pos.GTIDSet, "\n", "")
+	}
+	return pos, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (m *MySQLCDC) table(schema, table string) (*mysqlCDCTable, error) {
+	key := schema + "." + table
+	if t, exists := m.schemas[key]; exists {
+		return t, nil
+	}
+	t, err := queryMySQLTable(m.ctx, m.db, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	m.schemas[key] = t
+	return t, nil
+}
+
+func (m *MySQLCDC) included(schema, table string) bool {
+	if len(m.tables) == 0 {
+		return true
+	}
+	_, exists := m.tables[schema+"."+table]
+	return exists
+}
+
+// snapshotTables returns the tables to snapshot, which are all tables outside
+// of the system schemas unless tables are configured.
+func (m *MySQLCDC) snapshotTables() ([][2]string, error) {
+	var tables [][2]string
+	if len(m.conf.Tables) > 0 {
+		for _, t := range m.conf.Tables {
+			parts := strings.SplitN(t, ".", 2)
+			tables = append(tables, [2]string{parts[0], parts[1]})
+		}
+		return tables, nil
+	}
+
+	rows, err := m.db.QueryContext(
+		m.ctx,
+		"SELECT TABLE_SCHEMA, TABLE_NAME FROM information_schema.TABLES WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_SCHEMA NOT IN ('mysql', 'information_schema', 'performance_schema', 'sys') ORDER BY TABLE_SCHEMA, TABLE_NAME",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t [2]string
+		if err := rows.Scan(&t[0], &t[1]); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// takeSnapshot reads the rows of each table within a consistent snapshot,
+// which is followed by the changes made since the position of the binary log
+// obtained before the snapshot began.
+func (m *MySQLCDC) takeSnapshot() error {
+	tables, err := m.snapshotTables()
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	conn, err := m.db.Conn(m.ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(m.ctx, "SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ"); err != nil {
+		return err
+	}
+	if _, err = conn.ExecContext(m.ctx, "START TRANSACTION WITH CONSISTENT SNAPSHOT"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "ROLLBACK")
+
+	for _, t := range tables {
+		if err = m.snapshotTable(conn, t[0], t[1]); err != nil {
+			return fmt.Errorf("failed to snapshot table %v.%v: %w", t[0], t[1], err)
+		}
+	}
+
+	// The position prior to the snapshot is committed once all rows have
+	// been acknowledged.
+	pos := m.readPos
+	m.track(&pos, 0)
+	return nil
+}
+
+func (m *MySQLCDC) snapshotTable(conn *sql.Conn, schema, table string) error {
+	t, err := m.table(schema, table)
+	if err != nil {
+		return err
+	}
+	rows, err := conn.QueryContext(m.ctx, "SELECT * FROM "+quoteMySQLIdentifier(schema)+"."+quoteMySQLIdentifier(table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		dests := make([]interface{}, len(cols))
+		for i := range values {
+			dests[i] = &values[i]
+		}
+		if err := rows.Scan(dests...); err != nil {
+			return err
+		}
+		change := mysqlCDCChange{
+			Operation:  "read",
+			Schema:     schema,
+			Table:      table,
+			PrimaryKey: t.primaryKey,
+			New:        mysqlCDCRow(t, values, nil),
+		}
+		m.cpMut.Lock()
+		tracked := m.tracked
+		m.cpMut.Unlock()
+		if err := m.send(m.track(tracked, 1), []mysqlCDCChange{change}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+//------------------------------------------------------------------------------
+
+// track adds a transaction of changes that are pending acknowledgement,
+// returning the index of each change. Only the final change of a transaction
+// progresses the committed position once resolved, and a transaction without
+// changes is resolved immediately.
+func (m *MySQLCDC) track(pos *mysqlCDCPosition, changes int) []int {
+	m.cpMut.Lock()
+	defer m.cpMut.Unlock()
+
+	indexes := make([]int, changes)
+	for i := range indexes {
+		m.index++
+		m.cp.MustTrack(m.index)
+		m.positions[m.index] = m.tracked
+		indexes[i] = m.index
+	}
+	if changes == 0 {
+		m.index++
+		m.cp.MustTrack(m.index)
+		m.positions[m.index] = pos
+		m.resolveLocked(m.index)
+	} else {
+		m.positions[m.index] = pos
+	}
+	m.tracked = pos
+	return indexes
+}
+
+func (m *MySQLCDC) resolveLocked(index int) {
+	highest := m.cp.MustResolve(index)
+	if highest <= m.pruned {
+		return
+	}
+	if pos := m.positions[highest]; pos != nil {
+		m.position = pos
+	}
+	for ; m.pruned < highest; m.pruned++ {
+		delete(m.positions, m.pruned+1)
+	}
+}
+
+func (m *MySQLCDC) resolve(index int) {
+	m.cpMut.Lock()
+	m.resolveLocked(index)
+	m.cpMut.Unlock()
+}
+
+func (m *MySQLCDC) send(indexes []int, changes []mysqlCDCChange) error {
+	for i, change := range changes {
+		data, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		part := message.NewPart(data)
+		meta := part.Metadata()
+		meta.Set("mysql_cdc_operation", change.Operation)
+		meta.Set("mysql_cdc_schema", change.Schema)
+		meta.Set("mysql_cdc_table", change.Table)
+		if change.File != "" {
+			meta.Set("mysql_cdc_binlog_file", change.File)
+			meta.Set("mysql_cdc_binlog_position", strconv.FormatUint(uint64(change.Position), 10))
+		}
+		if change.GTID != "" {
+			meta.Set("mysql_cdc_gtid", change.GTID)
+		}
+		if change.timestamp > 0 {
+			meta.Set("mysql_cdc_timestamp_unix", strconv.FormatInt(change.timestamp, 10))
+		}
+
+		msg := message.New(nil)
+		msg.Append(part)
+
+		index := indexes[i]
+		select {
+		case m.msgChan <- asyncMessage{
+			msg: msg,
+			ackFn: func(ctx context.Context, res types.Response) error {
+				if res.Error() == nil {
+					m.resolve(index)
+				}
+				return nil
+			},
+		}:
+		case <-m.ctx.Done():
+			return m.ctx.Err()
+		}
+	}
+	return nil
+}
+
+// commit writes the position of the latest fully acknowledged transaction to
+// the checkpoint cache.
+func (m *MySQLCDC) commit() {
+	if m.cache == nil {
+		return
+	}
+
+	m.commitMut.Lock()
+	defer m.commitMut.Unlock()
+
+	m.cpMut.Lock()
+	pos := m.position
+	m.cpMut.Unlock()
+
+	if pos == nil || pos == m.committed {
+		return
+	}
+	data, err := json.Marshal(pos)
+	if err != nil {
+		m.log.Errorf("Failed to encode checkpoint: %v\n", err)
+		return
+	}
+	if err = m.cache.Set(m.conf.CheckpointKey, data); err != nil {
+		m.log.Errorf("Failed to write checkpoint: %v\n", err)
+		return
+	}
+	m.committed = pos
+}
+
+//------------------------------------------------------------------------------
+
+// stream consumes the binary log from the position following the last
+// transaction that was read until an error occurs.
+func (m *MySQLCDC) stream() error {
+	syncer := replication.NewBinlogSyncer(m.binlogConf)
+	defer syncer.Close()
+
+	var streamer *replication.BinlogStreamer
+	var err error
+	if m.useGTID {
+		var gtidSet gmysql.GTIDSet
+		if gtidSet, err = gmysql.ParseGTIDSet(m.binlogConf.Flavor, m.readPos.GTIDSet); err == nil {
+			streamer, err = syncer.StartSyncGTID(gtidSet)
+		}
+	} else {
+		streamer, err = syncer.StartSync(gmysql.Position{
+			Name: m.readPos.File,
+			Pos:  m.readPos.Position,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	m.file = m.readPos.File
+	m.resetTxn()
+	for {
+		e, err := streamer.GetEvent(m.ctx)
+		if err != nil {
+			return err
+		}
+		if err = m.handleEvent(e); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *MySQLCDC) resetTxn() {
+	m.gtid = ""
+	m.inTxn = false
+	m.changes = nil
+}
+
+func (m *MySQLCDC) handleEvent(e *replication.BinlogEvent) error {
+	switch ev := e.Event.(type) {
+	case *replication.RotateEvent:
+		m.file = string(ev.NextLogName)
+	case *replication.GTIDEvent:
+		m.resetTxn()
+		sid, err := uuid.FromBytes(ev.SID)
+		if err != nil {
+			return fmt.Errorf("failed to parse gtid: %w", err)
+		}
+		m.gtid = sid.String() + ":" + strconv.FormatInt(ev.GNO, 10)
+	case *replication.MariadbGTIDEvent:
+		m.resetTxn()
+		m.gtid = ev.GTID.String()
+		m.inTxn = !ev.IsStandalone()
+	case *replication.QueryEvent:
+		switch strings.ToUpper(strings.TrimSpace(string(ev.Query))) {
+		case "BEGIN":
+			m.inTxn = true
+		case "COMMIT":
+			return m.commitTxn(e.Header, ev.GSet)
+		default:
+			// Statements other than those that delimit transactions change
+			// the schema of tables, the columns of which are queried again.
+			m.schemas = map[string]*mysqlCDCTable{}
+			if !m.inTxn {
+				return m.commitTxn(e.Header, ev.GSet)
+			}
+		}
+	case *replication.XIDEvent:
+		return m.commitTxn(e.Header, ev.GSet)
+	case *replication.RowsEvent:
+		return m.addRows(ev, e.Header)
+	}
+	return nil
+}
+
+func (m *MySQLCDC) addRows(ev *replication.RowsEvent, header *replication.EventHeader) error {
+	schema, table := string(ev.Table.Schema), string(ev.Table.Table)
+	if !m.included(schema, table) {
+		return nil
+	}
+	t, err := m.table(schema, table)
+	if err != nil {
+		return err
+	}
+
+	var operation string
+	switch header.EventType {
+	case replication.WRITE_ROWS_EVENTv0, replication.WRITE_ROWS_EVENTv1, replication.WRITE_ROWS_EVENTv2:
+		operation = "insert"
+	case replication.UPDATE_ROWS_EVENTv0, replication.UPDATE_ROWS_EVENTv1, replication.UPDATE_ROWS_EVENTv2:
+		operation = "update"
+	case replication.DELETE_ROWS_EVENTv0, replication.DELETE_ROWS_EVENTv1, replication.DELETE_ROWS_EVENTv2:
+		operation = "delete"
+	default:
+		return nil
+	}
+
+	row := func(i int) map[string]interface{} {
+		var skipped []int
+		if i < len(ev.SkippedColumns) {
+			skipped = ev.SkippedColumns[i]
+		}
+		return mysqlCDCRow(t, ev.Rows[i], skipped)
+	}
+
+	// The rows of update events alternate between the row before and after
+	// each change.
+	step := 1
+	if operation == "update" {
+		step = 2
+	}
+	for i := 0; i+step-1 < len(ev.Rows); i += step {
+		change := mysqlCDCChange{
+			Operation:  operation,
+			Schema:     schema,
+			Table:      table,
+			PrimaryKey: t.primaryKey,
+			timestamp:  int64(header.Timestamp),
+		}
+		switch operation {
+		case "insert":
+			change.New = row(i)
+		case "update":
+			change.Old = row(i)
+			change.New = row(i + 1)
+		case "delete":
+			change.Old = row(i)
+		}
+		m.changes = append(m.changes, change)
+	}
+	return nil
+}
+
+// commitTxn sends the changes of a transaction once it has been committed,
+// where gtidSet is the set of transactions executed up to and including it.
+func (m *MySQLCDC) commitTxn(header *replication.EventHeader, gtidSet gmysql.GTIDSet) error {
+	pos := mysqlCDCPosition{
+		File:     m.file,
+		Position: header.LogPos,
+	}
+	if m.useGTID && gtidSet != nil {
+		pos.GTIDSet = gtidSet.String()
+	} else {
+		pos.GTIDSet = m.readPos.GTIDSet
+	}
+
+	changes := m.changes
+	for i := range changes {
+		changes[i].GTID = m.gtid
+		changes[i].File = pos.File
+		changes[i].Position = pos.Position
+	}
+	m.readPos = pos
+	m.resetTxn()
+	return m.send(m.track(&pos, len(changes)), changes)
+}
+
+func (m *MySQLCDC) loop() {
+	defer func() {
+		m.commit()
+		m.db.Close()
+		close(m.msgChan)
+		close(m.closedChan)
+	}()
+
+	go func() {
+		commitTicker := time.NewTicker(m.commitPeriod)
+		defer commitTicker.Stop()
+		for {
+			select {
+			case <-commitTicker.C:
+				m.commit()
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for m.snapshot {
+		err := m.takeSnapshot()
+		if err == nil {
+			m.snapshot = false
+			break
+		}
+		if m.ctx.Err() != nil {
+			return
+		}
+		m.log.Errorf("Failed to take snapshot: %v\n", err)
+		select {
+		case <-time.After(time.Second):
+		case <-m.ctx.Done():
+			return
+		}
+	}
+
+	for {
+		err := m.stream()
+		if m.ctx.Err() != nil {
+			return
+		}
+		m.log.Errorf("Failed to consume binlog: %v\n", err)
+		select {
+		case <-time.After(time.Second):
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ReadWithContext attempts to read a new change from the binary log.
+func (m *MySQLCDC) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	m.connMut.Lock()
+	connected := m.db != nil || m.closed
+	m.connMut.Unlock()
+
+	if !connected {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	select {
+	case msg, open := <-m.msgChan:
+		if !open {
+			return nil, nil, types.ErrTypeClosed
+		}
+		return msg.msg, msg.ackFn, nil
+	case <-ctx.Done():
+	}
+	return nil, nil, types.ErrTimeout
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (m *MySQLCDC) CloseAsync() {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.closed {
+		return
+	}
+	m.closed = true
+	m.done()
+
+	// Without a connection the background loop was never started.
+	if m.db == nil {
+		close(m.msgChan)
+		close(m.closedChan)
+	}
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (m *MySQLCDC) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-m.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,219 @@
+package reader
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+//------------------------------------------------------------------------------
+
+// mysqlCDCChange is a row level change event of a MySQL table.
+type mysqlCDCChange struct {
+	Operation  string                 `json:"operation"`
+	Schema     string                 `json:"schema"`
+	Table      string                 `json:"table"`
+	PrimaryKey []string               `json:"primary_key,omitempty"`
+	GTID       string                 `json:"gtid,omitempty"`
+	File       string                 `json:"binlog_file,omitempty"`
+	Position   uint32                 `json:"binlog_position,omitempty"`
+	New        map[string]interface{} `json:"new,omitempty"`
+	Old        map[string]interface{} `json:"old,omitempty"`
+
+	timestamp int64
+}
+
+type mysqlCDCColumn struct {
+	name     string
+	dataType string
+	unsigned bool
+
+	// The permitted values of enum and set columns.
+	values []string
+}
+
+type mysqlCDCTable struct {
+	columns    []mysqlCDCColumn
+	primaryKey []string
+}
+
+// quoteMySQLIdentifier quotes a schema or table name.
+func quoteMySQLIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// parseMySQLEnumValues parses the permitted values of an enum or set column
+// from its column type, such as enum('a','b').
+func parseMySQLEnumValues(columnType string) []string {
+	start, end := strings.Index(columnType, "("), strings.LastIndex(columnType, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+	var values []string
+	var current strings.Builder
+	quoted := false
+	list := columnType[start+1 : end]
+	for i := 0; i < len(list); i++ {
+		c := list[i]
+		switch {
+		case c == '\'' && quoted && i+1 < len(list) && list[i+1] == '\'':
+			current.WriteByte('\'')
+			i++
+		case c == '\'':
+			if quoted {
+				values = append(values, current.String())
+				current.Reset()
+			}
+			quoted = !quoted
+		case quoted:
+			current.WriteByte(c)
+		}
+	}
+	return values
+}
+
+// queryMySQLTable obtains the columns of a table from the information schema.
+func queryMySQLTable(ctx context.Context, db *sql.DB, schema, table string) (*mysqlCDCTable, error) {
+	rows, err := db.QueryContext(
+		ctx,
+		"SELECT COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, COLUMN_KEY FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION",
+		schema, table,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns of table %v.%v: %w", schema, table, err)
+	}
+	defer rows.Close()
+
+	t := &mysqlCDCTable{}
+	for rows.Next() {
+		var col mysqlCDCColumn
+		var columnType, columnKey string
+		if err := rows.Scan(&col.name, &col.dataType, &columnType, &columnKey); err != nil {
+			return nil, err
+		}
+		col.dataType = strings.ToLower(col.dataType)
+		col.unsigned = strings.Contains(strings.ToLower(columnType), "unsigned")
+		if col.dataType == "enum" || col.dataType == "set" {
+			col.values = parseMySQLEnumValues(columnType)
+		}
+		if columnKey == "PRI" {
+			t.primaryKey = append(t.primaryKey, col.name)
+		}
+		t.columns = append(t.columns, col)
+	}
+	return t, rows.Err()
+}
+
+//------------------------------------------------------------------------------
+
+// mysqlCDCRow converts the values of a row into a map of column names to
+// values, where the values of skipped columns are omitted.
+func mysqlCDCRow(t *mysqlCDCTable, values []interface{}, skipped []int) map[string]interface{} {
+	omit := make(map[int]struct{}, len(skipped))
+	for _, i := range skipped {
+		omit[i] = struct{}{}
+	}
+	row := make(map[string]interface{}, len(values))
+	for i, v := range values {
+		if _, exists := omit[i]; exists {
+			continue
+		}
+		if i < len(t.columns) {
+			row[t.columns[i].name] = mysqlCDCValue(t.columns[i], v)
+		} else {
+			row[strconv.Itoa(i)] = v
+		}
+	}
+	return row
+}
+
+// mysqlCDCValue converts a value of a column with either the types decoded
+// from the binary log or the text representation returned by a query into
+// the same JSON compatible value.
+func mysqlCDCValue(col mysqlCDCColumn, v interface{}) interface{} {
+	switch t := v.(type) {
+	case decimal.Decimal:
+		return json.Number(t.String())
+	case int8:
+		if col.unsigned {
+			return uint8(t)
+		}
+	case int16:
+		if col.unsigned {
+			return uint16(t)
+		}
+	case int32:
+		if col.unsigned {
+			if col.dataType == "mediumint" {
+				return uint32(t) & 0xffffff
+			}
+			return uint32(t)
+		}
+	case int64:
+		switch col.dataType {
+		case "enum":
+			if t <= 0 || int(t) > len(col.values) {
+				return ""
+			}
+			return col.values[t-1]
+		case "set":
+			var values []string
+			for i, value := range col.values {
+				if uint64(t)&(1<<uint(i)) != 0 {
+					values = append(values, value)
+				}
+			}
+			return strings.Join(values, ",")
+		case "bit":
+			return uint64(t)
+		}
+		if col.unsigned {
+			return uint64(t)
+		}
+	case string:
+		return mysqlCDCTextValue(col, []byte(t))
+	case []byte:
+		return mysqlCDCTextValue(col, t)
+	}
+	return v
+}
+
+func mysqlCDCTextValue(col mysqlCDCColumn, b []byte) interface{} {
+	s := string(b)
+	switch col.dataType {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "year":
+		if col.unsigned {
+			if i, err := strconv.ParseUint(s, 10, 64); err == nil {
+				return i
+			}
+		} else if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case "decimal", "numeric", "float", "double", "real":
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return json.Number(s)
+		}
+	case "bit":
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v
+	case "json":
+		if json.Valid(b) {
+			return json.RawMessage(s)
+		}
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob",
+		"geometry", "point", "linestring", "polygon", "multipoint",
+		"multilinestring", "multipolygon", "geometrycollection":
+		return append([]byte(nil), b...)
+	}
+	return s
+}
+
+//------------------------------------------------------------------------------
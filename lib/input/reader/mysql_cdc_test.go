@@ -0,0 +1,241 @@
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	gmysql "github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/gofrs/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMySQLCDCConfigErrors(t *testing.T) {
+	conf := NewMySQLCDCConfig()
+	_, err := NewMySQLCDC(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.DSN = "foo:bar@tcp(localhost:3306)/"
+	conf.Tables = []string{"nope"}
+	_, err = NewMySQLCDC(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Tables = []string{"shop.users"}
+	conf.CheckpointCache = "nope"
+	_, err = NewMySQLCDC(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.CheckpointCache = ""
+	m, err := NewMySQLCDC(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", m.binlogConf.Host)
+	assert.Equal(t, uint16(3306), m.binlogConf.Port)
+	assert.Equal(t, "foo", m.binlogConf.User)
+	assert.Equal(t, "bar", m.binlogConf.Password)
+	assert.Equal(t, uint32(1001), m.binlogConf.ServerID)
+}
+
+func TestMySQLCDCEnumValues(t *testing.T) {
+	assert.Equal(t, []string{"a", "b'c", "d,e"}, parseMySQLEnumValues("enum('a','b''c','d,e')"))
+	assert.Equal(t, []string{"x"}, parseMySQLEnumValues("set('x')"))
+	assert.Nil(t, parseMySQLEnumValues("int"))
+}
+
+func TestMySQLCDCValues(t *testing.T) {
+	tests := []struct {
+		col      mysqlCDCColumn
+		value    interface{}
+		expected interface{}
+	}{
+		{mysqlCDCColumn{dataType: "tinyint", unsigned: true}, int8(-1), uint8(255)},
+		{mysqlCDCColumn{dataType: "mediumint", unsigned: true}, int32(-1), uint32(0xffffff)},
+		{mysqlCDCColumn{dataType: "bigint"}, int64(-1), int64(-1)},
+		{mysqlCDCColumn{dataType: "bigint"}, []byte("-5"), int64(-5)},
+		{mysqlCDCColumn{dataType: "bigint", unsigned: true}, []byte("18446744073709551615"), uint64(18446744073709551615)},
+		{mysqlCDCColumn{dataType: "decimal"}, "10.50", json.Number("10.50")},
+		{mysqlCDCColumn{dataType: "decimal"}, decimal.RequireFromString("10.50"), json.Number("10.5")},
+		{mysqlCDCColumn{dataType: "enum", values: []string{"a", "b"}}, int64(2), "b"},
+		{mysqlCDCColumn{dataType: "enum", values: []string{"a", "b"}}, []byte("a"), "a"},
+		{mysqlCDCColumn{dataType: "set", values: []string{"a", "b", "c"}}, int64(5), "a,c"},
+		{mysqlCDCColumn{dataType: "bit"}, int64(0x0102), uint64(0x0102)},
+		{mysqlCDCColumn{dataType: "bit"}, []byte{0x01, 0x02}, uint64(0x0102)},
+		{mysqlCDCColumn{dataType: "json"}, []byte(`{"a":1}`), json.RawMessage(`{"a":1}`)},
+		{mysqlCDCColumn{dataType: "text"}, []byte("foo"), "foo"},
+		{mysqlCDCColumn{dataType: "varbinary"}, "foo", []byte("foo")},
+		{mysqlCDCColumn{dataType: "datetime"}, "2020-01-02 03:04:05", "2020-01-02 03:04:05"},
+		{mysqlCDCColumn{dataType: "int"}, nil, nil},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, mysqlCDCValue(test.col, test.value), test.col.dataType)
+	}
+}
+
+func TestMySQLCDCTransactions(t *testing.T) {
+	conf := NewMySQLCDCConfig()
+	conf.DSN = "foo:bar@tcp(localhost:3306)/"
+	conf.Tables = []string{"shop.users"}
+
+	m, err := NewMySQLCDC(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer m.done()
+
+	m.cache, err = cache.NewMemory(cache.NewConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	m.useGTID = true
+	m.schemas["shop.users"] = &mysqlCDCTable{
+		columns: []mysqlCDCColumn{
+			{name: "id", dataType: "int", unsigned: true},
+			{name: "name", dataType: "varchar"},
+		},
+		primaryKey: []string{"id"},
+	}
+	users := &replication.TableMapEvent{Schema: []byte("shop"), Table: []byte("users")}
+	orders := &replication.TableMapEvent{Schema: []byte("shop"), Table: []byte("orders")}
+
+	sid := uuid.Must(uuid.FromString("3e11fa47-71ca-11e1-9e33-c80aa9429562"))
+	gtidSet := func(s string) gmysql.GTIDSet {
+		set, err := gmysql.ParseMysqlGTIDSet(s)
+		require.NoError(t, err)
+		return set
+	}
+
+	events := []*replication.BinlogEvent{
+		{Header: &replication.EventHeader{}, Event: &replication.RotateEvent{NextLogName: []byte("mysql-bin.000003")}},
+		{Header: &replication.EventHeader{}, Event: &replication.GTIDEvent{SID: sid.Bytes(), GNO: 6}},
+		{Header: &replication.EventHeader{}, Event: &replication.QueryEvent{Query: []byte("BEGIN")}},
+		{
+			Header: &replication.EventHeader{Timestamp: 1600000000, EventType: replication.WRITE_ROWS_EVENTv2},
+			Event: &replication.RowsEvent{
+				Table: users,
+				Rows: [][]interface{}{
+					{int32(1), "foo"},
+					{int32(2), nil},
+				},
+			},
+		},
+		{
+			Header: &replication.EventHeader{EventType: replication.DELETE_ROWS_EVENTv2},
+			Event: &replication.RowsEvent{
+				Table: orders,
+				Rows:  [][]interface{}{{int32(1)}},
+			},
+		},
+		{
+			Header: &replication.EventHeader{LogPos: 500},
+			Event:  &replication.XIDEvent{XID: 10, GSet: gtidSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-6")},
+		},
+		{Header: &replication.EventHeader{}, Event: &replication.GTIDEvent{SID: sid.Bytes(), GNO: 7}},
+		{Header: &replication.EventHeader{}, Event: &replication.QueryEvent{Query: []byte("BEGIN")}},
+		{
+			Header: &replication.EventHeader{EventType: replication.UPDATE_ROWS_EVENTv2},
+			Event: &replication.RowsEvent{
+				Table: users,
+				Rows: [][]interface{}{
+					{int32(2), nil},
+					{int32(3), nil},
+				},
+				SkippedColumns: [][]int{nil, {1}},
+			},
+		},
+		{
+			Header: &replication.EventHeader{LogPos: 700},
+			Event:  &replication.XIDEvent{XID: 11, GSet: gtidSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-7")},
+		},
+		{Header: &replication.EventHeader{}, Event: &replication.GTIDEvent{SID: sid.Bytes(), GNO: 8}},
+		{
+			Header: &replication.EventHeader{LogPos: 800},
+			Event: &replication.QueryEvent{
+				Query: []byte("ALTER TABLE shop.orders ADD COLUMN total INT"),
+				GSet:  gtidSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-8"),
+			},
+		},
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		for _, e := range events {
+			if err := m.handleEvent(e); err != nil {
+				errChan <- err
+				return
+			}
+		}
+		errChan <- nil
+	}()
+
+	var msgs []asyncMessage
+	for i := 0; i < 3; i++ {
+		select {
+		case msg := <-m.msgChan:
+			msgs = append(msgs, msg)
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+	require.NoError(t, <-errChan)
+
+	var change map[string]interface{}
+	require.NoError(t, json.Unmarshal(msgs[0].msg.Get(0).Get(), &change))
+	assert.Equal(t, map[string]interface{}{
+		"operation":       "insert",
+		"schema":          "shop",
+		"table":           "users",
+		"primary_key":     []interface{}{"id"},
+		"gtid":            "3e11fa47-71ca-11e1-9e33-c80aa9429562:6",
+		"binlog_file":     "mysql-bin.000003",
+		"binlog_position": float64(500),
+		"new":             map[string]interface{}{"id": float64(1), "name": "foo"},
+	}, change)
+
+	meta := msgs[0].msg.Get(0).Metadata()
+	assert.Equal(t, "insert", meta.Get("mysql_cdc_operation"))
+	assert.Equal(t, "users", meta.Get("mysql_cdc_table"))
+	assert.Equal(t, "500", meta.Get("mysql_cdc_binlog_position"))
+	assert.Equal(t, "1600000000", meta.Get("mysql_cdc_timestamp_unix"))
+
+	assert.Equal(t, `{"id":2,"name":null}`, string(mustMarshalField(t, msgs[1].msg.Get(0).Get(), "new")))
+	assert.Equal(t, `{"id":3}`, string(mustMarshalField(t, msgs[2].msg.Get(0).Get(), "new")))
+	assert.Equal(t, `{"id":2,"name":null}`, string(mustMarshalField(t, msgs[2].msg.Get(0).Get(), "old")))
+	assert.Equal(t, mysqlCDCPosition{
+		File:     "mysql-bin.000003",
+		Position: 800,
+		GTIDSet:  "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-8",
+	}, m.readPos)
+
+	// The schema change invalidates cached table columns.
+	assert.Empty(t, m.schemas)
+
+	// Positions are committed only once all prior changes are acknowledged.
+	ctx := context.Background()
+	require.NoError(t, msgs[2].ackFn(ctx, response.NewAck()))
+	require.NoError(t, msgs[0].ackFn(ctx, response.NewAck()))
+	m.commit()
+	_, err = m.cache.Get(conf.CheckpointKey)
+	assert.Equal(t, types.ErrKeyNotFound, err)
+
+	require.NoError(t, msgs[1].ackFn(ctx, response.NewAck()))
+	m.commit()
+	data, err := m.cache.Get(conf.CheckpointKey)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"file":"mysql-bin.000003","position":800,"gtid_set":"3e11fa47-71ca-11e1-9e33-c80aa9429562:1-8"}`, string(data))
+
+	pos, err := m.loadCheckpoint()
+	require.NoError(t, err)
+	assert.Equal(t, &m.readPos, pos)
+}
+
+func mustMarshalField(t *testing.T, data []byte, field string) []byte {
+	t.Helper()
+	var doc map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &doc))
+	return doc[field]
+}
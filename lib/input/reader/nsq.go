@@ -3,6 +3,7 @@ package reader
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"io/ioutil"
 	llog "log"
 	"strings"
@@ -28,6 +29,8 @@ type NSQConfig struct {
 	Channel         string             `json:"channel" yaml:"channel"`
 	UserAgent       string             `json:"user_agent" yaml:"user_agent"`
 	TLS             btls.Config        `json:"tls" yaml:"tls"`
+	AuthSecret      string             `json:"auth_secret" yaml:"auth_secret"`
+	SampleRate      int32              `json:"sample_rate" yaml:"sample_rate"`
 	MaxInFlight     int                `json:"max_in_flight" yaml:"max_in_flight"`
 	Batching        batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
@@ -43,6 +46,8 @@ func NewNSQConfig() NSQConfig {
 		Channel:         "benthos_stream",
 		UserAgent:       "benthos_consumer",
 		TLS:             btls.NewConfig(),
+		AuthSecret:      "",
+		SampleRate:      0,
 		MaxInFlight:     100,
 		Batching:        batching,
 	}
@@ -97,6 +102,9 @@ func NewNSQ(conf NSQConfig, log log.Modular, stats metrics.Type) (*NSQ, error) {
 			return nil, err
 		}
 	}
+	if conf.SampleRate < 0 || conf.SampleRate > 99 {
+		return nil, fmt.Errorf("sample rate must be between 0 and 99, got %v", conf.SampleRate)
+	}
 	return &n, nil
 }
 
@@ -133,6 +141,8 @@ func (n *NSQ) ConnectWithContext(ctx context.Context) (err error) {
 	cfg := nsq.NewConfig()
 	cfg.UserAgent = n.conf.UserAgent
 	cfg.MaxInFlight = n.conf.MaxInFlight
+	cfg.AuthSecret = n.conf.AuthSecret
+	cfg.SampleRate = n.conf.SampleRate
 	if n.tlsConf != nil {
 		cfg.TlsV1 = true
 		cfg.TlsConfig = n.tlsConf
@@ -0,0 +1,479 @@
+package reader
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
+)
+
+//------------------------------------------------------------------------------
+
+// PostgresCDCConfig contains configuration fields for the PostgresCDC input
+// type.
+type PostgresCDCConfig struct {
+	DSN          string `json:"dsn" yaml:"dsn"`
+	Slot         string `json:"slot" yaml:"slot"`
+	CreateSlot   bool   `json:"create_slot" yaml:"create_slot"`
+	Plugin       string `json:"plugin" yaml:"plugin"`
+	Publication  string `json:"publication" yaml:"publication"`
+	MaxChanges   int    `json:"max_changes" yaml:"max_changes"`
+	PollInterval string `json:"poll_interval" yaml:"poll_interval"`
+	CommitPeriod string `json:"commit_period" yaml:"commit_period"`
+}
+
+// NewPostgresCDCConfig creates a new PostgresCDCConfig with default values.
+func NewPostgresCDCConfig() PostgresCDCConfig {
+	return PostgresCDCConfig{
+		DSN:          "",
+		Slot:         "benthos",
+		CreateSlot:   false,
+		Plugin:       "pgoutput",
+		Publication:  "benthos",
+		MaxChanges:   1000,
+		PollInterval: "1s",
+		CommitPeriod: "1s",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// PostgresCDC is a benthos reader.Async implementation that consumes the
+// changes of a PostgreSQL logical replication slot.
+type PostgresCDC struct {
+	conf    PostgresCDCConfig
+	decoder pgCDCDecoder
+
+	db      *sql.DB
+	closed  bool
+	connMut sync.Mutex
+
+	// The commit LSN of the last transaction read from the slot.
+	readLSN uint64
+
+	// The commit LSN of the last transaction that was tracked.
+	tracked uint64
+
+	cpMut     sync.Mutex
+	cp        *checkpoint.Type
+	index     int
+	pruned    int
+	positions map[int]uint64
+	position  uint64
+	committed uint64
+
+	msgChan chan asyncMessage
+
+	pollInterval time.Duration
+	commitPeriod time.Duration
+
+	ctx        context.Context
+	done       func()
+	closedChan chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewPostgresCDC creates a new PostgreSQL change data capture reader.
+func NewPostgresCDC(
+	conf PostgresCDCConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*PostgresCDC, error) {
+	if conf.DSN == "" {
+		return nil, errors.New("a dsn must be specified")
+	}
+	if conf.Slot == "" {
+		return nil, errors.New("a slot must be specified")
+	}
+	if conf.Plugin == "pgoutput" && conf.Publication == "" {
+		return nil, errors.New("a publication must be specified when using the pgoutput plugin")
+	}
+	if conf.MaxChanges < 1 {
+		return nil, errors.New("max changes must be greater than zero")
+	}
+
+	p := PostgresCDC{
+		conf:       conf,
+		cp:         checkpoint.New(0),
+		positions:  map[int]uint64{},
+		msgChan:    make(chan asyncMessage),
+		closedChan: make(chan struct{}),
+		log:        log,
+		stats:      stats,
+	}
+	p.ctx, p.done = context.WithCancel(context.Background())
+
+	var err error
+	if p.decoder, err = newPGCDCDecoder(conf.Plugin); err != nil {
+		return nil, err
+	}
+	if p.pollInterval, err = time.ParseDuration(conf.PollInterval); err != nil {
+		return nil, fmt.Errorf("failed to parse poll interval string: %v", err)
+	}
+	if p.commitPeriod, err = time.ParseDuration(conf.CommitPeriod); err != nil {
+		return nil, fmt.Errorf("failed to parse commit period string: %v", err)
+	}
+	return &p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext connects to the database, creating the replication slot
+// if necessary, and begins consuming changes.
+func (p *PostgresCDC) ConnectWithContext(ctx context.Context) error {
+	p.connMut.Lock()
+	defer p.connMut.Unlock()
+
+	if p.closed {
+		return types.ErrTypeClosed
+	}
+	if p.db != nil {
+		return nil
+	}
+
+	db, err := sql.Open("postgres", p.conf.DSN)
+	if err != nil {
+		return err
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return err
+	}
+
+	if p.conf.CreateSlot {
+		if err = p.createSlot(ctx, db); err != nil {
+			db.Close()
+			return err
+		}
+	}
+
+	var confirmed sql.NullString
+	if err = db.QueryRowContext(
+		ctx, "SELECT confirmed_flush_lsn::text FROM pg_replication_slots WHERE slot_name = $1", p.conf.Slot,
+	).Scan(&confirmed); err != nil {
+		db.Close()
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("replication slot '%v' does not exist", p.conf.Slot)
+		}
+		return fmt.Errorf("failed to obtain replication slot: %w", err)
+	}
+	if confirmed.Valid {
+		lsn, err := parseLSN(confirmed.String)
+		if err != nil {
+			db.Close()
+			return err
+		}
+		p.position, p.committed, p.tracked = lsn, lsn, lsn
+	}
+
+	p.db = db
+	go p.loop()
+
+	p.log.Infof("Receiving PostgreSQL changes from replication slot '%v' at position %v\n", p.conf.Slot, formatLSN(p.committed))
+	return nil
+}
+
+func (p *PostgresCDC) createSlot(ctx context.Context, db *sql.DB) error {
+	var exists bool
+	if err := db.QueryRowContext(
+		ctx, "SELECT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = $1)", p.conf.Slot,
+	).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check for replication slot: %w", err)
+	}
+	if exists {
+		return nil
+	}
+	if _, err := db.ExecContext(
+		ctx, "SELECT pg_create_logical_replication_slot($1, $2)", p.conf.Slot, p.conf.Plugin,
+	); err != nil {
+		return fmt.Errorf("failed to create replication slot: %w", err)
+	}
+	p.log.Infof("Created replication slot '%v' with plugin '%v'\n", p.conf.Slot, p.conf.Plugin)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type pgCDCRow struct {
+	lsn  string
+	xid  string
+	data []byte
+}
+
+// peek reads changes from the slot without consuming them, which begins at
+// the last position that was committed.
+func (p *PostgresCDC) peek() ([]pgCDCRow, error) {
+	var rows *sql.Rows
+	var err error
+	if p.conf.Plugin == "pgoutput" {
+		rows, err = p.db.QueryContext(
+			p.ctx,
+			"SELECT lsn::text, xid::text, data FROM pg_logical_slot_peek_binary_changes($1, NULL, $2, 'proto_version', '1', 'publication_names', $3)",
+			p.conf.Slot, p.conf.MaxChanges, p.conf.Publication,
+		)
+	} else {
+		rows, err = p.db.QueryContext(
+			p.ctx,
+			"SELECT lsn::text, xid::text, data FROM pg_logical_slot_peek_changes($1, NULL, $2, 'format-version', '2')",
+			p.conf.Slot, p.conf.MaxChanges,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []pgCDCRow
+	for rows.Next() {
+		var row pgCDCRow
+		if err := rows.Scan(&row.lsn, &row.xid, &row.data); err != nil {
+			return nil, err
+		}
+		res = append(res, row)
+	}
+	return res, rows.Err()
+}
+
+// track adds a transaction of changes that are pending acknowledgement,
+// returning the index of each change. Only the final change of a transaction
+// progresses the position of the slot once resolved, and a transaction without
+// changes is resolved immediately.
+func (p *PostgresCDC) track(commitLSN uint64, changes int) []int {
+	p.cpMut.Lock()
+	defer p.cpMut.Unlock()
+
+	indexes := make([]int, changes)
+	for i := range indexes {
+		p.index++
+		p.cp.MustTrack(p.index)
+		p.positions[p.index] = p.tracked
+		indexes[i] = p.index
+	}
+	if changes == 0 {
+		p.index++
+		p.cp.MustTrack(p.index)
+		p.positions[p.index] = commitLSN
+		p.resolveLocked(p.index)
+	} else {
+		p.positions[p.index] = commitLSN
+	}
+	p.tracked = commitLSN
+	return indexes
+}
+
+func (p *PostgresCDC) resolveLocked(index int) {
+	highest := p.cp.MustResolve(index)
+	if highest <= p.pruned {
+		return
+	}
+	p.position = p.positions[highest]
+	for ; p.pruned < highest; p.pruned++ {
+		delete(p.positions, p.pruned+1)
+	}
+}
+
+func (p *PostgresCDC) resolve(index int) {
+	p.cpMut.Lock()
+	p.resolveLocked(index)
+	p.cpMut.Unlock()
+}
+
+// poll reads the next transactions from the slot and sends their changes,
+// returning the number of transactions that were read.
+func (p *PostgresCDC) poll() (int, error) {
+	rows, err := p.peek()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read changes: %w", err)
+	}
+
+	read := 0
+	var changes []pgCDCChange
+	for _, row := range rows {
+		kind, rowChanges, err := p.decoder.decode(row.data)
+		if err != nil {
+			return read, fmt.Errorf("failed to decode change at %v: %w", row.lsn, err)
+		}
+		switch kind {
+		case pgCDCRowBegin:
+			changes = nil
+		case pgCDCRowChange:
+			changes = append(changes, rowChanges...)
+		case pgCDCRowCommit:
+			commitLSN, err := parseLSN(row.lsn)
+			if err != nil {
+				return read, err
+			}
+			// Transactions that are pending acknowledgement are read again
+			// until the position of the slot is committed.
+			if commitLSN <= p.readLSN {
+				continue
+			}
+			xid, _ := strconv.ParseInt(row.xid, 10, 64)
+			if err := p.send(commitLSN, xid, changes); err != nil {
+				return read, err
+			}
+			p.readLSN = commitLSN
+			read++
+		}
+	}
+	return read, nil
+}
+
+func (p *PostgresCDC) send(commitLSN uint64, xid int64, changes []pgCDCChange) error {
+	indexes := p.track(commitLSN, len(changes))
+	for i, change := range changes {
+		change.XID = xid
+		change.LSN = formatLSN(commitLSN)
+
+		data, err := json.Marshal(change)
+		if err != nil {
+			return err
+		}
+		part := message.NewPart(data)
+		meta := part.Metadata()
+		meta.Set("postgres_cdc_operation", change.Operation)
+		meta.Set("postgres_cdc_schema", change.Schema)
+		meta.Set("postgres_cdc_table", change.Table)
+		meta.Set("postgres_cdc_lsn", change.LSN)
+		meta.Set("postgres_cdc_xid", strconv.FormatInt(xid, 10))
+
+		msg := message.New(nil)
+		msg.Append(part)
+
+		index := indexes[i]
+		select {
+		case p.msgChan <- asyncMessage{
+			msg: msg,
+			ackFn: func(ctx context.Context, res types.Response) error {
+				if res.Error() == nil {
+					p.resolve(index)
+				}
+				return nil
+			},
+		}:
+		case <-p.ctx.Done():
+			return p.ctx.Err()
+		}
+	}
+	return nil
+}
+
+// commit advances the slot to the position of the latest fully acknowledged
+// transaction.
+func (p *PostgresCDC) commit() {
+	p.cpMut.Lock()
+	position, committed := p.position, p.committed
+	p.cpMut.Unlock()
+
+	if position <= committed {
+		return
+	}
+	if _, err := p.db.ExecContext(
+		context.Background(), "SELECT pg_replication_slot_advance($1, $2::pg_lsn)", p.conf.Slot, formatLSN(position),
+	); err != nil {
+		p.log.Errorf("Failed to advance replication slot: %v\n", err)
+		return
+	}
+
+	p.cpMut.Lock()
+	p.committed = position
+	p.cpMut.Unlock()
+}
+
+func (p *PostgresCDC) loop() {
+	defer func() {
+		p.commit()
+		p.db.Close()
+		close(p.msgChan)
+		close(p.closedChan)
+	}()
+
+	commitTicker := time.NewTicker(p.commitPeriod)
+	defer commitTicker.Stop()
+
+	pollChan := time.After(0)
+	for {
+		select {
+		case <-commitTicker.C:
+			p.commit()
+		case <-pollChan:
+			read, err := p.poll()
+			if err != nil && p.ctx.Err() == nil {
+				p.log.Errorf("Failed to consume replication slot: %v\n", err)
+			}
+			if read > 0 {
+				pollChan = time.After(0)
+			} else {
+				pollChan = time.After(p.pollInterval)
+			}
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ReadWithContext attempts to read a new change from the slot.
+func (p *PostgresCDC) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	p.connMut.Lock()
+	connected := p.db != nil || p.closed
+	p.connMut.Unlock()
+
+	if !connected {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	select {
+	case m, open := <-p.msgChan:
+		if !open {
+			return nil, nil, types.ErrTypeClosed
+		}
+		return m.msg, m.ackFn, nil
+	case <-ctx.Done():
+	}
+	return nil, nil, types.ErrTimeout
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (p *PostgresCDC) CloseAsync() {
+	p.connMut.Lock()
+	defer p.connMut.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+	p.done()
+
+	// Without a connection the background loop was never started.
+	if p.db == nil {
+		close(p.msgChan)
+		close(p.closedChan)
+	}
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (p *PostgresCDC) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-p.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
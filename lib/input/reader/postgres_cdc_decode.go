@@ -0,0 +1,392 @@
+package reader
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// pgCDCChange is a row level change event decoded from a logical replication
+// slot.
+type pgCDCChange struct {
+	Operation string                 `json:"operation"`
+	Schema    string                 `json:"schema"`
+	Table     string                 `json:"table"`
+	XID       int64                  `json:"xid"`
+	LSN       string                 `json:"lsn"`
+	New       map[string]interface{} `json:"new,omitempty"`
+	Old       map[string]interface{} `json:"old,omitempty"`
+}
+
+// Kinds of rows obtained from a logical replication slot.
+const (
+	pgCDCRowOther = iota
+	pgCDCRowBegin
+	pgCDCRowChange
+	pgCDCRowCommit
+)
+
+// pgCDCDecoder decodes the rows of a logical replication slot as produced by a
+// particular output plugin.
+type pgCDCDecoder interface {
+	decode(data []byte) (int, []pgCDCChange, error)
+}
+
+func newPGCDCDecoder(plugin string) (pgCDCDecoder, error) {
+	switch plugin {
+	case "pgoutput":
+		return &pgOutputDecoder{relations: map[uint32]pgOutputRelation{}}, nil
+	case "wal2json":
+		return wal2JSONDecoder{}, nil
+	}
+	return nil, fmt.Errorf("plugin not recognised: %v", plugin)
+}
+
+//------------------------------------------------------------------------------
+
+// parseLSN parses a log sequence number in its textual form of two
+// hexadecimal numbers separated by a slash.
+func parseLSN(str string) (uint64, error) {
+	parts := strings.Split(str, "/")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid log sequence number: %v", str)
+	}
+	upper, err := strconv.ParseUint(parts[0], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid log sequence number: %v", str)
+	}
+	lower, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid log sequence number: %v", str)
+	}
+	return upper<<32 | lower, nil
+}
+
+func formatLSN(lsn uint64) string {
+	return fmt.Sprintf("%X/%X", lsn>>32, uint32(lsn))
+}
+
+//------------------------------------------------------------------------------
+
+type pgOutputColumn struct {
+	name    string
+	typeOID uint32
+}
+
+type pgOutputRelation struct {
+	schema  string
+	table   string
+	columns []pgOutputColumn
+}
+
+// pgOutputDecoder decodes messages of version 1 of the pgoutput logical
+// replication protocol, which is the plugin built into PostgreSQL.
+type pgOutputDecoder struct {
+	relations map[uint32]pgOutputRelation
+}
+
+var errPGOutputShort = errors.New("pgoutput message is too short")
+
+type pgOutputReader struct {
+	b   []byte
+	err error
+}
+
+func (r *pgOutputReader) byte() byte {
+	if r.err != nil || len(r.b) < 1 {
+		r.err = errPGOutputShort
+		return 0
+	}
+	v := r.b[0]
+	r.b = r.b[1:]
+	return v
+}
+
+func (r *pgOutputReader) uint16() uint16 {
+	if r.err != nil || len(r.b) < 2 {
+		r.err = errPGOutputShort
+		return 0
+	}
+	v := binary.BigEndian.Uint16(r.b)
+	r.b = r.b[2:]
+	return v
+}
+
+func (r *pgOutputReader) uint32() uint32 {
+	if r.err != nil || len(r.b) < 4 {
+		r.err = errPGOutputShort
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.b)
+	r.b = r.b[4:]
+	return v
+}
+
+func (r *pgOutputReader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	for i, c := range r.b {
+		if c == 0 {
+			v := string(r.b[:i])
+			r.b = r.b[i+1:]
+			return v
+		}
+	}
+	r.err = errPGOutputShort
+	return ""
+}
+
+func (r *pgOutputReader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || len(r.b) < n {
+		r.err = errPGOutputShort
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+// Type OIDs of values that are converted into JSON types other than strings.
+const (
+	pgOIDBool    = 16
+	pgOIDInt8    = 20
+	pgOIDInt2    = 21
+	pgOIDInt4    = 23
+	pgOIDOID     = 26
+	pgOIDJSON    = 114
+	pgOIDFloat4  = 700
+	pgOIDFloat8  = 701
+	pgOIDNumeric = 1700
+	pgOIDJSONB   = 3802
+)
+
+func pgOutputValue(typeOID uint32, text string) interface{} {
+	switch typeOID {
+	case pgOIDBool:
+		return text == "t"
+	case pgOIDInt2, pgOIDInt4, pgOIDInt8, pgOIDOID:
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return i
+		}
+	case pgOIDFloat4, pgOIDFloat8, pgOIDNumeric:
+		// Special values such as NaN are not valid JSON numbers.
+		if _, err := strconv.ParseFloat(text, 64); err == nil && !strings.ContainsAny(text, "NnIi") {
+			return json.Number(text)
+		}
+	case pgOIDJSON, pgOIDJSONB:
+		if json.Valid([]byte(text)) {
+			return json.RawMessage(text)
+		}
+	}
+	return text
+}
+
+// tuple reads tuple data, where the values of unchanged TOAST columns are
+// omitted as they are not sent by the server.
+func (d *pgOutputDecoder) tuple(r *pgOutputReader, rel pgOutputRelation) map[string]interface{} {
+	n := int(r.uint16())
+	values := make(map[string]interface{}, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		var col pgOutputColumn
+		if i < len(rel.columns) {
+			col = rel.columns[i]
+		} else {
+			col.name = strconv.Itoa(i)
+		}
+		switch kind := r.byte(); kind {
+		case 'n':
+			values[col.name] = nil
+		case 'u':
+		case 't':
+			l := int(r.uint32())
+			values[col.name] = pgOutputValue(col.typeOID, string(r.bytes(l)))
+		default:
+			if r.err == nil {
+				r.err = fmt.Errorf("unexpected tuple data type: %c", kind)
+			}
+		}
+	}
+	return values
+}
+
+func (d *pgOutputDecoder) relation(r *pgOutputReader) (pgOutputRelation, error) {
+	id := r.uint32()
+	if r.err != nil {
+		return pgOutputRelation{}, r.err
+	}
+	rel, exists := d.relations[id]
+	if !exists {
+		return rel, fmt.Errorf("relation %v has not been described", id)
+	}
+	return rel, nil
+}
+
+func (d *pgOutputDecoder) decode(data []byte) (int, []pgCDCChange, error) {
+	r := &pgOutputReader{b: data}
+	switch msgType := r.byte(); msgType {
+	case 'B':
+		return pgCDCRowBegin, nil, r.err
+	case 'C':
+		return pgCDCRowCommit, nil, r.err
+	case 'R':
+		id := r.uint32()
+		rel := pgOutputRelation{
+			schema: r.string(),
+			table:  r.string(),
+		}
+		r.byte() // Replica identity
+		n := int(r.uint16())
+		for i := 0; i < n && r.err == nil; i++ {
+			r.byte() // Flags
+			col := pgOutputColumn{name: r.string()}
+			col.typeOID = r.uint32()
+			r.uint32() // Type modifier
+			rel.columns = append(rel.columns, col)
+		}
+		if r.err != nil {
+			return pgCDCRowOther, nil, r.err
+		}
+		d.relations[id] = rel
+		return pgCDCRowOther, nil, nil
+	case 'I':
+		rel, err := d.relation(r)
+		if err != nil {
+			return pgCDCRowOther, nil, err
+		}
+		if kind := r.byte(); kind != 'N' && r.err == nil {
+			return pgCDCRowOther, nil, fmt.Errorf("unexpected insert tuple type: %c", kind)
+		}
+		change := pgCDCChange{
+			Operation: "insert",
+			Schema:    rel.schema,
+			Table:     rel.table,
+			New:       d.tuple(r, rel),
+		}
+		return pgCDCRowChange, []pgCDCChange{change}, r.err
+	case 'U':
+		rel, err := d.relation(r)
+		if err != nil {
+			return pgCDCRowOther, nil, err
+		}
+		change := pgCDCChange{
+			Operation: "update",
+			Schema:    rel.schema,
+			Table:     rel.table,
+		}
+		kind := r.byte()
+		if kind == 'K' || kind == 'O' {
+			change.Old = d.tuple(r, rel)
+			kind = r.byte()
+		}
+		if kind != 'N' && r.err == nil {
+			return pgCDCRowOther, nil, fmt.Errorf("unexpected update tuple type: %c", kind)
+		}
+		change.New = d.tuple(r, rel)
+		return pgCDCRowChange, []pgCDCChange{change}, r.err
+	case 'D':
+		rel, err := d.relation(r)
+		if err != nil {
+			return pgCDCRowOther, nil, err
+		}
+		if kind := r.byte(); kind != 'K' && kind != 'O' && r.err == nil {
+			return pgCDCRowOther, nil, fmt.Errorf("unexpected delete tuple type: %c", kind)
+		}
+		change := pgCDCChange{
+			Operation: "delete",
+			Schema:    rel.schema,
+			Table:     rel.table,
+			Old:       d.tuple(r, rel),
+		}
+		return pgCDCRowChange, []pgCDCChange{change}, r.err
+	case 'T':
+		n := int(r.uint32())
+		r.byte() // Options
+		var changes []pgCDCChange
+		for i := 0; i < n && r.err == nil; i++ {
+			rel, err := d.relation(r)
+			if err != nil {
+				return pgCDCRowOther, nil, err
+			}
+			changes = append(changes, pgCDCChange{
+				Operation: "truncate",
+				Schema:    rel.schema,
+				Table:     rel.table,
+			})
+		}
+		return pgCDCRowChange, changes, r.err
+	default:
+		// Origin and type messages are not required for decoding changes.
+		return pgCDCRowOther, nil, r.err
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type wal2JSONColumn struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+type wal2JSONRow struct {
+	Action   string           `json:"action"`
+	Schema   string           `json:"schema"`
+	Table    string           `json:"table"`
+	Columns  []wal2JSONColumn `json:"columns"`
+	Identity []wal2JSONColumn `json:"identity"`
+}
+
+// wal2JSONDecoder decodes rows of version 2 of the wal2json output format,
+// where each row describes a single change.
+type wal2JSONDecoder struct{}
+
+func wal2JSONValues(cols []wal2JSONColumn) map[string]interface{} {
+	if cols == nil {
+		return nil
+	}
+	values := make(map[string]interface{}, len(cols))
+	for _, c := range cols {
+		values[c.Name] = c.Value
+	}
+	return values
+}
+
+func (d wal2JSONDecoder) decode(data []byte) (int, []pgCDCChange, error) {
+	var row wal2JSONRow
+	if err := json.Unmarshal(data, &row); err != nil {
+		return pgCDCRowOther, nil, fmt.Errorf("failed to parse wal2json row: %w", err)
+	}
+
+	change := pgCDCChange{
+		Schema: row.Schema,
+		Table:  row.Table,
+	}
+	switch row.Action {
+	case "B":
+		return pgCDCRowBegin, nil, nil
+	case "C":
+		return pgCDCRowCommit, nil, nil
+	case "I":
+		change.Operation = "insert"
+		change.New = wal2JSONValues(row.Columns)
+	case "U":
+		change.Operation = "update"
+		change.New = wal2JSONValues(row.Columns)
+		change.Old = wal2JSONValues(row.Identity)
+	case "D":
+		change.Operation = "delete"
+		change.Old = wal2JSONValues(row.Identity)
+	case "T":
+		change.Operation = "truncate"
+	default:
+		return pgCDCRowOther, nil, nil
+	}
+	return pgCDCRowChange, []pgCDCChange{change}, nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,178 @@
+package reader
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pgOutputBuilder []byte
+
+func (b pgOutputBuilder) byte(v byte) pgOutputBuilder {
+	return append(b, v)
+}
+
+func (b pgOutputBuilder) uint16(v uint16) pgOutputBuilder {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func (b pgOutputBuilder) uint32(v uint32) pgOutputBuilder {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, v)
+	return append(b, buf...)
+}
+
+func (b pgOutputBuilder) string(v string) pgOutputBuilder {
+	return append(append(b, v...), 0)
+}
+
+func (b pgOutputBuilder) text(v string) pgOutputBuilder {
+	return append(b.byte('t').uint32(uint32(len(v))), v...)
+}
+
+func TestPGOutputDecode(t *testing.T) {
+	d, err := newPGCDCDecoder("pgoutput")
+	require.NoError(t, err)
+
+	kind, _, err := d.decode(pgOutputBuilder{}.byte('B').uint32(0).uint32(0).uint32(0).uint32(0).uint32(1))
+	require.NoError(t, err)
+	assert.Equal(t, pgCDCRowBegin, kind)
+
+	relation := pgOutputBuilder{}.byte('R').uint32(10).string("public").string("users").byte('d').uint16(4).
+		byte(1).string("id").uint32(pgOIDInt4).uint32(0).
+		byte(0).string("name").uint32(25).uint32(0).
+		byte(0).string("attrs").uint32(pgOIDJSONB).uint32(0).
+		byte(0).string("score").uint32(pgOIDNumeric).uint32(0)
+	kind, changes, err := d.decode(relation)
+	require.NoError(t, err)
+	assert.Equal(t, pgCDCRowOther, kind)
+	assert.Empty(t, changes)
+
+	kind, changes, err = d.decode(pgOutputBuilder{}.byte('I').uint32(10).byte('N').uint16(4).
+		text("1").text("foo").text(`{"a":true}`).text("NaN"))
+	require.NoError(t, err)
+	assert.Equal(t, pgCDCRowChange, kind)
+	require.Len(t, changes, 1)
+	assert.Equal(t, pgCDCChange{
+		Operation: "insert",
+		Schema:    "public",
+		Table:     "users",
+		New: map[string]interface{}{
+			"id":    int64(1),
+			"name":  "foo",
+			"attrs": json.RawMessage(`{"a":true}`),
+			"score": "NaN",
+		},
+	}, changes[0])
+
+	_, changes, err = d.decode(pgOutputBuilder{}.byte('U').uint32(10).
+		byte('K').uint16(4).text("1").byte('n').byte('n').byte('n').
+		byte('N').uint16(4).text("2").text("bar").byte('u').text("1.5"))
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, pgCDCChange{
+		Operation: "update",
+		Schema:    "public",
+		Table:     "users",
+		Old: map[string]interface{}{
+			"id":    int64(1),
+			"name":  nil,
+			"attrs": nil,
+			"score": nil,
+		},
+		New: map[string]interface{}{
+			"id":    int64(2),
+			"name":  "bar",
+			"score": json.Number("1.5"),
+		},
+	}, changes[0])
+
+	_, changes, err = d.decode(pgOutputBuilder{}.byte('D').uint32(10).byte('K').uint16(1).text("2"))
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "delete", changes[0].Operation)
+	assert.Equal(t, map[string]interface{}{"id": int64(2)}, changes[0].Old)
+	assert.Nil(t, changes[0].New)
+
+	_, changes, err = d.decode(pgOutputBuilder{}.byte('T').uint32(1).byte(0).uint32(10))
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "truncate", changes[0].Operation)
+
+	kind, _, err = d.decode(pgOutputBuilder{}.byte('C').byte(0).uint32(0).uint32(0))
+	require.NoError(t, err)
+	assert.Equal(t, pgCDCRowCommit, kind)
+
+	_, _, err = d.decode(pgOutputBuilder{}.byte('I').uint32(11).byte('N').uint16(0))
+	assert.Error(t, err)
+
+	_, _, err = d.decode(pgOutputBuilder{}.byte('I').uint32(10).byte('N').uint16(1).byte('t').uint32(10))
+	assert.Error(t, err)
+}
+
+func TestWal2JSONDecode(t *testing.T) {
+	d, err := newPGCDCDecoder("wal2json")
+	require.NoError(t, err)
+
+	kind, _, err := d.decode([]byte(`{"action":"B","xid":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, pgCDCRowBegin, kind)
+
+	kind, changes, err := d.decode([]byte(`{"action":"U","schema":"public","table":"users","columns":[{"name":"id","type":"integer","value":2},{"name":"name","type":"text","value":"bar"}],"identity":[{"name":"id","type":"integer","value":1}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, pgCDCRowChange, kind)
+	require.Len(t, changes, 1)
+
+	data, err := json.Marshal(changes[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"operation":"update","schema":"public","table":"users","xid":0,"lsn":"","new":{"id":2,"name":"bar"},"old":{"id":1}}`, string(data))
+
+	kind, _, err = d.decode([]byte(`{"action":"C","xid":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, pgCDCRowCommit, kind)
+
+	_, _, err = d.decode([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestPostgresCDCLSN(t *testing.T) {
+	lsn, err := parseLSN("16/B374D848")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x16B374D848), lsn)
+	assert.Equal(t, "16/B374D848", formatLSN(lsn))
+	assert.Equal(t, "0/0", formatLSN(0))
+
+	_, err = parseLSN("nope")
+	assert.Error(t, err)
+}
+
+func TestPostgresCDCTracking(t *testing.T) {
+	conf := NewPostgresCDCConfig()
+	conf.DSN = "postgres://localhost:5432/foo"
+
+	p, err := NewPostgresCDC(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	first := p.track(10, 2)
+	require.Len(t, first, 2)
+	assert.Empty(t, p.track(20, 0))
+	second := p.track(30, 1)
+	require.Len(t, second, 1)
+
+	p.resolve(first[0])
+	assert.Equal(t, uint64(0), p.position)
+
+	p.resolve(second[0])
+	assert.Equal(t, uint64(0), p.position)
+
+	p.resolve(first[1])
+	assert.Equal(t, uint64(30), p.position)
+
+	assert.Empty(t, p.track(40, 0))
+	assert.Equal(t, uint64(40), p.position)
+}
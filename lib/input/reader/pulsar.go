@@ -0,0 +1,193 @@
+// +build !wasm
+
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/pulsarconf"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+//------------------------------------------------------------------------------
+
+func strToPulsarSubscriptionType(str string) (pulsar.SubscriptionType, error) {
+	switch str {
+	case "exclusive":
+		return pulsar.Exclusive, nil
+	case "shared":
+		return pulsar.Shared, nil
+	case "key_shared":
+		return pulsar.KeyShared, nil
+	case "failover":
+		return pulsar.Failover, nil
+	}
+	return 0, fmt.Errorf("subscription type not recognised: %v", str)
+}
+
+//------------------------------------------------------------------------------
+
+// Pulsar is an input type that reads from a Pulsar subscription.
+type Pulsar struct {
+	client   pulsar.Client
+	consumer pulsar.Consumer
+	cMut     sync.Mutex
+
+	topics  []string
+	subType pulsar.SubscriptionType
+
+	conf  PulsarConfig
+	stats metrics.Type
+	log   log.Modular
+}
+
+// NewPulsar creates a new Pulsar input type.
+func NewPulsar(conf PulsarConfig, log log.Modular, stats metrics.Type) (*Pulsar, error) {
+	p := Pulsar{
+		conf:  conf,
+		stats: stats,
+		log:   log,
+	}
+
+	if conf.URL == "" {
+		return nil, errors.New("a url must be specified")
+	}
+	if conf.SubscriptionName == "" {
+		return nil, errors.New("a subscription name must be specified")
+	}
+
+	for _, t := range conf.Topics {
+		for _, splitTopics := range strings.Split(t, ",") {
+			if trimmed := strings.TrimSpace(splitTopics); len(trimmed) > 0 {
+				p.topics = append(p.topics, trimmed)
+			}
+		}
+	}
+	if len(p.topics) == 0 {
+		return nil, errors.New("at least one topic must be specified")
+	}
+
+	var err error
+	if p.subType, err = strToPulsarSubscriptionType(conf.SubscriptionType); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext establishes a connection to a Pulsar cluster and
+// subscribes to the configured topics.
+func (p *Pulsar) ConnectWithContext(ctx context.Context) error {
+	p.cMut.Lock()
+	defer p.cMut.Unlock()
+
+	if p.client != nil {
+		return nil
+	}
+
+	client, err := pulsar.NewClient(pulsarconf.ClientOptions(p.conf.URL, p.conf.Auth, p.conf.TLS, p.log))
+	if err != nil {
+		return err
+	}
+
+	consumer, err := client.Subscribe(pulsar.ConsumerOptions{
+		Topics:           p.topics,
+		SubscriptionName: p.conf.SubscriptionName,
+		Type:             p.subType,
+	})
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	p.client = client
+	p.consumer = consumer
+
+	p.log.Infof("Receiving Pulsar messages from topics %v as subscription '%v'\n", p.topics, p.conf.SubscriptionName)
+	return nil
+}
+
+// disconnect safely closes a consumer and client.
+func (p *Pulsar) disconnect() {
+	p.cMut.Lock()
+	defer p.cMut.Unlock()
+
+	if p.consumer != nil {
+		p.consumer.Close()
+		p.consumer = nil
+	}
+	if p.client != nil {
+		p.client.Close()
+		p.client = nil
+	}
+}
+
+// ReadWithContext attempts to read a new message from the Pulsar subscription.
+func (p *Pulsar) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	p.cMut.Lock()
+	consumer := p.consumer
+	p.cMut.Unlock()
+
+	if consumer == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	pMsg, err := consumer.Receive(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, types.ErrTimeout
+		}
+		p.log.Errorf("Lost connection due to: %v\n", err)
+		p.disconnect()
+		return nil, nil, types.ErrNotConnected
+	}
+
+	part := message.NewPart(pMsg.Payload())
+
+	meta := part.Metadata()
+	for k, v := range pMsg.Properties() {
+		meta.Set(k, v)
+	}
+	meta.Set("pulsar_key", pMsg.Key())
+	meta.Set("pulsar_topic", pMsg.Topic())
+	meta.Set("pulsar_publish_time_unix", strconv.FormatInt(pMsg.PublishTime().Unix(), 10))
+	if eventTime := pMsg.EventTime(); !eventTime.IsZero() {
+		meta.Set("pulsar_event_time_unix", strconv.FormatInt(eventTime.Unix(), 10))
+	}
+	meta.Set("pulsar_redelivery_count", strconv.FormatUint(uint64(pMsg.RedeliveryCount()), 10))
+
+	msg := message.New(nil)
+	msg.Append(part)
+
+	return msg, func(ctx context.Context, res types.Response) error {
+		if res.Error() != nil {
+			consumer.Nack(pMsg)
+		} else {
+			consumer.Ack(pMsg)
+		}
+		return nil
+	}, nil
+}
+
+// CloseAsync shuts down the Pulsar input and stops processing requests.
+func (p *Pulsar) CloseAsync() {
+	go p.disconnect()
+}
+
+// WaitForClose blocks until the Pulsar input has closed down.
+func (p *Pulsar) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,31 @@
+package reader
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/pulsarconf"
+)
+
+//------------------------------------------------------------------------------
+
+// PulsarConfig contains configuration for the Pulsar input type.
+type PulsarConfig struct {
+	URL              string          `json:"url" yaml:"url"`
+	Topics           []string        `json:"topics" yaml:"topics"`
+	SubscriptionName string          `json:"subscription_name" yaml:"subscription_name"`
+	SubscriptionType string          `json:"subscription_type" yaml:"subscription_type"`
+	Auth             pulsarconf.Auth `json:"auth" yaml:"auth"`
+	TLS              pulsarconf.TLS  `json:"tls" yaml:"tls"`
+}
+
+// NewPulsarConfig creates a new PulsarConfig with default values.
+func NewPulsarConfig() PulsarConfig {
+	return PulsarConfig{
+		URL:              "pulsar://localhost:6650",
+		Topics:           []string{},
+		SubscriptionName: "benthos",
+		SubscriptionType: "shared",
+		Auth:             pulsarconf.NewAuth(),
+		TLS:              pulsarconf.NewTLS(),
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,38 @@
+// +build !wasm
+
+package reader
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPulsarConfigValidation(t *testing.T) {
+	conf := NewPulsarConfig()
+	_, err := NewPulsar(conf, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	conf.Topics = []string{"foo, bar", "baz"}
+	conf.SubscriptionType = "nope"
+	_, err = NewPulsar(conf, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	tests := map[string]pulsar.SubscriptionType{
+		"exclusive":  pulsar.Exclusive,
+		"shared":     pulsar.Shared,
+		"key_shared": pulsar.KeyShared,
+		"failover":   pulsar.Failover,
+	}
+	for str, exp := range tests {
+		conf.SubscriptionType = str
+		p, err := NewPulsar(conf, log.Noop(), metrics.Noop())
+		require.NoError(t, err, str)
+		assert.Equal(t, exp, p.subType, str)
+		assert.Equal(t, []string{"foo", "bar", "baz"}, p.topics, str)
+	}
+}
@@ -0,0 +1,17 @@
+// +build wasm
+
+package reader
+
+import (
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+)
+
+//------------------------------------------------------------------------------
+
+// NewPulsar returns an error as it is not supported in WASM builds.
+func NewPulsar(conf PulsarConfig, log log.Modular, stats metrics.Type) (Async, error) {
+	return nil, errNoWASM
+}
+
+//------------------------------------------------------------------------------
@@ -2,7 +2,8 @@ package reader
 
 import (
 	"context"
-	"net/url"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	bredis "github.com/Jeffail/benthos/v3/lib/util/redis"
 	"github.com/go-redis/redis/v7"
 )
 
@@ -18,17 +20,19 @@ import (
 // RedisPubSubConfig contains configuration fields for the RedisPubSub input
 // type.
 type RedisPubSubConfig struct {
-	URL         string   `json:"url" yaml:"url"`
-	Channels    []string `json:"channels" yaml:"channels"`
-	UsePatterns bool     `json:"use_patterns" yaml:"use_patterns"`
+	bredis.Config       `json:",inline" yaml:",inline"`
+	Channels            []string `json:"channels" yaml:"channels"`
+	UsePatterns         bool     `json:"use_patterns" yaml:"use_patterns"`
+	SubscribeAllMasters bool     `json:"subscribe_all_masters" yaml:"subscribe_all_masters"`
 }
 
 // NewRedisPubSubConfig creates a new RedisPubSubConfig with default values.
 func NewRedisPubSubConfig() RedisPubSubConfig {
 	return RedisPubSubConfig{
-		URL:         "tcp://localhost:6379",
-		Channels:    []string{"benthos_chan"},
-		UsePatterns: false,
+		Config:              bredis.NewConfig(),
+		Channels:            []string{"benthos_chan"},
+		UsePatterns:         false,
+		SubscribeAllMasters: false,
 	}
 }
 
@@ -36,11 +40,12 @@ func NewRedisPubSubConfig() RedisPubSubConfig {
 
 // RedisPubSub is an input type that reads Redis Pub/Sub messages.
 type RedisPubSub struct {
-	client *redis.Client
-	pubsub *redis.PubSub
-	cMut   sync.Mutex
+	client  redis.UniversalClient
+	pubsubs []*redis.PubSub
+	msgChan <-chan *redis.Message
+	done    chan struct{}
+	cMut    sync.Mutex
 
-	url  *url.URL
 	conf RedisPubSubConfig
 
 	stats metrics.Type
@@ -57,12 +62,15 @@ func NewRedisPubSub(
 		log:   log,
 	}
 
-	var err error
-	r.url, err = url.Parse(r.conf.URL)
+	client, err := conf.Client()
 	if err != nil {
 		return nil, err
 	}
+	client.Close()
 
+	if conf.SubscribeAllMasters && conf.Kind != "cluster" {
+		return nil, errors.New("subscribe_all_masters requires the kind cluster")
+	}
 	return r, nil
 }
 
@@ -73,6 +81,62 @@ func (r *RedisPubSub) Connect() error {
 	return r.ConnectWithContext(context.Background())
 }
 
+func (r *RedisPubSub) subscribe(client redis.UniversalClient) *redis.PubSub {
+	if r.conf.UsePatterns {
+		return client.PSubscribe(r.conf.Channels...)
+	}
+	return client.Subscribe(r.conf.Channels...)
+}
+
+// subscribeAllMasters subscribes to the channels on each master node of a
+// cluster, which is necessary for receiving events that are local to a node
+// such as keyspace notifications.
+func (r *RedisPubSub) subscribeAllMasters(client *redis.ClusterClient, done <-chan struct{}) ([]*redis.PubSub, <-chan *redis.Message, error) {
+	if err := client.ReloadState(); err != nil {
+		return nil, nil, err
+	}
+
+	var pubsubs []*redis.PubSub
+	var pMut sync.Mutex
+	err := client.ForEachMaster(func(node *redis.Client) error {
+		pubsub := r.subscribe(node)
+		pMut.Lock()
+		pubsubs = append(pubsubs, pubsub)
+		pMut.Unlock()
+		if _, err := pubsub.Receive(); err != nil {
+			return fmt.Errorf("failed to subscribe to node %v: %w", node.Options().Addr, err)
+		}
+		return nil
+	})
+	if err != nil {
+		for _, pubsub := range pubsubs {
+			pubsub.Close()
+		}
+		return nil, nil, err
+	}
+
+	msgChan := make(chan *redis.Message)
+	var wg sync.WaitGroup
+	wg.Add(len(pubsubs))
+	for _, pubsub := range pubsubs {
+		go func(c <-chan *redis.Message) {
+			defer wg.Done()
+			for msg := range c {
+				select {
+				case msgChan <- msg:
+				case <-done:
+					return
+				}
+			}
+		}(pubsub.Channel())
+	}
+	go func() {
+		wg.Wait()
+		close(msgChan)
+	}()
+	return pubsubs, msgChan, nil
+}
+
 // ConnectWithContext establishes a connection to an RedisPubSub server.
 func (r *RedisPubSub) ConnectWithContext(ctx context.Context) error {
 	r.cMut.Lock()
@@ -82,28 +146,30 @@ func (r *RedisPubSub) ConnectWithContext(ctx context.Context) error {
 		return nil
 	}
 
-	var pass string
-	if r.url.User != nil {
-		pass, _ = r.url.User.Password()
+	client, err := r.conf.Client()
+	if err != nil {
+		return err
 	}
-	client := redis.NewClient(&redis.Options{
-		Addr:     r.url.Host,
-		Network:  r.url.Scheme,
-		Password: pass,
-	})
-
 	if _, err := client.Ping().Result(); err != nil {
+		client.Close()
 		return err
 	}
 
+	if r.conf.SubscribeAllMasters {
+		r.done = make(chan struct{})
+		if r.pubsubs, r.msgChan, err = r.subscribeAllMasters(client.(*redis.ClusterClient), r.done); err != nil {
+			r.done = nil
+			client.Close()
+			return err
+		}
+	} else {
+		pubsub := r.subscribe(client)
+		r.pubsubs, r.msgChan = []*redis.PubSub{pubsub}, pubsub.Channel()
+	}
+
 	r.log.Infof("Receiving Redis pub/sub messages from channels: %v\n", r.conf.Channels)
 
 	r.client = client
-	if r.conf.UsePatterns {
-		r.pubsub = r.client.PSubscribe(r.conf.Channels...)
-	} else {
-		r.pubsub = r.client.Subscribe(r.conf.Channels...)
-	}
 	return nil
 }
 
@@ -115,23 +181,27 @@ func (r *RedisPubSub) Read() (types.Message, error) {
 
 // ReadWithContext attempts to pop a message from a redis pubsub channel.
 func (r *RedisPubSub) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
-	var pubsub *redis.PubSub
-
 	r.cMut.Lock()
-	pubsub = r.pubsub
+	msgChan := r.msgChan
 	r.cMut.Unlock()
 
-	if pubsub == nil {
+	if msgChan == nil {
 		return nil, nil, types.ErrNotConnected
 	}
 
 	select {
-	case rMsg, open := <-pubsub.Channel():
+	case rMsg, open := <-msgChan:
 		if !open {
 			r.disconnect()
 			return nil, nil, types.ErrTypeClosed
 		}
-		return message.New([][]byte{[]byte(rMsg.Payload)}), noopAsyncAckFn, nil
+		msg := message.New([][]byte{[]byte(rMsg.Payload)})
+		meta := msg.Get(0).Metadata()
+		meta.Set("redis_pubsub_channel", rMsg.Channel)
+		if len(rMsg.Pattern) > 0 {
+			meta.Set("redis_pubsub_pattern", rMsg.Pattern)
+		}
+		return msg, noopAsyncAckFn, nil
 	case <-ctx.Done():
 	}
 
@@ -150,10 +220,14 @@ func (r *RedisPubSub) disconnect() error {
 	defer r.cMut.Unlock()
 
 	var err error
-	if r.pubsub != nil {
-		err = r.pubsub.Close()
-		r.pubsub = nil
+	for _, pubsub := range r.pubsubs {
+		err = pubsub.Close()
+	}
+	if r.done != nil {
+		close(r.done)
+		r.done = nil
 	}
+	r.pubsubs, r.msgChan = nil, nil
 	if r.client != nil {
 		err = r.client.Close()
 		r.client = nil
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +20,26 @@ import (
 
 //------------------------------------------------------------------------------
 
+// RedisStreamsAutoClaimConfig contains configuration fields for claiming the
+// pending entries of other consumers of a group.
+type RedisStreamsAutoClaimConfig struct {
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+	MinIdle      string `json:"min_idle" yaml:"min_idle"`
+	Period       string `json:"period" yaml:"period"`
+	DrainOnStart bool   `json:"drain_on_start" yaml:"drain_on_start"`
+}
+
+// NewRedisStreamsAutoClaimConfig creates a new RedisStreamsAutoClaimConfig
+// with default values.
+func NewRedisStreamsAutoClaimConfig() RedisStreamsAutoClaimConfig {
+	return RedisStreamsAutoClaimConfig{
+		Enabled:      false,
+		MinIdle:      "5m",
+		Period:       "30s",
+		DrainOnStart: false,
+	}
+}
+
 // RedisStreamsConfig contains configuration fields for the RedisStreams input
 // type.
 type RedisStreamsConfig struct {
@@ -32,6 +53,8 @@ type RedisStreamsConfig struct {
 	CommitPeriod    string   `json:"commit_period" yaml:"commit_period"`
 	Timeout         string   `json:"timeout" yaml:"timeout"`
 
+	AutoClaim RedisStreamsAutoClaimConfig `json:"auto_claim" yaml:"auto_claim"`
+
 	// TODO: V4 remove this.
 	Batching batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
@@ -51,6 +74,7 @@ func NewRedisStreamsConfig() RedisStreamsConfig {
 		StartFromOldest: true,
 		CommitPeriod:    "1s",
 		Timeout:         "1s",
+		AutoClaim:       NewRedisStreamsAutoClaimConfig(),
 	}
 }
 
@@ -71,17 +95,27 @@ type RedisStreams struct {
 
 	timeout      time.Duration
 	commitPeriod time.Duration
+	claimMinIdle time.Duration
+	claimPeriod  time.Duration
 
 	url  *url.URL
 	conf RedisStreamsConfig
 
 	backlogs map[string]string
 
+	// Cursors of the auto claim sweep in progress for each stream, a stream is
+	// removed once its sweep has completed.
+	claimCursors map[string]string
+	nextClaim    time.Time
+	draining     bool
+
 	aMut    sync.Mutex
 	ackSend map[string][]string // Acks that can be sent
 
 	deprecatedAckFns []AsyncAckFn
 
+	mClaimed metrics.StatCounter
+
 	stats metrics.Type
 	log   log.Modular
 
@@ -95,13 +129,15 @@ func NewRedisStreams(
 	conf RedisStreamsConfig, log log.Modular, stats metrics.Type,
 ) (*RedisStreams, error) {
 	r := &RedisStreams{
-		conf:       conf,
-		stats:      stats,
-		log:        log,
-		backlogs:   make(map[string]string, len(conf.Streams)),
-		ackSend:    make(map[string][]string, len(conf.Streams)),
-		closeChan:  make(chan struct{}),
-		closedChan: make(chan struct{}),
+		conf:         conf,
+		stats:        stats,
+		log:          log,
+		backlogs:     make(map[string]string, len(conf.Streams)),
+		claimCursors: make(map[string]string, len(conf.Streams)),
+		ackSend:      make(map[string][]string, len(conf.Streams)),
+		mClaimed:     stats.GetCounter("auto_claim.claimed"),
+		closeChan:    make(chan struct{}),
+		closedChan:   make(chan struct{}),
 	}
 
 	for _, str := range conf.Streams {
@@ -128,6 +164,16 @@ func NewRedisStreams(
 		}
 	}
 
+	if conf.AutoClaim.Enabled {
+		if r.claimMinIdle, err = time.ParseDuration(conf.AutoClaim.MinIdle); err != nil {
+			return nil, fmt.Errorf("failed to parse auto claim min idle string: %v", err)
+		}
+		if r.claimPeriod, err = time.ParseDuration(conf.AutoClaim.Period); err != nil {
+			return nil, fmt.Errorf("failed to parse auto claim period string: %v", err)
+		}
+		r.draining = conf.AutoClaim.DrainOnStart
+	}
+
 	go r.loop()
 	return r, nil
 }
@@ -263,15 +309,44 @@ func (r *RedisStreams) read() (pendingRedisStreamMsg, error) {
 		return msg, nil
 	}
 
-	strs := make([]string, len(r.conf.Streams)*2)
-	for i, str := range r.conf.Streams {
-		strs[i] = str
+	if r.claimMinIdle > 0 && len(r.backlogs) == 0 {
+		if len(r.claimCursors) == 0 && !time.Now().Before(r.nextClaim) {
+			for _, str := range r.conf.Streams {
+				r.claimCursors[str] = "0-0"
+			}
+			r.nextClaim = time.Now().Add(r.claimPeriod)
+		}
+		for len(r.claimCursors) > 0 {
+			claimed, err := r.claim(client)
+			if err != nil {
+				r.log.Errorf("Failed to claim pending entries: %v\n", err)
+				r.claimCursors = map[string]string{}
+				break
+			}
+			if len(claimed) > 0 {
+				r.mClaimed.Incr(int64(len(claimed)))
+				r.pendingMsgs = claimed[1:]
+				return claimed[0], nil
+			}
+		}
+		r.draining = false
+	}
+
+	strs := make([]string, 0, len(r.conf.Streams)*2)
+	ids := make([]string, 0, len(r.conf.Streams))
+	for _, str := range r.conf.Streams {
 		if bl := r.backlogs[str]; bl != "" {
-			strs[len(r.conf.Streams)+i] = bl
-		} else {
-			strs[len(r.conf.Streams)+i] = ">"
+			strs = append(strs, str)
+			ids = append(ids, bl)
+		} else if !r.draining {
+			strs = append(strs, str)
+			ids = append(ids, ">")
 		}
 	}
+	if len(strs) == 0 {
+		return msg, types.ErrTimeout
+	}
+	strs = append(strs, ids...)
 
 	res, err := client.XReadGroup(&redis.XReadGroupArgs{
 		Block:    r.timeout,
@@ -300,35 +375,10 @@ func (r *RedisStreams) read() (pendingRedisStreamMsg, error) {
 			}
 		}
 		for _, xmsg := range strRes.Messages {
-			body, exists := xmsg.Values[r.conf.BodyKey]
-			if !exists {
+			nextMsg, ok := r.toPendingMsg(strRes.Stream, xmsg)
+			if !ok {
 				continue
 			}
-			delete(xmsg.Values, r.conf.BodyKey)
-
-			var bodyBytes []byte
-			switch t := body.(type) {
-			case string:
-				bodyBytes = []byte(t)
-			case []byte:
-				bodyBytes = t
-			}
-			if bodyBytes == nil {
-				continue
-			}
-
-			part := message.NewPart(bodyBytes)
-			part.Metadata().Set("redis_stream", xmsg.ID)
-			for k, v := range xmsg.Values {
-				part.Metadata().Set(k, fmt.Sprintf("%v", v))
-			}
-
-			nextMsg := pendingRedisStreamMsg{
-				payload: message.New(nil),
-				stream:  strRes.Stream,
-				id:      xmsg.ID,
-			}
-			nextMsg.payload.Append(part)
 			if msg.payload == nil {
 				msg = nextMsg
 			} else {
@@ -344,6 +394,128 @@ func (r *RedisStreams) read() (pendingRedisStreamMsg, error) {
 	return msg, nil
 }
 
+func (r *RedisStreams) toPendingMsg(stream string, xmsg redis.XMessage) (pendingRedisStreamMsg, bool) {
+	body, exists := xmsg.Values[r.conf.BodyKey]
+	if !exists {
+		return pendingRedisStreamMsg{}, false
+	}
+	delete(xmsg.Values, r.conf.BodyKey)
+
+	var bodyBytes []byte
+	switch t := body.(type) {
+	case string:
+		bodyBytes = []byte(t)
+	case []byte:
+		bodyBytes = t
+	}
+	if bodyBytes == nil {
+		return pendingRedisStreamMsg{}, false
+	}
+
+	part := message.NewPart(bodyBytes)
+	part.Metadata().Set("redis_stream", xmsg.ID)
+	for k, v := range xmsg.Values {
+		part.Metadata().Set(k, fmt.Sprintf("%v", v))
+	}
+
+	nextMsg := pendingRedisStreamMsg{
+		payload: message.New(nil),
+		stream:  stream,
+		id:      xmsg.ID,
+	}
+	nextMsg.payload.Append(part)
+	return nextMsg, true
+}
+
+// claim takes ownership of a page of the entries that have been pending for
+// longer than the min idle period for each stream with a sweep in progress,
+// using XAUTOCLAIM (Redis v6.2+).
+func (r *RedisStreams) claim(client *redis.Client) ([]pendingRedisStreamMsg, error) {
+	minIdle := strconv.FormatInt(int64(r.claimMinIdle/time.Millisecond), 10)
+
+	var claimed []pendingRedisStreamMsg
+	for _, str := range r.conf.Streams {
+		cursor, exists := r.claimCursors[str]
+		if !exists {
+			continue
+		}
+		res, err := client.Do(
+			"XAUTOCLAIM", str, r.conf.ConsumerGroup, r.conf.ClientID,
+			minIdle, cursor, "COUNT", r.conf.Limit,
+		).Result()
+		if err != nil {
+			return nil, err
+		}
+		next, xmsgs, deleted, err := parseAutoClaimResult(res)
+		if err != nil {
+			return nil, err
+		}
+		if next == "0-0" {
+			delete(r.claimCursors, str)
+		} else {
+			r.claimCursors[str] = next
+		}
+		if len(deleted) > 0 {
+			// Entries that no longer exist can never be processed, and
+			// therefore are removed from the pending list.
+			r.addAsyncAcks(str, deleted...)
+		}
+		for _, xmsg := range xmsgs {
+			if nextMsg, ok := r.toPendingMsg(str, xmsg); ok {
+				claimed = append(claimed, nextMsg)
+			}
+		}
+	}
+	return claimed, nil
+}
+
+// parseAutoClaimResult parses the reply of an XAUTOCLAIM command into the
+// cursor of the next call, the claimed entries and the IDs of pending entries
+// that have been deleted from the stream. Redis v7.0+ removes deleted entries
+// from the pending list itself, and so those are not reported.
+func parseAutoClaimResult(res interface{}) (string, []redis.XMessage, []string, error) {
+	parts, ok := res.([]interface{})
+	if !ok || len(parts) < 2 {
+		return "", nil, nil, fmt.Errorf("unexpected XAUTOCLAIM reply: %v", res)
+	}
+	next, ok := parts[0].(string)
+	if !ok {
+		return "", nil, nil, fmt.Errorf("unexpected XAUTOCLAIM cursor: %v", parts[0])
+	}
+	entries, ok := parts[1].([]interface{})
+	if !ok {
+		return "", nil, nil, fmt.Errorf("unexpected XAUTOCLAIM entries: %v", parts[1])
+	}
+
+	var deleted []string
+	xmsgs := make([]redis.XMessage, 0, len(entries))
+	for _, e := range entries {
+		entry, ok := e.([]interface{})
+		if !ok || len(entry) < 2 {
+			continue
+		}
+		id, ok := entry[0].(string)
+		if !ok {
+			continue
+		}
+		fields, ok := entry[1].([]interface{})
+		if !ok {
+			// Redis v6.2 returns deleted entries without any fields.
+			deleted = append(deleted, id)
+			continue
+		}
+		values := make(map[string]interface{}, len(fields)/2)
+		for i := 0; i+1 < len(fields); i += 2 {
+			if k, ok := fields[i].(string); ok {
+				values[k] = fields[i+1]
+			}
+		}
+		xmsgs = append(xmsgs, redis.XMessage{ID: id, Values: values})
+	}
+
+	return next, xmsgs, deleted, nil
+}
+
 // ReadWithContext attempts to pop a message from a Redis list.
 func (r *RedisStreams) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
 	msg, err := r.read()
@@ -0,0 +1,66 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStreamsAutoClaimConfigErrors(t *testing.T) {
+	conf := NewRedisStreamsConfig()
+	conf.AutoClaim.MinIdle = "nope"
+
+	r, err := NewRedisStreams(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	r.CloseAsync()
+
+	conf.AutoClaim.Enabled = true
+	_, err = NewRedisStreams(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.AutoClaim.MinIdle = "1m"
+	conf.AutoClaim.Period = "nope"
+	_, err = NewRedisStreams(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestRedisStreamsParseAutoClaimResult(t *testing.T) {
+	next, xmsgs, deleted, err := parseAutoClaimResult([]interface{}{
+		"1-5",
+		[]interface{}{
+			[]interface{}{"1-1", []interface{}{"body", "foo", "bar", "baz"}},
+			[]interface{}{"1-2", nil},
+			nil,
+			[]interface{}{"1-3", []interface{}{"body", "bar"}},
+		},
+		[]interface{}{"1-4"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1-5", next)
+	assert.Equal(t, []redis.XMessage{
+		{ID: "1-1", Values: map[string]interface{}{"body": "foo", "bar": "baz"}},
+		{ID: "1-3", Values: map[string]interface{}{"body": "bar"}},
+	}, xmsgs)
+	assert.Equal(t, []string{"1-2"}, deleted)
+
+	next, xmsgs, deleted, err = parseAutoClaimResult([]interface{}{"0-0", []interface{}{}})
+	require.NoError(t, err)
+	assert.Equal(t, "0-0", next)
+	assert.Empty(t, xmsgs)
+	assert.Empty(t, deleted)
+
+	for _, res := range []interface{}{
+		nil,
+		"nope",
+		[]interface{}{"0-0"},
+		[]interface{}{int64(0), []interface{}{}},
+		[]interface{}{"0-0", "nope"},
+	} {
+		_, _, _, err = parseAutoClaimResult(res)
+		assert.Error(t, err, res)
+	}
+}
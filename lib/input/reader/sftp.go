@@ -0,0 +1,486 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/codec"
+	"github.com/Jeffail/benthos/v3/internal/sftp"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"golang.org/x/crypto/ssh"
+)
+
+//------------------------------------------------------------------------------
+
+// SFTPWatcherConfig contains configuration fields for the watcher mode of the
+// SFTP input type.
+type SFTPWatcherConfig struct {
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+	MinimumAge   string `json:"minimum_age" yaml:"minimum_age"`
+	PollInterval string `json:"poll_interval" yaml:"poll_interval"`
+	Cache        string `json:"cache" yaml:"cache"`
+}
+
+// SFTPConfig contains configuration fields for the SFTP input type.
+type SFTPConfig struct {
+	Address        string            `json:"address" yaml:"address"`
+	Credentials    sftp.Credentials  `json:"credentials" yaml:"credentials"`
+	KnownHostsFile string            `json:"known_hosts_file" yaml:"known_hosts_file"`
+	Paths          []string          `json:"paths" yaml:"paths"`
+	Codec          string            `json:"codec" yaml:"codec"`
+	MaxBuffer      int               `json:"max_buffer" yaml:"max_buffer"`
+	DeleteOnFinish bool              `json:"delete_on_finish" yaml:"delete_on_finish"`
+	MoveOnFinish   string            `json:"move_on_finish" yaml:"move_on_finish"`
+	Watcher        SFTPWatcherConfig `json:"watcher" yaml:"watcher"`
+}
+
+// NewSFTPConfig creates a new SFTPConfig with default values.
+func NewSFTPConfig() SFTPConfig {
+	return SFTPConfig{
+		Address:        "",
+		Credentials:    sftp.NewCredentials(),
+		KnownHostsFile: "",
+		Paths:          []string{},
+		Codec:          "all-bytes",
+		MaxBuffer:      1000000,
+		DeleteOnFinish: false,
+		MoveOnFinish:   "",
+		Watcher: SFTPWatcherConfig{
+			Enabled:      false,
+			MinimumAge:   "1s",
+			PollInterval: "1s",
+			Cache:        "",
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// sftpFile is a remote file that matches the paths of the input.
+type sftpFile struct {
+	path    string
+	modTime time.Time
+}
+
+// sftpReading is a remote file that is being consumed with a codec.
+type sftpReading struct {
+	file   sftpFile
+	reader codec.Reader
+}
+
+// SFTP is a benthos reader.Async implementation that consumes the files of a
+// remote host that match glob patterns over SFTP.
+type SFTP struct {
+	conf      SFTPConfig
+	cache     types.Cache
+	sshConf   *ssh.ClientConfig
+	codecCtor codec.ReaderConstructor
+
+	minimumAge   time.Duration
+	pollInterval time.Duration
+
+	dial func() (*sftp.Client, error)
+
+	// Accessed only by ReadWithContext.
+	pending []sftpFile
+	current *sftpReading
+	scanned bool
+
+	mut        sync.Mutex
+	client     *sftp.Client
+	processing map[string]struct{}
+	seen       map[string]struct{}
+	closed     bool
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewSFTP creates a new SFTP input type.
+func NewSFTP(conf SFTPConfig, mgr types.Manager, log log.Modular, stats metrics.Type) (*SFTP, error) {
+	if len(conf.Address) == 0 {
+		return nil, errors.New("an address must be specified")
+	}
+	if len(conf.Paths) == 0 {
+		return nil, errors.New("at least one path must be specified")
+	}
+	for _, p := range conf.Paths {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("failed to parse path pattern '%v': %v", p, err)
+		}
+	}
+	if conf.DeleteOnFinish && len(conf.MoveOnFinish) > 0 {
+		return nil, errors.New("files cannot be both deleted and moved once finished")
+	}
+
+	s := &SFTP{
+		conf:       conf,
+		processing: map[string]struct{}{},
+		seen:       map[string]struct{}{},
+		log:        log,
+		stats:      stats,
+	}
+
+	var err error
+	if s.codecCtor, err = codec.GetReader(conf.Codec, codec.ReaderConfig{
+		MaxScanTokenSize: conf.MaxBuffer,
+	}); err != nil {
+		return nil, err
+	}
+	if s.sshConf, err = conf.Credentials.ClientConfig(conf.KnownHostsFile); err != nil {
+		return nil, err
+	}
+	if conf.Watcher.Enabled {
+		if s.minimumAge, err = time.ParseDuration(conf.Watcher.MinimumAge); err != nil {
+			return nil, fmt.Errorf("failed to parse minimum age: %v", err)
+		}
+		if s.pollInterval, err = time.ParseDuration(conf.Watcher.PollInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse poll interval: %v", err)
+		}
+		if len(conf.Watcher.Cache) > 0 {
+			if s.cache, err = mgr.GetCache(conf.Watcher.Cache); err != nil {
+				return nil, fmt.Errorf("failed to obtain cache '%v': %v", conf.Watcher.Cache, err)
+			}
+		}
+	}
+	s.dial = func() (*sftp.Client, error) {
+		return sftp.Dial(s.conf.Address, s.sshConf)
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext attempts to establish a connection to the SFTP server.
+func (s *SFTP) ConnectWithContext(ctx context.Context) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.closed {
+		return types.ErrTypeClosed
+	}
+	if s.client != nil {
+		return nil
+	}
+
+	client, err := s.dial()
+	if err != nil {
+		return err
+	}
+	s.client = client
+
+	s.log.Infof("Consuming files from SFTP server %v matching: %v\n", s.conf.Address, strings.Join(s.conf.Paths, ", "))
+	return nil
+}
+
+// lostConnection closes a client that is no longer connected and returns
+// types.ErrNotConnected.
+func (s *SFTP) lostConnection(client *sftp.Client) error {
+	s.mut.Lock()
+	if s.client == client {
+		s.client.Close()
+		s.client = nil
+	}
+	s.mut.Unlock()
+	return types.ErrNotConnected
+}
+
+//------------------------------------------------------------------------------
+
+func sftpHasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// sftpGlob returns the regular files that match a pattern, where each segment
+// of the pattern is matched with the entries of a directory.
+func sftpGlob(client *sftp.Client, pattern string) ([]sftpFile, error) {
+	pattern = path.Clean(pattern)
+
+	candidates := []string{""}
+	segments := strings.Split(pattern, "/")
+	if path.IsAbs(pattern) {
+		candidates = []string{"/"}
+		segments = segments[1:]
+	}
+
+	for i, segment := range segments {
+		last := i == len(segments)-1
+
+		var matched []string
+		for _, dir := range candidates {
+			if !sftpHasMeta(segment) {
+				matched = append(matched, path.Join(dir, segment))
+				continue
+			}
+			listDir := dir
+			if len(listDir) == 0 {
+				listDir = "."
+			}
+			entries, err := client.ReadDir(listDir)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return nil, err
+			}
+			for _, e := range entries {
+				if ok, _ := path.Match(segment, e.Name()); ok && (last || e.IsDir()) {
+					matched = append(matched, path.Join(dir, e.Name()))
+				}
+			}
+		}
+		candidates = matched
+	}
+
+	var files []sftpFile
+	for _, p := range candidates {
+		info, err := client.Stat(p)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		if info.Mode().IsRegular() {
+			files = append(files, sftpFile{path: p, modTime: info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+	return files, nil
+}
+
+// skip returns whether a file should not be queued for consumption.
+func (s *SFTP) skip(file sftpFile, now time.Time) bool {
+	s.mut.Lock()
+	_, processing := s.processing[file.path]
+	_, seen := s.seen[file.path]
+	s.mut.Unlock()
+	if processing || seen {
+		return true
+	}
+	if s.conf.Watcher.Enabled && now.Sub(file.modTime) < s.minimumAge {
+		return true
+	}
+	if s.cache != nil {
+		if _, err := s.cache.Get(file.path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// scan queues the files that match the paths of the input and that are not
+// already consumed or being consumed.
+func (s *SFTP) scan(client *sftp.Client) error {
+	now := time.Now()
+	queued := map[string]struct{}{}
+	for _, pattern := range s.conf.Paths {
+		files, err := sftpGlob(client, pattern)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if _, exists := queued[file.path]; exists || s.skip(file, now) {
+				continue
+			}
+			queued[file.path] = struct{}{}
+			s.pending = append(s.pending, file)
+		}
+	}
+	s.mut.Lock()
+	for p := range queued {
+		s.processing[p] = struct{}{}
+	}
+	s.mut.Unlock()
+	return nil
+}
+
+// release removes a file from those being consumed, and optionally marks it
+// as consumed so that it is not consumed again.
+func (s *SFTP) release(file sftpFile, consumed bool) {
+	s.mut.Lock()
+	delete(s.processing, file.path)
+	if consumed {
+		s.seen[file.path] = struct{}{}
+	}
+	s.mut.Unlock()
+}
+
+// fileAckFn returns a function that is called once all messages of a file are
+// acknowledged, which deletes or moves the file if configured to do so.
+func (s *SFTP) fileAckFn(file sftpFile) codec.ReaderAckFn {
+	return func(ctx context.Context, err error) error {
+		if err != nil {
+			s.release(file, false)
+			return nil
+		}
+
+		s.mut.Lock()
+		client := s.client
+		s.mut.Unlock()
+		if client == nil {
+			s.release(file, false)
+			return types.ErrNotConnected
+		}
+
+		switch {
+		case s.conf.DeleteOnFinish:
+			err = client.Remove(file.path)
+			s.release(file, false)
+			if err != nil {
+				return fmt.Errorf("failed to delete file %v: %v", file.path, err)
+			}
+		case len(s.conf.MoveOnFinish) > 0:
+			target := path.Join(s.conf.MoveOnFinish, path.Base(file.path))
+			if err = client.MkdirAll(s.conf.MoveOnFinish); err == nil {
+				err = client.Rename(file.path, target)
+			}
+			s.release(file, false)
+			if err != nil {
+				return fmt.Errorf("failed to move file %v to %v: %v", file.path, target, err)
+			}
+		default:
+			s.release(file, s.conf.Watcher.Enabled)
+			if s.cache != nil {
+				if err = s.cache.Set(file.path, []byte(strconv.FormatInt(file.modTime.Unix(), 10))); err != nil {
+					return fmt.Errorf("failed to record file %v as consumed: %v", file.path, err)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// openNext opens the next pending file, scanning for files when there are none
+// pending.
+func (s *SFTP) openNext(ctx context.Context, client *sftp.Client) error {
+	for {
+		for len(s.pending) == 0 {
+			if s.scanned {
+				if !s.conf.Watcher.Enabled {
+					return types.ErrTypeClosed
+				}
+				select {
+				case <-time.After(s.pollInterval):
+				case <-ctx.Done():
+					return types.ErrTimeout
+				}
+			}
+			if err := s.scan(client); err != nil {
+				if errors.Is(err, sftp.ErrClosed) {
+					return s.lostConnection(client)
+				}
+				return err
+			}
+			s.scanned = true
+		}
+
+		file := s.pending[0]
+		f, err := client.Open(file.path)
+		if err != nil {
+			if errors.Is(err, sftp.ErrClosed) {
+				return s.lostConnection(client)
+			}
+			s.pending = s.pending[1:]
+			s.release(file, false)
+			if !errors.Is(err, os.ErrNotExist) {
+				s.log.Errorf("Failed to open file %v: %v\n", file.path, err)
+			}
+			continue
+		}
+		s.pending = s.pending[1:]
+
+		r, err := s.codecCtor(f, s.fileAckFn(file))
+		if err != nil {
+			s.log.Errorf("Failed to read file %v: %v\n", file.path, err)
+			s.release(file, true)
+			continue
+		}
+		s.current = &sftpReading{file: file, reader: r}
+		return nil
+	}
+}
+
+// ReadWithContext attempts to read a new message from the files of the SFTP
+// server.
+func (s *SFTP) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	s.mut.Lock()
+	client, closed := s.client, s.closed
+	s.mut.Unlock()
+	if closed {
+		return nil, nil, types.ErrTypeClosed
+	}
+	if client == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	for {
+		if s.current == nil {
+			if err := s.openNext(ctx, client); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		parts, codecAckFn, err := s.current.reader.Next(ctx)
+		if err != nil {
+			file := s.current.file
+			s.current.reader.Close(ctx)
+			s.current = nil
+			if err == io.EOF {
+				continue
+			}
+			if errors.Is(err, sftp.ErrClosed) {
+				// Consume the file again from the start once reconnected.
+				s.pending = append([]sftpFile{file}, s.pending...)
+				return nil, nil, s.lostConnection(client)
+			}
+			s.log.Errorf("Failed to read file %v: %v\n", file.path, err)
+			s.release(file, true)
+			continue
+		}
+
+		msg := message.New(nil)
+		for _, p := range parts {
+			p.Metadata().
+				Set("sftp_path", s.current.file.path).
+				Set("sftp_mod_time_unix", strconv.FormatInt(s.current.file.modTime.Unix(), 10))
+			msg.Append(p)
+		}
+		return msg, func(ctx context.Context, res types.Response) error {
+			return codecAckFn(ctx, res.Error())
+		}, nil
+	}
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (s *SFTP) CloseAsync() {
+	s.mut.Lock()
+	s.closed = true
+	if s.client != nil {
+		s.client.Close()
+		s.client = nil
+	}
+	s.mut.Unlock()
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (s *SFTP) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
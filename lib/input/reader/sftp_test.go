@@ -0,0 +1,235 @@
+package reader
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/sftp"
+	"github.com/Jeffail/benthos/v3/internal/sftp/sftptest"
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSFTPTestReader(t *testing.T, conf SFTPConfig, mgr types.Manager, dir string, posixRename bool) *SFTP {
+	t.Helper()
+
+	conf.Address = "localhost:22"
+	conf.Credentials.Username = "foo"
+	conf.Credentials.Password = "bar"
+
+	s, err := NewSFTP(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	s.dial = func() (*sftp.Client, error) {
+		return sftptest.NewClient(dir, posixRename)
+	}
+	require.NoError(t, s.ConnectWithContext(context.Background()))
+	return s
+}
+
+func writeSFTPTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for p, content := range files {
+		p = filepath.Join(dir, filepath.FromSlash(p))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, ioutil.WriteFile(p, []byte(content), 0644))
+	}
+}
+
+func TestSFTPConfigErrors(t *testing.T) {
+	conf := NewSFTPConfig()
+	_, err := NewSFTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Address = "localhost:22"
+	_, err = NewSFTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Paths = []string{"/data/[*.txt"}
+	_, err = NewSFTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Paths = []string{"/data/*.txt"}
+	conf.Codec = "nope"
+	_, err = NewSFTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Codec = "lines"
+	_, err = NewSFTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Credentials.Username = "foo"
+	conf.Credentials.Password = "bar"
+	conf.DeleteOnFinish = true
+	conf.MoveOnFinish = "/data/done"
+	_, err = NewSFTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.MoveOnFinish = ""
+	conf.Watcher.Enabled = true
+	conf.Watcher.Cache = "nope"
+	_, err = NewSFTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Watcher.Cache = ""
+	_, err = NewSFTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.NoError(t, err)
+}
+
+func TestSFTPDeleteOnFinish(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_sftp_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeSFTPTestFiles(t, dir, map[string]string{
+		"data/a/1.txt":  "foo\nbar",
+		"data/b/2.txt":  "baz",
+		"data/b/3.json": "nope",
+		"data/c.txt":    "nope",
+	})
+
+	conf := NewSFTPConfig()
+	conf.Paths = []string{"/data/*/*.txt"}
+	conf.Codec = "lines"
+	conf.DeleteOnFinish = true
+
+	s := newSFTPTestReader(t, conf, types.NoopMgr(), dir, true)
+	defer s.CloseAsync()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	var contents, paths []string
+	var ackFns []AsyncAckFn
+	for i := 0; i < 3; i++ {
+		msg, ackFn, err := s.ReadWithContext(ctx)
+		require.NoError(t, err)
+		contents = append(contents, string(msg.Get(0).Get()))
+		paths = append(paths, msg.Get(0).Metadata().Get("sftp_path"))
+		ackFns = append(ackFns, ackFn)
+	}
+	assert.Equal(t, []string{"foo", "bar", "baz"}, contents)
+	assert.Equal(t, []string{"/data/a/1.txt", "/data/a/1.txt", "/data/b/2.txt"}, paths)
+
+	_, _, err = s.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrTypeClosed, err)
+
+	// Files are deleted only once all of their messages are acknowledged.
+	require.NoError(t, ackFns[0](ctx, response.NewAck()))
+	require.NoError(t, ackFns[2](ctx, response.NewAck()))
+	assert.FileExists(t, filepath.Join(dir, "data", "a", "1.txt"))
+	_, err = os.Stat(filepath.Join(dir, "data", "b", "2.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ackFns[1](ctx, response.NewAck()))
+	_, err = os.Stat(filepath.Join(dir, "data", "a", "1.txt"))
+	assert.True(t, os.IsNotExist(err))
+	assert.FileExists(t, filepath.Join(dir, "data", "b", "3.json"))
+	assert.FileExists(t, filepath.Join(dir, "data", "c.txt"))
+}
+
+func TestSFTPWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_sftp_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeSFTPTestFiles(t, dir, map[string]string{
+		"in/1.txt": "foo",
+	})
+
+	memCache, err := cache.NewMemory(cache.NewConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	mgr := &fakeCacheMgr{caches: map[string]types.Cache{"foocache": memCache}}
+
+	conf := NewSFTPConfig()
+	conf.Paths = []string{"in/*.txt"}
+	conf.Watcher.Enabled = true
+	conf.Watcher.MinimumAge = "0s"
+	conf.Watcher.PollInterval = "10ms"
+	conf.Watcher.Cache = "foocache"
+
+	s := newSFTPTestReader(t, conf, mgr, dir, true)
+	defer s.CloseAsync()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, ackFn, err := s.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(msg.Get(0).Get()))
+
+	readCtx, readDone := context.WithTimeout(ctx, time.Millisecond*50)
+	_, _, err = s.ReadWithContext(readCtx)
+	readDone()
+	assert.Equal(t, types.ErrTimeout, err)
+
+	require.NoError(t, ackFn(ctx, response.NewAck()))
+	_, err = memCache.Get("in/1.txt")
+	require.NoError(t, err)
+
+	writeSFTPTestFiles(t, dir, map[string]string{
+		"in/2.txt": "bar",
+	})
+	msg, _, err = s.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(msg.Get(0).Get()))
+	assert.Equal(t, "in/2.txt", msg.Get(0).Metadata().Get("sftp_path"))
+
+	// Files recorded within the cache are not consumed by a later reader.
+	s2 := newSFTPTestReader(t, conf, mgr, dir, false)
+	defer s2.CloseAsync()
+
+	msg, _, err = s2.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(msg.Get(0).Get()))
+}
+
+func TestSFTPMoveOnFinish(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_sftp_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeSFTPTestFiles(t, dir, map[string]string{
+		"in/1.txt": "foo",
+	})
+
+	conf := NewSFTPConfig()
+	conf.Paths = []string{"/in/1.txt"}
+	conf.MoveOnFinish = "/done/in"
+
+	for _, posixRename := range []bool{true, false} {
+		s := newSFTPTestReader(t, conf, types.NoopMgr(), dir, posixRename)
+
+		ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+		msg, ackFn, err := s.ReadWithContext(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", string(msg.Get(0).Get()))
+
+		writeSFTPTestFiles(t, dir, map[string]string{
+			"done/in/1.txt": "old",
+		})
+		require.NoError(t, ackFn(ctx, response.NewAck()))
+
+		// The file is moved once it is known to be fully consumed.
+		_, _, err = s.ReadWithContext(ctx)
+		assert.Equal(t, types.ErrTypeClosed, err)
+		done()
+		s.CloseAsync()
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "done", "in", "1.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "foo", string(data))
+
+		writeSFTPTestFiles(t, dir, map[string]string{
+			"in/1.txt": "foo",
+		})
+	}
+}
@@ -0,0 +1,460 @@
+package reader
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
+
+	// SQL Drivers
+	_ "github.com/ClickHouse/clickhouse-go"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+//------------------------------------------------------------------------------
+
+// SQLSelectConfig contains configuration fields for the SQLSelect input type.
+type SQLSelectConfig struct {
+	Driver          string   `json:"driver" yaml:"driver"`
+	DataSourceName  string   `json:"data_source_name" yaml:"data_source_name"`
+	Table           string   `json:"table" yaml:"table"`
+	Columns         []string `json:"columns" yaml:"columns"`
+	Where           string   `json:"where" yaml:"where"`
+	CursorColumn    string   `json:"cursor_column" yaml:"cursor_column"`
+	CursorInitial   string   `json:"cursor_initial" yaml:"cursor_initial"`
+	PageSize        int      `json:"page_size" yaml:"page_size"`
+	PollInterval    string   `json:"poll_interval" yaml:"poll_interval"`
+	CheckpointCache string   `json:"checkpoint_cache" yaml:"checkpoint_cache"`
+	CheckpointKey   string   `json:"checkpoint_key" yaml:"checkpoint_key"`
+}
+
+// NewSQLSelectConfig creates a new SQLSelectConfig with default values.
+func NewSQLSelectConfig() SQLSelectConfig {
+	return SQLSelectConfig{
+		Driver:          "mysql",
+		DataSourceName:  "",
+		Table:           "",
+		Columns:         []string{},
+		Where:           "",
+		CursorColumn:    "",
+		CursorInitial:   "",
+		PageSize:        1000,
+		PollInterval:    "5s",
+		CheckpointCache: "",
+		CheckpointKey:   "sql_select",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// sqlSelectRow is a row that is pending delivery along with the value of its
+// cursor column.
+type sqlSelectRow struct {
+	data   map[string]interface{}
+	cursor string
+	index  int
+}
+
+// SQLSelect is a benthos reader.Async implementation that polls a table for
+// rows with a cursor column greater than the last value read.
+type SQLSelect struct {
+	conf  SQLSelectConfig
+	cache types.Cache
+
+	pollInterval time.Duration
+
+	db      *sql.DB
+	dbMut   sync.Mutex
+	closed  bool
+	queries [2]string
+
+	// The cursor of the last page of rows read and the rows of it that are
+	// pending delivery.
+	cursor    *string
+	rows      []sqlSelectRow
+	lastPoll  time.Time
+	exhausted bool
+
+	cpMut     sync.Mutex
+	cp        *checkpoint.Type
+	index     int
+	pruned    int
+	positions map[int]string
+	position  string
+
+	commitMut sync.Mutex
+	committed string
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewSQLSelect creates a new SQL select reader.
+func NewSQLSelect(
+	conf SQLSelectConfig,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (*SQLSelect, error) {
+	switch conf.Driver {
+	case "mysql", "postgres", "clickhouse":
+	default:
+		return nil, fmt.Errorf("unrecognised driver: %v", conf.Driver)
+	}
+	if conf.DataSourceName == "" {
+		return nil, errors.New("a data source name must be specified")
+	}
+	if conf.Table == "" {
+		return nil, errors.New("a table must be specified")
+	}
+	if conf.CursorColumn == "" {
+		return nil, errors.New("a cursor column must be specified")
+	}
+	if conf.PageSize < 1 {
+		return nil, errors.New("page size must be greater than zero")
+	}
+	if conf.CheckpointCache != "" && conf.CheckpointKey == "" {
+		return nil, errors.New("a checkpoint key must be specified")
+	}
+
+	s := &SQLSelect{
+		conf:      conf,
+		cp:        checkpoint.New(0),
+		positions: map[int]string{},
+		log:       log,
+		stats:     stats,
+	}
+
+	var err error
+	if s.pollInterval, err = time.ParseDuration(conf.PollInterval); err != nil {
+		return nil, fmt.Errorf("failed to parse poll interval string: %v", err)
+	}
+	if conf.CheckpointCache != "" {
+		if s.cache, err = mgr.GetCache(conf.CheckpointCache); err != nil {
+			return nil, fmt.Errorf("failed to obtain cache '%v': %v", conf.CheckpointCache, err)
+		}
+	}
+	s.queries[0], s.queries[1] = s.buildQueries()
+	return s, nil
+}
+
+// buildQueries returns the query for the first page of rows when there is no
+// cursor, and the query for the rows that follow a cursor.
+func (s *SQLSelect) buildQueries() (first, next string) {
+	columns := s.conf.Columns
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+	selected := false
+	for _, c := range columns {
+		if c == "*" || c == s.conf.CursorColumn {
+			selected = true
+		}
+	}
+	if !selected {
+		columns = append(append([]string{}, columns...), s.conf.CursorColumn)
+	}
+
+	placeholder := "?"
+	if s.conf.Driver == "postgres" {
+		placeholder = "$1"
+	}
+
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM " + s.conf.Table
+	suffix := " ORDER BY " + s.conf.CursorColumn + " ASC LIMIT " + strconv.Itoa(s.conf.PageSize)
+	cursorCond := s.conf.CursorColumn + " > " + placeholder
+	if s.conf.Where != "" {
+		first = query + " WHERE " + s.conf.Where + suffix
+		next = query + " WHERE (" + s.conf.Where + ") AND " + cursorCond + suffix
+	} else {
+		first = query + suffix
+		next = query + " WHERE " + cursorCond + suffix
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext connects to the database and reads the cursor that was
+// last committed.
+func (s *SQLSelect) ConnectWithContext(ctx context.Context) error {
+	s.dbMut.Lock()
+	defer s.dbMut.Unlock()
+
+	if s.closed {
+		return types.ErrTypeClosed
+	}
+	if s.db != nil {
+		return nil
+	}
+
+	cursor, err := s.loadCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open(s.conf.Driver, s.conf.DataSourceName)
+	if err != nil {
+		return err
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return err
+	}
+
+	s.cursor = cursor
+	s.rows = nil
+	if cursor != nil {
+		s.committed = *cursor
+		s.log.Infof("Polling table %v from cursor '%v'\n", s.conf.Table, *cursor)
+	} else {
+		s.log.Infof("Polling table %v\n", s.conf.Table)
+	}
+	s.db = db
+	return nil
+}
+
+// loadCheckpoint reads the cursor that was last committed to the cache,
+// falling back to the initial cursor, and returns nil when there isn't one.
+func (s *SQLSelect) loadCheckpoint() (*string, error) {
+	if s.cache != nil {
+		data, err := s.cache.Get(s.conf.CheckpointKey)
+		if err == nil {
+			cursor := string(data)
+			return &cursor, nil
+		}
+		if err != types.ErrKeyNotFound {
+			return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+		}
+	}
+	if s.conf.CursorInitial != "" {
+		cursor := s.conf.CursorInitial
+		return &cursor, nil
+	}
+	return nil, nil
+}
+
+//------------------------------------------------------------------------------
+
+// sqlSelectCursor returns the string representation of the value of a cursor
+// column, which is used as the argument of subsequent queries.
+func sqlSelectCursor(v interface{}) string {
+	switch t := v.(type) {
+	case []byte:
+		return string(t)
+	case time.Time:
+		return t.UTC().Format(time.RFC3339Nano)
+	case nil:
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// queryPage reads the page of rows that follows the current cursor. When a
+// full page ends with rows sharing the same cursor value those rows are left
+// for the next page, as the page limit may have excluded others with that
+// value.
+func (s *SQLSelect) queryPage(ctx context.Context, db *sql.DB) ([]sqlSelectRow, error) {
+	var rows *sql.Rows
+	var err error
+	if s.cursor == nil {
+		rows, err = db.QueryContext(ctx, s.queries[0])
+	} else {
+		rows, err = db.QueryContext(ctx, s.queries[1], *s.cursor)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	cursorIndex := -1
+	for i, c := range columns {
+		if strings.EqualFold(c, s.conf.CursorColumn) || strings.HasSuffix(s.conf.CursorColumn, "."+c) {
+			cursorIndex = i
+		}
+	}
+	if cursorIndex < 0 {
+		return nil, fmt.Errorf("cursor column %v was not found in the results", s.conf.CursorColumn)
+	}
+
+	var page []sqlSelectRow
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dests := make([]interface{}, len(columns))
+		for i := range values {
+			dests[i] = &values[i]
+		}
+		if err := rows.Scan(dests...); err != nil {
+			return nil, err
+		}
+		row := sqlSelectRow{
+			data:   make(map[string]interface{}, len(columns)),
+			cursor: sqlSelectCursor(values[cursorIndex]),
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			row.data[columns[i]] = v
+		}
+		page = append(page, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page) == s.conf.PageSize {
+		last := page[len(page)-1].cursor
+		i := len(page) - 1
+		for i >= 0 && page[i].cursor == last {
+			i--
+		}
+		if i >= 0 {
+			page = page[:i+1]
+		} else {
+			s.log.Warnf("All rows of a page share the cursor value '%v', rows beyond the page size with this value are skipped\n", last)
+		}
+	}
+	return page, nil
+}
+
+//------------------------------------------------------------------------------
+
+// track adds a page of rows that are pending acknowledgement, where the final
+// row of the page progresses the committed cursor once resolved.
+func (s *SQLSelect) track(page []sqlSelectRow) []int {
+	s.cpMut.Lock()
+	defer s.cpMut.Unlock()
+
+	indexes := make([]int, len(page))
+	for i := range page {
+		s.index++
+		s.cp.MustTrack(s.index)
+		indexes[i] = s.index
+	}
+	s.positions[s.index] = page[len(page)-1].cursor
+	return indexes
+}
+
+// resolve marks a row as acknowledged and commits the cursor to the cache when
+// it has progressed.
+func (s *SQLSelect) resolve(index int) error {
+	s.cpMut.Lock()
+	highest := s.cp.MustResolve(index)
+	for ; s.pruned < highest; s.pruned++ {
+		if cursor, exists := s.positions[s.pruned+1]; exists {
+			s.position = cursor
+			delete(s.positions, s.pruned+1)
+		}
+	}
+	cursor := s.position
+	s.cpMut.Unlock()
+
+	if s.cache == nil {
+		return nil
+	}
+
+	s.commitMut.Lock()
+	defer s.commitMut.Unlock()
+	if cursor == "" || cursor == s.committed {
+		return nil
+	}
+	if err := s.cache.Set(s.conf.CheckpointKey, []byte(cursor)); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	s.committed = cursor
+	return nil
+}
+
+// ReadWithContext attempts to read a new row from the table, querying the
+// next page of rows once those previously read have been consumed.
+func (s *SQLSelect) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	s.dbMut.Lock()
+	db, closed := s.db, s.closed
+	s.dbMut.Unlock()
+
+	if closed {
+		return nil, nil, types.ErrTypeClosed
+	}
+	if db == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	for len(s.rows) == 0 {
+		// After reaching the end of the table the next page is queried at the
+		// rate of the poll interval.
+		if s.exhausted {
+			select {
+			case <-time.After(time.Until(s.lastPoll.Add(s.pollInterval))):
+			case <-ctx.Done():
+				return nil, nil, types.ErrTimeout
+			}
+		}
+		s.lastPoll = time.Now()
+		page, err := s.queryPage(ctx, db)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to query rows: %w", err)
+		}
+		s.exhausted = len(page) == 0
+		if len(page) > 0 {
+			cursor := page[len(page)-1].cursor
+			s.cursor = &cursor
+			for i, index := range s.track(page) {
+				page[i].index = index
+			}
+			s.rows = page
+		}
+	}
+
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+
+	part := message.NewPart(nil)
+	if err := part.SetJSON(row.data); err != nil {
+		return nil, nil, err
+	}
+	part.Metadata().Set("sql_select_cursor", row.cursor)
+
+	msg := message.New(nil)
+	msg.Append(part)
+	return msg, func(ctx context.Context, res types.Response) error {
+		if res.Error() != nil {
+			return nil
+		}
+		return s.resolve(row.index)
+	}, nil
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (s *SQLSelect) CloseAsync() {
+	s.dbMut.Lock()
+	defer s.dbMut.Unlock()
+
+	s.closed = true
+	if s.db != nil {
+		s.db.Close()
+		s.db = nil
+	}
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (s *SQLSelect) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
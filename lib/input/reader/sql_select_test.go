@@ -0,0 +1,278 @@
+package reader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//------------------------------------------------------------------------------
+
+// sqlSelectTestDriver is a driver of a table with the columns id and ts, which
+// returns the rows with a ts greater than the argument of a query.
+type sqlSelectTestDriver struct {
+	sync.Mutex
+	rows    [][]driver.Value
+	queries []string
+}
+
+var sqlSelectTestTable = &sqlSelectTestDriver{}
+
+func init() {
+	sql.Register("sql_select_test", sqlSelectTestTable)
+}
+
+func (d *sqlSelectTestDriver) Open(name string) (driver.Conn, error) {
+	return sqlSelectTestConn{d: d}, nil
+}
+
+type sqlSelectTestConn struct {
+	d *sqlSelectTestDriver
+}
+
+func (c sqlSelectTestConn) Prepare(query string) (driver.Stmt, error) {
+	return sqlSelectTestStmt{d: c.d, query: query}, nil
+}
+
+func (c sqlSelectTestConn) Close() error {
+	return nil
+}
+
+func (c sqlSelectTestConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type sqlSelectTestStmt struct {
+	d     *sqlSelectTestDriver
+	query string
+}
+
+func (s sqlSelectTestStmt) Close() error {
+	return nil
+}
+
+func (s sqlSelectTestStmt) NumInput() int {
+	return -1
+}
+
+func (s sqlSelectTestStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s sqlSelectTestStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.Lock()
+	defer s.d.Unlock()
+	s.d.queries = append(s.d.queries, s.query)
+
+	limit, err := strconv.Atoi(s.query[strings.LastIndex(s.query, " ")+1:])
+	if err != nil {
+		return nil, err
+	}
+	var cursor int64 = -1
+	if len(args) > 0 {
+		if cursor, err = strconv.ParseInt(args[0].(string), 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	rows := &sqlSelectTestRows{}
+	for _, row := range s.d.rows {
+		if row[1].(int64) > cursor && len(rows.rows) < limit {
+			rows.rows = append(rows.rows, row)
+		}
+	}
+	return rows, nil
+}
+
+type sqlSelectTestRows struct {
+	rows [][]driver.Value
+}
+
+func (r *sqlSelectTestRows) Columns() []string {
+	return []string{"id", "ts"}
+}
+
+func (r *sqlSelectTestRows) Close() error {
+	return nil
+}
+
+func (r *sqlSelectTestRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type fakeCacheMgr struct {
+	types.DudMgr
+	caches map[string]types.Cache
+}
+
+func (f *fakeCacheMgr) GetCache(name string) (types.Cache, error) {
+	if c, exists := f.caches[name]; exists {
+		return c, nil
+	}
+	return nil, types.ErrCacheNotFound
+}
+
+//------------------------------------------------------------------------------
+
+func TestSQLSelectConfigErrors(t *testing.T) {
+	conf := NewSQLSelectConfig()
+	conf.Driver = "nope"
+	_, err := NewSQLSelect(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Driver = "postgres"
+	_, err = NewSQLSelect(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.DataSourceName = "postgres://localhost:5432/foo"
+	conf.Table = "foo"
+	_, err = NewSQLSelect(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.CursorColumn = "id"
+	conf.CheckpointCache = "nope"
+	_, err = NewSQLSelect(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestSQLSelectQueries(t *testing.T) {
+	conf := NewSQLSelectConfig()
+	conf.DataSourceName = "foo"
+	conf.Table = "foo"
+	conf.CursorColumn = "id"
+	conf.PageSize = 10
+
+	s, err := NewSQLSelect(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, [2]string{
+		"SELECT * FROM foo ORDER BY id ASC LIMIT 10",
+		"SELECT * FROM foo WHERE id > ? ORDER BY id ASC LIMIT 10",
+	}, s.queries)
+
+	conf.Driver = "postgres"
+	conf.Columns = []string{"name", "age"}
+	conf.Where = "age > 18 OR vip"
+	s, err = NewSQLSelect(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, [2]string{
+		"SELECT name, age, id FROM foo WHERE age > 18 OR vip ORDER BY id ASC LIMIT 10",
+		"SELECT name, age, id FROM foo WHERE (age > 18 OR vip) AND id > $1 ORDER BY id ASC LIMIT 10",
+	}, s.queries)
+}
+
+func TestSQLSelectPaging(t *testing.T) {
+	sqlSelectTestTable.Lock()
+	sqlSelectTestTable.rows = [][]driver.Value{
+		{int64(1), int64(1)},
+		{int64(2), int64(2)},
+		{int64(3), int64(2)},
+		{int64(4), int64(3)},
+		{int64(5), int64(3)},
+		{int64(6), int64(3)},
+		{int64(7), int64(4)},
+	}
+	sqlSelectTestTable.queries = nil
+	sqlSelectTestTable.Unlock()
+
+	memCache, err := cache.NewMemory(cache.NewConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	mgr := &fakeCacheMgr{caches: map[string]types.Cache{"foocache": memCache}}
+
+	conf := NewSQLSelectConfig()
+	conf.DataSourceName = "foo"
+	conf.Table = "foo"
+	conf.CursorColumn = "ts"
+	conf.PageSize = 3
+	conf.PollInterval = "10ms"
+	conf.CheckpointCache = "foocache"
+
+	s, err := NewSQLSelect(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	s.conf.Driver = "sql_select_test"
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+	require.NoError(t, s.ConnectWithContext(ctx))
+	defer s.CloseAsync()
+
+	var ackFns []AsyncAckFn
+	var ids []string
+	for i := 0; i < 7; i++ {
+		msg, ackFn, err := s.ReadWithContext(ctx)
+		require.NoError(t, err)
+		ids = append(ids, string(msg.Get(0).Get()))
+		ackFns = append(ackFns, ackFn)
+	}
+
+	// Rows sharing the cursor value of the end of a full page are left for the
+	// following page.
+	assert.Equal(t, []string{
+		`{"id":1,"ts":1}`,
+		`{"id":2,"ts":2}`,
+		`{"id":3,"ts":2}`,
+		`{"id":4,"ts":3}`,
+		`{"id":5,"ts":3}`,
+		`{"id":6,"ts":3}`,
+		`{"id":7,"ts":4}`,
+	}, ids)
+
+	sqlSelectTestTable.Lock()
+	assert.Equal(t, []string{
+		"SELECT * FROM foo ORDER BY ts ASC LIMIT 3",
+		"SELECT * FROM foo WHERE ts > ? ORDER BY ts ASC LIMIT 3",
+		"SELECT * FROM foo WHERE ts > ? ORDER BY ts ASC LIMIT 3",
+		"SELECT * FROM foo WHERE ts > ? ORDER BY ts ASC LIMIT 3",
+	}, sqlSelectTestTable.queries)
+	sqlSelectTestTable.Unlock()
+
+	readCtx, readDone := context.WithTimeout(ctx, time.Millisecond*50)
+	_, _, err = s.ReadWithContext(readCtx)
+	readDone()
+	assert.Equal(t, types.ErrTimeout, err)
+
+	// The cursor is committed only once all prior rows are acknowledged.
+	require.NoError(t, ackFns[2](ctx, response.NewAck()))
+	_, err = memCache.Get("sql_select")
+	assert.Equal(t, types.ErrKeyNotFound, err)
+
+	require.NoError(t, ackFns[0](ctx, response.NewAck()))
+	data, err := memCache.Get("sql_select")
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(data))
+
+	require.NoError(t, ackFns[1](ctx, response.NewAck()))
+	data, err = memCache.Get("sql_select")
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(data))
+
+	// A later reader resumes from the committed cursor.
+	s2, err := NewSQLSelect(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	s2.conf.Driver = "sql_select_test"
+	require.NoError(t, s2.ConnectWithContext(ctx))
+	defer s2.CloseAsync()
+
+	msg, _, err := s2.ReadWithContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":4,"ts":3}`, string(msg.Get(0).Get()))
+	assert.Equal(t, "3", msg.Get(0).Metadata().Get("sql_select_cursor"))
+}
@@ -0,0 +1,294 @@
+package reader
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+// SSEConfig contains configuration fields for the SSE input type.
+type SSEConfig struct {
+	URL            string            `json:"url" yaml:"url"`
+	Headers        map[string]string `json:"headers" yaml:"headers"`
+	LastEventID    string            `json:"last_event_id" yaml:"last_event_id"`
+	ReconnectDelay string            `json:"reconnect_delay" yaml:"reconnect_delay"`
+	Timeout        string            `json:"timeout" yaml:"timeout"`
+	TLS            btls.Config       `json:"tls" yaml:"tls"`
+	auth.Config    `json:",inline" yaml:",inline"`
+}
+
+// NewSSEConfig creates a new SSEConfig with default values.
+func NewSSEConfig() SSEConfig {
+	return SSEConfig{
+		URL:            "http://localhost:4195/events",
+		Headers:        map[string]string{},
+		LastEventID:    "",
+		ReconnectDelay: "3s",
+		Timeout:        "10s",
+		TLS:            btls.NewConfig(),
+		Config:         auth.NewConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SSE is an input type that consumes the events of a Server-Sent Events
+// stream, reconnecting with the ID of the last event received when the stream
+// ends.
+type SSE struct {
+	conf   SSEConfig
+	client *http.Client
+
+	connMut        sync.Mutex
+	body           io.ReadCloser
+	events         *bufio.Reader
+	cancelFn       func()
+	disconnectedAt time.Time
+	closed         bool
+	lastEventID    string
+	reconnectDelay time.Duration
+
+	log   log.Modular
+	stats metrics.Type
+
+	mRetryHint metrics.StatCounter
+}
+
+// NewSSE creates a new SSE input type.
+func NewSSE(conf SSEConfig, log log.Modular, stats metrics.Type) (*SSE, error) {
+	s := &SSE{
+		conf:        conf,
+		client:      &http.Client{},
+		lastEventID: conf.LastEventID,
+		log:         log,
+		stats:       stats,
+		mRetryHint:  stats.GetCounter("retry_hint"),
+	}
+
+	if len(conf.URL) == 0 {
+		return nil, fmt.Errorf("a url must be specified")
+	}
+
+	var err error
+	if s.reconnectDelay, err = time.ParseDuration(conf.ReconnectDelay); err != nil {
+		return nil, fmt.Errorf("failed to parse reconnect delay: %v", err)
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	}
+	if len(conf.Timeout) > 0 {
+		if transport.ResponseHeaderTimeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %v", err)
+		}
+	}
+	if conf.TLS.Enabled {
+		if transport.TLSClientConfig, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+	s.client.Transport = transport
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a connection to the event stream.
+func (s *SSE) Connect() error {
+	return s.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext establishes a connection to the event stream, waiting
+// for the reconnection delay when a previous connection has ended.
+func (s *SSE) ConnectWithContext(ctx context.Context) error {
+	s.connMut.Lock()
+	defer s.connMut.Unlock()
+
+	if s.closed {
+		return types.ErrTypeClosed
+	}
+	if s.body != nil {
+		return nil
+	}
+
+	if !s.disconnectedAt.IsZero() {
+		select {
+		case <-time.After(time.Until(s.disconnectedAt.Add(s.reconnectDelay))):
+		case <-ctx.Done():
+			return types.ErrTimeout
+		}
+	}
+
+	reqCtx, cancelFn := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(reqCtx, "GET", s.conf.URL, nil)
+	if err != nil {
+		cancelFn()
+		return err
+	}
+	for k, v := range s.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if len(s.lastEventID) > 0 {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+	if err = s.conf.Sign(req); err != nil {
+		cancelFn()
+		return err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		cancelFn()
+		s.disconnectedAt = time.Now()
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		cancelFn()
+		s.disconnectedAt = time.Now()
+		return types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
+	}
+	if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		res.Body.Close()
+		cancelFn()
+		s.disconnectedAt = time.Now()
+		return fmt.Errorf("unexpected content type: %v", res.Header.Get("Content-Type"))
+	}
+
+	s.body = res.Body
+	s.events = bufio.NewReader(res.Body)
+	s.cancelFn = cancelFn
+
+	s.log.Infof("Receiving Server-Sent Events from: %v\n", s.conf.URL)
+	return nil
+}
+
+func (s *SSE) disconnect() {
+	s.connMut.Lock()
+	defer s.connMut.Unlock()
+
+	if s.body != nil {
+		s.body.Close()
+		s.body = nil
+		s.events = nil
+	}
+	if s.cancelFn != nil {
+		s.cancelFn()
+		s.cancelFn = nil
+	}
+	s.disconnectedAt = time.Now()
+}
+
+//------------------------------------------------------------------------------
+
+// ReadWithContext attempts to read the next event of the stream.
+func (s *SSE) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	s.connMut.Lock()
+	events := s.events
+	s.connMut.Unlock()
+
+	if events == nil {
+		return nil, nil, types.ErrNotConnected
+	}
+
+	var eventType string
+	var data strings.Builder
+	for {
+		line, err := events.ReadString('\n')
+		if err != nil {
+			// Events that are incomplete when the stream ends are discarded.
+			s.disconnect()
+			return nil, nil, types.ErrNotConnected
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if len(line) == 0 {
+			if data.Len() == 0 {
+				eventType = ""
+				continue
+			}
+			break
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.connMut.Lock()
+				s.lastEventID = value
+				s.connMut.Unlock()
+			}
+		case "retry":
+			if millis, err := strconv.ParseUint(value, 10, 64); err == nil {
+				s.mRetryHint.Incr(1)
+				s.connMut.Lock()
+				s.reconnectDelay = time.Duration(millis) * time.Millisecond
+				s.connMut.Unlock()
+			}
+		}
+	}
+
+	if len(eventType) == 0 {
+		eventType = "message"
+	}
+	s.connMut.Lock()
+	eventID := s.lastEventID
+	s.connMut.Unlock()
+
+	part := message.NewPart([]byte(strings.TrimSuffix(data.String(), "\n")))
+	part.Metadata().Set("sse_event", eventType)
+	if len(eventID) > 0 {
+		part.Metadata().Set("sse_id", eventID)
+	}
+
+	msg := message.New(nil)
+	msg.Append(part)
+	return msg, noopAsyncAckFn, nil
+}
+
+// CloseAsync shuts down the SSE input and stops reading events.
+func (s *SSE) CloseAsync() {
+	s.connMut.Lock()
+	s.closed = true
+	if s.cancelFn != nil {
+		s.cancelFn()
+	}
+	s.connMut.Unlock()
+}
+
+// WaitForClose blocks until the SSE input has closed down.
+func (s *SSE) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,155 @@
+package reader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEConfigErrors(t *testing.T) {
+	conf := NewSSEConfig()
+	conf.URL = ""
+	_, err := NewSSE(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf = NewSSEConfig()
+	conf.ReconnectDelay = "nope"
+	_, err = NewSSE(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf = NewSSEConfig()
+	conf.Timeout = "nope"
+	_, err = NewSSE(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestSSEEvents(t *testing.T) {
+	var lastIDsMut sync.Mutex
+	var lastIDs []string
+
+	var connections int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		assert.Equal(t, "bar", r.Header.Get("X-Foo"))
+
+		lastIDsMut.Lock()
+		lastIDs = append(lastIDs, r.Header.Get("Last-Event-ID"))
+		connections++
+		conn := connections
+		lastIDsMut.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		if conn == 1 {
+			fmt.Fprint(w, ": a comment\n\n")
+			fmt.Fprint(w, "retry: 10\n")
+			fmt.Fprint(w, "data: first\n\n")
+			fmt.Fprint(w, "event: update\r\nid: 1\r\ndata: second\r\ndata:  line\r\n\r\n")
+			fmt.Fprint(w, "id: 2\n\n")
+			fmt.Fprint(w, "data: incomplete")
+			return
+		}
+		fmt.Fprint(w, "data:third\n\n")
+	}))
+	defer server.Close()
+
+	conf := NewSSEConfig()
+	conf.URL = server.URL
+	conf.Headers = map[string]string{"X-Foo": "bar"}
+	conf.LastEventID = "0"
+	conf.ReconnectDelay = "1h"
+
+	s, err := NewSSE(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	type event struct {
+		data, name, id string
+	}
+	var events []event
+	for len(events) < 3 {
+		require.NoError(t, s.ConnectWithContext(ctx))
+		msg, _, err := s.ReadWithContext(ctx)
+		if err == types.ErrNotConnected {
+			continue
+		}
+		require.NoError(t, err)
+		meta := msg.Get(0).Metadata()
+		events = append(events, event{
+			data: string(msg.Get(0).Get()),
+			name: meta.Get("sse_event"),
+			id:   meta.Get("sse_id"),
+		})
+	}
+
+	assert.Equal(t, []event{
+		{data: "first", name: "message", id: "0"},
+		{data: "second\n line", name: "update", id: "1"},
+		{data: "third", name: "message", id: "2"},
+	}, events)
+
+	lastIDsMut.Lock()
+	assert.Equal(t, []string{"0", "2"}, lastIDs)
+	lastIDsMut.Unlock()
+
+	s.CloseAsync()
+	assert.Equal(t, types.ErrTypeClosed, s.ConnectWithContext(ctx))
+	require.NoError(t, s.WaitForClose(time.Second))
+}
+
+func TestSSEBadResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+			return
+		}
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	for _, path := range []string{"/status", "/json"} {
+		conf := NewSSEConfig()
+		conf.URL = server.URL + path
+
+		s, err := NewSSE(conf, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+		assert.Error(t, s.Connect(), path)
+	}
+}
+
+func TestSSEClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	conf := NewSSEConfig()
+	conf.URL = server.URL
+
+	s, err := NewSSE(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, s.Connect())
+
+	go func() {
+		<-time.After(time.Millisecond * 50)
+		s.CloseAsync()
+	}()
+
+	_, _, err = s.ReadWithContext(context.Background())
+	assert.Equal(t, types.ErrNotConnected, err)
+	assert.Equal(t, types.ErrTypeClosed, s.Connect())
+}
@@ -0,0 +1,181 @@
+package reader
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// SubprocessConfig contains configuration fields for the Subprocess input
+// type.
+type SubprocessConfig struct {
+	Name          string   `json:"name" yaml:"name"`
+	Args          []string `json:"args" yaml:"args"`
+	Codec         string   `json:"codec" yaml:"codec"`
+	RestartOnExit bool     `json:"restart_on_exit" yaml:"restart_on_exit"`
+	MaxBuffer     int      `json:"max_buffer" yaml:"max_buffer"`
+}
+
+// NewSubprocessConfig creates a new SubprocessConfig with default values.
+func NewSubprocessConfig() SubprocessConfig {
+	return SubprocessConfig{
+		Name:          "",
+		Args:          []string{},
+		Codec:         "lines",
+		RestartOnExit: false,
+		MaxBuffer:     bufio.MaxScanTokenSize,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Subprocess is an input type that runs a command and reads messages from its
+// stdout stream.
+type Subprocess struct {
+	conf SubprocessConfig
+
+	stream *CodecStream
+
+	cmdMut  sync.Mutex
+	cmd     *exec.Cmd
+	started bool
+	closed  bool
+
+	closedChan chan struct{}
+
+	mRestart metrics.StatCounter
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewSubprocess creates a new Subprocess input type.
+func NewSubprocess(conf SubprocessConfig, log log.Modular, stats metrics.Type) (*Subprocess, error) {
+	if len(conf.Name) == 0 {
+		return nil, errors.New("a command name must be provided")
+	}
+	s := &Subprocess{
+		conf:       conf,
+		closedChan: make(chan struct{}),
+		mRestart:   stats.GetCounter("restart"),
+		log:        log,
+		stats:      stats,
+	}
+	var err error
+	if s.stream, err = NewCodecStream(conf.Codec, conf.MaxBuffer, s.start, s.stop); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// reap waits for the current command to exit, which must be called with the
+// command mutex held.
+func (s *Subprocess) reap() {
+	if s.cmd == nil {
+		return
+	}
+	if err := s.cmd.Wait(); err != nil {
+		s.log.Warnf("Subprocess exited: %v\n", err)
+	} else {
+		s.log.Infof("Subprocess exited\n")
+	}
+	s.cmd = nil
+}
+
+// start runs the command, or reruns it once it has exited when the restart on
+// exit policy is enabled, and returns its stdout stream.
+func (s *Subprocess) start(ctx context.Context) (io.Reader, error) {
+	s.cmdMut.Lock()
+	defer s.cmdMut.Unlock()
+
+	s.reap()
+	if s.closed || (s.started && !s.conf.RestartOnExit) {
+		return nil, io.EOF
+	}
+
+	cmd := exec.Command(s.conf.Name, s.conf.Args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	if s.started {
+		s.mRestart.Incr(1)
+	}
+	s.started = true
+	s.cmd = cmd
+
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			s.log.Warnf("Subprocess stderr: %s\n", scanner.Bytes())
+		}
+	}()
+
+	s.log.Infof("Reading messages from the stdout of subprocess: %v\n", s.conf.Name)
+	return stdout, nil
+}
+
+// stop kills the command if it is still running.
+func (s *Subprocess) stop(ctx context.Context) {
+	s.cmdMut.Lock()
+	defer s.cmdMut.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if s.cmd != nil {
+		s.cmd.Process.Kill()
+	}
+	s.reap()
+	close(s.closedChan)
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext starts the command.
+func (s *Subprocess) ConnectWithContext(ctx context.Context) error {
+	return s.stream.ConnectWithContext(ctx)
+}
+
+// ReadWithContext attempts to read a new message from the stdout of the
+// command.
+func (s *Subprocess) ReadWithContext(ctx context.Context) (types.Message, AsyncAckFn, error) {
+	return s.stream.ReadWithContext(ctx)
+}
+
+// CloseAsync shuts down the input and stops the command.
+func (s *Subprocess) CloseAsync() {
+	s.stream.CloseAsync()
+}
+
+// WaitForClose blocks until the input has closed down.
+func (s *Subprocess) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-s.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
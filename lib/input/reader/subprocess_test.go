@@ -0,0 +1,99 @@
+package reader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubprocessConfigErrors(t *testing.T) {
+	conf := NewSubprocessConfig()
+	_, err := NewSubprocess(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Name = "cat"
+	conf.Codec = "nope"
+	_, err = NewSubprocess(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func readSubprocessMessages(t *testing.T, s *Subprocess, n int) []string {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	var msgs []string
+	for len(msgs) < n {
+		msg, ackFn, err := s.ReadWithContext(ctx)
+		if err == types.ErrNotConnected {
+			require.NoError(t, s.ConnectWithContext(ctx))
+			continue
+		}
+		require.NoError(t, err)
+		msgs = append(msgs, string(msg.Get(0).Get()))
+		require.NoError(t, ackFn(ctx, response.NewAck()))
+	}
+	return msgs
+}
+
+func TestSubprocessExit(t *testing.T) {
+	conf := NewSubprocessConfig()
+	conf.Name = "sh"
+	conf.Args = []string{"-c", "printf 'foo\\nbar\\n'; echo 'nope' >&2"}
+
+	s, err := NewSubprocess(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, s.ConnectWithContext(context.Background()))
+
+	assert.Equal(t, []string{"foo", "bar"}, readSubprocessMessages(t, s, 2))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	_, _, err = s.ReadWithContext(ctx)
+	assert.Equal(t, types.ErrNotConnected, err)
+	assert.Equal(t, types.ErrTypeClosed, s.ConnectWithContext(ctx))
+
+	s.CloseAsync()
+	assert.NoError(t, s.WaitForClose(time.Second))
+}
+
+func TestSubprocessRestartOnExit(t *testing.T) {
+	conf := NewSubprocessConfig()
+	conf.Name = "sh"
+	conf.Args = []string{"-c", "printf 'foo|bar|'"}
+	conf.Codec = "delim:|"
+	conf.RestartOnExit = true
+
+	s, err := NewSubprocess(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, s.ConnectWithContext(context.Background()))
+
+	assert.Equal(t, []string{"foo", "bar", "foo", "bar"}, readSubprocessMessages(t, s, 4))
+
+	s.CloseAsync()
+	assert.NoError(t, s.WaitForClose(time.Second))
+}
+
+func TestSubprocessCloseRunning(t *testing.T) {
+	conf := NewSubprocessConfig()
+	conf.Name = "sh"
+	conf.Args = []string{"-c", "echo foo; sleep 10"}
+
+	s, err := NewSubprocess(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, s.ConnectWithContext(context.Background()))
+
+	assert.Equal(t, []string{"foo"}, readSubprocessMessages(t, s, 1))
+
+	s.CloseAsync()
+	assert.NoError(t, s.WaitForClose(time.Second*2))
+}
@@ -2,34 +2,77 @@ package reader
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/checkpoint"
 	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
+	"github.com/cenkalti/backoff/v4"
 	"github.com/gorilla/websocket"
+	"golang.org/x/oauth2"
 )
 
 //------------------------------------------------------------------------------
 
+// WebsocketCursorConfig contains configuration fields for tracking the
+// position of a Websocket input within a stream.
+type WebsocketCursorConfig struct {
+	Value   string `json:"value" yaml:"value"`
+	Initial string `json:"initial" yaml:"initial"`
+	Cache   string `json:"cache" yaml:"cache"`
+	Key     string `json:"key" yaml:"key"`
+}
+
+// NewWebsocketCursorConfig creates a new WebsocketCursorConfig with default
+// values.
+func NewWebsocketCursorConfig() WebsocketCursorConfig {
+	return WebsocketCursorConfig{
+		Value:   "",
+		Initial: "",
+		Cache:   "",
+		Key:     "websocket_cursor",
+	}
+}
+
 // WebsocketConfig contains configuration fields for the Websocket input type.
 type WebsocketConfig struct {
-	URL         string `json:"url" yaml:"url"`
-	OpenMsg     string `json:"open_message" yaml:"open_message"`
-	auth.Config `json:",inline" yaml:",inline"`
+	URL          string                `json:"url" yaml:"url"`
+	OpenMsg      string                `json:"open_message" yaml:"open_message"`
+	OpenMsgType  string                `json:"open_message_type" yaml:"open_message_type"`
+	Headers      map[string]string     `json:"headers" yaml:"headers"`
+	Subprotocols []string              `json:"subprotocols" yaml:"subprotocols"`
+	Cursor       WebsocketCursorConfig `json:"cursor" yaml:"cursor"`
+	Reconnect    retries.Config        `json:"reconnect" yaml:"reconnect"`
+	OAuth2       auth.OAuth2Config     `json:"oauth2" yaml:"oauth2"`
+	auth.Config  `json:",inline" yaml:",inline"`
 }
 
 // NewWebsocketConfig creates a new WebsocketConfig with default values.
 func NewWebsocketConfig() WebsocketConfig {
+	rConf := retries.NewConfig()
+	rConf.Backoff.InitialInterval = "1s"
+	rConf.Backoff.MaxInterval = "30s"
 	return WebsocketConfig{
-		URL:     "ws://localhost:4195/get/ws",
-		OpenMsg: "",
-		Config:  auth.NewConfig(),
+		URL:          "ws://localhost:4195/get/ws",
+		OpenMsg:      "",
+		OpenMsgType:  "binary",
+		Headers:      map[string]string{},
+		Subprotocols: []string{},
+		Cursor:       NewWebsocketCursorConfig(),
+		Reconnect:    rConf,
+		OAuth2:       auth.NewOAuth2Config(),
+		Config:       auth.NewConfig(),
 	}
 }
 
@@ -42,21 +85,99 @@ type Websocket struct {
 
 	lock *sync.Mutex
 
-	conf   WebsocketConfig
-	client *websocket.Conn
+	conf        WebsocketConfig
+	client      *websocket.Conn
+	openMsg     field.Expression
+	openMsgType int
+	tokens      oauth2.TokenSource
+	boffCtor    func() backoff.BackOff
+
+	cursor    field.Expression
+	cache     types.Cache
+	cp        *checkpoint.Type
+	cpMut     sync.Mutex
+	index     int
+	pruned    int
+	positions map[int]string
+	position  string
+	committed string
+	commitMut sync.Mutex
+
+	connectAttempted bool
+
+	mReconnectAttempt metrics.StatCounter
+	mReconnectErr     metrics.StatCounter
 }
 
 // NewWebsocket creates a new Websocket input type.
+//
+// Deprecated: Use NewWebsocketWithManager instead, which is able to access the
+// cache resource of a cursor.
 func NewWebsocket(
 	conf WebsocketConfig,
 	log log.Modular,
 	stats metrics.Type,
+) (*Websocket, error) {
+	return NewWebsocketWithManager(conf, types.NoopMgr(), log, stats)
+}
+
+// NewWebsocketWithManager creates a new Websocket input type with access to
+// the resources of a manager.
+func NewWebsocketWithManager(
+	conf WebsocketConfig,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
 ) (*Websocket, error) {
 	ws := &Websocket{
-		log:   log,
-		stats: stats,
-		lock:  &sync.Mutex{},
-		conf:  conf,
+		log:       log,
+		stats:     stats,
+		lock:      &sync.Mutex{},
+		conf:      conf,
+		cp:        checkpoint.New(0),
+		positions: map[int]string{},
+		position:  conf.Cursor.Initial,
+
+		mReconnectAttempt: stats.GetCounter("reconnect.attempt"),
+		mReconnectErr:     stats.GetCounter("reconnect.error"),
+	}
+
+	var err error
+	if ws.openMsg, err = bloblang.NewField(conf.OpenMsg); err != nil {
+		return nil, fmt.Errorf("failed to parse open message expression: %v", err)
+	}
+	switch conf.OpenMsgType {
+	case "binary", "":
+		ws.openMsgType = websocket.BinaryMessage
+	case "text":
+		ws.openMsgType = websocket.TextMessage
+	default:
+		return nil, fmt.Errorf("open message type not recognised: %v", conf.OpenMsgType)
+	}
+	if ws.boffCtor, err = conf.Reconnect.GetCtor(); err != nil {
+		return nil, err
+	}
+	if conf.OAuth2.Enabled {
+		if len(conf.OAuth2.TokenURL) == 0 {
+			return nil, errors.New("an oauth2 token_url must be specified")
+		}
+		ws.tokens = conf.OAuth2.TokenSource(nil)
+	}
+	if len(conf.Cursor.Value) > 0 {
+		if ws.cursor, err = bloblang.NewField(conf.Cursor.Value); err != nil {
+			return nil, fmt.Errorf("failed to parse cursor expression: %v", err)
+		}
+	}
+	if len(conf.Cursor.Cache) > 0 {
+		if ws.cursor == nil {
+			return nil, errors.New("a cursor value must be specified in order to store it in a cache")
+		}
+		if len(conf.Cursor.Key) == 0 {
+			return nil, errors.New("a cursor key must be specified")
+		}
+		if ws.cache, err = mgr.GetCache(conf.Cursor.Cache); err != nil {
+			return nil, fmt.Errorf("failed to obtain cache '%v': %v", conf.Cursor.Cache, err)
+		}
 	}
 	return ws, nil
 }
@@ -77,7 +198,8 @@ func (w *Websocket) Connect() error {
 	return w.ConnectWithContext(context.Background())
 }
 
-// ConnectWithContext establishes a connection to a Websocket server.
+// ConnectWithContext establishes a connection to a Websocket server, retrying
+// with a backoff until it succeeds or the retries are exhausted.
 func (w *Websocket) ConnectWithContext(ctx context.Context) error {
 	w.lock.Lock()
 	defer w.lock.Unlock()
@@ -86,34 +208,149 @@ func (w *Websocket) ConnectWithContext(ctx context.Context) error {
 		return nil
 	}
 
+	boff := w.boffCtor()
+	for {
+		// Every attempt other than the very first is counted as a reconnect.
+		isReconnect := w.connectAttempted
+		w.connectAttempted = true
+		if isReconnect {
+			w.mReconnectAttempt.Incr(1)
+		}
+
+		client, err := w.dial(ctx)
+		if err == nil {
+			w.client = client
+			return nil
+		}
+		if isReconnect {
+			w.mReconnectErr.Incr(1)
+		}
+
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			w.log.Errorf("Giving up on connecting to websocket server after %v retries: %v\n", w.conf.Reconnect.MaxRetries, err)
+			return err
+		}
+		w.log.Warnf("Failed to connect to websocket server, retrying in %v: %v\n", wait, err)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func (w *Websocket) dial(ctx context.Context) (*websocket.Conn, error) {
 	headers := http.Header{}
+	for k, v := range w.conf.Headers {
+		headers.Set(k, v)
+	}
 
 	purl, err := url.Parse(w.conf.URL)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err = w.conf.Sign(&http.Request{
 		URL:    purl,
 		Header: headers,
 	}); err != nil {
-		return err
+		return nil, err
+	}
+	if w.tokens != nil {
+		token, err := w.tokens.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain oauth2 token: %v", err)
+		}
+		token.SetAuthHeader(&http.Request{Header: headers})
 	}
 
-	var client *websocket.Conn
-	if client, _, err = websocket.DefaultDialer.Dial(w.conf.URL, headers); err != nil {
-		return err
+	dialer := *websocket.DefaultDialer
+	dialer.Subprotocols = w.conf.Subprotocols
+
+	client, _, err := dialer.DialContext(ctx, w.conf.URL, headers)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(w.conf.OpenMsg) > 0 {
-		if err = client.WriteMessage(
-			websocket.BinaryMessage, []byte(w.conf.OpenMsg),
-		); err != nil {
-			return err
+	if openMsg := w.openMsg.Bytes(0, w.openMsgContext()); len(openMsg) > 0 {
+		if err = client.WriteMessage(w.openMsgType, openMsg); err != nil {
+			client.Close()
+			return nil, err
 		}
 	}
+	return client, nil
+}
 
-	w.client = client
+// openMsgContext returns a message that open messages are interpolated with,
+// which contains the latest cursor as the metadata field websocket_cursor.
+func (w *Websocket) openMsgContext() types.Message {
+	msg := message.New([][]byte{nil})
+	if w.cursor == nil {
+		return msg
+	}
+
+	w.cpMut.Lock()
+	cursor := w.position
+	w.cpMut.Unlock()
+
+	if w.cache != nil && cursor == w.conf.Cursor.Initial {
+		if data, err := w.cache.Get(w.conf.Cursor.Key); err == nil {
+			cursor = string(data)
+		} else if err != types.ErrKeyNotFound {
+			w.log.Errorf("Failed to read cursor from cache: %v\n", err)
+		}
+	}
+	if len(cursor) > 0 {
+		msg.Get(0).Metadata().Set("websocket_cursor", cursor)
+	}
+	return msg
+}
+
+//------------------------------------------------------------------------------
+
+// track registers a message as pending acknowledgement along with the cursor
+// it results in, and returns its index.
+func (w *Websocket) track(cursor string) int {
+	w.cpMut.Lock()
+	defer w.cpMut.Unlock()
+
+	w.index++
+	w.cp.MustTrack(w.index)
+	if len(cursor) > 0 {
+		w.positions[w.index] = cursor
+	}
+	return w.index
+}
+
+// resolve marks a message as acknowledged and commits the cursor to the cache
+// when it has progressed.
+func (w *Websocket) resolve(index int) error {
+	w.cpMut.Lock()
+	highest := w.cp.MustResolve(index)
+	for ; w.pruned < highest; w.pruned++ {
+		if cursor, exists := w.positions[w.pruned+1]; exists {
+			w.position = cursor
+			delete(w.positions, w.pruned+1)
+		}
+	}
+	cursor := w.position
+	w.cpMut.Unlock()
+
+	if w.cache == nil {
+		return nil
+	}
+
+	w.commitMut.Lock()
+	defer w.commitMut.Unlock()
+	if cursor == "" || cursor == w.committed {
+		return nil
+	}
+	if err := w.cache.Set(w.conf.Cursor.Key, []byte(cursor)); err != nil {
+		return fmt.Errorf("failed to write cursor: %w", err)
+	}
+	w.committed = cursor
 	return nil
 }
 
@@ -141,7 +378,22 @@ func (w *Websocket) ReadWithContext(ctx context.Context) (types.Message, AsyncAc
 		return nil, nil, err
 	}
 
-	return message.New([][]byte{data}), noopAsyncAckFn, nil
+	msg := message.New([][]byte{data})
+	if w.cursor == nil {
+		return msg, noopAsyncAckFn, nil
+	}
+
+	cursor := w.cursor.String(0, msg)
+	if len(cursor) > 0 {
+		msg.Get(0).Metadata().Set("websocket_cursor", cursor)
+	}
+	index := w.track(cursor)
+	return msg, func(ctx context.Context, res types.Response) error {
+		if res.Error() != nil {
+			return nil
+		}
+		return w.resolve(index)
+	}, nil
 }
 
 // Acknowledge instructs whether the pending messages were propagated
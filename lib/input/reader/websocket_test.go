@@ -1,18 +1,26 @@
 package reader
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/lib/cache"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWebsocketBasic(t *testing.T) {
@@ -193,3 +201,185 @@ func TestWebsocketClose(t *testing.T) {
 	wg.Wait()
 	close(closeChan)
 }
+
+func TestWebsocketConfigErrors(t *testing.T) {
+	conf := NewWebsocketConfig()
+	conf.OpenMsgType = "nope"
+	_, err := NewWebsocketWithManager(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf = NewWebsocketConfig()
+	conf.Cursor.Cache = "foocache"
+	_, err = NewWebsocketWithManager(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Cursor.Value = `${! json("id") }`
+	_, err = NewWebsocketWithManager(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf = NewWebsocketConfig()
+	conf.OAuth2.Enabled = true
+	_, err = NewWebsocketWithManager(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf = NewWebsocketConfig()
+	conf.Reconnect.Backoff.MaxInterval = "nope"
+	_, err = NewWebsocketWithManager(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestWebsocketHandshake(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"footoken","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer footoken", r.Header.Get("Authorization"))
+		assert.Equal(t, "bar", r.Header.Get("X-Foo"))
+
+		upgrader := websocket.Upgrader{Subprotocols: []string{"bar.v1"}}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+
+		assert.Equal(t, "bar.v1", ws.Subprotocol())
+
+		msgType, data, err := ws.ReadMessage()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, websocket.TextMessage, msgType)
+		assert.Equal(t, "hello", string(data))
+		assert.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte("world")))
+	}))
+	defer server.Close()
+
+	conf := NewWebsocketConfig()
+	conf.URL = "ws" + strings.TrimPrefix(server.URL, "http")
+	conf.OpenMsg = "hello"
+	conf.OpenMsgType = "text"
+	conf.Headers = map[string]string{"X-Foo": "bar"}
+	conf.Subprotocols = []string{"foo.v1", "bar.v1"}
+	conf.OAuth2.Enabled = true
+	conf.OAuth2.TokenURL = tokenServer.URL
+
+	m, err := NewWebsocketWithManager(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, m.Connect())
+
+	msg, err := m.Read()
+	require.NoError(t, err)
+	assert.Equal(t, "world", string(msg.Get(0).Get()))
+
+	m.CloseAsync()
+	require.NoError(t, m.WaitForClose(time.Second))
+}
+
+func TestWebsocketReconnectCursor(t *testing.T) {
+	var openMsgsMut sync.Mutex
+	var openMsgs []string
+
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reject the first handshake in order to exercise retries.
+		if atomic.AddInt32(&connections, 1) == 1 {
+			http.Error(w, "nope", http.StatusServiceUnavailable)
+			return
+		}
+
+		upgrader := websocket.Upgrader{}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		openMsgsMut.Lock()
+		openMsgs = append(openMsgs, string(data))
+		openMsgsMut.Unlock()
+
+		var after int
+		fmt.Sscanf(string(data), "after %d", &after)
+		for i := after + 1; i <= after+3; i++ {
+			ws.WriteMessage(websocket.BinaryMessage, []byte(fmt.Sprintf(`{"id":"%v"}`, i)))
+		}
+	}))
+	defer server.Close()
+
+	memCache, err := cache.NewMemory(cache.NewConfig(), types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	mgr := &fakeCacheMgr{caches: map[string]types.Cache{"foocache": memCache}}
+
+	conf := NewWebsocketConfig()
+	conf.URL = "ws" + strings.TrimPrefix(server.URL, "http")
+	conf.OpenMsg = `after ${! meta("websocket_cursor").or("0") }`
+	conf.Cursor.Value = `${! json("id") }`
+	conf.Cursor.Cache = "foocache"
+	conf.Reconnect.Backoff.InitialInterval = "1ms"
+	conf.Reconnect.Backoff.MaxInterval = "10ms"
+
+	m, err := NewWebsocketWithManager(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	readIDs := func(n int, ack bool) []string {
+		var ids []string
+		var ackFns []AsyncAckFn
+		for len(ids) < n {
+			require.NoError(t, m.ConnectWithContext(ctx))
+			msg, ackFn, err := m.ReadWithContext(ctx)
+			if err == types.ErrNotConnected {
+				continue
+			}
+			require.NoError(t, err)
+			ids = append(ids, msg.Get(0).Metadata().Get("websocket_cursor"))
+			ackFns = append(ackFns, ackFn)
+		}
+		// Acknowledge out of order, the cursor only progresses once all prior
+		// messages are acknowledged.
+		if ack {
+			for i := len(ackFns) - 1; i >= 0; i-- {
+				require.NoError(t, ackFns[i](ctx, response.NewAck()))
+			}
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"1", "2"}, readIDs(2, true))
+	assert.Equal(t, []string{"3"}, readIDs(1, true))
+
+	// The server closes the connection after three messages, and the new
+	// connection resumes from the last acknowledged cursor.
+	assert.Equal(t, []string{"4", "5"}, readIDs(2, true))
+	assert.Equal(t, []string{"6"}, readIDs(1, false))
+
+	// Message 6 is yet to be acknowledged and so the cursor remains at 5.
+	cursor, err := memCache.Get("websocket_cursor")
+	require.NoError(t, err)
+	assert.Equal(t, "5", string(cursor))
+
+	m.CloseAsync()
+	require.NoError(t, m.WaitForClose(time.Second))
+
+	// A new input resumes from the cursor stored within the cache.
+	m, err = NewWebsocketWithManager(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"6"}, readIDs(1, false))
+
+	m.CloseAsync()
+	require.NoError(t, m.WaitForClose(time.Second))
+
+	openMsgsMut.Lock()
+	assert.Equal(t, []string{"after 0", "after 3", "after 5"}, openMsgs)
+	openMsgsMut.Unlock()
+}
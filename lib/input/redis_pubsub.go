@@ -6,6 +6,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	bredis "github.com/Jeffail/benthos/v3/lib/util/redis"
 )
 
 //------------------------------------------------------------------------------
@@ -26,12 +27,42 @@ patterns in your channel names. For example:
 - ` + "`h[ae]llo`" + ` subscribes to hello and hallo, but not hillo
 
 Use ` + "`\\`" + ` to escape special characters if you want to match them
-verbatim.`,
-		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("url", "The URL of a Redis server to connect to.", "tcp://localhost:6379"),
+verbatim.
+
+### Clusters
+
+With the field ` + "`kind`" + ` set to ` + "`cluster`" + ` the topology of a Redis
+Cluster is discovered from the nodes listed in ` + "`url`" + `, and with the
+` + "`kind`" + ` ` + "`failover`" + ` the current master of the group
+` + "`master`" + ` is obtained from the sentinels listed in ` + "`url`" + `.
+
+Messages published with PUBLISH are propagated to every node of a cluster, but
+events such as [keyspace notifications](https://redis.io/topics/notifications)
+are only emitted by the node that owns a key. In order to consume those set
+` + "`subscribe_all_masters`" + ` to ` + "`true`" + `, which subscribes to the
+channels on every master node of the cluster as it is known when connecting.
+Note that with this option messages published with PUBLISH are received once
+for each master.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- redis_pubsub_channel
+- redis_pubsub_pattern
+` + "```" + `
+
+Where ` + "`redis_pubsub_pattern`" + ` is only set when ` + "`use_patterns`" + `
+is ` + "`true`" + `.
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		FieldSpecs: bredis.ConfigDocs().Merge(docs.FieldSpecs{
 			docs.FieldCommon("channels", "A list of channels to consume from."),
 			docs.FieldCommon("use_patterns", "Whether to use the PSUBSCRIBE command."),
-		},
+			docs.FieldAdvanced("subscribe_all_masters", "Whether to subscribe to the channels on every master node of a cluster, which is required in order to consume events local to a node such as keyspace notifications. Requires the `kind` `cluster`."),
+		}),
 		Categories: []Category{
 			CategoryServices,
 		},
@@ -19,7 +19,22 @@ Pulls messages from Redis (v5.0+) streams with the XREADGROUP command. The
 		Description: `
 Redis stream entries are key/value pairs, as such it is necessary to specify the
 key that contains the body of the message. All other keys/value pairs are saved
-as metadata fields.`,
+as metadata fields.
+
+### Auto Claim
+
+Entries that have been delivered to a consumer of the group but never
+acknowledged, for example because the consumer died, remain pending within the
+group until they are claimed. When ` + "`auto_claim.enabled`" + ` is set to
+` + "`true`" + ` this input periodically takes ownership of entries that have been
+pending for longer than ` + "`auto_claim.min_idle`" + ` using the XAUTOCLAIM
+command, which requires Redis v6.2+. This includes entries pending for this
+consumer, and therefore ` + "`min_idle`" + ` should be comfortably longer than it
+takes to process a message.
+
+When ` + "`auto_claim.drain_on_start`" + ` is also set to ` + "`true`" + ` the
+input consumes the entries pending for this consumer and claims the pending
+entries of the group before reading any new entries.`,
 		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
 			return sanitiseWithBatch(conf.RedisStreams, conf.RedisStreams.Batching)
 		},
@@ -34,6 +49,12 @@ as metadata fields.`,
 			docs.FieldAdvanced("start_from_oldest", "If an offset is not found for a stream, determines whether to consume from the oldest available offset, otherwise messages are consumed from the latest offset."),
 			docs.FieldAdvanced("commit_period", "The period of time between each commit of the current offset. Offsets are always committed during shutdown."),
 			docs.FieldAdvanced("timeout", "The length of time to poll for new messages before reattempting."),
+			docs.FieldAdvanced("auto_claim", "Allows pending entries of other consumers of the group to be claimed after a period of inactivity.").WithChildren(
+				docs.FieldCommon("enabled", "Whether pending entries should be claimed."),
+				docs.FieldCommon("min_idle", "The minimum period of time that an entry must have been pending for before it is claimed."),
+				docs.FieldCommon("period", "The period of time between each attempt to claim pending entries."),
+				docs.FieldCommon("drain_on_start", "Whether to consume all pending entries of the group before reading new entries on start up."),
+			),
 		},
 		Categories: []Category{
 			CategoryServices,
@@ -19,9 +19,28 @@ Downloads objects within an Amazon S3 bucket, optionally filtered by a prefix.
 If an SQS queue has been configured then only object keys read from the queue
 will be downloaded.`,
 		Description: `
-If an SQS queue is not specified the entire list of objects found when this
-input starts will be consumed. Note that the prefix configuration is only used
-when downloading objects without SQS configured.
+If an SQS queue is not specified the objects of the bucket are listed and
+consumed in lexicographic order of their keys, one page of objects at a time, and
+once all objects have been consumed this input shuts down.
+
+### Scanning Buckets
+
+The objects consumed can be filtered with the fields ` + "`prefix`" + ` and
+` + "`glob`" + `. A glob pattern must match the entire key of an object, where
+` + "`*`" + ` matches any sequence of characters other than ` + "`/`" + `,
+` + "`**`" + ` matches any sequence of characters including ` + "`/`" + `,
+` + "`?`" + ` matches any single character other than ` + "`/`" + `, and
+` + "`[abc]`" + ` and ` + "`{foo,bar}`" + ` match any character or string of
+those listed. Only objects below the literal prefix of the pattern are listed.
+
+Since objects are listed in lexicographic order it's possible to process huge
+buckets incrementally. The field ` + "`start_after`" + ` skips all objects with
+keys that are lexicographically lower than or equal to it, and when a
+` + "`checkpoint_cache`" + ` is configured the key of the highest object of
+which it and all prior objects have been acknowledged is written to the cache.
+When the input is restarted the listing resumes after that key, which may
+result in objects being consumed more than once. Objects that are added to the
+bucket with keys lower than the checkpoint are not consumed.
 
 If your bucket is configured to send events directly to an SQS queue then you
 need to set the ` + "`sqs_body_path`" + ` field to a
@@ -50,6 +69,12 @@ at-least-once crash resiliency, but also means that if the S3 item takes longer
 to process than the visibility timeout of your queue then the same items might
 be processed multiple times.
 
+SQS messages that are test events sent by S3 when a bucket notification is
+configured are deleted from the queue, as are messages that do not contain any
+objects matching the ` + "`prefix`" + ` and ` + "`glob`" + ` fields. When
+` + "`delete_objects`" + ` is enabled each object is also deleted from the bucket
+once it has been successfully processed.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -78,7 +103,14 @@ You can access these metadata fields using
 		FieldSpecs: append(
 			append(docs.FieldSpecs{
 				docs.FieldCommon("bucket", "The bucket to consume from. If `sqs_bucket_path` is set this field is still required as a fallback."),
-				docs.FieldCommon("prefix", "An optional path prefix, if set only objects with the prefix are consumed. This field is ignored when SQS is used."),
+				docs.FieldCommon("prefix", "An optional path prefix, if set only objects with the prefix are consumed."),
+				docs.FieldCommon(
+					"glob", "An optional glob pattern, if set only objects with keys matching the pattern are consumed.",
+					"logs/*.json", "events/2020-*/**/*.gz", "data/{foo,bar}/*.csv",
+				),
+				docs.FieldAdvanced("start_after", "An optional object key to start listing objects after when there is no checkpoint to resume from. This field is ignored when SQS is used.", "data/2020-06-01/00000.json"),
+				docs.FieldAdvanced("checkpoint_cache", "The name of a [cache resource](/docs/components/caches/about) to store the key of the highest object acknowledged in. This field cannot be used with SQS."),
+				docs.FieldAdvanced("checkpoint_key", "The key to store the checkpoint under within the cache."),
 				docs.FieldCommon("sqs_url", "An optional SQS URL to connect to. When specified this queue will control which objects are downloaded from the target bucket."),
 				docs.FieldCommon("sqs_body_path", "A [dot path](/docs/configuration/field_paths) whereby object keys are found in SQS messages, this field is only required when an `sqs_url` is specified."),
 				docs.FieldCommon("sqs_bucket_path", "An optional [dot path](/docs/configuration/field_paths) whereby the bucket of an object can be found in consumed SQS messages."),
@@ -110,7 +142,7 @@ func NewAmazonS3(conf Config, mgr types.Manager, log log.Modular, stats metrics.
 	if conf.S3.MaxBatchCount > 1 {
 		log.Warnf("Field '%v.max_batch_count' is deprecated, use the batching methods outlined in https://benthos.dev/docs/configuration/batching instead.\n", conf.Type)
 	}
-	r, err := reader.NewAmazonS3(conf.S3, log, stats)
+	r, err := reader.NewAmazonS3WithManager(conf.S3, mgr, log, stats)
 	if err != nil {
 		return nil, err
 	}
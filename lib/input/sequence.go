@@ -7,10 +7,15 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/gabs/v2"
+	"github.com/OneOfOne/xxhash"
 )
 
 //------------------------------------------------------------------------------
@@ -24,7 +29,28 @@ Reads messages from a sequence of child inputs, starting with the first and once
 that input gracefully terminates starts consuming from the next, and so on.`,
 		Description: `
 This input is useful for consuming from inputs that have an explicit end but
-must not be consumed in parallel.`,
+must not be consumed in parallel.
+
+### Joining
+
+When ` + "`sharded_join.type`" + ` is set the messages of each input are not
+forwarded as they are read, but instead are joined with the messages of all
+other inputs in the sequence that share the same identifier, found at the path
+` + "`sharded_join.id_path`" + `. Messages must be structured (JSON or otherwise
+processed into a structured form), and messages that are not structured or are
+missing the identifier are dropped.
+
+Messages consumed from the inputs are acknowledged as soon as they are added to
+the join, and therefore any joined data that has not yet been flushed is lost if
+the service is stopped. Joined messages are flushed once the final input of the
+sequence has been exhausted (for a ` + "`full-outer`" + ` join), or as each
+message of the final input is read (for an ` + "`outer`" + ` join).
+
+In order to join datasets that are larger than the available memory the
+sequence can be consumed multiple times by setting
+` + "`sharded_join.iterations`" + `, where each iteration only captures the
+messages of a subset (shard) of the identifiers, and the joined messages of a
+shard are flushed before the next iteration begins.`,
 		Footnotes: `
 ## Examples
 
@@ -36,14 +62,39 @@ input:
     inputs:
       - csv:
           paths: [ ./dataset.csv ]
-      - bloblang:
+      - generate:
           count: 1
           mapping: 'root = {"status":"finished"}'
 ` + "```" + `
 
 With this config once the records within ` + "`./dataset.csv`" + ` are exhausted
 our final payload ` + "`" + `{"status":"finished"}` + "`" + ` will be routed
-through the pipeline.`,
+through the pipeline.
+
+Another use case is replaying a static dataset before switching to a live
+stream, and joining the records of the dataset onto the live messages that
+share the same identifier:
+
+` + "```yaml" + `
+input:
+  sequence:
+    sharded_join:
+      type: outer
+      id_path: user.id
+    inputs:
+      - file:
+          path: ./users.ndjson
+          codec: lines
+      - kafka:
+          addresses: [ localhost:9092 ]
+          topics: [ user_events ]
+          consumer_group: benthos_events
+` + "```" + `
+
+With this config each record within ` + "`./users.ndjson`" + ` is captured
+and once the file is exhausted each message consumed from Kafka is merged with
+the record that shares the same ` + "`user.id`" + ` before being routed through
+the pipeline.`,
 		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
 			inputsSanit := make([]interface{}, 0, len(conf.Sequence.Inputs))
 			for _, in := range conf.Sequence.Inputs {
@@ -54,10 +105,29 @@ through the pipeline.`,
 				inputsSanit = append(inputsSanit, sanit)
 			}
 			return map[string]interface{}{
-				"inputs": inputsSanit,
+				"sharded_join": conf.Sequence.ShardedJoin,
+				"inputs":       inputsSanit,
 			}, nil
 		},
 		FieldSpecs: docs.FieldSpecs{
+			docs.FieldAdvanced(
+				"sharded_join",
+				"Allows you to optionally join the messages of each input in the sequence that share a common identifier, see [joining](#joining) for more information.",
+			).WithChildren(
+				docs.FieldCommon(
+					"type", "The type of join to perform. A `full-outer` join ensures that all identifiers seen in any of the inputs are sent, and flushes the joined messages once all inputs have been consumed. An `outer` join only sends messages from the final input of the sequence, each joined with the data of the previous inputs that shares its identifier.",
+				).HasOptions("none", "full-outer", "outer"),
+				docs.FieldCommon(
+					"id_path", "A [dot path](/docs/configuration/field_paths) that points to a common field within the messages of each input that is used to join them. This field must be set in order to enable joins.",
+					"id", "user.id",
+				),
+				docs.FieldAdvanced(
+					"iterations", "The number of times to consume the sequence of inputs, where each iteration only joins the messages of a different shard of the identifiers. Increasing this number reduces the memory used, but increases the overall time taken as the inputs must be consumed and parsed once for each iteration.",
+				),
+				docs.FieldAdvanced(
+					"merge_strategy", "The strategy to use when joining messages results in two values for the same field. The strategy `array` places colliding non-array values into an array and merges colliding arrays, `replace` replaces the old value with the new value and `keep` keeps the old value.",
+				).HasOptions("array", "replace", "keep"),
+			),
 			docs.FieldCommon("inputs", "An array of inputs to read from sequentially."),
 		},
 		Categories: []Category{
@@ -68,29 +138,186 @@ through the pipeline.`,
 
 //------------------------------------------------------------------------------
 
+// SequenceShardedJoinConfig describes an optional mechanism for joining the
+// messages of a sequence of inputs that share a common identifier.
+type SequenceShardedJoinConfig struct {
+	Type          string `json:"type" yaml:"type"`
+	IDPath        string `json:"id_path" yaml:"id_path"`
+	Iterations    int    `json:"iterations" yaml:"iterations"`
+	MergeStrategy string `json:"merge_strategy" yaml:"merge_strategy"`
+}
+
+// NewSequenceShardedJoinConfig creates a new SequenceShardedJoinConfig with
+// default values.
+func NewSequenceShardedJoinConfig() SequenceShardedJoinConfig {
+	return SequenceShardedJoinConfig{
+		Type:          "none",
+		IDPath:        "",
+		Iterations:    1,
+		MergeStrategy: "array",
+	}
+}
+
 // SequenceConfig contains configuration values for the Sequence input type.
 type SequenceConfig struct {
-	Inputs []Config `json:"inputs" yaml:"inputs"`
+	ShardedJoin SequenceShardedJoinConfig `json:"sharded_join" yaml:"sharded_join"`
+	Inputs      []Config                  `json:"inputs" yaml:"inputs"`
 }
 
 // NewSequenceConfig creates a new SequenceConfig with default values.
 func NewSequenceConfig() SequenceConfig {
 	return SequenceConfig{
-		Inputs: []Config{},
+		ShardedJoin: NewSequenceShardedJoinConfig(),
+		Inputs:      []Config{},
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// messageJoiner accumulates structured messages that share an identifier by
+// merging them together.
+type messageJoiner struct {
+	idPath    string
+	collision func(dest, source interface{}) interface{}
+	messages  map[string]types.Part
+}
+
+func newMessageJoiner(conf SequenceShardedJoinConfig) (*messageJoiner, error) {
+	if len(conf.IDPath) == 0 {
+		return nil, errors.New("the id path must not be empty")
+	}
+	if conf.Iterations < 1 {
+		return nil, fmt.Errorf("iterations must be at least 1, got %v", conf.Iterations)
+	}
+
+	j := &messageJoiner{
+		idPath:   conf.IDPath,
+		messages: map[string]types.Part{},
+	}
+	switch conf.MergeStrategy {
+	case "array":
+		j.collision = func(dest, source interface{}) interface{} {
+			destArr, destIsArray := dest.([]interface{})
+			sourceArr, sourceIsArray := source.([]interface{})
+			if destIsArray {
+				if sourceIsArray {
+					return append(destArr, sourceArr...)
+				}
+				return append(destArr, source)
+			}
+			if sourceIsArray {
+				return append([]interface{}{dest}, sourceArr...)
+			}
+			return []interface{}{dest, source}
+		}
+	case "replace":
+		j.collision = func(dest, source interface{}) interface{} {
+			return source
+		}
+	case "keep":
+		j.collision = func(dest, source interface{}) interface{} {
+			return dest
+		}
+	default:
+		return nil, fmt.Errorf("merge strategy not recognised: %v", conf.MergeStrategy)
+	}
+	return j, nil
+}
+
+// id returns the identifier of a message part, or false if the part is not
+// structured or is missing the identifier.
+func (j *messageJoiner) id(p types.Part) (string, interface{}, bool) {
+	structured, err := p.JSON()
+	if err != nil {
+		return "", nil, false
+	}
+	idV := gabs.Wrap(structured).Path(j.idPath).Data()
+	if idV == nil {
+		return "", nil, false
+	}
+	return query.IToString(idV), structured, true
+}
+
+// merge returns a copy of the base part with the fields of the structured
+// source merged into it.
+func (j *messageJoiner) merge(base types.Part, source interface{}) (types.Part, error) {
+	structured, err := base.JSON()
+	if err != nil {
+		return nil, err
+	}
+	if structured, err = message.CopyJSON(structured); err != nil {
+		return nil, err
+	}
+	dest := gabs.Wrap(structured)
+	id := dest.Path(j.idPath).Data()
+	if err = dest.MergeFn(gabs.Wrap(source), j.collision); err != nil {
+		return nil, err
+	}
+	// The identifiers always collide and must not be merged.
+	if _, err = dest.SetP(id, j.idPath); err != nil {
+		return nil, err
+	}
+	merged := base.Copy()
+	if err = merged.SetJSON(dest.Data()); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// Add merges a structured message part into the joined message that shares its
+// identifier.
+func (j *messageJoiner) Add(id string, p types.Part, structured interface{}) error {
+	existing, exists := j.messages[id]
+	if !exists {
+		j.messages[id] = p.Copy()
+		return nil
+	}
+	merged, err := j.merge(existing, structured)
+	if err != nil {
+		return err
+	}
+	j.messages[id] = merged
+	return nil
+}
+
+// Join returns a message part merged with the joined message that shares its
+// identifier, without adding it to the join.
+func (j *messageJoiner) Join(id string, p types.Part, structured interface{}) (types.Part, error) {
+	existing, exists := j.messages[id]
+	if !exists {
+		return p, nil
+	}
+	return j.merge(existing, structured)
+}
+
+// Flush returns all joined messages and resets the join.
+func (j *messageJoiner) Flush() []types.Part {
+	parts := make([]types.Part, 0, len(j.messages))
+	for _, p := range j.messages {
+		parts = append(parts, p)
+	}
+	j.messages = map[string]types.Part{}
+	return parts
+}
+
+//------------------------------------------------------------------------------
+
 // Sequence is an input type that reads from a sequence of inputs, starting with
 // the first, and when it ends gracefully it moves onto the next, and so on.
 type Sequence struct {
 	running int32
 	conf    SequenceConfig
 
-	targetMut sync.Mutex
-	target    Type
-	remaining []sequenceTarget
+	targetMut   sync.Mutex
+	target      Type
+	finalTarget bool
+	remaining   []sequenceTarget
+	targets     []sequenceTarget
+
+	joiner    *messageJoiner
+	iteration int
+
+	mJoinDropped metrics.StatCounter
 
 	wrapperMgr   types.Manager
 	wrapperLog   log.Modular
@@ -121,6 +348,18 @@ func NewSequence(
 		return nil, errors.New("requires at least one child input")
 	}
 
+	var joiner *messageJoiner
+	switch conf.Sequence.ShardedJoin.Type {
+	case "none", "":
+	case "full-outer", "outer":
+		var err error
+		if joiner, err = newMessageJoiner(conf.Sequence.ShardedJoin); err != nil {
+			return nil, fmt.Errorf("failed to create sharded join: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("sharded join type not recognised: %v", conf.Sequence.ShardedJoin.Type)
+	}
+
 	targets := make([]sequenceTarget, 0, len(conf.Sequence.Inputs))
 	for i, c := range conf.Sequence.Inputs {
 		targets = append(targets, sequenceTarget{
@@ -134,6 +373,8 @@ func NewSequence(
 		conf:    conf.Sequence,
 
 		remaining: targets,
+		targets:   targets,
+		joiner:    joiner,
 
 		wrapperLog:   log,
 		wrapperStats: stats,
@@ -145,6 +386,7 @@ func NewSequence(
 		closeChan:    make(chan struct{}),
 		closedChan:   make(chan struct{}),
 	}
+	rdr.mJoinDropped = rdr.stats.GetCounter("sharded_join.dropped")
 
 	if target, err := rdr.createNextTarget(); err != nil {
 		return nil, err
@@ -158,11 +400,11 @@ func NewSequence(
 
 //------------------------------------------------------------------------------
 
-func (r *Sequence) getTarget() Type {
+func (r *Sequence) getTarget() (Type, bool) {
 	r.targetMut.Lock()
-	target := r.target
+	target, final := r.target, r.finalTarget
 	r.targetMut.Unlock()
-	return target
+	return target, final
 }
 
 func (r *Sequence) createNextTarget() (Type, error) {
@@ -185,6 +427,7 @@ func (r *Sequence) createNextTarget() (Type, error) {
 	}
 	if target != nil {
 		r.target = target
+		r.finalTarget = len(r.remaining) == 0
 	}
 	r.targetMut.Unlock()
 
@@ -193,7 +436,7 @@ func (r *Sequence) createNextTarget() (Type, error) {
 
 func (r *Sequence) loop() {
 	defer func() {
-		if t := r.getTarget(); t != nil {
+		if t, _ := r.getTarget(); t != nil {
 			t.CloseAsync()
 			err := t.WaitForClose(time.Second)
 			for ; err != nil; err = t.WaitForClose(time.Second) {
@@ -203,7 +446,7 @@ func (r *Sequence) loop() {
 		close(r.closedChan)
 	}()
 
-	target := r.getTarget()
+	target, final := r.getTarget()
 
 runLoop:
 	for atomic.LoadInt32(&r.running) == 1 {
@@ -221,6 +464,7 @@ runLoop:
 				r.log.Infoln("Exhausted all sequence inputs, shutting down.")
 				return
 			}
+			_, final = r.getTarget()
 		}
 
 		var tran types.Transaction
@@ -230,12 +474,22 @@ runLoop:
 			if !open {
 				target.CloseAsync() // For good measure.
 				target = nil
+				if r.joiner != nil && final && !r.finishIteration() {
+					return
+				}
 				continue runLoop
 			}
 		case <-r.closeChan:
 			return
 		}
 
+		if r.joiner != nil {
+			if !r.joinTransaction(tran, final) {
+				return
+			}
+			continue runLoop
+		}
+
 		select {
 		case r.transactions <- tran:
 		case <-r.closeChan:
@@ -244,6 +498,138 @@ runLoop:
 	}
 }
 
+// inShard returns whether an identifier belongs to the shard of the current
+// iteration.
+func (r *Sequence) inShard(id string) bool {
+	iterations := uint64(r.conf.ShardedJoin.Iterations)
+	if iterations <= 1 {
+		return true
+	}
+	return xxhash.ChecksumString64(id)%iterations == uint64(r.iteration)
+}
+
+// joinTransaction adds the messages of a transaction to the join, or for an
+// outer join of the final input forwards them merged with the joined data.
+// Returns false if the input was closed.
+func (r *Sequence) joinTransaction(tran types.Transaction, final bool) bool {
+	forward := final && r.conf.ShardedJoin.Type == "outer"
+
+	joinedMsg := message.New(nil)
+	tran.Payload.Iter(func(i int, p types.Part) error {
+		id, structured, ok := r.joiner.id(p)
+		if !ok {
+			r.mJoinDropped.Incr(1)
+			r.log.Debugf("Dropping message %v as it is not structured or lacks an identifier at path '%v'\n", i, r.conf.ShardedJoin.IDPath)
+			return nil
+		}
+		if !r.inShard(id) {
+			return nil
+		}
+		if !forward {
+			if err := r.joiner.Add(id, p, structured); err != nil {
+				r.mJoinDropped.Incr(1)
+				r.log.Errorf("Failed to join message: %v\n", err)
+			}
+			return nil
+		}
+		joined, err := r.joiner.Join(id, p, structured)
+		if err != nil {
+			r.mJoinDropped.Incr(1)
+			r.log.Errorf("Failed to join message: %v\n", err)
+			return nil
+		}
+		joinedMsg.Append(joined)
+		return nil
+	})
+
+	if forward && joinedMsg.Len() > 0 {
+		select {
+		case r.transactions <- types.NewTransaction(joinedMsg, tran.ResponseChan):
+		case <-r.closeChan:
+			return false
+		}
+		return true
+	}
+
+	select {
+	case tran.ResponseChan <- response.NewAck():
+	case <-r.closeChan:
+		return false
+	}
+	return true
+}
+
+// finishIteration is called once the final input of the sequence is exhausted,
+// it flushes the joined messages of a full outer join and, if there are
+// iterations remaining, resets the sequence of inputs. Returns false if the
+// input was closed.
+func (r *Sequence) finishIteration() bool {
+	if r.conf.ShardedJoin.Type == "full-outer" && !r.flushJoined() {
+		return false
+	}
+	r.joiner.Flush()
+
+	r.iteration++
+	if r.iteration < r.conf.ShardedJoin.Iterations {
+		r.log.Infof("Finished sharded join iteration %v of %v, starting the next iteration.\n", r.iteration, r.conf.ShardedJoin.Iterations)
+		r.targetMut.Lock()
+		r.remaining = r.targets
+		r.targetMut.Unlock()
+	}
+	return true
+}
+
+// flushJoined sends each joined message downstream and blocks until all of them
+// have been acknowledged, resending those that fail. Returns false if the input
+// was closed.
+func (r *Sequence) flushJoined() bool {
+	parts := r.joiner.Flush()
+	for len(parts) > 0 {
+		var wg sync.WaitGroup
+		var failedMut sync.Mutex
+		var failed []types.Part
+
+		for _, p := range parts {
+			msg := message.New(nil)
+			msg.Append(p)
+
+			resChan := make(chan types.Response)
+			select {
+			case r.transactions <- types.NewTransaction(msg, resChan):
+			case <-r.closeChan:
+				wg.Wait()
+				return false
+			}
+
+			wg.Add(1)
+			go func(p types.Part) {
+				defer wg.Done()
+				select {
+				case res := <-resChan:
+					if res.Error() != nil {
+						failedMut.Lock()
+						failed = append(failed, p)
+						failedMut.Unlock()
+					}
+				case <-r.closeChan:
+				}
+			}(p)
+		}
+		wg.Wait()
+
+		if len(failed) > 0 {
+			r.log.Errorf("Failed to send %v joined messages, retrying.\n", len(failed))
+			select {
+			case <-time.After(time.Second):
+			case <-r.closeChan:
+				return false
+			}
+		}
+		parts = failed
+	}
+	return true
+}
+
 // TransactionChan returns a transactions channel for consuming messages from
 // this input type.
 func (r *Sequence) TransactionChan() <-chan types.Transaction {
@@ -253,7 +639,7 @@ func (r *Sequence) TransactionChan() <-chan types.Transaction {
 // Connected returns a boolean indicating whether this input is currently
 // connected to its target.
 func (r *Sequence) Connected() bool {
-	if t := r.getTarget(); t != nil {
+	if t, _ := r.getTarget(); t != nil {
 		return t.Connected()
 	}
 	return false
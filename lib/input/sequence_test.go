@@ -1,13 +1,16 @@
 package input
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -165,3 +168,158 @@ func TestSequenceSad(t *testing.T) {
 	rdr.CloseAsync()
 	assert.NoError(t, rdr.WaitForClose(time.Second))
 }
+
+func consumeSequenceJoin(t *testing.T, conf Config) []string {
+	t.Helper()
+
+	rdr, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var act []string
+	nacked := false
+
+consumeLoop:
+	for {
+		select {
+		case tran, open := <-rdr.TransactionChan():
+			if !open {
+				break consumeLoop
+			}
+			var res types.Response = response.NewAck()
+			if !nacked {
+				// Reject the first message in order to test retries.
+				nacked = true
+				res = response.NewError(errors.New("nope"))
+			} else {
+				for _, p := range message.GetAllBytes(tran.Payload) {
+					act = append(act, string(p))
+				}
+			}
+			select {
+			case tran.ResponseChan <- res:
+			case <-time.After(time.Second * 5):
+				t.Fatalf("failed to ack after: %v", act)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("Failed to consume message after: %v", act)
+		}
+	}
+
+	rdr.CloseAsync()
+	assert.NoError(t, rdr.WaitForClose(time.Second))
+
+	sort.Strings(act)
+	return act
+}
+
+func sequenceJoinConfig(t *testing.T, joinType string, files map[string]string) Config {
+	t.Helper()
+
+	tmpDir, err := ioutil.TempDir("", "benthos_sequence_input_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	writeFiles(t, tmpDir, files)
+
+	conf := NewConfig()
+	conf.Type = TypeSequence
+	conf.Sequence.ShardedJoin.Type = joinType
+	conf.Sequence.ShardedJoin.IDPath = "id"
+
+	keys := make([]string, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		inConf := NewConfig()
+		inConf.Type = TypeFile
+		inConf.File.Path = filepath.Join(tmpDir, k)
+		conf.Sequence.Inputs = append(conf.Sequence.Inputs, inConf)
+	}
+	return conf
+}
+
+func TestSequenceJoinConfigErrors(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSequence
+	conf.Sequence.Inputs = append(conf.Sequence.Inputs, NewConfig())
+
+	conf.Sequence.ShardedJoin.Type = "nope"
+	_, err := New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Sequence.ShardedJoin.Type = "full-outer"
+	_, err = New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Sequence.ShardedJoin.IDPath = "id"
+	conf.Sequence.ShardedJoin.Iterations = 0
+	_, err = New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Sequence.ShardedJoin.Iterations = 1
+	conf.Sequence.ShardedJoin.MergeStrategy = "nope"
+	_, err = New(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+var sequenceJoinTestFiles = map[string]string{
+	"f1": `{"id":"a","name":"foo","tags":["x"]}
+{"id":"b","name":"bar"}
+not structured
+{"id":"c","name":"baz"}`,
+	"f2": `{"id":"a","age":10,"tags":["y"]}
+{"id":"b","age":20,"name":"bev"}
+{"nope":"d"}
+{"id":"e","age":30}`,
+}
+
+func TestSequenceJoinFullOuter(t *testing.T) {
+	t.Parallel()
+
+	for _, iterations := range []int{1, 3} {
+		conf := sequenceJoinConfig(t, "full-outer", sequenceJoinTestFiles)
+		conf.Sequence.ShardedJoin.Iterations = iterations
+
+		assert.Equal(t, []string{
+			`{"age":10,"id":"a","name":"foo","tags":["x","y"]}`,
+			`{"age":20,"id":"b","name":["bar","bev"]}`,
+			`{"id":"c","name":"baz"}`,
+			`{"id":"e","age":30}`,
+		}, consumeSequenceJoin(t, conf), iterations)
+	}
+}
+
+func TestSequenceJoinOuter(t *testing.T) {
+	t.Parallel()
+
+	conf := sequenceJoinConfig(t, "outer", sequenceJoinTestFiles)
+	conf.Sequence.ShardedJoin.MergeStrategy = "replace"
+
+	// Joined messages of an outer join are acknowledged by the final input,
+	// which therefore resends the rejected message.
+	assert.Equal(t, []string{
+		`{"age":10,"id":"a","name":"foo","tags":["y"]}`,
+		`{"age":20,"id":"b","name":"bev"}`,
+		`{"id":"e","age":30}`,
+	}, consumeSequenceJoin(t, conf))
+}
+
+func TestSequenceJoinKeep(t *testing.T) {
+	t.Parallel()
+
+	conf := sequenceJoinConfig(t, "full-outer", map[string]string{
+		"f1": `{"id":"a","name":"foo"}`,
+		"f2": `{"id":"a","name":"bar","age":10}`,
+		"f3": `{"id":1,"name":"baz"}`,
+		"f4": `{"id":1,"age":20}`,
+	})
+	conf.Sequence.ShardedJoin.MergeStrategy = "keep"
+
+	assert.Equal(t, []string{
+		`{"age":10,"id":"a","name":"foo"}`,
+		`{"age":20,"id":1,"name":"baz"}`,
+	}, consumeSequenceJoin(t, conf))
+}
@@ -0,0 +1,90 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/codec"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/sftp"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSFTP] = TypeSpec{
+		constructor: NewSFTP,
+		Summary: `
+Consumes files from a server over SFTP.`,
+		Description: `
+Files that match any of the glob ` + "`paths`" + ` are consumed in order of
+their path, where each segment of a path may be a pattern that is matched
+against the entries of a directory, such as ` + "`/data/*/*.csv`" + `. Paths
+that are not absolute are relative to the home directory of the user.
+
+The contents of each file are converted into messages with the ` + "`codec`" + `.
+Once all messages of a file have been acknowledged the file can either be
+deleted with ` + "`delete_on_finish`" + ` or moved into the directory
+` + "`move_on_finish`" + `.
+
+### Watcher Mode
+
+By default the input consumes the files that match its paths when it is
+started and then closes. When the ` + "`watcher`" + ` is enabled the paths are
+instead polled indefinitely for new files at the rate of
+` + "`poll_interval`" + `, and files are only consumed once they have not been
+modified for the ` + "`minimum_age`" + `, giving writers time to finish.
+
+Files that are neither deleted nor moved are not consumed again while the input
+runs. In order to prevent consuming them again after a restart the paths of
+consumed files can be written to the cache ` + "`watcher.cache`" + `.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- sftp_path
+- sftp_mod_time_unix
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		Beta: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("address", "The address of the server to connect to, with a port.", "localhost:22"),
+			sftp.CredentialsFieldSpec(),
+			sftp.KnownHostsFieldSpec(),
+			docs.FieldCommon("paths", "A list of glob patterns of the paths of files to consume.", []string{"/data/*.csv", "uploads/*/*.json.gz"}),
+			docs.FieldCommon("codec", codec.ReaderDocs).HasOptions(
+				"all-bytes", "lines", "delim:x", "regex:x", "chunker:x", "csv", "length-prefixed", "protobuf-delimited", "tar", "parquet", "gzip", "gzip/lines", "gzip/tar",
+			),
+			docs.FieldAdvanced("max_buffer", "The largest message that can be read with the `lines`, `delim:x` and `regex:x` codecs, and the largest frame that can be read with the `length-prefixed` and `protobuf-delimited` codecs."),
+			docs.FieldCommon("delete_on_finish", "Whether to delete files once all of their messages have been acknowledged."),
+			docs.FieldCommon("move_on_finish", "A directory to move files into once all of their messages have been acknowledged, which is created if it does not exist.", "/data/done"),
+			docs.FieldCommon("watcher", "Allows you to configure the input to poll for new files rather than closing once the existing files are consumed.").WithChildren(
+				docs.FieldCommon("enabled", "Whether file watching is enabled."),
+				docs.FieldCommon("minimum_age", "The minimum period of time since a file was last modified before it is consumed."),
+				docs.FieldCommon("poll_interval", "The period of time between each poll for new files."),
+				docs.FieldCommon("cache", "The name of a [cache resource](/docs/components/caches/about) to store the paths of consumed files in, which are not consumed again."),
+			),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSFTP creates a new SFTP input type.
+func NewSFTP(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	s, err := reader.NewSFTP(conf.SFTP, mgr, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeSFTP, true, reader.NewAsyncPreserver(s), log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -1,10 +1,12 @@
 package input
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net"
 
+	"github.com/Jeffail/benthos/v3/internal/codec"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -28,7 +30,11 @@ Messages consumed by this input can be processed in parallel, meaning a single
 instance of this input can utilise any number of threads within a
 ` + "`pipeline`" + ` section of a config.
 
-If the delimiter field is left empty then line feed (\n) is used.`,
+If the delimiter field is left empty then line feed (\n) is used.
+
+When the field ` + "`codec`" + ` is set the data is instead converted into
+messages with the codec, and the fields ` + "`multipart`" + ` and
+` + "`delimiter`" + ` are ignored.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("network", "A network type to assume (unix|tcp).").HasOptions(
 				"unix", "tcp",
@@ -37,6 +43,9 @@ If the delimiter field is left empty then line feed (\n) is used.`,
 			docs.FieldAdvanced("multipart", "Whether messages should be consumed as multiple parts. If so, each line is consumed as a message parts and the full message ends with an empty line."),
 			docs.FieldAdvanced("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed."),
 			docs.FieldAdvanced("delimiter", "The delimiter to use to detect the end of each message. If left empty line breaks are used."),
+			docs.FieldAdvanced("codec", codec.ReaderDocs).HasOptions(
+				"lines", "delim:x", "regex:x", "chunker:x", "csv", "length-prefixed", "protobuf-delimited", "all-bytes", "tar", "gzip/lines",
+			),
 		},
 		Categories: []Category{
 			CategoryNetwork,
@@ -53,6 +62,7 @@ type SocketConfig struct {
 	Multipart bool   `json:"multipart" yaml:"multipart"`
 	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
 	Delim     string `json:"delimiter" yaml:"delimiter"`
+	Codec     string `json:"codec" yaml:"codec"`
 }
 
 // NewSocketConfig creates a new SocketConfig with default values.
@@ -63,6 +73,7 @@ func NewSocketConfig() SocketConfig {
 		Multipart: false,
 		MaxBuffer: 1000000,
 		Delim:     "",
+		Codec:     "",
 	}
 }
 
@@ -80,26 +91,43 @@ func NewSocket(conf Config, mgr types.Manager, log log.Modular, stats metrics.Ty
 		return nil, fmt.Errorf("socket network '%v' is not supported by this input", conf.Socket.Network)
 	}
 	var conn net.Conn
-	rdr, err := reader.NewLines(
-		func() (io.Reader, error) {
-			if conn != nil {
-				conn.Close()
-				conn = nil
-			}
-			var err error
-			conn, err = net.Dial(conf.Socket.Network, conf.Socket.Address)
-			return conn, err
-		},
-		func() {
-			if conn != nil {
-				conn.Close()
-				conn = nil
-			}
-		},
-		reader.OptLinesSetDelimiter(delim),
-		reader.OptLinesSetMaxBuffer(conf.Socket.MaxBuffer),
-		reader.OptLinesSetMultipart(conf.Socket.Multipart),
-	)
+	handleCtor := func() (io.Reader, error) {
+		if conn != nil {
+			conn.Close()
+			conn = nil
+		}
+		var err error
+		conn, err = net.Dial(conf.Socket.Network, conf.Socket.Address)
+		return conn, err
+	}
+	onClose := func() {
+		if conn != nil {
+			conn.Close()
+			conn = nil
+		}
+	}
+
+	var rdr reader.Async
+	var err error
+	if len(conf.Socket.Codec) > 0 {
+		rdr, err = reader.NewCodecStream(
+			conf.Socket.Codec, conf.Socket.MaxBuffer,
+			func(ctx context.Context) (io.Reader, error) {
+				return handleCtor()
+			},
+			func(ctx context.Context) {
+				onClose()
+			},
+		)
+	} else {
+		rdr, err = reader.NewLines(
+			handleCtor,
+			onClose,
+			reader.OptLinesSetDelimiter(delim),
+			reader.OptLinesSetMaxBuffer(conf.Socket.MaxBuffer),
+			reader.OptLinesSetMultipart(conf.Socket.Multipart),
+		)
+	}
 	if err != nil {
 		return nil, err
 	}
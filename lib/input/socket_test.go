@@ -904,3 +904,87 @@ func TestTCPSocketMultipartShutdown(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestTCPSocketCodecReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		if ln, err = net.Listen("tcp6", "[::1]:0"); err != nil {
+			t.Fatalf("failed to listen on a port: %v", err)
+		}
+	}
+	defer ln.Close()
+
+	conf := NewConfig()
+	conf.Socket.Network = "tcp"
+	conf.Socket.Address = ln.Addr().String()
+	conf.Socket.Codec = "length-prefixed"
+
+	rdr, err := NewSocket(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		rdr.CloseAsync()
+		if err := rdr.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		conn.SetWriteDeadline(time.Now().Add(time.Second * 5))
+		if _, cerr := conn.Write([]byte("\x00\x00\x00\x04foo\n")); cerr != nil {
+			t.Error(cerr)
+		}
+		conn.Close()
+		var cerr error
+		if conn, cerr = ln.Accept(); cerr != nil {
+			t.Error(cerr)
+			return
+		}
+		if _, cerr = conn.Write([]byte("\x00\x00\x00\x03bar\x00\x00\x00\x00")); cerr != nil {
+			t.Error(cerr)
+		}
+	}()
+
+	readNextMsg := func() (types.Message, error) {
+		var msg types.Message
+		select {
+		case tran := <-rdr.TransactionChan():
+			msg = tran.Payload.DeepCopy()
+			select {
+			case tran.ResponseChan <- response.NewAck():
+			case <-time.After(time.Second):
+				return nil, errors.New("timed out")
+			}
+		case <-time.After(time.Second):
+			return nil, errors.New("timed out")
+		}
+		return msg, nil
+	}
+
+	for _, exp := range [][][]byte{
+		{[]byte("foo\n")},
+		{[]byte("bar")},
+		{[]byte("")},
+	} {
+		msg, err := readNextMsg()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := message.GetAllBytes(msg); !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong message contents: %s != %s", act, exp)
+		}
+	}
+
+	wg.Wait()
+	conn.Close()
+}
@@ -32,11 +32,29 @@ This input adds the following metadata fields to each message:
 - sqs_message_id
 - sqs_receipt_handle
 - sqs_approximate_receive_count
+- sqs_message_group_id
+- sqs_message_deduplication_id
+- sqs_sequence_number
 - All message attributes
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata). The
+fields ` + "`sqs_message_group_id`, `sqs_message_deduplication_id` and `sqs_sequence_number`" + `
+are only set for messages consumed from FIFO queues.
+
+### Visibility Timeout
+
+When the field ` + "`visibility_timeout`" + ` is set the visibility timeout of
+each received message is set to that duration, and is then extended by the same
+duration periodically (at half the timeout) until the message is acknowledged.
+This prevents messages from being redelivered to other consumers while they are
+still being processed by long running pipelines, whilst ensuring that messages
+are redelivered quickly should Benthos stop unexpectedly.
+
+Messages that fail to be delivered are made visible again immediately, unless
+the failure requests a delay before redelivery, in which case the visibility
+timeout of the messages is set to that delay (up to a maximum of 12 hours.)`,
 		FieldSpecs: append(
 			append(docs.FieldSpecs{
 				docs.FieldCommon("url", "The SQS URL to consume from."),
@@ -44,6 +62,7 @@ You can access these metadata fields using
 			}, session.FieldSpecs()...),
 			docs.FieldAdvanced("timeout", "The period of time to wait before abandoning a request and trying again."),
 			docs.FieldAdvanced("max_number_of_messages", "The maximum number of messages to consume from each request."),
+			docs.FieldAdvanced("visibility_timeout", "An optional visibility timeout to set for received messages, which is extended periodically until they are acknowledged. When empty the default visibility timeout of the queue is used and is not extended.", "30s", "5m"),
 		),
 		Categories: []Category{
 			CategoryServices,
@@ -0,0 +1,79 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSSE] = TypeSpec{
+		constructor: NewSSE,
+		Summary: `
+Connects to a [Server-Sent Events](https://html.spec.whatwg.org/multipage/server-sent-events.html)
+endpoint and consumes each event as a message.`,
+		Description: `
+The data of each event is consumed as the message contents, where events with
+multiple data lines are joined with line feeds. Comments and events without
+data are ignored.
+
+### Reconnecting
+
+When the stream ends, or a connection cannot be established, the input
+reconnects after waiting for the ` + "`reconnect_delay`" + `, which is replaced
+by the delay of any ` + "`retry`" + ` hint sent by the server. Reconnection
+requests include the ID of the last event received within the
+` + "`Last-Event-ID`" + ` header, allowing the server to resume the stream. This
+ID is only held in memory, but the field ` + "`last_event_id`" + ` can be used
+in order to resume from a known ID when Benthos starts.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- sse_event
+- sse_id
+` + "```" + `
+
+The field ` + "`sse_event`" + ` is the event name, which is ` + "`message`" + `
+unless specified by the server, and ` + "`sse_id`" + ` is the last event ID of
+the stream, which is absent until the server has sent one.
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
+		Beta: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "The URL of the event stream.", "https://example.com/events"),
+			docs.FieldAdvanced("headers", "A map of headers to add to each request.", map[string]string{
+				"Authorization": "Bearer foo",
+			}),
+			docs.FieldAdvanced("last_event_id", "An optional event ID to resume the stream from when Benthos starts."),
+			docs.FieldAdvanced("reconnect_delay", "The period to wait before reconnecting when the stream ends, unless the server has sent a `retry` hint."),
+			docs.FieldAdvanced("timeout", "The maximum period to wait for the response headers of a request."),
+			tls.FieldSpec(),
+		}.Merge(auth.FieldSpecs()),
+		Categories: []Category{
+			CategoryNetwork,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSSE creates a new SSE input type.
+func NewSSE(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	s, err := reader.NewSSE(conf.SSE, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeSSE, true, reader.NewAsyncPreserver(s), log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -1,9 +1,11 @@
 package input
 
 import (
+	"context"
 	"io"
 	"os"
 
+	"github.com/Jeffail/benthos/v3/internal/codec"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/input/reader"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -22,11 +24,18 @@ Consumes data piped to stdin as line delimited messages.`,
 If the multipart option is set to true then lines are interpretted as message
 parts, and an empty line indicates the end of the message.
 
-If the delimiter field is left empty then line feed (\n) is used.`,
+If the delimiter field is left empty then line feed (\n) is used.
+
+When the field ` + "`codec`" + ` is set the data is instead converted into
+messages with the codec, and the fields ` + "`multipart`" + ` and
+` + "`delimiter`" + ` are ignored.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldAdvanced("multipart", "Whether messages should be consumed as multiple parts. If so, each line is consumed as a message parts and the full message ends with an empty line."),
 			docs.FieldAdvanced("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed."),
 			docs.FieldAdvanced("delimiter", "The delimiter to use to detect the end of each message. If left empty line breaks are used."),
+			docs.FieldAdvanced("codec", codec.ReaderDocs).HasOptions(
+				"lines", "delim:x", "regex:x", "chunker:x", "csv", "length-prefixed", "protobuf-delimited", "all-bytes", "tar", "gzip/lines",
+			),
 		},
 		Categories: []Category{
 			CategoryLocal,
@@ -41,6 +50,7 @@ type STDINConfig struct {
 	Multipart bool   `json:"multipart" yaml:"multipart"`
 	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
 	Delim     string `json:"delimiter" yaml:"delimiter"`
+	Codec     string `json:"codec" yaml:"codec"`
 }
 
 // NewSTDINConfig creates a STDINConfig populated with default values.
@@ -49,6 +59,7 @@ func NewSTDINConfig() STDINConfig {
 		Multipart: false,
 		MaxBuffer: 1000000,
 		Delim:     "",
+		Codec:     "",
 	}
 }
 
@@ -62,22 +73,36 @@ func NewSTDIN(conf Config, mgr types.Manager, log log.Modular, stats metrics.Typ
 	}
 
 	stdin := os.Stdin
-	rdr, err := reader.NewLines(
-		func() (io.Reader, error) {
-			// Swap so this only works once since we don't want to read stdin
-			// multiple times.
-			if stdin == nil {
-				return nil, io.EOF
-			}
-			sendStdin := stdin
-			stdin = nil
-			return sendStdin, nil
-		},
-		func() {},
-		reader.OptLinesSetDelimiter(delim),
-		reader.OptLinesSetMaxBuffer(conf.STDIN.MaxBuffer),
-		reader.OptLinesSetMultipart(conf.STDIN.Multipart),
-	)
+	handleCtor := func() (io.Reader, error) {
+		// Swap so this only works once since we don't want to read stdin
+		// multiple times.
+		if stdin == nil {
+			return nil, io.EOF
+		}
+		sendStdin := stdin
+		stdin = nil
+		return sendStdin, nil
+	}
+
+	var rdr reader.Async
+	var err error
+	if len(conf.STDIN.Codec) > 0 {
+		rdr, err = reader.NewCodecStream(
+			conf.STDIN.Codec, conf.STDIN.MaxBuffer,
+			func(ctx context.Context) (io.Reader, error) {
+				return handleCtor()
+			},
+			func(ctx context.Context) {},
+		)
+	} else {
+		rdr, err = reader.NewLines(
+			handleCtor,
+			func() {},
+			reader.OptLinesSetDelimiter(delim),
+			reader.OptLinesSetMaxBuffer(conf.STDIN.MaxBuffer),
+			reader.OptLinesSetMultipart(conf.STDIN.Multipart),
+		)
+	}
 	if err != nil {
 		return nil, err
 	}
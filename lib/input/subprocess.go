@@ -0,0 +1,61 @@
+package input
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/codec"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/input/reader"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSubprocess] = TypeSpec{
+		constructor: NewSubprocess,
+		Summary: `
+Executes a command, runs it as a subprocess, and consumes messages from it over
+stdout.`,
+		Description: `
+Messages are consumed from the stdout stream of the subprocess with the
+` + "`codec`" + `, and anything written to stderr is logged as a warning.
+
+### Restarting
+
+By default the input closes once the subprocess exits and all of its messages
+are consumed, which when it is the only input of a config shuts Benthos down.
+When ` + "`restart_on_exit`" + ` is set to ` + "`true`" + ` the subprocess is
+instead restarted each time it exits, and the input only stops it when Benthos
+shuts down.
+
+Messages consumed by this input are not acknowledged to the subprocess, and so
+those that are not yet delivered are lost when it exits.`,
+		Beta: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("name", "The command to execute as a subprocess.", "cat", "sed", "awk"),
+			docs.FieldCommon("args", "A list of arguments to provide the command."),
+			docs.FieldCommon("codec", codec.ReaderDocs).HasOptions(
+				"lines", "delim:x", "regex:x", "chunker:x", "csv", "length-prefixed", "protobuf-delimited", "all-bytes",
+			),
+			docs.FieldAdvanced("restart_on_exit", "Whether the command should be restarted each time it exits."),
+			docs.FieldAdvanced("max_buffer", "The largest message that can be read with the `lines`, `delim:x` and `regex:x` codecs, and the largest frame that can be read with the `length-prefixed` and `protobuf-delimited` codecs."),
+		},
+		Categories: []Category{
+			CategoryLocal,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSubprocess creates a new Subprocess input type.
+func NewSubprocess(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	s, err := reader.NewSubprocess(conf.Subprocess, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewAsyncReader(TypeSubprocess, true, reader.NewAsyncPreserver(s), log, stats)
+}
+
+//------------------------------------------------------------------------------
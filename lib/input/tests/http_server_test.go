@@ -2,8 +2,14 @@ package tests
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -21,6 +27,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type apiRegMutWrapper struct {
@@ -717,3 +725,257 @@ func TestHTTPSyncResponseHeadersStatus(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestHTTPServerFormData(t *testing.T) {
+	t.Parallel()
+
+	reg := apiRegMutWrapper{mut: &http.ServeMux{}}
+	mgr, err := manager.New(manager.NewConfig(), reg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.HTTPServer.Path = "/testpost"
+
+	h, err := input.NewHTTPServer(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(reg.mut)
+	defer server.Close()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("title", "hello world"))
+	fw, err := mw.CreateFormFile("upload", "foo.txt")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("foo bar baz"))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	resChan := make(chan int, 1)
+	go func() {
+		res, err := http.Post(server.URL+"/testpost?id=10", mw.FormDataContentType(), &body)
+		if err != nil {
+			resChan <- 0
+			return
+		}
+		res.Body.Close()
+		resChan <- res.StatusCode
+	}()
+
+	var ts types.Transaction
+	select {
+	case ts = <-h.TransactionChan():
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for message")
+	}
+
+	require.Equal(t, 2, ts.Payload.Len())
+	assert.Equal(t, "hello world", string(ts.Payload.Get(0).Get()))
+	assert.Equal(t, "title", ts.Payload.Get(0).Metadata().Get("http_server_form_name"))
+	assert.Equal(t, "", ts.Payload.Get(0).Metadata().Get("http_server_form_filename"))
+	assert.Equal(t, "10", ts.Payload.Get(0).Metadata().Get("id"))
+	assert.Equal(t, "POST", ts.Payload.Get(0).Metadata().Get("http_server_verb"))
+	assert.Equal(t, "/testpost", ts.Payload.Get(0).Metadata().Get("http_server_request_path"))
+
+	assert.Equal(t, "foo bar baz", string(ts.Payload.Get(1).Get()))
+	assert.Equal(t, "upload", ts.Payload.Get(1).Metadata().Get("http_server_form_name"))
+	assert.Equal(t, "foo.txt", ts.Payload.Get(1).Metadata().Get("http_server_form_filename"))
+	assert.Equal(t, "application/octet-stream", ts.Payload.Get(1).Metadata().Get("Content-Type"))
+	assert.Equal(t, "10", ts.Payload.Get(1).Metadata().Get("id"))
+
+	select {
+	case ts.ResponseChan <- response.NewAck():
+	case <-time.After(time.Second):
+		t.Error("Timed out waiting for response")
+	}
+	assert.Equal(t, 200, <-resChan)
+
+	h.CloseAsync()
+	assert.NoError(t, h.WaitForClose(time.Second*5))
+}
+
+func TestHTTPServerCompressedBodies(t *testing.T) {
+	t.Parallel()
+
+	reg := apiRegMutWrapper{mut: &http.ServeMux{}}
+	mgr, err := manager.New(manager.NewConfig(), reg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.HTTPServer.Path = "/testpost"
+
+	h, err := input.NewHTTPServer(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(reg.mut)
+	defer server.Close()
+
+	compress := func(encoding string, payload []byte) []byte {
+		var buf bytes.Buffer
+		var w io.WriteCloser
+		switch encoding {
+		case "gzip":
+			w = gzip.NewWriter(&buf)
+		case "deflate":
+			w = zlib.NewWriter(&buf)
+		case "raw_deflate":
+			w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+		default:
+			return payload
+		}
+		_, err := w.Write(payload)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		encoding string
+		header   string
+		status   int
+	}{
+		{encoding: "gzip", header: "gzip", status: 200},
+		{encoding: "deflate", header: "deflate", status: 200},
+		{encoding: "raw_deflate", header: "deflate", status: 200},
+		{encoding: "identity", header: "identity", status: 200},
+		{encoding: "identity", header: "gzip", status: 400},
+	}
+
+	for _, test := range tests {
+		input := fmt.Sprintf("hello world from %v", test.encoding)
+
+		req, err := http.NewRequest("POST", server.URL+"/testpost", bytes.NewReader(compress(test.encoding, []byte(input))))
+		require.NoError(t, err)
+		req.Header.Set("Content-Encoding", test.header)
+
+		resChan := make(chan int, 1)
+		go func() {
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				resChan <- 0
+				return
+			}
+			res.Body.Close()
+			resChan <- res.StatusCode
+		}()
+
+		if test.status != 200 {
+			assert.Equal(t, test.status, <-resChan, test.encoding)
+			continue
+		}
+
+		var ts types.Transaction
+		select {
+		case ts = <-h.TransactionChan():
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+		assert.Equal(t, input, string(ts.Payload.Get(0).Get()), test.encoding)
+		select {
+		case ts.ResponseChan <- response.NewAck():
+		case <-time.After(time.Second):
+			t.Error("Timed out waiting for response")
+		}
+		assert.Equal(t, test.status, <-resChan, test.encoding)
+	}
+
+	h.CloseAsync()
+	assert.NoError(t, h.WaitForClose(time.Second*5))
+}
+
+func TestHTTPServerWSStream(t *testing.T) {
+	t.Parallel()
+
+	reg := apiRegMutWrapper{mut: &http.ServeMux{}}
+	mgr, err := manager.New(manager.NewConfig(), reg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.HTTPServer.Path = "/testpost"
+	conf.HTTPServer.WSPath = "/testws"
+	conf.HTTPServer.WSStreamPath = "/teststream"
+	conf.HTTPServer.WSWelcomeMessage = "welcome"
+
+	h, err := input.NewHTTPServer(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(reg.mut)
+	defer server.Close()
+
+	purl, err := url.Parse(server.URL + "/teststream")
+	require.NoError(t, err)
+	purl.Scheme = "ws"
+
+	streamClient, _, err := websocket.DefaultDialer.Dial(purl.String(), http.Header{})
+	require.NoError(t, err)
+	defer streamClient.Close()
+
+	_, welcome, err := streamClient.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "welcome", string(welcome))
+
+	deliver := func(res types.Response) {
+		t.Helper()
+		select {
+		case ts := <-h.TransactionChan():
+			select {
+			case ts.ResponseChan <- res:
+			case <-time.After(time.Second):
+				t.Error("Timed out waiting for response")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+	}
+
+	// Messages that fail to be delivered aren't streamed.
+	resChan := make(chan int, 1)
+	go func() {
+		res, err := http.Post(server.URL+"/testpost", "application/octet-stream", bytes.NewBufferString("nope"))
+		if err != nil {
+			resChan <- 0
+			return
+		}
+		res.Body.Close()
+		resChan <- res.StatusCode
+	}()
+	deliver(response.NewError(errors.New("nope")))
+	assert.Equal(t, http.StatusBadGateway, <-resChan)
+
+	go func() {
+		res, err := http.Post(
+			server.URL+"/testpost", "multipart/mixed; boundary=foo",
+			bytes.NewBufferString("--foo\r\n\r\nfirst\r\n--foo\r\n\r\nsecond\r\n--foo--\r\n"),
+		)
+		if err != nil {
+			resChan <- 0
+			return
+		}
+		res.Body.Close()
+		resChan <- res.StatusCode
+	}()
+	deliver(response.NewAck())
+	assert.Equal(t, 200, <-resChan)
+
+	wsurl, err := url.Parse(server.URL + "/testws")
+	require.NoError(t, err)
+	wsurl.Scheme = "ws"
+
+	wsClient, _, err := websocket.DefaultDialer.Dial(wsurl.String(), http.Header{})
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	require.NoError(t, wsClient.WriteMessage(websocket.BinaryMessage, []byte("third")))
+	deliver(response.NewAck())
+
+	for _, exp := range []string{"first", "second", "third"} {
+		require.NoError(t, streamClient.SetReadDeadline(time.Now().Add(time.Second*5)))
+		_, act, err := streamClient.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(act))
+	}
+
+	wsClient.Close()
+	h.CloseAsync()
+	assert.NoError(t, h.WaitForClose(time.Second*5))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
 )
 
 //------------------------------------------------------------------------------
@@ -19,10 +20,65 @@ Connects to a websocket server and continuously receives messages.`,
 		Description: `
 It is possible to configure an ` + "`open_message`" + `, which when set to a
 non-empty string will be sent to the websocket server each time a connection is
-first established.`,
+established.
+
+### Reconnecting
+
+When the connection is lost, or cannot be established, attempts to reconnect are
+made with an exponential backoff as configured by the field ` + "`reconnect`" + `.
+Each reconnect attempt increments the metric ` + "`reconnect.attempt`" + `, and
+each failed reconnect attempt increments ` + "`reconnect.error`" + `.
+
+### Cursors
+
+Streaming APIs often allow clients to resume from a position within the stream,
+such as the ID of the last event received, by specifying it within the open
+message. When a ` + "`cursor.value`" + ` is set it is evaluated for each message
+received in order to obtain its position within the stream, and the position of
+the latest message to be acknowledged, along with all prior messages, is tracked
+and added to the open message of each connection as the metadata field
+` + "`websocket_cursor`" + `:
+
+` + "```yaml" + `
+input:
+  websocket:
+    url: wss://example.com/stream
+    open_message: '{"action":"subscribe","resume_after":"${! meta("websocket_cursor") }"}'
+    cursor:
+      value: ${! json("event_id") }
+      cache: cursors
+` + "```" + `
+
+If a ` + "`cursor.cache`" + ` is specified the cursor is also written to the
+cache, allowing the stream to be resumed after Benthos is restarted.
+
+### Metadata
+
+When a ` + "`cursor.value`" + ` is set this input adds the following metadata
+fields to each message:
+
+` + "``` text" + `
+- websocket_cursor
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolation](/docs/configuration/interpolation#metadata).`,
 		FieldSpecs: append(docs.FieldSpecs{
 			docs.FieldCommon("url", "The URL to connect to.", "ws://localhost:4195/get/ws").HasType("string"),
-			docs.FieldAdvanced("open_message", "An optional message to send to the server upon connection."),
+			docs.FieldAdvanced("open_message", "An optional message to send to the server upon connection. The latest cursor is available as the metadata field `websocket_cursor`.", `{"subscribe":"events","after":"${! meta("websocket_cursor") }"}`).SupportsInterpolation(false),
+			docs.FieldAdvanced("open_message_type", "The type of websocket frame to send the open message as.").HasOptions("binary", "text"),
+			docs.FieldAdvanced("headers", "A map of headers to add to the upgrade request.", map[string]string{
+				"Authorization": "Bearer foo",
+			}),
+			docs.FieldAdvanced("subprotocols", "A list of subprotocols to request during the opening handshake, in order of preference.", []string{"graphql-ws"}),
+			docs.FieldAdvanced("cursor", "Track the position of consumed messages within the stream, allowing connections to be resumed from the position of the latest acknowledged message.").WithChildren(
+				docs.FieldCommon("value", "A value evaluated for each message in order to obtain its position within the stream. Messages that result in an empty string do not progress the cursor.", `${! json("id") }`, `${! meta("sequence") }`).SupportsInterpolation(false),
+				docs.FieldAdvanced("initial", "The cursor to use when there is no cursor within the cache and no messages have yet been acknowledged."),
+				docs.FieldCommon("cache", "An optional [cache resource](/docs/components/caches/about) to store the cursor of acknowledged messages in."),
+				docs.FieldAdvanced("key", "The key to store the cursor under within the cache."),
+			),
+			docs.FieldAdvanced("reconnect", "Control the exponential backoff applied between attempts to connect to the server.").WithChildren(retries.FieldSpecs()...),
+			auth.OAuth2FieldSpec(),
 		}, auth.FieldSpecs()...),
 		Categories: []Category{
 			CategoryNetwork,
@@ -34,7 +90,7 @@ first established.`,
 
 // NewWebsocket creates a new Websocket input type.
 func NewWebsocket(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-	ws, err := reader.NewWebsocket(conf.Websocket, log, stats)
+	ws, err := reader.NewWebsocketWithManager(conf.Websocket, mgr, log, stats)
 	if err != nil {
 		return nil, err
 	}
@@ -170,6 +170,37 @@ func TestPolicyCheck(t *testing.T) {
 	}
 }
 
+func TestPolicyCheckContentBoundary(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.Check = `this.end_of_transaction == true`
+
+	pol, err := NewPolicy(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := [][]byte{
+		[]byte(`{"id":1}`),
+		[]byte(`{"id":2,"end_of_transaction":false}`),
+		[]byte(`{"id":3,"end_of_transaction":true}`),
+	}
+
+	for i, part := range exp {
+		if triggered := pol.Add(message.NewPart(part)); triggered != (i == len(exp)-1) {
+			t.Errorf("Unexpected trigger result for message %v: %v", i, triggered)
+		}
+	}
+
+	msg := pol.Flush()
+	if !reflect.DeepEqual(exp, message.GetAllBytes(msg)) {
+		t.Errorf("Wrong result: %s != %s", message.GetAllBytes(msg), exp)
+	}
+
+	if pol.Add(message.NewPart([]byte(`{"id":4}`))) {
+		t.Error("Unexpected batch")
+	}
+}
+
 func TestPolicyCheckAdvanced(t *testing.T) {
 	conf := NewPolicyConfig()
 	conf.Check = `batch_size() >= 3`
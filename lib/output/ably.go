@@ -0,0 +1,107 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAbly] = TypeSpec{
+		constructor: NewAbly,
+		Summary: `
+Publishes messages to [Ably](https://ably.com) channels, pushing them to the
+browsers and devices subscribed to a channel.`,
+		Description: `
+The contents of each message are published as the data of an Ably message,
+where the channel, the name and the ID of the message support
+[interpolation functions](/docs/configuration/interpolation#bloblang-queries),
+allowing messages to be routed to channels dynamically.
+
+Setting the ` + "`id`" + ` field enables
+[idempotent publishing](https://ably.com/docs/core-features/publishing#idempotent-publishing),
+where Ably discards messages with an ID that has already been published, which
+prevents duplicates when a batch is retried after a partial failure.
+
+The messages of a batch are grouped by their channel and each group is
+published with a single request, preserving the order of messages within a
+channel. Messages of a channel that fail to be published are rejected
+individually, which allows
+[error handling patterns](/docs/configuration/error_handling) to deal with them.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Live Scores",
+				Summary: `
+Publish score updates to a channel per match, using the ID of each update to
+avoid publishing duplicates:`,
+				Config: `
+output:
+  ably:
+    api_key: ${ABLY_API_KEY}
+    channel: 'matches:${! json("match_id") }'
+    event: score
+    id: '${! json("update_id") }'
+    batching:
+      count: 50
+      period: 100ms
+`,
+			},
+		},
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.Ably, conf.Ably.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("api_key", "An Ably API key of the form `<app>.<key>:<secret>`, which must have the publish capability for the channels written to."),
+			docs.FieldAdvanced("endpoint", "The URL of the Ably REST API."),
+			docs.FieldCommon("channel", "The channel to publish messages to.", "my-channel", `users:${! meta("user_id") }`).SupportsInterpolation(false),
+			docs.FieldCommon("event", "An optional name of messages.", "new-message", `${! json("type") }`).SupportsInterpolation(false),
+			docs.FieldAdvanced("id", "An optional ID of messages, which enables idempotent publishing.", `${! meta("id") }`).SupportsInterpolation(false),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on a request before abandoning it and reattempting."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAbly creates a new Ably output type.
+func NewAbly(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	a, err := writer.NewAbly(conf.Ably, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.Ably.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeAbly, a, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeAbly, conf.Ably.MaxInFlight, a, log, stats,
+		)
+	}
+	if bconf := conf.Ably.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
@@ -6,6 +6,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/amqp/link"
 	"github.com/Jeffail/benthos/v3/lib/util/amqp/sasl"
 	"github.com/Jeffail/benthos/v3/lib/util/tls"
 )
@@ -18,8 +19,12 @@ func init() {
 		Beta:        true,
 		Summary: `
 Sends messages to an AMQP (1.0) server.`,
-		Description: ``,
-		Async:       true,
+		Description: `
+Each message is sent once the previous message has been settled by the server,
+unless ` + "`max_in_flight`" + ` is greater than one. When the sender settle
+mode is ` + "`settled`" + ` messages are considered delivered as soon as they are
+sent, which results in at-most-once delivery.`,
+		Async: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("url",
 				"A URL to connect to.",
@@ -28,9 +33,11 @@ Sends messages to an AMQP (1.0) server.`,
 			),
 			docs.FieldCommon("target_address", "The target address to write to.", "/foo", "queue:/bar", "topic:/baz"),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldAdvanced("container_id", "An identifier of the client container, which when empty is generated randomly for each connection."),
+		}.Merge(link.SettleModeFieldSpecs()).Merge(docs.FieldSpecs{
 			tls.FieldSpec(),
 			sasl.FieldSpec(),
-		},
+		}),
 		Categories: []Category{
 			CategoryServices,
 		},
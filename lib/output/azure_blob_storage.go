@@ -0,0 +1,143 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAzureBlobStorage] = TypeSpec{
+		constructor: NewAzureBlobStorage,
+		Beta:        true,
+		Summary: `
+Sends message parts as objects to an Azure Blob Storage Account container. Each
+object is uploaded with the filename specified with the ` + "`container`" + `
+field.`,
+		Description: `
+In order to have a different path for each object you should use function
+interpolations described [here](/docs/configuration/interpolation#bloblang-queries), which are
+calculated per message of a batch.
+
+Metadata of messages can be set as the user defined metadata of their blobs by
+listing patterns of keys within the field ` + "`metadata.include_patterns`" + `,
+and no metadata is set by default. Since Azure only accepts metadata names that
+are valid C# identifiers, characters of keys that are not letters, digits or
+underscores are replaced with underscores, such that ` + "`Content-Type`" + `
+becomes ` + "`Content_Type`" + `.
+
+When the ` + "`blob_type`" + ` is ` + "`APPEND`" + ` the content type,
+content encoding and metadata of a blob are only set when it is created by the
+first message appended to it.
+
+### Batching
+
+It's common to want to upload messages as batched archives, the easiest way to
+do this is to batch your messages at the output level and join the batch of
+messages with an
+` + "[`archive`](/docs/components/processors/archive)" + ` and/or
+` + "[`compress`](/docs/components/processors/compress)" + ` processor.
+
+For example, if we wished to upload JSON documents as a single large document
+containing an array of objects we can do that with:
+
+` + "```yaml" + `
+output:
+  azure_blob_storage:
+    storage_account: TODO
+    storage_access_key: TODO
+    container: TODO
+    path: ${!count("files")}-${!timestamp_unix_nano()}.json
+    content_type: application/json
+    batching:
+      count: 100
+      period: 10s
+      processors:
+        - archive:
+            format: json_array
+` + "```" + ``,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.AzureBlobStorage, conf.AzureBlobStorage.Batching)
+		},
+		Async:      true,
+		FieldSpecs: azureBlobStorageFieldSpecs(),
+		Categories: []Category{
+			CategoryServices,
+			CategoryAzure,
+		},
+	}
+}
+
+func azureBlobStorageFieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldCommon("storage_account", "The storage account to upload messages to."),
+		docs.FieldCommon("storage_access_key", "The storage account access key."),
+		docs.FieldCommon(
+			"container", "The container for uploading the messages to.",
+			`messages-${!timestamp("2006")}`,
+		).SupportsInterpolation(false),
+		docs.FieldCommon(
+			"path", "The path of each message to upload.",
+			`${!count("files")}-${!timestamp_unix_nano()}.json`,
+			`${!meta("kafka_key")}.json`,
+			`${!json("doc.namespace")}/${!json("doc.id")}.json`,
+		).SupportsInterpolation(false),
+		docs.FieldAdvanced("blob_type", "Block and Append blobs are comprised of blocks, and each blob can support up to 50,000 blocks.").HasOptions(
+			"BLOCK", "APPEND",
+		).SupportsInterpolation(false),
+		docs.FieldCommon("content_type", "The content type to set for each blob.").SupportsInterpolation(false),
+		docs.FieldAdvanced("content_encoding", "An optional content encoding to set for each blob.").SupportsInterpolation(false),
+		docs.FieldAdvanced("metadata", "Specify criteria for which metadata values are set as the metadata of blobs.").WithChildren(
+			docs.FieldAdvanced("include_patterns", "Provide a list of explicit metadata key regular expression (re2) patterns to match against. Only metadata keys that match at least one pattern are set, and when empty no metadata is set.", []string{".*"}, []string{"^kafka_"}),
+			docs.FieldAdvanced("exclude_patterns", "Provide a list of metadata key regular expression (re2) patterns to exclude, which takes precedence over the include patterns.", []string{"^http_server_"}),
+		),
+		docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+		docs.FieldAdvanced("timeout", "The maximum period to wait on an upload before abandoning it and reattempting."),
+		batch.FieldSpec(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAzureBlobStorage creates a new AzureBlobStorage output type.
+func NewAzureBlobStorage(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	return newAzureBlobStorage(TypeAzureBlobStorage, conf.AzureBlobStorage, mgr, log, stats)
+}
+
+func newAzureBlobStorage(typeStr string, conf writer.AzureBlobStorageConfig, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	blobStorage, err := writer.NewAzureBlobStorage(conf, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.MaxInFlight == 1 {
+		w, err = NewWriter(
+			typeStr, blobStorage, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			typeStr, conf.MaxInFlight, blobStorage, log, stats,
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if bconf := conf.Batching; !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, nil
+}
+
+//------------------------------------------------------------------------------
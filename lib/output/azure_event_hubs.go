@@ -0,0 +1,83 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAzureEventHubs] = TypeSpec{
+		constructor: NewAzureEventHubs,
+		Summary: `
+Sends messages to an Azure Event Hub. [Metadata](/docs/configuration/metadata)
+from messages are sent as application properties.`,
+		Description: `
+This output connects to Event Hubs natively over AMQP, authenticating either
+with a shared access key connection string or with Azure Active Directory using
+a service principal or managed identity. When the ` + "`event_hub`" + ` field is
+empty the ` + "`EntityPath`" + ` of the connection string is used instead.
+
+The field ` + "`partition_key`" + ` can be dynamically set using function
+interpolations described [here](/docs/configuration/interpolation#bloblang-queries)
+and is hashed by Event Hubs in order to select the partition of a message, such
+that messages with the same key are delivered to the same partition in order.
+When the key is empty messages are distributed across partitions. When sending
+batched messages the interpolations are performed per message part.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.AzureEventHubs, conf.AzureEventHubs.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: append(
+			azureamqp.FieldSpecs(),
+			docs.FieldCommon("event_hub", "The name of the event hub to publish to."),
+			docs.FieldCommon("partition_key", "An optional key to publish messages with, which determines the partition of each message.", `${! meta("kafka_key") }`).SupportsInterpolation(false),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		),
+		Categories: []Category{
+			CategoryServices,
+			CategoryAzure,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAzureEventHubs creates a new Azure Event Hubs output type.
+func NewAzureEventHubs(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	a, err := writer.NewAzureEventHubs(conf.AzureEventHubs, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.AzureEventHubs.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeAzureEventHubs, a, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeAzureEventHubs, conf.AzureEventHubs.MaxInFlight, a, log, stats,
+		)
+	}
+	if bconf := conf.AzureEventHubs.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
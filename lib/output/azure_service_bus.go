@@ -0,0 +1,92 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeAzureServiceBus] = TypeSpec{
+		constructor: NewAzureServiceBus,
+		Summary: `
+Sends messages to an Azure Service Bus queue or topic.
+[Metadata](/docs/configuration/metadata) from messages are sent as application
+properties.`,
+		Description: `
+This output connects to Service Bus natively over AMQP, authenticating either
+with a shared access key connection string or with Azure Active Directory using
+a service principal or managed identity. Either a ` + "`queue`" + ` or a
+` + "`topic`" + ` must be specified unless the ` + "`EntityPath`" + ` of the
+connection string identifies the entity to send to.
+
+The fields ` + "`message_id`" + `, ` + "`correlation_id`" + `,
+` + "`session_id`" + ` and ` + "`scheduled_enqueue_time`" + ` can be
+dynamically set using function interpolations described
+[here](/docs/configuration/interpolation#bloblang-queries). When sending batched
+messages the interpolations are performed per message part.
+
+Messages sent to a session enabled entity must have a ` + "`session_id`" + `.
+When ` + "`scheduled_enqueue_time`" + ` results in an RFC 3339 timestamp the
+message is not made available to consumers until that time.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.AzureServiceBus, conf.AzureServiceBus.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: append(
+			azureamqp.FieldSpecs(),
+			docs.FieldCommon("queue", "The name of a queue to send to."),
+			docs.FieldCommon("topic", "The name of a topic to send to."),
+			docs.FieldAdvanced("message_id", "An optional ID to set for each message, which is used by entities with duplicate detection enabled.", `${! meta("id") }`).SupportsInterpolation(false),
+			docs.FieldAdvanced("correlation_id", "An optional correlation ID to set for each message.").SupportsInterpolation(false),
+			docs.FieldCommon("session_id", "An optional session ID to set for each message, which is required when sending to session enabled entities.", `${! json("user_id") }`).SupportsInterpolation(false),
+			docs.FieldAdvanced("scheduled_enqueue_time", "An optional RFC 3339 timestamp at which messages are made available to consumers.", `${! meta("scheduled_at") }`).SupportsInterpolation(false),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		),
+		Categories: []Category{
+			CategoryServices,
+			CategoryAzure,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAzureServiceBus creates a new Azure Service Bus output type.
+func NewAzureServiceBus(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	a, err := writer.NewAzureServiceBus(conf.AzureServiceBus, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.AzureServiceBus.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeAzureServiceBus, a, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeAzureServiceBus, conf.AzureServiceBus.MaxInFlight, a, log, stats,
+		)
+	}
+	if bconf := conf.AzureServiceBus.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
@@ -12,59 +12,47 @@ import (
 
 func init() {
 	Constructors[TypeBlobStorage] = TypeSpec{
-		constructor: NewAzureBlobStorage,
-		Beta:        true,
-		Summary: `
-Sends message parts as objects to an Azure Blob Storage Account container. Each
-object is uploaded with the filename specified with the ` + "`container`" + `
-field.`,
+		constructor: NewBlobStorage,
 		Description: `
-In order to have a different path for each object you should use function
-interpolations described [here](/docs/configuration/interpolation#bloblang-queries), which are
-calculated per message of a batch.`,
-		Async: true,
-		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("storage_account", "The storage account to upload messages to."),
-			docs.FieldCommon("storage_access_key", "The storage account access key."),
-			docs.FieldCommon(
-				"container", "The container for uploading the messages to.",
-				`messages-${!timestamp("2006")}`,
-			).SupportsInterpolation(false),
-			docs.FieldCommon(
-				"path", "The path of each message to upload.",
-				`${!count("files")}-${!timestamp_unix_nano()}.json`,
-				`${!meta("kafka_key")}.json`,
-				`${!json("doc.namespace")}/${!json("doc.id")}.json`,
-			).SupportsInterpolation(false),
-			docs.FieldAdvanced("blob_type", "Block and Append blobs are comprised of blocks, and each blob can support up to 50,000 blocks.").HasOptions(
-				"BLOCK", "APPEND",
-			).SupportsInterpolation(false),
-			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
-			docs.FieldAdvanced("timeout", "The maximum period to wait on an upload before abandoning it and reattempting."),
-		},
-		Categories: []Category{
-			CategoryServices,
-			CategoryAzure,
+DEPRECATED: This output is deprecated and scheduled for removal in Benthos V4.
+Please use [` + "`azure_blob_storage`" + `](azure_blob_storage) instead.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			sanit, err := sanitiseWithBatch(conf.BlobStorage, conf.BlobStorage.Batching)
+			if err != nil {
+				return nil, err
+			}
+			// Metadata is not supported by the deprecated output.
+			delete(sanit, "metadata")
+			return sanit, nil
 		},
+		Async:      true,
+		FieldSpecs: blobStorageFieldSpecs(),
+		Deprecated: true,
+	}
+}
+
+// blobStorageFieldSpecs returns the fields of the azure_blob_storage output
+// without those unsupported by the deprecated output.
+func blobStorageFieldSpecs() docs.FieldSpecs {
+	var specs docs.FieldSpecs
+	for _, f := range azureBlobStorageFieldSpecs() {
+		if f.Name != "metadata" {
+			specs = append(specs, f)
+		}
 	}
+	return specs
 }
 
 //------------------------------------------------------------------------------
 
-// NewAzureBlobStorage creates a new AzureBlobStorage output type.
-func NewAzureBlobStorage(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-	sthree, err := writer.NewAzureBlobStorage(conf.BlobStorage, log, stats)
-	if err != nil {
-		return nil, err
-	}
-	if conf.BlobStorage.MaxInFlight == 1 {
-		return NewWriter(
-			TypeBlobStorage, sthree, log, stats,
-		)
-	}
-	return NewAsyncWriter(
-		TypeBlobStorage, conf.BlobStorage.MaxInFlight, sthree, log, stats,
-	)
+// NewBlobStorage creates a new AzureBlobStorage output type from the
+// deprecated blob_storage config.
+// TODO: V4 Remove this.
+func NewBlobStorage(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	log.Warnln("The blob_storage output is deprecated, please use azure_blob_storage instead.")
+	// Metadata is not supported by the deprecated output.
+	conf.BlobStorage.Metadata = writer.NewAzureBlobStorageConfig().Metadata
+	return newAzureBlobStorage(TypeBlobStorage, conf.BlobStorage, mgr, log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,134 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeCassandra] = TypeSpec{
+		constructor: NewCassandra,
+		Summary: `
+Runs a query against a Cassandra or ScyllaDB cluster for each message.`,
+		Description: `
+The arguments of the query are the result of the ` + "`args_mapping`" + `, a
+[Bloblang mapping](/docs/guides/bloblang/about) that should evaluate to an
+array of values matching in size to the number of placeholders of the query.
+Values are converted into the types of the columns they are bound to, where
+numbers can be bound to any numeric column, strings can be bound to
+` + "`timestamp`" + ` columns as RFC 3339 timestamps, and objects and arrays
+can be bound to maps, sets, lists and user defined types.
+
+Queries are routed to a replica that owns the partition being written to where
+possible, and otherwise to the nodes of the cluster in turn. Batches of more
+than one message are executed as a single batch statement, which is either
+logged or unlogged depending on the ` + "`logged_batch`" + ` field. Logged
+batches guarantee that either all or none of the queries are eventually
+applied, whereas unlogged batches are cheaper but may be partially applied
+when they fail. Counter updates cannot be combined with other queries in
+batches, and so outputs that update counter columns should not batch messages.
+
+### Performance
+
+Batches that span many partitions place a heavy load on the coordinating node,
+and so it's recommended to keep batches small, especially when they are
+logged.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Basic Inserts",
+				Summary: `
+Insert each message as a row of a table, where the ` + "`created_at`" + `
+column is set to the time the message was written:`,
+				Config: `
+output:
+  cassandra:
+    addresses:
+      - localhost:9042
+    query: 'INSERT INTO shop.users (id, name, tags, created_at) VALUES (?, ?, ?, ?)'
+    args_mapping: |
+      root = [
+        this.user.id,
+        this.user.name,
+        this.user.tags,
+        timestamp_utc("2006-01-02T15:04:05.999Z07:00"),
+      ]
+    batching:
+      count: 50
+      period: 1s
+`,
+			},
+		},
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.Cassandra, conf.Cassandra.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("addresses", "A list of Cassandra nodes to connect to. Multiple comma separated addresses can be specified on a single line.", []string{"localhost:9042"}, []string{"foo:9042", "bar:9042"}, []string{"foo:9042,bar:9042"}),
+			tls.FieldSpec(),
+			docs.FieldAdvanced("password_authenticator", "Optional configuration of Cassandra authentication parameters.").WithChildren(
+				docs.FieldCommon("enabled", "Whether to use password authentication."),
+				docs.FieldCommon("username", "A username."),
+				docs.FieldCommon("password", "A password."),
+			),
+			docs.FieldAdvanced("disable_initial_host_lookup", "If enabled the driver will not attempt to get host info from the system.peers table. This can speed up queries but will mean that data_centre, rack and token information will not be available."),
+			docs.FieldCommon("query", "A query to execute for each message, where arguments are specified with `?` placeholders.", "INSERT INTO shop.users (id, name) VALUES (?, ?)"),
+			docs.FieldCommon("args_mapping", "A [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an array of values matching in size to the number of placeholders of the query.", "root = [ this.id, this.name ]"),
+			docs.FieldAdvanced("consistency", "The consistency level of queries.").HasOptions(
+				"ANY", "ONE", "TWO", "THREE", "QUORUM", "ALL", "LOCAL_QUORUM", "EACH_QUORUM", "LOCAL_ONE",
+			),
+			docs.FieldAdvanced("logged_batch", "Whether batches of messages are executed as logged batches, which are applied atomically, or unlogged batches."),
+			docs.FieldAdvanced("max_retries", "The maximum number of times to retry a query before failing."),
+			docs.FieldAdvanced("backoff", "Control time intervals between retry attempts.").WithChildren(
+				docs.FieldAdvanced("initial_interval", "The initial period to wait between retry attempts."),
+				docs.FieldAdvanced("max_interval", "The maximum period to wait between retry attempts."),
+			),
+			docs.FieldAdvanced("timeout", "The maximum period of time to wait for connections and queries to complete."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewCassandra creates a new Cassandra output type.
+func NewCassandra(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	c, err := writer.NewCassandra(conf.Cassandra, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.Cassandra.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeCassandra, c, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeCassandra, conf.Cassandra.MaxInFlight, c, log, stats,
+		)
+	}
+	if bconf := conf.Cassandra.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,111 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeClickHouse] = TypeSpec{
+		constructor: NewClickHouse,
+		Summary: `
+Inserts messages as rows of a ClickHouse table using the native protocol.`,
+		Description: `
+Each message must be a JSON object, where the value of each field is inserted
+into the column of the same name. The columns of the table are read when
+connecting, and field values are converted into the type of their column, for
+example strings containing numbers are converted into numeric columns, numbers
+are converted into ` + "`DateTime`" + ` columns as unix timestamps, and objects
+and arrays are converted into ` + "`String`" + ` columns as JSON. Fields that
+are missing from a message are inserted as ` + "`NULL`" + ` into
+` + "`Nullable`" + ` columns and as the zero value of the type for all other
+columns. Messages that cannot be converted fail the entire batch.
+
+The columns that are inserted into can be restricted with the
+` + "`columns`" + ` field, in which case the remaining columns of the table
+take their default values. Materialized and alias columns are never inserted
+into.
+
+### Performance
+
+ClickHouse performs best when rows are inserted in large and infrequent
+batches, and each batch of messages is inserted as a single block of rows. It
+is therefore strongly recommended that this output is configured with a
+[batching policy](/docs/configuration/batching):
+
+` + "```yaml" + `
+output:
+  clickhouse:
+    data_source_name: tcp://localhost:9000?database=analytics&username=benthos
+    table: events
+    batching:
+      count: 10000
+      period: 1s
+` + "```" + `
+
+When it isn't possible to batch messages within Benthos, such as when many
+instances write small batches to the same table, the ` + "`async_insert`" + `
+field enables the asynchronous inserts of ClickHouse (21.11 and above), where
+the server buffers rows from many inserts and writes them together.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.ClickHouse, conf.ClickHouse.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon(
+				"data_source_name", "A [data source name](https://github.com/ClickHouse/clickhouse-go#dsn) of the form `tcp://[netloc][:port][?param1=value1&...&paramN=valueN]`, which identifies the server, database and credentials to connect with.",
+				"tcp://localhost:9000?database=analytics&username=benthos&password=foo",
+				"tcp://host1:9000?alt_hosts=host2:9000,host3:9000&compress=true",
+			),
+			docs.FieldCommon("table", "The table to insert rows into, which can be prefixed with a database.", "events", "analytics.events"),
+			docs.FieldAdvanced("columns", "An optional list of columns to insert into. If empty all columns of the table are inserted into.", []string{"id", "timestamp", "event"}),
+			docs.FieldAdvanced("async_insert", "Whether to enable asynchronous inserts, where rows are buffered by the server before being written to the table."),
+			docs.FieldAdvanced("wait_for_async_insert", "When `async_insert` is enabled, whether to wait for rows to be written to the table before acknowledging a batch. Disabling this improves throughput but messages can be lost if the server fails before rows are written."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewClickHouse creates a new ClickHouse output type.
+func NewClickHouse(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	c, err := writer.NewClickHouse(conf.ClickHouse, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.ClickHouse.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeClickHouse, c, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeClickHouse, conf.ClickHouse.MaxInFlight, c, log, stats,
+		)
+	}
+	if bconf := conf.ClickHouse.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
@@ -67,158 +67,251 @@ var Constructors = map[string]TypeSpec{}
 
 // String constants representing each output type.
 const (
-	TypeAMQP            = "amqp"
-	TypeAMQP09          = "amqp_0_9"
-	TypeAMQP1           = "amqp_1"
-	TypeBlobStorage     = "blob_storage"
-	TypeBroker          = "broker"
-	TypeCache           = "cache"
-	TypeDrop            = "drop"
-	TypeDropOnError     = "drop_on_error"
-	TypeDynamic         = "dynamic"
-	TypeDynamoDB        = "dynamodb"
-	TypeElasticsearch   = "elasticsearch"
-	TypeFile            = "file"
-	TypeFiles           = "files"
-	TypeGCPPubSub       = "gcp_pubsub"
-	TypeHDFS            = "hdfs"
-	TypeHTTPClient      = "http_client"
-	TypeHTTPServer      = "http_server"
-	TypeInproc          = "inproc"
-	TypeKafka           = "kafka"
-	TypeKinesis         = "kinesis"
-	TypeKinesisFirehose = "kinesis_firehose"
-	TypeMQTT            = "mqtt"
-	TypeNanomsg         = "nanomsg"
-	TypeNATS            = "nats"
-	TypeNATSStream      = "nats_stream"
-	TypeNSQ             = "nsq"
-	TypeRedisHash       = "redis_hash"
-	TypeRedisList       = "redis_list"
-	TypeRedisPubSub     = "redis_pubsub"
-	TypeRedisStreams    = "redis_streams"
-	TypeResource        = "resource"
-	TypeRetry           = "retry"
-	TypeS3              = "s3"
-	TypeSNS             = "sns"
-	TypeSQS             = "sqs"
-	TypeSTDOUT          = "stdout"
-	TypeSwitch          = "switch"
-	TypeSyncResponse    = "sync_response"
-	TypeTableStorage    = "table_storage"
-	TypeTCP             = "tcp"
-	TypeTry             = "try"
-	TypeUDP             = "udp"
-	TypeSocket          = "socket"
-	TypeWebsocket       = "websocket"
-	TypeZMQ4            = "zmq4"
+	TypeAbly                  = "ably"
+	TypeAMQP                  = "amqp"
+	TypeAMQP09                = "amqp_0_9"
+	TypeAMQP1                 = "amqp_1"
+	TypeAzureBlobStorage      = "azure_blob_storage"
+	TypeAzureEventHubs        = "azure_event_hubs"
+	TypeAzureServiceBus       = "azure_service_bus"
+	TypeBlobStorage           = "blob_storage"
+	TypeBroker                = "broker"
+	TypeCache                 = "cache"
+	TypeCassandra             = "cassandra"
+	TypeClickHouse            = "clickhouse"
+	TypeCosmosDB              = "cosmosdb"
+	TypeDiscord               = "discord"
+	TypeDrop                  = "drop"
+	TypeDropOnError           = "drop_on_error"
+	TypeDynamic               = "dynamic"
+	TypeDynamoDB              = "dynamodb"
+	TypeElasticsearch         = "elasticsearch"
+	TypeFallback              = "fallback"
+	TypeFile                  = "file"
+	TypeFiles                 = "files"
+	TypeGCPBigQuery           = "gcp_bigquery"
+	TypeGCPCloudStorage       = "gcp_cloud_storage"
+	TypeGCPPubSub             = "gcp_pubsub"
+	TypeGrafanaLoki           = "grafana_loki"
+	TypeGRPCClient            = "grpc_client"
+	TypeHDFS                  = "hdfs"
+	TypeHTTPClient            = "http_client"
+	TypeHTTPServer            = "http_server"
+	TypeInfluxDB              = "influxdb"
+	TypeInproc                = "inproc"
+	TypeKafka                 = "kafka"
+	TypeKinesis               = "kinesis"
+	TypeKinesisFirehose       = "kinesis_firehose"
+	TypeLambda                = "lambda"
+	TypeMongoDB               = "mongodb"
+	TypeMQTT                  = "mqtt"
+	TypeMQTT5                 = "mqtt_5"
+	TypeMSTeams               = "msteams"
+	TypeNanomsg               = "nanomsg"
+	TypeNATS                  = "nats"
+	TypeNATSStream            = "nats_stream"
+	TypeNSQ                   = "nsq"
+	TypeOpenSearch            = "opensearch"
+	TypePrometheusRemoteWrite = "prometheus_remote_write"
+	TypePulsar                = "pulsar"
+	TypePusher                = "pusher"
+	TypeRedisHash             = "redis_hash"
+	TypeRedisList             = "redis_list"
+	TypeRedisPubSub           = "redis_pubsub"
+	TypeRedisStreams          = "redis_streams"
+	TypeReject                = "reject"
+	TypeResource              = "resource"
+	TypeRetry                 = "retry"
+	TypeS3                    = "s3"
+	TypeSFTP                  = "sftp"
+	TypeSlack                 = "slack"
+	TypeSMTP                  = "smtp"
+	TypeSNS                   = "sns"
+	TypeSnowflake             = "snowflake"
+	TypeSplunkHEC             = "splunk_hec"
+	TypeSQLInsert             = "sql_insert"
+	TypeSQLRaw                = "sql_raw"
+	TypeSQS                   = "sqs"
+	TypeSTDOUT                = "stdout"
+	TypeSubprocess            = "subprocess"
+	TypeSwitch                = "switch"
+	TypeSyncResponse          = "sync_response"
+	TypeTableStorage          = "table_storage"
+	TypeTCP                   = "tcp"
+	TypeTry                   = "try"
+	TypeUDP                   = "udp"
+	TypeSocket                = "socket"
+	TypeWebsocket             = "websocket"
+	TypeZMQ4                  = "zmq4"
 )
 
 //------------------------------------------------------------------------------
 
 // Config is the all encompassing configuration struct for all output types.
 type Config struct {
-	Type            string                         `json:"type" yaml:"type"`
-	AMQP            writer.AMQPConfig              `json:"amqp" yaml:"amqp"`
-	AMQP09          writer.AMQPConfig              `json:"amqp_0_9" yaml:"amqp_0_9"`
-	AMQP1           writer.AMQP1Config             `json:"amqp_1" yaml:"amqp_1"`
-	BlobStorage     writer.AzureBlobStorageConfig  `json:"blob_storage" yaml:"blob_storage"`
-	Broker          BrokerConfig                   `json:"broker" yaml:"broker"`
-	Cache           writer.CacheConfig             `json:"cache" yaml:"cache"`
-	Drop            writer.DropConfig              `json:"drop" yaml:"drop"`
-	DropOnError     DropOnErrorConfig              `json:"drop_on_error" yaml:"drop_on_error"`
-	Dynamic         DynamicConfig                  `json:"dynamic" yaml:"dynamic"`
-	DynamoDB        writer.DynamoDBConfig          `json:"dynamodb" yaml:"dynamodb"`
-	Elasticsearch   writer.ElasticsearchConfig     `json:"elasticsearch" yaml:"elasticsearch"`
-	File            FileConfig                     `json:"file" yaml:"file"`
-	Files           writer.FilesConfig             `json:"files" yaml:"files"`
-	GCPPubSub       writer.GCPPubSubConfig         `json:"gcp_pubsub" yaml:"gcp_pubsub"`
-	HDFS            writer.HDFSConfig              `json:"hdfs" yaml:"hdfs"`
-	HTTPClient      writer.HTTPClientConfig        `json:"http_client" yaml:"http_client"`
-	HTTPServer      HTTPServerConfig               `json:"http_server" yaml:"http_server"`
-	Inproc          InprocConfig                   `json:"inproc" yaml:"inproc"`
-	Kafka           writer.KafkaConfig             `json:"kafka" yaml:"kafka"`
-	Kinesis         writer.KinesisConfig           `json:"kinesis" yaml:"kinesis"`
-	KinesisFirehose writer.KinesisFirehoseConfig   `json:"kinesis_firehose" yaml:"kinesis_firehose"`
-	MQTT            writer.MQTTConfig              `json:"mqtt" yaml:"mqtt"`
-	Nanomsg         writer.NanomsgConfig           `json:"nanomsg" yaml:"nanomsg"`
-	NATS            writer.NATSConfig              `json:"nats" yaml:"nats"`
-	NATSStream      writer.NATSStreamConfig        `json:"nats_stream" yaml:"nats_stream"`
-	NSQ             writer.NSQConfig               `json:"nsq" yaml:"nsq"`
-	Plugin          interface{}                    `json:"plugin,omitempty" yaml:"plugin,omitempty"`
-	RedisHash       writer.RedisHashConfig         `json:"redis_hash" yaml:"redis_hash"`
-	RedisList       writer.RedisListConfig         `json:"redis_list" yaml:"redis_list"`
-	RedisPubSub     writer.RedisPubSubConfig       `json:"redis_pubsub" yaml:"redis_pubsub"`
-	RedisStreams    writer.RedisStreamsConfig      `json:"redis_streams" yaml:"redis_streams"`
-	Resource        string                         `json:"resource" yaml:"resource"`
-	Retry           RetryConfig                    `json:"retry" yaml:"retry"`
-	S3              writer.AmazonS3Config          `json:"s3" yaml:"s3"`
-	SNS             writer.SNSConfig               `json:"sns" yaml:"sns"`
-	SQS             writer.AmazonSQSConfig         `json:"sqs" yaml:"sqs"`
-	STDOUT          STDOUTConfig                   `json:"stdout" yaml:"stdout"`
-	Switch          SwitchConfig                   `json:"switch" yaml:"switch"`
-	SyncResponse    struct{}                       `json:"sync_response" yaml:"sync_response"`
-	TableStorage    writer.AzureTableStorageConfig `json:"table_storage" yaml:"table_storage"`
-	TCP             writer.TCPConfig               `json:"tcp" yaml:"tcp"`
-	Try             TryConfig                      `json:"try" yaml:"try"`
-	UDP             writer.UDPConfig               `json:"udp" yaml:"udp"`
-	Socket          writer.SocketConfig            `json:"socket" yaml:"socket"`
-	Websocket       writer.WebsocketConfig         `json:"websocket" yaml:"websocket"`
-	ZMQ4            *writer.ZMQ4Config             `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
-	Processors      []processor.Config             `json:"processors" yaml:"processors"`
+	Type                  string                             `json:"type" yaml:"type"`
+	Ably                  writer.AblyConfig                  `json:"ably" yaml:"ably"`
+	AMQP                  writer.AMQPConfig                  `json:"amqp" yaml:"amqp"`
+	AMQP09                writer.AMQPConfig                  `json:"amqp_0_9" yaml:"amqp_0_9"`
+	AMQP1                 writer.AMQP1Config                 `json:"amqp_1" yaml:"amqp_1"`
+	AzureBlobStorage      writer.AzureBlobStorageConfig      `json:"azure_blob_storage" yaml:"azure_blob_storage"`
+	AzureEventHubs        writer.AzureEventHubsConfig        `json:"azure_event_hubs" yaml:"azure_event_hubs"`
+	AzureServiceBus       writer.AzureServiceBusConfig       `json:"azure_service_bus" yaml:"azure_service_bus"`
+	BlobStorage           writer.AzureBlobStorageConfig      `json:"blob_storage" yaml:"blob_storage"`
+	Broker                BrokerConfig                       `json:"broker" yaml:"broker"`
+	Cache                 writer.CacheConfig                 `json:"cache" yaml:"cache"`
+	Cassandra             writer.CassandraConfig             `json:"cassandra" yaml:"cassandra"`
+	ClickHouse            writer.ClickHouseConfig            `json:"clickhouse" yaml:"clickhouse"`
+	CosmosDB              writer.CosmosDBConfig              `json:"cosmosdb" yaml:"cosmosdb"`
+	Discord               writer.DiscordConfig               `json:"discord" yaml:"discord"`
+	Drop                  writer.DropConfig                  `json:"drop" yaml:"drop"`
+	DropOnError           DropOnErrorConfig                  `json:"drop_on_error" yaml:"drop_on_error"`
+	Dynamic               DynamicConfig                      `json:"dynamic" yaml:"dynamic"`
+	DynamoDB              writer.DynamoDBConfig              `json:"dynamodb" yaml:"dynamodb"`
+	Elasticsearch         writer.ElasticsearchConfig         `json:"elasticsearch" yaml:"elasticsearch"`
+	Fallback              FallbackConfig                     `json:"fallback" yaml:"fallback"`
+	File                  FileConfig                         `json:"file" yaml:"file"`
+	Files                 writer.FilesConfig                 `json:"files" yaml:"files"`
+	GCPBigQuery           writer.GCPBigQueryConfig           `json:"gcp_bigquery" yaml:"gcp_bigquery"`
+	GCPCloudStorage       writer.GCPCloudStorageConfig       `json:"gcp_cloud_storage" yaml:"gcp_cloud_storage"`
+	GCPPubSub             writer.GCPPubSubConfig             `json:"gcp_pubsub" yaml:"gcp_pubsub"`
+	GrafanaLoki           writer.GrafanaLokiConfig           `json:"grafana_loki" yaml:"grafana_loki"`
+	GRPCClient            writer.GRPCClientConfig            `json:"grpc_client" yaml:"grpc_client"`
+	HDFS                  writer.HDFSConfig                  `json:"hdfs" yaml:"hdfs"`
+	HTTPClient            writer.HTTPClientConfig            `json:"http_client" yaml:"http_client"`
+	HTTPServer            HTTPServerConfig                   `json:"http_server" yaml:"http_server"`
+	InfluxDB              writer.InfluxDBConfig              `json:"influxdb" yaml:"influxdb"`
+	Inproc                InprocConfig                       `json:"inproc" yaml:"inproc"`
+	Kafka                 writer.KafkaConfig                 `json:"kafka" yaml:"kafka"`
+	Kinesis               writer.KinesisConfig               `json:"kinesis" yaml:"kinesis"`
+	KinesisFirehose       writer.KinesisFirehoseConfig       `json:"kinesis_firehose" yaml:"kinesis_firehose"`
+	Lambda                writer.LambdaConfig                `json:"lambda" yaml:"lambda"`
+	MongoDB               writer.MongoDBConfig               `json:"mongodb" yaml:"mongodb"`
+	MQTT                  writer.MQTTConfig                  `json:"mqtt" yaml:"mqtt"`
+	MQTT5                 writer.MQTT5Config                 `json:"mqtt_5" yaml:"mqtt_5"`
+	MSTeams               writer.MSTeamsConfig               `json:"msteams" yaml:"msteams"`
+	Nanomsg               writer.NanomsgConfig               `json:"nanomsg" yaml:"nanomsg"`
+	NATS                  writer.NATSConfig                  `json:"nats" yaml:"nats"`
+	NATSStream            writer.NATSStreamConfig            `json:"nats_stream" yaml:"nats_stream"`
+	NSQ                   writer.NSQConfig                   `json:"nsq" yaml:"nsq"`
+	OpenSearch            writer.OpenSearchConfig            `json:"opensearch" yaml:"opensearch"`
+	PrometheusRemoteWrite writer.PrometheusRemoteWriteConfig `json:"prometheus_remote_write" yaml:"prometheus_remote_write"`
+	Pulsar                writer.PulsarConfig                `json:"pulsar" yaml:"pulsar"`
+	Pusher                writer.PusherConfig                `json:"pusher" yaml:"pusher"`
+	Plugin                interface{}                        `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	RedisHash             writer.RedisHashConfig             `json:"redis_hash" yaml:"redis_hash"`
+	RedisList             writer.RedisListConfig             `json:"redis_list" yaml:"redis_list"`
+	RedisPubSub           writer.RedisPubSubConfig           `json:"redis_pubsub" yaml:"redis_pubsub"`
+	RedisStreams          writer.RedisStreamsConfig          `json:"redis_streams" yaml:"redis_streams"`
+	Reject                RejectConfig                       `json:"reject" yaml:"reject"`
+	Resource              string                             `json:"resource" yaml:"resource"`
+	Retry                 RetryConfig                        `json:"retry" yaml:"retry"`
+	S3                    writer.AmazonS3Config              `json:"s3" yaml:"s3"`
+	SFTP                  writer.SFTPConfig                  `json:"sftp" yaml:"sftp"`
+	Slack                 writer.SlackConfig                 `json:"slack" yaml:"slack"`
+	SMTP                  writer.SMTPConfig                  `json:"smtp" yaml:"smtp"`
+	SNS                   writer.SNSConfig                   `json:"sns" yaml:"sns"`
+	Snowflake             writer.SnowflakeConfig             `json:"snowflake" yaml:"snowflake"`
+	SplunkHEC             writer.SplunkHECConfig             `json:"splunk_hec" yaml:"splunk_hec"`
+	SQLInsert             writer.SQLInsertConfig             `json:"sql_insert" yaml:"sql_insert"`
+	SQLRaw                writer.SQLRawConfig                `json:"sql_raw" yaml:"sql_raw"`
+	SQS                   writer.AmazonSQSConfig             `json:"sqs" yaml:"sqs"`
+	STDOUT                STDOUTConfig                       `json:"stdout" yaml:"stdout"`
+	Subprocess            writer.SubprocessConfig            `json:"subprocess" yaml:"subprocess"`
+	Switch                SwitchConfig                       `json:"switch" yaml:"switch"`
+	SyncResponse          struct{}                           `json:"sync_response" yaml:"sync_response"`
+	TableStorage          writer.AzureTableStorageConfig     `json:"table_storage" yaml:"table_storage"`
+	TCP                   writer.TCPConfig                   `json:"tcp" yaml:"tcp"`
+	Try                   TryConfig                          `json:"try" yaml:"try"`
+	UDP                   writer.UDPConfig                   `json:"udp" yaml:"udp"`
+	Socket                writer.SocketConfig                `json:"socket" yaml:"socket"`
+	Websocket             writer.WebsocketConfig             `json:"websocket" yaml:"websocket"`
+	ZMQ4                  *writer.ZMQ4Config                 `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
+	Processors            []processor.Config                 `json:"processors" yaml:"processors"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Type:            "stdout",
-		AMQP:            writer.NewAMQPConfig(),
-		AMQP09:          writer.NewAMQPConfig(),
-		AMQP1:           writer.NewAMQP1Config(),
-		BlobStorage:     writer.NewAzureBlobStorageConfig(),
-		Broker:          NewBrokerConfig(),
-		Cache:           writer.NewCacheConfig(),
-		Drop:            writer.NewDropConfig(),
-		DropOnError:     NewDropOnErrorConfig(),
-		Dynamic:         NewDynamicConfig(),
-		DynamoDB:        writer.NewDynamoDBConfig(),
-		Elasticsearch:   writer.NewElasticsearchConfig(),
-		File:            NewFileConfig(),
-		Files:           writer.NewFilesConfig(),
-		GCPPubSub:       writer.NewGCPPubSubConfig(),
-		HDFS:            writer.NewHDFSConfig(),
-		HTTPClient:      writer.NewHTTPClientConfig(),
-		HTTPServer:      NewHTTPServerConfig(),
-		Inproc:          NewInprocConfig(),
-		Kafka:           writer.NewKafkaConfig(),
-		Kinesis:         writer.NewKinesisConfig(),
-		KinesisFirehose: writer.NewKinesisFirehoseConfig(),
-		MQTT:            writer.NewMQTTConfig(),
-		Nanomsg:         writer.NewNanomsgConfig(),
-		NATS:            writer.NewNATSConfig(),
-		NATSStream:      writer.NewNATSStreamConfig(),
-		NSQ:             writer.NewNSQConfig(),
-		Plugin:          nil,
-		RedisHash:       writer.NewRedisHashConfig(),
-		RedisList:       writer.NewRedisListConfig(),
-		RedisPubSub:     writer.NewRedisPubSubConfig(),
-		RedisStreams:    writer.NewRedisStreamsConfig(),
-		Resource:        "",
-		Retry:           NewRetryConfig(),
-		S3:              writer.NewAmazonS3Config(),
-		SNS:             writer.NewSNSConfig(),
-		SQS:             writer.NewAmazonSQSConfig(),
-		STDOUT:          NewSTDOUTConfig(),
-		Switch:          NewSwitchConfig(),
-		SyncResponse:    struct{}{},
-		TableStorage:    writer.NewAzureTableStorageConfig(),
-		TCP:             writer.NewTCPConfig(),
-		Try:             NewTryConfig(),
-		UDP:             writer.NewUDPConfig(),
-		Socket:          writer.NewSocketConfig(),
-		Websocket:       writer.NewWebsocketConfig(),
-		ZMQ4:            writer.NewZMQ4Config(),
-		Processors:      []processor.Config{},
+		Type:                  "stdout",
+		Ably:                  writer.NewAblyConfig(),
+		AMQP:                  writer.NewAMQPConfig(),
+		AMQP09:                writer.NewAMQPConfig(),
+		AMQP1:                 writer.NewAMQP1Config(),
+		AzureBlobStorage:      writer.NewAzureBlobStorageConfig(),
+		AzureEventHubs:        writer.NewAzureEventHubsConfig(),
+		AzureServiceBus:       writer.NewAzureServiceBusConfig(),
+		BlobStorage:           writer.NewAzureBlobStorageConfig(),
+		Broker:                NewBrokerConfig(),
+		Cache:                 writer.NewCacheConfig(),
+		Cassandra:             writer.NewCassandraConfig(),
+		ClickHouse:            writer.NewClickHouseConfig(),
+		CosmosDB:              writer.NewCosmosDBConfig(),
+		Discord:               writer.NewDiscordConfig(),
+		Drop:                  writer.NewDropConfig(),
+		DropOnError:           NewDropOnErrorConfig(),
+		Dynamic:               NewDynamicConfig(),
+		DynamoDB:              writer.NewDynamoDBConfig(),
+		Elasticsearch:         writer.NewElasticsearchConfig(),
+		Fallback:              NewFallbackConfig(),
+		File:                  NewFileConfig(),
+		Files:                 writer.NewFilesConfig(),
+		GCPBigQuery:           writer.NewGCPBigQueryConfig(),
+		GCPCloudStorage:       writer.NewGCPCloudStorageConfig(),
+		GCPPubSub:             writer.NewGCPPubSubConfig(),
+		GrafanaLoki:           writer.NewGrafanaLokiConfig(),
+		GRPCClient:            writer.NewGRPCClientConfig(),
+		HDFS:                  writer.NewHDFSConfig(),
+		HTTPClient:            writer.NewHTTPClientConfig(),
+		HTTPServer:            NewHTTPServerConfig(),
+		InfluxDB:              writer.NewInfluxDBConfig(),
+		Inproc:                NewInprocConfig(),
+		Kafka:                 writer.NewKafkaConfig(),
+		Kinesis:               writer.NewKinesisConfig(),
+		KinesisFirehose:       writer.NewKinesisFirehoseConfig(),
+		Lambda:                writer.NewLambdaConfig(),
+		MongoDB:               writer.NewMongoDBConfig(),
+		MQTT:                  writer.NewMQTTConfig(),
+		MQTT5:                 writer.NewMQTT5Config(),
+		MSTeams:               writer.NewMSTeamsConfig(),
+		Nanomsg:               writer.NewNanomsgConfig(),
+		NATS:                  writer.NewNATSConfig(),
+		NATSStream:            writer.NewNATSStreamConfig(),
+		NSQ:                   writer.NewNSQConfig(),
+		OpenSearch:            writer.NewOpenSearchConfig(),
+		PrometheusRemoteWrite: writer.NewPrometheusRemoteWriteConfig(),
+		Pulsar:                writer.NewPulsarConfig(),
+		Pusher:                writer.NewPusherConfig(),
+		Plugin:                nil,
+		RedisHash:             writer.NewRedisHashConfig(),
+		RedisList:             writer.NewRedisListConfig(),
+		RedisPubSub:           writer.NewRedisPubSubConfig(),
+		RedisStreams:          writer.NewRedisStreamsConfig(),
+		Reject:                NewRejectConfig(),
+		Resource:              "",
+		Retry:                 NewRetryConfig(),
+		S3:                    writer.NewAmazonS3Config(),
+		SFTP:                  writer.NewSFTPConfig(),
+		Slack:                 writer.NewSlackConfig(),
+		SMTP:                  writer.NewSMTPConfig(),
+		SNS:                   writer.NewSNSConfig(),
+		Snowflake:             writer.NewSnowflakeConfig(),
+		SplunkHEC:             writer.NewSplunkHECConfig(),
+		SQLInsert:             writer.NewSQLInsertConfig(),
+		SQLRaw:                writer.NewSQLRawConfig(),
+		SQS:                   writer.NewAmazonSQSConfig(),
+		STDOUT:                NewSTDOUTConfig(),
+		Subprocess:            writer.NewSubprocessConfig(),
+		Switch:                NewSwitchConfig(),
+		SyncResponse:          struct{}{},
+		TableStorage:          writer.NewAzureTableStorageConfig(),
+		TCP:                   writer.NewTCPConfig(),
+		Try:                   NewTryConfig(),
+		UDP:                   writer.NewUDPConfig(),
+		Socket:                writer.NewSocketConfig(),
+		Websocket:             writer.NewWebsocketConfig(),
+		ZMQ4:                  writer.NewZMQ4Config(),
+		Processors:            []processor.Config{},
 	}
 }
 
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/azureaad"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeCosmosDB] = TypeSpec{
+		constructor: NewCosmosDB,
+		Summary: `
+Writes messages as documents to a container of an Azure CosmosDB account via
+the SQL API.`,
+		Description: `
+Each message must be a JSON object, which is written as a document with either
+an upsert or a create ` + "`operation`" + `. Documents require a string
+` + "`id`" + ` field, which can be set from an
+[interpolated string](/docs/configuration/interpolation#bloblang-queries) with
+the ` + "`id`" + ` field of this output, where it replaces any existing id of a
+message.
+
+The ` + "`partition_key_mapping`" + ` is a
+[Bloblang mapping](/docs/guides/bloblang/about) that should evaluate to the
+partition key value of a document, which must match the value found at the
+partition key path of the container within the document.
+
+When a ` + "`ttl`" + ` is set it's added to documents that don't already have a
+` + "`ttl`" + ` field, which only takes effect if time to live is enabled on the
+container.
+
+Messages that cannot be written are rejected individually, which allows
+[error handling patterns](/docs/configuration/error_handling) to deal with
+them. Requests that exceed the provisioned throughput of a container are
+reattempted after the period requested by CosmosDB.
+
+### Authentication
+
+The output authenticates with either an ` + "`account_key`" + ` of the
+` + "`endpoint`" + `, a ` + "`connection_string`" + ` containing both, or with
+Azure Active Directory when ` + "`aad.enabled`" + ` is set, in which case a
+managed identity is used unless the credentials of a service principal are
+provided. Identities require a data plane role of the account, such as Cosmos
+DB Built-in Data Contributor.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Device Readings",
+				Summary: `
+Upsert the latest reading of each device into a container partitioned by the
+site of devices, with readings expiring after a week:`,
+				Config: `
+output:
+  cosmosdb:
+    endpoint: https://example.documents.azure.com:443/
+    aad:
+      enabled: true
+    database: telemetry
+    container: readings
+    id: '${! json("device_id") }'
+    partition_key_mapping: root = this.site
+    ttl: 168h
+`,
+			},
+		},
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.CosmosDB, conf.CosmosDB.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("endpoint", "The endpoint of the account.", "https://example.documents.azure.com:443/"),
+			docs.FieldCommon("account_key", "A key of the account. It is recommended that you use environment variables to populate this field.", "${COSMOSDB_ACCOUNT_KEY}"),
+			docs.FieldAdvanced("connection_string", "A connection string containing the endpoint and a key of the account, which can be used instead of the endpoint and account key fields.", "AccountEndpoint=https://example.documents.azure.com:443/;AccountKey=${COSMOSDB_ACCOUNT_KEY};"),
+			azureaad.FieldSpec("Authenticate with Azure Active Directory instead of an account key, using either a service principal or, when a client secret is not provided, a managed identity."),
+			docs.FieldCommon("database", "The database of the container."),
+			docs.FieldCommon("container", "The container to write documents to."),
+			docs.FieldCommon("operation", "Whether to upsert documents, replacing existing documents of the same id, or to create them and reject messages of existing documents.").HasOptions("upsert", "create"),
+			docs.FieldCommon("id", "An optional id to set on documents.", `${! json("device_id") }`, `${! uuid_v4() }`).SupportsInterpolation(false),
+			docs.FieldCommon("partition_key_mapping", "A [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to the partition key value of documents.", `root = this.tenant`, `root = meta("kafka_key")`),
+			docs.FieldCommon("ttl", "An optional time to live to add to documents, which is rounded down to whole seconds.", "24h"),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on a request before abandoning it and reattempting."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+			CategoryAzure,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewCosmosDB creates a new CosmosDB output type.
+func NewCosmosDB(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	c, err := writer.NewCosmosDB(conf.CosmosDB, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.CosmosDB.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeCosmosDB, c, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeCosmosDB, conf.CosmosDB.MaxInFlight, c, log, stats,
+		)
+	}
+	if bconf := conf.CosmosDB.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
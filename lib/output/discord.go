@@ -0,0 +1,92 @@
+package output
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeDiscord] = TypeSpec{
+		constructor: NewDiscord,
+		Summary: `
+Posts messages to a Discord channel via a
+[webhook](https://discord.com/developers/docs/resources/webhook).`,
+		Description: `
+Each message is posted to the webhook as a JSON payload. The ` + "`content`" + `
+field of the payload is set from an
+[interpolated string](/docs/configuration/interpolation#bloblang-queries),
+which by default is the raw contents of the message. Richer messages can be
+built with the ` + "`payload_mapping`" + `, a
+[Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an
+object of payload fields such as ` + "`embeds`" + ` or ` + "`username`" + `.
+Fields set by the mapping take precedence over the ` + "`content`" + ` field.
+
+When the ` + "`thread_id`" + ` field resolves to a non-empty string for a
+message it's posted to the thread of that ID.
+
+### Rate Limits
+
+Discord limits the rate at which messages can be posted to a webhook and
+announces when a limit has been reached with the headers of its responses.
+Messages are delayed until a limit has reset, and when a request is rate
+limited regardless it's reattempted once the period requested by Discord has
+elapsed.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Alerting",
+				Summary: `
+Post alerts as embeds, coloured by their severity:`,
+				Config: `
+output:
+  discord:
+    url: https://discord.com/api/webhooks/000000000000000000/XXXXXXXXXXXXXXXXXXXX
+    content: ""
+    payload_mapping: |
+      root.embeds = [{
+        "title": this.summary,
+        "description": this.details,
+        "color": if this.severity == "critical" { 15158332 } else { 15105570 }
+      }]
+`,
+			},
+		},
+		Async: true,
+		Beta:  true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "The URL of the webhook to post messages to."),
+			docs.FieldCommon("content", "The content of messages.").SupportsInterpolation(false),
+			docs.FieldCommon("payload_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an object of payload fields.", `root.embeds = [ { "title": this.summary, "description": this.details } ]`),
+			docs.FieldAdvanced("thread_id", "An optional ID of a thread to post messages to.").SupportsInterpolation(false),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on a request before abandoning it and reattempting."),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput, at the cost of the order of messages."),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewDiscord creates a new Discord output type.
+func NewDiscord(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	d, err := writer.NewDiscord(conf.Discord, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Discord.MaxInFlight == 1 {
+		return NewWriter(
+			TypeDiscord, d, log, stats,
+		)
+	}
+	return NewAsyncWriter(
+		TypeDiscord, conf.Discord.MaxInFlight, d, log, stats,
+	)
+}
+
+//------------------------------------------------------------------------------
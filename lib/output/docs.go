@@ -107,7 +107,8 @@ conditions please [read the docs here](/docs/components/conditions/about).
 ### Dead Letter Queues
 
 It's possible to create fallback outputs for when an output target fails using
-a ` + "[`try`](/docs/components/outputs/try)" + ` output.`
+a ` + "[`fallback`](/docs/components/outputs/fallback)" + ` output, which
+adds the error of a failed attempt to messages as metadata.`
 
 // Descriptions returns a formatted string of collated descriptions of each
 // type.
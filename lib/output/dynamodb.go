@@ -58,6 +58,52 @@ In which case the top level document fields will be written at the root of the
 item, potentially overwriting previously defined column values. If a path is not
 found within a document the column will not be populated.
 
+### Batching
+
+Batches of messages are written with ` + "`BatchWriteItem`" + ` requests of up
+to 25 items, where items left unprocessed by a request are retried according to
+the backoff settings of this output. Only the messages of items that could not
+be written are rejected.
+
+### Conditional Writes
+
+When a ` + "`condition.expression`" + ` is set each message with a non-empty
+expression is instead written with a ` + "`PutItem`" + ` request using the
+expression as a
+[condition](https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Expressions.ConditionExpressions.html).
+The expression is
+[function interpolated](/docs/configuration/interpolation#bloblang-queries) per
+message, and so conditions can be selected by the contents of messages, where a
+message whose expression resolves to an empty string is written
+unconditionally. Placeholders of attribute names are set with
+` + "`condition.attribute_names`" + `, and placeholders of attribute values are
+set with the object resulting from the Bloblang mapping
+` + "`condition.attribute_values_mapping`" + `, where only the placeholders used
+by the expression of a message are sent. For example, in order to only
+overwrite items with an older version:
+
+` + "``` yaml" + `
+condition:
+  expression: 'attribute_not_exists(id) OR #v < :v'
+  attribute_names:
+    "#v": version
+  attribute_values_mapping: 'root.":v" = this.version'
+` + "```" + `
+
+Messages that fail their condition are rejected without being retried, unless
+` + "`condition.ignore_failures`" + ` is set, in which case they are
+acknowledged without being written.
+
+### Time to Live
+
+Items can be given an expiry time for
+[time to live](https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/TTL.html)
+with the attribute ` + "`ttl_key`" + `, which is either set to a fixed period
+after the moment each message is sent with ` + "`ttl`" + `, or to the result of
+the Bloblang mapping ` + "`ttl_mapping`" + `. The mapping should result in
+either a unix timestamp in seconds or an RFC 3339 timestamp string, and when it
+results in ` + "`deleted()`" + ` the attribute is not set.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -88,7 +134,14 @@ allowing you to transfer data across accounts. You can find out more
 					"": ".",
 				},
 			),
+			docs.FieldAdvanced("condition", "Conditions that messages must meet in order for their items to be written.").WithChildren(
+				docs.FieldCommon("expression", "A condition expression for the item of each message, where messages with an empty expression are written unconditionally.", "attribute_not_exists(id)", `${! meta("condition") }`).SupportsInterpolation(false),
+				docs.FieldCommon("attribute_names", "A map of placeholders to the attribute names they substitute within expressions.", map[string]string{"#v": "version"}),
+				docs.FieldCommon("attribute_values_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) that results in an object of placeholders to the values they substitute within expressions.", `root.":v" = this.version`),
+				docs.FieldCommon("ignore_failures", "Whether messages that fail their condition are acknowledged without being written, otherwise they are rejected."),
+			),
 			docs.FieldAdvanced("ttl", "An optional TTL to set for items, calculated from the moment the message is sent."),
+			docs.FieldAdvanced("ttl_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) that results in the expiry time of the item of each message, as either a unix timestamp in seconds or an RFC 3339 timestamp string. Cannot be combined with `ttl`.", `root = this.expires_at`, `root = timestamp_unix() + 86400`),
 			docs.FieldAdvanced("ttl_key", "The column key to place the TTL value within."),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			batch.FieldSpec(),
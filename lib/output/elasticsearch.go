@@ -24,9 +24,46 @@ func init() {
 Publishes messages into an Elasticsearch index. If the index does not exist then
 it is created with a dynamic mapping.`,
 		Description: `
-Both the ` + "`id` and `index`" + ` fields can be dynamically set using function
-interpolations described [here](/docs/configuration/interpolation#bloblang-queries). When
-sending batched messages these interpolations are performed per message part.
+The ` + "`id`, `index`, `action` and `routing`" + ` fields can be dynamically
+set using function interpolations described
+[here](/docs/configuration/interpolation#bloblang-queries). When sending batched
+messages these interpolations are performed per message part.
+
+Messages are written with the bulk API, and documents of a batch that are
+rejected because the cluster is overloaded (with a status code of 429 or an
+` + "`es_rejected_execution_exception`" + ` error) or that fail with a server
+error are retried according to the ` + "`backoff`" + ` fields, along with bulk
+requests that are rejected with a status code of 429.
+
+### Actions
+
+The ` + "`action`" + ` of each message can be one of ` + "`index`" + `, which
+creates or replaces the document, ` + "`create`" + `, which fails if the
+document already exists, ` + "`update`" + `, which merges the message into an
+existing document, and ` + "`delete`" + `, which deletes the document and
+ignores the contents of the message.
+
+When ` + "`upsert`" + ` is enabled ` + "`update`" + ` actions create the
+document from the message if it does not already exist. If a
+` + "`script`" + ` is set then ` + "`update`" + ` actions run the script
+against the document instead, where the message is provided as the
+` + "`params`" + ` of the script, and when ` + "`upsert`" + ` is also enabled
+the script is run against an empty document if it does not already exist.
+
+For example, in order to count the occurrences of each user:
+
+` + "```yaml" + `
+output:
+  elasticsearch:
+    urls: [ http://localhost:9200 ]
+    index: user_counts
+    id: ${!json("user.id")}
+    action: update
+    upsert: true
+    script: |
+      if (ctx._source.count == null) { ctx._source.count = 0 }
+      ctx._source.count += params.count
+` + "```" + `
 
 ### AWS
 
@@ -43,6 +80,10 @@ false for connections to succeed.`,
 			docs.FieldCommon("index", "The index to place messages.").SupportsInterpolation(false),
 			docs.FieldAdvanced("pipeline", "An optional pipeline id to preprocess incoming documents.").SupportsInterpolation(false),
 			docs.FieldCommon("id", "The ID for indexed messages. Interpolation should be used in order to create a unique ID for each message.").SupportsInterpolation(false),
+			docs.FieldCommon("action", "The [action](#actions) to take on the document, which can be `index`, `create`, `update` or `delete`.", "index", `${!meta("elastic_action")}`).SupportsInterpolation(false),
+			docs.FieldAdvanced("routing", "An optional routing key to set for each document, which controls the shard that it is written to.").SupportsInterpolation(false),
+			docs.FieldAdvanced("upsert", "Whether `update` actions should create documents that do not already exist."),
+			docs.FieldAdvanced("script", "An optional inline Painless script to run against documents for `update` actions, where the message is provided as the `params` of the script.", "ctx._source.count += params.count"),
 			docs.FieldCommon("type", "The document type."),
 			docs.FieldAdvanced("sniff", "Prompts Benthos to sniff for brokers to connect to when establishing a connection."),
 			docs.FieldAdvanced("healthcheck", "Whether to enable healthchecks."),
@@ -0,0 +1,123 @@
+package output
+
+import (
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeFallback] = TypeSpec{
+		brokerConstructor: NewFallback,
+		Summary: `
+Attempts to send each message to a child output, starting from the first output
+on the list. If an output attempt fails then the next output in the list is
+attempted, and so on, with the error of the failed attempt added to messages as
+metadata.`,
+		Description: `
+This pattern is useful for triggering events in the case where certain output
+targets have broken, and for building dead letter queues. For example, if you
+had an output type ` + "`http_client`" + ` but wished to reroute messages
+whenever the endpoint becomes unreachable you could use this pattern:
+
+` + "``` yaml" + `
+output:
+  fallback:
+  - http_client:
+      url: http://foo:4195/post/might/become/unreachable
+      retries: 3
+      retry_period: 1s
+  - http_client:
+      url: http://bar:4196/somewhere/else
+      retries: 3
+      retry_period: 1s
+  - file:
+      path: /usr/local/benthos/everything_failed.jsonl
+` + "```" + `
+
+An output is only attempted once the output before it has rejected a message,
+and so ordinarily every message is written to the first output only.
+
+### Metadata
+
+When a message is sent to an output after the previous output failed the
+error of the failure is added to the message as the metadata field
+` + "`fallback_error`" + `, which is overwritten by the error of each
+subsequent failure. This makes it possible to capture why messages ended up in
+a dead letter queue:
+
+` + "``` yaml" + `
+output:
+  fallback:
+  - kafka:
+      addresses: [ localhost:9092 ]
+      topic: events
+  - kafka:
+      addresses: [ localhost:9092 ]
+      topic: events_dlq
+    processors:
+    - bloblang: |
+        root = this
+        root.error = meta("fallback_error")
+` + "```" + `
+
+### Batching
+
+When an output within a fallback sequence uses batching and rejects a batch
+with errors for individual messages, only the messages that failed are sent to
+the next output. When the individual messages that failed cannot be determined
+the whole batch is sent to the next output in order to preserve at-least-once
+guarantees.
+
+### Metrics
+
+The number of successful and failed attempts of each output are tracked with
+the counters ` + "`fallback.outputs.<index>.success`" + ` and
+` + "`fallback.outputs.<index>.failed`" + `, where ` + "`<index>`" + ` is the
+position of the output within the list, starting from zero.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			outSlice := []interface{}{}
+			for _, output := range conf.Fallback {
+				sanOutput, err := SanitiseConfig(output)
+				if err != nil {
+					return nil, err
+				}
+				outSlice = append(outSlice, sanOutput)
+			}
+			return outSlice, nil
+		},
+		Categories: []Category{
+			CategoryUtility,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// FallbackConfig contains configuration fields for the Fallback output type.
+type FallbackConfig brokerOutputList
+
+// NewFallbackConfig creates a new FallbackConfig with default values.
+func NewFallbackConfig() FallbackConfig {
+	return FallbackConfig{}
+}
+
+//------------------------------------------------------------------------------
+
+// NewFallback creates a new fallback broker output type.
+func NewFallback(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+	pipelines ...types.PipelineConstructorFunc,
+) (Type, error) {
+	return newTryBroker(
+		TypeFallback, brokerOutputList(conf.Fallback), "fallback_error",
+		mgr, log, stats, pipelines...,
+	)
+}
+
+//------------------------------------------------------------------------------
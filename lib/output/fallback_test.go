@@ -0,0 +1,74 @@
+package output
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbackOutputErrorMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_fallback_output_tests")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	outOne, outTwo := NewConfig(), NewConfig()
+	outOne.Type, outTwo.Type = TypeHTTPClient, TypeFiles
+	outOne.HTTPClient.URL = "http://localhost:11111111/badurl"
+	outOne.HTTPClient.NumRetries = 1
+	outOne.HTTPClient.Retry = "1ms"
+	outTwo.Files.Path = filepath.Join(dir, `${! content() }.txt`)
+
+	proc := processor.NewConfig()
+	proc.Type = processor.TypeBloblang
+	proc.Bloblang = `root = if meta("fallback_error").contains("badurl") { "badurl" } else { "unknown" }`
+	outTwo.Processors = append(outTwo.Processors, proc)
+
+	conf := NewConfig()
+	conf.Type = TypeFallback
+	conf.Fallback = append(conf.Fallback, outOne, outTwo)
+
+	stats := metrics.NewLocal()
+	s, err := New(conf, nil, log.Noop(), stats)
+	require.NoError(t, err)
+
+	sendChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, s.Consume(sendChan))
+
+	defer func() {
+		s.CloseAsync()
+		assert.NoError(t, s.WaitForClose(time.Second))
+	}()
+
+	select {
+	case sendChan <- types.NewTransaction(message.New([][]byte{[]byte("first")}), resChan):
+	case <-time.After(time.Second * 2):
+		t.Fatal("Action timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		require.NoError(t, res.Error())
+	case <-time.After(time.Second * 2):
+		t.Fatal("Action timed out")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "badurl.txt", files[0].Name())
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(1), counters["fallback.outputs.0.failed"])
+	assert.Equal(t, int64(1), counters["fallback.outputs.1.success"])
+}
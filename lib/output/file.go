@@ -1,11 +1,10 @@
 package output
 
 import (
-	"os"
-
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
@@ -15,7 +14,7 @@ func init() {
 	Constructors[TypeFile] = TypeSpec{
 		constructor: NewFile,
 		Summary: `
-Writes messages in line delimited format to a file.`,
+Writes messages in line delimited format to files.`,
 		Description: `
 Each message written is followed by a delimiter (defaults to '\n' if left empty)
 and when sending multipart messages (message batches) the last message ends with
@@ -33,10 +32,59 @@ Whereas a multipart message [ "foo", "bar", "baz" ] would be written as:
 foo\n
 bar\n
 baz\n\n
-` + "```" + ``,
+` + "```" + `
+
+### Dynamic Paths
+
+The path of each message can be set with
+[interpolation functions](/docs/configuration/interpolation#bloblang-queries),
+which allows a single output to write partitioned directory trees, where
+directories are created as required. The handles of the most recently written
+files are kept open, and when more than ` + "`max_open_files`" + ` files are
+written to the least recently used handle is closed. When the messages of a
+batch are written to several files those that fail to be written are rejected
+individually.
+
+### Rotation
+
+When ` + "`rotation.max_size`" + ` is set a file is rotated once it reaches
+that number of bytes, and when ` + "`rotation.max_age`" + ` is set a file is
+rotated once it has been open for that period. A rotated file is renamed by
+inserting a timestamp before the extensions of its name, e.g. ` + "`foo.log.gz`" + `
+becomes ` + "`foo-2021-02-03T04-05-06.000.log.gz`" + `, and a new file is
+created at the original path. Files are only rotated when they're written to.
+
+### Compression
+
+When ` + "`compression`" + ` is set to ` + "`gzip`" + ` the contents of files
+are gzip compressed. Each time a file is opened a new gzip member is appended
+to it, which tools that decompress gzip files read as a continuation of the
+previous members.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Partitioned Logs",
+				Summary: `
+Write logs to a compressed file per tenant within a directory per day, rotating
+files once they reach 100MB:`,
+				Config: `
+output:
+  file:
+    path: ./out/${! timestamp("2006/01/02") }/${! meta("tenant") }.log.gz
+    compression: gzip
+    rotation:
+      max_size: 104857600
+`,
+			},
+		},
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("path", "The file to write to, if the file does not yet exist it will be created."),
+			docs.FieldCommon("path", "The file to write to, if the file does not yet exist it will be created.", "/tmp/data.txt", `/tmp/${! timestamp("2006-01-02") }/${! meta("tenant") }.txt`).SupportsInterpolation(false),
 			docs.FieldCommon("delimiter", "A custom delimiter to separate messages with. If left empty defaults to a line break."),
+			docs.FieldAdvanced("max_open_files", "The maximum number of files to keep open at a given time."),
+			docs.FieldAdvanced("compression", "The compression of the contents of files.").HasOptions("none", "gzip"),
+			docs.FieldAdvanced("rotation", "Rotate files when they reach a size or age.").WithChildren(
+				docs.FieldAdvanced("max_size", "The size in bytes at which a file is rotated. If zero files are not rotated by size."),
+				docs.FieldAdvanced("max_age", "An optional period after which an open file is rotated.", "1h", "24h"),
+			),
 		},
 		Categories: []Category{
 			CategoryLocal,
@@ -47,28 +95,22 @@ baz\n\n
 //------------------------------------------------------------------------------
 
 // FileConfig contains configuration fields for the file based output type.
-type FileConfig struct {
-	Path  string `json:"path" yaml:"path"`
-	Delim string `json:"delimiter" yaml:"delimiter"`
-}
+type FileConfig = writer.FileConfig
 
 // NewFileConfig creates a new FileConfig with default values.
 func NewFileConfig() FileConfig {
-	return FileConfig{
-		Path:  "",
-		Delim: "",
-	}
+	return writer.NewFileConfig()
 }
 
 //------------------------------------------------------------------------------
 
 // NewFile creates a new File output type.
 func NewFile(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-	file, err := os.OpenFile(conf.File.Path, os.O_CREATE|os.O_RDWR|os.O_APPEND, os.FileMode(0666))
+	f, err := writer.NewFile(conf.File, log, stats)
 	if err != nil {
 		return nil, err
 	}
-	return NewLineWriter(file, true, []byte(conf.File.Delim), "file", log, stats)
+	return NewWriter(TypeFile, f, log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,102 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeGCPBigQuery] = TypeSpec{
+		constructor: NewGCPBigQuery,
+		Summary: `
+Writes messages as rows of a GCP BigQuery table with the Storage Write API.`,
+		Description: `
+Each message must be a JSON object, where the value of each field is written to
+the column of the same name. The schema of the table is obtained each time a
+batch is written, and field values are converted into the type of their column,
+for example strings containing numbers are converted into numeric columns,
+` + "`TIMESTAMP`" + ` columns accept RFC 3339 strings or numbers of seconds
+since the unix epoch, ` + "`DATE`" + ` columns accept strings of the form
+` + "`2006-01-02`" + ` and ` + "`BYTES`" + ` columns accept base64 encoded
+strings.
+
+For information on how to set up credentials check out
+[this guide](https://cloud.google.com/docs/authentication/production).
+
+### Delivery Guarantees
+
+Each batch of messages is appended to a new pending stream, which is only
+committed to the table once all rows of the batch have been appended. Therefore
+the rows of a batch become visible together, and failed attempts to write a
+batch never result in duplicate rows. Since creating streams is subject to
+quotas it is strongly recommended that this output is configured with a
+[batching policy](/docs/configuration/batching).
+
+### Dead Letters
+
+Messages that cannot be converted into rows of the table, such as messages that
+are missing required fields or have values of the wrong type, cause the entire
+batch to fail. When a ` + "`dead_letter_table`" + ` is set these messages are
+instead written to the dead letter table, which should have a
+` + "`STRING`" + ` column ` + "`content`" + ` for the raw message, a
+` + "`STRING`" + ` column ` + "`error`" + ` for the reason it could not be
+converted and a ` + "`TIMESTAMP`" + ` column ` + "`timestamp`" + `.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.GCPBigQuery, conf.GCPBigQuery.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("project", "The project ID of the dataset."),
+			docs.FieldCommon("dataset", "The dataset of the table."),
+			docs.FieldCommon("table", "The table to write rows to."),
+			docs.FieldCommon("dead_letter_table", "An optional table within the same dataset to write messages that cannot be converted into rows to."),
+			docs.FieldAdvanced("ignore_unknown_fields", "Whether fields of messages that are not columns of the table should be ignored, otherwise their messages cannot be converted."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+			CategoryGCP,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewGCPBigQuery creates a new GCP BigQuery output type.
+func NewGCPBigQuery(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	g, err := writer.NewGCPBigQuery(conf.GCPBigQuery, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.GCPBigQuery.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeGCPBigQuery, g, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeGCPBigQuery, conf.GCPBigQuery.MaxInFlight, g, log, stats,
+		)
+	}
+	if bconf := conf.GCPBigQuery.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
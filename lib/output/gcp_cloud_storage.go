@@ -0,0 +1,128 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeGCPCloudStorage] = TypeSpec{
+		constructor: NewGCPCloudStorage,
+		Beta:        true,
+		Summary: `
+Sends message parts as objects to a GCP Cloud Storage bucket. Each object is
+uploaded with the path specified with the ` + "`path`" + ` field.`,
+		Description: `
+In order to have a different path for each object you should use function
+interpolations described [here](/docs/configuration/interpolation#bloblang-queries), which are
+calculated per message of a batch. The metadata of each message is set as the
+user defined metadata of its object.
+
+### Credentials
+
+By default Benthos will use the
+[application default credentials](https://cloud.google.com/docs/authentication/production)
+of the environment when connecting to GCP services.
+
+### Batching
+
+It's common to want to upload messages to Cloud Storage as batched archives,
+the easiest way to do this is to batch your messages at the output level and
+join the batch of messages with an
+` + "[`archive`](/docs/components/processors/archive)" + ` and/or
+` + "[`compress`](/docs/components/processors/compress)" + ` processor.
+
+For example, if we wished to upload messages as a .tar.gz archive of documents
+we could achieve that with the following config:
+
+` + "```yaml" + `
+output:
+  gcp_cloud_storage:
+    bucket: TODO
+    path: ${!count("files")}-${!timestamp_unix_nano()}.tar.gz
+    batching:
+      count: 100
+      period: 10s
+      processors:
+        - archive:
+            format: tar
+        - compress:
+            algorithm: gzip
+` + "```" + `
+
+Alternatively, if we wished to upload JSON documents as a single large document
+containing an array of objects we can do that with:
+
+` + "```yaml" + `
+output:
+  gcp_cloud_storage:
+    bucket: TODO
+    path: ${!count("files")}-${!timestamp_unix_nano()}.json
+    content_type: application/json
+    batching:
+      count: 100
+      processors:
+        - archive:
+            format: json_array
+` + "```" + ``,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.GCPCloudStorage, conf.GCPCloudStorage.Batching)
+		},
+		Async: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("bucket", "The bucket to upload messages to."),
+			docs.FieldCommon(
+				"path", "The path of each message to upload.",
+				`${!count("files")}-${!timestamp_unix_nano()}.txt`,
+				`${!meta("kafka_key")}.json`,
+				`${!json("doc.namespace")}/${!json("doc.id")}.json`,
+			).SupportsInterpolation(false),
+			docs.FieldCommon("content_type", "The content type to set for each object.").SupportsInterpolation(false),
+			docs.FieldAdvanced("content_encoding", "An optional content encoding to set for each object.").SupportsInterpolation(false),
+			docs.FieldAdvanced("chunk_size", "The maximum number of bytes to upload in each request, objects larger than this are uploaded over multiple requests. If set to zero objects are uploaded in a single request."),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on an upload before abandoning it and reattempting."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+			CategoryGCP,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewGCPCloudStorage creates a new GCPCloudStorage output type.
+func NewGCPCloudStorage(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	gcs, err := writer.NewGCPCloudStorage(conf.GCPCloudStorage, log, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := NewAsyncWriter(
+		TypeGCPCloudStorage, conf.GCPCloudStorage.MaxInFlight, gcs, log, stats,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if bconf := conf.GCPCloudStorage.Batching; !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, nil
+}
+
+//------------------------------------------------------------------------------
@@ -18,11 +18,21 @@ Sends messages to a GCP Cloud Pub/Sub topic. [Metadata](/docs/configuration/meta
 from messages are sent as attributes.`,
 		Description: `
 For information on how to set up credentials check out
-[this guide](https://cloud.google.com/docs/authentication/production).`,
+[this guide](https://cloud.google.com/docs/authentication/production).
+
+### Ordering
+
+When the field ` + "`ordering_key`" + ` is set messages are published with an
+ordering key, which when consumed from a subscription with
+[message ordering](https://cloud.google.com/pubsub/docs/ordering) enabled are
+delivered in the order that they were published. In order to guarantee that
+messages of the same key are published in order the field
+` + "`max_in_flight`" + ` should be set to ` + "`1`" + `.`,
 		Async: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("project", "The project ID of the topic to publish to."),
 			docs.FieldCommon("topic", "The topic to publish to.").SupportsInterpolation(false),
+			docs.FieldAdvanced("ordering_key", "An optional key used in order to publish messages in order.", `${! meta("kafka_key") }`).SupportsInterpolation(false),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 		},
 		Categories: []Category{
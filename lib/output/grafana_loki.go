@@ -0,0 +1,118 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeGrafanaLoki] = TypeSpec{
+		constructor: NewGrafanaLoki,
+		Summary: `
+Pushes messages as log entries to Grafana Loki.`,
+		Description: `
+Each message is pushed as a log entry where the line of the entry is the raw
+contents of the message. The stream that an entry belongs to is identified by
+the result of the ` + "`labels_mapping`" + `, a
+[Bloblang mapping](/docs/guides/bloblang/about) that should evaluate to an
+object of labels, where labels with empty values are omitted. Since Loki
+indexes streams by their labels it's recommended to only use labels with a
+small number of distinct values, such as the name of an application or the
+level of a log, and to leave everything else within the line.
+
+The timestamp of an entry is the result of the ` + "`timestamp_mapping`" + `,
+which can be either a number of nanoseconds since the Unix epoch or an RFC 3339
+timestamp string. When the mapping isn't set or doesn't produce a value the
+time the message is written is used.
+
+The entries of a batch are grouped into streams and pushed with a single
+request, and messages that cannot be converted into entries are rejected
+individually, which allows
+[error handling patterns](/docs/configuration/error_handling) to deal with
+them. Depending on its version Loki might reject entries that are older than
+the latest entry of their stream, and so messages of a stream should be written
+in order.
+
+When Loki runs in multi-tenant mode the ` + "`tenant_id`" + ` field sets the
+tenant that entries are pushed to.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Log Shipping",
+				Summary: `
+Push structured logs to Loki, where the service and level of each log are used
+as labels:`,
+				Config: `
+output:
+  grafana_loki:
+    url: http://localhost:3100/loki/api/v1/push
+    labels_mapping: 'root = { "service": this.service, "level": this.level }'
+    timestamp_mapping: root = this.time
+    batching:
+      count: 500
+      period: 1s
+`,
+			},
+		},
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.GrafanaLoki, conf.GrafanaLoki.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "The URL of the push API of Loki.", "http://localhost:3100/loki/api/v1/push"),
+			docs.FieldCommon("tenant_id", "An optional tenant to push entries to, which is sent as the `X-Scope-OrgID` header."),
+			docs.FieldCommon("labels_mapping", "A [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an object of labels.", `root = { "app": meta("app") }`),
+			docs.FieldCommon("timestamp_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to the timestamp of entries, either as a number of nanoseconds since the Unix epoch or an RFC 3339 string.", `root = this.time`),
+			docs.FieldAdvanced("headers", "A map of headers to add to requests.", map[string]string{"X-Foo": "bar"}),
+			auth.BasicAuthFieldSpec(),
+			tls.FieldSpec(),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on a request before abandoning it and reattempting."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewGrafanaLoki creates a new Grafana Loki output type.
+func NewGrafanaLoki(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	g, err := writer.NewGrafanaLoki(conf.GrafanaLoki, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.GrafanaLoki.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeGrafanaLoki, g, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeGrafanaLoki, conf.GrafanaLoki.MaxInFlight, g, log, stats,
+		)
+	}
+	if bconf := conf.GrafanaLoki.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,76 @@
+package output
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeGRPCClient] = TypeSpec{
+		constructor: NewGRPCClient,
+		Summary: `
+Calls a gRPC method with messages, where the method is described by a supplied
+descriptor set.`,
+		Description: `
+Messages must be JSON documents, which are converted into the input type of the
+method as described by the ` + "`descriptor_set`" + `, a file containing a
+serialised ` + "`FileDescriptorSet`" + ` that can be generated with
+` + "`protoc --include_imports --descriptor_set_out`" + `. The responses of
+calls are discarded.
+
+Unary methods are called once for each message of a batch, and client streaming
+methods are called once for each batch with a request for each of its messages.
+Server streaming methods are not supported.
+
+The metadata of each call can be set with the field ` + "`metadata`" + `, where
+function interpolations can be used in order to set values from the metadata of
+messages, as described [here](/docs/configuration/interpolation#bloblang-queries).
+For client streaming methods the interpolations are performed with the first
+message of a batch.`,
+		Async: true,
+		Beta:  true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("address", "The address of the server to connect to."),
+			docs.FieldCommon("method", "The full name of the method to call.", "/benthos.v1.Ingest/Send"),
+			docs.FieldCommon("descriptor_set", "A path to a file containing a serialised `FileDescriptorSet` that describes the method.", "./protos/ingest.protoset"),
+			docs.FieldCommon(
+				"metadata", "A map of metadata to add to each call.",
+				map[string]string{
+					"authorization": `Bearer ${!meta("token")}`,
+				},
+			).SupportsInterpolation(false),
+			docs.FieldAdvanced("timeout", "The maximum period of time to wait for a call to complete."),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			tls.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryNetwork,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewGRPCClient creates a new GRPCClient output type.
+func NewGRPCClient(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	g, err := writer.NewGRPCClient(conf.GRPCClient, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	if conf.GRPCClient.MaxInFlight == 1 {
+		return NewWriter(
+			TypeGRPCClient, g, log, stats,
+		)
+	}
+	return NewAsyncWriter(
+		TypeGRPCClient, conf.GRPCClient.MaxInFlight, g, log, stats,
+	)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,140 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeInfluxDB] = TypeSpec{
+		constructor: NewInfluxDB,
+		Summary: `
+Converts messages into points of the InfluxDB line protocol and writes them to
+an InfluxDB server.`,
+		Description: `
+Each message is converted into a single point, where the measurement of the
+point is the result of the ` + "`measurement`" + ` interpolation and its tags
+and fields are the results of the ` + "`tags_mapping`" + ` and
+` + "`fields_mapping`" + `
+[Bloblang mappings](/docs/guides/bloblang/about), which should evaluate to
+objects. Tags with empty values are omitted. Field values can be strings,
+booleans or numbers, where numbers are written as floats unless they are the
+integer result of a mapping function such as ` + "`length()`" + `, or they
+belong to a field listed in ` + "`integer_fields`" + `. Since InfluxDB rejects
+points where the type of a field differs from previously written points, it's
+recommended to list any integer fields explicitly.
+
+The timestamp of a point is the result of the ` + "`timestamp_mapping`" + `,
+which can be either a number in units of the ` + "`precision`" + ` or an RFC
+3339 timestamp string. When the mapping isn't set or doesn't produce a value
+the server assigns the time a point is received.
+
+The points of a batch are written with a single request, and messages that
+cannot be converted into points are rejected individually, which allows
+[error handling patterns](/docs/configuration/error_handling) to deal with
+them.
+
+### API Versions
+
+The ` + "`api_version`" + ` field determines which write API is used. The
+` + "`v2`" + ` API writes to a ` + "`bucket`" + ` of an ` + "`org`" + ` and
+authenticates with a ` + "`token`" + `, and is supported by InfluxDB 2.x as
+well as InfluxDB 1.8 and later, where the bucket is a database and retention
+policy separated by a slash. The ` + "`v1`" + ` API writes to a ` + "`db`" + `
+and optional ` + "`retention_policy`" + `, and authenticates with a
+` + "`username`" + ` and ` + "`password`" + `.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Metrics Routing",
+				Summary: `
+Write structured CPU metrics to a bucket of InfluxDB 2.x, where the host and
+region of each metric are written as tags:`,
+				Config: `
+output:
+  influxdb:
+    url: http://localhost:8086
+    org: benthos
+    bucket: metrics
+    token: ${INFLUXDB_TOKEN}
+    measurement: cpu
+    tags_mapping: 'root = { "host": this.host, "region": this.region }'
+    fields_mapping: 'root = this.without("host", "region", "time")'
+    integer_fields: [ cores ]
+    timestamp_mapping: root = this.time
+    batching:
+      count: 100
+      period: 1s
+`,
+			},
+		},
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.InfluxDB, conf.InfluxDB.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "The base URL of the InfluxDB server."),
+			docs.FieldCommon("api_version", "The version of the write API to use.").HasOptions("v1", "v2"),
+			docs.FieldCommon("token", "An API token to authenticate with, used by the `v2` API."),
+			docs.FieldCommon("org", "The organization to write to, used by the `v2` API."),
+			docs.FieldCommon("bucket", "The bucket to write to, used by the `v2` API."),
+			docs.FieldCommon("db", "The database to write to, used by the `v1` API."),
+			docs.FieldAdvanced("retention_policy", "An optional retention policy to write to, used by the `v1` API."),
+			docs.FieldCommon("username", "A username to authenticate with, used by the `v1` API."),
+			docs.FieldCommon("password", "A password to authenticate with, used by the `v1` API."),
+			docs.FieldCommon("measurement", "The measurement of points.", "cpu", `${! json("name") }`).SupportsInterpolation(false),
+			docs.FieldCommon("tags_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an object of tags.", `root = { "host": this.host }`),
+			docs.FieldCommon("fields_mapping", "A [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an object of fields.", `root = this.metrics`),
+			docs.FieldAdvanced("integer_fields", "A list of fields where numbers are written as integers.", []string{"count"}),
+			docs.FieldCommon("timestamp_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to the timestamp of points, either as a number in units of the `precision` or an RFC 3339 string.", `root = this.time`),
+			docs.FieldAdvanced("precision", "The precision of timestamps.").HasOptions("ns", "us", "ms", "s"),
+			tls.FieldSpec(),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on a request before abandoning it and reattempting."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewInfluxDB creates a new InfluxDB output type.
+func NewInfluxDB(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	i, err := writer.NewInfluxDB(conf.InfluxDB, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.InfluxDB.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeInfluxDB, i, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeInfluxDB, conf.InfluxDB.MaxInFlight, i, log, stats,
+		)
+	}
+	if bconf := conf.InfluxDB.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
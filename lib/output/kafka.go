@@ -26,6 +26,51 @@ acknowledgement before propagating it back to the input.`,
 The config field ` + "`ack_replicas`" + ` determines whether we wait for
 acknowledgement from all replicas or just a single broker.
 
+### Idempotent Writes
+
+When the field ` + "`idempotent_write`" + ` is enabled the producer is assigned
+an ID by the brokers and each message is sent with a sequence number, which
+allows brokers to discard duplicates that would otherwise be created when a
+send is retried after a transient failure within the same session. Idempotent
+writes require a ` + "`target_version`" + ` of at least 0.11.0.0, and imply
+` + "`ack_replicas`" + `.
+
+Idempotence does not extend across restarts of Benthos, and therefore messages
+that are redelivered by an input after a crash may still be written more than
+once.
+
+### Transactional Writes
+
+When a ` + "`transactional_id`" + ` is set batches are written within
+transactions, and a batch is only acknowledged once the transaction it was
+written within has been committed. When Benthos restarts with the same
+` + "`transactional_id`" + ` the brokers abort any transaction left open by the
+previous instance, and therefore messages that were redelivered because their
+transaction was never committed are not duplicated for consumers that read with
+the ` + "`read_committed`" + ` isolation level. Each instance of Benthos must
+use a unique ` + "`transactional_id`" + `, and transactional writes imply
+` + "`idempotent_write`" + `.
+
+By default each batch is committed within its own transaction. Setting
+` + "`transaction_commit_interval`" + ` instead groups all batches written within
+the interval into one transaction, which reduces the overhead of commits at the
+cost of latency, and is most useful with a ` + "`max_in_flight`" + ` greater
+than one. When a batch fails to send the transaction is aborted, which fails
+all batches that were written within it.
+
+A batch that was committed but whose acknowledgement did not reach the input
+before a crash will still be written again when it is redelivered.
+
+### Metadata
+
+All metadata fields of messages are sent as record headers when the
+` + "`target_version`" + ` is at least 0.11.0.0, along with any headers
+specified within ` + "`static_headers`" + `. The fields within
+` + "`metadata`" + ` can be used in order to restrict which metadata keys are
+sent with regular expressions, which is useful for preventing internal metadata
+(such as the ` + "`kafka_`" + ` fields added by the ` + "`kafka`" + ` input)
+from leaking into downstream topics whilst propagating business metadata.
+
 Both the ` + "`key` and `topic`" + ` fields can be dynamically set using
 function interpolations described [here](/docs/configuration/interpolation#bloblang-queries).
 When sending batched messages these interpolations are performed per message
@@ -46,8 +91,15 @@ part.`,
 			docs.FieldCommon("partitioner", "The partitioning algorithm to use.").HasOptions("fnv1a_hash", "murmur2_hash", "random", "round_robin"),
 			docs.FieldCommon("compression", "The compression algorithm to use.").HasOptions("none", "snappy", "lz4", "gzip"),
 			docs.FieldCommon("static_headers", "An optional map of static headers that should be added to messages in addition to metadata.", map[string]string{"first-static-header": "value-1", "second-static-header": "value-2"}),
+			docs.FieldAdvanced("metadata", "Specify criteria for which metadata values are sent with messages as headers.").WithChildren(
+				docs.FieldAdvanced("include_patterns", "Provide a list of explicit metadata key regular expression (re2) patterns to match against. When set only metadata keys that match at least one pattern are sent.", []string{".*"}, []string{"_timestamp_unix$"}),
+				docs.FieldAdvanced("exclude_patterns", "Provide a list of metadata key regular expression (re2) patterns to exclude, which takes precedence over the include patterns.", []string{"^kafka_"}),
+			),
 			docs.FieldCommon("max_in_flight", "The maximum number of parallel message batches to have in flight at any given time."),
 			docs.FieldAdvanced("ack_replicas", "Ensure that messages have been copied across all replicas before acknowledging receipt."),
+			docs.FieldAdvanced("idempotent_write", "Enable the idempotent producer, which prevents retried sends from creating duplicate messages within the target topic."),
+			docs.FieldAdvanced("transactional_id", "An optional transactional ID, which enables writing batches within transactions. Must be unique for each instance of Benthos."),
+			docs.FieldAdvanced("transaction_commit_interval", "An optional period of time to group batches into a single transaction for before committing it, which must be less than one minute. When empty each batch is committed within its own transaction.", "100ms", "1s"),
 			docs.FieldAdvanced("max_msg_bytes", "The maximum size in bytes of messages sent to the target topic."),
 			docs.FieldAdvanced("timeout", "The maximum period of time to wait for message sends before abandoning the request and retrying."),
 			docs.FieldAdvanced("target_version", "The version of the Kafka protocol to use."),
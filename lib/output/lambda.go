@@ -0,0 +1,73 @@
+package output
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/session"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeLambda] = TypeSpec{
+		constructor: NewLambda,
+		Summary: `
+Invokes an AWS Lambda function for each message, where the contents of the
+message is the payload of the invocation.`,
+		Description: `
+The ` + "`invocation_type`" + ` field determines whether invocations are
+synchronous or asynchronous. A ` + "`RequestResponse`" + ` invocation waits
+for the function to complete, and a message is only acknowledged once the
+function has succeeded. An ` + "`Event`" + ` invocation places the payload on
+the event queue of the function and returns immediately, and a message is
+acknowledged once the event has been queued.
+
+Synchronous invocations that fail, either because the function returned an
+error or because it could not be invoked, are reattempted. The results of
+successful invocations are discarded, in order to replace the contents of
+messages with the results of invocations use the
+` + "[`lambda` processor](/docs/components/processors/lambda)" + ` instead.
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to AWS
+services. It's also possible to set them explicitly at the component level,
+allowing you to transfer data across accounts. You can find out more
+[in this document](/docs/guides/aws).`,
+		Async: true,
+		Beta:  true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("function", "The function to invoke."),
+			docs.FieldCommon("invocation_type", "Whether to invoke the function synchronously and wait for it to complete, or asynchronously.").HasOptions("RequestResponse", "Event"),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on an invocation before abandoning it and reattempting."),
+		}.Merge(session.FieldSpecs()),
+		Categories: []Category{
+			CategoryServices,
+			CategoryAWS,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewLambda creates a new Lambda output type.
+func NewLambda(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	l, err := writer.NewLambda(conf.Lambda, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Lambda.MaxInFlight == 1 {
+		return NewWriter(
+			TypeLambda, l, log, stats,
+		)
+	}
+	return NewAsyncWriter(
+		TypeLambda, conf.Lambda.MaxInFlight, l, log, stats,
+	)
+}
+
+//------------------------------------------------------------------------------
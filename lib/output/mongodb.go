@@ -0,0 +1,128 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeMongoDB] = TypeSpec{
+		constructor: NewMongoDB,
+		Summary: `
+Inserts, replaces, updates or deletes documents of a MongoDB collection.`,
+		Description: `
+Each message of a batch is converted into one operation of the same type, and
+the operations of a batch are written with as few bulk write commands as
+possible. The ` + "`operation`" + ` field determines which
+[Bloblang mappings](/docs/guides/bloblang/about) are executed for each message:
+
+- ` + "`insert-one`" + `: The ` + "`document_map`" + ` builds the document to
+  insert.
+- ` + "`replace-one`" + `: The ` + "`filter_map`" + ` selects the document to
+  replace and the ` + "`document_map`" + ` builds its replacement.
+- ` + "`update-one`" + `: The ` + "`filter_map`" + ` selects the document to
+  update and the ` + "`document_map`" + ` builds a document of
+  [update operators](https://docs.mongodb.com/manual/reference/operator/update/).
+- ` + "`delete-one`" + ` and ` + "`delete-many`" + `: The ` + "`filter_map`" + `
+  selects the documents to delete.
+
+The results of mappings are interpreted as
+[extended JSON](https://docs.mongodb.com/manual/reference/mongodb-extended-json/),
+and so values such as object IDs and dates can be written with
+` + "`{\"$oid\":\"5f1d7a2b0c3e4a5b6c7d8e9f\"}`" + ` and
+` + "`{\"$date\":\"2020-09-13T12:26:40Z\"}`" + `. The ` + "`upsert`" + ` field
+inserts a new document when the filter of a replacement or update matches no
+documents.
+
+### Ordering and Errors
+
+When ` + "`ordered`" + ` is true the operations of a batch are applied in
+order, and the first operation to fail stops the remaining operations from
+being attempted. Otherwise the server may apply operations in any order and
+attempts all of them regardless of failures. Either way only the messages of
+operations that failed or weren't attempted are retried, which allows
+[error handling patterns](/docs/configuration/error_handling) that break
+batches apart.
+
+The ` + "`write_concern`" + ` field sets the level of acknowledgement requested
+from the server for each write command. When it isn't set the default write
+concern of the deployment is used, and a write concern that cannot be satisfied
+fails the entire batch.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.MongoDB, conf.MongoDB.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "A [connection string](https://docs.mongodb.com/manual/reference/connection-string/) of the deployment to connect to.", "mongodb://localhost:27017/shop?replicaSet=rs0", "mongodb+srv://cluster0.example.com"),
+			docs.FieldCommon("username", "A username to authenticate with, which overrides any username of the `url`."),
+			docs.FieldCommon("password", "A password to authenticate with."),
+			docs.FieldCommon("database", "The database to write to, which defaults to the database of the `url`."),
+			docs.FieldCommon("collection", "The collection of the database to write to."),
+			docs.FieldCommon("operation", "The operation to perform for each message.").HasOptions("insert-one", "replace-one", "update-one", "delete-one", "delete-many"),
+			docs.FieldCommon(
+				"document_map", "A [Bloblang mapping](/docs/guides/bloblang/about) that builds the document to insert, the replacement of a document, or the update of a document. Required by the `insert-one`, `replace-one` and `update-one` operations.",
+				`root = this`,
+				`root."$set".status = this.status`,
+			),
+			docs.FieldCommon(
+				"filter_map", "A [Bloblang mapping](/docs/guides/bloblang/about) that builds the filter selecting the documents to replace, update or delete. Required by all operations other than `insert-one`.",
+				`root._id = this.id`,
+				`root._id."$oid" = this.id`,
+			),
+			docs.FieldAdvanced("upsert", "Whether a document should be inserted when the filter of a `replace-one` or `update-one` operation matches no documents."),
+			docs.FieldAdvanced("ordered", "Whether the operations of a batch are applied in order, stopping at the first operation that fails."),
+			docs.FieldAdvanced("write_concern", "The [write concern](https://docs.mongodb.com/manual/reference/write-concern/) of write commands.").WithChildren(
+				docs.FieldCommon("w", "The number of members that must acknowledge writes, or `majority`.", "1", "majority"),
+				docs.FieldCommon("j", "Whether writes must be written to the on-disk journal before being acknowledged."),
+				docs.FieldCommon("w_timeout", "An optional time limit for writes to be acknowledged, after which the write concern fails.", "5s"),
+			),
+			tls.FieldSpec(),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewMongoDB creates a new MongoDB output type.
+func NewMongoDB(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	m, err := writer.NewMongoDB(conf.MongoDB, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.MongoDB.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeMongoDB, m, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeMongoDB, conf.MongoDB.MaxInFlight, m, log, stats,
+		)
+	}
+	if bconf := conf.MongoDB.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
@@ -2,10 +2,12 @@ package output
 
 import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/mqttconf"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
 )
 
 //------------------------------------------------------------------------------
@@ -18,15 +20,32 @@ Pushes messages to an MQTT broker.`,
 		Description: `
 The ` + "`topic`" + ` field can be dynamically set using function interpolations
 described [here](/docs/configuration/interpolation#bloblang-queries). When sending batched
-messages these interpolations are performed per message part.`,
+messages these interpolations are performed per message part.
+
+### Delivery Guarantees
+
+A message is only considered delivered once the broker has acknowledged it. For
+a QoS of 1 this is the receipt of a PUBACK, and for a QoS of 2 this is the
+completion of the full PUBREC, PUBREL and PUBCOMP exchange, which guarantees that
+the broker has received the message exactly once. If an acknowledgement is not
+received within ` + "`write_timeout`" + ` the connection to the broker is
+re-established and the message is sent again, which can result in duplicates.`,
 		Async: true,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.", []string{"tcp://localhost:1883"}),
+			docs.FieldCommon("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs. Brokers that require TLS should use the `ssl://` or `tls://` URL schemes, and brokers that are exposed over a WebSocket should use the `ws://` or `wss://` URL schemes.", []string{"tcp://localhost:1883"}, []string{"ssl://localhost:8883"}, []string{"wss://localhost:443/mqtt"}),
 			docs.FieldCommon("qos", "The QoS value to set for each message.").HasOptions("0", "1", "2"),
-			docs.FieldCommon("topic", "The topic to publish messages to."),
+			docs.FieldAdvanced("retained", "Set message as retained on the topic, which means that the broker keeps the last message of the topic and sends it to new subscribers straight away."),
+			docs.FieldAdvanced("retained_interpolated", "Override the value of `retained` with an interpolable value, this allows it to be dynamically set based on message contents. The value must resolve to either `true` or `false`.", `${! meta("retain") }`).SupportsInterpolation(false),
+			docs.FieldCommon("topic", "The topic to publish messages to.", "benthos_topic", `devices/${! meta("device_id") }/events`).SupportsInterpolation(false),
 			docs.FieldCommon("client_id", "An identifier for the client."),
 			docs.FieldAdvanced("user", "A username to connect with."),
 			docs.FieldAdvanced("password", "A password to connect with."),
+			docs.FieldAdvanced("http_headers", "A map of HTTP headers to add to the upgrade request when connecting to a broker over a WebSocket with the `ws://` or `wss://` URL schemes.", map[string]string{
+				"Authorization": "Bearer foo",
+			}),
+			docs.FieldAdvanced("write_timeout", "The maximum amount of time to wait for a message to be acknowledged by the broker."),
+			mqttconf.WillFieldSpec(),
+			tls.FieldSpec(),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 		},
 		Categories: []Category{
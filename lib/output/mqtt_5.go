@@ -0,0 +1,64 @@
+package output
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeMQTT5] = TypeSpec{
+		constructor: NewMQTT5,
+		Beta:        true,
+		Summary: `
+Pushes messages to an MQTT broker using the MQTT v5 protocol.`,
+		Description: `
+The ` + "`topic`" + ` field can be dynamically set using function interpolations
+described [here](/docs/configuration/interpolation#bloblang-queries). When sending batched
+messages these interpolations are performed per message part.
+
+All metadata fields of a message are sent as MQTT user properties, where the
+name of each property is the metadata key.`,
+		Async: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs. Brokers that require TLS should use the `ssl://` or `tls://` URL schemes, and brokers that are exposed over a WebSocket should use the `ws://` or `wss://` URL schemes.", []string{"tcp://localhost:1883"}, []string{"ssl://localhost:8883"}, []string{"wss://localhost:443/mqtt"}),
+			docs.FieldCommon("qos", "The QoS value to set for each message.").HasOptions("0", "1", "2"),
+			docs.FieldCommon("topic", "The topic to publish messages to.", "benthos_topic", `devices/${! meta("device_id") }/events`).SupportsInterpolation(false),
+			docs.FieldCommon("client_id", "An identifier for the client."),
+			docs.FieldAdvanced("session_expiry_interval", "The period of time that the broker should retain the session of this client after it disconnects. A value of zero indicates that the session ends when the connection is closed.", "0s", "1h"),
+			docs.FieldAdvanced("message_expiry_interval", "An optional period of time after which the broker should discard messages that have not yet been delivered to subscribers. When empty messages do not expire.", "60s", "24h"),
+			docs.FieldAdvanced("topic_alias_maximum", "The maximum number of topic aliases to assign when publishing, which reduces the size of messages sent to repeated topics. The limit advertised by the broker is respected when lower, and zero disables topic aliases."),
+			docs.FieldAdvanced("user", "A username to connect with."),
+			docs.FieldAdvanced("password", "A password to connect with."),
+			docs.FieldAdvanced("http_headers", "A map of HTTP headers to add to the upgrade request when connecting to a broker over a WebSocket with the `ws://` or `wss://` URL schemes.", map[string]string{
+				"Authorization": "Bearer foo",
+			}),
+			tls.FieldSpec(),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewMQTT5 creates a new MQTT5 output type.
+func NewMQTT5(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	w, err := writer.NewMQTT5(conf.MQTT5, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	if conf.MQTT5.MaxInFlight == 1 {
+		return NewWriter(TypeMQTT5, w, log, stats)
+	}
+	return NewAsyncWriter(TypeMQTT5, conf.MQTT5.MaxInFlight, w, log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,93 @@
+package output
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeMSTeams] = TypeSpec{
+		constructor: NewMSTeams,
+		Summary: `
+Posts messages to a Microsoft Teams channel via an incoming webhook.`,
+		Description: `
+Each message is posted to the webhook as a JSON payload. The ` + "`text`" + `
+field of the payload is set from an
+[interpolated string](/docs/configuration/interpolation#bloblang-queries),
+which by default is the raw contents of the message. Richer messages such as
+[Adaptive Cards](https://adaptivecards.io) can be built with the
+` + "`payload_mapping`" + `, a [Bloblang mapping](/docs/guides/bloblang/about)
+which should evaluate to an object of payload fields. Fields set by the mapping
+take precedence over the ` + "`text`" + ` field.
+
+### Rate Limits
+
+Microsoft Teams limits the rate at which messages can be posted to a webhook.
+When a request is rate limited it's reattempted once the period given by the
+` + "`Retry-After`" + ` header of the response has elapsed, and any other
+messages written in the meantime are delayed until then.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Adaptive Cards",
+				Summary: `
+Post alerts as Adaptive Cards:`,
+				Config: `
+output:
+  msteams:
+    url: https://example.webhook.office.com/webhookb2/XXXXXXXX
+    text: ""
+    payload_mapping: |
+      root.type = "message"
+      root.attachments = [{
+        "contentType": "application/vnd.microsoft.card.adaptive",
+        "content": {
+          "type": "AdaptiveCard",
+          "version": "1.4",
+          "body": [
+            { "type": "TextBlock", "text": this.summary, "weight": "Bolder" },
+            { "type": "TextBlock", "text": this.details, "wrap": true }
+          ]
+        }
+      }]
+`,
+			},
+		},
+		Async: true,
+		Beta:  true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "The URL of the incoming webhook to post messages to."),
+			docs.FieldCommon("text", "The text of messages.").SupportsInterpolation(false),
+			docs.FieldCommon("payload_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an object of payload fields.", `root.attachments = [ { "contentType": "application/vnd.microsoft.card.adaptive", "content": this.card } ]`),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on a request before abandoning it and reattempting."),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput, at the cost of the order of messages."),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewMSTeams creates a new Microsoft Teams output type.
+func NewMSTeams(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	t, err := writer.NewMSTeams(conf.MSTeams, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	if conf.MSTeams.MaxInFlight == 1 {
+		return NewWriter(
+			TypeMSTeams, t, log, stats,
+		)
+	}
+	return NewAsyncWriter(
+		TypeMSTeams, conf.MSTeams.MaxInFlight, t, log, stats,
+	)
+}
+
+//------------------------------------------------------------------------------
@@ -19,13 +19,26 @@ Publish to an NSQ topic.`,
 		Description: `
 The ` + "`topic`" + ` field can be dynamically set using function interpolations
 described [here](/docs/configuration/interpolation#bloblang-queries). When sending
-batched messages these interpolations are performed per message part.`,
+batched messages these interpolations are performed per message part.
+
+### Deferred Publishing
+
+When the field ` + "`defer_delay`" + ` is set messages are published with the
+DPUB command, which delays their delivery to consumers by the resulting
+duration. This field also supports interpolation functions, allowing the delay
+to be set per message, and messages where it resolves to an empty string or a
+duration that is not positive are published immediately.`,
 		Async: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("nsqd_tcp_address", "The address of the target NSQD server."),
 			docs.FieldCommon("topic", "The topic to publish to.").SupportsInterpolation(false),
 			docs.FieldCommon("user_agent", "A user agent string to connect with."),
 			tls.FieldSpec(),
+			docs.FieldAdvanced("auth_secret", "An optional secret for NSQ authentication (requires nsqd 0.2.29+)."),
+			docs.FieldAdvanced(
+				"defer_delay", "An optional duration to delay the delivery of messages by.",
+				"1m", `${! meta("delay") }`,
+			).SupportsInterpolation(false),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 		},
 		Categories: []Category{
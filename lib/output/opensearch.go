@@ -0,0 +1,129 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeOpenSearch] = TypeSpec{
+		constructor: NewOpenSearch,
+		Summary: `
+Publishes messages into an OpenSearch index or data stream, with support for
+signing requests for Amazon OpenSearch Service.`,
+		Description: `
+The ` + "`id`, `index`, `action` and `routing`" + ` fields can be dynamically
+set using function interpolations described
+[here](/docs/configuration/interpolation#bloblang-queries). When sending batched
+messages these interpolations are performed per message part.
+
+Messages are written with the bulk API, and documents of a batch that are
+rejected because the cluster is overloaded or that fail with a server error are
+retried according to the ` + "`backoff`" + ` fields. The actions and upsert
+behaviour of this output are the same as those of the
+[` + "`elasticsearch`" + ` output](/docs/components/outputs/elasticsearch#actions).
+
+### Data Streams
+
+In order to append documents to a data stream set the ` + "`index`" + ` to the
+name of the data stream and the ` + "`action`" + ` to ` + "`create`" + `, as
+data streams only accept new documents. Setting the ` + "`id`" + ` to an empty
+string allows OpenSearch to generate an ID for each document. Each document
+must also contain a ` + "`@timestamp`" + ` field:
+
+` + "```yaml" + `
+output:
+  opensearch:
+    urls: [ https://search-foo.eu-west-1.es.amazonaws.com ]
+    index: logs-benthos
+    action: create
+    id: ""
+    aws:
+      enabled: true
+      region: eu-west-1
+  processors:
+    - bloblang: |
+        root = this
+        root."@timestamp" = timestamp_utc("2006-01-02T15:04:05.000Z")
+` + "```" + `
+
+### AWS
+
+When the ` + "`aws`" + ` fields are enabled requests are signed with AWS
+Signature Version 4 using the configured credentials, which is required by
+Amazon OpenSearch Service domains that use IAM based access policies.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.OpenSearch, conf.OpenSearch.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.", []string{"http://localhost:9200"}),
+			docs.FieldCommon("index", "The index or data stream to place messages.").SupportsInterpolation(false),
+			docs.FieldAdvanced("pipeline", "An optional pipeline id to preprocess incoming documents.").SupportsInterpolation(false),
+			docs.FieldCommon("id", "The ID for indexed messages. Interpolation should be used in order to create a unique ID for each message, or it can be left empty in order for OpenSearch to generate IDs.").SupportsInterpolation(false),
+			docs.FieldCommon("action", "The action to take on the document, which can be `index`, `create`, `update` or `delete`. Data streams only support `create`.", "index", "create", `${!meta("opensearch_action")}`).SupportsInterpolation(false),
+			docs.FieldAdvanced("routing", "An optional routing key to set for each document, which controls the shard that it is written to.").SupportsInterpolation(false),
+			docs.FieldAdvanced("upsert", "Whether `update` actions should create documents that do not already exist."),
+			docs.FieldAdvanced("script", "An optional inline Painless script to run against documents for `update` actions, where the message is provided as the `params` of the script.", "ctx._source.count += params.count"),
+			docs.FieldAdvanced("timeout", "The maximum time to wait before abandoning a request (and trying again)."),
+			tls.FieldSpec(),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+		}.Merge(retries.FieldSpecs()).Add(
+			auth.BasicAuthFieldSpec(),
+			batch.FieldSpec(),
+			docs.FieldCommon("aws", "Enables and customises the signing of requests for Amazon OpenSearch Service.").WithChildren(
+				docs.FieldSpecs{
+					docs.FieldCommon("enabled", "Whether to sign requests with AWS Signature Version 4."),
+				}.Merge(sess.FieldSpecs())...,
+			),
+		),
+		Categories: []Category{
+			CategoryServices,
+			CategoryAWS,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewOpenSearch creates a new OpenSearch output type.
+func NewOpenSearch(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	osWriter, err := writer.NewOpenSearch(conf.OpenSearch, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.OpenSearch.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeOpenSearch, osWriter, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeOpenSearch, conf.OpenSearch.MaxInFlight, osWriter, log, stats,
+		)
+	}
+	if bconf := conf.OpenSearch.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
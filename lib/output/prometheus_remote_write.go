@@ -0,0 +1,119 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypePrometheusRemoteWrite] = TypeSpec{
+		constructor: NewPrometheusRemoteWrite,
+		Summary: `
+Converts messages into samples and writes them to an endpoint implementing the
+Prometheus remote write protocol.`,
+		Description: `
+Each message is converted into a single sample, where the metric name of the
+sample is the result of the ` + "`name`" + ` interpolation, its labels are the
+result of the ` + "`labels_mapping`" + `, and its value is the result of the
+` + "`value_mapping`" + `. Labels with empty values are omitted.
+
+The timestamp of a sample is the result of the ` + "`timestamp_mapping`" + `,
+which can be either a number of milliseconds since the Unix epoch or an RFC
+3339 timestamp string. When the mapping isn't set or doesn't produce a value
+the time the message is written is used.
+
+The samples of a batch are grouped into series and written with a single
+request, and messages that cannot be converted into samples are rejected
+individually, which allows
+[error handling patterns](/docs/configuration/error_handling) to deal with
+them. Most receivers reject samples that are older than the latest sample of
+their series, and so messages of a series should be written in order.
+
+This output is compatible with Prometheus as well as other systems that accept
+remote writes such as Cortex, Thanos, Mimir and VictoriaMetrics.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Metrics Routing",
+				Summary: `
+Write request counts to a multi-tenant receiver, where the method and status
+code of each count are written as labels:`,
+				Config: `
+output:
+  prometheus_remote_write:
+    url: http://localhost:9009/api/v1/push
+    name: http_requests_total
+    labels_mapping: 'root = { "method": this.method, "code": this.code.string() }'
+    value_mapping: root = this.count
+    timestamp_mapping: root = this.time
+    headers:
+      X-Scope-OrgID: benthos
+    batching:
+      count: 500
+      period: 1s
+`,
+			},
+		},
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.PrometheusRemoteWrite, conf.PrometheusRemoteWrite.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "The URL of the remote write endpoint.", "http://localhost:9090/api/v1/write"),
+			docs.FieldCommon("name", "The metric name of samples.", "http_requests_total", `${! json("metric") }`).SupportsInterpolation(false),
+			docs.FieldCommon("labels_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an object of labels.", `root = { "host": this.host }`),
+			docs.FieldCommon("value_mapping", "A [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to the value of samples."),
+			docs.FieldCommon("timestamp_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to the timestamp of samples, either as a number of milliseconds since the Unix epoch or an RFC 3339 string.", `root = this.time`),
+			docs.FieldAdvanced("headers", "A map of headers to add to requests.", map[string]string{"X-Scope-OrgID": "benthos"}),
+			auth.BasicAuthFieldSpec(),
+			tls.FieldSpec(),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on a request before abandoning it and reattempting."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewPrometheusRemoteWrite creates a new Prometheus remote write output type.
+func NewPrometheusRemoteWrite(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	p, err := writer.NewPrometheusRemoteWrite(conf.PrometheusRemoteWrite, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.PrometheusRemoteWrite.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypePrometheusRemoteWrite, p, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypePrometheusRemoteWrite, conf.PrometheusRemoteWrite.MaxInFlight, p, log, stats,
+		)
+	}
+	if bconf := conf.PrometheusRemoteWrite.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/pulsarconf"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypePulsar] = TypeSpec{
+		constructor: NewPulsar,
+		Summary: `
+Writes messages to an Apache Pulsar topic. [Metadata](/docs/configuration/metadata)
+from messages are sent as properties.`,
+		Description: `
+The field ` + "`key`" + ` can be dynamically set using function interpolations
+described [here](/docs/configuration/interpolation#bloblang-queries) and is
+used by the broker in order to route messages to partitions and to consumers of
+` + "`key_shared`" + ` subscriptions. When sending batched messages the
+interpolations are performed per message part.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.Pulsar, conf.Pulsar.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "A URL to connect to, use a `pulsar+ssl://` scheme in order to connect with TLS.", "pulsar://localhost:6650", "pulsar+ssl://pulsar.example.com:6651"),
+			docs.FieldCommon("topic", "The topic to publish to."),
+			docs.FieldCommon("key", "The key to publish messages with, which determines how messages are routed.", `${! content().hash("xxhash64") }`, `${! meta("kafka_key") }`).SupportsInterpolation(false),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			pulsarconf.AuthFieldSpec(),
+			pulsarconf.TLSFieldSpec(),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewPulsar creates a new Pulsar output type.
+func NewPulsar(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	p, err := writer.NewPulsar(conf.Pulsar, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.Pulsar.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypePulsar, p, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypePulsar, conf.Pulsar.MaxInFlight, p, log, stats,
+		)
+	}
+	if bconf := conf.Pulsar.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,106 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypePusher] = TypeSpec{
+		constructor: NewPusher,
+		Summary: `
+Triggers events on [Pusher Channels](https://pusher.com/channels), pushing
+messages to the browsers and devices subscribed to a channel.`,
+		Description: `
+The contents of each message are sent as the data of an event, where both the
+channel and the name of the event support
+[interpolation functions](/docs/configuration/interpolation#bloblang-queries),
+allowing messages to be routed to channels dynamically. The data of an event
+is limited to 10KB, and larger messages are rejected.
+
+The events of a batch are triggered with as few requests as possible, where
+Pusher allows up to ten events per request. Events that fail to be triggered
+are rejected individually, which allows
+[error handling patterns](/docs/configuration/error_handling) to deal with them.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Live Order Updates",
+				Summary: `
+Push the status of orders to a private channel per customer, where the name of
+the event is the status of the order:`,
+				Config: `
+output:
+  pusher:
+    app_id: ${PUSHER_APP_ID}
+    key: ${PUSHER_KEY}
+    secret: ${PUSHER_SECRET}
+    cluster: eu
+    channel: 'private-customer-${! json("customer_id") }'
+    event: 'order-${! json("status") }'
+    batching:
+      count: 10
+      period: 100ms
+`,
+			},
+		},
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.Pusher, conf.Pusher.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("app_id", "The ID of the Pusher app."),
+			docs.FieldCommon("key", "The key of the Pusher app."),
+			docs.FieldCommon("secret", "The secret of the Pusher app, used to sign requests."),
+			docs.FieldCommon("cluster", "The cluster that the app belongs to.", "mt1", "eu", "us2"),
+			docs.FieldAdvanced("endpoint", "An optional URL of the HTTP API to use instead of the one of the cluster.", "http://localhost:8080"),
+			docs.FieldCommon("channel", "The channel to trigger events on.", "my-channel", `private-${! meta("user_id") }`).SupportsInterpolation(false),
+			docs.FieldCommon("event", "The name of events.", "new-message", `${! json("type") }`).SupportsInterpolation(false),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on a request before abandoning it and reattempting."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewPusher creates a new Pusher output type.
+func NewPusher(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	p, err := writer.NewPusher(conf.Pusher, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.Pusher.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypePusher, p, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypePusher, conf.Pusher.MaxInFlight, p, log, stats,
+		)
+	}
+	if bconf := conf.Pusher.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
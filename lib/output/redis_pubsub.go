@@ -6,6 +6,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output/writer"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	bredis "github.com/Jeffail/benthos/v3/lib/util/redis"
 )
 
 //------------------------------------------------------------------------------
@@ -20,11 +21,10 @@ guarantee that messages have been received.`,
 This output will interpolate functions within the channel field, you
 can find a list of functions [here](/docs/configuration/interpolation#bloblang-queries).`,
 		Async: true,
-		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("url", "The URL of a Redis server to connect to.", "tcp://localhost:6379"),
+		FieldSpecs: bredis.ConfigDocs().Merge(docs.FieldSpecs{
 			docs.FieldCommon("channel", "The channel to publish messages to.").SupportsInterpolation(false),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
-		},
+		}),
 		Categories: []Category{
 			CategoryServices,
 		},
@@ -0,0 +1,199 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeReject] = TypeSpec{
+		constructor: NewReject,
+		Summary: `
+Rejects all messages, treating them as though the output destination failed to
+publish them.`,
+		Description: `
+The error of the rejection is the interpolated ` + "`message`" + `, which is
+resolved from the first message of a batch. Rejected messages are nacked by the
+input that consumed them, and so this output is useful within a
+[` + "`switch`" + `](/docs/components/outputs/switch) output for sending
+messages that cannot be delivered back to their source.
+
+When a ` + "`redelivery_delay`" + ` is set the rejection requests that the
+messages are redelivered once the delay has passed rather than immediately. The
+` + "`sqs`" + ` input honours the delay by setting the visibility timeout of
+the messages, and the ` + "`amqp_0_9`" + ` and ` + "`amqp_1`" + ` inputs hold
+the messages for the delay before requeuing them. Other inputs redeliver the
+messages as they would for any other error.
+
+The ` + "`switch`" + ` output propagates rejections with a redelivery delay
+back to the input immediately, regardless of its ` + "`retry_until_success`" + `
+field or the retry policies of its cases. Other brokers, such as
+` + "`fan_out`" + `, retry failed outputs until they succeed and must
+therefore not be used with this output.`,
+		Categories: []Category{
+			CategoryUtility,
+		},
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("message", "The error message of the rejection.", `failed to process: ${! error() }`).SupportsInterpolation(false),
+			docs.FieldCommon("redelivery_delay", "An optional delay before rejected messages are redelivered by inputs that support it.", "30s", "5m"),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Delayed Redelivery",
+				Summary: `
+Messages that fail processing are rejected and redelivered by the input after a
+minute, whilst all other messages are written to a file.`,
+				Config: `
+output:
+  switch:
+    cases:
+      - check: errored()
+        output:
+          reject:
+            message: "failed to process: ${! error() }"
+            redelivery_delay: 1m
+      - output:
+          file:
+            path: ./processed.jsonl
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// RejectConfig contains configuration fields for the Reject output type.
+type RejectConfig struct {
+	Message         string `json:"message" yaml:"message"`
+	RedeliveryDelay string `json:"redelivery_delay" yaml:"redelivery_delay"`
+}
+
+// NewRejectConfig creates a new RejectConfig with default values.
+func NewRejectConfig() RejectConfig {
+	return RejectConfig{
+		Message:         "message rejected",
+		RedeliveryDelay: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Reject is an output type that responds to all messages with an error.
+type Reject struct {
+	message field.Expression
+	delay   time.Duration
+
+	log      log.Modular
+	mRejects metrics.StatCounter
+
+	transactions <-chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewReject creates a new Reject output type.
+func NewReject(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	r := &Reject{
+		log:        log,
+		mRejects:   stats.GetCounter("reject.count"),
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+
+	var err error
+	if r.message, err = bloblang.NewField(conf.Reject.Message); err != nil {
+		return nil, fmt.Errorf("failed to parse message expression: %v", err)
+	}
+	if conf.Reject.RedeliveryDelay != "" {
+		if r.delay, err = time.ParseDuration(conf.Reject.RedeliveryDelay); err != nil {
+			return nil, fmt.Errorf("failed to parse redelivery delay string: %v", err)
+		}
+		if r.delay < 0 {
+			return nil, errors.New("redelivery delay must not be negative")
+		}
+	}
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (r *Reject) loop() {
+	defer close(r.closedChan)
+
+	for {
+		var ts types.Transaction
+		var open bool
+		select {
+		case ts, open = <-r.transactions:
+			if !open {
+				return
+			}
+		case <-r.closeChan:
+			return
+		}
+
+		r.mRejects.Incr(1)
+		err := errors.New(r.message.String(0, ts.Payload))
+
+		var res types.Response = response.NewError(err)
+		if r.delay > 0 {
+			res = response.NewErrorWithDelay(err, r.delay)
+		}
+
+		select {
+		case ts.ResponseChan <- res:
+		case <-r.closeChan:
+			return
+		}
+	}
+}
+
+// Consume assigns a messages channel for the output to read.
+func (r *Reject) Consume(ts <-chan types.Transaction) error {
+	if r.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	r.transactions = ts
+	go r.loop()
+	return nil
+}
+
+// Connected returns a boolean indicating whether this output is currently
+// connected to its target.
+func (r *Reject) Connected() bool {
+	return true
+}
+
+// CloseAsync shuts down the Reject output and stops processing messages.
+func (r *Reject) CloseAsync() {
+	select {
+	case <-r.closeChan:
+	default:
+		close(r.closeChan)
+	}
+}
+
+// WaitForClose blocks until the Reject output has closed down.
+func (r *Reject) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-r.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -71,6 +71,32 @@ output:
       processors:
         - archive:
             format: json_array
+` + "```" + `
+
+### Multipart Uploads
+
+Objects larger than ` + "`multipart.part_size`" + ` are uploaded with a
+multipart upload, where the parts of an object are streamed from the message
+and up to ` + "`multipart.concurrency`" + ` parts of an object are uploaded in
+parallel. This allows large objects such as archives of big batches to be
+uploaded efficiently, in which case the ` + "`timeout`" + ` should be increased
+to cover the time to upload a whole object.
+
+### Tags and Encryption
+
+The values of ` + "`tags`" + `, along with the ` + "`storage_class`" + `,
+` + "`kms_key_id`" + ` and ` + "`website_redirect_location`" + ` fields, support
+interpolation functions, and so can be set per message:
+
+` + "```yaml" + `
+output:
+  s3:
+    bucket: TODO
+    path: ${! meta("tenant") }/${! timestamp_unix_nano() }.json
+    tags:
+      tenant: ${! meta("tenant") }
+      source: benthos
+    kms_key_id: ${! meta("tenant_kms_key") }
 ` + "```" + ``,
 		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
 			return sanitiseWithBatch(conf.S3, conf.S3.Batching)
@@ -84,12 +110,21 @@ output:
 				`${!meta("kafka_key")}.json`,
 				`${!json("doc.namespace")}/${!json("doc.id")}.json`,
 			).SupportsInterpolation(false),
+			docs.FieldCommon("tags", "Key/value pairs to store with the object as tags.", map[string]string{
+				"Key1":      "Value1",
+				"Timestamp": `${!meta("Timestamp")}`,
+			}).SupportsInterpolation(false),
 			docs.FieldCommon("content_type", "The content type to set for each object.").SupportsInterpolation(false),
 			docs.FieldAdvanced("content_encoding", "An optional content encoding to set for each object.").SupportsInterpolation(false),
 			docs.FieldAdvanced("storage_class", "The storage class to set for each object.").HasOptions(
 				"STANDARD", "REDUCED_REDUNDANCY", "GLACIER", "STANDARD_IA", "ONEZONE_IA", "INTELLIGENT_TIERING", "DEEP_ARCHIVE",
 			).SupportsInterpolation(false),
-			docs.FieldAdvanced("kms_key_id", "An optional server side encryption key."),
+			docs.FieldAdvanced("website_redirect_location", "An optional URL that requests for the object are redirected to when the bucket is configured as a website.", "/other/page.html").SupportsInterpolation(false),
+			docs.FieldAdvanced("kms_key_id", "An optional server side encryption key.").SupportsInterpolation(false),
+			docs.FieldAdvanced("multipart", "Control multipart uploads of large objects.").WithChildren(
+				docs.FieldAdvanced("part_size", "The size in bytes of the parts of a multipart upload, objects larger than this are uploaded in parts. Must be at least 5MB."),
+				docs.FieldAdvanced("concurrency", "The maximum number of parts of an object to upload in parallel."),
+			),
 			docs.FieldAdvanced("force_path_style_urls", "Forces the client API to use path style URLs, which helps when connecting to custom endpoints."),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			docs.FieldAdvanced("timeout", "The maximum period to wait on an upload before abandoning it and reattempting."),
@@ -0,0 +1,69 @@
+package output
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/sftp"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSFTP] = TypeSpec{
+		constructor: NewSFTP,
+		Summary: `
+Writes message parts as files to a server over SFTP.`,
+		Description: `
+Each file is written with the path specified with the 'path' field, in order to
+have a different path for each file you should use function interpolations
+described [here](/docs/configuration/interpolation#bloblang-queries). When
+sending batched messages the interpolations are performed per message part.
+Directories of the path that do not exist are created.
+
+Files are first written to a temporary path within the same directory, and are
+renamed to their path once complete, which replaces any file that already
+exists at the path. Therefore consumers of the directory never observe
+partially written files, although they may observe the temporary files, which
+end with ` + "`.tmp`" + `. When the server supports the OpenSSH
+` + "`posix-rename`" + ` extension replacing an existing file is also atomic.`,
+		Async: true,
+		Beta:  true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("address", "The address of the server to connect to, with a port.", "localhost:22"),
+			sftp.CredentialsFieldSpec(),
+			sftp.KnownHostsFieldSpec(),
+			docs.FieldCommon(
+				"path", "The path to upload messages as, interpolation functions should be used in order to generate unique file paths.",
+				`/data/${!timestamp_unix_nano()}.json`,
+				`uploads/${!meta("kafka_topic")}/${!count("files")}.txt`,
+			).SupportsInterpolation(false),
+			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSFTP creates a new SFTP output type.
+func NewSFTP(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	s, err := writer.NewSFTP(conf.SFTP, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	if conf.SFTP.MaxInFlight == 1 {
+		return NewWriter(
+			TypeSFTP, s, log, stats,
+		)
+	}
+	return NewAsyncWriter(
+		TypeSFTP, conf.SFTP.MaxInFlight, s, log, stats,
+	)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,175 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSMTP] = TypeSpec{
+		constructor: NewSMTP,
+		Summary: `
+Sends messages as emails to an SMTP server.`,
+		Description: `
+Each message is sent as an email where the sender, recipients, subject and body
+are [interpolated strings](/docs/configuration/interpolation#bloblang-queries)
+resolved against the message. The ` + "`to`" + `, ` + "`cc`" + ` and
+` + "`bcc`" + ` fields accept comma separated lists of addresses, and an email
+is sent to all of them, where ` + "`bcc`" + ` recipients are omitted from the
+headers of the email. Emails that are rejected by the server are reattempted
+individually, which allows
+[error handling patterns](/docs/configuration/error_handling) to deal with
+them.
+
+A connection is opened to the server for each batch of messages. When the
+` + "`tls`" + ` field is enabled connections are encrypted from the start, which
+is usually expected on port 465, otherwise they're upgraded with STARTTLS as
+configured by the ` + "`start_tls`" + ` field, in which case the settings of
+` + "`tls`" + ` are used for the upgrade.
+
+### Attachments
+
+When ` + "`attachments.enabled`" + ` is set each batch is sent as a single email
+where every message of the batch is attached as a file, and the headers and
+body of the email are resolved against the first message of the batch. Batches
+can be formed with the ` + "`batching`" + ` fields of this output, or with a
+` + "[`batching` policy](/docs/configuration/batching)" + ` elsewhere in the
+pipeline.
+
+### Rate Limits
+
+Mail providers commonly limit the number of recipients that emails can be
+delivered to over a period of time. The ` + "`rate_limit`" + ` field names a
+[rate limit resource](/docs/components/rate_limits/about) that's accessed once
+for each recipient of an email before it's sent, and therefore limits the
+number of deliveries rather than the number of emails.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Alerting",
+				Summary: `
+Send alerts to the on call address of the team that owns a service, with the
+team resolved from the metadata of each alert:`,
+				Config: `
+output:
+  smtp:
+    address: smtp.example.com:587
+    auth:
+      mechanism: plain
+      username: alerts@example.com
+      password: ${SMTP_PASSWORD}
+    start_tls: required
+    from: Alerts <alerts@example.com>
+    to: '${! meta("team") }-oncall@example.com'
+    subject: '[${! json("severity") }] ${! json("summary") }'
+    body: '${! json("details") }'
+    rate_limit: smtp_quota
+
+resources:
+  rate_limits:
+    smtp_quota:
+      local:
+        count: 100
+        interval: 1h
+`,
+			},
+			{
+				Title: "Reports",
+				Summary: `
+Send the files of a directory as the attachments of a single email:`,
+				Config: `
+output:
+  smtp:
+    address: smtp.example.com:465
+    tls:
+      enabled: true
+    from: reports@example.com
+    to: finance@example.com
+    subject: Daily reports
+    body: The daily reports are attached.
+    attachments:
+      enabled: true
+      filename: '${! meta("path").re_replace(".*/", "") }'
+      content_type: text/csv
+    batching:
+      period: 10s
+`,
+			},
+		},
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.SMTP, conf.SMTP.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("address", "The address of the SMTP server as a host and port.", "smtp.example.com:587", "localhost:25"),
+			docs.FieldAdvanced("local_name", "An optional host name to greet the server with, which defaults to `localhost`."),
+			tls.FieldSpec(),
+			docs.FieldAdvanced("start_tls", "Whether to upgrade unencrypted connections with STARTTLS. When `opportunistic` connections are upgraded if the server supports it, and when `required` emails are not sent to servers that don't.").HasOptions("opportunistic", "required", "disabled"),
+			docs.FieldCommon("auth", "Optional authentication with the server.").WithChildren(
+				docs.FieldCommon("mechanism", "The authentication mechanism to use. With the exception of `cram-md5` mechanisms are only used over encrypted connections, or with servers on the local host.").HasOptions("none", "plain", "login", "cram-md5"),
+				docs.FieldCommon("username", "The username to authenticate as."),
+				docs.FieldCommon("password", "The password to authenticate with."),
+			),
+			docs.FieldCommon("from", "The address to send emails from.", "alerts@example.com", "Alerts <alerts@example.com>").SupportsInterpolation(false),
+			docs.FieldCommon("to", "A comma separated list of addresses to send emails to.", "ops@example.com, Jane <jane@example.com>").SupportsInterpolation(false),
+			docs.FieldCommon("cc", "A comma separated list of addresses to copy emails to.").SupportsInterpolation(false),
+			docs.FieldCommon("bcc", "A comma separated list of addresses to blind copy emails to, which are omitted from the headers of emails.").SupportsInterpolation(false),
+			docs.FieldCommon("subject", "The subject of emails.").SupportsInterpolation(false),
+			docs.FieldCommon("body", "The body of emails.").SupportsInterpolation(false),
+			docs.FieldAdvanced("content_type", "The content type of the body of emails.", "text/plain; charset=utf-8", "text/html; charset=utf-8"),
+			docs.FieldAdvanced("headers", "A map of additional headers to add to emails.", map[string]string{"X-Priority": "1"}).SupportsInterpolation(false),
+			docs.FieldCommon("attachments", "Send each batch as a single email with the messages of the batch attached.").WithChildren(
+				docs.FieldCommon("enabled", "Whether to send batches as emails with attachments."),
+				docs.FieldCommon("filename", "The file name of each attachment.").SupportsInterpolation(false),
+				docs.FieldCommon("content_type", "The content type of each attachment.").SupportsInterpolation(false),
+			),
+			docs.FieldAdvanced("rate_limit", "An optional [rate limit resource](/docs/components/rate_limits/about) to throttle deliveries by, which is accessed once for each recipient of an email."),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on an exchange with the server before abandoning it and reattempting."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSMTP creates a new SMTP output type.
+func NewSMTP(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	s, err := writer.NewSMTP(conf.SMTP, mgr, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.SMTP.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeSMTP, s, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeSMTP, conf.SMTP.MaxInFlight, s, log, stats,
+		)
+	}
+	if bconf := conf.SMTP.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSnowflake] = TypeSpec{
+		constructor: NewSnowflake,
+		Summary: `
+Loads batches of messages into Snowflake tables by uploading them as files to
+the S3 bucket of an external stage and ingesting them with Snowpipe.`,
+		Description: `
+Each batch of messages is written as newline delimited files, where a file is
+created for each unique ` + "`pipe`" + ` of the batch. Files are uploaded to the
+S3 bucket of the stage at the path ` + "`stage.prefix`" + ` followed by
+` + "`stage.path`" + `, and then the
+[Snowpipe REST API](https://docs.snowflake.com/en/user-guide/data-load-snowpipe-rest-apis.html)
+is called in order to ingest the file with its pipe, where the path of the file
+is given relative to the location of the stage.
+
+The ` + "`pipe`" + ` field can be dynamically set using function interpolations
+described [here](/docs/configuration/interpolation#bloblang-queries), which
+allows messages to be routed to different tables by creating a pipe for each
+table. The ` + "`stage.path`" + ` of each file is interpolated from the first
+message of the file. When a file fails to be ingested the path is kept for
+retries of the same file, and since Snowpipe skips files that it has already
+loaded a retried batch is not loaded twice.
+
+Messages are delivered once their files have been accepted by Snowpipe, and
+loading then happens asynchronously. Load errors can be observed with the
+` + "`COPY_HISTORY`" + ` table function of Snowflake.
+
+### Authentication
+
+Requests to Snowpipe are authenticated with
+[key pair authentication](https://docs.snowflake.com/en/user-guide/key-pair-auth.html),
+where ` + "`private_key_file`" + ` is the path of an unencrypted PEM encoded
+RSA private key, and the public key has been assigned to the user.
+
+### Setup
+
+The pipes must copy from an external stage whose location is the S3 bucket and
+prefix of the ` + "`stage`" + ` fields, and use a file format that parses
+newline delimited JSON:
+
+` + "```sql" + `
+CREATE STAGE benthos_stage URL = 's3://my-bucket/benthos/' STORAGE_INTEGRATION = my_integration;
+CREATE PIPE events_pipe AS
+  COPY INTO events FROM @benthos_stage
+  FILE_FORMAT = (TYPE = 'JSON' COMPRESSION = AUTO)
+  MATCH_BY_COLUMN_NAME = CASE_INSENSITIVE;
+` + "```" + `
+
+### Performance
+
+Snowpipe is most efficient when files are between 100 and 250 MB compressed,
+and therefore it is recommended to configure a
+[batching policy](/docs/configuration/batching) that produces large files, and
+to enable ` + "`gzip`" + ` compression.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.Snowflake, conf.Snowflake.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("account", "The account identifier of the Snowflake account, which is the subdomain of the `snowflakecomputing.com` URL of the account.", "ab12345.eu-west-1", "myorg-myaccount"),
+			docs.FieldCommon("user", "The user to authenticate as."),
+			docs.FieldCommon("private_key_file", "The path of a PEM encoded RSA private key for authenticating as the user."),
+			docs.FieldCommon("database", "The database of the pipes."),
+			docs.FieldCommon("schema", "The schema of the pipes.", "PUBLIC"),
+			docs.FieldCommon("pipe", "The name of the pipe to ingest files with.", "EVENTS_PIPE", `${!meta("table")}_PIPE`).SupportsInterpolation(false),
+			docs.FieldCommon("stage", "The S3 bucket and prefix of the external stage that files are uploaded to.").WithChildren(
+				docs.FieldCommon("bucket", "The bucket of the stage."),
+				docs.FieldCommon("prefix", "The prefix of the location of the stage within the bucket.", "benthos/"),
+				docs.FieldCommon("path", "The path of each file relative to the location of the stage.", `${!meta("table")}/${!timestamp_unix_nano()}.json.gz`).SupportsInterpolation(false),
+				docs.FieldAdvanced("force_path_style_urls", "Forces the client API to use path style URLs, which helps when connecting to custom endpoints."),
+			).WithChildren(sess.FieldSpecs()...),
+			docs.FieldCommon("compression", "The compression to apply to files.").HasOptions("none", "gzip"),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on a request to Snowpipe before abandoning it and reattempting."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSnowflake creates a new Snowflake output type.
+func NewSnowflake(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	s, err := writer.NewSnowflake(conf.Snowflake, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.Snowflake.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeSnowflake, s, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeSnowflake, conf.Snowflake.MaxInFlight, s, log, stats,
+		)
+	}
+	if bconf := conf.Snowflake.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
@@ -17,6 +17,18 @@ func init() {
 		Summary: `
 Sends messages to an AWS SNS topic.`,
 		Description: `
+Metadata values are sent along with the payload as message attributes with the
+data type String. If the number of metadata values in a message exceeds the
+message attribute limit (10) then the top ten keys ordered alphabetically will
+be selected.
+
+When publishing to a FIFO topic the ` + "`message_group_id`" + ` field must be
+set, and the ` + "`message_deduplication_id`" + ` field must be set unless
+content-based deduplication is enabled for the topic. Both fields can be set
+dynamically using
+[function interpolations](/docs/configuration/interpolation#bloblang-queries), which are
+resolved individually for each message of a batch.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -26,6 +38,8 @@ allowing you to transfer data across accounts. You can find out more
 		Async: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("topic_arn", "The topic to publish to."),
+			docs.FieldCommon("message_group_id", "An optional group ID to set for messages, which is required by FIFO topics.").SupportsInterpolation(false),
+			docs.FieldCommon("message_deduplication_id", "An optional deduplication ID to set for messages.").SupportsInterpolation(false),
 			docs.FieldCommon("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			docs.FieldAdvanced("timeout", "The maximum period to wait on an upload before abandoning it and reattempting."),
 		}.Merge(session.FieldSpecs()),
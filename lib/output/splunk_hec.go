@@ -0,0 +1,133 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSplunkHEC] = TypeSpec{
+		constructor: NewSplunkHEC,
+		Summary: `
+Sends messages to a Splunk HTTP Event Collector (HEC).`,
+		Description: `
+The ` + "`endpoint`" + ` field determines how messages are sent:
+
+- ` + "`event`" + `: Each message is sent as an event where messages that are
+  valid JSON are sent as structured events, and other messages are sent as
+  strings. The ` + "`host`, `source`, `sourcetype` and `index`" + ` fields are
+  resolved for each message and the time of an event is the result of the
+  optional ` + "`timestamp_mapping`" + `, which can be either a number of
+  seconds since the Unix epoch or an RFC 3339 timestamp string. The events of a
+  batch are sent with a single request.
+- ` + "`raw`" + `: The raw contents of messages are sent as newline delimited
+  lines, which are broken into events according to the configuration of the
+  sourcetype. Since the metadata of raw events is set per request, the messages
+  of a batch are sent with a request for each distinct combination of the
+  ` + "`host`, `source`, `sourcetype` and `index`" + ` fields.
+
+Fields that resolve to empty strings are omitted, in which case the defaults of
+the token are used.
+
+### Indexer Acknowledgement
+
+When indexer acknowledgement is enabled for the token the
+` + "`acknowledgement`" + ` fields can be used in order to only acknowledge
+messages once Splunk has indexed them. After each request the status of its
+events is polled until they have been indexed, and if they aren't indexed
+within the ` + "`acknowledgement.timeout`" + ` the messages are reattempted,
+which means events might be duplicated. Acknowledgements are tracked within a
+channel identified by the ` + "`channel`" + ` field, which is generated
+randomly when not set.`,
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Structured Events",
+				Summary: `
+Send JSON documents as structured events to an index chosen by each document,
+and wait for the events to be indexed:`,
+				Config: `
+output:
+  splunk_hec:
+    url: https://localhost:8088
+    token: ${SPLUNK_HEC_TOKEN}
+    sourcetype: _json
+    index: ${! json("team").or("main") }
+    timestamp_mapping: root = this.time
+    acknowledgement:
+      enabled: true
+    batching:
+      count: 100
+      period: 1s
+`,
+			},
+		},
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return sanitiseWithBatch(conf.SplunkHEC, conf.SplunkHEC.Batching)
+		},
+		Async:   true,
+		Batches: true,
+		Beta:    true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "The base URL of the HTTP Event Collector.", "https://localhost:8088"),
+			docs.FieldCommon("token", "The token to authenticate with."),
+			docs.FieldCommon("endpoint", "The endpoint to send messages to.").HasOptions("event", "raw"),
+			docs.FieldCommon("host", "An optional host of events.").SupportsInterpolation(false),
+			docs.FieldCommon("source", "An optional source of events.").SupportsInterpolation(false),
+			docs.FieldCommon("sourcetype", "An optional sourcetype of events.", "_json", `${! meta("sourcetype") }`).SupportsInterpolation(false),
+			docs.FieldCommon("index", "An optional index to write events to.", "main", `${! json("team") }`).SupportsInterpolation(false),
+			docs.FieldCommon("timestamp_mapping", "An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to the time of events, either as a number of seconds since the Unix epoch or an RFC 3339 string. Only supported by the `event` endpoint.", `root = this.time`),
+			docs.FieldAdvanced("channel", "An optional channel identifier, which must be a GUID, to send requests with. Required by tokens with indexer acknowledgement enabled, and generated randomly when not set and `acknowledgement.enabled` is true."),
+			docs.FieldAdvanced("acknowledgement", "Configure the polling of indexer acknowledgements.").WithChildren(
+				docs.FieldCommon("enabled", "Whether to wait for events to be indexed before acknowledging messages."),
+				docs.FieldAdvanced("poll_interval", "The period to wait between polls of the acknowledgement status of events."),
+				docs.FieldAdvanced("timeout", "The maximum period to wait for events to be indexed before reattempting them."),
+			),
+			tls.FieldSpec(),
+			docs.FieldAdvanced("timeout", "The maximum period to wait on a request before abandoning it and reattempting."),
+			docs.FieldCommon("max_in_flight", "The maximum number of batches to have in flight at a given time. Increase this to improve throughput."),
+			batch.FieldSpec(),
+		},
+		Categories: []Category{
+			CategoryServices,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSplunkHEC creates a new Splunk HEC output type.
+func NewSplunkHEC(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	s, err := writer.NewSplunkHEC(conf.SplunkHEC, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	var w Type
+	if conf.SplunkHEC.MaxInFlight == 1 {
+		w, err = NewWriter(
+			TypeSplunkHEC, s, log, stats,
+		)
+	} else {
+		w, err = NewAsyncWriter(
+			TypeSplunkHEC, conf.SplunkHEC.MaxInFlight, s, log, stats,
+		)
+	}
+	if bconf := conf.SplunkHEC.Batching; err == nil && !bconf.IsNoop() {
+		policy, err := batch.NewPolicy(bconf, mgr, log.NewModule(".batching"), metrics.Namespaced(stats, "batching"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
+		}
+		w = NewBatcher(policy, w, log, stats)
+	}
+	return w, err
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,61 @@
+package output
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output/writer"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSubprocess] = TypeSpec{
+		constructor: NewSubprocess,
+		Summary: `
+Executes a command, runs it as a subprocess, and writes messages to it over
+stdin.`,
+		Description: `
+Messages are written to the stdin stream of the subprocess framed with the
+` + "`codec`" + `, where each message of a batch is written individually:
+
+- ` + "`lines`" + ` follows each message with a line break.
+- ` + "`delim:x`" + ` follows each message with the custom delimiter x.
+- ` + "`length-prefixed`" + ` prefixes each message with its length as a
+  32-bit big-endian unsigned integer.
+- ` + "`protobuf-delimited`" + ` prefixes each message with its length as a
+  varint, as read by protobuf delimited readers.
+
+Anything written by the subprocess to stdout is logged at the debug level, and
+anything written to stderr is logged as a warning.
+
+If the subprocess exits it is restarted before the next message is written.
+When Benthos shuts down the stdin of the subprocess is closed, and it is killed
+if it has not exited within a second.`,
+		Beta: true,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("name", "The command to execute as a subprocess.", "cat", "sed", "awk"),
+			docs.FieldCommon("args", "A list of arguments to provide the command."),
+			docs.FieldCommon("codec", "The way in which messages should be written to the subprocess.").HasOptions(
+				"lines", "delim:x", "length-prefixed", "protobuf-delimited",
+			),
+		},
+		Categories: []Category{
+			CategoryLocal,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSubprocess creates a new Subprocess output type.
+func NewSubprocess(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	s, err := writer.NewSubprocess(conf.Subprocess, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter(TypeSubprocess, s, log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -2,6 +2,7 @@ package output
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
@@ -16,7 +17,9 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
 	"github.com/Jeffail/benthos/v3/lib/util/throttle"
+	"github.com/cenkalti/backoff/v4"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -50,7 +53,12 @@ reattempted indefinitely. If set to false the error is instead propagated back
 to the input level.
 
 If a message can be routed to >1 outputs it is usually best to set this to true
-in order to avoid duplicate messages being routed to an output.`,
+in order to avoid duplicate messages being routed to an output.
+
+Cases with an enabled `+"`retry`"+` policy use that policy instead. Errors
+that request a delayed redelivery, such as those of the
+`+"[`reject`](/docs/components/outputs/reject)"+` output, are always propagated
+back to the input level.`,
 			),
 			docs.FieldAdvanced(
 				"strict_mode", `
@@ -99,9 +107,42 @@ The maximum number of parallel message batches to have in flight at any given ti
 					"continue",
 					"Indicates whether, if this case passes for a message, the next case should also be tested.",
 				).HasDefault(false),
+				docs.FieldAdvanced(
+					"retry",
+					"An optional retry policy for the case output, which when enabled is used instead of `retry_until_success`. Once the retries of a failed send are exhausted the error is propagated back to the input level.",
+				).WithChildren(
+					docs.FieldAdvanced("enabled", "Whether to use the retry policy of this case.").HasDefault(false),
+					docs.FieldAdvanced("max_retries", "The maximum number of retries before giving up on the request. If set to zero there is no discrete limit.").HasDefault(0),
+					docs.FieldAdvanced("backoff", "Control time intervals between retry attempts.").WithChildren(
+						docs.FieldAdvanced("initial_interval", "The initial period to wait between retry attempts.").HasDefault("500ms"),
+						docs.FieldAdvanced("max_interval", "The maximum period to wait between retry attempts.").HasDefault("3s"),
+						docs.FieldAdvanced("max_elapsed_time", "The maximum period to wait before retry attempts are abandoned. If zero then no limit is used.").HasDefault("0s"),
+					).HasDefault(map[string]interface{}{
+						"initial_interval": "500ms",
+						"max_interval":     "3s",
+						"max_elapsed_time": "0s",
+					}),
+				).HasDefault(map[string]interface{}{
+					"enabled":     false,
+					"max_retries": 0,
+					"backoff": map[string]interface{}{
+						"initial_interval": "500ms",
+						"max_interval":     "3s",
+						"max_elapsed_time": "0s",
+					},
+				}),
 			),
 			docs.FieldDeprecated("outputs"),
 		},
+		Footnotes: `
+## Metrics
+
+Along with the metrics of the switch as a whole each case exposes the counters
+` + "`switch.cases.<index>.matched`" + `, which is the number of messages that
+passed the check of the case, ` + "`switch.cases.<index>.sent`" + `, which is
+the number of batches successfully sent to the case output, and
+` + "`switch.cases.<index>.error`" + `, which is the number of failed attempts
+to send a batch to the case output.`,
 		Categories: []Category{
 			CategoryUtility,
 		},
@@ -179,6 +220,7 @@ output:
 					"check":    c.Check,
 					"output":   sanOutput,
 					"continue": c.Continue,
+					"retry":    c.Retry,
 				}
 				casesSlice = append(casesSlice, sanit)
 			}
@@ -233,9 +275,17 @@ func NewSwitchConfig() SwitchConfig {
 
 // SwitchConfigCase contains configuration fields per output of a switch type.
 type SwitchConfigCase struct {
-	Check    string `json:"check" yaml:"check"`
-	Continue bool   `json:"continue" yaml:"continue"`
-	Output   Config `json:"output" yaml:"output"`
+	Check    string                `json:"check" yaml:"check"`
+	Continue bool                  `json:"continue" yaml:"continue"`
+	Output   Config                `json:"output" yaml:"output"`
+	Retry    SwitchConfigCaseRetry `json:"retry" yaml:"retry"`
+}
+
+// SwitchConfigCaseRetry contains configuration fields for the retry policy of
+// a switch case.
+type SwitchConfigCaseRetry struct {
+	Enabled        bool `json:"enabled" yaml:"enabled"`
+	retries.Config `json:",inline" yaml:",inline"`
 }
 
 // NewSwitchConfigCase creates a new switch output config with default values.
@@ -244,7 +294,39 @@ func NewSwitchConfigCase() SwitchConfigCase {
 		Check:    "",
 		Continue: false,
 		Output:   NewConfig(),
+		Retry: SwitchConfigCaseRetry{
+			Enabled: false,
+			Config:  retries.NewConfig(),
+		},
+	}
+}
+
+// UnmarshalJSON ensures that when parsing configs that are in a map or slice
+// the default values are still applied.
+func (s *SwitchConfigCase) UnmarshalJSON(bytes []byte) error {
+	type confAlias SwitchConfigCase
+	aliased := confAlias(NewSwitchConfigCase())
+
+	if err := json.Unmarshal(bytes, &aliased); err != nil {
+		return err
+	}
+
+	*s = SwitchConfigCase(aliased)
+	return nil
+}
+
+// UnmarshalYAML ensures that when parsing configs that are in a map or slice
+// the default values are still applied.
+func (s *SwitchConfigCase) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type confAlias SwitchConfigCase
+	aliased := confAlias(NewSwitchConfigCase())
+
+	if err := unmarshal(&aliased); err != nil {
+		return err
 	}
+
+	*s = SwitchConfigCase(aliased)
+	return nil
 }
 
 //------------------------------------------------------------------------------
@@ -265,6 +347,7 @@ type Switch struct {
 	checks            []*mapping.Executor
 	conditions        []types.Condition
 	continues         []bool
+	backoffCtors      []func() backoff.BackOff
 	fallthroughs      []bool
 
 	ctx        context.Context
@@ -305,6 +388,7 @@ func NewSwitch(
 		o.outputs = make([]types.Output, lCases)
 		o.checks = make([]*mapping.Executor, lCases)
 		o.continues = make([]bool, lCases)
+		o.backoffCtors = make([]func() backoff.BackOff, lCases)
 		o.fallthroughs = make([]bool, lCases)
 	} else {
 		o.outputs = make([]types.Output, lOutputs)
@@ -347,6 +431,14 @@ func NewSwitch(
 			}
 		}
 		o.continues[i] = cConf.Continue
+		if cConf.Retry.Enabled {
+			// The constructor references the config, and so it mustn't be
+			// given the loop variable.
+			retryConf := cConf.Retry.Config
+			if o.backoffCtors[i], err = retryConf.GetCtor(); err != nil {
+				return nil, fmt.Errorf("failed to parse case '%v' retry policy: %v", i, err)
+			}
+		}
 	}
 
 	o.outputTsChans = make([]chan types.Transaction, len(o.outputs))
@@ -397,7 +489,16 @@ func (o *Switch) loop() {
 		mMsgRcvd   = o.stats.GetCounter("switch.messages.received")
 		mMsgSnt    = o.stats.GetCounter("switch.messages.sent")
 		mOutputErr = o.stats.GetCounter("switch.output.error")
+
+		mCaseMatched = make([]metrics.StatCounter, len(o.checks))
+		mCaseSent    = make([]metrics.StatCounter, len(o.checks))
+		mCaseErr     = make([]metrics.StatCounter, len(o.checks))
 	)
+	for i := range o.checks {
+		mCaseMatched[i] = o.stats.GetCounter(fmt.Sprintf("switch.cases.%v.matched", i))
+		mCaseSent[i] = o.stats.GetCounter(fmt.Sprintf("switch.cases.%v.sent", i))
+		mCaseErr[i] = o.stats.GetCounter(fmt.Sprintf("switch.cases.%v.error", i))
+	}
 
 	defer func() {
 		wg.Wait()
@@ -445,6 +546,7 @@ func (o *Switch) loop() {
 					}
 					if test {
 						routedAtLeastOnce = true
+						mCaseMatched[j].Incr(1)
 						outputTargets[j] = append(outputTargets[j], p.Copy())
 						if !o.continues[j] {
 							return nil
@@ -475,6 +577,11 @@ func (o *Switch) loop() {
 					throt := throttle.New(throttle.OptCloseChan(o.ctx.Done()))
 					resChan := make(chan types.Response)
 
+					var boff backoff.BackOff
+					if ctor := o.backoffCtors[i]; ctor != nil {
+						boff = ctor()
+					}
+
 					// Try until success or shutdown.
 					for {
 						select {
@@ -485,7 +592,26 @@ func (o *Switch) loop() {
 						select {
 						case res := <-resChan:
 							if res.Error() != nil {
-								if o.retryUntilSuccess {
+								mCaseErr[i].Incr(1)
+								if _, delayed := response.RedeliveryDelay(res.Error()); delayed {
+									// Rejections that request a delayed
+									// redelivery are returned to the input
+									// rather than retried.
+									return res.Error()
+								}
+								if boff != nil {
+									wait := boff.NextBackOff()
+									if wait == backoff.Stop {
+										return res.Error()
+									}
+									o.logger.Errorf("Failed to dispatch switch message to case %v: %v\n", i, res.Error())
+									mOutputErr.Incr(1)
+									select {
+									case <-time.After(wait):
+									case <-o.ctx.Done():
+										return types.ErrTypeClosed
+									}
+								} else if o.retryUntilSuccess {
 									o.logger.Errorf("Failed to dispatch switch message: %v\n", res.Error())
 									mOutputErr.Incr(1)
 									if !throt.Retry() {
@@ -496,6 +622,7 @@ func (o *Switch) loop() {
 								}
 							} else {
 								mMsgSnt.Incr(1)
+								mCaseSent[i].Incr(1)
 								return nil
 							}
 						case <-o.ctx.Done():
@@ -14,6 +14,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
 )
 
 //------------------------------------------------------------------------------
@@ -178,6 +179,162 @@ func TestSwitchNoRetries(t *testing.T) {
 	}
 }
 
+func TestSwitchCaseRetryDefaults(t *testing.T) {
+	conf := NewSwitchConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+cases:
+  - check: this.foo == "bar"
+    output:
+      drop: {}
+  - output:
+      drop: {}
+    retry:
+      enabled: true
+      max_retries: 3
+`), &conf))
+
+	require.Len(t, conf.Cases, 2)
+
+	assert.False(t, conf.Cases[0].Retry.Enabled)
+	assert.Equal(t, "500ms", conf.Cases[0].Retry.Backoff.InitialInterval)
+
+	assert.True(t, conf.Cases[1].Retry.Enabled)
+	assert.Equal(t, uint64(3), conf.Cases[1].Retry.MaxRetries)
+	assert.Equal(t, "3s", conf.Cases[1].Retry.Backoff.MaxInterval)
+}
+
+func TestSwitchCaseRetries(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSwitch
+	mockOutputs := []*MockOutputType{{}, {}}
+	for i := range mockOutputs {
+		conf.Switch.Cases = append(conf.Switch.Cases, NewSwitchConfigCase())
+		conf.Switch.Cases[i].Continue = true
+	}
+	conf.Switch.Cases[0].Retry.Enabled = true
+	conf.Switch.Cases[0].Retry.MaxRetries = 1
+	conf.Switch.Cases[0].Retry.Backoff.InitialInterval = "1ms"
+	conf.Switch.Cases[0].Retry.Backoff.MaxInterval = "1ms"
+
+	stats := metrics.NewLocal()
+	genType, err := New(conf, nil, log.Noop(), stats)
+	require.NoError(t, err)
+
+	s, ok := genType.(*Switch)
+	require.True(t, ok)
+	for i := range mockOutputs {
+		close(s.outputTsChans[i])
+		s.outputs[i] = mockOutputs[i]
+		s.outputTsChans[i] = make(chan types.Transaction)
+		mockOutputs[i].Consume(s.outputTsChans[i])
+	}
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, s.Consume(readChan))
+
+	select {
+	case readChan <- types.NewTransaction(message.New([][]byte{[]byte("hello world")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+
+	go func() {
+		for i := 0; i < 2; i++ {
+			select {
+			case ts := <-mockOutputs[0].TChan:
+				ts.ResponseChan <- response.NewError(errors.New("nope"))
+			case <-time.After(time.Second):
+				t.Error("Timed out waiting for broker propagate")
+				return
+			}
+		}
+	}()
+
+	select {
+	case ts := <-mockOutputs[1].TChan:
+		ts.ResponseChan <- response.NewAck()
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker propagate")
+	}
+
+	select {
+	case res := <-resChan:
+		assert.EqualError(t, res.Error(), "nope")
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for response")
+	}
+
+	s.CloseAsync()
+	require.NoError(t, s.WaitForClose(time.Second*5))
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(1), counters["switch.cases.0.matched"])
+	assert.Equal(t, int64(2), counters["switch.cases.0.error"])
+	assert.Equal(t, int64(0), counters["switch.cases.0.sent"])
+	assert.Equal(t, int64(1), counters["switch.cases.1.matched"])
+	assert.Equal(t, int64(1), counters["switch.cases.1.sent"])
+}
+
+func TestSwitchRejectRedeliveryDelay(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSwitch
+	require.True(t, conf.Switch.RetryUntilSuccess)
+
+	rejectCase := NewSwitchConfigCase()
+	rejectCase.Check = `content() == "reject"`
+	rejectCase.Output.Type = TypeReject
+	rejectCase.Output.Reject.Message = "rejected: ${! content() }"
+	rejectCase.Output.Reject.RedeliveryDelay = "1m"
+	dropCase := NewSwitchConfigCase()
+	dropCase.Output.Type = TypeDrop
+	conf.Switch.Cases = append(conf.Switch.Cases, rejectCase, dropCase)
+
+	stats := metrics.NewLocal()
+	s, err := New(conf, nil, log.Noop(), stats)
+	require.NoError(t, err)
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+	require.NoError(t, s.Consume(readChan))
+
+	select {
+	case readChan <- types.NewTransaction(message.New([][]byte{[]byte("reject")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+
+	select {
+	case res := <-resChan:
+		require.EqualError(t, res.Error(), "rejected: reject")
+		delay, ok := response.RedeliveryDelay(res.Error())
+		assert.True(t, ok)
+		assert.Equal(t, time.Minute, delay)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for response")
+	}
+
+	select {
+	case readChan <- types.NewTransaction(message.New([][]byte{[]byte("hello")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+
+	select {
+	case res := <-resChan:
+		assert.NoError(t, res.Error())
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for response")
+	}
+
+	s.CloseAsync()
+	require.NoError(t, s.WaitForClose(time.Second*5))
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(1), counters["switch.cases.0.error"])
+	assert.Equal(t, int64(1), counters["switch.cases.1.sent"])
+}
+
 func TestSwitchWithConditions(t *testing.T) {
 	nMsgs := 100
 
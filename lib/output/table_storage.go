@@ -3,6 +3,7 @@ package output
 import (
 	"fmt"
 
+	"github.com/Jeffail/benthos/v3/internal/azureaad"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
@@ -53,7 +54,20 @@ It's also possible to use function interpolations to get or transform the proper
 properties:
 	device: '${! json("device") }'
 	timestamp: '${! json("timestamp") }'
-` + "```" + ``,
+` + "```" + `
+
+Entities are inserted by default, which fails for entities that already exist.
+In order to upsert entities set the ` + "`insert_type`" + ` to either
+` + "`INSERT_MERGE`" + `, which merges the properties of a message into an
+existing entity, or ` + "`INSERT_REPLACE`" + `, which replaces the entity.
+
+### Authentication
+
+The output authenticates with either the ` + "`storage_access_key`" + ` of the
+` + "`storage_account`" + `, a ` + "`storage_connection_string`" + `, or with
+Azure Active Directory when ` + "`aad.enabled`" + ` is set, in which case a
+managed identity is used unless the credentials of a service principal are
+provided.`,
 		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
 			return sanitiseWithBatch(conf.TableStorage, conf.TableStorage.Batching)
 		},
@@ -62,6 +76,8 @@ properties:
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("storage_account", "The storage account to upload messages to."),
 			docs.FieldCommon("storage_access_key", "The storage account access key."),
+			docs.FieldAdvanced("storage_connection_string", "A storage account connection string, which can be used instead of the storage account and access key. It is recommended that you use environment variables to populate this field.", "${AZURE_STORAGE_CONNECTION_STRING}"),
+			azureaad.FieldSpec("Authenticate with Azure Active Directory instead of an access key, using either a service principal or, when a client secret is not provided, a managed identity. The identity requires a data role of the storage account, such as Storage Table Data Contributor."),
 			docs.FieldCommon("table_name", "The table to store messages into.",
 				`${!meta("kafka_topic")}`,
 			).SupportsInterpolation(false),
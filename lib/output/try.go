@@ -68,7 +68,10 @@ failed, and only propagates those individual messages to the next try tier.
 However, depending on the output and the error returned it is sometimes not
 possible to determine the individual messages that failed, in which case the
 whole batch is passed to the next tier in order to preserve at-least-once
-guarantees.`,
+guarantees.
+
+The ` + "[`fallback`](/docs/components/outputs/fallback)" + ` output behaves the
+same way and also adds the error of a failed attempt to messages as metadata.`,
 		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
 			outSlice := []interface{}{}
 			for _, output := range conf.Try {
@@ -106,8 +109,20 @@ func NewTry(
 	stats metrics.Type,
 	pipelines ...types.PipelineConstructorFunc,
 ) (Type, error) {
-	outputConfs := conf.Try
+	return newTryBroker(TypeTry, brokerOutputList(conf.Try), "", mgr, log, stats, pipelines...)
+}
 
+// newTryBroker creates a try broker from a list of outputs, which is shared by
+// the try and fallback output types.
+func newTryBroker(
+	typeStr string,
+	outputConfs brokerOutputList,
+	errorMetaKey string,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+	pipelines ...types.PipelineConstructorFunc,
+) (Type, error) {
 	if len(outputConfs) == 0 {
 		return nil, ErrBrokerNoOutputs
 	}
@@ -115,7 +130,7 @@ func NewTry(
 
 	var err error
 	for i, oConf := range outputConfs {
-		ns := fmt.Sprintf("try.%v", i)
+		ns := fmt.Sprintf("%v.%v", typeStr, i)
 		var pipes []types.PipelineConstructorFunc
 		outputs[i], err = New(
 			oConf, mgr,
@@ -132,7 +147,8 @@ func NewTry(
 		return nil, err
 	}
 	t.WithMaxInFlight(50)
-	t.WithOutputMetricsPrefix("try.outputs")
+	t.WithOutputMetricsPrefix(typeStr + ".outputs")
+	t.WithErrorMetadataKey(errorMetaKey)
 	return WrapWithPipelines(t, pipelines...)
 }
 
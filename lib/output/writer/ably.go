@@ -0,0 +1,229 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	batchInternal "github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// AblyConfig contains configuration fields for the Ably output type.
+type AblyConfig struct {
+	APIKey      string             `json:"api_key" yaml:"api_key"`
+	Endpoint    string             `json:"endpoint" yaml:"endpoint"`
+	Channel     string             `json:"channel" yaml:"channel"`
+	Event       string             `json:"event" yaml:"event"`
+	ID          string             `json:"id" yaml:"id"`
+	Timeout     string             `json:"timeout" yaml:"timeout"`
+	MaxInFlight int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching    batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewAblyConfig creates a new AblyConfig with default values.
+func NewAblyConfig() AblyConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return AblyConfig{
+		APIKey:      "",
+		Endpoint:    "https://rest.ably.io",
+		Channel:     "",
+		Event:       "",
+		ID:          "",
+		Timeout:     "5s",
+		MaxInFlight: 1,
+		Batching:    batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type ablyMessage struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	Data string `json:"data"`
+}
+
+// Ably is a writer type that publishes messages to Ably channels.
+type Ably struct {
+	conf AblyConfig
+
+	endpoint string
+	auth     string
+	channel  field.Expression
+	event    field.Expression
+	id       field.Expression
+
+	httpClient *http.Client
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewAbly creates a new Ably writer type.
+func NewAbly(conf AblyConfig, log log.Modular, stats metrics.Type) (*Ably, error) {
+	a := &Ably{
+		conf:     conf,
+		endpoint: strings.TrimSuffix(conf.Endpoint, "/"),
+		log:      log,
+		stats:    stats,
+	}
+	if conf.APIKey == "" {
+		return nil, errors.New("an api_key must be specified")
+	}
+	if a.endpoint == "" {
+		return nil, errors.New("an endpoint must be specified")
+	}
+	a.auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(conf.APIKey))
+
+	if conf.Channel == "" {
+		return nil, errors.New("a channel must be specified")
+	}
+	var err error
+	if a.channel, err = bloblang.NewField(conf.Channel); err != nil {
+		return nil, fmt.Errorf("failed to parse channel expression: %v", err)
+	}
+	if a.event, err = bloblang.NewField(conf.Event); err != nil {
+		return nil, fmt.Errorf("failed to parse event expression: %v", err)
+	}
+	if a.id, err = bloblang.NewField(conf.ID); err != nil {
+		return nil, fmt.Errorf("failed to parse id expression: %v", err)
+	}
+
+	timeout, err := time.ParseDuration(conf.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+	}
+	a.httpClient = &http.Client{Timeout: timeout}
+	return a, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (a *Ably) publish(ctx context.Context, channel string, msgs []ablyMessage) error {
+	body, err := json.Marshal(msgs)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", a.endpoint+"/channels/"+url.PathEscape(channel)+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", a.auth)
+
+	res, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to publish to channel %v: %v: %s", channel, res.Status, bytes.TrimSpace(resBody))
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as the Ably output writes with plain HTTP requests.
+func (a *Ably) Connect() error {
+	return a.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as the Ably output writes with plain HTTP
+// requests.
+func (a *Ably) ConnectWithContext(ctx context.Context) error {
+	a.log.Infof("Publishing messages to Ably at: %v\n", a.endpoint)
+	return nil
+}
+
+// Write attempts to write a message batch to Ably.
+func (a *Ably) Write(msg types.Message) error {
+	return a.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext groups the messages of a batch by their channel and
+// publishes each group with a single request, preserving the order of messages
+// within a channel. Messages of a channel that cannot be published are rejected
+// individually.
+func (a *Ably) WriteWithContext(ctx context.Context, msg types.Message) error {
+	var batchErr *batchInternal.Error
+	failed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = batchInternal.NewError(msg, err)
+		}
+		batchErr.Failed(i, err)
+	}
+
+	var channels []string
+	groups := map[string][]ablyMessage{}
+	indexes := map[string][]int{}
+
+	msg.Iter(func(i int, part types.Part) error {
+		channel := a.channel.String(i, msg)
+		if channel == "" {
+			failed(i, errors.New("channel is empty"))
+			return nil
+		}
+		if _, exists := groups[channel]; !exists {
+			channels = append(channels, channel)
+		}
+		groups[channel] = append(groups[channel], ablyMessage{
+			ID:   a.id.String(i, msg),
+			Name: a.event.String(i, msg),
+			Data: string(part.Get()),
+		})
+		indexes[channel] = append(indexes[channel], i)
+		return nil
+	})
+
+	var lastErr error
+	for _, channel := range channels {
+		if err := a.publish(ctx, channel, groups[channel]); err != nil {
+			lastErr = err
+			for _, i := range indexes[channel] {
+				failed(i, err)
+			}
+		}
+	}
+
+	if batchErr != nil {
+		if lastErr != nil && batchErr.IndexedErrors() == msg.Len() {
+			return lastErr
+		}
+		return batchErr
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (a *Ably) CloseAsync() {
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (a *Ably) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
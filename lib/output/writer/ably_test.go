@@ -0,0 +1,149 @@
+package writer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ablyTestRequest struct {
+	path string
+	auth string
+	msgs []ablyMessage
+}
+
+type ablyTestServer struct {
+	*httptest.Server
+
+	mut  sync.Mutex
+	reqs []ablyTestRequest
+}
+
+func newAblyTestServer(t *testing.T) *ablyTestServer {
+	t.Helper()
+
+	s := &ablyTestServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		req := ablyTestRequest{
+			path: r.URL.EscapedPath(),
+			auth: r.Header.Get("Authorization"),
+		}
+		if err := json.Unmarshal(b, &req.msgs); err != nil {
+			t.Error(err)
+		}
+		s.mut.Lock()
+		s.reqs = append(s.reqs, req)
+		s.mut.Unlock()
+
+		if r.URL.Path == "/channels/bad/messages" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":40160}}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *ablyTestServer) requests() []ablyTestRequest {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]ablyTestRequest(nil), s.reqs...)
+}
+
+func TestAblyConfigErrors(t *testing.T) {
+	tests := map[string]func(c *AblyConfig){
+		"no api key": func(c *AblyConfig) {
+			c.APIKey = ""
+		},
+		"no endpoint": func(c *AblyConfig) {
+			c.Endpoint = ""
+		},
+		"no channel": func(c *AblyConfig) {
+			c.Channel = ""
+		},
+		"bad id": func(c *AblyConfig) {
+			c.ID = "${! meta( }"
+		},
+	}
+
+	for name, fn := range tests {
+		fn := fn
+		t.Run(name, func(t *testing.T) {
+			conf := NewAblyConfig()
+			conf.APIKey = "foo.bar:baz"
+			conf.Channel = "foo"
+			fn(&conf)
+
+			_, err := NewAbly(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAblyWrite(t *testing.T) {
+	srv := newAblyTestServer(t)
+
+	conf := NewAblyConfig()
+	conf.APIKey = "foo.bar:baz"
+	conf.Endpoint = srv.URL
+	conf.Channel = `${! meta("channel") }`
+	conf.Event = "update"
+	conf.ID = `${! meta("id") }`
+
+	a, err := NewAbly(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte("first"),
+		[]byte("second"),
+		[]byte("third"),
+		[]byte("fourth"),
+		[]byte("fifth"),
+	})
+	for i, c := range []string{"foo:bar", "bad", "foo:bar", "", "baz"} {
+		msg.Get(i).Metadata().Set("channel", c)
+		msg.Get(i).Metadata().Set("id", string(rune('a'+i)))
+	}
+
+	err = a.Write(msg)
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok)
+	var failed []int
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1, 3}, failed)
+
+	reqs := srv.requests()
+	require.Len(t, reqs, 3)
+	assert.Equal(t, "/channels/foo:bar/messages", reqs[0].path)
+	assert.Equal(t, "Basic Zm9vLmJhcjpiYXo=", reqs[0].auth)
+	assert.Equal(t, []ablyMessage{
+		{ID: "a", Name: "update", Data: "first"},
+		{ID: "c", Name: "update", Data: "third"},
+	}, reqs[0].msgs)
+	assert.Equal(t, "/channels/bad/messages", reqs[1].path)
+	assert.Equal(t, "/channels/baz/messages", reqs[2].path)
+	assert.Equal(t, []ablyMessage{
+		{ID: "e", Name: "update", Data: "fifth"},
+	}, reqs[2].msgs)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/amqp/link"
 	"github.com/Jeffail/benthos/v3/lib/util/amqp/sasl"
 	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 )
@@ -19,21 +20,27 @@ import (
 
 // AMQP1Config contains configuration fields for the AMQP1 output type.
 type AMQP1Config struct {
-	URL           string      `json:"url" yaml:"url"`
-	TargetAddress string      `json:"target_address" yaml:"target_address"`
-	MaxInFlight   int         `json:"max_in_flight" yaml:"max_in_flight"`
-	TLS           btls.Config `json:"tls" yaml:"tls"`
-	SASL          sasl.Config `json:"sasl" yaml:"sasl"`
+	URL                string      `json:"url" yaml:"url"`
+	TargetAddress      string      `json:"target_address" yaml:"target_address"`
+	MaxInFlight        int         `json:"max_in_flight" yaml:"max_in_flight"`
+	ContainerID        string      `json:"container_id" yaml:"container_id"`
+	SenderSettleMode   string      `json:"sender_settle_mode" yaml:"sender_settle_mode"`
+	ReceiverSettleMode string      `json:"receiver_settle_mode" yaml:"receiver_settle_mode"`
+	TLS                btls.Config `json:"tls" yaml:"tls"`
+	SASL               sasl.Config `json:"sasl" yaml:"sasl"`
 }
 
 // NewAMQP1Config creates a new AMQP1Config with default values.
 func NewAMQP1Config() AMQP1Config {
 	return AMQP1Config{
-		URL:           "",
-		TargetAddress: "",
-		MaxInFlight:   1,
-		TLS:           btls.NewConfig(),
-		SASL:          sasl.NewConfig(),
+		URL:                "",
+		TargetAddress:      "",
+		MaxInFlight:        1,
+		ContainerID:        "",
+		SenderSettleMode:   "",
+		ReceiverSettleMode: "",
+		TLS:                btls.NewConfig(),
+		SASL:               sasl.NewConfig(),
 	}
 }
 
@@ -51,8 +58,9 @@ type AMQP1 struct {
 	log   log.Modular
 	stats metrics.Type
 
-	conf    AMQP1Config
-	tlsConf *tls.Config
+	conf     AMQP1Config
+	tlsConf  *tls.Config
+	linkOpts []amqp.LinkOption
 
 	connLock sync.RWMutex
 }
@@ -65,6 +73,13 @@ func NewAMQP1(conf AMQP1Config, log log.Modular, stats metrics.Type) (*AMQP1, er
 		conf:  conf,
 	}
 	var err error
+	if _, err = conf.SASL.ToOptFns(); err != nil {
+		return nil, err
+	}
+	if a.linkOpts, err = link.SettleModes(conf.SenderSettleMode, conf.ReceiverSettleMode); err != nil {
+		return nil, err
+	}
+	a.linkOpts = append(a.linkOpts, amqp.LinkTargetAddress(conf.TargetAddress))
 	if conf.TLS.Enabled {
 		if a.tlsConf, err = conf.TLS.Get(); err != nil {
 			return nil, err
@@ -96,17 +111,13 @@ func (a *AMQP1) ConnectWithContext(ctx context.Context) error {
 		err     error
 	)
 
-	opts, err := a.conf.SASL.ToOptFns()
-	if err != nil {
-		return err
-	}
-	if a.conf.TLS.Enabled {
-		opts = append(opts, amqp.ConnTLS(true))
-		opts = append(opts, amqp.ConnTLSConfig(a.tlsConf))
+	var opts []amqp.ConnOption
+	if len(a.conf.ContainerID) > 0 {
+		opts = append(opts, amqp.ConnContainerID(a.conf.ContainerID))
 	}
 
 	// Create client
-	if client, err = amqp.Dial(a.conf.URL, opts...); err != nil {
+	if client, err = a.conf.SASL.Dial(a.conf.URL, a.tlsConf, opts...); err != nil {
 		return err
 	}
 
@@ -117,9 +128,7 @@ func (a *AMQP1) ConnectWithContext(ctx context.Context) error {
 	}
 
 	// Create a sender
-	if sender, err = session.NewSender(
-		amqp.LinkTargetAddress(a.conf.TargetAddress),
-	); err != nil {
+	if sender, err = session.NewSender(a.linkOpts...); err != nil {
 		session.Close(context.Background())
 		client.Close()
 		return err
@@ -0,0 +1,165 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// AzureEventHubs is an output type that writes messages to an Azure Event Hub.
+type AzureEventHubs struct {
+	conf AzureEventHubsConfig
+
+	partitionKey field.Expression
+
+	client   *azureamqp.Client
+	sender   *amqp.Sender
+	connLock sync.RWMutex
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewAzureEventHubs creates a new Azure Event Hubs writer type.
+func NewAzureEventHubs(conf AzureEventHubsConfig, log log.Modular, stats metrics.Type) (*AzureEventHubs, error) {
+	a := AzureEventHubs{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	var err error
+	if a.partitionKey, err = bloblang.NewField(conf.PartitionKey); err != nil {
+		return nil, fmt.Errorf("failed to parse partition key expression: %v", err)
+	}
+	return &a, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a connection to an Azure Event Hub.
+func (a *AzureEventHubs) Connect() error {
+	return a.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext establishes a connection to an Azure Event Hub.
+func (a *AzureEventHubs) ConnectWithContext(ctx context.Context) error {
+	a.connLock.Lock()
+	defer a.connLock.Unlock()
+
+	if a.client != nil {
+		return nil
+	}
+
+	client, err := azureamqp.Dial(ctx, a.conf.Config, a.conf.EventHub, azureamqp.EventHubsResource, a.log)
+	if err != nil {
+		return err
+	}
+
+	sender, err := client.Session().NewSender(
+		amqp.LinkTargetAddress(client.Entity()),
+	)
+	if err != nil {
+		client.Close(context.Background())
+		return err
+	}
+
+	a.client = client
+	a.sender = sender
+
+	a.log.Infof("Sending Azure Event Hubs messages to event hub: %v\n", client.Entity())
+	return nil
+}
+
+// disconnect safely closes a connection to an Azure Event Hub.
+func (a *AzureEventHubs) disconnect(ctx context.Context) error {
+	a.connLock.Lock()
+	defer a.connLock.Unlock()
+
+	if a.client == nil {
+		return nil
+	}
+	if err := a.sender.Close(ctx); err != nil {
+		a.log.Errorf("Failed to cleanly close sender: %v\n", err)
+	}
+	if err := a.client.Close(ctx); err != nil {
+		a.log.Errorf("Failed to cleanly close client: %v\n", err)
+	}
+	a.client = nil
+	a.sender = nil
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Write will attempt to write a message to an Azure Event Hub, wait for
+// acknowledgement, and returns an error if applicable.
+func (a *AzureEventHubs) Write(msg types.Message) error {
+	return a.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext will attempt to write a message to an Azure Event Hub, wait
+// for acknowledgement, and returns an error if applicable.
+func (a *AzureEventHubs) WriteWithContext(ctx context.Context, msg types.Message) error {
+	a.connLock.RLock()
+	s := a.sender
+	a.connLock.RUnlock()
+
+	if s == nil {
+		return types.ErrNotConnected
+	}
+
+	return IterateBatchedSend(msg, func(i int, p types.Part) error {
+		m := amqp.NewMessage(p.Get())
+		p.Metadata().Iter(func(k, v string) error {
+			if m.ApplicationProperties == nil {
+				m.ApplicationProperties = map[string]interface{}{}
+			}
+			m.ApplicationProperties[k] = v
+			return nil
+		})
+		if key := a.partitionKey.String(i, msg); key != "" {
+			m.Annotations = amqp.Annotations{
+				"x-opt-partition-key": key,
+			}
+		}
+
+		err := s.Send(ctx, m)
+		if err != nil {
+			if err == amqp.ErrTimeout {
+				err = types.ErrTimeout
+			} else if _, isDetachError := err.(*amqp.DetachError); isDetachError ||
+				errors.Is(err, amqp.ErrConnClosed) ||
+				errors.Is(err, amqp.ErrSessionClosed) {
+				a.log.Errorf("Lost connection due to: %v\n", err)
+				a.disconnect(ctx)
+				err = types.ErrNotConnected
+			}
+		}
+		return err
+	})
+}
+
+// CloseAsync shuts down the Azure Event Hubs output and stops processing
+// messages.
+func (a *AzureEventHubs) CloseAsync() {
+	a.disconnect(context.Background())
+}
+
+// WaitForClose blocks until the Azure Event Hubs output has closed down.
+func (a *AzureEventHubs) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,32 @@
+package writer
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+)
+
+//------------------------------------------------------------------------------
+
+// AzureEventHubsConfig contains configuration fields for the AzureEventHubs
+// output type.
+type AzureEventHubsConfig struct {
+	azureamqp.Config `json:",inline" yaml:",inline"`
+	EventHub         string             `json:"event_hub" yaml:"event_hub"`
+	PartitionKey     string             `json:"partition_key" yaml:"partition_key"`
+	MaxInFlight      int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching         batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewAzureEventHubsConfig creates a new AzureEventHubsConfig with default
+// values.
+func NewAzureEventHubsConfig() AzureEventHubsConfig {
+	return AzureEventHubsConfig{
+		Config:       azureamqp.NewConfig(),
+		EventHub:     "",
+		PartitionKey: "",
+		MaxInFlight:  1,
+		Batching:     batch.NewPolicyConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
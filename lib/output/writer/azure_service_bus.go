@@ -0,0 +1,210 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// AzureServiceBus is an output type that writes messages to an Azure Service
+// Bus queue or topic.
+type AzureServiceBus struct {
+	conf   AzureServiceBusConfig
+	entity string
+
+	messageID            field.Expression
+	correlationID        field.Expression
+	sessionID            field.Expression
+	scheduledEnqueueTime field.Expression
+
+	client   *azureamqp.Client
+	sender   *amqp.Sender
+	connLock sync.RWMutex
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewAzureServiceBus creates a new Azure Service Bus writer type.
+func NewAzureServiceBus(conf AzureServiceBusConfig, log log.Modular, stats metrics.Type) (*AzureServiceBus, error) {
+	a := AzureServiceBus{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	if conf.Queue != "" && conf.Topic != "" {
+		return nil, errors.New("cannot specify both a queue and a topic")
+	}
+	a.entity = conf.Queue
+	if conf.Topic != "" {
+		a.entity = conf.Topic
+	}
+
+	var err error
+	if a.messageID, err = bloblang.NewField(conf.MessageID); err != nil {
+		return nil, fmt.Errorf("failed to parse message id expression: %v", err)
+	}
+	if a.correlationID, err = bloblang.NewField(conf.CorrelationID); err != nil {
+		return nil, fmt.Errorf("failed to parse correlation id expression: %v", err)
+	}
+	if a.sessionID, err = bloblang.NewField(conf.SessionID); err != nil {
+		return nil, fmt.Errorf("failed to parse session id expression: %v", err)
+	}
+	if a.scheduledEnqueueTime, err = bloblang.NewField(conf.ScheduledEnqueueTime); err != nil {
+		return nil, fmt.Errorf("failed to parse scheduled enqueue time expression: %v", err)
+	}
+	return &a, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a connection to Azure Service Bus.
+func (a *AzureServiceBus) Connect() error {
+	return a.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext establishes a connection to Azure Service Bus.
+func (a *AzureServiceBus) ConnectWithContext(ctx context.Context) error {
+	a.connLock.Lock()
+	defer a.connLock.Unlock()
+
+	if a.client != nil {
+		return nil
+	}
+
+	client, err := azureamqp.Dial(ctx, a.conf.Config, a.entity, azureamqp.ServiceBusResource, a.log)
+	if err != nil {
+		return err
+	}
+
+	sender, err := client.Session().NewSender(
+		amqp.LinkTargetAddress(client.Entity()),
+	)
+	if err != nil {
+		client.Close(context.Background())
+		return err
+	}
+
+	a.client = client
+	a.sender = sender
+
+	a.log.Infof("Sending Azure Service Bus messages to: %v\n", client.Entity())
+	return nil
+}
+
+// disconnect safely closes a connection to Azure Service Bus.
+func (a *AzureServiceBus) disconnect(ctx context.Context) error {
+	a.connLock.Lock()
+	defer a.connLock.Unlock()
+
+	if a.client == nil {
+		return nil
+	}
+	if err := a.sender.Close(ctx); err != nil {
+		a.log.Errorf("Failed to cleanly close sender: %v\n", err)
+	}
+	if err := a.client.Close(ctx); err != nil {
+		a.log.Errorf("Failed to cleanly close client: %v\n", err)
+	}
+	a.client = nil
+	a.sender = nil
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// toAMQPMessage converts a message part into a Service Bus message, where
+// metadata is sent as application properties.
+func (a *AzureServiceBus) toAMQPMessage(i int, msg types.Message, p types.Part) (*amqp.Message, error) {
+	m := amqp.NewMessage(p.Get())
+	p.Metadata().Iter(func(k, v string) error {
+		if m.ApplicationProperties == nil {
+			m.ApplicationProperties = map[string]interface{}{}
+		}
+		m.ApplicationProperties[k] = v
+		return nil
+	})
+
+	m.Properties = &amqp.MessageProperties{}
+	if id := a.messageID.String(i, msg); id != "" {
+		m.Properties.MessageID = id
+	}
+	if id := a.correlationID.String(i, msg); id != "" {
+		m.Properties.CorrelationID = id
+	}
+	m.Properties.GroupID = a.sessionID.String(i, msg)
+
+	if tStr := a.scheduledEnqueueTime.String(i, msg); tStr != "" {
+		t, err := time.Parse(time.RFC3339, tStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse scheduled enqueue time: %w", err)
+		}
+		m.Annotations = amqp.Annotations{
+			"x-opt-scheduled-enqueue-time": t,
+		}
+	}
+	return m, nil
+}
+
+// Write will attempt to write a message to Azure Service Bus, wait for
+// acknowledgement, and returns an error if applicable.
+func (a *AzureServiceBus) Write(msg types.Message) error {
+	return a.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext will attempt to write a message to Azure Service Bus, wait
+// for acknowledgement, and returns an error if applicable.
+func (a *AzureServiceBus) WriteWithContext(ctx context.Context, msg types.Message) error {
+	a.connLock.RLock()
+	s := a.sender
+	a.connLock.RUnlock()
+
+	if s == nil {
+		return types.ErrNotConnected
+	}
+
+	return IterateBatchedSend(msg, func(i int, p types.Part) error {
+		m, err := a.toAMQPMessage(i, msg, p)
+		if err != nil {
+			return err
+		}
+
+		if err = s.Send(ctx, m); err != nil {
+			if err == amqp.ErrTimeout {
+				err = types.ErrTimeout
+			} else if _, isDetachError := err.(*amqp.DetachError); isDetachError ||
+				errors.Is(err, amqp.ErrConnClosed) ||
+				errors.Is(err, amqp.ErrSessionClosed) {
+				a.log.Errorf("Lost connection due to: %v\n", err)
+				a.disconnect(ctx)
+				err = types.ErrNotConnected
+			}
+		}
+		return err
+	})
+}
+
+// CloseAsync shuts down the Azure Service Bus output and stops processing
+// messages.
+func (a *AzureServiceBus) CloseAsync() {
+	a.disconnect(context.Background())
+}
+
+// WaitForClose blocks until the Azure Service Bus output has closed down.
+func (a *AzureServiceBus) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
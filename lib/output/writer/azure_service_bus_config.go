@@ -0,0 +1,40 @@
+package writer
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/azureamqp"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+)
+
+//------------------------------------------------------------------------------
+
+// AzureServiceBusConfig contains configuration fields for the AzureServiceBus
+// output type.
+type AzureServiceBusConfig struct {
+	azureamqp.Config     `json:",inline" yaml:",inline"`
+	Queue                string             `json:"queue" yaml:"queue"`
+	Topic                string             `json:"topic" yaml:"topic"`
+	MessageID            string             `json:"message_id" yaml:"message_id"`
+	CorrelationID        string             `json:"correlation_id" yaml:"correlation_id"`
+	SessionID            string             `json:"session_id" yaml:"session_id"`
+	ScheduledEnqueueTime string             `json:"scheduled_enqueue_time" yaml:"scheduled_enqueue_time"`
+	MaxInFlight          int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching             batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewAzureServiceBusConfig creates a new AzureServiceBusConfig with default
+// values.
+func NewAzureServiceBusConfig() AzureServiceBusConfig {
+	return AzureServiceBusConfig{
+		Config:               azureamqp.NewConfig(),
+		Queue:                "",
+		Topic:                "",
+		MessageID:            "",
+		CorrelationID:        "",
+		SessionID:            "",
+		ScheduledEnqueueTime: "",
+		MaxInFlight:          1,
+		Batching:             batch.NewPolicyConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,60 @@
+package writer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureServiceBusMessage(t *testing.T) {
+	conf := NewAzureServiceBusConfig()
+	conf.Queue = "foo"
+	conf.MessageID = `${! meta("id") }`
+	conf.SessionID = `${! json("user") }`
+	conf.ScheduledEnqueueTime = `${! meta("at") }`
+
+	a, err := NewAzureServiceBus(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte(`{"user":"bob"}`),
+		[]byte(`{}`),
+	})
+	msg.Get(0).Metadata().Set("id", "1")
+	msg.Get(0).Metadata().Set("at", "2020-09-13T12:26:40Z")
+
+	m, err := a.toAMQPMessage(0, msg, msg.Get(0))
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"user":"bob"}`)}, m.Data)
+	assert.Equal(t, "1", m.Properties.MessageID)
+	assert.Nil(t, m.Properties.CorrelationID)
+	assert.Equal(t, "bob", m.Properties.GroupID)
+	assert.Equal(t, map[string]interface{}{
+		"id": "1",
+		"at": "2020-09-13T12:26:40Z",
+	}, m.ApplicationProperties)
+	assert.True(t, time.Unix(1600000000, 0).Equal(m.Annotations["x-opt-scheduled-enqueue-time"].(time.Time)))
+
+	m, err = a.toAMQPMessage(1, msg, msg.Get(1))
+	require.NoError(t, err)
+	assert.Nil(t, m.Properties.MessageID)
+	assert.Nil(t, m.Annotations)
+
+	msg.Get(1).Metadata().Set("at", "not a timestamp")
+	_, err = a.toAMQPMessage(1, msg, msg.Get(1))
+	assert.Error(t, err)
+}
+
+func TestAzureServiceBusConfigValidation(t *testing.T) {
+	conf := NewAzureServiceBusConfig()
+	conf.Queue = "foo"
+	conf.Topic = "bar"
+
+	_, err := NewAzureServiceBus(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 	"time"
 
@@ -28,6 +29,10 @@ type AzureBlobStorage struct {
 	container  field.Expression
 	path       field.Expression
 	blobType   field.Expression
+	ctype      field.Expression
+	cencoding  field.Expression
+	inclMeta   []*regexp.Regexp
+	exclMeta   []*regexp.Regexp
 	timeout    time.Duration
 	log        log.Modular
 	stats      metrics.Type
@@ -74,6 +79,18 @@ func NewAzureBlobStorage(
 	if a.blobType, err = bloblang.NewField(conf.BlobType); err != nil {
 		return nil, fmt.Errorf("failed to parse blob type expression: %v", err)
 	}
+	if a.ctype, err = bloblang.NewField(conf.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to parse content type expression: %v", err)
+	}
+	if a.cencoding, err = bloblang.NewField(conf.ContentEncoding); err != nil {
+		return nil, fmt.Errorf("failed to parse content encoding expression: %v", err)
+	}
+	if a.inclMeta, err = compileMetadataPatterns(conf.Metadata.IncludePatterns); err != nil {
+		return nil, fmt.Errorf("failed to compile metadata include pattern: %v", err)
+	}
+	if a.exclMeta, err = compileMetadataPatterns(conf.Metadata.ExcludePatterns); err != nil {
+		return nil, fmt.Errorf("failed to compile metadata exclude pattern: %v", err)
+	}
 	return a, nil
 }
 
@@ -99,16 +116,26 @@ func (a *AzureBlobStorage) getContainer(name string) (*azblob.ContainerURL, erro
 	return &containerURL, nil
 }
 
-func (a *AzureBlobStorage) uploadToBlob(ctx context.Context, message []byte, blobName string, blobType string, containerURL *azblob.ContainerURL) error {
+func (a *AzureBlobStorage) uploadToBlob(ctx context.Context, message []byte, blobName string, blobType string, headers azblob.BlobHTTPHeaders, metadata azblob.Metadata, containerURL *azblob.ContainerURL) error {
 	var err error
 
 	switch blobType {
 	case "BLOCK":
 		blobURL := containerURL.NewBlockBlobURL(blobName)
-		_, err = azblob.UploadStreamToBlockBlob(ctx, bytes.NewReader(message), blobURL, azblob.UploadStreamToBlockBlobOptions{})
+		_, err = azblob.UploadStreamToBlockBlob(ctx, bytes.NewReader(message), blobURL, azblob.UploadStreamToBlockBlobOptions{
+			BlobHTTPHeaders: headers,
+			Metadata:        metadata,
+		})
 	case "APPEND":
 		blobURL := containerURL.NewAppendBlobURL(blobName)
 		_, err = blobURL.AppendBlock(ctx, bytes.NewReader(message), azblob.AppendBlobAccessConditions{}, nil)
+		if blobNotFound(err) {
+			// The headers and metadata of an append blob are set when it is
+			// created by the first message appended to it.
+			if _, err = blobURL.Create(ctx, headers, metadata, azblob.BlobAccessConditions{}); err == nil {
+				_, err = blobURL.AppendBlock(ctx, bytes.NewReader(message), azblob.AppendBlobAccessConditions{}, nil)
+			}
+		}
 	}
 
 	return err
@@ -124,14 +151,19 @@ func (a *AzureBlobStorage) WriteWithContext(wctx context.Context, msg types.Mess
 		if err != nil {
 			return err
 		}
-		if err := a.uploadToBlob(ctx, p.Get(), a.path.String(i, msg), a.blobType.String(i, msg), c); err != nil {
+		headers := azblob.BlobHTTPHeaders{
+			ContentType:     a.ctype.String(i, msg),
+			ContentEncoding: a.cencoding.String(i, msg),
+		}
+		metadata := blobMetadata(p, a.inclMeta, a.exclMeta, a.log)
+		if err := a.uploadToBlob(ctx, p.Get(), a.path.String(i, msg), a.blobType.String(i, msg), headers, metadata, c); err != nil {
 			if containerNotFound(err) {
 				if _, cerr := c.Create(ctx, azblob.Metadata{}, azblob.PublicAccessNone); cerr != nil {
 					a.log.Errorf("error creating container: %v.", cerr)
 				} else {
 					a.log.Infof("created container: %s.", c.String())
 					// Retry upload to blob
-					err = a.uploadToBlob(ctx, p.Get(), a.path.String(i, msg), a.blobType.String(i, msg), c)
+					err = a.uploadToBlob(ctx, p.Get(), a.path.String(i, msg), a.blobType.String(i, msg), headers, metadata, c)
 				}
 			}
 			return err
@@ -140,6 +172,56 @@ func (a *AzureBlobStorage) WriteWithContext(wctx context.Context, msg types.Mess
 	})
 }
 
+// blobMetadata converts the metadata of a message part into blob metadata.
+// Only keys matching at least one include pattern and no exclude patterns are
+// added, and keys are sanitised as Azure only accepts C# identifiers.
+func blobMetadata(part types.Part, include, exclude []*regexp.Regexp, log log.Modular) azblob.Metadata {
+	metadata := azblob.Metadata{}
+	if len(include) == 0 {
+		return metadata
+	}
+	part.Metadata().Iter(func(k, v string) error {
+		if !matchesAnyPattern(include, k) || matchesAnyPattern(exclude, k) {
+			return nil
+		}
+		key := sanitiseBlobMetadataKey(k)
+		if key == "" {
+			log.Debugf("Skipping metadata key %q as it is not a valid blob metadata name\n", k)
+			return nil
+		}
+		metadata[key] = v
+		return nil
+	})
+	return metadata
+}
+
+// sanitiseBlobMetadataKey replaces characters that are invalid within a C#
+// identifier with underscores, and prefixes keys beginning with a digit with an
+// underscore. Keys without any valid characters result in an empty string.
+func sanitiseBlobMetadataKey(k string) string {
+	var b strings.Builder
+	valid := false
+	for i, r := range k {
+		switch {
+		case r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			valid = true
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			valid = true
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if !valid {
+		return ""
+	}
+	return b.String()
+}
+
 func containerNotFound(err error) bool {
 	if serr, ok := err.(azblob.StorageError); ok {
 		return serr.ServiceCode() == azblob.ServiceCodeContainerNotFound
@@ -148,6 +230,13 @@ func containerNotFound(err error) bool {
 	return strings.Contains(err.Error(), "ContainerNotFound")
 }
 
+func blobNotFound(err error) bool {
+	if serr, ok := err.(azblob.StorageError); ok {
+		return serr.ServiceCode() == azblob.ServiceCodeBlobNotFound
+	}
+	return err != nil && strings.Contains(err.Error(), "BlobNotFound")
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (a *AzureBlobStorage) CloseAsync() {
 }
@@ -1,28 +1,52 @@
 package writer
 
+import (
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+)
+
 //------------------------------------------------------------------------------
 
 // AzureBlobStorageConfig contains configuration fields for the AzureBlobStorage output type.
 type AzureBlobStorageConfig struct {
-	StorageAccount   string `json:"storage_account" yaml:"storage_account"`
-	StorageAccessKey string `json:"storage_access_key" yaml:"storage_access_key"`
-	Container        string `json:"container" yaml:"container"`
-	Path             string `json:"path" yaml:"path"`
-	BlobType         string `json:"blob_type" yaml:"blob_type"`
-	Timeout          string `json:"timeout" yaml:"timeout"`
-	MaxInFlight      int    `json:"max_in_flight" yaml:"max_in_flight"`
+	StorageAccount   string                         `json:"storage_account" yaml:"storage_account"`
+	StorageAccessKey string                         `json:"storage_access_key" yaml:"storage_access_key"`
+	Container        string                         `json:"container" yaml:"container"`
+	Path             string                         `json:"path" yaml:"path"`
+	BlobType         string                         `json:"blob_type" yaml:"blob_type"`
+	ContentType      string                         `json:"content_type" yaml:"content_type"`
+	ContentEncoding  string                         `json:"content_encoding" yaml:"content_encoding"`
+	Metadata         AzureBlobStorageMetadataConfig `json:"metadata" yaml:"metadata"`
+	Timeout          string                         `json:"timeout" yaml:"timeout"`
+	MaxInFlight      int                            `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching         batch.PolicyConfig             `json:"batching" yaml:"batching"`
+}
+
+// AzureBlobStorageMetadataConfig contains configuration fields for controlling
+// which metadata fields of a message are set as the metadata of its blob.
+type AzureBlobStorageMetadataConfig struct {
+	IncludePatterns []string `json:"include_patterns" yaml:"include_patterns"`
+	ExcludePatterns []string `json:"exclude_patterns" yaml:"exclude_patterns"`
 }
 
 // NewAzureBlobStorageConfig creates a new Config with default values.
 func NewAzureBlobStorageConfig() AzureBlobStorageConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
 	return AzureBlobStorageConfig{
 		StorageAccount:   "",
 		StorageAccessKey: "",
 		Container:        "",
 		Path:             `${!count("files")}-${!timestamp_unix_nano()}.txt`,
 		BlobType:         "BLOCK",
-		Timeout:          "5s",
-		MaxInFlight:      1,
+		ContentType:      "application/octet-stream",
+		ContentEncoding:  "",
+		Metadata: AzureBlobStorageMetadataConfig{
+			IncludePatterns: []string{},
+			ExcludePatterns: []string{},
+		},
+		Timeout:     "5s",
+		MaxInFlight: 1,
+		Batching:    batching,
 	}
 }
 
@@ -0,0 +1,82 @@
+// +build !wasm
+
+package writer
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureBlobStorageMetadata(t *testing.T) {
+	part := message.NewPart(nil)
+	part.Metadata().
+		Set("Content-Type", "application/json").
+		Set("X-Forwarded-For", "127.0.0.1").
+		Set("kafka_key", "foo").
+		Set("1st", "bar").
+		Set("---", "baz").
+		Set("http_server_user_agent", "qux")
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected azblob.Metadata
+	}{
+		{
+			name:     "no include patterns",
+			expected: azblob.Metadata{},
+		},
+		{
+			name:    "all keys",
+			include: []string{".*"},
+			expected: azblob.Metadata{
+				"Content_Type":           "application/json",
+				"X_Forwarded_For":        "127.0.0.1",
+				"kafka_key":              "foo",
+				"_1st":                   "bar",
+				"http_server_user_agent": "qux",
+			},
+		},
+		{
+			name:    "include and exclude",
+			include: []string{"^kafka_", "^http_server_", "^Content"},
+			exclude: []string{"_user_agent$"},
+			expected: azblob.Metadata{
+				"Content_Type": "application/json",
+				"kafka_key":    "foo",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewAzureBlobStorageConfig()
+			conf.StorageAccount = "foo"
+			conf.Metadata.IncludePatterns = test.include
+			conf.Metadata.ExcludePatterns = test.exclude
+
+			a, err := NewAzureBlobStorage(conf, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, blobMetadata(part, a.inclMeta, a.exclMeta, log.Noop()))
+		})
+	}
+}
+
+func TestAzureBlobStorageBadMetadataPattern(t *testing.T) {
+	conf := NewAzureBlobStorageConfig()
+	conf.StorageAccount = "foo"
+	conf.Metadata.IncludePatterns = []string{"("}
+
+	_, err := NewAzureBlobStorage(conf, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compile metadata include pattern")
+}
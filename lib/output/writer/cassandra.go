@@ -0,0 +1,367 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+)
+
+//------------------------------------------------------------------------------
+
+// CassandraPasswordAuthenticator contains the fields of plain text password
+// authentication for the Cassandra output type.
+type CassandraPasswordAuthenticator struct {
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+}
+
+// CassandraBackoffConfig contains the backoff between retries of queries for
+// the Cassandra output type.
+type CassandraBackoffConfig struct {
+	InitialInterval string `json:"initial_interval" yaml:"initial_interval"`
+	MaxInterval     string `json:"max_interval" yaml:"max_interval"`
+}
+
+// CassandraConfig contains configuration fields for the Cassandra output type.
+type CassandraConfig struct {
+	Addresses                []string                       `json:"addresses" yaml:"addresses"`
+	TLS                      btls.Config                    `json:"tls" yaml:"tls"`
+	PasswordAuthenticator    CassandraPasswordAuthenticator `json:"password_authenticator" yaml:"password_authenticator"`
+	DisableInitialHostLookup bool                           `json:"disable_initial_host_lookup" yaml:"disable_initial_host_lookup"`
+	Query                    string                         `json:"query" yaml:"query"`
+	ArgsMapping              string                         `json:"args_mapping" yaml:"args_mapping"`
+	Consistency              string                         `json:"consistency" yaml:"consistency"`
+	LoggedBatch              bool                           `json:"logged_batch" yaml:"logged_batch"`
+	MaxRetries               int                            `json:"max_retries" yaml:"max_retries"`
+	Backoff                  CassandraBackoffConfig         `json:"backoff" yaml:"backoff"`
+	Timeout                  string                         `json:"timeout" yaml:"timeout"`
+	MaxInFlight              int                            `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching                 batch.PolicyConfig             `json:"batching" yaml:"batching"`
+}
+
+// NewCassandraConfig creates a new CassandraConfig with default values.
+func NewCassandraConfig() CassandraConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return CassandraConfig{
+		Addresses: []string{},
+		TLS:       btls.NewConfig(),
+		PasswordAuthenticator: CassandraPasswordAuthenticator{
+			Enabled:  false,
+			Username: "",
+			Password: "",
+		},
+		DisableInitialHostLookup: false,
+		Query:                    "",
+		ArgsMapping:              "",
+		Consistency:              gocql.Quorum.String(),
+		LoggedBatch:              true,
+		MaxRetries:               3,
+		Backoff: CassandraBackoffConfig{
+			InitialInterval: "1s",
+			MaxInterval:     "5s",
+		},
+		Timeout:     "600ms",
+		MaxInFlight: 1,
+		Batching:    batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Cassandra is a writer type that executes a query for each message of a
+// batch against a Cassandra or ScyllaDB cluster.
+type Cassandra struct {
+	conf        CassandraConfig
+	cluster     *gocql.ClusterConfig
+	argsMapping *mapping.Executor
+	batchType   gocql.BatchType
+
+	sessionMut sync.RWMutex
+	session    *gocql.Session
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewCassandra creates a new Cassandra writer type.
+func NewCassandra(conf CassandraConfig, log log.Modular, stats metrics.Type) (*Cassandra, error) {
+	var addresses []string
+	for _, addr := range conf.Addresses {
+		for _, splitAddr := range strings.Split(addr, ",") {
+			if trimmed := strings.TrimSpace(splitAddr); len(trimmed) > 0 {
+				addresses = append(addresses, trimmed)
+			}
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, errors.New("at least one address must be specified")
+	}
+	if conf.Query == "" {
+		return nil, errors.New("a query must be specified")
+	}
+
+	c := &Cassandra{
+		conf:      conf,
+		batchType: gocql.UnloggedBatch,
+		log:       log,
+		stats:     stats,
+	}
+	if conf.LoggedBatch {
+		c.batchType = gocql.LoggedBatch
+	}
+
+	cluster := gocql.NewCluster(addresses...)
+	if conf.TLS.Enabled {
+		tlsConf, err := conf.TLS.Get()
+		if err != nil {
+			return nil, err
+		}
+		cluster.SslOpts = &gocql.SslOptions{Config: tlsConf}
+	}
+	if conf.PasswordAuthenticator.Enabled {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: conf.PasswordAuthenticator.Username,
+			Password: conf.PasswordAuthenticator.Password,
+		}
+	}
+	cluster.DisableInitialHostLookup = conf.DisableInitialHostLookup
+	cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.RoundRobinHostPolicy())
+
+	var err error
+	if cluster.Consistency, err = gocql.ParseConsistencyWrapper(conf.Consistency); err != nil {
+		return nil, fmt.Errorf("failed to parse consistency: %v", err)
+	}
+
+	retryPolicy := &gocql.ExponentialBackoffRetryPolicy{NumRetries: conf.MaxRetries}
+	if conf.Backoff.InitialInterval != "" {
+		if retryPolicy.Min, err = time.ParseDuration(conf.Backoff.InitialInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse backoff initial interval: %v", err)
+		}
+	}
+	if conf.Backoff.MaxInterval != "" {
+		if retryPolicy.Max, err = time.ParseDuration(conf.Backoff.MaxInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse backoff max interval: %v", err)
+		}
+	}
+	cluster.RetryPolicy = retryPolicy
+
+	if conf.Timeout != "" {
+		if cluster.Timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %v", err)
+		}
+		cluster.ConnectTimeout = cluster.Timeout
+	}
+	c.cluster = cluster
+
+	if conf.ArgsMapping != "" {
+		if c.argsMapping, err = newMapping("args mapping", conf.ArgsMapping); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a session with the cluster.
+func (c *Cassandra) Connect() error {
+	return c.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext attempts to establish a session with the cluster.
+func (c *Cassandra) ConnectWithContext(ctx context.Context) error {
+	c.sessionMut.Lock()
+	defer c.sessionMut.Unlock()
+
+	if c.session != nil {
+		return nil
+	}
+
+	session, err := c.cluster.CreateSession()
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	c.session = session
+
+	c.log.Infof("Sending messages to Cassandra: %v\n", c.cluster.Hosts)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Write attempts to write message contents to the cluster.
+func (c *Cassandra) Write(msg types.Message) error {
+	return c.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext executes the query for each message of a batch, where
+// batches of more than one message are executed as a single batch statement.
+func (c *Cassandra) WriteWithContext(ctx context.Context, msg types.Message) error {
+	c.sessionMut.RLock()
+	defer c.sessionMut.RUnlock()
+
+	if c.session == nil {
+		return types.ErrNotConnected
+	}
+
+	argSets, err := c.args(msg)
+	if err != nil {
+		return err
+	}
+
+	if len(argSets) == 1 {
+		return c.session.Query(c.conf.Query, argSets[0]...).WithContext(ctx).Exec()
+	}
+
+	b := c.session.NewBatch(c.batchType).WithContext(ctx)
+	for _, args := range argSets {
+		b.Query(c.conf.Query, args...)
+	}
+	return c.session.ExecuteBatch(b)
+}
+
+// args returns the arguments of the query for each message of a batch.
+func (c *Cassandra) args(msg types.Message) ([][]interface{}, error) {
+	argSets := make([][]interface{}, msg.Len())
+	if c.argsMapping == nil {
+		return argSets, nil
+	}
+	for i := range argSets {
+		values, err := execArgsMapping(c.argsMapping, i, msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute args mapping for message %v: %w", i, err)
+		}
+		args := make([]interface{}, len(values))
+		for j, v := range values {
+			args[j] = cassandraArgValue(v)
+		}
+		argSets[i] = args
+	}
+	return argSets, nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (c *Cassandra) CloseAsync() {
+	c.sessionMut.Lock()
+	if c.session != nil {
+		c.session.Close()
+		c.session = nil
+	}
+	c.sessionMut.Unlock()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (c *Cassandra) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// cassandraNumber is a number resulting from an args mapping, which is
+// marshalled according to the type of the column it is bound to.
+type cassandraNumber json.Number
+
+func (n cassandraNumber) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	switch info.Type() {
+	case gocql.TypeFloat:
+		f, err := json.Number(n).Float64()
+		if err != nil {
+			return nil, err
+		}
+		return gocql.Marshal(info, float32(f))
+	case gocql.TypeDouble:
+		f, err := json.Number(n).Float64()
+		if err != nil {
+			return nil, err
+		}
+		return gocql.Marshal(info, f)
+	case gocql.TypeDecimal:
+		d, ok := new(inf.Dec).SetString(string(n))
+		if !ok {
+			return nil, fmt.Errorf("can not marshal %v into %v", string(n), info)
+		}
+		return gocql.Marshal(info, *d)
+	case gocql.TypeVarint:
+		i, ok := new(big.Int).SetString(string(n), 10)
+		if !ok {
+			return nil, fmt.Errorf("can not marshal %v into %v", string(n), info)
+		}
+		return gocql.Marshal(info, *i)
+	case gocql.TypeVarchar, gocql.TypeText, gocql.TypeAscii:
+		return gocql.Marshal(info, string(n))
+	}
+	if i, err := json.Number(n).Int64(); err == nil {
+		return gocql.Marshal(info, i)
+	}
+	f, err := json.Number(n).Float64()
+	if err != nil {
+		return nil, err
+	}
+	return gocql.Marshal(info, f)
+}
+
+// cassandraString is a string resulting from an args mapping, which is parsed
+// as an RFC 3339 timestamp when bound to a timestamp column.
+type cassandraString string
+
+func (s cassandraString) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if info.Type() == gocql.TypeTimestamp {
+		t, err := time.Parse(time.RFC3339Nano, string(s))
+		if err != nil {
+			return nil, err
+		}
+		return gocql.Marshal(info, t)
+	}
+	return gocql.Marshal(info, string(s))
+}
+
+// cassandraArgValue converts a value resulting from an args mapping into a
+// type that gocql marshals according to the column it is bound to, including
+// the elements of collections.
+func cassandraArgValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		return cassandraNumber(t)
+	case float64:
+		return cassandraNumber(strconv.FormatFloat(t, 'f', -1, 64))
+	case int64:
+		return cassandraNumber(strconv.FormatInt(t, 10))
+	case uint64:
+		return cassandraNumber(strconv.FormatUint(t, 10))
+	case string:
+		return cassandraString(t)
+	case []interface{}:
+		values := make([]interface{}, len(t))
+		for i, e := range t {
+			values[i] = cassandraArgValue(e)
+		}
+		return values
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			values[k] = cassandraArgValue(e)
+		}
+		return values
+	}
+	return v
+}
+
+//------------------------------------------------------------------------------
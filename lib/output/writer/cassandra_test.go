@@ -0,0 +1,207 @@
+package writer
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/inf.v0"
+)
+
+func TestCassandraConfigErrors(t *testing.T) {
+	tests := map[string]func(c *CassandraConfig){
+		"no addresses": func(c *CassandraConfig) {
+			c.Addresses = []string{" , "}
+		},
+		"no query": func(c *CassandraConfig) {
+			c.Query = ""
+		},
+		"bad args mapping": func(c *CassandraConfig) {
+			c.ArgsMapping = "root = ["
+		},
+		"bad consistency": func(c *CassandraConfig) {
+			c.Consistency = "SOME"
+		},
+		"bad backoff": func(c *CassandraConfig) {
+			c.Backoff.MaxInterval = "nope"
+		},
+		"bad timeout": func(c *CassandraConfig) {
+			c.Timeout = "nope"
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewCassandraConfig()
+			conf.Addresses = []string{"localhost:9042"}
+			conf.Query = "INSERT INTO shop.users (id, name) VALUES (?, ?)"
+			conf.ArgsMapping = "root = [ this.id, this.name ]"
+			test(&conf)
+
+			_, err := NewCassandra(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCassandraClusterConfig(t *testing.T) {
+	conf := NewCassandraConfig()
+	conf.Addresses = []string{"foo:9042,bar:9042", "baz:9042"}
+	conf.Query = "INSERT INTO shop.users (id, name) VALUES (?, ?)"
+	conf.Consistency = "local_quorum"
+	conf.LoggedBatch = false
+
+	c, err := NewCassandra(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"foo:9042", "bar:9042", "baz:9042"}, c.cluster.Hosts)
+	assert.Equal(t, gocql.LocalQuorum, c.cluster.Consistency)
+	assert.Equal(t, gocql.UnloggedBatch, c.batchType)
+	assert.Equal(t, 600*time.Millisecond, c.cluster.Timeout)
+	assert.Equal(t, &gocql.ExponentialBackoffRetryPolicy{
+		NumRetries: 3,
+		Min:        time.Second,
+		Max:        5 * time.Second,
+	}, c.cluster.RetryPolicy)
+
+	assert.Equal(t, types.ErrNotConnected, c.Write(message.New([][]byte{[]byte(`{}`)})))
+}
+
+func TestCassandraArgs(t *testing.T) {
+	conf := NewCassandraConfig()
+	conf.Addresses = []string{"localhost:9042"}
+	conf.Query = "INSERT INTO shop.users (id, name, tags, joined) VALUES (?, ?, ?, ?)"
+	conf.ArgsMapping = `root = [ this.id, this.name, this.tags, this.joined ]`
+
+	c, err := NewCassandra(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	argSets, err := c.args(message.New([][]byte{
+		[]byte(`{"id":1,"name":"foo","tags":{"a":1},"joined":"2020-09-13T12:26:40Z"}`),
+		[]byte(`{"id":2.5,"name":"bar","tags":["b"]}`),
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, [][]interface{}{
+		{cassandraNumber("1"), cassandraString("foo"), map[string]interface{}{"a": cassandraNumber("1")}, cassandraString("2020-09-13T12:26:40Z")},
+		{cassandraNumber("2.5"), cassandraString("bar"), []interface{}{cassandraString("b")}, nil},
+	}, argSets)
+
+	_, err = c.args(message.New([][]byte{[]byte(`not json`)}))
+	assert.Error(t, err)
+}
+
+func TestCassandraArgValues(t *testing.T) {
+	native := func(typ gocql.Type) gocql.NativeType {
+		return gocql.NewNativeType(4, typ, "")
+	}
+
+	tests := map[string]struct {
+		info     gocql.TypeInfo
+		value    interface{}
+		dest     interface{}
+		expected interface{}
+	}{
+		"int": {
+			info:     native(gocql.TypeInt),
+			value:    json.Number("5"),
+			dest:     new(int32),
+			expected: int32(5),
+		},
+		"computed int": {
+			info:     native(gocql.TypeBigInt),
+			value:    float64(6),
+			dest:     new(int64),
+			expected: int64(6),
+		},
+		"double": {
+			info:     native(gocql.TypeDouble),
+			value:    json.Number("5"),
+			dest:     new(float64),
+			expected: float64(5),
+		},
+		"float": {
+			info:     native(gocql.TypeFloat),
+			value:    json.Number("1.5"),
+			dest:     new(float32),
+			expected: float32(1.5),
+		},
+		"decimal": {
+			info:     native(gocql.TypeDecimal),
+			value:    json.Number("12.345"),
+			dest:     new(inf.Dec),
+			expected: "12.345",
+		},
+		"varint": {
+			info:     native(gocql.TypeVarint),
+			value:    json.Number("123456789012345678901234567890"),
+			dest:     new(big.Int),
+			expected: "123456789012345678901234567890",
+		},
+		"text number": {
+			info:     native(gocql.TypeText),
+			value:    json.Number("5"),
+			dest:     new(string),
+			expected: "5",
+		},
+		"timestamp": {
+			info:     native(gocql.TypeTimestamp),
+			value:    "2020-09-13T12:26:40.5Z",
+			dest:     new(time.Time),
+			expected: time.Date(2020, 9, 13, 12, 26, 40, 5e8, time.UTC),
+		},
+		"map": {
+			info: gocql.CollectionType{
+				NativeType: native(gocql.TypeMap),
+				Key:        native(gocql.TypeText),
+				Elem:       native(gocql.TypeDouble),
+			},
+			value:    map[string]interface{}{"a": json.Number("1")},
+			dest:     new(map[string]float64),
+			expected: map[string]float64{"a": 1},
+		},
+		"list": {
+			info: gocql.CollectionType{
+				NativeType: native(gocql.TypeList),
+				Elem:       native(gocql.TypeInt),
+			},
+			value:    []interface{}{json.Number("1"), json.Number("2")},
+			dest:     new([]int),
+			expected: []int{1, 2},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			b, err := gocql.Marshal(test.info, cassandraArgValue(test.value))
+			require.NoError(t, err)
+			require.NoError(t, gocql.Unmarshal(test.info, b, test.dest))
+
+			switch d := test.dest.(type) {
+			case *time.Time:
+				assert.Equal(t, test.expected, d.UTC())
+			case *inf.Dec:
+				assert.Equal(t, test.expected, d.String())
+			case *big.Int:
+				assert.Equal(t, test.expected, d.String())
+			default:
+				assert.Equal(t, test.expected, reflect.ValueOf(d).Elem().Interface())
+			}
+		})
+	}
+
+	_, err := gocql.Marshal(native(gocql.TypeTimestamp), cassandraArgValue("nope"))
+	assert.Error(t, err)
+	_, err = gocql.Marshal(native(gocql.TypeDecimal), cassandraArgValue(json.Number("1e5x")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,172 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// chatWebhook posts JSON payloads to the incoming webhook of a chat service
+// and complies with the rate limits of the service, where requests that are
+// rate limited are retried once the period requested by the service has
+// elapsed, and requests made while a limit is in effect are delayed until it
+// expires.
+type chatWebhook struct {
+	name       string
+	httpClient *http.Client
+
+	limitMut   sync.Mutex
+	limitUntil time.Time
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	log log.Modular
+}
+
+func newChatWebhook(name, timeout string, log log.Modular) (*chatWebhook, error) {
+	tout, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+	}
+	return &chatWebhook{
+		name:       name,
+		httpClient: &http.Client{Timeout: tout},
+		closeChan:  make(chan struct{}),
+		log:        log,
+	}, nil
+}
+
+// chatPayload executes an optional payload mapping against a message of a
+// batch, returning a copy of the resulting object that fields can be added to.
+func chatPayload(exec *mapping.Executor, index int, msg types.Message) (map[string]interface{}, error) {
+	payload := map[string]interface{}{}
+	if exec == nil {
+		return payload, nil
+	}
+	res, err := execMapping(exec, index, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute payload mapping: %w", err)
+	}
+	switch t := res.(type) {
+	case query.Delete, query.Nothing, nil:
+	case map[string]interface{}:
+		for k, v := range t {
+			payload[k] = v
+		}
+	default:
+		return nil, fmt.Errorf("payload mapping returned non-object result: %v", query.ITypeOf(res))
+	}
+	return payload, nil
+}
+
+// retryAfter parses the period to wait before retrying a rate limited request,
+// which is either given by the Retry-After header or by the retry_after field
+// of a JSON body, and is a number of seconds that might be fractional.
+func retryAfter(res *http.Response, body []byte) time.Duration {
+	secs, err := strconv.ParseFloat(res.Header.Get("Retry-After"), 64)
+	if err != nil {
+		var bodyObj struct {
+			RetryAfter float64 `json:"retry_after"`
+		}
+		if json.Unmarshal(body, &bodyObj) == nil {
+			secs = bodyObj.RetryAfter
+		}
+	}
+	if secs <= 0 {
+		secs = 1
+	}
+	return time.Duration(secs * float64(time.Second))
+}
+
+func (c *chatWebhook) setLimit(d time.Duration) {
+	c.limitMut.Lock()
+	if until := time.Now().Add(d); until.After(c.limitUntil) {
+		c.limitUntil = until
+	}
+	c.limitMut.Unlock()
+}
+
+func (c *chatWebhook) waitForLimit(ctx context.Context) error {
+	c.limitMut.Lock()
+	wait := time.Until(c.limitUntil)
+	c.limitMut.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+		return types.ErrTimeout
+	case <-c.closeChan:
+		return types.ErrTypeClosed
+	}
+	return nil
+}
+
+// post sends a payload to a webhook URL, retrying it for as long as it's rate
+// limited.
+func (c *chatWebhook) post(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	for {
+		if err := c.waitForLimit(ctx); err != nil {
+			return err
+		}
+
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resBody, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.StatusCode == http.StatusTooManyRequests {
+			wait := retryAfter(res, resBody)
+			c.log.Warnf("Requests to %v are rate limited, waiting %v\n", c.name, wait)
+			c.setLimit(wait)
+			continue
+		}
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return fmt.Errorf("failed to post message to %v: %v: %s", c.name, res.Status, bytes.TrimSpace(resBody))
+		}
+
+		// Discord announces when the next request will be rate limited.
+		if res.Header.Get("X-RateLimit-Remaining") == "0" {
+			if secs, err := strconv.ParseFloat(res.Header.Get("X-RateLimit-Reset-After"), 64); err == nil {
+				c.setLimit(time.Duration(secs * float64(time.Second)))
+			}
+		}
+		return nil
+	}
+}
+
+func (c *chatWebhook) closeAsync() {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+	})
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,365 @@
+package writer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type chatTestRequest struct {
+	query   string
+	payload map[string]interface{}
+}
+
+type chatTestServer struct {
+	*httptest.Server
+
+	mut     sync.Mutex
+	reqs    []chatTestRequest
+	respond func(w http.ResponseWriter, n int)
+}
+
+func newChatTestServer(t *testing.T, respond func(w http.ResponseWriter, n int)) *chatTestServer {
+	t.Helper()
+
+	s := &chatTestServer{respond: respond}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		req := chatTestRequest{query: r.URL.RawQuery}
+		if err := json.Unmarshal(b, &req.payload); err != nil {
+			t.Error(err)
+		}
+		s.mut.Lock()
+		s.reqs = append(s.reqs, req)
+		n := len(s.reqs)
+		s.mut.Unlock()
+
+		if s.respond != nil {
+			s.respond(w, n)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *chatTestServer) requests() []chatTestRequest {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]chatTestRequest(nil), s.reqs...)
+}
+
+func TestChatWebhookRetryAfter(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		body   string
+		exp    time.Duration
+	}{
+		"header": {
+			header: "2",
+			exp:    2 * time.Second,
+		},
+		"body": {
+			body: `{"message":"You are being rate limited.","retry_after":0.5}`,
+			exp:  500 * time.Millisecond,
+		},
+		"header precedence": {
+			header: "3",
+			body:   `{"retry_after":0.5}`,
+			exp:    3 * time.Second,
+		},
+		"neither": {
+			body: "slow down",
+			exp:  time.Second,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			res := &http.Response{Header: http.Header{}}
+			if test.header != "" {
+				res.Header.Set("Retry-After", test.header)
+			}
+			assert.Equal(t, test.exp, retryAfter(res, []byte(test.body)))
+		})
+	}
+}
+
+func TestChatWebhookRateLimited(t *testing.T) {
+	srv := newChatTestServer(t, func(w http.ResponseWriter, n int) {
+		if n == 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"retry_after":0.01}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	conf := NewSlackConfig()
+	conf.URL = srv.URL
+
+	s, err := NewSlack(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, s.Write(message.New([][]byte{[]byte("hello world")})))
+
+	reqs := srv.requests()
+	require.Len(t, reqs, 2)
+	assert.Equal(t, reqs[0], reqs[1])
+}
+
+func TestChatWebhookRateLimitHeaders(t *testing.T) {
+	var mut sync.Mutex
+	var times []time.Time
+	srv := newChatTestServer(t, func(w http.ResponseWriter, n int) {
+		mut.Lock()
+		times = append(times, time.Now())
+		mut.Unlock()
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset-After", "0.1")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	conf := NewDiscordConfig()
+	conf.URL = srv.URL
+
+	d, err := NewDiscord(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, d.Write(message.New([][]byte{
+		[]byte("first"),
+		[]byte("second"),
+	})))
+
+	mut.Lock()
+	defer mut.Unlock()
+	require.Len(t, times, 2)
+	assert.True(t, times[1].Sub(times[0]) >= 100*time.Millisecond)
+}
+
+func TestChatWebhookErrors(t *testing.T) {
+	srv := newChatTestServer(t, func(w http.ResponseWriter, n int) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid_payload"))
+	})
+
+	conf := NewMSTeamsConfig()
+	conf.URL = srv.URL
+
+	m, err := NewMSTeams(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	err = m.Write(message.New([][]byte{[]byte("hello world")}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid_payload")
+}
+
+func TestChatWebhookClose(t *testing.T) {
+	srv := newChatTestServer(t, func(w http.ResponseWriter, n int) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	conf := NewSlackConfig()
+	conf.URL = srv.URL
+
+	s, err := NewSlack(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	go func() {
+		<-time.After(time.Millisecond * 100)
+		s.CloseAsync()
+	}()
+
+	err = s.Write(message.New([][]byte{[]byte("hello world")}))
+	require.Error(t, err)
+}
+
+func TestSlackConfigErrors(t *testing.T) {
+	tests := map[string]func(c *SlackConfig){
+		"no url": func(c *SlackConfig) {
+			c.URL = ""
+		},
+		"no text or payload": func(c *SlackConfig) {
+			c.Text = ""
+		},
+		"bad payload mapping": func(c *SlackConfig) {
+			c.PayloadMapping = "root = "
+		},
+		"bad timeout": func(c *SlackConfig) {
+			c.Timeout = "nope"
+		},
+	}
+
+	for name, fn := range tests {
+		fn := fn
+		t.Run(name, func(t *testing.T) {
+			conf := NewSlackConfig()
+			conf.URL = "http://localhost:8080"
+			fn(&conf)
+
+			_, err := NewSlack(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSlackPayload(t *testing.T) {
+	srv := newChatTestServer(t, nil)
+
+	conf := NewSlackConfig()
+	conf.URL = srv.URL
+	conf.Text = `${! json("summary") }`
+	conf.ThreadTS = `${! meta("thread") }`
+	conf.PayloadMapping = `root.blocks = [{"type":"section","text":{"type":"mrkdwn","text":this.details}}]`
+
+	s, err := NewSlack(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte(`{"summary":"disk full","details":"*/var* is at 100%"}`),
+		[]byte(`{"summary":"disk ok","details":"*/var* is at 20%"}`),
+	})
+	msg.Get(1).Metadata().Set("thread", "1503435956.000247")
+	require.NoError(t, s.Write(msg))
+
+	reqs := srv.requests()
+	require.Len(t, reqs, 2)
+	assert.Equal(t, map[string]interface{}{
+		"text": "disk full",
+		"blocks": []interface{}{
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]interface{}{
+					"type": "mrkdwn",
+					"text": "*/var* is at 100%",
+				},
+			},
+		},
+	}, reqs[0].payload)
+	assert.Equal(t, "disk ok", reqs[1].payload["text"])
+	assert.Equal(t, "1503435956.000247", reqs[1].payload["thread_ts"])
+}
+
+func TestSlackPayloadMappingPrecedence(t *testing.T) {
+	srv := newChatTestServer(t, nil)
+
+	conf := NewSlackConfig()
+	conf.URL = srv.URL
+	conf.PayloadMapping = `root.text = "ALERT: " + content().string()`
+
+	s, err := NewSlack(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, s.Write(message.New([][]byte{[]byte("disk full")})))
+
+	reqs := srv.requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, map[string]interface{}{
+		"text": "ALERT: disk full",
+	}, reqs[0].payload)
+}
+
+func TestSlackPayloadMappingError(t *testing.T) {
+	srv := newChatTestServer(t, nil)
+
+	conf := NewSlackConfig()
+	conf.URL = srv.URL
+	conf.PayloadMapping = `root = "not an object"`
+
+	s, err := NewSlack(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.Error(t, s.Write(message.New([][]byte{[]byte("disk full")})))
+	assert.Empty(t, srv.requests())
+}
+
+func TestDiscordPayload(t *testing.T) {
+	srv := newChatTestServer(t, nil)
+
+	conf := NewDiscordConfig()
+	conf.URL = srv.URL + "?wait=true"
+	conf.ThreadID = `${! meta("thread") }`
+	conf.PayloadMapping = `root.embeds = [{"title":"Alert","description":content().string()}]`
+
+	d, err := NewDiscord(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte("disk full"),
+		[]byte("disk ok"),
+	})
+	msg.Get(1).Metadata().Set("thread", "12345")
+	require.NoError(t, d.Write(msg))
+
+	reqs := srv.requests()
+	require.Len(t, reqs, 2)
+	assert.Equal(t, "wait=true", reqs[0].query)
+	assert.Equal(t, map[string]interface{}{
+		"content": "disk full",
+		"embeds": []interface{}{
+			map[string]interface{}{
+				"title":       "Alert",
+				"description": "disk full",
+			},
+		},
+	}, reqs[0].payload)
+	assert.Equal(t, "thread_id=12345&wait=true", reqs[1].query)
+	assert.Equal(t, "disk ok", reqs[1].payload["content"])
+}
+
+func TestMSTeamsPayload(t *testing.T) {
+	srv := newChatTestServer(t, nil)
+
+	conf := NewMSTeamsConfig()
+	conf.URL = srv.URL
+	conf.Text = ""
+	conf.PayloadMapping = `root.type = "message"
+root.attachments = [{
+  "contentType": "application/vnd.microsoft.card.adaptive",
+  "content": {
+    "type": "AdaptiveCard",
+    "version": "1.4",
+    "body": [{"type":"TextBlock","text":content().string()}]
+  }
+}]`
+
+	m, err := NewMSTeams(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, m.Write(message.New([][]byte{[]byte("disk full")})))
+
+	reqs := srv.requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]interface{}{
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body": []interface{}{
+						map[string]interface{}{
+							"type": "TextBlock",
+							"text": "disk full",
+						},
+					},
+				},
+			},
+		},
+	}, reqs[0].payload)
+}
@@ -0,0 +1,441 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+
+	// SQL Drivers
+	_ "github.com/ClickHouse/clickhouse-go"
+)
+
+//------------------------------------------------------------------------------
+
+// ClickHouseConfig contains configuration fields for the ClickHouse output
+// type.
+type ClickHouseConfig struct {
+	DataSourceName     string             `json:"data_source_name" yaml:"data_source_name"`
+	Table              string             `json:"table" yaml:"table"`
+	Columns            []string           `json:"columns" yaml:"columns"`
+	AsyncInsert        bool               `json:"async_insert" yaml:"async_insert"`
+	WaitForAsyncInsert bool               `json:"wait_for_async_insert" yaml:"wait_for_async_insert"`
+	MaxInFlight        int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching           batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewClickHouseConfig creates a new ClickHouseConfig with default values.
+func NewClickHouseConfig() ClickHouseConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return ClickHouseConfig{
+		DataSourceName:     "tcp://localhost:9000",
+		Table:              "",
+		Columns:            []string{},
+		AsyncInsert:        false,
+		WaitForAsyncInsert: true,
+		MaxInFlight:        1,
+		Batching:           batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// clickHouseColumn is a column of the target table that rows are inserted
+// into.
+type clickHouseColumn struct {
+	name   string
+	chType string
+}
+
+// ClickHouse is a writer type that inserts messages as rows of a ClickHouse
+// table using the native protocol.
+type ClickHouse struct {
+	conf ClickHouseConfig
+
+	dbMut   sync.RWMutex
+	db      *sql.DB
+	columns []clickHouseColumn
+	query   string
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewClickHouse creates a new ClickHouse writer type.
+func NewClickHouse(conf ClickHouseConfig, log log.Modular, stats metrics.Type) (*ClickHouse, error) {
+	if conf.DataSourceName == "" {
+		return nil, errors.New("a data source name must be specified")
+	}
+	if conf.Table == "" {
+		return nil, errors.New("a table must be specified")
+	}
+	return &ClickHouse{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a connection to ClickHouse.
+func (c *ClickHouse) Connect() error {
+	return c.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext attempts to establish a connection to ClickHouse and
+// reads the schema of the target table.
+func (c *ClickHouse) ConnectWithContext(ctx context.Context) error {
+	c.dbMut.Lock()
+	defer c.dbMut.Unlock()
+
+	if c.db != nil {
+		return nil
+	}
+
+	db, err := sql.Open("clickhouse", c.conf.DataSourceName)
+	if err != nil {
+		return err
+	}
+
+	tableColumns, err := describeClickHouseTable(ctx, db, c.conf.Table)
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("failed to read schema of table '%v': %w", c.conf.Table, err)
+	}
+	columns, err := selectClickHouseColumns(tableColumns, c.conf.Columns)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	c.db = db
+	c.columns = columns
+	c.query = clickHouseInsertQuery(c.conf, columns)
+
+	c.log.Infof("Inserting messages as rows into ClickHouse table: %v\n", c.conf.Table)
+	return nil
+}
+
+// describeClickHouseTable returns the columns of a table that can be inserted
+// into, which excludes materialized and alias columns.
+func describeClickHouseTable(ctx context.Context, db *sql.DB, table string) ([]clickHouseColumn, error) {
+	rows, err := db.QueryContext(ctx, "DESCRIBE TABLE "+table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []clickHouseColumn
+	for rows.Next() {
+		values := make([]interface{}, len(names))
+		ptrs := make([]interface{}, len(names))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err = rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		fields := map[string]string{}
+		for i, name := range names {
+			fields[name] = fmt.Sprintf("%v", values[i])
+		}
+		switch fields["default_type"] {
+		case "MATERIALIZED", "ALIAS":
+			continue
+		}
+		columns = append(columns, clickHouseColumn{
+			name:   fields["name"],
+			chType: fields["type"],
+		})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("table has no insertable columns")
+	}
+	return columns, nil
+}
+
+// selectClickHouseColumns returns the columns of a table that are named, or
+// all columns of the table when no names are given.
+func selectClickHouseColumns(tableColumns []clickHouseColumn, names []string) ([]clickHouseColumn, error) {
+	if len(names) == 0 {
+		return tableColumns, nil
+	}
+	columns := make([]clickHouseColumn, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, col := range tableColumns {
+			if col.name == name {
+				columns = append(columns, col)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("column '%v' does not exist or cannot be inserted into", name)
+		}
+	}
+	return columns, nil
+}
+
+func clickHouseInsertQuery(conf ClickHouseConfig, columns []clickHouseColumn) string {
+	names := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = "`" + strings.Replace(col.name, "`", "\\`", -1) + "`"
+		placeholders[i] = "?"
+	}
+
+	query := "INSERT INTO " + conf.Table + " (" + strings.Join(names, ", ") + ")"
+	if conf.AsyncInsert {
+		wait := "0"
+		if conf.WaitForAsyncInsert {
+			wait = "1"
+		}
+		query += " SETTINGS async_insert = 1, wait_for_async_insert = " + wait
+	}
+	return query + " VALUES (" + strings.Join(placeholders, ", ") + ")"
+}
+
+//------------------------------------------------------------------------------
+
+// Write attempts to write message contents to ClickHouse.
+func (c *ClickHouse) Write(msg types.Message) error {
+	return c.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext inserts each message of a batch as a row of the table
+// within a single block.
+func (c *ClickHouse) WriteWithContext(ctx context.Context, msg types.Message) error {
+	c.dbMut.RLock()
+	db, columns, query := c.db, c.columns, c.query
+	c.dbMut.RUnlock()
+
+	if db == nil {
+		return types.ErrNotConnected
+	}
+
+	rows := make([][]interface{}, msg.Len())
+	if err := msg.Iter(func(i int, p types.Part) error {
+		var err error
+		if rows[i], err = clickHouseRow(columns, p.Get()); err != nil {
+			return fmt.Errorf("failed to convert message %v: %w", i, err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// Rows are buffered by the driver in columnar blocks that are sent once the
+	// transaction is committed.
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, row := range rows {
+		if _, err = stmt.ExecContext(ctx, row...); err != nil {
+			stmt.Close()
+			tx.Rollback()
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		stmt.Close()
+		return err
+	}
+	return stmt.Close()
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (c *ClickHouse) CloseAsync() {
+	c.dbMut.Lock()
+	if c.db != nil {
+		c.db.Close()
+		c.db = nil
+	}
+	c.dbMut.Unlock()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (c *ClickHouse) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// clickHouseRow parses a JSON object and returns the values of its fields for
+// each column, converted to the type of the column.
+func clickHouseRow(columns []clickHouseColumn, b []byte) ([]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected object, found %T", doc)
+	}
+
+	row := make([]interface{}, len(columns))
+	for i, col := range columns {
+		var err error
+		if row[i], err = clickHouseValue(col.chType, obj[col.name]); err != nil {
+			return nil, fmt.Errorf("column '%v': %w", col.name, err)
+		}
+	}
+	return row, nil
+}
+
+// clickHouseInnerType returns the type within a wrapping type such as
+// Nullable(T), or an empty string if the type is not of the wrapping kind.
+func clickHouseInnerType(chType, kind string) string {
+	if strings.HasPrefix(chType, kind+"(") && strings.HasSuffix(chType, ")") {
+		return chType[len(kind)+1 : len(chType)-1]
+	}
+	return ""
+}
+
+var (
+	clickHouseInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	clickHouseTimeType      = reflect.TypeOf(time.Time{})
+)
+
+// clickHouseGoType returns the Go type that values of a column type are
+// converted to.
+func clickHouseGoType(chType string) reflect.Type {
+	if inner := clickHouseInnerType(chType, "LowCardinality"); inner != "" {
+		return clickHouseGoType(inner)
+	}
+	if inner := clickHouseInnerType(chType, "Array"); inner != "" {
+		return reflect.SliceOf(clickHouseGoType(inner))
+	}
+	if clickHouseInnerType(chType, "Nullable") != "" {
+		return clickHouseInterfaceType
+	}
+	switch chType {
+	case "Int8":
+		return reflect.TypeOf(int8(0))
+	case "Int16":
+		return reflect.TypeOf(int16(0))
+	case "Int32":
+		return reflect.TypeOf(int32(0))
+	case "Int64":
+		return reflect.TypeOf(int64(0))
+	case "UInt8":
+		return reflect.TypeOf(uint8(0))
+	case "UInt16":
+		return reflect.TypeOf(uint16(0))
+	case "UInt32":
+		return reflect.TypeOf(uint32(0))
+	case "UInt64":
+		return reflect.TypeOf(uint64(0))
+	case "Float32":
+		return reflect.TypeOf(float32(0))
+	case "Float64":
+		return reflect.TypeOf(float64(0))
+	}
+	switch {
+	case strings.HasPrefix(chType, "Decimal"):
+		return reflect.TypeOf(float64(0))
+	case strings.HasPrefix(chType, "Date"):
+		return clickHouseTimeType
+	}
+	return reflect.TypeOf("")
+}
+
+// clickHouseValue converts a JSON value into a value of a column type. Missing
+// values of columns that are not nullable are converted to the zero value of
+// the type.
+func clickHouseValue(chType string, v interface{}) (interface{}, error) {
+	if inner := clickHouseInnerType(chType, "LowCardinality"); inner != "" {
+		return clickHouseValue(inner, v)
+	}
+	if inner := clickHouseInnerType(chType, "Nullable"); inner != "" {
+		if v == nil {
+			return nil, nil
+		}
+		return clickHouseValue(inner, v)
+	}
+	if inner := clickHouseInnerType(chType, "Array"); inner != "" {
+		var elements []interface{}
+		if v != nil {
+			var ok bool
+			if elements, ok = v.([]interface{}); !ok {
+				return nil, fmt.Errorf("expected array, found %T", v)
+			}
+		}
+		arr := reflect.MakeSlice(reflect.SliceOf(clickHouseGoType(inner)), len(elements), len(elements))
+		for i, e := range elements {
+			ev, err := clickHouseValue(inner, e)
+			if err != nil {
+				return nil, err
+			}
+			if ev != nil {
+				arr.Index(i).Set(reflect.ValueOf(ev))
+			}
+		}
+		return arr.Interface(), nil
+	}
+
+	goType := clickHouseGoType(chType)
+	switch goType.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := jsonInt64(v)
+		if err != nil {
+			return nil, err
+		}
+		if reflect.Zero(goType).OverflowInt(i) {
+			return nil, fmt.Errorf("value %v overflows type %v", i, chType)
+		}
+		return reflect.ValueOf(i).Convert(goType).Interface(), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := jsonUint64(v)
+		if err != nil {
+			return nil, err
+		}
+		if reflect.Zero(goType).OverflowUint(u) {
+			return nil, fmt.Errorf("value %v overflows type %v", u, chType)
+		}
+		return reflect.ValueOf(u).Convert(goType).Interface(), nil
+	case reflect.Float32, reflect.Float64:
+		f, err := jsonFloat64(v)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(f).Convert(goType).Interface(), nil
+	case reflect.Struct:
+		return jsonTime(v)
+	}
+	return jsonString(v)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,145 @@
+package writer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickHouseBadConfig(t *testing.T) {
+	conf := NewClickHouseConfig()
+	_, err := NewClickHouse(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Table = "foo"
+	conf.DataSourceName = ""
+	_, err = NewClickHouse(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestClickHouseInsertQuery(t *testing.T) {
+	columns := []clickHouseColumn{
+		{name: "id", chType: "UInt64"},
+		{name: "na`me", chType: "String"},
+	}
+
+	conf := NewClickHouseConfig()
+	conf.Table = "db.events"
+	assert.Equal(t, "INSERT INTO db.events (`id`, `na\\`me`) VALUES (?, ?)", clickHouseInsertQuery(conf, columns))
+
+	conf.AsyncInsert = true
+	assert.Equal(t, "INSERT INTO db.events (`id`, `na\\`me`) SETTINGS async_insert = 1, wait_for_async_insert = 1 VALUES (?, ?)", clickHouseInsertQuery(conf, columns))
+
+	conf.WaitForAsyncInsert = false
+	assert.Equal(t, "INSERT INTO db.events (`id`, `na\\`me`) SETTINGS async_insert = 1, wait_for_async_insert = 0 VALUES (?, ?)", clickHouseInsertQuery(conf, columns))
+}
+
+func TestClickHouseSelectColumns(t *testing.T) {
+	tableColumns := []clickHouseColumn{
+		{name: "a", chType: "String"},
+		{name: "b", chType: "Int64"},
+		{name: "c", chType: "Float64"},
+	}
+
+	columns, err := selectClickHouseColumns(tableColumns, nil)
+	require.NoError(t, err)
+	assert.Equal(t, tableColumns, columns)
+
+	columns, err = selectClickHouseColumns(tableColumns, []string{"c", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, []clickHouseColumn{tableColumns[2], tableColumns[0]}, columns)
+
+	_, err = selectClickHouseColumns(tableColumns, []string{"d"})
+	assert.Error(t, err)
+}
+
+func TestClickHouseRow(t *testing.T) {
+	columns := []clickHouseColumn{
+		{name: "i8", chType: "Int8"},
+		{name: "i64", chType: "Int64"},
+		{name: "u64", chType: "UInt64"},
+		{name: "u8", chType: "UInt8"},
+		{name: "f32", chType: "Float32"},
+		{name: "dec", chType: "Decimal(18, 4)"},
+		{name: "str", chType: "String"},
+		{name: "obj", chType: "String"},
+		{name: "lc", chType: "LowCardinality(String)"},
+		{name: "null", chType: "Nullable(Int32)"},
+		{name: "notnull", chType: "Nullable(Int32)"},
+		{name: "missing", chType: "Int32"},
+		{name: "ts", chType: "DateTime"},
+		{name: "unix", chType: "DateTime64(3)"},
+		{name: "date", chType: "Date"},
+		{name: "tags", chType: "Array(String)"},
+		{name: "matrix", chType: "Array(Array(UInt16))"},
+		{name: "nullables", chType: "Array(Nullable(Int64))"},
+	}
+
+	row, err := clickHouseRow(columns, []byte(`{
+		"i8": "-12",
+		"i64": 9007199254740993,
+		"u64": 18446744073709551615,
+		"u8": true,
+		"f32": "1.5",
+		"dec": 10.25,
+		"str": 5,
+		"obj": {"foo":"bar"},
+		"lc": "baz",
+		"null": null,
+		"notnull": 3.0,
+		"ts": "2020-01-02T03:04:05Z",
+		"unix": 1577934245.5,
+		"date": "2020-01-02",
+		"tags": ["a","b"],
+		"matrix": [[1,2],[3]],
+		"nullables": [1,null]
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []interface{}{
+		int8(-12),
+		int64(9007199254740993),
+		uint64(18446744073709551615),
+		uint8(1),
+		float32(1.5),
+		float64(10.25),
+		"5",
+		`{"foo":"bar"}`,
+		"baz",
+		nil,
+		int32(3),
+		int32(0),
+		time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		time.Unix(1577934245, 5e8),
+		time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		[]string{"a", "b"},
+		[][]uint16{{1, 2}, {3}},
+		[]interface{}{int64(1), nil},
+	}, row)
+}
+
+func TestClickHouseRowErrors(t *testing.T) {
+	tests := map[string]struct {
+		chType string
+		doc    string
+	}{
+		"not an object":  {chType: "String", doc: `["foo"]`},
+		"not json":       {chType: "String", doc: `nope`},
+		"overflow":       {chType: "Int8", doc: `{"a":300}`},
+		"negative uint":  {chType: "UInt32", doc: `{"a":-1}`},
+		"fraction":       {chType: "Int64", doc: `{"a":1.5}`},
+		"bad int string": {chType: "Int64", doc: `{"a":"foo"}`},
+		"bad float":      {chType: "Float64", doc: `{"a":{}}`},
+		"bad timestamp":  {chType: "DateTime", doc: `{"a":"yesterday"}`},
+		"not an array":   {chType: "Array(String)", doc: `{"a":"foo"}`},
+		"bad element":    {chType: "Array(Int64)", doc: `{"a":["foo"]}`},
+	}
+	for name, test := range tests {
+		_, err := clickHouseRow([]clickHouseColumn{{name: "a", chType: test.chType}}, []byte(test.doc))
+		assert.Error(t, err, name)
+	}
+}
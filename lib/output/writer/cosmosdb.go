@@ -0,0 +1,377 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/azureaad"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// CosmosDBConfig contains configuration fields for the CosmosDB output type.
+type CosmosDBConfig struct {
+	Endpoint            string             `json:"endpoint" yaml:"endpoint"`
+	AccountKey          string             `json:"account_key" yaml:"account_key"`
+	ConnectionString    string             `json:"connection_string" yaml:"connection_string"`
+	AAD                 azureaad.Config    `json:"aad" yaml:"aad"`
+	Database            string             `json:"database" yaml:"database"`
+	Container           string             `json:"container" yaml:"container"`
+	Operation           string             `json:"operation" yaml:"operation"`
+	ID                  string             `json:"id" yaml:"id"`
+	PartitionKeyMapping string             `json:"partition_key_mapping" yaml:"partition_key_mapping"`
+	TTL                 string             `json:"ttl" yaml:"ttl"`
+	Timeout             string             `json:"timeout" yaml:"timeout"`
+	MaxInFlight         int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching            batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewCosmosDBConfig creates a new CosmosDBConfig with default values.
+func NewCosmosDBConfig() CosmosDBConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return CosmosDBConfig{
+		Endpoint:            "",
+		AccountKey:          "",
+		ConnectionString:    "",
+		AAD:                 azureaad.NewConfig(),
+		Database:            "",
+		Container:           "",
+		Operation:           "upsert",
+		ID:                  "",
+		PartitionKeyMapping: "",
+		TTL:                 "",
+		Timeout:             "5s",
+		MaxInFlight:         1,
+		Batching:            batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// The version of the CosmosDB REST API that requests are made with.
+const cosmosDBAPIVersion = "2018-12-31"
+
+// CosmosDB is a writer type that writes messages as documents to a container
+// of an Azure CosmosDB account via the SQL API.
+type CosmosDB struct {
+	conf CosmosDBConfig
+
+	endpoint     *url.URL
+	accountKey   []byte
+	token        func(ctx context.Context) (string, error)
+	resourceLink string
+
+	id                  field.Expression
+	partitionKeyMapping *mapping.Executor
+	ttl                 int64
+
+	httpClient *http.Client
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewCosmosDB creates a new CosmosDB writer type.
+func NewCosmosDB(conf CosmosDBConfig, log log.Modular, stats metrics.Type) (*CosmosDB, error) {
+	c := &CosmosDB{
+		conf:      conf,
+		closeChan: make(chan struct{}),
+		log:       log,
+		stats:     stats,
+	}
+
+	endpoint, key := conf.Endpoint, conf.AccountKey
+	if conf.ConnectionString != "" {
+		var err error
+		if endpoint, key, err = parseCosmosDBConnectionString(conf.ConnectionString); err != nil {
+			return nil, fmt.Errorf("failed to parse connection string: %v", err)
+		}
+	}
+	if endpoint == "" {
+		return nil, errors.New("an endpoint or connection string must be specified")
+	}
+	var err error
+	if c.endpoint, err = url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse endpoint: %v", err)
+	}
+
+	switch {
+	case conf.AAD.Enabled:
+		resource := c.endpoint.Scheme + "://" + c.endpoint.Hostname()
+		source, err := azureaad.NewTokenSource(conf.AAD, resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure Active Directory token source: %v", err)
+		}
+		c.token = func(ctx context.Context) (string, error) {
+			t, err := source.Token(ctx)
+			return t.AccessToken, err
+		}
+	case key != "":
+		if c.accountKey, err = base64.StdEncoding.DecodeString(key); err != nil {
+			return nil, fmt.Errorf("failed to decode account key: %v", err)
+		}
+	default:
+		return nil, errors.New("either an account key, connection string or Azure Active Directory authentication must be specified")
+	}
+
+	if conf.Database == "" {
+		return nil, errors.New("a database must be specified")
+	}
+	if conf.Container == "" {
+		return nil, errors.New("a container must be specified")
+	}
+	c.resourceLink = "dbs/" + conf.Database + "/colls/" + conf.Container
+
+	switch conf.Operation {
+	case "upsert", "create":
+	default:
+		return nil, fmt.Errorf("operation must be either upsert or create, got: %v", conf.Operation)
+	}
+	if conf.ID != "" {
+		if c.id, err = bloblang.NewField(conf.ID); err != nil {
+			return nil, fmt.Errorf("failed to parse id expression: %v", err)
+		}
+	}
+	if conf.PartitionKeyMapping == "" {
+		return nil, errors.New("a partition key mapping must be specified")
+	}
+	if c.partitionKeyMapping, err = newMapping("partition key mapping", conf.PartitionKeyMapping); err != nil {
+		return nil, err
+	}
+	if conf.TTL != "" {
+		ttl, err := time.ParseDuration(conf.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ttl: %v", err)
+		}
+		if c.ttl = int64(ttl / time.Second); c.ttl < 1 {
+			return nil, errors.New("ttl must be at least one second")
+		}
+	}
+
+	timeout, err := time.ParseDuration(conf.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+	}
+	c.httpClient = &http.Client{Timeout: timeout}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+// parseCosmosDBConnectionString extracts the endpoint and account key of a
+// connection string of the form AccountEndpoint=...;AccountKey=...;
+func parseCosmosDBConnectionString(str string) (endpoint, key string, err error) {
+	for _, pair := range strings.Split(str, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return "", "", fmt.Errorf("invalid connection string segment: %v", pair)
+		}
+		switch strings.ToLower(kv[0]) {
+		case "accountendpoint":
+			endpoint = kv[1]
+		case "accountkey":
+			key = kv[1]
+		}
+	}
+	if endpoint == "" {
+		return "", "", errors.New("connection string is missing an account endpoint")
+	}
+	if key == "" {
+		return "", "", errors.New("connection string is missing an account key")
+	}
+	return endpoint, key, nil
+}
+
+// authorization returns the authorization header of a request for a
+// resource, which is either signed with the account key or carries a token
+// obtained from Azure Active Directory.
+func (c *CosmosDB) authorization(ctx context.Context, verb, resourceType, resourceLink, date string) (string, error) {
+	if c.token != nil {
+		token, err := c.token(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to obtain token: %w", err)
+		}
+		return url.QueryEscape("type=aad&ver=1.0&sig=" + token), nil
+	}
+	mac := hmac.New(sha256.New, c.accountKey)
+	mac.Write([]byte(strings.ToLower(verb) + "\n" + strings.ToLower(resourceType) + "\n" + resourceLink + "\n" + strings.ToLower(date) + "\n\n"))
+	sig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return url.QueryEscape("type=master&ver=1.0&sig=" + sig), nil
+}
+
+// document converts a message of a batch into a document and the value of its
+// partition key, formatted as the JSON array expected by the
+// x-ms-documentdb-partitionkey header.
+func (c *CosmosDB) document(index int, msg types.Message) ([]byte, string, error) {
+	jObj, err := msg.Get(index).JSON()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse message as JSON: %w", err)
+	}
+	doc, ok := jObj.(map[string]interface{})
+	if !ok {
+		return nil, "", fmt.Errorf("expected message to be a JSON object, got %v", query.ITypeOf(jObj))
+	}
+	docCopy := make(map[string]interface{}, len(doc)+2)
+	for k, v := range doc {
+		docCopy[k] = v
+	}
+	if c.id != nil {
+		docCopy["id"] = c.id.String(index, msg)
+	}
+	if id, _ := docCopy["id"].(string); id == "" {
+		return nil, "", errors.New("document is missing a string id")
+	}
+	if _, exists := docCopy["ttl"]; !exists && c.ttl > 0 {
+		docCopy["ttl"] = c.ttl
+	}
+
+	res, err := execMapping(c.partitionKeyMapping, index, msg)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to execute partition key mapping: %w", err)
+	}
+	switch res.(type) {
+	case query.Delete, query.Nothing:
+		return nil, "", errors.New("partition key mapping returned no value")
+	case map[string]interface{}, []interface{}:
+		return nil, "", fmt.Errorf("partition key mapping returned invalid result: %v", query.ITypeOf(res))
+	}
+	partitionKey, err := json.Marshal([]interface{}{res})
+	if err != nil {
+		return nil, "", err
+	}
+
+	body, err := json.Marshal(docCopy)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, string(partitionKey), nil
+}
+
+// post writes a document, waiting for as long as requested by CosmosDB and
+// retrying when the request rate of the container is exceeded.
+func (c *CosmosDB) post(ctx context.Context, body []byte, partitionKey string) error {
+	u := *c.endpoint
+	u.Path = "/" + c.resourceLink + "/docs"
+	for {
+		req, err := http.NewRequest("POST", u.String(), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req = req.WithContext(ctx)
+
+		date := time.Now().UTC().Format(http.TimeFormat)
+		auth, err := c.authorization(ctx, "POST", "docs", c.resourceLink, date)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", auth)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-ms-date", date)
+		req.Header.Set("x-ms-version", cosmosDBAPIVersion)
+		req.Header.Set("x-ms-documentdb-partitionkey", partitionKey)
+		if c.conf.Operation == "upsert" {
+			req.Header.Set("x-ms-documentdb-is-upsert", "True")
+		}
+
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resBody, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.StatusCode == http.StatusTooManyRequests {
+			wait := time.Second
+			if ms, err := strconv.ParseFloat(res.Header.Get("x-ms-retry-after-ms"), 64); err == nil {
+				wait = time.Duration(ms * float64(time.Millisecond))
+			}
+			c.log.Debugf("Request rate of CosmosDB container exceeded, waiting %v\n", wait)
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return types.ErrTimeout
+			case <-c.closeChan:
+				return types.ErrTypeClosed
+			}
+			continue
+		}
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return fmt.Errorf("failed to write document: %v: %s", res.Status, bytes.TrimSpace(resBody))
+		}
+		return nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as the CosmosDB output writes with plain HTTP requests.
+func (c *CosmosDB) Connect() error {
+	return c.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as the CosmosDB output writes with plain HTTP
+// requests.
+func (c *CosmosDB) ConnectWithContext(ctx context.Context) error {
+	c.log.Infof("Writing documents to CosmosDB container %v at: %v\n", c.resourceLink, c.endpoint)
+	return nil
+}
+
+// Write attempts to write a message batch to CosmosDB.
+func (c *CosmosDB) Write(msg types.Message) error {
+	return c.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext writes each message of a batch as a document, where
+// messages that cannot be written are rejected individually.
+func (c *CosmosDB) WriteWithContext(ctx context.Context, msg types.Message) error {
+	return IterateBatchedSend(msg, func(i int, _ types.Part) error {
+		body, partitionKey, err := c.document(i, msg)
+		if err != nil {
+			return err
+		}
+		return c.post(ctx, body, partitionKey)
+	})
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (c *CosmosDB) CloseAsync() {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+	})
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (c *CosmosDB) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,276 @@
+package writer
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var cosmosDBTestKey = base64.StdEncoding.EncodeToString([]byte("foobar"))
+
+type cosmosDBTestRequest struct {
+	path         string
+	auth         string
+	date         string
+	partitionKey string
+	upsert       string
+	doc          map[string]interface{}
+}
+
+type cosmosDBTestServer struct {
+	*httptest.Server
+
+	mut     sync.Mutex
+	reqs    []cosmosDBTestRequest
+	respond func(w http.ResponseWriter, n int)
+}
+
+func newCosmosDBTestServer(t *testing.T, respond func(w http.ResponseWriter, n int)) *cosmosDBTestServer {
+	t.Helper()
+
+	s := &cosmosDBTestServer{respond: respond}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		req := cosmosDBTestRequest{
+			path:         r.URL.Path,
+			auth:         r.Header.Get("Authorization"),
+			date:         r.Header.Get("x-ms-date"),
+			partitionKey: r.Header.Get("x-ms-documentdb-partitionkey"),
+			upsert:       r.Header.Get("x-ms-documentdb-is-upsert"),
+		}
+		if err := json.Unmarshal(b, &req.doc); err != nil {
+			t.Error(err)
+		}
+		s.mut.Lock()
+		s.reqs = append(s.reqs, req)
+		n := len(s.reqs)
+		s.mut.Unlock()
+
+		if s.respond != nil {
+			s.respond(w, n)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *cosmosDBTestServer) requests() []cosmosDBTestRequest {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]cosmosDBTestRequest(nil), s.reqs...)
+}
+
+func TestCosmosDBConfigErrors(t *testing.T) {
+	tests := map[string]func(c *CosmosDBConfig){
+		"no endpoint": func(c *CosmosDBConfig) {
+			c.Endpoint = ""
+		},
+		"no credentials": func(c *CosmosDBConfig) {
+			c.AccountKey = ""
+		},
+		"bad account key": func(c *CosmosDBConfig) {
+			c.AccountKey = "not base64!"
+		},
+		"bad connection string": func(c *CosmosDBConfig) {
+			c.ConnectionString = "AccountEndpoint=https://foo.documents.azure.com:443/"
+		},
+		"no database": func(c *CosmosDBConfig) {
+			c.Database = ""
+		},
+		"no container": func(c *CosmosDBConfig) {
+			c.Container = ""
+		},
+		"bad operation": func(c *CosmosDBConfig) {
+			c.Operation = "delete"
+		},
+		"no partition key mapping": func(c *CosmosDBConfig) {
+			c.PartitionKeyMapping = ""
+		},
+		"bad ttl": func(c *CosmosDBConfig) {
+			c.TTL = "10ms"
+		},
+	}
+
+	for name, fn := range tests {
+		fn := fn
+		t.Run(name, func(t *testing.T) {
+			conf := NewCosmosDBConfig()
+			conf.Endpoint = "https://foo.documents.azure.com:443/"
+			conf.AccountKey = cosmosDBTestKey
+			conf.Database = "foo"
+			conf.Container = "bar"
+			conf.PartitionKeyMapping = "root = this.tenant"
+			fn(&conf)
+
+			_, err := NewCosmosDB(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCosmosDBConnectionString(t *testing.T) {
+	endpoint, key, err := parseCosmosDBConnectionString("AccountEndpoint=https://foo.documents.azure.com:443/;AccountKey=" + cosmosDBTestKey + ";")
+	require.NoError(t, err)
+	assert.Equal(t, "https://foo.documents.azure.com:443/", endpoint)
+	assert.Equal(t, cosmosDBTestKey, key)
+
+	_, _, err = parseCosmosDBConnectionString("AccountKey=" + cosmosDBTestKey)
+	assert.Error(t, err)
+}
+
+func TestCosmosDBWrite(t *testing.T) {
+	srv := newCosmosDBTestServer(t, nil)
+
+	conf := NewCosmosDBConfig()
+	conf.ConnectionString = "AccountEndpoint=" + srv.URL + ";AccountKey=" + cosmosDBTestKey
+	conf.Database = "foo"
+	conf.Container = "bar"
+	conf.ID = `${! meta("id") }`
+	conf.PartitionKeyMapping = "root = this.tenant"
+	conf.TTL = "1h"
+
+	c, err := NewCosmosDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte(`{"tenant":"acme","value":1}`),
+		[]byte(`{"tenant":7,"value":2,"ttl":-1}`),
+	})
+	msg.Get(0).Metadata().Set("id", "a")
+	msg.Get(1).Metadata().Set("id", "b")
+	require.NoError(t, c.Write(msg))
+
+	reqs := srv.requests()
+	require.Len(t, reqs, 2)
+
+	assert.Equal(t, "/dbs/foo/colls/bar/docs", reqs[0].path)
+	assert.Equal(t, "True", reqs[0].upsert)
+	assert.Equal(t, `["acme"]`, reqs[0].partitionKey)
+	assert.Equal(t, map[string]interface{}{
+		"id":     "a",
+		"tenant": "acme",
+		"value":  1.0,
+		"ttl":    3600.0,
+	}, reqs[0].doc)
+
+	assert.Equal(t, `[7]`, reqs[1].partitionKey)
+	assert.Equal(t, -1.0, reqs[1].doc["ttl"])
+
+	// Recompute the signature of the first request with the account key.
+	key, _ := base64.StdEncoding.DecodeString(cosmosDBTestKey)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("post\ndocs\ndbs/foo/colls/bar\n" + strings.ToLower(reqs[0].date) + "\n\n"))
+	expAuth := url.QueryEscape("type=master&ver=1.0&sig=" + base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	assert.Equal(t, expAuth, reqs[0].auth)
+}
+
+func TestCosmosDBAADToken(t *testing.T) {
+	srv := newCosmosDBTestServer(t, nil)
+
+	conf := NewCosmosDBConfig()
+	conf.Endpoint = srv.URL
+	conf.AccountKey = cosmosDBTestKey
+	conf.Database = "foo"
+	conf.Container = "bar"
+	conf.Operation = "create"
+	conf.PartitionKeyMapping = "root = this.tenant"
+
+	c, err := NewCosmosDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	c.token = func(ctx context.Context) (string, error) {
+		return "footoken", nil
+	}
+
+	require.NoError(t, c.Write(message.New([][]byte{[]byte(`{"id":"a","tenant":"acme"}`)})))
+
+	reqs := srv.requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, url.QueryEscape("type=aad&ver=1.0&sig=footoken"), reqs[0].auth)
+	assert.Equal(t, "", reqs[0].upsert)
+}
+
+func TestCosmosDBRateLimited(t *testing.T) {
+	srv := newCosmosDBTestServer(t, func(w http.ResponseWriter, n int) {
+		if n == 1 {
+			w.Header().Set("x-ms-retry-after-ms", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	conf := NewCosmosDBConfig()
+	conf.Endpoint = srv.URL
+	conf.AccountKey = cosmosDBTestKey
+	conf.Database = "foo"
+	conf.Container = "bar"
+	conf.PartitionKeyMapping = "root = this.tenant"
+
+	c, err := NewCosmosDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, c.Write(message.New([][]byte{[]byte(`{"id":"a","tenant":"acme"}`)})))
+	assert.Len(t, srv.requests(), 2)
+}
+
+func TestCosmosDBErrors(t *testing.T) {
+	srv := newCosmosDBTestServer(t, func(w http.ResponseWriter, n int) {
+		if n == 2 {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":"Conflict"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	conf := NewCosmosDBConfig()
+	conf.Endpoint = srv.URL
+	conf.AccountKey = cosmosDBTestKey
+	conf.Database = "foo"
+	conf.Container = "bar"
+	conf.Operation = "create"
+	conf.PartitionKeyMapping = "root = this.tenant"
+
+	c, err := NewCosmosDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	err = c.Write(message.New([][]byte{
+		[]byte(`{"id":"a","tenant":"acme"}`),
+		[]byte(`{"id":"b","tenant":"acme"}`),
+		[]byte(`{"tenant":"acme"}`),
+		[]byte(`not json`),
+		[]byte(`{"id":"c","tenant":"acme"}`),
+	}))
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok)
+	var failed []int
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1, 2, 3}, failed)
+	assert.Len(t, srv.requests(), 3)
+}
@@ -0,0 +1,170 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// DiscordConfig contains configuration fields for the Discord output type.
+type DiscordConfig struct {
+	URL            string `json:"url" yaml:"url"`
+	Content        string `json:"content" yaml:"content"`
+	PayloadMapping string `json:"payload_mapping" yaml:"payload_mapping"`
+	ThreadID       string `json:"thread_id" yaml:"thread_id"`
+	Timeout        string `json:"timeout" yaml:"timeout"`
+	MaxInFlight    int    `json:"max_in_flight" yaml:"max_in_flight"`
+}
+
+// NewDiscordConfig creates a new DiscordConfig with default values.
+func NewDiscordConfig() DiscordConfig {
+	return DiscordConfig{
+		URL:            "",
+		Content:        "${! content() }",
+		PayloadMapping: "",
+		ThreadID:       "",
+		Timeout:        "5s",
+		MaxInFlight:    1,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Discord is a writer type that posts messages to a Discord webhook.
+type Discord struct {
+	conf DiscordConfig
+
+	content        field.Expression
+	threadID       field.Expression
+	payloadMapping *mapping.Executor
+
+	webhook *chatWebhook
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewDiscord creates a new Discord writer type.
+func NewDiscord(conf DiscordConfig, log log.Modular, stats metrics.Type) (*Discord, error) {
+	d := &Discord{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	if conf.URL == "" {
+		return nil, errors.New("a url must be specified")
+	}
+	if conf.Content == "" && conf.PayloadMapping == "" {
+		return nil, errors.New("either a content or payload mapping must be specified")
+	}
+	if _, err := url.Parse(conf.URL); err != nil {
+		return nil, fmt.Errorf("failed to parse url: %v", err)
+	}
+
+	var err error
+	if conf.Content != "" {
+		if d.content, err = bloblang.NewField(conf.Content); err != nil {
+			return nil, fmt.Errorf("failed to parse content expression: %v", err)
+		}
+	}
+	if conf.ThreadID != "" {
+		if d.threadID, err = bloblang.NewField(conf.ThreadID); err != nil {
+			return nil, fmt.Errorf("failed to parse thread id expression: %v", err)
+		}
+	}
+	if conf.PayloadMapping != "" {
+		if d.payloadMapping, err = newMapping("payload mapping", conf.PayloadMapping); err != nil {
+			return nil, err
+		}
+	}
+	if d.webhook, err = newChatWebhook("Discord", conf.Timeout, log); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// payload builds the webhook payload of a message of a batch, where the fields
+// of the payload mapping take precedence over other fields.
+func (d *Discord) payload(index int, msg types.Message) (map[string]interface{}, error) {
+	payload, err := chatPayload(d.payloadMapping, index, msg)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := payload["content"]; !exists && d.content != nil {
+		if content := d.content.String(index, msg); content != "" {
+			payload["content"] = content
+		}
+	}
+	return payload, nil
+}
+
+// url returns the webhook URL of a message of a batch, which targets a thread
+// when the thread ID of the message is set.
+func (d *Discord) url(index int, msg types.Message) string {
+	if d.threadID == nil {
+		return d.conf.URL
+	}
+	id := d.threadID.String(index, msg)
+	if id == "" {
+		return d.conf.URL
+	}
+	u, _ := url.Parse(d.conf.URL)
+	query := u.Query()
+	query.Set("thread_id", id)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as the Discord output writes with plain HTTP requests.
+func (d *Discord) Connect() error {
+	return d.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as the Discord output writes with plain HTTP
+// requests.
+func (d *Discord) ConnectWithContext(ctx context.Context) error {
+	d.log.Infoln("Posting messages to a Discord webhook")
+	return nil
+}
+
+// Write attempts to post a message batch to Discord.
+func (d *Discord) Write(msg types.Message) error {
+	return d.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext posts each message of a batch to Discord in order.
+func (d *Discord) WriteWithContext(ctx context.Context, msg types.Message) error {
+	return IterateBatchedSend(msg, func(i int, _ types.Part) error {
+		payload, err := d.payload(i, msg)
+		if err != nil {
+			return err
+		}
+		return d.webhook.post(ctx, d.url(i, msg), payload)
+	})
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (d *Discord) CloseAsync() {
+	d.webhook.closeAsync()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (d *Discord) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -6,12 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	batchInternal "github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message/batch"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
@@ -20,6 +23,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/util/retries"
 	"github.com/Jeffail/gabs/v2"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/cenkalti/backoff/v4"
@@ -28,15 +32,26 @@ import (
 
 //------------------------------------------------------------------------------
 
+// DynamoDBConditionConfig contains config fields for the conditional writes of
+// the DynamoDB output type.
+type DynamoDBConditionConfig struct {
+	Expression             string            `json:"expression" yaml:"expression"`
+	AttributeNames         map[string]string `json:"attribute_names" yaml:"attribute_names"`
+	AttributeValuesMapping string            `json:"attribute_values_mapping" yaml:"attribute_values_mapping"`
+	IgnoreFailures         bool              `json:"ignore_failures" yaml:"ignore_failures"`
+}
+
 // DynamoDBConfig contains config fields for the DynamoDB output type.
 type DynamoDBConfig struct {
 	sessionConfig  `json:",inline" yaml:",inline"`
-	Table          string            `json:"table" yaml:"table"`
-	StringColumns  map[string]string `json:"string_columns" yaml:"string_columns"`
-	JSONMapColumns map[string]string `json:"json_map_columns" yaml:"json_map_columns"`
-	TTL            string            `json:"ttl" yaml:"ttl"`
-	TTLKey         string            `json:"ttl_key" yaml:"ttl_key"`
-	MaxInFlight    int               `json:"max_in_flight" yaml:"max_in_flight"`
+	Table          string                  `json:"table" yaml:"table"`
+	StringColumns  map[string]string       `json:"string_columns" yaml:"string_columns"`
+	JSONMapColumns map[string]string       `json:"json_map_columns" yaml:"json_map_columns"`
+	Condition      DynamoDBConditionConfig `json:"condition" yaml:"condition"`
+	TTL            string                  `json:"ttl" yaml:"ttl"`
+	TTLMapping     string                  `json:"ttl_mapping" yaml:"ttl_mapping"`
+	TTLKey         string                  `json:"ttl_key" yaml:"ttl_key"`
+	MaxInFlight    int                     `json:"max_in_flight" yaml:"max_in_flight"`
 	retries.Config `json:",inline" yaml:",inline"`
 	Batching       batch.PolicyConfig `json:"batching" yaml:"batching"`
 }
@@ -57,11 +72,18 @@ func NewDynamoDBConfig() DynamoDBConfig {
 		Table:          "",
 		StringColumns:  map[string]string{},
 		JSONMapColumns: map[string]string{},
-		TTL:            "",
-		TTLKey:         "",
-		MaxInFlight:    1,
-		Config:         rConf,
-		Batching:       batching,
+		Condition: DynamoDBConditionConfig{
+			Expression:             "",
+			AttributeNames:         map[string]string{},
+			AttributeValuesMapping: "",
+			IgnoreFailures:         false,
+		},
+		TTL:         "",
+		TTLMapping:  "",
+		TTLKey:      "",
+		MaxInFlight: 1,
+		Config:      rConf,
+		Batching:    batching,
 	}
 }
 
@@ -80,8 +102,13 @@ type DynamoDB struct {
 
 	table          *string
 	ttl            time.Duration
+	ttlMapping     *mapping.Executor
 	strColumns     map[string]field.Expression
 	jsonMapColumns map[string]string
+
+	condition       field.Expression
+	conditionNames  map[string]*string
+	conditionValues *mapping.Executor
 }
 
 // NewDynamoDB creates a new Amazon SQS writer.Type.
@@ -120,6 +147,31 @@ func NewDynamoDB(
 		}
 		db.ttl = ttl
 	}
+	if conf.TTLMapping != "" {
+		if conf.TTL != "" {
+			return nil, errors.New("a ttl and a ttl_mapping cannot both be specified")
+		}
+		if conf.TTLKey == "" {
+			return nil, errors.New("a ttl_key must be specified with a ttl_mapping")
+		}
+		if db.ttlMapping, err = newMapping("ttl mapping", conf.TTLMapping); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Condition.Expression != "" {
+		if db.condition, err = bloblang.NewField(conf.Condition.Expression); err != nil {
+			return nil, fmt.Errorf("failed to parse condition expression: %v", err)
+		}
+		db.conditionNames = make(map[string]*string, len(conf.Condition.AttributeNames))
+		for k, v := range conf.Condition.AttributeNames {
+			db.conditionNames[k] = aws.String(v)
+		}
+		if conf.Condition.AttributeValuesMapping != "" {
+			if db.conditionValues, err = newMapping("condition attribute values mapping", conf.Condition.AttributeValuesMapping); err != nil {
+				return nil, err
+			}
+		}
+	}
 	if db.backoffCtor, err = conf.Config.GetCtor(); err != nil {
 		return nil, err
 	}
@@ -228,6 +280,134 @@ func (d *DynamoDB) Write(msg types.Message) error {
 	return d.WriteWithContext(context.Background(), msg)
 }
 
+// dynamoDBMaxBatchItems is the maximum number of items of a BatchWriteItem
+// request.
+const dynamoDBMaxBatchItems = 25
+
+// item returns the attributes of the item to write for a message of a batch.
+func (d *DynamoDB) item(i int, p types.Part, msg types.Message) (map[string]*dynamodb.AttributeValue, error) {
+	items := map[string]*dynamodb.AttributeValue{}
+	if d.ttl != 0 && d.conf.TTLKey != "" {
+		items[d.conf.TTLKey] = &dynamodb.AttributeValue{
+			N: aws.String(strconv.FormatInt(time.Now().Add(d.ttl).Unix(), 10)),
+		}
+	}
+	for k, v := range d.strColumns {
+		s := v.String(i, msg)
+		items[k] = &dynamodb.AttributeValue{
+			S: &s,
+		}
+	}
+	if len(d.jsonMapColumns) > 0 {
+		jRoot, err := p.JSON()
+		if err != nil {
+			d.log.Errorf("Failed to extract JSON maps from document: %v", err)
+		} else {
+			for k, v := range d.jsonMapColumns {
+				if attr, err := jsonToMap(v, jRoot); err == nil {
+					if len(k) == 0 {
+						for ak, av := range attr.M {
+							items[ak] = av
+						}
+					} else {
+						items[k] = attr
+					}
+				} else {
+					d.log.Warnf("Unable to extract JSON map path '%v' from document: %v", v, err)
+				}
+			}
+		}
+	}
+	if d.ttlMapping != nil {
+		res, err := execMapping(d.ttlMapping, i, msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute ttl mapping: %w", err)
+		}
+		switch t := res.(type) {
+		case query.Delete, query.Nothing:
+		case string:
+			ts, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse ttl mapping result: %w", err)
+			}
+			items[d.conf.TTLKey] = &dynamodb.AttributeValue{
+				N: aws.String(strconv.FormatInt(ts.Unix(), 10)),
+			}
+		default:
+			n, err := query.IGetNumber(res)
+			if err != nil {
+				return nil, fmt.Errorf("ttl mapping returned invalid result: %w", err)
+			}
+			items[d.conf.TTLKey] = &dynamodb.AttributeValue{
+				N: aws.String(strconv.FormatInt(int64(n), 10)),
+			}
+		}
+	}
+	return items, nil
+}
+
+// conditionalPut returns the conditional put of an item for a message of a
+// batch, or nil when the condition of the message is empty.
+func (d *DynamoDB) conditionalPut(i int, msg types.Message, item map[string]*dynamodb.AttributeValue) (*dynamodb.PutItemInput, error) {
+	if d.condition == nil {
+		return nil, nil
+	}
+	expr := d.condition.String(i, msg)
+	if expr == "" {
+		return nil, nil
+	}
+	input := &dynamodb.PutItemInput{
+		TableName:           d.table,
+		Item:                item,
+		ConditionExpression: aws.String(expr),
+	}
+	for k, v := range d.conditionNames {
+		if dynamoDBExprUses(expr, k) {
+			if input.ExpressionAttributeNames == nil {
+				input.ExpressionAttributeNames = map[string]*string{}
+			}
+			input.ExpressionAttributeNames[k] = v
+		}
+	}
+	if d.conditionValues != nil {
+		res, err := execMapping(d.conditionValues, i, msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute condition attribute values mapping: %w", err)
+		}
+		obj, ok := res.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("condition attribute values mapping returned non-object result: %v", query.ITypeOf(res))
+		}
+		for k, v := range obj {
+			if dynamoDBExprUses(expr, k) {
+				if input.ExpressionAttributeValues == nil {
+					input.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{}
+				}
+				input.ExpressionAttributeValues[k] = walkJSON(v)
+			}
+		}
+	}
+	return input, nil
+}
+
+// dynamoDBExprUses returns whether an expression refers to an attribute name
+// or value placeholder, as DynamoDB rejects requests with unused placeholders.
+func dynamoDBExprUses(expr, placeholder string) bool {
+	for {
+		i := strings.Index(expr, placeholder)
+		if i < 0 {
+			return false
+		}
+		expr = expr[i+len(placeholder):]
+		if expr == "" {
+			return true
+		}
+		if c := expr[0]; !(c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
+			return true
+		}
+	}
+}
+
 // WriteWithContext attempts to write message contents to a target DynamoDB
 // table.
 func (d *DynamoDB) WriteWithContext(ctx context.Context, msg types.Message) error {
@@ -241,48 +421,89 @@ func (d *DynamoDB) WriteWithContext(ctx context.Context, msg types.Message) erro
 		d.boffPool.Put(boff)
 	}()
 
+	var batchErr *batchInternal.Error
+	failed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = batchInternal.NewError(msg, err)
+		}
+		batchErr.Failed(i, err)
+	}
+	merge := func(indexes []int, err error) {
+		var bErr *batchInternal.Error
+		if errors.As(err, &bErr) && bErr.IndexedErrors() > 0 {
+			bErr.WalkParts(func(i int, _ types.Part, pErr error) bool {
+				if pErr != nil {
+					failed(i, pErr)
+				}
+				return true
+			})
+			return
+		}
+		for _, i := range indexes {
+			failed(i, err)
+		}
+	}
+
 	writeReqs := []*dynamodb.WriteRequest{}
+	var reqIndexes, putIndexes []int
+	var puts []*dynamodb.PutItemInput
 	msg.Iter(func(i int, p types.Part) error {
-		items := map[string]*dynamodb.AttributeValue{}
-		if d.ttl != 0 && d.conf.TTLKey != "" {
-			items[d.conf.TTLKey] = &dynamodb.AttributeValue{
-				N: aws.String(strconv.FormatInt(time.Now().Add(d.ttl).Unix(), 10)),
-			}
+		item, err := d.item(i, p, msg)
+		if err != nil {
+			d.log.Errorf("Failed to create item: %v\n", err)
+			failed(i, err)
+			return nil
 		}
-		for k, v := range d.strColumns {
-			s := v.String(i, msg)
-			items[k] = &dynamodb.AttributeValue{
-				S: &s,
-			}
+		put, err := d.conditionalPut(i, msg, item)
+		if err != nil {
+			d.log.Errorf("Failed to create condition: %v\n", err)
+			failed(i, err)
+			return nil
 		}
-		if len(d.jsonMapColumns) > 0 {
-			jRoot, err := p.JSON()
-			if err != nil {
-				d.log.Errorf("Failed to extract JSON maps from document: %v", err)
-			} else {
-				for k, v := range d.jsonMapColumns {
-					if attr, err := jsonToMap(v, jRoot); err == nil {
-						if len(k) == 0 {
-							for ak, av := range attr.M {
-								items[ak] = av
-							}
-						} else {
-							items[k] = attr
-						}
-					} else {
-						d.log.Warnf("Unable to extract JSON map path '%v' from document: %v", v, err)
-					}
-				}
-			}
+		if put != nil {
+			puts = append(puts, put)
+			putIndexes = append(putIndexes, i)
+			return nil
 		}
 		writeReqs = append(writeReqs, &dynamodb.WriteRequest{
 			PutRequest: &dynamodb.PutRequest{
-				Item: items,
+				Item: item,
 			},
 		})
+		reqIndexes = append(reqIndexes, i)
 		return nil
 	})
 
+	if len(writeReqs) == msg.Len() && len(writeReqs) <= dynamoDBMaxBatchItems {
+		return d.writeBatch(ctx, boff, msg, reqIndexes, writeReqs)
+	}
+
+	for start := 0; start < len(writeReqs); start += dynamoDBMaxBatchItems {
+		end := start + dynamoDBMaxBatchItems
+		if end > len(writeReqs) {
+			end = len(writeReqs)
+		}
+		if err := d.writeBatch(ctx, boff, msg, reqIndexes[start:end], writeReqs[start:end]); err != nil {
+			merge(reqIndexes[start:end], err)
+		}
+		boff.Reset()
+	}
+	if len(puts) > 0 {
+		if err := d.putItems(ctx, boff, msg, putIndexes, puts, nil); err != nil {
+			merge(putIndexes, err)
+		}
+	}
+
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+// writeBatch writes items with a BatchWriteItem request, where indexes are the
+// indexes of the messages of the items. Unprocessed items are retried, and
+// when the request fails entirely the items are written individually.
+func (d *DynamoDB) writeBatch(ctx context.Context, boff backoff.BackOff, msg types.Message, indexes []int, writeReqs []*dynamodb.WriteRequest) error {
 	batchResult, err := d.client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
 		RequestItems: map[string][]*dynamodb.WriteRequest{
 			*d.table: writeReqs,
@@ -290,39 +511,14 @@ func (d *DynamoDB) WriteWithContext(ctx context.Context, msg types.Message) erro
 	})
 	if err != nil {
 		// None of the messages were successful, attempt to send individually
-	individualRequestsLoop:
-		for err != nil {
-			batchErr := batchInternal.NewError(msg, err)
-			for i, req := range writeReqs {
-				if req == nil {
-					continue
-				}
-				if _, iErr := d.client.PutItem(&dynamodb.PutItemInput{
-					TableName: d.table,
-					Item:      req.PutRequest.Item,
-				}); iErr != nil {
-					d.log.Errorf("Put error: %v\n", iErr)
-					wait := boff.NextBackOff()
-					if wait == backoff.Stop {
-						break individualRequestsLoop
-					}
-					select {
-					case <-time.After(wait):
-					case <-ctx.Done():
-						break individualRequestsLoop
-					}
-					batchErr.Failed(i, iErr)
-				} else {
-					writeReqs[i] = nil
-				}
-			}
-			if batchErr.IndexedErrors() == 0 {
-				err = nil
-			} else {
-				err = batchErr
+		puts := make([]*dynamodb.PutItemInput, len(writeReqs))
+		for i, req := range writeReqs {
+			puts[i] = &dynamodb.PutItemInput{
+				TableName: d.table,
+				Item:      req.PutRequest.Item,
 			}
 		}
-		return err
+		return d.putItems(ctx, boff, msg, indexes, puts, err)
 	}
 
 	unproc := batchResult.UnprocessedItems[*d.table]
@@ -364,7 +560,7 @@ unprocessedLoop:
 		for _, req := range unproc {
 			for i, src := range writeReqs {
 				if cmp.Equal(req, src) {
-					batchErr.Failed(i, errors.New("failed to set item"))
+					batchErr.Failed(indexes[i], errors.New("failed to set item"))
 					continue requestsLoop
 				}
 			}
@@ -379,6 +575,80 @@ unprocessedLoop:
 	return err
 }
 
+// putItems writes items individually with PutItem requests, where indexes are
+// the indexes of the messages of the items. Failed items are retried until
+// they're all written or the backoff is exhausted, except for items that fail
+// their condition, which are either rejected or ignored. When items fail the
+// returned error wraps err, or the first failure when err is nil.
+func (d *DynamoDB) putItems(ctx context.Context, boff backoff.BackOff, msg types.Message, indexes []int, puts []*dynamodb.PutItemInput, err error) error {
+	failures := map[int]error{}
+	pending := make([]bool, len(puts))
+	for i := range pending {
+		pending[i] = true
+	}
+
+individualRequestsLoop:
+	for retry := true; retry; {
+		retry = false
+		for i, put := range puts {
+			if !pending[i] {
+				continue
+			}
+			_, iErr := d.client.PutItem(put)
+			if iErr == nil {
+				pending[i] = false
+				delete(failures, indexes[i])
+				continue
+			}
+			if aErr, ok := iErr.(awserr.Error); ok && aErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+				pending[i] = false
+				if d.conf.Condition.IgnoreFailures {
+					d.log.Debugf("Ignoring item that failed its condition: %v\n", iErr)
+					delete(failures, indexes[i])
+				} else {
+					failures[indexes[i]] = iErr
+				}
+				continue
+			}
+			d.log.Errorf("Put error: %v\n", iErr)
+			failures[indexes[i]] = iErr
+			retry = true
+			wait := boff.NextBackOff()
+			if wait == backoff.Stop {
+				break individualRequestsLoop
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				break individualRequestsLoop
+			}
+		}
+	}
+
+	// Items that weren't attempted before giving up are failed.
+	for i, p := range pending {
+		if _, exists := failures[indexes[i]]; p && !exists {
+			failures[indexes[i]] = errors.New("ran out of request retries")
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	if err == nil {
+		for _, i := range indexes {
+			if fErr, exists := failures[i]; exists {
+				err = fErr
+				break
+			}
+		}
+	}
+	batchErr := batchInternal.NewError(msg, err)
+	for i, fErr := range failures {
+		batchErr.Failed(i, fErr)
+	}
+	return batchErr
+}
+
 // CloseAsync begins cleaning up resources used by this writer asynchronously.
 func (d *DynamoDB) CloseAsync() {
 }
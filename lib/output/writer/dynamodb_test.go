@@ -2,13 +2,16 @@ package writer
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/internal/batch"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/stretchr/testify/assert"
@@ -497,3 +500,246 @@ func TestDynamoDBSadBatch(t *testing.T) {
 
 	assert.Equal(t, expected, requests)
 }
+
+func TestDynamoDBConfigErrors(t *testing.T) {
+	conf := NewDynamoDBConfig()
+	conf.StringColumns = map[string]string{"id": `${!json("id")}`}
+	conf.Table = "FooTable"
+
+	conf.TTLMapping = `root = this.expires`
+	_, err := NewDynamoDB(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.TTLKey = "expires_at"
+	conf.TTL = "1h"
+	_, err = NewDynamoDB(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.TTL = ""
+	conf.TTLMapping = `root = `
+	_, err = NewDynamoDB(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.TTLMapping = ""
+	conf.Condition.Expression = `${!json(}`
+	_, err = NewDynamoDB(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Condition.Expression = `attribute_not_exists(id)`
+	conf.Condition.AttributeValuesMapping = `root = `
+	_, err = NewDynamoDB(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestDynamoDBBatchLimit(t *testing.T) {
+	t.Parallel()
+
+	conf := NewDynamoDBConfig()
+	conf.StringColumns = map[string]string{
+		"id": `${!json("id")}`,
+	}
+	conf.Table = "FooTable"
+
+	db, err := NewDynamoDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var requests [][]string
+	db.client = &mockDynamoDB{
+		fn: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			t.Error("not expected")
+			return nil, errors.New("not implemented")
+		},
+		batchFn: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			var ids []string
+			for _, req := range input.RequestItems["FooTable"] {
+				ids = append(ids, *req.PutRequest.Item["id"].S)
+			}
+			requests = append(requests, ids)
+			if len(requests) == 2 {
+				return &dynamodb.BatchWriteItemOutput{
+					UnprocessedItems: map[string][]*dynamodb.WriteRequest{
+						"FooTable": input.RequestItems["FooTable"][:1],
+					},
+				}, nil
+			}
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		},
+	}
+
+	var parts [][]byte
+	for i := 0; i < 30; i++ {
+		parts = append(parts, []byte(fmt.Sprintf(`{"id":"%v"}`, i)))
+	}
+	require.NoError(t, db.Write(message.New(parts)))
+
+	require.Len(t, requests, 3)
+	assert.Len(t, requests[0], 25)
+	assert.Equal(t, "0", requests[0][0])
+	assert.Equal(t, []string{"25", "26", "27", "28", "29"}, requests[1])
+	assert.Equal(t, []string{"25"}, requests[2])
+}
+
+func TestDynamoDBConditions(t *testing.T) {
+	t.Parallel()
+
+	conf := NewDynamoDBConfig()
+	conf.StringColumns = map[string]string{
+		"id": `${!json("id")}`,
+	}
+	conf.Condition.Expression = `${!json("condition").or("")}`
+	conf.Condition.AttributeNames = map[string]string{"#v": "version"}
+	conf.Condition.AttributeValuesMapping = `
+root.":v" = this.version
+root.":vv" = this.version.or(0) + 1
+root.":unused" = "nope"
+`
+	conf.Table = "FooTable"
+
+	db, err := NewDynamoDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	condErr := awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "nope", nil)
+
+	var batchRequests [][]*dynamodb.WriteRequest
+	var requests []*dynamodb.PutItemInput
+	db.client = &mockDynamoDB{
+		fn: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			requests = append(requests, input)
+			if *input.Item["id"].S == "bar" {
+				return nil, condErr
+			}
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		batchFn: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			batchRequests = append(batchRequests, input.RequestItems["FooTable"])
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		},
+	}
+
+	msg := message.New([][]byte{
+		[]byte(`{"id":"foo","condition":"#v < :v OR #v < :vv","version":2}`),
+		[]byte(`{"id":"bar","condition":"#v < :v","version":3}`),
+		[]byte(`{"id":"baz"}`),
+		[]byte(`{"id":"buz","condition":"attribute_not_exists(id)"}`),
+	})
+
+	expErr := batch.NewError(msg, condErr)
+	expErr.Failed(1, condErr)
+	require.Equal(t, expErr, db.Write(msg))
+
+	assert.Equal(t, [][]*dynamodb.WriteRequest{
+		{
+			{
+				PutRequest: &dynamodb.PutRequest{
+					Item: map[string]*dynamodb.AttributeValue{
+						"id": {S: aws.String("baz")},
+					},
+				},
+			},
+		},
+	}, batchRequests)
+
+	assert.Equal(t, []*dynamodb.PutItemInput{
+		{
+			TableName: aws.String("FooTable"),
+			Item: map[string]*dynamodb.AttributeValue{
+				"id": {S: aws.String("foo")},
+			},
+			ConditionExpression:      aws.String("#v < :v OR #v < :vv"),
+			ExpressionAttributeNames: map[string]*string{"#v": aws.String("version")},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":v":  {N: aws.String("2")},
+				":vv": {N: aws.String("3")},
+			},
+		},
+		{
+			TableName: aws.String("FooTable"),
+			Item: map[string]*dynamodb.AttributeValue{
+				"id": {S: aws.String("bar")},
+			},
+			ConditionExpression:      aws.String("#v < :v"),
+			ExpressionAttributeNames: map[string]*string{"#v": aws.String("version")},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":v": {N: aws.String("3")},
+			},
+		},
+		{
+			TableName: aws.String("FooTable"),
+			Item: map[string]*dynamodb.AttributeValue{
+				"id": {S: aws.String("buz")},
+			},
+			ConditionExpression: aws.String("attribute_not_exists(id)"),
+		},
+	}, requests)
+
+	// Items that fail their condition aren't retried, and can be ignored.
+	requests = nil
+	db.conf.Condition.IgnoreFailures = true
+	require.NoError(t, db.Write(msg))
+	assert.Len(t, requests, 3)
+}
+
+func TestDynamoDBTTLMapping(t *testing.T) {
+	t.Parallel()
+
+	conf := NewDynamoDBConfig()
+	conf.StringColumns = map[string]string{
+		"id": `${!json("id")}`,
+	}
+	conf.TTLKey = "expires_at"
+	conf.TTLMapping = `root = this.expires`
+	conf.Table = "FooTable"
+
+	db, err := NewDynamoDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var request []*dynamodb.WriteRequest
+	db.client = &mockDynamoDB{
+		fn: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			t.Error("not expected")
+			return nil, errors.New("not implemented")
+		},
+		batchFn: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			request = input.RequestItems["FooTable"]
+			return &dynamodb.BatchWriteItemOutput{}, nil
+		},
+	}
+
+	msg := message.New([][]byte{
+		[]byte(`{"id":"foo","expires":1600000000}`),
+		[]byte(`{"id":"bar","expires":"2020-09-13T12:26:40Z"}`),
+		[]byte(`{"id":"baz","expires":"nope"}`),
+	})
+	err = db.Write(msg)
+	require.Error(t, err)
+
+	var bErr *batch.Error
+	require.True(t, errors.As(err, &bErr))
+	var failed []int
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{2}, failed)
+
+	assert.Equal(t, []*dynamodb.WriteRequest{
+		{
+			PutRequest: &dynamodb.PutRequest{
+				Item: map[string]*dynamodb.AttributeValue{
+					"id":         {S: aws.String("foo")},
+					"expires_at": {N: aws.String("1600000000")},
+				},
+			},
+		},
+		{
+			PutRequest: &dynamodb.PutRequest{
+				Item: map[string]*dynamodb.AttributeValue{
+					"id":         {S: aws.String("bar")},
+					"expires_at": {N: aws.String("1600000000")},
+				},
+			},
+		},
+	}, request)
+}
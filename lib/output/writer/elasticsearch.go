@@ -41,9 +41,13 @@ type ElasticsearchConfig struct {
 	Sniff          bool                 `json:"sniff" yaml:"sniff"`
 	Healthcheck    bool                 `json:"healthcheck" yaml:"healthcheck"`
 	ID             string               `json:"id" yaml:"id"`
+	Action         string               `json:"action" yaml:"action"`
 	Index          string               `json:"index" yaml:"index"`
 	Pipeline       string               `json:"pipeline" yaml:"pipeline"`
+	Routing        string               `json:"routing" yaml:"routing"`
 	Type           string               `json:"type" yaml:"type"`
+	Upsert         bool                 `json:"upsert" yaml:"upsert"`
+	Script         string               `json:"script" yaml:"script"`
 	Timeout        string               `json:"timeout" yaml:"timeout"`
 	TLS            btls.Config          `json:"tls" yaml:"tls"`
 	Auth           auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
@@ -68,9 +72,13 @@ func NewElasticsearchConfig() ElasticsearchConfig {
 		Sniff:       true,
 		Healthcheck: true,
 		ID:          `${!count("elastic_ids")}-${!timestamp_unix()}`,
+		Action:      "index",
 		Index:       "benthos_index",
 		Pipeline:    "",
+		Routing:     "",
 		Type:        "doc",
+		Upsert:      false,
+		Script:      "",
 		Timeout:     "5s",
 		TLS:         btls.NewConfig(),
 		Auth:        auth.NewBasicAuthConfig(),
@@ -100,10 +108,11 @@ type Elasticsearch struct {
 	timeout     time.Duration
 	tlsConf     *tls.Config
 
-	idStr             field.Expression
-	indexStr          field.Expression
-	pipelineStr       field.Expression
-	interpolatedIndex bool
+	idStr       field.Expression
+	actionStr   field.Expression
+	indexStr    field.Expression
+	pipelineStr field.Expression
+	routingStr  field.Expression
 
 	eJSONErr metrics.StatCounter
 
@@ -125,12 +134,18 @@ func NewElasticsearch(conf ElasticsearchConfig, log log.Modular, stats metrics.T
 	if e.idStr, err = bloblang.NewField(conf.ID); err != nil {
 		return nil, fmt.Errorf("failed to parse id expression: %v", err)
 	}
+	if e.actionStr, err = bloblang.NewField(conf.Action); err != nil {
+		return nil, fmt.Errorf("failed to parse action expression: %v", err)
+	}
 	if e.indexStr, err = bloblang.NewField(conf.Index); err != nil {
 		return nil, fmt.Errorf("failed to parse index expression: %v", err)
 	}
 	if e.pipelineStr, err = bloblang.NewField(conf.Pipeline); err != nil {
 		return nil, fmt.Errorf("failed to parse pipeline expression: %v", err)
 	}
+	if e.routingStr, err = bloblang.NewField(conf.Routing); err != nil {
+		return nil, fmt.Errorf("failed to parse routing expression: %v", err)
+	}
 
 	for _, u := range conf.URLs {
 		for _, splitURL := range strings.Split(u, ",") {
@@ -186,18 +201,13 @@ func (e *Elasticsearch) Connect() error {
 		))
 	}
 
+	httpClient := &http.Client{
+		Timeout: e.timeout,
+	}
 	if e.conf.TLS.Enabled {
-		opts = append(opts, elastic.SetHttpClient(&http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: e.tlsConf,
-			},
-			Timeout: e.timeout,
-		}))
-
-	} else {
-		opts = append(opts, elastic.SetHttpClient(&http.Client{
-			Timeout: e.timeout,
-		}))
+		httpClient.Transport = &http.Transport{
+			TLSClientConfig: e.tlsConf,
+		}
 	}
 
 	if e.conf.AWS.Enabled {
@@ -205,9 +215,9 @@ func (e *Elasticsearch) Connect() error {
 		if err != nil {
 			return err
 		}
-		signingClient := aws.NewV4SigningClient(tsess.Config.Credentials, e.conf.AWS.Region)
-		opts = append(opts, elastic.SetHttpClient(signingClient))
+		httpClient = aws.NewV4SigningClientWithHTTPClient(tsess.Config.Credentials, e.conf.AWS.Region, httpClient)
 	}
+	opts = append(opts, elastic.SetHttpClient(httpClient))
 
 	client, err := elastic.NewClient(opts...)
 	if err != nil {
@@ -219,20 +229,75 @@ func (e *Elasticsearch) Connect() error {
 	return nil
 }
 
-func shouldRetry(s int) bool {
-	if s >= 500 && s <= 599 {
+// shouldRetry returns true for bulk items that failed due to server errors, or
+// that were rejected because the cluster is overloaded.
+func shouldRetry(item *elastic.BulkResponseItem) bool {
+	if item.Status == http.StatusTooManyRequests || (item.Status >= 500 && item.Status <= 599) {
 		return true
 	}
-	return false
+	return item.Error != nil && item.Error.Type == "es_rejected_execution_exception"
+}
+
+func bulkItemFailed(item *elastic.BulkResponseItem) bool {
+	return item.Status > 299 || item.Error != nil
 }
 
 type pendingBulkIndex struct {
+	Action   string
+	ID       string
 	Index    string
 	Pipeline string
+	Routing  string
 	Type     string
 	Doc      interface{}
 }
 
+func (e *Elasticsearch) bulkRequest(p *pendingBulkIndex) (elastic.BulkableRequest, error) {
+	switch p.Action {
+	case "index", "create":
+		r := elastic.NewBulkIndexRequest().
+			Index(p.Index).
+			Pipeline(p.Pipeline).
+			Routing(p.Routing).
+			Type(p.Type).
+			Id(p.ID).
+			Doc(p.Doc)
+		if p.Action == "create" {
+			r = r.OpType("create")
+		}
+		return r, nil
+	case "update":
+		r := elastic.NewBulkUpdateRequest().
+			Index(p.Index).
+			Routing(p.Routing).
+			Type(p.Type).
+			Id(p.ID)
+		if e.conf.Script == "" {
+			r = r.Doc(p.Doc)
+			if e.conf.Upsert {
+				r = r.DocAsUpsert(true)
+			}
+			return r, nil
+		}
+		params, ok := p.Doc.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a JSON object for script params, got %T", p.Doc)
+		}
+		r = r.Script(elastic.NewScriptInline(e.conf.Script).Params(params))
+		if e.conf.Upsert {
+			r = r.ScriptedUpsert(true).Upsert(map[string]interface{}{})
+		}
+		return r, nil
+	case "delete":
+		return elastic.NewBulkDeleteRequest().
+			Index(p.Index).
+			Routing(p.Routing).
+			Type(p.Type).
+			Id(p.ID), nil
+	}
+	return nil, fmt.Errorf("action not recognised: %v", p.Action)
+}
+
 // WriteWithContext will attempt to write a message to Elasticsearch, wait for
 // acknowledgement, and returns an error if applicable.
 func (e *Elasticsearch) WriteWithContext(ctx context.Context, msg types.Message) error {
@@ -248,84 +313,91 @@ func (e *Elasticsearch) Write(msg types.Message) error {
 
 	boff := e.backoffCtor()
 
-	if msg.Len() == 1 {
-		index := e.indexStr.String(0, msg)
-		_, err := e.client.Index().
-			Index(index).
-			Pipeline(e.pipelineStr.String(0, msg)).
-			Type(e.conf.Type).
-			Id(e.idStr.String(0, msg)).
-			BodyString(string(msg.Get(0).Get())).
-			Do(context.Background())
-		if err == nil {
-			// Flush to make sure the document got written.
-			_, err = e.client.Flush().Index(index).Do(context.Background())
-		}
-		return err
-	}
-
-	requests := map[string]*pendingBulkIndex{}
+	var requests []elastic.BulkableRequest
+	var reqErr error
 	msg.Iter(func(i int, part types.Part) error {
-		jObj, ierr := part.JSON()
-		if ierr != nil {
-			e.eJSONErr.Incr(1)
-			e.log.Errorf("Failed to marshal message into JSON document: %v\n", ierr)
-			return nil
-		}
-		requests[e.idStr.String(i, msg)] = &pendingBulkIndex{
+		req := &pendingBulkIndex{
+			Action:   e.actionStr.String(i, msg),
+			ID:       e.idStr.String(i, msg),
 			Index:    e.indexStr.String(i, msg),
 			Pipeline: e.pipelineStr.String(i, msg),
+			Routing:  e.routingStr.String(i, msg),
 			Type:     e.conf.Type,
-			Doc:      jObj,
 		}
+		if req.Action != "delete" {
+			var ierr error
+			if req.Doc, ierr = part.JSON(); ierr != nil {
+				e.eJSONErr.Incr(1)
+				e.log.Errorf("Failed to marshal message into JSON document: %v\n", ierr)
+				return nil
+			}
+		}
+		r, err := e.bulkRequest(req)
+		if err != nil {
+			reqErr = err
+			return err
+		}
+		requests = append(requests, r)
 		return nil
 	})
-
-	b := e.client.Bulk()
-	for k, v := range requests {
-		b.Add(
-			elastic.NewBulkIndexRequest().
-				Index(v.Index).
-				Pipeline(v.Pipeline).
-				Type(v.Type).
-				Id(k).
-				Doc(v.Doc),
-		)
+	if reqErr != nil {
+		return reqErr
 	}
 
-	for b.NumberOfActions() != 0 {
+	for len(requests) > 0 {
+		b := e.client.Bulk().Add(requests...)
+
 		result, err := b.Do(context.Background())
 		if err != nil {
-			return err
-		}
-
-		failed := result.Failed()
-		if len(failed) == 0 {
+			if !elastic.IsStatusCode(err, http.StatusTooManyRequests) {
+				return err
+			}
+			wait := boff.NextBackOff()
+			if wait == backoff.Stop {
+				return err
+			}
+			e.log.Warnf("Elasticsearch bulk request rejected, retrying in %v: %v\n", wait, err)
+			time.Sleep(wait)
 			continue
 		}
 
-		wait := boff.NextBackOff()
-		for i := 0; i < len(failed); i++ {
-			if !shouldRetry(failed[i].Status) {
-				e.log.Errorf("Elasticsearch message '%v' rejected with code [%s]: %v\n", failed[i].Id, failed[i].Status, failed[i].Error.Reason)
-				return fmt.Errorf("failed to send %v parts from message: %v", len(failed), failed[0].Error.Reason)
+		var retryRequests []elastic.BulkableRequest
+		var lastFailed *elastic.BulkResponseItem
+		for i, itemMap := range result.Items {
+			if i >= len(requests) {
+				break
+			}
+			for _, item := range itemMap {
+				if !bulkItemFailed(item) {
+					continue
+				}
+				reason := ""
+				if item.Error != nil {
+					reason = item.Error.Reason
+				}
+				if !shouldRetry(item) {
+					e.log.Errorf("Elasticsearch message '%v' rejected with code [%v]: %v\n", item.Id, item.Status, reason)
+					return fmt.Errorf("failed to send message '%v': %v", item.Id, reason)
+				}
+				e.log.Errorf("Elasticsearch message '%v' failed with code [%v]: %v\n", item.Id, item.Status, reason)
+				retryRequests = append(retryRequests, requests[i])
+				lastFailed = item
 			}
-			e.log.Errorf("Elasticsearch message '%v' failed with code [%s]: %v\n", failed[i].Id, failed[i].Status, failed[i].Error.Reason)
-			id := failed[i].Id
-			req := requests[id]
-			b.Add(
-				elastic.NewBulkIndexRequest().
-					Index(req.Index).
-					Pipeline(req.Pipeline).
-					Type(req.Type).
-					Id(id).
-					Doc(req.Doc),
-			)
 		}
+		if len(retryRequests) == 0 {
+			return nil
+		}
+
+		wait := boff.NextBackOff()
 		if wait == backoff.Stop {
-			return fmt.Errorf("failed to send %v parts from message: %v", len(failed), failed[0].Error.Reason)
+			reason := ""
+			if lastFailed.Error != nil {
+				reason = lastFailed.Error.Reason
+			}
+			return fmt.Errorf("failed to send %v parts from message: %v", len(retryRequests), reason)
 		}
 		time.Sleep(wait)
+		requests = retryRequests
 	}
 
 	return nil
@@ -0,0 +1,224 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBulkServer struct {
+	mut       sync.Mutex
+	requests  [][]map[string]interface{}
+	headers   []http.Header
+	responses []func(actions []map[string]interface{}) (int, interface{})
+}
+
+func (m *mockBulkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/_bulk" {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+		return
+	}
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		lines = append(lines, line)
+	}
+
+	m.mut.Lock()
+	m.requests = append(m.requests, lines)
+	m.headers = append(m.headers, r.Header.Clone())
+	var respFn func(actions []map[string]interface{}) (int, interface{})
+	if len(m.responses) > 0 {
+		respFn, m.responses = m.responses[0], m.responses[1:]
+	}
+	m.mut.Unlock()
+
+	var actions []map[string]interface{}
+	for _, l := range lines {
+		for _, k := range []string{"index", "create", "update", "delete"} {
+			if _, exists := l[k]; exists {
+				actions = append(actions, l)
+			}
+		}
+	}
+
+	status, body := http.StatusOK, interface{}(bulkResponse(actions, nil))
+	if respFn != nil {
+		status, body = respFn(actions)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func bulkResponse(actions []map[string]interface{}, statuses map[int]int) map[string]interface{} {
+	items := []interface{}{}
+	for i, a := range actions {
+		for k, v := range a {
+			status := 200
+			if s, exists := statuses[i]; exists {
+				status = s
+			}
+			item := map[string]interface{}{
+				"_id":    v.(map[string]interface{})["_id"],
+				"status": status,
+			}
+			if status > 299 {
+				item["error"] = map[string]interface{}{"type": "some_error", "reason": "nope"}
+			}
+			items = append(items, map[string]interface{}{k: item})
+		}
+	}
+	return map[string]interface{}{"errors": len(statuses) > 0, "items": items}
+}
+
+func newMockElasticsearch(t *testing.T, m *mockBulkServer, fn func(conf *ElasticsearchConfig)) *Elasticsearch {
+	t.Helper()
+
+	server := httptest.NewServer(m)
+	t.Cleanup(server.Close)
+
+	conf := NewElasticsearchConfig()
+	conf.URLs = []string{server.URL}
+	conf.Sniff = false
+	conf.Healthcheck = false
+	conf.Type = ""
+	conf.Backoff.InitialInterval = "1ms"
+	conf.Backoff.MaxInterval = "1ms"
+	conf.Backoff.MaxElapsedTime = "1s"
+	if fn != nil {
+		fn(&conf)
+	}
+
+	e, err := NewElasticsearch(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, e.Connect())
+	return e
+}
+
+func TestElasticsearchBulkActions(t *testing.T) {
+	m := &mockBulkServer{}
+	e := newMockElasticsearch(t, m, func(conf *ElasticsearchConfig) {
+		conf.ID = `${!json("id")}`
+		conf.Action = `${!meta("action")}`
+		conf.Routing = `${!json("user")}`
+	})
+
+	msg := message.New([][]byte{
+		[]byte(`{"id":"1","user":"foo"}`),
+		[]byte(`{"id":"2","user":"bar"}`),
+		[]byte(`{"id":"3","user":"baz"}`),
+		[]byte(`{"id":"4","user":"qux"}`),
+	})
+	for i, action := range []string{"index", "create", "update", "delete"} {
+		msg.Get(i).Metadata().Set("action", action)
+	}
+	require.NoError(t, e.Write(msg))
+
+	require.Len(t, m.requests, 1)
+	assert.Equal(t, []map[string]interface{}{
+		{"index": map[string]interface{}{"_index": "benthos_index", "_id": "1", "routing": "foo"}},
+		{"id": "1", "user": "foo"},
+		{"create": map[string]interface{}{"_index": "benthos_index", "_id": "2", "routing": "bar"}},
+		{"id": "2", "user": "bar"},
+		{"update": map[string]interface{}{"_index": "benthos_index", "_id": "3", "routing": "baz"}},
+		{"doc": map[string]interface{}{"id": "3", "user": "baz"}},
+		{"delete": map[string]interface{}{"_index": "benthos_index", "_id": "4", "routing": "qux"}},
+	}, m.requests[0])
+}
+
+func TestElasticsearchBulkScriptedUpsert(t *testing.T) {
+	m := &mockBulkServer{}
+	e := newMockElasticsearch(t, m, func(conf *ElasticsearchConfig) {
+		conf.ID = "foo"
+		conf.Action = "update"
+		conf.Upsert = true
+		conf.Script = "ctx._source.count += params.count"
+	})
+
+	require.NoError(t, e.Write(message.New([][]byte{[]byte(`{"count":2}`)})))
+	require.Len(t, m.requests, 1)
+	assert.Equal(t, []map[string]interface{}{
+		{"update": map[string]interface{}{"_index": "benthos_index", "_id": "foo"}},
+		{
+			"script": map[string]interface{}{
+				"source": "ctx._source.count += params.count",
+				"params": map[string]interface{}{"count": float64(2)},
+			},
+			"scripted_upsert": true,
+			"upsert":          map[string]interface{}{},
+		},
+	}, m.requests[0])
+
+	assert.Error(t, e.Write(message.New([][]byte{[]byte(`"not an object"`)})))
+}
+
+func TestElasticsearchBulkRetries(t *testing.T) {
+	m := &mockBulkServer{
+		responses: []func(actions []map[string]interface{}) (int, interface{}){
+			func(actions []map[string]interface{}) (int, interface{}) {
+				return http.StatusTooManyRequests, map[string]interface{}{
+					"error":  map[string]interface{}{"type": "es_rejected_execution_exception", "reason": "busy"},
+					"status": http.StatusTooManyRequests,
+				}
+			},
+			func(actions []map[string]interface{}) (int, interface{}) {
+				return http.StatusOK, bulkResponse(actions, map[int]int{1: 429, 2: 503})
+			},
+		},
+	}
+	e := newMockElasticsearch(t, m, func(conf *ElasticsearchConfig) {
+		conf.ID = `${!json("id")}`
+	})
+
+	require.NoError(t, e.Write(message.New([][]byte{
+		[]byte(`{"id":"1"}`),
+		[]byte(`{"id":"2"}`),
+		[]byte(`{"id":"3"}`),
+	})))
+
+	require.Len(t, m.requests, 3)
+	assert.Len(t, m.requests[0], 6)
+	assert.Len(t, m.requests[1], 6)
+	assert.Equal(t, []map[string]interface{}{
+		{"index": map[string]interface{}{"_index": "benthos_index", "_id": "2"}},
+		{"id": "2"},
+		{"index": map[string]interface{}{"_index": "benthos_index", "_id": "3"}},
+		{"id": "3"},
+	}, m.requests[2])
+}
+
+func TestElasticsearchBulkRejected(t *testing.T) {
+	m := &mockBulkServer{
+		responses: []func(actions []map[string]interface{}) (int, interface{}){
+			func(actions []map[string]interface{}) (int, interface{}) {
+				return http.StatusOK, bulkResponse(actions, map[int]int{0: 400})
+			},
+		},
+	}
+	e := newMockElasticsearch(t, m, nil)
+
+	assert.Error(t, e.Write(message.New([][]byte{[]byte(`{"id":"1"}`)})))
+	assert.Len(t, m.requests, 1)
+
+	e = newMockElasticsearch(t, &mockBulkServer{}, func(conf *ElasticsearchConfig) {
+		conf.Action = "nope"
+	})
+	assert.Error(t, e.Write(message.New([][]byte{[]byte(`{"id":"1"}`)})))
+}
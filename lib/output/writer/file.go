@@ -0,0 +1,391 @@
+package writer
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	batchInternal "github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// FileRotationConfig contains configuration fields for rotating the files of
+// the file output type.
+type FileRotationConfig struct {
+	MaxSize int64  `json:"max_size" yaml:"max_size"`
+	MaxAge  string `json:"max_age" yaml:"max_age"`
+}
+
+// FileConfig contains configuration fields for the file output type.
+type FileConfig struct {
+	Path         string             `json:"path" yaml:"path"`
+	Delim        string             `json:"delimiter" yaml:"delimiter"`
+	MaxOpenFiles int                `json:"max_open_files" yaml:"max_open_files"`
+	Compression  string             `json:"compression" yaml:"compression"`
+	Rotation     FileRotationConfig `json:"rotation" yaml:"rotation"`
+}
+
+// NewFileConfig creates a new FileConfig with default values.
+func NewFileConfig() FileConfig {
+	return FileConfig{
+		Path:         "",
+		Delim:        "",
+		MaxOpenFiles: 16,
+		Compression:  "none",
+		Rotation: FileRotationConfig{
+			MaxSize: 0,
+			MaxAge:  "",
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// countingWriter counts the bytes written to a file, which are also the bytes
+// that the file grows by.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type fileHandle struct {
+	path   string
+	file   *os.File
+	count  *countingWriter
+	gzip   *gzip.Writer
+	w      io.Writer
+	opened time.Time
+	used   time.Time
+}
+
+func (h *fileHandle) flush() error {
+	if h.gzip != nil {
+		return h.gzip.Flush()
+	}
+	return nil
+}
+
+func (h *fileHandle) close() error {
+	var err error
+	if h.gzip != nil {
+		err = h.gzip.Close()
+	}
+	if cerr := h.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// File is a writer type that writes messages as delimited lines to files,
+// where the path of each message is resolved with an interpolated string and
+// the handles of recently used files are kept open.
+type File struct {
+	conf FileConfig
+
+	path   field.Expression
+	delim  []byte
+	maxAge time.Duration
+
+	handlesMut sync.Mutex
+	handles    map[string]*fileHandle
+
+	log   log.Modular
+	stats metrics.Type
+
+	mRotated metrics.StatCounter
+	mClosed  metrics.StatCounter
+}
+
+// NewFile creates a new File writer type.
+func NewFile(conf FileConfig, log log.Modular, stats metrics.Type) (*File, error) {
+	f := &File{
+		conf:     conf,
+		delim:    []byte("\n"),
+		handles:  map[string]*fileHandle{},
+		log:      log,
+		stats:    stats,
+		mRotated: stats.GetCounter("rotated"),
+		mClosed:  stats.GetCounter("handles.closed"),
+	}
+	if conf.Path == "" {
+		return nil, errors.New("a path must be specified")
+	}
+	var err error
+	if f.path, err = bloblang.NewField(conf.Path); err != nil {
+		return nil, fmt.Errorf("failed to parse path expression: %v", err)
+	}
+	if len(conf.Delim) > 0 {
+		f.delim = []byte(conf.Delim)
+	}
+	if conf.MaxOpenFiles < 1 {
+		return nil, errors.New("max_open_files must be at least one")
+	}
+	switch conf.Compression {
+	case "none", "gzip":
+	default:
+		return nil, fmt.Errorf("compression not recognised: %v", conf.Compression)
+	}
+	if conf.Rotation.MaxSize < 0 {
+		return nil, errors.New("rotation max_size must not be negative")
+	}
+	if conf.Rotation.MaxAge != "" {
+		if f.maxAge, err = time.ParseDuration(conf.Rotation.MaxAge); err != nil {
+			return nil, fmt.Errorf("failed to parse rotation max_age: %v", err)
+		}
+	}
+	return f, nil
+}
+
+//------------------------------------------------------------------------------
+
+// rotatedPath returns the path that a file is moved to when it's rotated,
+// which is the path with a timestamp inserted before the extensions of the
+// file name.
+func rotatedPath(path string, t time.Time) string {
+	dir, base := filepath.Split(path)
+	name, ext := base, ""
+	if i := strings.Index(base, "."); i > 0 {
+		name, ext = base[:i], base[i:]
+	}
+	stamp := t.UTC().Format("2006-01-02T15-04-05.000")
+	rotated := filepath.Join(dir, name+"-"+stamp+ext)
+	for n := 1; ; n++ {
+		if _, err := os.Stat(rotated); os.IsNotExist(err) {
+			return rotated
+		}
+		rotated = filepath.Join(dir, fmt.Sprintf("%v-%v-%v%v", name, stamp, n, ext))
+	}
+}
+
+func (f *File) open(path string) (*fileHandle, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, os.FileMode(0777)); err != nil {
+			return nil, err
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.FileMode(0666))
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	now := time.Now()
+	h := &fileHandle{
+		path:   path,
+		file:   file,
+		count:  &countingWriter{w: file, n: info.Size()},
+		opened: now,
+		used:   now,
+	}
+	h.w = h.count
+	if f.conf.Compression == "gzip" {
+		// Appending to an existing gzip file adds a new member to it, which
+		// is decompressed as a continuation of the previous members.
+		h.gzip = gzip.NewWriter(h.count)
+		h.w = h.gzip
+	}
+	return h, nil
+}
+
+// closeHandle closes and forgets the handle of a file, must be called with
+// the handles mutex held.
+func (f *File) closeHandle(h *fileHandle) {
+	delete(f.handles, h.path)
+	if err := h.close(); err != nil {
+		f.log.Errorf("Failed to close file '%v': %v\n", h.path, err)
+	}
+	f.mClosed.Incr(1)
+}
+
+// handle returns an open handle of a file, closing the least recently used
+// handle when the limit of open files is reached, and rotating the file when
+// it has exceeded its size or age. Must be called with the handles mutex held.
+func (f *File) handle(path string) (*fileHandle, error) {
+	h, exists := f.handles[path]
+	if exists && f.shouldRotate(h) {
+		f.closeHandle(h)
+		if err := f.rotate(path); err != nil {
+			return nil, err
+		}
+		exists = false
+	}
+	if exists {
+		h.used = time.Now()
+		return h, nil
+	}
+
+	if len(f.handles) >= f.conf.MaxOpenFiles {
+		var oldest *fileHandle
+		for _, o := range f.handles {
+			if oldest == nil || o.used.Before(oldest.used) {
+				oldest = o
+			}
+		}
+		f.closeHandle(oldest)
+	}
+
+	var err error
+	if h, err = f.open(path); err != nil {
+		return nil, err
+	}
+	// A file that already exceeds its size is rotated before being written to.
+	if f.conf.Rotation.MaxSize > 0 && h.count.n >= f.conf.Rotation.MaxSize {
+		h.close()
+		if err := f.rotate(path); err != nil {
+			return nil, err
+		}
+		if h, err = f.open(path); err != nil {
+			return nil, err
+		}
+	}
+	f.handles[path] = h
+	return h, nil
+}
+
+func (f *File) rotate(path string) error {
+	if err := os.Rename(path, rotatedPath(path, time.Now())); err != nil {
+		return fmt.Errorf("failed to rotate file: %v", err)
+	}
+	f.mRotated.Incr(1)
+	return nil
+}
+
+func (f *File) shouldRotate(h *fileHandle) bool {
+	if f.conf.Rotation.MaxSize > 0 && h.count.n >= f.conf.Rotation.MaxSize {
+		return true
+	}
+	if f.maxAge > 0 && time.Since(h.opened) >= f.maxAge {
+		return true
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as files are opened when they're first written to.
+func (f *File) Connect() error {
+	return f.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as files are opened when they're first
+// written to.
+func (f *File) ConnectWithContext(ctx context.Context) error {
+	f.log.Infof("Writing messages to files: %v\n", f.conf.Path)
+	return nil
+}
+
+// Write attempts to write a message batch to files.
+func (f *File) Write(msg types.Message) error {
+	return f.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext writes the messages of a batch to the files of their
+// paths, where each message is followed by the delimiter, and the messages of
+// a batch larger than one message written to a file are followed by an extra
+// delimiter. Messages that fail to be written to a file are rejected
+// individually.
+func (f *File) WriteWithContext(ctx context.Context, msg types.Message) error {
+	var paths []string
+	indexes := map[string][]int{}
+	msg.Iter(func(i int, _ types.Part) error {
+		path := filepath.Clean(f.path.String(i, msg))
+		if _, exists := indexes[path]; !exists {
+			paths = append(paths, path)
+		}
+		indexes[path] = append(indexes[path], i)
+		return nil
+	})
+
+	f.handlesMut.Lock()
+	defer f.handlesMut.Unlock()
+
+	var batchErr *batchInternal.Error
+	var lastErr error
+	for _, path := range paths {
+		if err := f.writeFile(path, indexes[path], msg); err != nil {
+			lastErr = err
+			if batchErr == nil {
+				batchErr = batchInternal.NewError(msg, err)
+			}
+			for _, i := range indexes[path] {
+				batchErr.Failed(i, err)
+			}
+		}
+	}
+
+	if batchErr != nil {
+		if len(paths) == 1 {
+			return lastErr
+		}
+		return batchErr
+	}
+	return nil
+}
+
+func (f *File) writeFile(path string, indexes []int, msg types.Message) error {
+	h, err := f.handle(path)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range indexes {
+		if _, err = h.w.Write(msg.Get(i).Get()); err == nil {
+			_, err = h.w.Write(f.delim)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == nil && msg.Len() > 1 {
+		_, err = h.w.Write(f.delim)
+	}
+	if err == nil {
+		err = h.flush()
+	}
+	if err != nil {
+		f.closeHandle(h)
+	}
+	return err
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (f *File) CloseAsync() {
+	f.handlesMut.Lock()
+	for _, h := range f.handles {
+		f.closeHandle(h)
+	}
+	f.handlesMut.Unlock()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (f *File) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
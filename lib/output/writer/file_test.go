@@ -0,0 +1,204 @@
+package writer
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestFileDynamicPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_output_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, `${! meta("day") }/${! meta("tenant") }.log`)
+	conf.MaxOpenFiles = 1
+
+	f, err := NewFile(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, f.Connect())
+
+	write := func(day, tenant string, contents ...string) {
+		t.Helper()
+		var parts [][]byte
+		for _, c := range contents {
+			parts = append(parts, []byte(c))
+		}
+		msg := message.New(parts)
+		for i := range parts {
+			msg.Get(i).Metadata().Set("day", day).Set("tenant", tenant)
+		}
+		require.NoError(t, f.Write(msg))
+	}
+
+	write("01", "foo", "first")
+	write("01", "bar", "second", "third")
+	write("02", "foo", "fourth")
+	write("01", "foo", "fifth")
+
+	f.CloseAsync()
+	require.NoError(t, f.WaitForClose(time.Second))
+
+	assert.Equal(t, "first\nfifth\n", readTestFile(t, filepath.Join(dir, "01", "foo.log")))
+	assert.Equal(t, "second\nthird\n\n", readTestFile(t, filepath.Join(dir, "01", "bar.log")))
+	assert.Equal(t, "fourth\n", readTestFile(t, filepath.Join(dir, "02", "foo.log")))
+}
+
+func TestFileBatchAcrossPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_output_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, `${! content() }.txt`)
+	conf.Delim = "|"
+
+	f, err := NewFile(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, f.Write(message.New([][]byte{[]byte("a"), []byte("b"), []byte("a")})))
+	f.CloseAsync()
+
+	assert.Equal(t, "a|a||", readTestFile(t, filepath.Join(dir, "a.txt")))
+	assert.Equal(t, "b||", readTestFile(t, filepath.Join(dir, "b.txt")))
+}
+
+func TestFileRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_output_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, "foo.log")
+	conf.Rotation.MaxSize = 10
+
+	f, err := NewFile(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for _, c := range []string{"hello", "world", "foo", "bar"} {
+		require.NoError(t, f.Write(message.New([][]byte{[]byte(c)})))
+	}
+	f.CloseAsync()
+
+	files, err := filepath.Glob(filepath.Join(dir, "foo-*.log"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "hello\nworld\n", readTestFile(t, files[0]))
+	assert.Equal(t, "foo\nbar\n", readTestFile(t, filepath.Join(dir, "foo.log")))
+
+	// A file that exceeds the size when it's opened is rotated first.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "foo.log"), []byte("0123456789"), 0666))
+	f, err = NewFile(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, f.Write(message.New([][]byte{[]byte("baz")})))
+	f.CloseAsync()
+
+	files, err = filepath.Glob(filepath.Join(dir, "foo-*.log"))
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, "baz\n", readTestFile(t, filepath.Join(dir, "foo.log")))
+}
+
+func TestFileRotationAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_output_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, "foo.log")
+	conf.Rotation.MaxAge = "10ms"
+
+	f, err := NewFile(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, f.Write(message.New([][]byte{[]byte("hello")})))
+	<-time.After(time.Millisecond * 20)
+	require.NoError(t, f.Write(message.New([][]byte{[]byte("world")})))
+	f.CloseAsync()
+
+	files, err := filepath.Glob(filepath.Join(dir, "foo-*.log"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "hello\n", readTestFile(t, files[0]))
+	assert.Equal(t, "world\n", readTestFile(t, filepath.Join(dir, "foo.log")))
+}
+
+func TestFileGzip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_file_output_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	conf := NewFileConfig()
+	conf.Path = filepath.Join(dir, "foo.log.gz")
+	conf.Compression = "gzip"
+
+	for _, c := range []string{"hello", "world"} {
+		f, err := NewFile(conf, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+		require.NoError(t, f.Write(message.New([][]byte{[]byte(c)})))
+		f.CloseAsync()
+	}
+
+	file, err := os.Open(filepath.Join(dir, "foo.log.gz"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	r, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\nworld\n", string(b))
+}
+
+func TestFileRotatedPath(t *testing.T) {
+	ts := time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC)
+	assert.Equal(t, "/tmp/foo-2021-02-03T04-05-06.000.log.gz", rotatedPath("/tmp/foo.log.gz", ts))
+	assert.Equal(t, "/tmp/foo-2021-02-03T04-05-06.000", rotatedPath("/tmp/foo", ts))
+	assert.Equal(t, "/tmp/.foo-2021-02-03T04-05-06.000", rotatedPath("/tmp/.foo", ts))
+}
+
+func TestFileConfigErrors(t *testing.T) {
+	tests := map[string]func(c *FileConfig){
+		"no path": func(c *FileConfig) {
+			c.Path = ""
+		},
+		"bad compression": func(c *FileConfig) {
+			c.Compression = "lz4"
+		},
+		"no open files": func(c *FileConfig) {
+			c.MaxOpenFiles = 0
+		},
+		"bad max age": func(c *FileConfig) {
+			c.Rotation.MaxAge = "nope"
+		},
+	}
+
+	for name, fn := range tests {
+		fn := fn
+		t.Run(name, func(t *testing.T) {
+			conf := NewFileConfig()
+			conf.Path = "/tmp/foo.txt"
+			fn(&conf)
+
+			_, err := NewFile(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
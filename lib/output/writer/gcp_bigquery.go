@@ -0,0 +1,566 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/api/option"
+	gtransport "google.golang.org/api/transport/grpc"
+	storage "google.golang.org/genproto/googleapis/cloud/bigquery/storage/v1alpha2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	descpb "google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+//------------------------------------------------------------------------------
+
+// GCPBigQueryConfig contains configuration fields for the GCP BigQuery output
+// type.
+type GCPBigQueryConfig struct {
+	ProjectID           string             `json:"project" yaml:"project"`
+	Dataset             string             `json:"dataset" yaml:"dataset"`
+	Table               string             `json:"table" yaml:"table"`
+	DeadLetterTable     string             `json:"dead_letter_table" yaml:"dead_letter_table"`
+	IgnoreUnknownFields bool               `json:"ignore_unknown_fields" yaml:"ignore_unknown_fields"`
+	MaxInFlight         int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching            batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewGCPBigQueryConfig creates a new GCPBigQueryConfig with default values.
+func NewGCPBigQueryConfig() GCPBigQueryConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return GCPBigQueryConfig{
+		ProjectID:           "",
+		Dataset:             "",
+		Table:               "",
+		DeadLetterTable:     "",
+		IgnoreUnknownFields: false,
+		MaxInFlight:         1,
+		Batching:            batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// bigQueryMaxAppendBytes is the maximum size of the rows of a single append
+// request, which is kept below the request size limit of the API.
+const bigQueryMaxAppendBytes = 8 * 1024 * 1024
+
+// GCPBigQuery is a writer type that writes messages as rows of a BigQuery
+// table with the Storage Write API.
+type GCPBigQuery struct {
+	conf GCPBigQueryConfig
+
+	tablePath      string
+	deadLetterPath string
+
+	dial     func(ctx context.Context) (*grpc.ClientConn, error)
+	connMut  sync.RWMutex
+	conn     *grpc.ClientConn
+	client   storage.BigQueryWriteClient
+	mDLQSent metrics.StatCounter
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewGCPBigQuery creates a new GCP BigQuery writer type.
+func NewGCPBigQuery(conf GCPBigQueryConfig, log log.Modular, stats metrics.Type) (*GCPBigQuery, error) {
+	if conf.ProjectID == "" || conf.Dataset == "" || conf.Table == "" {
+		return nil, errors.New("a project, dataset and table must be specified")
+	}
+	g := &GCPBigQuery{
+		conf:      conf,
+		tablePath: bigQueryTablePath(conf.ProjectID, conf.Dataset, conf.Table),
+		mDLQSent:  stats.GetCounter("dead_letter.sent"),
+		log:       log,
+		stats:     stats,
+	}
+	if conf.DeadLetterTable != "" {
+		g.deadLetterPath = bigQueryTablePath(conf.ProjectID, conf.Dataset, conf.DeadLetterTable)
+	}
+	g.dial = func(ctx context.Context) (*grpc.ClientConn, error) {
+		return gtransport.Dial(ctx,
+			option.WithEndpoint("bigquerystorage.googleapis.com:443"),
+			option.WithScopes(
+				"https://www.googleapis.com/auth/bigquery",
+				"https://www.googleapis.com/auth/bigquery.insertdata",
+				"https://www.googleapis.com/auth/cloud-platform",
+			),
+		)
+	}
+	return g, nil
+}
+
+func bigQueryTablePath(project, dataset, table string) string {
+	return "projects/" + project + "/datasets/" + dataset + "/tables/" + table
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a connection to the Storage Write API.
+func (g *GCPBigQuery) Connect() error {
+	return g.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext attempts to establish a connection to the Storage Write
+// API.
+func (g *GCPBigQuery) ConnectWithContext(ctx context.Context) error {
+	g.connMut.Lock()
+	defer g.connMut.Unlock()
+
+	if g.conn != nil {
+		return nil
+	}
+
+	conn, err := g.dial(ctx)
+	if err != nil {
+		return err
+	}
+	g.conn = conn
+	g.client = storage.NewBigQueryWriteClient(conn)
+
+	g.log.Infof("Writing rows to BigQuery table: %v\n", g.tablePath)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Write attempts to write a message batch to BigQuery.
+func (g *GCPBigQuery) Write(msg types.Message) error {
+	return g.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext writes the messages of a batch as rows of the table within
+// a pending stream, which is committed once all rows have been appended in
+// order for the batch to be written exactly once. Messages that cannot be
+// converted into rows are written to the dead letter table when configured.
+func (g *GCPBigQuery) WriteWithContext(ctx context.Context, msg types.Message) error {
+	g.connMut.RLock()
+	client := g.client
+	g.connMut.RUnlock()
+
+	if client == nil {
+		return types.ErrNotConnected
+	}
+
+	var deadLetters []map[string]interface{}
+	err := g.writeStream(ctx, client, g.tablePath, func(schema *storage.TableSchema, desc protoreflect.MessageDescriptor) ([][]byte, error) {
+		deadLetters = nil
+		var rows [][]byte
+		err := msg.Iter(func(i int, p types.Part) error {
+			row, err := bigQueryRow(desc, schema.Fields, p.Get(), g.conf.IgnoreUnknownFields)
+			if err == nil {
+				rows = append(rows, row)
+				return nil
+			}
+			if g.deadLetterPath == "" {
+				return fmt.Errorf("failed to convert message %v: %w", i, err)
+			}
+			g.log.Debugf("Writing message %v to dead letter table: %v\n", i, err)
+			deadLetters = append(deadLetters, map[string]interface{}{
+				"content":   string(p.Get()),
+				"error":     err.Error(),
+				"timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+			})
+			return nil
+		})
+		return rows, err
+	})
+	if err != nil || len(deadLetters) == 0 {
+		return err
+	}
+
+	if err = g.writeStream(ctx, client, g.deadLetterPath, func(schema *storage.TableSchema, desc protoreflect.MessageDescriptor) ([][]byte, error) {
+		rows := make([][]byte, len(deadLetters))
+		for i, dl := range deadLetters {
+			b, err := json.Marshal(dl)
+			if err != nil {
+				return nil, err
+			}
+			if rows[i], err = bigQueryRow(desc, schema.Fields, b, true); err != nil {
+				return nil, fmt.Errorf("failed to convert dead letter: %w", err)
+			}
+		}
+		return rows, nil
+	}); err != nil {
+		return fmt.Errorf("failed to write to dead letter table: %w", err)
+	}
+	g.mDLQSent.Incr(int64(len(deadLetters)))
+	return nil
+}
+
+// writeStream creates a pending stream for a table, appends the rows returned
+// by a function from the schema of the table and commits the stream.
+func (g *GCPBigQuery) writeStream(
+	ctx context.Context,
+	client storage.BigQueryWriteClient,
+	tablePath string,
+	rowsFn func(schema *storage.TableSchema, desc protoreflect.MessageDescriptor) ([][]byte, error),
+) error {
+	parentCtx := metadata.AppendToOutgoingContext(ctx, "x-goog-request-params", "parent="+url.QueryEscape(tablePath))
+	stream, err := client.CreateWriteStream(parentCtx, &storage.CreateWriteStreamRequest{
+		Parent: tablePath,
+		WriteStream: &storage.WriteStream{
+			Type: storage.WriteStream_PENDING,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create write stream: %w", err)
+	}
+	if stream.TableSchema == nil {
+		return errors.New("write stream did not contain a table schema")
+	}
+
+	descProto, err := bigQueryDescriptor(stream.TableSchema.Fields)
+	if err != nil {
+		return fmt.Errorf("failed to create row descriptor from table schema: %w", err)
+	}
+	desc, err := bigQueryMessageDescriptor(descProto)
+	if err != nil {
+		return fmt.Errorf("failed to create row descriptor from table schema: %w", err)
+	}
+
+	rows, err := rowsFn(stream.TableSchema, desc)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	streamCtx := metadata.AppendToOutgoingContext(ctx, "x-goog-request-params", "write_stream="+url.QueryEscape(stream.Name))
+	if err = g.appendRows(streamCtx, client, stream.Name, descProto, rows); err != nil {
+		return err
+	}
+	if _, err = client.FinalizeWriteStream(streamCtx, &storage.FinalizeWriteStreamRequest{
+		Name: stream.Name,
+	}); err != nil {
+		return fmt.Errorf("failed to finalize write stream: %w", err)
+	}
+	res, err := client.BatchCommitWriteStreams(parentCtx, &storage.BatchCommitWriteStreamsRequest{
+		Parent:       tablePath,
+		WriteStreams: []string{stream.Name},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit write stream: %w", err)
+	}
+	if res.CommitTime == nil {
+		return errors.New("write stream was not committed")
+	}
+	return nil
+}
+
+// appendRows appends rows to a stream, split into requests that are within
+// the size limit of the API, with the offset of each request set in order for
+// rows to never be appended more than once.
+func (g *GCPBigQuery) appendRows(
+	ctx context.Context,
+	client storage.BigQueryWriteClient,
+	streamName string,
+	descProto *descpb.DescriptorProto,
+	rows [][]byte,
+) error {
+	arc, err := client.AppendRows(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open append stream: %w", err)
+	}
+	defer arc.CloseSend()
+
+	var offset int64
+	for len(rows) > 0 {
+		n, size := 0, 0
+		for n < len(rows) && (n == 0 || size+len(rows[n]) <= bigQueryMaxAppendBytes) {
+			size += len(rows[n])
+			n++
+		}
+
+		req := &storage.AppendRowsRequest{
+			WriteStream: streamName,
+			Offset:      &wrappers.Int64Value{Value: offset},
+			Rows: &storage.AppendRowsRequest_ProtoRows{
+				ProtoRows: &storage.AppendRowsRequest_ProtoData{
+					Rows: &storage.ProtoRows{
+						SerializedRows: rows[:n],
+					},
+				},
+			},
+		}
+		// The schema is only required by the first request of a connection.
+		if offset == 0 {
+			req.GetProtoRows().WriterSchema = &storage.ProtoSchema{
+				ProtoDescriptor: descProto,
+			}
+		}
+		if err = arc.Send(req); err != nil {
+			return fmt.Errorf("failed to append rows: %w", err)
+		}
+		res, err := arc.Recv()
+		if err != nil {
+			return fmt.Errorf("failed to append rows: %w", err)
+		}
+		if resErr := res.GetError(); resErr != nil {
+			return fmt.Errorf("failed to append rows: %v", resErr.GetMessage())
+		}
+
+		offset += int64(n)
+		rows = rows[n:]
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (g *GCPBigQuery) CloseAsync() {
+	g.connMut.Lock()
+	if g.conn != nil {
+		g.conn.Close()
+		g.conn = nil
+		g.client = nil
+	}
+	g.connMut.Unlock()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (g *GCPBigQuery) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// bigQueryDescriptor creates a self contained proto2 descriptor of the rows of
+// a table schema, where struct fields are nested message types.
+func bigQueryDescriptor(fields []*storage.TableFieldSchema) (*descpb.DescriptorProto, error) {
+	return bigQueryMessageProto("Row", fields)
+}
+
+func bigQueryMessageProto(name string, fields []*storage.TableFieldSchema) (*descpb.DescriptorProto, error) {
+	msg := &descpb.DescriptorProto{Name: proto.String(name)}
+	for i, f := range fields {
+		fd := &descpb.FieldDescriptorProto{
+			Name:   proto.String(f.Name),
+			Number: proto.Int32(int32(i + 1)),
+			Label:  descpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+		switch f.Mode {
+		case storage.TableFieldSchema_REQUIRED:
+			fd.Label = descpb.FieldDescriptorProto_LABEL_REQUIRED.Enum()
+		case storage.TableFieldSchema_REPEATED:
+			fd.Label = descpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		}
+
+		switch f.Type {
+		case storage.TableFieldSchema_STRING,
+			storage.TableFieldSchema_GEOGRAPHY,
+			storage.TableFieldSchema_NUMERIC,
+			storage.TableFieldSchema_DATETIME,
+			storage.TableFieldSchema_TIME:
+			fd.Type = descpb.FieldDescriptorProto_TYPE_STRING.Enum()
+		case storage.TableFieldSchema_INT64, storage.TableFieldSchema_TIMESTAMP:
+			fd.Type = descpb.FieldDescriptorProto_TYPE_INT64.Enum()
+		case storage.TableFieldSchema_DATE:
+			fd.Type = descpb.FieldDescriptorProto_TYPE_INT32.Enum()
+		case storage.TableFieldSchema_DOUBLE:
+			fd.Type = descpb.FieldDescriptorProto_TYPE_DOUBLE.Enum()
+		case storage.TableFieldSchema_BOOL:
+			fd.Type = descpb.FieldDescriptorProto_TYPE_BOOL.Enum()
+		case storage.TableFieldSchema_BYTES:
+			fd.Type = descpb.FieldDescriptorProto_TYPE_BYTES.Enum()
+		case storage.TableFieldSchema_STRUCT:
+			nestedName := fmt.Sprintf("Struct%v", i+1)
+			nested, err := bigQueryMessageProto(nestedName, f.Fields)
+			if err != nil {
+				return nil, err
+			}
+			msg.NestedType = append(msg.NestedType, nested)
+			fd.Type = descpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+			fd.TypeName = proto.String(nestedName)
+		default:
+			return nil, fmt.Errorf("field '%v' has unsupported type: %v", f.Name, f.Type)
+		}
+		msg.Field = append(msg.Field, fd)
+	}
+	return msg, nil
+}
+
+func bigQueryMessageDescriptor(descProto *descpb.DescriptorProto) (protoreflect.MessageDescriptor, error) {
+	fd, err := protodesc.NewFile(&descpb.FileDescriptorProto{
+		Name:        proto.String("benthos_bigquery_row.proto"),
+		Syntax:      proto.String("proto2"),
+		MessageType: []*descpb.DescriptorProto{descProto},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return fd.Messages().Get(0), nil
+}
+
+// bigQueryRow parses a JSON object and serializes it as a row of a table.
+func bigQueryRow(desc protoreflect.MessageDescriptor, fields []*storage.TableFieldSchema, b []byte, ignoreUnknown bool) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	msg := dynamicpb.NewMessage(desc)
+	if err := bigQuerySetFields(msg, fields, doc, ignoreUnknown); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(msg)
+}
+
+func bigQuerySetFields(msg protoreflect.Message, fields []*storage.TableFieldSchema, v interface{}, ignoreUnknown bool) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected object, found %T", v)
+	}
+
+	if !ignoreUnknown {
+		for k := range obj {
+			if msg.Descriptor().Fields().ByName(protoreflect.Name(k)) == nil {
+				return fmt.Errorf("field '%v' does not exist within the table schema", k)
+			}
+		}
+	}
+
+	for i, f := range fields {
+		fd := msg.Descriptor().Fields().Get(i)
+		fv := obj[f.Name]
+		if fv == nil {
+			if f.Mode == storage.TableFieldSchema_REQUIRED {
+				return fmt.Errorf("field '%v' is required", f.Name)
+			}
+			continue
+		}
+
+		if f.Mode == storage.TableFieldSchema_REPEATED {
+			arr, ok := fv.([]interface{})
+			if !ok {
+				return fmt.Errorf("field '%v': expected array, found %T", f.Name, fv)
+			}
+			list := msg.Mutable(fd).List()
+			for _, e := range arr {
+				ev, err := bigQueryValue(msg, fd, f, e, ignoreUnknown)
+				if err != nil {
+					return fmt.Errorf("field '%v': %w", f.Name, err)
+				}
+				list.Append(ev)
+			}
+			continue
+		}
+
+		pv, err := bigQueryValue(msg, fd, f, fv, ignoreUnknown)
+		if err != nil {
+			return fmt.Errorf("field '%v': %w", f.Name, err)
+		}
+		msg.Set(fd, pv)
+	}
+	return nil
+}
+
+// bigQueryValue converts a JSON value into the protobuf value of a field.
+func bigQueryValue(
+	msg protoreflect.Message,
+	fd protoreflect.FieldDescriptor,
+	f *storage.TableFieldSchema,
+	v interface{},
+	ignoreUnknown bool,
+) (protoreflect.Value, error) {
+	switch f.Type {
+	case storage.TableFieldSchema_STRUCT:
+		var nested protoreflect.Message
+		if fd.IsList() {
+			nested = msg.Mutable(fd).List().NewElement().Message()
+		} else {
+			nested = msg.NewField(fd).Message()
+		}
+		if err := bigQuerySetFields(nested, f.Fields, v, ignoreUnknown); err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfMessage(nested), nil
+	case storage.TableFieldSchema_INT64:
+		i, err := jsonInt64(v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfInt64(i), nil
+	case storage.TableFieldSchema_DOUBLE:
+		fl, err := jsonFloat64(v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfFloat64(fl), nil
+	case storage.TableFieldSchema_BOOL:
+		switch t := v.(type) {
+		case bool:
+			return protoreflect.ValueOfBool(t), nil
+		case string:
+			b, err := strconv.ParseBool(t)
+			if err != nil {
+				return protoreflect.Value{}, fmt.Errorf("expected boolean, found string: %v", t)
+			}
+			return protoreflect.ValueOfBool(b), nil
+		}
+		return protoreflect.Value{}, fmt.Errorf("expected boolean, found %T", v)
+	case storage.TableFieldSchema_BYTES:
+		s, ok := v.(string)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("expected base64 encoded string, found %T", v)
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfBytes(b), nil
+	case storage.TableFieldSchema_TIMESTAMP:
+		if _, isNum := v.(json.Number); !isNum {
+			if _, isStr := v.(string); !isStr {
+				return protoreflect.Value{}, fmt.Errorf("expected timestamp, found %T", v)
+			}
+		}
+		ts, err := jsonTime(v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfInt64(ts.UnixNano() / 1000), nil
+	case storage.TableFieldSchema_DATE:
+		s, ok := v.(string)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("expected date string, found %T", v)
+		}
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		days := math.Floor(float64(d.Unix()) / (24 * 60 * 60))
+		return protoreflect.ValueOfInt32(int32(days)), nil
+	}
+	s, err := jsonString(v)
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+	return protoreflect.ValueOfString(s), nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,305 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	storage "google.golang.org/genproto/googleapis/cloud/bigquery/storage/v1alpha2"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type mockBigQueryWrite struct {
+	storage.UnimplementedBigQueryWriteServer
+
+	mut       sync.Mutex
+	schemas   map[string]*storage.TableSchema
+	streams   int
+	rows      map[string][]string
+	offsets   map[string][]int64
+	committed map[string][]string
+}
+
+func (m *mockBigQueryWrite) CreateWriteStream(ctx context.Context, req *storage.CreateWriteStreamRequest) (*storage.WriteStream, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	schema, exists := m.schemas[req.Parent]
+	if !exists {
+		return nil, fmt.Errorf("table not found: %v", req.Parent)
+	}
+	m.streams++
+	return &storage.WriteStream{
+		Name:        fmt.Sprintf("%v/streams/%v", req.Parent, m.streams),
+		Type:        req.WriteStream.Type,
+		TableSchema: schema,
+	}, nil
+}
+
+func (m *mockBigQueryWrite) AppendRows(srv storage.BigQueryWrite_AppendRowsServer) error {
+	var desc *dynamicpb.Message
+	for {
+		req, err := srv.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if schema := req.GetProtoRows().GetWriterSchema(); schema != nil {
+			md, err := bigQueryMessageDescriptor(schema.ProtoDescriptor)
+			if err != nil {
+				return err
+			}
+			desc = dynamicpb.NewMessage(md)
+		}
+
+		m.mut.Lock()
+		m.offsets[req.WriteStream] = append(m.offsets[req.WriteStream], req.Offset.GetValue())
+		for _, row := range req.GetProtoRows().GetRows().GetSerializedRows() {
+			msg := desc.New().Interface()
+			if err := proto.Unmarshal(row, msg); err != nil {
+				m.mut.Unlock()
+				return err
+			}
+			b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+			if err != nil {
+				m.mut.Unlock()
+				return err
+			}
+			m.rows[req.WriteStream] = append(m.rows[req.WriteStream], string(b))
+		}
+		m.mut.Unlock()
+
+		if err := srv.Send(&storage.AppendRowsResponse{
+			Response: &storage.AppendRowsResponse_Offset{Offset: req.Offset.GetValue()},
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *mockBigQueryWrite) FinalizeWriteStream(ctx context.Context, req *storage.FinalizeWriteStreamRequest) (*storage.FinalizeWriteStreamResponse, error) {
+	return &storage.FinalizeWriteStreamResponse{}, nil
+}
+
+func (m *mockBigQueryWrite) BatchCommitWriteStreams(ctx context.Context, req *storage.BatchCommitWriteStreamsRequest) (*storage.BatchCommitWriteStreamsResponse, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	for _, s := range req.WriteStreams {
+		m.committed[req.Parent] = append(m.committed[req.Parent], m.rows[s]...)
+	}
+	return &storage.BatchCommitWriteStreamsResponse{CommitTime: ptypes.TimestampNow()}, nil
+}
+
+func newMockBigQuery(t *testing.T, m *mockBigQueryWrite, fn func(conf *GCPBigQueryConfig)) *GCPBigQuery {
+	t.Helper()
+
+	m.rows = map[string][]string{}
+	m.offsets = map[string][]int64{}
+	m.committed = map[string][]string{}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(grpc.MaxRecvMsgSize(16 * 1024 * 1024))
+	storage.RegisterBigQueryWriteServer(srv, m)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conf := NewGCPBigQueryConfig()
+	conf.ProjectID = "foo"
+	conf.Dataset = "bar"
+	conf.Table = "events"
+	if fn != nil {
+		fn(&conf)
+	}
+
+	g, err := NewGCPBigQuery(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	g.dial = func(ctx context.Context) (*grpc.ClientConn, error) {
+		return grpc.DialContext(ctx, lis.Addr().String(), grpc.WithInsecure())
+	}
+	require.NoError(t, g.Connect())
+	t.Cleanup(g.CloseAsync)
+	return g
+}
+
+var bigQueryTestSchema = &storage.TableSchema{
+	Fields: []*storage.TableFieldSchema{
+		{Name: "id", Type: storage.TableFieldSchema_INT64, Mode: storage.TableFieldSchema_REQUIRED},
+		{Name: "name", Type: storage.TableFieldSchema_STRING},
+		{Name: "score", Type: storage.TableFieldSchema_DOUBLE},
+		{Name: "active", Type: storage.TableFieldSchema_BOOL},
+		{Name: "data", Type: storage.TableFieldSchema_BYTES},
+		{Name: "created", Type: storage.TableFieldSchema_TIMESTAMP},
+		{Name: "day", Type: storage.TableFieldSchema_DATE},
+		{Name: "tags", Type: storage.TableFieldSchema_STRING, Mode: storage.TableFieldSchema_REPEATED},
+		{Name: "user", Type: storage.TableFieldSchema_STRUCT, Fields: []*storage.TableFieldSchema{
+			{Name: "first", Type: storage.TableFieldSchema_STRING},
+			{Name: "visits", Type: storage.TableFieldSchema_INT64},
+		}},
+		{Name: "items", Type: storage.TableFieldSchema_STRUCT, Mode: storage.TableFieldSchema_REPEATED, Fields: []*storage.TableFieldSchema{
+			{Name: "sku", Type: storage.TableFieldSchema_STRING},
+		}},
+	},
+}
+
+var bigQueryTestDeadLetterSchema = &storage.TableSchema{
+	Fields: []*storage.TableFieldSchema{
+		{Name: "content", Type: storage.TableFieldSchema_STRING},
+		{Name: "error", Type: storage.TableFieldSchema_STRING},
+		{Name: "timestamp", Type: storage.TableFieldSchema_TIMESTAMP},
+	},
+}
+
+func parseJSONRows(t *testing.T, rows []string) []map[string]interface{} {
+	t.Helper()
+	res := make([]map[string]interface{}, len(rows))
+	for i, r := range rows {
+		require.NoError(t, json.Unmarshal([]byte(r), &res[i]))
+	}
+	return res
+}
+
+func TestGCPBigQueryWrite(t *testing.T) {
+	m := &mockBigQueryWrite{
+		schemas: map[string]*storage.TableSchema{
+			"projects/foo/datasets/bar/tables/events": bigQueryTestSchema,
+		},
+	}
+	g := newMockBigQuery(t, m, nil)
+
+	require.NoError(t, g.Write(message.New([][]byte{
+		[]byte(`{"id":1,"name":"foo","score":"1.5","active":true,"data":"aGVsbG8=","created":"2020-01-01T00:00:01Z","day":"2020-01-02","tags":["a","b"],"user":{"first":"bar","visits":"3"},"items":[{"sku":"x"},{"sku":"y"}]}`),
+		[]byte(`{"id":"2","created":1577836801.5}`),
+	})))
+
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"id":      "1",
+			"name":    "foo",
+			"score":   1.5,
+			"active":  true,
+			"data":    "aGVsbG8=",
+			"created": "1577836801000000",
+			"day":     float64(18263),
+			"tags":    []interface{}{"a", "b"},
+			"user":    map[string]interface{}{"first": "bar", "visits": "3"},
+			"items":   []interface{}{map[string]interface{}{"sku": "x"}, map[string]interface{}{"sku": "y"}},
+		},
+		{
+			"id":      "2",
+			"created": "1577836801500000",
+		},
+	}, parseJSONRows(t, m.committed["projects/foo/datasets/bar/tables/events"]))
+	assert.Equal(t, map[string][]int64{
+		"projects/foo/datasets/bar/tables/events/streams/1": {0},
+	}, m.offsets)
+}
+
+func TestGCPBigQueryConversionErrors(t *testing.T) {
+	m := &mockBigQueryWrite{
+		schemas: map[string]*storage.TableSchema{
+			"projects/foo/datasets/bar/tables/events": bigQueryTestSchema,
+		},
+	}
+	g := newMockBigQuery(t, m, nil)
+
+	for _, doc := range []string{
+		`not json`,
+		`["not an object"]`,
+		`{"name":"missing required id"}`,
+		`{"id":1,"unknown":"field"}`,
+		`{"id":"nope"}`,
+		`{"id":1,"tags":"not an array"}`,
+		`{"id":1,"data":"not base64!"}`,
+		`{"id":1,"day":"yesterday"}`,
+		`{"id":1,"user":"not an object"}`,
+	} {
+		assert.Error(t, g.Write(message.New([][]byte{[]byte(doc)})), doc)
+	}
+	assert.Empty(t, m.committed)
+
+	g = newMockBigQuery(t, m, func(conf *GCPBigQueryConfig) {
+		conf.IgnoreUnknownFields = true
+	})
+	require.NoError(t, g.Write(message.New([][]byte{[]byte(`{"id":1,"unknown":"field"}`)})))
+	assert.Equal(t, []map[string]interface{}{
+		{"id": "1"},
+	}, parseJSONRows(t, m.committed["projects/foo/datasets/bar/tables/events"]))
+}
+
+func TestGCPBigQueryDeadLetters(t *testing.T) {
+	m := &mockBigQueryWrite{
+		schemas: map[string]*storage.TableSchema{
+			"projects/foo/datasets/bar/tables/events":      bigQueryTestSchema,
+			"projects/foo/datasets/bar/tables/dead_events": bigQueryTestDeadLetterSchema,
+		},
+	}
+	g := newMockBigQuery(t, m, func(conf *GCPBigQueryConfig) {
+		conf.DeadLetterTable = "dead_events"
+	})
+
+	require.NoError(t, g.Write(message.New([][]byte{
+		[]byte(`{"id":1}`),
+		[]byte(`{"id":"nope"}`),
+		[]byte(`{"id":3}`),
+	})))
+
+	assert.Equal(t, []map[string]interface{}{
+		{"id": "1"},
+		{"id": "3"},
+	}, parseJSONRows(t, m.committed["projects/foo/datasets/bar/tables/events"]))
+
+	deadLetters := parseJSONRows(t, m.committed["projects/foo/datasets/bar/tables/dead_events"])
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, `{"id":"nope"}`, deadLetters[0]["content"])
+	assert.True(t, strings.HasPrefix(deadLetters[0]["error"].(string), "field 'id'"), deadLetters[0]["error"])
+	assert.NotEmpty(t, deadLetters[0]["timestamp"])
+
+	m.mut.Lock()
+	delete(m.schemas, "projects/foo/datasets/bar/tables/dead_events")
+	m.mut.Unlock()
+	assert.Error(t, g.Write(message.New([][]byte{[]byte(`{"id":"nope"}`)})))
+}
+
+func TestGCPBigQueryAppendSplitting(t *testing.T) {
+	m := &mockBigQueryWrite{
+		schemas: map[string]*storage.TableSchema{
+			"projects/foo/datasets/bar/tables/events": {
+				Fields: []*storage.TableFieldSchema{
+					{Name: "content", Type: storage.TableFieldSchema_STRING},
+				},
+			},
+		},
+	}
+	g := newMockBigQuery(t, m, nil)
+
+	content := strings.Repeat("x", 3*1024*1024)
+	msg := message.New(nil)
+	for i := 0; i < 5; i++ {
+		msg.Append(message.NewPart([]byte(`{"content":"` + content + `"}`)))
+	}
+	require.NoError(t, g.Write(msg))
+
+	assert.Len(t, m.committed["projects/foo/datasets/bar/tables/events"], 5)
+	assert.Equal(t, map[string][]int64{
+		"projects/foo/datasets/bar/tables/events/streams/1": {0, 2, 4},
+	}, m.offsets)
+}
@@ -0,0 +1,192 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// GCPCloudStorageConfig contains configuration fields for the GCP Cloud
+// Storage output type.
+type GCPCloudStorageConfig struct {
+	Bucket          string             `json:"bucket" yaml:"bucket"`
+	Path            string             `json:"path" yaml:"path"`
+	ContentType     string             `json:"content_type" yaml:"content_type"`
+	ContentEncoding string             `json:"content_encoding" yaml:"content_encoding"`
+	ChunkSize       int                `json:"chunk_size" yaml:"chunk_size"`
+	Timeout         string             `json:"timeout" yaml:"timeout"`
+	MaxInFlight     int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching        batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewGCPCloudStorageConfig creates a new Config with default values.
+func NewGCPCloudStorageConfig() GCPCloudStorageConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return GCPCloudStorageConfig{
+		Bucket:          "",
+		Path:            `${!count("files")}-${!timestamp_unix_nano()}.txt`,
+		ContentType:     "application/octet-stream",
+		ContentEncoding: "",
+		ChunkSize:       16 * 1024 * 1024,
+		Timeout:         "5s",
+		MaxInFlight:     1,
+		Batching:        batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GCPCloudStorage is a benthos writer.Type implementation that writes messages
+// to a GCP Cloud Storage bucket.
+type GCPCloudStorage struct {
+	conf GCPCloudStorageConfig
+
+	path            field.Expression
+	contentType     field.Expression
+	contentEncoding field.Expression
+
+	clientMut sync.Mutex
+	client    *storage.Client
+	timeout   time.Duration
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewGCPCloudStorage creates a new GCP Cloud Storage bucket writer.Type.
+func NewGCPCloudStorage(
+	conf GCPCloudStorageConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*GCPCloudStorage, error) {
+	if len(conf.Bucket) == 0 {
+		return nil, errors.New("a bucket must be specified")
+	}
+	var timeout time.Duration
+	if tout := conf.Timeout; len(tout) > 0 {
+		var err error
+		if timeout, err = time.ParseDuration(tout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+		}
+	}
+	g := &GCPCloudStorage{
+		conf:    conf,
+		log:     log,
+		stats:   stats,
+		timeout: timeout,
+	}
+	var err error
+	if g.path, err = bloblang.NewField(conf.Path); err != nil {
+		return nil, fmt.Errorf("failed to parse path expression: %v", err)
+	}
+	if g.contentType, err = bloblang.NewField(conf.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to parse content type expression: %v", err)
+	}
+	if g.contentEncoding, err = bloblang.NewField(conf.ContentEncoding); err != nil {
+		return nil, fmt.Errorf("failed to parse content encoding expression: %v", err)
+	}
+	return g, nil
+}
+
+// ConnectWithContext attempts to establish a connection to the target GCP
+// Cloud Storage bucket.
+func (g *GCPCloudStorage) ConnectWithContext(ctx context.Context) error {
+	return g.Connect()
+}
+
+// Connect attempts to establish a connection to the target GCP Cloud Storage
+// bucket.
+func (g *GCPCloudStorage) Connect() error {
+	g.clientMut.Lock()
+	defer g.clientMut.Unlock()
+
+	if g.client != nil {
+		return nil
+	}
+
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		return err
+	}
+	g.client = client
+
+	g.log.Infof("Uploading message parts as objects to GCP Cloud Storage bucket: %v\n", g.conf.Bucket)
+	return nil
+}
+
+// Write attempts to write message contents to a target GCP Cloud Storage
+// bucket as files.
+func (g *GCPCloudStorage) Write(msg types.Message) error {
+	return g.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext attempts to write message contents to a target GCP Cloud
+// Storage bucket as files.
+func (g *GCPCloudStorage) WriteWithContext(wctx context.Context, msg types.Message) error {
+	g.clientMut.Lock()
+	client := g.client
+	g.clientMut.Unlock()
+
+	if client == nil {
+		return types.ErrNotConnected
+	}
+
+	ctx, cancel := context.WithTimeout(
+		wctx, g.timeout,
+	)
+	defer cancel()
+
+	bucket := client.Bucket(g.conf.Bucket)
+	return IterateBatchedSend(msg, func(i int, p types.Part) error {
+		metadata := map[string]string{}
+		p.Metadata().Iter(func(k, v string) error {
+			metadata[k] = v
+			return nil
+		})
+
+		w := bucket.Object(g.path.String(i, msg)).NewWriter(ctx)
+		w.ChunkSize = g.conf.ChunkSize
+		w.ContentType = g.contentType.String(i, msg)
+		w.ContentEncoding = g.contentEncoding.String(i, msg)
+		w.Metadata = metadata
+
+		if _, err := w.Write(p.Get()); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
+	})
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (g *GCPCloudStorage) CloseAsync() {
+	go func() {
+		g.clientMut.Lock()
+		if g.client != nil {
+			g.client.Close()
+			g.client = nil
+		}
+		g.clientMut.Unlock()
+	}()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (g *GCPCloudStorage) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
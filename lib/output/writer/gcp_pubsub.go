@@ -20,6 +20,7 @@ import (
 type GCPPubSubConfig struct {
 	ProjectID   string `json:"project" yaml:"project"`
 	TopicID     string `json:"topic" yaml:"topic"`
+	OrderingKey string `json:"ordering_key" yaml:"ordering_key"`
 	MaxInFlight int    `json:"max_in_flight" yaml:"max_in_flight"`
 }
 
@@ -28,6 +29,7 @@ func NewGCPPubSubConfig() GCPPubSubConfig {
 	return GCPPubSubConfig{
 		ProjectID:   "",
 		TopicID:     "",
+		OrderingKey: "",
 		MaxInFlight: 1,
 	}
 }
@@ -41,9 +43,10 @@ type GCPPubSub struct {
 
 	client *pubsub.Client
 
-	topicID  field.Expression
-	topics   map[string]*pubsub.Topic
-	topicMut sync.Mutex
+	topicID     field.Expression
+	orderingKey field.Expression
+	topics      map[string]*pubsub.Topic
+	topicMut    sync.Mutex
 
 	log   log.Modular
 	stats metrics.Type
@@ -63,12 +66,19 @@ func NewGCPPubSub(
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse topic expression: %v", err)
 	}
+	var orderingKey field.Expression
+	if len(conf.OrderingKey) > 0 {
+		if orderingKey, err = bloblang.NewField(conf.OrderingKey); err != nil {
+			return nil, fmt.Errorf("failed to parse ordering key expression: %v", err)
+		}
+	}
 	return &GCPPubSub{
-		conf:    conf,
-		log:     log,
-		client:  client,
-		stats:   stats,
-		topicID: topic,
+		conf:        conf,
+		log:         log,
+		client:      client,
+		stats:       stats,
+		topicID:     topic,
+		orderingKey: orderingKey,
 	}, nil
 }
 
@@ -107,6 +117,9 @@ func (c *GCPPubSub) getTopic(ctx context.Context, t string) (*pubsub.Topic, erro
 	if !exists {
 		return nil, fmt.Errorf("topic '%v' does not exist", t)
 	}
+	if c.orderingKey != nil {
+		topic.EnableMessageOrdering = true
+	}
 	c.topics[t] = topic
 	return topic, nil
 }
@@ -130,6 +143,7 @@ func (c *GCPPubSub) WriteWithContext(ctx context.Context, msg types.Message) err
 	}
 
 	results := make([]*pubsub.PublishResult, msg.Len())
+	orderingKeys := make([]string, msg.Len())
 	msg.Iter(func(i int, part types.Part) error {
 		topic := topics[i]
 		attr := map[string]string{}
@@ -143,14 +157,23 @@ func (c *GCPPubSub) WriteWithContext(ctx context.Context, msg types.Message) err
 		if len(attr) > 0 {
 			gmsg.Attributes = attr
 		}
+		if c.orderingKey != nil {
+			orderingKeys[i] = c.orderingKey.String(i, msg)
+			gmsg.OrderingKey = orderingKeys[i]
+		}
 		results[i] = topic.Publish(ctx, gmsg)
 		return nil
 	})
 
 	var errs []error
-	for _, r := range results {
+	for i, r := range results {
 		if _, err := r.Get(ctx); err != nil {
 			errs = append(errs, err)
+			if key := orderingKeys[i]; key != "" {
+				// Publishing is paused for an ordering key after a failure,
+				// and must be resumed in order for the message to be retried.
+				topics[i].ResumePublish(key)
+			}
 		}
 	}
 	if len(errs) > 0 {
@@ -0,0 +1,343 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	batchInternal "github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+//------------------------------------------------------------------------------
+
+// GrafanaLokiConfig contains configuration fields for the Grafana Loki output
+// type.
+type GrafanaLokiConfig struct {
+	URL              string               `json:"url" yaml:"url"`
+	TenantID         string               `json:"tenant_id" yaml:"tenant_id"`
+	LabelsMapping    string               `json:"labels_mapping" yaml:"labels_mapping"`
+	TimestampMapping string               `json:"timestamp_mapping" yaml:"timestamp_mapping"`
+	Headers          map[string]string    `json:"headers" yaml:"headers"`
+	BasicAuth        auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	TLS              btls.Config          `json:"tls" yaml:"tls"`
+	Timeout          string               `json:"timeout" yaml:"timeout"`
+	MaxInFlight      int                  `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching         batch.PolicyConfig   `json:"batching" yaml:"batching"`
+}
+
+// NewGrafanaLokiConfig creates a new GrafanaLokiConfig with default values.
+func NewGrafanaLokiConfig() GrafanaLokiConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return GrafanaLokiConfig{
+		URL:              "",
+		TenantID:         "",
+		LabelsMapping:    "",
+		TimestampMapping: "",
+		Headers:          map[string]string{},
+		BasicAuth:        auth.NewBasicAuthConfig(),
+		TLS:              btls.NewConfig(),
+		Timeout:          "5s",
+		MaxInFlight:      1,
+		Batching:         batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type lokiEntry struct {
+	timestamp time.Time
+	line      []byte
+}
+
+type lokiStream struct {
+	labels  string
+	entries []lokiEntry
+}
+
+// GrafanaLoki is a writer type that pushes messages as log entries to Grafana
+// Loki.
+type GrafanaLoki struct {
+	conf GrafanaLokiConfig
+
+	labelsMapping    *mapping.Executor
+	timestampMapping *mapping.Executor
+
+	httpClient *http.Client
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewGrafanaLoki creates a new Grafana Loki writer type.
+func NewGrafanaLoki(conf GrafanaLokiConfig, log log.Modular, stats metrics.Type) (*GrafanaLoki, error) {
+	g := &GrafanaLoki{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+
+	if conf.URL == "" {
+		return nil, errors.New("a url must be specified")
+	}
+	if conf.LabelsMapping == "" {
+		return nil, errors.New("a labels mapping must be specified")
+	}
+	var err error
+	if g.labelsMapping, err = newMapping("labels mapping", conf.LabelsMapping); err != nil {
+		return nil, err
+	}
+	if conf.TimestampMapping != "" {
+		if g.timestampMapping, err = newMapping("timestamp mapping", conf.TimestampMapping); err != nil {
+			return nil, err
+		}
+	}
+
+	timeout, err := time.ParseDuration(conf.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+	}
+	g.httpClient = &http.Client{Timeout: timeout}
+	if conf.TLS.Enabled {
+		tlsConf, err := conf.TLS.Get()
+		if err != nil {
+			return nil, err
+		}
+		g.httpClient.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConf,
+		}
+	}
+	return g, nil
+}
+
+//------------------------------------------------------------------------------
+
+// entry converts a message of a batch into the labels of a stream, formatted as
+// a label selector, and an entry of it.
+func (g *GrafanaLoki) entry(index int, msg types.Message) (string, lokiEntry, error) {
+	entry := lokiEntry{
+		timestamp: time.Now(),
+		line:      msg.Get(index).Get(),
+	}
+
+	res, err := execMapping(g.labelsMapping, index, msg)
+	if err != nil {
+		return "", entry, fmt.Errorf("failed to execute labels mapping: %w", err)
+	}
+	labelsObj, ok := res.(map[string]interface{})
+	if !ok {
+		return "", entry, fmt.Errorf("labels mapping returned non-object result: %v", query.ITypeOf(res))
+	}
+	labels := make([]promLabel, 0, len(labelsObj))
+	for k, v := range labelsObj {
+		if !promLabelNameRegexp.MatchString(k) || strings.HasPrefix(k, "__") {
+			return "", entry, fmt.Errorf("invalid label name: %q", k)
+		}
+		if v == nil {
+			continue
+		}
+		if str := query.IToString(v); str != "" {
+			labels = append(labels, promLabel{name: k, value: str})
+		}
+	}
+	if len(labels) == 0 {
+		return "", entry, errors.New("labels mapping returned no labels")
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+
+	var selector strings.Builder
+	selector.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			selector.WriteString(", ")
+		}
+		selector.WriteString(l.name)
+		selector.WriteByte('=')
+		selector.WriteString(strconv.Quote(l.value))
+	}
+	selector.WriteByte('}')
+
+	if g.timestampMapping != nil {
+		res, err := execMapping(g.timestampMapping, index, msg)
+		if err != nil {
+			return "", entry, fmt.Errorf("failed to execute timestamp mapping: %w", err)
+		}
+		switch t := res.(type) {
+		case query.Delete, query.Nothing, nil:
+		case string:
+			if entry.timestamp, err = time.Parse(time.RFC3339Nano, t); err != nil {
+				return "", entry, fmt.Errorf("failed to parse timestamp mapping result: %w", err)
+			}
+		default:
+			n, err := query.IGetInt(res)
+			if err != nil {
+				return "", entry, fmt.Errorf("timestamp mapping returned invalid result: %w", err)
+			}
+			entry.timestamp = time.Unix(0, n)
+		}
+	}
+	return selector.String(), entry, nil
+}
+
+// encodePushRequest encodes streams as a PushRequest protobuf message of the
+// Loki push API.
+func encodePushRequest(streams []*lokiStream) []byte {
+	var req []byte
+	for _, s := range streams {
+		var stream []byte
+		stream = protowire.AppendTag(stream, 1, protowire.BytesType)
+		stream = protowire.AppendString(stream, s.labels)
+		for _, e := range s.entries {
+			var ts []byte
+			ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(e.timestamp.Unix()))
+			ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(e.timestamp.Nanosecond()))
+
+			var entry []byte
+			entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+			entry = protowire.AppendBytes(entry, ts)
+			entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+			entry = protowire.AppendBytes(entry, e.line)
+
+			stream = protowire.AppendTag(stream, 2, protowire.BytesType)
+			stream = protowire.AppendBytes(stream, entry)
+		}
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, stream)
+	}
+	return req
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as the Grafana Loki output writes with plain HTTP
+// requests.
+func (g *GrafanaLoki) Connect() error {
+	return g.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as the Grafana Loki output writes with plain
+// HTTP requests.
+func (g *GrafanaLoki) ConnectWithContext(ctx context.Context) error {
+	g.log.Infof("Pushing log entries to Grafana Loki at: %v\n", g.conf.URL)
+	return nil
+}
+
+// Write attempts to write a message batch to Grafana Loki.
+func (g *GrafanaLoki) Write(msg types.Message) error {
+	return g.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext converts the messages of a batch into log entries, grouped
+// by the streams they belong to, and pushes them with a single request.
+// Messages that cannot be converted are rejected individually and the
+// remaining entries are pushed regardless.
+func (g *GrafanaLoki) WriteWithContext(ctx context.Context, msg types.Message) error {
+	var batchErr *batchInternal.Error
+	var streams []*lokiStream
+	var indexes []int
+	streamsByLabels := map[string]*lokiStream{}
+	msg.Iter(func(i int, _ types.Part) error {
+		labels, entry, err := g.entry(i, msg)
+		if err != nil {
+			g.log.Errorf("Failed to convert message into a log entry: %v\n", err)
+			if batchErr == nil {
+				batchErr = batchInternal.NewError(msg, err)
+			}
+			batchErr.Failed(i, err)
+			return nil
+		}
+		s, exists := streamsByLabels[labels]
+		if !exists {
+			s = &lokiStream{labels: labels}
+			streamsByLabels[labels] = s
+			streams = append(streams, s)
+		}
+		s.entries = append(s.entries, entry)
+		indexes = append(indexes, i)
+		return nil
+	})
+
+	if len(indexes) > 0 {
+		for _, s := range streams {
+			sort.SliceStable(s.entries, func(i, j int) bool {
+				return s.entries[i].timestamp.Before(s.entries[j].timestamp)
+			})
+		}
+		if err := g.post(ctx, snappy.Encode(nil, encodePushRequest(streams))); err != nil {
+			if batchErr == nil {
+				return err
+			}
+			for _, i := range indexes {
+				batchErr.Failed(i, err)
+			}
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (g *GrafanaLoki) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequest("POST", g.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range g.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	if g.conf.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", g.conf.TenantID)
+	}
+	if err = g.conf.BasicAuth.Sign(req); err != nil {
+		return err
+	}
+
+	res, err := g.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to push log entries: %v: %s", res.Status, bytes.TrimSpace(resBody))
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (g *GrafanaLoki) CloseAsync() {
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (g *GrafanaLoki) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
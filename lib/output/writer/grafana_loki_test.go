@@ -0,0 +1,193 @@
+package writer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodeLokiPushRequest decodes a PushRequest protobuf message into streams.
+func decodeLokiPushRequest(t *testing.T, b []byte) []*lokiStream {
+	t.Helper()
+
+	fields := func(b []byte, fn func(num protowire.Number, b []byte) int) {
+		for len(b) > 0 {
+			num, _, n := protowire.ConsumeTag(b)
+			require.True(t, n > 0)
+			b = b[n:]
+			n = fn(num, b)
+			require.True(t, n > 0)
+			b = b[n:]
+		}
+	}
+
+	var streams []*lokiStream
+	fields(b, func(_ protowire.Number, b []byte) int {
+		streamBytes, n := protowire.ConsumeBytes(b)
+		s := &lokiStream{}
+		fields(streamBytes, func(num protowire.Number, b []byte) int {
+			v, n := protowire.ConsumeBytes(b)
+			if num == 1 {
+				s.labels = string(v)
+				return n
+			}
+			var e lokiEntry
+			fields(v, func(num protowire.Number, b []byte) int {
+				v, n := protowire.ConsumeBytes(b)
+				if num == 2 {
+					e.line = v
+					return n
+				}
+				var secs, nanos uint64
+				fields(v, func(num protowire.Number, b []byte) int {
+					v, n := protowire.ConsumeVarint(b)
+					if num == 1 {
+						secs = v
+					} else {
+						nanos = v
+					}
+					return n
+				})
+				e.timestamp = time.Unix(int64(secs), int64(nanos))
+				return n
+			})
+			s.entries = append(s.entries, e)
+			return n
+		})
+		streams = append(streams, s)
+		return n
+	})
+	return streams
+}
+
+func TestGrafanaLokiConfigErrors(t *testing.T) {
+	tests := map[string]func(c *GrafanaLokiConfig){
+		"no url": func(c *GrafanaLokiConfig) {
+			c.URL = ""
+		},
+		"no labels mapping": func(c *GrafanaLokiConfig) {
+			c.LabelsMapping = ""
+		},
+		"bad timestamp mapping": func(c *GrafanaLokiConfig) {
+			c.TimestampMapping = "root = "
+		},
+		"bad timeout": func(c *GrafanaLokiConfig) {
+			c.Timeout = "nope"
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewGrafanaLokiConfig()
+			conf.URL = "http://localhost:3100/loki/api/v1/push"
+			conf.LabelsMapping = `root = { "app": "foo" }`
+			test(&conf)
+
+			_, err := NewGrafanaLoki(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGrafanaLokiPush(t *testing.T) {
+	var reqs []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		reqs = append(reqs, r)
+		bodies = append(bodies, b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	conf := NewGrafanaLokiConfig()
+	conf.URL = server.URL + "/loki/api/v1/push"
+	conf.TenantID = "foo"
+	conf.LabelsMapping = `root = { "app": meta("app"), "level": this.level }`
+	conf.TimestampMapping = `root = this.ts`
+	conf.Headers = map[string]string{"X-Foo": "bar"}
+
+	g, err := NewGrafanaLoki(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, g.Connect())
+
+	msg := message.New([][]byte{
+		[]byte(`{"level":"info","ts":"2020-09-13T12:26:41Z"}`),
+		[]byte(`{"level":"error","ts":1600000000500000000}`),
+		[]byte(`{"level":"info","ts":"2020-09-13T12:26:40Z"}`),
+		[]byte(`{"level":""}`),
+		[]byte(`not json`),
+	})
+	for i := 0; i < msg.Len(); i++ {
+		msg.Get(i).Metadata().Set("app", `say "hi"`)
+	}
+	msg.Get(3).Metadata().Set("app", "")
+
+	err = g.Write(msg)
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok)
+	var failed []int
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{3, 4}, failed)
+
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "/loki/api/v1/push", reqs[0].URL.Path)
+	assert.Equal(t, "application/x-protobuf", reqs[0].Header.Get("Content-Type"))
+	assert.Equal(t, "foo", reqs[0].Header.Get("X-Scope-OrgID"))
+	assert.Equal(t, "bar", reqs[0].Header.Get("X-Foo"))
+
+	body, err := snappy.Decode(nil, bodies[0])
+	require.NoError(t, err)
+
+	streams := decodeLokiPushRequest(t, body)
+	require.Len(t, streams, 2)
+
+	assert.Equal(t, `{app="say \"hi\"", level="info"}`, streams[0].labels)
+	require.Len(t, streams[0].entries, 2)
+	assert.Equal(t, `{"level":"info","ts":"2020-09-13T12:26:40Z"}`, string(streams[0].entries[0].line))
+	assert.Equal(t, int64(1600000000), streams[0].entries[0].timestamp.Unix())
+	assert.Equal(t, `{"level":"info","ts":"2020-09-13T12:26:41Z"}`, string(streams[0].entries[1].line))
+	assert.Equal(t, int64(1600000001), streams[0].entries[1].timestamp.Unix())
+
+	assert.Equal(t, `{app="say \"hi\"", level="error"}`, streams[1].labels)
+	require.Len(t, streams[1].entries, 1)
+	assert.Equal(t, int64(1600000000500000000), streams[1].entries[0].timestamp.UnixNano())
+}
+
+func TestGrafanaLokiPushError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "entry out of order", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	conf := NewGrafanaLokiConfig()
+	conf.URL = server.URL
+	conf.LabelsMapping = `root = { "app": "foo" }`
+
+	g, err := NewGrafanaLoki(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	err = g.Write(message.New([][]byte{[]byte(`foo`)}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400 Bad Request: entry out of order")
+}
@@ -0,0 +1,249 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/grpcconf"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+//------------------------------------------------------------------------------
+
+// GRPCClientConfig contains configuration fields for the GRPCClient output
+// type.
+type GRPCClientConfig struct {
+	Address       string            `json:"address" yaml:"address"`
+	Method        string            `json:"method" yaml:"method"`
+	DescriptorSet string            `json:"descriptor_set" yaml:"descriptor_set"`
+	Metadata      map[string]string `json:"metadata" yaml:"metadata"`
+	Timeout       string            `json:"timeout" yaml:"timeout"`
+	MaxInFlight   int               `json:"max_in_flight" yaml:"max_in_flight"`
+	TLS           btls.Config       `json:"tls" yaml:"tls"`
+}
+
+// NewGRPCClientConfig creates a new GRPCClientConfig with default values.
+func NewGRPCClientConfig() GRPCClientConfig {
+	return GRPCClientConfig{
+		Address:       "localhost:50051",
+		Method:        "",
+		DescriptorSet: "",
+		Metadata:      map[string]string{},
+		Timeout:       "5s",
+		MaxInFlight:   1,
+		TLS:           btls.NewConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type grpcClientMetadata struct {
+	key   string
+	value field.Expression
+}
+
+// GRPCClient is an output type that calls a gRPC method with messages.
+type GRPCClient struct {
+	conf       GRPCClientConfig
+	fullMethod string
+	method     *desc.MethodDescriptor
+	metadata   []grpcClientMetadata
+	timeout    time.Duration
+	creds      credentials.TransportCredentials
+
+	connMut sync.RWMutex
+	conn    *grpc.ClientConn
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewGRPCClient creates a new GRPCClient writer type.
+func NewGRPCClient(conf GRPCClientConfig, log log.Modular, stats metrics.Type) (*GRPCClient, error) {
+	g := &GRPCClient{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+
+	var err error
+	if _, _, g.fullMethod, err = grpcconf.ParseMethod(conf.Method); err != nil {
+		return nil, err
+	}
+	if g.method, err = grpcconf.LoadMethod(conf.DescriptorSet, conf.Method); err != nil {
+		return nil, err
+	}
+	if g.method.IsServerStreaming() {
+		return nil, fmt.Errorf("method '%v' is server streaming, which is not supported", conf.Method)
+	}
+
+	keys := make([]string, 0, len(conf.Metadata))
+	for k := range conf.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value, err := bloblang.NewField(conf.Metadata[k])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse metadata '%v' expression: %v", k, err)
+		}
+		g.metadata = append(g.metadata, grpcClientMetadata{key: k, value: value})
+	}
+
+	if g.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %v", err)
+	}
+	if conf.TLS.Enabled {
+		tlsConf, err := conf.TLS.Get()
+		if err != nil {
+			return nil, err
+		}
+		g.creds = credentials.NewTLS(tlsConf)
+	}
+	return g, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a connection to a gRPC server.
+func (g *GRPCClient) Connect() error {
+	return g.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext establishes a connection to a gRPC server.
+func (g *GRPCClient) ConnectWithContext(ctx context.Context) error {
+	g.connMut.Lock()
+	defer g.connMut.Unlock()
+
+	if g.conn != nil {
+		return nil
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(grpcconf.Codec{})),
+	}
+	if g.creds != nil {
+		opts = append(opts, grpc.WithTransportCredentials(g.creds))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	conn, err := grpc.DialContext(ctx, g.conf.Address, opts...)
+	if err != nil {
+		return err
+	}
+	g.conn = conn
+
+	g.log.Infof("Calling gRPC method %v at address: %v\n", g.fullMethod, g.conf.Address)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// encode converts the JSON document of a message part into a request.
+func (g *GRPCClient) encode(p types.Part) ([]byte, error) {
+	msg := dynamic.NewMessage(g.method.GetInputType())
+	if err := msg.UnmarshalJSON(p.Get()); err != nil {
+		return nil, fmt.Errorf("failed to convert message to %v: %w", g.method.GetInputType().GetFullyQualifiedName(), err)
+	}
+	return msg.Marshal()
+}
+
+// callContext returns a context for a call containing the metadata of a
+// message part.
+func (g *GRPCClient) callContext(ctx context.Context, index int, msg types.Message) (context.Context, context.CancelFunc) {
+	if len(g.metadata) > 0 {
+		pairs := make([]string, 0, len(g.metadata)*2)
+		for _, m := range g.metadata {
+			pairs = append(pairs, m.key, m.value.String(index, msg))
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+	}
+	return context.WithTimeout(ctx, g.timeout)
+}
+
+// Write attempts to write a message by calling the gRPC method.
+func (g *GRPCClient) Write(msg types.Message) error {
+	return g.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext attempts to write a message by calling the gRPC method,
+// where unary methods are called once for each message of a batch and client
+// streaming methods are called once with a stream of each message of a batch.
+func (g *GRPCClient) WriteWithContext(ctx context.Context, msg types.Message) error {
+	g.connMut.RLock()
+	conn := g.conn
+	g.connMut.RUnlock()
+
+	if conn == nil {
+		return types.ErrNotConnected
+	}
+
+	if !g.method.IsClientStreaming() {
+		return IterateBatchedSend(msg, func(i int, p types.Part) error {
+			req, err := g.encode(p)
+			if err != nil {
+				return err
+			}
+
+			callCtx, done := g.callContext(ctx, i, msg)
+			defer done()
+
+			var res []byte
+			return conn.Invoke(callCtx, g.fullMethod, &req, &res)
+		})
+	}
+
+	callCtx, done := g.callContext(ctx, 0, msg)
+	defer done()
+
+	stream, err := conn.NewStream(callCtx, &grpc.StreamDesc{ClientStreams: true}, g.fullMethod)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < msg.Len(); i++ {
+		req, err := g.encode(msg.Get(i))
+		if err != nil {
+			return err
+		}
+		if err = stream.SendMsg(&req); err != nil {
+			break
+		}
+	}
+	if err = stream.CloseSend(); err != nil {
+		return err
+	}
+	var res []byte
+	return stream.RecvMsg(&res)
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (g *GRPCClient) CloseAsync() {
+	g.connMut.Lock()
+	if g.conn != nil {
+		g.conn.Close()
+		g.conn = nil
+	}
+	g.connMut.Unlock()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (g *GRPCClient) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,196 @@
+package writer
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/grpcconf"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func writeGRPCClientTestDescriptorSet(t *testing.T, dir string) (string, *desc.MessageDescriptor) {
+	t.Helper()
+
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{
+			"ingest.proto": `
+syntax = "proto3";
+package benthos.test;
+message Event { string id = 1; int64 count = 2; }
+message Ack {}
+service Ingest {
+  rpc Send(Event) returns (Ack);
+  rpc SendStream(stream Event) returns (Ack);
+  rpc Subscribe(Event) returns (stream Event);
+}`,
+		}),
+	}
+	files, err := parser.ParseFiles("ingest.proto")
+	require.NoError(t, err)
+
+	setBytes, err := proto.Marshal(desc.ToFileDescriptorSet(files...))
+	require.NoError(t, err)
+	setPath := filepath.Join(dir, "ingest.protoset")
+	require.NoError(t, ioutil.WriteFile(setPath, setBytes, 0644))
+	return setPath, files[0].FindMessage("benthos.test.Event")
+}
+
+type grpcClientTestCall struct {
+	method   string
+	token    string
+	requests []string
+}
+
+// startGRPCClientTestServer serves the methods of the test service, recording
+// the requests of each call as JSON documents.
+func startGRPCClientTestServer(t *testing.T, event *desc.MessageDescriptor) (string, func() []grpcClientTestCall, func()) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var callsMut sync.Mutex
+	var calls []grpcClientTestCall
+
+	handler := func(_ interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+		call := grpcClientTestCall{method: method}
+		if md, ok := metadata.FromIncomingContext(stream.Context()); ok && len(md.Get("token")) > 0 {
+			call.token = md.Get("token")[0]
+		}
+		for {
+			var frame []byte
+			if err := stream.RecvMsg(&frame); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return err
+			}
+			msg := dynamic.NewMessage(event)
+			if err := msg.Unmarshal(frame); err != nil {
+				return err
+			}
+			jBytes, err := msg.MarshalJSON()
+			if err != nil {
+				return err
+			}
+			call.requests = append(call.requests, string(jBytes))
+		}
+
+		callsMut.Lock()
+		calls = append(calls, call)
+		callsMut.Unlock()
+
+		res := []byte{}
+		return stream.SendMsg(&res)
+	}
+
+	server := grpc.NewServer(grpc.CustomCodec(grpcconf.Codec{}))
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "benthos.test.Ingest",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{
+			{StreamName: "Send", Handler: handler},
+			{StreamName: "SendStream", Handler: handler, ClientStreams: true},
+		},
+	}, struct{}{})
+	go server.Serve(listener)
+
+	return listener.Addr().String(), func() []grpcClientTestCall {
+		callsMut.Lock()
+		defer callsMut.Unlock()
+		return calls
+	}, server.Stop
+}
+
+func TestGRPCClientConfigErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_grpc_client_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setPath, _ := writeGRPCClientTestDescriptorSet(t, dir)
+
+	conf := NewGRPCClientConfig()
+	conf.Method = "/benthos.test.Ingest/Send"
+	_, err = NewGRPCClient(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.DescriptorSet = setPath
+	conf.Method = "/benthos.test.Ingest/Subscribe"
+	_, err = NewGRPCClient(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Method = "/benthos.test.Ingest/Send"
+	conf.Metadata = map[string]string{"token": "${!nope()}"}
+	_, err = NewGRPCClient(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestGRPCClientWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_grpc_client_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	setPath, event := writeGRPCClientTestDescriptorSet(t, dir)
+	addr, getCalls, stop := startGRPCClientTestServer(t, event)
+	defer stop()
+
+	for _, method := range []string{"/benthos.test.Ingest/Send", "/benthos.test.Ingest/SendStream"} {
+		conf := NewGRPCClientConfig()
+		conf.Address = addr
+		conf.Method = method
+		conf.DescriptorSet = setPath
+		conf.Metadata = map[string]string{"token": `${!meta("token")}`}
+
+		g, err := NewGRPCClient(conf, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+		require.NoError(t, g.Connect())
+
+		msg := message.New([][]byte{
+			[]byte(`{"id":"foo","count":2}`),
+			[]byte(`{"id":"bar"}`),
+		})
+		msg.Get(0).Metadata().Set("token", "first")
+		msg.Get(1).Metadata().Set("token", "second")
+		require.NoError(t, g.Write(msg))
+
+		assert.Error(t, g.Write(message.New([][]byte{[]byte(`{"nope":true}`)})))
+
+		g.CloseAsync()
+		require.NoError(t, g.WaitForClose(0))
+	}
+
+	calls := getCalls()
+	require.Len(t, calls, 3)
+
+	assert.Equal(t, "/benthos.test.Ingest/Send", calls[0].method)
+	assert.Equal(t, "first", calls[0].token)
+	assert.Len(t, calls[0].requests, 1)
+	assert.JSONEq(t, `{"id":"foo","count":"2"}`, calls[0].requests[0])
+
+	assert.Equal(t, "/benthos.test.Ingest/Send", calls[1].method)
+	assert.Equal(t, "second", calls[1].token)
+	assert.Len(t, calls[1].requests, 1)
+	assert.JSONEq(t, `{"id":"bar"}`, calls[1].requests[0])
+
+	assert.Equal(t, "/benthos.test.Ingest/SendStream", calls[2].method)
+	assert.Equal(t, "first", calls[2].token)
+	require.Len(t, calls[2].requests, 2)
+	assert.JSONEq(t, `{"id":"foo","count":"2"}`, calls[2].requests[0])
+	assert.JSONEq(t, `{"id":"bar"}`, calls[2].requests[1])
+}
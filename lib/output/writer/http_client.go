@@ -108,7 +108,7 @@ func (h *HTTPClient) WriteWithContext(ctx context.Context, msg types.Message) er
 				parts[i] = msgCopy.Get(0)
 			}
 			parts[i].Set(p.Get())
-			if h.conf.CopyResponseHeaders {
+			if h.conf.CopyResponseHeaders || len(h.conf.ExtractHeaders.IncludePrefixes) > 0 || len(h.conf.ExtractHeaders.IncludePatterns) > 0 {
 				p.Metadata().Iter(func(k, v string) error {
 					parts[i].Metadata().Set(k, v)
 					return nil
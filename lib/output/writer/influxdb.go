@@ -0,0 +1,439 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	batchInternal "github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+// InfluxDBConfig contains configuration fields for the InfluxDB output type.
+type InfluxDBConfig struct {
+	URL              string             `json:"url" yaml:"url"`
+	APIVersion       string             `json:"api_version" yaml:"api_version"`
+	Token            string             `json:"token" yaml:"token"`
+	Org              string             `json:"org" yaml:"org"`
+	Bucket           string             `json:"bucket" yaml:"bucket"`
+	DB               string             `json:"db" yaml:"db"`
+	RetentionPolicy  string             `json:"retention_policy" yaml:"retention_policy"`
+	Username         string             `json:"username" yaml:"username"`
+	Password         string             `json:"password" yaml:"password"`
+	Measurement      string             `json:"measurement" yaml:"measurement"`
+	TagsMapping      string             `json:"tags_mapping" yaml:"tags_mapping"`
+	FieldsMapping    string             `json:"fields_mapping" yaml:"fields_mapping"`
+	IntegerFields    []string           `json:"integer_fields" yaml:"integer_fields"`
+	TimestampMapping string             `json:"timestamp_mapping" yaml:"timestamp_mapping"`
+	Precision        string             `json:"precision" yaml:"precision"`
+	TLS              btls.Config        `json:"tls" yaml:"tls"`
+	Timeout          string             `json:"timeout" yaml:"timeout"`
+	MaxInFlight      int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching         batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewInfluxDBConfig creates a new InfluxDBConfig with default values.
+func NewInfluxDBConfig() InfluxDBConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return InfluxDBConfig{
+		URL:              "http://localhost:8086",
+		APIVersion:       "v2",
+		Token:            "",
+		Org:              "",
+		Bucket:           "",
+		DB:               "",
+		RetentionPolicy:  "",
+		Username:         "",
+		Password:         "",
+		Measurement:      "",
+		TagsMapping:      "",
+		FieldsMapping:    "root = this",
+		IntegerFields:    []string{},
+		TimestampMapping: "",
+		Precision:        "ns",
+		TLS:              btls.NewConfig(),
+		Timeout:          "5s",
+		MaxInFlight:      1,
+		Batching:         batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// influxDBPrecisions maps the supported precisions to their durations and the
+// names of the precisions within the v1 API.
+var influxDBPrecisions = map[string]struct {
+	unit time.Duration
+	v1   string
+}{
+	"ns": {time.Nanosecond, "n"},
+	"us": {time.Microsecond, "u"},
+	"ms": {time.Millisecond, "ms"},
+	"s":  {time.Second, "s"},
+}
+
+// InfluxDB is a writer type that converts messages into points of the InfluxDB
+// line protocol and writes them to an InfluxDB server.
+type InfluxDB struct {
+	conf InfluxDBConfig
+
+	writeURL  string
+	unit      time.Duration
+	intFields map[string]struct{}
+
+	measurement      field.Expression
+	tagsMapping      *mapping.Executor
+	fieldsMapping    *mapping.Executor
+	timestampMapping *mapping.Executor
+
+	httpClient *http.Client
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewInfluxDB creates a new InfluxDB writer type.
+func NewInfluxDB(conf InfluxDBConfig, log log.Modular, stats metrics.Type) (*InfluxDB, error) {
+	i := &InfluxDB{
+		conf:      conf,
+		intFields: map[string]struct{}{},
+		log:       log,
+		stats:     stats,
+	}
+
+	precision, exists := influxDBPrecisions[conf.Precision]
+	if !exists {
+		return nil, fmt.Errorf("precision not recognised: %v", conf.Precision)
+	}
+	i.unit = precision.unit
+
+	baseURL, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %v", err)
+	}
+	query := url.Values{}
+	switch conf.APIVersion {
+	case "v1":
+		if conf.DB == "" {
+			return nil, errors.New("a db must be specified for the v1 API")
+		}
+		baseURL.Path = strings.TrimSuffix(baseURL.Path, "/") + "/write"
+		query.Set("db", conf.DB)
+		if conf.RetentionPolicy != "" {
+			query.Set("rp", conf.RetentionPolicy)
+		}
+		query.Set("precision", precision.v1)
+	case "v2":
+		if conf.Org == "" || conf.Bucket == "" {
+			return nil, errors.New("an org and bucket must be specified for the v2 API")
+		}
+		baseURL.Path = strings.TrimSuffix(baseURL.Path, "/") + "/api/v2/write"
+		query.Set("org", conf.Org)
+		query.Set("bucket", conf.Bucket)
+		query.Set("precision", conf.Precision)
+	default:
+		return nil, fmt.Errorf("api version not recognised: %v", conf.APIVersion)
+	}
+	baseURL.RawQuery = query.Encode()
+	i.writeURL = baseURL.String()
+
+	if conf.Measurement == "" {
+		return nil, errors.New("a measurement must be specified")
+	}
+	if i.measurement, err = bloblang.NewField(conf.Measurement); err != nil {
+		return nil, fmt.Errorf("failed to parse measurement expression: %v", err)
+	}
+	if conf.TagsMapping != "" {
+		if i.tagsMapping, err = newMapping("tags mapping", conf.TagsMapping); err != nil {
+			return nil, err
+		}
+	}
+	if conf.FieldsMapping == "" {
+		return nil, errors.New("a fields mapping must be specified")
+	}
+	if i.fieldsMapping, err = newMapping("fields mapping", conf.FieldsMapping); err != nil {
+		return nil, err
+	}
+	if conf.TimestampMapping != "" {
+		if i.timestampMapping, err = newMapping("timestamp mapping", conf.TimestampMapping); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range conf.IntegerFields {
+		i.intFields[f] = struct{}{}
+	}
+
+	timeout, err := time.ParseDuration(conf.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+	}
+	i.httpClient = &http.Client{Timeout: timeout}
+	if conf.TLS.Enabled {
+		tlsConf, err := conf.TLS.Get()
+		if err != nil {
+			return nil, err
+		}
+		i.httpClient.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConf,
+		}
+	}
+	return i, nil
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	influxDBMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	influxDBKeyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+	influxDBStringEscaper      = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+// fieldValue formats a field value of the line protocol, where numbers
+// are written as floats unless they are integers or belong to an integer
+// field.
+func (i *InfluxDB) fieldValue(key string, v interface{}) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return `"` + influxDBStringEscaper.Replace(t) + `"`, nil
+	case []byte:
+		return `"` + influxDBStringEscaper.Replace(string(t)) + `"`, nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case int64:
+		return strconv.FormatInt(t, 10) + "i", nil
+	case uint64:
+		return strconv.FormatUint(t, 10) + "i", nil
+	}
+	f, err := query.IGetNumber(v)
+	if err != nil {
+		return "", fmt.Errorf("field '%v' has unsupported value type: %v", key, query.ITypeOf(v))
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("field '%v' has non-finite value: %v", key, f)
+	}
+	if _, isInt := i.intFields[key]; isInt {
+		return strconv.FormatInt(int64(f), 10) + "i", nil
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
+// line converts a message of a batch into a point of the line protocol.
+func (i *InfluxDB) line(index int, msg types.Message) (string, error) {
+	measurement := i.measurement.String(index, msg)
+	if measurement == "" {
+		return "", errors.New("measurement resolved to an empty string")
+	}
+
+	var buf strings.Builder
+	buf.WriteString(influxDBMeasurementEscaper.Replace(measurement))
+
+	if i.tagsMapping != nil {
+		res, err := execMapping(i.tagsMapping, index, msg)
+		if err != nil {
+			return "", fmt.Errorf("failed to execute tags mapping: %w", err)
+		}
+		var tags map[string]interface{}
+		switch t := res.(type) {
+		case query.Delete, query.Nothing, nil:
+		case map[string]interface{}:
+			tags = t
+		default:
+			return "", fmt.Errorf("tags mapping returned non-object result: %v", query.ITypeOf(res))
+		}
+		keys := make([]string, 0, len(tags))
+		for k := range tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := query.IToString(tags[k])
+			if v == "" {
+				continue
+			}
+			buf.WriteByte(',')
+			buf.WriteString(influxDBKeyEscaper.Replace(k))
+			buf.WriteByte('=')
+			buf.WriteString(influxDBKeyEscaper.Replace(v))
+		}
+	}
+
+	res, err := execMapping(i.fieldsMapping, index, msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute fields mapping: %w", err)
+	}
+	fields, ok := res.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("fields mapping returned non-object result: %v", query.ITypeOf(res))
+	}
+	keys := make([]string, 0, len(fields))
+	for k, v := range fields {
+		if v != nil {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return "", errors.New("fields mapping returned no fields")
+	}
+	sort.Strings(keys)
+	for j, k := range keys {
+		v, err := i.fieldValue(k, fields[k])
+		if err != nil {
+			return "", err
+		}
+		if j == 0 {
+			buf.WriteByte(' ')
+		} else {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(influxDBKeyEscaper.Replace(k))
+		buf.WriteByte('=')
+		buf.WriteString(v)
+	}
+
+	if i.timestampMapping != nil {
+		res, err := execMapping(i.timestampMapping, index, msg)
+		if err != nil {
+			return "", fmt.Errorf("failed to execute timestamp mapping: %w", err)
+		}
+		switch t := res.(type) {
+		case query.Delete, query.Nothing, nil:
+		case string:
+			ts, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return "", fmt.Errorf("failed to parse timestamp mapping result: %w", err)
+			}
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.FormatInt(ts.UnixNano()/int64(i.unit), 10))
+		default:
+			n, err := query.IGetInt(res)
+			if err != nil {
+				return "", fmt.Errorf("timestamp mapping returned invalid result: %w", err)
+			}
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.FormatInt(n, 10))
+		}
+	}
+	return buf.String(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as the InfluxDB output writes with plain HTTP requests.
+func (i *InfluxDB) Connect() error {
+	return i.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as the InfluxDB output writes with plain
+// HTTP requests.
+func (i *InfluxDB) ConnectWithContext(ctx context.Context) error {
+	i.log.Infof("Writing points to InfluxDB at: %v\n", i.conf.URL)
+	return nil
+}
+
+// Write attempts to write a message batch to InfluxDB.
+func (i *InfluxDB) Write(msg types.Message) error {
+	return i.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext converts the messages of a batch into points and writes
+// them with a single request. Messages that cannot be converted are rejected
+// individually and the remaining points are written regardless.
+func (i *InfluxDB) WriteWithContext(ctx context.Context, msg types.Message) error {
+	var batchErr *batchInternal.Error
+	var body bytes.Buffer
+	var indexes []int
+	msg.Iter(func(j int, p types.Part) error {
+		line, err := i.line(j, msg)
+		if err != nil {
+			i.log.Errorf("Failed to convert message into a point: %v\n", err)
+			if batchErr == nil {
+				batchErr = batchInternal.NewError(msg, err)
+			}
+			batchErr.Failed(j, err)
+			return nil
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+		indexes = append(indexes, j)
+		return nil
+	})
+
+	if len(indexes) > 0 {
+		if err := i.post(ctx, body.Bytes()); err != nil {
+			if batchErr == nil {
+				return err
+			}
+			for _, j := range indexes {
+				batchErr.Failed(j, err)
+			}
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (i *InfluxDB) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequest("POST", i.writeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	switch i.conf.APIVersion {
+	case "v1":
+		if i.conf.Username != "" {
+			req.SetBasicAuth(i.conf.Username, i.conf.Password)
+		}
+	case "v2":
+		if i.conf.Token != "" {
+			req.Header.Set("Authorization", "Token "+i.conf.Token)
+		}
+	}
+
+	res, err := i.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to write points: %v: %s", res.Status, bytes.TrimSpace(resBody))
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (i *InfluxDB) CloseAsync() {
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (i *InfluxDB) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
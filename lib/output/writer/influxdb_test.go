@@ -0,0 +1,198 @@
+package writer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfluxDBConfigErrors(t *testing.T) {
+	tests := map[string]func(c *InfluxDBConfig){
+		"bad api version": func(c *InfluxDBConfig) {
+			c.APIVersion = "v3"
+		},
+		"no bucket": func(c *InfluxDBConfig) {
+			c.Bucket = ""
+		},
+		"no v1 db": func(c *InfluxDBConfig) {
+			c.APIVersion = "v1"
+		},
+		"bad precision": func(c *InfluxDBConfig) {
+			c.Precision = "m"
+		},
+		"no measurement": func(c *InfluxDBConfig) {
+			c.Measurement = ""
+		},
+		"bad fields mapping": func(c *InfluxDBConfig) {
+			c.FieldsMapping = "root = {"
+		},
+		"bad timeout": func(c *InfluxDBConfig) {
+			c.Timeout = "nope"
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewInfluxDBConfig()
+			conf.Org = "foo"
+			conf.Bucket = "bar"
+			conf.Measurement = "cpu"
+			test(&conf)
+
+			_, err := NewInfluxDB(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestInfluxDBLines(t *testing.T) {
+	conf := NewInfluxDBConfig()
+	conf.Org = "foo"
+	conf.Bucket = "bar"
+	conf.Measurement = `${! json("name") }`
+	conf.TagsMapping = `root = this.tags`
+	conf.FieldsMapping = `root = this.fields`
+	conf.IntegerFields = []string{"count"}
+	conf.TimestampMapping = `root = this.ts`
+	conf.Precision = "s"
+
+	i, err := NewInfluxDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		input    string
+		expected string
+		err      string
+	}{
+		"all types": {
+			input:    `{"name":"cpu load","tags":{"host":"a,b","region":"eu=1","empty":""},"fields":{"value":0.5,"count":3,"up":true,"msg":"say \"hi\""},"ts":1600000000}`,
+			expected: `cpu\ load,host=a\,b,region=eu\=1 count=3i,msg="say \"hi\"",up=true,value=0.5 1600000000`,
+		},
+		"string timestamp": {
+			input:    `{"name":"cpu","fields":{"value":1},"ts":"2020-09-13T12:26:40Z"}`,
+			expected: `cpu value=1 1600000000`,
+		},
+		"no timestamp": {
+			input:    `{"name":"cpu","tags":{},"fields":{"value":1}}`,
+			expected: `cpu value=1`,
+		},
+		"no fields": {
+			input: `{"name":"cpu","fields":{}}`,
+			err:   "fields mapping returned no fields",
+		},
+		"nested field": {
+			input: `{"name":"cpu","fields":{"value":{"a":1}}}`,
+			err:   "field 'value' has unsupported value type: object",
+		},
+		"no measurement": {
+			input: `{"name":"","fields":{"value":1}}`,
+			err:   "measurement resolved to an empty string",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			line, err := i.line(0, message.New([][]byte{[]byte(test.input)}))
+			if test.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, line)
+		})
+	}
+}
+
+func TestInfluxDBWrite(t *testing.T) {
+	var reqs []*http.Request
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		reqs = append(reqs, r)
+		bodies = append(bodies, string(b))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	conf := NewInfluxDBConfig()
+	conf.URL = server.URL
+	conf.Org = "foo"
+	conf.Bucket = "bar"
+	conf.Token = "baz"
+	conf.Measurement = "cpu"
+
+	i, err := NewInfluxDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, i.Connect())
+
+	err = i.Write(message.New([][]byte{
+		[]byte(`{"value":1}`),
+		[]byte(`not json`),
+		[]byte(`{"value":2.5}`),
+	}))
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok)
+	var failed []int
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1}, failed)
+
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "/api/v2/write", reqs[0].URL.Path)
+	assert.Equal(t, "bucket=bar&org=foo&precision=ns", reqs[0].URL.RawQuery)
+	assert.Equal(t, "Token baz", reqs[0].Header.Get("Authorization"))
+	assert.Equal(t, "cpu value=1\ncpu value=2.5\n", bodies[0])
+}
+
+func TestInfluxDBWriteV1(t *testing.T) {
+	var req *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"partial write"}`))
+	}))
+	defer server.Close()
+
+	conf := NewInfluxDBConfig()
+	conf.URL = server.URL + "/influx/"
+	conf.APIVersion = "v1"
+	conf.DB = "foo"
+	conf.RetentionPolicy = "bar"
+	conf.Username = "user"
+	conf.Password = "pass"
+	conf.Precision = "us"
+	conf.Measurement = "cpu"
+
+	i, err := NewInfluxDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	err = i.Write(message.New([][]byte{[]byte(`{"value":1}`)}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `400 Bad Request: {"error":"partial write"}`)
+
+	require.NotNil(t, req)
+	assert.Equal(t, "/influx/write", req.URL.Path)
+	assert.Equal(t, "db=foo&precision=u&rp=bar", req.URL.RawQuery)
+	user, pass, ok := req.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", user)
+	assert.Equal(t, "pass", pass)
+}
@@ -0,0 +1,140 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// The following functions convert values parsed from JSON documents, where
+// numbers are parsed as json.Number, into the types of columns of databases.
+
+func jsonInt64(v interface{}) (int64, error) {
+	switch t := v.(type) {
+	case nil:
+		return 0, nil
+	case bool:
+		if t {
+			return 1, nil
+		}
+		return 0, nil
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return 0, err
+		}
+		return jsonFloatToInt64(f)
+	case string:
+		if i, err := strconv.ParseInt(t, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected integer, found string: %v", t)
+		}
+		return jsonFloatToInt64(f)
+	}
+	return 0, fmt.Errorf("expected integer, found %T", v)
+}
+
+func jsonFloatToInt64(f float64) (int64, error) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, fmt.Errorf("expected integer, found number: %v", f)
+	}
+	return int64(f), nil
+}
+
+func jsonUint64(v interface{}) (uint64, error) {
+	if s, ok := v.(json.Number); ok {
+		if u, err := strconv.ParseUint(s.String(), 10, 64); err == nil {
+			return u, nil
+		}
+	}
+	if s, ok := v.(string); ok {
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u, nil
+		}
+	}
+	i, err := jsonInt64(v)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("expected unsigned integer, found: %v", i)
+	}
+	return uint64(i), nil
+}
+
+func jsonFloat64(v interface{}) (float64, error) {
+	switch t := v.(type) {
+	case nil:
+		return 0, nil
+	case bool:
+		if t {
+			return 1, nil
+		}
+		return 0, nil
+	case json.Number:
+		return t.Float64()
+	case string:
+		f, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected number, found string: %v", t)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("expected number, found %T", v)
+}
+
+var jsonTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func jsonTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case nil:
+		return time.Unix(0, 0), nil
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	case string:
+		for _, layout := range jsonTimeLayouts {
+			if ts, err := time.Parse(layout, t); err == nil {
+				return ts, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("failed to parse timestamp: %v", t)
+	}
+	return time.Time{}, fmt.Errorf("expected timestamp, found %T", v)
+}
+
+func jsonString(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	case json.Number:
+		return t.String(), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+//------------------------------------------------------------------------------
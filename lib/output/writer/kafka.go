@@ -3,7 +3,9 @@ package writer
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -27,27 +29,38 @@ import (
 
 // KafkaConfig contains configuration fields for the Kafka output type.
 type KafkaConfig struct {
-	Addresses      []string    `json:"addresses" yaml:"addresses"`
-	ClientID       string      `json:"client_id" yaml:"client_id"`
-	Key            string      `json:"key" yaml:"key"`
-	Partitioner    string      `json:"partitioner" yaml:"partitioner"`
-	Topic          string      `json:"topic" yaml:"topic"`
-	Compression    string      `json:"compression" yaml:"compression"`
-	MaxMsgBytes    int         `json:"max_msg_bytes" yaml:"max_msg_bytes"`
-	Timeout        string      `json:"timeout" yaml:"timeout"`
-	AckReplicas    bool        `json:"ack_replicas" yaml:"ack_replicas"`
-	TargetVersion  string      `json:"target_version" yaml:"target_version"`
-	TLS            btls.Config `json:"tls" yaml:"tls"`
-	SASL           sasl.Config `json:"sasl" yaml:"sasl"`
-	MaxInFlight    int         `json:"max_in_flight" yaml:"max_in_flight"`
-	retries.Config `json:",inline" yaml:",inline"`
-	Batching       batch.PolicyConfig `json:"batching" yaml:"batching"`
-	StaticHeaders  map[string]string  `json:"static_headers" yaml:"static_headers"`
+	Addresses       []string    `json:"addresses" yaml:"addresses"`
+	ClientID        string      `json:"client_id" yaml:"client_id"`
+	Key             string      `json:"key" yaml:"key"`
+	Partitioner     string      `json:"partitioner" yaml:"partitioner"`
+	Topic           string      `json:"topic" yaml:"topic"`
+	Compression     string      `json:"compression" yaml:"compression"`
+	MaxMsgBytes     int         `json:"max_msg_bytes" yaml:"max_msg_bytes"`
+	Timeout         string      `json:"timeout" yaml:"timeout"`
+	AckReplicas     bool        `json:"ack_replicas" yaml:"ack_replicas"`
+	IdempotentWrite bool        `json:"idempotent_write" yaml:"idempotent_write"`
+	TransactionalID string      `json:"transactional_id" yaml:"transactional_id"`
+	CommitInterval  string      `json:"transaction_commit_interval" yaml:"transaction_commit_interval"`
+	TargetVersion   string      `json:"target_version" yaml:"target_version"`
+	TLS             btls.Config `json:"tls" yaml:"tls"`
+	SASL            sasl.Config `json:"sasl" yaml:"sasl"`
+	MaxInFlight     int         `json:"max_in_flight" yaml:"max_in_flight"`
+	retries.Config  `json:",inline" yaml:",inline"`
+	Batching        batch.PolicyConfig  `json:"batching" yaml:"batching"`
+	StaticHeaders   map[string]string   `json:"static_headers" yaml:"static_headers"`
+	Metadata        KafkaMetadataConfig `json:"metadata" yaml:"metadata"`
 
 	// TODO: V4 remove this.
 	RoundRobinPartitions bool `json:"round_robin_partitions" yaml:"round_robin_partitions"`
 }
 
+// KafkaMetadataConfig contains configuration fields for controlling which
+// metadata fields of a message are sent as record headers.
+type KafkaMetadataConfig struct {
+	IncludePatterns []string `json:"include_patterns" yaml:"include_patterns"`
+	ExcludePatterns []string `json:"exclude_patterns" yaml:"exclude_patterns"`
+}
+
 // NewKafkaConfig creates a new KafkaConfig with default values.
 func NewKafkaConfig() KafkaConfig {
 	rConf := retries.NewConfig()
@@ -67,13 +80,20 @@ func NewKafkaConfig() KafkaConfig {
 		MaxMsgBytes:          1000000,
 		Timeout:              "5s",
 		AckReplicas:          false,
+		IdempotentWrite:      false,
+		TransactionalID:      "",
+		CommitInterval:       "",
 		TargetVersion:        sarama.V1_0_0_0.String(),
 		StaticHeaders:        map[string]string{},
-		TLS:                  btls.NewConfig(),
-		SASL:                 sasl.NewConfig(),
-		MaxInFlight:          1,
-		Config:               rConf,
-		Batching:             batching,
+		Metadata: KafkaMetadataConfig{
+			IncludePatterns: []string{},
+			ExcludePatterns: []string{},
+		},
+		TLS:         btls.NewConfig(),
+		SASL:        sasl.NewConfig(),
+		MaxInFlight: 1,
+		Config:      rConf,
+		Batching:    batching,
 	}
 }
 
@@ -103,11 +123,26 @@ type Kafka struct {
 	compression sarama.CompressionCodec
 	partitioner sarama.PartitionerConstructor
 
-	staticHeaders map[string]string
+	staticHeaders  map[string]string
+	includeHeaders []*regexp.Regexp
+	excludeHeaders []*regexp.Regexp
+
+	commitInterval time.Duration
+	txnMut         sync.Mutex
+	txn            *kafkaTxn
 
 	connMut sync.RWMutex
 }
 
+// kafkaTxn is an open transaction that batches are written within, done is
+// closed once the transaction is either committed or aborted, after which err
+// is safe to read.
+type kafkaTxn struct {
+	producer sarama.SyncProducer
+	done     chan struct{}
+	err      error
+}
+
 // NewKafka creates a new Kafka writer type.
 func NewKafka(conf KafkaConfig, mgr types.Manager, log log.Modular, stats metrics.Type) (*Kafka, error) {
 	compression, err := strToCompressionCodec(conf.Compression)
@@ -136,6 +171,13 @@ func NewKafka(conf KafkaConfig, mgr types.Manager, log log.Modular, stats metric
 		staticHeaders: conf.StaticHeaders,
 	}
 
+	if k.includeHeaders, err = compileMetadataPatterns(conf.Metadata.IncludePatterns); err != nil {
+		return nil, fmt.Errorf("failed to compile metadata include pattern: %v", err)
+	}
+	if k.excludeHeaders, err = compileMetadataPatterns(conf.Metadata.ExcludePatterns); err != nil {
+		return nil, fmt.Errorf("failed to compile metadata exclude pattern: %v", err)
+	}
+
 	if k.key, err = bloblang.NewField(conf.Key); err != nil {
 		return nil, fmt.Errorf("failed to parse key expression: %v", err)
 	}
@@ -163,6 +205,23 @@ func NewKafka(conf KafkaConfig, mgr types.Manager, log log.Modular, stats metric
 	if k.version, err = sarama.ParseKafkaVersion(conf.TargetVersion); err != nil {
 		return nil, err
 	}
+	if conf.IdempotentWrite && !k.version.IsAtLeast(sarama.V0_11_0_0) {
+		return nil, fmt.Errorf("idempotent writes require a target_version of at least %v", sarama.V0_11_0_0)
+	}
+	if conf.TransactionalID != "" && !k.version.IsAtLeast(sarama.V0_11_0_0) {
+		return nil, fmt.Errorf("transactional writes require a target_version of at least %v", sarama.V0_11_0_0)
+	}
+	if conf.CommitInterval != "" {
+		if conf.TransactionalID == "" {
+			return nil, errors.New("a transaction_commit_interval requires a transactional_id")
+		}
+		if k.commitInterval, err = time.ParseDuration(conf.CommitInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse transaction_commit_interval: %v", err)
+		}
+		if k.commitInterval >= kafkaTxnTimeout {
+			return nil, fmt.Errorf("transaction_commit_interval must be less than the transaction timeout of %v", kafkaTxnTimeout)
+		}
+	}
 
 	for _, addr := range conf.Addresses {
 		for _, splitAddr := range strings.Split(addr, ",") {
@@ -175,6 +234,10 @@ func NewKafka(conf KafkaConfig, mgr types.Manager, log log.Modular, stats metric
 	return &k, nil
 }
 
+// kafkaTxnTimeout is how long the brokers allow a transaction to remain open
+// before aborting it.
+const kafkaTxnTimeout = time.Minute
+
 //------------------------------------------------------------------------------
 
 func strToCompressionCodec(str string) (sarama.CompressionCodec, error) {
@@ -213,11 +276,41 @@ func strToPartitioner(str string) (sarama.PartitionerConstructor, error) {
 
 //------------------------------------------------------------------------------
 
-func buildSystemHeaders(version sarama.KafkaVersion, part types.Part) []sarama.RecordHeader {
+func compileMetadataPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func matchesAnyPattern(patterns []*regexp.Regexp, str string) bool {
+	for _, re := range patterns {
+		if re.MatchString(str) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildSystemHeaders converts the metadata of a message part into record
+// headers. When include patterns are provided only keys matching at least one
+// of them are added, and keys matching any exclude pattern are always omitted.
+func buildSystemHeaders(version sarama.KafkaVersion, part types.Part, include, exclude []*regexp.Regexp) []sarama.RecordHeader {
 	if version.IsAtLeast(sarama.V0_11_0_0) {
 		out := []sarama.RecordHeader{}
 		meta := part.Metadata()
 		meta.Iter(func(k, v string) error {
+			if len(include) > 0 && !matchesAnyPattern(include, k) {
+				return nil
+			}
+			if matchesAnyPattern(exclude, k) {
+				return nil
+			}
 			out = append(out, sarama.RecordHeader{
 				Key:   []byte(k),
 				Value: []byte(v),
@@ -292,6 +385,22 @@ func (k *Kafka) Connect() error {
 		config.Producer.RequiredAcks = sarama.WaitForLocal
 	}
 
+	if k.conf.IdempotentWrite {
+		// Idempotence requires acknowledgements from all in sync replicas and
+		// a single open request per broker in order to preserve ordering.
+		config.Producer.Idempotent = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Net.MaxOpenRequests = 1
+	}
+
+	if k.conf.TransactionalID != "" {
+		config.Producer.Idempotent = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Producer.Transaction.ID = k.conf.TransactionalID
+		config.Producer.Transaction.Timeout = kafkaTxnTimeout
+		config.Net.MaxOpenRequests = 1
+	}
+
 	var err error
 	k.producer, err = sarama.NewSyncProducer(k.addresses, config)
 
@@ -328,7 +437,7 @@ func (k *Kafka) Write(msg types.Message) error {
 		nextMsg := &sarama.ProducerMessage{
 			Topic:    k.topic.String(i, msg),
 			Value:    sarama.ByteEncoder(p.Get()),
-			Headers:  append(buildSystemHeaders(version, p), userDefinedHeaders...),
+			Headers:  append(buildSystemHeaders(version, p, k.includeHeaders, k.excludeHeaders), userDefinedHeaders...),
 			Metadata: i, // Store the original index for later reference.
 		}
 		if len(key) > 0 {
@@ -338,6 +447,10 @@ func (k *Kafka) Write(msg types.Message) error {
 		return nil
 	})
 
+	if producer.IsTransactional() {
+		return k.writeTxn(producer, msgs)
+	}
+
 	err := producer.SendMessages(msgs)
 	for err != nil {
 		if pErrs, ok := err.(sarama.ProducerErrors); ok {
@@ -385,6 +498,87 @@ func (k *Kafka) Write(msg types.Message) error {
 	return nil
 }
 
+// writeTxn sends messages within the open transaction of the producer,
+// beginning one if necessary, and blocks until that transaction is resolved.
+// Failed sends abort the transaction, and therefore also fail any other batches
+// that were sent within it.
+func (k *Kafka) writeTxn(producer sarama.SyncProducer, msgs []*sarama.ProducerMessage) error {
+	k.txnMut.Lock()
+	txn := k.txn
+	if txn == nil || txn.producer != producer {
+		if err := producer.BeginTxn(); err != nil {
+			k.txnMut.Unlock()
+			k.log.Errorf("Failed to begin transaction: %v\n", err)
+			k.resetTxnProducer(producer)
+			return err
+		}
+		txn = &kafkaTxn{
+			producer: producer,
+			done:     make(chan struct{}),
+		}
+		k.txn = txn
+		if k.commitInterval > 0 {
+			time.AfterFunc(k.commitInterval, func() {
+				k.txnMut.Lock()
+				k.endTxnLocked(txn, nil)
+				k.txnMut.Unlock()
+			})
+		}
+	}
+
+	err := producer.SendMessages(msgs)
+	if err != nil || k.commitInterval <= 0 {
+		k.endTxnLocked(txn, err)
+	}
+	k.txnMut.Unlock()
+
+	<-txn.done
+	return txn.err
+}
+
+// endTxnLocked commits a transaction, or aborts it when sendErr is not nil,
+// unless it has already been resolved. Must be called whilst holding txnMut.
+func (k *Kafka) endTxnLocked(txn *kafkaTxn, sendErr error) {
+	if k.txn != txn {
+		return
+	}
+	k.txn = nil
+
+	txn.err = sendErr
+	if sendErr == nil {
+		if txn.err = txn.producer.CommitTxn(); txn.err != nil {
+			k.log.Errorf("Failed to commit transaction: %v\n", txn.err)
+		}
+	} else {
+		k.log.Errorf("Failed to send messages, aborting transaction: %v\n", sendErr)
+	}
+	if txn.err != nil {
+		if err := txn.producer.AbortTxn(); err != nil {
+			k.log.Errorf("Failed to abort transaction: %v\n", err)
+		}
+	}
+	close(txn.done)
+
+	if txn.err != nil {
+		go k.resetTxnProducer(txn.producer)
+	}
+}
+
+// resetTxnProducer closes a transactional producer that has entered a fatal
+// state, which can no longer be used, so that a new one is created when the
+// writer next connects.
+func (k *Kafka) resetTxnProducer(producer sarama.SyncProducer) {
+	if producer.TxnStatus()&sarama.ProducerTxnFlagFatalError == 0 {
+		return
+	}
+	k.connMut.Lock()
+	if k.producer == producer {
+		k.producer.Close()
+		k.producer = nil
+	}
+	k.connMut.Unlock()
+}
+
 // CloseAsync shuts down the Kafka writer and stops processing messages.
 func (k *Kafka) CloseAsync() {
 	go func() {
@@ -0,0 +1,211 @@
+package writer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaIdempotentWriteVersion(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.IdempotentWrite = true
+	conf.TargetVersion = sarama.V0_10_2_0.String()
+
+	_, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.TargetVersion = sarama.V0_11_0_0.String()
+	_, err = NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+}
+
+func TestKafkaTransactionalConfig(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.TransactionalID = "foo"
+	conf.TargetVersion = sarama.V0_10_2_0.String()
+
+	_, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "transactional writes require a target_version of at least 0.11.0.0")
+
+	conf.TargetVersion = sarama.V0_11_0_0.String()
+	conf.CommitInterval = "nope"
+	_, err = NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, `failed to parse transaction_commit_interval: time: invalid duration "nope"`)
+
+	conf.CommitInterval = "1m"
+	_, err = NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "transaction_commit_interval must be less than the transaction timeout of 1m0s")
+
+	conf.CommitInterval = "1s"
+	_, err = NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf.TransactionalID = ""
+	_, err = NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "a transaction_commit_interval requires a transactional_id")
+}
+
+type kafkaTxnRecorder struct {
+	*mocks.SyncProducer
+
+	mut   sync.Mutex
+	calls []string
+}
+
+func (r *kafkaTxnRecorder) record(call string) {
+	r.mut.Lock()
+	r.calls = append(r.calls, call)
+	r.mut.Unlock()
+}
+
+func (r *kafkaTxnRecorder) getCalls() []string {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func (r *kafkaTxnRecorder) SendMessages(msgs []*sarama.ProducerMessage) error {
+	r.record("send")
+	return r.SyncProducer.SendMessages(msgs)
+}
+
+func (r *kafkaTxnRecorder) BeginTxn() error {
+	r.record("begin")
+	return r.SyncProducer.BeginTxn()
+}
+
+func (r *kafkaTxnRecorder) CommitTxn() error {
+	r.record("commit")
+	return r.SyncProducer.CommitTxn()
+}
+
+func (r *kafkaTxnRecorder) AbortTxn() error {
+	r.record("abort")
+	return r.SyncProducer.AbortTxn()
+}
+
+func newTestKafkaTxn(t *testing.T, commitInterval string) (*Kafka, *kafkaTxnRecorder) {
+	t.Helper()
+
+	conf := NewKafkaConfig()
+	conf.TransactionalID = "foo"
+	conf.CommitInterval = commitInterval
+
+	k, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mConf := sarama.NewConfig()
+	mConf.Version = k.version
+	mConf.Producer.Return.Successes = true
+	mConf.Producer.Idempotent = true
+	mConf.Producer.RequiredAcks = sarama.WaitForAll
+	mConf.Producer.Transaction.ID = conf.TransactionalID
+	mConf.Net.MaxOpenRequests = 1
+
+	producer := &kafkaTxnRecorder{SyncProducer: mocks.NewSyncProducer(t, mConf)}
+	k.producer = producer
+	return k, producer
+}
+
+func TestKafkaTransactionPerBatch(t *testing.T) {
+	k, producer := newTestKafkaTxn(t, "")
+
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndSucceed()
+	require.NoError(t, k.Write(message.New([][]byte{[]byte("foo"), []byte("bar")})))
+
+	producer.ExpectSendMessageAndFail(errors.New("nope"))
+	require.Error(t, k.Write(message.New([][]byte{[]byte("baz")})))
+
+	assert.Equal(t, []string{
+		"begin", "send", "commit",
+		"begin", "send", "abort",
+	}, producer.getCalls())
+}
+
+func TestKafkaTransactionCommitInterval(t *testing.T) {
+	k, producer := newTestKafkaTxn(t, "50ms")
+
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndSucceed()
+
+	var wg sync.WaitGroup
+	for _, content := range []string{"foo", "bar"} {
+		content := content
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, k.Write(message.New([][]byte{[]byte(content)})))
+		}()
+	}
+
+	<-time.After(20 * time.Millisecond)
+	assert.Equal(t, []string{"begin", "send", "send"}, producer.getCalls())
+
+	wg.Wait()
+	assert.Equal(t, []string{"begin", "send", "send", "commit"}, producer.getCalls())
+}
+
+func TestKafkaMetadataHeaders(t *testing.T) {
+	tests := map[string]struct {
+		include []string
+		exclude []string
+		output  map[string]string
+	}{
+		"no patterns": {
+			output: map[string]string{"kafka_key": "a", "kafka_topic": "b", "business_id": "c", "trace": "d"},
+		},
+		"include": {
+			include: []string{"^business_", "^trace$"},
+			output:  map[string]string{"business_id": "c", "trace": "d"},
+		},
+		"exclude": {
+			exclude: []string{"^kafka_"},
+			output:  map[string]string{"business_id": "c", "trace": "d"},
+		},
+		"include and exclude": {
+			include: []string{"^kafka_", "^business_"},
+			exclude: []string{"_topic$"},
+			output:  map[string]string{"kafka_key": "a", "business_id": "c"},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewKafkaConfig()
+			conf.Metadata.IncludePatterns = test.include
+			conf.Metadata.ExcludePatterns = test.exclude
+
+			k, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			part := message.NewPart(nil)
+			part.Metadata().
+				Set("kafka_key", "a").
+				Set("kafka_topic", "b").
+				Set("business_id", "c").
+				Set("trace", "d")
+
+			headers := map[string]string{}
+			for _, h := range buildSystemHeaders(k.version, part, k.includeHeaders, k.excludeHeaders) {
+				headers[string(h.Key)] = string(h.Value)
+			}
+			assert.Equal(t, test.output, headers)
+		})
+	}
+
+	conf := NewKafkaConfig()
+	conf.Metadata.ExcludePatterns = []string{"("}
+	_, err := NewKafka(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+)
+
+//------------------------------------------------------------------------------
+
+// LambdaConfig contains configuration fields for the Lambda output type.
+type LambdaConfig struct {
+	sessionConfig  `json:",inline" yaml:",inline"`
+	Function       string `json:"function" yaml:"function"`
+	InvocationType string `json:"invocation_type" yaml:"invocation_type"`
+	Timeout        string `json:"timeout" yaml:"timeout"`
+	MaxInFlight    int    `json:"max_in_flight" yaml:"max_in_flight"`
+}
+
+// NewLambdaConfig creates a new Config with default values.
+func NewLambdaConfig() LambdaConfig {
+	return LambdaConfig{
+		sessionConfig: sessionConfig{
+			Config: sess.NewConfig(),
+		},
+		Function:       "",
+		InvocationType: lambda.InvocationTypeRequestResponse,
+		Timeout:        "5s",
+		MaxInFlight:    1,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Lambda is a benthos writer.Type implementation that invokes an AWS Lambda
+// function for each message.
+type Lambda struct {
+	conf LambdaConfig
+
+	session *session.Session
+	lambda  lambdaiface.LambdaAPI
+
+	tout time.Duration
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewLambda creates a new AWS Lambda writer.Type.
+func NewLambda(conf LambdaConfig, log log.Modular, stats metrics.Type) (*Lambda, error) {
+	l := &Lambda{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	if len(conf.Function) == 0 {
+		return nil, errors.New("lambda function must not be empty")
+	}
+	switch conf.InvocationType {
+	case lambda.InvocationTypeRequestResponse, lambda.InvocationTypeEvent:
+	default:
+		return nil, fmt.Errorf("unrecognised invocation type: %v", conf.InvocationType)
+	}
+	if tout := conf.Timeout; len(tout) > 0 {
+		var err error
+		if l.tout, err = time.ParseDuration(tout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+		}
+	}
+	return l, nil
+}
+
+// ConnectWithContext attempts to establish a connection to AWS Lambda.
+func (l *Lambda) ConnectWithContext(ctx context.Context) error {
+	return l.Connect()
+}
+
+// Connect attempts to establish a connection to AWS Lambda.
+func (l *Lambda) Connect() error {
+	if l.session != nil {
+		return nil
+	}
+
+	sess, err := l.conf.GetSession()
+	if err != nil {
+		return err
+	}
+
+	l.session = sess
+	l.lambda = lambda.New(sess)
+
+	l.log.Infof("Invoking AWS Lambda function: %v\n", l.conf.Function)
+	return nil
+}
+
+// Write attempts to invoke a Lambda function with message contents.
+func (l *Lambda) Write(msg types.Message) error {
+	return l.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext attempts to invoke a Lambda function with message contents.
+func (l *Lambda) WriteWithContext(wctx context.Context, msg types.Message) error {
+	if l.session == nil {
+		return types.ErrNotConnected
+	}
+
+	return IterateBatchedSend(msg, func(i int, p types.Part) error {
+		ctx, cancel := context.WithTimeout(wctx, l.tout)
+		defer cancel()
+
+		result, err := l.lambda.InvokeWithContext(ctx, &lambda.InvokeInput{
+			FunctionName:   aws.String(l.conf.Function),
+			InvocationType: aws.String(l.conf.InvocationType),
+			Payload:        p.Get(),
+		})
+		if err != nil {
+			return err
+		}
+		if result.FunctionError != nil {
+			return fmt.Errorf("lambda function returned an error (%v): %s", *result.FunctionError, result.Payload)
+		}
+		return nil
+	})
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (l *Lambda) CloseAsync() {
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (l *Lambda) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,131 @@
+package writer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLambda struct {
+	lambdaiface.LambdaAPI
+	fn func(*lambda.InvokeInput) (*lambda.InvokeOutput, error)
+}
+
+func (m *mockLambda) InvokeWithContext(ctx aws.Context, input *lambda.InvokeInput, opts ...request.Option) (*lambda.InvokeOutput, error) {
+	return m.fn(input)
+}
+
+func TestLambdaConfigErrors(t *testing.T) {
+	tests := map[string]func(c *LambdaConfig){
+		"no function": func(c *LambdaConfig) {
+			c.Function = ""
+		},
+		"bad invocation type": func(c *LambdaConfig) {
+			c.InvocationType = "DryRun"
+		},
+		"bad timeout": func(c *LambdaConfig) {
+			c.Timeout = "nope"
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewLambdaConfig()
+			conf.Function = "foo"
+			test(&conf)
+
+			_, err := NewLambda(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLambdaInvoke(t *testing.T) {
+	conf := NewLambdaConfig()
+	conf.Function = "foo"
+	conf.InvocationType = "Event"
+
+	l, err := NewLambda(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.Equal(t, types.ErrNotConnected, l.Write(message.New([][]byte{[]byte(`foo`)})))
+
+	var inputs []*lambda.InvokeInput
+	l.session = session.Must(session.NewSession())
+	l.lambda = &mockLambda{
+		fn: func(input *lambda.InvokeInput) (*lambda.InvokeOutput, error) {
+			inputs = append(inputs, input)
+			return &lambda.InvokeOutput{StatusCode: aws.Int64(202)}, nil
+		},
+	}
+
+	require.NoError(t, l.Write(message.New([][]byte{[]byte(`foo`), []byte(`bar`)})))
+	assert.Equal(t, []*lambda.InvokeInput{
+		{
+			FunctionName:   aws.String("foo"),
+			InvocationType: aws.String("Event"),
+			Payload:        []byte(`foo`),
+		},
+		{
+			FunctionName:   aws.String("foo"),
+			InvocationType: aws.String("Event"),
+			Payload:        []byte(`bar`),
+		},
+	}, inputs)
+}
+
+func TestLambdaFunctionErrors(t *testing.T) {
+	conf := NewLambdaConfig()
+	conf.Function = "foo"
+
+	l, err := NewLambda(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	l.session = session.Must(session.NewSession())
+	l.lambda = &mockLambda{
+		fn: func(input *lambda.InvokeInput) (*lambda.InvokeOutput, error) {
+			assert.Equal(t, "RequestResponse", *input.InvocationType)
+			switch string(input.Payload) {
+			case "bar":
+				return &lambda.InvokeOutput{
+					FunctionError: aws.String("Unhandled"),
+					Payload:       []byte(`{"errorMessage":"bad bar"}`),
+				}, nil
+			case "baz":
+				return nil, errors.New("nope")
+			}
+			return &lambda.InvokeOutput{Payload: []byte(`ignored`)}, nil
+		},
+	}
+
+	err = l.Write(message.New([][]byte{[]byte(`foo`), []byte(`bar`), []byte(`baz`)}))
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok)
+
+	failed := map[int]string{}
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed[i] = err.Error()
+		}
+		return true
+	})
+	assert.Equal(t, map[int]string{
+		1: `lambda function returned an error (Unhandled): {"errorMessage":"bad bar"}`,
+		2: "nope",
+	}, failed)
+}
@@ -0,0 +1,76 @@
+package writer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/parser"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// newMapping parses a Bloblang mapping, where the name of the mapping is used
+// within errors.
+func newMapping(name, m string) (*mapping.Executor, error) {
+	exec, err := bloblang.NewMapping("", m)
+	if err != nil {
+		if perr, ok := err.(*parser.Error); ok {
+			return nil, fmt.Errorf("failed to parse %v: %v", name, perr.ErrorAtPosition([]rune(m)))
+		}
+		return nil, fmt.Errorf("failed to parse %v: %v", name, err)
+	}
+	return exec, nil
+}
+
+// execMapping executes a mapping against a message of a batch and returns the
+// resulting value.
+func execMapping(exec *mapping.Executor, index int, msg types.Message) (interface{}, error) {
+	var valuePtr *interface{}
+	var parseErr error
+
+	lazyValue := func() *interface{} {
+		if valuePtr == nil && parseErr == nil {
+			if jObj, err := msg.Get(index).JSON(); err == nil {
+				valuePtr = &jObj
+			} else {
+				parseErr = err
+			}
+		}
+		return valuePtr
+	}
+
+	res, err := exec.Exec(query.FunctionContext{
+		Maps:     exec.Maps(),
+		Value:    lazyValue,
+		Vars:     map[string]interface{}{},
+		Index:    index,
+		MsgBatch: msg,
+	})
+	if err != nil {
+		if parseErr != nil && errors.Is(err, query.ErrNoContext) {
+			err = fmt.Errorf("failed to parse message as JSON: %w", parseErr)
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// execArgsMapping executes an args mapping against a message of a batch and
+// returns the resulting array of values.
+func execArgsMapping(exec *mapping.Executor, index int, msg types.Message) ([]interface{}, error) {
+	res, err := execMapping(exec, index, msg)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := res.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("args mapping returned non-array result: %v", query.ITypeOf(res))
+	}
+	return values, nil
+}
+
+//------------------------------------------------------------------------------
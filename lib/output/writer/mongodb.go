@@ -0,0 +1,367 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	mbatch "github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/mongodb"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+//------------------------------------------------------------------------------
+
+// MongoDBWriteConcernConfig contains configuration fields for the write
+// concern of the MongoDB output type.
+type MongoDBWriteConcernConfig struct {
+	W        string `json:"w" yaml:"w"`
+	J        bool   `json:"j" yaml:"j"`
+	WTimeout string `json:"w_timeout" yaml:"w_timeout"`
+}
+
+// MongoDBConfig contains configuration fields for the MongoDB output type.
+type MongoDBConfig struct {
+	URL          string                    `json:"url" yaml:"url"`
+	Username     string                    `json:"username" yaml:"username"`
+	Password     string                    `json:"password" yaml:"password"`
+	Database     string                    `json:"database" yaml:"database"`
+	Collection   string                    `json:"collection" yaml:"collection"`
+	Operation    string                    `json:"operation" yaml:"operation"`
+	DocumentMap  string                    `json:"document_map" yaml:"document_map"`
+	FilterMap    string                    `json:"filter_map" yaml:"filter_map"`
+	Upsert       bool                      `json:"upsert" yaml:"upsert"`
+	Ordered      bool                      `json:"ordered" yaml:"ordered"`
+	WriteConcern MongoDBWriteConcernConfig `json:"write_concern" yaml:"write_concern"`
+	TLS          btls.Config               `json:"tls" yaml:"tls"`
+	MaxInFlight  int                       `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching     mbatch.PolicyConfig       `json:"batching" yaml:"batching"`
+}
+
+// NewMongoDBConfig creates a new MongoDBConfig with default values.
+func NewMongoDBConfig() MongoDBConfig {
+	batching := mbatch.NewPolicyConfig()
+	batching.Count = 1
+	return MongoDBConfig{
+		URL:         "",
+		Username:    "",
+		Password:    "",
+		Database:    "",
+		Collection:  "",
+		Operation:   "insert-one",
+		DocumentMap: "",
+		FilterMap:   "",
+		Upsert:      false,
+		Ordered:     true,
+		WriteConcern: MongoDBWriteConcernConfig{
+			W:        "",
+			J:        false,
+			WTimeout: "",
+		},
+		TLS:         btls.NewConfig(),
+		MaxInFlight: 1,
+		Batching:    batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var errMongoDBWriteNotAttempted = errors.New("write not attempted due to the failure of a prior write of an ordered batch")
+
+// MongoDB is a writer type that writes each message of a batch as an operation
+// of a bulk write.
+type MongoDB struct {
+	conf       MongoDBConfig
+	clientOpts *options.ClientOptions
+	database   string
+
+	documentMap  *mapping.Executor
+	filterMap    *mapping.Executor
+	writeConcern *writeconcern.WriteConcern
+
+	clientMut  sync.RWMutex
+	client     *mongo.Client
+	collection *mongo.Collection
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewMongoDB creates a new MongoDB writer type.
+func NewMongoDB(conf MongoDBConfig, log log.Modular, stats metrics.Type) (*MongoDB, error) {
+	if conf.URL == "" {
+		return nil, errors.New("a url must be specified")
+	}
+	if conf.Collection == "" {
+		return nil, errors.New("a collection must be specified")
+	}
+
+	needsDocument, needsFilter := true, true
+	switch conf.Operation {
+	case "insert-one":
+		needsFilter = false
+	case "replace-one", "update-one":
+	case "delete-one", "delete-many":
+		needsDocument = false
+	default:
+		return nil, fmt.Errorf("unrecognised operation: %v", conf.Operation)
+	}
+
+	m := &MongoDB{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+
+	var err error
+	if needsDocument {
+		if conf.DocumentMap == "" {
+			return nil, fmt.Errorf("a document map must be specified for operation %v", conf.Operation)
+		}
+		if m.documentMap, err = newMapping("document map", conf.DocumentMap); err != nil {
+			return nil, err
+		}
+	}
+	if needsFilter {
+		if conf.FilterMap == "" {
+			return nil, fmt.Errorf("a filter map must be specified for operation %v", conf.Operation)
+		}
+		if m.filterMap, err = newMapping("filter map", conf.FilterMap); err != nil {
+			return nil, err
+		}
+	}
+	if m.writeConcern, err = mongoDBWriteConcern(conf.WriteConcern); err != nil {
+		return nil, err
+	}
+
+	if m.clientOpts, err = mongodb.ClientOptions(conf.URL, conf.Username, conf.Password, conf.TLS); err != nil {
+		return nil, err
+	}
+	if m.database = conf.Database; m.database == "" {
+		cs, err := connstring.Parse(conf.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse url: %w", err)
+		}
+		if m.database = cs.Database; m.database == "" {
+			return nil, errors.New("a database must be specified")
+		}
+	}
+	return m, nil
+}
+
+// mongoDBWriteConcern returns the write concern of writes, which is nil when
+// the default write concern of the deployment is used.
+func mongoDBWriteConcern(conf MongoDBWriteConcernConfig) (*writeconcern.WriteConcern, error) {
+	var opts []writeconcern.Option
+	if conf.W != "" {
+		if n, err := strconv.Atoi(conf.W); err == nil {
+			opts = append(opts, writeconcern.W(n))
+		} else if conf.W == "majority" {
+			opts = append(opts, writeconcern.WMajority())
+		} else {
+			opts = append(opts, writeconcern.WTagSet(conf.W))
+		}
+	}
+	if conf.J {
+		opts = append(opts, writeconcern.J(true))
+	}
+	if conf.WTimeout != "" {
+		timeout, err := time.ParseDuration(conf.WTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse write concern timeout string: %v", err)
+		}
+		opts = append(opts, writeconcern.WTimeout(timeout))
+	}
+	if len(opts) == 0 {
+		return nil, nil
+	}
+	return writeconcern.New(opts...), nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a connection to MongoDB.
+func (m *MongoDB) Connect() error {
+	return m.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext attempts to establish a connection to the primary server
+// of the deployment.
+func (m *MongoDB) ConnectWithContext(ctx context.Context) error {
+	m.clientMut.Lock()
+	defer m.clientMut.Unlock()
+
+	if m.client != nil {
+		return nil
+	}
+
+	client, err := mongo.Connect(ctx, m.clientOpts)
+	if err != nil {
+		return err
+	}
+	if err = client.Ping(ctx, readpref.Primary()); err != nil {
+		client.Disconnect(context.Background())
+		return err
+	}
+
+	collOpts := options.Collection()
+	if m.writeConcern != nil {
+		collOpts.SetWriteConcern(m.writeConcern)
+	}
+	m.client = client
+	m.collection = client.Database(m.database).Collection(m.conf.Collection, collOpts)
+
+	m.log.Infof("Writing messages to MongoDB collection: %v.%v\n", m.database, m.conf.Collection)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// mapMongoDBDocument executes a mapping against a message and returns the
+// resulting document, which is decoded from extended JSON.
+func mapMongoDBDocument(exec *mapping.Executor, index int, msg types.Message) (bson.D, error) {
+	part, err := exec.MapPart(index, msg)
+	if err != nil {
+		return nil, err
+	}
+	if part == nil {
+		return nil, errors.New("mapping deleted the message")
+	}
+	var doc bson.D
+	if err = bson.UnmarshalExtJSON(part.Get(), false, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// model returns the write model of the operation of a message.
+func (m *MongoDB) model(index int, msg types.Message) (mongo.WriteModel, error) {
+	var doc, filter bson.D
+	var err error
+	if m.documentMap != nil {
+		if doc, err = mapMongoDBDocument(m.documentMap, index, msg); err != nil {
+			return nil, fmt.Errorf("failed to execute document map: %w", err)
+		}
+	}
+	if m.filterMap != nil {
+		if filter, err = mapMongoDBDocument(m.filterMap, index, msg); err != nil {
+			return nil, fmt.Errorf("failed to execute filter map: %w", err)
+		}
+	}
+
+	switch m.conf.Operation {
+	case "insert-one":
+		return mongo.NewInsertOneModel().SetDocument(doc), nil
+	case "replace-one":
+		return mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(doc).SetUpsert(m.conf.Upsert), nil
+	case "update-one":
+		return mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(doc).SetUpsert(m.conf.Upsert), nil
+	case "delete-one":
+		return mongo.NewDeleteOneModel().SetFilter(filter), nil
+	}
+	return mongo.NewDeleteManyModel().SetFilter(filter), nil
+}
+
+// batchErr returns the error of a bulk write of a batch, where the errors of
+// individual writes are attributed to their messages.
+func (m *MongoDB) batchErr(msg types.Message, err error) error {
+	var bwErr mongo.BulkWriteException
+	if !errors.As(err, &bwErr) {
+		return err
+	}
+	if bwErr.WriteConcernError != nil {
+		return fmt.Errorf("failed to satisfy write concern: %w", bwErr.WriteConcernError)
+	}
+	if len(bwErr.WriteErrors) == 0 {
+		return err
+	}
+
+	var batchErr *batch.Error
+	failed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = batch.NewError(msg, err)
+		}
+		batchErr.Failed(i, err)
+	}
+
+	firstFailed := -1
+	for _, we := range bwErr.WriteErrors {
+		failed(we.Index, we.WriteError)
+		if firstFailed < 0 || we.Index < firstFailed {
+			firstFailed = we.Index
+		}
+	}
+	if m.conf.Ordered {
+		for i := firstFailed + 1; i < msg.Len(); i++ {
+			failed(i, errMongoDBWriteNotAttempted)
+		}
+	}
+	return batchErr
+}
+
+//------------------------------------------------------------------------------
+
+// Write attempts to write message contents to MongoDB.
+func (m *MongoDB) Write(msg types.Message) error {
+	return m.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext writes each message of a batch as an operation of a bulk
+// write.
+func (m *MongoDB) WriteWithContext(ctx context.Context, msg types.Message) error {
+	m.clientMut.RLock()
+	collection := m.collection
+	m.clientMut.RUnlock()
+
+	if collection == nil {
+		return types.ErrNotConnected
+	}
+
+	models := make([]mongo.WriteModel, msg.Len())
+	for i := range models {
+		model, err := m.model(i, msg)
+		if err != nil {
+			return fmt.Errorf("failed to build operation for message %v: %w", i, err)
+		}
+		models[i] = model
+	}
+
+	_, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(m.conf.Ordered))
+	if err != nil && !errors.Is(err, mongo.ErrUnacknowledgedWrite) {
+		return m.batchErr(msg, err)
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (m *MongoDB) CloseAsync() {
+	m.clientMut.Lock()
+	if m.client != nil {
+		m.client.Disconnect(context.Background())
+		m.client = nil
+		m.collection = nil
+	}
+	m.clientMut.Unlock()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (m *MongoDB) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,220 @@
+package writer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+func TestMongoDBConfigErrors(t *testing.T) {
+	tests := map[string]func(c *MongoDBConfig){
+		"no url": func(c *MongoDBConfig) {
+			c.URL = ""
+		},
+		"bad url": func(c *MongoDBConfig) {
+			c.URL = "localhost:27017"
+		},
+		"no database": func(c *MongoDBConfig) {
+			c.Database = ""
+		},
+		"no collection": func(c *MongoDBConfig) {
+			c.Collection = ""
+		},
+		"bad operation": func(c *MongoDBConfig) {
+			c.Operation = "nope"
+		},
+		"no document map": func(c *MongoDBConfig) {
+			c.DocumentMap = ""
+		},
+		"bad document map": func(c *MongoDBConfig) {
+			c.DocumentMap = "root = "
+		},
+		"no filter map": func(c *MongoDBConfig) {
+			c.Operation = "delete-one"
+			c.FilterMap = ""
+		},
+		"bad write concern timeout": func(c *MongoDBConfig) {
+			c.WriteConcern.WTimeout = "nope"
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewMongoDBConfig()
+			conf.URL = "mongodb://localhost"
+			conf.Database = "shop"
+			conf.Collection = "users"
+			conf.DocumentMap = "root = this"
+			conf.FilterMap = "root._id = this.id"
+			test(&conf)
+
+			_, err := NewMongoDB(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+
+	conf := NewMongoDBConfig()
+	conf.URL = "mongodb://localhost/shop"
+	conf.Collection = "users"
+	conf.DocumentMap = "root = this"
+	m, err := NewMongoDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, "shop", m.database)
+}
+
+func TestMongoDBWriteConcern(t *testing.T) {
+	wc, err := mongoDBWriteConcern(MongoDBWriteConcernConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, wc)
+
+	wc, err = mongoDBWriteConcern(MongoDBWriteConcernConfig{W: "majority", J: true, WTimeout: "5s"})
+	require.NoError(t, err)
+	assert.Equal(t, writeconcern.New(writeconcern.WMajority(), writeconcern.J(true), writeconcern.WTimeout(5*time.Second)), wc)
+
+	wc, err = mongoDBWriteConcern(MongoDBWriteConcernConfig{W: "2"})
+	require.NoError(t, err)
+	assert.Equal(t, writeconcern.New(writeconcern.W(2)), wc)
+
+	wc, err = mongoDBWriteConcern(MongoDBWriteConcernConfig{W: "dc"})
+	require.NoError(t, err)
+	assert.Equal(t, writeconcern.New(writeconcern.WTagSet("dc")), wc)
+}
+
+func TestMongoDBModels(t *testing.T) {
+	newWriter := func(fn func(c *MongoDBConfig)) *MongoDB {
+		conf := NewMongoDBConfig()
+		conf.URL = "mongodb://localhost"
+		conf.Database = "shop"
+		conf.Collection = "users"
+		fn(&conf)
+		m, err := NewMongoDB(conf, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+		return m
+	}
+
+	m := newWriter(func(c *MongoDBConfig) {
+		c.DocumentMap = `root = this.user`
+	})
+	assert.Equal(t, types.ErrNotConnected, m.Write(message.New([][]byte{[]byte(`{}`)})))
+
+	msg := message.New([][]byte{
+		[]byte(`{"user":{"_id":{"$oid":"5f1d7a2b0000000000000001"},"name":"foo","age":30}}`),
+		[]byte(`{"user":{"name":"bar","joined":{"$date":"2020-09-13T12:26:40.123Z"}}}`),
+	})
+	oid, err := primitive.ObjectIDFromHex("5f1d7a2b0000000000000001")
+	require.NoError(t, err)
+
+	model, err := m.model(0, msg)
+	require.NoError(t, err)
+	assert.Equal(t, mongo.NewInsertOneModel().SetDocument(bson.D{
+		{Key: "_id", Value: oid}, {Key: "age", Value: int32(30)}, {Key: "name", Value: "foo"},
+	}), model)
+
+	model, err = m.model(1, msg)
+	require.NoError(t, err)
+	assert.Equal(t, mongo.NewInsertOneModel().SetDocument(bson.D{
+		{Key: "joined", Value: primitive.DateTime(1600000000123)}, {Key: "name", Value: "bar"},
+	}), model)
+
+	_, err = m.model(0, message.New([][]byte{[]byte(`not json`)}))
+	assert.Error(t, err)
+
+	m = newWriter(func(c *MongoDBConfig) {
+		c.Operation = "update-one"
+		c.FilterMap = `root._id = this.id`
+		c.DocumentMap = `root."$set".name = this.name`
+		c.Upsert = true
+	})
+	model, err = m.model(0, message.New([][]byte{[]byte(`{"id":1,"name":"foo"}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, mongo.NewUpdateOneModel().
+		SetFilter(bson.D{{Key: "_id", Value: int32(1)}}).
+		SetUpdate(bson.D{{Key: "$set", Value: bson.D{{Key: "name", Value: "foo"}}}}).
+		SetUpsert(true), model)
+
+	m = newWriter(func(c *MongoDBConfig) {
+		c.Operation = "replace-one"
+		c.FilterMap = `root._id = this.id`
+		c.DocumentMap = `root.name = this.name`
+	})
+	model, err = m.model(0, message.New([][]byte{[]byte(`{"id":1,"name":"foo"}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, mongo.NewReplaceOneModel().
+		SetFilter(bson.D{{Key: "_id", Value: int32(1)}}).
+		SetReplacement(bson.D{{Key: "name", Value: "foo"}}).
+		SetUpsert(false), model)
+
+	for op, expected := range map[string]mongo.WriteModel{
+		"delete-one":  mongo.NewDeleteOneModel().SetFilter(bson.D{{Key: "name", Value: "foo"}}),
+		"delete-many": mongo.NewDeleteManyModel().SetFilter(bson.D{{Key: "name", Value: "foo"}}),
+	} {
+		op := op
+		m = newWriter(func(c *MongoDBConfig) {
+			c.Operation = op
+			c.FilterMap = `root.name = this.name`
+		})
+		model, err = m.model(0, message.New([][]byte{[]byte(`{"name":"foo"}`)}))
+		require.NoError(t, err, op)
+		assert.Equal(t, expected, model, op)
+	}
+}
+
+func TestMongoDBBatchErrors(t *testing.T) {
+	conf := NewMongoDBConfig()
+	conf.URL = "mongodb://localhost/shop"
+	conf.Collection = "users"
+	conf.DocumentMap = "root = this"
+
+	m, err := NewMongoDB(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{[]byte(`{"id":1}`), []byte(`{"id":2}`), []byte(`{"id":3}`)})
+	partErrs := func(err error) []error {
+		var batchErr *batch.Error
+		require.True(t, errors.As(err, &batchErr), err)
+		var errs []error
+		batchErr.WalkParts(func(_ int, _ types.Part, err error) bool {
+			errs = append(errs, err)
+			return true
+		})
+		return errs
+	}
+
+	dupErr := mongo.WriteError{Index: 1, Code: 11000, Message: "duplicate key"}
+	bwErr := mongo.BulkWriteException{
+		WriteErrors: []mongo.BulkWriteError{{WriteError: dupErr}},
+	}
+
+	errs := partErrs(m.batchErr(msg, bwErr))
+	assert.Nil(t, errs[0])
+	assert.Equal(t, dupErr, errs[1])
+	assert.Equal(t, errMongoDBWriteNotAttempted, errs[2])
+
+	// Writes of unordered batches continue after failures.
+	m.conf.Ordered = false
+	errs = partErrs(m.batchErr(msg, bwErr))
+	assert.Nil(t, errs[0])
+	assert.Equal(t, dupErr, errs[1])
+	assert.Nil(t, errs[2])
+
+	err = m.batchErr(msg, mongo.BulkWriteException{
+		WriteConcernError: &mongo.WriteConcernError{Code: 64, Message: "waiting for replication timed out"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "waiting for replication timed out")
+
+	assert.EqualError(t, m.batchErr(msg, errors.New("not authorized")), "not authorized")
+}
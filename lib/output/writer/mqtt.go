@@ -2,16 +2,21 @@ package writer
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/mqttconf"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
@@ -19,25 +24,37 @@ import (
 
 // MQTTConfig contains configuration fields for the MQTT output type.
 type MQTTConfig struct {
-	URLs        []string `json:"urls" yaml:"urls"`
-	QoS         uint8    `json:"qos" yaml:"qos"`
-	Topic       string   `json:"topic" yaml:"topic"`
-	ClientID    string   `json:"client_id" yaml:"client_id"`
-	User        string   `json:"user" yaml:"user"`
-	Password    string   `json:"password" yaml:"password"`
-	MaxInFlight int      `json:"max_in_flight" yaml:"max_in_flight"`
+	URLs                 []string          `json:"urls" yaml:"urls"`
+	QoS                  uint8             `json:"qos" yaml:"qos"`
+	Retained             bool              `json:"retained" yaml:"retained"`
+	RetainedInterpolated string            `json:"retained_interpolated" yaml:"retained_interpolated"`
+	Topic                string            `json:"topic" yaml:"topic"`
+	ClientID             string            `json:"client_id" yaml:"client_id"`
+	User                 string            `json:"user" yaml:"user"`
+	Password             string            `json:"password" yaml:"password"`
+	HTTPHeaders          map[string]string `json:"http_headers" yaml:"http_headers"`
+	WriteTimeout         string            `json:"write_timeout" yaml:"write_timeout"`
+	Will                 mqttconf.Will     `json:"will" yaml:"will"`
+	MaxInFlight          int               `json:"max_in_flight" yaml:"max_in_flight"`
+	TLS                  btls.Config       `json:"tls" yaml:"tls"`
 }
 
 // NewMQTTConfig creates a new MQTTConfig with default values.
 func NewMQTTConfig() MQTTConfig {
 	return MQTTConfig{
-		URLs:        []string{"tcp://localhost:1883"},
-		QoS:         1,
-		Topic:       "benthos_topic",
-		ClientID:    "benthos_output",
-		User:        "",
-		Password:    "",
-		MaxInFlight: 1,
+		URLs:                 []string{"tcp://localhost:1883"},
+		QoS:                  1,
+		Retained:             false,
+		RetainedInterpolated: "",
+		Topic:                "benthos_topic",
+		ClientID:             "benthos_output",
+		User:                 "",
+		Password:             "",
+		HTTPHeaders:          map[string]string{},
+		WriteTimeout:         "3s",
+		Will:                 mqttconf.EmptyWill(),
+		MaxInFlight:          1,
+		TLS:                  btls.NewConfig(),
 	}
 }
 
@@ -52,6 +69,11 @@ type MQTT struct {
 	conf  MQTTConfig
 	topic field.Expression
 
+	retained     field.Expression
+	writeTimeout time.Duration
+	tlsConf      *tls.Config
+	headers      http.Header
+
 	client  mqtt.Client
 	connMut sync.RWMutex
 }
@@ -73,6 +95,31 @@ func NewMQTT(
 		return nil, fmt.Errorf("failed to parse topic expression: %v", err)
 	}
 
+	if conf.RetainedInterpolated != "" {
+		if m.retained, err = bloblang.NewField(conf.RetainedInterpolated); err != nil {
+			return nil, fmt.Errorf("failed to parse retained expression: %v", err)
+		}
+	}
+
+	if err = conf.Will.Validate(); err != nil {
+		return nil, err
+	}
+
+	if m.writeTimeout, err = time.ParseDuration(conf.WriteTimeout); err != nil {
+		return nil, fmt.Errorf("unable to parse write timeout duration string: %w", err)
+	}
+
+	if conf.TLS.Enabled {
+		if m.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
+	m.headers = http.Header{}
+	for k, v := range conf.HTTPHeaders {
+		m.headers.Add(k, v)
+	}
+
 	for _, u := range conf.URLs {
 		for _, splitURL := range strings.Split(u, ",") {
 			if len(splitURL) > 0 {
@@ -107,7 +154,7 @@ func (m *MQTT) Connect() error {
 			m.log.Errorf("Connection lost due to: %v\n", reason)
 		}).
 		SetConnectTimeout(time.Second).
-		SetWriteTimeout(time.Second).
+		SetWriteTimeout(m.writeTimeout).
 		SetClientID(m.conf.ClientID)
 
 	for _, u := range m.urls {
@@ -122,6 +169,18 @@ func (m *MQTT) Connect() error {
 		conf.SetPassword(m.conf.Password)
 	}
 
+	if m.conf.Will.Enabled {
+		conf = conf.SetWill(m.conf.Will.Topic, m.conf.Will.Payload, m.conf.Will.QoS, m.conf.Will.Retained)
+	}
+
+	if m.tlsConf != nil {
+		conf.SetTLSConfig(m.tlsConf)
+	}
+
+	if len(m.headers) > 0 {
+		conf.SetHTTPHeaders(m.headers)
+	}
+
 	client := mqtt.NewClient(conf)
 
 	tok := client.Connect()
@@ -152,19 +211,42 @@ func (m *MQTT) Write(msg types.Message) error {
 	}
 
 	return IterateBatchedSend(msg, func(i int, p types.Part) error {
-		mtok := client.Publish(m.topic.String(i, msg), byte(m.conf.QoS), false, p.Get())
-		mtok.Wait()
+		retained := m.conf.Retained
+		if m.retained != nil {
+			var parseErr error
+			if retained, parseErr = strconv.ParseBool(m.retained.String(i, msg)); parseErr != nil {
+				m.log.Errorf("Error parsing boolean value from retained flag: %v\n", parseErr)
+				retained = m.conf.Retained
+			}
+		}
+
+		// For QoS 2 the token is only completed once the PUBREC, PUBREL and
+		// PUBCOMP exchange has finished, and therefore the message is
+		// guaranteed to have been received by the broker exactly once.
+		mtok := client.Publish(m.topic.String(i, msg), byte(m.conf.QoS), retained, p.Get())
+		if !mtok.WaitTimeout(m.writeTimeout) {
+			m.log.Errorln("Timed out waiting for publish acknowledgement, re-establishing connection to broker.")
+			m.dropClient(client)
+			return types.ErrTimeout
+		}
 		sendErr := mtok.Error()
-		if sendErr != nil && strings.Contains(sendErr.Error(), "Not Connected") {
-			m.connMut.RLock()
-			m.client = nil
-			m.connMut.RUnlock()
+		if sendErr != nil && (!client.IsConnected() || strings.Contains(sendErr.Error(), "Not Connected")) {
+			m.dropClient(client)
 			sendErr = types.ErrNotConnected
 		}
 		return sendErr
 	})
 }
 
+func (m *MQTT) dropClient(client mqtt.Client) {
+	m.connMut.Lock()
+	if m.client == client {
+		m.client.Disconnect(0)
+		m.client = nil
+	}
+	m.connMut.Unlock()
+}
+
 // CloseAsync shuts down the MQTT output and stops processing messages.
 func (m *MQTT) CloseAsync() {
 	go func() {
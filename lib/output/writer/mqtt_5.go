@@ -0,0 +1,319 @@
+package writer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/mqttconf"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/eclipse/paho.golang/paho"
+)
+
+//------------------------------------------------------------------------------
+
+// MQTT5Config contains configuration fields for the MQTT5 output type.
+type MQTT5Config struct {
+	URLs                  []string          `json:"urls" yaml:"urls"`
+	QoS                   uint8             `json:"qos" yaml:"qos"`
+	Topic                 string            `json:"topic" yaml:"topic"`
+	ClientID              string            `json:"client_id" yaml:"client_id"`
+	SessionExpiryInterval string            `json:"session_expiry_interval" yaml:"session_expiry_interval"`
+	MessageExpiryInterval string            `json:"message_expiry_interval" yaml:"message_expiry_interval"`
+	TopicAliasMaximum     uint16            `json:"topic_alias_maximum" yaml:"topic_alias_maximum"`
+	User                  string            `json:"user" yaml:"user"`
+	Password              string            `json:"password" yaml:"password"`
+	HTTPHeaders           map[string]string `json:"http_headers" yaml:"http_headers"`
+	MaxInFlight           int               `json:"max_in_flight" yaml:"max_in_flight"`
+	TLS                   btls.Config       `json:"tls" yaml:"tls"`
+}
+
+// NewMQTT5Config creates a new MQTT5Config with default values.
+func NewMQTT5Config() MQTT5Config {
+	return MQTT5Config{
+		URLs:                  []string{"tcp://localhost:1883"},
+		QoS:                   1,
+		Topic:                 "benthos_topic",
+		ClientID:              "benthos_output",
+		SessionExpiryInterval: "0s",
+		MessageExpiryInterval: "",
+		TopicAliasMaximum:     0,
+		User:                  "",
+		Password:              "",
+		HTTPHeaders:           map[string]string{},
+		TLS:                   btls.NewConfig(),
+		MaxInFlight:           1,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MQTT5 is an output type that publishes messages to MQTT brokers using the
+// MQTT v5 protocol.
+type MQTT5 struct {
+	log   log.Modular
+	stats metrics.Type
+
+	urls  []*url.URL
+	conf  MQTT5Config
+	topic field.Expression
+
+	tlsConf       *tls.Config
+	headers       http.Header
+	sessionExpiry uint32
+	messageExpiry *uint32
+
+	client  *paho.Client
+	aliases *mqtt5TopicAliases
+	connMut sync.RWMutex
+}
+
+// mqtt5TopicAliases assigns topic aliases to the publishes of a connection. An
+// alias is first sent along with its topic, and is only used in place of the
+// topic once that publish has completed, as otherwise a concurrent publish
+// could reach the broker before the alias is established.
+type mqtt5TopicAliases struct {
+	mut     sync.Mutex
+	max     uint16
+	next    uint16
+	aliases map[string]*mqtt5TopicAlias
+}
+
+type mqtt5TopicAlias struct {
+	alias       uint16
+	established bool
+}
+
+func newMQTT5TopicAliases(max uint16) *mqtt5TopicAliases {
+	return &mqtt5TopicAliases{
+		max:     max,
+		aliases: map[string]*mqtt5TopicAlias{},
+	}
+}
+
+// apply sets the topic alias of a publish where possible, and returns a
+// function to be called once the publish has succeeded.
+func (t *mqtt5TopicAliases) apply(p *paho.Publish) func() {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if a, exists := t.aliases[p.Topic]; exists {
+		if a.established {
+			p.Properties.TopicAlias = paho.Uint16(a.alias)
+			p.Topic = ""
+		}
+		return func() {}
+	}
+	if t.next >= t.max {
+		return func() {}
+	}
+
+	t.next++
+	a := &mqtt5TopicAlias{alias: t.next}
+	t.aliases[p.Topic] = a
+	p.Properties.TopicAlias = paho.Uint16(a.alias)
+	return func() {
+		t.mut.Lock()
+		a.established = true
+		t.mut.Unlock()
+	}
+}
+
+// NewMQTT5 creates a new MQTT5 output type.
+func NewMQTT5(
+	conf MQTT5Config,
+	log log.Modular,
+	stats metrics.Type,
+) (*MQTT5, error) {
+	m := &MQTT5{
+		log:   log,
+		stats: stats,
+		conf:  conf,
+	}
+
+	var err error
+	if m.topic, err = bloblang.NewField(conf.Topic); err != nil {
+		return nil, fmt.Errorf("failed to parse topic expression: %v", err)
+	}
+	if m.urls, err = mqttconf.ParseURLs(conf.URLs); err != nil {
+		return nil, err
+	}
+	m.headers = http.Header{}
+	for k, v := range conf.HTTPHeaders {
+		m.headers.Add(k, v)
+	}
+	if conf.TLS.Enabled {
+		if m.tlsConf, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+	if m.sessionExpiry, err = mqttconf.IntervalSeconds(conf.SessionExpiryInterval); err != nil {
+		return nil, fmt.Errorf("unable to parse session expiry interval: %w", err)
+	}
+	if conf.MessageExpiryInterval != "" {
+		var expiry uint32
+		if expiry, err = mqttconf.IntervalSeconds(conf.MessageExpiryInterval); err != nil {
+			return nil, fmt.Errorf("unable to parse message expiry interval: %w", err)
+		}
+		m.messageExpiry = &expiry
+	}
+
+	return m, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext establishes a connection to an MQTT server.
+func (m *MQTT5) ConnectWithContext(ctx context.Context) error {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.client != nil {
+		return nil
+	}
+
+	conn, err := mqttconf.Dial(ctx, m.urls, m.tlsConf, m.headers)
+	if err != nil {
+		return err
+	}
+
+	var client *paho.Client
+	clientConf := paho.ClientConfig{
+		Conn: conn,
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			m.dropClient(client)
+			m.log.Errorf("Server initiated disconnect with reason code: %v\n", d.ReasonCode)
+		},
+		OnClientError: func(err error) {
+			m.dropClient(client)
+			m.log.Errorf("Connection lost due to: %v\n", err)
+		},
+	}
+	client = paho.NewClient(clientConf)
+
+	connPacket := &paho.Connect{
+		ClientID:   m.conf.ClientID,
+		CleanStart: true,
+		KeepAlive:  30,
+		Properties: &paho.ConnectProperties{
+			SessionExpiryInterval: &m.sessionExpiry,
+		},
+	}
+	if m.conf.User != "" {
+		connPacket.UsernameFlag = true
+		connPacket.Username = m.conf.User
+	}
+	if m.conf.Password != "" {
+		connPacket.PasswordFlag = true
+		connPacket.Password = []byte(m.conf.Password)
+	}
+
+	connAck, err := client.Connect(ctx, connPacket)
+	if err != nil {
+		return err
+	}
+
+	// Brokers that don't specify a topic alias maximum don't accept aliases.
+	var aliasMax uint16
+	if connAck.Properties != nil && connAck.Properties.TopicAliasMaximum != nil {
+		aliasMax = *connAck.Properties.TopicAliasMaximum
+	}
+	if aliasMax > m.conf.TopicAliasMaximum {
+		aliasMax = m.conf.TopicAliasMaximum
+	}
+
+	m.client = client
+	m.aliases = newMQTT5TopicAliases(aliasMax)
+	return nil
+}
+
+// Connect establishes a connection to an MQTT server.
+func (m *MQTT5) Connect() error {
+	return m.ConnectWithContext(context.Background())
+}
+
+func (m *MQTT5) dropClient(client *paho.Client) {
+	m.connMut.Lock()
+	if m.client == client {
+		m.client = nil
+	}
+	m.connMut.Unlock()
+}
+
+//------------------------------------------------------------------------------
+
+// WriteWithContext attempts to write a message by pushing it to an MQTT broker.
+func (m *MQTT5) WriteWithContext(ctx context.Context, msg types.Message) error {
+	m.connMut.RLock()
+	client, aliases := m.client, m.aliases
+	m.connMut.RUnlock()
+
+	if client == nil {
+		return types.ErrNotConnected
+	}
+
+	return IterateBatchedSend(msg, func(i int, p types.Part) error {
+		props := &paho.PublishProperties{
+			MessageExpiry: m.messageExpiry,
+		}
+		p.Metadata().Iter(func(k, v string) error {
+			props.User.Add(k, v)
+			return nil
+		})
+
+		pub := &paho.Publish{
+			QoS:        m.conf.QoS,
+			Topic:      m.topic.String(i, msg),
+			Properties: props,
+			Payload:    p.Get(),
+		}
+		established := aliases.apply(pub)
+
+		res, err := client.Publish(ctx, pub)
+		if err != nil {
+			return err
+		}
+		if res != nil && res.ReasonCode >= 0x80 {
+			reason := fmt.Sprintf("reason code %v", res.ReasonCode)
+			if res.Properties != nil && res.Properties.ReasonString != "" {
+				reason = res.Properties.ReasonString
+			}
+			return fmt.Errorf("publish rejected by broker: %v", reason)
+		}
+		established()
+		return nil
+	})
+}
+
+// Write attempts to write a message by pushing it to an MQTT broker.
+func (m *MQTT5) Write(msg types.Message) error {
+	return m.WriteWithContext(context.Background(), msg)
+}
+
+// CloseAsync shuts down the MQTT5 output and stops processing messages.
+func (m *MQTT5) CloseAsync() {
+	go func() {
+		m.connMut.Lock()
+		if m.client != nil {
+			_ = m.client.Disconnect(&paho.Disconnect{})
+			m.client = nil
+		}
+		m.connMut.Unlock()
+	}()
+}
+
+// WaitForClose blocks until the MQTT5 output has closed down.
+func (m *MQTT5) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,165 @@
+package writer
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/eclipse/paho.golang/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runMQTT5TestBroker accepts a single connection and responds to it as a
+// broker advertising the provided topic alias maximum would.
+func runMQTT5TestBroker(t *testing.T, aliasMax *uint16) (string, <-chan *packets.Publish) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	pubs := make(chan *packets.Publish, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			cp, err := packets.ReadPacket(conn)
+			if err != nil {
+				return
+			}
+			switch p := cp.Content.(type) {
+			case *packets.Connect:
+				_, _ = (&packets.Connack{Properties: &packets.Properties{
+					TopicAliasMaximum: aliasMax,
+				}}).WriteTo(conn)
+			case *packets.Publish:
+				pubs <- p
+				if p.QoS == 1 {
+					_, _ = (&packets.Puback{PacketID: p.PacketID, Properties: &packets.Properties{}}).WriteTo(conn)
+				}
+			case *packets.Pingreq:
+				_, _ = (&packets.Pingresp{}).WriteTo(conn)
+			case *packets.Disconnect:
+				return
+			}
+		}
+	}()
+
+	return "tcp://" + ln.Addr().String(), pubs
+}
+
+func newMQTT5TestWriter(t *testing.T, conf MQTT5Config) *MQTT5 {
+	t.Helper()
+
+	m, err := NewMQTT5(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		m.CloseAsync()
+		require.NoError(t, m.WaitForClose(time.Second))
+	})
+
+	require.NoError(t, m.Connect())
+	return m
+}
+
+func writeMQTT5TestMessage(t *testing.T, m *MQTT5, topic string) {
+	t.Helper()
+
+	msg := message.New([][]byte{[]byte("hello")})
+	msg.Get(0).Metadata().Set("topic", topic)
+	require.NoError(t, m.WriteWithContext(context.Background(), msg))
+}
+
+func TestMQTT5UserPropertiesAndExpiry(t *testing.T) {
+	url, pubs := runMQTT5TestBroker(t, nil)
+
+	conf := NewMQTT5Config()
+	conf.URLs = []string{url}
+	conf.Topic = "foo/bar"
+	conf.MessageExpiryInterval = "1m"
+
+	m := newMQTT5TestWriter(t, conf)
+
+	msg := message.New([][]byte{[]byte("hello")})
+	msg.Get(0).Metadata().Set("foo", "bar").Set("baz", "qux")
+	require.NoError(t, m.WriteWithContext(context.Background(), msg))
+
+	pub := <-pubs
+	assert.Equal(t, "foo/bar", pub.Topic)
+	assert.Equal(t, "hello", string(pub.Payload))
+	assert.ElementsMatch(t, []packets.User{
+		{Key: "foo", Value: "bar"},
+		{Key: "baz", Value: "qux"},
+	}, pub.Properties.User)
+	require.NotNil(t, pub.Properties.MessageExpiry)
+	assert.Equal(t, uint32(60), *pub.Properties.MessageExpiry)
+}
+
+func TestMQTT5TopicAliases(t *testing.T) {
+	brokerMax := uint16(5)
+	url, pubs := runMQTT5TestBroker(t, &brokerMax)
+
+	conf := NewMQTT5Config()
+	conf.URLs = []string{url}
+	conf.Topic = `${! meta("topic") }`
+	conf.TopicAliasMaximum = 2
+
+	m := newMQTT5TestWriter(t, conf)
+
+	type aliasedTopic struct {
+		topic string
+		alias uint16
+	}
+
+	var received []aliasedTopic
+	for _, topic := range []string{"foo", "foo", "bar", "baz", "baz", "foo", "bar"} {
+		writeMQTT5TestMessage(t, m, topic)
+
+		pub := <-pubs
+		at := aliasedTopic{topic: pub.Topic}
+		if pub.Properties.TopicAlias != nil {
+			at.alias = *pub.Properties.TopicAlias
+		}
+		received = append(received, at)
+	}
+
+	assert.Equal(t, []aliasedTopic{
+		{topic: "foo", alias: 1},
+		{topic: "", alias: 1},
+		{topic: "bar", alias: 2},
+		{topic: "baz"},
+		{topic: "baz"},
+		{topic: "", alias: 1},
+		{topic: "", alias: 2},
+	}, received)
+}
+
+func TestMQTT5TopicAliasesNotSupported(t *testing.T) {
+	url, pubs := runMQTT5TestBroker(t, nil)
+
+	conf := NewMQTT5Config()
+	conf.URLs = []string{url}
+	conf.Topic = "foo"
+	conf.TopicAliasMaximum = 10
+
+	m := newMQTT5TestWriter(t, conf)
+
+	for i := 0; i < 2; i++ {
+		writeMQTT5TestMessage(t, m, "foo")
+
+		pub := <-pubs
+		assert.Equal(t, "foo", pub.Topic)
+		assert.Nil(t, pub.Properties.TopicAlias)
+	}
+}
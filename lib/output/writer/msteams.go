@@ -0,0 +1,144 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// MSTeamsConfig contains configuration fields for the Microsoft Teams output
+// type.
+type MSTeamsConfig struct {
+	URL            string `json:"url" yaml:"url"`
+	Text           string `json:"text" yaml:"text"`
+	PayloadMapping string `json:"payload_mapping" yaml:"payload_mapping"`
+	Timeout        string `json:"timeout" yaml:"timeout"`
+	MaxInFlight    int    `json:"max_in_flight" yaml:"max_in_flight"`
+}
+
+// NewMSTeamsConfig creates a new MSTeamsConfig with default values.
+func NewMSTeamsConfig() MSTeamsConfig {
+	return MSTeamsConfig{
+		URL:            "",
+		Text:           "${! content() }",
+		PayloadMapping: "",
+		Timeout:        "5s",
+		MaxInFlight:    1,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MSTeams is a writer type that posts messages to a Microsoft Teams incoming
+// webhook.
+type MSTeams struct {
+	conf MSTeamsConfig
+
+	text           field.Expression
+	payloadMapping *mapping.Executor
+
+	webhook *chatWebhook
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewMSTeams creates a new Microsoft Teams writer type.
+func NewMSTeams(conf MSTeamsConfig, log log.Modular, stats metrics.Type) (*MSTeams, error) {
+	t := &MSTeams{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	if conf.URL == "" {
+		return nil, errors.New("a url must be specified")
+	}
+	if conf.Text == "" && conf.PayloadMapping == "" {
+		return nil, errors.New("either a text or payload mapping must be specified")
+	}
+
+	var err error
+	if conf.Text != "" {
+		if t.text, err = bloblang.NewField(conf.Text); err != nil {
+			return nil, fmt.Errorf("failed to parse text expression: %v", err)
+		}
+	}
+	if conf.PayloadMapping != "" {
+		if t.payloadMapping, err = newMapping("payload mapping", conf.PayloadMapping); err != nil {
+			return nil, err
+		}
+	}
+	if t.webhook, err = newChatWebhook("Microsoft Teams", conf.Timeout, log); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// payload builds the webhook payload of a message of a batch, where the fields
+// of the payload mapping take precedence over other fields.
+func (t *MSTeams) payload(index int, msg types.Message) (map[string]interface{}, error) {
+	payload, err := chatPayload(t.payloadMapping, index, msg)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := payload["text"]; !exists && t.text != nil {
+		if text := t.text.String(index, msg); text != "" {
+			payload["text"] = text
+		}
+	}
+	return payload, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as the Microsoft Teams output writes with plain HTTP
+// requests.
+func (t *MSTeams) Connect() error {
+	return t.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as the Microsoft Teams output writes with
+// plain HTTP requests.
+func (t *MSTeams) ConnectWithContext(ctx context.Context) error {
+	t.log.Infoln("Posting messages to a Microsoft Teams webhook")
+	return nil
+}
+
+// Write attempts to post a message batch to Microsoft Teams.
+func (t *MSTeams) Write(msg types.Message) error {
+	return t.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext posts each message of a batch to Microsoft Teams in order.
+func (t *MSTeams) WriteWithContext(ctx context.Context, msg types.Message) error {
+	return IterateBatchedSend(msg, func(i int, _ types.Part) error {
+		payload, err := t.payload(i, msg)
+		if err != nil {
+			return err
+		}
+		return t.webhook.post(ctx, t.conf.URL, payload)
+	})
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (t *MSTeams) CloseAsync() {
+	t.webhook.closeAsync()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (t *MSTeams) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
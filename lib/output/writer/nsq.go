@@ -26,6 +26,8 @@ type NSQConfig struct {
 	Topic       string      `json:"topic" yaml:"topic"`
 	UserAgent   string      `json:"user_agent" yaml:"user_agent"`
 	TLS         btls.Config `json:"tls" yaml:"tls"`
+	AuthSecret  string      `json:"auth_secret" yaml:"auth_secret"`
+	DeferDelay  string      `json:"defer_delay" yaml:"defer_delay"`
 	MaxInFlight int         `json:"max_in_flight" yaml:"max_in_flight"`
 }
 
@@ -36,6 +38,8 @@ func NewNSQConfig() NSQConfig {
 		Topic:       "benthos_messages",
 		UserAgent:   "benthos_producer",
 		TLS:         btls.NewConfig(),
+		AuthSecret:  "",
+		DeferDelay:  "",
 		MaxInFlight: 1,
 	}
 }
@@ -47,6 +51,7 @@ type NSQ struct {
 	log log.Modular
 
 	topicStr field.Expression
+	delayStr field.Expression
 
 	tlsConf  *tls.Config
 	connMut  sync.RWMutex
@@ -65,6 +70,11 @@ func NewNSQ(conf NSQConfig, log log.Modular, stats metrics.Type) (*NSQ, error) {
 	if n.topicStr, err = bloblang.NewField(conf.Topic); err != nil {
 		return nil, fmt.Errorf("failed to parse topic expression: %v", err)
 	}
+	if len(conf.DeferDelay) > 0 {
+		if n.delayStr, err = bloblang.NewField(conf.DeferDelay); err != nil {
+			return nil, fmt.Errorf("failed to parse defer delay expression: %v", err)
+		}
+	}
 	if conf.TLS.Enabled {
 		if n.tlsConf, err = conf.TLS.Get(); err != nil {
 			return nil, err
@@ -87,6 +97,7 @@ func (n *NSQ) Connect() error {
 
 	cfg := nsq.NewConfig()
 	cfg.UserAgent = n.conf.UserAgent
+	cfg.AuthSecret = n.conf.AuthSecret
 	if n.tlsConf != nil {
 		cfg.TlsV1 = true
 		cfg.TlsConfig = n.tlsConf
@@ -123,7 +134,19 @@ func (n *NSQ) Write(msg types.Message) error {
 	}
 
 	return IterateBatchedSend(msg, func(i int, p types.Part) error {
-		return prod.Publish(n.topicStr.String(i, msg), p.Get())
+		topic := n.topicStr.String(i, msg)
+		if n.delayStr != nil {
+			if delayStr := n.delayStr.String(i, msg); len(delayStr) > 0 {
+				delay, err := time.ParseDuration(delayStr)
+				if err != nil {
+					return fmt.Errorf("failed to parse defer delay: %v", err)
+				}
+				if delay > 0 {
+					return prod.DeferredPublish(topic, delay, p.Get())
+				}
+			}
+		}
+		return prod.Publish(topic, p.Get())
 	})
 }
 
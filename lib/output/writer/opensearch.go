@@ -0,0 +1,97 @@
+package writer
+
+import (
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+// OpenSearchConfig contains configuration fields for the OpenSearch output
+// type.
+type OpenSearchConfig struct {
+	URLs           []string             `json:"urls" yaml:"urls"`
+	ID             string               `json:"id" yaml:"id"`
+	Action         string               `json:"action" yaml:"action"`
+	Index          string               `json:"index" yaml:"index"`
+	Pipeline       string               `json:"pipeline" yaml:"pipeline"`
+	Routing        string               `json:"routing" yaml:"routing"`
+	Upsert         bool                 `json:"upsert" yaml:"upsert"`
+	Script         string               `json:"script" yaml:"script"`
+	Timeout        string               `json:"timeout" yaml:"timeout"`
+	TLS            btls.Config          `json:"tls" yaml:"tls"`
+	Auth           auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	AWS            OptionalAWSConfig    `json:"aws" yaml:"aws"`
+	MaxInFlight    int                  `json:"max_in_flight" yaml:"max_in_flight"`
+	retries.Config `json:",inline" yaml:",inline"`
+	Batching       batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewOpenSearchConfig creates a new OpenSearchConfig with default values.
+func NewOpenSearchConfig() OpenSearchConfig {
+	rConf := retries.NewConfig()
+	rConf.Backoff.InitialInterval = "1s"
+	rConf.Backoff.MaxInterval = "5s"
+	rConf.Backoff.MaxElapsedTime = "30s"
+
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+
+	return OpenSearchConfig{
+		URLs:     []string{"http://localhost:9200"},
+		ID:       `${!count("opensearch_ids")}-${!timestamp_unix()}`,
+		Action:   "index",
+		Index:    "benthos_index",
+		Pipeline: "",
+		Routing:  "",
+		Upsert:   false,
+		Script:   "",
+		Timeout:  "5s",
+		TLS:      btls.NewConfig(),
+		Auth:     auth.NewBasicAuthConfig(),
+		AWS: OptionalAWSConfig{
+			Enabled: false,
+			Config:  sess.NewConfig(),
+		},
+		MaxInFlight: 1,
+		Config:      rConf,
+		Batching:    batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewOpenSearch creates a new writer type that writes messages into
+// OpenSearch. OpenSearch is compatible with the bulk API of Elasticsearch and
+// therefore the writer is an Elasticsearch writer with sniffing, healthchecks
+// and document types disabled, as these are unsupported by Amazon OpenSearch
+// Service.
+func NewOpenSearch(conf OpenSearchConfig, log log.Modular, stats metrics.Type) (*Elasticsearch, error) {
+	return NewElasticsearch(ElasticsearchConfig{
+		URLs:        conf.URLs,
+		Sniff:       false,
+		Healthcheck: false,
+		ID:          conf.ID,
+		Action:      conf.Action,
+		Index:       conf.Index,
+		Pipeline:    conf.Pipeline,
+		Routing:     conf.Routing,
+		Type:        "",
+		Upsert:      conf.Upsert,
+		Script:      conf.Script,
+		Timeout:     conf.Timeout,
+		TLS:         conf.TLS,
+		Auth:        conf.Auth,
+		AWS:         conf.AWS,
+		MaxInFlight: conf.MaxInFlight,
+		Config:      conf.Config,
+		Batching:    conf.Batching,
+	}, log, stats)
+}
+
+//------------------------------------------------------------------------------
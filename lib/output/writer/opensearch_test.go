@@ -0,0 +1,71 @@
+package writer
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockOpenSearch(t *testing.T, m *mockBulkServer, fn func(conf *OpenSearchConfig)) *Elasticsearch {
+	t.Helper()
+
+	server := httptest.NewServer(m)
+	t.Cleanup(server.Close)
+
+	conf := NewOpenSearchConfig()
+	conf.URLs = []string{server.URL}
+	if fn != nil {
+		fn(&conf)
+	}
+
+	e, err := NewOpenSearch(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, e.Connect())
+	return e
+}
+
+func TestOpenSearchDataStream(t *testing.T) {
+	m := &mockBulkServer{}
+	e := newMockOpenSearch(t, m, func(conf *OpenSearchConfig) {
+		conf.Index = "logs-benthos"
+		conf.Action = "create"
+		conf.ID = ""
+	})
+
+	require.NoError(t, e.Write(message.New([][]byte{
+		[]byte(`{"@timestamp":"2020-01-01T00:00:00Z","message":"foo"}`),
+		[]byte(`{"@timestamp":"2020-01-01T00:00:01Z","message":"bar"}`),
+	})))
+
+	require.Len(t, m.requests, 1)
+	assert.Equal(t, []map[string]interface{}{
+		{"create": map[string]interface{}{"_index": "logs-benthos"}},
+		{"@timestamp": "2020-01-01T00:00:00Z", "message": "foo"},
+		{"create": map[string]interface{}{"_index": "logs-benthos"}},
+		{"@timestamp": "2020-01-01T00:00:01Z", "message": "bar"},
+	}, m.requests[0])
+}
+
+func TestOpenSearchAWSSigning(t *testing.T) {
+	m := &mockBulkServer{}
+	e := newMockOpenSearch(t, m, func(conf *OpenSearchConfig) {
+		conf.AWS.Enabled = true
+		conf.AWS.Region = "eu-west-1"
+		conf.AWS.Credentials.ID = "foo"
+		conf.AWS.Credentials.Secret = "bar"
+	})
+
+	require.NoError(t, e.Write(message.New([][]byte{[]byte(`{"id":"1"}`)})))
+
+	require.Len(t, m.headers, 1)
+	authHeader := m.headers[0].Get("Authorization")
+	assert.True(t, strings.HasPrefix(authHeader, "AWS4-HMAC-SHA256 Credential=foo/"), authHeader)
+	assert.Contains(t, authHeader, "/eu-west-1/es/aws4_request")
+	assert.NotEmpty(t, m.headers[0].Get("X-Amz-Date"))
+}
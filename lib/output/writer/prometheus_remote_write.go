@@ -0,0 +1,381 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	batchInternal "github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/http/auth"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+//------------------------------------------------------------------------------
+
+// PrometheusRemoteWriteConfig contains configuration fields for the Prometheus
+// remote write output type.
+type PrometheusRemoteWriteConfig struct {
+	URL              string               `json:"url" yaml:"url"`
+	Name             string               `json:"name" yaml:"name"`
+	LabelsMapping    string               `json:"labels_mapping" yaml:"labels_mapping"`
+	ValueMapping     string               `json:"value_mapping" yaml:"value_mapping"`
+	TimestampMapping string               `json:"timestamp_mapping" yaml:"timestamp_mapping"`
+	Headers          map[string]string    `json:"headers" yaml:"headers"`
+	BasicAuth        auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	TLS              btls.Config          `json:"tls" yaml:"tls"`
+	Timeout          string               `json:"timeout" yaml:"timeout"`
+	MaxInFlight      int                  `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching         batch.PolicyConfig   `json:"batching" yaml:"batching"`
+}
+
+// NewPrometheusRemoteWriteConfig creates a new PrometheusRemoteWriteConfig
+// with default values.
+func NewPrometheusRemoteWriteConfig() PrometheusRemoteWriteConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return PrometheusRemoteWriteConfig{
+		URL:              "",
+		Name:             "",
+		LabelsMapping:    "",
+		ValueMapping:     "root = this.value",
+		TimestampMapping: "",
+		Headers:          map[string]string{},
+		BasicAuth:        auth.NewBasicAuthConfig(),
+		TLS:              btls.NewConfig(),
+		Timeout:          "5s",
+		MaxInFlight:      1,
+		Batching:         batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var promLabelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+type promLabel struct {
+	name, value string
+}
+
+type promSample struct {
+	value     float64
+	timestamp int64
+}
+
+type promSeries struct {
+	labels  []promLabel
+	samples []promSample
+}
+
+// PrometheusRemoteWrite is a writer type that converts messages into samples
+// and writes them to an endpoint implementing the Prometheus remote write
+// protocol.
+type PrometheusRemoteWrite struct {
+	conf PrometheusRemoteWriteConfig
+
+	name             field.Expression
+	labelsMapping    *mapping.Executor
+	valueMapping     *mapping.Executor
+	timestampMapping *mapping.Executor
+
+	httpClient *http.Client
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewPrometheusRemoteWrite creates a new Prometheus remote write writer type.
+func NewPrometheusRemoteWrite(conf PrometheusRemoteWriteConfig, log log.Modular, stats metrics.Type) (*PrometheusRemoteWrite, error) {
+	p := &PrometheusRemoteWrite{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+
+	if conf.URL == "" {
+		return nil, errors.New("a url must be specified")
+	}
+	if conf.Name == "" {
+		return nil, errors.New("a name must be specified")
+	}
+	var err error
+	if p.name, err = bloblang.NewField(conf.Name); err != nil {
+		return nil, fmt.Errorf("failed to parse name expression: %v", err)
+	}
+	if conf.LabelsMapping != "" {
+		if p.labelsMapping, err = newMapping("labels mapping", conf.LabelsMapping); err != nil {
+			return nil, err
+		}
+	}
+	if conf.ValueMapping == "" {
+		return nil, errors.New("a value mapping must be specified")
+	}
+	if p.valueMapping, err = newMapping("value mapping", conf.ValueMapping); err != nil {
+		return nil, err
+	}
+	if conf.TimestampMapping != "" {
+		if p.timestampMapping, err = newMapping("timestamp mapping", conf.TimestampMapping); err != nil {
+			return nil, err
+		}
+	}
+
+	timeout, err := time.ParseDuration(conf.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+	}
+	p.httpClient = &http.Client{Timeout: timeout}
+	if conf.TLS.Enabled {
+		tlsConf, err := conf.TLS.Get()
+		if err != nil {
+			return nil, err
+		}
+		p.httpClient.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConf,
+		}
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// sample converts a message of a batch into the sorted labels of a series and
+// a sample of it.
+func (p *PrometheusRemoteWrite) sample(index int, msg types.Message) ([]promLabel, promSample, error) {
+	var sample promSample
+
+	name := p.name.String(index, msg)
+	if !promLabelNameRegexp.MatchString(strings.ReplaceAll(name, ":", "_")) {
+		return nil, sample, fmt.Errorf("invalid metric name: %q", name)
+	}
+	labels := []promLabel{{name: "__name__", value: name}}
+
+	if p.labelsMapping != nil {
+		res, err := execMapping(p.labelsMapping, index, msg)
+		if err != nil {
+			return nil, sample, fmt.Errorf("failed to execute labels mapping: %w", err)
+		}
+		var labelsObj map[string]interface{}
+		switch t := res.(type) {
+		case query.Delete, query.Nothing, nil:
+		case map[string]interface{}:
+			labelsObj = t
+		default:
+			return nil, sample, fmt.Errorf("labels mapping returned non-object result: %v", query.ITypeOf(res))
+		}
+		for k, v := range labelsObj {
+			if !promLabelNameRegexp.MatchString(k) || strings.HasPrefix(k, "__") {
+				return nil, sample, fmt.Errorf("invalid label name: %q", k)
+			}
+			if v == nil {
+				continue
+			}
+			if str := query.IToString(v); str != "" {
+				labels = append(labels, promLabel{name: k, value: str})
+			}
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+
+	res, err := execMapping(p.valueMapping, index, msg)
+	if err != nil {
+		return nil, sample, fmt.Errorf("failed to execute value mapping: %w", err)
+	}
+	if sample.value, err = query.IGetNumber(res); err != nil {
+		return nil, sample, fmt.Errorf("value mapping returned invalid result: %w", err)
+	}
+
+	sample.timestamp = time.Now().UnixNano() / int64(time.Millisecond)
+	if p.timestampMapping != nil {
+		res, err := execMapping(p.timestampMapping, index, msg)
+		if err != nil {
+			return nil, sample, fmt.Errorf("failed to execute timestamp mapping: %w", err)
+		}
+		switch t := res.(type) {
+		case query.Delete, query.Nothing, nil:
+		case string:
+			ts, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return nil, sample, fmt.Errorf("failed to parse timestamp mapping result: %w", err)
+			}
+			sample.timestamp = ts.UnixNano() / int64(time.Millisecond)
+		default:
+			if sample.timestamp, err = query.IGetInt(res); err != nil {
+				return nil, sample, fmt.Errorf("timestamp mapping returned invalid result: %w", err)
+			}
+		}
+	}
+	return labels, sample, nil
+}
+
+// encodeWriteRequest encodes series as a WriteRequest protobuf message of the
+// remote write protocol.
+func encodeWriteRequest(series []*promSeries) []byte {
+	var req []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, l.name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, l.value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+		for _, smp := range s.samples {
+			var sample []byte
+			sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+			sample = protowire.AppendFixed64(sample, math.Float64bits(smp.value))
+			sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+			sample = protowire.AppendVarint(sample, uint64(smp.timestamp))
+
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sample)
+		}
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as the Prometheus remote write output writes with plain
+// HTTP requests.
+func (p *PrometheusRemoteWrite) Connect() error {
+	return p.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as the Prometheus remote write output writes
+// with plain HTTP requests.
+func (p *PrometheusRemoteWrite) ConnectWithContext(ctx context.Context) error {
+	p.log.Infof("Writing samples to Prometheus remote write endpoint: %v\n", p.conf.URL)
+	return nil
+}
+
+// Write attempts to write a message batch to a Prometheus remote write
+// endpoint.
+func (p *PrometheusRemoteWrite) Write(msg types.Message) error {
+	return p.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext converts the messages of a batch into samples, grouped by
+// the series they belong to, and writes them with a single request. Messages
+// that cannot be converted are rejected individually and the remaining samples
+// are written regardless.
+func (p *PrometheusRemoteWrite) WriteWithContext(ctx context.Context, msg types.Message) error {
+	var batchErr *batchInternal.Error
+	var series []*promSeries
+	var indexes []int
+	seriesByKey := map[string]*promSeries{}
+	msg.Iter(func(i int, _ types.Part) error {
+		labels, sample, err := p.sample(i, msg)
+		if err != nil {
+			p.log.Errorf("Failed to convert message into a sample: %v\n", err)
+			if batchErr == nil {
+				batchErr = batchInternal.NewError(msg, err)
+			}
+			batchErr.Failed(i, err)
+			return nil
+		}
+
+		var key strings.Builder
+		for _, l := range labels {
+			key.WriteString(l.name)
+			key.WriteByte(0)
+			key.WriteString(l.value)
+			key.WriteByte(0)
+		}
+		s, exists := seriesByKey[key.String()]
+		if !exists {
+			s = &promSeries{labels: labels}
+			seriesByKey[key.String()] = s
+			series = append(series, s)
+		}
+		s.samples = append(s.samples, sample)
+		indexes = append(indexes, i)
+		return nil
+	})
+
+	if len(indexes) > 0 {
+		for _, s := range series {
+			sort.SliceStable(s.samples, func(i, j int) bool {
+				return s.samples[i].timestamp < s.samples[j].timestamp
+			})
+		}
+		if err := p.post(ctx, snappy.Encode(nil, encodeWriteRequest(series))); err != nil {
+			if batchErr == nil {
+				return err
+			}
+			for _, i := range indexes {
+				batchErr.Failed(i, err)
+			}
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+func (p *PrometheusRemoteWrite) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequest("POST", p.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range p.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if err = p.conf.BasicAuth.Sign(req); err != nil {
+		return err
+	}
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to write samples: %v: %s", res.Status, bytes.TrimSpace(resBody))
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (p *PrometheusRemoteWrite) CloseAsync() {
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (p *PrometheusRemoteWrite) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,208 @@
+package writer
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodePromWriteRequest decodes a WriteRequest protobuf message into series.
+func decodePromWriteRequest(t *testing.T, b []byte) []*promSeries {
+	t.Helper()
+
+	fields := func(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) int) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			require.True(t, n > 0)
+			b = b[n:]
+			n = fn(num, typ, b)
+			require.True(t, n > 0)
+			b = b[n:]
+		}
+	}
+
+	var series []*promSeries
+	fields(b, func(_ protowire.Number, _ protowire.Type, b []byte) int {
+		tsBytes, n := protowire.ConsumeBytes(b)
+		s := &promSeries{}
+		fields(tsBytes, func(num protowire.Number, _ protowire.Type, b []byte) int {
+			msgBytes, n := protowire.ConsumeBytes(b)
+			switch num {
+			case 1:
+				var l promLabel
+				fields(msgBytes, func(num protowire.Number, _ protowire.Type, b []byte) int {
+					v, n := protowire.ConsumeString(b)
+					if num == 1 {
+						l.name = v
+					} else {
+						l.value = v
+					}
+					return n
+				})
+				s.labels = append(s.labels, l)
+			case 2:
+				var smp promSample
+				fields(msgBytes, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					if num == 1 {
+						v, n := protowire.ConsumeFixed64(b)
+						smp.value = math.Float64frombits(v)
+						return n
+					}
+					v, n := protowire.ConsumeVarint(b)
+					smp.timestamp = int64(v)
+					return n
+				})
+				s.samples = append(s.samples, smp)
+			}
+			return n
+		})
+		series = append(series, s)
+		return n
+	})
+	return series
+}
+
+func TestPrometheusRemoteWriteConfigErrors(t *testing.T) {
+	tests := map[string]func(c *PrometheusRemoteWriteConfig){
+		"no url": func(c *PrometheusRemoteWriteConfig) {
+			c.URL = ""
+		},
+		"no name": func(c *PrometheusRemoteWriteConfig) {
+			c.Name = ""
+		},
+		"bad labels mapping": func(c *PrometheusRemoteWriteConfig) {
+			c.LabelsMapping = "root = {"
+		},
+		"no value mapping": func(c *PrometheusRemoteWriteConfig) {
+			c.ValueMapping = ""
+		},
+		"bad timeout": func(c *PrometheusRemoteWriteConfig) {
+			c.Timeout = "nope"
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewPrometheusRemoteWriteConfig()
+			conf.URL = "http://localhost:9090/api/v1/write"
+			conf.Name = "foo"
+			test(&conf)
+
+			_, err := NewPrometheusRemoteWrite(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPrometheusRemoteWrite(t *testing.T) {
+	var reqs []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		reqs = append(reqs, r)
+		bodies = append(bodies, b)
+	}))
+	defer server.Close()
+
+	conf := NewPrometheusRemoteWriteConfig()
+	conf.URL = server.URL + "/api/v1/write"
+	conf.Name = `${! json("name") }`
+	conf.LabelsMapping = `root = this.labels`
+	conf.TimestampMapping = `root = this.ts`
+	conf.Headers = map[string]string{"X-Scope-OrgID": "foo"}
+	conf.BasicAuth.Enabled = true
+	conf.BasicAuth.Username = "user"
+	conf.BasicAuth.Password = "pass"
+
+	p, err := NewPrometheusRemoteWrite(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, p.Connect())
+
+	err = p.Write(message.New([][]byte{
+		[]byte(`{"name":"http_requests_total","labels":{"code":"200","path":"/"},"value":3,"ts":2000}`),
+		[]byte(`{"name":"http_requests_total","labels":{"path":"/","code":"200"},"value":2,"ts":1000}`),
+		[]byte(`{"name":"node:load1","labels":{"empty":"","host":"a"},"value":0.5,"ts":"2020-09-13T12:26:40Z"}`),
+		[]byte(`{"name":"bad-name","value":1}`),
+		[]byte(`{"name":"foo","labels":{"__bad":"a"},"value":1}`),
+		[]byte(`{"name":"foo","value":"nope"}`),
+	}))
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok)
+	var failed []int
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{3, 4, 5}, failed)
+
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "/api/v1/write", reqs[0].URL.Path)
+	assert.Equal(t, "snappy", reqs[0].Header.Get("Content-Encoding"))
+	assert.Equal(t, "application/x-protobuf", reqs[0].Header.Get("Content-Type"))
+	assert.Equal(t, "0.1.0", reqs[0].Header.Get("X-Prometheus-Remote-Write-Version"))
+	assert.Equal(t, "foo", reqs[0].Header.Get("X-Scope-OrgID"))
+	user, pass, ok := reqs[0].BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", user)
+	assert.Equal(t, "pass", pass)
+
+	body, err := snappy.Decode(nil, bodies[0])
+	require.NoError(t, err)
+	assert.Equal(t, []*promSeries{
+		{
+			labels: []promLabel{
+				{name: "__name__", value: "http_requests_total"},
+				{name: "code", value: "200"},
+				{name: "path", value: "/"},
+			},
+			samples: []promSample{
+				{value: 2, timestamp: 1000},
+				{value: 3, timestamp: 2000},
+			},
+		},
+		{
+			labels: []promLabel{
+				{name: "__name__", value: "node:load1"},
+				{name: "host", value: "a"},
+			},
+			samples: []promSample{
+				{value: 0.5, timestamp: 1600000000000},
+			},
+		},
+	}, decodePromWriteRequest(t, body))
+}
+
+func TestPrometheusRemoteWriteError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	conf := NewPrometheusRemoteWriteConfig()
+	conf.URL = server.URL
+	conf.Name = "foo"
+
+	p, err := NewPrometheusRemoteWrite(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	err = p.Write(message.New([][]byte{[]byte(`{"value":1}`)}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400 Bad Request: out of order sample")
+}
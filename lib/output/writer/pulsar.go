@@ -0,0 +1,178 @@
+// +build !wasm
+
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/pulsarconf"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+//------------------------------------------------------------------------------
+
+// Pulsar is a benthos writer.Type implementation that writes messages to a
+// Pulsar topic.
+type Pulsar struct {
+	client   pulsar.Client
+	producer pulsar.Producer
+	connMut  sync.RWMutex
+
+	key field.Expression
+
+	conf  PulsarConfig
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewPulsar creates a new Pulsar writer.Type.
+func NewPulsar(conf PulsarConfig, log log.Modular, stats metrics.Type) (*Pulsar, error) {
+	if conf.URL == "" {
+		return nil, errors.New("a url must be specified")
+	}
+	if conf.Topic == "" {
+		return nil, errors.New("a topic must be specified")
+	}
+	key, err := bloblang.NewField(conf.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key expression: %v", err)
+	}
+	return &Pulsar{
+		key:   key,
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a connection to a Pulsar cluster.
+func (p *Pulsar) Connect() error {
+	return p.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext attempts to establish a connection to a Pulsar cluster
+// and create a producer for the target topic.
+func (p *Pulsar) ConnectWithContext(ctx context.Context) error {
+	p.connMut.Lock()
+	defer p.connMut.Unlock()
+
+	if p.client != nil {
+		return nil
+	}
+
+	client, err := pulsar.NewClient(pulsarconf.ClientOptions(p.conf.URL, p.conf.Auth, p.conf.TLS, p.log))
+	if err != nil {
+		return err
+	}
+
+	producer, err := client.CreateProducer(pulsar.ProducerOptions{
+		Topic: p.conf.Topic,
+	})
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	p.client = client
+	p.producer = producer
+
+	p.log.Infof("Sending Pulsar messages to topic '%v'\n", p.conf.Topic)
+	return nil
+}
+
+// disconnect safely closes a producer and client.
+func (p *Pulsar) disconnect() {
+	p.connMut.Lock()
+	defer p.connMut.Unlock()
+
+	if p.producer != nil {
+		p.producer.Close()
+		p.producer = nil
+	}
+	if p.client != nil {
+		p.client.Close()
+		p.client = nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Write attempts to write a message to the target Pulsar topic.
+func (p *Pulsar) Write(msg types.Message) error {
+	return p.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext attempts to write a message to the target Pulsar topic.
+// Batched messages are sent asynchronously and flushed together.
+func (p *Pulsar) WriteWithContext(ctx context.Context, msg types.Message) error {
+	p.connMut.RLock()
+	producer := p.producer
+	p.connMut.RUnlock()
+
+	if producer == nil {
+		return types.ErrNotConnected
+	}
+
+	var wg sync.WaitGroup
+	var errMut sync.Mutex
+	var errs []error
+
+	wg.Add(msg.Len())
+	msg.Iter(func(i int, part types.Part) error {
+		pMsg := &pulsar.ProducerMessage{
+			Payload: part.Get(),
+			Key:     p.key.String(i, msg),
+		}
+		properties := map[string]string{}
+		part.Metadata().Iter(func(k, v string) error {
+			properties[k] = v
+			return nil
+		})
+		if len(properties) > 0 {
+			pMsg.Properties = properties
+		}
+		producer.SendAsync(ctx, pMsg, func(_ pulsar.MessageID, _ *pulsar.ProducerMessage, err error) {
+			if err != nil {
+				errMut.Lock()
+				errs = append(errs, err)
+				errMut.Unlock()
+			}
+			wg.Done()
+		})
+		return nil
+	})
+
+	if err := producer.Flush(); err != nil {
+		p.log.Errorf("Failed to flush producer: %v\n", err)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to send messages: %v", errs)
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (p *Pulsar) CloseAsync() {
+	go p.disconnect()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (p *Pulsar) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,34 @@
+package writer
+
+import (
+	"github.com/Jeffail/benthos/v3/internal/pulsarconf"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+)
+
+//------------------------------------------------------------------------------
+
+// PulsarConfig contains configuration fields for the Pulsar output type.
+type PulsarConfig struct {
+	URL         string             `json:"url" yaml:"url"`
+	Topic       string             `json:"topic" yaml:"topic"`
+	Key         string             `json:"key" yaml:"key"`
+	MaxInFlight int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Auth        pulsarconf.Auth    `json:"auth" yaml:"auth"`
+	TLS         pulsarconf.TLS     `json:"tls" yaml:"tls"`
+	Batching    batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewPulsarConfig creates a new PulsarConfig with default values.
+func NewPulsarConfig() PulsarConfig {
+	return PulsarConfig{
+		URL:         "pulsar://localhost:6650",
+		Topic:       "",
+		Key:         "",
+		MaxInFlight: 1,
+		Auth:        pulsarconf.NewAuth(),
+		TLS:         pulsarconf.NewTLS(),
+		Batching:    batch.NewPolicyConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
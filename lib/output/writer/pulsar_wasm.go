@@ -0,0 +1,19 @@
+// +build wasm
+
+package writer
+
+import (
+	"errors"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+)
+
+//------------------------------------------------------------------------------
+
+// NewPulsar returns an error as it is not supported in WASM builds.
+func NewPulsar(conf PulsarConfig, log log.Modular, stats metrics.Type) (dummy, error) {
+	return nil, errors.New("Pulsar is disabled in WASM builds")
+}
+
+//------------------------------------------------------------------------------
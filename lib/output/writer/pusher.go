@@ -0,0 +1,272 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	batchInternal "github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// PusherConfig contains configuration fields for the Pusher output type.
+type PusherConfig struct {
+	AppID       string             `json:"app_id" yaml:"app_id"`
+	Key         string             `json:"key" yaml:"key"`
+	Secret      string             `json:"secret" yaml:"secret"`
+	Cluster     string             `json:"cluster" yaml:"cluster"`
+	Endpoint    string             `json:"endpoint" yaml:"endpoint"`
+	Channel     string             `json:"channel" yaml:"channel"`
+	Event       string             `json:"event" yaml:"event"`
+	Timeout     string             `json:"timeout" yaml:"timeout"`
+	MaxInFlight int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching    batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewPusherConfig creates a new PusherConfig with default values.
+func NewPusherConfig() PusherConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return PusherConfig{
+		AppID:       "",
+		Key:         "",
+		Secret:      "",
+		Cluster:     "mt1",
+		Endpoint:    "",
+		Channel:     "",
+		Event:       "",
+		Timeout:     "5s",
+		MaxInFlight: 1,
+		Batching:    batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Limits of the Pusher Channels HTTP API.
+const (
+	pusherMaxBatchEvents = 10
+	pusherMaxDataSize    = 10240
+)
+
+type pusherEvent struct {
+	Channel string `json:"channel"`
+	Name    string `json:"name"`
+	Data    string `json:"data"`
+}
+
+// Pusher is a writer type that triggers events on Pusher Channels.
+type Pusher struct {
+	conf PusherConfig
+
+	endpoint string
+	channel  field.Expression
+	event    field.Expression
+
+	httpClient *http.Client
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewPusher creates a new Pusher writer type.
+func NewPusher(conf PusherConfig, log log.Modular, stats metrics.Type) (*Pusher, error) {
+	p := &Pusher{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	if conf.AppID == "" || conf.Key == "" || conf.Secret == "" {
+		return nil, errors.New("an app_id, key and secret must be specified")
+	}
+	if p.endpoint = conf.Endpoint; p.endpoint == "" {
+		if conf.Cluster == "" {
+			return nil, errors.New("either a cluster or an endpoint must be specified")
+		}
+		p.endpoint = "https://api-" + conf.Cluster + ".pusher.com"
+	}
+
+	if conf.Channel == "" {
+		return nil, errors.New("a channel must be specified")
+	}
+	var err error
+	if p.channel, err = bloblang.NewField(conf.Channel); err != nil {
+		return nil, fmt.Errorf("failed to parse channel expression: %v", err)
+	}
+	if conf.Event == "" {
+		return nil, errors.New("an event must be specified")
+	}
+	if p.event, err = bloblang.NewField(conf.Event); err != nil {
+		return nil, fmt.Errorf("failed to parse event expression: %v", err)
+	}
+
+	timeout, err := time.ParseDuration(conf.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+	}
+	p.httpClient = &http.Client{Timeout: timeout}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// signedURL returns the URL of a request to a path of the app with a body,
+// signed with the secret of the app.
+func (p *Pusher) signedURL(path string, body []byte) string {
+	bodyMD5 := md5.Sum(body)
+
+	// Encoded query parameters are sorted by key, which is what the signature
+	// is calculated from.
+	params := url.Values{}
+	params.Set("auth_key", p.conf.Key)
+	params.Set("auth_timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	params.Set("auth_version", "1.0")
+	params.Set("body_md5", hex.EncodeToString(bodyMD5[:]))
+	query := params.Encode()
+
+	mac := hmac.New(sha256.New, []byte(p.conf.Secret))
+	mac.Write([]byte("POST\n" + path + "\n" + query))
+	return p.endpoint + path + "?" + query + "&auth_signature=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *Pusher) trigger(ctx context.Context, events []pusherEvent) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"batch": events,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", p.signedURL("/apps/"+p.conf.AppID+"/batch_events", body), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to trigger events: %v: %s", res.Status, bytes.TrimSpace(resBody))
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as the Pusher output writes with plain HTTP requests.
+func (p *Pusher) Connect() error {
+	return p.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as the Pusher output writes with plain HTTP
+// requests.
+func (p *Pusher) ConnectWithContext(ctx context.Context) error {
+	p.log.Infof("Triggering events on Pusher app %v at: %v\n", p.conf.AppID, p.endpoint)
+	return nil
+}
+
+// Write attempts to write a message batch to Pusher.
+func (p *Pusher) Write(msg types.Message) error {
+	return p.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext converts the messages of a batch into events and triggers
+// them with as few requests as the limits of Pusher allow. Messages that cannot
+// be triggered are rejected individually.
+func (p *Pusher) WriteWithContext(ctx context.Context, msg types.Message) error {
+	var batchErr *batchInternal.Error
+	failed := func(i int, err error) {
+		if batchErr == nil {
+			batchErr = batchInternal.NewError(msg, err)
+		}
+		batchErr.Failed(i, err)
+	}
+
+	var events []pusherEvent
+	var indexes []int
+	flush := func() error {
+		if len(events) == 0 {
+			return nil
+		}
+		err := p.trigger(ctx, events)
+		if err != nil {
+			for _, i := range indexes {
+				failed(i, err)
+			}
+		}
+		events, indexes = nil, nil
+		return err
+	}
+
+	var lastErr error
+	msg.Iter(func(i int, part types.Part) error {
+		e := pusherEvent{
+			Channel: p.channel.String(i, msg),
+			Name:    p.event.String(i, msg),
+			Data:    string(part.Get()),
+		}
+		if e.Channel == "" {
+			failed(i, errors.New("channel is empty"))
+			return nil
+		}
+		if len(e.Data) > pusherMaxDataSize {
+			failed(i, fmt.Errorf("event data of %v bytes exceeds the limit of %v bytes", len(e.Data), pusherMaxDataSize))
+			return nil
+		}
+		events = append(events, e)
+		indexes = append(indexes, i)
+		if len(events) == pusherMaxBatchEvents {
+			if err := flush(); err != nil {
+				lastErr = err
+			}
+		}
+		return nil
+	})
+	if err := flush(); err != nil {
+		lastErr = err
+	}
+
+	if batchErr != nil {
+		if lastErr != nil && batchErr.IndexedErrors() == msg.Len() {
+			return lastErr
+		}
+		return batchErr
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (p *Pusher) CloseAsync() {
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (p *Pusher) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
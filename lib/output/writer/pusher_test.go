@@ -0,0 +1,187 @@
+package writer
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pusherTestServer struct {
+	*httptest.Server
+
+	mut     sync.Mutex
+	batches [][]pusherEvent
+	fail    bool
+}
+
+func newPusherTestServer(t *testing.T, secret string) *pusherTestServer {
+	t.Helper()
+
+	s := &pusherTestServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		bodyMD5 := md5.Sum(b)
+		if exp, act := hex.EncodeToString(bodyMD5[:]), r.URL.Query().Get("body_md5"); exp != act {
+			t.Errorf("Wrong body md5: %v != %v", act, exp)
+		}
+		query := r.URL.RawQuery[:strings.Index(r.URL.RawQuery, "&auth_signature=")]
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(r.Method + "\n" + r.URL.Path + "\n" + query))
+		if exp, act := hex.EncodeToString(mac.Sum(nil)), r.URL.Query().Get("auth_signature"); exp != act {
+			t.Errorf("Wrong signature: %v != %v", act, exp)
+		}
+
+		var body struct {
+			Batch []pusherEvent `json:"batch"`
+		}
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Error(err)
+		}
+
+		s.mut.Lock()
+		s.batches = append(s.batches, body.Batch)
+		fail := s.fail
+		s.mut.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *pusherTestServer) requests() [][]pusherEvent {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([][]pusherEvent(nil), s.batches...)
+}
+
+func TestPusherConfigErrors(t *testing.T) {
+	tests := map[string]func(c *PusherConfig){
+		"no secret": func(c *PusherConfig) {
+			c.Secret = ""
+		},
+		"no cluster or endpoint": func(c *PusherConfig) {
+			c.Cluster = ""
+		},
+		"no channel": func(c *PusherConfig) {
+			c.Channel = ""
+		},
+		"no event": func(c *PusherConfig) {
+			c.Event = ""
+		},
+		"bad channel": func(c *PusherConfig) {
+			c.Channel = "${! meta( }"
+		},
+	}
+
+	for name, fn := range tests {
+		fn := fn
+		t.Run(name, func(t *testing.T) {
+			conf := NewPusherConfig()
+			conf.AppID = "123"
+			conf.Key = "foo"
+			conf.Secret = "bar"
+			conf.Channel = "baz"
+			conf.Event = "update"
+			fn(&conf)
+
+			_, err := NewPusher(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPusherWrite(t *testing.T) {
+	srv := newPusherTestServer(t, "bar")
+
+	conf := NewPusherConfig()
+	conf.AppID = "123"
+	conf.Key = "foo"
+	conf.Secret = "bar"
+	conf.Endpoint = srv.URL
+	conf.Channel = `${! meta("channel") }`
+	conf.Event = "update"
+
+	p, err := NewPusher(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var parts [][]byte
+	for i := 0; i < 12; i++ {
+		parts = append(parts, []byte(`{"n":1}`))
+	}
+	parts = append(parts, []byte(strings.Repeat("a", pusherMaxDataSize+1)))
+
+	msg := message.New(parts)
+	msg.Iter(func(i int, p types.Part) error {
+		if i != 3 {
+			p.Metadata().Set("channel", "private-foo")
+		}
+		return nil
+	})
+
+	err = p.Write(msg)
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok)
+	var failed []int
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{3, 12}, failed)
+
+	reqs := srv.requests()
+	require.Len(t, reqs, 2)
+	assert.Len(t, reqs[0], 10)
+	assert.Len(t, reqs[1], 1)
+	assert.Equal(t, pusherEvent{
+		Channel: "private-foo",
+		Name:    "update",
+		Data:    `{"n":1}`,
+	}, reqs[1][0])
+}
+
+func TestPusherWriteFailed(t *testing.T) {
+	srv := newPusherTestServer(t, "bar")
+	srv.fail = true
+
+	conf := NewPusherConfig()
+	conf.AppID = "123"
+	conf.Key = "foo"
+	conf.Secret = "bar"
+	conf.Endpoint = srv.URL
+	conf.Channel = "foo"
+	conf.Event = "update"
+
+	p, err := NewPusher(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	err = p.Write(message.New([][]byte{[]byte("hello")}))
+	require.Error(t, err)
+	_, isBatchErr := err.(*batch.Error)
+	assert.False(t, isBatchErr)
+}
@@ -3,7 +3,6 @@ package writer
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"sync"
 	"time"
 
@@ -12,6 +11,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	bredis "github.com/Jeffail/benthos/v3/lib/util/redis"
 	"github.com/go-redis/redis/v7"
 )
 
@@ -20,15 +20,15 @@ import (
 // RedisPubSubConfig contains configuration fields for the RedisPubSub output
 // type.
 type RedisPubSubConfig struct {
-	URL         string `json:"url" yaml:"url"`
-	Channel     string `json:"channel" yaml:"channel"`
-	MaxInFlight int    `json:"max_in_flight" yaml:"max_in_flight"`
+	bredis.Config `json:",inline" yaml:",inline"`
+	Channel       string `json:"channel" yaml:"channel"`
+	MaxInFlight   int    `json:"max_in_flight" yaml:"max_in_flight"`
 }
 
 // NewRedisPubSubConfig creates a new RedisPubSubConfig with default values.
 func NewRedisPubSubConfig() RedisPubSubConfig {
 	return RedisPubSubConfig{
-		URL:         "tcp://localhost:6379",
+		Config:      bredis.NewConfig(),
 		Channel:     "benthos_chan",
 		MaxInFlight: 1,
 	}
@@ -41,11 +41,10 @@ type RedisPubSub struct {
 	log   log.Modular
 	stats metrics.Type
 
-	url        *url.URL
 	conf       RedisPubSubConfig
 	channelStr field.Expression
 
-	client  *redis.Client
+	client  redis.UniversalClient
 	connMut sync.RWMutex
 }
 
@@ -64,10 +63,11 @@ func NewRedisPubSub(
 	if r.channelStr, err = bloblang.NewField(conf.Channel); err != nil {
 		return nil, fmt.Errorf("failed to parse channel expression: %v", err)
 	}
-	r.url, err = url.Parse(conf.URL)
+	client, err := conf.Client()
 	if err != nil {
 		return nil, err
 	}
+	client.Close()
 	return r, nil
 }
 
@@ -83,17 +83,12 @@ func (r *RedisPubSub) Connect() error {
 	r.connMut.Lock()
 	defer r.connMut.Unlock()
 
-	var pass string
-	if r.url.User != nil {
-		pass, _ = r.url.User.Password()
+	client, err := r.conf.Client()
+	if err != nil {
+		return err
 	}
-	client := redis.NewClient(&redis.Options{
-		Addr:     r.url.Host,
-		Network:  r.url.Scheme,
-		Password: pass,
-	})
-
-	if _, err := client.Ping().Result(); err != nil {
+	if _, err = client.Ping().Result(); err != nil {
+		client.Close()
 		return err
 	}
 
@@ -3,7 +3,10 @@ package writer
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"sort"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
@@ -20,19 +23,29 @@ import (
 
 //------------------------------------------------------------------------------
 
+// AmazonS3MultipartConfig contains configuration fields for the multipart
+// uploads of the AmazonS3 output type.
+type AmazonS3MultipartConfig struct {
+	PartSize    int64 `json:"part_size" yaml:"part_size"`
+	Concurrency int   `json:"concurrency" yaml:"concurrency"`
+}
+
 // AmazonS3Config contains configuration fields for the AmazonS3 output type.
 type AmazonS3Config struct {
-	sess.Config        `json:",inline" yaml:",inline"`
-	Bucket             string             `json:"bucket" yaml:"bucket"`
-	ForcePathStyleURLs bool               `json:"force_path_style_urls" yaml:"force_path_style_urls"`
-	Path               string             `json:"path" yaml:"path"`
-	ContentType        string             `json:"content_type" yaml:"content_type"`
-	ContentEncoding    string             `json:"content_encoding" yaml:"content_encoding"`
-	StorageClass       string             `json:"storage_class" yaml:"storage_class"`
-	Timeout            string             `json:"timeout" yaml:"timeout"`
-	KMSKeyID           string             `json:"kms_key_id" yaml:"kms_key_id"`
-	MaxInFlight        int                `json:"max_in_flight" yaml:"max_in_flight"`
-	Batching           batch.PolicyConfig `json:"batching" yaml:"batching"`
+	sess.Config             `json:",inline" yaml:",inline"`
+	Bucket                  string                  `json:"bucket" yaml:"bucket"`
+	ForcePathStyleURLs      bool                    `json:"force_path_style_urls" yaml:"force_path_style_urls"`
+	Path                    string                  `json:"path" yaml:"path"`
+	Tags                    map[string]string       `json:"tags" yaml:"tags"`
+	ContentType             string                  `json:"content_type" yaml:"content_type"`
+	ContentEncoding         string                  `json:"content_encoding" yaml:"content_encoding"`
+	StorageClass            string                  `json:"storage_class" yaml:"storage_class"`
+	WebsiteRedirectLocation string                  `json:"website_redirect_location" yaml:"website_redirect_location"`
+	Timeout                 string                  `json:"timeout" yaml:"timeout"`
+	KMSKeyID                string                  `json:"kms_key_id" yaml:"kms_key_id"`
+	Multipart               AmazonS3MultipartConfig `json:"multipart" yaml:"multipart"`
+	MaxInFlight             int                     `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching                batch.PolicyConfig      `json:"batching" yaml:"batching"`
 }
 
 // NewAmazonS3Config creates a new Config with default values.
@@ -40,17 +53,23 @@ func NewAmazonS3Config() AmazonS3Config {
 	batching := batch.NewPolicyConfig()
 	batching.Count = 1
 	return AmazonS3Config{
-		Config:             sess.NewConfig(),
-		Bucket:             "",
-		ForcePathStyleURLs: false,
-		Path:               `${!count("files")}-${!timestamp_unix_nano()}.txt`,
-		ContentType:        "application/octet-stream",
-		ContentEncoding:    "",
-		StorageClass:       "STANDARD",
-		Timeout:            "5s",
-		KMSKeyID:           "",
-		MaxInFlight:        1,
-		Batching:           batching,
+		Config:                  sess.NewConfig(),
+		Bucket:                  "",
+		ForcePathStyleURLs:      false,
+		Path:                    `${!count("files")}-${!timestamp_unix_nano()}.txt`,
+		Tags:                    map[string]string{},
+		ContentType:             "application/octet-stream",
+		ContentEncoding:         "",
+		StorageClass:            "STANDARD",
+		WebsiteRedirectLocation: "",
+		Timeout:                 "5s",
+		KMSKeyID:                "",
+		Multipart: AmazonS3MultipartConfig{
+			PartSize:    s3manager.DefaultUploadPartSize,
+			Concurrency: s3manager.DefaultUploadConcurrency,
+		},
+		MaxInFlight: 1,
+		Batching:    batching,
 	}
 }
 
@@ -62,9 +81,13 @@ type AmazonS3 struct {
 	conf AmazonS3Config
 
 	path            field.Expression
+	tagKeys         []string
+	tagValues       map[string]field.Expression
 	contentType     field.Expression
 	contentEncoding field.Expression
 	storageClass    field.Expression
+	websiteRedirect field.Expression
+	kmsKeyID        field.Expression
 
 	session  *session.Session
 	uploader *s3manager.Uploader
@@ -106,6 +129,28 @@ func NewAmazonS3(
 	if a.storageClass, err = bloblang.NewField(conf.StorageClass); err != nil {
 		return nil, fmt.Errorf("failed to parse storage class expression: %v", err)
 	}
+	if a.websiteRedirect, err = bloblang.NewField(conf.WebsiteRedirectLocation); err != nil {
+		return nil, fmt.Errorf("failed to parse website redirect location expression: %v", err)
+	}
+	if a.kmsKeyID, err = bloblang.NewField(conf.KMSKeyID); err != nil {
+		return nil, fmt.Errorf("failed to parse kms key id expression: %v", err)
+	}
+
+	a.tagValues = make(map[string]field.Expression, len(conf.Tags))
+	for k, v := range conf.Tags {
+		if a.tagValues[k], err = bloblang.NewField(v); err != nil {
+			return nil, fmt.Errorf("failed to parse tag '%v' expression: %v", k, err)
+		}
+		a.tagKeys = append(a.tagKeys, k)
+	}
+	sort.Strings(a.tagKeys)
+
+	if conf.Multipart.PartSize < s3manager.MinUploadPartSize {
+		return nil, fmt.Errorf("multipart part_size must be at least %v bytes", s3manager.MinUploadPartSize)
+	}
+	if conf.Multipart.Concurrency < 1 {
+		return nil, errors.New("multipart concurrency must be at least one")
+	}
 	return a, nil
 }
 
@@ -129,7 +174,10 @@ func (a *AmazonS3) Connect() error {
 	}
 
 	a.session = sess
-	a.uploader = s3manager.NewUploader(sess)
+	a.uploader = s3manager.NewUploader(sess, func(u *s3manager.Uploader) {
+		u.PartSize = a.conf.Multipart.PartSize
+		u.Concurrency = a.conf.Multipart.Concurrency
+	})
 
 	a.log.Infof("Uploading message parts as objects to Amazon S3 bucket: %v\n", a.conf.Bucket)
 	return nil
@@ -164,6 +212,8 @@ func (a *AmazonS3) WriteWithContext(wctx context.Context, msg types.Message) err
 			contentEncoding = aws.String(ce)
 		}
 
+		// Objects larger than the part size are uploaded in parts, which are
+		// read from the message as they're sent.
 		uploadInput := &s3manager.UploadInput{
 			Bucket:          &a.conf.Bucket,
 			Key:             aws.String(a.path.String(i, msg)),
@@ -174,9 +224,21 @@ func (a *AmazonS3) WriteWithContext(wctx context.Context, msg types.Message) err
 			Metadata:        metadata,
 		}
 
-		if a.conf.KMSKeyID != "" {
+		if len(a.tagKeys) > 0 {
+			tags := url.Values{}
+			for _, k := range a.tagKeys {
+				tags.Set(k, a.tagValues[k].String(i, msg))
+			}
+			uploadInput.Tagging = aws.String(tags.Encode())
+		}
+
+		if redirect := a.websiteRedirect.String(i, msg); redirect != "" {
+			uploadInput.WebsiteRedirectLocation = aws.String(redirect)
+		}
+
+		if kmsKeyID := a.kmsKeyID.String(i, msg); kmsKeyID != "" {
 			uploadInput.ServerSideEncryption = aws.String("aws:kms")
-			uploadInput.SSEKMSKeyId = &a.conf.KMSKeyID
+			uploadInput.SSEKMSKeyId = aws.String(kmsKeyID)
 		}
 
 		if _, err := a.uploader.UploadWithContext(ctx, uploadInput); err != nil {
@@ -0,0 +1,166 @@
+package writer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type s3TestRequest struct {
+	method string
+	path   string
+	query  string
+	header http.Header
+	size   int
+}
+
+type s3TestServer struct {
+	*httptest.Server
+
+	mut     sync.Mutex
+	reqs    []s3TestRequest
+	objects map[string][]byte
+}
+
+func newS3TestServer(t *testing.T) *s3TestServer {
+	t.Helper()
+
+	s := &s3TestServer{objects: map[string][]byte{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		s.reqs = append(s.reqs, s3TestRequest{
+			method: r.Method,
+			path:   r.URL.Path,
+			query:  r.URL.RawQuery,
+			header: r.Header,
+			size:   len(b),
+		})
+
+		q := r.URL.Query()
+		switch {
+		case r.Method == "POST" && q.Get("uploadId") == "":
+			fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>foo</Bucket><Key>%v</Key><UploadId>bar</UploadId></InitiateMultipartUploadResult>`, r.URL.Path)
+		case r.Method == "PUT" && q.Get("partNumber") != "":
+			s.objects[r.URL.Path] = append(s.objects[r.URL.Path], b...)
+			w.Header().Set("ETag", `"etag`+q.Get("partNumber")+`"`)
+		case r.Method == "POST":
+			fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>foo</Bucket><Key>%v</Key><ETag>"baz"</ETag></CompleteMultipartUploadResult>`, r.URL.Path)
+		default:
+			s.objects[r.URL.Path] = b
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *s3TestServer) requests() []s3TestRequest {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]s3TestRequest(nil), s.reqs...)
+}
+
+func testS3Config(url string) AmazonS3Config {
+	conf := NewAmazonS3Config()
+	conf.Endpoint = url
+	conf.Region = "eu-west-1"
+	conf.Credentials.ID = "foo"
+	conf.Credentials.Secret = "bar"
+	conf.ForcePathStyleURLs = true
+	conf.Bucket = "foo"
+	return conf
+}
+
+func TestAmazonS3TagsAndEncryption(t *testing.T) {
+	srv := newS3TestServer(t)
+
+	conf := testS3Config(srv.URL)
+	conf.Path = `${! meta("tenant") }.txt`
+	conf.Tags = map[string]string{
+		"tenant": `${! meta("tenant") }`,
+		"source": "benthos & co",
+	}
+	conf.StorageClass = `${! meta("class") }`
+	conf.KMSKeyID = `${! meta("key") }`
+	conf.WebsiteRedirectLocation = `/${! meta("tenant") }.html`
+
+	s, err := NewAmazonS3(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, s.Connect())
+
+	msg := message.New([][]byte{[]byte("hello"), []byte("world")})
+	msg.Get(0).Metadata().Set("tenant", "acme").Set("class", "STANDARD_IA").Set("key", "acmekey")
+	msg.Get(1).Metadata().Set("tenant", "globex").Set("class", "GLACIER")
+	require.NoError(t, s.Write(msg))
+
+	reqs := srv.requests()
+	require.Len(t, reqs, 2)
+
+	assert.Equal(t, "/foo/acme.txt", reqs[0].path)
+	assert.Equal(t, "source=benthos+%26+co&tenant=acme", reqs[0].header.Get("X-Amz-Tagging"))
+	assert.Equal(t, "STANDARD_IA", reqs[0].header.Get("X-Amz-Storage-Class"))
+	assert.Equal(t, "aws:kms", reqs[0].header.Get("X-Amz-Server-Side-Encryption"))
+	assert.Equal(t, "acmekey", reqs[0].header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+	assert.Equal(t, "/acme.html", reqs[0].header.Get("X-Amz-Website-Redirect-Location"))
+
+	assert.Equal(t, "/foo/globex.txt", reqs[1].path)
+	assert.Equal(t, "source=benthos+%26+co&tenant=globex", reqs[1].header.Get("X-Amz-Tagging"))
+	assert.Equal(t, "GLACIER", reqs[1].header.Get("X-Amz-Storage-Class"))
+	assert.Equal(t, "", reqs[1].header.Get("X-Amz-Server-Side-Encryption"))
+}
+
+func TestAmazonS3Multipart(t *testing.T) {
+	srv := newS3TestServer(t)
+
+	conf := testS3Config(srv.URL)
+	conf.Path = "big.bin"
+	conf.Tags = map[string]string{"size": "big"}
+	conf.Multipart.Concurrency = 1
+	conf.Timeout = "30s"
+
+	s, err := NewAmazonS3(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, s.Connect())
+
+	body := bytes.Repeat([]byte("a"), int(s3manager.MinUploadPartSize*2+10))
+	require.NoError(t, s.Write(message.New([][]byte{body})))
+
+	reqs := srv.requests()
+	require.Len(t, reqs, 5)
+	assert.Equal(t, "POST", reqs[0].method)
+	assert.Equal(t, "uploads=", reqs[0].query)
+	assert.Equal(t, "size=big", reqs[0].header.Get("X-Amz-Tagging"))
+	for _, r := range reqs[1:4] {
+		assert.Equal(t, "PUT", r.method)
+	}
+	assert.Equal(t, "POST", reqs[4].method)
+
+	srv.mut.Lock()
+	assert.Equal(t, body, srv.objects["/foo/big.bin"])
+	srv.mut.Unlock()
+}
+
+func TestAmazonS3ConfigErrors(t *testing.T) {
+	conf := NewAmazonS3Config()
+	conf.Multipart.PartSize = 1024
+	_, err := NewAmazonS3(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf = NewAmazonS3Config()
+	conf.Tags = map[string]string{"foo": "${! meta( }"}
+	_, err = NewAmazonS3(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
@@ -0,0 +1,199 @@
+package writer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/sftp"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"golang.org/x/crypto/ssh"
+)
+
+//------------------------------------------------------------------------------
+
+// SFTPConfig contains configuration fields for the SFTP output type.
+type SFTPConfig struct {
+	Address        string           `json:"address" yaml:"address"`
+	Credentials    sftp.Credentials `json:"credentials" yaml:"credentials"`
+	KnownHostsFile string           `json:"known_hosts_file" yaml:"known_hosts_file"`
+	Path           string           `json:"path" yaml:"path"`
+	MaxInFlight    int              `json:"max_in_flight" yaml:"max_in_flight"`
+}
+
+// NewSFTPConfig creates a new Config with default values.
+func NewSFTPConfig() SFTPConfig {
+	return SFTPConfig{
+		Address:        "",
+		Credentials:    sftp.NewCredentials(),
+		KnownHostsFile: "",
+		Path:           `${!count("files")}-${!timestamp_unix_nano()}.txt`,
+		MaxInFlight:    1,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SFTP is a benthos writer.Type implementation that writes messages as files
+// to a server over SFTP.
+type SFTP struct {
+	conf    SFTPConfig
+	sshConf *ssh.ClientConfig
+
+	path field.Expression
+
+	dial func() (*sftp.Client, error)
+
+	client    *sftp.Client
+	clientMut sync.Mutex
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewSFTP creates a new SFTP writer.Type.
+func NewSFTP(
+	conf SFTPConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*SFTP, error) {
+	if len(conf.Address) == 0 {
+		return nil, errors.New("an address must be specified")
+	}
+	path, err := bloblang.NewField(conf.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse path expression: %v", err)
+	}
+	sshConf, err := conf.Credentials.ClientConfig(conf.KnownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+	s := &SFTP{
+		conf:    conf,
+		sshConf: sshConf,
+		path:    path,
+		log:     log,
+		stats:   stats,
+	}
+	s.dial = func() (*sftp.Client, error) {
+		return sftp.Dial(s.conf.Address, s.sshConf)
+	}
+	return s, nil
+}
+
+// ConnectWithContext attempts to establish a connection to the SFTP server.
+func (s *SFTP) ConnectWithContext(ctx context.Context) error {
+	return s.Connect()
+}
+
+// Connect attempts to establish a connection to the SFTP server.
+func (s *SFTP) Connect() error {
+	s.clientMut.Lock()
+	defer s.clientMut.Unlock()
+
+	if s.client != nil {
+		return nil
+	}
+
+	client, err := s.dial()
+	if err != nil {
+		return err
+	}
+	s.client = client
+
+	s.log.Infof("Writing message parts as files to SFTP server: %v\n", s.conf.Address)
+	return nil
+}
+
+// writeFile writes a file to a temporary path within the directory of the
+// target path, and then renames it to the target path in order for the file
+// to only appear once it is complete.
+func (s *SFTP) writeFile(client *sftp.Client, p string, content []byte) error {
+	var suffix [8]byte
+	if _, err := rand.Read(suffix[:]); err != nil {
+		return err
+	}
+	tmpPath := p + "." + hex.EncodeToString(suffix[:]) + ".tmp"
+
+	f, err := client.Create(tmpPath)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		if err = client.MkdirAll(path.Dir(p)); err == nil {
+			f, err = client.Create(tmpPath)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(content); err != nil {
+		f.Close()
+		client.Remove(tmpPath)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		client.Remove(tmpPath)
+		return err
+	}
+	if err = client.Rename(tmpPath, p); err != nil {
+		client.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// WriteWithContext attempts to write message contents to the SFTP server as
+// files.
+func (s *SFTP) WriteWithContext(ctx context.Context, msg types.Message) error {
+	return s.Write(msg)
+}
+
+// Write attempts to write message contents to the SFTP server as files.
+func (s *SFTP) Write(msg types.Message) error {
+	s.clientMut.Lock()
+	client := s.client
+	s.clientMut.Unlock()
+	if client == nil {
+		return types.ErrNotConnected
+	}
+
+	return IterateBatchedSend(msg, func(i int, p types.Part) error {
+		err := s.writeFile(client, s.path.String(i, msg), p.Get())
+		if errors.Is(err, sftp.ErrClosed) {
+			s.clientMut.Lock()
+			if s.client == client {
+				s.client.Close()
+				s.client = nil
+			}
+			s.clientMut.Unlock()
+			return types.ErrNotConnected
+		}
+		return err
+	})
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (s *SFTP) CloseAsync() {
+	s.clientMut.Lock()
+	if s.client != nil {
+		s.client.Close()
+		s.client = nil
+	}
+	s.clientMut.Unlock()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (s *SFTP) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
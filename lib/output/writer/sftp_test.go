@@ -0,0 +1,80 @@
+package writer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/sftp"
+	"github.com/Jeffail/benthos/v3/internal/sftp/sftptest"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSFTPConfigErrors(t *testing.T) {
+	conf := NewSFTPConfig()
+	_, err := NewSFTP(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Address = "localhost:22"
+	_, err = NewSFTP(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Credentials.Username = "foo"
+	conf.Credentials.PrivateKeyFile = "/does/not/exist"
+	_, err = NewSFTP(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Credentials.PrivateKeyFile = ""
+	conf.Credentials.Password = "bar"
+	conf.Path = "${!nope()}"
+	_, err = NewSFTP(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestSFTPWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_sftp_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, posixRename := range []bool{true, false} {
+		conf := NewSFTPConfig()
+		conf.Address = "localhost:22"
+		conf.Credentials.Username = "foo"
+		conf.Credentials.Password = "bar"
+		conf.Path = `/out/${!meta("dir")}/${!content()}.txt`
+
+		s, err := NewSFTP(conf, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+		s.dial = func() (*sftp.Client, error) {
+			return sftptest.NewClient(dir, posixRename)
+		}
+		require.NoError(t, s.Connect())
+
+		msg := message.New([][]byte{[]byte("foo"), []byte("bar")})
+		msg.Get(0).Metadata().Set("dir", "a")
+		msg.Get(1).Metadata().Set("dir", "b/c")
+		require.NoError(t, s.Write(msg))
+
+		// Writing a file again replaces it.
+		require.NoError(t, s.Write(msg))
+		s.CloseAsync()
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "out", "a", "foo.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "foo", string(data))
+
+		data, err = ioutil.ReadFile(filepath.Join(dir, "out", "b", "c", "bar.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "bar", string(data))
+
+		// No temporary files remain.
+		entries, err := ioutil.ReadDir(filepath.Join(dir, "out", "a"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	}
+}
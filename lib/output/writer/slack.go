@@ -0,0 +1,154 @@
+package writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// SlackConfig contains configuration fields for the Slack output type.
+type SlackConfig struct {
+	URL            string `json:"url" yaml:"url"`
+	Text           string `json:"text" yaml:"text"`
+	PayloadMapping string `json:"payload_mapping" yaml:"payload_mapping"`
+	ThreadTS       string `json:"thread_ts" yaml:"thread_ts"`
+	Timeout        string `json:"timeout" yaml:"timeout"`
+	MaxInFlight    int    `json:"max_in_flight" yaml:"max_in_flight"`
+}
+
+// NewSlackConfig creates a new SlackConfig with default values.
+func NewSlackConfig() SlackConfig {
+	return SlackConfig{
+		URL:            "",
+		Text:           "${! content() }",
+		PayloadMapping: "",
+		ThreadTS:       "",
+		Timeout:        "5s",
+		MaxInFlight:    1,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Slack is a writer type that posts messages to a Slack incoming webhook.
+type Slack struct {
+	conf SlackConfig
+
+	text           field.Expression
+	threadTS       field.Expression
+	payloadMapping *mapping.Executor
+
+	webhook *chatWebhook
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewSlack creates a new Slack writer type.
+func NewSlack(conf SlackConfig, log log.Modular, stats metrics.Type) (*Slack, error) {
+	s := &Slack{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	if conf.URL == "" {
+		return nil, errors.New("a url must be specified")
+	}
+	if conf.Text == "" && conf.PayloadMapping == "" {
+		return nil, errors.New("either a text or payload mapping must be specified")
+	}
+
+	var err error
+	if conf.Text != "" {
+		if s.text, err = bloblang.NewField(conf.Text); err != nil {
+			return nil, fmt.Errorf("failed to parse text expression: %v", err)
+		}
+	}
+	if conf.ThreadTS != "" {
+		if s.threadTS, err = bloblang.NewField(conf.ThreadTS); err != nil {
+			return nil, fmt.Errorf("failed to parse thread ts expression: %v", err)
+		}
+	}
+	if conf.PayloadMapping != "" {
+		if s.payloadMapping, err = newMapping("payload mapping", conf.PayloadMapping); err != nil {
+			return nil, err
+		}
+	}
+	if s.webhook, err = newChatWebhook("Slack", conf.Timeout, log); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// payload builds the webhook payload of a message of a batch, where the fields
+// of the payload mapping take precedence over other fields.
+func (s *Slack) payload(index int, msg types.Message) (map[string]interface{}, error) {
+	payload, err := chatPayload(s.payloadMapping, index, msg)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := payload["text"]; !exists && s.text != nil {
+		if text := s.text.String(index, msg); text != "" {
+			payload["text"] = text
+		}
+	}
+	if s.threadTS != nil {
+		if ts := s.threadTS.String(index, msg); ts != "" {
+			payload["thread_ts"] = ts
+		}
+	}
+	return payload, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as the Slack output writes with plain HTTP requests.
+func (s *Slack) Connect() error {
+	return s.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as the Slack output writes with plain HTTP
+// requests.
+func (s *Slack) ConnectWithContext(ctx context.Context) error {
+	s.log.Infoln("Posting messages to a Slack webhook")
+	return nil
+}
+
+// Write attempts to post a message batch to Slack.
+func (s *Slack) Write(msg types.Message) error {
+	return s.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext posts each message of a batch to Slack in order.
+func (s *Slack) WriteWithContext(ctx context.Context, msg types.Message) error {
+	return IterateBatchedSend(msg, func(i int, _ types.Part) error {
+		payload, err := s.payload(i, msg)
+		if err != nil {
+			return err
+		}
+		return s.webhook.post(ctx, s.conf.URL, payload)
+	})
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (s *Slack) CloseAsync() {
+	s.webhook.closeAsync()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (s *Slack) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
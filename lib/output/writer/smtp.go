@@ -0,0 +1,647 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+// SMTPAuthConfig contains configuration fields for authenticating with an SMTP
+// server.
+type SMTPAuthConfig struct {
+	Mechanism string `json:"mechanism" yaml:"mechanism"`
+	Username  string `json:"username" yaml:"username"`
+	Password  string `json:"password" yaml:"password"`
+}
+
+// SMTPAttachmentsConfig contains configuration fields for sending the messages
+// of a batch as the attachments of a single email.
+type SMTPAttachmentsConfig struct {
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+	Filename    string `json:"filename" yaml:"filename"`
+	ContentType string `json:"content_type" yaml:"content_type"`
+}
+
+// SMTPConfig contains configuration fields for the SMTP output type.
+type SMTPConfig struct {
+	Address     string                `json:"address" yaml:"address"`
+	LocalName   string                `json:"local_name" yaml:"local_name"`
+	TLS         btls.Config           `json:"tls" yaml:"tls"`
+	StartTLS    string                `json:"start_tls" yaml:"start_tls"`
+	Auth        SMTPAuthConfig        `json:"auth" yaml:"auth"`
+	From        string                `json:"from" yaml:"from"`
+	To          string                `json:"to" yaml:"to"`
+	Cc          string                `json:"cc" yaml:"cc"`
+	Bcc         string                `json:"bcc" yaml:"bcc"`
+	Subject     string                `json:"subject" yaml:"subject"`
+	Body        string                `json:"body" yaml:"body"`
+	ContentType string                `json:"content_type" yaml:"content_type"`
+	Headers     map[string]string     `json:"headers" yaml:"headers"`
+	Attachments SMTPAttachmentsConfig `json:"attachments" yaml:"attachments"`
+	RateLimit   string                `json:"rate_limit" yaml:"rate_limit"`
+	Timeout     string                `json:"timeout" yaml:"timeout"`
+	MaxInFlight int                   `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching    batch.PolicyConfig    `json:"batching" yaml:"batching"`
+}
+
+// NewSMTPConfig creates a new SMTPConfig with default values.
+func NewSMTPConfig() SMTPConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return SMTPConfig{
+		Address:   "",
+		LocalName: "",
+		TLS:       btls.NewConfig(),
+		StartTLS:  "opportunistic",
+		Auth: SMTPAuthConfig{
+			Mechanism: "none",
+			Username:  "",
+			Password:  "",
+		},
+		From:        "",
+		To:          "",
+		Cc:          "",
+		Bcc:         "",
+		Subject:     "",
+		Body:        "${! content() }",
+		ContentType: "text/plain; charset=utf-8",
+		Headers:     map[string]string{},
+		Attachments: SMTPAttachmentsConfig{
+			Enabled:     false,
+			Filename:    `attachment_${! batch_index() }`,
+			ContentType: "application/octet-stream",
+		},
+		RateLimit:   "",
+		Timeout:     "10s",
+		MaxInFlight: 1,
+		Batching:    batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// smtpLoginAuth implements the LOGIN authentication mechanism, which isn't
+// provided by net/smtp but is still the only mechanism offered by some
+// servers.
+type smtpLoginAuth struct {
+	username, password string
+}
+
+func (a *smtpLoginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalSMTPHost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *smtpLoginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+}
+
+func isLocalSMTPHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+//------------------------------------------------------------------------------
+
+// smtpReservedHeaders are the headers of an email that are written by the
+// output itself.
+var smtpReservedHeaders = map[string]struct{}{
+	"From":                      {},
+	"To":                        {},
+	"Cc":                        {},
+	"Bcc":                       {},
+	"Subject":                   {},
+	"Date":                      {},
+	"Mime-Version":              {},
+	"Content-Type":              {},
+	"Content-Transfer-Encoding": {},
+}
+
+// smtpEmail is an email built from one or more messages of a batch.
+type smtpEmail struct {
+	from       *mail.Address
+	to         []*mail.Address
+	cc         []*mail.Address
+	bcc        []*mail.Address
+	recipients []string
+	data       []byte
+}
+
+// SMTP is a writer type that sends messages as emails to an SMTP server.
+type SMTP struct {
+	conf SMTPConfig
+
+	host    string
+	tlsConf *tls.Config
+	auth    smtp.Auth
+	timeout time.Duration
+
+	from        field.Expression
+	to          field.Expression
+	cc          field.Expression
+	bcc         field.Expression
+	subject     field.Expression
+	body        field.Expression
+	headers     map[string]field.Expression
+	attFilename field.Expression
+	attType     field.Expression
+
+	rateLimit types.RateLimit
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+
+	mLimited  metrics.StatCounter
+	mLimitFor metrics.StatCounter
+	mLimitErr metrics.StatCounter
+}
+
+// NewSMTP creates a new SMTP writer type.
+func NewSMTP(conf SMTPConfig, mgr types.Manager, log log.Modular, stats metrics.Type) (*SMTP, error) {
+	s := &SMTP{
+		conf:      conf,
+		headers:   map[string]field.Expression{},
+		closeChan: make(chan struct{}),
+		log:       log,
+		stats:     stats,
+		mLimited:  stats.GetCounter("rate_limit.count"),
+		mLimitFor: stats.GetCounter("rate_limit.total_ms"),
+		mLimitErr: stats.GetCounter("rate_limit.error"),
+	}
+
+	if conf.Address == "" {
+		return nil, errors.New("an address must be specified")
+	}
+	var err error
+	if s.host, _, err = net.SplitHostPort(conf.Address); err != nil {
+		return nil, fmt.Errorf("failed to parse address: %v", err)
+	}
+	if conf.From == "" {
+		return nil, errors.New("a from address must be specified")
+	}
+	if conf.To == "" && conf.Cc == "" && conf.Bcc == "" {
+		return nil, errors.New("at least one of to, cc or bcc must be specified")
+	}
+
+	switch conf.StartTLS {
+	case "opportunistic", "required", "disabled":
+	default:
+		return nil, fmt.Errorf("start_tls must be either opportunistic, required or disabled, got: %v", conf.StartTLS)
+	}
+	if s.tlsConf, err = conf.TLS.Get(); err != nil {
+		return nil, err
+	}
+	if s.tlsConf.ServerName == "" {
+		s.tlsConf.ServerName = s.host
+	}
+
+	switch conf.Auth.Mechanism {
+	case "none":
+	case "plain":
+		s.auth = smtp.PlainAuth("", conf.Auth.Username, conf.Auth.Password, s.host)
+	case "login":
+		s.auth = &smtpLoginAuth{username: conf.Auth.Username, password: conf.Auth.Password}
+	case "cram-md5":
+		s.auth = smtp.CRAMMD5Auth(conf.Auth.Username, conf.Auth.Password)
+	default:
+		return nil, fmt.Errorf("auth mechanism must be either none, plain, login or cram-md5, got: %v", conf.Auth.Mechanism)
+	}
+
+	for name, expr := range map[string]struct {
+		field *field.Expression
+		value string
+	}{
+		"from":    {&s.from, conf.From},
+		"to":      {&s.to, conf.To},
+		"cc":      {&s.cc, conf.Cc},
+		"bcc":     {&s.bcc, conf.Bcc},
+		"subject": {&s.subject, conf.Subject},
+		"body":    {&s.body, conf.Body},
+	} {
+		if expr.value == "" {
+			continue
+		}
+		if *expr.field, err = bloblang.NewField(expr.value); err != nil {
+			return nil, fmt.Errorf("failed to parse %v expression: %v", name, err)
+		}
+	}
+	for k, v := range conf.Headers {
+		if _, reserved := smtpReservedHeaders[textproto.CanonicalMIMEHeaderKey(k)]; reserved {
+			return nil, fmt.Errorf("header '%v' cannot be set as it's written by the output", k)
+		}
+		if s.headers[textproto.CanonicalMIMEHeaderKey(k)], err = bloblang.NewField(v); err != nil {
+			return nil, fmt.Errorf("failed to parse header '%v' expression: %v", k, err)
+		}
+	}
+	if conf.Attachments.Enabled {
+		if s.attFilename, err = bloblang.NewField(conf.Attachments.Filename); err != nil {
+			return nil, fmt.Errorf("failed to parse attachment filename expression: %v", err)
+		}
+		if s.attType, err = bloblang.NewField(conf.Attachments.ContentType); err != nil {
+			return nil, fmt.Errorf("failed to parse attachment content type expression: %v", err)
+		}
+	}
+
+	if s.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+	}
+
+	if conf.RateLimit != "" {
+		if s.rateLimit, err = mgr.GetRateLimit(conf.RateLimit); err != nil {
+			return nil, fmt.Errorf("failed to obtain rate limit resource: %v", err)
+		}
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+func resolveSMTPAddresses(expr field.Expression, index int, msg types.Message) ([]*mail.Address, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	list := strings.TrimSpace(expr.String(index, msg))
+	if list == "" {
+		return nil, nil
+	}
+	return mail.ParseAddressList(list)
+}
+
+func formatSMTPAddresses(addrs []*mail.Address) string {
+	strs := make([]string, len(addrs))
+	for i, a := range addrs {
+		strs[i] = a.String()
+	}
+	return strings.Join(strs, ", ")
+}
+
+// email builds an email from a message of a batch, where the addresses,
+// subject and headers of the email are resolved against the message at index.
+// When attachments are enabled every message of the batch is attached to the
+// email.
+func (s *SMTP) email(index int, msg types.Message) (*smtpEmail, error) {
+	e := &smtpEmail{}
+
+	from, err := resolveSMTPAddresses(s.from, index, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse from address: %w", err)
+	}
+	if len(from) != 1 {
+		return nil, fmt.Errorf("expected exactly one from address, got %v", len(from))
+	}
+	e.from = from[0]
+	if e.to, err = resolveSMTPAddresses(s.to, index, msg); err != nil {
+		return nil, fmt.Errorf("failed to parse to addresses: %w", err)
+	}
+	if e.cc, err = resolveSMTPAddresses(s.cc, index, msg); err != nil {
+		return nil, fmt.Errorf("failed to parse cc addresses: %w", err)
+	}
+	if e.bcc, err = resolveSMTPAddresses(s.bcc, index, msg); err != nil {
+		return nil, fmt.Errorf("failed to parse bcc addresses: %w", err)
+	}
+	for _, addrs := range [][]*mail.Address{e.to, e.cc, e.bcc} {
+		for _, a := range addrs {
+			e.recipients = append(e.recipients, a.Address)
+		}
+	}
+	if len(e.recipients) == 0 {
+		return nil, errors.New("email has no recipients")
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(k, v string) {
+		buf.WriteString(k)
+		buf.WriteString(": ")
+		buf.WriteString(v)
+		buf.WriteString("\r\n")
+	}
+
+	headerKeys := make([]string, 0, len(s.headers))
+	for k := range s.headers {
+		headerKeys = append(headerKeys, k)
+	}
+	sort.Strings(headerKeys)
+	for _, k := range headerKeys {
+		if v := s.headers[k].String(index, msg); v != "" {
+			writeHeader(k, mime.QEncoding.Encode("utf-8", v))
+		}
+	}
+
+	writeHeader("From", e.from.String())
+	if len(e.to) > 0 {
+		writeHeader("To", formatSMTPAddresses(e.to))
+	}
+	if len(e.cc) > 0 {
+		writeHeader("Cc", formatSMTPAddresses(e.cc))
+	}
+	if s.subject != nil {
+		writeHeader("Subject", mime.QEncoding.Encode("utf-8", s.subject.String(index, msg)))
+	}
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("MIME-Version", "1.0")
+
+	var body []byte
+	if s.body != nil {
+		body = s.body.Bytes(index, msg)
+	}
+
+	if !s.conf.Attachments.Enabled {
+		writeHeader("Content-Type", s.conf.ContentType)
+		writeHeader("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		e.data = buf.Bytes()
+		return e, nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	writeHeader("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mw.Boundary()}))
+	buf.WriteString("\r\n")
+
+	pw, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {s.conf.ContentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeQuotedPrintable(pw, body); err != nil {
+		return nil, err
+	}
+
+	if err := msg.Iter(func(i int, p types.Part) error {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {s.attType.String(i, msg)},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition": {mime.FormatMediaType("attachment", map[string]string{
+				"filename": s.attFilename.String(i, msg),
+			})},
+		})
+		if err != nil {
+			return err
+		}
+		return writeBase64Lines(pw, p.Get())
+	}); err != nil {
+		return nil, err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	e.data = buf.Bytes()
+	return e, nil
+}
+
+func writeQuotedPrintable(w io.Writer, b []byte) error {
+	qw := quotedprintable.NewWriter(w)
+	if _, err := qw.Write(b); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+// writeBase64Lines writes base64 encoded data wrapped at 76 characters, which
+// is the line length limit of MIME bodies.
+func writeBase64Lines(w io.Writer, b []byte) error {
+	enc := base64.StdEncoding.EncodeToString(b)
+	for len(enc) > 76 {
+		if _, err := w.Write([]byte(enc[:76] + "\r\n")); err != nil {
+			return err
+		}
+		enc = enc[76:]
+	}
+	_, err := w.Write([]byte(enc + "\r\n"))
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// waitForAccess blocks until the rate limit allows access, returning false if
+// the writer was closed in the meantime.
+func (s *SMTP) waitForAccess(ctx context.Context) bool {
+	if s.rateLimit == nil {
+		return true
+	}
+	for {
+		period, err := s.rateLimit.Access()
+		if err != nil {
+			s.log.Errorf("Rate limit error: %v\n", err)
+			s.mLimitErr.Incr(1)
+			period = time.Second
+		}
+		if period <= 0 {
+			return true
+		}
+		if err == nil {
+			s.mLimited.Incr(1)
+			s.mLimitFor.Incr(period.Nanoseconds() / 1000000)
+		}
+		select {
+		case <-time.After(period):
+		case <-ctx.Done():
+			return false
+		case <-s.closeChan:
+			return false
+		}
+	}
+}
+
+// deadline returns the deadline of an exchange with the SMTP server.
+func (s *SMTP) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	return deadline
+}
+
+// dial opens a connection to the SMTP server, upgrading it with STARTTLS and
+// authenticating as configured.
+func (s *SMTP) dial(ctx context.Context) (*smtp.Client, net.Conn, error) {
+	dialer := &net.Dialer{Timeout: s.timeout}
+	var conn net.Conn
+	var err error
+	if s.conf.TLS.Enabled {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.conf.Address, s.tlsConf)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", s.conf.Address)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	conn.SetDeadline(s.deadline(ctx))
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if s.conf.LocalName != "" {
+		if err = c.Hello(s.conf.LocalName); err != nil {
+			c.Close()
+			return nil, nil, err
+		}
+	}
+	if !s.conf.TLS.Enabled && s.conf.StartTLS != "disabled" {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err = c.StartTLS(s.tlsConf); err != nil {
+				c.Close()
+				return nil, nil, err
+			}
+		} else if s.conf.StartTLS == "required" {
+			c.Close()
+			return nil, nil, errors.New("server does not support STARTTLS")
+		}
+	}
+	if s.auth != nil {
+		if err = c.Auth(s.auth); err != nil {
+			c.Close()
+			return nil, nil, err
+		}
+	}
+	return c, conn, nil
+}
+
+// send sends an email over an open connection, where the rate limit is
+// accessed once for each recipient of the email beforehand.
+func (s *SMTP) send(ctx context.Context, c *smtp.Client, conn net.Conn, e *smtpEmail) error {
+	for range e.recipients {
+		if !s.waitForAccess(ctx) {
+			return types.ErrTypeClosed
+		}
+	}
+	conn.SetDeadline(s.deadline(ctx))
+
+	err := c.Mail(e.from.Address)
+	for _, r := range e.recipients {
+		if err != nil {
+			break
+		}
+		err = c.Rcpt(r)
+	}
+	if err == nil {
+		var w io.WriteCloser
+		if w, err = c.Data(); err == nil {
+			if _, err = w.Write(e.data); err == nil {
+				err = w.Close()
+			}
+		}
+	}
+	if err != nil {
+		var protoErr *textproto.Error
+		if !errors.As(err, &protoErr) {
+			// The connection is broken and so are the remaining sends.
+			s.log.Errorf("Lost connection to SMTP server: %v\n", err)
+			return types.ErrNotConnected
+		}
+		if rErr := c.Reset(); rErr != nil {
+			s.log.Debugf("Failed to reset SMTP transaction: %v\n", rErr)
+		}
+		return fmt.Errorf("server rejected email: %w", err)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as the SMTP output opens a connection for each batch.
+func (s *SMTP) Connect() error {
+	return s.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as the SMTP output opens a connection for
+// each batch.
+func (s *SMTP) ConnectWithContext(ctx context.Context) error {
+	s.log.Infof("Sending emails to SMTP server at: %v\n", s.conf.Address)
+	return nil
+}
+
+// Write attempts to send a message batch as emails.
+func (s *SMTP) Write(msg types.Message) error {
+	return s.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext opens a connection to the SMTP server and sends each message
+// of a batch as an email, or the whole batch as a single email with
+// attachments.
+func (s *SMTP) WriteWithContext(ctx context.Context, msg types.Message) error {
+	c, conn, err := s.dial(ctx)
+	if err != nil {
+		s.log.Errorf("Failed to connect to SMTP server: %v\n", err)
+		return err
+	}
+	defer func() {
+		if err := c.Quit(); err != nil {
+			c.Close()
+		}
+	}()
+
+	if s.conf.Attachments.Enabled {
+		e, err := s.email(0, msg)
+		if err != nil {
+			return err
+		}
+		return s.send(ctx, c, conn, e)
+	}
+	return IterateBatchedSend(msg, func(i int, _ types.Part) error {
+		e, err := s.email(i, msg)
+		if err != nil {
+			return err
+		}
+		return s.send(ctx, c, conn, e)
+	})
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (s *SMTP) CloseAsync() {
+	s.closeOnce.Do(func() {
+		close(s.closeChan)
+	})
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (s *SMTP) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
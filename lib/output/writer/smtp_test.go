@@ -0,0 +1,398 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type smtpTestEmail struct {
+	from       string
+	recipients []string
+	data       string
+}
+
+type smtpTestServer struct {
+	addr string
+
+	mut    sync.Mutex
+	auth   []string
+	emails []smtpTestEmail
+
+	reject string
+}
+
+func newSMTPTestServer(t *testing.T, reject string) *smtpTestServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	s := &smtpTestServer{addr: ln.Addr().String(), reject: reject}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpTestServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+
+	var email smtpTestEmail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		arg := strings.TrimSpace(strings.TrimPrefix(line, strings.SplitN(line, " ", 2)[0]))
+		switch cmd {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-localhost")
+			tp.PrintfLine("250 AUTH PLAIN LOGIN")
+		case "AUTH":
+			if strings.HasPrefix(arg, "LOGIN") {
+				tp.PrintfLine("334 %v", base64.StdEncoding.EncodeToString([]byte("Username:")))
+				user, _ := tp.ReadLine()
+				tp.PrintfLine("334 %v", base64.StdEncoding.EncodeToString([]byte("Password:")))
+				pass, _ := tp.ReadLine()
+				userB, _ := base64.StdEncoding.DecodeString(user)
+				passB, _ := base64.StdEncoding.DecodeString(pass)
+				arg = fmt.Sprintf("LOGIN %s %s", userB, passB)
+			}
+			s.mut.Lock()
+			s.auth = append(s.auth, arg)
+			s.mut.Unlock()
+			tp.PrintfLine("235 Authentication successful")
+		case "MAIL":
+			email = smtpTestEmail{from: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			rcpt := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if rcpt == s.reject {
+				tp.PrintfLine("550 No such user")
+				continue
+			}
+			email.recipients = append(email.recipients, rcpt)
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 Go ahead")
+			data, _ := tp.ReadDotBytes()
+			email.data = string(data)
+			s.mut.Lock()
+			s.emails = append(s.emails, email)
+			s.mut.Unlock()
+			tp.PrintfLine("250 OK")
+		case "RSET", "NOOP":
+			email = smtpTestEmail{}
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Unknown command")
+		}
+	}
+}
+
+func (s *smtpTestServer) sent() []smtpTestEmail {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]smtpTestEmail(nil), s.emails...)
+}
+
+func (s *smtpTestServer) auths() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]string(nil), s.auth...)
+}
+
+func readSMTPTestEmail(t *testing.T, data string) *mail.Message {
+	t.Helper()
+
+	m, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(data)))
+	require.NoError(t, err)
+	return m
+}
+
+//------------------------------------------------------------------------------
+
+type smtpTestRateLimit struct {
+	mut      sync.Mutex
+	accessed int
+}
+
+func (r *smtpTestRateLimit) Access() (time.Duration, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.accessed++
+	return 0, nil
+}
+
+func (r *smtpTestRateLimit) CloseAsync() {}
+
+func (r *smtpTestRateLimit) WaitForClose(time.Duration) error {
+	return nil
+}
+
+type smtpTestMgr struct {
+	types.DudMgr
+	rateLimit types.RateLimit
+}
+
+func (m smtpTestMgr) GetRateLimit(name string) (types.RateLimit, error) {
+	if name == "foo" {
+		return m.rateLimit, nil
+	}
+	return nil, types.ErrRateLimitNotFound
+}
+
+//------------------------------------------------------------------------------
+
+func TestSMTPConfigErrors(t *testing.T) {
+	tests := map[string]func(c *SMTPConfig){
+		"no address": func(c *SMTPConfig) {
+			c.Address = ""
+		},
+		"address without port": func(c *SMTPConfig) {
+			c.Address = "localhost"
+		},
+		"no from": func(c *SMTPConfig) {
+			c.From = ""
+		},
+		"no recipients": func(c *SMTPConfig) {
+			c.To = ""
+		},
+		"bad start tls": func(c *SMTPConfig) {
+			c.StartTLS = "sometimes"
+		},
+		"bad auth mechanism": func(c *SMTPConfig) {
+			c.Auth.Mechanism = "xoauth2"
+		},
+		"reserved header": func(c *SMTPConfig) {
+			c.Headers["subject"] = "nope"
+		},
+		"bad subject": func(c *SMTPConfig) {
+			c.Subject = "${! meta( }"
+		},
+		"missing rate limit": func(c *SMTPConfig) {
+			c.RateLimit = "bar"
+		},
+	}
+
+	for name, fn := range tests {
+		fn := fn
+		t.Run(name, func(t *testing.T) {
+			conf := NewSMTPConfig()
+			conf.Address = "localhost:25"
+			conf.From = "benthos@example.com"
+			conf.To = "ops@example.com"
+			fn(&conf)
+
+			_, err := NewSMTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	srv := newSMTPTestServer(t, "")
+	rl := &smtpTestRateLimit{}
+
+	conf := NewSMTPConfig()
+	conf.Address = srv.addr
+	conf.Auth.Mechanism = "login"
+	conf.Auth.Username = "foo"
+	conf.Auth.Password = "bar"
+	conf.From = "Benthos <benthos@example.com>"
+	conf.To = `${! meta("to") }`
+	conf.Bcc = "audit@example.com"
+	conf.Subject = `Alert: ${! json("summary") }`
+	conf.Body = `${! json("details") }`
+	conf.Headers["X-Priority"] = "1"
+	conf.RateLimit = "foo"
+
+	s, err := NewSMTP(conf, smtpTestMgr{rateLimit: rl}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte(`{"summary":"disk full","details":"/var is at 100%"}`),
+		[]byte(`{"summary":"disk ok","details":"/var is at 20%"}`),
+	})
+	msg.Get(0).Metadata().Set("to", "ops@example.com, Jane <jane@example.com>")
+	msg.Get(1).Metadata().Set("to", "ops@example.com")
+	require.NoError(t, s.Write(msg))
+
+	emails := srv.sent()
+	require.Len(t, emails, 2)
+	assert.Equal(t, []string{"LOGIN foo bar"}, srv.auths())
+	assert.Equal(t, 5, rl.accessed)
+
+	assert.Equal(t, "benthos@example.com", emails[0].from)
+	assert.Equal(t, []string{"ops@example.com", "jane@example.com", "audit@example.com"}, emails[0].recipients)
+	assert.Equal(t, []string{"ops@example.com", "audit@example.com"}, emails[1].recipients)
+
+	m := readSMTPTestEmail(t, emails[0].data)
+	assert.Equal(t, `"Benthos" <benthos@example.com>`, m.Header.Get("From"))
+	assert.Equal(t, `<ops@example.com>, "Jane" <jane@example.com>`, m.Header.Get("To"))
+	assert.Equal(t, "", m.Header.Get("Bcc"))
+	assert.Equal(t, "Alert: disk full", m.Header.Get("Subject"))
+	assert.Equal(t, "1", m.Header.Get("X-Priority"))
+	assert.Equal(t, "text/plain; charset=utf-8", m.Header.Get("Content-Type"))
+	body, err := ioutil.ReadAll(m.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "/var is at 100%\n", string(body))
+}
+
+func TestSMTPRejected(t *testing.T) {
+	srv := newSMTPTestServer(t, "nobody@example.com")
+
+	conf := NewSMTPConfig()
+	conf.Address = srv.addr
+	conf.From = "benthos@example.com"
+	conf.To = `${! meta("to") }`
+
+	s, err := NewSMTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte("first"),
+		[]byte("second"),
+		[]byte("third"),
+	})
+	msg.Get(0).Metadata().Set("to", "ops@example.com")
+	msg.Get(1).Metadata().Set("to", "nobody@example.com")
+	msg.Get(2).Metadata().Set("to", "ops@example.com")
+
+	err = s.Write(msg)
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok)
+	var failed []int
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1}, failed)
+
+	emails := srv.sent()
+	require.Len(t, emails, 2)
+	assert.Equal(t, "first\n", strings.SplitN(emails[0].data, "\n\n", 2)[1])
+	assert.Equal(t, "third\n", strings.SplitN(emails[1].data, "\n\n", 2)[1])
+}
+
+func TestSMTPAttachments(t *testing.T) {
+	srv := newSMTPTestServer(t, "")
+
+	conf := NewSMTPConfig()
+	conf.Address = srv.addr
+	conf.From = "benthos@example.com"
+	conf.To = "ops@example.com"
+	conf.Subject = "Daily report"
+	conf.Body = "Reports are attached."
+	conf.Attachments.Enabled = true
+	conf.Attachments.Filename = `${! meta("name") }`
+	conf.Attachments.ContentType = "text/csv"
+
+	s, err := NewSMTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{
+		[]byte("a,b\n1,2\n"),
+		[]byte("c,d\n3,4\n"),
+	})
+	msg.Get(0).Metadata().Set("name", "foo.csv")
+	msg.Get(1).Metadata().Set("name", "bar.csv")
+	require.NoError(t, s.Write(msg))
+
+	emails := srv.sent()
+	require.Len(t, emails, 1)
+
+	m := readSMTPTestEmail(t, emails[0].data)
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	mr := multipart.NewReader(m.Body, params["boundary"])
+
+	p, err := mr.NextPart()
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(p)
+	require.NoError(t, err)
+	assert.Equal(t, "Reports are attached.", string(body))
+
+	for _, exp := range []struct {
+		filename string
+		content  string
+	}{
+		{"foo.csv", "a,b\n1,2\n"},
+		{"bar.csv", "c,d\n3,4\n"},
+	} {
+		p, err := mr.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, exp.filename, p.FileName())
+		assert.Equal(t, "text/csv", p.Header.Get("Content-Type"))
+
+		enc, err := ioutil.ReadAll(p)
+		require.NoError(t, err)
+		content, err := base64.StdEncoding.DecodeString(string(enc))
+		require.NoError(t, err)
+		assert.Equal(t, exp.content, string(content))
+	}
+
+	_, err = mr.NextPart()
+	assert.Error(t, err)
+}
+
+func TestSMTPConnectionError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	conf := NewSMTPConfig()
+	conf.Address = addr
+	conf.From = "benthos@example.com"
+	conf.To = "ops@example.com"
+
+	s, err := NewSMTP(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	err = s.Write(message.New([][]byte{[]byte("hello world")}))
+	require.Error(t, err)
+	_, isBatchErr := err.(*batch.Error)
+	assert.False(t, isBatchErr)
+}
@@ -0,0 +1,441 @@
+package writer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	sess "github.com/Jeffail/benthos/v3/lib/util/aws/session"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/gofrs/uuid"
+)
+
+//------------------------------------------------------------------------------
+
+// SnowflakeStageConfig contains configuration fields for the S3 bucket of an
+// external stage that files are uploaded to.
+type SnowflakeStageConfig struct {
+	sess.Config        `json:",inline" yaml:",inline"`
+	Bucket             string `json:"bucket" yaml:"bucket"`
+	Prefix             string `json:"prefix" yaml:"prefix"`
+	Path               string `json:"path" yaml:"path"`
+	ForcePathStyleURLs bool   `json:"force_path_style_urls" yaml:"force_path_style_urls"`
+}
+
+// SnowflakeConfig contains configuration fields for the Snowflake output type.
+type SnowflakeConfig struct {
+	Account        string               `json:"account" yaml:"account"`
+	User           string               `json:"user" yaml:"user"`
+	PrivateKeyFile string               `json:"private_key_file" yaml:"private_key_file"`
+	Database       string               `json:"database" yaml:"database"`
+	Schema         string               `json:"schema" yaml:"schema"`
+	Pipe           string               `json:"pipe" yaml:"pipe"`
+	Stage          SnowflakeStageConfig `json:"stage" yaml:"stage"`
+	Compression    string               `json:"compression" yaml:"compression"`
+	Timeout        string               `json:"timeout" yaml:"timeout"`
+	MaxInFlight    int                  `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching       batch.PolicyConfig   `json:"batching" yaml:"batching"`
+}
+
+// NewSnowflakeConfig creates a new SnowflakeConfig with default values.
+func NewSnowflakeConfig() SnowflakeConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return SnowflakeConfig{
+		Account:        "",
+		User:           "",
+		PrivateKeyFile: "",
+		Database:       "",
+		Schema:         "",
+		Pipe:           "",
+		Stage: SnowflakeStageConfig{
+			Config:             sess.NewConfig(),
+			Bucket:             "",
+			Prefix:             "",
+			Path:               `${!count("snowflake_files")}-${!timestamp_unix_nano()}.json`,
+			ForcePathStyleURLs: false,
+		},
+		Compression: "none",
+		Timeout:     "30s",
+		MaxInFlight: 1,
+		Batching:    batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// snowflakeTokenTTL is the lifetime of the key pair tokens that authenticate
+// requests, which Snowflake limits to an hour.
+const snowflakeTokenTTL = 59 * time.Minute
+
+// snowflakeStagedLimit is the maximum number of staged files that failed to be
+// ingested whose paths are remembered for retries.
+const snowflakeStagedLimit = 1024
+
+// Snowflake is a writer type that uploads batches of messages as files to the
+// S3 bucket of an external stage and ingests them into tables with Snowpipe.
+type Snowflake struct {
+	conf SnowflakeConfig
+
+	pipe     field.Expression
+	path     field.Expression
+	endpoint string
+	qualName string
+
+	privateKey  *rsa.PrivateKey
+	fingerprint string
+	tokenMut    sync.Mutex
+	token       string
+	tokenExpiry time.Time
+
+	httpClient *http.Client
+
+	uploaderMut sync.RWMutex
+	uploader    *s3manager.Uploader
+
+	stagedMut sync.Mutex
+	staged    map[[sha256.Size]byte]string
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewSnowflake creates a new Snowflake writer type.
+func NewSnowflake(conf SnowflakeConfig, log log.Modular, stats metrics.Type) (*Snowflake, error) {
+	if conf.Account == "" {
+		return nil, errors.New("an account must be specified")
+	}
+	if conf.User == "" {
+		return nil, errors.New("a user must be specified")
+	}
+	if conf.Database == "" || conf.Schema == "" {
+		return nil, errors.New("a database and schema must be specified")
+	}
+	if conf.Stage.Bucket == "" {
+		return nil, errors.New("a stage bucket must be specified")
+	}
+	switch conf.Compression {
+	case "none", "gzip":
+	default:
+		return nil, fmt.Errorf("compression type not recognised: %v", conf.Compression)
+	}
+
+	s := &Snowflake{
+		conf:     conf,
+		endpoint: "https://" + conf.Account + ".snowflakecomputing.com",
+		staged:   map[[sha256.Size]byte]string{},
+		log:      log,
+		stats:    stats,
+	}
+
+	account := strings.ToUpper(conf.Account)
+	if i := strings.Index(account, "."); i >= 0 {
+		account = account[:i]
+	}
+	s.qualName = account + "." + strings.ToUpper(conf.User)
+
+	var err error
+	if s.pipe, err = bloblang.NewField(conf.Pipe); err != nil {
+		return nil, fmt.Errorf("failed to parse pipe expression: %v", err)
+	}
+	if s.path, err = bloblang.NewField(conf.Stage.Path); err != nil {
+		return nil, fmt.Errorf("failed to parse path expression: %v", err)
+	}
+
+	timeout, err := time.ParseDuration(conf.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+	}
+	s.httpClient = &http.Client{Timeout: timeout}
+
+	keyBytes, err := ioutil.ReadFile(conf.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %v", err)
+	}
+	if s.privateKey, err = parseSnowflakePrivateKey(keyBytes); err != nil {
+		return nil, err
+	}
+	pubBytes, err := x509.MarshalPKIXPublicKey(&s.privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(pubBytes)
+	s.fingerprint = "SHA256:" + base64.StdEncoding.EncodeToString(sum[:])
+	return s, nil
+}
+
+func parseSnowflakePrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("failed to decode private key: no PEM block found")
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected RSA private key, found %T", key)
+	}
+	return rsaKey, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a connection to the S3 bucket of the stage.
+func (s *Snowflake) Connect() error {
+	return s.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext attempts to establish a connection to the S3 bucket of
+// the stage.
+func (s *Snowflake) ConnectWithContext(ctx context.Context) error {
+	s.uploaderMut.Lock()
+	defer s.uploaderMut.Unlock()
+
+	if s.uploader != nil {
+		return nil
+	}
+
+	awsSess, err := s.conf.Stage.GetSession(func(c *aws.Config) {
+		c.S3ForcePathStyle = aws.Bool(s.conf.Stage.ForcePathStyleURLs)
+	})
+	if err != nil {
+		return err
+	}
+	s.uploader = s3manager.NewUploader(awsSess)
+
+	s.log.Infof("Ingesting files staged in Amazon S3 bucket %v into Snowflake account: %v\n", s.conf.Stage.Bucket, s.conf.Account)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// getToken returns a key pair token for authenticating requests, generating a
+// new token when the previous one is close to expiring.
+func (s *Snowflake) getToken() (string, error) {
+	s.tokenMut.Lock()
+	defer s.tokenMut.Unlock()
+
+	now := time.Now()
+	if s.token != "" && now.Add(time.Minute).Before(s.tokenExpiry) {
+		return s.token, nil
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	expiry := now.Add(snowflakeTokenTTL)
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss": s.qualName + "." + s.fingerprint,
+		"sub": s.qualName,
+		"iat": now.Unix(),
+		"exp": expiry.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+
+	s.token = unsigned + "." + enc.EncodeToString(sig)
+	s.tokenExpiry = expiry
+	return s.token, nil
+}
+
+// insertFiles requests that Snowpipe ingests a staged file with a pipe.
+func (s *Snowflake) insertFiles(ctx context.Context, pipe, path string) error {
+	token, err := s.getToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %v", err)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"files": []map[string]string{{"path": path}},
+	})
+	if err != nil {
+		return err
+	}
+
+	requestID, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	pipeName := s.conf.Database + "." + s.conf.Schema + "." + pipe
+	reqURL := s.endpoint + "/v1/data/pipes/" + url.PathEscape(pipeName) + "/insertFiles?requestId=" + requestID.String()
+
+	req, err := http.NewRequest("POST", reqURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Snowflake-Authorization-Token-Type", "KEYPAIR_JWT")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("failed to ingest file '%v' with pipe '%v': %v: %s", path, pipeName, res.Status, resBody)
+	}
+	return nil
+}
+
+// snowflakeFile is the messages of a batch that are staged as a single file
+// and ingested with the same pipe.
+type snowflakeFile struct {
+	pipe    string
+	index   int
+	content bytes.Buffer
+}
+
+// stagedPath returns the path to stage a file at. Files that failed to be
+// ingested keep the path of their first attempt, which allows Snowpipe to skip
+// files of retried batches that it has already loaded.
+func (s *Snowflake) stagedPath(key [sha256.Size]byte, resolve func() string) string {
+	s.stagedMut.Lock()
+	defer s.stagedMut.Unlock()
+
+	if p, exists := s.staged[key]; exists {
+		return p
+	}
+	if len(s.staged) >= snowflakeStagedLimit {
+		for k := range s.staged {
+			delete(s.staged, k)
+			break
+		}
+	}
+	p := resolve()
+	s.staged[key] = p
+	return p
+}
+
+func (s *Snowflake) ingested(key [sha256.Size]byte) {
+	s.stagedMut.Lock()
+	delete(s.staged, key)
+	s.stagedMut.Unlock()
+}
+
+// Write attempts to write a message batch to Snowflake.
+func (s *Snowflake) Write(msg types.Message) error {
+	return s.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext uploads the messages of a batch as newline delimited files
+// to the stage, with a file for each pipe of the batch, and requests that
+// Snowpipe ingests them.
+func (s *Snowflake) WriteWithContext(ctx context.Context, msg types.Message) error {
+	s.uploaderMut.RLock()
+	uploader := s.uploader
+	s.uploaderMut.RUnlock()
+
+	if uploader == nil {
+		return types.ErrNotConnected
+	}
+
+	var files []*snowflakeFile
+	filesByPipe := map[string]*snowflakeFile{}
+	msg.Iter(func(i int, p types.Part) error {
+		pipe := s.pipe.String(i, msg)
+		f, exists := filesByPipe[pipe]
+		if !exists {
+			f = &snowflakeFile{
+				pipe:  pipe,
+				index: i,
+			}
+			filesByPipe[pipe] = f
+			files = append(files, f)
+		}
+		f.content.Write(p.Get())
+		f.content.WriteByte('\n')
+		return nil
+	})
+
+	for _, f := range files {
+		if f.pipe == "" {
+			return errors.New("pipe resolved to an empty string")
+		}
+
+		content := f.content.Bytes()
+		key := sha256.Sum256(append([]byte(f.pipe+"\x00"), content...))
+		path := s.stagedPath(key, func() string {
+			return s.path.String(f.index, msg)
+		})
+
+		if s.conf.Compression == "gzip" {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			if _, err := zw.Write(content); err != nil {
+				return err
+			}
+			if err := zw.Close(); err != nil {
+				return err
+			}
+			content = buf.Bytes()
+		}
+
+		if _, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+			Bucket: &s.conf.Stage.Bucket,
+			Key:    aws.String(s.conf.Stage.Prefix + path),
+			Body:   bytes.NewReader(content),
+		}); err != nil {
+			return fmt.Errorf("failed to upload file to stage: %w", err)
+		}
+		if err := s.insertFiles(ctx, f.pipe, path); err != nil {
+			return err
+		}
+		s.ingested(key)
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (s *Snowflake) CloseAsync() {
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (s *Snowflake) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,238 @@
+package writer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSnowflake struct {
+	mut     sync.Mutex
+	objects map[string][]byte
+	ingests []map[string]interface{}
+	tokens  []string
+	status  int
+}
+
+func (m *mockSnowflake) s3Handler(w http.ResponseWriter, r *http.Request) {
+	b, _ := ioutil.ReadAll(r.Body)
+	m.mut.Lock()
+	m.objects[r.URL.Path] = b
+	m.mut.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *mockSnowflake) pipeHandler(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	body["url"] = r.URL.Path
+	body["token_type"] = r.Header.Get("X-Snowflake-Authorization-Token-Type")
+
+	m.mut.Lock()
+	m.ingests = append(m.ingests, body)
+	m.tokens = append(m.tokens, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	status := m.status
+	m.mut.Unlock()
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(`{"responseCode":"SUCCESS"}`))
+}
+
+func newMockSnowflake(t *testing.T, m *mockSnowflake, fn func(conf *SnowflakeConfig)) (*Snowflake, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "benthos_snowflake_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	keyPath := filepath.Join(dir, "rsa_key.p8")
+	require.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}), 0600))
+
+	m.objects = map[string][]byte{}
+	s3Server := httptest.NewServer(http.HandlerFunc(m.s3Handler))
+	t.Cleanup(s3Server.Close)
+	pipeServer := httptest.NewServer(http.HandlerFunc(m.pipeHandler))
+	t.Cleanup(pipeServer.Close)
+
+	conf := NewSnowflakeConfig()
+	conf.Account = "ab12345.eu-west-1"
+	conf.User = "benthos"
+	conf.PrivateKeyFile = keyPath
+	conf.Database = "DB"
+	conf.Schema = "PUBLIC"
+	conf.Pipe = "EVENTS_PIPE"
+	conf.Stage.Bucket = "stage-bucket"
+	conf.Stage.Prefix = "benthos/"
+	conf.Stage.Path = `${!meta("file")}.json`
+	conf.Stage.Endpoint = s3Server.URL
+	conf.Stage.ForcePathStyleURLs = true
+	conf.Stage.Credentials.ID = "foo"
+	conf.Stage.Credentials.Secret = "bar"
+	if fn != nil {
+		fn(&conf)
+	}
+
+	s, err := NewSnowflake(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	s.endpoint = pipeServer.URL
+	require.NoError(t, s.Connect())
+	return s, key
+}
+
+func TestSnowflakeBadConfig(t *testing.T) {
+	conf := NewSnowflakeConfig()
+	_, err := NewSnowflake(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Account = "foo"
+	conf.User = "bar"
+	conf.Database = "baz"
+	conf.Schema = "PUBLIC"
+	conf.Stage.Bucket = "buz"
+	conf.Compression = "nope"
+	_, err = NewSnowflake(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Compression = "none"
+	conf.PrivateKeyFile = "/does/not/exist"
+	_, err = NewSnowflake(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestSnowflakeRouting(t *testing.T) {
+	m := &mockSnowflake{}
+	s, key := newMockSnowflake(t, m, func(conf *SnowflakeConfig) {
+		conf.Pipe = `${!json("table")}_PIPE`
+	})
+
+	msg := message.New([][]byte{
+		[]byte(`{"table":"FOO","id":1}`),
+		[]byte(`{"table":"BAR","id":2}`),
+		[]byte(`{"table":"FOO","id":3}`),
+	})
+	for i := 0; i < msg.Len(); i++ {
+		msg.Get(i).Metadata().Set("file", string(rune('a'+i)))
+	}
+	require.NoError(t, s.Write(msg))
+
+	assert.Equal(t, map[string][]byte{
+		"/stage-bucket/benthos/a.json": []byte("{\"table\":\"FOO\",\"id\":1}\n{\"table\":\"FOO\",\"id\":3}\n"),
+		"/stage-bucket/benthos/b.json": []byte("{\"table\":\"BAR\",\"id\":2}\n"),
+	}, m.objects)
+
+	require.Len(t, m.ingests, 2)
+	assert.Equal(t, map[string]interface{}{
+		"files":      []interface{}{map[string]interface{}{"path": "a.json"}},
+		"url":        "/v1/data/pipes/DB.PUBLIC.FOO_PIPE/insertFiles",
+		"token_type": "KEYPAIR_JWT",
+	}, m.ingests[0])
+	assert.Equal(t, map[string]interface{}{
+		"files":      []interface{}{map[string]interface{}{"path": "b.json"}},
+		"url":        "/v1/data/pipes/DB.PUBLIC.BAR_PIPE/insertFiles",
+		"token_type": "KEYPAIR_JWT",
+	}, m.ingests[1])
+
+	// Tokens are reused until close to expiry.
+	require.Len(t, m.tokens, 2)
+	assert.Equal(t, m.tokens[0], m.tokens[1])
+
+	parts := strings.Split(m.tokens[0], ".")
+	require.Len(t, parts, 3)
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig))
+
+	claimBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims map[string]interface{}
+	require.NoError(t, json.Unmarshal(claimBytes, &claims))
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	fp := sha256.Sum256(pubBytes)
+	assert.Equal(t, "AB12345.BENTHOS.SHA256:"+base64.StdEncoding.EncodeToString(fp[:]), claims["iss"])
+	assert.Equal(t, "AB12345.BENTHOS", claims["sub"])
+}
+
+func TestSnowflakeGzip(t *testing.T) {
+	m := &mockSnowflake{}
+	s, _ := newMockSnowflake(t, m, func(conf *SnowflakeConfig) {
+		conf.Compression = "gzip"
+		conf.Stage.Path = "foo.json.gz"
+	})
+
+	require.NoError(t, s.Write(message.New([][]byte{
+		[]byte(`{"id":1}`),
+		[]byte(`{"id":2}`),
+	})))
+
+	zr, err := gzip.NewReader(bytes.NewReader(m.objects["/stage-bucket/benthos/foo.json.gz"]))
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n", string(content))
+}
+
+func TestSnowflakeIngestError(t *testing.T) {
+	m := &mockSnowflake{status: http.StatusForbidden}
+	s, _ := newMockSnowflake(t, m, nil)
+
+	msg := message.New([][]byte{[]byte(`{"id":1}`)})
+	msg.Get(0).Metadata().Set("file", "foo")
+	assert.Error(t, s.Write(msg))
+}
+
+func TestSnowflakeRetryReusesPath(t *testing.T) {
+	m := &mockSnowflake{status: http.StatusServiceUnavailable}
+	s, _ := newMockSnowflake(t, m, func(conf *SnowflakeConfig) {
+		conf.Stage.Path = `${!count("snowflake_retry_test")}.json`
+	})
+
+	msg := message.New([][]byte{[]byte(`{"id":1}`)})
+	assert.Error(t, s.Write(msg))
+
+	m.mut.Lock()
+	m.status = http.StatusOK
+	m.mut.Unlock()
+	require.NoError(t, s.Write(msg.Copy()))
+
+	require.NoError(t, s.Write(message.New([][]byte{[]byte(`{"id":2}`)})))
+
+	require.Len(t, m.ingests, 3)
+	assert.Equal(t, []interface{}{map[string]interface{}{"path": "1.json"}}, m.ingests[0]["files"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"path": "1.json"}}, m.ingests[1]["files"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"path": "2.json"}}, m.ingests[2]["files"])
+	assert.Len(t, m.objects, 2)
+}
@@ -3,8 +3,11 @@ package writer
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -12,16 +15,25 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	snsMaxAttributes = 10
 )
 
 //------------------------------------------------------------------------------
 
 // SNSConfig contains configuration fields for the output SNS type.
 type SNSConfig struct {
-	TopicArn      string `json:"topic_arn" yaml:"topic_arn"`
-	sessionConfig `json:",inline" yaml:",inline"`
-	Timeout       string `json:"timeout" yaml:"timeout"`
-	MaxInFlight   int    `json:"max_in_flight" yaml:"max_in_flight"`
+	TopicArn               string `json:"topic_arn" yaml:"topic_arn"`
+	MessageGroupID         string `json:"message_group_id" yaml:"message_group_id"`
+	MessageDeduplicationID string `json:"message_deduplication_id" yaml:"message_deduplication_id"`
+	sessionConfig          `json:",inline" yaml:",inline"`
+	Timeout                string `json:"timeout" yaml:"timeout"`
+	MaxInFlight            int    `json:"max_in_flight" yaml:"max_in_flight"`
 }
 
 // NewSNSConfig creates a new Config with default values.
@@ -30,9 +42,11 @@ func NewSNSConfig() SNSConfig {
 		sessionConfig: sessionConfig{
 			Config: sess.NewConfig(),
 		},
-		TopicArn:    "",
-		Timeout:     "5s",
-		MaxInFlight: 1,
+		TopicArn:               "",
+		MessageGroupID:         "",
+		MessageDeduplicationID: "",
+		Timeout:                "5s",
+		MaxInFlight:            1,
 	}
 }
 
@@ -44,7 +58,10 @@ type SNS struct {
 	conf SNSConfig
 
 	session *session.Session
-	sns     *sns.SNS
+	sns     snsiface.SNSAPI
+
+	groupID  field.Expression
+	dedupeID field.Expression
 
 	tout time.Duration
 
@@ -59,8 +76,18 @@ func NewSNS(conf SNSConfig, log log.Modular, stats metrics.Type) (*SNS, error) {
 		log:   log,
 		stats: stats,
 	}
+	var err error
+	if id := conf.MessageGroupID; len(id) > 0 {
+		if s.groupID, err = bloblang.NewField(id); err != nil {
+			return nil, fmt.Errorf("failed to parse group ID expression: %v", err)
+		}
+	}
+	if id := conf.MessageDeduplicationID; len(id) > 0 {
+		if s.dedupeID, err = bloblang.NewField(id); err != nil {
+			return nil, fmt.Errorf("failed to parse dedupe ID expression: %v", err)
+		}
+	}
 	if tout := conf.Timeout; len(tout) > 0 {
-		var err error
 		if s.tout, err = time.ParseDuration(tout); err != nil {
 			return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
 		}
@@ -91,6 +118,36 @@ func (a *SNS) Connect() error {
 	return nil
 }
 
+// SNS message attribute names are subject to the same restrictions as SQS
+// attribute names.
+func (a *SNS) getSNSAttributes(p types.Part) map[string]*sns.MessageAttributeValue {
+	keys := []string{}
+	p.Metadata().Iter(func(k, v string) error {
+		if isValidSQSAttribute(k, v) {
+			keys = append(keys, k)
+		} else {
+			a.log.Debugf("Rejecting metadata key '%v' due to invalid characters\n", k)
+		}
+		return nil
+	})
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	if len(keys) > snsMaxAttributes {
+		keys = keys[:snsMaxAttributes]
+	}
+
+	values := map[string]*sns.MessageAttributeValue{}
+	for _, k := range keys {
+		values[k] = &sns.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(p.Metadata().Get(k)),
+		}
+	}
+	return values
+}
+
 // Write attempts to write message contents to a target SNS.
 func (a *SNS) Write(msg types.Message) error {
 	return a.WriteWithContext(context.Background(), msg)
@@ -107,8 +164,15 @@ func (a *SNS) WriteWithContext(wctx context.Context, msg types.Message) error {
 
 	return IterateBatchedSend(msg, func(i int, p types.Part) error {
 		message := &sns.PublishInput{
-			TopicArn: aws.String(a.conf.TopicArn),
-			Message:  aws.String(string(p.Get())),
+			TopicArn:          aws.String(a.conf.TopicArn),
+			Message:           aws.String(string(p.Get())),
+			MessageAttributes: a.getSNSAttributes(p),
+		}
+		if a.groupID != nil {
+			message.MessageGroupId = aws.String(a.groupID.String(i, msg))
+		}
+		if a.dedupeID != nil {
+			message.MessageDeduplicationId = aws.String(a.dedupeID.String(i, msg))
 		}
 		_, err := a.sns.PublishWithContext(ctx, message)
 		return err
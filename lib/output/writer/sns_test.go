@@ -0,0 +1,109 @@
+package writer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSNS struct {
+	snsiface.SNSAPI
+	fn func(*sns.PublishInput) (*sns.PublishOutput, error)
+}
+
+func (m *mockSNS) PublishWithContext(ctx aws.Context, input *sns.PublishInput, opts ...request.Option) (*sns.PublishOutput, error) {
+	return m.fn(input)
+}
+
+func TestSNSAttributes(t *testing.T) {
+	conf := NewSNSConfig()
+	conf.TopicArn = "arn:aws:sns:us-east-1:123456789012:foo.fifo"
+	conf.MessageGroupID = `${! json("group") }`
+	conf.MessageDeduplicationID = `${! json("id") }`
+
+	s, err := NewSNS(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var inputs []*sns.PublishInput
+	s.session = session.Must(session.NewSession())
+	s.sns = &mockSNS{
+		fn: func(input *sns.PublishInput) (*sns.PublishOutput, error) {
+			inputs = append(inputs, input)
+			return &sns.PublishOutput{}, nil
+		},
+	}
+
+	msg := message.New([][]byte{
+		[]byte(`{"group":"foo","id":"1"}`),
+		[]byte(`{"group":"bar","id":"2"}`),
+	})
+	msg.Get(0).Metadata().Set("a", "a value").Set("b", "b value").Set("aws.nope", "nope")
+	for _, k := range []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7", "k8", "k9", "k10"} {
+		msg.Get(1).Metadata().Set(k, k)
+	}
+	require.NoError(t, s.Write(msg))
+
+	require.Len(t, inputs, 2)
+	assert.Equal(t, &sns.PublishInput{
+		TopicArn: aws.String(conf.TopicArn),
+		Message:  aws.String(`{"group":"foo","id":"1"}`),
+		MessageAttributes: map[string]*sns.MessageAttributeValue{
+			"a": {DataType: aws.String("String"), StringValue: aws.String("a value")},
+			"b": {DataType: aws.String("String"), StringValue: aws.String("b value")},
+		},
+		MessageGroupId:         aws.String("foo"),
+		MessageDeduplicationId: aws.String("1"),
+	}, inputs[0])
+
+	assert.Equal(t, "bar", *inputs[1].MessageGroupId)
+	assert.Equal(t, "2", *inputs[1].MessageDeduplicationId)
+	assert.Len(t, inputs[1].MessageAttributes, 10)
+	assert.NotContains(t, inputs[1].MessageAttributes, "k9")
+}
+
+func TestSNSPartialFailure(t *testing.T) {
+	s, err := NewSNS(NewSNSConfig(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.Equal(t, types.ErrNotConnected, s.Write(message.New([][]byte{[]byte(`foo`)})))
+
+	s.session = session.Must(session.NewSession())
+	s.sns = &mockSNS{
+		fn: func(input *sns.PublishInput) (*sns.PublishOutput, error) {
+			if *input.Message == "bar" {
+				return nil, errors.New("nope")
+			}
+			assert.Nil(t, input.MessageAttributes)
+			assert.Nil(t, input.MessageGroupId)
+			assert.Nil(t, input.MessageDeduplicationId)
+			return &sns.PublishOutput{}, nil
+		},
+	}
+
+	err = s.Write(message.New([][]byte{[]byte(`foo`), []byte(`bar`), []byte(`baz`)}))
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok)
+
+	var failed []int
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{1}, failed)
+}
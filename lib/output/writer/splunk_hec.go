@@ -0,0 +1,475 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	batchInternal "github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/query"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/gofrs/uuid"
+)
+
+//------------------------------------------------------------------------------
+
+// SplunkHECAckConfig contains configuration fields for polling the indexer
+// acknowledgement of events.
+type SplunkHECAckConfig struct {
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+	PollInterval string `json:"poll_interval" yaml:"poll_interval"`
+	Timeout      string `json:"timeout" yaml:"timeout"`
+}
+
+// SplunkHECConfig contains configuration fields for the Splunk HEC output
+// type.
+type SplunkHECConfig struct {
+	URL              string             `json:"url" yaml:"url"`
+	Token            string             `json:"token" yaml:"token"`
+	Endpoint         string             `json:"endpoint" yaml:"endpoint"`
+	Host             string             `json:"host" yaml:"host"`
+	Source           string             `json:"source" yaml:"source"`
+	SourceType       string             `json:"sourcetype" yaml:"sourcetype"`
+	Index            string             `json:"index" yaml:"index"`
+	TimestampMapping string             `json:"timestamp_mapping" yaml:"timestamp_mapping"`
+	Channel          string             `json:"channel" yaml:"channel"`
+	Ack              SplunkHECAckConfig `json:"acknowledgement" yaml:"acknowledgement"`
+	TLS              btls.Config        `json:"tls" yaml:"tls"`
+	Timeout          string             `json:"timeout" yaml:"timeout"`
+	MaxInFlight      int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching         batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewSplunkHECConfig creates a new SplunkHECConfig with default values.
+func NewSplunkHECConfig() SplunkHECConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return SplunkHECConfig{
+		URL:              "",
+		Token:            "",
+		Endpoint:         "event",
+		Host:             "",
+		Source:           "",
+		SourceType:       "",
+		Index:            "",
+		TimestampMapping: "",
+		Channel:          "",
+		Ack: SplunkHECAckConfig{
+			Enabled:      false,
+			PollInterval: "1s",
+			Timeout:      "1m",
+		},
+		TLS:         btls.NewConfig(),
+		Timeout:     "5s",
+		MaxInFlight: 1,
+		Batching:    batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// splunkHECResponse is the body of responses from the HTTP Event Collector.
+type splunkHECResponse struct {
+	Text  string `json:"text"`
+	Code  int    `json:"code"`
+	AckID *int64 `json:"ackId"`
+}
+
+// splunkHECMetadata is the metadata of events that is either written with each
+// event of the event endpoint or as the query parameters of raw requests.
+type splunkHECMetadata struct {
+	host       string
+	source     string
+	sourceType string
+	index      string
+}
+
+// SplunkHEC is a writer type that sends messages as events to a Splunk HTTP
+// Event Collector.
+type SplunkHEC struct {
+	conf SplunkHECConfig
+
+	baseURL  string
+	channel  string
+	pollIntv time.Duration
+	ackTout  time.Duration
+
+	host             field.Expression
+	source           field.Expression
+	sourceType       field.Expression
+	index            field.Expression
+	timestampMapping *mapping.Executor
+
+	httpClient *http.Client
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewSplunkHEC creates a new Splunk HEC writer type.
+func NewSplunkHEC(conf SplunkHECConfig, log log.Modular, stats metrics.Type) (*SplunkHEC, error) {
+	s := &SplunkHEC{
+		conf:      conf,
+		baseURL:   strings.TrimSuffix(conf.URL, "/"),
+		channel:   conf.Channel,
+		closeChan: make(chan struct{}),
+		log:       log,
+		stats:     stats,
+	}
+
+	if conf.URL == "" {
+		return nil, errors.New("a url must be specified")
+	}
+	if conf.Token == "" {
+		return nil, errors.New("a token must be specified")
+	}
+	switch conf.Endpoint {
+	case "event":
+	case "raw":
+		if conf.TimestampMapping != "" {
+			return nil, errors.New("a timestamp mapping cannot be used with the raw endpoint")
+		}
+	default:
+		return nil, fmt.Errorf("endpoint not recognised: %v", conf.Endpoint)
+	}
+
+	var err error
+	for _, f := range []struct {
+		name  string
+		value string
+		expr  *field.Expression
+	}{
+		{"host", conf.Host, &s.host},
+		{"source", conf.Source, &s.source},
+		{"sourcetype", conf.SourceType, &s.sourceType},
+		{"index", conf.Index, &s.index},
+	} {
+		if f.value == "" {
+			continue
+		}
+		if *f.expr, err = bloblang.NewField(f.value); err != nil {
+			return nil, fmt.Errorf("failed to parse %v expression: %v", f.name, err)
+		}
+	}
+	if conf.TimestampMapping != "" {
+		if s.timestampMapping, err = newMapping("timestamp mapping", conf.TimestampMapping); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.Ack.Enabled {
+		if s.pollIntv, err = time.ParseDuration(conf.Ack.PollInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse acknowledgement poll interval string: %v", err)
+		}
+		if s.ackTout, err = time.ParseDuration(conf.Ack.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse acknowledgement timeout string: %v", err)
+		}
+		if s.channel == "" {
+			id, err := uuid.NewV4()
+			if err != nil {
+				return nil, err
+			}
+			s.channel = id.String()
+		}
+	}
+
+	timeout, err := time.ParseDuration(conf.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
+	}
+	s.httpClient = &http.Client{Timeout: timeout}
+	if conf.TLS.Enabled {
+		tlsConf, err := conf.TLS.Get()
+		if err != nil {
+			return nil, err
+		}
+		s.httpClient.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConf,
+		}
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *SplunkHEC) metadata(index int, msg types.Message) splunkHECMetadata {
+	var m splunkHECMetadata
+	if s.host != nil {
+		m.host = s.host.String(index, msg)
+	}
+	if s.source != nil {
+		m.source = s.source.String(index, msg)
+	}
+	if s.sourceType != nil {
+		m.sourceType = s.sourceType.String(index, msg)
+	}
+	if s.index != nil {
+		m.index = s.index.String(index, msg)
+	}
+	return m
+}
+
+// event converts a message of a batch into an event of the event endpoint,
+// where messages that are valid JSON are sent as structured events.
+func (s *SplunkHEC) event(index int, msg types.Message) ([]byte, error) {
+	p := msg.Get(index)
+
+	event := map[string]interface{}{}
+	if jObj, err := p.JSON(); err == nil {
+		event["event"] = jObj
+	} else {
+		event["event"] = string(p.Get())
+	}
+
+	m := s.metadata(index, msg)
+	for k, v := range map[string]string{
+		"host":       m.host,
+		"source":     m.source,
+		"sourcetype": m.sourceType,
+		"index":      m.index,
+	} {
+		if v != "" {
+			event[k] = v
+		}
+	}
+
+	if s.timestampMapping != nil {
+		res, err := execMapping(s.timestampMapping, index, msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute timestamp mapping: %w", err)
+		}
+		switch t := res.(type) {
+		case query.Delete, query.Nothing, nil:
+		case string:
+			ts, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse timestamp mapping result: %w", err)
+			}
+			event["time"] = json.Number(fmt.Sprintf("%d.%03d", ts.Unix(), ts.Nanosecond()/int(time.Millisecond)))
+		default:
+			n, err := query.IGetNumber(res)
+			if err != nil {
+				return nil, fmt.Errorf("timestamp mapping returned invalid result: %w", err)
+			}
+			event["time"] = n
+		}
+	}
+	return json.Marshal(event)
+}
+
+//------------------------------------------------------------------------------
+
+// Connect does nothing as the Splunk HEC output writes with plain HTTP
+// requests.
+func (s *SplunkHEC) Connect() error {
+	return s.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext does nothing as the Splunk HEC output writes with plain
+// HTTP requests.
+func (s *SplunkHEC) ConnectWithContext(ctx context.Context) error {
+	s.log.Infof("Sending events to Splunk HEC at: %v\n", s.conf.URL)
+	return nil
+}
+
+// Write attempts to write a message batch to Splunk HEC.
+func (s *SplunkHEC) Write(msg types.Message) error {
+	return s.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext sends the messages of a batch to Splunk HEC. Batches sent
+// to the event endpoint are sent with a single request, whereas batches sent to
+// the raw endpoint are sent with a request for each distinct combination of
+// metadata, as the metadata of raw events is specified per request.
+func (s *SplunkHEC) WriteWithContext(ctx context.Context, msg types.Message) error {
+	if s.conf.Endpoint == "event" {
+		var batchErr *batchInternal.Error
+		var body bytes.Buffer
+		var indexes []int
+		msg.Iter(func(i int, _ types.Part) error {
+			event, err := s.event(i, msg)
+			if err != nil {
+				s.log.Errorf("Failed to convert message into an event: %v\n", err)
+				if batchErr == nil {
+					batchErr = batchInternal.NewError(msg, err)
+				}
+				batchErr.Failed(i, err)
+				return nil
+			}
+			body.Write(event)
+			body.WriteByte('\n')
+			indexes = append(indexes, i)
+			return nil
+		})
+		if len(indexes) > 0 {
+			if err := s.send(ctx, "/services/collector/event", nil, body.Bytes()); err != nil {
+				if batchErr == nil {
+					return err
+				}
+				for _, i := range indexes {
+					batchErr.Failed(i, err)
+				}
+			}
+		}
+		if batchErr != nil {
+			return batchErr
+		}
+		return nil
+	}
+
+	var metas []splunkHECMetadata
+	bodies := map[splunkHECMetadata]*bytes.Buffer{}
+	msg.Iter(func(i int, p types.Part) error {
+		m := s.metadata(i, msg)
+		body, exists := bodies[m]
+		if !exists {
+			body = &bytes.Buffer{}
+			bodies[m] = body
+			metas = append(metas, m)
+		}
+		body.Write(p.Get())
+		body.WriteByte('\n')
+		return nil
+	})
+	for _, m := range metas {
+		params := url.Values{}
+		for k, v := range map[string]string{
+			"host":       m.host,
+			"source":     m.source,
+			"sourcetype": m.sourceType,
+			"index":      m.index,
+		} {
+			if v != "" {
+				params.Set(k, v)
+			}
+		}
+		if err := s.send(ctx, "/services/collector/raw", params, bodies[m].Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// request performs a request against the HTTP Event Collector and parses the
+// response.
+func (s *SplunkHEC) request(ctx context.Context, path string, params url.Values, body []byte) (*splunkHECResponse, []byte, error) {
+	reqURL := s.baseURL + path
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+	req, err := http.NewRequest("POST", reqURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Splunk "+s.conf.Token)
+	if s.channel != "" {
+		req.Header.Set("X-Splunk-Request-Channel", s.channel)
+	}
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	var hecRes splunkHECResponse
+	jErr := json.Unmarshal(resBody, &hecRes)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		if jErr == nil && hecRes.Text != "" {
+			return nil, nil, fmt.Errorf("request failed: %v: %v (code %v)", res.Status, hecRes.Text, hecRes.Code)
+		}
+		return nil, nil, fmt.Errorf("request failed: %v: %s", res.Status, bytes.TrimSpace(resBody))
+	}
+	return &hecRes, resBody, nil
+}
+
+// send sends events and, when acknowledgements are enabled, waits until they
+// have been indexed.
+func (s *SplunkHEC) send(ctx context.Context, path string, params url.Values, body []byte) error {
+	res, _, err := s.request(ctx, path, params, body)
+	if err != nil {
+		return err
+	}
+	if !s.conf.Ack.Enabled {
+		return nil
+	}
+	if res.AckID == nil {
+		return errors.New("response did not contain an acknowledgement id, indexer acknowledgement might not be enabled for the token")
+	}
+	return s.waitForAck(ctx, *res.AckID)
+}
+
+// waitForAck polls the acknowledgement status of events until they have been
+// indexed or the acknowledgement timeout is reached.
+func (s *SplunkHEC) waitForAck(ctx context.Context, ackID int64) error {
+	ackBody, err := json.Marshal(map[string]interface{}{
+		"acks": []int64{ackID},
+	})
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(s.ackTout)
+	for {
+		select {
+		case <-time.After(s.pollIntv):
+		case <-ctx.Done():
+			return types.ErrTimeout
+		case <-s.closeChan:
+			return types.ErrTypeClosed
+		}
+
+		_, resBody, err := s.request(ctx, "/services/collector/ack", nil, ackBody)
+		if err != nil {
+			s.log.Warnf("Failed to poll acknowledgement status: %v\n", err)
+		} else {
+			var ackRes struct {
+				Acks map[string]bool `json:"acks"`
+			}
+			if err = json.Unmarshal(resBody, &ackRes); err != nil {
+				s.log.Warnf("Failed to parse acknowledgement status: %v\n", err)
+			} else if ackRes.Acks[fmt.Sprintf("%d", ackID)] {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("events with acknowledgement id %v were not indexed within %v", ackID, s.ackTout)
+		}
+	}
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (s *SplunkHEC) CloseAsync() {
+	s.closeOnce.Do(func() {
+		close(s.closeChan)
+	})
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (s *SplunkHEC) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
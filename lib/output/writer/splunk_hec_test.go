@@ -0,0 +1,236 @@
+package writer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type splunkHECTestRequest struct {
+	path    string
+	query   string
+	auth    string
+	channel string
+	body    string
+}
+
+type splunkHECTestServer struct {
+	*httptest.Server
+
+	mut     sync.Mutex
+	reqs    []splunkHECTestRequest
+	respond func(r splunkHECTestRequest) (int, string)
+}
+
+func newSplunkHECTestServer(t *testing.T, respond func(r splunkHECTestRequest) (int, string)) *splunkHECTestServer {
+	t.Helper()
+
+	s := &splunkHECTestServer{respond: respond}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		req := splunkHECTestRequest{
+			path:    r.URL.Path,
+			query:   r.URL.RawQuery,
+			auth:    r.Header.Get("Authorization"),
+			channel: r.Header.Get("X-Splunk-Request-Channel"),
+			body:    string(b),
+		}
+		s.mut.Lock()
+		s.reqs = append(s.reqs, req)
+		s.mut.Unlock()
+
+		code, body := s.respond(req)
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *splunkHECTestServer) requests() []splunkHECTestRequest {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]splunkHECTestRequest(nil), s.reqs...)
+}
+
+func TestSplunkHECConfigErrors(t *testing.T) {
+	tests := map[string]func(c *SplunkHECConfig){
+		"no url": func(c *SplunkHECConfig) {
+			c.URL = ""
+		},
+		"no token": func(c *SplunkHECConfig) {
+			c.Token = ""
+		},
+		"bad endpoint": func(c *SplunkHECConfig) {
+			c.Endpoint = "metrics"
+		},
+		"raw timestamp mapping": func(c *SplunkHECConfig) {
+			c.Endpoint = "raw"
+			c.TimestampMapping = "root = this.time"
+		},
+		"bad index": func(c *SplunkHECConfig) {
+			c.Index = "${! json( }"
+		},
+		"bad poll interval": func(c *SplunkHECConfig) {
+			c.Ack.Enabled = true
+			c.Ack.PollInterval = "nope"
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewSplunkHECConfig()
+			conf.URL = "https://localhost:8088"
+			conf.Token = "foo"
+			test(&conf)
+
+			_, err := NewSplunkHEC(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSplunkHECEvents(t *testing.T) {
+	server := newSplunkHECTestServer(t, func(r splunkHECTestRequest) (int, string) {
+		return http.StatusOK, `{"text":"Success","code":0}`
+	})
+
+	conf := NewSplunkHECConfig()
+	conf.URL = server.URL + "/"
+	conf.Token = "foo"
+	conf.SourceType = `${! meta("sourcetype") }`
+	conf.Index = "main"
+	conf.Host = "benthos"
+	conf.TimestampMapping = `root = this.time`
+
+	s, err := NewSplunkHEC(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, s.Connect())
+
+	msg := message.New([][]byte{
+		[]byte(`{"time":"2020-09-13T12:26:40.5Z","msg":"hello"}`),
+		[]byte(`{"time":1600000000.25}`),
+		[]byte(`{"time":"not a time"}`),
+	})
+	msg.Get(0).Metadata().Set("sourcetype", "_json")
+
+	err = s.Write(msg)
+	require.Error(t, err)
+
+	bErr, ok := err.(*batch.Error)
+	require.True(t, ok)
+	var failed []int
+	bErr.WalkParts(func(i int, _ types.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{2}, failed)
+
+	reqs := server.requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "/services/collector/event", reqs[0].path)
+	assert.Equal(t, "Splunk foo", reqs[0].auth)
+	assert.Equal(t, "", reqs[0].channel)
+	assert.Equal(t, `{"event":{"msg":"hello","time":"2020-09-13T12:26:40.5Z"},"host":"benthos","index":"main","sourcetype":"_json","time":1600000000.500}
+{"event":{"time":1600000000.25},"host":"benthos","index":"main","time":1600000000.25}
+`, reqs[0].body)
+
+	s.timestampMapping = nil
+	require.NoError(t, s.Write(message.New([][]byte{[]byte(`not json`)})))
+	reqs = server.requests()
+	require.Len(t, reqs, 2)
+	assert.Equal(t, `{"event":"not json","host":"benthos","index":"main"}`+"\n", reqs[1].body)
+}
+
+func TestSplunkHECRaw(t *testing.T) {
+	server := newSplunkHECTestServer(t, func(r splunkHECTestRequest) (int, string) {
+		if r.query == "index=bar" {
+			return http.StatusBadRequest, `{"text":"Incorrect index","code":7}`
+		}
+		return http.StatusOK, `{"text":"Success","code":0}`
+	})
+
+	conf := NewSplunkHECConfig()
+	conf.URL = server.URL
+	conf.Token = "foo"
+	conf.Endpoint = "raw"
+	conf.Index = `${! meta("index") }`
+
+	s, err := NewSplunkHEC(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.New([][]byte{[]byte(`first`), []byte(`second`), []byte(`third`)})
+	msg.Get(0).Metadata().Set("index", "foo")
+	msg.Get(1).Metadata().Set("index", "main")
+	msg.Get(2).Metadata().Set("index", "foo")
+	require.NoError(t, s.Write(msg))
+
+	reqs := server.requests()
+	require.Len(t, reqs, 2)
+	assert.Equal(t, "/services/collector/raw", reqs[0].path)
+	assert.Equal(t, "index=foo", reqs[0].query)
+	assert.Equal(t, "first\nthird\n", reqs[0].body)
+	assert.Equal(t, "index=main", reqs[1].query)
+	assert.Equal(t, "second\n", reqs[1].body)
+
+	msg = message.New([][]byte{[]byte(`fourth`)})
+	msg.Get(0).Metadata().Set("index", "bar")
+	err = s.Write(msg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400 Bad Request: Incorrect index (code 7)")
+}
+
+func TestSplunkHECAcknowledgement(t *testing.T) {
+	var polls int
+	server := newSplunkHECTestServer(t, func(r splunkHECTestRequest) (int, string) {
+		if r.path == "/services/collector/ack" {
+			polls++
+			if polls < 3 {
+				return http.StatusOK, `{"acks":{"5":false}}`
+			}
+			return http.StatusOK, `{"acks":{"5":true}}`
+		}
+		return http.StatusOK, `{"text":"Success","code":0,"ackId":5}`
+	})
+
+	conf := NewSplunkHECConfig()
+	conf.URL = server.URL
+	conf.Token = "foo"
+	conf.Ack.Enabled = true
+	conf.Ack.PollInterval = "1ms"
+
+	s, err := NewSplunkHEC(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, s.Write(message.New([][]byte{[]byte(`{"msg":"hello"}`)})))
+
+	reqs := server.requests()
+	require.Len(t, reqs, 4)
+	assert.Equal(t, "/services/collector/event", reqs[0].path)
+	assert.NotEmpty(t, reqs[0].channel)
+	for _, r := range reqs[1:] {
+		assert.Equal(t, "/services/collector/ack", r.path)
+		assert.Equal(t, reqs[0].channel, r.channel)
+		assert.Equal(t, `{"acks":[5]}`, r.body)
+	}
+
+	conf.Ack.Timeout = "5ms"
+	polls = -1000
+	s, err = NewSplunkHEC(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	err = s.Write(message.New([][]byte{[]byte(`{"msg":"hello"}`)}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "were not indexed within 5ms")
+}
@@ -0,0 +1,115 @@
+package writer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/lib/types"
+
+	// SQL Drivers
+	_ "github.com/ClickHouse/clickhouse-go"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+//------------------------------------------------------------------------------
+
+// sqlDriverNames maps the drivers supported by the SQL outputs to the names
+// they are registered with by their packages.
+var sqlDriverNames = map[string]string{
+	"clickhouse": "clickhouse",
+	"mssql":      "sqlserver",
+	"mysql":      "mysql",
+	"postgres":   "postgres",
+	"sqlite":     "sqlite",
+}
+
+// sqlMaxArgs is the maximum number of arguments that each driver supports
+// within a single statement.
+var sqlMaxArgs = map[string]int{
+	"mssql":    2100,
+	"mysql":    65535,
+	"postgres": 65535,
+	"sqlite":   32766,
+}
+
+func sqlCheckDriver(driver string) error {
+	if _, exists := sqlDriverNames[driver]; !exists {
+		return fmt.Errorf("unrecognised driver: %v", driver)
+	}
+	return nil
+}
+
+// sqlOpen opens a database with a driver and checks that it can be reached.
+func sqlOpen(ctx context.Context, driver, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(sqlDriverNames[driver], dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// sqlPlaceholder returns the placeholder of the nth argument of a statement
+// in the syntax of a driver, where n begins at one.
+func sqlPlaceholder(driver string, n int) string {
+	switch driver {
+	case "postgres":
+		return "$" + strconv.Itoa(n)
+	case "mssql":
+		return "@p" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// sqlInsertOnlyBatchDriver returns whether a driver only supports batches of
+// rows as prepared inserts within a transaction, where each row of the batch
+// is executed separately.
+func sqlInsertOnlyBatchDriver(driver string) bool {
+	return driver == "clickhouse"
+}
+
+//------------------------------------------------------------------------------
+
+// sqlArgs executes an args mapping against a message of a batch and returns
+// the resulting arguments.
+func sqlArgs(exec *mapping.Executor, index int, msg types.Message) ([]interface{}, error) {
+	values, err := execArgsMapping(exec, index, msg)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		if args[i], err = sqlArgValue(v); err != nil {
+			return nil, fmt.Errorf("failed to convert arg %v: %w", i, err)
+		}
+	}
+	return args, nil
+}
+
+// sqlArgValue converts a value resulting from an args mapping into a type
+// accepted by drivers, where objects and arrays are serialised as JSON.
+func sqlArgValue(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	}
+	return v, nil
+}
+
+//------------------------------------------------------------------------------
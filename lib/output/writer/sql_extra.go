@@ -0,0 +1,11 @@
+// +build !wasm
+
+package writer
+
+// Import extra drivers that aren't supported by WASM builds.
+import (
+	// SQL Drivers
+	_ "github.com/denisenkom/go-mssqldb"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
@@ -0,0 +1,349 @@
+package writer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// SQLInsertOnConflictConfig contains configuration fields for how the
+// SQLInsert output type handles rows that conflict with existing rows.
+type SQLInsertOnConflictConfig struct {
+	Action        string   `json:"action" yaml:"action"`
+	Columns       []string `json:"columns" yaml:"columns"`
+	UpdateColumns []string `json:"update_columns" yaml:"update_columns"`
+}
+
+// SQLInsertConfig contains configuration fields for the SQLInsert output type.
+type SQLInsertConfig struct {
+	Driver         string                    `json:"driver" yaml:"driver"`
+	DataSourceName string                    `json:"data_source_name" yaml:"data_source_name"`
+	Table          string                    `json:"table" yaml:"table"`
+	Columns        []string                  `json:"columns" yaml:"columns"`
+	ArgsMapping    string                    `json:"args_mapping" yaml:"args_mapping"`
+	OnConflict     SQLInsertOnConflictConfig `json:"on_conflict" yaml:"on_conflict"`
+	Suffix         string                    `json:"suffix" yaml:"suffix"`
+	MaxInFlight    int                       `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching       batch.PolicyConfig        `json:"batching" yaml:"batching"`
+}
+
+// NewSQLInsertConfig creates a new SQLInsertConfig with default values.
+func NewSQLInsertConfig() SQLInsertConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return SQLInsertConfig{
+		Driver:         "mysql",
+		DataSourceName: "",
+		Table:          "",
+		Columns:        []string{},
+		ArgsMapping:    "",
+		OnConflict: SQLInsertOnConflictConfig{
+			Action:        "none",
+			Columns:       []string{},
+			UpdateColumns: []string{},
+		},
+		Suffix:      "",
+		MaxInFlight: 1,
+		Batching:    batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SQLInsert is a writer type that inserts each message of a batch as a row of
+// a table, where the rows of a batch are inserted with multi-row statements
+// within a single transaction.
+type SQLInsert struct {
+	conf        SQLInsertConfig
+	argsMapping *mapping.Executor
+
+	// The statement inserting n rows is the prefix followed by n row
+	// placeholders and then the suffix.
+	prefix  string
+	suffix  string
+	maxRows int
+
+	dbMut sync.RWMutex
+	db    *sql.DB
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewSQLInsert creates a new SQLInsert writer type.
+func NewSQLInsert(conf SQLInsertConfig, log log.Modular, stats metrics.Type) (*SQLInsert, error) {
+	if err := sqlCheckDriver(conf.Driver); err != nil {
+		return nil, err
+	}
+	if conf.DataSourceName == "" {
+		return nil, errors.New("a data source name must be specified")
+	}
+	if conf.Table == "" {
+		return nil, errors.New("a table must be specified")
+	}
+	if len(conf.Columns) == 0 {
+		return nil, errors.New("at least one column must be specified")
+	}
+	if conf.ArgsMapping == "" {
+		return nil, errors.New("an args mapping must be specified")
+	}
+
+	s := &SQLInsert{
+		conf:    conf,
+		maxRows: 1,
+		log:     log,
+		stats:   stats,
+	}
+
+	var err error
+	if s.argsMapping, err = newMapping("args mapping", conf.ArgsMapping); err != nil {
+		return nil, err
+	}
+	if s.prefix, s.suffix, err = sqlInsertQueryParts(conf); err != nil {
+		return nil, err
+	}
+	if maxArgs, exists := sqlMaxArgs[conf.Driver]; exists && maxArgs/len(conf.Columns) > 1 {
+		s.maxRows = maxArgs / len(conf.Columns)
+	}
+	return s, nil
+}
+
+// sqlInsertQueryParts returns the parts of an insert statement that precede
+// and follow the rows being inserted, including the clauses that handle
+// conflicts in the syntax of the driver.
+func sqlInsertQueryParts(conf SQLInsertConfig) (prefix, suffix string, err error) {
+	verb := "INSERT INTO "
+	switch conf.OnConflict.Action {
+	case "none":
+	case "ignore":
+		switch conf.Driver {
+		case "postgres", "sqlite":
+			suffix = " ON CONFLICT"
+			if len(conf.OnConflict.Columns) > 0 {
+				suffix += " (" + strings.Join(conf.OnConflict.Columns, ", ") + ")"
+			}
+			suffix += " DO NOTHING"
+		case "mysql":
+			verb = "INSERT IGNORE INTO "
+		default:
+			return "", "", fmt.Errorf("on conflict action '%v' is not supported by driver '%v'", conf.OnConflict.Action, conf.Driver)
+		}
+	case "update":
+		updateColumns := conf.OnConflict.UpdateColumns
+		if len(updateColumns) == 0 {
+			for _, c := range conf.Columns {
+				conflicting := false
+				for _, cc := range conf.OnConflict.Columns {
+					if c == cc {
+						conflicting = true
+					}
+				}
+				if !conflicting {
+					updateColumns = append(updateColumns, c)
+				}
+			}
+		}
+		if len(updateColumns) == 0 {
+			return "", "", errors.New("at least one column must be updated on conflict")
+		}
+		assignments := make([]string, len(updateColumns))
+		switch conf.Driver {
+		case "postgres", "sqlite":
+			if len(conf.OnConflict.Columns) == 0 {
+				return "", "", errors.New("the columns of a unique constraint must be specified in order to update rows on conflict")
+			}
+			for i, c := range updateColumns {
+				assignments[i] = c + " = EXCLUDED." + c
+			}
+			suffix = " ON CONFLICT (" + strings.Join(conf.OnConflict.Columns, ", ") + ") DO UPDATE SET " + strings.Join(assignments, ", ")
+		case "mysql":
+			for i, c := range updateColumns {
+				assignments[i] = c + " = VALUES(" + c + ")"
+			}
+			suffix = " ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+		default:
+			return "", "", fmt.Errorf("on conflict action '%v' is not supported by driver '%v'", conf.OnConflict.Action, conf.Driver)
+		}
+	default:
+		return "", "", fmt.Errorf("unrecognised on conflict action: %v", conf.OnConflict.Action)
+	}
+
+	prefix = verb + conf.Table + " (" + strings.Join(conf.Columns, ", ") + ") VALUES "
+	if conf.Suffix != "" {
+		suffix += " " + conf.Suffix
+	}
+	return
+}
+
+// query returns the statement that inserts a number of rows.
+func (s *SQLInsert) query(rows int) string {
+	var b strings.Builder
+	b.WriteString(s.prefix)
+	n := 0
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j := range s.conf.Columns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			n++
+			b.WriteString(sqlPlaceholder(s.conf.Driver, n))
+		}
+		b.WriteByte(')')
+	}
+	b.WriteString(s.suffix)
+	return b.String()
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a connection to the database.
+func (s *SQLInsert) Connect() error {
+	return s.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext attempts to establish a connection to the database.
+func (s *SQLInsert) ConnectWithContext(ctx context.Context) error {
+	s.dbMut.Lock()
+	defer s.dbMut.Unlock()
+
+	if s.db != nil {
+		return nil
+	}
+
+	db, err := sqlOpen(ctx, s.conf.Driver, s.conf.DataSourceName)
+	if err != nil {
+		return err
+	}
+	s.db = db
+
+	s.log.Infof("Inserting messages as rows into %v table: %v\n", s.conf.Driver, s.conf.Table)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Write attempts to write message contents to the database.
+func (s *SQLInsert) Write(msg types.Message) error {
+	return s.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext inserts each message of a batch as a row of the table
+// within a single transaction.
+func (s *SQLInsert) WriteWithContext(ctx context.Context, msg types.Message) error {
+	s.dbMut.RLock()
+	db := s.db
+	s.dbMut.RUnlock()
+
+	if db == nil {
+		return types.ErrNotConnected
+	}
+
+	rows := make([][]interface{}, msg.Len())
+	for i := range rows {
+		args, err := sqlArgs(s.argsMapping, i, msg)
+		if err != nil {
+			return fmt.Errorf("failed to execute args mapping for message %v: %w", i, err)
+		}
+		if len(args) != len(s.conf.Columns) {
+			return fmt.Errorf("args mapping for message %v returned %v args but %v columns are inserted", i, len(args), len(s.conf.Columns))
+		}
+		rows[i] = args
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = s.insertRows(ctx, tx, rows); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertRows inserts rows with as few statements as the argument limit of the
+// driver permits, where statements are prepared for each number of rows.
+func (s *SQLInsert) insertRows(ctx context.Context, tx *sql.Tx, rows [][]interface{}) error {
+	if sqlInsertOnlyBatchDriver(s.conf.Driver) {
+		stmt, err := tx.PrepareContext(ctx, s.query(1))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, row := range rows {
+			if _, err = stmt.ExecContext(ctx, row...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var stmt *sql.Stmt
+	stmtRows := 0
+	defer func() {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}()
+
+	for len(rows) > 0 {
+		n := len(rows)
+		if n > s.maxRows {
+			n = s.maxRows
+		}
+		if n != stmtRows {
+			if stmt != nil {
+				stmt.Close()
+				stmt = nil
+			}
+			var err error
+			if stmt, err = tx.PrepareContext(ctx, s.query(n)); err != nil {
+				return err
+			}
+			stmtRows = n
+		}
+		args := make([]interface{}, 0, n*len(s.conf.Columns))
+		for _, row := range rows[:n] {
+			args = append(args, row...)
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return err
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (s *SQLInsert) CloseAsync() {
+	s.dbMut.Lock()
+	if s.db != nil {
+		s.db.Close()
+		s.db = nil
+	}
+	s.dbMut.Unlock()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (s *SQLInsert) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
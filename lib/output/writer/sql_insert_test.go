@@ -0,0 +1,260 @@
+package writer
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sqliteTestDB creates a database file with a table and returns its path,
+// skipping the test when the sqlite driver isn't available.
+func sqliteTestDB(t *testing.T, schema string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "benthos_sql_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	path := filepath.Join(dir, "test.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Skipf("sqlite driver not available: %v", err)
+	}
+	defer db.Close()
+	if err = db.Ping(); err != nil {
+		t.Skipf("sqlite driver not available: %v", err)
+	}
+	_, err = db.Exec(schema)
+	require.NoError(t, err)
+	return path
+}
+
+func sqliteTestRows(t *testing.T, path, query string) [][]interface{} {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query(query)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+
+	var res [][]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dests := make([]interface{}, len(columns))
+		for i := range values {
+			dests[i] = &values[i]
+		}
+		require.NoError(t, rows.Scan(dests...))
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		res = append(res, values)
+	}
+	require.NoError(t, rows.Err())
+	return res
+}
+
+//------------------------------------------------------------------------------
+
+func TestSQLInsertConfigErrors(t *testing.T) {
+	tests := map[string]func(c *SQLInsertConfig){
+		"bad driver": func(c *SQLInsertConfig) {
+			c.Driver = "nope"
+		},
+		"no data source name": func(c *SQLInsertConfig) {
+			c.DataSourceName = ""
+		},
+		"no table": func(c *SQLInsertConfig) {
+			c.Table = ""
+		},
+		"no columns": func(c *SQLInsertConfig) {
+			c.Columns = nil
+		},
+		"no args mapping": func(c *SQLInsertConfig) {
+			c.ArgsMapping = ""
+		},
+		"bad args mapping": func(c *SQLInsertConfig) {
+			c.ArgsMapping = "root = ["
+		},
+		"bad on conflict action": func(c *SQLInsertConfig) {
+			c.OnConflict.Action = "nope"
+		},
+		"unsupported on conflict driver": func(c *SQLInsertConfig) {
+			c.Driver = "mssql"
+			c.OnConflict.Action = "ignore"
+		},
+		"update without conflict columns": func(c *SQLInsertConfig) {
+			c.Driver = "postgres"
+			c.OnConflict.Action = "update"
+		},
+		"update without update columns": func(c *SQLInsertConfig) {
+			c.Driver = "postgres"
+			c.OnConflict.Action = "update"
+			c.OnConflict.Columns = []string{"id", "name"}
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewSQLInsertConfig()
+			conf.DataSourceName = "foo"
+			conf.Table = "footable"
+			conf.Columns = []string{"id", "name"}
+			conf.ArgsMapping = "root = [ this.id, this.name ]"
+			test(&conf)
+
+			_, err := NewSQLInsert(conf, log.Noop(), metrics.Noop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSQLInsertQueries(t *testing.T) {
+	tests := map[string]struct {
+		conf     func(c *SQLInsertConfig)
+		expected string
+	}{
+		"mysql": {
+			conf:     func(c *SQLInsertConfig) {},
+			expected: "INSERT INTO footable (id, name) VALUES (?, ?), (?, ?)",
+		},
+		"postgres": {
+			conf: func(c *SQLInsertConfig) {
+				c.Driver = "postgres"
+			},
+			expected: "INSERT INTO footable (id, name) VALUES ($1, $2), ($3, $4)",
+		},
+		"mssql": {
+			conf: func(c *SQLInsertConfig) {
+				c.Driver = "mssql"
+			},
+			expected: "INSERT INTO footable (id, name) VALUES (@p1, @p2), (@p3, @p4)",
+		},
+		"mysql ignore": {
+			conf: func(c *SQLInsertConfig) {
+				c.OnConflict.Action = "ignore"
+			},
+			expected: "INSERT IGNORE INTO footable (id, name) VALUES (?, ?), (?, ?)",
+		},
+		"mysql update": {
+			conf: func(c *SQLInsertConfig) {
+				c.OnConflict.Action = "update"
+			},
+			expected: "INSERT INTO footable (id, name) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE id = VALUES(id), name = VALUES(name)",
+		},
+		"postgres ignore": {
+			conf: func(c *SQLInsertConfig) {
+				c.Driver = "postgres"
+				c.OnConflict.Action = "ignore"
+			},
+			expected: "INSERT INTO footable (id, name) VALUES ($1, $2), ($3, $4) ON CONFLICT DO NOTHING",
+		},
+		"sqlite update": {
+			conf: func(c *SQLInsertConfig) {
+				c.Driver = "sqlite"
+				c.OnConflict.Action = "update"
+				c.OnConflict.Columns = []string{"id"}
+			},
+			expected: "INSERT INTO footable (id, name) VALUES (?, ?), (?, ?) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name",
+		},
+		"postgres update columns and suffix": {
+			conf: func(c *SQLInsertConfig) {
+				c.Driver = "postgres"
+				c.OnConflict.Action = "update"
+				c.OnConflict.Columns = []string{"id"}
+				c.OnConflict.UpdateColumns = []string{"name"}
+				c.Suffix = "RETURNING id"
+			},
+			expected: "INSERT INTO footable (id, name) VALUES ($1, $2), ($3, $4) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name RETURNING id",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewSQLInsertConfig()
+			conf.DataSourceName = "foo"
+			conf.Table = "footable"
+			conf.Columns = []string{"id", "name"}
+			conf.ArgsMapping = "root = [ this.id, this.name ]"
+			test.conf(&conf)
+
+			s, err := NewSQLInsert(conf, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, s.query(2))
+		})
+	}
+}
+
+func TestSQLInsertSQLite(t *testing.T) {
+	path := sqliteTestDB(t, `CREATE TABLE footable (id INTEGER PRIMARY KEY, name TEXT NOT NULL, tags TEXT);`)
+
+	conf := NewSQLInsertConfig()
+	conf.Driver = "sqlite"
+	conf.DataSourceName = path
+	conf.Table = "footable"
+	conf.Columns = []string{"id", "name", "tags"}
+	conf.ArgsMapping = `root = [ this.id, this.name, this.tags ]`
+	conf.OnConflict.Action = "update"
+	conf.OnConflict.Columns = []string{"id"}
+
+	s, err := NewSQLInsert(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	s.maxRows = 2
+	require.NoError(t, s.Connect())
+	defer func() {
+		s.CloseAsync()
+		assert.NoError(t, s.WaitForClose(time.Second))
+	}()
+
+	require.NoError(t, s.Write(message.New([][]byte{
+		[]byte(`{"id":1,"name":"foo","tags":["a","b"]}`),
+		[]byte(`{"id":2,"name":"bar"}`),
+		[]byte(`{"id":3,"name":"baz"}`),
+		[]byte(`{"id":4,"name":"qux"}`),
+		[]byte(`{"id":5,"name":"quz"}`),
+	})))
+	require.NoError(t, s.Write(message.New([][]byte{
+		[]byte(`{"id":2,"name":"bar2"}`),
+	})))
+
+	assert.Equal(t, [][]interface{}{
+		{int64(1), "foo", `["a","b"]`},
+		{int64(2), "bar2", nil},
+		{int64(3), "baz", nil},
+		{int64(4), "qux", nil},
+		{int64(5), "quz", nil},
+	}, sqliteTestRows(t, path, "SELECT id, name, tags FROM footable ORDER BY id"))
+
+	// A batch with a row that fails to insert is rolled back entirely.
+	assert.Error(t, s.Write(message.New([][]byte{
+		[]byte(`{"id":6,"name":"quack"}`),
+		[]byte(`{"id":7,"name":"quock"}`),
+		[]byte(`{"id":8,"name":null}`),
+	})))
+	assert.Len(t, sqliteTestRows(t, path, "SELECT id FROM footable"), 5)
+
+	assert.Error(t, s.Write(message.New([][]byte{
+		[]byte(`not json`),
+	})))
+}
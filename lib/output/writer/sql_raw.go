@@ -0,0 +1,192 @@
+package writer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/batch"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// SQLRawConfig contains configuration fields for the SQLRaw output type.
+type SQLRawConfig struct {
+	Driver         string             `json:"driver" yaml:"driver"`
+	DataSourceName string             `json:"data_source_name" yaml:"data_source_name"`
+	Query          string             `json:"query" yaml:"query"`
+	ArgsMapping    string             `json:"args_mapping" yaml:"args_mapping"`
+	MaxInFlight    int                `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching       batch.PolicyConfig `json:"batching" yaml:"batching"`
+}
+
+// NewSQLRawConfig creates a new SQLRawConfig with default values.
+func NewSQLRawConfig() SQLRawConfig {
+	batching := batch.NewPolicyConfig()
+	batching.Count = 1
+	return SQLRawConfig{
+		Driver:         "mysql",
+		DataSourceName: "",
+		Query:          "",
+		ArgsMapping:    "",
+		MaxInFlight:    1,
+		Batching:       batching,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SQLRaw is a writer type that executes a prepared statement for each message
+// of a batch within a single transaction.
+type SQLRaw struct {
+	conf        SQLRawConfig
+	argsMapping *mapping.Executor
+
+	dbMut sync.RWMutex
+	db    *sql.DB
+	stmt  *sql.Stmt
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewSQLRaw creates a new SQLRaw writer type.
+func NewSQLRaw(conf SQLRawConfig, log log.Modular, stats metrics.Type) (*SQLRaw, error) {
+	if err := sqlCheckDriver(conf.Driver); err != nil {
+		return nil, err
+	}
+	if conf.DataSourceName == "" {
+		return nil, errors.New("a data source name must be specified")
+	}
+	if conf.Query == "" {
+		return nil, errors.New("a query must be specified")
+	}
+
+	s := &SQLRaw{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	if conf.ArgsMapping != "" {
+		var err error
+		if s.argsMapping, err = newMapping("args mapping", conf.ArgsMapping); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a connection to the database.
+func (s *SQLRaw) Connect() error {
+	return s.ConnectWithContext(context.Background())
+}
+
+// ConnectWithContext attempts to establish a connection to the database and
+// prepares the query.
+func (s *SQLRaw) ConnectWithContext(ctx context.Context) error {
+	s.dbMut.Lock()
+	defer s.dbMut.Unlock()
+
+	if s.db != nil {
+		return nil
+	}
+
+	db, err := sqlOpen(ctx, s.conf.Driver, s.conf.DataSourceName)
+	if err != nil {
+		return err
+	}
+
+	// Some drivers only support transactional prepared inserts.
+	var stmt *sql.Stmt
+	if !sqlInsertOnlyBatchDriver(s.conf.Driver) {
+		if stmt, err = db.PrepareContext(ctx, s.conf.Query); err != nil {
+			db.Close()
+			return fmt.Errorf("failed to prepare query: %w", err)
+		}
+	}
+	s.db, s.stmt = db, stmt
+
+	s.log.Infof("Executing queries for messages against %v database\n", s.conf.Driver)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Write attempts to write message contents to the database.
+func (s *SQLRaw) Write(msg types.Message) error {
+	return s.WriteWithContext(context.Background(), msg)
+}
+
+// WriteWithContext executes the query for each message of a batch within a
+// single transaction.
+func (s *SQLRaw) WriteWithContext(ctx context.Context, msg types.Message) error {
+	s.dbMut.RLock()
+	defer s.dbMut.RUnlock()
+
+	if s.db == nil {
+		return types.ErrNotConnected
+	}
+
+	argSets := make([][]interface{}, msg.Len())
+	if s.argsMapping != nil {
+		for i := range argSets {
+			var err error
+			if argSets[i], err = sqlArgs(s.argsMapping, i, msg); err != nil {
+				return fmt.Errorf("failed to execute args mapping for message %v: %w", i, err)
+			}
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var stmt *sql.Stmt
+	if s.stmt != nil {
+		stmt = tx.StmtContext(ctx, s.stmt)
+	} else if stmt, err = tx.PrepareContext(ctx, s.conf.Query); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to prepare query: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, args := range argSets {
+		if _, err = stmt.ExecContext(ctx, args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to execute query for message %v: %w", i, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (s *SQLRaw) CloseAsync() {
+	s.dbMut.Lock()
+	if s.db != nil {
+		if s.stmt != nil {
+			s.stmt.Close()
+			s.stmt = nil
+		}
+		s.db.Close()
+		s.db = nil
+	}
+	s.dbMut.Unlock()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (s *SQLRaw) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
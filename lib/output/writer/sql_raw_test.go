@@ -0,0 +1,75 @@
+package writer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLRawConfigErrors(t *testing.T) {
+	conf := NewSQLRawConfig()
+	conf.Driver = "nope"
+	_, err := NewSQLRaw(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Driver = "sqlite"
+	_, err = NewSQLRaw(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.DataSourceName = "foo.db"
+	_, err = NewSQLRaw(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Query = "DELETE FROM footable WHERE id = ?"
+	conf.ArgsMapping = "root = ["
+	_, err = NewSQLRaw(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestSQLRawSQLite(t *testing.T) {
+	path := sqliteTestDB(t, `CREATE TABLE footable (id INTEGER PRIMARY KEY, count INTEGER NOT NULL);`)
+
+	conf := NewSQLRawConfig()
+	conf.Driver = "sqlite"
+	conf.DataSourceName = path
+	conf.Query = "INSERT INTO footable (id, count) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET count = count + EXCLUDED.count"
+	conf.ArgsMapping = `root = [ this.id, this.count.or(1) ]`
+
+	s, err := NewSQLRaw(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.Equal(t, types.ErrNotConnected, s.Write(message.New([][]byte{[]byte(`{}`)})))
+
+	require.NoError(t, s.Connect())
+	defer func() {
+		s.CloseAsync()
+		assert.NoError(t, s.WaitForClose(time.Second))
+	}()
+
+	require.NoError(t, s.Write(message.New([][]byte{
+		[]byte(`{"id":1}`),
+		[]byte(`{"id":2,"count":5}`),
+		[]byte(`{"id":1,"count":2}`),
+	})))
+
+	assert.Equal(t, [][]interface{}{
+		{int64(1), int64(3)},
+		{int64(2), int64(5)},
+	}, sqliteTestRows(t, path, "SELECT id, count FROM footable ORDER BY id"))
+
+	// A batch with a message that fails to execute is rolled back entirely.
+	assert.Error(t, s.Write(message.New([][]byte{
+		[]byte(`{"id":1}`),
+		[]byte(`{"id":"nope"}`),
+	})))
+	assert.Equal(t, [][]interface{}{
+		{int64(1), int64(3)},
+		{int64(2), int64(5)},
+	}, sqliteTestRows(t, path, "SELECT id, count FROM footable ORDER BY id"))
+}
@@ -0,0 +1,247 @@
+package writer
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// SubprocessConfig contains configuration fields for the Subprocess output
+// type.
+type SubprocessConfig struct {
+	Name  string   `json:"name" yaml:"name"`
+	Args  []string `json:"args" yaml:"args"`
+	Codec string   `json:"codec" yaml:"codec"`
+}
+
+// NewSubprocessConfig creates a new SubprocessConfig with default values.
+func NewSubprocessConfig() SubprocessConfig {
+	return SubprocessConfig{
+		Name:  "",
+		Args:  []string{},
+		Codec: "lines",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// subprocessEncoder returns a function that frames a message for the codec.
+func subprocessEncoder(codec string) (func(b []byte) []byte, error) {
+	switch {
+	case codec == "lines":
+		return func(b []byte) []byte {
+			return append(b[:len(b):len(b)], '\n')
+		}, nil
+	case strings.HasPrefix(codec, "delim:"):
+		delim := []byte(strings.TrimPrefix(codec, "delim:"))
+		if len(delim) == 0 {
+			return nil, errors.New("custom delimiter codec requires a non-empty delimiter")
+		}
+		return func(b []byte) []byte {
+			return append(b[:len(b):len(b)], delim...)
+		}, nil
+	case codec == "length-prefixed":
+		return func(b []byte) []byte {
+			frame := make([]byte, 4, 4+len(b))
+			binary.BigEndian.PutUint32(frame, uint32(len(b)))
+			return append(frame, b...)
+		}, nil
+	case codec == "protobuf-delimited":
+		return func(b []byte) []byte {
+			frame := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(b))
+			frame = frame[:binary.PutUvarint(frame, uint64(len(b)))]
+			return append(frame, b...)
+		}, nil
+	}
+	return nil, fmt.Errorf("codec was not recognised: %v", codec)
+}
+
+//------------------------------------------------------------------------------
+
+// Subprocess is an output type that writes messages to the stdin stream of a
+// running command.
+type Subprocess struct {
+	conf   SubprocessConfig
+	encode func(b []byte) []byte
+
+	cmdMut sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	exited chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewSubprocess creates a new Subprocess output type.
+func NewSubprocess(conf SubprocessConfig, log log.Modular, stats metrics.Type) (*Subprocess, error) {
+	if len(conf.Name) == 0 {
+		return nil, errors.New("a command name must be provided")
+	}
+	s := &Subprocess{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+	}
+	var err error
+	if s.encode, err = subprocessEncoder(conf.Codec); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ConnectWithContext starts the command.
+func (s *Subprocess) ConnectWithContext(ctx context.Context) error {
+	return s.Connect()
+}
+
+// Connect starts the command, or restarts it if it has exited.
+func (s *Subprocess) Connect() error {
+	s.cmdMut.Lock()
+	defer s.cmdMut.Unlock()
+
+	if s.cmd != nil {
+		return nil
+	}
+
+	cmd := exec.Command(s.conf.Name, s.conf.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			s.log.Debugf("Subprocess stdout: %s\n", scanner.Bytes())
+		}
+	}()
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			s.log.Warnf("Subprocess stderr: %s\n", scanner.Bytes())
+		}
+	}()
+
+	exited := make(chan struct{})
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			s.log.Warnf("Subprocess exited: %v\n", err)
+		} else {
+			s.log.Infof("Subprocess exited\n")
+		}
+		close(exited)
+
+		s.cmdMut.Lock()
+		if s.cmd == cmd {
+			s.cmd, s.stdin = nil, nil
+		}
+		s.cmdMut.Unlock()
+	}()
+
+	s.cmd, s.stdin, s.exited = cmd, stdin, exited
+	s.log.Infof("Writing messages to the stdin of subprocess: %v\n", s.conf.Name)
+	return nil
+}
+
+// WriteWithContext attempts to write a message to the stdin of the command.
+func (s *Subprocess) WriteWithContext(ctx context.Context, msg types.Message) error {
+	return s.Write(msg)
+}
+
+// Write attempts to write a message to the stdin of the command.
+func (s *Subprocess) Write(msg types.Message) error {
+	s.cmdMut.Lock()
+	stdin := s.stdin
+	s.cmdMut.Unlock()
+
+	if stdin == nil {
+		return types.ErrNotConnected
+	}
+
+	return msg.Iter(func(i int, p types.Part) error {
+		if _, err := stdin.Write(s.encode(p.Get())); err != nil {
+			s.log.Errorf("Failed to write to subprocess: %v\n", err)
+			s.stop()
+			return types.ErrNotConnected
+		}
+		return nil
+	})
+}
+
+// stop closes the stdin of the command, which allows it to exit gracefully,
+// and kills it if it has not exited within a second.
+func (s *Subprocess) stop() {
+	s.cmdMut.Lock()
+	cmd, stdin, exited := s.cmd, s.stdin, s.exited
+	s.cmd, s.stdin = nil, nil
+	s.cmdMut.Unlock()
+
+	if cmd == nil {
+		return
+	}
+	stdin.Close()
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		cmd.Process.Kill()
+		<-exited
+	}
+}
+
+// CloseAsync shuts down the output and stops the command.
+func (s *Subprocess) CloseAsync() {
+	s.cmdMut.Lock()
+	exited := s.exited
+	s.cmdMut.Unlock()
+
+	if exited == nil {
+		return
+	}
+	go s.stop()
+}
+
+// WaitForClose blocks until the output has closed down.
+func (s *Subprocess) WaitForClose(timeout time.Duration) error {
+	s.cmdMut.Lock()
+	exited := s.exited
+	s.cmdMut.Unlock()
+
+	if exited == nil {
+		return nil
+	}
+	select {
+	case <-exited:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,85 @@
+package writer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubprocessConfigErrors(t *testing.T) {
+	conf := NewSubprocessConfig()
+	_, err := NewSubprocess(conf, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+
+	conf.Name = "cat"
+	for _, codec := range []string{"nope", "delim:"} {
+		conf.Codec = codec
+		_, err = NewSubprocess(conf, log.Noop(), metrics.Noop())
+		assert.Error(t, err, codec)
+	}
+}
+
+func TestSubprocessCodecs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_subprocess_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		codec    string
+		expected string
+	}{
+		{"lines", "foo\nbar\n"},
+		{"delim:||", "foo||bar||"},
+		{"length-prefixed", "\x00\x00\x00\x03foo\x00\x00\x00\x03bar"},
+		{"protobuf-delimited", "\x03foo\x03bar"},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("out%v", i))
+
+		conf := NewSubprocessConfig()
+		conf.Name = "sh"
+		conf.Args = []string{"-c", "cat > " + path}
+		conf.Codec = test.codec
+
+		s, err := NewSubprocess(conf, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+		require.NoError(t, s.Connect())
+
+		require.NoError(t, s.Write(message.New([][]byte{[]byte("foo"), []byte("bar")})))
+
+		s.CloseAsync()
+		require.NoError(t, s.WaitForClose(time.Second*5))
+
+		b, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, string(b), test.codec)
+	}
+}
+
+func TestSubprocessRestart(t *testing.T) {
+	conf := NewSubprocessConfig()
+	conf.Name = "sh"
+	conf.Args = []string{"-c", "exit 0"}
+
+	s, err := NewSubprocess(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, s.Connect())
+	require.NoError(t, s.WaitForClose(time.Second*5))
+
+	assert.Eventually(t, func() bool {
+		return s.Write(message.New([][]byte{[]byte("foo")})) != nil
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, s.Connect())
+	s.CloseAsync()
+	require.NoError(t, s.WaitForClose(time.Second*5))
+}
@@ -4,11 +4,14 @@ package writer
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/Jeffail/benthos/v3/internal/azureaad"
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
 	"github.com/Jeffail/benthos/v3/lib/log"
@@ -18,6 +21,55 @@ import (
 
 //------------------------------------------------------------------------------
 
+// The version of the Table service API that requests authorized with Azure
+// Active Directory are made with, which is the earliest supporting it.
+const aadTableAPIVersion = "2019-02-02"
+
+// aadBearerTransport authorizes requests with bearer tokens obtained from Azure
+// Active Directory, replacing any existing authorization header.
+type aadBearerTransport struct {
+	token func(ctx context.Context) (string, error)
+	base  http.RoundTripper
+}
+
+func (t *aadBearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.token(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain token: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+// newAADTableClient creates a storage client that is authorized with Azure
+// Active Directory. The client always signs requests with an account key, and
+// so it's given a well formed placeholder key where the signatures are replaced
+// with bearer tokens by the transport of the client.
+func newAADTableClient(conf AzureTableStorageConfig) (storage.Client, error) {
+	source, err := azureaad.NewTokenSource(conf.AAD, "https://storage.azure.com/")
+	if err != nil {
+		return storage.Client{}, err
+	}
+	placeholderKey := base64.StdEncoding.EncodeToString([]byte("aad"))
+	client, err := storage.NewClient(conf.StorageAccount, placeholderKey, storage.DefaultBaseURL, aadTableAPIVersion, true)
+	if err != nil {
+		return storage.Client{}, err
+	}
+	client.HTTPClient = &http.Client{
+		Transport: &aadBearerTransport{
+			token: func(ctx context.Context) (string, error) {
+				t, err := source.Token(ctx)
+				return t.AccessToken, err
+			},
+			base: http.DefaultTransport,
+		},
+	}
+	return client, nil
+}
+
+//------------------------------------------------------------------------------
+
 // AzureTableStorage is a benthos writer. Type implementation that writes messages to an
 // Azure Table Storage table.
 type AzureTableStorage struct {
@@ -45,19 +97,29 @@ func NewAzureTableStorage(
 			return nil, fmt.Errorf("failed to parse timeout period string: %v", err)
 		}
 	}
-	if len(conf.StorageAccount) == 0 {
+	var client storage.Client
+	switch {
+	case conf.StorageConnectionString != "":
+		if client, err = storage.NewClientFromConnectionString(conf.StorageConnectionString); err != nil {
+			return nil, fmt.Errorf("invalid azure storage connection string: %v", err)
+		}
+	case len(conf.StorageAccount) == 0:
 		return nil, fmt.Errorf("invalid azure storage account")
-	}
-	basicClient, err := storage.NewBasicClient(conf.StorageAccount, conf.StorageAccessKey)
-	if err != nil {
-		return nil, fmt.Errorf("invalid azure storage account credentials: %v", err)
+	case conf.AAD.Enabled:
+		if client, err = newAADTableClient(conf); err != nil {
+			return nil, fmt.Errorf("failed to create Azure Active Directory client: %v", err)
+		}
+	default:
+		if client, err = storage.NewBasicClient(conf.StorageAccount, conf.StorageAccessKey); err != nil {
+			return nil, fmt.Errorf("invalid azure storage account credentials: %v", err)
+		}
 	}
 	a := &AzureTableStorage{
 		conf:    conf,
 		log:     log,
 		stats:   stats,
 		timeout: timeout,
-		client:  basicClient.GetTableService(),
+		client:  client.GetTableService(),
 	}
 	if a.tableName, err = bloblang.NewField(conf.TableName); err != nil {
 		return nil, fmt.Errorf("failed to parse table name expression: %v", err)
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"os"
 	"time"
@@ -43,7 +44,7 @@ of the batch.`,
 		},
 		UsesBatches: true,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("format", "The archiving [format](#formats) to apply.").HasOptions("tar", "zip", "binary", "lines", "json_array"),
+			docs.FieldCommon("format", "The archiving [format](#formats) to apply.").HasOptions("tar", "tar.zst", "zip", "binary", "lines", "json_array"),
 			docs.FieldCommon(
 				"path", "The path to set for each message in the archive (when applicable).",
 				"${!count(\"files\")}-${!timestamp_unix_nano()}.txt", "${!meta(\"kafka_key\")}-${!json(\"id\")}.json",
@@ -56,6 +57,10 @@ of the batch.`,
 
 Archive messages to a unix standard tape archive.
 
+### ` + "`tar.zst`" + `
+
+Archive messages to a unix standard tape archive compressed with zstd.
+
 ### ` + "`zip`" + `
 
 Archive messages to a zip file.
@@ -148,6 +153,19 @@ func tarArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
 	return newPart, nil
 }
 
+func tarZstdArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
+	newPart, err := tarArchive(hFunc, msg)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := zstdCompress(gzip.DefaultCompression, newPart.Get())
+	if err != nil {
+		return nil, err
+	}
+	newPart.Set(compressed)
+	return newPart, nil
+}
+
 func zipArchive(hFunc headerFunc, msg types.Message) (types.Part, error) {
 	buf := &bytes.Buffer{}
 	zw := zip.NewWriter(buf)
@@ -223,6 +241,8 @@ func strToArchiver(str string) (archiveFunc, error) {
 	switch str {
 	case "tar":
 		return tarArchive, nil
+	case "tar.zst":
+		return tarZstdArchive, nil
 	case "zip":
 		return zipArchive, nil
 	case "binary":
@@ -1,9 +1,12 @@
 package processor
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"sync"
 	"time"
 
 	"net/http"
@@ -39,12 +42,50 @@ specifies how the source documents are encoded.
 ### ` + "`from_json`" + `
 
 Attempts to convert JSON documents into Avro documents according to the
-specified encoding.`,
+specified encoding.
+
+## Encodings
+
+The ` + "`textual`, `binary` and `single`" + ` encodings convert a single Avro
+document per message. The ` + "`ocf`" + ` encoding converts Avro object container
+files, which contain any number of records along with the schema that they were
+written with. The ` + "`to_json`" + ` operator converts an object container file
+into a JSON array of its records, and therefore doesn't require a schema, and
+the ` + "`from_json`" + ` operator converts a JSON array into a container file
+with a record for each element, compressed with the ` + "`compression`" + ` codec.
+
+## Schema Evolution
+
+When a ` + "`reader_schema`" + ` is set the ` + "`to_json`" + ` operator resolves
+documents written with the writer schema (the ` + "`schema`" + ` or the
+schema embedded within a container file) into documents of the reader schema,
+following the [schema resolution rules](https://avro.apache.org/docs/current/spec.html#Schema+Resolution)
+of the Avro specification. Fields missing from the writer schema are set to
+their defaults, fields missing from the reader schema are dropped, and numeric
+values are promoted.
+
+## Logical Types
+
+Values of the logical types ` + "`date`, `timestamp-millis` and `timestamp-micros`" + `
+are converted to JSON as RFC 3339 timestamps. By default values of
+` + "`time-millis` and `time-micros`" + ` are converted as numbers of
+nanoseconds and decimals as fractions such as ` + "`\"617/50\"`" + `, and when
+` + "`convert_logical_types`" + ` is enabled they're converted as numbers of their
+unit and decimal numbers respectively.
+
+The ` + "`from_json`" + ` operator accepts timestamps as RFC 3339 strings or as
+numbers of their unit since the Unix epoch, times of day as numbers of their
+unit, and decimals as strings or numbers. Values of unions must be objects with
+a single key that is the name of the member, such as
+` + "`{\"long.timestamp-millis\":\"2020-09-01T10:00:00Z\"}`" + `.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json"),
-			docs.FieldCommon("encoding", "An Avro encoding format to use for conversions to and from a schema.").HasOptions("textual", "binary", "single"),
+			docs.FieldCommon("encoding", "An Avro [encoding](#encodings) format to use for conversions to and from a schema.").HasOptions("textual", "binary", "single", "ocf"),
 			docs.FieldCommon("schema", "A full Avro schema to use."),
 			docs.FieldCommon("schema_path", "The path of a schema document to apply. Use either this or the `schema` field."),
+			docs.FieldAdvanced("reader_schema", "An optional Avro schema to [resolve](#schema-evolution) documents into when converting them to JSON."),
+			docs.FieldAdvanced("convert_logical_types", "Whether the `to_json` operator converts values of the `time-millis`, `time-micros` and `decimal` [logical types](#logical-types) into numbers of their unit and decimal numbers."),
+			docs.FieldAdvanced("compression", "The compression codec of object container files created with the `ocf` encoding.").HasOptions("none", "deflate", "snappy"),
 			partsFieldSpec,
 		},
 	}
@@ -54,21 +95,29 @@ specified encoding.`,
 
 // AvroConfig contains configuration fields for the Avro processor.
 type AvroConfig struct {
-	Parts      []int  `json:"parts" yaml:"parts"`
-	Operator   string `json:"operator" yaml:"operator"`
-	Encoding   string `json:"encoding" yaml:"encoding"`
-	Schema     string `json:"schema" yaml:"schema"`
-	SchemaPath string `json:"schema_path" yaml:"schema_path"`
+	Parts        []int  `json:"parts" yaml:"parts"`
+	Operator     string `json:"operator" yaml:"operator"`
+	Encoding     string `json:"encoding" yaml:"encoding"`
+	Schema       string `json:"schema" yaml:"schema"`
+	SchemaPath   string `json:"schema_path" yaml:"schema_path"`
+	ReaderSchema string `json:"reader_schema" yaml:"reader_schema"`
+	Compression  string `json:"compression" yaml:"compression"`
+
+	ConvertLogicalTypes bool `json:"convert_logical_types" yaml:"convert_logical_types"`
 }
 
 // NewAvroConfig returns a AvroConfig with default values.
 func NewAvroConfig() AvroConfig {
 	return AvroConfig{
-		Parts:      []int{},
-		Operator:   "to_json",
-		Encoding:   "textual",
-		Schema:     "",
-		SchemaPath: "",
+		Parts:        []int{},
+		Operator:     "to_json",
+		Encoding:     "textual",
+		Schema:       "",
+		SchemaPath:   "",
+		ReaderSchema: "",
+		Compression:  "none",
+
+		ConvertLogicalTypes: false,
 	}
 }
 
@@ -76,98 +125,230 @@ func NewAvroConfig() AvroConfig {
 
 type avroOperator func(part types.Part) error
 
-func newAvroToJSONOperator(encoding string, codec *goavro.Codec) (avroOperator, error) {
+// avroCodec contains the codec and parsed schema of an Avro schema.
+type avroCodec struct {
+	codec  *goavro.Codec
+	schema *avroSchema
+}
+
+func newAvroCodec(schema string) (*avroCodec, error) {
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %v", err)
+	}
+	parsed, err := parseAvroSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &avroCodec{codec: codec, schema: parsed}, nil
+}
+
+// avroReader converts native values decoded with a writer schema into JSON
+// values, resolving them into values of a reader schema when one is set.
+type avroReader struct {
+	reader  *avroCodec
+	convert bool
+
+	// Parsed writer schemas of object container files, which are cached as
+	// they're usually the same for all messages.
+	ocfMut     sync.Mutex
+	ocfSchemas map[string]*avroSchema
+}
+
+func (r *avroReader) toJSON(writer *avroSchema, native interface{}) (interface{}, error) {
+	if r.reader != nil {
+		var err error
+		if native, err = resolveAvro(writer, r.reader.schema, native); err != nil {
+			return nil, fmt.Errorf("failed to resolve Avro document with reader schema: %v", err)
+		}
+		writer = r.reader.schema
+	}
+	if !r.convert {
+		return native, nil
+	}
+	return writer.toJSON(native), nil
+}
+
+func (r *avroReader) ocfSchema(schema string) (*avroSchema, error) {
+	r.ocfMut.Lock()
+	defer r.ocfMut.Unlock()
+	if parsed, exists := r.ocfSchemas[schema]; exists {
+		return parsed, nil
+	}
+	parsed, err := parseAvroSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	if r.ocfSchemas == nil {
+		r.ocfSchemas = map[string]*avroSchema{}
+	}
+	r.ocfSchemas[schema] = parsed
+	return parsed, nil
+}
+
+func (r *avroReader) ocfToJSON(data []byte) (interface{}, error) {
+	ocfr, err := goavro.NewOCFReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	writer, err := r.ocfSchema(ocfr.Codec().Schema())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse writer schema of container: %v", err)
+	}
+
+	records := []interface{}{}
+	for ocfr.Scan() {
+		native, err := ocfr.Read()
+		if err != nil {
+			return nil, err
+		}
+		record, err := r.toJSON(writer, native)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err = ocfr.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func newAvroToJSONOperator(encoding string, writer *avroCodec, reader *avroReader) (avroOperator, error) {
+	var decode func(data []byte) (interface{}, error)
 	switch encoding {
-	case "textual":
-		return func(part types.Part) error {
-			jObj, _, err := codec.NativeFromTextual(part.Get())
-			if err != nil {
-				return fmt.Errorf("failed to convert Avro document to JSON: %v", err)
-			}
-			if err = part.SetJSON(jObj); err != nil {
-				return fmt.Errorf("failed to set JSON: %v", err)
-			}
-			return nil
-		}, nil
-	case "binary":
-		return func(part types.Part) error {
-			jObj, _, err := codec.NativeFromBinary(part.Get())
-			if err != nil {
-				return fmt.Errorf("failed to convert Avro document to JSON: %v", err)
-			}
-			if err = part.SetJSON(jObj); err != nil {
-				return fmt.Errorf("failed to set JSON: %v", err)
-			}
-			return nil
-		}, nil
-	case "single":
-		return func(part types.Part) error {
-			jObj, _, err := codec.NativeFromSingle(part.Get())
+	case "textual", "binary", "single":
+		if writer == nil {
+			return nil, errors.New("a schema must be specified")
+		}
+		decodeFn := writer.codec.NativeFromTextual
+		if encoding == "binary" {
+			decodeFn = writer.codec.NativeFromBinary
+		} else if encoding == "single" {
+			decodeFn = writer.codec.NativeFromSingle
+		}
+		decode = func(data []byte) (interface{}, error) {
+			native, _, err := decodeFn(data)
 			if err != nil {
-				return fmt.Errorf("failed to convert Avro document to JSON: %v", err)
-			}
-			if err = part.SetJSON(jObj); err != nil {
-				return fmt.Errorf("failed to set JSON: %v", err)
+				return nil, err
 			}
-			return nil
-		}, nil
+			return reader.toJSON(writer.schema, native)
+		}
+	case "ocf":
+		decode = reader.ocfToJSON
+	default:
+		return nil, fmt.Errorf("encoding '%v' not recognised", encoding)
 	}
-	return nil, fmt.Errorf("encoding '%v' not recognised", encoding)
+	return func(part types.Part) error {
+		jObj, err := decode(part.Get())
+		if err != nil {
+			return fmt.Errorf("failed to convert Avro document to JSON: %v", err)
+		}
+		if err = part.SetJSON(jObj); err != nil {
+			return fmt.Errorf("failed to set JSON: %v", err)
+		}
+		return nil
+	}, nil
 }
 
-func newAvroFromJSONOperator(encoding string, codec *goavro.Codec) (avroOperator, error) {
+func newAvroFromJSONOperator(encoding, compression string, writer *avroCodec) (avroOperator, error) {
+	if writer == nil {
+		return nil, errors.New("a schema must be specified")
+	}
+
+	var encode func(jObj interface{}) ([]byte, error)
 	switch encoding {
-	case "textual":
-		return func(part types.Part) error {
-			jObj, err := part.JSON()
+	case "textual", "binary", "single":
+		encodeFn := writer.codec.TextualFromNative
+		if encoding == "binary" {
+			encodeFn = writer.codec.BinaryFromNative
+		} else if encoding == "single" {
+			encodeFn = writer.codec.SingleFromNative
+		}
+		encode = func(jObj interface{}) ([]byte, error) {
+			native, err := writer.schema.fromJSON(jObj)
 			if err != nil {
-				return fmt.Errorf("failed to parse message as JSON: %v", err)
+				return nil, err
 			}
-			var textual []byte
-			if textual, err = codec.TextualFromNative(nil, jObj); err != nil {
-				return fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
-			}
-			part.Set(textual)
-			return nil
-		}, nil
-	case "binary":
-		return func(part types.Part) error {
-			jObj, err := part.JSON()
-			if err != nil {
-				return fmt.Errorf("failed to parse message as JSON: %v", err)
+			return encodeFn(nil, native)
+		}
+	case "ocf":
+		compressionName, err := strToAvroCompression(compression)
+		if err != nil {
+			return nil, err
+		}
+		encode = func(jObj interface{}) ([]byte, error) {
+			// Arrays are written as a record each, other values are written as
+			// a single record.
+			records, ok := jObj.([]interface{})
+			if !ok {
+				records = []interface{}{jObj}
 			}
-			var binary []byte
-			if binary, err = codec.BinaryFromNative(nil, jObj); err != nil {
-				return fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
+			natives := make([]interface{}, len(records))
+			for i, record := range records {
+				var err error
+				if natives[i], err = writer.schema.fromJSON(record); err != nil {
+					return nil, fmt.Errorf("record %v: %v", i, err)
+				}
 			}
-			part.Set(binary)
-			return nil
-		}, nil
-	case "single":
-		return func(part types.Part) error {
-			jObj, err := part.JSON()
+
+			var buf bytes.Buffer
+			ocfw, err := goavro.NewOCFWriter(goavro.OCFConfig{
+				W:               &buf,
+				Codec:           writer.codec,
+				CompressionName: compressionName,
+			})
 			if err != nil {
-				return fmt.Errorf("failed to parse message as JSON: %v", err)
+				return nil, err
 			}
-			var single []byte
-			if single, err = codec.SingleFromNative(nil, jObj); err != nil {
-				return fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
+			if err = ocfw.Append(natives); err != nil {
+				return nil, err
 			}
-			part.Set(single)
-			return nil
-		}, nil
+			return buf.Bytes(), nil
+		}
+	default:
+		return nil, fmt.Errorf("encoding '%v' not recognised", encoding)
 	}
-	return nil, fmt.Errorf("encoding '%v' not recognised", encoding)
+	return func(part types.Part) error {
+		jObj, err := part.JSON()
+		if err != nil {
+			return fmt.Errorf("failed to parse message as JSON: %v", err)
+		}
+		var data []byte
+		if data, err = encode(jObj); err != nil {
+			return fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
+		}
+		part.Set(data)
+		return nil
+	}, nil
 }
 
-func strToAvroOperator(opStr, encoding string, codec *goavro.Codec) (avroOperator, error) {
-	switch opStr {
+func strToAvroCompression(compression string) (string, error) {
+	switch compression {
+	case "", "none":
+		return goavro.CompressionNullLabel, nil
+	case "deflate":
+		return goavro.CompressionDeflateLabel, nil
+	case "snappy":
+		return goavro.CompressionSnappyLabel, nil
+	}
+	return "", fmt.Errorf("compression '%v' not recognised", compression)
+}
+
+func strToAvroOperator(conf AvroConfig, writer, reader *avroCodec) (avroOperator, error) {
+	switch conf.Operator {
 	case "to_json":
-		return newAvroToJSONOperator(encoding, codec)
+		return newAvroToJSONOperator(conf.Encoding, writer, &avroReader{
+			reader:  reader,
+			convert: conf.ConvertLogicalTypes,
+		})
 	case "from_json":
-		return newAvroFromJSONOperator(encoding, codec)
+		if reader != nil {
+			return nil, errors.New("a reader_schema can only be used with the to_json operator")
+		}
+		return newAvroFromJSONOperator(conf.Encoding, conf.Compression, writer)
 	}
-	return nil, fmt.Errorf("operator not recognised: %v", opStr)
+	return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
 }
 
 func loadSchema(schemaPath string) (string, error) {
@@ -240,12 +421,21 @@ func NewAvro(
 		schema = conf.Avro.Schema
 	}
 
-	codec, err := goavro.NewCodec(schema)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse schema: %v", err)
+	// The schema of object container files is embedded within them, and
+	// therefore isn't required for reading them.
+	var writer, reader *avroCodec
+	if schema != "" || conf.Avro.Operator != "to_json" || conf.Avro.Encoding != "ocf" {
+		if writer, err = newAvroCodec(schema); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Avro.ReaderSchema != "" {
+		if reader, err = newAvroCodec(conf.Avro.ReaderSchema); err != nil {
+			return nil, fmt.Errorf("failed to parse reader schema: %v", err)
+		}
 	}
 
-	if a.operator, err = strToAvroOperator(conf.Avro.Operator, conf.Avro.Encoding, codec); err != nil {
+	if a.operator, err = strToAvroOperator(conf.Avro, writer, reader); err != nil {
 		return nil, err
 	}
 	return a, nil
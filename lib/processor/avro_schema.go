@@ -0,0 +1,622 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// avroSchema is a parsed Avro schema, which is used for converting between
+// JSON documents and the native values of goavro, and for resolving values
+// written with one schema into values of another schema.
+type avroSchema struct {
+	typ     string
+	name    string
+	aliases []string
+	logical string
+	scale   int
+
+	fields  []*avroField
+	symbols []string
+	enumDef string
+	items   *avroSchema
+	values  *avroSchema
+	members []*avroSchema
+}
+
+type avroField struct {
+	name    string
+	aliases []string
+	schema  *avroSchema
+	def     interface{}
+	hasDef  bool
+}
+
+var avroPrimitives = map[string]struct{}{
+	"null": {}, "boolean": {}, "int": {}, "long": {},
+	"float": {}, "double": {}, "bytes": {}, "string": {},
+}
+
+// goavro names the union members of these logical types differently to the
+// underlying type.
+var avroLogicalNames = map[string]struct{}{
+	"long.timestamp-millis": {},
+	"long.timestamp-micros": {},
+	"int.time-millis":       {},
+	"long.time-micros":      {},
+	"int.date":              {},
+	"bytes.decimal":         {},
+}
+
+func parseAvroSchema(schema string) (*avroSchema, error) {
+	var raw interface{}
+	if err := json.Unmarshal([]byte(schema), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %v", err)
+	}
+	return newAvroSchemaParser().parse(raw, "")
+}
+
+type avroSchemaParser struct {
+	named map[string]*avroSchema
+}
+
+func newAvroSchemaParser() *avroSchemaParser {
+	return &avroSchemaParser{named: map[string]*avroSchema{}}
+}
+
+func avroFullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func (p *avroSchemaParser) parse(raw interface{}, namespace string) (*avroSchema, error) {
+	switch t := raw.(type) {
+	case string:
+		if _, exists := avroPrimitives[t]; exists {
+			return &avroSchema{typ: t}, nil
+		}
+		if s, exists := p.named[avroFullName(t, namespace)]; exists {
+			return s, nil
+		}
+		if s, exists := p.named[t]; exists {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unknown type: %v", t)
+	case []interface{}:
+		s := &avroSchema{typ: "union"}
+		for _, m := range t {
+			member, err := p.parse(m, namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.members = append(s.members, member)
+		}
+		return s, nil
+	case map[string]interface{}:
+		return p.parseMap(t, namespace)
+	}
+	return nil, fmt.Errorf("unexpected schema type: %T", raw)
+}
+
+func schemaStrings(v interface{}) []string {
+	var strs []string
+	if arr, ok := v.([]interface{}); ok {
+		for _, e := range arr {
+			if str, ok := e.(string); ok {
+				strs = append(strs, str)
+			}
+		}
+	}
+	return strs
+}
+
+func (p *avroSchemaParser) parseMap(m map[string]interface{}, namespace string) (*avroSchema, error) {
+	typ, _ := m["type"].(string)
+	if typ == "" {
+		// The type is itself a schema, such as a union or named type.
+		s, err := p.parse(m["type"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
+	s := &avroSchema{typ: typ}
+	if lt, ok := m["logicalType"].(string); ok {
+		s.logical = lt
+		if scale, ok := m["scale"].(float64); ok {
+			s.scale = int(scale)
+		}
+	}
+
+	switch typ {
+	case "record", "error", "enum", "fixed":
+		name, _ := m["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("named type %v is missing a name", typ)
+		}
+		if ns, ok := m["namespace"].(string); ok && !strings.Contains(name, ".") {
+			namespace = ns
+		}
+		s.name = avroFullName(name, namespace)
+		if i := strings.LastIndex(s.name, "."); i > 0 {
+			namespace = s.name[:i]
+		}
+		for _, alias := range schemaStrings(m["aliases"]) {
+			s.aliases = append(s.aliases, avroFullName(alias, namespace))
+		}
+		p.named[s.name] = s
+	}
+
+	switch typ {
+	case "record", "error":
+		s.typ = "record"
+		fields, _ := m["fields"].([]interface{})
+		for _, f := range fields {
+			fm, ok := f.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("record field must be an object")
+			}
+			field := &avroField{}
+			field.name, _ = fm["name"].(string)
+			field.aliases = schemaStrings(fm["aliases"])
+			field.def, field.hasDef = fm["default"]
+			var err error
+			if field.schema, err = p.parse(fm["type"], namespace); err != nil {
+				return nil, fmt.Errorf("field %v: %v", field.name, err)
+			}
+			s.fields = append(s.fields, field)
+		}
+	case "enum":
+		s.symbols = schemaStrings(m["symbols"])
+		s.enumDef, _ = m["default"].(string)
+	case "array":
+		var err error
+		if s.items, err = p.parse(m["items"], namespace); err != nil {
+			return nil, err
+		}
+	case "map":
+		var err error
+		if s.values, err = p.parse(m["values"], namespace); err != nil {
+			return nil, err
+		}
+	case "fixed":
+	default:
+		if _, exists := avroPrimitives[typ]; !exists {
+			return p.parse(typ, namespace)
+		}
+	}
+	return s, nil
+}
+
+// unionName returns the name that goavro uses for a member of a union.
+func (s *avroSchema) unionName() string {
+	switch s.typ {
+	case "record", "enum", "fixed":
+		return s.name
+	}
+	if s.logical != "" {
+		if name := s.typ + "." + s.logical; isAvroLogicalName(name) {
+			return name
+		}
+	}
+	return s.typ
+}
+
+func isAvroLogicalName(name string) bool {
+	_, exists := avroLogicalNames[name]
+	return exists
+}
+
+func (s *avroSchema) isDecimal() bool {
+	return s.logical == "decimal" && (s.typ == "bytes" || s.typ == "fixed")
+}
+
+func (s *avroSchema) nameMatches(other *avroSchema) bool {
+	if s.name == other.name {
+		return true
+	}
+	for _, alias := range s.aliases {
+		if alias == other.name {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+func jsonInt(v interface{}) (int64, bool) {
+	f, ok := v.(float64)
+	if !ok || f != float64(int64(f)) {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+func parseAvroTime(v interface{}) (time.Time, bool) {
+	str, ok := v.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// logicalFromJSON converts a JSON value into the native value of a logical
+// type, which goavro only accepts as a time.Time, time.Duration or *big.Rat,
+// and returns false if the schema isn't a supported logical type.
+func (s *avroSchema) logicalFromJSON(v interface{}) (interface{}, bool, error) {
+	switch s.unionName() {
+	case "long.timestamp-millis", "long.timestamp-micros", "int.date":
+		if t, ok := parseAvroTime(v); ok {
+			return t, true, nil
+		}
+		i, ok := jsonInt(v)
+		if !ok {
+			return nil, true, fmt.Errorf("expected timestamp string or number for %v, received %T", s.logical, v)
+		}
+		switch s.logical {
+		case "timestamp-millis":
+			return time.Unix(0, i*int64(time.Millisecond)).UTC(), true, nil
+		case "timestamp-micros":
+			return time.Unix(0, i*int64(time.Microsecond)).UTC(), true, nil
+		}
+		return time.Unix(i*24*60*60, 0).UTC(), true, nil
+	case "int.time-millis", "long.time-micros":
+		i, ok := jsonInt(v)
+		if !ok {
+			return nil, true, fmt.Errorf("expected number for %v, received %T", s.logical, v)
+		}
+		if s.logical == "time-millis" {
+			return time.Duration(i) * time.Millisecond, true, nil
+		}
+		return time.Duration(i) * time.Microsecond, true, nil
+	}
+	if s.isDecimal() {
+		var str string
+		switch t := v.(type) {
+		case string:
+			str = t
+		case float64:
+			str = strconv.FormatFloat(t, 'f', -1, 64)
+		default:
+			return nil, true, fmt.Errorf("expected decimal string or number, received %T", v)
+		}
+		r, ok := new(big.Rat).SetString(str)
+		if !ok {
+			return nil, true, fmt.Errorf("failed to parse decimal: %v", str)
+		}
+		return r, true, nil
+	}
+	return nil, false, nil
+}
+
+// fromJSON converts the values of logical types within a JSON value into the
+// native values of goavro according to the schema. All other values are left
+// as they are, as goavro converts them itself, and values of unions must be
+// objects with a single key that is the name of a member.
+func (s *avroSchema) fromJSON(v interface{}) (interface{}, error) {
+	if n, ok, err := s.logicalFromJSON(v); ok {
+		return n, err
+	}
+
+	switch s.typ {
+	case "record":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return v, nil
+		}
+		native := make(map[string]interface{}, len(obj))
+		for k, fv := range obj {
+			native[k] = fv
+		}
+		for _, f := range s.fields {
+			fv, exists := obj[f.name]
+			if !exists {
+				continue
+			}
+			var err error
+			if native[f.name], err = f.schema.fromJSON(fv); err != nil {
+				return nil, fmt.Errorf("field %v: %v", f.name, err)
+			}
+		}
+		return native, nil
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return v, nil
+		}
+		native := make([]interface{}, len(arr))
+		for i, e := range arr {
+			var err error
+			if native[i], err = s.items.fromJSON(e); err != nil {
+				return nil, fmt.Errorf("index %v: %v", i, err)
+			}
+		}
+		return native, nil
+	case "map":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return v, nil
+		}
+		native := make(map[string]interface{}, len(obj))
+		for k, e := range obj {
+			var err error
+			if native[k], err = s.values.fromJSON(e); err != nil {
+				return nil, fmt.Errorf("key %v: %v", k, err)
+			}
+		}
+		return native, nil
+	case "union":
+		obj, ok := v.(map[string]interface{})
+		if !ok || len(obj) != 1 {
+			return v, nil
+		}
+		for k, inner := range obj {
+			for _, m := range s.members {
+				if m.unionName() != k {
+					continue
+				}
+				native, err := m.fromJSON(inner)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]interface{}{k: native}, nil
+			}
+		}
+	}
+	return v, nil
+}
+
+// toJSON converts a native value of goavro according to the schema into a
+// value that can be marshalled as JSON, where times of day are converted into
+// numbers of their unit and decimals into numbers.
+func (s *avroSchema) toJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case time.Duration:
+		if s.logical == "time-millis" {
+			return int64(t / time.Millisecond)
+		}
+		return int64(t / time.Microsecond)
+	case *big.Rat:
+		return json.Number(t.FloatString(s.scale))
+	}
+
+	switch s.typ {
+	case "record":
+		if obj, ok := v.(map[string]interface{}); ok {
+			for _, f := range s.fields {
+				if fv, exists := obj[f.name]; exists {
+					obj[f.name] = f.schema.toJSON(fv)
+				}
+			}
+		}
+	case "array":
+		if arr, ok := v.([]interface{}); ok {
+			for i, e := range arr {
+				arr[i] = s.items.toJSON(e)
+			}
+		}
+	case "map":
+		if obj, ok := v.(map[string]interface{}); ok {
+			for k, e := range obj {
+				obj[k] = s.values.toJSON(e)
+			}
+		}
+	case "union":
+		if obj, ok := v.(map[string]interface{}); ok && len(obj) == 1 {
+			for k, inner := range obj {
+				for _, m := range s.members {
+					if m.unionName() == k {
+						obj[k] = m.toJSON(inner)
+					}
+				}
+			}
+		}
+	}
+	return v
+}
+
+//------------------------------------------------------------------------------
+
+// resolve converts a native value written with the writer schema into a
+// native value of the reader schema, following the schema resolution rules of
+// the Avro specification.
+func resolveAvro(writer, reader *avroSchema, v interface{}) (interface{}, error) {
+	if writer.typ == "union" {
+		if v == nil {
+			for _, m := range writer.members {
+				if m.typ == "null" {
+					return resolveAvro(m, reader, nil)
+				}
+			}
+			return nil, errors.New("writer union does not contain null")
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok || len(obj) != 1 {
+			return nil, fmt.Errorf("expected union value, received %T", v)
+		}
+		for k, inner := range obj {
+			for _, m := range writer.members {
+				if m.unionName() == k {
+					return resolveAvro(m, reader, inner)
+				}
+			}
+			return nil, fmt.Errorf("writer union does not contain %v", k)
+		}
+	}
+
+	if reader.typ == "union" {
+		for _, m := range reader.members {
+			if !avroMatches(writer, m) {
+				continue
+			}
+			native, err := resolveAvro(writer, m, v)
+			if err != nil {
+				return nil, err
+			}
+			if m.typ == "null" {
+				return nil, nil
+			}
+			return map[string]interface{}{m.unionName(): native}, nil
+		}
+		return nil, fmt.Errorf("no member of the reader union matches the writer type %v", writer.unionName())
+	}
+
+	if !avroMatches(writer, reader) {
+		return nil, fmt.Errorf("writer type %v does not match reader type %v", writer.unionName(), reader.unionName())
+	}
+
+	switch reader.typ {
+	case "long":
+		if i, ok := v.(int32); ok {
+			return int64(i), nil
+		}
+	case "float":
+		switch t := v.(type) {
+		case int32:
+			return float32(t), nil
+		case int64:
+			return float32(t), nil
+		}
+	case "double":
+		switch t := v.(type) {
+		case int32:
+			return float64(t), nil
+		case int64:
+			return float64(t), nil
+		case float32:
+			return float64(t), nil
+		}
+	case "string":
+		if b, ok := v.([]byte); ok {
+			return string(b), nil
+		}
+	case "bytes":
+		if str, ok := v.(string); ok {
+			return []byte(str), nil
+		}
+	case "enum":
+		str, _ := v.(string)
+		for _, sym := range reader.symbols {
+			if sym == str {
+				return str, nil
+			}
+		}
+		if reader.enumDef != "" {
+			return reader.enumDef, nil
+		}
+		return nil, fmt.Errorf("symbol %v is not in the reader enum %v", str, reader.name)
+	case "array":
+		arr, _ := v.([]interface{})
+		resolved := make([]interface{}, len(arr))
+		for i, e := range arr {
+			var err error
+			if resolved[i], err = resolveAvro(writer.items, reader.items, e); err != nil {
+				return nil, fmt.Errorf("index %v: %v", i, err)
+			}
+		}
+		return resolved, nil
+	case "map":
+		obj, _ := v.(map[string]interface{})
+		resolved := make(map[string]interface{}, len(obj))
+		for k, e := range obj {
+			var err error
+			if resolved[k], err = resolveAvro(writer.values, reader.values, e); err != nil {
+				return nil, fmt.Errorf("key %v: %v", k, err)
+			}
+		}
+		return resolved, nil
+	case "record":
+		return resolveAvroRecord(writer, reader, v)
+	}
+	return v, nil
+}
+
+func resolveAvroRecord(writer, reader *avroSchema, v interface{}) (interface{}, error) {
+	obj, _ := v.(map[string]interface{})
+	resolved := make(map[string]interface{}, len(reader.fields))
+
+	for _, rf := range reader.fields {
+		var wf *avroField
+		for _, f := range writer.fields {
+			if f.name == rf.name {
+				wf = f
+				break
+			}
+			for _, alias := range rf.aliases {
+				if f.name == alias {
+					wf = f
+				}
+			}
+		}
+
+		if wf != nil {
+			fv, err := resolveAvro(wf.schema, rf.schema, obj[wf.name])
+			if err != nil {
+				return nil, fmt.Errorf("field %v: %v", rf.name, err)
+			}
+			resolved[rf.name] = fv
+			continue
+		}
+		if !rf.hasDef {
+			return nil, fmt.Errorf("field %v is missing from the writer schema and has no default", rf.name)
+		}
+
+		// Default values of unions correspond to the first member of the
+		// union.
+		defSchema := rf.schema
+		if defSchema.typ == "union" && len(defSchema.members) > 0 {
+			defSchema = defSchema.members[0]
+		}
+		fv, err := defSchema.fromJSON(rf.def)
+		if err != nil {
+			return nil, fmt.Errorf("field %v default: %v", rf.name, err)
+		}
+		if rf.schema.typ == "union" && defSchema.typ != "null" {
+			fv = map[string]interface{}{defSchema.unionName(): fv}
+		}
+		resolved[rf.name] = fv
+	}
+	return resolved, nil
+}
+
+// avroMatches returns whether values of the writer schema can be resolved
+// into values of the reader schema.
+func avroMatches(writer, reader *avroSchema) bool {
+	switch reader.typ {
+	case "union":
+		for _, m := range reader.members {
+			if avroMatches(writer, m) {
+				return true
+			}
+		}
+		return false
+	case "record", "enum", "fixed":
+		return writer.typ == reader.typ && reader.nameMatches(writer)
+	case "long":
+		return writer.typ == "int" || writer.typ == "long"
+	case "float":
+		return writer.typ == "int" || writer.typ == "long" || writer.typ == "float"
+	case "double":
+		return writer.typ == "int" || writer.typ == "long" || writer.typ == "float" || writer.typ == "double"
+	case "string", "bytes":
+		return writer.typ == "string" || writer.typ == "bytes"
+	}
+	return writer.typ == reader.typ
+}
+
+//------------------------------------------------------------------------------
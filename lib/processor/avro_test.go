@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -12,6 +13,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAvroBasic(t *testing.T) {
@@ -331,3 +334,190 @@ func TestAvroSchemaPathNotExist(t *testing.T) {
 		t.Error("expected error from loading non existant schema file")
 	}
 }
+
+func avroTestProc(t *testing.T, fn func(c *AvroConfig)) Type {
+	t.Helper()
+
+	conf := NewConfig()
+	conf.Type = TypeAvro
+	fn(&conf.Avro)
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	return proc
+}
+
+func avroTestProcess(t *testing.T, proc Type, input []byte) []byte {
+	t.Helper()
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{input}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Empty(t, msgs[0].Get(0).Metadata().Get(FailFlagKey))
+	return msgs[0].Get(0).Get()
+}
+
+func TestAvroOCF(t *testing.T) {
+	schema := `{
+	"type": "record",
+	"name": "event",
+	"fields": [
+		{ "name": "id", "type": "string" },
+		{ "name": "count", "type": "int" }
+	]
+}`
+
+	for _, compression := range []string{"none", "deflate", "snappy"} {
+		compression := compression
+		t.Run(compression, func(t *testing.T) {
+			encoder := avroTestProc(t, func(c *AvroConfig) {
+				c.Operator = "from_json"
+				c.Encoding = "ocf"
+				c.Schema = schema
+				c.Compression = compression
+			})
+			// The schema is read from the container.
+			decoder := avroTestProc(t, func(c *AvroConfig) {
+				c.Operator = "to_json"
+				c.Encoding = "ocf"
+			})
+
+			ocf := avroTestProcess(t, encoder, []byte(`[{"id":"foo","count":1},{"id":"bar","count":2}]`))
+			assert.True(t, bytes.HasPrefix(ocf, []byte("Obj\x01")))
+			assert.Equal(t, `[{"count":1,"id":"foo"},{"count":2,"id":"bar"}]`, string(avroTestProcess(t, decoder, ocf)))
+
+			ocf = avroTestProcess(t, encoder, []byte(`{"id":"baz","count":3}`))
+			assert.Equal(t, `[{"count":3,"id":"baz"}]`, string(avroTestProcess(t, decoder, ocf)))
+		})
+	}
+}
+
+func TestAvroSchemaEvolution(t *testing.T) {
+	writerSchema := `{
+	"type": "record",
+	"name": "user",
+	"fields": [
+		{ "name": "name", "type": "string" },
+		{ "name": "age", "type": "int" },
+		{ "name": "nickname", "type": "string" },
+		{ "name": "colour", "type": { "type": "enum", "name": "colour", "symbols": ["RED", "GREEN", "BLUE"] } },
+		{ "name": "tags", "type": { "type": "array", "items": "int" } }
+	]
+}`
+	readerSchema := `{
+	"type": "record",
+	"name": "user",
+	"fields": [
+		{ "name": "full_name", "aliases": ["name"], "type": "string" },
+		{ "name": "age", "type": "long" },
+		{ "name": "email", "type": ["null", "string"], "default": null },
+		{ "name": "country", "type": "string", "default": "unknown" },
+		{ "name": "colour", "type": { "type": "enum", "name": "colour", "symbols": ["RED", "GREEN"], "default": "RED" } },
+		{ "name": "tags", "type": { "type": "array", "items": ["null", "double"] } }
+	]
+}`
+
+	input := []byte(`{"name":"foo","age":30,"nickname":"f","colour":"BLUE","tags":[1,2]}`)
+	for _, encoding := range []string{"binary", "ocf"} {
+		encoding := encoding
+		t.Run(encoding, func(t *testing.T) {
+			encoder := avroTestProc(t, func(c *AvroConfig) {
+				c.Operator = "from_json"
+				c.Encoding = encoding
+				c.Schema = writerSchema
+			})
+			decoder := avroTestProc(t, func(c *AvroConfig) {
+				c.Operator = "to_json"
+				c.Encoding = encoding
+				c.Schema = writerSchema
+				c.ReaderSchema = readerSchema
+			})
+
+			exp := `{"age":30,"colour":"RED","country":"unknown","email":null,"full_name":"foo","tags":[{"double":1},{"double":2}]}`
+			if encoding == "ocf" {
+				exp = "[" + exp + "]"
+			}
+			assert.Equal(t, exp, string(avroTestProcess(t, decoder, avroTestProcess(t, encoder, input))))
+		})
+	}
+
+	conf := NewConfig()
+	conf.Type = TypeAvro
+	conf.Avro.Operator = "to_json"
+	conf.Avro.Encoding = "binary"
+	conf.Avro.Schema = writerSchema
+	conf.Avro.ReaderSchema = `{"type":"record","name":"user","fields":[{"name":"missing","type":"string"}]}`
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	encoder := avroTestProc(t, func(c *AvroConfig) {
+		c.Operator = "from_json"
+		c.Encoding = "binary"
+		c.Schema = writerSchema
+	})
+	msgs, _ := proc.ProcessMessage(message.New([][]byte{avroTestProcess(t, encoder, input)}))
+	require.Len(t, msgs, 1)
+	assert.Contains(t, msgs[0].Get(0).Metadata().Get(FailFlagKey), "field missing is missing from the writer schema and has no default")
+
+	conf.Avro.Operator = "from_json"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
+
+func TestAvroLogicalTypes(t *testing.T) {
+	schema := `{
+	"type": "record",
+	"name": "logical",
+	"fields": [
+		{ "name": "ts", "type": { "type": "long", "logicalType": "timestamp-millis" } },
+		{ "name": "day", "type": { "type": "int", "logicalType": "date" } },
+		{ "name": "time", "type": { "type": "int", "logicalType": "time-millis" } },
+		{ "name": "price", "type": { "type": "bytes", "logicalType": "decimal", "precision": 8, "scale": 2 } },
+		{ "name": "maybe_ts", "type": ["null", { "type": "long", "logicalType": "timestamp-micros" }], "default": null }
+	]
+}`
+
+	encoder := avroTestProc(t, func(c *AvroConfig) {
+		c.Operator = "from_json"
+		c.Encoding = "binary"
+		c.Schema = schema
+	})
+	decoder := avroTestProc(t, func(c *AvroConfig) {
+		c.Operator = "to_json"
+		c.Encoding = "binary"
+		c.Schema = schema
+	})
+	convertDecoder := avroTestProc(t, func(c *AvroConfig) {
+		c.Operator = "to_json"
+		c.Encoding = "binary"
+		c.Schema = schema
+		c.ConvertLogicalTypes = true
+	})
+
+	tests := map[string]struct {
+		input         string
+		output        string
+		convertOutput string
+	}{
+		"strings": {
+			input:         `{"ts":"2020-09-01T10:00:00.5Z","day":"2020-09-01","time":1500,"price":"12.34","maybe_ts":{"long.timestamp-micros":"2020-09-01T10:00:00Z"}}`,
+			output:        `{"day":"2020-09-01T00:00:00Z","maybe_ts":{"long.timestamp-micros":"2020-09-01T10:00:00Z"},"price":"617/50","time":1500000000,"ts":"2020-09-01T10:00:00.5Z"}`,
+			convertOutput: `{"day":"2020-09-01T00:00:00Z","maybe_ts":{"long.timestamp-micros":"2020-09-01T10:00:00Z"},"price":12.34,"time":1500,"ts":"2020-09-01T10:00:00.5Z"}`,
+		},
+		"numbers": {
+			input:         `{"ts":1598954400500,"day":18506,"time":1500,"price":12.5,"maybe_ts":null}`,
+			output:        `{"day":"2020-09-01T00:00:00Z","maybe_ts":null,"price":"25/2","time":1500000000,"ts":"2020-09-01T10:00:00.5Z"}`,
+			convertOutput: `{"day":"2020-09-01T00:00:00Z","maybe_ts":null,"price":12.50,"time":1500,"ts":"2020-09-01T10:00:00.5Z"}`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			encoded := avroTestProcess(t, encoder, []byte(test.input))
+			assert.Equal(t, test.output, string(avroTestProcess(t, decoder, encoded)))
+			assert.Equal(t, test.convertOutput, string(avroTestProcess(t, convertDecoder, encoded)))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+//------------------------------------------------------------------------------
+
+// jsonWithIntegers parses a JSON document where numbers without a fraction or
+// exponent are parsed as int64 (or uint64 when they're too large) rather than
+// float64, so that they're encoded as integers by binary formats.
+func jsonWithIntegers(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after JSON document")
+	}
+	return jsonNumbersToNative(v), nil
+}
+
+func jsonNumbersToNative(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(t.String(), 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+			return u
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = jsonNumbersToNative(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = jsonNumbersToNative(e)
+		}
+	}
+	return v
+}
+
+// jsonCompatible converts maps with keys that aren't strings, which binary
+// formats allow, into maps with string keys so that values can be marshalled
+// as JSON.
+func jsonCompatible(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[fmt.Sprintf("%v", k)] = jsonCompatible(e)
+		}
+		return m
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = jsonCompatible(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = jsonCompatible(e)
+		}
+	}
+	return v
+}
+
+//------------------------------------------------------------------------------
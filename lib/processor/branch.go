@@ -40,7 +40,8 @@ processors that would otherwise replace the entire contents.
 If the ` + "`request_map`" + ` fails the child processors will not be executed.
 If the child processors themselves result in an (uncaught) error then the
 ` + "`result_map`" + ` will not be executed. If the ` + "`result_map`" + ` fails
-the message will remain unchanged. Under any of these conditions standard
+the message will remain unchanged, including any fields or metadata assigned by
+the mapping before it failed. Under any of these conditions standard
 [error handling methods](/docs/configuration/error_handling) can be used in
 order to filter, DLQ or recover the failed messages.
 
@@ -492,7 +493,9 @@ func (b *Branch) overlayResult(payload types.Message, results []types.Part) ([]b
 				continue partLoop
 			}
 
-			newPart, err := b.resultMap.MapOnto(payload.Get(i), i, resultMsg)
+			// Map onto a copy so that the original remains untouched when the
+			// mapping fails part way through.
+			newPart, err := b.resultMap.MapOnto(payload.Get(i).DeepCopy(), i, resultMsg)
 			if err != nil {
 				b.mErrRes.Incr(1)
 				b.log.Debugf("Failed to map result '%v': %v\n", i, err)
@@ -503,7 +506,10 @@ func (b *Branch) overlayResult(payload types.Message, results []types.Part) ([]b
 
 			// TODO: Allow filtering here?
 			if newPart != nil {
-				parts[i] = newPart
+				// Update the original in place as the payload parts may be
+				// referenced elsewhere, e.g. by subsequent workflow branches.
+				parts[i].Set(newPart.Get())
+				parts[i].SetMetadata(newPart.Metadata())
 			}
 		}
 
@@ -112,6 +112,25 @@ func TestBranchBasic(t *testing.T) {
 				msg(`{"id":4,"name":"fifth","result":"FIFTH"}`),
 			},
 		},
+		"partially failed result mapping": {
+			requestMap:   `root = this`,
+			processorMap: `root.name_upper = this.name.uppercase()`,
+			resultMap: `meta foo = "bar"
+root.result = this.name_upper
+root.checked = if this.name_upper == "SECOND" { throw("i dont like two") } else { true }`,
+			input: []mockMsg{
+				msg(`{"id":1,"name":"first"}`),
+				msg(`{"id":2,"name":"second"}`),
+			},
+			output: []mockMsg{
+				msg(`{"checked":true,"id":1,"name":"first","result":"FIRST"}`, "foo", "bar"),
+				msg(
+					`{"id":2,"name":"second"}`,
+					FailFlagKey,
+					"response failed: result map: failed to execute mapping query at line 3: i dont like two",
+				),
+			},
+		},
 		"filter all requests": {
 			requestMap:   `root = deleted()`,
 			processorMap: `root = this`,
@@ -0,0 +1,188 @@
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/opentracing/opentracing-go"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeBSON] = TypeSpec{
+		constructor: NewBSON,
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Converts messages to or from the [BSON](http://bsonspec.org/) format.`,
+		Beta: true,
+		Description: `
+## Operators
+
+### ` + "`to_json`" + `
+
+Converts BSON documents into [Extended JSON](https://docs.mongodb.com/manual/reference/mongodb-extended-json/),
+which represents the types of BSON values that JSON doesn't have, such as
+ObjectIds, dates and binary data, as objects like
+` + "`{\"$oid\":\"5f5b2b1c9d1e8a3f4c8b4567\"}`" + `. By default the relaxed
+format is produced, where numbers are plain JSON numbers, and when
+` + "`canonical`" + ` is enabled the canonical format is produced, where the type
+of every number is preserved, such as ` + "`{\"$numberLong\":\"5\"}`" + `.
+
+### ` + "`from_json`" + `
+
+Converts JSON documents, which may contain values in either Extended JSON
+format, into BSON documents. Numbers without a fraction or exponent are encoded
+as 32-bit integers, or 64-bit integers when they're too large, and all other
+numbers as doubles.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json"),
+			docs.FieldAdvanced("canonical", "Whether the `to_json` operator produces canonical rather than relaxed Extended JSON."),
+			partsFieldSpec,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// BSONConfig contains configuration fields for the BSON processor.
+type BSONConfig struct {
+	Parts     []int  `json:"parts" yaml:"parts"`
+	Operator  string `json:"operator" yaml:"operator"`
+	Canonical bool   `json:"canonical" yaml:"canonical"`
+}
+
+// NewBSONConfig returns a BSONConfig with default values.
+func NewBSONConfig() BSONConfig {
+	return BSONConfig{
+		Parts:     []int{},
+		Operator:  "to_json",
+		Canonical: false,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type bsonOperator func(part types.Part) error
+
+func newBSONToJSONOperator(canonical bool) bsonOperator {
+	return func(part types.Part) error {
+		doc := bson.Raw(part.Get())
+		if err := doc.Validate(); err != nil {
+			return fmt.Errorf("failed to convert BSON document to JSON: %v", err)
+		}
+		data, err := bson.MarshalExtJSON(doc, canonical, false)
+		if err != nil {
+			return fmt.Errorf("failed to convert BSON document to JSON: %v", err)
+		}
+		part.Set(data)
+		return nil
+	}
+}
+
+func newBSONFromJSONOperator() bsonOperator {
+	return func(part types.Part) error {
+		var doc bson.D
+		if err := bson.UnmarshalExtJSON(part.Get(), false, &doc); err != nil {
+			return fmt.Errorf("failed to parse message as JSON: %v", err)
+		}
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("failed to convert JSON to BSON: %v", err)
+		}
+		part.Set(data)
+		return nil
+	}
+}
+
+func strToBSONOperator(conf BSONConfig) (bsonOperator, error) {
+	switch conf.Operator {
+	case "to_json":
+		return newBSONToJSONOperator(conf.Canonical), nil
+	case "from_json":
+		return newBSONFromJSONOperator(), nil
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
+}
+
+//------------------------------------------------------------------------------
+
+// BSON is a processor that converts messages to or from BSON.
+type BSON struct {
+	parts    []int
+	operator bsonOperator
+
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewBSON returns a BSON processor.
+func NewBSON(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	p := &BSON{
+		parts: conf.BSON.Parts,
+		conf:  conf,
+		log:   log,
+		stats: stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	var err error
+	if p.operator, err = strToBSONOperator(conf.BSON); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (p *BSON) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	p.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(index int, span opentracing.Span, part types.Part) error {
+		if err := p.operator(part); err != nil {
+			p.mErr.Incr(1)
+			p.log.Debugf("Operator failed: %v\n", err)
+			return err
+		}
+		return nil
+	}
+
+	IteratePartsWithSpan(TypeBSON, p.parts, newMsg, proc)
+
+	p.mBatchSent.Incr(1)
+	p.mSent.Incr(int64(newMsg.Len()))
+	return []types.Message{newMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (p *BSON) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (p *BSON) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func bsonTestProcess(t *testing.T, operator string, canonical bool, input []byte) []byte {
+	t.Helper()
+
+	conf := NewConfig()
+	conf.Type = TypeBSON
+	conf.BSON.Operator = operator
+	conf.BSON.Canonical = canonical
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{input}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.False(t, HasFailed(msgs[0].Get(0)), GetFail(msgs[0].Get(0)))
+	return msgs[0].Get(0).Get()
+}
+
+func TestBSONFromJSON(t *testing.T) {
+	encoded := bsonTestProcess(t, "from_json", false, []byte(`{"small":1,"big":9007199254740993,"float":1.0,"id":{"$oid":"5f5b2b1c9d1e8a3f4c8b4567"},"nested":{"b":[1,"two"],"a":null}}`))
+
+	var doc bson.D
+	require.NoError(t, bson.Unmarshal(encoded, &doc))
+
+	oid, err := primitive.ObjectIDFromHex("5f5b2b1c9d1e8a3f4c8b4567")
+	require.NoError(t, err)
+
+	assert.Equal(t, bson.D{
+		{Key: "small", Value: int32(1)},
+		{Key: "big", Value: int64(9007199254740993)},
+		{Key: "float", Value: float64(1)},
+		{Key: "id", Value: oid},
+		{Key: "nested", Value: bson.D{
+			{Key: "b", Value: bson.A{int32(1), "two"}},
+			{Key: "a", Value: nil},
+		}},
+	}, doc)
+}
+
+func TestBSONToJSON(t *testing.T) {
+	input, err := bson.Marshal(bson.D{
+		{Key: "small", Value: int32(1)},
+		{Key: "big", Value: int64(9007199254740993)},
+		{Key: "float", Value: 1.5},
+		{Key: "nested", Value: bson.D{{Key: "b", Value: bson.A{int64(1), "two"}}}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		`{"small":1,"big":9007199254740993,"float":1.5,"nested":{"b":[1,"two"]}}`,
+		string(bsonTestProcess(t, "to_json", false, input)),
+	)
+	assert.Equal(t,
+		`{"small":{"$numberInt":"1"},"big":{"$numberLong":"9007199254740993"},"float":{"$numberDouble":"1.5"},"nested":{"b":[{"$numberLong":"1"},"two"]}}`,
+		string(bsonTestProcess(t, "to_json", true, input)),
+	)
+
+	// Canonical Extended JSON converts back into the same types.
+	assert.Equal(t, input, bsonTestProcess(t, "from_json", false, bsonTestProcess(t, "to_json", true, input)))
+}
+
+func TestBSONErrors(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeBSON
+	conf.BSON.Operator = "nope"
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	for _, operator := range []string{"to_json", "from_json"} {
+		conf.BSON.Operator = operator
+		proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		msgs, _ := proc.ProcessMessage(message.New([][]byte{[]byte(`not a document`)}))
+		require.Len(t, msgs, 1)
+		assert.True(t, HasFailed(msgs[0].Get(0)), operator)
+	}
+}
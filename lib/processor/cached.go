@@ -0,0 +1,268 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeCached] = TypeSpec{
+		constructor: NewCached,
+		Categories: []Category{
+			CategoryComposition,
+		},
+		Summary: `
+Caches the result of applying a list of child processors to each message with a
+key, so that the processors are only applied once for each key.`,
+		Beta: true,
+		Description: `
+For each message the ` + "`key`" + ` is resolved and the
+` + "[`cache` resource](/docs/components/caches/about)" + ` is checked for an
+existing result. When a result exists the contents of the message are replaced
+with it, otherwise the child processors are applied to the message and the
+contents of the resulting message are stored within the cache.
+
+This is useful for expensive enrichments such as HTTP requests or database
+queries that return the same result for the same key. How long results are
+kept for is determined by the cache, where caches such as
+` + "[`memory`](/docs/components/caches/memory)" + ` support a TTL.
+
+The child processors must result in exactly one message for each message they
+are applied to. Results that are flagged as failed by the child processors are
+not cached, and only the contents of results are cached, and so metadata added
+by the child processors is only present on messages that weren't cached.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("cache", "The [`cache` resource](/docs/components/caches/about) to store results within."),
+			docs.FieldCommon("key", "The key to store the result of each message with.", `${! json("user.id") }`, `${! meta("kafka_key") }`).SupportsInterpolation(false),
+			docs.FieldCommon("processors", "A list of processors to apply to messages that don't have a cached result."),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Cached Enrichment",
+				Summary: `
+Here the profile of the user of each message is fetched with an HTTP request
+and added to the message, where profiles are cached for ten minutes:`,
+				Config: `
+pipeline:
+  processors:
+    - branch:
+        request_map: 'root = ""'
+        processors:
+          - cached:
+              cache: profiles
+              key: ${! json("user.id") }
+              processors:
+                - http:
+                    url: https://example.com/users/${! json("user.id") }/profile
+                    verb: GET
+        result_map: 'root.user.profile = this'
+
+resources:
+  caches:
+    profiles:
+      memory:
+        ttl: 600
+`,
+			},
+		},
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return conf.Cached.Sanitise()
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CachedConfig contains configuration fields for the Cached processor.
+type CachedConfig struct {
+	Cache      string   `json:"cache" yaml:"cache"`
+	Key        string   `json:"key" yaml:"key"`
+	Processors []Config `json:"processors" yaml:"processors"`
+}
+
+// NewCachedConfig returns a CachedConfig with default values.
+func NewCachedConfig() CachedConfig {
+	return CachedConfig{
+		Cache:      "",
+		Key:        "",
+		Processors: []Config{},
+	}
+}
+
+// Sanitise the configuration into a minimal structure that can be printed
+// without changing the intent.
+func (c CachedConfig) Sanitise() (map[string]interface{}, error) {
+	var err error
+	procConfs := make([]interface{}, len(c.Processors))
+	for i, pConf := range c.Processors {
+		if procConfs[i], err = SanitiseConfig(pConf); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]interface{}{
+		"cache":      c.Cache,
+		"key":        c.Key,
+		"processors": procConfs,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Cached is a processor that caches the results of child processors by key.
+type Cached struct {
+	cache    types.Cache
+	key      field.Expression
+	children []types.Processor
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mHit       metrics.StatCounter
+	mMiss      metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewCached returns a Cached processor.
+func NewCached(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	c, err := mgr.GetCache(conf.Cached.Cache)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := bloblang.NewField(conf.Cached.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key expression: %v", err)
+	}
+
+	var children []types.Processor
+	for i, pconf := range conf.Cached.Processors {
+		prefix := fmt.Sprintf("processor.%v", i)
+		proc, err := New(pconf, mgr, log.NewModule("."+prefix), metrics.Namespaced(stats, prefix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to init processor %v: %w", i, err)
+		}
+		children = append(children, proc)
+	}
+	if len(children) == 0 {
+		return nil, errors.New("the cached processor requires at least one child processor")
+	}
+
+	return &Cached{
+		cache:    c,
+		key:      key,
+		children: children,
+
+		log: log,
+
+		mCount:     stats.GetCounter("count"),
+		mHit:       stats.GetCounter("cache.hit"),
+		mMiss:      stats.GetCounter("cache.miss"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// process applies the child processors to a message part, returning the
+// resulting part.
+func (c *Cached) process(part types.Part) (types.Part, error) {
+	msg := message.New(nil)
+	msg.Append(part.Copy())
+
+	results, res := ExecuteAll(c.children, msg)
+	if res != nil && res.Error() != nil {
+		return nil, res.Error()
+	}
+	if len(results) != 1 || results[0].Len() != 1 {
+		count := 0
+		for _, m := range results {
+			count += m.Len()
+		}
+		return nil, fmt.Errorf("child processors resulted in %v messages rather than one", count)
+	}
+	return results[0].Get(0), nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (c *Cached) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	c.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	IteratePartsWithSpan(TypeCached, nil, newMsg, func(index int, span opentracing.Span, part types.Part) error {
+		key := c.key.String(index, msg)
+
+		cached, err := c.cache.Get(key)
+		if err == nil {
+			c.mHit.Incr(1)
+			part.Set(cached)
+			return nil
+		}
+		if err != types.ErrKeyNotFound {
+			c.mErr.Incr(1)
+			c.log.Debugf("Failed to read result from cache: %v\n", err)
+		}
+		c.mMiss.Incr(1)
+
+		result, err := c.process(part)
+		if err != nil {
+			c.mErr.Incr(1)
+			c.log.Debugf("Failed to process message: %v\n", err)
+			return err
+		}
+		part.Set(result.Get())
+		part.SetMetadata(result.Metadata())
+		if HasFailed(part) {
+			return nil
+		}
+
+		if err = c.cache.Set(key, result.Get()); err != nil {
+			c.mErr.Incr(1)
+			c.log.Debugf("Failed to store result in cache: %v\n", err)
+		}
+		return nil
+	})
+
+	c.mBatchSent.Incr(1)
+	c.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (c *Cached) CloseAsync() {
+	for _, child := range c.children {
+		child.CloseAsync()
+	}
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (c *Cached) WaitForClose(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	for _, child := range c.children {
+		if err := child.WaitForClose(time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,162 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCachedOutput(t *testing.T, proc Type, input []string) []string {
+	t.Helper()
+
+	msg := message.New(nil)
+	for _, in := range input {
+		msg.Append(message.NewPart([]byte(in)))
+	}
+
+	msgs, res := proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	var output []string
+	msgs[0].Iter(func(i int, p types.Part) error {
+		if HasFailed(p) {
+			output = append(output, "failed: "+GetFail(p))
+		} else {
+			output = append(output, string(p.Get()))
+		}
+		return nil
+	})
+	return output
+}
+
+func TestCached(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	bConf := NewConfig()
+	bConf.Type = TypeBloblang
+	bConf.Bloblang = `root = this
+root.name = this.name.uppercase()
+meta enriched = "true"`
+
+	conf := NewConfig()
+	conf.Type = TypeCached
+	conf.Cached.Cache = "foocache"
+	conf.Cached.Key = `${! json("id") }`
+	conf.Cached.Processors = []Config{bConf}
+
+	proc, err := New(conf, &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"id":1,"name":"FOO"}`,
+		`{"id":2,"name":"BAR"}`,
+		`{"id":1,"name":"FOO"}`,
+		`failed: failed to execute mapping query at line 2: expected string value, found null`,
+	}, testCachedOutput(t, proc, []string{
+		`{"id":1,"name":"foo"}`,
+		`{"id":2,"name":"bar"}`,
+		`{"id":1,"name":"baz"}`,
+		`{"id":3}`,
+	}))
+
+	cached, err := memCache.Get("1")
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1,"name":"FOO"}`, string(cached))
+
+	_, err = memCache.Get("3")
+	assert.Equal(t, types.ErrKeyNotFound, err)
+
+	assert.Equal(t, []string{
+		`{"id":2,"name":"BAR"}`,
+		`{"id":3,"name":"BUZ"}`,
+	}, testCachedOutput(t, proc, []string{
+		`{"id":2,"name":"nope"}`,
+		`{"id":3,"name":"buz"}`,
+	}))
+}
+
+func TestCachedMetadata(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	bConf := NewConfig()
+	bConf.Type = TypeBloblang
+	bConf.Bloblang = `root = content().uppercase()
+meta enriched = "true"`
+
+	conf := NewConfig()
+	conf.Type = TypeCached
+	conf.Cached.Cache = "foocache"
+	conf.Cached.Key = `${! content() }`
+	conf.Cached.Processors = []Config{bConf}
+
+	proc, err := New(conf, &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(`foo`), []byte(`foo`)}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{[]byte(`FOO`), []byte(`FOO`)}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "true", msgs[0].Get(0).Metadata().Get("enriched"))
+	assert.Equal(t, "", msgs[0].Get(1).Metadata().Get("enriched"))
+}
+
+func TestCachedBadChildResult(t *testing.T) {
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	bConf := NewConfig()
+	bConf.Type = TypeBloblang
+	bConf.Bloblang = `root = deleted()`
+
+	conf := NewConfig()
+	conf.Type = TypeCached
+	conf.Cached.Cache = "foocache"
+	conf.Cached.Key = `${! content() }`
+	conf.Cached.Processors = []Config{bConf}
+
+	proc, err := New(conf, &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`failed: child processors resulted in 0 messages rather than one`,
+	}, testCachedOutput(t, proc, []string{`foo`}))
+}
+
+func TestCachedBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCached
+	conf.Cached.Cache = "foocache"
+
+	_, err := New(conf, &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": nil,
+		},
+	}, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "the cached processor requires at least one child processor")
+
+	conf.Cached.Processors = []Config{NewConfig()}
+	_, err = New(conf, &fakeMgr{}, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "cache not found")
+}
@@ -0,0 +1,195 @@
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeCBOR] = TypeSpec{
+		constructor: NewCBOR,
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Converts messages to or from the [CBOR](https://cbor.io/) format.`,
+		Beta: true,
+		Description: `
+## Operators
+
+### ` + "`to_json`" + `
+
+Converts CBOR documents into JSON. Integers remain integers, byte strings are
+converted into base64 encoded strings, timestamps into RFC 3339 strings, and
+maps with keys that aren't strings have their keys converted into strings.
+
+### ` + "`from_json`" + `
+
+Converts JSON documents into CBOR. Numbers without a fraction or exponent
+are encoded as integers, and all other numbers as floats.
+
+When ` + "`canonical`" + ` is enabled documents are encoded with the
+[Core Deterministic Encoding](https://tools.ietf.org/html/rfc8949#section-4.2.1)
+requirements, where the keys of maps are sorted and floats are encoded with the
+smallest type that preserves their value, and therefore the same document is
+always encoded to the same bytes.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json"),
+			docs.FieldAdvanced("canonical", "Whether the `from_json` operator produces canonical encodings."),
+			partsFieldSpec,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CBORConfig contains configuration fields for the CBOR processor.
+type CBORConfig struct {
+	Parts     []int  `json:"parts" yaml:"parts"`
+	Operator  string `json:"operator" yaml:"operator"`
+	Canonical bool   `json:"canonical" yaml:"canonical"`
+}
+
+// NewCBORConfig returns a CBORConfig with default values.
+func NewCBORConfig() CBORConfig {
+	return CBORConfig{
+		Parts:     []int{},
+		Operator:  "to_json",
+		Canonical: false,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type cborOperator func(part types.Part) error
+
+func newCBORToJSONOperator() cborOperator {
+	return func(part types.Part) error {
+		var v interface{}
+		if err := cbor.Unmarshal(part.Get(), &v); err != nil {
+			return fmt.Errorf("failed to convert CBOR document to JSON: %v", err)
+		}
+		if err := part.SetJSON(jsonCompatible(v)); err != nil {
+			return fmt.Errorf("failed to set JSON: %v", err)
+		}
+		return nil
+	}
+}
+
+func newCBORFromJSONOperator(canonical bool) (cborOperator, error) {
+	opts := cbor.EncOptions{}
+	if canonical {
+		opts = cbor.CoreDetEncOptions()
+	}
+	encMode, err := opts.EncMode()
+	if err != nil {
+		return nil, err
+	}
+	return func(part types.Part) error {
+		jObj, err := jsonWithIntegers(part.Get())
+		if err != nil {
+			return fmt.Errorf("failed to parse message as JSON: %v", err)
+		}
+
+		var data []byte
+		if data, err = encMode.Marshal(jObj); err != nil {
+			return fmt.Errorf("failed to convert JSON to CBOR: %v", err)
+		}
+		part.Set(data)
+		return nil
+	}, nil
+}
+
+func strToCBOROperator(conf CBORConfig) (cborOperator, error) {
+	switch conf.Operator {
+	case "to_json":
+		return newCBORToJSONOperator(), nil
+	case "from_json":
+		return newCBORFromJSONOperator(conf.Canonical)
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
+}
+
+//------------------------------------------------------------------------------
+
+// CBOR is a processor that converts messages to or from CBOR.
+type CBOR struct {
+	parts    []int
+	operator cborOperator
+
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewCBOR returns a CBOR processor.
+func NewCBOR(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	p := &CBOR{
+		parts: conf.CBOR.Parts,
+		conf:  conf,
+		log:   log,
+		stats: stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	var err error
+	if p.operator, err = strToCBOROperator(conf.CBOR); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (p *CBOR) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	p.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(index int, span opentracing.Span, part types.Part) error {
+		if err := p.operator(part); err != nil {
+			p.mErr.Incr(1)
+			p.log.Debugf("Operator failed: %v\n", err)
+			return err
+		}
+		return nil
+	}
+
+	IteratePartsWithSpan(TypeCBOR, p.parts, newMsg, proc)
+
+	p.mBatchSent.Incr(1)
+	p.mSent.Incr(int64(newMsg.Len()))
+	return []types.Message{newMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (p *CBOR) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (p *CBOR) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
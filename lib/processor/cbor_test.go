@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cborTestProcess(t *testing.T, operator string, canonical bool, input []byte) []byte {
+	t.Helper()
+
+	conf := NewConfig()
+	conf.Type = TypeCBOR
+	conf.CBOR.Operator = operator
+	conf.CBOR.Canonical = canonical
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{input}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.False(t, HasFailed(msgs[0].Get(0)), GetFail(msgs[0].Get(0)))
+	return msgs[0].Get(0).Get()
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		output string
+	}{
+		"integers": {
+			input:  `{"big":9007199254740993,"max":18446744073709551615,"neg":-5,"small":1}`,
+			output: `{"big":9007199254740993,"max":18446744073709551615,"neg":-5,"small":1}`,
+		},
+		"floats": {
+			input:  `{"a":1.5,"b":-0.25,"c":1e3}`,
+			output: `{"a":1.5,"b":-0.25,"c":1000}`,
+		},
+		"nested": {
+			input:  `{"a":[1,"two",{"three":3.5}],"b":null,"c":true}`,
+			output: `{"a":[1,"two",{"three":3.5}],"b":null,"c":true}`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			for _, canonical := range []bool{false, true} {
+				encoded := cborTestProcess(t, "from_json", canonical, []byte(test.input))
+				assert.Equal(t, test.output, string(cborTestProcess(t, "to_json", false, encoded)))
+			}
+		})
+	}
+}
+
+func TestCBORCanonical(t *testing.T) {
+	a := cborTestProcess(t, "from_json", true, []byte(`{"bb":1,"a":2.5,"c":{"z":1,"y":2}}`))
+	b := cborTestProcess(t, "from_json", true, []byte(`{"c":{"y":2,"z":1},"a":2.5,"bb":1}`))
+	assert.Equal(t, a, b)
+
+	// The integer remains an integer and the float is encoded as a float16.
+	assert.Equal(t, []byte{0xa1, 0x61, 'a', 0x01}, cborTestProcess(t, "from_json", true, []byte(`{"a":1}`)))
+	assert.Equal(t, []byte{0xa1, 0x61, 'a', 0xf9, 0x3c, 0x00}, cborTestProcess(t, "from_json", true, []byte(`{"a":1.0}`)))
+}
+
+func TestCBORToJSONTypes(t *testing.T) {
+	tests := map[string]struct {
+		input  []byte
+		output string
+	}{
+		"non string keys": {
+			// {1: "foo"}
+			input:  []byte{0xa1, 0x01, 0x63, 'f', 'o', 'o'},
+			output: `{"1":"foo"}`,
+		},
+		"byte string": {
+			// h'0102'
+			input:  []byte{0x42, 0x01, 0x02},
+			output: `"AQI="`,
+		},
+		"timestamp": {
+			// 1(1598954400)
+			input:  []byte{0xc1, 0x1a, 0x5f, 0x4e, 0x1b, 0xa0},
+			output: `"2020-09-01T10:00:00Z"`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.output, string(cborTestProcess(t, "to_json", false, test.input)))
+		})
+	}
+}
+
+func TestCBORErrors(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeCBOR
+	conf.CBOR.Operator = "nope"
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	conf.CBOR.Operator = "to_json"
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, _ := proc.ProcessMessage(message.New([][]byte{{0xa1}}))
+	require.Len(t, msgs, 1)
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+}
@@ -6,6 +6,8 @@ import (
 	"compress/gzip"
 	"compress/zlib"
 	"fmt"
+	"io/ioutil"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -13,7 +15,11 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/andybalholm/brotli"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/opentracing/opentracing-go"
+	"github.com/pierrec/lz4"
 )
 
 //------------------------------------------------------------------------------
@@ -26,12 +32,20 @@ func init() {
 		},
 		Summary: `
 Compresses messages according to the selected algorithm. Supported compression
-algorithms are: gzip, zlib, flate.`,
+algorithms are: gzip, zlib, flate, zstd, brotli, lz4, snappy.`,
 		Description: `
-The 'level' field might not apply to all algorithms.`,
+The 'level' field might not apply to all algorithms. For zstd the level is
+mapped to the closest speed of the encoder, where levels below 3 are the
+fastest and levels above 5 give the best compression. Brotli levels range from
+0 to 11, for lz4 a level above zero enables high compression, and snappy ignores
+the level.
+
+The lz4 algorithm writes the lz4 frame format, and the snappy algorithm writes
+the snappy block format.`,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("algorithm", "The compression algorithm to use.").HasOptions("gzip", "zlib", "flate"),
+			docs.FieldCommon("algorithm", "The compression algorithm to use.").HasOptions("gzip", "zlib", "flate", "zstd", "brotli", "lz4", "snappy"),
 			docs.FieldCommon("level", "The level of compression to use. May not be applicable to all algorithms."),
+			docs.FieldAdvanced("dictionary", "An optional path to a zstd dictionary file to compress messages with, only applicable to the zstd algorithm. Messages must be decompressed with the same dictionary."),
 			partsFieldSpec,
 		},
 	}
@@ -41,17 +55,19 @@ The 'level' field might not apply to all algorithms.`,
 
 // CompressConfig contains configuration fields for the Compress processor.
 type CompressConfig struct {
-	Algorithm string `json:"algorithm" yaml:"algorithm"`
-	Level     int    `json:"level" yaml:"level"`
-	Parts     []int  `json:"parts" yaml:"parts"`
+	Algorithm  string `json:"algorithm" yaml:"algorithm"`
+	Level      int    `json:"level" yaml:"level"`
+	Dictionary string `json:"dictionary" yaml:"dictionary"`
+	Parts      []int  `json:"parts" yaml:"parts"`
 }
 
 // NewCompressConfig returns a CompressConfig with default values.
 func NewCompressConfig() CompressConfig {
 	return CompressConfig{
-		Algorithm: "gzip",
-		Level:     gzip.DefaultCompression,
-		Parts:     []int{},
+		Algorithm:  "gzip",
+		Level:      gzip.DefaultCompression,
+		Dictionary: "",
+		Parts:      []int{},
 	}
 }
 
@@ -101,6 +117,82 @@ func flateCompress(level int, b []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// newZSTDCompressor returns a compressFunc backed by a single encoder, which is
+// reused for all messages rather than being created for each one. The level
+// argument of the returned function is ignored in favour of the level given
+// here.
+func newZSTDCompressor(level int, dict []byte) (compressFunc, error) {
+	zLevel := zstd.SpeedDefault
+	if level != gzip.DefaultCompression {
+		zLevel = zstd.EncoderLevelFromZstd(level)
+	}
+
+	opts := []zstd.EOption{zstd.WithEncoderLevel(zLevel), zstd.WithEncoderConcurrency(1)}
+	if len(dict) > 0 {
+		opts = append(opts, zstd.WithEncoderDict(dict))
+	}
+	zw, err := zstd.NewWriter(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return func(_ int, b []byte) ([]byte, error) {
+		return zw.EncodeAll(b, nil), nil
+	}, nil
+}
+
+var (
+	zstdDefaultCompressorOnce sync.Once
+	zstdDefaultCompressor     compressFunc
+	zstdDefaultCompressorErr  error
+)
+
+// zstdCompress compresses with a shared encoder of the default level.
+func zstdCompress(level int, b []byte) ([]byte, error) {
+	zstdDefaultCompressorOnce.Do(func() {
+		zstdDefaultCompressor, zstdDefaultCompressorErr = newZSTDCompressor(gzip.DefaultCompression, nil)
+	})
+	if zstdDefaultCompressorErr != nil {
+		return nil, zstdDefaultCompressorErr
+	}
+	return zstdDefaultCompressor(level, b)
+}
+
+func brotliCompress(level int, b []byte) ([]byte, error) {
+	if level == gzip.DefaultCompression {
+		level = brotli.DefaultCompression
+	}
+
+	buf := &bytes.Buffer{}
+	zw := brotli.NewWriterLevel(buf, level)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func lz4Compress(level int, b []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	zw := lz4.NewWriter(buf)
+	if level > 0 {
+		zw.Header.CompressionLevel = level
+	}
+
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func snappyCompress(level int, b []byte) ([]byte, error) {
+	return snappy.Encode(nil, b), nil
+}
+
 func strToCompressor(str string) (compressFunc, error) {
 	switch str {
 	case "gzip":
@@ -109,6 +201,14 @@ func strToCompressor(str string) (compressFunc, error) {
 		return zlibCompress, nil
 	case "flate":
 		return flateCompress, nil
+	case "zstd":
+		return zstdCompress, nil
+	case "brotli":
+		return brotliCompress, nil
+	case "lz4":
+		return lz4Compress, nil
+	case "snappy":
+		return snappyCompress, nil
 	}
 	return nil, fmt.Errorf("compression type not recognised: %v", str)
 }
@@ -138,6 +238,19 @@ func NewCompress(
 	if err != nil {
 		return nil, err
 	}
+	if conf.Compress.Algorithm == "zstd" {
+		var dict []byte
+		if conf.Compress.Dictionary != "" {
+			if dict, err = ioutil.ReadFile(conf.Compress.Dictionary); err != nil {
+				return nil, fmt.Errorf("failed to read dictionary: %v", err)
+			}
+		}
+		if cor, err = newZSTDCompressor(conf.Compress.Level, dict); err != nil {
+			return nil, fmt.Errorf("failed to parse dictionary: %v", err)
+		}
+	} else if conf.Compress.Dictionary != "" {
+		return nil, fmt.Errorf("dictionaries are not supported by the %v algorithm", conf.Compress.Algorithm)
+	}
 	return &Compress{
 		conf:  conf.Compress,
 		comp:  cor,
@@ -5,13 +5,18 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"compress/zlib"
+	"encoding/base64"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCompressBadAlgo(t *testing.T) {
@@ -243,3 +248,99 @@ func TestCompressEmpty(t *testing.T) {
 		t.Error("Expected failure with zero part message")
 	}
 }
+
+func TestCompressDecompressRoundTrip(t *testing.T) {
+	input := [][]byte{
+		[]byte("hello world first part"),
+		bytes.Repeat([]byte("hello world second part "), 100),
+		[]byte("5"),
+	}
+
+	for _, algo := range []string{"zstd", "brotli", "lz4", "snappy"} {
+		for _, level := range []int{gzip.DefaultCompression, 1, 3, 9} {
+			conf := NewConfig()
+			conf.Compress.Algorithm = algo
+			conf.Compress.Level = level
+			comp, err := NewCompress(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			conf.Decompress.Algorithm = algo
+			decomp, err := NewDecompress(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			msgs, res := comp.ProcessMessage(message.New(input))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			assert.NotEqual(t, input, message.GetAllBytes(msgs[0]), algo)
+
+			msgs, res = decomp.ProcessMessage(msgs[0])
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			assert.Equal(t, input, message.GetAllBytes(msgs[0]), "%v level %v", algo, level)
+		}
+	}
+}
+
+// testZSTDDictionary is a small dictionary trained with `zstd --train` on JSON
+// documents.
+const testZSTDDictionary = "" +
+	"N6Qw7GtBhAsxEKCr1AHDMAzDMAzDMAzDMAyTqqooiu8kAgAAABiTUkoppdyKAAAAAAAAAIARAABD" +
+	"AQMDAAAAAK06AAAABAAAgBJGQgYAAAAArqJwKgMAAAAAEAAAAAAAAAAAAABcdwAAAAAABO+rewAA" +
+	"AAAAAAAAAAAAAAAAAAEAAAAEAAAACAAAACJpZCI6MTIzLCJuYW1lIjoidXNlci0xMjMiLCJlbWFp" +
+	"bCI6InVzZXIxMjNAZXhhbXBsfQp7ImlkIjoxMSwibmFtZSI6InVzZXItMTEiLCJlbWFpbCI6InVz" +
+	"ZXIxMUBleGFtcGwiaWQiOjE3OSwibmFtZSI6Ig=="
+
+func TestCompressDictionary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_compress_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dict, err := base64.StdEncoding.DecodeString(testZSTDDictionary)
+	require.NoError(t, err)
+
+	dictPath := filepath.Join(dir, "test.dict")
+	require.NoError(t, ioutil.WriteFile(dictPath, dict, 0644))
+
+	input := [][]byte{
+		[]byte(`{"id":5,"name":"user-5","email":"user5@example.com","status":"active"}`),
+		[]byte("5"),
+	}
+
+	conf := NewConfig()
+	conf.Compress.Algorithm = "zstd"
+	conf.Compress.Dictionary = dictPath
+	comp, err := NewCompress(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := comp.ProcessMessage(message.New(input))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	compressed := msgs[0]
+
+	conf.Decompress.Algorithm = "zstd"
+	decomp, err := NewDecompress(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res = decomp.ProcessMessage(compressed)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+
+	conf.Decompress.Dictionary = dictPath
+	decomp, err = NewDecompress(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res = decomp.ProcessMessage(compressed)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, input, message.GetAllBytes(msgs[0]))
+
+	conf.Compress.Algorithm = "gzip"
+	_, err = NewCompress(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "dictionaries are not supported by the gzip algorithm")
+
+	conf.Compress.Algorithm = "zstd"
+	conf.Compress.Dictionary = filepath.Join(dir, "nope.dict")
+	_, err = NewCompress(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "failed to read dictionary: open "+filepath.Join(dir, "nope.dict")+": no such file or directory")
+}
@@ -84,202 +84,265 @@ func Block(typeStr, reason string) {
 
 // String constants representing each processor type.
 const (
-	TypeArchive      = "archive"
-	TypeAvro         = "avro"
-	TypeAWK          = "awk"
-	TypeBatch        = "batch"
-	TypeBloblang     = "bloblang"
-	TypeBoundsCheck  = "bounds_check"
-	TypeBranch       = "branch"
-	TypeCache        = "cache"
-	TypeCatch        = "catch"
-	TypeCompress     = "compress"
-	TypeConditional  = "conditional"
-	TypeDecode       = "decode"
-	TypeDecompress   = "decompress"
-	TypeDedupe       = "dedupe"
-	TypeEncode       = "encode"
-	TypeFilter       = "filter"
-	TypeFilterParts  = "filter_parts"
-	TypeForEach      = "for_each"
-	TypeGrok         = "grok"
-	TypeGroupBy      = "group_by"
-	TypeGroupByValue = "group_by_value"
-	TypeHash         = "hash"
-	TypeHashSample   = "hash_sample"
-	TypeHTTP         = "http"
-	TypeInsertPart   = "insert_part"
-	TypeJMESPath     = "jmespath"
-	TypeJQ           = "jq"
-	TypeJSON         = "json"
-	TypeJSONSchema   = "json_schema"
-	TypeLambda       = "lambda"
-	TypeLog          = "log"
-	TypeMergeJSON    = "merge_json"
-	TypeMetadata     = "metadata"
-	TypeMetric       = "metric"
-	TypeNoop         = "noop"
-	TypeNumber       = "number"
-	TypeParallel     = "parallel"
-	TypeParseLog     = "parse_log"
-	TypeProcessBatch = "process_batch"
-	TypeProcessDAG   = "process_dag"
-	TypeProcessField = "process_field"
-	TypeProcessMap   = "process_map"
-	TypeProtobuf     = "protobuf"
-	TypeRateLimit    = "rate_limit"
-	TypeRedis        = "redis"
-	TypeResource     = "resource"
-	TypeSample       = "sample"
-	TypeSelectParts  = "select_parts"
-	TypeSleep        = "sleep"
-	TypeSplit        = "split"
-	TypeSQL          = "sql"
-	TypeSubprocess   = "subprocess"
-	TypeSwitch       = "switch"
-	TypeSyncResponse = "sync_response"
-	TypeText         = "text"
-	TypeTry          = "try"
-	TypeThrottle     = "throttle"
-	TypeUnarchive    = "unarchive"
-	TypeWhile        = "while"
-	TypeWorkflow     = "workflow"
-	TypeXML          = "xml"
+	TypeArchive              = "archive"
+	TypeAvro                 = "avro"
+	TypeAWK                  = "awk"
+	TypeBatch                = "batch"
+	TypeBloblang             = "bloblang"
+	TypeBoundsCheck          = "bounds_check"
+	TypeBranch               = "branch"
+	TypeCached               = "cached"
+	TypeBSON                 = "bson"
+	TypeCache                = "cache"
+	TypeCBOR                 = "cbor"
+	TypeCatch                = "catch"
+	TypeCompress             = "compress"
+	TypeConditional          = "conditional"
+	TypeDecode               = "decode"
+	TypeDecompress           = "decompress"
+	TypeDecryptFields        = "decrypt_fields"
+	TypeDedupe               = "dedupe"
+	TypeEncode               = "encode"
+	TypeEncryptFields        = "encrypt_fields"
+	TypeFilter               = "filter"
+	TypeFilterParts          = "filter_parts"
+	TypeForEach              = "for_each"
+	TypeGeoIP                = "geoip"
+	TypeGrok                 = "grok"
+	TypeGroupBy              = "group_by"
+	TypeGroupByValue         = "group_by_value"
+	TypeHash                 = "hash"
+	TypeHashSample           = "hash_sample"
+	TypeHTTP                 = "http"
+	TypeInsertPart           = "insert_part"
+	TypeJavaScript           = "javascript"
+	TypeJMESPath             = "jmespath"
+	TypeJQ                   = "jq"
+	TypeJSON                 = "json"
+	TypeJSONSchema           = "json_schema"
+	TypeJWTSign              = "jwt_sign"
+	TypeJWTVerify            = "jwt_verify"
+	TypeLambda               = "lambda"
+	TypeLog                  = "log"
+	TypeMergeJSON            = "merge_json"
+	TypeMetadata             = "metadata"
+	TypeMetric               = "metric"
+	TypeMsgPack              = "msgpack"
+	TypeNoop                 = "noop"
+	TypeNumber               = "number"
+	TypeOpenAPI              = "openapi"
+	TypeParallel             = "parallel"
+	TypeParquet              = "parquet"
+	TypeParseCSV             = "parse_csv"
+	TypeParseLog             = "parse_log"
+	TypeProcessBatch         = "process_batch"
+	TypeProcessDAG           = "process_dag"
+	TypeProcessField         = "process_field"
+	TypeProcessMap           = "process_map"
+	TypeProtobuf             = "protobuf"
+	TypeRateLimit            = "rate_limit"
+	TypeRateLimitSpillover   = "rate_limit_spillover"
+	TypeRedact               = "redact"
+	TypeRedis                = "redis"
+	TypeResource             = "resource"
+	TypeRetry                = "retry"
+	TypeSample               = "sample"
+	TypeSchemaRegistryDecode = "schema_registry_decode"
+	TypeSchemaRegistryEncode = "schema_registry_encode"
+	TypeSelectParts          = "select_parts"
+	TypeSleep                = "sleep"
+	TypeSplit                = "split"
+	TypeSQL                  = "sql"
+	TypeSQLSelect            = "sql_select"
+	TypeSubprocess           = "subprocess"
+	TypeSwitch               = "switch"
+	TypeSyncResponse         = "sync_response"
+	TypeText                 = "text"
+	TypeTry                  = "try"
+	TypeThrottle             = "throttle"
+	TypeUnarchive            = "unarchive"
+	TypeWASM                 = "wasm"
+	TypeWhile                = "while"
+	TypeWindow               = "window"
+	TypeWorkflow             = "workflow"
+	TypeXML                  = "xml"
 )
 
 //------------------------------------------------------------------------------
 
 // Config is the all encompassing configuration struct for all processor types.
 type Config struct {
-	Type         string             `json:"type" yaml:"type"`
-	Archive      ArchiveConfig      `json:"archive" yaml:"archive"`
-	Avro         AvroConfig         `json:"avro" yaml:"avro"`
-	AWK          AWKConfig          `json:"awk" yaml:"awk"`
-	Batch        BatchConfig        `json:"batch" yaml:"batch"`
-	Bloblang     BloblangConfig     `json:"bloblang" yaml:"bloblang"`
-	BoundsCheck  BoundsCheckConfig  `json:"bounds_check" yaml:"bounds_check"`
-	Branch       BranchConfig       `json:"branch" yaml:"branch"`
-	Cache        CacheConfig        `json:"cache" yaml:"cache"`
-	Catch        CatchConfig        `json:"catch" yaml:"catch"`
-	Compress     CompressConfig     `json:"compress" yaml:"compress"`
-	Conditional  ConditionalConfig  `json:"conditional" yaml:"conditional"`
-	Decode       DecodeConfig       `json:"decode" yaml:"decode"`
-	Decompress   DecompressConfig   `json:"decompress" yaml:"decompress"`
-	Dedupe       DedupeConfig       `json:"dedupe" yaml:"dedupe"`
-	Encode       EncodeConfig       `json:"encode" yaml:"encode"`
-	Filter       FilterConfig       `json:"filter" yaml:"filter"`
-	FilterParts  FilterPartsConfig  `json:"filter_parts" yaml:"filter_parts"`
-	ForEach      ForEachConfig      `json:"for_each" yaml:"for_each"`
-	Grok         GrokConfig         `json:"grok" yaml:"grok"`
-	GroupBy      GroupByConfig      `json:"group_by" yaml:"group_by"`
-	GroupByValue GroupByValueConfig `json:"group_by_value" yaml:"group_by_value"`
-	Hash         HashConfig         `json:"hash" yaml:"hash"`
-	HashSample   HashSampleConfig   `json:"hash_sample" yaml:"hash_sample"`
-	HTTP         HTTPConfig         `json:"http" yaml:"http"`
-	InsertPart   InsertPartConfig   `json:"insert_part" yaml:"insert_part"`
-	JMESPath     JMESPathConfig     `json:"jmespath" yaml:"jmespath"`
-	JQ           JQConfig           `json:"jq" yaml:"jq"`
-	JSON         JSONConfig         `json:"json" yaml:"json"`
-	JSONSchema   JSONSchemaConfig   `json:"json_schema" yaml:"json_schema"`
-	Lambda       LambdaConfig       `json:"lambda" yaml:"lambda"`
-	Log          LogConfig          `json:"log" yaml:"log"`
-	MergeJSON    MergeJSONConfig    `json:"merge_json" yaml:"merge_json"`
-	Metadata     MetadataConfig     `json:"metadata" yaml:"metadata"`
-	Metric       MetricConfig       `json:"metric" yaml:"metric"`
-	Number       NumberConfig       `json:"number" yaml:"number"`
-	Plugin       interface{}        `json:"plugin,omitempty" yaml:"plugin,omitempty"`
-	Parallel     ParallelConfig     `json:"parallel" yaml:"parallel"`
-	ParseLog     ParseLogConfig     `json:"parse_log" yaml:"parse_log"`
-	ProcessBatch ForEachConfig      `json:"process_batch" yaml:"process_batch"`
-	ProcessDAG   ProcessDAGConfig   `json:"process_dag" yaml:"process_dag"`
-	ProcessField ProcessFieldConfig `json:"process_field" yaml:"process_field"`
-	ProcessMap   ProcessMapConfig   `json:"process_map" yaml:"process_map"`
-	Protobuf     ProtobufConfig     `json:"protobuf" yaml:"protobuf"`
-	RateLimit    RateLimitConfig    `json:"rate_limit" yaml:"rate_limit"`
-	Redis        RedisConfig        `json:"redis" yaml:"redis"`
-	Resource     string             `json:"resource" yaml:"resource"`
-	Sample       SampleConfig       `json:"sample" yaml:"sample"`
-	SelectParts  SelectPartsConfig  `json:"select_parts" yaml:"select_parts"`
-	Sleep        SleepConfig        `json:"sleep" yaml:"sleep"`
-	Split        SplitConfig        `json:"split" yaml:"split"`
-	SQL          SQLConfig          `json:"sql" yaml:"sql"`
-	Subprocess   SubprocessConfig   `json:"subprocess" yaml:"subprocess"`
-	Switch       SwitchConfig       `json:"switch" yaml:"switch"`
-	SyncResponse SyncResponseConfig `json:"sync_response" yaml:"sync_response"`
-	Text         TextConfig         `json:"text" yaml:"text"`
-	Try          TryConfig          `json:"try" yaml:"try"`
-	Throttle     ThrottleConfig     `json:"throttle" yaml:"throttle"`
-	Unarchive    UnarchiveConfig    `json:"unarchive" yaml:"unarchive"`
-	While        WhileConfig        `json:"while" yaml:"while"`
-	Workflow     WorkflowConfig     `json:"workflow" yaml:"workflow"`
-	XML          XMLConfig          `json:"xml" yaml:"xml"`
+	Type                 string                     `json:"type" yaml:"type"`
+	Archive              ArchiveConfig              `json:"archive" yaml:"archive"`
+	Avro                 AvroConfig                 `json:"avro" yaml:"avro"`
+	AWK                  AWKConfig                  `json:"awk" yaml:"awk"`
+	Batch                BatchConfig                `json:"batch" yaml:"batch"`
+	Bloblang             BloblangConfig             `json:"bloblang" yaml:"bloblang"`
+	BoundsCheck          BoundsCheckConfig          `json:"bounds_check" yaml:"bounds_check"`
+	Branch               BranchConfig               `json:"branch" yaml:"branch"`
+	Cached               CachedConfig               `json:"cached" yaml:"cached"`
+	BSON                 BSONConfig                 `json:"bson" yaml:"bson"`
+	Cache                CacheConfig                `json:"cache" yaml:"cache"`
+	Catch                CatchConfig                `json:"catch" yaml:"catch"`
+	CBOR                 CBORConfig                 `json:"cbor" yaml:"cbor"`
+	Compress             CompressConfig             `json:"compress" yaml:"compress"`
+	Conditional          ConditionalConfig          `json:"conditional" yaml:"conditional"`
+	Decode               DecodeConfig               `json:"decode" yaml:"decode"`
+	Decompress           DecompressConfig           `json:"decompress" yaml:"decompress"`
+	DecryptFields        DecryptFieldsConfig        `json:"decrypt_fields" yaml:"decrypt_fields"`
+	Dedupe               DedupeConfig               `json:"dedupe" yaml:"dedupe"`
+	Encode               EncodeConfig               `json:"encode" yaml:"encode"`
+	EncryptFields        EncryptFieldsConfig        `json:"encrypt_fields" yaml:"encrypt_fields"`
+	Filter               FilterConfig               `json:"filter" yaml:"filter"`
+	FilterParts          FilterPartsConfig          `json:"filter_parts" yaml:"filter_parts"`
+	ForEach              ForEachConfig              `json:"for_each" yaml:"for_each"`
+	GeoIP                GeoIPConfig                `json:"geoip" yaml:"geoip"`
+	Grok                 GrokConfig                 `json:"grok" yaml:"grok"`
+	GroupBy              GroupByConfig              `json:"group_by" yaml:"group_by"`
+	GroupByValue         GroupByValueConfig         `json:"group_by_value" yaml:"group_by_value"`
+	Hash                 HashConfig                 `json:"hash" yaml:"hash"`
+	HashSample           HashSampleConfig           `json:"hash_sample" yaml:"hash_sample"`
+	HTTP                 HTTPConfig                 `json:"http" yaml:"http"`
+	InsertPart           InsertPartConfig           `json:"insert_part" yaml:"insert_part"`
+	JavaScript           JavaScriptConfig           `json:"javascript" yaml:"javascript"`
+	JMESPath             JMESPathConfig             `json:"jmespath" yaml:"jmespath"`
+	JQ                   JQConfig                   `json:"jq" yaml:"jq"`
+	JSON                 JSONConfig                 `json:"json" yaml:"json"`
+	JSONSchema           JSONSchemaConfig           `json:"json_schema" yaml:"json_schema"`
+	JWTSign              JWTSignConfig              `json:"jwt_sign" yaml:"jwt_sign"`
+	JWTVerify            JWTVerifyConfig            `json:"jwt_verify" yaml:"jwt_verify"`
+	Lambda               LambdaConfig               `json:"lambda" yaml:"lambda"`
+	Log                  LogConfig                  `json:"log" yaml:"log"`
+	MergeJSON            MergeJSONConfig            `json:"merge_json" yaml:"merge_json"`
+	Metadata             MetadataConfig             `json:"metadata" yaml:"metadata"`
+	Metric               MetricConfig               `json:"metric" yaml:"metric"`
+	MsgPack              MsgPackConfig              `json:"msgpack" yaml:"msgpack"`
+	Number               NumberConfig               `json:"number" yaml:"number"`
+	Plugin               interface{}                `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	OpenAPI              OpenAPIConfig              `json:"openapi" yaml:"openapi"`
+	Parallel             ParallelConfig             `json:"parallel" yaml:"parallel"`
+	Parquet              ParquetConfig              `json:"parquet" yaml:"parquet"`
+	ParseCSV             ParseCSVConfig             `json:"parse_csv" yaml:"parse_csv"`
+	ParseLog             ParseLogConfig             `json:"parse_log" yaml:"parse_log"`
+	ProcessBatch         ForEachConfig              `json:"process_batch" yaml:"process_batch"`
+	ProcessDAG           ProcessDAGConfig           `json:"process_dag" yaml:"process_dag"`
+	ProcessField         ProcessFieldConfig         `json:"process_field" yaml:"process_field"`
+	ProcessMap           ProcessMapConfig           `json:"process_map" yaml:"process_map"`
+	Protobuf             ProtobufConfig             `json:"protobuf" yaml:"protobuf"`
+	RateLimit            RateLimitConfig            `json:"rate_limit" yaml:"rate_limit"`
+	RateLimitSpillover   RateLimitSpilloverConfig   `json:"rate_limit_spillover" yaml:"rate_limit_spillover"`
+	Redact               RedactConfig               `json:"redact" yaml:"redact"`
+	Redis                RedisConfig                `json:"redis" yaml:"redis"`
+	Resource             string                     `json:"resource" yaml:"resource"`
+	Retry                RetryConfig                `json:"retry" yaml:"retry"`
+	Sample               SampleConfig               `json:"sample" yaml:"sample"`
+	SchemaRegistryDecode SchemaRegistryDecodeConfig `json:"schema_registry_decode" yaml:"schema_registry_decode"`
+	SchemaRegistryEncode SchemaRegistryEncodeConfig `json:"schema_registry_encode" yaml:"schema_registry_encode"`
+	SelectParts          SelectPartsConfig          `json:"select_parts" yaml:"select_parts"`
+	Sleep                SleepConfig                `json:"sleep" yaml:"sleep"`
+	Split                SplitConfig                `json:"split" yaml:"split"`
+	SQL                  SQLConfig                  `json:"sql" yaml:"sql"`
+	SQLSelect            SQLSelectConfig            `json:"sql_select" yaml:"sql_select"`
+	Subprocess           SubprocessConfig           `json:"subprocess" yaml:"subprocess"`
+	Switch               SwitchConfig               `json:"switch" yaml:"switch"`
+	SyncResponse         SyncResponseConfig         `json:"sync_response" yaml:"sync_response"`
+	Text                 TextConfig                 `json:"text" yaml:"text"`
+	Try                  TryConfig                  `json:"try" yaml:"try"`
+	Throttle             ThrottleConfig             `json:"throttle" yaml:"throttle"`
+	Unarchive            UnarchiveConfig            `json:"unarchive" yaml:"unarchive"`
+	WASM                 WASMConfig                 `json:"wasm" yaml:"wasm"`
+	While                WhileConfig                `json:"while" yaml:"while"`
+	Window               WindowConfig               `json:"window" yaml:"window"`
+	Workflow             WorkflowConfig             `json:"workflow" yaml:"workflow"`
+	XML                  XMLConfig                  `json:"xml" yaml:"xml"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Type:         "bounds_check",
-		Archive:      NewArchiveConfig(),
-		Avro:         NewAvroConfig(),
-		AWK:          NewAWKConfig(),
-		Batch:        NewBatchConfig(),
-		Bloblang:     NewBloblangConfig(),
-		BoundsCheck:  NewBoundsCheckConfig(),
-		Branch:       NewBranchConfig(),
-		Cache:        NewCacheConfig(),
-		Catch:        NewCatchConfig(),
-		Compress:     NewCompressConfig(),
-		Conditional:  NewConditionalConfig(),
-		Decode:       NewDecodeConfig(),
-		Decompress:   NewDecompressConfig(),
-		Dedupe:       NewDedupeConfig(),
-		Encode:       NewEncodeConfig(),
-		Filter:       NewFilterConfig(),
-		FilterParts:  NewFilterPartsConfig(),
-		ForEach:      NewForEachConfig(),
-		Grok:         NewGrokConfig(),
-		GroupBy:      NewGroupByConfig(),
-		GroupByValue: NewGroupByValueConfig(),
-		Hash:         NewHashConfig(),
-		HashSample:   NewHashSampleConfig(),
-		HTTP:         NewHTTPConfig(),
-		InsertPart:   NewInsertPartConfig(),
-		JMESPath:     NewJMESPathConfig(),
-		JQ:           NewJQConfig(),
-		JSON:         NewJSONConfig(),
-		JSONSchema:   NewJSONSchemaConfig(),
-		Lambda:       NewLambdaConfig(),
-		Log:          NewLogConfig(),
-		MergeJSON:    NewMergeJSONConfig(),
-		Metadata:     NewMetadataConfig(),
-		Metric:       NewMetricConfig(),
-		Number:       NewNumberConfig(),
-		Plugin:       nil,
-		Parallel:     NewParallelConfig(),
-		ParseLog:     NewParseLogConfig(),
-		ProcessBatch: NewForEachConfig(),
-		ProcessDAG:   NewProcessDAGConfig(),
-		ProcessField: NewProcessFieldConfig(),
-		ProcessMap:   NewProcessMapConfig(),
-		Protobuf:     NewProtobufConfig(),
-		RateLimit:    NewRateLimitConfig(),
-		Redis:        NewRedisConfig(),
-		Resource:     "",
-		Sample:       NewSampleConfig(),
-		SelectParts:  NewSelectPartsConfig(),
-		Sleep:        NewSleepConfig(),
-		Split:        NewSplitConfig(),
-		SQL:          NewSQLConfig(),
-		Subprocess:   NewSubprocessConfig(),
-		Switch:       NewSwitchConfig(),
-		SyncResponse: NewSyncResponseConfig(),
-		Text:         NewTextConfig(),
-		Try:          NewTryConfig(),
-		Throttle:     NewThrottleConfig(),
-		Unarchive:    NewUnarchiveConfig(),
-		While:        NewWhileConfig(),
-		Workflow:     NewWorkflowConfig(),
-		XML:          NewXMLConfig(),
+		Type:                 "bounds_check",
+		Archive:              NewArchiveConfig(),
+		Avro:                 NewAvroConfig(),
+		AWK:                  NewAWKConfig(),
+		Batch:                NewBatchConfig(),
+		Bloblang:             NewBloblangConfig(),
+		BoundsCheck:          NewBoundsCheckConfig(),
+		Branch:               NewBranchConfig(),
+		Cached:               NewCachedConfig(),
+		BSON:                 NewBSONConfig(),
+		Cache:                NewCacheConfig(),
+		Catch:                NewCatchConfig(),
+		CBOR:                 NewCBORConfig(),
+		Compress:             NewCompressConfig(),
+		Conditional:          NewConditionalConfig(),
+		Decode:               NewDecodeConfig(),
+		Decompress:           NewDecompressConfig(),
+		DecryptFields:        NewDecryptFieldsConfig(),
+		Dedupe:               NewDedupeConfig(),
+		Encode:               NewEncodeConfig(),
+		EncryptFields:        NewEncryptFieldsConfig(),
+		Filter:               NewFilterConfig(),
+		FilterParts:          NewFilterPartsConfig(),
+		ForEach:              NewForEachConfig(),
+		GeoIP:                NewGeoIPConfig(),
+		Grok:                 NewGrokConfig(),
+		GroupBy:              NewGroupByConfig(),
+		GroupByValue:         NewGroupByValueConfig(),
+		Hash:                 NewHashConfig(),
+		HashSample:           NewHashSampleConfig(),
+		HTTP:                 NewHTTPConfig(),
+		InsertPart:           NewInsertPartConfig(),
+		JavaScript:           NewJavaScriptConfig(),
+		JMESPath:             NewJMESPathConfig(),
+		JQ:                   NewJQConfig(),
+		JSON:                 NewJSONConfig(),
+		JSONSchema:           NewJSONSchemaConfig(),
+		JWTSign:              NewJWTSignConfig(),
+		JWTVerify:            NewJWTVerifyConfig(),
+		Lambda:               NewLambdaConfig(),
+		Log:                  NewLogConfig(),
+		MergeJSON:            NewMergeJSONConfig(),
+		Metadata:             NewMetadataConfig(),
+		Metric:               NewMetricConfig(),
+		MsgPack:              NewMsgPackConfig(),
+		Number:               NewNumberConfig(),
+		Plugin:               nil,
+		OpenAPI:              NewOpenAPIConfig(),
+		Parallel:             NewParallelConfig(),
+		Parquet:              NewParquetConfig(),
+		ParseCSV:             NewParseCSVConfig(),
+		ParseLog:             NewParseLogConfig(),
+		ProcessBatch:         NewForEachConfig(),
+		ProcessDAG:           NewProcessDAGConfig(),
+		ProcessField:         NewProcessFieldConfig(),
+		ProcessMap:           NewProcessMapConfig(),
+		Protobuf:             NewProtobufConfig(),
+		RateLimit:            NewRateLimitConfig(),
+		RateLimitSpillover:   NewRateLimitSpilloverConfig(),
+		Redact:               NewRedactConfig(),
+		Redis:                NewRedisConfig(),
+		Resource:             "",
+		Retry:                NewRetryConfig(),
+		Sample:               NewSampleConfig(),
+		SchemaRegistryDecode: NewSchemaRegistryDecodeConfig(),
+		SchemaRegistryEncode: NewSchemaRegistryEncodeConfig(),
+		SelectParts:          NewSelectPartsConfig(),
+		Sleep:                NewSleepConfig(),
+		Split:                NewSplitConfig(),
+		SQL:                  NewSQLConfig(),
+		SQLSelect:            NewSQLSelectConfig(),
+		Subprocess:           NewSubprocessConfig(),
+		Switch:               NewSwitchConfig(),
+		SyncResponse:         NewSyncResponseConfig(),
+		Text:                 NewTextConfig(),
+		Try:                  NewTryConfig(),
+		Throttle:             NewThrottleConfig(),
+		Unarchive:            NewUnarchiveConfig(),
+		WASM:                 NewWASMConfig(),
+		While:                NewWhileConfig(),
+		Window:               NewWindowConfig(),
+		Workflow:             NewWorkflowConfig(),
+		XML:                  NewXMLConfig(),
 	}
 }
 
@@ -8,6 +8,7 @@ import (
 	"compress/zlib"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -15,7 +16,11 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/andybalholm/brotli"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/opentracing/opentracing-go"
+	"github.com/pierrec/lz4"
 )
 
 //------------------------------------------------------------------------------
@@ -28,9 +33,13 @@ func init() {
 		},
 		Summary: `
 Decompresses messages according to the selected algorithm. Supported
-decompression types are: gzip, zlib, bzip2, flate.`,
+decompression types are: gzip, zlib, bzip2, flate, zstd, brotli, lz4, snappy.`,
+		Description: `
+The lz4 algorithm reads the lz4 frame format, and the snappy algorithm reads
+the snappy block format.`,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("algorithm", "The decompression algorithm to use.").HasOptions("gzip", "zlib", "bzip2", "flate"),
+			docs.FieldCommon("algorithm", "The decompression algorithm to use.").HasOptions("gzip", "zlib", "bzip2", "flate", "zstd", "brotli", "lz4", "snappy"),
+			docs.FieldAdvanced("dictionary", "An optional path to a zstd dictionary file that messages were compressed with, only applicable to the zstd algorithm."),
 			partsFieldSpec,
 		},
 	}
@@ -40,15 +49,17 @@ decompression types are: gzip, zlib, bzip2, flate.`,
 
 // DecompressConfig contains configuration fields for the Decompress processor.
 type DecompressConfig struct {
-	Algorithm string `json:"algorithm" yaml:"algorithm"`
-	Parts     []int  `json:"parts" yaml:"parts"`
+	Algorithm  string `json:"algorithm" yaml:"algorithm"`
+	Dictionary string `json:"dictionary" yaml:"dictionary"`
+	Parts      []int  `json:"parts" yaml:"parts"`
 }
 
 // NewDecompressConfig returns a DecompressConfig with default values.
 func NewDecompressConfig() DecompressConfig {
 	return DecompressConfig{
-		Algorithm: "gzip",
-		Parts:     []int{},
+		Algorithm:  "gzip",
+		Dictionary: "",
+		Parts:      []int{},
 	}
 }
 
@@ -109,6 +120,46 @@ func bzip2Decompress(b []byte) ([]byte, error) {
 	return outBuf.Bytes(), nil
 }
 
+func newZSTDDecompressor(dicts ...[]byte) decompressFunc {
+	return func(b []byte) ([]byte, error) {
+		opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+		if len(dicts) > 0 {
+			opts = append(opts, zstd.WithDecoderDicts(dicts...))
+		}
+		zr, err := zstd.NewReader(nil, opts...)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return zr.DecodeAll(b, nil)
+	}
+}
+
+func brotliDecompress(b []byte) ([]byte, error) {
+	zr := brotli.NewReader(bytes.NewReader(b))
+
+	outBuf := bytes.Buffer{}
+	if _, err := outBuf.ReadFrom(zr); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return outBuf.Bytes(), nil
+}
+
+func lz4Decompress(b []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(b)
+	zr := lz4.NewReader(buf)
+
+	outBuf := bytes.Buffer{}
+	if _, err := outBuf.ReadFrom(zr); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return outBuf.Bytes(), nil
+}
+
+func snappyDecompress(b []byte) ([]byte, error) {
+	return snappy.Decode(nil, b)
+}
+
 func strToDecompressor(str string) (decompressFunc, error) {
 	switch str {
 	case "gzip":
@@ -119,6 +170,14 @@ func strToDecompressor(str string) (decompressFunc, error) {
 		return flateDecompress, nil
 	case "bzip2":
 		return bzip2Decompress, nil
+	case "zstd":
+		return newZSTDDecompressor(), nil
+	case "brotli":
+		return brotliDecompress, nil
+	case "lz4":
+		return lz4Decompress, nil
+	case "snappy":
+		return snappyDecompress, nil
 	}
 	return nil, fmt.Errorf("decompression type not recognised: %v", str)
 }
@@ -148,6 +207,21 @@ func NewDecompress(
 	if err != nil {
 		return nil, err
 	}
+	if conf.Decompress.Dictionary != "" {
+		if conf.Decompress.Algorithm != "zstd" {
+			return nil, fmt.Errorf("dictionaries are not supported by the %v algorithm", conf.Decompress.Algorithm)
+		}
+		dict, err := ioutil.ReadFile(conf.Decompress.Dictionary)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dictionary: %v", err)
+		}
+		zr, err := zstd.NewReader(nil, zstd.WithDecoderDicts(dict))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse dictionary: %v", err)
+		}
+		zr.Close()
+		dcor = newZSTDDecompressor(dict)
+	}
 	return &Decompress{
 		conf:   conf.Decompress,
 		decomp: dcor,
@@ -5,13 +5,17 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"compress/zlib"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDecompressBadAlgo(t *testing.T) {
@@ -265,3 +269,45 @@ func TestDecompressIndexBounds(t *testing.T) {
 		}
 	}
 }
+
+func TestDecompressDictionary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_decompress_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	badDict := filepath.Join(dir, "bad.dict")
+	require.NoError(t, ioutil.WriteFile(badDict, []byte("not a dictionary"), 0644))
+
+	tests := map[string]struct {
+		algo string
+		dict string
+		err  string
+	}{
+		"wrong algorithm": {
+			algo: "gzip",
+			dict: badDict,
+			err:  "dictionaries are not supported by the gzip algorithm",
+		},
+		"missing file": {
+			algo: "zstd",
+			dict: filepath.Join(dir, "nope.dict"),
+			err:  "failed to read dictionary: open " + filepath.Join(dir, "nope.dict") + ": no such file or directory",
+		},
+	}
+
+	for name, test := range tests {
+		conf := NewConfig()
+		conf.Decompress.Algorithm = test.algo
+		conf.Decompress.Dictionary = test.dict
+
+		_, err := NewDecompress(conf, nil, log.Noop(), metrics.Noop())
+		assert.EqualError(t, err, test.err, name)
+	}
+
+	conf := NewConfig()
+	conf.Decompress.Algorithm = "zstd"
+	conf.Decompress.Dictionary = badDict
+	_, err = NewDecompress(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse dictionary: ")
+}
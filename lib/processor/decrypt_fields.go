@@ -0,0 +1,213 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/gabs/v2"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeDecryptFields] = TypeSpec{
+		constructor: NewDecryptFields,
+		Categories: []Category{
+			CategoryMapping,
+		},
+		Summary: `
+Decrypts fields of JSON documents that were encrypted with the
+` + "[`encrypt_fields`](/docs/components/processors/encrypt_fields)" + ` processor.`,
+		Beta: true,
+		Description: `
+Each field is decrypted and replaced with its original value. Fields that don't
+exist within a message are skipped, and messages where a field is not an
+encrypted value or fails to decrypt are flagged as failed.
+` + fieldKeyDescription + `
+
+Unwrapped data keys are kept in memory, and therefore the key management
+service is only called once for each data key.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("fields", "A list of [dot paths](/docs/configuration/field_paths) of the fields to decrypt.", []string{"user.email", "user.address"}),
+			fieldKeyFieldSpec(),
+			partsFieldSpec,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "AWS KMS",
+				Summary: `
+Here the email address of users is decrypted with data keys that were wrapped by
+an AWS KMS key:`,
+				Config: `
+pipeline:
+  processors:
+    - decrypt_fields:
+        fields: [ user.email ]
+        key:
+          provider: aws_kms
+          aws_kms:
+            key_id: alias/pii
+            region: eu-west-1
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// DecryptFieldsConfig contains configuration fields for the DecryptFields
+// processor.
+type DecryptFieldsConfig struct {
+	Fields []string       `json:"fields" yaml:"fields"`
+	Key    FieldKeyConfig `json:"key" yaml:"key"`
+	Parts  []int          `json:"parts" yaml:"parts"`
+}
+
+// NewDecryptFieldsConfig returns a DecryptFieldsConfig with default values.
+func NewDecryptFieldsConfig() DecryptFieldsConfig {
+	return DecryptFieldsConfig{
+		Fields: []string{},
+		Key:    NewFieldKeyConfig(),
+		Parts:  []int{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// DecryptFields is a processor that decrypts fields of JSON documents.
+type DecryptFields struct {
+	parts   []int
+	fields  []string
+	paths   [][]string
+	keyring *fieldKeyring
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewDecryptFields returns a DecryptFields processor.
+func NewDecryptFields(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if len(conf.DecryptFields.Fields) == 0 {
+		return nil, errors.New("at least one field must be specified")
+	}
+
+	keyring, err := newFieldKeyring(conf.DecryptFields.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &DecryptFields{
+		parts:   conf.DecryptFields.Parts,
+		fields:  conf.DecryptFields.Fields,
+		keyring: keyring,
+
+		log: log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+	for _, f := range d.fields {
+		d.paths = append(d.paths, gabs.DotPathToSlice(f))
+	}
+	return d, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *DecryptFields) decryptField(field string, value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, errors.New("value is not encrypted")
+	}
+	wrapped, sealed, err := parseSealedField(str)
+	if err != nil {
+		return nil, err
+	}
+	key, err := d.keyring.Unwrap(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := openField(key, sealed, []byte(field))
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err = json.Unmarshal(plaintext, &v); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted value: %v", err)
+	}
+	return v, nil
+}
+
+func (d *DecryptFields) decrypt(part types.Part) error {
+	jsonPart, err := part.JSON()
+	if err == nil {
+		jsonPart, err = message.CopyJSON(jsonPart)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse part into json: %v", err)
+	}
+
+	gPart := gabs.Wrap(jsonPart)
+	for i, path := range d.paths {
+		if !gPart.Exists(path...) {
+			continue
+		}
+		v, err := d.decryptField(d.fields[i], gPart.Search(path...).Data())
+		if err != nil {
+			return fmt.Errorf("failed to decrypt field %v: %v", d.fields[i], err)
+		}
+		if _, err = gPart.Set(v, path...); err != nil {
+			return fmt.Errorf("failed to set field %v: %v", d.fields[i], err)
+		}
+	}
+	return part.SetJSON(gPart.Data())
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (d *DecryptFields) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	d.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	IteratePartsWithSpan(TypeDecryptFields, d.parts, newMsg, func(index int, span opentracing.Span, part types.Part) error {
+		if err := d.decrypt(part); err != nil {
+			d.mErr.Incr(1)
+			d.log.Debugf("Failed to decrypt fields: %v\n", err)
+			return err
+		}
+		return nil
+	})
+
+	d.mBatchSent.Incr(1)
+	d.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (d *DecryptFields) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (d *DecryptFields) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
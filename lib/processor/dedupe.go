@@ -2,12 +2,15 @@ package processor
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/bloblang"
 	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/cuckoo"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message/tracing"
@@ -53,6 +56,27 @@ dedupe:
 Caches should be configured as a resource, for more information check out the
 [documentation here](/docs/components/caches/about).
 
+## Time Windows
+
+Instead of a cache, messages can be deduplicated within a sliding window held
+in memory by setting ` + "`window.max_keys`" + ` and leaving ` + "`cache`" + `
+empty. The window holds the keys of at most ` + "`max_keys`" + ` of the most
+recent messages, and when ` + "`window.period`" + ` is set keys are also
+removed from the window once they are older than the period:
+
+` + "``` yaml" + `
+dedupe:
+  key: ${! json("id") }
+  window:
+    max_keys: 1000000
+    period: 1h
+` + "```" + `
+
+Keys are stored within a [cuckoo filter](https://www.cs.cmu.edu/~dga/papers/cuckoo-conext2014.pdf)
+which uses roughly 20 bytes per key, at the cost of roughly 0.012% of unique
+messages being mistaken for duplicates and dropped. The number of keys within
+the window is exposed with the metric ` + "`window.cardinality`" + `.
+
 When using this processor with an output target that might fail you should
 always wrap the output within a ` + "[`retry`](/docs/components/outputs/retry)" + `
 block. This ensures that during outages your messages aren't reprocessed after
@@ -71,10 +95,14 @@ problem by using a memory based cache. This is a compromise that can achieve
 effective deduplication but parallel deployments of the pipeline as well as
 service restarts increase the chances of duplicates passing undetected.`,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("cache", "The [`cache` resource](/docs/components/caches/about) to target with this processor."),
+			docs.FieldCommon("cache", "The [`cache` resource](/docs/components/caches/about) to target with this processor, which must be empty when a [time window](#time-windows) is used."),
 			docs.FieldCommon("hash", "The hash type to used.").HasOptions("none", "xxhash"),
 			docs.FieldCommon("key", "An optional key to use for deduplication (instead of the entire message contents).").SupportsInterpolation(true),
 			docs.FieldCommon("drop_on_err", "Whether messages should be dropped when the cache returns an error."),
+			docs.FieldAdvanced("window", "A sliding [time window](#time-windows) to deduplicate messages within instead of a cache.").WithChildren(
+				docs.FieldCommon("max_keys", "The maximum number of keys within the window, which must be greater than zero in order to use a window."),
+				docs.FieldCommon("period", "An optional period after which keys are removed from the window.", "10m", "1h"),
+			),
 			docs.FieldAdvanced("parts", "An array of message indexes within the batch to deduplicate based on. If left empty all messages included. This field is only applicable when batching messages [at the input level](/docs/configuration/batching)."),
 		},
 	}
@@ -82,13 +110,21 @@ service restarts increase the chances of duplicates passing undetected.`,
 
 //------------------------------------------------------------------------------
 
+// DedupeWindowConfig contains configuration fields for the sliding window of
+// the Dedupe processor.
+type DedupeWindowConfig struct {
+	MaxKeys int    `json:"max_keys" yaml:"max_keys"`
+	Period  string `json:"period" yaml:"period"`
+}
+
 // DedupeConfig contains configuration fields for the Dedupe processor.
 type DedupeConfig struct {
-	Cache          string `json:"cache" yaml:"cache"`
-	HashType       string `json:"hash" yaml:"hash"`
-	Parts          []int  `json:"parts" yaml:"parts"` // message parts to hash
-	Key            string `json:"key" yaml:"key"`
-	DropOnCacheErr bool   `json:"drop_on_err" yaml:"drop_on_err"`
+	Cache          string             `json:"cache" yaml:"cache"`
+	HashType       string             `json:"hash" yaml:"hash"`
+	Parts          []int              `json:"parts" yaml:"parts"` // message parts to hash
+	Key            string             `json:"key" yaml:"key"`
+	DropOnCacheErr bool               `json:"drop_on_err" yaml:"drop_on_err"`
+	Window         DedupeWindowConfig `json:"window" yaml:"window"`
 }
 
 // NewDedupeConfig returns a DedupeConfig with default values.
@@ -99,6 +135,10 @@ func NewDedupeConfig() DedupeConfig {
 		Parts:          []int{0}, // only consider the 1st part
 		Key:            "",
 		DropOnCacheErr: true,
+		Window: DedupeWindowConfig{
+			MaxKeys: 0,
+			Period:  "",
+		},
 	}
 }
 
@@ -145,6 +185,81 @@ func strToHasher(str string) (hasherFunc, error) {
 
 //------------------------------------------------------------------------------
 
+type dedupeWindowEntry struct {
+	hash  uint64
+	added time.Time
+}
+
+// dedupeWindow is a sliding window of the hashes of the most recent keys,
+// bounded by count and optionally by age.
+type dedupeWindow struct {
+	period time.Duration
+	filter *cuckoo.Filter
+
+	// A ring buffer of the hashes within the filter in the order they were
+	// added.
+	entries []dedupeWindowEntry
+	head    int
+	size    int
+
+	now func() time.Time
+	mut sync.Mutex
+}
+
+func newDedupeWindow(conf DedupeWindowConfig) (*dedupeWindow, error) {
+	w := &dedupeWindow{
+		filter:  cuckoo.New(conf.MaxKeys),
+		entries: make([]dedupeWindowEntry, conf.MaxKeys),
+		now:     time.Now,
+	}
+	if conf.Period != "" {
+		var err error
+		if w.period, err = time.ParseDuration(conf.Period); err != nil {
+			return nil, fmt.Errorf("failed to parse window period: %v", err)
+		}
+	}
+	return w, nil
+}
+
+func (w *dedupeWindow) evictOldest() {
+	w.filter.Delete(w.entries[w.head].hash)
+	w.head = (w.head + 1) % len(w.entries)
+	w.size--
+}
+
+// add adds a hash to the window, returning false if it already exists, along
+// with the number of hashes within the window.
+func (w *dedupeWindow) add(hash uint64) (bool, int) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	now := w.now()
+	if w.period > 0 {
+		for w.size > 0 && now.Sub(w.entries[w.head].added) >= w.period {
+			w.evictOldest()
+		}
+	}
+
+	if w.filter.Lookup(hash) {
+		return false, w.size
+	}
+
+	if w.size == len(w.entries) {
+		w.evictOldest()
+	}
+	for !w.filter.Insert(hash) && w.size > 0 {
+		w.evictOldest()
+	}
+	w.entries[(w.head+w.size)%len(w.entries)] = dedupeWindowEntry{
+		hash:  hash,
+		added: now,
+	}
+	w.size++
+	return true, w.size
+}
+
+//------------------------------------------------------------------------------
+
 // Dedupe is a processor that deduplicates messages either by hashing the full
 // contents of message parts or by hashing the value of an interpolated string.
 type Dedupe struct {
@@ -155,6 +270,7 @@ type Dedupe struct {
 	key field.Expression
 
 	cache      types.Cache
+	window     *dedupeWindow
 	hasherFunc hasherFunc
 
 	mCount     metrics.StatCounter
@@ -164,14 +280,24 @@ type Dedupe struct {
 	mDropped   metrics.StatCounter
 	mSent      metrics.StatCounter
 	mBatchSent metrics.StatCounter
+	mWindow    metrics.StatGauge
 }
 
 // NewDedupe returns a Dedupe processor.
 func NewDedupe(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
-	c, err := mgr.GetCache(conf.Dedupe.Cache)
-	if err != nil {
+	var c types.Cache
+	var w *dedupeWindow
+	var err error
+	if conf.Dedupe.Window.MaxKeys > 0 {
+		if conf.Dedupe.Cache != "" {
+			return nil, errors.New("a cache cannot be used along with a window")
+		}
+		if w, err = newDedupeWindow(conf.Dedupe.Window); err != nil {
+			return nil, err
+		}
+	} else if c, err = mgr.GetCache(conf.Dedupe.Cache); err != nil {
 		return nil, err
 	}
 
@@ -193,6 +319,7 @@ func NewDedupe(
 		key: key,
 
 		cache:      c,
+		window:     w,
 		hasherFunc: hFunc,
 
 		mCount:     stats.GetCounter("count"),
@@ -202,6 +329,7 @@ func NewDedupe(
 		mDropped:   stats.GetCounter("dropped"),
 		mSent:      stats.GetCounter("sent"),
 		mBatchSent: stats.GetCounter("batch.sent"),
+		mWindow:    stats.GetGauge("window.cardinality"),
 	}, nil
 }
 
@@ -247,6 +375,19 @@ func (d *Dedupe) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 			d.mDropped.Incr(1)
 			return nil, response.NewAck()
 		}
+	} else if d.window != nil {
+		added, size := d.window.add(xxhash.Checksum64(hasher.Bytes()))
+		d.mWindow.Set(int64(size))
+		if !added {
+			for _, s := range spans {
+				s.LogFields(
+					olog.String("event", "dropped"),
+					olog.String("type", "deduplicated"),
+				)
+			}
+			d.mDropped.Incr(1)
+			return nil, response.NewAck()
+		}
 	} else if err := d.cache.Add(string(hasher.Bytes()), []byte{'t'}); err != nil {
 		if err != types.ErrKeyAlreadyExists {
 			d.mErrCache.Incr(1)
@@ -447,6 +447,89 @@ func TestDedupeNegBoundsCheck(t *testing.T) {
 	}
 }
 
+func TestDedupeWindow(t *testing.T) {
+	conf := NewConfig()
+	conf.Dedupe.Key = "${! content() }"
+	conf.Dedupe.Window.MaxKeys = 2
+
+	proc, err := NewDedupe(conf, &fakeMgr{}, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range []struct {
+		input string
+		keep  bool
+	}{
+		{input: "foo", keep: true},
+		{input: "foo", keep: false},
+		{input: "bar", keep: true},
+		{input: "foo", keep: false},
+		{input: "baz", keep: true},
+		{input: "bar", keep: false},
+		{input: "foo", keep: true},
+	} {
+		msgs, _ := proc.ProcessMessage(message.New([][]byte{[]byte(test.input)}))
+		if exp, act := test.keep, len(msgs) == 1; exp != act {
+			t.Errorf("Wrong result for message %v (%v): %v != %v", i, test.input, act, exp)
+		}
+	}
+}
+
+func TestDedupeWindowPeriod(t *testing.T) {
+	conf := NewConfig()
+	conf.Dedupe.Key = "${! content() }"
+	conf.Dedupe.Window.MaxKeys = 10
+	conf.Dedupe.Window.Period = "1m"
+
+	proc, err := NewDedupe(conf, &fakeMgr{}, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(0, 0)
+	proc.(*Dedupe).window.now = func() time.Time {
+		return now
+	}
+
+	for i, test := range []struct {
+		input   string
+		elapsed time.Duration
+		keep    bool
+	}{
+		{input: "foo", keep: true},
+		{input: "bar", elapsed: 30 * time.Second, keep: true},
+		{input: "foo", elapsed: 29 * time.Second, keep: false},
+		{input: "foo", elapsed: time.Second, keep: true},
+		{input: "bar", keep: false},
+		{input: "bar", elapsed: 30 * time.Second, keep: true},
+	} {
+		now = now.Add(test.elapsed)
+		msgs, _ := proc.ProcessMessage(message.New([][]byte{[]byte(test.input)}))
+		if exp, act := test.keep, len(msgs) == 1; exp != act {
+			t.Errorf("Wrong result for message %v (%v): %v != %v", i, test.input, act, exp)
+		}
+	}
+}
+
+func TestDedupeWindowBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Dedupe.Cache = "foocache"
+	conf.Dedupe.Window.MaxKeys = 10
+
+	if _, err := NewDedupe(conf, &fakeMgr{}, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from cache and window")
+	}
+
+	conf = NewConfig()
+	conf.Dedupe.Window.MaxKeys = 10
+	conf.Dedupe.Window.Period = "nope"
+
+	if _, err := NewDedupe(conf, &fakeMgr{}, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad period")
+	}
+}
+
 func randStringRunes(n int) string {
 	b := make([]rune, n)
 	for i := range b {
@@ -0,0 +1,216 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/gabs/v2"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeEncryptFields] = TypeSpec{
+		constructor: NewEncryptFields,
+		Categories: []Category{
+			CategoryMapping,
+		},
+		Summary: `
+Encrypts fields of JSON documents with AES-GCM, optionally with data keys that
+are wrapped by AWS KMS, GCP Cloud KMS or Vault.`,
+		Beta: true,
+		Description: `
+The value of each field is serialised as JSON, encrypted, and replaced with a
+string that can be decrypted with the
+` + "[`decrypt_fields`](/docs/components/processors/decrypt_fields)" + ` processor
+using the same key. Fields that don't exist within a message are skipped.
+
+This is useful for protecting sensitive information such as personally
+identifiable information before messages reach downstream systems, whilst
+leaving the remaining fields of messages readable.
+` + fieldKeyDescription + `
+
+When data keys are wrapped a new data key is created after each
+` + "`data_key_rotation`" + ` period.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("fields", "A list of [dot paths](/docs/configuration/field_paths) of the fields to encrypt.", []string{"user.email", "user.address"}),
+			fieldKeyFieldSpec(),
+			docs.FieldAdvanced("data_key_rotation", "The period after which a new data key is created when data keys are wrapped. Set to an empty string in order to use one data key for the lifetime of the processor."),
+			partsFieldSpec,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Vault Transit",
+				Summary: `
+Here the email address and phone number of users are encrypted with data keys
+that are wrapped by a Vault transit key:`,
+				Config: `
+pipeline:
+  processors:
+    - encrypt_fields:
+        fields: [ user.email, user.phone ]
+        key:
+          provider: vault_transit
+          vault_transit:
+            address: https://vault.example.com:8200
+            token: ${VAULT_TOKEN}
+            key_name: pii
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// EncryptFieldsConfig contains configuration fields for the EncryptFields
+// processor.
+type EncryptFieldsConfig struct {
+	Fields          []string       `json:"fields" yaml:"fields"`
+	Key             FieldKeyConfig `json:"key" yaml:"key"`
+	DataKeyRotation string         `json:"data_key_rotation" yaml:"data_key_rotation"`
+	Parts           []int          `json:"parts" yaml:"parts"`
+}
+
+// NewEncryptFieldsConfig returns a EncryptFieldsConfig with default values.
+func NewEncryptFieldsConfig() EncryptFieldsConfig {
+	return EncryptFieldsConfig{
+		Fields:          []string{},
+		Key:             NewFieldKeyConfig(),
+		DataKeyRotation: "1h",
+		Parts:           []int{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// EncryptFields is a processor that encrypts fields of JSON documents.
+type EncryptFields struct {
+	parts    []int
+	fields   []string
+	paths    [][]string
+	keyring  *fieldKeyring
+	rotation time.Duration
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewEncryptFields returns a EncryptFields processor.
+func NewEncryptFields(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if len(conf.EncryptFields.Fields) == 0 {
+		return nil, errors.New("at least one field must be specified")
+	}
+
+	keyring, err := newFieldKeyring(conf.EncryptFields.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	var rotation time.Duration
+	if conf.EncryptFields.DataKeyRotation != "" {
+		if rotation, err = time.ParseDuration(conf.EncryptFields.DataKeyRotation); err != nil {
+			return nil, fmt.Errorf("failed to parse data_key_rotation: %v", err)
+		}
+	}
+
+	e := &EncryptFields{
+		parts:    conf.EncryptFields.Parts,
+		fields:   conf.EncryptFields.Fields,
+		keyring:  keyring,
+		rotation: rotation,
+
+		log: log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+	for _, f := range e.fields {
+		e.paths = append(e.paths, gabs.DotPathToSlice(f))
+	}
+	return e, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (e *EncryptFields) encrypt(part types.Part) error {
+	jsonPart, err := part.JSON()
+	if err == nil {
+		jsonPart, err = message.CopyJSON(jsonPart)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse part into json: %v", err)
+	}
+
+	key, wrapped, err := e.keyring.DataKey(e.rotation)
+	if err != nil {
+		return err
+	}
+
+	gPart := gabs.Wrap(jsonPart)
+	for i, path := range e.paths {
+		if !gPart.Exists(path...) {
+			continue
+		}
+		plaintext, err := json.Marshal(gPart.Search(path...).Data())
+		if err != nil {
+			return fmt.Errorf("failed to serialise field %v: %v", e.fields[i], err)
+		}
+		sealed, err := sealField(key, wrapped, plaintext, []byte(e.fields[i]))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt field %v: %v", e.fields[i], err)
+		}
+		if _, err = gPart.Set(sealed, path...); err != nil {
+			return fmt.Errorf("failed to set field %v: %v", e.fields[i], err)
+		}
+	}
+	return part.SetJSON(gPart.Data())
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (e *EncryptFields) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	e.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	IteratePartsWithSpan(TypeEncryptFields, e.parts, newMsg, func(index int, span opentracing.Span, part types.Part) error {
+		if err := e.encrypt(part); err != nil {
+			e.mErr.Incr(1)
+			e.log.Debugf("Failed to encrypt fields: %v\n", err)
+			return err
+		}
+		return nil
+	})
+
+	e.mBatchSent.Incr(1)
+	e.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (e *EncryptFields) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (e *EncryptFields) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,257 @@
+package processor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFieldKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func testFieldsOutput(t *testing.T, proc Type, input []string) []string {
+	t.Helper()
+
+	msg := message.New(nil)
+	for _, in := range input {
+		msg.Append(message.NewPart([]byte(in)))
+	}
+
+	msgs, res := proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	var output []string
+	msgs[0].Iter(func(i int, p types.Part) error {
+		if HasFailed(p) {
+			output = append(output, "failed: "+GetFail(p))
+		} else {
+			output = append(output, string(p.Get()))
+		}
+		return nil
+	})
+	return output
+}
+
+func newFieldsProcs(t *testing.T, fields []string, key FieldKeyConfig) (Type, Type) {
+	t.Helper()
+
+	conf := NewConfig()
+	conf.Type = TypeEncryptFields
+	conf.EncryptFields.Fields = fields
+	conf.EncryptFields.Key = key
+
+	encrypt, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf = NewConfig()
+	conf.Type = TypeDecryptFields
+	conf.DecryptFields.Fields = fields
+	conf.DecryptFields.Key = key
+
+	decrypt, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	return encrypt, decrypt
+}
+
+func TestEncryptFieldsStatic(t *testing.T) {
+	key := NewFieldKeyConfig()
+	key.Static = testFieldKey
+
+	encrypt, decrypt := newFieldsProcs(t, []string{"user.email", "user.address", "missing"}, key)
+
+	input := []string{
+		`{"id":1,"user":{"address":{"city":"London","lines":["1 Foo Street"]},"email":"foo@example.com"}}`,
+		`{"id":2,"user":{"email":null}}`,
+	}
+
+	encrypted := testFieldsOutput(t, encrypt, input)
+	require.Len(t, encrypted, 2)
+	for _, e := range encrypted {
+		assert.NotContains(t, e, "example.com")
+		assert.NotContains(t, e, "London")
+		assert.NotContains(t, e, "missing")
+	}
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(encrypted[0]), &doc))
+	assert.Equal(t, float64(1), doc["id"])
+	assert.True(t, strings.HasPrefix(doc["user"].(map[string]interface{})["email"].(string), encryptedFieldPrefix))
+
+	assert.Equal(t, input, testFieldsOutput(t, decrypt, encrypted))
+
+	assert.Equal(t, []string{
+		`failed: failed to decrypt field user.email: value is not encrypted`,
+	}, testFieldsOutput(t, decrypt, []string{`{"user":{"email":"foo@example.com"}}`}))
+}
+
+func TestEncryptFieldsPathBinding(t *testing.T) {
+	key := NewFieldKeyConfig()
+	key.Static = testFieldKey
+
+	encrypt, _ := newFieldsProcs(t, []string{"a"}, key)
+	_, decrypt := newFieldsProcs(t, []string{"b"}, key)
+
+	encrypted := testFieldsOutput(t, encrypt, []string{`{"a":"foo"}`})
+	require.Len(t, encrypted, 1)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(encrypted[0]), &doc))
+	moved, err := json.Marshal(map[string]interface{}{"b": doc["a"]})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`failed: failed to decrypt field b: cipher: message authentication failed`,
+	}, testFieldsOutput(t, decrypt, []string{string(moved)}))
+}
+
+func TestEncryptFieldsWrongKey(t *testing.T) {
+	key := NewFieldKeyConfig()
+	key.Static = testFieldKey
+	encrypt, _ := newFieldsProcs(t, []string{"a"}, key)
+
+	key.Static = strings.Repeat("ff", 32)
+	_, decrypt := newFieldsProcs(t, []string{"a"}, key)
+
+	assert.Equal(t, []string{
+		`failed: failed to decrypt field a: cipher: message authentication failed`,
+	}, testFieldsOutput(t, decrypt, testFieldsOutput(t, encrypt, []string{`{"a":"foo"}`})))
+}
+
+func TestEncryptFieldsVaultTransit(t *testing.T) {
+	var encrypts, decrypts int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "footoken" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		var data map[string]interface{}
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/fookey":
+			atomic.AddInt64(&encrypts, 1)
+			data = map[string]interface{}{
+				"ciphertext":  "vault:v1:" + body["plaintext"],
+				"key_version": 1,
+			}
+		case "/v1/transit/decrypt/fookey":
+			atomic.AddInt64(&decrypts, 1)
+			data = map[string]interface{}{
+				"plaintext": strings.TrimPrefix(body["ciphertext"], "vault:v1:"),
+			}
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer ts.Close()
+
+	key := NewFieldKeyConfig()
+	key.Provider = "vault_transit"
+	key.VaultTransit.Address = ts.URL
+	key.VaultTransit.Token = "footoken"
+	key.VaultTransit.KeyName = "fookey"
+
+	encrypt, decrypt := newFieldsProcs(t, []string{"secret"}, key)
+
+	input := []string{`{"secret":"foo"}`, `{"secret":"bar"}`, `{"secret":5}`}
+	encrypted := testFieldsOutput(t, encrypt, input)
+	encrypted = append(encrypted, testFieldsOutput(t, encrypt, input)...)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&encrypts))
+
+	assert.Equal(t, append(input, input...), testFieldsOutput(t, decrypt, encrypted))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&decrypts))
+
+	var doc map[string]string
+	require.NoError(t, json.Unmarshal([]byte(encrypted[0]), &doc))
+	wrapped, _, err := parseSealedField(doc["secret"])
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(wrapped), "vault:v1:"))
+
+	key.Static = testFieldKey
+	key.Provider = "static"
+	_, staticDecrypt := newFieldsProcs(t, []string{"secret"}, key)
+	assert.Equal(t, []string{
+		`failed: failed to decrypt field secret: field was encrypted with a wrapped data key but the provider is static`,
+	}, testFieldsOutput(t, staticDecrypt, encrypted[:1]))
+
+	key.Provider = "vault_transit"
+	key.VaultTransit.Token = "bartoken"
+	badEncrypt, _ := newFieldsProcs(t, []string{"secret"}, key)
+	assert.Equal(t, []string{
+		`failed: failed to wrap data key: vault returned unexpected response code (403): {"errors":["permission denied"]}`,
+	}, testFieldsOutput(t, badEncrypt, input[:1]))
+}
+
+func TestEncryptFieldsBadConfig(t *testing.T) {
+	tests := map[string]struct {
+		conf func(c *EncryptFieldsConfig)
+		err  string
+	}{
+		"no fields": {
+			conf: func(c *EncryptFieldsConfig) {},
+			err:  "at least one field must be specified",
+		},
+		"bad static key": {
+			conf: func(c *EncryptFieldsConfig) {
+				c.Fields = []string{"foo"}
+				c.Key.Static = "abcd"
+			},
+			err: "invalid static key: crypto/aes: invalid key size 2",
+		},
+		"bad provider": {
+			conf: func(c *EncryptFieldsConfig) {
+				c.Fields = []string{"foo"}
+				c.Key.Provider = "nope"
+			},
+			err: "key provider not recognised: nope",
+		},
+		"no vault key": {
+			conf: func(c *EncryptFieldsConfig) {
+				c.Fields = []string{"foo"}
+				c.Key.Provider = "vault_transit"
+			},
+			err: "a key_name must be specified for the vault_transit provider",
+		},
+		"bad rotation": {
+			conf: func(c *EncryptFieldsConfig) {
+				c.Fields = []string{"foo"}
+				c.Key.Static = testFieldKey
+				c.DataKeyRotation = "nope"
+			},
+			err: `failed to parse data_key_rotation: time: invalid duration "nope"`,
+		},
+	}
+
+	for name, test := range tests {
+		conf := NewConfig()
+		conf.Type = TypeEncryptFields
+		test.conf(&conf.EncryptFields)
+
+		_, err := New(conf, nil, log.Noop(), metrics.Noop())
+		assert.EqualError(t, err, test.err, name)
+	}
+}
+
+func TestSealedFieldTruncated(t *testing.T) {
+	_, _, err := parseSealedField(encryptedFieldPrefix + base64.StdEncoding.EncodeToString([]byte{0x00, 0x05, 0x01}))
+	assert.EqualError(t, err, "encrypted value is truncated")
+
+	_, err = openField([]byte(strings.Repeat("a", 32)), []byte{0x01}, nil)
+	assert.EqualError(t, err, "encrypted value is truncated")
+}
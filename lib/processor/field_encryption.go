@@ -0,0 +1,448 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/session"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"google.golang.org/api/cloudkms/v1"
+)
+
+//------------------------------------------------------------------------------
+
+// FieldKeyAWSKMSConfig contains configuration fields for wrapping data keys
+// with AWS KMS.
+type FieldKeyAWSKMSConfig struct {
+	session.Config `json:",inline" yaml:",inline"`
+	KeyID          string `json:"key_id" yaml:"key_id"`
+}
+
+// FieldKeyGCPKMSConfig contains configuration fields for wrapping data keys
+// with GCP Cloud KMS.
+type FieldKeyGCPKMSConfig struct {
+	KeyName string `json:"key_name" yaml:"key_name"`
+}
+
+// FieldKeyVaultTransitConfig contains configuration fields for wrapping data
+// keys with the transit secrets engine of Vault.
+type FieldKeyVaultTransitConfig struct {
+	Address string `json:"address" yaml:"address"`
+	Token   string `json:"token" yaml:"token"`
+	Mount   string `json:"mount" yaml:"mount"`
+	KeyName string `json:"key_name" yaml:"key_name"`
+}
+
+// FieldKeyConfig contains configuration fields for the keys used to encrypt
+// and decrypt fields.
+type FieldKeyConfig struct {
+	Provider     string                     `json:"provider" yaml:"provider"`
+	Static       string                     `json:"static" yaml:"static"`
+	AWSKMS       FieldKeyAWSKMSConfig       `json:"aws_kms" yaml:"aws_kms"`
+	GCPKMS       FieldKeyGCPKMSConfig       `json:"gcp_kms" yaml:"gcp_kms"`
+	VaultTransit FieldKeyVaultTransitConfig `json:"vault_transit" yaml:"vault_transit"`
+}
+
+// NewFieldKeyConfig returns a FieldKeyConfig with default values.
+func NewFieldKeyConfig() FieldKeyConfig {
+	return FieldKeyConfig{
+		Provider: "static",
+		Static:   "",
+		AWSKMS: FieldKeyAWSKMSConfig{
+			Config: session.NewConfig(),
+			KeyID:  "",
+		},
+		GCPKMS: FieldKeyGCPKMSConfig{
+			KeyName: "",
+		},
+		VaultTransit: FieldKeyVaultTransitConfig{
+			Address: "http://127.0.0.1:8200",
+			Token:   "",
+			Mount:   "transit",
+			KeyName: "",
+		},
+	}
+}
+
+func fieldKeyFieldSpec() docs.FieldSpec {
+	return docs.FieldCommon("key", "The key used to encrypt and decrypt fields.").WithChildren(
+		docs.FieldCommon("provider", "The provider of the key.").HasOptions("static", "aws_kms", "gcp_kms", "vault_transit"),
+		docs.FieldCommon("static", "A hex encoded AES key of 16, 24 or 32 bytes, used when the provider is `static`."),
+		docs.FieldAdvanced("aws_kms", "The AWS KMS key that wraps data keys when the provider is `aws_kms`.").WithChildren(
+			docs.FieldCommon("key_id", "The ID, ARN or alias of the key.", "alias/benthos-fields"),
+		).WithChildren(session.FieldSpecs()...),
+		docs.FieldAdvanced("gcp_kms", "The GCP Cloud KMS key that wraps data keys when the provider is `gcp_kms`. Credentials are found with the application default credentials of the environment.").WithChildren(
+			docs.FieldCommon("key_name", "The resource name of the key.", "projects/foo/locations/global/keyRings/bar/cryptoKeys/baz"),
+		),
+		docs.FieldAdvanced("vault_transit", "The Vault transit key that wraps data keys when the provider is `vault_transit`.").WithChildren(
+			docs.FieldCommon("address", "The address of the Vault server."),
+			docs.FieldCommon("token", "A token for the Vault server."),
+			docs.FieldAdvanced("mount", "The path that the transit secrets engine is mounted at."),
+			docs.FieldCommon("key_name", "The name of the transit key."),
+		),
+	)
+}
+
+const fieldKeyDescription = `
+## Keys
+
+Each field is encrypted with AES-GCM using a data key. With the ` + "`static`" + `
+provider the data key is the configured key. With the ` + "`aws_kms`" + `,
+` + "`gcp_kms`" + ` and ` + "`vault_transit`" + ` providers data keys are randomly
+generated and wrapped (encrypted) by the key management service, which is known
+as envelope encryption. The wrapped data key is stored along with each encrypted
+field, and therefore the service is only called when a data key is created or
+unwrapped for the first time, rather than for each field.
+
+Encrypted fields are bound to their path, and therefore fields can only be
+decrypted at the same path they were encrypted at.`
+
+//------------------------------------------------------------------------------
+
+// fieldKeyWrapper wraps and unwraps data keys with a key management service.
+type fieldKeyWrapper interface {
+	Wrap(ctx context.Context, key []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+type awsKMSKeyWrapper struct {
+	keyID  string
+	client *kms.KMS
+}
+
+func (a *awsKMSKeyWrapper) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	out, err := a.client.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:     aws.String(a.keyID),
+		Plaintext: key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (a *awsKMSKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	out, err := a.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(a.keyID),
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
+
+type gcpKMSKeyWrapper struct {
+	keyName string
+	keys    *cloudkms.ProjectsLocationsKeyRingsCryptoKeysService
+}
+
+func (g *gcpKMSKeyWrapper) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	res, err := g.keys.Encrypt(g.keyName, &cloudkms.EncryptRequest{
+		Plaintext: base64.StdEncoding.EncodeToString(key),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(res.Ciphertext)
+}
+
+func (g *gcpKMSKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	res, err := g.keys.Decrypt(g.keyName, &cloudkms.DecryptRequest{
+		Ciphertext: base64.StdEncoding.EncodeToString(wrapped),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(res.Plaintext)
+}
+
+type vaultTransitKeyWrapper struct {
+	baseURL string
+	keyName string
+	token   string
+	client  *http.Client
+}
+
+func (v *vaultTransitKeyWrapper) call(ctx context.Context, op string, reqBody map[string]string, resField string) (string, error) {
+	b, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", fmt.Sprintf("%v/%v/%v", v.baseURL, op, v.keyName), bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if b, err = ioutil.ReadAll(res.Body); err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned unexpected response code (%v): %s", res.StatusCode, bytes.TrimSpace(b))
+	}
+
+	var resBody struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err = json.Unmarshal(b, &resBody); err != nil {
+		return "", fmt.Errorf("failed to parse vault response: %v", err)
+	}
+	value, ok := resBody.Data[resField].(string)
+	if !ok {
+		return "", fmt.Errorf("vault response did not contain %v", resField)
+	}
+	return value, nil
+}
+
+func (v *vaultTransitKeyWrapper) Wrap(ctx context.Context, key []byte) ([]byte, error) {
+	ciphertext, err := v.call(ctx, "encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(key),
+	}, "ciphertext")
+	if err != nil {
+		return nil, err
+	}
+	return []byte(ciphertext), nil
+}
+
+func (v *vaultTransitKeyWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	plaintext, err := v.call(ctx, "decrypt", map[string]string{
+		"ciphertext": string(wrapped),
+	}, "plaintext")
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
+
+//------------------------------------------------------------------------------
+
+// fieldKeyring provides the data keys for encrypting fields, and unwraps the
+// data keys of encrypted fields.
+type fieldKeyring struct {
+	static  []byte
+	wrapper fieldKeyWrapper
+	timeout time.Duration
+
+	mut       sync.Mutex
+	dataKey   []byte
+	wrapped   []byte
+	createdAt time.Time
+	unwrapped map[string][]byte
+}
+
+// fieldKeyringMaxUnwrapped is the number of unwrapped data keys kept in memory
+// before they're cleared.
+const fieldKeyringMaxUnwrapped = 1024
+
+func newFieldKeyring(conf FieldKeyConfig) (*fieldKeyring, error) {
+	k := &fieldKeyring{
+		timeout:   10 * time.Second,
+		unwrapped: map[string][]byte{},
+	}
+
+	switch conf.Provider {
+	case "static":
+		key, err := hex.DecodeString(conf.Static)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode static key: %v", err)
+		}
+		if _, err = aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("invalid static key: %v", err)
+		}
+		k.static = key
+	case "aws_kms":
+		if conf.AWSKMS.KeyID == "" {
+			return nil, errors.New("a key_id must be specified for the aws_kms provider")
+		}
+		sess, err := conf.AWSKMS.GetSession()
+		if err != nil {
+			return nil, err
+		}
+		k.wrapper = &awsKMSKeyWrapper{
+			keyID:  conf.AWSKMS.KeyID,
+			client: kms.New(sess),
+		}
+	case "gcp_kms":
+		if conf.GCPKMS.KeyName == "" {
+			return nil, errors.New("a key_name must be specified for the gcp_kms provider")
+		}
+		svc, err := cloudkms.NewService(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCP KMS client: %v", err)
+		}
+		k.wrapper = &gcpKMSKeyWrapper{
+			keyName: conf.GCPKMS.KeyName,
+			keys:    cloudkms.NewProjectsLocationsKeyRingsCryptoKeysService(svc),
+		}
+	case "vault_transit":
+		if conf.VaultTransit.KeyName == "" {
+			return nil, errors.New("a key_name must be specified for the vault_transit provider")
+		}
+		k.wrapper = &vaultTransitKeyWrapper{
+			baseURL: fmt.Sprintf(
+				"%v/v1/%v", strings.TrimSuffix(conf.VaultTransit.Address, "/"),
+				strings.Trim(conf.VaultTransit.Mount, "/"),
+			),
+			keyName: conf.VaultTransit.KeyName,
+			token:   conf.VaultTransit.Token,
+			client:  &http.Client{},
+		}
+	default:
+		return nil, fmt.Errorf("key provider not recognised: %v", conf.Provider)
+	}
+	return k, nil
+}
+
+// DataKey returns the current data key along with its wrapped form, creating
+// a new data key when none exists or the current key is older than the
+// rotation period.
+func (k *fieldKeyring) DataKey(rotation time.Duration) (key, wrapped []byte, err error) {
+	if k.wrapper == nil {
+		return k.static, nil, nil
+	}
+
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	if k.dataKey != nil && (rotation <= 0 || time.Since(k.createdAt) < rotation) {
+		return k.dataKey, k.wrapped, nil
+	}
+
+	key = make([]byte, 32)
+	if _, err = io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), k.timeout)
+	defer done()
+	if wrapped, err = k.wrapper.Wrap(ctx, key); err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap data key: %v", err)
+	}
+
+	k.dataKey, k.wrapped, k.createdAt = key, wrapped, time.Now()
+	return key, wrapped, nil
+}
+
+// Unwrap returns the data key of a wrapped data key.
+func (k *fieldKeyring) Unwrap(wrapped []byte) ([]byte, error) {
+	if k.wrapper == nil {
+		if len(wrapped) > 0 {
+			return nil, errors.New("field was encrypted with a wrapped data key but the provider is static")
+		}
+		return k.static, nil
+	}
+	if len(wrapped) == 0 {
+		return nil, errors.New("field was encrypted with a static key")
+	}
+
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	if key, exists := k.unwrapped[string(wrapped)]; exists {
+		return key, nil
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), k.timeout)
+	defer done()
+	key, err := k.wrapper.Unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %v", err)
+	}
+
+	if len(k.unwrapped) >= fieldKeyringMaxUnwrapped {
+		k.unwrapped = map[string][]byte{}
+	}
+	k.unwrapped[string(wrapped)] = key
+	return key, nil
+}
+
+//------------------------------------------------------------------------------
+
+// encryptedFieldPrefix marks the values of encrypted fields, which are
+// followed by the base64 encoding of the length of the wrapped data key as a
+// big endian uint16, the wrapped data key, the nonce and the ciphertext.
+const encryptedFieldPrefix = "enc:v1:"
+
+func sealField(key, wrapped, plaintext, path []byte) (string, error) {
+	if len(wrapped) > 0xFFFF {
+		return "", errors.New("wrapped data key is too large")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 2+len(wrapped)+gcm.NonceSize(), 2+len(wrapped)+gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	binary.BigEndian.PutUint16(b, uint16(len(wrapped)))
+	copy(b[2:], wrapped)
+	nonce := b[2+len(wrapped):]
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	b = gcm.Seal(b, nonce, plaintext, path)
+	return encryptedFieldPrefix + base64.StdEncoding.EncodeToString(b), nil
+}
+
+func parseSealedField(value string) (wrapped, sealed []byte, err error) {
+	if !strings.HasPrefix(value, encryptedFieldPrefix) {
+		return nil, nil, errors.New("value is not encrypted")
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedFieldPrefix))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode encrypted value: %v", err)
+	}
+	if len(b) < 2 || len(b) < 2+int(binary.BigEndian.Uint16(b)) {
+		return nil, nil, errors.New("encrypted value is truncated")
+	}
+	wrappedLen := int(binary.BigEndian.Uint16(b))
+	return b[2 : 2+wrappedLen], b[2+wrappedLen:], nil
+}
+
+func openField(key, sealed, path []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted value is truncated")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], path)
+}
+
+//------------------------------------------------------------------------------
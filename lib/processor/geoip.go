@@ -0,0 +1,350 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/gabs/v2"
+	"github.com/opentracing/opentracing-go"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeGeoIP] = TypeSpec{
+		constructor: NewGeoIP,
+		Categories: []Category{
+			CategoryIntegration,
+		},
+		Summary: `
+Looks up IP addresses within a MaxMind GeoIP2 or GeoLite2 database and adds the
+resulting record to messages.`,
+		Beta: true,
+		Description: `
+The IP address of each message is resolved with the ` + "`ip`" + ` field and looked
+up within the database [file](https://maxmind.github.io/MaxMind-DB/), which can
+be any MaxMind DB such as the City, Country or ASN databases. When a record is
+found it is set at the ` + "`target_path`" + ` of the message, which must be a JSON
+document. Messages where the IP address is not found are left unchanged.
+
+The database file is checked for changes periodically, and when it is modified
+the new database is loaded without interrupting the pipeline, which allows
+databases to be updated in place with tools such as ` + "`geoipupdate`" + `.
+
+The records of each database are added as they are, the structure of a City
+database record looks like this:
+
+` + "```json" + `
+{
+  "city": { "geoname_id": 2643743, "names": { "en": "London" } },
+  "continent": { "code": "EU", "geoname_id": 6255148, "names": { "en": "Europe" } },
+  "country": { "geoname_id": 2635167, "iso_code": "GB", "names": { "en": "United Kingdom" } },
+  "location": { "accuracy_radius": 10, "latitude": 51.5142, "longitude": -0.0931, "time_zone": "Europe/London" },
+  "postal": { "code": "EC2V" }
+}
+` + "```" + `
+
+And records of an ASN database look like this:
+
+` + "```json" + `
+{ "autonomous_system_number": 15169, "autonomous_system_organization": "Google LLC" }
+` + "```" + `
+
+The records of multiple databases can be combined by listing a
+` + "`geoip`" + ` processor for each database with different target paths.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("file", "The path of a MaxMind DB file."),
+			docs.FieldCommon("ip", "The IP address to look up, which may include a port.", `${! json("client.ip") }`, `${! meta("http_server_remote_addr") }`).SupportsInterpolation(false),
+			docs.FieldCommon("target_path", "A [dot path](/docs/configuration/field_paths) to set the record at within each message. When empty the whole message is replaced with the record.", "geo", "client.location"),
+			docs.FieldAdvanced("reload_interval", "How often to check the database file for changes, set to an empty string in order to disable reloading."),
+			partsFieldSpec,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "City and ASN",
+				Summary: `
+Here the location and network of each client IP address are added to messages
+from the GeoLite2 City and ASN databases:`,
+				Config: `
+pipeline:
+  processors:
+    - geoip:
+        file: /var/lib/GeoIP/GeoLite2-City.mmdb
+        ip: ${! json("client_ip") }
+        target_path: client.geo
+    - geoip:
+        file: /var/lib/GeoIP/GeoLite2-ASN.mmdb
+        ip: ${! json("client_ip") }
+        target_path: client.asn
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GeoIPConfig contains configuration fields for the GeoIP processor.
+type GeoIPConfig struct {
+	File           string `json:"file" yaml:"file"`
+	IP             string `json:"ip" yaml:"ip"`
+	TargetPath     string `json:"target_path" yaml:"target_path"`
+	ReloadInterval string `json:"reload_interval" yaml:"reload_interval"`
+	Parts          []int  `json:"parts" yaml:"parts"`
+}
+
+// NewGeoIPConfig returns a GeoIPConfig with default values.
+func NewGeoIPConfig() GeoIPConfig {
+	return GeoIPConfig{
+		File:           "",
+		IP:             "",
+		TargetPath:     "geoip",
+		ReloadInterval: "1m",
+		Parts:          []int{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GeoIP is a processor that adds the records of IP addresses from a MaxMind DB
+// to messages.
+type GeoIP struct {
+	parts      []int
+	file       string
+	ip         field.Expression
+	targetPath []string
+
+	dbMux   sync.RWMutex
+	db      *maxminddb.Reader
+	modTime time.Time
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mNotFound  metrics.StatCounter
+	mReload    metrics.StatCounter
+	mReloadErr metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewGeoIP returns a GeoIP processor.
+func NewGeoIP(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if conf.GeoIP.File == "" {
+		return nil, errors.New("a database file must be specified")
+	}
+	if conf.GeoIP.IP == "" {
+		return nil, errors.New("an ip must be specified")
+	}
+
+	ip, err := bloblang.NewField(conf.GeoIP.IP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ip expression: %v", err)
+	}
+
+	var reloadInterval time.Duration
+	if conf.GeoIP.ReloadInterval != "" {
+		if reloadInterval, err = time.ParseDuration(conf.GeoIP.ReloadInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse reload_interval: %v", err)
+		}
+	}
+
+	g := &GeoIP{
+		parts: conf.GeoIP.Parts,
+		file:  conf.GeoIP.File,
+		ip:    ip,
+
+		log: log,
+
+		mCount:     stats.GetCounter("count"),
+		mNotFound:  stats.GetCounter("not_found"),
+		mReload:    stats.GetCounter("reload"),
+		mReloadErr: stats.GetCounter("reload.error"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+	if conf.GeoIP.TargetPath != "" {
+		g.targetPath = gabs.DotPathToSlice(conf.GeoIP.TargetPath)
+	}
+
+	if _, err = g.reload(); err != nil {
+		return nil, err
+	}
+
+	go g.loop(reloadInterval)
+	return g, nil
+}
+
+//------------------------------------------------------------------------------
+
+// reload opens the database file when it has been modified since it was last
+// loaded, and returns true if the database was replaced.
+func (g *GeoIP) reload() (bool, error) {
+	info, err := os.Stat(g.file)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat database file: %v", err)
+	}
+
+	g.dbMux.RLock()
+	unchanged := g.db != nil && info.ModTime().Equal(g.modTime)
+	g.dbMux.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	// The database is read into memory rather than memory mapped so that
+	// replaced readers can be left to the garbage collector whilst lookups
+	// might still be using them.
+	dbBytes, err := ioutil.ReadFile(g.file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read database file: %v", err)
+	}
+	db, err := maxminddb.FromBytes(dbBytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to open database file: %v", err)
+	}
+
+	g.dbMux.Lock()
+	g.db, g.modTime = db, info.ModTime()
+	g.dbMux.Unlock()
+	return true, nil
+}
+
+func (g *GeoIP) loop(reloadInterval time.Duration) {
+	defer close(g.closedChan)
+	if reloadInterval <= 0 {
+		<-g.closeChan
+		return
+	}
+
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			reloaded, err := g.reload()
+			if err != nil {
+				g.mReloadErr.Incr(1)
+				g.log.Errorf("Failed to reload database: %v\n", err)
+			} else if reloaded {
+				g.mReload.Incr(1)
+				g.log.Infof("Reloaded database file: %v\n", g.file)
+			}
+		case <-g.closeChan:
+			return
+		}
+	}
+}
+
+func (g *GeoIP) lookup(ipStr string) (interface{}, bool, error) {
+	ip := net.ParseIP(strings.TrimSpace(ipStr))
+	if ip == nil {
+		host, _, err := net.SplitHostPort(ipStr)
+		if err == nil {
+			ip = net.ParseIP(host)
+		}
+	}
+	if ip == nil {
+		return nil, false, fmt.Errorf("failed to parse IP address: %q", ipStr)
+	}
+
+	g.dbMux.RLock()
+	db := g.db
+	g.dbMux.RUnlock()
+
+	var record interface{}
+	_, found, err := db.LookupNetwork(ip, &record)
+	return record, found, err
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (g *GeoIP) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	g.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(index int, span opentracing.Span, part types.Part) error {
+		record, found, err := g.lookup(g.ip.String(index, msg))
+		if err != nil {
+			g.mErr.Incr(1)
+			g.log.Debugf("Failed to look up IP address: %v\n", err)
+			return err
+		}
+		if !found {
+			g.mNotFound.Incr(1)
+			return nil
+		}
+
+		if len(g.targetPath) == 0 {
+			return part.SetJSON(record)
+		}
+
+		jsonPart, err := part.JSON()
+		if err == nil {
+			jsonPart, err = message.CopyJSON(jsonPart)
+		}
+		if err != nil {
+			g.mErr.Incr(1)
+			g.log.Debugf("Failed to parse part into json: %v\n", err)
+			return err
+		}
+
+		gPart := gabs.Wrap(jsonPart)
+		if _, err = gPart.Set(record, g.targetPath...); err != nil {
+			g.mErr.Incr(1)
+			return fmt.Errorf("failed to set target path: %v", err)
+		}
+		return part.SetJSON(gPart.Data())
+	}
+
+	IteratePartsWithSpan(TypeGeoIP, g.parts, newMsg, proc)
+
+	g.mBatchSent.Incr(1)
+	g.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (g *GeoIP) CloseAsync() {
+	g.closeOnce.Do(func() {
+		close(g.closeChan)
+	})
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (g *GeoIP) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	case <-g.closedChan:
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
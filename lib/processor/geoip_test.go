@@ -0,0 +1,226 @@
+package processor
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeGeoIPTestDB(t *testing.T, path string, records map[string]interface{}) {
+	t.Helper()
+
+	w := newMMDBTestWriter("GeoLite2-City")
+	for cidr, record := range records {
+		_, network, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		require.NoError(t, w.Insert(network, record))
+	}
+
+	b, err := w.Bytes()
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, b, 0644))
+}
+
+func geoIPTestDB(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "benthos_geoip_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	path := filepath.Join(dir, "test.mmdb")
+	writeGeoIPTestDB(t, path, map[string]interface{}{
+		"81.2.69.0/24": map[string]interface{}{
+			"city":    map[string]interface{}{"names": map[string]interface{}{"en": "London"}},
+			"country": map[string]interface{}{"iso_code": "GB"},
+			"location": map[string]interface{}{
+				"latitude":  51.5142,
+				"longitude": -0.0931,
+			},
+		},
+		"2001:480::/32": map[string]interface{}{
+			"country": map[string]interface{}{"iso_code": "US"},
+		},
+	})
+	return path
+}
+
+func testGeoIPOutput(t *testing.T, proc Type, input []string) []string {
+	t.Helper()
+
+	msg := message.New(nil)
+	for _, in := range input {
+		msg.Append(message.NewPart([]byte(in)))
+	}
+
+	msgs, res := proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	var output []string
+	msgs[0].Iter(func(i int, p types.Part) error {
+		if HasFailed(p) {
+			output = append(output, "failed: "+GetFail(p))
+		} else {
+			output = append(output, string(p.Get()))
+		}
+		return nil
+	})
+	return output
+}
+
+func TestGeoIP(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeGeoIP
+	conf.GeoIP.File = geoIPTestDB(t)
+	conf.GeoIP.IP = `${! json("ip") }`
+	conf.GeoIP.TargetPath = "client.geo"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		proc.CloseAsync()
+		require.NoError(t, proc.WaitForClose(time.Second))
+	}()
+
+	assert.Equal(t, []string{
+		`{"client":{"geo":{"city":{"names":{"en":"London"}},"country":{"iso_code":"GB"},"location":{"latitude":51.5142,"longitude":-0.0931}}},"ip":"81.2.69.142"}`,
+		`{"client":{"geo":{"country":{"iso_code":"US"}}},"ip":"2001:480::1"}`,
+		`{"client":{"geo":{"city":{"names":{"en":"London"}},"country":{"iso_code":"GB"},"location":{"latitude":51.5142,"longitude":-0.0931}}},"ip":"81.2.69.1:4195"}`,
+		`{"ip":"10.0.0.1"}`,
+		`failed: failed to parse IP address: "nope"`,
+		`failed: failed to parse IP address: "null"`,
+	}, testGeoIPOutput(t, proc, []string{
+		`{"ip":"81.2.69.142"}`,
+		`{"ip":"2001:480::1"}`,
+		`{"ip":"81.2.69.1:4195"}`,
+		`{"ip":"10.0.0.1"}`,
+		`{"ip":"nope"}`,
+		`{}`,
+	}))
+}
+
+func TestGeoIPRoot(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeGeoIP
+	conf.GeoIP.File = geoIPTestDB(t)
+	conf.GeoIP.IP = `${! content() }`
+	conf.GeoIP.TargetPath = ""
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer proc.CloseAsync()
+
+	assert.Equal(t, []string{
+		`{"country":{"iso_code":"US"}}`,
+		`10.0.0.1`,
+		`failed: failed to parse IP address: "nope"`,
+	}, testGeoIPOutput(t, proc, []string{
+		`2001:480::1`,
+		`10.0.0.1`,
+		`nope`,
+	}))
+}
+
+func TestGeoIPReload(t *testing.T) {
+	path := geoIPTestDB(t)
+
+	conf := NewConfig()
+	conf.Type = TypeGeoIP
+	conf.GeoIP.File = path
+	conf.GeoIP.IP = `${! content() }`
+	conf.GeoIP.TargetPath = ""
+	conf.GeoIP.ReloadInterval = ""
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer proc.CloseAsync()
+
+	reloaded, err := proc.(*GeoIP).reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	writeGeoIPTestDB(t, path, map[string]interface{}{
+		"10.0.0.0/8": map[string]interface{}{"network": "private"},
+	})
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, future, future))
+
+	reloaded, err = proc.(*GeoIP).reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	assert.Equal(t, []string{
+		`2001:480::1`,
+		`{"network":"private"}`,
+	}, testGeoIPOutput(t, proc, []string{
+		`2001:480::1`,
+		`10.0.0.1`,
+	}))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("nope"), 0644))
+	require.NoError(t, os.Chtimes(path, future.Add(time.Hour), future.Add(time.Hour)))
+
+	_, err = proc.(*GeoIP).reload()
+	assert.EqualError(t, err, "failed to open database file: error opening database: invalid MaxMind DB file")
+
+	assert.Equal(t, []string{
+		`{"network":"private"}`,
+	}, testGeoIPOutput(t, proc, []string{
+		`10.0.0.1`,
+	}))
+}
+
+func TestGeoIPBadConfig(t *testing.T) {
+	tests := map[string]struct {
+		conf func(c *GeoIPConfig)
+		err  string
+	}{
+		"no file": {
+			conf: func(c *GeoIPConfig) {},
+			err:  "a database file must be specified",
+		},
+		"no ip": {
+			conf: func(c *GeoIPConfig) {
+				c.File = "/does/not/exist.mmdb"
+			},
+			err: "an ip must be specified",
+		},
+		"bad reload interval": {
+			conf: func(c *GeoIPConfig) {
+				c.File = "/does/not/exist.mmdb"
+				c.IP = `${! content() }`
+				c.ReloadInterval = "nope"
+			},
+			err: `failed to parse reload_interval: time: invalid duration "nope"`,
+		},
+		"missing file": {
+			conf: func(c *GeoIPConfig) {
+				c.File = "/does/not/exist.mmdb"
+				c.IP = `${! content() }`
+			},
+			err: "failed to stat database file: stat /does/not/exist.mmdb: no such file or directory",
+		},
+	}
+
+	for name, test := range tests {
+		conf := NewConfig()
+		conf.Type = TypeGeoIP
+		test.conf(&conf.GeoIP)
+
+		_, err := New(conf, nil, log.Noop(), metrics.Noop())
+		assert.EqualError(t, err, test.err, name)
+	}
+}
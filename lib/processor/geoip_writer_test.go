@@ -0,0 +1,243 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+)
+
+// mmdbMetadataMarker precedes the metadata section at the end of a database.
+var mmdbMetadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+const (
+	mmdbTypeString = 2
+	mmdbTypeDouble = 3
+	mmdbTypeBytes  = 4
+	mmdbTypeUint16 = 5
+	mmdbTypeUint32 = 6
+	mmdbTypeMap    = 7
+	mmdbTypeInt32  = 8
+	mmdbTypeUint64 = 9
+	mmdbTypeArray  = 11
+	mmdbTypeBool   = 14
+)
+
+// mmdbTestWriter builds IPv6 MaxMind databases with a record size of 32 bits
+// for use as geoip test fixtures.
+type mmdbTestWriter struct {
+	dbType string
+	root   *mmdbTestNode
+}
+
+type mmdbTestNode struct {
+	children [2]*mmdbTestNode
+	value    interface{}
+	leaf     bool
+}
+
+// newMMDBTestWriter creates a database writer with a database type.
+func newMMDBTestWriter(databaseType string) *mmdbTestWriter {
+	return &mmdbTestWriter{
+		dbType: databaseType,
+		root:   &mmdbTestNode{},
+	}
+}
+
+// Insert adds a network to the database with a record value, which must be
+// composed of maps, slices, strings, booleans, floats and integers. IPv4
+// networks are inserted within the ::/96 subnet, where the readers of IPv6
+// databases look up IPv4 addresses.
+func (w *mmdbTestWriter) Insert(network *net.IPNet, value interface{}) error {
+	if _, err := mmdbEncodeValue(nil, value); err != nil {
+		return err
+	}
+
+	ip := network.IP.To16()
+	ones, bits := network.Mask.Size()
+	if ip == nil || bits == 0 {
+		return errors.New("invalid network")
+	}
+	if bits == 32 {
+		ip = append(make(net.IP, 12), network.IP.To4()...)
+		ones += 96
+	}
+	if ones == 0 {
+		return errors.New("networks must have a prefix")
+	}
+
+	node := w.root
+	for i := 0; i < ones; i++ {
+		if node.leaf {
+			node.children = [2]*mmdbTestNode{
+				{value: node.value, leaf: true},
+				{value: node.value, leaf: true},
+			}
+			node.leaf, node.value = false, nil
+		}
+		bit := (ip[i>>3] >> (7 - uint(i&7))) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &mmdbTestNode{}
+		}
+		node = node.children[bit]
+	}
+	node.children = [2]*mmdbTestNode{}
+	node.value, node.leaf = value, true
+	return nil
+}
+
+// Bytes returns the serialised database.
+func (w *mmdbTestWriter) Bytes() ([]byte, error) {
+	var nodes []*mmdbTestNode
+	ids := map[*mmdbTestNode]int{}
+	var walk func(n *mmdbTestNode)
+	walk = func(n *mmdbTestNode) {
+		if n == nil || n.leaf {
+			return
+		}
+		ids[n] = len(nodes)
+		nodes = append(nodes, n)
+		walk(n.children[0])
+		walk(n.children[1])
+	}
+	walk(w.root)
+
+	var data []byte
+	var err error
+	tree := make([]byte, 0, len(nodes)*8)
+	for _, n := range nodes {
+		for _, c := range n.children {
+			record := len(nodes)
+			if c != nil && c.leaf {
+				record = len(nodes) + 16 + len(data)
+				if data, err = mmdbEncodeValue(data, c.value); err != nil {
+					return nil, err
+				}
+			} else if c != nil {
+				record = ids[c]
+			}
+			tree = append(tree, 0, 0, 0, 0)
+			binary.BigEndian.PutUint32(tree[len(tree)-4:], uint32(record))
+		}
+	}
+
+	meta, err := mmdbEncodeValue(nil, map[string]interface{}{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(0),
+		"database_type":               w.dbType,
+		"ip_version":                  uint16(6),
+		"languages":                   []interface{}{},
+		"node_count":                  uint32(len(nodes)),
+		"record_size":                 uint16(32),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(tree)
+	buf.Write(make([]byte, 16))
+	buf.Write(data)
+	buf.Write(mmdbMetadataMarker)
+	buf.Write(meta)
+	return buf.Bytes(), nil
+}
+
+//------------------------------------------------------------------------------
+
+func mmdbEncodeCtrl(b []byte, dtype, size int) []byte {
+	var sizeBytes []byte
+	switch {
+	case size < 29:
+	case size < 285:
+		sizeBytes = []byte{byte(size - 29)}
+		size = 29
+	case size < 65821:
+		size -= 285
+		sizeBytes = []byte{byte(size >> 8), byte(size)}
+		size = 30
+	default:
+		size -= 65821
+		sizeBytes = []byte{byte(size >> 16), byte(size >> 8), byte(size)}
+		size = 31
+	}
+	if dtype > 7 {
+		b = append(b, byte(size), byte(dtype-7))
+	} else {
+		b = append(b, byte(dtype<<5|size))
+	}
+	return append(b, sizeBytes...)
+}
+
+func mmdbEncodeUint(b []byte, dtype int, v uint64) []byte {
+	var payload []byte
+	for ; v > 0; v >>= 8 {
+		payload = append([]byte{byte(v)}, payload...)
+	}
+	b = mmdbEncodeCtrl(b, dtype, len(payload))
+	return append(b, payload...)
+}
+
+func mmdbEncodeValue(b []byte, value interface{}) ([]byte, error) {
+	var err error
+	switch t := value.(type) {
+	case string:
+		b = mmdbEncodeCtrl(b, mmdbTypeString, len(t))
+		b = append(b, t...)
+	case []byte:
+		b = mmdbEncodeCtrl(b, mmdbTypeBytes, len(t))
+		b = append(b, t...)
+	case float64:
+		b = mmdbEncodeCtrl(b, mmdbTypeDouble, 8)
+		b = append(b, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(b[len(b)-8:], math.Float64bits(t))
+	case bool:
+		size := 0
+		if t {
+			size = 1
+		}
+		b = mmdbEncodeCtrl(b, mmdbTypeBool, size)
+	case uint16:
+		b = mmdbEncodeUint(b, mmdbTypeUint16, uint64(t))
+	case uint32:
+		b = mmdbEncodeUint(b, mmdbTypeUint32, uint64(t))
+	case uint64:
+		b = mmdbEncodeUint(b, mmdbTypeUint64, t)
+	case int32:
+		b = mmdbEncodeCtrl(b, mmdbTypeInt32, 4)
+		b = append(b, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[len(b)-4:], uint32(t))
+	case int:
+		if t < 0 {
+			return mmdbEncodeValue(b, int32(t))
+		}
+		b = mmdbEncodeUint(b, mmdbTypeUint64, uint64(t))
+	case []interface{}:
+		b = mmdbEncodeCtrl(b, mmdbTypeArray, len(t))
+		for _, v := range t {
+			if b, err = mmdbEncodeValue(b, v); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = mmdbEncodeCtrl(b, mmdbTypeMap, len(t))
+		for _, k := range keys {
+			b, _ = mmdbEncodeValue(b, k)
+			if b, err = mmdbEncodeValue(b, t[k]); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported value type: %T", value)
+	}
+	return b, nil
+}
@@ -1,8 +1,15 @@
 package processor
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -11,6 +18,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/opentracing/opentracing-go"
 	"github.com/trivago/grok"
+	"github.com/trivago/grok/patterns"
 )
 
 //------------------------------------------------------------------------------
@@ -33,6 +41,25 @@ Currently only json is a supported output format.
 Type hints within patterns are respected, therefore with the pattern
 ` + "`%{WORD:first},%{INT:second:int}`" + ` and a payload of ` + "`foo,1`" + `
 the resulting payload would be ` + "`{\"first\":\"foo\",\"second\":1}`" + `.
+The supported type hints are ` + "`int`, `float` and `string`" + `.
+
+Patterns are attempted in order and the first that returns at least one value is
+used, if none of the patterns match the message is flagged as failed.
+
+### Pattern Library
+
+Patterns from the [Logstash pattern library](https://github.com/logstash-plugins/logstash-patterns-core/tree/master/patterns)
+can be loaded by listing the names of its sets in ` + "`pattern_sets`" + `,
+which can be any of ` + "`" + strings.Join(grokPatternSetNames(), "`, `") + "`" + `.
+
+Patterns can also be loaded from files with ` + "`pattern_paths`" + `, where
+each line of a file is the name of a pattern followed by a space and its
+definition, and lines that are empty or begin with ` + "`#`" + ` are ignored,
+which is the format of the Logstash pattern library. When a path is a directory
+all files within it are loaded.
+
+Definitions from ` + "`pattern_definitions`" + ` take precedence over those
+loaded from files, which take precedence over the pattern sets.
 
 ### Performance
 
@@ -44,6 +71,8 @@ based implementations of grok. For more information see
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("patterns", "A list of patterns to attempt against the incoming messages."),
 			docs.FieldCommon("pattern_definitions", "A map of pattern definitions that can be referenced within `patterns`."),
+			docs.FieldAdvanced("pattern_sets", "A list of [pattern sets](#pattern-library) from the Logstash pattern library to load, which can be referenced within `patterns`.", []string{"linux_syslog", "java"}),
+			docs.FieldAdvanced("pattern_paths", "A list of paths to files or directories containing [pattern definitions](#pattern-library) to load, which can be referenced within `patterns`.", []string{"/etc/benthos/patterns"}),
 			docs.FieldCommon("output_format", "The structured output format.").HasOptions("json"),
 			docs.FieldAdvanced("named_captures_only", "Whether to only capture values from named patterns."),
 			docs.FieldAdvanced("use_default_patterns", "Whether to use a [default set of patterns](#default-patterns)."),
@@ -75,6 +104,8 @@ type GrokConfig struct {
 	UseDefaults        bool              `json:"use_default_patterns" yaml:"use_default_patterns"`
 	To                 string            `json:"output_format" yaml:"output_format"`
 	PatternDefinitions map[string]string `json:"pattern_definitions" yaml:"pattern_definitions"`
+	PatternSets        []string          `json:"pattern_sets" yaml:"pattern_sets"`
+	PatternPaths       []string          `json:"pattern_paths" yaml:"pattern_paths"`
 }
 
 // NewGrokConfig returns a GrokConfig with default values.
@@ -87,11 +118,98 @@ func NewGrokConfig() GrokConfig {
 		UseDefaults:        true,
 		To:                 "json",
 		PatternDefinitions: make(map[string]string),
+		PatternSets:        []string{},
+		PatternPaths:       []string{},
 	}
 }
 
 //------------------------------------------------------------------------------
 
+var grokPatternSets = map[string]map[string]string{
+	"aws":          patterns.AWS,
+	"bacula":       patterns.Bacula,
+	"bro":          patterns.Bro,
+	"exim":         patterns.Exim,
+	"firewalls":    patterns.Firewalls,
+	"grok":         patterns.Grok,
+	"haproxy":      patterns.Haproxy,
+	"java":         patterns.Java,
+	"junos":        patterns.Junos,
+	"linux_syslog": patterns.LinuxSyslog,
+	"mcollective":  patterns.MCollective,
+	"mongodb":      patterns.MongoDB,
+	"nagios":       patterns.Nagios,
+	"postgresql":   patterns.PostgreSQL,
+	"rails":        patterns.Rails,
+	"redis":        patterns.Redis,
+	"ruby":         patterns.Ruby,
+}
+
+func grokPatternSetNames() []string {
+	names := make([]string, 0, len(grokPatternSets))
+	for k := range grokPatternSets {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readGrokPatterns parses pattern definitions in the format of the Logstash
+// pattern library into a map.
+func readGrokPatterns(data []byte, definitions map[string]string) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		nameEnd := strings.IndexAny(line, " \t")
+		if nameEnd == -1 {
+			return fmt.Errorf("line %v: pattern definition is missing", i)
+		}
+		definitions[line[:nameEnd]] = strings.TrimSpace(line[nameEnd:])
+	}
+	return scanner.Err()
+}
+
+func loadGrokPatterns(conf GrokConfig) (map[string]string, error) {
+	definitions := map[string]string{}
+	for _, name := range conf.PatternSets {
+		set, exists := grokPatternSets[name]
+		if !exists {
+			return nil, fmt.Errorf("pattern set not recognised: %v", name)
+		}
+		for k, v := range set {
+			definitions[k] = v
+		}
+	}
+
+	for _, path := range conf.PatternPaths {
+		if err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err = readGrokPatterns(data, definitions); err != nil {
+				return fmt.Errorf("failed to parse patterns file '%v': %v", path, err)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for k, v := range conf.PatternDefinitions {
+		definitions[k] = v
+	}
+	return definitions, nil
+}
+
+//------------------------------------------------------------------------------
+
 // Grok is a processor that executes Grok queries on a message part and replaces
 // the contents with the result.
 type Grok struct {
@@ -114,11 +232,16 @@ type Grok struct {
 func NewGrok(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
+	definitions, err := loadGrokPatterns(conf.Grok)
+	if err != nil {
+		return nil, err
+	}
+
 	gcompiler, err := grok.New(grok.Config{
 		RemoveEmptyValues:   conf.Grok.RemoveEmpty,
 		NamedCapturesOnly:   conf.Grok.NamedOnly,
 		SkipDefaultPatterns: !conf.Grok.UseDefaults,
-		Patterns:            conf.Grok.PatternDefinitions,
+		Patterns:            definitions,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create grok compiler: %v", err)
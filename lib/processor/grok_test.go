@@ -1,7 +1,9 @@
 package processor
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -58,6 +60,7 @@ func TestGrok(t *testing.T) {
 		input       string
 		output      string
 		definitions map[string]string
+		sets        []string
 	}
 
 	tests := []gTest{
@@ -76,6 +79,21 @@ func TestGrok(t *testing.T) {
 			pattern: "%{ACTION:action} connection from %{IPV4:ipv4}",
 			output:  `{"action":"pass","ipv4":"127.0.0.1"}`,
 		},
+		{
+			name:    "Test pattern sets",
+			sets:    []string{"redis"},
+			input:   `[4018] 14 Nov 07:01:22.119 * Background saving terminated`,
+			pattern: "%{REDISLOG}%{GREEDYDATA:message}",
+			output:  `{"message":"Background saving terminated","pid":"4018","timestamp":"14 Nov 07:01:22.119"}`,
+		},
+		{
+			name:        "Test pattern definitions override sets",
+			sets:        []string{"redis"},
+			definitions: map[string]string{"REDISTIMESTAMP": `%{MONTHDAY} %{MONTH}`},
+			input:       `[4018] 14 Nov * Background saving terminated`,
+			pattern:     "%{REDISLOG}%{GREEDYDATA:message}",
+			output:      `{"message":"Background saving terminated","pid":"4018","timestamp":"14 Nov"}`,
+		},
 	}
 
 	for _, test := range tests {
@@ -83,6 +101,7 @@ func TestGrok(t *testing.T) {
 		conf.Grok.Parts = []int{0}
 		conf.Grok.Patterns = []string{test.pattern}
 		conf.Grok.PatternDefinitions = test.definitions
+		conf.Grok.PatternSets = test.sets
 
 		gSet, err := NewGrok(conf, nil, tLog, tStats)
 		if err != nil {
@@ -104,3 +123,70 @@ func TestGrok(t *testing.T) {
 		}
 	}
 }
+
+func TestGrokPatternPaths(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "benthos_grok_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err = os.MkdirAll(filepath.Join(tmpDir, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(tmpDir, "actions"), []byte(`
+# Actions of the firewall
+ACTION (pass|deny)
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(tmpDir, "nested", "firewall"), []byte(`FIREWALL %{ACTION:action} connection from %{IPV4:ipv4} port %{INT:port:int}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Grok.Patterns = []string{"%{FIREWALL}"}
+	conf.Grok.PatternPaths = []string{tmpDir}
+
+	gSet, err := NewGrok(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, _ := gSet.ProcessMessage(message.New([][]byte{
+		[]byte(`deny connection from 127.0.0.1 port 22`),
+	}))
+	if len(msgs) != 1 {
+		t.Fatal("Wrong count of messages")
+	}
+	if exp, act := `{"action":"deny","ipv4":"127.0.0.1","port":22}`, string(msgs[0].Get(0).Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+}
+
+func TestGrokBadPatterns(t *testing.T) {
+	conf := NewConfig()
+	conf.Grok.PatternSets = []string{"nope"}
+
+	if _, err := NewGrok(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad pattern set")
+	}
+
+	tmpFile, err := ioutil.TempFile("", "benthos_grok_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write([]byte("ACTION\n")); err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+
+	conf = NewConfig()
+	conf.Grok.PatternPaths = []string{tmpFile.Name()}
+
+	if _, err = NewGrok(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad pattern file")
+	}
+}
@@ -2,10 +2,14 @@ package processor
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
@@ -31,7 +35,8 @@ the original message parts with the body of the response.`,
 If a processed message batch contains more than one message they will be sent in
 a single request as a [multipart message](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html).
 Alternatively, message batches can be sent in parallel by setting the field
-` + "`parallel` to `true`" + `.
+` + "`parallel` to `true`" + `, where the number of concurrent requests of
+each batch can be capped with ` + "`max_parallel`" + `.
 
 The ` + "`rate_limit`" + ` field can be used to specify a rate limit
 [resource](/docs/components/rate_limits/about) to cap the rate of requests
@@ -62,8 +67,20 @@ If the request returns an error response code this processor sets a metadata
 field ` + "`http_status_code`" + ` on the resulting message.
 
 If the field ` + "`copy_response_headers` is set to `true`" + ` then any headers
-in the response will also be set in the resulting message as metadata.
- 
+in the response will also be set in the resulting message as metadata, and
+specific headers can be selected instead with the field
+` + "`extract_headers`" + `.
+
+## Circuit Breaking
+
+When ` + "`circuit_breaker.failures`" + ` is greater than zero the processor
+stops sending requests after that many consecutive requests have failed, and
+messages are instead mapped with the Bloblang mapping
+` + "`circuit_breaker.fallback`" + `, or flagged as failed when no fallback is
+set. After ` + "`circuit_breaker.reset_period`" + ` a single request is sent
+in order to test whether the target has recovered, and if it succeeds requests
+resume, otherwise the circuit breaker remains open for another period.
+
 ## Error Handling
 
 When all retry attempts for a message are exhausted the processor cancels the
@@ -72,7 +89,17 @@ can be dropped or placed in a dead letter queue according to your config, you
 can read about these patterns [here](/docs/configuration/error_handling).`,
 		FieldSpecs: append(docs.FieldSpecs{
 			docs.FieldCommon("parallel", "When processing batched messages, whether to send messages of the batch in parallel, otherwise they are sent within a single request."),
-			docs.FieldDeprecated("max_parallel"),
+			docs.FieldAdvanced("max_parallel", "When `parallel` is true, the maximum number of requests of a batch to send concurrently, where zero means no limit."),
+			docs.FieldAdvanced("circuit_breaker", "A [circuit breaker](#circuit-breaking) that stops requests after consecutive failures.").WithChildren(
+				docs.FieldCommon("failures", "The number of consecutive failed requests after which the circuit breaker opens, where zero disables the circuit breaker."),
+				docs.FieldCommon("reset_period", "The period after which an open circuit breaker allows a request to be sent in order to test the target."),
+				docs.FieldCommon(
+					"fallback",
+					"An optional [Bloblang mapping](/docs/guides/bloblang/about) to apply to messages instead of sending requests while the circuit breaker is open.",
+					`root = this
+root.status = "unavailable"`,
+				),
+			),
 			docs.FieldDeprecated("request"),
 		}, client.FieldSpecs()...),
 		Examples: []docs.AnnotatedExample{
@@ -115,12 +142,21 @@ pipeline:
 
 //------------------------------------------------------------------------------
 
+// HTTPCircuitBreakerConfig contains configuration fields for the circuit
+// breaker of the HTTP processor.
+type HTTPCircuitBreakerConfig struct {
+	Failures    int    `json:"failures" yaml:"failures"`
+	ResetPeriod string `json:"reset_period" yaml:"reset_period"`
+	Fallback    string `json:"fallback" yaml:"fallback"`
+}
+
 // HTTPConfig contains configuration fields for the HTTP processor.
 type HTTPConfig struct {
-	Parallel      bool          `json:"parallel" yaml:"parallel"`
-	MaxParallel   int           `json:"max_parallel" yaml:"max_parallel"`
-	Client        client.Config `json:"request" yaml:"request"`
-	client.Config `json:",inline" yaml:",inline"`
+	Parallel       bool                     `json:"parallel" yaml:"parallel"`
+	MaxParallel    int                      `json:"max_parallel" yaml:"max_parallel"`
+	CircuitBreaker HTTPCircuitBreakerConfig `json:"circuit_breaker" yaml:"circuit_breaker"`
+	Client         client.Config            `json:"request" yaml:"request"`
+	client.Config  `json:",inline" yaml:",inline"`
 }
 
 // NewHTTPConfig returns a HTTPConfig with default values.
@@ -129,8 +165,68 @@ func NewHTTPConfig() HTTPConfig {
 		Client:      client.NewConfig(),
 		Parallel:    false,
 		MaxParallel: 0,
-		Config:      client.NewConfig(),
+		CircuitBreaker: HTTPCircuitBreakerConfig{
+			Failures:    0,
+			ResetPeriod: "30s",
+			Fallback:    "",
+		},
+		Config: client.NewConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var errHTTPCircuitOpen = errors.New("circuit breaker is open")
+
+// httpCircuitBreaker tracks consecutive failed requests in order to stop
+// sending requests to a target that is failing.
+type httpCircuitBreaker struct {
+	failures int
+	reset    time.Duration
+
+	consecutive int
+	openedAt    time.Time
+	testing     bool
+
+	now func() time.Time
+	mut sync.Mutex
+}
+
+// allow returns whether a request may be sent.
+func (c *httpCircuitBreaker) allow() bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.openedAt.IsZero() {
+		return true
+	}
+	if c.testing || c.now().Sub(c.openedAt) < c.reset {
+		return false
+	}
+	c.testing = true
+	return true
+}
+
+// report records the result of a request, returning true if the failure of the
+// request opened the circuit breaker.
+func (c *httpCircuitBreaker) report(err error) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if err == nil {
+		c.consecutive = 0
+		c.openedAt = time.Time{}
+		c.testing = false
+		return false
+	}
+
+	c.consecutive++
+	if c.testing || (c.openedAt.IsZero() && c.consecutive >= c.failures) {
+		c.openedAt = c.now()
+		c.testing = false
+		return true
 	}
+	return false
 }
 
 //------------------------------------------------------------------------------
@@ -143,6 +239,9 @@ type HTTP struct {
 	parallel bool
 	max      int
 
+	breaker  *httpCircuitBreaker
+	fallback *mapping.Executor
+
 	conf  Config
 	log   log.Modular
 	stats metrics.Type
@@ -152,6 +251,9 @@ type HTTP struct {
 	mErr       metrics.StatCounter
 	mSent      metrics.StatCounter
 	mBatchSent metrics.StatCounter
+
+	mBreakerOpen  metrics.StatCounter
+	mShortCircuit metrics.StatCounter
 }
 
 // NewHTTP returns a HTTP processor.
@@ -178,8 +280,25 @@ func NewHTTP(
 		mErr:       stats.GetCounter("error"),
 		mSent:      stats.GetCounter("sent"),
 		mBatchSent: stats.GetCounter("batch.sent"),
+
+		mBreakerOpen:  stats.GetCounter("circuit_breaker.open"),
+		mShortCircuit: stats.GetCounter("circuit_breaker.short_circuit"),
 	}
 	var err error
+	if cbConf := conf.HTTP.CircuitBreaker; cbConf.Failures > 0 {
+		g.breaker = &httpCircuitBreaker{
+			failures: cbConf.Failures,
+			now:      time.Now,
+		}
+		if g.breaker.reset, err = time.ParseDuration(cbConf.ResetPeriod); err != nil {
+			return nil, fmt.Errorf("failed to parse circuit breaker reset period: %v", err)
+		}
+		if len(cbConf.Fallback) > 0 {
+			if g.fallback, err = bloblang.NewMapping("", cbConf.Fallback); err != nil {
+				return nil, fmt.Errorf("failed to parse circuit breaker fallback mapping: %w", err)
+			}
+		}
+	}
 	if g.client, err = client.New(
 		conf.HTTP.Config,
 		client.OptSetLogger(g.log),
@@ -193,15 +312,59 @@ func NewHTTP(
 
 //------------------------------------------------------------------------------
 
+// allowRequest returns whether a request may be sent according to the circuit
+// breaker.
+func (h *HTTP) allowRequest() bool {
+	if h.breaker == nil || h.breaker.allow() {
+		return true
+	}
+	h.mShortCircuit.Incr(1)
+	return false
+}
+
+// reportRequest records the result of a request with the circuit breaker.
+func (h *HTTP) reportRequest(err error) {
+	if h.breaker != nil && h.breaker.report(err) {
+		h.mBreakerOpen.Incr(1)
+		h.log.Warnf("Circuit breaker for '%v' opened after failed requests\n", h.conf.HTTP.URL)
+	}
+}
+
+// shortCircuit returns the result of a message of a batch that isn't sent
+// because the circuit breaker is open.
+func (h *HTTP) shortCircuit(index int, msg types.Message) types.Part {
+	if h.fallback == nil {
+		p := msg.Get(index).Copy()
+		FlagErr(p, errHTTPCircuitOpen)
+		return p
+	}
+	p, err := h.fallback.MapPart(index, msg)
+	if err != nil {
+		h.mErr.Incr(1)
+		h.log.Debugf("Circuit breaker fallback mapping failed: %v\n", err)
+		p = msg.Get(index).Copy()
+		FlagErr(p, fmt.Errorf("%v, fallback mapping failed: %w", errHTTPCircuitOpen, err))
+	} else if p == nil {
+		p = msg.Get(index).Copy()
+	}
+	return p
+}
+
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
 	h.mCount.Incr(1)
 	var responseMsg types.Message
 
-	if !h.parallel || msg.Len() == 1 {
+	if (!h.parallel || msg.Len() == 1) && !h.allowRequest() {
+		responseMsg = message.New(nil)
+		for i := 0; i < msg.Len(); i++ {
+			responseMsg.Append(h.shortCircuit(i, msg))
+		}
+	} else if !h.parallel || msg.Len() == 1 {
 		// Easy, just do a single request.
 		resultMsg, err := h.client.Send(msg)
+		h.reportRequest(err)
 		if err != nil {
 			var codeStr string
 			if hErr, ok := err.(types.ErrUnexpectedHTTPRes); ok {
@@ -253,7 +416,13 @@ func (h *HTTP) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 		for i := 0; i < max; i++ {
 			go func() {
 				for index := range reqChan {
+					if !h.allowRequest() {
+						results[index] = h.shortCircuit(index, msg)
+						resChan <- nil
+						continue
+					}
 					result, err := h.client.Send(message.Lock(msg, index))
+					h.reportRequest(err)
 					if err == nil && result.Len() != 1 {
 						err = fmt.Errorf("unexpected response size: %v", result.Len())
 					}
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -406,3 +407,139 @@ func TestHTTPClientParallelCapped(t *testing.T) {
 		t.Errorf("Wrong result: %v != %v", act, exp)
 	}
 }
+
+func TestHTTPClientCircuitBreaker(t *testing.T) {
+	var reqCount uint32
+	var healthy uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		if atomic.LoadUint32(&healthy) == 0 {
+			http.Error(w, "test error", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Config.URL = ts.URL + "/testpost"
+	conf.HTTP.Config.NumRetries = 0
+	conf.HTTP.CircuitBreaker.Failures = 2
+	conf.HTTP.CircuitBreaker.ResetPeriod = "1m"
+
+	proc, err := NewHTTP(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	proc.(*HTTP).breaker.now = func() time.Time {
+		return now
+	}
+
+	for i, test := range []struct {
+		elapsed  time.Duration
+		healthy  bool
+		requests uint32
+		result   string
+		err      string
+	}{
+		{requests: 1, result: "test", err: "HTTP request returned unexpected response code (502): 502 Bad Gateway"},
+		{requests: 2, result: "test", err: "HTTP request returned unexpected response code (502): 502 Bad Gateway"},
+		{requests: 2, result: "test", err: "circuit breaker is open"},
+		{elapsed: time.Minute, requests: 3, result: "test", err: "HTTP request returned unexpected response code (502): 502 Bad Gateway"},
+		{requests: 3, result: "test", err: "circuit breaker is open"},
+		{elapsed: time.Minute, healthy: true, requests: 4, result: "ok"},
+		{requests: 5, result: "ok"},
+	} {
+		now = now.Add(test.elapsed)
+		if test.healthy {
+			atomic.StoreUint32(&healthy, 1)
+		}
+
+		msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("test")}))
+		if res != nil {
+			t.Fatal(res.Error())
+		}
+		if exp, act := test.result, string(msgs[0].Get(0).Get()); exp != act {
+			t.Errorf("Wrong result %v: %v != %v", i, act, exp)
+		}
+		if exp, act := test.err, GetFail(msgs[0].Get(0)); exp != act {
+			t.Errorf("Wrong error %v: %v != %v", i, act, exp)
+		}
+		if exp, act := test.requests, atomic.LoadUint32(&reqCount); exp != act {
+			t.Errorf("Wrong count of requests %v: %v != %v", i, act, exp)
+		}
+	}
+}
+
+func TestHTTPClientCircuitBreakerFallback(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "test error", http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Config.URL = ts.URL + "/testpost"
+	conf.HTTP.Config.NumRetries = 0
+	conf.HTTP.Parallel = true
+	conf.HTTP.MaxParallel = 1
+	conf.HTTP.CircuitBreaker.Failures = 1
+	conf.HTTP.CircuitBreaker.Fallback = `root = content().uppercase()`
+
+	proc, err := NewHTTP(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"),
+	}))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if exp, act := [][]byte{[]byte("foo"), []byte("BAR"), []byte("BAZ")}, message.GetAllBytes(msgs[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	if !HasFailed(msgs[0].Get(0)) {
+		t.Error("Expected first message to fail")
+	}
+	if HasFailed(msgs[0].Get(1)) || HasFailed(msgs[0].Get(2)) {
+		t.Error("Expected fallback messages not to fail")
+	}
+}
+
+func TestHTTPClientExtractHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "10")
+		w.Header().Set("X-Request-Id", "foo")
+		w.Header().Set("X-Other", "bar")
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTP.Config.URL = ts.URL + "/testpost"
+	conf.HTTP.Config.ExtractHeaders.IncludePrefixes = []string{"X-RateLimit-"}
+	conf.HTTP.Config.ExtractHeaders.IncludePatterns = []string{"^x-request-id$"}
+
+	proc, err := NewHTTP(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("test")}))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	meta := msgs[0].Get(0).Metadata()
+	if exp, act := "10", meta.Get("x-ratelimit-remaining"); exp != act {
+		t.Errorf("Wrong metadata value: %v != %v", act, exp)
+	}
+	if exp, act := "foo", meta.Get("x-request-id"); exp != act {
+		t.Errorf("Wrong metadata value: %v != %v", act, exp)
+	}
+	if exp, act := "", meta.Get("x-other"); exp != act {
+		t.Errorf("Wrong metadata value: %v != %v", act, exp)
+	}
+}
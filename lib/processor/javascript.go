@@ -0,0 +1,426 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/dop251/goja"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeJavaScript] = TypeSpec{
+		constructor: NewJavaScript,
+		Categories: []Category{
+			CategoryMapping,
+		},
+		Summary: `
+Executes a JavaScript program for each message, which can modify the contents
+and metadata of the message.`,
+		Beta: true,
+		Description: `
+The program is executed with an embedded ECMAScript 5.1 interpreter implemented
+in pure Go, and therefore custom logic can be deployed without compiling plugins.
+The message being processed is exposed to the program as the global object
+` + "`msg`" + `, and changes made to it are applied to the message once the
+program finishes.
+
+When the program throws an error, or exceeds the ` + "`timeout`" + `, the
+message is left unchanged and flagged as failed, which can be handled with
+[error handling patterns](/docs/configuration/error_handling).
+
+Programs are executed one message at a time within the same runtime. Variables
+declared with ` + "`var`" + ` are reset for each message, but properties
+assigned to the global object, such as ` + "`this.count`" + `, persist between
+messages.`,
+		Footnotes: `
+## API
+
+### ` + "`msg.content`" + `
+
+The contents of the message as a string. When the value is replaced with
+anything other than a string it is serialised as JSON.
+
+### ` + "`msg.meta`" + `
+
+An object containing the metadata of the message, where each value is a string.
+Keys can be added, modified or deleted, and values that are not strings are
+converted to strings.
+
+### ` + "`fetch(url, options)`" + `
+
+Performs an HTTP request and returns the response as an object with the fields
+` + "`status`" + `, ` + "`headers`" + ` and ` + "`body`" + `, where header
+names are lower case and the body is a string. Unlike the ` + "`fetch`" + `
+function of browsers the request is synchronous and an object is returned rather
+than a promise. The optional ` + "`options`" + ` object supports the fields
+` + "`method`" + `, ` + "`headers`" + ` and ` + "`body`" + `.
+
+Failed requests, and responses exceeding the limits set within the field
+` + "`fetch`" + `, throw an error.
+
+### ` + "`console.log(...values)`" + `
+
+Prints a log message at the INFO level.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("code", "The JavaScript program to execute.", `msg.content = msg.content.toUpperCase();`),
+			docs.FieldCommon("file", "The path of a file containing a JavaScript program to execute, which is used instead of `code`."),
+			docs.FieldAdvanced("timeout", "The maximum period of time to execute the program for each message, set to an empty string in order to disable the timeout."),
+			docs.FieldAdvanced("fetch", "Limits for HTTP requests made with `fetch`.").WithChildren(
+				docs.FieldAdvanced("timeout", "The maximum period of time to wait for each request."),
+				docs.FieldAdvanced("max_calls", "The maximum number of requests made for each message, set to zero in order to disable `fetch`."),
+				docs.FieldAdvanced("max_response_bytes", "The maximum size of response bodies."),
+			),
+			partsFieldSpec,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Enrichment",
+				Summary: `
+Here the name of a user is fetched from an HTTP service and added to each
+message, along with metadata recording where the name came from:`,
+				Config: `
+pipeline:
+  processors:
+    - javascript:
+        code: |
+          var doc = JSON.parse(msg.content);
+          var res = fetch("http://users:8080/users/" + doc.user_id);
+          if (res.status !== 200) {
+            throw new Error("user lookup failed: " + res.status);
+          }
+          doc.user_name = JSON.parse(res.body).name;
+          msg.content = doc;
+          msg.meta.user_source = "users";
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// JavaScriptFetchConfig contains configuration fields for limiting the HTTP
+// requests made by JavaScript programs.
+type JavaScriptFetchConfig struct {
+	Timeout          string `json:"timeout" yaml:"timeout"`
+	MaxCalls         int    `json:"max_calls" yaml:"max_calls"`
+	MaxResponseBytes int64  `json:"max_response_bytes" yaml:"max_response_bytes"`
+}
+
+// JavaScriptConfig contains configuration fields for the JavaScript processor.
+type JavaScriptConfig struct {
+	Code    string                `json:"code" yaml:"code"`
+	File    string                `json:"file" yaml:"file"`
+	Timeout string                `json:"timeout" yaml:"timeout"`
+	Fetch   JavaScriptFetchConfig `json:"fetch" yaml:"fetch"`
+	Parts   []int                 `json:"parts" yaml:"parts"`
+}
+
+// NewJavaScriptConfig returns a JavaScriptConfig with default values.
+func NewJavaScriptConfig() JavaScriptConfig {
+	return JavaScriptConfig{
+		Code:    "",
+		File:    "",
+		Timeout: "5s",
+		Fetch: JavaScriptFetchConfig{
+			Timeout:          "5s",
+			MaxCalls:         10,
+			MaxResponseBytes: 1048576,
+		},
+		Parts: []int{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var errJavaScriptTimeout = errors.New("program execution timed out")
+
+// JavaScript is a processor that executes a JavaScript program for each
+// message.
+type JavaScript struct {
+	parts   []int
+	program *goja.Program
+	timeout time.Duration
+
+	client        *http.Client
+	fetchMaxCalls int
+	fetchMaxBytes int64
+	fetchCalls    int
+
+	vm  *goja.Runtime
+	mut sync.Mutex
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewJavaScript returns a JavaScript processor.
+func NewJavaScript(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	code, name := conf.JavaScript.Code, "javascript"
+	if conf.JavaScript.File != "" {
+		if code != "" {
+			return nil, errors.New("a code and a file cannot both be specified")
+		}
+		codeBytes, err := ioutil.ReadFile(conf.JavaScript.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %v", err)
+		}
+		code, name = string(codeBytes), conf.JavaScript.File
+	}
+	if code == "" {
+		return nil, errors.New("a code or a file must be specified")
+	}
+
+	program, err := goja.Compile(name, code, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile program: %v", err)
+	}
+
+	var timeout time.Duration
+	if conf.JavaScript.Timeout != "" {
+		if timeout, err = time.ParseDuration(conf.JavaScript.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %v", err)
+		}
+	}
+
+	var fetchTimeout time.Duration
+	if conf.JavaScript.Fetch.Timeout != "" {
+		if fetchTimeout, err = time.ParseDuration(conf.JavaScript.Fetch.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse fetch timeout: %v", err)
+		}
+	}
+
+	j := &JavaScript{
+		parts:   conf.JavaScript.Parts,
+		program: program,
+		timeout: timeout,
+
+		client: &http.Client{
+			Timeout: fetchTimeout,
+		},
+		fetchMaxCalls: conf.JavaScript.Fetch.MaxCalls,
+		fetchMaxBytes: conf.JavaScript.Fetch.MaxResponseBytes,
+
+		vm:  goja.New(),
+		log: log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	console := j.vm.NewObject()
+	if err = console.Set("log", j.consoleLog); err != nil {
+		return nil, err
+	}
+	j.vm.Set("console", console)
+	j.vm.Set("fetch", j.fetch)
+	return j, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (j *JavaScript) consoleLog(call goja.FunctionCall) goja.Value {
+	args := make([]string, len(call.Arguments))
+	for i, arg := range call.Arguments {
+		args[i] = arg.String()
+	}
+	j.log.Infoln(strings.Join(args, " "))
+	return goja.Undefined()
+}
+
+// fetch performs an HTTP request on behalf of the program, where errors are
+// thrown within the program.
+func (j *JavaScript) fetch(call goja.FunctionCall) goja.Value {
+	res, err := j.doFetch(call)
+	if err != nil {
+		panic(j.vm.NewGoError(err))
+	}
+	return j.vm.ToValue(res)
+}
+
+func (j *JavaScript) doFetch(call goja.FunctionCall) (map[string]interface{}, error) {
+	if j.fetchCalls >= j.fetchMaxCalls {
+		return nil, fmt.Errorf("fetch call limit of %v reached", j.fetchMaxCalls)
+	}
+	j.fetchCalls++
+
+	method, headers := "GET", map[string]string{}
+	var body io.Reader
+	if opts := call.Argument(1); !goja.IsUndefined(opts) && !goja.IsNull(opts) {
+		optsObj := opts.ToObject(j.vm)
+		if v := optsObj.Get("method"); v != nil && !goja.IsUndefined(v) {
+			method = v.String()
+		}
+		if v := optsObj.Get("body"); v != nil && !goja.IsUndefined(v) && !goja.IsNull(v) {
+			body = strings.NewReader(v.String())
+		}
+		if v := optsObj.Get("headers"); v != nil && !goja.IsUndefined(v) && !goja.IsNull(v) {
+			headersObj := v.ToObject(j.vm)
+			for _, k := range headersObj.Keys() {
+				headers[k] = headersObj.Get(k).String()
+			}
+		}
+	}
+
+	req, err := http.NewRequest(method, call.Argument(0).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	resBytes, err := ioutil.ReadAll(io.LimitReader(resp.Body, j.fetchMaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if int64(len(resBytes)) > j.fetchMaxBytes {
+		return nil, fmt.Errorf("response body exceeds limit of %v bytes", j.fetchMaxBytes)
+	}
+
+	resHeaders := map[string]interface{}{}
+	for k, v := range resp.Header {
+		resHeaders[strings.ToLower(k)] = strings.Join(v, ", ")
+	}
+	return map[string]interface{}{
+		"status":  resp.StatusCode,
+		"headers": resHeaders,
+		"body":    string(resBytes),
+	}, nil
+}
+
+// run executes the program for a message part and applies the changes made to
+// the msg object to the part.
+func (j *JavaScript) run(part types.Part) error {
+	meta := j.vm.NewObject()
+	part.Metadata().Iter(func(k, v string) error {
+		return meta.Set(k, v)
+	})
+	msgObj := j.vm.NewObject()
+	if err := msgObj.Set("content", string(part.Get())); err != nil {
+		return err
+	}
+	if err := msgObj.Set("meta", meta); err != nil {
+		return err
+	}
+	j.vm.Set("msg", msgObj)
+	j.fetchCalls = 0
+
+	var timer *time.Timer
+	interrupted := make(chan struct{})
+	if j.timeout > 0 {
+		timer = time.AfterFunc(j.timeout, func() {
+			j.vm.Interrupt(errJavaScriptTimeout)
+			close(interrupted)
+		})
+	}
+	_, err := j.vm.RunProgram(j.program)
+	if timer != nil && !timer.Stop() {
+		// Wait for a late interrupt so that it can't leak into the next run.
+		<-interrupted
+	}
+	j.vm.ClearInterrupt()
+	if err != nil {
+		var iErr *goja.InterruptedError
+		if errors.As(err, &iErr) {
+			return errJavaScriptTimeout
+		}
+		return err
+	}
+
+	newContent := []byte{}
+	if v := msgObj.Get("content"); v != nil && !goja.IsUndefined(v) {
+		if s, ok := v.Export().(string); ok {
+			newContent = []byte(s)
+		} else if newContent, err = json.Marshal(v.Export()); err != nil {
+			return fmt.Errorf("failed to serialise content: %v", err)
+		}
+	}
+
+	newMeta := map[string]string{}
+	if v := msgObj.Get("meta"); v != nil && !goja.IsUndefined(v) && !goja.IsNull(v) {
+		metaObj := v.ToObject(j.vm)
+		for _, k := range metaObj.Keys() {
+			newMeta[k] = metaObj.Get(k).String()
+		}
+	}
+
+	part.Set(newContent)
+	var oldKeys []string
+	part.Metadata().Iter(func(k, _ string) error {
+		oldKeys = append(oldKeys, k)
+		return nil
+	})
+	for _, k := range oldKeys {
+		part.Metadata().Delete(k)
+	}
+	for k, v := range newMeta {
+		part.Metadata().Set(k, v)
+	}
+	return nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (j *JavaScript) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	j.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	j.mut.Lock()
+	defer j.mut.Unlock()
+
+	proc := func(index int, span opentracing.Span, part types.Part) error {
+		if err := j.run(part); err != nil {
+			j.mErr.Incr(1)
+			j.log.Debugf("Failed to execute program: %v\n", err)
+			return err
+		}
+		return nil
+	}
+
+	IteratePartsWithSpan(TypeJavaScript, j.parts, newMsg, proc)
+
+	j.mBatchSent.Incr(1)
+	j.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (j *JavaScript) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (j *JavaScript) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
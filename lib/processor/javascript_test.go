@@ -0,0 +1,224 @@
+package processor
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJavaScript(t *testing.T, code string) Type {
+	t.Helper()
+
+	conf := NewConfig()
+	conf.Type = TypeJavaScript
+	conf.JavaScript.Code = code
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	return proc
+}
+
+func TestJavaScriptContentAndMeta(t *testing.T) {
+	proc := newTestJavaScript(t, `
+msg.content = msg.content.toUpperCase();
+msg.meta.bar = msg.meta.foo + " world";
+msg.meta.count = 10;
+delete msg.meta.foo;
+`)
+
+	input := message.New([][]byte{[]byte("hello")})
+	input.Get(0).Metadata().Set("foo", "hello")
+
+	msgs, res := proc.ProcessMessage(input)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	part := msgs[0].Get(0)
+	assert.Equal(t, "HELLO", string(part.Get()))
+	assert.Equal(t, "", part.Metadata().Get("foo"))
+	assert.Equal(t, "hello world", part.Metadata().Get("bar"))
+	assert.Equal(t, "10", part.Metadata().Get("count"))
+
+	assert.Equal(t, "hello", string(input.Get(0).Get()))
+	assert.Equal(t, "hello", input.Get(0).Metadata().Get("foo"))
+}
+
+func TestJavaScriptJSONContent(t *testing.T) {
+	proc := newTestJavaScript(t, `
+var doc = JSON.parse(msg.content);
+doc.total = doc.values.reduce(function(a, b) { return a + b; }, 0);
+msg.content = doc;
+`)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"values":[1,2,3]}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, `{"total":6,"values":[1,2,3]}`, string(msgs[0].Get(0).Get()))
+}
+
+func TestJavaScriptGlobalState(t *testing.T) {
+	proc := newTestJavaScript(t, `
+var count = 10;
+this.total = (this.total || 0) + 1;
+msg.content = msg.content + " " + this.total + " " + count;
+`)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte("foo"), []byte("bar"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{[]byte("foo 1 10"), []byte("bar 2 10")}, message.GetAllBytes(msgs[0]))
+}
+
+func TestJavaScriptErrors(t *testing.T) {
+	proc := newTestJavaScript(t, `
+if (msg.content === "fail") {
+  throw new Error("nope");
+}
+msg.content = "processed: " + msg.content;
+msg.meta.processed = "true";
+`)
+
+	input := message.New([][]byte{[]byte("fail"), []byte("foo")})
+	msgs, res := proc.ProcessMessage(input)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{[]byte("fail"), []byte("processed: foo")}, message.GetAllBytes(msgs[0]))
+	assert.Contains(t, GetFail(msgs[0].Get(0)), "Error: nope")
+	assert.Equal(t, "", msgs[0].Get(0).Metadata().Get("processed"))
+	assert.False(t, HasFailed(msgs[0].Get(1)))
+}
+
+func TestJavaScriptTimeout(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeJavaScript
+	conf.JavaScript.Code = `
+if (msg.content === "loop") {
+  while (true) {}
+}
+msg.content = "processed: " + msg.content;
+`
+	conf.JavaScript.Timeout = "10ms"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("loop"), []byte("foo")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{[]byte("loop"), []byte("processed: foo")}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "program execution timed out", GetFail(msgs[0].Get(0)))
+	assert.False(t, HasFailed(msgs[0].Get(1)))
+}
+
+func TestJavaScriptFetch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBytes, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		if r.URL.Path == "/big" {
+			w.Write(make([]byte, 100))
+			return
+		}
+		w.Write([]byte(r.Header.Get("X-Foo") + ": " + string(reqBytes)))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.Type = TypeJavaScript
+	conf.JavaScript.Code = `
+var res;
+if (msg.content === "many") {
+  fetch(msg.meta.url);
+  fetch(msg.meta.url);
+}
+if (msg.content === "big") {
+  res = fetch(msg.meta.url + "/big");
+} else {
+  res = fetch(msg.meta.url, {method: "POST", headers: {"X-Foo": "foo"}, body: msg.content});
+}
+msg.content = res.body;
+msg.meta.status = res.status;
+msg.meta.method = res.headers["x-method"];
+`
+	conf.JavaScript.Fetch.MaxCalls = 2
+	conf.JavaScript.Fetch.MaxResponseBytes = 50
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := message.New([][]byte{[]byte("hello"), []byte("many"), []byte("big")})
+	input.Iter(func(i int, p types.Part) error {
+		p.Metadata().Set("url", ts.URL)
+		return nil
+	})
+
+	msgs, res := proc.ProcessMessage(input)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	part := msgs[0].Get(0)
+	assert.Equal(t, "foo: hello", string(part.Get()))
+	assert.Equal(t, "200", part.Metadata().Get("status"))
+	assert.Equal(t, "POST", part.Metadata().Get("method"))
+
+	assert.Contains(t, GetFail(msgs[0].Get(1)), "fetch call limit of 2 reached")
+	assert.Contains(t, GetFail(msgs[0].Get(2)), "response body exceeds limit of 50 bytes")
+}
+
+func TestJavaScriptFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "benthos_javascript_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "program.js")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`msg.content = msg.content + " from file";`), 0644))
+
+	conf := NewConfig()
+	conf.Type = TypeJavaScript
+	conf.JavaScript.File = path
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("hello")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "hello from file", string(msgs[0].Get(0).Get()))
+
+	conf.JavaScript.Code = "msg.content = 'foo';"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "a code and a file cannot both be specified")
+}
+
+func TestJavaScriptBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeJavaScript
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "a code or a file must be specified")
+
+	conf.JavaScript.Code = "msg.content = ;"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compile program: ")
+
+	conf.JavaScript.Code = "msg.content = 'foo';"
+	conf.JavaScript.Timeout = "nope"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, `failed to parse timeout: time: invalid duration "nope"`)
+}
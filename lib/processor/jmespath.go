@@ -30,7 +30,16 @@ For better performance and improved capabilities try out native Benthos mapping
 
 It is possible to create boolean queries with JMESPath, in order to filter
 messages with boolean queries please instead use the
-` + "[`jmespath`](/docs/components/conditions/jmespath)" + ` condition.`,
+` + "[`jmespath`](/docs/components/conditions/jmespath)" + ` condition.
+
+### Functions
+
+Queries support the [built-in functions](https://jmespath.org/specification.html#built-in-functions)
+of the JMESPath specification, and custom functions cannot be added. Functions
+for parsing timestamps and manipulating strings are available from
+[Bloblang](/docs/guides/bloblang/about), which can be executed on the result of
+a query with a ` + "[`bloblang`](/docs/components/processors/bloblang)" + `
+processor.`,
 		Examples: []docs.AnnotatedExample{
 			{
 				Title: "Mapping",
@@ -60,6 +69,22 @@ pipeline:
   processors:
     - jmespath:
         query: "locations[?state == 'WA'].name | sort(@) | {Cities: join(', ', @)}"
+`,
+			},
+			{
+				Title: "Timestamps and Strings",
+				Summary: `
+Values selected with a query can be further transformed with Bloblang, here the
+timestamp of each event is parsed into a unix timestamp and the name is
+uppercased:`,
+				Config: `
+pipeline:
+  processors:
+    - jmespath:
+        query: "events[?type == 'login'] | [0].{name: user.name, ts: created_at}"
+    - bloblang: |
+        root.name = this.name.uppercase()
+        root.ts = this.ts.parse_timestamp_unix()
 `,
 			},
 		},
@@ -7,11 +7,13 @@ import (
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
 	"github.com/itchyny/gojq"
 	"github.com/opentracing/opentracing-go"
+	olog "github.com/opentracing/opentracing-go/log"
 )
 
 func init() {
@@ -42,7 +44,9 @@ differences in how these queries are executed versus the jq cli which you can
 
 If the query does not emit any value then the message is filtered, if the query
 returns multiple values then the resulting message will be an array containing
-all values.
+all values. When ` + "`split`" + ` is enabled each value emitted by the query
+is instead a message of the resulting batch, with the metadata of the message
+it was emitted from.
 
 The full query syntax is described in [jq's documentation][jq-docs].
 
@@ -84,12 +88,26 @@ pipeline:
   processors:
     - jq:
         query: '{Cities: .locations | map(select(.state == "WA").name) | sort | join(", ") }'
+`,
+			},
+			{
+				Title: "Splitting",
+				Summary: `
+With the same documents we could instead emit a message for each location in
+the state of Washington with the following config:`,
+				Config: `
+pipeline:
+  processors:
+    - jq:
+        query: '.locations[] | select(.state == "WA")'
+        split: true
 `,
 			},
 		},
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("query", "The jq query to filter and transform messages with."),
 			docs.FieldAdvanced("raw", "Whether to process the input as a raw string instead of as JSON."),
+			docs.FieldAdvanced("split", "Whether each value emitted by the query is a separate message rather than an element of an array."),
 		},
 	}
 }
@@ -100,6 +118,7 @@ pipeline:
 type JQConfig struct {
 	Query string `json:"query" yaml:"query"`
 	Raw   bool   `json:"raw" yaml:"raw"`
+	Split bool   `json:"split" yaml:"split"`
 }
 
 // NewJQConfig returns a JQConfig with default values.
@@ -195,60 +214,114 @@ func (j *JQ) getPartValue(part types.Part, raw bool) (obj interface{}, err error
 	return obj, nil
 }
 
-// ProcessMessage applies the processor to a message, either creating >0
-// resulting messages or a response to be sent back to the message source.
-func (j *JQ) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
-	j.mCount.Incr(1)
+func (j *JQ) queryPart(part types.Part) ([]interface{}, error) {
+	in, err := j.getPartValue(part, j.conf.Raw)
+	if err != nil {
+		j.mErr.Incr(1)
+		return nil, err
+	}
+	metadata := j.getPartMetadata(part)
+
+	var emitted []interface{}
+	iter := j.code.Run(in, metadata)
+	for {
+		out, ok := iter.Next()
+		if !ok {
+			break
+		}
 
-	newMsg := msg.Copy()
-	iteratePartsFilterableWithSpan(TypeJQ, nil, newMsg, func(index int, span opentracing.Span, part types.Part) (bool, error) {
-		in, err := j.getPartValue(part, j.conf.Raw)
-		if err != nil {
+		if err, ok := out.(error); ok {
+			j.log.Debugf("Failed to query part: %v\n", err)
 			j.mErr.Incr(1)
-			return false, err
+			j.mErrQuery.Incr(1)
+			return nil, err
 		}
-		metadata := j.getPartMetadata(part)
-
-		var emitted []interface{}
-		iter := j.code.Run(in, metadata)
-		for {
-			out, ok := iter.Next()
-			if !ok {
-				break
-			}
 
-			if err, ok := out.(error); ok {
-				j.log.Debugf("Failed to query part: %v\n", err)
-				j.mErr.Incr(1)
-				j.mErrQuery.Incr(1)
-				return false, err
-			}
+		j.mSent.Incr(1)
+		emitted = append(emitted, out)
+	}
+	return emitted, nil
+}
 
-			j.mSent.Incr(1)
-			emitted = append(emitted, out)
-		}
+func (j *JQ) setPartJSON(part types.Part, v interface{}) error {
+	if err := part.SetJSON(v); err != nil {
+		j.log.Debugf("Failed to set part JSON: %v\n", err)
+		j.mErr.Incr(1)
+		j.mErrJSONSet.Incr(1)
+		return err
+	}
+	return nil
+}
 
-		if len(emitted) > 1 {
-			if err = part.SetJSON(emitted); err != nil {
-				j.log.Debugf("Failed to set part JSON: %v\n", err)
-				j.mErr.Incr(1)
-				j.mErrJSONSet.Incr(1)
-				return false, err
+func (j *JQ) processSplit(msg types.Message) types.Message {
+	newMsg := message.New(nil)
+	msg.Iter(func(i int, part types.Part) error {
+		span := tracing.CreateChildSpan(TypeJQ, part)
+		defer span.Finish()
+
+		emitted, err := j.queryPart(part)
+		if err == nil {
+			newParts := make([]types.Part, 0, len(emitted))
+			for _, v := range emitted {
+				newPart := message.NewPart(nil)
+				newPart.SetMetadata(part.Metadata().Copy())
+				if err = j.setPartJSON(newPart, v); err != nil {
+					break
+				}
+				newParts = append(newParts, newPart)
 			}
-		} else if len(emitted) == 1 {
-			if err = part.SetJSON(emitted[0]); err != nil {
-				j.log.Debugf("Failed to set part JSON: %v\n", err)
-				j.mErr.Incr(1)
-				j.mErrJSONSet.Incr(1)
-				return false, err
+			if err == nil {
+				if len(newParts) == 0 {
+					j.mDroppedParts.Incr(1)
+				}
+				newMsg.Append(newParts...)
+				return nil
 			}
-		} else {
-			j.mDroppedParts.Incr(1)
-			return false, nil
 		}
 
-		return true, nil
+		newMsg.Append(part.Copy())
+		FlagErr(newMsg.Get(-1), err)
+		span.LogFields(
+			olog.String("event", "error"),
+			olog.String("type", err.Error()),
+		)
+		return nil
 	})
+	return newMsg
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (j *JQ) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	j.mCount.Incr(1)
+
+	var newMsg types.Message
+	if j.conf.Split {
+		newMsg = j.processSplit(msg)
+	} else {
+		newMsg = msg.Copy()
+		iteratePartsFilterableWithSpan(TypeJQ, nil, newMsg, func(index int, span opentracing.Span, part types.Part) (bool, error) {
+			emitted, err := j.queryPart(part)
+			if err != nil {
+				return false, err
+			}
+
+			if len(emitted) > 1 {
+				if err = j.setPartJSON(part, emitted); err != nil {
+					return false, err
+				}
+			} else if len(emitted) == 1 {
+				if err = j.setPartJSON(part, emitted[0]); err != nil {
+					return false, err
+				}
+			} else {
+				j.mDroppedParts.Incr(1)
+				return false, nil
+			}
+
+			return true, nil
+		})
+	}
 
 	if newMsg.Len() == 0 {
 		j.mDropped.Incr(1)
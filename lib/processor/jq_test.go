@@ -139,3 +139,40 @@ func TestJQ(t *testing.T) {
 		assert.Equal(t, test.output, string(message.GetAllBytes(msgs[0])[0]))
 	}
 }
+
+func TestJQSplit(t *testing.T) {
+	conf := NewConfig()
+	conf.JQ.Query = `.foo[] | select(. != "skip")`
+	conf.JQ.Split = true
+
+	jSet, err := NewJQ(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgIn := message.New([][]byte{
+		[]byte(`{"foo":["a",{"b":1}]}`),
+		[]byte(`{"foo":["skip"]}`),
+		[]byte(`not json`),
+		[]byte(`{"foo":[2]}`),
+	})
+	msgIn.Get(0).Metadata().Set("index", "0")
+	msgIn.Get(3).Metadata().Set("index", "3")
+
+	msgs, res := jSet.ProcessMessage(msgIn)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{
+		[]byte(`"a"`),
+		[]byte(`{"b":1}`),
+		[]byte(`not json`),
+		[]byte(`2`),
+	}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "0", msgs[0].Get(0).Metadata().Get("index"))
+	assert.Equal(t, "0", msgs[0].Get(1).Metadata().Get("index"))
+	assert.True(t, HasFailed(msgs[0].Get(2)))
+	assert.Equal(t, "3", msgs[0].Get(3).Metadata().Get("index"))
+
+	msgs, res = jSet.ProcessMessage(message.New([][]byte{[]byte(`{"foo":[]}`)}))
+	assert.Empty(t, msgs)
+	assert.NotNil(t, res)
+}
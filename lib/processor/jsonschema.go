@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -30,7 +31,22 @@ payload under any circumstances. If a message does not match the schema it can
 be caught using error handling methods outlined [here](/docs/configuration/error_handling).`,
 		Description: `
 Please refer to the [JSON Schema website](https://json-schema.org/) for
-information and tutorials regarding the syntax of the schema.`,
+information and tutorials regarding the syntax of the schema. Schemas of drafts
+4, 6 and 7 are supported, where the draft is determined by the ` + "`$schema`" + `
+keyword and otherwise defaults to the latest.
+
+Schemas loaded from ` + "`schema_path`" + `, including those that it
+references, are fetched once when the processor is created and reused for all
+messages.
+
+### Error Routing
+
+When ` + "`errors_metadata_key`" + ` is set, messages that fail validation have
+the metadata key set to a JSON array of their validation errors, each being an
+object with the ` + "`field`" + ` that failed and a ` + "`description`" + ` of
+the failure. Since the metadata is kept when messages are written to other
+destinations, this allows consumers of a dead letter queue to inspect why a
+message failed.`,
 		Footnotes: `
 ## Examples
 
@@ -81,10 +97,35 @@ If a payload being processed looked like:
 ` + "```" + `
 
 Then a log message would appear explaining the fault and the payload would be
-dropped.`,
+dropped.
+
+Alternatively, messages that fail validation can be routed to a dead letter
+queue along with their validation errors:
+
+` + "```yaml" + `
+pipeline:
+  processors:
+  - json_schema:
+      schema_path: "file://path_to_schema.json"
+      errors_metadata_key: validation_errors
+
+output:
+  switch:
+    cases:
+    - check: errored()
+      output:
+        kafka:
+          addresses: [ localhost:9092 ]
+          topic: people_dlq
+    - output:
+        kafka:
+          addresses: [ localhost:9092 ]
+          topic: people
+` + "```" + ``,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("schema", "A schema to apply. Use either this or the `schema_path` field."),
-			docs.FieldCommon("schema_path", "The path of a schema document to apply. Use either this or the `schema` field."),
+			docs.FieldCommon("schema_path", "The path of a schema document to apply, which must begin with `file://`, `http://` or `https://`. Use either this or the `schema` field."),
+			docs.FieldAdvanced("errors_metadata_key", "An optional metadata key to set to the [validation errors](#error-routing) of messages that fail validation.", "validation_errors"),
 			partsFieldSpec,
 		},
 	}
@@ -95,17 +136,19 @@ dropped.`,
 // JSONSchemaConfig is a configuration struct containing fields for the
 // jsonschema processor.
 type JSONSchemaConfig struct {
-	Parts      []int  `json:"parts" yaml:"parts"`
-	SchemaPath string `json:"schema_path" yaml:"schema_path"`
-	Schema     string `json:"schema" yaml:"schema"`
+	Parts             []int  `json:"parts" yaml:"parts"`
+	SchemaPath        string `json:"schema_path" yaml:"schema_path"`
+	Schema            string `json:"schema" yaml:"schema"`
+	ErrorsMetadataKey string `json:"errors_metadata_key" yaml:"errors_metadata_key"`
 }
 
 // NewJSONSchemaConfig returns a JSONSchemaConfig with default values.
 func NewJSONSchemaConfig() JSONSchemaConfig {
 	return JSONSchemaConfig{
-		Parts:      []int{},
-		SchemaPath: "",
-		Schema:     "",
+		Parts:             []int{},
+		SchemaPath:        "",
+		Schema:            "",
+		ErrorsMetadataKey: "",
 	}
 }
 
@@ -134,8 +177,10 @@ func NewJSONSchema(
 
 	// load JSONSchema definition
 	if schemaPath := conf.JSONSchema.SchemaPath; schemaPath != "" {
-		if !(strings.HasPrefix(schemaPath, "file://") || strings.HasPrefix(schemaPath, "http://")) {
-			return nil, fmt.Errorf("invalid schema_path provided, must start with file:// or http://")
+		if !(strings.HasPrefix(schemaPath, "file://") ||
+			strings.HasPrefix(schemaPath, "http://") ||
+			strings.HasPrefix(schemaPath, "https://")) {
+			return nil, fmt.Errorf("invalid schema_path provided, must start with file://, http:// or https://")
 		}
 
 		schema, err = jsonschema.NewSchema(jsonschema.NewReferenceLoader(conf.JSONSchema.SchemaPath))
@@ -152,6 +197,7 @@ func NewJSONSchema(
 	}
 
 	return &JSONSchema{
+		conf:   conf.JSONSchema,
 		stats:  stats,
 		log:    log,
 		schema: schema,
@@ -166,6 +212,16 @@ func NewJSONSchema(
 
 //------------------------------------------------------------------------------
 
+// jsonSchemaErrorDescription returns a lowercase description of a validation
+// error, keeping the case of the property that the error refers to.
+func jsonSchemaErrorDescription(desc jsonschema.ResultError) string {
+	description := strings.ToLower(desc.Description())
+	if property := desc.Details()["property"]; property != nil {
+		description = property.(string) + strings.TrimPrefix(description, strings.ToLower(property.(string)))
+	}
+	return description
+}
+
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (s *JSONSchema) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
@@ -192,15 +248,21 @@ func (s *JSONSchema) ProcessMessage(msg types.Message) ([]types.Message, types.R
 			s.log.Debugf("The document is not valid\n")
 			s.mErr.Incr(1)
 			var errStr string
+			errObjs := make([]interface{}, 0, len(result.Errors()))
 			for i, desc := range result.Errors() {
 				if i > 0 {
 					errStr = errStr + "\n"
 				}
-				description := strings.ToLower(desc.Description())
-				if property := desc.Details()["property"]; property != nil {
-					description = property.(string) + strings.TrimPrefix(description, strings.ToLower(property.(string)))
-				}
+				description := jsonSchemaErrorDescription(desc)
 				errStr = errStr + desc.Field() + " " + description
+				errObjs = append(errObjs, map[string]interface{}{
+					"field":       desc.Field(),
+					"description": description,
+				})
+			}
+			if s.conf.ErrorsMetadataKey != "" {
+				errBytes, _ := json.Marshal(errObjs)
+				part.Metadata().Set(s.conf.ErrorsMetadataKey, string(errBytes))
 			}
 			return errors.New(errStr)
 		}
@@ -354,3 +354,54 @@ func TestJSONSchemaInvalidSchema(t *testing.T) {
 		t.Error("expected error from loading bad schema")
 	}
 }
+
+func TestJSONSchemaErrorsMetadata(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+		  "age": {
+			"type": "integer",
+			"minimum": 0
+		  }
+		}
+	}`
+
+	conf := NewConfig()
+	conf.JSONSchema.Schema = schema
+	conf.JSONSchema.ErrorsMetadataKey = "validation_errors"
+	conf.JSONSchema.Parts = []int{0, 1}
+
+	c, err := NewJSONSchema(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, _ := c.ProcessMessage(message.New([][]byte{
+		[]byte(`{"name":"John","age":21}`),
+		[]byte(`{"age":-20}`),
+		[]byte(`{"age":-20}`),
+	}))
+	if len(msgs) != 1 {
+		t.Fatal("Wrong count of messages")
+	}
+
+	if HasFailed(msgs[0].Get(0)) {
+		t.Errorf("Unexpected failure: %v", GetFail(msgs[0].Get(0)))
+	}
+	if act := msgs[0].Get(0).Metadata().Get("validation_errors"); act != "" {
+		t.Errorf("Unexpected validation errors: %v", act)
+	}
+
+	if !HasFailed(msgs[0].Get(1)) {
+		t.Error("Expected failure")
+	}
+	exp := `[{"description":"name is required","field":"(root)"},{"description":"must be greater than or equal to 0","field":"age"}]`
+	if act := msgs[0].Get(1).Metadata().Get("validation_errors"); act != exp {
+		t.Errorf("Wrong validation errors: %v != %v", act, exp)
+	}
+
+	if HasFailed(msgs[0].Get(2)) {
+		t.Error("Expected part outside of parts to be skipped")
+	}
+}
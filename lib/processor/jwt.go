@@ -0,0 +1,247 @@
+package processor
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/dgrijalva/jwt-go"
+)
+
+//------------------------------------------------------------------------------
+
+// JWKSConfig contains configuration fields for fetching the keys used to verify
+// JSON web tokens from a JWKS endpoint.
+type JWKSConfig struct {
+	URL           string `json:"url" yaml:"url"`
+	RefreshPeriod string `json:"refresh_period" yaml:"refresh_period"`
+	Timeout       string `json:"timeout" yaml:"timeout"`
+}
+
+// NewJWKSConfig returns a JWKSConfig with default values.
+func NewJWKSConfig() JWKSConfig {
+	return JWKSConfig{
+		URL:           "",
+		RefreshPeriod: "1h",
+		Timeout:       "5s",
+	}
+}
+
+func jwksFieldSpec() docs.FieldSpec {
+	return docs.FieldCommon("jwks", "A [JSON Web Key Set](https://tools.ietf.org/html/rfc7517) endpoint to fetch the public keys of tokens from, which are selected by the `kid` header of tokens.").WithChildren(
+		docs.FieldCommon("url", "The URL of the key set.", "https://example.auth0.com/.well-known/jwks.json"),
+		docs.FieldAdvanced("refresh_period", "The period after which keys are fetched again."),
+		docs.FieldAdvanced("timeout", "The maximum period to wait for the key set to be fetched."),
+	)
+}
+
+var jwtAlgorithms = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"ES256", "ES384", "ES512",
+}
+
+// jwtPEM returns PEM encoded key contents from either a string or a file.
+func jwtPEM(content, file string) ([]byte, error) {
+	if content != "" && file != "" {
+		return nil, errors.New("a key and a key file cannot both be specified")
+	}
+	if file != "" {
+		return ioutil.ReadFile(file)
+	}
+	return []byte(content), nil
+}
+
+//------------------------------------------------------------------------------
+
+// jwksMinRefresh is the minimum period between fetches of a key set that are
+// caused by tokens with an unknown key ID.
+var jwksMinRefresh = time.Second * 10
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+func jwkBigInt(field, v string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %v: %v", field, err)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := jwkBigInt("n", k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := jwkBigInt("e", k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("curve not supported: %v", k.Crv)
+		}
+		x, err := jwkBigInt("x", k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := jwkBigInt("y", k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		b, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode k: %v", err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("key type not supported: %v", k.Kty)
+}
+
+func parseJWKS(data []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse key set: %v", err)
+	}
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %q: %v", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+// jwksCache fetches a key set and caches its keys until the refresh period has
+// passed.
+type jwksCache struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mut       sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+func newJWKSCache(conf JWKSConfig) (*jwksCache, error) {
+	j := &jwksCache{
+		url:    conf.URL,
+		client: &http.Client{},
+	}
+	var err error
+	if conf.RefreshPeriod != "" {
+		if j.refresh, err = time.ParseDuration(conf.RefreshPeriod); err != nil {
+			return nil, fmt.Errorf("failed to parse jwks refresh_period: %v", err)
+		}
+	}
+	if conf.Timeout != "" {
+		if j.client.Timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse jwks timeout: %v", err)
+		}
+	}
+	return j, nil
+}
+
+func (j *jwksCache) fetch() error {
+	res, err := j.client.Get(j.url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch key set: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read key set: %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("key set returned unexpected response code (%v): %s", res.StatusCode, body)
+	}
+
+	keys, err := parseJWKS(body)
+	if err != nil {
+		return err
+	}
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	return nil
+}
+
+// Key returns the key of an ID, fetching the key set when it has expired or
+// doesn't contain the key.
+func (j *jwksCache) Key(kid string) (interface{}, error) {
+	j.mut.Lock()
+	defer j.mut.Unlock()
+
+	key, exists := j.keys[kid]
+	stale := j.keys == nil || (j.refresh > 0 && time.Since(j.fetchedAt) > j.refresh)
+	if stale || (!exists && time.Since(j.fetchedAt) > jwksMinRefresh) {
+		if err := j.fetch(); err != nil {
+			// Keys that were previously fetched remain usable until a fetch
+			// succeeds.
+			if !exists {
+				return nil, err
+			}
+			return key, nil
+		}
+		key, exists = j.keys[kid]
+	}
+	if !exists {
+		return nil, fmt.Errorf("key not found in key set: %q", kid)
+	}
+	return key, nil
+}
+
+//------------------------------------------------------------------------------
+
+// jwtSigningMethod returns the signing method of an algorithm.
+func jwtSigningMethod(alg string) (jwt.SigningMethod, error) {
+	for _, a := range jwtAlgorithms {
+		if a == alg {
+			return jwt.GetSigningMethod(alg), nil
+		}
+	}
+	return nil, fmt.Errorf("algorithm not supported: %v", alg)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,268 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeJWTSign] = TypeSpec{
+		constructor: NewJWTSign,
+		Categories: []Category{
+			CategoryMapping,
+		},
+		Summary: `
+Creates signed [JSON Web Tokens](https://jwt.io/) from the claims of messages.`,
+		Beta: true,
+		Description: `
+The claims of a token are the JSON document of a message, or the result of the
+` + "`claims`" + ` mapping when it is specified, and must be an object. By
+default the contents of messages are replaced with tokens, but tokens can
+instead be written to metadata with the ` + "`meta_key`" + ` field.
+
+Tokens signed with HMAC algorithms (` + "`HS256`, `HS384` and `HS512`" + `)
+require a ` + "`secret`" + `, and tokens signed with RSA (` + "`RS256`, `RS384` and `RS512`" + `)
+or ECDSA (` + "`ES256`, `ES384` and `ES512`" + `) algorithms require a PEM encoded
+private key.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("algorithm", "The algorithm to sign tokens with.").HasOptions(jwtAlgorithms...),
+			docs.FieldCommon("secret", "The secret of HMAC algorithms."),
+			docs.FieldCommon("private_key", "A PEM encoded private key of RSA or ECDSA algorithms."),
+			docs.FieldCommon("private_key_file", "A path to a PEM encoded private key of RSA or ECDSA algorithms."),
+			docs.FieldAdvanced("key_id", "An optional key ID to add as the `kid` header of tokens."),
+			docs.FieldCommon(
+				"claims", "An optional [Bloblang mapping](/docs/guides/bloblang/about) that creates the claims of a token from a message.",
+				`root.sub = this.user.id
+root.scope = this.user.roles.join(" ")`,
+			),
+			docs.FieldCommon("expiry", "An optional period after which tokens expire, which sets the `iat` and `exp` claims of tokens.", "15m"),
+			docs.FieldCommon("meta_key", "An optional metadata key to write tokens to, in which case the contents of messages are unchanged."),
+			partsFieldSpec,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Service Tokens",
+				Summary: `
+Here each message is sent to an HTTP service with a short lived token for the
+user of the message:`,
+				Config: `
+pipeline:
+  processors:
+    - jwt_sign:
+        algorithm: RS256
+        private_key_file: ./keys/service.pem
+        key_id: service-2020
+        claims: 'root.sub = this.user.id'
+        expiry: 5m
+        meta_key: token
+
+output:
+  http_client:
+    url: http://localhost:4195/post
+    headers:
+      Authorization: Bearer ${! meta("token") }
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// JWTSignConfig contains configuration fields for the JWTSign processor.
+type JWTSignConfig struct {
+	Algorithm      string `json:"algorithm" yaml:"algorithm"`
+	Secret         string `json:"secret" yaml:"secret"`
+	PrivateKey     string `json:"private_key" yaml:"private_key"`
+	PrivateKeyFile string `json:"private_key_file" yaml:"private_key_file"`
+	KeyID          string `json:"key_id" yaml:"key_id"`
+	Claims         string `json:"claims" yaml:"claims"`
+	Expiry         string `json:"expiry" yaml:"expiry"`
+	MetaKey        string `json:"meta_key" yaml:"meta_key"`
+	Parts          []int  `json:"parts" yaml:"parts"`
+}
+
+// NewJWTSignConfig returns a JWTSignConfig with default values.
+func NewJWTSignConfig() JWTSignConfig {
+	return JWTSignConfig{
+		Algorithm:      "HS256",
+		Secret:         "",
+		PrivateKey:     "",
+		PrivateKeyFile: "",
+		KeyID:          "",
+		Claims:         "",
+		Expiry:         "",
+		MetaKey:        "",
+		Parts:          []int{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// JWTSign is a processor that creates signed JSON web tokens.
+type JWTSign struct {
+	parts   []int
+	method  jwt.SigningMethod
+	key     interface{}
+	keyID   string
+	claims  *mapping.Executor
+	expiry  time.Duration
+	metaKey string
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewJWTSign returns a JWTSign processor.
+func NewJWTSign(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	method, err := jwtSigningMethod(conf.JWTSign.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &JWTSign{
+		parts:   conf.JWTSign.Parts,
+		method:  method,
+		keyID:   conf.JWTSign.KeyID,
+		metaKey: conf.JWTSign.MetaKey,
+
+		log: log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if conf.JWTSign.Secret == "" {
+			return nil, fmt.Errorf("a secret must be specified for the %v algorithm", conf.JWTSign.Algorithm)
+		}
+		s.key = []byte(conf.JWTSign.Secret)
+	default:
+		pem, err := jwtPEM(conf.JWTSign.PrivateKey, conf.JWTSign.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(pem) == 0 {
+			return nil, fmt.Errorf("a private key must be specified for the %v algorithm", conf.JWTSign.Algorithm)
+		}
+		if _, isRSA := method.(*jwt.SigningMethodRSA); isRSA {
+			s.key, err = jwt.ParseRSAPrivateKeyFromPEM(pem)
+		} else {
+			s.key, err = jwt.ParseECPrivateKeyFromPEM(pem)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %v", err)
+		}
+	}
+
+	if conf.JWTSign.Claims != "" {
+		if s.claims, err = bloblang.NewMapping("", conf.JWTSign.Claims); err != nil {
+			return nil, fmt.Errorf("failed to parse claims mapping: %v", err)
+		}
+	}
+	if conf.JWTSign.Expiry != "" {
+		if s.expiry, err = time.ParseDuration(conf.JWTSign.Expiry); err != nil {
+			return nil, fmt.Errorf("failed to parse expiry: %v", err)
+		}
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *JWTSign) sign(index int, msg types.Message, part types.Part) (string, error) {
+	claimsPart := part
+	if s.claims != nil {
+		var err error
+		if claimsPart, err = s.claims.MapPart(index, msg); err != nil {
+			return "", err
+		}
+		if claimsPart == nil {
+			return "", errors.New("claims mapping resulted in a deleted message")
+		}
+	}
+
+	v, err := claimsPart.JSON()
+	if err != nil {
+		return "", fmt.Errorf("failed to parse claims as json: %v", err)
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("expected claims to be an object, got %T", v)
+	}
+
+	claims := make(jwt.MapClaims, len(obj)+2)
+	for k, v := range obj {
+		claims[k] = v
+	}
+	if s.expiry > 0 {
+		now := time.Now()
+		claims["iat"] = now.Unix()
+		claims["exp"] = now.Add(s.expiry).Unix()
+	}
+
+	token := jwt.NewWithClaims(s.method, claims)
+	if s.keyID != "" {
+		token.Header["kid"] = s.keyID
+	}
+	return token.SignedString(s.key)
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (s *JWTSign) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	s.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	IteratePartsWithSpan(TypeJWTSign, s.parts, newMsg, func(index int, span opentracing.Span, part types.Part) error {
+		token, err := s.sign(index, msg, part)
+		if err != nil {
+			s.mErr.Incr(1)
+			s.log.Debugf("Failed to sign token: %v\n", err)
+			return err
+		}
+		if s.metaKey != "" {
+			part.Metadata().Set(s.metaKey, token)
+		} else {
+			part.Set([]byte(token))
+		}
+		return nil
+	})
+
+	s.mBatchSent.Incr(1)
+	s.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (s *JWTSign) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (s *JWTSign) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
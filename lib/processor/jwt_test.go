@@ -0,0 +1,274 @@
+package processor
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJWTProc(t *testing.T, conf Config) Type {
+	t.Helper()
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	return proc
+}
+
+func TestJWTSignVerifyHMAC(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeJWTSign
+	conf.JWTSign.Secret = "foosecret"
+	conf.JWTSign.Claims = `root.sub = this.user
+root.iss = "benthos"
+root.aud = ["foo", "bar"]`
+	conf.JWTSign.Expiry = "1m"
+	conf.JWTSign.MetaKey = "token"
+	sign := newJWTProc(t, conf)
+
+	conf = NewConfig()
+	conf.Type = TypeJWTVerify
+	conf.JWTVerify.Token = `Bearer ${! meta("token") }`
+	conf.JWTVerify.Algorithms = []string{"HS256"}
+	conf.JWTVerify.Secret = "foosecret"
+	conf.JWTVerify.Issuer = "benthos"
+	conf.JWTVerify.Audience = "bar"
+	conf.JWTVerify.TargetPath = "claims"
+	verify := newJWTProc(t, conf)
+
+	msgs, res := sign.ProcessMessage(message.New([][]byte{
+		[]byte(`{"user":"foo"}`),
+		[]byte(`not json`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, `{"user":"foo"}`, string(msgs[0].Get(0).Get()))
+	assert.NotEmpty(t, msgs[0].Get(0).Metadata().Get("token"))
+	assert.True(t, HasFailed(msgs[0].Get(1)))
+
+	signed := message.New(nil)
+	signed.Append(msgs[0].Get(0))
+	msgs, res = verify.ProcessMessage(signed)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.False(t, HasFailed(msgs[0].Get(0)), GetFail(msgs[0].Get(0)))
+
+	var doc struct {
+		User   string
+		Claims map[string]interface{}
+	}
+	require.NoError(t, json.Unmarshal(msgs[0].Get(0).Get(), &doc))
+	assert.Equal(t, "foo", doc.User)
+	assert.Equal(t, "foo", doc.Claims["sub"])
+	assert.Equal(t, float64(60), doc.Claims["exp"].(float64)-doc.Claims["iat"].(float64))
+}
+
+func TestJWTVerifyFailures(t *testing.T) {
+	sign := func(method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+	secret := []byte("foosecret")
+
+	conf := NewConfig()
+	conf.Type = TypeJWTVerify
+	conf.JWTVerify.Algorithms = []string{"HS256"}
+	conf.JWTVerify.Secret = string(secret)
+	conf.JWTVerify.Issuer = "benthos"
+	conf.JWTVerify.Audience = "foo"
+	verify := newJWTProc(t, conf)
+
+	assert.Equal(t, []string{
+		`{"aud":"foo","iss":"benthos","sub":"bar"}`,
+		`failed: signature is invalid`,
+		`failed: signing method HS384 is invalid`,
+		`failed: Token is expired`,
+		`failed: token has an unexpected issuer`,
+		`failed: token has an unexpected audience`,
+		`failed: token contains an invalid number of segments`,
+		`failed: token is empty`,
+	}, testFieldsOutput(t, verify, []string{
+		sign(jwt.SigningMethodHS256, secret, jwt.MapClaims{"sub": "bar", "iss": "benthos", "aud": "foo"}),
+		sign(jwt.SigningMethodHS256, []byte("nope"), jwt.MapClaims{"iss": "benthos", "aud": "foo"}),
+		sign(jwt.SigningMethodHS384, secret, jwt.MapClaims{"iss": "benthos", "aud": "foo"}),
+		sign(jwt.SigningMethodHS256, secret, jwt.MapClaims{"iss": "benthos", "aud": "foo", "exp": time.Now().Add(-time.Minute).Unix()}),
+		sign(jwt.SigningMethodHS256, secret, jwt.MapClaims{"iss": "nope", "aud": "foo"}),
+		sign(jwt.SigningMethodHS256, secret, jwt.MapClaims{"iss": "benthos", "aud": []string{"bar"}}),
+		`nope`,
+		``,
+	}))
+}
+
+func TestJWTSignVerifyECDSAStatic(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	privBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	pubBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	conf := NewConfig()
+	conf.Type = TypeJWTSign
+	conf.JWTSign.Algorithm = "ES256"
+	conf.JWTSign.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privBytes}))
+	sign := newJWTProc(t, conf)
+
+	conf = NewConfig()
+	conf.Type = TypeJWTVerify
+	conf.JWTVerify.Algorithms = []string{"ES256"}
+	conf.JWTVerify.PublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}))
+	conf.JWTVerify.MetaPrefix = "jwt_"
+	verify := newJWTProc(t, conf)
+
+	msgs, res := sign.ProcessMessage(message.New([][]byte{[]byte(`{"sub":"foo","roles":["a","b"]}`)}))
+	require.Nil(t, res)
+	msgs, res = verify.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+
+	part := msgs[0].Get(0)
+	require.False(t, HasFailed(part), GetFail(part))
+	assert.Equal(t, "foo", part.Metadata().Get("jwt_sub"))
+	assert.Equal(t, `["a","b"]`, part.Metadata().Get("jwt_roles"))
+}
+
+func TestJWTVerifyJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&fetches, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []interface{}{
+				map[string]interface{}{
+					"kty": "RSA",
+					"kid": "foo",
+					"use": "sig",
+					"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				},
+				map[string]interface{}{
+					"kty": "RSA",
+					"kid": "enc",
+					"use": "enc",
+				},
+			},
+		})
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.Type = TypeJWTSign
+	conf.JWTSign.Algorithm = "RS256"
+	conf.JWTSign.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	conf.JWTSign.KeyID = "foo"
+	sign := newJWTProc(t, conf)
+
+	conf.JWTSign.KeyID = "bar"
+	signUnknown := newJWTProc(t, conf)
+
+	conf = NewConfig()
+	conf.Type = TypeJWTVerify
+	conf.JWTVerify.Algorithms = []string{"RS256", "HS256"}
+	conf.JWTVerify.JWKS.URL = ts.URL
+	verify := newJWTProc(t, conf)
+
+	tokens := testFieldsOutput(t, sign, []string{`{"sub":"foo"}`, `{"sub":"bar"}`})
+	// An HMAC token signed with an RSA public key must not be accepted.
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "baz"})
+	hmac.Header["kid"] = "foo"
+	hmacToken, err := hmac.SignedString(x509.MarshalPKCS1PublicKey(&key.PublicKey))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"sub":"foo"}`,
+		`{"sub":"bar"}`,
+		`failed: key not found in key set: "bar"`,
+		`failed: key is of invalid type`,
+	}, testFieldsOutput(t, verify, append(tokens, testFieldsOutput(t, signUnknown, []string{`{}`})[0], hmacToken)))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&fetches))
+}
+
+func TestJWTBadConfig(t *testing.T) {
+	tests := map[string]struct {
+		conf func(c *Config)
+		err  string
+	}{
+		"bad sign algorithm": {
+			conf: func(c *Config) {
+				c.Type = TypeJWTSign
+				c.JWTSign.Algorithm = "none"
+			},
+			err: "algorithm not supported: none",
+		},
+		"no sign secret": {
+			conf: func(c *Config) {
+				c.Type = TypeJWTSign
+			},
+			err: "a secret must be specified for the HS256 algorithm",
+		},
+		"no sign private key": {
+			conf: func(c *Config) {
+				c.Type = TypeJWTSign
+				c.JWTSign.Algorithm = "RS256"
+			},
+			err: "a private key must be specified for the RS256 algorithm",
+		},
+		"bad sign private key": {
+			conf: func(c *Config) {
+				c.Type = TypeJWTSign
+				c.JWTSign.Algorithm = "ES256"
+				c.JWTSign.PrivateKey = "nope"
+			},
+			err: "failed to parse private key: Invalid Key: Key must be PEM encoded PKCS1 or PKCS8 private key",
+		},
+		"no verify algorithms": {
+			conf: func(c *Config) {
+				c.Type = TypeJWTVerify
+			},
+			err: "at least one algorithm must be specified",
+		},
+		"no verify keys": {
+			conf: func(c *Config) {
+				c.Type = TypeJWTVerify
+				c.JWTVerify.Algorithms = []string{"RS256"}
+			},
+			err: "a secret, public key or jwks url must be specified",
+		},
+		"verify key and file": {
+			conf: func(c *Config) {
+				c.Type = TypeJWTVerify
+				c.JWTVerify.Algorithms = []string{"RS256"}
+				c.JWTVerify.PublicKey = "foo"
+				c.JWTVerify.PublicKeyFile = "bar"
+			},
+			err: "a key and a key file cannot both be specified",
+		},
+	}
+
+	for name, test := range tests {
+		conf := NewConfig()
+		test.conf(&conf)
+
+		_, err := New(conf, nil, log.Noop(), metrics.Noop())
+		assert.EqualError(t, err, test.err, name)
+	}
+}
@@ -0,0 +1,336 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/gabs/v2"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeJWTVerify] = TypeSpec{
+		constructor: NewJWTVerify,
+		Categories: []Category{
+			CategoryMapping,
+		},
+		Summary: `
+Verifies [JSON Web Tokens](https://jwt.io/) and extracts their claims.`,
+		Beta: true,
+		Description: `
+The token of each message is verified with the ` + "`secret`" + ` for HMAC
+algorithms, or with either the ` + "`public_key`" + ` or the keys of a ` + "`jwks`" + `
+endpoint for RSA and ECDSA algorithms. Tokens are only accepted when they are
+signed with one of the configured ` + "`algorithms`" + `, haven't expired, and
+match the ` + "`issuer`" + ` and ` + "`audience`" + ` when they are specified. A
+` + "`Bearer `" + ` prefix of tokens is ignored.
+
+The claims of verified tokens are written to the ` + "`target_path`" + ` of
+messages, or to metadata when a ` + "`meta_prefix`" + ` is specified, in which
+case claims that aren't strings are written as JSON. Messages with tokens that
+fail verification are flagged as failed, and can be handled with
+[error handling patterns](/docs/configuration/error_handling).`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("token", "The token to verify.", `${! meta("Authorization") }`).SupportsInterpolation(false),
+			docs.FieldCommon("algorithms", "A list of the algorithms that tokens may be signed with.", []string{"RS256"}).HasOptions(jwtAlgorithms...),
+			docs.FieldCommon("secret", "The secret of HMAC algorithms."),
+			docs.FieldCommon("public_key", "A PEM encoded public key of RSA or ECDSA algorithms."),
+			docs.FieldCommon("public_key_file", "A path to a PEM encoded public key of RSA or ECDSA algorithms."),
+			jwksFieldSpec(),
+			docs.FieldCommon("issuer", "An optional issuer that the `iss` claim of tokens must match."),
+			docs.FieldCommon("audience", "An optional audience that the `aud` claim of tokens must contain."),
+			docs.FieldCommon("target_path", "A [dot path](/docs/configuration/field_paths) to write claims to. When empty the contents of messages are replaced with claims.", "claims"),
+			docs.FieldCommon("meta_prefix", "An optional prefix of metadata keys to write claims to instead of the message."),
+			partsFieldSpec,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Authenticated Requests",
+				Summary: `
+Here the bearer tokens of HTTP requests are verified with the keys of an
+identity provider, and the subject of tokens is added to documents:`,
+				Config: `
+input:
+  http_server:
+    path: /post
+
+pipeline:
+  processors:
+    - jwt_verify:
+        token: ${! meta("Authorization") }
+        algorithms: [ RS256 ]
+        jwks:
+          url: https://example.auth0.com/.well-known/jwks.json
+        issuer: https://example.auth0.com/
+        audience: benthos
+        meta_prefix: jwt_
+    - bloblang: |
+        root = this
+        root.user_id = meta("jwt_sub")
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// JWTVerifyConfig contains configuration fields for the JWTVerify processor.
+type JWTVerifyConfig struct {
+	Token         string     `json:"token" yaml:"token"`
+	Algorithms    []string   `json:"algorithms" yaml:"algorithms"`
+	Secret        string     `json:"secret" yaml:"secret"`
+	PublicKey     string     `json:"public_key" yaml:"public_key"`
+	PublicKeyFile string     `json:"public_key_file" yaml:"public_key_file"`
+	JWKS          JWKSConfig `json:"jwks" yaml:"jwks"`
+	Issuer        string     `json:"issuer" yaml:"issuer"`
+	Audience      string     `json:"audience" yaml:"audience"`
+	TargetPath    string     `json:"target_path" yaml:"target_path"`
+	MetaPrefix    string     `json:"meta_prefix" yaml:"meta_prefix"`
+	Parts         []int      `json:"parts" yaml:"parts"`
+}
+
+// NewJWTVerifyConfig returns a JWTVerifyConfig with default values.
+func NewJWTVerifyConfig() JWTVerifyConfig {
+	return JWTVerifyConfig{
+		Token:         "${! content() }",
+		Algorithms:    []string{},
+		Secret:        "",
+		PublicKey:     "",
+		PublicKeyFile: "",
+		JWKS:          NewJWKSConfig(),
+		Issuer:        "",
+		Audience:      "",
+		TargetPath:    "",
+		MetaPrefix:    "",
+		Parts:         []int{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// JWTVerify is a processor that verifies JSON web tokens.
+type JWTVerify struct {
+	parts      []int
+	token      field.Expression
+	parser     *jwt.Parser
+	secret     []byte
+	publicKey  interface{}
+	jwks       *jwksCache
+	issuer     string
+	audience   string
+	targetPath []string
+	metaPrefix string
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewJWTVerify returns a JWTVerify processor.
+func NewJWTVerify(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if len(conf.JWTVerify.Algorithms) == 0 {
+		return nil, errors.New("at least one algorithm must be specified")
+	}
+	for _, alg := range conf.JWTVerify.Algorithms {
+		if _, err := jwtSigningMethod(alg); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := bloblang.NewField(conf.JWTVerify.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token expression: %v", err)
+	}
+
+	v := &JWTVerify{
+		parts: conf.JWTVerify.Parts,
+		token: token,
+		parser: &jwt.Parser{
+			ValidMethods:  conf.JWTVerify.Algorithms,
+			UseJSONNumber: true,
+		},
+		issuer:     conf.JWTVerify.Issuer,
+		audience:   conf.JWTVerify.Audience,
+		metaPrefix: conf.JWTVerify.MetaPrefix,
+
+		log: log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+	if conf.JWTVerify.TargetPath != "" {
+		v.targetPath = gabs.DotPathToSlice(conf.JWTVerify.TargetPath)
+	}
+	if conf.JWTVerify.Secret != "" {
+		v.secret = []byte(conf.JWTVerify.Secret)
+	}
+
+	pem, err := jwtPEM(conf.JWTVerify.PublicKey, conf.JWTVerify.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(pem) > 0 {
+		if v.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+			if v.publicKey, err = jwt.ParseECPublicKeyFromPEM(pem); err != nil {
+				return nil, fmt.Errorf("failed to parse public key: %v", err)
+			}
+		}
+	}
+	if conf.JWTVerify.JWKS.URL != "" {
+		if v.publicKey != nil {
+			return nil, errors.New("a public key and a jwks url cannot both be specified")
+		}
+		if v.jwks, err = newJWKSCache(conf.JWTVerify.JWKS); err != nil {
+			return nil, err
+		}
+	}
+	if v.secret == nil && v.publicKey == nil && v.jwks == nil {
+		return nil, errors.New("a secret, public key or jwks url must be specified")
+	}
+	return v, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (v *JWTVerify) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, isHMAC := token.Method.(*jwt.SigningMethodHMAC); isHMAC && v.secret != nil {
+		return v.secret, nil
+	}
+	if v.jwks != nil {
+		kid, _ := token.Header["kid"].(string)
+		return v.jwks.Key(kid)
+	}
+	if v.publicKey == nil {
+		return nil, fmt.Errorf("no key is configured for the %v algorithm", token.Method.Alg())
+	}
+	return v.publicKey, nil
+}
+
+func (v *JWTVerify) hasAudience(claims jwt.MapClaims) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == v.audience
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok && s == v.audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *JWTVerify) verify(tokenStr string) (map[string]interface{}, error) {
+	tokenStr = strings.TrimSpace(tokenStr)
+	if len(tokenStr) > 7 && strings.EqualFold(tokenStr[:7], "bearer ") {
+		tokenStr = strings.TrimSpace(tokenStr[7:])
+	}
+	if tokenStr == "" {
+		return nil, errors.New("token is empty")
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(tokenStr, claims, v.keyFunc); err != nil {
+		return nil, err
+	}
+	if v.issuer != "" && !claims.VerifyIssuer(v.issuer, true) {
+		return nil, errors.New("token has an unexpected issuer")
+	}
+	if v.audience != "" && !v.hasAudience(claims) {
+		return nil, errors.New("token has an unexpected audience")
+	}
+	return claims, nil
+}
+
+func (v *JWTVerify) setClaims(part types.Part, claims map[string]interface{}) error {
+	if v.metaPrefix != "" {
+		meta := part.Metadata()
+		for k, c := range claims {
+			if s, ok := c.(string); ok {
+				meta.Set(v.metaPrefix+k, s)
+				continue
+			}
+			b, err := json.Marshal(c)
+			if err != nil {
+				return fmt.Errorf("failed to serialise claim %v: %v", k, err)
+			}
+			meta.Set(v.metaPrefix+k, string(b))
+		}
+		return nil
+	}
+
+	if len(v.targetPath) == 0 {
+		return part.SetJSON(claims)
+	}
+
+	jsonPart, err := part.JSON()
+	if err == nil {
+		jsonPart, err = message.CopyJSON(jsonPart)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse part into json: %v", err)
+	}
+
+	gPart := gabs.Wrap(jsonPart)
+	if _, err = gPart.Set(claims, v.targetPath...); err != nil {
+		return fmt.Errorf("failed to set target path: %v", err)
+	}
+	return part.SetJSON(gPart.Data())
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (v *JWTVerify) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	v.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	IteratePartsWithSpan(TypeJWTVerify, v.parts, newMsg, func(index int, span opentracing.Span, part types.Part) error {
+		claims, err := v.verify(v.token.String(index, msg))
+		if err == nil {
+			err = v.setClaims(part, claims)
+		}
+		if err != nil {
+			v.mErr.Incr(1)
+			v.log.Debugf("Failed to verify token: %v\n", err)
+			return err
+		}
+		return nil
+	})
+
+	v.mBatchSent.Incr(1)
+	v.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (v *JWTVerify) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (v *JWTVerify) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,197 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/opentracing/opentracing-go"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeMsgPack] = TypeSpec{
+		constructor: NewMsgPack,
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Converts messages to or from the [MessagePack](https://msgpack.org/) format.`,
+		Beta: true,
+		Description: `
+## Operators
+
+### ` + "`to_json`" + `
+
+Converts MessagePack documents into JSON. Integers remain integers, binary
+values are converted into base64 encoded strings, and maps with keys that aren't
+strings have their keys converted into strings.
+
+### ` + "`from_json`" + `
+
+Converts JSON documents into MessagePack. Numbers without a fraction or exponent
+are encoded as integers, and all other numbers as floats.
+
+When ` + "`canonical`" + ` is enabled the keys of maps are sorted and integers
+are encoded with the smallest type that holds their value, and therefore the
+same document is always encoded to the same bytes.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json"),
+			docs.FieldAdvanced("canonical", "Whether the `from_json` operator produces canonical encodings."),
+			partsFieldSpec,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MsgPackConfig contains configuration fields for the MsgPack processor.
+type MsgPackConfig struct {
+	Parts     []int  `json:"parts" yaml:"parts"`
+	Operator  string `json:"operator" yaml:"operator"`
+	Canonical bool   `json:"canonical" yaml:"canonical"`
+}
+
+// NewMsgPackConfig returns a MsgPackConfig with default values.
+func NewMsgPackConfig() MsgPackConfig {
+	return MsgPackConfig{
+		Parts:     []int{},
+		Operator:  "to_json",
+		Canonical: false,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type msgPackOperator func(part types.Part) error
+
+func newMsgPackToJSONOperator() msgPackOperator {
+	return func(part types.Part) error {
+		dec := msgpack.NewDecoder(bytes.NewReader(part.Get()))
+		dec.UseLooseInterfaceDecoding(true)
+		dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+			return d.DecodeUntypedMap()
+		})
+
+		v, err := dec.DecodeInterface()
+		if err != nil {
+			return fmt.Errorf("failed to convert MessagePack document to JSON: %v", err)
+		}
+		if err = part.SetJSON(jsonCompatible(v)); err != nil {
+			return fmt.Errorf("failed to set JSON: %v", err)
+		}
+		return nil
+	}
+}
+
+func newMsgPackFromJSONOperator(canonical bool) msgPackOperator {
+	return func(part types.Part) error {
+		jObj, err := jsonWithIntegers(part.Get())
+		if err != nil {
+			return fmt.Errorf("failed to parse message as JSON: %v", err)
+		}
+
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		if canonical {
+			enc.SetSortMapKeys(true)
+			enc.UseCompactInts(true)
+		}
+		if err = enc.Encode(jObj); err != nil {
+			return fmt.Errorf("failed to convert JSON to MessagePack: %v", err)
+		}
+		part.Set(buf.Bytes())
+		return nil
+	}
+}
+
+func strToMsgPackOperator(conf MsgPackConfig) (msgPackOperator, error) {
+	switch conf.Operator {
+	case "to_json":
+		return newMsgPackToJSONOperator(), nil
+	case "from_json":
+		return newMsgPackFromJSONOperator(conf.Canonical), nil
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
+}
+
+//------------------------------------------------------------------------------
+
+// MsgPack is a processor that converts messages to or from MessagePack.
+type MsgPack struct {
+	parts    []int
+	operator msgPackOperator
+
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewMsgPack returns a MsgPack processor.
+func NewMsgPack(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	p := &MsgPack{
+		parts: conf.MsgPack.Parts,
+		conf:  conf,
+		log:   log,
+		stats: stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	var err error
+	if p.operator, err = strToMsgPackOperator(conf.MsgPack); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (p *MsgPack) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	p.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(index int, span opentracing.Span, part types.Part) error {
+		if err := p.operator(part); err != nil {
+			p.mErr.Incr(1)
+			p.log.Debugf("Operator failed: %v\n", err)
+			return err
+		}
+		return nil
+	}
+
+	IteratePartsWithSpan(TypeMsgPack, p.parts, newMsg, proc)
+
+	p.mBatchSent.Incr(1)
+	p.mSent.Incr(int64(newMsg.Len()))
+	return []types.Message{newMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (p *MsgPack) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (p *MsgPack) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func msgPackTestProcess(t *testing.T, operator string, canonical bool, input []byte) []byte {
+	t.Helper()
+
+	conf := NewConfig()
+	conf.Type = TypeMsgPack
+	conf.MsgPack.Operator = operator
+	conf.MsgPack.Canonical = canonical
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{input}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.False(t, HasFailed(msgs[0].Get(0)), GetFail(msgs[0].Get(0)))
+	return msgs[0].Get(0).Get()
+}
+
+func TestMsgPackRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		output string
+	}{
+		"integers": {
+			input:  `{"big":9007199254740993,"max":18446744073709551615,"neg":-5,"small":1}`,
+			output: `{"big":9007199254740993,"max":18446744073709551615,"neg":-5,"small":1}`,
+		},
+		"floats": {
+			input:  `{"a":1.5,"b":-0.25,"c":1e3}`,
+			output: `{"a":1.5,"b":-0.25,"c":1000}`,
+		},
+		"nested": {
+			input:  `{"a":[1,"two",{"three":3.5}],"b":null,"c":true}`,
+			output: `{"a":[1,"two",{"three":3.5}],"b":null,"c":true}`,
+		},
+		"array": {
+			input:  `[1,2.5,"three"]`,
+			output: `[1,2.5,"three"]`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			for _, canonical := range []bool{false, true} {
+				encoded := msgPackTestProcess(t, "from_json", canonical, []byte(test.input))
+				assert.Equal(t, test.output, string(msgPackTestProcess(t, "to_json", false, encoded)))
+			}
+		})
+	}
+}
+
+func TestMsgPackCanonical(t *testing.T) {
+	a := msgPackTestProcess(t, "from_json", true, []byte(`{"b":1,"a":2.5,"c":{"z":1,"y":2}}`))
+	b := msgPackTestProcess(t, "from_json", true, []byte(`{"c":{"y":2,"z":1},"a":2.5,"b":1}`))
+	assert.Equal(t, a, b)
+
+	// Integers are encoded with the smallest type, and floats remain floats
+	// even when they're whole numbers.
+	assert.Equal(t, []byte{0x81, 0xa1, 'a', 0x01}, msgPackTestProcess(t, "from_json", true, []byte(`{"a":1}`)))
+	assert.Equal(t, []byte{0x81, 0xa1, 'a', 0xcb, 0x3f, 0xf0, 0, 0, 0, 0, 0, 0}, msgPackTestProcess(t, "from_json", true, []byte(`{"a":1.0}`)))
+}
+
+func TestMsgPackNonStringKeys(t *testing.T) {
+	// {1: "foo"}
+	input := []byte{0x81, 0x01, 0xa3, 'f', 'o', 'o'}
+	assert.Equal(t, `{"1":"foo"}`, string(msgPackTestProcess(t, "to_json", false, input)))
+}
+
+func TestMsgPackErrors(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeMsgPack
+	conf.MsgPack.Operator = "nope"
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	conf.MsgPack.Operator = "from_json"
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, _ := proc.ProcessMessage(message.New([][]byte{[]byte(`not json`)}))
+	require.Len(t, msgs, 1)
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, "not json", string(msgs[0].Get(0).Get()))
+}
@@ -0,0 +1,711 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/opentracing/opentracing-go"
+	jsonschema "github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeOpenAPI] = TypeSpec{
+		constructor: NewOpenAPI,
+		Categories: []Category{
+			CategoryMapping,
+		},
+		Summary: `
+Checks that messages representing HTTP requests or responses match an
+[OpenAPI 3](https://swagger.io/specification/) specification, but does not
+change the payload under any circumstances. If a message does not match the spec
+it can be caught using error handling methods outlined [here](/docs/configuration/error_handling).`,
+		Beta: true,
+		Description: `
+The method and path of each message are resolved from the interpolated fields
+` + "`method`" + ` and ` + "`path`" + `, which by default read the metadata
+added by the ` + "[`http_server`](/docs/components/inputs/http_server)" + `
+input, and are used to find the operation of the spec that the message targets.
+Paths of the spec are matched with concrete paths taking precedence over
+templated paths, and the paths of ` + "`servers`" + ` of the spec are removed
+from the start of paths that do not otherwise match.
+
+A message fails validation when its path or method is not found within the
+spec, when its path parameters do not match their schemas, or when its body
+does not match the schema of its content type, which is resolved from the
+interpolated field ` + "`content_type`" + `. Only bodies with JSON content
+types are checked against their schemas, and query, header and cookie
+parameters are not validated.
+
+When ` + "`status_code`" + ` is set messages are validated as responses of the
+operation with the interpolated status code rather than as requests.
+
+Schemas follow the OpenAPI 3.0 dialect of JSON Schema, including the
+` + "`nullable`" + ` keyword, and may reference other parts of the spec with
+` + "`$ref`" + `. The spec is loaded and compiled once when the processor is
+created.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("spec", "An OpenAPI 3 spec in YAML or JSON format. Use either this or the `spec_path` field."),
+			docs.FieldCommon("spec_path", "The path of a file containing an OpenAPI 3 spec in YAML or JSON format. Use either this or the `spec` field.", "./openapi.yaml"),
+			docs.FieldAdvanced("method", "The method of the HTTP request of messages.").SupportsInterpolation(false),
+			docs.FieldAdvanced("path", "The path of the HTTP request of messages.").SupportsInterpolation(false),
+			docs.FieldAdvanced("content_type", "The content type of the body of messages. When empty the content type is assumed to be `application/json` unless the operation only documents a single content type.").SupportsInterpolation(false),
+			docs.FieldAdvanced("status_code", "An optional status code of messages, when set messages are validated as responses with this status code.", `${! meta("http_status_code") }`).SupportsInterpolation(false),
+			partsFieldSpec,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Webhook Validation",
+				Summary: `
+Requests received by an HTTP server that don't match a spec are rejected
+with a 400 status code, which requires the responses of the server to be
+synchronous:`,
+				Config: `
+input:
+  http_server:
+    path: /webhooks
+    sync_response:
+      status: '${! if errored() { 400 } else { 200 } }'
+  processors:
+    - openapi:
+        spec_path: ./webhooks.yaml
+
+output:
+  switch:
+    cases:
+      - check: errored()
+        output:
+          sync_response: {}
+      - output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: webhooks
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// OpenAPIConfig contains configuration fields for the OpenAPI processor.
+type OpenAPIConfig struct {
+	Parts       []int  `json:"parts" yaml:"parts"`
+	Spec        string `json:"spec" yaml:"spec"`
+	SpecPath    string `json:"spec_path" yaml:"spec_path"`
+	Method      string `json:"method" yaml:"method"`
+	Path        string `json:"path" yaml:"path"`
+	ContentType string `json:"content_type" yaml:"content_type"`
+	StatusCode  string `json:"status_code" yaml:"status_code"`
+}
+
+// NewOpenAPIConfig returns a OpenAPIConfig with default values.
+func NewOpenAPIConfig() OpenAPIConfig {
+	return OpenAPIConfig{
+		Parts:       []int{},
+		Spec:        "",
+		SpecPath:    "",
+		Method:      `${! meta("http_server_verb") }`,
+		Path:        `${! meta("http_server_request_path") }`,
+		ContentType: `${! meta("Content-Type") }`,
+		StatusCode:  "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// openAPISpecURL is the reference that a spec is added to the schema pool with,
+// in order for schemas to reference other parts of the spec.
+const openAPISpecURL = "benthos:///openapi.json"
+
+var openAPIMethods = []string{
+	"get", "put", "post", "delete", "options", "head", "patch", "trace",
+}
+
+// openAPIContent is a map of media types to the schemas of their bodies, where
+// the schema is nil when bodies of the media type aren't validated.
+type openAPIContent map[string]*jsonschema.Schema
+
+func (c openAPIContent) match(contentType string) (*jsonschema.Schema, bool) {
+	if contentType == "" {
+		if len(c) == 1 {
+			for _, s := range c {
+				return s, true
+			}
+		}
+		contentType = "application/json"
+	}
+	mediaType := openAPIMediaType(contentType)
+	if s, exists := c[mediaType]; exists {
+		return s, true
+	}
+	if i := strings.Index(mediaType, "/"); i > 0 {
+		if s, exists := c[mediaType[:i]+"/*"]; exists {
+			return s, true
+		}
+	}
+	s, exists := c["*/*"]
+	return s, exists
+}
+
+func openAPIMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
+
+func openAPIIsJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+type openAPIParam struct {
+	name   string
+	typ    string
+	schema *jsonschema.Schema
+}
+
+type openAPIOperation struct {
+	pathParams   []openAPIParam
+	bodyRequired bool
+	body         openAPIContent
+	responses    map[string]openAPIContent
+}
+
+func (o *openAPIOperation) response(status string) (openAPIContent, bool) {
+	if c, exists := o.responses[status]; exists {
+		return c, true
+	}
+	if len(status) > 0 {
+		if c, exists := o.responses[status[:1]+"XX"]; exists {
+			return c, true
+		}
+	}
+	c, exists := o.responses["default"]
+	return c, exists
+}
+
+type openAPIPath struct {
+	template   string
+	segments   []string
+	templated  int
+	operations map[string]*openAPIOperation
+}
+
+func (p *openAPIPath) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(p.segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, s := range p.segments {
+		if len(s) > 2 && s[0] == '{' && s[len(s)-1] == '}' {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[s[1:len(s)-1]] = segments[i]
+		} else if s != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+type openAPISpec struct {
+	basePaths []string
+	paths     []*openAPIPath
+}
+
+func (s *openAPISpec) match(path string) (*openAPIPath, map[string]string) {
+	candidates := []string{path}
+	for _, base := range s.basePaths {
+		if strings.HasPrefix(path, base+"/") {
+			candidates = append(candidates, strings.TrimPrefix(path, base))
+		}
+	}
+	for _, c := range candidates {
+		segments := strings.Split(c, "/")
+		for _, p := range s.paths {
+			if params, ok := p.match(segments); ok {
+				return p, params
+			}
+		}
+	}
+	return nil, nil
+}
+
+//------------------------------------------------------------------------------
+
+func openAPIPointerEscape(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+func openAPIPointerUnescape(token string) string {
+	return strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+}
+
+// openAPINullable converts schemas using the OpenAPI 3.0 nullable keyword into
+// their JSON Schema equivalent.
+func openAPINullable(v interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if nullable, _ := t["nullable"].(bool); nullable {
+			if typ, ok := t["type"].(string); ok {
+				t["type"] = []interface{}{typ, "null"}
+			}
+		}
+		for _, e := range t {
+			openAPINullable(e)
+		}
+	case []interface{}:
+		for _, e := range t {
+			openAPINullable(e)
+		}
+	}
+}
+
+type openAPICompiler struct {
+	root   map[string]interface{}
+	loader *jsonschema.SchemaLoader
+}
+
+func (c *openAPICompiler) lookup(ptr string) (interface{}, error) {
+	var v interface{} = c.root
+	for _, token := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+		token = openAPIPointerUnescape(token)
+		switch t := v.(type) {
+		case map[string]interface{}:
+			var exists bool
+			if v, exists = t[token]; !exists {
+				return nil, fmt.Errorf("reference not found: #%v", ptr)
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, fmt.Errorf("reference not found: #%v", ptr)
+			}
+			v = t[i]
+		default:
+			return nil, fmt.Errorf("reference not found: #%v", ptr)
+		}
+	}
+	return v, nil
+}
+
+// resolve follows the local references of an object of the spec, returning the
+// object and its location within the spec.
+func (c *openAPICompiler) resolve(v interface{}, ptr string) (map[string]interface{}, string, error) {
+	for i := 0; i < 32; i++ {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("expected object at #%v", ptr)
+		}
+		ref, isRef := obj["$ref"].(string)
+		if !isRef {
+			return obj, ptr, nil
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			return nil, "", fmt.Errorf("only local references are supported: %v", ref)
+		}
+		ptr = ref[1:]
+		var err error
+		if v, err = c.lookup(ptr); err != nil {
+			return nil, "", err
+		}
+	}
+	return nil, "", fmt.Errorf("too many references at #%v", ptr)
+}
+
+func (c *openAPICompiler) schema(ptr string) (*jsonschema.Schema, error) {
+	tokens := strings.Split(strings.TrimPrefix(ptr, "/"), "/")
+	for i, t := range tokens {
+		tokens[i] = url.PathEscape(t)
+	}
+	return c.loader.Compile(jsonschema.NewReferenceLoader(openAPISpecURL + "#/" + strings.Join(tokens, "/")))
+}
+
+func (c *openAPICompiler) content(obj map[string]interface{}, ptr string) (openAPIContent, error) {
+	content := openAPIContent{}
+	media, _ := obj["content"].(map[string]interface{})
+	for k, v := range media {
+		mediaObj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected object at #%v/content/%v", ptr, openAPIPointerEscape(k))
+		}
+		mediaType := openAPIMediaType(k)
+
+		var schema *jsonschema.Schema
+		if _, exists := mediaObj["schema"]; exists && openAPIIsJSON(mediaType) {
+			var err error
+			if schema, err = c.schema(ptr + "/content/" + openAPIPointerEscape(k) + "/schema"); err != nil {
+				return nil, fmt.Errorf("failed to compile schema of %v: %v", k, err)
+			}
+		}
+		content[mediaType] = schema
+	}
+	return content, nil
+}
+
+func (c *openAPICompiler) pathParams(params map[string]openAPIParam, v interface{}, ptr string) error {
+	list, _ := v.([]interface{})
+	for i, p := range list {
+		paramObj, paramPtr, err := c.resolve(p, ptr+"/"+strconv.Itoa(i))
+		if err != nil {
+			return err
+		}
+		if in, _ := paramObj["in"].(string); in != "path" {
+			continue
+		}
+		param := openAPIParam{}
+		param.name, _ = paramObj["name"].(string)
+		if s, exists := paramObj["schema"]; exists {
+			schemaObj, _, err := c.resolve(s, paramPtr+"/schema")
+			if err != nil {
+				return err
+			}
+			param.typ, _ = schemaObj["type"].(string)
+			if param.schema, err = c.schema(paramPtr + "/schema"); err != nil {
+				return fmt.Errorf("failed to compile schema of parameter %v: %v", param.name, err)
+			}
+		}
+		params[param.name] = param
+	}
+	return nil
+}
+
+func (c *openAPICompiler) operation(pathItem map[string]interface{}, pathPtr, method string) (*openAPIOperation, error) {
+	ptr := pathPtr + "/" + method
+	opObj, ok := pathItem[method].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected object at #%v", ptr)
+	}
+
+	params := map[string]openAPIParam{}
+	if err := c.pathParams(params, pathItem["parameters"], pathPtr+"/parameters"); err != nil {
+		return nil, err
+	}
+	if err := c.pathParams(params, opObj["parameters"], ptr+"/parameters"); err != nil {
+		return nil, err
+	}
+
+	op := &openAPIOperation{
+		responses: map[string]openAPIContent{},
+	}
+	for _, p := range params {
+		op.pathParams = append(op.pathParams, p)
+	}
+	sort.Slice(op.pathParams, func(i, j int) bool {
+		return op.pathParams[i].name < op.pathParams[j].name
+	})
+
+	if b, exists := opObj["requestBody"]; exists {
+		bodyObj, bodyPtr, err := c.resolve(b, ptr+"/requestBody")
+		if err != nil {
+			return nil, err
+		}
+		op.bodyRequired, _ = bodyObj["required"].(bool)
+		if op.body, err = c.content(bodyObj, bodyPtr); err != nil {
+			return nil, err
+		}
+	}
+
+	responses, _ := opObj["responses"].(map[string]interface{})
+	for status, r := range responses {
+		resObj, resPtr, err := c.resolve(r, ptr+"/responses/"+openAPIPointerEscape(status))
+		if err != nil {
+			return nil, err
+		}
+		if status != "default" {
+			status = strings.ToUpper(status)
+		}
+		if op.responses[status], err = c.content(resObj, resPtr); err != nil {
+			return nil, err
+		}
+	}
+	return op, nil
+}
+
+func newOpenAPISpec(data []byte) (*openAPISpec, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %v", err)
+	}
+	root, ok := jsonCompatible(doc).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("expected spec to be an object")
+	}
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("expected an OpenAPI 3 spec, found version: %v", root["openapi"])
+	}
+	openAPINullable(root)
+
+	c := &openAPICompiler{
+		root:   root,
+		loader: jsonschema.NewSchemaLoader(),
+	}
+	if err := c.loader.AddSchema(openAPISpecURL, jsonschema.NewGoLoader(root)); err != nil {
+		return nil, fmt.Errorf("failed to load spec: %v", err)
+	}
+
+	spec := &openAPISpec{}
+	servers, _ := root["servers"].([]interface{})
+	for _, s := range servers {
+		serverObj, _ := s.(map[string]interface{})
+		serverURL, _ := serverObj["url"].(string)
+		if strings.Contains(serverURL, "{") {
+			continue
+		}
+		if u, err := url.Parse(serverURL); err == nil {
+			if base := strings.TrimSuffix(u.Path, "/"); base != "" {
+				spec.basePaths = append(spec.basePaths, base)
+			}
+		}
+	}
+
+	paths, _ := root["paths"].(map[string]interface{})
+	for template, v := range paths {
+		pathItem, pathPtr, err := c.resolve(v, "/paths/"+openAPIPointerEscape(template))
+		if err != nil {
+			return nil, err
+		}
+		p := &openAPIPath{
+			template:   template,
+			segments:   strings.Split(template, "/"),
+			operations: map[string]*openAPIOperation{},
+		}
+		for _, s := range p.segments {
+			if strings.HasPrefix(s, "{") {
+				p.templated++
+			}
+		}
+		for _, method := range openAPIMethods {
+			if _, exists := pathItem[method]; !exists {
+				continue
+			}
+			if p.operations[method], err = c.operation(pathItem, pathPtr, method); err != nil {
+				return nil, fmt.Errorf("%v %v: %v", strings.ToUpper(method), template, err)
+			}
+		}
+		spec.paths = append(spec.paths, p)
+	}
+	sort.Slice(spec.paths, func(i, j int) bool {
+		if spec.paths[i].templated == spec.paths[j].templated {
+			return spec.paths[i].template < spec.paths[j].template
+		}
+		return spec.paths[i].templated < spec.paths[j].templated
+	})
+	return spec, nil
+}
+
+//------------------------------------------------------------------------------
+
+// OpenAPI is a processor that validates messages against an OpenAPI spec.
+type OpenAPI struct {
+	parts []int
+	spec  *openAPISpec
+
+	method      field.Expression
+	path        field.Expression
+	contentType field.Expression
+	statusCode  field.Expression
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewOpenAPI returns an OpenAPI processor.
+func NewOpenAPI(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	var specBytes []byte
+	if conf.OpenAPI.SpecPath != "" {
+		var err error
+		if specBytes, err = ioutil.ReadFile(conf.OpenAPI.SpecPath); err != nil {
+			return nil, fmt.Errorf("failed to read spec: %v", err)
+		}
+	} else if conf.OpenAPI.Spec != "" {
+		specBytes = []byte(conf.OpenAPI.Spec)
+	} else {
+		return nil, errors.New("either spec or spec_path must be provided")
+	}
+
+	spec, err := newOpenAPISpec(specBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &OpenAPI{
+		parts: conf.OpenAPI.Parts,
+		spec:  spec,
+		log:   log,
+		stats: stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	if o.method, err = bloblang.NewField(conf.OpenAPI.Method); err != nil {
+		return nil, fmt.Errorf("failed to parse method expression: %v", err)
+	}
+	if o.path, err = bloblang.NewField(conf.OpenAPI.Path); err != nil {
+		return nil, fmt.Errorf("failed to parse path expression: %v", err)
+	}
+	if o.contentType, err = bloblang.NewField(conf.OpenAPI.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to parse content type expression: %v", err)
+	}
+	if conf.OpenAPI.StatusCode != "" {
+		if o.statusCode, err = bloblang.NewField(conf.OpenAPI.StatusCode); err != nil {
+			return nil, fmt.Errorf("failed to parse status code expression: %v", err)
+		}
+	}
+	return o, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (o *OpenAPI) validateBody(name string, content openAPIContent, contentType string, part types.Part) error {
+	schema, exists := content.match(contentType)
+	if !exists {
+		return fmt.Errorf("%v content type not documented: %v", name, contentType)
+	}
+	if schema == nil || len(part.Get()) == 0 {
+		return nil
+	}
+
+	jObj, err := part.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to parse %v as JSON: %v", name, err)
+	}
+	result, err := schema.Validate(jsonschema.NewGoLoader(jObj))
+	if err != nil {
+		return err
+	}
+	if !result.Valid() {
+		errStr := name + " does not match schema:"
+		for _, desc := range result.Errors() {
+			errStr = errStr + "\n" + desc.Field() + " " + jsonSchemaErrorDescription(desc)
+		}
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+func (o *OpenAPI) validate(index int, msg types.Message) error {
+	method := strings.ToLower(o.method.String(index, msg))
+	path := o.path.String(index, msg)
+
+	p, params := o.spec.match(path)
+	if p == nil {
+		return fmt.Errorf("path not documented: %v", path)
+	}
+	op, exists := p.operations[method]
+	if !exists {
+		return fmt.Errorf("method %v not documented for path: %v", strings.ToUpper(method), p.template)
+	}
+
+	for _, param := range op.pathParams {
+		if param.schema == nil {
+			continue
+		}
+		v, err := openAPIParamValue(param.typ, params[param.name])
+		if err != nil {
+			return fmt.Errorf("path parameter %v: %v", param.name, err)
+		}
+		result, err := param.schema.Validate(jsonschema.NewGoLoader(v))
+		if err != nil {
+			return err
+		}
+		if !result.Valid() {
+			return fmt.Errorf("path parameter %v: %v", param.name, jsonSchemaErrorDescription(result.Errors()[0]))
+		}
+	}
+
+	part := msg.Get(index)
+	contentType := o.contentType.String(index, msg)
+	if o.statusCode != nil {
+		status := o.statusCode.String(index, msg)
+		content, exists := op.response(status)
+		if !exists {
+			return fmt.Errorf("response status not documented: %v", status)
+		}
+		return o.validateBody("response body", content, contentType, part)
+	}
+
+	if len(part.Get()) == 0 {
+		if op.bodyRequired {
+			return errors.New("request body is required")
+		}
+		return nil
+	}
+	if op.body == nil {
+		return nil
+	}
+	return o.validateBody("request body", op.body, contentType, part)
+}
+
+func openAPIParamValue(typ, v string) (interface{}, error) {
+	switch typ {
+	case "integer":
+		return strconv.ParseInt(v, 10, 64)
+	case "number":
+		return strconv.ParseFloat(v, 64)
+	case "boolean":
+		return strconv.ParseBool(v)
+	}
+	return v, nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (o *OpenAPI) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	o.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(index int, span opentracing.Span, part types.Part) error {
+		if err := o.validate(index, newMsg); err != nil {
+			o.mErr.Incr(1)
+			o.log.Debugf("Message failed validation: %v\n", err)
+			return err
+		}
+		return nil
+	}
+
+	IteratePartsWithSpan(TypeOpenAPI, o.parts, newMsg, proc)
+
+	o.mBatchSent.Incr(1)
+	o.mSent.Incr(int64(newMsg.Len()))
+	return []types.Message{newMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (o *OpenAPI) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (o *OpenAPI) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,308 @@
+package processor
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const openAPITestSpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+servers:
+  - url: https://example.com/v1
+paths:
+  /pets:
+    post:
+      requestBody:
+        $ref: '#/components/requestBodies/Pet'
+      responses:
+        201:
+          description: Created
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+        4XX:
+          description: Bad request
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Error'
+  /pets/mine:
+    get:
+      responses:
+        default:
+          description: My pet
+          content:
+            text/plain: {}
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: integer
+          minimum: 1
+    get:
+      responses:
+        200:
+          description: A pet
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+components:
+  requestBodies:
+    Pet:
+      required: true
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Pet'
+  schemas:
+    Pet:
+      type: object
+      required: [ name ]
+      properties:
+        name:
+          type: string
+        tag:
+          type: string
+          nullable: true
+    Error:
+      type: object
+      required: [ message ]
+      properties:
+        message:
+          type: string
+`
+
+func TestOpenAPIRequests(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeOpenAPI
+	conf.OpenAPI.Spec = openAPITestSpec
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		method      string
+		path        string
+		contentType string
+		body        string
+		err         string
+	}{
+		"valid body": {
+			method:      "POST",
+			path:        "/pets",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"name":"fido","tag":null}`,
+		},
+		"valid body without content type": {
+			method: "POST",
+			path:   "/pets",
+			body:   `{"name":"fido","tag":"dog"}`,
+		},
+		"server base path": {
+			method: "POST",
+			path:   "/v1/pets",
+			body:   `{"name":"fido"}`,
+		},
+		"invalid body": {
+			method: "POST",
+			path:   "/pets",
+			body:   `{"tag":5}`,
+			err:    "request body does not match schema:\n(root) name is required\ntag invalid type. expected: [string,null], given: integer",
+		},
+		"missing body": {
+			method: "POST",
+			path:   "/pets",
+			err:    "request body is required",
+		},
+		"body not json": {
+			method: "POST",
+			path:   "/pets",
+			body:   `nope`,
+			err:    "failed to parse request body as JSON: invalid character 'o' in literal null (expecting 'u')",
+		},
+		"undocumented content type": {
+			method:      "POST",
+			path:        "/pets",
+			contentType: "text/plain",
+			body:        `fido`,
+			err:         "request body content type not documented: text/plain",
+		},
+		"valid path parameter": {
+			method: "GET",
+			path:   "/pets/5",
+		},
+		"concrete path precedence": {
+			method: "GET",
+			path:   "/pets/mine",
+		},
+		"path parameter wrong type": {
+			method: "GET",
+			path:   "/pets/fido",
+			err:    `path parameter petId: strconv.ParseInt: parsing "fido": invalid syntax`,
+		},
+		"path parameter out of range": {
+			method: "GET",
+			path:   "/pets/0",
+			err:    "path parameter petId: must be greater than or equal to 1",
+		},
+		"undocumented method": {
+			method: "DELETE",
+			path:   "/pets/5",
+			err:    "method DELETE not documented for path: /pets/{petId}",
+		},
+		"undocumented path": {
+			method: "GET",
+			path:   "/owners",
+			err:    "path not documented: /owners",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			msg := message.New([][]byte{[]byte(test.body)})
+			msg.Get(0).Metadata().Set("http_server_verb", test.method)
+			msg.Get(0).Metadata().Set("http_server_request_path", test.path)
+			if test.contentType != "" {
+				msg.Get(0).Metadata().Set("Content-Type", test.contentType)
+			}
+
+			msgs, res := proc.ProcessMessage(msg)
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+
+			assert.Equal(t, test.body, string(msgs[0].Get(0).Get()))
+			assert.Equal(t, test.err, GetFail(msgs[0].Get(0)))
+		})
+	}
+}
+
+func TestOpenAPIResponses(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeOpenAPI
+	conf.OpenAPI.Spec = openAPITestSpec
+	conf.OpenAPI.StatusCode = `${! meta("status") }`
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		path   string
+		method string
+		status string
+		body   string
+		err    string
+	}{
+		"valid response": {
+			method: "POST",
+			path:   "/pets",
+			status: "201",
+			body:   `{"name":"fido"}`,
+		},
+		"status range": {
+			method: "POST",
+			path:   "/pets",
+			status: "404",
+			body:   `{"message":"not found"}`,
+		},
+		"status range invalid": {
+			method: "POST",
+			path:   "/pets",
+			status: "400",
+			body:   `{"name":"fido"}`,
+			err:    "response body does not match schema:\n(root) message is required",
+		},
+		"default response": {
+			method: "GET",
+			path:   "/pets/mine",
+			status: "200",
+			body:   `fido`,
+		},
+		"undocumented status": {
+			method: "POST",
+			path:   "/pets",
+			status: "500",
+			body:   `{"message":"oops"}`,
+			err:    "response status not documented: 500",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			msg := message.New([][]byte{[]byte(test.body)})
+			msg.Get(0).Metadata().Set("http_server_verb", test.method)
+			msg.Get(0).Metadata().Set("http_server_request_path", test.path)
+			msg.Get(0).Metadata().Set("status", test.status)
+
+			msgs, res := proc.ProcessMessage(msg)
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			assert.Equal(t, test.err, GetFail(msgs[0].Get(0)))
+		})
+	}
+}
+
+func TestOpenAPISpecPath(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "benthos_openapi_test")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write([]byte(`{"openapi":"3.0.0","paths":{"/foo":{"get":{"responses":{}}}}}`))
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+
+	conf := NewConfig()
+	conf.Type = TypeOpenAPI
+	conf.OpenAPI.SpecPath = tmpFile.Name()
+	conf.OpenAPI.Method = "GET"
+	conf.OpenAPI.Path = `${! content() }`
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`/foo`),
+		[]byte(`/bar`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.False(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, "path not documented: /bar", GetFail(msgs[0].Get(1)))
+}
+
+func TestOpenAPIBadSpecs(t *testing.T) {
+	tests := map[string]string{
+		"not openapi 3":     `{"swagger":"2.0","paths":{}}`,
+		"missing reference": `{"openapi":"3.0.0","paths":{"/foo":{"post":{"requestBody":{"$ref":"#/components/requestBodies/Nope"}}}}}`,
+		"remote reference":  `{"openapi":"3.0.0","paths":{"/foo":{"$ref":"https://example.com/paths.json"}}}`,
+	}
+
+	for name, spec := range tests {
+		conf := NewConfig()
+		conf.Type = TypeOpenAPI
+		conf.OpenAPI.Spec = spec
+
+		_, err := New(conf, nil, log.Noop(), metrics.Noop())
+		assert.Error(t, err, name)
+	}
+
+	conf := NewConfig()
+	conf.Type = TypeOpenAPI
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "either spec or spec_path must be provided")
+}
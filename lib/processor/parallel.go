@@ -29,7 +29,18 @@ though they were each a batch of one message (similar to the
 processed in parallel.`,
 		Description: `
 The field ` + "`cap`" + `, if greater than zero, caps the maximum number of
-parallel processing threads.`,
+parallel processing threads, and the field ` + "`max_in_flight_bytes`" + `, if
+greater than zero, caps the total size of the messages being processed at a
+given time. A message larger than the cap is processed on its own.
+
+Messages are isolated from each other, and therefore when the child processors
+fail to process a message, or panic, only that message is flagged as having
+failed, with its original contents, and the remaining messages of the batch are
+unaffected. Failed messages can be handled with
+[error handling patterns](/docs/configuration/error_handling).
+
+The results of each message are reassembled into a single batch in the order of
+the messages they originated from.`,
 		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
 			var err error
 			procConfs := make([]interface{}, len(conf.Parallel.Processors))
@@ -39,13 +50,15 @@ parallel processing threads.`,
 				}
 			}
 			return map[string]interface{}{
-				"cap":        conf.Parallel.Cap,
-				"processors": procConfs,
+				"cap":                 conf.Parallel.Cap,
+				"max_in_flight_bytes": conf.Parallel.MaxInFlightBytes,
+				"processors":          procConfs,
 			}, nil
 		},
 		UsesBatches: true,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("cap", "The maximum number of messages to have processing at a given time."),
+			docs.FieldAdvanced("max_in_flight_bytes", "The maximum total size in bytes of messages to have processing at a given time, where zero means no limit."),
 			docs.FieldCommon("processors", "A list of child processors to apply."),
 		},
 	}
@@ -56,15 +69,17 @@ parallel processing threads.`,
 // ParallelConfig is a config struct containing fields for the Parallel
 // processor.
 type ParallelConfig struct {
-	Cap        int      `json:"cap" yaml:"cap"`
-	Processors []Config `json:"processors" yaml:"processors"`
+	Cap              int      `json:"cap" yaml:"cap"`
+	MaxInFlightBytes int      `json:"max_in_flight_bytes" yaml:"max_in_flight_bytes"`
+	Processors       []Config `json:"processors" yaml:"processors"`
 }
 
 // NewParallelConfig returns a default ParallelConfig.
 func NewParallelConfig() ParallelConfig {
 	return ParallelConfig{
-		Cap:        0,
-		Processors: []Config{},
+		Cap:              0,
+		MaxInFlightBytes: 0,
+		Processors:       []Config{},
 	}
 }
 
@@ -76,6 +91,10 @@ type Parallel struct {
 	children []types.Processor
 	cap      int
 
+	maxInFlightBytes int
+	inFlightBytes    int
+	inFlightCond     *sync.Cond
+
 	log log.Modular
 
 	mCount     metrics.StatCounter
@@ -102,6 +121,9 @@ func NewParallel(
 		cap:      conf.Parallel.Cap,
 		log:      log,
 
+		maxInFlightBytes: conf.Parallel.MaxInFlightBytes,
+		inFlightCond:     sync.NewCond(&sync.Mutex{}),
+
 		mCount:     stats.GetCounter("count"),
 		mErr:       stats.GetCounter("error"),
 		mSent:      stats.GetCounter("sent"),
@@ -111,18 +133,72 @@ func NewParallel(
 
 //------------------------------------------------------------------------------
 
+// acquireBytes blocks until a message of a size can be processed without
+// exceeding the in flight bytes cap.
+func (p *Parallel) acquireBytes(size int) {
+	if p.maxInFlightBytes <= 0 {
+		return
+	}
+	p.inFlightCond.L.Lock()
+	for p.inFlightBytes > 0 && p.inFlightBytes+size > p.maxInFlightBytes {
+		p.inFlightCond.Wait()
+	}
+	p.inFlightBytes += size
+	p.inFlightCond.L.Unlock()
+}
+
+func (p *Parallel) releaseBytes(size int) {
+	if p.maxInFlightBytes <= 0 {
+		return
+	}
+	p.inFlightCond.L.Lock()
+	p.inFlightBytes -= size
+	p.inFlightCond.L.Unlock()
+	p.inFlightCond.Broadcast()
+}
+
+// processPart applies the child processors to a single message part, where
+// errors and panics result in the original part being flagged as failed.
+func (p *Parallel) processPart(part types.Part) (resultParts []types.Part, skipAck bool) {
+	fail := func(err error) {
+		p.mErr.Incr(1)
+		p.log.Errorf("Failed to process message: %v\n", err)
+		failed := part.Copy()
+		FlagErr(failed, err)
+		resultParts, skipAck = []types.Part{failed}, false
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			fail(fmt.Errorf("child processor panic: %v", r))
+		}
+	}()
+
+	tmpMsg := message.New(nil)
+	tmpMsg.SetAll([]types.Part{part.Copy()})
+
+	resMsgs, res := ExecuteAll(p.children, tmpMsg)
+	if res != nil {
+		if err := res.Error(); err != nil {
+			fail(err)
+			return
+		}
+		skipAck = res.SkipAck()
+	}
+	for _, m := range resMsgs {
+		m.Iter(func(i int, p types.Part) error {
+			resultParts = append(resultParts, p)
+			return nil
+		})
+	}
+	return
+}
+
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (p *Parallel) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
 	p.mCount.Incr(1)
 
-	resultMsgs := make([]types.Message, msg.Len())
-	msg.Iter(func(i int, p types.Part) error {
-		tmpMsg := message.New(nil)
-		tmpMsg.SetAll([]types.Part{p})
-		resultMsgs[i] = tmpMsg
-		return nil
-	})
+	resultParts := make([][]types.Part, msg.Len())
 
 	max := p.cap
 	if max == 0 || msg.Len() < max {
@@ -137,34 +213,26 @@ func (p *Parallel) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 	for i := 0; i < max; i++ {
 		go func() {
 			for index := range reqChan {
-				resMsgs, res := ExecuteAll(p.children, resultMsgs[index])
-				if res != nil && res.SkipAck() {
+				part := msg.Get(index)
+				var skipAck bool
+				if resultParts[index], skipAck = p.processPart(part); skipAck {
 					atomic.AddInt32(&unAcks, 1)
 				}
-				resultParts := []types.Part{}
-				for _, m := range resMsgs {
-					m.Iter(func(i int, p types.Part) error {
-						resultParts = append(resultParts, p)
-						return nil
-					})
-				}
-				resultMsgs[index].SetAll(resultParts)
+				p.releaseBytes(len(part.Get()))
 			}
 			wg.Done()
 		}()
 	}
 	for i := 0; i < msg.Len(); i++ {
+		p.acquireBytes(len(msg.Get(i).Get()))
 		reqChan <- i
 	}
 	close(reqChan)
 	wg.Wait()
 
 	resMsg := message.New(nil)
-	for _, m := range resultMsgs {
-		m.Iter(func(i int, p types.Part) error {
-			resMsg.Append(p)
-			return nil
-		})
+	for _, parts := range resultParts {
+		resMsg.Append(parts...)
 	}
 	if resMsg.Len() == 0 && unAcks == int32(msg.Len()) {
 		return nil, response.NewUnack()
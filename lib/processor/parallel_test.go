@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +13,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
 )
 
 func TestParallelBasic(t *testing.T) {
@@ -187,3 +190,133 @@ func TestParallelCapped(t *testing.T) {
 		t.Errorf("Wrong result: %v != %v", act, exp)
 	}
 }
+
+type parallelFaulty struct{}
+
+func (p *parallelFaulty) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	switch string(msg.Get(0).Get()) {
+	case "error":
+		msg.Get(0).Set([]byte("mutated"))
+		return nil, response.NewError(errors.New("test error"))
+	case "panic":
+		panic("test panic")
+	}
+	msg.Get(0).Set(append([]byte("processed: "), msg.Get(0).Get()...))
+	return []types.Message{msg}, nil
+}
+
+func (p *parallelFaulty) CloseAsync() {
+}
+
+func (p *parallelFaulty) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+func TestParallelIsolation(t *testing.T) {
+	conf := NewConfig()
+	conf.Parallel.Cap = 2
+
+	stats := metrics.NewLocal()
+	h, err := NewParallel(conf, nil, log.Noop(), stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.(*Parallel).children = []types.Processor{&parallelFaulty{}}
+
+	msgs, res := h.ProcessMessage(message.New([][]byte{
+		[]byte("foo"),
+		[]byte("error"),
+		[]byte("bar"),
+		[]byte("panic"),
+		[]byte("baz"),
+	}))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of result messages: %v", len(msgs))
+	}
+
+	exp := []string{
+		"processed: foo",
+		"error",
+		"processed: bar",
+		"panic",
+		"processed: baz",
+	}
+	expFails := []string{
+		"",
+		"test error",
+		"",
+		"child processor panic: test panic",
+		"",
+	}
+	if act := msgs[0].Len(); act != len(exp) {
+		t.Fatalf("Wrong result count: %v != %v", act, len(exp))
+	}
+	for i, e := range exp {
+		part := msgs[0].Get(i)
+		if act := string(part.Get()); act != e {
+			t.Errorf("Wrong result at %v: %v != %v", i, act, e)
+		}
+		if act := GetFail(part); act != expFails[i] {
+			t.Errorf("Wrong fail flag at %v: %v != %v", i, act, expFails[i])
+		}
+	}
+	if exp, act := int64(2), stats.GetCounters()["error"]; exp != act {
+		t.Errorf("Wrong error count: %v != %v", act, exp)
+	}
+}
+
+func TestParallelMaxInFlightBytes(t *testing.T) {
+	var inFlight, maxInFlight int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqBytes, _ := ioutil.ReadAll(r.Body)
+		size := int64(len(reqBytes))
+		current := atomic.AddInt64(&inFlight, size)
+		for {
+			prev := atomic.LoadInt64(&maxInFlight)
+			if current <= prev || atomic.CompareAndSwapInt64(&maxInFlight, prev, current) {
+				break
+			}
+		}
+		<-time.After(time.Millisecond * 10)
+		atomic.AddInt64(&inFlight, -size)
+		w.Write(reqBytes)
+	}))
+	defer ts.Close()
+
+	httpConf := NewConfig()
+	httpConf.Type = TypeHTTP
+	httpConf.HTTP.Client.URL = ts.URL + "/testpost"
+
+	conf := NewConfig()
+	conf.Parallel.Processors = []Config{httpConf}
+	conf.Parallel.MaxInFlightBytes = 10
+
+	h, err := NewParallel(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := [][]byte{
+		[]byte("foo"),
+		[]byte("bar"),
+		[]byte("baz"),
+		[]byte("this is larger than the cap"),
+		[]byte("qux"),
+		[]byte("quz"),
+	}
+	msgs, res := h.ProcessMessage(message.New(input))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	for i, exp := range input {
+		if act := string(msgs[0].Get(i).Get()); act != string(exp) {
+			t.Errorf("Wrong result at %v: %v != %v", i, act, string(exp))
+		}
+	}
+	if max := atomic.LoadInt64(&maxInFlight); max > int64(len(input[3])) {
+		t.Errorf("Beyond in flight bytes cap: %v", max)
+	}
+}
@@ -0,0 +1,314 @@
+package processor
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/internal/parquet"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	olog "github.com/opentracing/opentracing-go/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeParquet] = TypeSpec{
+		constructor: NewParquet,
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Converts batches of JSON documents into Parquet files, and Parquet files into
+batches of JSON documents.`,
+		Beta:        true,
+		UsesBatches: true,
+		Description: `
+This processor is useful for archiving data to object storage in a format that
+is cheap to store and can be queried directly by data lake tools. The schema of
+the Parquet files can either be declared with the ` + "`schema`" + ` field, or
+when the field is empty it is inferred from the documents of each batch.
+
+In order to read Parquet files with an input that supports codecs you can also
+use the ` + "`parquet`" + ` codec, which emits a message for each row of a file.
+
+## Operators
+
+### ` + "`from_json`" + `
+
+Writes all the JSON documents of a batch as the rows of a single Parquet file,
+which becomes the contents of the resulting message. The resulting message
+adopts the metadata of the _first_ message of the batch. Fields of documents
+that are not within the schema are ignored.
+
+When a schema is inferred integers are written as ` + "`INT64`" + ` columns,
+other numbers as ` + "`DOUBLE`" + ` columns, strings as ` + "`UTF8`" + `
+columns, objects as groups and arrays as repeated fields, and all fields are
+optional.
+
+### ` + "`to_json`" + `
+
+Reads each message as a Parquet file and replaces it with a JSON document for
+each row of the file, where each document adopts the metadata of the file.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("operator", "The [operator](#operators) to execute.").HasOptions("from_json", "to_json"),
+			docs.FieldCommon("compression", "The compression codec to apply to the pages of written files.").HasOptions(parquet.CompressionTypes...),
+			docs.FieldCommon(
+				"schema", "A list of fields that describes the schema of written files. If empty the schema is inferred from the documents of each batch.",
+				[]interface{}{
+					map[string]interface{}{"name": "id", "type": "INT64", "required": true},
+					map[string]interface{}{"name": "tags", "type": "UTF8", "repeated": true},
+					map[string]interface{}{"name": "user", "fields": []interface{}{
+						map[string]interface{}{"name": "name", "type": "UTF8"},
+						map[string]interface{}{"name": "score", "type": "DOUBLE"},
+					}},
+				},
+			).HasType(docs.FieldArray).WithChildren(
+				docs.FieldCommon("name", "The name of the field.").HasDefault(""),
+				docs.FieldCommon("type", "The type of the column, which must be empty for fields with child fields.").HasOptions(parquet.FieldTypes...).HasDefault(""),
+				docs.FieldCommon("required", "Whether documents must contain a value for the field.").HasDefault(false),
+				docs.FieldCommon("repeated", "Whether the field is an array of values.").HasDefault(false),
+				docs.FieldCommon("fields", "A list of child fields, which makes the field a group. Child fields have the same structure as their parent field.").HasType(docs.FieldArray).HasDefault([]interface{}{}),
+			),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Archive to S3",
+				Summary: `
+Parquet files are most efficient when they contain a large number of rows, and
+therefore it's best to batch messages at the output level and convert each
+batch into a file.`,
+				Config: `
+output:
+  s3:
+    bucket: TODO
+    path: ${!timestamp_unix_nano()}.parquet
+    batching:
+      count: 10000
+      period: 5m
+      processors:
+        - parquet:
+            operator: from_json
+            compression: snappy
+`,
+			},
+			{
+				Title: "Declared Schema",
+				Summary: `
+Declaring a schema ensures that all files share the same columns even when
+documents are missing fields.`,
+				Config: `
+output:
+  gcp_cloud_storage:
+    bucket: TODO
+    path: events/${!timestamp_unix_nano()}.parquet
+    batching:
+      count: 10000
+      period: 5m
+      processors:
+        - parquet:
+            operator: from_json
+            schema:
+              - name: id
+                type: INT64
+                required: true
+              - name: event
+                type: UTF8
+              - name: tags
+                type: UTF8
+                repeated: true
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ParquetConfig contains configuration fields for the Parquet processor.
+type ParquetConfig struct {
+	Operator    string                `json:"operator" yaml:"operator"`
+	Compression string                `json:"compression" yaml:"compression"`
+	Schema      []parquet.FieldConfig `json:"schema" yaml:"schema"`
+}
+
+// NewParquetConfig returns a ParquetConfig with default values.
+func NewParquetConfig() ParquetConfig {
+	return ParquetConfig{
+		Operator:    "from_json",
+		Compression: "snappy",
+		Schema:      []parquet.FieldConfig{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Parquet is a processor that converts batches of JSON documents to and from
+// Parquet files.
+type Parquet struct {
+	fromJSON bool
+	encoder  *parquet.Encoder
+
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewParquet returns a Parquet processor.
+func NewParquet(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	p := &Parquet{
+		conf:  conf,
+		log:   log,
+		stats: stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	switch conf.Parquet.Operator {
+	case "from_json":
+		p.fromJSON = true
+		var err error
+		if p.encoder, err = parquet.NewEncoder(conf.Parquet.Schema, conf.Parquet.Compression); err != nil {
+			return nil, err
+		}
+	case "to_json":
+	default:
+		return nil, fmt.Errorf("operator not recognised: %v", conf.Parquet.Operator)
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+func parquetRows(b []byte) ([][]byte, error) {
+	r, err := parquet.NewReader(b)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var rows [][]byte
+	for {
+		groupRows, err := r.NextRowGroup()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, groupRows...)
+	}
+}
+
+func (p *Parquet) processFromJSON(msg types.Message) types.Message {
+	newMsg := msg.Copy()
+
+	spans := tracing.CreateChildSpans(TypeParquet, newMsg)
+	jsonDocs := make([][]byte, msg.Len())
+	msg.Iter(func(i int, part types.Part) error {
+		jsonDocs[i] = part.Get()
+		return nil
+	})
+
+	b, err := p.encoder.Encode(jsonDocs)
+	if err != nil {
+		newMsg.Iter(func(i int, part types.Part) error {
+			FlagErr(part, err)
+			spans[i].LogFields(
+				olog.String("event", "error"),
+				olog.String("type", err.Error()),
+			)
+			return nil
+		})
+		p.log.Errorf("Failed to create parquet file: %v\n", err)
+		p.mErr.Incr(1)
+	} else {
+		newPart := newMsg.Get(0).Copy()
+		newPart.Set(b)
+		newMsg.SetAll([]types.Part{newPart})
+	}
+	for _, s := range spans {
+		s.Finish()
+	}
+	return newMsg
+}
+
+func (p *Parquet) processToJSON(msg types.Message) types.Message {
+	newMsg := message.New(nil)
+	msg.Iter(func(i int, part types.Part) error {
+		span := tracing.CreateChildSpan(TypeParquet, part)
+		defer span.Finish()
+
+		rows, err := parquetRows(part.Get())
+		if err != nil {
+			p.mErr.Incr(1)
+			p.log.Errorf("Failed to read parquet file: %v\n", err)
+			newMsg.Append(part.Copy())
+			FlagErr(newMsg.Get(-1), err)
+			span.LogFields(
+				olog.String("event", "error"),
+				olog.String("type", err.Error()),
+			)
+			return nil
+		}
+		for _, row := range rows {
+			newPart := part.Copy()
+			newPart.Set(row)
+			newMsg.Append(newPart)
+		}
+		return nil
+	})
+	return newMsg
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (p *Parquet) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	p.mCount.Incr(1)
+
+	if msg.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	var newMsg types.Message
+	if p.fromJSON {
+		newMsg = p.processFromJSON(msg)
+	} else {
+		newMsg = p.processToJSON(msg)
+	}
+	if newMsg.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	p.mBatchSent.Incr(1)
+	p.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (p *Parquet) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (p *Parquet) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
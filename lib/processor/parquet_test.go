@@ -0,0 +1,107 @@
+package processor
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/internal/parquet"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParquetBadConfig(t *testing.T) {
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Parquet.Operator = "nope"
+	_, err := NewParquet(conf, nil, testLog, metrics.DudType{})
+	assert.Error(t, err)
+
+	conf = NewConfig()
+	conf.Parquet.Compression = "nope"
+	_, err = NewParquet(conf, nil, testLog, metrics.DudType{})
+	assert.Error(t, err)
+
+	conf = NewConfig()
+	conf.Parquet.Schema = []parquet.FieldConfig{{Name: "foo", Type: "nope"}}
+	_, err = NewParquet(conf, nil, testLog, metrics.DudType{})
+	assert.Error(t, err)
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Parquet.Operator = "from_json"
+	conf.Parquet.Schema = []parquet.FieldConfig{
+		{Name: "id", Type: "INT64", Required: true},
+		{Name: "name", Type: "UTF8"},
+	}
+	fromJSON, err := NewParquet(conf, nil, testLog, metrics.DudType{})
+	require.NoError(t, err)
+
+	conf = NewConfig()
+	conf.Parquet.Operator = "to_json"
+	toJSON, err := NewParquet(conf, nil, testLog, metrics.DudType{})
+	require.NoError(t, err)
+
+	input := message.New([][]byte{
+		[]byte(`{"id":1,"name":"foo"}`),
+		[]byte(`{"id":2,"name":"bar"}`),
+		[]byte(`{"id":3}`),
+	})
+	input.Get(0).Metadata().Set("foo", "bar")
+
+	msgs, res := fromJSON.ProcessMessage(input)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+	assert.False(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, "bar", msgs[0].Get(0).Metadata().Get("foo"))
+
+	msgs, res = toJSON.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{
+		[]byte(`{"id":1,"name":"foo"}`),
+		[]byte(`{"id":2,"name":"bar"}`),
+		[]byte(`{"id":3,"name":null}`),
+	}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "bar", msgs[0].Get(2).Metadata().Get("foo"))
+}
+
+func TestParquetErrors(t *testing.T) {
+	testLog := log.New(os.Stdout, log.Config{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Parquet.Operator = "from_json"
+	fromJSON, err := NewParquet(conf, nil, testLog, metrics.DudType{})
+	require.NoError(t, err)
+
+	msgs, res := fromJSON.ProcessMessage(message.New([][]byte{
+		[]byte(`{"id":1}`),
+		[]byte(`not json`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 2, msgs[0].Len())
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+	assert.True(t, HasFailed(msgs[0].Get(1)))
+
+	conf = NewConfig()
+	conf.Parquet.Operator = "to_json"
+	toJSON, err := NewParquet(conf, nil, testLog, metrics.DudType{})
+	require.NoError(t, err)
+
+	msgs, res = toJSON.ProcessMessage(message.New([][]byte{
+		[]byte(`not parquet`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, "not parquet", string(msgs[0].Get(0).Get()))
+}
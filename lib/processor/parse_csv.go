@@ -0,0 +1,329 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/message/tracing"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	olog "github.com/opentracing/opentracing-go/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeParseCSV] = TypeSpec{
+		constructor: NewParseCSV,
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Parses messages containing CSV data into arrays of objects.`,
+		Beta: true,
+		Description: `
+Messages are parsed following the format described in
+[RFC 4180](https://tools.ietf.org/html/rfc4180), with the delimiter of fields
+set with ` + "`delimiter`" + ` so that other formats such as TSV can also be
+parsed.
+
+When ` + "`columns`" + ` is empty the first row of each message is a header row
+that determines the keys of values in each object, otherwise the keys are the
+declared columns and every row of the message is a record.
+
+Values are parsed as strings unless their column is given a type in
+` + "`column_types`" + `, which can be ` + "`string`, `int`, `float` or `bool`" + `.
+Empty values of typed columns are parsed as ` + "`null`" + `, and messages
+containing values that can't be parsed as the type of their column fail.
+
+Rows are read one at a time, and when ` + "`split`" + ` is enabled each row is
+emitted as an individual message of the resulting batch rather than as an
+element of an array, which avoids creating a single large document for messages
+containing many rows.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("delimiter", "The delimiter of fields within a row, which must be a single character.", ",", "\t", ";"),
+			docs.FieldCommon("columns", "An optional list of column names, when empty the first row of each message is parsed as a header row.", []string{"id", "name", "price"}),
+			docs.FieldAdvanced("column_types", "An optional map of column names to the type that their values are parsed as.", map[string]string{"id": "int", "price": "float"}),
+			docs.FieldAdvanced("lazy_quotes", "Whether quotes may appear within unquoted fields and unescaped quotes may appear within quoted fields."),
+			docs.FieldCommon("split", "Whether each row is emitted as an individual message rather than as an element of an array."),
+			partsFieldSpec,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "TSV With Typed Columns",
+				Summary: `
+Tab separated data without a header row can be parsed into a message per row
+with declared columns:`,
+				Config: `
+pipeline:
+  processors:
+    - parse_csv:
+        delimiter: "\t"
+        columns: [ id, name, price ]
+        column_types:
+          id: int
+          price: float
+        split: true
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ParseCSVConfig contains configuration fields for the ParseCSV processor.
+type ParseCSVConfig struct {
+	Parts       []int             `json:"parts" yaml:"parts"`
+	Delimiter   string            `json:"delimiter" yaml:"delimiter"`
+	Columns     []string          `json:"columns" yaml:"columns"`
+	ColumnTypes map[string]string `json:"column_types" yaml:"column_types"`
+	LazyQuotes  bool              `json:"lazy_quotes" yaml:"lazy_quotes"`
+	Split       bool              `json:"split" yaml:"split"`
+}
+
+// NewParseCSVConfig returns a ParseCSVConfig with default values.
+func NewParseCSVConfig() ParseCSVConfig {
+	return ParseCSVConfig{
+		Parts:       []int{},
+		Delimiter:   ",",
+		Columns:     []string{},
+		ColumnTypes: map[string]string{},
+		LazyQuotes:  false,
+		Split:       false,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type csvValueParser func(v string) (interface{}, error)
+
+func csvTypeParser(typeStr string) (csvValueParser, error) {
+	switch typeStr {
+	case "string":
+		return nil, nil
+	case "int":
+		return func(v string) (interface{}, error) {
+			return strconv.ParseInt(v, 10, 64)
+		}, nil
+	case "float":
+		return func(v string) (interface{}, error) {
+			return strconv.ParseFloat(v, 64)
+		}, nil
+	case "bool":
+		return func(v string) (interface{}, error) {
+			return strconv.ParseBool(v)
+		}, nil
+	}
+	return nil, fmt.Errorf("column type not recognised: %v", typeStr)
+}
+
+//------------------------------------------------------------------------------
+
+// ParseCSV is a processor that parses messages containing CSV data into arrays
+// of objects.
+type ParseCSV struct {
+	conf    ParseCSVConfig
+	delim   rune
+	parsers map[string]csvValueParser
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewParseCSV returns a ParseCSV processor.
+func NewParseCSV(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	p := &ParseCSV{
+		conf:    conf.ParseCSV,
+		parsers: map[string]csvValueParser{},
+		log:     log,
+		stats:   stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	var size int
+	if p.delim, size = utf8.DecodeRuneInString(conf.ParseCSV.Delimiter); size == 0 || size != len(conf.ParseCSV.Delimiter) {
+		return nil, fmt.Errorf("delimiter must be a single character, received: %q", conf.ParseCSV.Delimiter)
+	}
+	for column, typeStr := range conf.ParseCSV.ColumnTypes {
+		parser, err := csvTypeParser(typeStr)
+		if err != nil {
+			return nil, fmt.Errorf("column %v: %v", column, err)
+		}
+		if parser != nil {
+			p.parsers[column] = parser
+		}
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// parse reads the rows of a CSV document one at a time and calls fn with each
+// row as an object.
+func (p *ParseCSV) parse(data []byte, fn func(row map[string]interface{}) error) error {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = p.delim
+	r.LazyQuotes = p.conf.LazyQuotes
+	r.ReuseRecord = true
+
+	headers := p.conf.Columns
+	if len(headers) > 0 {
+		r.FieldsPerRecord = len(headers)
+	} else {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				return errors.New("no header row found")
+			}
+			return err
+		}
+		headers = make([]string, len(record))
+		copy(headers, record)
+	}
+
+	for index := 0; ; index++ {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		row := make(map[string]interface{}, len(record))
+		for i, v := range record {
+			parser, typed := p.parsers[headers[i]]
+			if !typed {
+				row[headers[i]] = v
+				continue
+			}
+			if v == "" {
+				row[headers[i]] = nil
+				continue
+			}
+			if row[headers[i]], err = parser(v); err != nil {
+				return fmt.Errorf("record %v: column %v: %v", index, headers[i], err)
+			}
+		}
+		if err = fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *ParseCSV) parsePart(part types.Part) ([]types.Part, error) {
+	if !p.conf.Split {
+		rows := []interface{}{}
+		if err := p.parse(part.Get(), func(row map[string]interface{}) error {
+			rows = append(rows, row)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		newPart := part.Copy()
+		if err := newPart.SetJSON(rows); err != nil {
+			return nil, err
+		}
+		return []types.Part{newPart}, nil
+	}
+
+	var newParts []types.Part
+	if err := p.parse(part.Get(), func(row map[string]interface{}) error {
+		newPart := message.NewPart(nil)
+		newPart.SetMetadata(part.Metadata().Copy())
+		if err := newPart.SetJSON(row); err != nil {
+			return err
+		}
+		newParts = append(newParts, newPart)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return newParts, nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (p *ParseCSV) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	p.mCount.Incr(1)
+
+	newMsg := message.New(nil)
+	lParts := msg.Len()
+
+	noParts := len(p.conf.Parts) == 0
+	msg.Iter(func(i int, part types.Part) error {
+		isTarget := noParts
+		if !isTarget {
+			nI := i - lParts
+			for _, t := range p.conf.Parts {
+				if t == nI || t == i {
+					isTarget = true
+					break
+				}
+			}
+		}
+		if !isTarget {
+			newMsg.Append(msg.Get(i).Copy())
+			return nil
+		}
+
+		span := tracing.CreateChildSpan(TypeParseCSV, part)
+		defer span.Finish()
+
+		newParts, err := p.parsePart(part)
+		if err == nil {
+			newMsg.Append(newParts...)
+		} else {
+			p.mErr.Incr(1)
+			p.log.Debugf("Failed to parse message part as CSV: %v\n", err)
+			newMsg.Append(part.Copy())
+			FlagErr(newMsg.Get(-1), err)
+			span.LogFields(
+				olog.String("event", "error"),
+				olog.String("type", err.Error()),
+			)
+		}
+		return nil
+	})
+
+	if newMsg.Len() == 0 {
+		return nil, response.NewAck()
+	}
+
+	p.mBatchSent.Incr(1)
+	p.mSent.Incr(int64(newMsg.Len()))
+	return []types.Message{newMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (p *ParseCSV) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (p *ParseCSV) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
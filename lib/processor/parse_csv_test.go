@@ -0,0 +1,171 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := map[string]struct {
+		conf   func(c *ParseCSVConfig)
+		input  string
+		output []string
+	}{
+		"header row": {
+			conf:   func(c *ParseCSVConfig) {},
+			input:  "foo,bar\nfoo 1,bar 1\nfoo 2,bar 2",
+			output: []string{`[{"bar":"bar 1","foo":"foo 1"},{"bar":"bar 2","foo":"foo 2"}]`},
+		},
+		"header only": {
+			conf:   func(c *ParseCSVConfig) {},
+			input:  "foo,bar\n",
+			output: []string{`[]`},
+		},
+		"quoted fields": {
+			conf:   func(c *ParseCSVConfig) {},
+			input:  "foo,bar\n\"a, \"\"b\"\"\",\"multi\nline\"",
+			output: []string{`[{"bar":"multi\nline","foo":"a, \"b\""}]`},
+		},
+		"lazy quotes": {
+			conf: func(c *ParseCSVConfig) {
+				c.LazyQuotes = true
+			},
+			input:  "foo,bar\na \"b\",c",
+			output: []string{`[{"bar":"c","foo":"a \"b\""}]`},
+		},
+		"tsv with columns": {
+			conf: func(c *ParseCSVConfig) {
+				c.Delimiter = "\t"
+				c.Columns = []string{"id", "name"}
+			},
+			input:  "1\tfoo\n2\tbar, baz",
+			output: []string{`[{"id":"1","name":"foo"},{"id":"2","name":"bar, baz"}]`},
+		},
+		"column types": {
+			conf: func(c *ParseCSVConfig) {
+				c.ColumnTypes = map[string]string{
+					"id":     "int",
+					"price":  "float",
+					"stock":  "bool",
+					"name":   "string",
+					"weight": "float",
+				}
+			},
+			input:  "id,name,price,stock,weight\n1,007,2.5,true,\n-2,bar,3,false,1.25",
+			output: []string{`[{"id":1,"name":"007","price":2.5,"stock":true,"weight":null},{"id":-2,"name":"bar","price":3,"stock":false,"weight":1.25}]`},
+		},
+		"split": {
+			conf: func(c *ParseCSVConfig) {
+				c.Split = true
+				c.ColumnTypes = map[string]string{"id": "int"}
+			},
+			input: "id,name\n1,foo\n2,bar\n3,baz",
+			output: []string{
+				`{"id":1,"name":"foo"}`,
+				`{"id":2,"name":"bar"}`,
+				`{"id":3,"name":"baz"}`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Type = TypeParseCSV
+			test.conf(&conf.ParseCSV)
+
+			proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			input := message.New([][]byte{[]byte(test.input)})
+			input.Get(0).Metadata().Set("foo", "bar")
+
+			msgs, res := proc.ProcessMessage(input)
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			require.Equal(t, len(test.output), msgs[0].Len())
+
+			for i, exp := range test.output {
+				part := msgs[0].Get(i)
+				require.False(t, HasFailed(part), GetFail(part))
+				assert.Equal(t, exp, string(part.Get()))
+				assert.Equal(t, "bar", part.Metadata().Get("foo"))
+			}
+		})
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	tests := map[string]struct {
+		conf  func(c *ParseCSVConfig)
+		input string
+		err   string
+	}{
+		"empty": {
+			conf:  func(c *ParseCSVConfig) {},
+			input: "",
+			err:   "no header row found",
+		},
+		"field count mismatch": {
+			conf:  func(c *ParseCSVConfig) {},
+			input: "foo,bar\n1,2,3",
+			err:   "record on line 2: wrong number of fields",
+		},
+		"column count mismatch": {
+			conf: func(c *ParseCSVConfig) {
+				c.Columns = []string{"foo", "bar"}
+			},
+			input: "1,2\n3",
+			err:   "record on line 2: wrong number of fields",
+		},
+		"bad type": {
+			conf: func(c *ParseCSVConfig) {
+				c.ColumnTypes = map[string]string{"id": "int"}
+			},
+			input: "id\n1\nnope",
+			err:   `record 1: column id: strconv.ParseInt: parsing "nope": invalid syntax`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Type = TypeParseCSV
+			test.conf(&conf.ParseCSV)
+
+			proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(test.input)}))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			require.Equal(t, 1, msgs[0].Len())
+
+			assert.Equal(t, test.input, string(msgs[0].Get(0).Get()))
+			assert.Equal(t, test.err, GetFail(msgs[0].Get(0)))
+		})
+	}
+}
+
+func TestParseCSVBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeParseCSV
+	conf.ParseCSV.Delimiter = "ab"
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	conf = NewConfig()
+	conf.Type = TypeParseCSV
+	conf.ParseCSV.ColumnTypes = map[string]string{"foo": "nope"}
+
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "column foo: column type not recognised: nope")
+}
@@ -3,6 +3,7 @@ package processor
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"time"
@@ -11,7 +12,9 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/desc/protoparse"
 	"github.com/jhump/protoreflect/dynamic"
@@ -51,11 +54,39 @@ to manipulate the contents of the document within Benthos.
 
 ### ` + "`from_json`" + `
 
-Attempts to create a target protobuf message from a generic JSON structure.`,
+Attempts to create a target protobuf message from a generic JSON structure.
+
+## Schemas
+
+The definition of the target message is loaded either from the .proto files
+found within ` + "`import_path`" + `, or from a compiled ` + "`FileDescriptorSet`" + `
+set with ` + "`descriptor_set`" + `, which can be generated with:
+
+` + "```sh" + `
+protoc --descriptor_set_out=./schema.protoset --include_imports -I. ./*.proto
+` + "```" + `
+
+When both fields are set the messages of the descriptor set and the .proto files
+are combined.
+
+### Any
+
+Fields of the type ` + "`google.protobuf.Any`" + ` are resolved using all of the
+messages that were loaded, including messages that aren't imported by the target
+message. The JSON form of an ` + "`Any`" + ` value contains the field ` + "`@type`" + `,
+which is the type URL of the message, and the fields of the message itself.
+
+### Enums
+
+By default enum values are converted to JSON as the names of the values, set
+` + "`use_enum_numbers`" + ` to ` + "`true`" + ` in order to use their numbers
+instead. Both forms are accepted by the ` + "`from_json`" + ` operator.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json"),
 			docs.FieldCommon("message", "The fully qualified name of the protobuf message to convert to/from."),
-			docs.FieldCommon("import_path", "A path to a .proto file, or directory containing all .proto files required for parsing the target message. If left empty the current directory is used."),
+			docs.FieldCommon("import_path", "A path to a .proto file, or directory containing all .proto files required for parsing the target message. If left empty the current directory is used, unless a `descriptor_set` is set."),
+			docs.FieldCommon("descriptor_set", "An optional path to a compiled `FileDescriptorSet` containing the target message and all of its dependencies.", "./schema.protoset"),
+			docs.FieldAdvanced("use_enum_numbers", "Whether the `to_json` operator should convert enum values to their numbers rather than their names."),
 			partsFieldSpec,
 		},
 	}
@@ -65,19 +96,23 @@ Attempts to create a target protobuf message from a generic JSON structure.`,
 
 // ProtobufConfig contains configuration fields for the Protobuf processor.
 type ProtobufConfig struct {
-	Parts      []int  `json:"parts" yaml:"parts"`
-	Operator   string `json:"operator" yaml:"operator"`
-	Message    string `json:"message" yaml:"message"`
-	ImportPath string `json:"import_path" yaml:"import_path"`
+	Parts          []int  `json:"parts" yaml:"parts"`
+	Operator       string `json:"operator" yaml:"operator"`
+	Message        string `json:"message" yaml:"message"`
+	ImportPath     string `json:"import_path" yaml:"import_path"`
+	DescriptorSet  string `json:"descriptor_set" yaml:"descriptor_set"`
+	UseEnumNumbers bool   `json:"use_enum_numbers" yaml:"use_enum_numbers"`
 }
 
 // NewProtobufConfig returns a ProtobufConfig with default values.
 func NewProtobufConfig() ProtobufConfig {
 	return ProtobufConfig{
-		Parts:      []int{},
-		Operator:   "to_json",
-		Message:    "",
-		ImportPath: "",
+		Parts:          []int{},
+		Operator:       "to_json",
+		Message:        "",
+		ImportPath:     "",
+		DescriptorSet:  "",
+		UseEnumNumbers: false,
 	}
 }
 
@@ -85,18 +120,22 @@ func NewProtobufConfig() ProtobufConfig {
 
 type protobufOperator func(part types.Part) error
 
-func newProtobufToJSONOperator(message, importPath string) (protobufOperator, error) {
-	m, err := loadDescriptor(message, importPath)
+func newProtobufToJSONOperator(conf ProtobufConfig) (protobufOperator, error) {
+	m, files, err := loadDescriptor(conf)
 	if err != nil {
 		return nil, err
 	}
+	marshaler := &jsonpb.Marshaler{
+		EnumsAsInts: conf.UseEnumNumbers,
+		AnyResolver: dynamic.AnyResolver(nil, files...),
+	}
 	return func(part types.Part) error {
 		msg := dynamic.NewMessage(m)
 		if err := proto.Unmarshal(part.Get(), msg); err != nil {
 			return fmt.Errorf("failed to unmarshal message: %w", err)
 		}
 
-		data, err := msg.MarshalJSON()
+		data, err := msg.MarshalJSONPB(marshaler)
 		if err != nil {
 			return fmt.Errorf("failed to marshal protobuf message: %w", err)
 		}
@@ -106,14 +145,17 @@ func newProtobufToJSONOperator(message, importPath string) (protobufOperator, er
 	}, nil
 }
 
-func newProtobufFromJSONOperator(message, importPath string) (protobufOperator, error) {
-	m, err := loadDescriptor(message, importPath)
+func newProtobufFromJSONOperator(conf ProtobufConfig) (protobufOperator, error) {
+	m, files, err := loadDescriptor(conf)
 	if err != nil {
 		return nil, err
 	}
+	unmarshaler := &jsonpb.Unmarshaler{
+		AnyResolver: dynamic.AnyResolver(nil, files...),
+	}
 	return func(part types.Part) error {
 		msg := dynamic.NewMessage(m)
-		if err := msg.UnmarshalJSON(part.Get()); err != nil {
+		if err := msg.UnmarshalJSONPB(unmarshaler, part.Get()); err != nil {
 			return fmt.Errorf("failed to unmarshal JSON message: %w", err)
 		}
 
@@ -127,21 +169,17 @@ func newProtobufFromJSONOperator(message, importPath string) (protobufOperator,
 	}, nil
 }
 
-func strToProtobufOperator(opStr, message, importPath string) (protobufOperator, error) {
-	switch opStr {
+func strToProtobufOperator(conf ProtobufConfig) (protobufOperator, error) {
+	switch conf.Operator {
 	case "to_json":
-		return newProtobufToJSONOperator(message, importPath)
+		return newProtobufToJSONOperator(conf)
 	case "from_json":
-		return newProtobufFromJSONOperator(message, importPath)
+		return newProtobufFromJSONOperator(conf)
 	}
-	return nil, fmt.Errorf("operator not recognised: %v", opStr)
+	return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
 }
 
-func loadDescriptor(message, importPath string) (*desc.MessageDescriptor, error) {
-	if len(message) == 0 {
-		return nil, errors.New("message field must not be empty")
-	}
-
+func parseProtoFiles(importPath string) ([]*desc.FileDescriptor, error) {
 	var parser protoparse.Parser
 	if len(importPath) > 0 {
 		parser.ImportPaths = []string{importPath}
@@ -174,17 +212,80 @@ func loadDescriptor(message, importPath string) (*desc.MessageDescriptor, error)
 	if len(fds) == 0 {
 		return nil, fmt.Errorf("no .proto files were found in the path '%v'", importPath)
 	}
+	return fds, nil
+}
+
+func readDescriptorSet(path string) ([]*desc.FileDescriptor, error) {
+	setBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %v", err)
+	}
+
+	var set descriptor.FileDescriptorSet
+	if err = proto.Unmarshal(setBytes, &set); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal descriptor set: %v", err)
+	}
+
+	fdMap, err := desc.CreateFileDescriptorsFromSet(&set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create descriptors from set: %v", err)
+	}
+
+	// Keep the order of the set so that message lookups are deterministic.
+	fds := make([]*desc.FileDescriptor, 0, len(fdMap))
+	for _, fdProto := range set.File {
+		if fd, exists := fdMap[fdProto.GetName()]; exists {
+			fds = append(fds, fd)
+		}
+	}
+	if len(fds) == 0 {
+		return nil, fmt.Errorf("no files were found in the descriptor set '%v'", path)
+	}
+	return fds, nil
+}
+
+// loadDescriptor returns the descriptor of the target message along with all
+// files loaded from the descriptor set and import path, which are used for
+// resolving the types of Any fields.
+func loadDescriptor(conf ProtobufConfig) (*desc.MessageDescriptor, []*desc.FileDescriptor, error) {
+	if len(conf.Message) == 0 {
+		return nil, nil, errors.New("message field must not be empty")
+	}
+
+	var fds []*desc.FileDescriptor
+	if len(conf.DescriptorSet) > 0 {
+		setFds, err := readDescriptorSet(conf.DescriptorSet)
+		if err != nil {
+			return nil, nil, err
+		}
+		fds = append(fds, setFds...)
+	}
+	// Proto files are only parsed alongside a descriptor set when an import
+	// path is explicitly set.
+	if len(conf.DescriptorSet) == 0 || len(conf.ImportPath) > 0 {
+		parsedFds, err := parseProtoFiles(conf.ImportPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		fds = append(fds, parsedFds...)
+	}
 
 	var msg *desc.MessageDescriptor
 	for _, d := range fds {
-		if msg = d.FindMessage(message); msg != nil {
+		if msg = d.FindMessage(conf.Message); msg != nil {
 			break
 		}
 	}
 	if msg == nil {
-		err = fmt.Errorf("unable to find message '%v' definition within '%v'", message, importPath)
+		source := conf.ImportPath
+		if len(conf.DescriptorSet) > 0 {
+			source = conf.DescriptorSet
+		} else if len(source) == 0 {
+			source = "."
+		}
+		return nil, nil, fmt.Errorf("unable to find message '%v' definition within '%v'", conf.Message, source)
 	}
-	return msg, err
+	return msg, fds, nil
 }
 
 //------------------------------------------------------------------------------
@@ -221,7 +322,7 @@ func NewProtobuf(
 	}
 
 	var err error
-	if p.operator, err = strToProtobufOperator(conf.Protobuf.Operator, conf.Protobuf.Message, conf.Protobuf.ImportPath); err != nil {
+	if p.operator, err = strToProtobufOperator(conf.Protobuf); err != nil {
 		return nil, err
 	}
 	return p, nil
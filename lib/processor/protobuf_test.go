@@ -1,12 +1,18 @@
 package processor
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -154,3 +160,155 @@ func TestProtobufErrors(t *testing.T) {
 		})
 	}
 }
+
+const protobufTestEventProto = `
+syntax = "proto3";
+package testing;
+
+import "google/protobuf/any.proto";
+
+enum Status {
+  UNKNOWN = 0;
+  ACTIVE = 1;
+}
+
+message Event {
+  string id = 1;
+  Status status = 2;
+  google.protobuf.Any detail = 3;
+}
+`
+
+// The Click message is not imported by the Event message.
+const protobufTestClickProto = `
+syntax = "proto3";
+package testing;
+
+message Click {
+  int32 x = 1;
+}
+`
+
+func writeProtobufTestSchema(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "event.proto"), []byte(protobufTestEventProto), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "click.proto"), []byte(protobufTestClickProto), 0644))
+	return dir
+}
+
+func writeProtobufTestDescriptorSet(t *testing.T, importPath string) string {
+	t.Helper()
+
+	parser := protoparse.Parser{ImportPaths: []string{importPath}}
+	fds, err := parser.ParseFiles("event.proto", "click.proto")
+	require.NoError(t, err)
+
+	var set descriptor.FileDescriptorSet
+	seen := map[string]struct{}{}
+	var add func(fd *desc.FileDescriptor)
+	add = func(fd *desc.FileDescriptor) {
+		if _, exists := seen[fd.GetName()]; exists {
+			return
+		}
+		seen[fd.GetName()] = struct{}{}
+		for _, dep := range fd.GetDependencies() {
+			add(dep)
+		}
+		set.File = append(set.File, fd.AsFileDescriptorProto())
+	}
+	for _, fd := range fds {
+		add(fd)
+	}
+
+	setBytes, err := proto.Marshal(&set)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "schema.protoset")
+	require.NoError(t, ioutil.WriteFile(path, setBytes, 0644))
+	return path
+}
+
+func TestProtobufAnyAndEnums(t *testing.T) {
+	importPath := writeProtobufTestSchema(t)
+	descriptorSet := writeProtobufTestDescriptorSet(t, importPath)
+
+	input := `{"id":"foo","status":"ACTIVE","detail":{"@type":"type.googleapis.com/testing.Click","x":5}}`
+
+	tests := map[string]struct {
+		conf   func(c *ProtobufConfig)
+		output string
+	}{
+		"import path": {
+			conf: func(c *ProtobufConfig) {
+				c.ImportPath = importPath
+			},
+			output: input,
+		},
+		"descriptor set": {
+			conf: func(c *ProtobufConfig) {
+				c.DescriptorSet = descriptorSet
+			},
+			output: input,
+		},
+		"enum numbers": {
+			conf: func(c *ProtobufConfig) {
+				c.DescriptorSet = descriptorSet
+				c.UseEnumNumbers = true
+			},
+			output: `{"id":"foo","status":1,"detail":{"@type":"type.googleapis.com/testing.Click","x":5}}`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			fromConf := NewConfig()
+			fromConf.Type = TypeProtobuf
+			fromConf.Protobuf.Operator = "from_json"
+			fromConf.Protobuf.Message = "testing.Event"
+			test.conf(&fromConf.Protobuf)
+
+			toConf := fromConf
+			toConf.Protobuf.Operator = "to_json"
+
+			fromProc, err := New(fromConf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+			toProc, err := New(toConf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			msgs, res := fromProc.ProcessMessage(message.New([][]byte{[]byte(input)}))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			require.Empty(t, msgs[0].Get(0).Metadata().Get(FailFlagKey))
+
+			msgs, res = toProc.ProcessMessage(msgs[0])
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			require.Empty(t, msgs[0].Get(0).Metadata().Get(FailFlagKey))
+
+			assert.Equal(t, test.output, string(msgs[0].Get(0).Get()))
+		})
+	}
+}
+
+func TestProtobufDescriptorSetErrors(t *testing.T) {
+	descriptorSet := writeProtobufTestDescriptorSet(t, writeProtobufTestSchema(t))
+
+	conf := NewConfig()
+	conf.Type = TypeProtobuf
+	conf.Protobuf.Message = "testing.Nope"
+	conf.Protobuf.DescriptorSet = descriptorSet
+
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "unable to find message 'testing.Nope' definition within '"+descriptorSet+"'")
+
+	notASet := filepath.Join(t.TempDir(), "nope.protoset")
+	require.NoError(t, ioutil.WriteFile(notASet, []byte("not a descriptor set"), 0644))
+
+	conf.Protobuf.Message = "testing.Event"
+	conf.Protobuf.DescriptorSet = notASet
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.Error(t, err)
+}
@@ -0,0 +1,275 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeRateLimitSpillover] = TypeSpec{
+		constructor: NewRateLimitSpillover,
+		Categories: []Category{
+			CategoryComposition,
+			CategoryUtility,
+		},
+		Summary: `
+Applies a list of child processors to messages within a
+[rate limit](/docs/components/rate_limits/about), and routes messages that
+exceed the limit to an alternative list of processors rather than waiting.`,
+		Beta: true,
+		Description: `
+Each message of a batch accesses the rate limit once. Messages within the limit
+have the ` + "`processors`" + ` applied to them, and messages that exceed the
+limit, or that fail to access the rate limit, have the ` + "`spillover`" + `
+processors applied to them instead. Unlike the
+` + "[`rate_limit`](/docs/components/processors/rate_limit)" + ` processor the
+pipeline is never blocked by the limit.
+
+When a batch is split the results of the ` + "`processors`" + ` and the
+` + "`spillover`" + ` processors continue as separate batches, and therefore the
+ordering of messages across the two is not preserved.
+
+## Metrics
+
+The number of messages that spill over is counted with the metric
+` + "`spillover`" + `.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("resource", "The target [`rate_limit` resource](/docs/components/rate_limits/about)."),
+			docs.FieldCommon("processors", "A list of processors to apply to messages within the rate limit. When empty the messages are unchanged."),
+			docs.FieldCommon("spillover", "A list of processors to apply to messages that exceed the rate limit."),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Secondary Queue",
+				Summary: `
+Here messages are sent to an enrichment service within its rate limit, and
+messages beyond the limit are posted to a secondary queue to be enriched later
+and removed from the pipeline:`,
+				Config: `
+pipeline:
+  processors:
+    - rate_limit_spillover:
+        resource: enrichment_limit
+        processors:
+          - http:
+              url: http://enrichment:8080/enrich
+              verb: POST
+        spillover:
+          - http:
+              url: http://queue:8080/deferred
+              verb: POST
+          - bloblang: root = deleted()
+
+resources:
+  rate_limits:
+    enrichment_limit:
+      local:
+        count: 500
+        interval: 1s
+`,
+			},
+		},
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			return conf.RateLimitSpillover.Sanitise()
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// RateLimitSpilloverConfig contains configuration fields for the
+// RateLimitSpillover processor.
+type RateLimitSpilloverConfig struct {
+	Resource   string   `json:"resource" yaml:"resource"`
+	Processors []Config `json:"processors" yaml:"processors"`
+	Spillover  []Config `json:"spillover" yaml:"spillover"`
+}
+
+// NewRateLimitSpilloverConfig returns a RateLimitSpilloverConfig with default
+// values.
+func NewRateLimitSpilloverConfig() RateLimitSpilloverConfig {
+	return RateLimitSpilloverConfig{
+		Resource:   "",
+		Processors: []Config{},
+		Spillover:  []Config{},
+	}
+}
+
+// Sanitise the configuration into a minimal structure that can be printed
+// without changing the intent.
+func (r RateLimitSpilloverConfig) Sanitise() (map[string]interface{}, error) {
+	sanitiseProcs := func(confs []Config) ([]interface{}, error) {
+		procConfs := make([]interface{}, len(confs))
+		for i, pConf := range confs {
+			var err error
+			if procConfs[i], err = SanitiseConfig(pConf); err != nil {
+				return nil, err
+			}
+		}
+		return procConfs, nil
+	}
+	procConfs, err := sanitiseProcs(r.Processors)
+	if err != nil {
+		return nil, err
+	}
+	spillConfs, err := sanitiseProcs(r.Spillover)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"resource":   r.Resource,
+		"processors": procConfs,
+		"spillover":  spillConfs,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// RateLimitSpillover is a processor that applies child processors to messages
+// within a rate limit, and alternative processors to messages that exceed it.
+type RateLimitSpillover struct {
+	rl        types.RateLimit
+	children  []types.Processor
+	spillover []types.Processor
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mSpillover metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewRateLimitSpillover returns a RateLimitSpillover processor.
+func NewRateLimitSpillover(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	rl, err := mgr.GetRateLimit(conf.RateLimitSpillover.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain rate limit resource '%v': %v", conf.RateLimitSpillover.Resource, err)
+	}
+
+	newChildren := func(name string, confs []Config) ([]types.Processor, error) {
+		var procs []types.Processor
+		for i, pconf := range confs {
+			prefix := fmt.Sprintf("%v.%v", name, i)
+			proc, err := New(pconf, mgr, log.NewModule("."+prefix), metrics.Namespaced(stats, prefix))
+			if err != nil {
+				return nil, fmt.Errorf("failed to init %v %v: %w", name, i, err)
+			}
+			procs = append(procs, proc)
+		}
+		return procs, nil
+	}
+
+	r := &RateLimitSpillover{
+		rl:  rl,
+		log: log,
+
+		mCount:     stats.GetCounter("count"),
+		mSpillover: stats.GetCounter("spillover"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+	if r.children, err = newChildren("processor", conf.RateLimitSpillover.Processors); err != nil {
+		return nil, err
+	}
+	if r.spillover, err = newChildren("spillover", conf.RateLimitSpillover.Spillover); err != nil {
+		return nil, err
+	}
+	if len(r.spillover) == 0 {
+		return nil, errors.New("at least one spillover processor must be specified")
+	}
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (r *RateLimitSpillover) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	r.mCount.Incr(1)
+
+	within, spilled := message.New(nil), message.New(nil)
+	msg.Iter(func(i int, p types.Part) error {
+		waitFor, err := r.rl.Access()
+		if err != nil {
+			r.mErr.Incr(1)
+			r.log.Errorf("Failed to access rate limit: %v\n", err)
+		}
+		if err != nil || waitFor > 0 {
+			r.mSpillover.Incr(1)
+			spilled.Append(p.Copy())
+		} else {
+			within.Append(p.Copy())
+		}
+		return nil
+	})
+
+	var resultMsgs []types.Message
+	var res types.Response
+	for _, b := range []struct {
+		procs []types.Processor
+		msg   types.Message
+	}{
+		{procs: r.children, msg: within},
+		{procs: r.spillover, msg: spilled},
+	} {
+		if b.msg.Len() == 0 {
+			continue
+		}
+		var msgs []types.Message
+		if msgs, res = ExecuteAll(b.procs, b.msg); res != nil && res.Error() != nil {
+			return nil, res
+		}
+		resultMsgs = append(resultMsgs, msgs...)
+	}
+
+	if len(resultMsgs) == 0 {
+		if res == nil {
+			res = response.NewAck()
+		}
+		return nil, res
+	}
+
+	r.mBatchSent.Incr(int64(len(resultMsgs)))
+	for _, m := range resultMsgs {
+		r.mSent.Incr(int64(m.Len()))
+	}
+	return resultMsgs, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (r *RateLimitSpillover) CloseAsync() {
+	for _, child := range r.children {
+		child.CloseAsync()
+	}
+	for _, child := range r.spillover {
+		child.CloseAsync()
+	}
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (r *RateLimitSpillover) WaitForClose(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	for _, child := range append(r.children, r.spillover...) {
+		if err := child.WaitForClose(time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSpilloverMgr(results ...error) *fakeMgr {
+	var hits int
+	return &fakeMgr{
+		ratelimits: map[string]types.RateLimit{
+			"foo": fakeRateLimit{resFn: func() (time.Duration, error) {
+				// Every other access exceeds the limit unless a result is
+				// provided.
+				defer func() { hits++ }()
+				if hits < len(results) && results[hits] != nil {
+					return 0, results[hits]
+				}
+				if hits%2 == 1 {
+					return time.Second, nil
+				}
+				return 0, nil
+			}},
+		},
+	}
+}
+
+func newSpilloverConf(processors, spillover []string) Config {
+	conf := NewConfig()
+	conf.Type = TypeRateLimitSpillover
+	conf.RateLimitSpillover.Resource = "foo"
+	for _, m := range processors {
+		pConf := NewConfig()
+		pConf.Type = TypeBloblang
+		pConf.Bloblang = BloblangConfig(m)
+		conf.RateLimitSpillover.Processors = append(conf.RateLimitSpillover.Processors, pConf)
+	}
+	for _, m := range spillover {
+		pConf := NewConfig()
+		pConf.Type = TypeBloblang
+		pConf.Bloblang = BloblangConfig(m)
+		conf.RateLimitSpillover.Spillover = append(conf.RateLimitSpillover.Spillover, pConf)
+	}
+	return conf
+}
+
+func TestRateLimitSpillover(t *testing.T) {
+	conf := newSpilloverConf(
+		[]string{`root = "within: " + content()`},
+		[]string{`root = "spilled: " + content()`},
+	)
+
+	stats := metrics.NewLocal()
+	proc, err := New(conf, newSpilloverMgr(nil, nil, nil, nil, errors.New("nope")), log.Noop(), stats)
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"), []byte("qux"), []byte("quz"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 2)
+
+	assert.Equal(t, [][]byte{
+		[]byte("within: foo"), []byte("within: baz"),
+	}, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, [][]byte{
+		[]byte("spilled: bar"), []byte("spilled: qux"), []byte("spilled: quz"),
+	}, message.GetAllBytes(msgs[1]))
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(3), counters["spillover"])
+	assert.Equal(t, int64(1), counters["error"])
+	assert.Equal(t, int64(5), counters["sent"])
+	assert.Equal(t, int64(2), counters["batch.sent"])
+}
+
+func TestRateLimitSpilloverNoProcessors(t *testing.T) {
+	conf := newSpilloverConf(nil, []string{`root = deleted()`})
+
+	proc, err := New(conf, newSpilloverMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{[]byte("foo"), []byte("baz")}, message.GetAllBytes(msgs[0]))
+
+	msgs, res = proc.ProcessMessage(message.New([][]byte{[]byte("bar")}))
+	assert.Empty(t, msgs)
+	require.NotNil(t, res)
+	assert.NoError(t, res.Error())
+}
+
+func TestRateLimitSpilloverBadConfig(t *testing.T) {
+	conf := newSpilloverConf(nil, nil)
+	_, err := New(conf, newSpilloverMgr(), log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "at least one spillover processor must be specified")
+
+	conf.RateLimitSpillover.Resource = "bar"
+	_, err = New(conf, newSpilloverMgr(), log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "failed to obtain rate limit resource 'bar': rate limit not found")
+
+	conf = newSpilloverConf([]string{`root = `}, []string{`root = deleted()`})
+	_, err = New(conf, newSpilloverMgr(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to init processor 0: ")
+}
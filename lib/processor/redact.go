@@ -0,0 +1,436 @@
+package processor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/gabs/v2"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeRedact] = TypeSpec{
+		constructor: NewRedact,
+		Categories: []Category{
+			CategoryMapping,
+		},
+		Summary: `
+Detects personally identifiable information such as email addresses, credit
+card numbers, phone numbers and IP addresses within messages and redacts it.`,
+		Beta: true,
+		Description: `
+When ` + "`fields`" + ` are specified the string values of those fields are
+redacted, otherwise the raw contents of messages are redacted.
+
+### Detectors
+
+The following detectors are built in:
+
+- ` + "`email`" + `: Email addresses.
+- ` + "`credit_card`" + `: Credit card numbers of 13 to 19 digits, optionally separated by spaces or hyphens, that pass the Luhn checksum.
+- ` + "`phone_number`" + `: Phone numbers of at least eight digits, optionally with a country code, parentheses and separators.
+- ` + "`ip_address`" + `: IPv4 and IPv6 addresses.
+
+Custom detectors can be added with regular expressions in the ` + "`patterns`" + `
+field. When the matches of detectors overlap the earliest and then longest
+match is redacted.
+
+### Actions
+
+- ` + "`mask`" + `: Replaces each character of a match with the ` + "`mask_char`" + `.
+- ` + "`hash`" + `: Replaces matches with the hex encoded HMAC-SHA256 of the match using the ` + "`key`" + `, truncated to 32 characters, so that the same values result in the same hashes.
+- ` + "`tokenize`" + `: Replaces matches with a token prefixed with ` + "`tok_`" + ` that is derived in the same way as hashes, and stores the original value in the ` + "`cache`" + ` with the token as the key, so that values can be recovered by those with access to the cache.
+
+When using the ` + "`hash`" + ` or ` + "`tokenize`" + ` actions a secret ` + "`key`" + ` should be
+set, as unsalted hashes of values such as phone numbers are easily reversed.
+
+## Metrics
+
+The number of redactions of each detector are counted with the metric
+` + "`redacted.<detector>`" + `.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("detectors", "A list of the built in detectors to use.").HasOptions("email", "credit_card", "phone_number", "ip_address"),
+			docs.FieldCommon(
+				"patterns", "A list of custom detectors that match regular expressions.",
+				[]interface{}{
+					map[string]interface{}{"name": "employee_id", "pattern": `EMP-\d{6}`},
+				},
+			).HasType(docs.FieldArray).WithChildren(
+				docs.FieldCommon("name", "The name of the detector, which is used for metrics.").HasDefault(""),
+				docs.FieldCommon("pattern", "The [regular expression](https://golang.org/s/re2syntax) to match.").HasDefault(""),
+			),
+			docs.FieldCommon("fields", "A list of [dot paths](/docs/configuration/field_paths) of string fields to redact. When empty the raw contents of messages are redacted.", []string{"message", "user.notes"}),
+			docs.FieldCommon("action", "The action to apply to detected values.").HasOptions("mask", "hash", "tokenize"),
+			docs.FieldAdvanced("mask_char", "The character to mask values with."),
+			docs.FieldAdvanced("key", "A secret key for deriving hashes and tokens."),
+			docs.FieldAdvanced("cache", "A [`cache` resource](/docs/components/caches/about) to store the original values of tokens within, required for the `tokenize` action."),
+			partsFieldSpec,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Tokenize Logs",
+				Summary: `
+Here email addresses and credit card numbers within log messages are replaced
+with tokens, and the original values are stored in Redis:`,
+				Config: `
+pipeline:
+  processors:
+    - redact:
+        detectors: [ email, credit_card ]
+        fields: [ message ]
+        action: tokenize
+        key: ${REDACT_KEY}
+        cache: pii_tokens
+
+resources:
+  caches:
+    pii_tokens:
+      redis:
+        url: tcp://localhost:6379
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// RedactPatternConfig contains configuration fields for a custom detector of
+// the Redact processor.
+type RedactPatternConfig struct {
+	Name    string `json:"name" yaml:"name"`
+	Pattern string `json:"pattern" yaml:"pattern"`
+}
+
+// RedactConfig contains configuration fields for the Redact processor.
+type RedactConfig struct {
+	Detectors []string              `json:"detectors" yaml:"detectors"`
+	Patterns  []RedactPatternConfig `json:"patterns" yaml:"patterns"`
+	Fields    []string              `json:"fields" yaml:"fields"`
+	Action    string                `json:"action" yaml:"action"`
+	MaskChar  string                `json:"mask_char" yaml:"mask_char"`
+	Key       string                `json:"key" yaml:"key"`
+	Cache     string                `json:"cache" yaml:"cache"`
+	Parts     []int                 `json:"parts" yaml:"parts"`
+}
+
+// NewRedactConfig returns a RedactConfig with default values.
+func NewRedactConfig() RedactConfig {
+	return RedactConfig{
+		Detectors: []string{"email", "credit_card", "phone_number", "ip_address"},
+		Patterns:  []RedactPatternConfig{},
+		Fields:    []string{},
+		Action:    "mask",
+		MaskChar:  "X",
+		Key:       "",
+		Cache:     "",
+		Parts:     []int{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type redactDetector struct {
+	name     string
+	re       *regexp.Regexp
+	validate func(match string) bool
+	mCount   metrics.StatCounter
+}
+
+func luhnValid(match string) bool {
+	var digits []int
+	for _, c := range match {
+		if c >= '0' && c <= '9' {
+			digits = append(digits, int(c-'0'))
+		}
+	}
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if (len(digits)-1-i)%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+func ipv6Valid(match string) bool {
+	ip := net.ParseIP(match)
+	return ip != nil && ip.To4() == nil && strings.ContainsAny(match, "0123456789abcdefABCDEF")
+}
+
+var redactBuiltinDetectors = map[string]func() *redactDetector{
+	"email": func() *redactDetector {
+		return &redactDetector{
+			re: regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9-]+(?:\.[a-zA-Z0-9-]+)*\.[a-zA-Z]{2,}`),
+		}
+	},
+	"credit_card": func() *redactDetector {
+		return &redactDetector{
+			re:       regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+			validate: luhnValid,
+		}
+	},
+	"phone_number": func() *redactDetector {
+		return &redactDetector{
+			re: regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[ .-]?\d{3,4}[ .-]?\d{3,4}\b`),
+		}
+	},
+	"ip_address": func() *redactDetector {
+		return &redactDetector{
+			re: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b|[0-9a-fA-F:]*:[0-9a-fA-F:]*:[0-9a-fA-F:.]*`),
+			validate: func(match string) bool {
+				if strings.Contains(match, ":") {
+					return ipv6Valid(match)
+				}
+				return true
+			},
+		}
+	},
+}
+
+//------------------------------------------------------------------------------
+
+// Redact is a processor that redacts personally identifiable information from
+// messages.
+type Redact struct {
+	parts     []int
+	fields    []string
+	paths     [][]string
+	detectors []*redactDetector
+	action    string
+	maskChar  string
+	key       []byte
+	cache     types.Cache
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewRedact returns a Redact processor.
+func NewRedact(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	r := &Redact{
+		parts:    conf.Redact.Parts,
+		fields:   conf.Redact.Fields,
+		action:   conf.Redact.Action,
+		maskChar: conf.Redact.MaskChar,
+		key:      []byte(conf.Redact.Key),
+
+		log: log,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}
+
+	for _, name := range conf.Redact.Detectors {
+		ctor, exists := redactBuiltinDetectors[name]
+		if !exists {
+			return nil, fmt.Errorf("detector not recognised: %v", name)
+		}
+		d := ctor()
+		d.name = name
+		r.detectors = append(r.detectors, d)
+	}
+	for i, p := range conf.Redact.Patterns {
+		if p.Name == "" {
+			return nil, fmt.Errorf("pattern %v requires a name", i)
+		}
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile pattern %v: %v", p.Name, err)
+		}
+		r.detectors = append(r.detectors, &redactDetector{name: p.Name, re: re})
+	}
+	if len(r.detectors) == 0 {
+		return nil, errors.New("at least one detector or pattern must be specified")
+	}
+	for _, d := range r.detectors {
+		d.mCount = stats.GetCounter("redacted." + d.name)
+	}
+
+	switch r.action {
+	case "mask":
+		if utf8.RuneCountInString(r.maskChar) != 1 {
+			return nil, fmt.Errorf("mask_char must be a single character, got %q", r.maskChar)
+		}
+	case "hash":
+	case "tokenize":
+		var err error
+		if r.cache, err = mgr.GetCache(conf.Redact.Cache); err != nil {
+			return nil, fmt.Errorf("failed to obtain cache for tokenize action: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("action not recognised: %v", r.action)
+	}
+
+	for _, f := range r.fields {
+		r.paths = append(r.paths, gabs.DotPathToSlice(f))
+	}
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+
+type redactMatch struct {
+	start, end int
+	detector   *redactDetector
+}
+
+func (r *Redact) replacement(value string) (string, error) {
+	switch r.action {
+	case "mask":
+		return strings.Repeat(r.maskChar, utf8.RuneCountInString(value)), nil
+	}
+
+	h := hmac.New(sha256.New, r.key)
+	h.Write([]byte(value))
+	hash := hex.EncodeToString(h.Sum(nil))[:32]
+	if r.action == "hash" {
+		return hash, nil
+	}
+
+	token := "tok_" + hash
+	if err := r.cache.Set(token, []byte(value)); err != nil {
+		return "", fmt.Errorf("failed to store token: %v", err)
+	}
+	return token, nil
+}
+
+// redact returns a string with all detected values replaced.
+func (r *Redact) redact(s string) (string, error) {
+	var matches []redactMatch
+	for _, d := range r.detectors {
+		for _, loc := range d.re.FindAllStringIndex(s, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			if d.validate != nil && !d.validate(s[loc[0]:loc[1]]) {
+				continue
+			}
+			matches = append(matches, redactMatch{start: loc[0], end: loc[1], detector: d})
+		}
+	}
+	if len(matches) == 0 {
+		return s, nil
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].start == matches[j].start {
+			return matches[i].end > matches[j].end
+		}
+		return matches[i].start < matches[j].start
+	})
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m.start < last {
+			continue
+		}
+		replacement, err := r.replacement(s[m.start:m.end])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s[last:m.start])
+		b.WriteString(replacement)
+		last = m.end
+		m.detector.mCount.Incr(1)
+	}
+	b.WriteString(s[last:])
+	return b.String(), nil
+}
+
+func (r *Redact) redactPart(part types.Part) error {
+	if len(r.paths) == 0 {
+		redacted, err := r.redact(string(part.Get()))
+		if err != nil {
+			return err
+		}
+		part.Set([]byte(redacted))
+		return nil
+	}
+
+	jsonPart, err := part.JSON()
+	if err == nil {
+		jsonPart, err = message.CopyJSON(jsonPart)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse part into json: %v", err)
+	}
+
+	gPart := gabs.Wrap(jsonPart)
+	for _, path := range r.paths {
+		str, ok := gPart.Search(path...).Data().(string)
+		if !ok {
+			continue
+		}
+		redacted, err := r.redact(str)
+		if err != nil {
+			return err
+		}
+		gPart.Set(redacted, path...)
+	}
+	return part.SetJSON(gPart.Data())
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (r *Redact) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	r.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	IteratePartsWithSpan(TypeRedact, r.parts, newMsg, func(index int, span opentracing.Span, part types.Part) error {
+		if err := r.redactPart(part); err != nil {
+			r.mErr.Incr(1)
+			r.log.Debugf("Failed to redact message: %v\n", err)
+			return err
+		}
+		return nil
+	})
+
+	r.mBatchSent.Incr(1)
+	r.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (r *Redact) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (r *Redact) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
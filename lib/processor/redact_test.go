@@ -0,0 +1,169 @@
+package processor
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactMask(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeRedact
+	conf.Redact.Patterns = []RedactPatternConfig{
+		{Name: "employee_id", Pattern: `EMP-\d{6}`},
+	}
+
+	stats := metrics.NewLocal()
+	proc, err := New(conf, nil, log.Noop(), stats)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`contact XXXXXXXXXXXXXXX, card XXXXXXXXXXXXXXXX`,
+		`card 4111111111111112 is not valid`,
+		`call XXXXXXXXXXXXXXXX, or XXXXXXXXXXXXX`,
+		`from XXXXXXXX and XXXXXXXXXXX`,
+		`at 12:30:45 on 2020-01-02 by XXXXXXXXXX`,
+		`nothing to see here`,
+	}, testFieldsOutput(t, proc, []string{
+		`contact foo@bar.example, card 4111111111111111`,
+		`card 4111111111111112 is not valid`,
+		`call +44 20 7946 0958, or (555)123-4567`,
+		`from 10.0.0.1 and 2001:db8::1`,
+		`at 12:30:45 on 2020-01-02 by EMP-123456`,
+		`nothing to see here`,
+	}))
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(1), counters["redacted.email"])
+	assert.Equal(t, int64(1), counters["redacted.credit_card"])
+	assert.Equal(t, int64(2), counters["redacted.phone_number"])
+	assert.Equal(t, int64(2), counters["redacted.ip_address"])
+	assert.Equal(t, int64(1), counters["redacted.employee_id"])
+}
+
+func TestRedactHashFields(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeRedact
+	conf.Redact.Detectors = []string{"email"}
+	conf.Redact.Fields = []string{"message", "user.id", "missing"}
+	conf.Redact.Action = "hash"
+	conf.Redact.Key = "foo"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	output := testFieldsOutput(t, proc, []string{
+		`{"message":"from foo@example.com to foo@example.com","user":{"id":5},"other":"bar@example.com"}`,
+		`not json`,
+	})
+	require.Len(t, output, 2)
+	assert.Equal(t, "failed: failed to parse part into json: invalid character 'o' in literal null (expecting 'u')", output[1])
+
+	matches := regexp.MustCompile(`^\{"message":"from ([0-9a-f]{32}) to ([0-9a-f]{32})","other":"bar@example.com","user":\{"id":5\}\}$`).FindStringSubmatch(output[0])
+	require.Len(t, matches, 3, output[0])
+	assert.Equal(t, matches[1], matches[2])
+}
+
+func TestRedactTokenize(t *testing.T) {
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{},
+	}
+	c, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	mgr.caches["foocache"] = c
+
+	conf := NewConfig()
+	conf.Type = TypeRedact
+	conf.Redact.Detectors = []string{"credit_card"}
+	conf.Redact.Action = "tokenize"
+	conf.Redact.Cache = "foocache"
+
+	proc, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	output := testFieldsOutput(t, proc, []string{`card: 4111-1111-1111-1111`})
+	require.Len(t, output, 1)
+	require.Regexp(t, `^card: tok_[0-9a-f]{32}$`, output[0])
+
+	value, err := c.Get(output[0][len("card: "):])
+	require.NoError(t, err)
+	assert.Equal(t, "4111-1111-1111-1111", string(value))
+}
+
+func TestRedactBadConfig(t *testing.T) {
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{},
+	}
+
+	tests := map[string]struct {
+		conf func(c *RedactConfig)
+		err  string
+	}{
+		"bad detector": {
+			conf: func(c *RedactConfig) {
+				c.Detectors = []string{"nope"}
+			},
+			err: "detector not recognised: nope",
+		},
+		"no detectors": {
+			conf: func(c *RedactConfig) {
+				c.Detectors = nil
+			},
+			err: "at least one detector or pattern must be specified",
+		},
+		"bad pattern": {
+			conf: func(c *RedactConfig) {
+				c.Patterns = []RedactPatternConfig{{Name: "foo", Pattern: "("}}
+			},
+			err: "failed to compile pattern foo: error parsing regexp: missing closing ): `(`",
+		},
+		"unnamed pattern": {
+			conf: func(c *RedactConfig) {
+				c.Patterns = []RedactPatternConfig{{Pattern: "foo"}}
+			},
+			err: "pattern 0 requires a name",
+		},
+		"bad action": {
+			conf: func(c *RedactConfig) {
+				c.Action = "nope"
+			},
+			err: "action not recognised: nope",
+		},
+		"bad mask char": {
+			conf: func(c *RedactConfig) {
+				c.MaskChar = "**"
+			},
+			err: `mask_char must be a single character, got "**"`,
+		},
+		"missing cache": {
+			conf: func(c *RedactConfig) {
+				c.Action = "tokenize"
+				c.Cache = "nope"
+			},
+			err: "failed to obtain cache for tokenize action: cache not found",
+		},
+	}
+
+	for name, test := range tests {
+		conf := NewConfig()
+		conf.Type = TypeRedact
+		test.conf(&conf.Redact)
+
+		_, err := New(conf, mgr, log.Noop(), metrics.Noop())
+		assert.EqualError(t, err, test.err, name)
+	}
+}
+
+func TestLuhnValid(t *testing.T) {
+	assert.True(t, luhnValid("4111111111111111"))
+	assert.True(t, luhnValid("5500 0000 0000 0004"))
+	assert.True(t, luhnValid("3400-0000-0000-009"))
+	assert.False(t, luhnValid("4111111111111112"))
+	assert.False(t, luhnValid("0000"))
+}
@@ -0,0 +1,308 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
+	"github.com/cenkalti/backoff/v4"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeRetry] = TypeSpec{
+		constructor: NewRetry,
+		Categories: []Category{
+			CategoryComposition,
+			CategoryUtility,
+		},
+		Summary: `
+Applies a list of child processors to each message of a batch and, when the
+resulting messages are flagged as failed, reapplies them to the original message
+with an exponential backoff until they succeed or the retries are exhausted.`,
+		Beta: true,
+		Description: `
+Each message of a batch is processed individually as a batch of one (similar to
+the ` + "[`for_each`](/docs/components/processors/for_each)" + ` processor),
+and therefore a failing message does not cause its siblings to be reprocessed.
+A message is retried when any of the messages resulting from the child
+processors are flagged as failed, or when the child processors return an error.
+Failure flags already present on messages before they reach this processor are
+removed.
+
+The period between retries grows exponentially from ` + "`backoff.initial_interval`" + `
+up to ` + "`backoff.max_interval`" + `, and each period is randomised by up
+to 50% in either direction in order to avoid retries from multiple messages
+occurring in lockstep.
+
+When the retries are exhausted the results of the final attempt are kept along
+with their failure flags, which can be handled with
+[error handling patterns](/docs/configuration/error_handling). Alternatively,
+the field ` + "`squash_errors`" + ` can be set in order to clear the flags.
+
+Since the child processors are reapplied to the original message they should
+avoid side effects that are unsafe to repeat.
+
+## Metrics
+
+The number of retry attempts is counted with the metric ` + "`retry`" + `, and
+the number of messages that fail after the retries are exhausted is counted with
+the metric ` + "`error`" + `.`,
+		sanitiseConfigFunc: func(conf Config) (interface{}, error) {
+			confBytes, err := json.Marshal(conf.Retry)
+			if err != nil {
+				return nil, err
+			}
+
+			confMap := map[string]interface{}{}
+			if err = json.Unmarshal(confBytes, &confMap); err != nil {
+				return nil, err
+			}
+
+			procConfs := make([]interface{}, len(conf.Retry.Processors))
+			for i, pConf := range conf.Retry.Processors {
+				if procConfs[i], err = SanitiseConfig(pConf); err != nil {
+					return nil, err
+				}
+			}
+			confMap["processors"] = procConfs
+			return confMap, nil
+		},
+		FieldSpecs: append(docs.FieldSpecs{
+			docs.FieldCommon("processors", "A list of child processors to apply."),
+			docs.FieldCommon("squash_errors", "Whether to clear the failure flags of messages that still fail once the retries are exhausted."),
+		}, retries.FieldSpecs()...),
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Flaky Enrichment",
+				Summary: `
+Here an HTTP enrichment is attempted up to four times per message, with the
+results of successful attempts placed at the path ` + "`user`" + `:`,
+				Config: `
+pipeline:
+  processors:
+    - retry:
+        max_retries: 3
+        backoff:
+          initial_interval: 100ms
+          max_interval: 1s
+        processors:
+          - branch:
+              request_map: 'root.id = this.user_id'
+              processors:
+                - http:
+                    url: http://users:8080/lookup
+                    verb: POST
+              result_map: 'root.user = this'
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// RetryConfig contains configuration fields for the Retry processor.
+type RetryConfig struct {
+	Processors     []Config `json:"processors" yaml:"processors"`
+	SquashErrors   bool     `json:"squash_errors" yaml:"squash_errors"`
+	retries.Config `json:",inline" yaml:",inline"`
+}
+
+// NewRetryConfig returns a RetryConfig with default values.
+func NewRetryConfig() RetryConfig {
+	rConf := retries.NewConfig()
+	rConf.MaxRetries = 3
+	return RetryConfig{
+		Processors:   []Config{},
+		SquashErrors: false,
+		Config:       rConf,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Retry is a processor that reapplies child processors to messages until they
+// succeed.
+type Retry struct {
+	children    []types.Processor
+	squash      bool
+	backoffCtor func() backoff.BackOff
+
+	log log.Modular
+
+	closeChan chan struct{}
+	closeOnce sync.Once
+
+	mCount     metrics.StatCounter
+	mRetry     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+}
+
+// NewRetry returns a Retry processor.
+func NewRetry(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if len(conf.Retry.Processors) == 0 {
+		return nil, errors.New("at least one child processor must be specified")
+	}
+
+	var children []types.Processor
+	for i, pconf := range conf.Retry.Processors {
+		prefix := fmt.Sprintf("%v", i)
+		proc, err := New(pconf, mgr, log.NewModule("."+prefix), metrics.Namespaced(stats, prefix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to init processor %v: %w", i, err)
+		}
+		children = append(children, proc)
+	}
+
+	backoffCtor, err := conf.Retry.GetCtor()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Retry{
+		children:    children,
+		squash:      conf.Retry.SquashErrors,
+		backoffCtor: backoffCtor,
+		log:         log,
+
+		closeChan: make(chan struct{}),
+
+		mCount:     stats.GetCounter("count"),
+		mRetry:     stats.GetCounter("retry"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// attempt applies the child processors to a copy of a message part, and
+// returns the resulting parts along with an error when any of them failed.
+func (r *Retry) attempt(part types.Part) ([]types.Part, types.Response, error) {
+	tmpPart := part.Copy()
+	// Remove errors so that prior failures aren't mistaken for failed attempts.
+	ClearFail(tmpPart)
+
+	tmpMsg := message.New(nil)
+	tmpMsg.SetAll([]types.Part{tmpPart})
+
+	resMsgs, res := ExecuteAll(r.children, tmpMsg)
+	if res != nil {
+		if err := res.Error(); err != nil {
+			failed := part.Copy()
+			FlagErr(failed, err)
+			return []types.Part{failed}, nil, err
+		}
+	}
+
+	var resultParts []types.Part
+	var err error
+	for _, m := range resMsgs {
+		m.Iter(func(i int, p types.Part) error {
+			if err == nil && HasFailed(p) {
+				err = errors.New(GetFail(p))
+			}
+			resultParts = append(resultParts, p)
+			return nil
+		})
+	}
+	return resultParts, res, err
+}
+
+// processPart attempts to process a message part until it succeeds or the
+// retries are exhausted.
+func (r *Retry) processPart(part types.Part) ([]types.Part, types.Response) {
+	boff := r.backoffCtor()
+	boff.Reset()
+	for {
+		resultParts, res, err := r.attempt(part)
+		if err == nil {
+			return resultParts, res
+		}
+
+		nextSleep := boff.NextBackOff()
+		if nextSleep == backoff.Stop {
+			r.mErr.Incr(1)
+			r.log.Errorf("Failed to process message after retries: %v\n", err)
+			if r.squash {
+				for _, p := range resultParts {
+					ClearFail(p)
+				}
+			}
+			return resultParts, res
+		}
+
+		r.mRetry.Incr(1)
+		r.log.Debugf("Retrying message after error: %v\n", err)
+		select {
+		case <-time.After(nextSleep):
+		case <-r.closeChan:
+			return resultParts, res
+		}
+	}
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (r *Retry) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	r.mCount.Incr(1)
+
+	resMsg := message.New(nil)
+	var lastRes types.Response
+	msg.Iter(func(i int, p types.Part) error {
+		var resultParts []types.Part
+		resultParts, lastRes = r.processPart(p)
+		resMsg.Append(resultParts...)
+		return nil
+	})
+
+	if resMsg.Len() == 0 {
+		if lastRes == nil {
+			lastRes = response.NewAck()
+		}
+		return nil, lastRes
+	}
+
+	r.mBatchSent.Incr(1)
+	r.mSent.Incr(int64(resMsg.Len()))
+	return []types.Message{resMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (r *Retry) CloseAsync() {
+	r.closeOnce.Do(func() {
+		close(r.closeChan)
+	})
+	for _, c := range r.children {
+		c.CloseAsync()
+	}
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (r *Retry) WaitForClose(timeout time.Duration) error {
+	stopBy := time.Now().Add(timeout)
+	for _, c := range r.children {
+		if err := c.WaitForClose(time.Until(stopBy)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
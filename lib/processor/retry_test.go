@@ -0,0 +1,162 @@
+package processor
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// retryFlaky fails each message until it has been attempted a number of times
+// given by the map of contents to failures, where messages of unknown contents
+// always fail.
+type retryFlaky struct {
+	failures map[string]int
+	attempts map[string]int
+	mut      sync.Mutex
+}
+
+func (r *retryFlaky) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	content := string(msg.Get(0).Get())
+	r.attempts[content]++
+	failures, exists := r.failures[content]
+	if content == "error" {
+		return nil, response.NewError(errors.New("test error"))
+	}
+	if !exists || r.attempts[content] <= failures {
+		FlagErr(msg.Get(0), errors.New("test failure"))
+		return []types.Message{msg}, nil
+	}
+	msg.Get(0).Set(append([]byte("processed: "), msg.Get(0).Get()...))
+	return []types.Message{msg}, nil
+}
+
+func (r *retryFlaky) CloseAsync() {
+}
+
+func (r *retryFlaky) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+func newRetryTestProc(t *testing.T, conf Config, stats metrics.Type) (Type, *retryFlaky) {
+	t.Helper()
+
+	conf.Type = TypeRetry
+	conf.Retry.Backoff.InitialInterval = "1ms"
+	conf.Retry.Backoff.MaxInterval = "1ms"
+	conf.Retry.Processors = []Config{NewConfig()}
+
+	proc, err := New(conf, nil, log.Noop(), stats)
+	require.NoError(t, err)
+
+	flaky := &retryFlaky{
+		failures: map[string]int{"foo": 0, "bar": 2},
+		attempts: map[string]int{},
+	}
+	proc.(*Retry).children = []types.Processor{flaky}
+	return proc, flaky
+}
+
+func TestRetryBasic(t *testing.T) {
+	stats := metrics.NewLocal()
+	proc, flaky := newRetryTestProc(t, NewConfig(), stats)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"), []byte("error"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{
+		[]byte("processed: foo"), []byte("processed: bar"), []byte("baz"), []byte("error"),
+	}, message.GetAllBytes(msgs[0]))
+	assert.False(t, HasFailed(msgs[0].Get(0)))
+	assert.False(t, HasFailed(msgs[0].Get(1)))
+	assert.Equal(t, "test failure", GetFail(msgs[0].Get(2)))
+	assert.Equal(t, "test error", GetFail(msgs[0].Get(3)))
+
+	assert.Equal(t, map[string]int{"foo": 1, "bar": 3, "baz": 4, "error": 4}, flaky.attempts)
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(8), counters["retry"])
+	assert.Equal(t, int64(2), counters["error"])
+	assert.Equal(t, int64(4), counters["sent"])
+}
+
+func TestRetrySquashErrors(t *testing.T) {
+	conf := NewConfig()
+	conf.Retry.MaxRetries = 1
+	conf.Retry.SquashErrors = true
+	proc, flaky := newRetryTestProc(t, conf, metrics.Noop())
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte("bar"), []byte("baz"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{[]byte("bar"), []byte("baz")}, message.GetAllBytes(msgs[0]))
+	assert.False(t, HasFailed(msgs[0].Get(0)))
+	assert.False(t, HasFailed(msgs[0].Get(1)))
+	assert.Equal(t, map[string]int{"bar": 2, "baz": 2}, flaky.attempts)
+}
+
+func TestRetryClose(t *testing.T) {
+	conf := NewConfig()
+	conf.Retry.MaxRetries = 0
+	proc, _ := newRetryTestProc(t, conf, metrics.Noop())
+	proc.(*Retry).backoffCtor = func() backoff.BackOff {
+		return backoff.NewConstantBackOff(time.Hour)
+	}
+
+	go func() {
+		<-time.After(time.Millisecond * 10)
+		proc.CloseAsync()
+	}()
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte("baz")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+	assert.NoError(t, proc.WaitForClose(time.Second))
+}
+
+func TestRetryBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeRetry
+	_, err := New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "at least one child processor must be specified")
+
+	conf.Retry.Processors = []Config{NewConfig()}
+	conf.Retry.Backoff.InitialInterval = "nope"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, `invalid backoff initial interval: time: invalid duration "nope"`)
+}
+
+func TestRetryPriorFailure(t *testing.T) {
+	proc, flaky := newRetryTestProc(t, NewConfig(), metrics.Noop())
+
+	part := message.NewPart([]byte("foo"))
+	FlagErr(part, errors.New("prior failure"))
+	msg := message.New(nil)
+	msg.Append(part)
+
+	msgs, res := proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "processed: foo", string(msgs[0].Get(0).Get()))
+	assert.False(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, map[string]int{"foo": 1}, flaky.attempts)
+}
@@ -0,0 +1,321 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/linkedin/goavro/v2"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+//------------------------------------------------------------------------------
+
+// Schema types as returned by a Confluent compatible schema registry, an empty
+// type implies Avro.
+const (
+	schemaRegistryTypeAvro     = "AVRO"
+	schemaRegistryTypeProtobuf = "PROTOBUF"
+	schemaRegistryTypeJSON     = "JSON"
+)
+
+const schemaRegistryTimeout = time.Second * 5
+
+// schemaRegistrySchema is a schema as returned by the schema registry API.
+type schemaRegistrySchema struct {
+	ID         int    `json:"id"`
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType"`
+}
+
+// schemaRegistryClient is a minimal client for the parts of the Confluent
+// Schema Registry API required for encoding and decoding messages.
+type schemaRegistryClient struct {
+	url    *url.URL
+	client *http.Client
+}
+
+func newSchemaRegistryClient(urlStr string, tlsConf btls.Config) (*schemaRegistryClient, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("url '%v' must use either the http or https scheme", urlStr)
+	}
+
+	client := &http.Client{Timeout: schemaRegistryTimeout}
+	if tlsConf.Enabled {
+		tlsConfig, err := tlsConf.Get()
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+	return &schemaRegistryClient{url: u, client: client}, nil
+}
+
+func (c *schemaRegistryClient) get(reqPath string, v interface{}) error {
+	reqURL := *c.url
+	reqURL.Path = path.Join(reqURL.Path, reqPath)
+
+	res, err := c.client.Get(reqURL.String())
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %v returned status code %v: %s", reqURL.Path, res.StatusCode, bytes.TrimSpace(body))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// getSchemaByID returns the schema registered under a given ID.
+func (c *schemaRegistryClient) getSchemaByID(id int) (schemaRegistrySchema, error) {
+	var s schemaRegistrySchema
+	if err := c.get("/schemas/ids/"+strconv.Itoa(id), &s); err != nil {
+		return s, fmt.Errorf("failed to fetch schema %v: %v", id, err)
+	}
+	s.ID = id
+	return s, nil
+}
+
+// getLatestSchema returns the latest version of the schema of a subject.
+func (c *schemaRegistryClient) getLatestSchema(subject string) (schemaRegistrySchema, error) {
+	var s schemaRegistrySchema
+	if err := c.get("/subjects/"+subject+"/versions/latest", &s); err != nil {
+		return s, fmt.Errorf("failed to fetch latest schema of subject '%v': %v", subject, err)
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Messages encoded for a schema registry are framed with a magic byte followed
+// by a four byte big endian schema ID.
+const (
+	schemaRegistryMagicByte  = byte(0)
+	schemaRegistryHeaderSize = 5
+)
+
+func schemaRegistryHeader(id int) []byte {
+	header := make([]byte, schemaRegistryHeaderSize)
+	header[0] = schemaRegistryMagicByte
+	binary.BigEndian.PutUint32(header[1:], uint32(id))
+	return header
+}
+
+func parseSchemaRegistryHeader(b []byte) (int, []byte, error) {
+	if len(b) < schemaRegistryHeaderSize {
+		return 0, nil, errors.New("message is too short to contain a schema registry header")
+	}
+	if b[0] != schemaRegistryMagicByte {
+		return 0, nil, fmt.Errorf("unexpected magic byte: %v", b[0])
+	}
+	return int(binary.BigEndian.Uint32(b[1:schemaRegistryHeaderSize])), b[schemaRegistryHeaderSize:], nil
+}
+
+//------------------------------------------------------------------------------
+
+// schemaRegistryCodec converts between JSON documents and the payload of a
+// message (excluding the header) for a particular schema.
+type schemaRegistryCodec struct {
+	encode func(doc []byte) ([]byte, error)
+	decode func(payload []byte) ([]byte, error)
+}
+
+func newSchemaRegistryCodec(s schemaRegistrySchema) (*schemaRegistryCodec, error) {
+	switch strings.ToUpper(s.SchemaType) {
+	case "", schemaRegistryTypeAvro:
+		return newSchemaRegistryAvroCodec(s.Schema)
+	case schemaRegistryTypeProtobuf:
+		return newSchemaRegistryProtobufCodec(s.Schema)
+	case schemaRegistryTypeJSON:
+		return newSchemaRegistryJSONCodec(s.Schema)
+	}
+	return nil, fmt.Errorf("schema type '%v' not supported", s.SchemaType)
+}
+
+func newSchemaRegistryAvroCodec(schema string) (*schemaRegistryCodec, error) {
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Avro schema: %v", err)
+	}
+	return &schemaRegistryCodec{
+		encode: func(doc []byte) ([]byte, error) {
+			native, _, err := codec.NativeFromTextual(doc)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
+			}
+			return codec.BinaryFromNative(nil, native)
+		},
+		decode: func(payload []byte) ([]byte, error) {
+			native, _, err := codec.NativeFromBinary(payload)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert Avro document to JSON: %v", err)
+			}
+			return codec.TextualFromNative(nil, native)
+		},
+	}, nil
+}
+
+// Protobuf payloads are prefixed with a list of indexes that describe the path
+// to the message type within the schema. The list is encoded as a zig-zag
+// varint of its length followed by each index, where a list containing only the
+// first message is encoded as a single zero byte.
+
+func encodeProtobufIndexes(indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return []byte{0}
+	}
+	buf := make([]byte, binary.MaxVarintLen64*(len(indexes)+1))
+	n := binary.PutVarint(buf, int64(len(indexes)))
+	for _, i := range indexes {
+		n += binary.PutVarint(buf[n:], int64(i))
+	}
+	return buf[:n]
+}
+
+func decodeProtobufIndexes(b []byte) ([]int, []byte, error) {
+	count, n := binary.Varint(b)
+	if n <= 0 {
+		return nil, nil, errors.New("failed to read message indexes")
+	}
+	b = b[n:]
+	if count == 0 {
+		return []int{0}, b, nil
+	}
+	if count < 0 || count > int64(len(b)) {
+		return nil, nil, fmt.Errorf("invalid count of message indexes: %v", count)
+	}
+	indexes := make([]int, count)
+	for i := range indexes {
+		index, n := binary.Varint(b)
+		if n <= 0 || index < 0 {
+			return nil, nil, errors.New("failed to read message indexes")
+		}
+		indexes[i] = int(index)
+		b = b[n:]
+	}
+	return indexes, b, nil
+}
+
+func protobufMessageByIndexes(fd *desc.FileDescriptor, indexes []int) (*desc.MessageDescriptor, error) {
+	msgs := fd.GetMessageTypes()
+	var msg *desc.MessageDescriptor
+	for _, i := range indexes {
+		if i >= len(msgs) {
+			return nil, fmt.Errorf("message index %v not found within schema", indexes)
+		}
+		msg = msgs[i]
+		msgs = msg.GetNestedMessageTypes()
+	}
+	if msg == nil {
+		return nil, errors.New("schema does not contain any messages")
+	}
+	return msg, nil
+}
+
+func newSchemaRegistryProtobufCodec(schema string) (*schemaRegistryCodec, error) {
+	const schemaFile = "schema.proto"
+	parser := protoparse.Parser{
+		Accessor: func(filename string) (io.ReadCloser, error) {
+			if filename != schemaFile {
+				return nil, fmt.Errorf("schema references such as '%v' are not supported", filename)
+			}
+			return ioutil.NopCloser(strings.NewReader(schema)), nil
+		},
+	}
+	fds, err := parser.ParseFiles(schemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse protobuf schema: %v", err)
+	}
+	fd := fds[0]
+
+	// Messages are always encoded as the first message type of the schema.
+	encodeMsg, err := protobufMessageByIndexes(fd, []int{0})
+	if err != nil {
+		return nil, err
+	}
+	encodeIndexes := encodeProtobufIndexes([]int{0})
+
+	return &schemaRegistryCodec{
+		encode: func(doc []byte) ([]byte, error) {
+			msg := dynamic.NewMessage(encodeMsg)
+			if err := msg.UnmarshalJSON(doc); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal JSON message: %w", err)
+			}
+			data, err := msg.Marshal()
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal protobuf message: %w", err)
+			}
+			return append(append([]byte{}, encodeIndexes...), data...), nil
+		},
+		decode: func(payload []byte) ([]byte, error) {
+			indexes, payload, err := decodeProtobufIndexes(payload)
+			if err != nil {
+				return nil, err
+			}
+			msgDesc, err := protobufMessageByIndexes(fd, indexes)
+			if err != nil {
+				return nil, err
+			}
+			msg := dynamic.NewMessage(msgDesc)
+			if err := proto.Unmarshal(payload, msg); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+			}
+			return msg.MarshalJSON()
+		},
+	}, nil
+}
+
+func newSchemaRegistryJSONCodec(schema string) (*schemaRegistryCodec, error) {
+	s, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema: %v", err)
+	}
+	return &schemaRegistryCodec{
+		encode: func(doc []byte) ([]byte, error) {
+			res, err := s.Validate(gojsonschema.NewBytesLoader(doc))
+			if err != nil {
+				return nil, fmt.Errorf("failed to validate JSON document: %v", err)
+			}
+			if !res.Valid() {
+				var errStrs []string
+				for _, desc := range res.Errors() {
+					errStrs = append(errStrs, desc.String())
+				}
+				return nil, fmt.Errorf("JSON document failed schema validation: %v", strings.Join(errStrs, ", "))
+			}
+			return doc, nil
+		},
+		decode: func(payload []byte) ([]byte, error) {
+			if !json.Valid(payload) {
+				return nil, errors.New("payload is not a valid JSON document")
+			}
+			return payload, nil
+		},
+	}, nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,190 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSchemaRegistryDecode] = TypeSpec{
+		constructor: NewSchemaRegistryDecode,
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Decodes messages automatically from a schema stored within a
+[Confluent Schema Registry service](https://docs.confluent.io/current/schema-registry/index.html).`,
+		Beta: true,
+		Description: `
+Messages are expected to be framed in the Confluent wire format, which consists
+of a magic byte and a four byte schema ID followed by the encoded payload. The
+schema ID is used in order to fetch the schema from the registry, and schemas
+are cached indefinitely as they are immutable once registered.
+
+Avro, Protobuf and JSON Schema payloads are supported, and are all decoded into
+JSON documents. Avro documents are converted into the
+[Avro JSON encoding](https://avro.apache.org/docs/current/spec.html#json_encoding),
+and Protobuf messages are converted using the
+[standard JSON mapping](https://developers.google.com/protocol-buffers/docs/proto3#json).
+Schemas that contain references to other schemas are not currently supported.
+
+The ID of the schema used to decode a message is added to it as the metadata
+field ` + "`schema_id`" + `.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "The base URL of the schema registry service."),
+			btls.FieldSpec(),
+			partsFieldSpec,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SchemaRegistryDecodeConfig contains configuration fields for the
+// SchemaRegistryDecode processor.
+type SchemaRegistryDecodeConfig struct {
+	Parts []int       `json:"parts" yaml:"parts"`
+	URL   string      `json:"url" yaml:"url"`
+	TLS   btls.Config `json:"tls" yaml:"tls"`
+}
+
+// NewSchemaRegistryDecodeConfig returns a SchemaRegistryDecodeConfig with
+// default values.
+func NewSchemaRegistryDecodeConfig() SchemaRegistryDecodeConfig {
+	return SchemaRegistryDecodeConfig{
+		Parts: []int{},
+		URL:   "http://localhost:8081",
+		TLS:   btls.NewConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SchemaRegistryDecode is a processor that decodes messages using schemas
+// fetched from a schema registry.
+type SchemaRegistryDecode struct {
+	parts  []int
+	client *schemaRegistryClient
+
+	codecsMut sync.RWMutex
+	codecs    map[int]*schemaRegistryCodec
+
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	mCount       metrics.StatCounter
+	mErr         metrics.StatCounter
+	mSchemaFetch metrics.StatCounter
+	mSent        metrics.StatCounter
+	mBatchSent   metrics.StatCounter
+}
+
+// NewSchemaRegistryDecode returns a SchemaRegistryDecode processor.
+func NewSchemaRegistryDecode(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	client, err := newSchemaRegistryClient(conf.SchemaRegistryDecode.URL, conf.SchemaRegistryDecode.TLS)
+	if err != nil {
+		return nil, err
+	}
+	return &SchemaRegistryDecode{
+		parts:  conf.SchemaRegistryDecode.Parts,
+		client: client,
+		codecs: map[int]*schemaRegistryCodec{},
+		conf:   conf,
+		log:    log,
+		stats:  stats,
+
+		mCount:       stats.GetCounter("count"),
+		mErr:         stats.GetCounter("error"),
+		mSchemaFetch: stats.GetCounter("schema.fetch"),
+		mSent:        stats.GetCounter("sent"),
+		mBatchSent:   stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (p *SchemaRegistryDecode) getCodec(id int) (*schemaRegistryCodec, error) {
+	p.codecsMut.RLock()
+	codec, exists := p.codecs[id]
+	p.codecsMut.RUnlock()
+	if exists {
+		return codec, nil
+	}
+
+	p.mSchemaFetch.Incr(1)
+	schema, err := p.client.getSchemaByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if codec, err = newSchemaRegistryCodec(schema); err != nil {
+		return nil, fmt.Errorf("failed to create codec for schema %v: %w", id, err)
+	}
+
+	p.codecsMut.Lock()
+	p.codecs[id] = codec
+	p.codecsMut.Unlock()
+	return codec, nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (p *SchemaRegistryDecode) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	p.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(index int, span opentracing.Span, part types.Part) error {
+		err := func() error {
+			id, payload, err := parseSchemaRegistryHeader(part.Get())
+			if err != nil {
+				return err
+			}
+			codec, err := p.getCodec(id)
+			if err != nil {
+				return err
+			}
+			doc, err := codec.decode(payload)
+			if err != nil {
+				return err
+			}
+			part.Set(doc)
+			part.Metadata().Set("schema_id", strconv.Itoa(id))
+			return nil
+		}()
+		if err != nil {
+			p.mErr.Incr(1)
+			p.log.Debugf("Failed to decode message: %v\n", err)
+		}
+		return err
+	}
+
+	IteratePartsWithSpan(TypeSchemaRegistryDecode, p.parts, newMsg, proc)
+
+	p.mBatchSent.Incr(1)
+	p.mSent.Incr(int64(newMsg.Len()))
+	return []types.Message{newMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (p *SchemaRegistryDecode) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (p *SchemaRegistryDecode) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
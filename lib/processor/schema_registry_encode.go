@@ -0,0 +1,248 @@
+package processor
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"github.com/opentracing/opentracing-go"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeSchemaRegistryEncode] = TypeSpec{
+		constructor: NewSchemaRegistryEncode,
+		Categories: []Category{
+			CategoryParsing,
+		},
+		Summary: `
+Encodes messages automatically from schemas obtained from a
+[Confluent Schema Registry service](https://docs.confluent.io/current/schema-registry/index.html).`,
+		Beta: true,
+		Description: `
+Messages are expected to be JSON documents, which are encoded using the latest
+schema version of a subject and framed in the Confluent wire format, which
+consists of a magic byte and a four byte schema ID followed by the encoded
+payload. Schemas are cached and the latest version of a subject is refreshed
+periodically according to the field ` + "`refresh_period`" + `.
+
+Avro, Protobuf and JSON Schema subjects are supported. Avro documents must
+follow the
+[Avro JSON encoding](https://avro.apache.org/docs/current/spec.html#json_encoding),
+Protobuf messages follow the
+[standard JSON mapping](https://developers.google.com/protocol-buffers/docs/proto3#json)
+and are always encoded as the first message type defined within the schema, and
+JSON documents are validated against their schema before being framed. Schemas
+that contain references to other schemas are not currently supported.
+
+The ID of the schema used to encode a message is added to it as the metadata
+field ` + "`schema_id`" + `.
+
+### Subject Name Strategies
+
+The ` + "`subject`" + ` field supports
+[interpolation functions](/docs/configuration/interpolation#bloblang-queries),
+which can be used in order to implement the common subject name strategies. For
+example, the topic name strategy for a message consumed from Kafka can be
+expressed as ` + "`${! meta(\"kafka_topic\") }-value`" + `, and the topic
+record name strategy as ` + "`${! meta(\"kafka_topic\") }-com.example.Record`" + `.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("url", "The base URL of the schema registry service."),
+			docs.FieldCommon("subject", "The schema subject to derive schemas from.", "foo", `${! meta("kafka_topic") }-value`).SupportsInterpolation(false),
+			docs.FieldAdvanced("refresh_period", "The period after which the latest schema of a subject is refreshed in order to pick up new versions.", "60s", "1h"),
+			btls.FieldSpec(),
+			partsFieldSpec,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SchemaRegistryEncodeConfig contains configuration fields for the
+// SchemaRegistryEncode processor.
+type SchemaRegistryEncodeConfig struct {
+	Parts         []int       `json:"parts" yaml:"parts"`
+	URL           string      `json:"url" yaml:"url"`
+	Subject       string      `json:"subject" yaml:"subject"`
+	RefreshPeriod string      `json:"refresh_period" yaml:"refresh_period"`
+	TLS           btls.Config `json:"tls" yaml:"tls"`
+}
+
+// NewSchemaRegistryEncodeConfig returns a SchemaRegistryEncodeConfig with
+// default values.
+func NewSchemaRegistryEncodeConfig() SchemaRegistryEncodeConfig {
+	return SchemaRegistryEncodeConfig{
+		Parts:         []int{},
+		URL:           "http://localhost:8081",
+		Subject:       "",
+		RefreshPeriod: "10m",
+		TLS:           btls.NewConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type schemaRegistryEncodeCodec struct {
+	id        int
+	header    []byte
+	codec     *schemaRegistryCodec
+	fetchedAt time.Time
+}
+
+// SchemaRegistryEncode is a processor that encodes messages using schemas
+// fetched from a schema registry.
+type SchemaRegistryEncode struct {
+	parts         []int
+	client        *schemaRegistryClient
+	subject       field.Expression
+	refreshPeriod time.Duration
+
+	codecsMut sync.Mutex
+	codecs    map[string]*schemaRegistryEncodeCodec
+
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	mCount       metrics.StatCounter
+	mErr         metrics.StatCounter
+	mSchemaFetch metrics.StatCounter
+	mSent        metrics.StatCounter
+	mBatchSent   metrics.StatCounter
+}
+
+// NewSchemaRegistryEncode returns a SchemaRegistryEncode processor.
+func NewSchemaRegistryEncode(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	client, err := newSchemaRegistryClient(conf.SchemaRegistryEncode.URL, conf.SchemaRegistryEncode.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.SchemaRegistryEncode.Subject == "" {
+		return nil, fmt.Errorf("a subject must be specified")
+	}
+	subject, err := bloblang.NewField(conf.SchemaRegistryEncode.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse subject expression: %v", err)
+	}
+
+	var refreshPeriod time.Duration
+	if refreshPeriod, err = time.ParseDuration(conf.SchemaRegistryEncode.RefreshPeriod); err != nil {
+		return nil, fmt.Errorf("failed to parse refresh period: %v", err)
+	}
+
+	return &SchemaRegistryEncode{
+		parts:         conf.SchemaRegistryEncode.Parts,
+		client:        client,
+		subject:       subject,
+		refreshPeriod: refreshPeriod,
+		codecs:        map[string]*schemaRegistryEncodeCodec{},
+		conf:          conf,
+		log:           log,
+		stats:         stats,
+
+		mCount:       stats.GetCounter("count"),
+		mErr:         stats.GetCounter("error"),
+		mSchemaFetch: stats.GetCounter("schema.fetch"),
+		mSent:        stats.GetCounter("sent"),
+		mBatchSent:   stats.GetCounter("batch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (p *SchemaRegistryEncode) getCodec(subject string) (*schemaRegistryEncodeCodec, error) {
+	p.codecsMut.Lock()
+	defer p.codecsMut.Unlock()
+
+	cached, exists := p.codecs[subject]
+	if exists && time.Since(cached.fetchedAt) < p.refreshPeriod {
+		return cached, nil
+	}
+
+	p.mSchemaFetch.Incr(1)
+	schema, err := p.client.getLatestSchema(subject)
+	if err != nil {
+		if exists {
+			// Continue with our cached schema until the registry recovers.
+			p.log.Warnf("Failed to refresh schema of subject '%v', using cached schema: %v\n", subject, err)
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	if exists && cached.id == schema.ID {
+		cached.fetchedAt = time.Now()
+		return cached, nil
+	}
+
+	codec, err := newSchemaRegistryCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create codec for schema %v: %w", schema.ID, err)
+	}
+
+	cached = &schemaRegistryEncodeCodec{
+		id:        schema.ID,
+		header:    schemaRegistryHeader(schema.ID),
+		codec:     codec,
+		fetchedAt: time.Now(),
+	}
+	p.codecs[subject] = cached
+	return cached, nil
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (p *SchemaRegistryEncode) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	p.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(index int, span opentracing.Span, part types.Part) error {
+		err := func() error {
+			cached, err := p.getCodec(p.subject.String(index, msg))
+			if err != nil {
+				return err
+			}
+			payload, err := cached.codec.encode(part.Get())
+			if err != nil {
+				return err
+			}
+			part.Set(append(append([]byte{}, cached.header...), payload...))
+			part.Metadata().Set("schema_id", strconv.Itoa(cached.id))
+			return nil
+		}()
+		if err != nil {
+			p.mErr.Incr(1)
+			p.log.Debugf("Failed to encode message: %v\n", err)
+		}
+		return err
+	}
+
+	IteratePartsWithSpan(TypeSchemaRegistryEncode, p.parts, newMsg, proc)
+
+	p.mBatchSent.Incr(1)
+	p.mSent.Incr(int64(newMsg.Len()))
+	return []types.Message{newMsg}, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (p *SchemaRegistryEncode) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (p *SchemaRegistryEncode) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
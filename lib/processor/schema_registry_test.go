@@ -0,0 +1,257 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAvroSchema = `{
+	"type": "record",
+	"name": "foo",
+	"fields": [
+		{"name": "name", "type": "string"},
+		{"name": "age", "type": "int"}
+	]
+}`
+
+const testProtobufSchema = `
+syntax = "proto3";
+package testing;
+
+message Person {
+	string name = 1;
+	int32 age = 2;
+
+	message Address {
+		string street = 1;
+	}
+}
+`
+
+const testJSONSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"}
+	},
+	"required": ["name"]
+}`
+
+func newTestSchemaRegistry(t *testing.T, requests *int32) *httptest.Server {
+	t.Helper()
+
+	schemas := map[string]schemaRegistrySchema{
+		"/schemas/ids/1":                     {Schema: testAvroSchema},
+		"/schemas/ids/2":                     {Schema: testProtobufSchema, SchemaType: "PROTOBUF"},
+		"/schemas/ids/3":                     {Schema: testJSONSchema, SchemaType: "JSON"},
+		"/subjects/avro/versions/latest":     {ID: 1, Schema: testAvroSchema},
+		"/subjects/protobuf/versions/latest": {ID: 2, Schema: testProtobufSchema, SchemaType: "PROTOBUF"},
+		"/subjects/json/versions/latest":     {ID: 3, Schema: testJSONSchema, SchemaType: "JSON"},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		s, exists := schemas[r.URL.Path]
+		if !exists {
+			http.Error(w, `{"error_code":40403,"message":"Schema not found"}`, http.StatusNotFound)
+			return
+		}
+		resBytes, err := json.Marshal(s)
+		require.NoError(t, err)
+		w.Write(resBytes)
+	}))
+}
+
+func TestSchemaRegistryRoundTrip(t *testing.T) {
+	var requests int32
+	server := newTestSchemaRegistry(t, &requests)
+	defer server.Close()
+
+	tests := map[string]struct {
+		subject  string
+		input    string
+		output   string
+		schemaID string
+	}{
+		"avro": {
+			subject:  "avro",
+			input:    `{"name":"foo","age":30}`,
+			output:   `{"age":30,"name":"foo"}`,
+			schemaID: "1",
+		},
+		"protobuf": {
+			subject:  "protobuf",
+			input:    `{"name":"foo","age":30}`,
+			output:   `{"age":30,"name":"foo"}`,
+			schemaID: "2",
+		},
+		"json": {
+			subject:  "json",
+			input:    `{"name":"foo","age":30}`,
+			output:   `{"age":30,"name":"foo"}`,
+			schemaID: "3",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			encConf := NewConfig()
+			encConf.Type = TypeSchemaRegistryEncode
+			encConf.SchemaRegistryEncode.URL = server.URL
+			encConf.SchemaRegistryEncode.Subject = test.subject
+
+			encoder, err := New(encConf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			decConf := NewConfig()
+			decConf.Type = TypeSchemaRegistryDecode
+			decConf.SchemaRegistryDecode.URL = server.URL
+
+			decoder, err := New(decConf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			msgs, res := encoder.ProcessMessage(message.New([][]byte{[]byte(test.input)}))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			require.Equal(t, "", msgs[0].Get(0).Metadata().Get(FailFlagKey))
+			assert.Equal(t, test.schemaID, msgs[0].Get(0).Metadata().Get("schema_id"))
+
+			id, _, err := parseSchemaRegistryHeader(msgs[0].Get(0).Get())
+			require.NoError(t, err)
+			assert.Equal(t, test.schemaID, strconv.Itoa(id))
+
+			msgs, res = decoder.ProcessMessage(msgs[0])
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			require.Equal(t, "", msgs[0].Get(0).Metadata().Get(FailFlagKey))
+			assert.JSONEq(t, test.output, string(msgs[0].Get(0).Get()))
+		})
+	}
+}
+
+func TestSchemaRegistryCaching(t *testing.T) {
+	var requests int32
+	server := newTestSchemaRegistry(t, &requests)
+	defer server.Close()
+
+	encConf := NewConfig()
+	encConf.Type = TypeSchemaRegistryEncode
+	encConf.SchemaRegistryEncode.URL = server.URL
+	encConf.SchemaRegistryEncode.Subject = `${! meta("subject") }`
+
+	encoder, err := New(encConf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	decConf := NewConfig()
+	decConf.Type = TypeSchemaRegistryDecode
+	decConf.SchemaRegistryDecode.URL = server.URL
+
+	decoder, err := New(decConf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := message.New([][]byte{
+		[]byte(`{"name":"foo","age":1}`),
+		[]byte(`{"name":"bar","age":2}`),
+		[]byte(`{"name":"baz"}`),
+	})
+	input.Get(0).Metadata().Set("subject", "avro")
+	input.Get(1).Metadata().Set("subject", "avro")
+	input.Get(2).Metadata().Set("subject", "json")
+
+	for i := 0; i < 2; i++ {
+		msgs, res := encoder.ProcessMessage(input)
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+
+		msgs, res = decoder.ProcessMessage(msgs[0])
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+
+		for j, exp := range []string{`{"name":"foo","age":1}`, `{"name":"bar","age":2}`, `{"name":"baz"}`} {
+			assert.Equal(t, "", msgs[0].Get(j).Metadata().Get(FailFlagKey))
+			assert.JSONEq(t, exp, string(msgs[0].Get(j).Get()))
+		}
+	}
+
+	// Two subjects fetched by the encoder and two IDs fetched by the decoder.
+	assert.Equal(t, int32(4), atomic.LoadInt32(&requests))
+}
+
+func TestSchemaRegistryErrors(t *testing.T) {
+	var requests int32
+	server := newTestSchemaRegistry(t, &requests)
+	defer server.Close()
+
+	encConf := NewConfig()
+	encConf.Type = TypeSchemaRegistryEncode
+	encConf.SchemaRegistryEncode.URL = server.URL
+
+	_, err := New(encConf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	encConf.SchemaRegistryEncode.Subject = `${! meta("subject") }`
+	encoder, err := New(encConf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	decConf := NewConfig()
+	decConf.Type = TypeSchemaRegistryDecode
+	decConf.SchemaRegistryDecode.URL = server.URL
+
+	decoder, err := New(decConf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := message.New([][]byte{
+		[]byte(`{"name":"foo","age":"not a number"}`),
+		[]byte(`{"age":2}`),
+		[]byte(`{"name":"foo"}`),
+	})
+	input.Get(0).Metadata().Set("subject", "avro")
+	input.Get(1).Metadata().Set("subject", "json")
+	input.Get(2).Metadata().Set("subject", "unknown")
+
+	msgs, res := encoder.ProcessMessage(input)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	for i := 0; i < 3; i++ {
+		assert.NotEqual(t, "", msgs[0].Get(i).Metadata().Get(FailFlagKey), i)
+	}
+
+	msgs, res = decoder.ProcessMessage(message.New([][]byte{
+		[]byte(`{"name":"foo"}`),
+		append(schemaRegistryHeader(10), []byte(`{"name":"foo"}`)...),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	for i := 0; i < 2; i++ {
+		assert.NotEqual(t, "", msgs[0].Get(i).Metadata().Get(FailFlagKey), i)
+	}
+}
+
+func TestSchemaRegistryProtobufIndexes(t *testing.T) {
+	tests := [][]int{
+		{0},
+		{1},
+		{0, 0},
+		{2, 5, 1},
+	}
+
+	for _, test := range tests {
+		encoded := encodeProtobufIndexes(test)
+		indexes, remaining, err := decodeProtobufIndexes(append(encoded, 'x'))
+		require.NoError(t, err)
+		assert.Equal(t, test, indexes)
+		assert.Equal(t, []byte("x"), remaining)
+	}
+
+	assert.Equal(t, []byte{0}, encodeProtobufIndexes([]int{0}))
+}
@@ -1,7 +1,12 @@
 package processor
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -35,9 +40,26 @@ each message of a batch place it within a
 for_each:
 - sleep:
     duration: ${! meta("sleep_for") }
+` + "```" + `
+
+### Server Driven Pacing
+
+As well as duration strings such as ` + "`500ms`" + `, the resolved duration can
+be a number of seconds or an HTTP date, which are the forms of the
+` + "`Retry-After`" + ` HTTP header. An HTTP date results in a sleep until that
+time, and an empty duration results in no sleep. Negative durations and
+numbers of seconds that aren't finite are rejected. The field
+` + "`max_duration`" + ` can be used in order to cap the period of sleeps
+requested by servers:
+
+` + "``` yaml" + `
+sleep:
+  duration: ${! meta("Retry-After") }
+  max_duration: 1m
 ` + "```" + ``,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("duration", "The duration of time to sleep for each execution."),
+			docs.FieldAdvanced("max_duration", "An optional maximum duration of time to sleep for each execution, regardless of the resolved duration."),
 		},
 	}
 }
@@ -46,13 +68,15 @@ for_each:
 
 // SleepConfig contains configuration fields for the Sleep processor.
 type SleepConfig struct {
-	Duration string `json:"duration" yaml:"duration"`
+	Duration    string `json:"duration" yaml:"duration"`
+	MaxDuration string `json:"max_duration" yaml:"max_duration"`
 }
 
 // NewSleepConfig returns a SleepConfig with default values.
 func NewSleepConfig() SleepConfig {
 	return SleepConfig{
-		Duration: "100us",
+		Duration:    "100us",
+		MaxDuration: "",
 	}
 }
 
@@ -69,6 +93,7 @@ type Sleep struct {
 	stats metrics.Type
 
 	durationStr field.Expression
+	maxDuration time.Duration
 
 	mCount     metrics.StatCounter
 	mErr       metrics.StatCounter
@@ -84,6 +109,15 @@ func NewSleep(
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse duration expression: %v", err)
 	}
+	var maxDuration time.Duration
+	if conf.Sleep.MaxDuration != "" {
+		if maxDuration, err = time.ParseDuration(conf.Sleep.MaxDuration); err != nil {
+			return nil, fmt.Errorf("failed to parse max duration: %v", err)
+		}
+		if maxDuration < 0 {
+			return nil, errors.New("max duration must not be negative")
+		}
+	}
 	t := &Sleep{
 		closeChan: make(chan struct{}),
 		conf:      conf,
@@ -91,6 +125,7 @@ func NewSleep(
 		stats:     stats,
 
 		durationStr: durationStr,
+		maxDuration: maxDuration,
 
 		mCount:     stats.GetCounter("count"),
 		mErr:       stats.GetCounter("error"),
@@ -102,6 +137,49 @@ func NewSleep(
 
 //------------------------------------------------------------------------------
 
+// parseSleepDuration parses a duration string, a number of seconds or an HTTP
+// date, where the latter results in the duration until the date. Durations are
+// capped at max, or the largest time.Duration when max is zero.
+func parseSleepDuration(str string, max time.Duration) (time.Duration, error) {
+	if max <= 0 {
+		max = math.MaxInt64
+	}
+
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return 0, nil
+	}
+	if d, err := time.ParseDuration(str); err == nil {
+		if d < 0 {
+			return 0, fmt.Errorf("duration must not be negative: %v", str)
+		}
+		if d > max {
+			d = max
+		}
+		return d, nil
+	}
+	if secs, err := strconv.ParseFloat(str, 64); err == nil {
+		if math.IsNaN(secs) || math.IsInf(secs, 0) || secs < 0 {
+			return 0, fmt.Errorf("number of seconds must be a finite, non-negative value: %v", str)
+		}
+		// Compare before converting as time.Duration overflows silently.
+		if nanos := secs * float64(time.Second); nanos < float64(max) {
+			return time.Duration(nanos), nil
+		}
+		return max, nil
+	}
+	if t, err := http.ParseTime(str); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		} else if d > max {
+			d = max
+		}
+		return d, nil
+	}
+	return 0, fmt.Errorf("value is not a duration, number of seconds or HTTP date: %v", str)
+}
+
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (s *Sleep) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
@@ -114,7 +192,7 @@ func (s *Sleep) ProcessMessage(msg types.Message) ([]types.Message, types.Respon
 		}
 	}()
 
-	period, err := time.ParseDuration(s.durationStr.String(0, msg))
+	period, err := parseSleepDuration(s.durationStr.String(0, msg), s.maxDuration)
 	if err != nil {
 		s.log.Errorf("Failed to parse duration: %v\n", err)
 		s.mErr.Incr(1)
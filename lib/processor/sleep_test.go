@@ -1,6 +1,8 @@
 package processor
 
 import (
+	"math"
+	"net/http"
 	"testing"
 	"time"
 
@@ -94,3 +96,83 @@ func TestSleepInterpolated(t *testing.T) {
 		t.Errorf("Message didn't take long enough")
 	}
 }
+
+func TestSleepParseDuration(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+
+	tests := map[string]struct {
+		min, max time.Duration
+		err      bool
+	}{
+		"":                              {},
+		"200ms":                         {min: time.Millisecond * 200, max: time.Millisecond * 200},
+		" 2 ":                           {min: time.Second * 2, max: time.Second * 2},
+		"0.5":                           {min: time.Millisecond * 500, max: time.Millisecond * 500},
+		future:                          {min: time.Minute * 59, max: time.Hour},
+		"Wed, 21 Oct 2015 07:28:00 GMT": {},
+		"1e300":                         {min: math.MaxInt64, max: math.MaxInt64},
+		"9223372036.854775807":          {min: math.MaxInt64, max: math.MaxInt64},
+		"-1":                            {err: true},
+		"-1s":                           {err: true},
+		"NaN":                           {err: true},
+		"Inf":                           {err: true},
+		"-Inf":                          {err: true},
+		"nope":                          {err: true},
+	}
+
+	for input, test := range tests {
+		d, err := parseSleepDuration(input, 0)
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error for '%v'", input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for '%v': %v", input, err)
+		} else if d < test.min || d > test.max {
+			t.Errorf("Wrong duration for '%v': %v", input, d)
+		}
+	}
+}
+
+func TestSleepMaxDuration(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSleep
+	conf.Sleep.Duration = "${! meta(\"Retry-After\") }"
+	conf.Sleep.MaxDuration = "50ms"
+
+	slp, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := message.New([][]byte{[]byte("foo")})
+	msg.Get(0).Metadata().Set("Retry-After", "3600")
+
+	tBefore := time.Now()
+	slp.ProcessMessage(msg)
+	tAfter := time.Now()
+
+	if dur := tAfter.Sub(tBefore); dur < (time.Millisecond*50) || dur > time.Second {
+		t.Errorf("Wrong sleep duration: %v", dur)
+	}
+
+	for _, input := range []string{"3600", "1h", "1e300", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)} {
+		if d, err := parseSleepDuration(input, time.Minute); err != nil {
+			t.Errorf("Unexpected error for '%v': %v", input, err)
+		} else if d != time.Minute {
+			t.Errorf("Wrong duration for '%v': %v", input, d)
+		}
+	}
+
+	conf.Sleep.MaxDuration = "nope"
+	if _, err = New(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad max duration")
+	}
+
+	conf.Sleep.MaxDuration = "-1s"
+	if _, err = New(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from negative max duration")
+	}
+}
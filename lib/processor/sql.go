@@ -282,10 +282,20 @@ func NewSQL(
 type sqlResultCodec func(rows *sql.Rows, part types.Part) error
 
 func sqlResultJSONArrayCodec(rows *sql.Rows, part types.Part) error {
-	columnNames, err := rows.Columns()
+	jArray, err := sqlRowsToArray(rows)
 	if err != nil {
 		return err
 	}
+	return part.SetJSON(jArray)
+}
+
+// sqlRowsToArray reads rows into an array of JSON objects, where each object
+// represents a row.
+func sqlRowsToArray(rows *sql.Rows) ([]interface{}, error) {
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
 	jArray := []interface{}{}
 	for rows.Next() {
 		values := make([]interface{}, len(columnNames))
@@ -294,7 +304,7 @@ func sqlResultJSONArrayCodec(rows *sql.Rows, part types.Part) error {
 			valuesWrapped[i] = &values[i]
 		}
 		if err := rows.Scan(valuesWrapped...); err != nil {
-			return err
+			return nil, err
 		}
 		jObj := map[string]interface{}{}
 		for i, v := range values {
@@ -316,9 +326,9 @@ func sqlResultJSONArrayCodec(rows *sql.Rows, part types.Part) error {
 		jArray = append(jArray, jObj)
 	}
 	if err := rows.Err(); err != nil {
-		return err
+		return nil, err
 	}
-	return part.SetJSON(jArray)
+	return jArray, nil
 }
 
 func strToSQLResultCodec(codec string) (sqlResultCodec, error) {
@@ -5,5 +5,7 @@ package processor
 // Import extra drivers that aren't supported by WASM builds.
 import (
 	// SQL Drivers
+	_ "github.com/denisenkom/go-mssqldb"
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
@@ -0,0 +1,259 @@
+package processor
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/cache"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sqliteSelectTestDB creates a database file with a table of users and returns
+// its path, skipping the test when the sqlite driver isn't available.
+func sqliteSelectTestDB(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "benthos_sql_select_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	path := filepath.Join(dir, "test.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Skipf("sqlite driver not available: %v", err)
+	}
+	defer db.Close()
+	if err = db.Ping(); err != nil {
+		t.Skipf("sqlite driver not available: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER, name TEXT, team TEXT);
+INSERT INTO users (id, name, team) VALUES (1, 'ash', 'red'), (2, 'bob', 'blue'), (3, 'cat', 'red');`)
+	require.NoError(t, err)
+	return path
+}
+
+func sqliteSelectTestExec(t *testing.T, path, stmt string) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(stmt)
+	require.NoError(t, err)
+}
+
+func testSQLSelectOutput(t *testing.T, proc Type, input []string) []string {
+	t.Helper()
+
+	msg := message.New(nil)
+	for _, in := range input {
+		msg.Append(message.NewPart([]byte(in)))
+	}
+
+	msgs, res := proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	var output []string
+	msgs[0].Iter(func(i int, p types.Part) error {
+		if HasFailed(p) {
+			output = append(output, "failed: "+GetFail(p))
+		} else {
+			output = append(output, string(p.Get()))
+		}
+		return nil
+	})
+	return output
+}
+
+func TestSQLSelectPerMessage(t *testing.T) {
+	path := sqliteSelectTestDB(t)
+
+	conf := NewConfig()
+	conf.Type = TypeSQLSelect
+	conf.SQLSelect.Driver = "sqlite"
+	conf.SQLSelect.DataSourceName = path
+	conf.SQLSelect.Query = "SELECT name FROM users WHERE team = ? ORDER BY id;"
+	conf.SQLSelect.ArgsMapping = `root = [ this.team ]`
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		proc.CloseAsync()
+		require.NoError(t, proc.WaitForClose(time.Second))
+	}()
+
+	assert.Equal(t, []string{
+		`[{"name":"ash"},{"name":"cat"}]`,
+		`[{"name":"bob"}]`,
+		`[]`,
+		`failed: args mapping failed: failed to parse message as JSON: invalid character 'o' in literal null (expecting 'u')`,
+	}, testSQLSelectOutput(t, proc, []string{
+		`{"team":"red"}`,
+		`{"team":"blue"}`,
+		`{"team":"green"}`,
+		`nope`,
+	}))
+}
+
+func TestSQLSelectResultMap(t *testing.T) {
+	path := sqliteSelectTestDB(t)
+
+	conf := NewConfig()
+	conf.Type = TypeSQLSelect
+	conf.SQLSelect.Driver = "sqlite"
+	conf.SQLSelect.DataSourceName = path
+	conf.SQLSelect.Query = "SELECT name, team FROM users WHERE id = ?;"
+	conf.SQLSelect.ArgsMapping = `root = [ this.user.id ]`
+	conf.SQLSelect.ResultMap = `root.user.name = this.index(0).name
+root.user.team = this.index(0).team`
+	conf.SQLSelect.Parts = []int{1}
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"user":{"id":1}}`,
+		`{"user":{"id":2,"name":"bob","team":"blue"}}`,
+	}, testSQLSelectOutput(t, proc, []string{
+		`{"user":{"id":1}}`,
+		`{"user":{"id":2}}`,
+	}))
+}
+
+func TestSQLSelectBatch(t *testing.T) {
+	path := sqliteSelectTestDB(t)
+
+	conf := NewConfig()
+	conf.Type = TypeSQLSelect
+	conf.SQLSelect.Driver = "sqlite"
+	conf.SQLSelect.DataSourceName = path
+	conf.SQLSelect.Query = "SELECT id, name FROM users WHERE id IN (?);"
+	conf.SQLSelect.ArgsMapping = `root = this.id`
+	conf.SQLSelect.BatchKeyColumn = "id"
+	conf.SQLSelect.ResultMap = `root.name = this.index(0).name | null`
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"id":3,"name":"cat"}`,
+		`{"id":1,"name":"ash"}`,
+		`{"id":3,"name":"cat"}`,
+		`{"id":4,"name":null}`,
+		`failed: args mapping must return a single key for batch lookups, got 2`,
+	}, testSQLSelectOutput(t, proc, []string{
+		`{"id":3}`,
+		`{"id":1}`,
+		`{"id":3}`,
+		`{"id":4}`,
+		`{"id":[1,2]}`,
+	}))
+}
+
+func TestSQLSelectCache(t *testing.T) {
+	for _, batchKey := range []string{"", "id"} {
+		path := sqliteSelectTestDB(t)
+
+		memCache, err := cache.NewMemory(cache.NewConfig(), nil, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		conf := NewConfig()
+		conf.Type = TypeSQLSelect
+		conf.SQLSelect.Driver = "sqlite"
+		conf.SQLSelect.DataSourceName = path
+		conf.SQLSelect.Query = "SELECT id, name FROM users WHERE id IN (?);"
+		conf.SQLSelect.ArgsMapping = `root = [ this.id ]`
+		conf.SQLSelect.BatchKeyColumn = batchKey
+		conf.SQLSelect.Cache = "foocache"
+
+		proc, err := New(conf, &fakeMgr{
+			caches: map[string]types.Cache{
+				"foocache": memCache,
+			},
+		}, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			`[{"id":1,"name":"ash"}]`,
+			`[]`,
+		}, testSQLSelectOutput(t, proc, []string{`{"id":1}`, `{"id":5}`}), batchKey)
+
+		sqliteSelectTestExec(t, path, `UPDATE users SET name = 'ann' WHERE id = 1;
+INSERT INTO users (id, name, team) VALUES (5, 'eve', 'blue');`)
+
+		assert.Equal(t, []string{
+			`[{"id":1,"name":"ash"}]`,
+			`[]`,
+			`[{"id":2,"name":"bob"}]`,
+		}, testSQLSelectOutput(t, proc, []string{`{"id":1}`, `{"id":5}`, `{"id":2}`}), batchKey)
+
+		require.NoError(t, memCache.Delete(`[1]`))
+
+		assert.Equal(t, []string{
+			`[{"id":1,"name":"ann"}]`,
+		}, testSQLSelectOutput(t, proc, []string{`{"id":1}`}), batchKey)
+	}
+}
+
+func TestSQLSelectBadConfig(t *testing.T) {
+	tests := map[string]struct {
+		conf func(c *SQLSelectConfig)
+		err  string
+	}{
+		"bad driver": {
+			conf: func(c *SQLSelectConfig) {
+				c.Driver = "nope"
+			},
+			err: "unrecognised driver: nope",
+		},
+		"no query": {
+			conf: func(c *SQLSelectConfig) {},
+			err:  "a query must be specified",
+		},
+		"batch placeholders": {
+			conf: func(c *SQLSelectConfig) {
+				c.Driver = "postgres"
+				c.Query = "SELECT * FROM foo WHERE id IN ($1) AND bar = $2"
+				c.BatchKeyColumn = "id"
+			},
+			err: "batch lookup queries must contain exactly one placeholder",
+		},
+		"batch no placeholder": {
+			conf: func(c *SQLSelectConfig) {
+				c.Query = "SELECT * FROM foo"
+				c.BatchKeyColumn = "id"
+			},
+			err: "batch lookup queries must contain exactly one placeholder",
+		},
+		"bad life time": {
+			conf: func(c *SQLSelectConfig) {
+				c.Query = "SELECT 1"
+				c.ConnMaxLifeTime = "nope"
+			},
+			err: `failed to parse conn_max_life_time: time: invalid duration "nope"`,
+		},
+	}
+
+	for name, test := range tests {
+		conf := NewConfig()
+		conf.Type = TypeSQLSelect
+		test.conf(&conf.SQLSelect)
+
+		_, err := New(conf, nil, log.Noop(), metrics.Noop())
+		assert.EqualError(t, err, test.err, name)
+	}
+}
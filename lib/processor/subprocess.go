@@ -4,7 +4,10 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os/exec"
 	"sync"
@@ -17,6 +20,7 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/response"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/cenkalti/backoff/v4"
 	olog "github.com/opentracing/opentracing-go/log"
 )
 
@@ -42,13 +46,43 @@ The field ` + "`max_buffer`" + ` defines the maximum response size able to be
 read from the subprocess. This value should be set significantly above the real
 expected maximum response size.
 
+## Codecs
+
+The field ` + "`codec`" + ` determines how messages are framed when they are
+written to and read from the subprocess:
+
+- ` + "`lines`" + `: Each line of a message is written followed by a newline and a
+  line is expected in response, see [messages containing line breaks](#messages-containing-line-breaks).
+- ` + "`json_lines`" + `: Each message is written as a single line of compacted
+  JSON, and the response line must also be valid JSON. Messages that aren't
+  valid JSON are marked as failed without being written.
+- ` + "`length_prefixed_uint32_be`" + `: Each message is written prefixed with
+  its length as a 32-bit big endian unsigned integer, and the response is
+  expected in the same form. This allows messages to contain any bytes,
+  including line breaks.
+
+Responses over stderr are always read as lines.
+
 ## Subprocess requirements
 
 It is required that subprocesses flush their stdout and stderr pipes for each
 line.
 
 Benthos will attempt to keep the process alive for as long as the pipeline is
-running. If the process exits early it will be restarted.
+running. If the process exits early it will be restarted, where repeated
+restarts are delayed by an exponential backoff from 100ms up to 10s.
+
+When the field ` + "`timeout`" + ` is set a message that doesn't receive a
+response within the timeout is marked as failed, and the subprocess is
+restarted in order to avoid a late response being attributed to the next
+message.
+
+## Logging stderr
+
+Subprocesses such as scripts often write warnings and diagnostics to stderr that
+aren't responses to a message. When the field ` + "`log_stderr`" + ` is set
+responses are only read from stdout, and each line written to stderr is instead
+logged with its contents in the field ` + "`stderr`" + `.
 
 ## Messages containing line breaks
 
@@ -58,6 +92,9 @@ another line is fed in.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("name", "The command to execute as a subprocess.", "cat", "sed", "awk"),
 			docs.FieldCommon("args", "A list of arguments to provide the command."),
+			docs.FieldAdvanced("codec", "The way in which messages are [framed](#codecs) when communicating with the subprocess.").HasOptions("lines", "json_lines", "length_prefixed_uint32_be"),
+			docs.FieldAdvanced("timeout", "An optional maximum period to wait for a response to each message, after which the message is marked as failed and the subprocess is restarted.", "5s"),
+			docs.FieldAdvanced("log_stderr", "Whether to [log lines written to stderr](#logging-stderr) rather than treat them as failed responses."),
 			docs.FieldAdvanced("max_buffer", "The maximum expected response size."),
 			partsFieldSpec,
 		},
@@ -71,6 +108,9 @@ type SubprocessConfig struct {
 	Parts     []int    `json:"parts" yaml:"parts"`
 	Name      string   `json:"name" yaml:"name"`
 	Args      []string `json:"args" yaml:"args"`
+	Codec     string   `json:"codec" yaml:"codec"`
+	Timeout   string   `json:"timeout" yaml:"timeout"`
+	LogStderr bool     `json:"log_stderr" yaml:"log_stderr"`
 	MaxBuffer int      `json:"max_buffer" yaml:"max_buffer"`
 }
 
@@ -80,6 +120,9 @@ func NewSubprocessConfig() SubprocessConfig {
 		Parts:     []int{},
 		Name:      "cat",
 		Args:      []string{},
+		Codec:     "lines",
+		Timeout:   "",
+		LogStderr: false,
 		MaxBuffer: bufio.MaxScanTokenSize,
 	}
 }
@@ -117,8 +160,29 @@ func NewSubprocess(
 		mSent:      stats.GetCounter("sent"),
 		mBatchSent: stats.GetCounter("batch.sent"),
 	}
+
+	wConf := subprocWrapperConfig{
+		name:      conf.Subprocess.Name,
+		args:      conf.Subprocess.Args,
+		maxBuf:    conf.Subprocess.MaxBuffer,
+		logStderr: conf.Subprocess.LogStderr,
+	}
+	switch conf.Subprocess.Codec {
+	case "lines", "json_lines":
+	case "length_prefixed_uint32_be":
+		wConf.lengthPrefixed = true
+	default:
+		return nil, fmt.Errorf("codec not recognised: %v", conf.Subprocess.Codec)
+	}
+	if conf.Subprocess.Timeout != "" {
+		var err error
+		if wConf.timeout, err = time.ParseDuration(conf.Subprocess.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %v", err)
+		}
+	}
+
 	var err error
-	if e.subproc, err = newSubprocWrapper(conf.Subprocess.Name, conf.Subprocess.Args, e.conf.MaxBuffer, log); err != nil {
+	if e.subproc, err = newSubprocWrapper(wConf, log); err != nil {
 		return nil, err
 	}
 	return e, nil
@@ -126,17 +190,31 @@ func NewSubprocess(
 
 //------------------------------------------------------------------------------
 
-type subprocWrapper struct {
+const (
+	subprocRestartInitialInterval = time.Millisecond * 100
+	subprocRestartMaxInterval     = time.Second * 10
+)
+
+type subprocWrapperConfig struct {
 	name   string
 	args   []string
 	maxBuf int
 
+	lengthPrefixed bool
+	logStderr      bool
+	timeout        time.Duration
+}
+
+type subprocWrapper struct {
+	conf subprocWrapperConfig
+
 	logger log.Modular
 
 	cmdMut      sync.Mutex
 	cmdExitChan chan struct{}
 	stdoutChan  chan []byte
 	stderrChan  chan []byte
+	startedAt   time.Time
 
 	cmd         *exec.Cmd
 	cmdStdin    io.WriteCloser
@@ -146,11 +224,9 @@ type subprocWrapper struct {
 	closedChan chan struct{}
 }
 
-func newSubprocWrapper(name string, args []string, maxBuf int, log log.Modular) (*subprocWrapper, error) {
+func newSubprocWrapper(conf subprocWrapperConfig, log log.Modular) (*subprocWrapper, error) {
 	s := &subprocWrapper{
-		name:       name,
-		args:       args,
-		maxBuf:     maxBuf,
+		conf:       conf,
 		logger:     log,
 		closeChan:  make(chan struct{}),
 		closedChan: make(chan struct{}),
@@ -163,6 +239,13 @@ func newSubprocWrapper(name string, args []string, maxBuf int, log log.Modular)
 			s.stop()
 			close(s.closedChan)
 		}()
+
+		boff := backoff.NewExponentialBackOff()
+		boff.InitialInterval = subprocRestartInitialInterval
+		boff.MaxInterval = subprocRestartMaxInterval
+		boff.MaxElapsedTime = 0
+		boff.Reset()
+
 		for {
 			select {
 			case <-s.cmdExitChan:
@@ -185,7 +268,23 @@ func newSubprocWrapper(name string, args []string, maxBuf int, log log.Modular)
 					log.Errorln(string(msgBytes))
 				}
 
-				s.start()
+				// Only back off when the subprocess exits shortly after
+				// starting, which indicates that it's crashing repeatedly.
+				if time.Since(s.startedAt) > subprocRestartMaxInterval {
+					boff.Reset()
+				}
+				for {
+					select {
+					case <-time.After(boff.NextBackOff()):
+					case <-s.closeChan:
+						return
+					}
+					err := s.start()
+					if err == nil {
+						break
+					}
+					log.Errorf("Failed to restart subprocess: %v\n", err)
+				}
 			case <-s.closeChan:
 				return
 			}
@@ -194,6 +293,25 @@ func newSubprocWrapper(name string, args []string, maxBuf int, log log.Modular)
 	return s, nil
 }
 
+// lengthPrefixedSplit is a bufio.SplitFunc for tokens prefixed with their
+// length as a 32-bit big endian unsigned integer.
+func lengthPrefixedSplit(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) < 4 {
+		if atEOF && len(data) > 0 {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+	length := int(binary.BigEndian.Uint32(data))
+	if len(data) < 4+length {
+		if atEOF {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+	return 4 + length, data[4 : 4+length], nil
+}
+
 func (s *subprocWrapper) start() error {
 	s.cmdMut.Lock()
 	defer s.cmdMut.Unlock()
@@ -206,7 +324,7 @@ func (s *subprocWrapper) start() error {
 		}
 	}()
 
-	cmd := exec.CommandContext(cmdCtx, s.name, s.args...)
+	cmd := exec.CommandContext(cmdCtx, s.conf.name, s.conf.args...)
 	var cmdStdin io.WriteCloser
 	if cmdStdin, err = cmd.StdinPipe(); err != nil {
 		return err
@@ -242,11 +360,15 @@ func (s *subprocWrapper) start() error {
 		}()
 
 		scanner := bufio.NewScanner(cmdStdout)
-		if s.maxBuf != bufio.MaxScanTokenSize {
-			scanner.Buffer(nil, s.maxBuf)
+		if s.conf.maxBuf != bufio.MaxScanTokenSize {
+			scanner.Buffer(nil, s.conf.maxBuf)
+		}
+		if s.conf.lengthPrefixed {
+			scanner.Split(lengthPrefixedSplit)
 		}
 		for scanner.Scan() {
-			stdoutChan <- scanner.Bytes()
+			// The scanner reuses its buffer and so the token must be copied.
+			stdoutChan <- append([]byte{}, scanner.Bytes()...)
 		}
 		if err := scanner.Err(); err != nil {
 			s.logger.Errorf("Failed to read subprocess output: %v\n", err)
@@ -264,11 +386,17 @@ func (s *subprocWrapper) start() error {
 		}()
 
 		scanner := bufio.NewScanner(cmdStderr)
-		if s.maxBuf != bufio.MaxScanTokenSize {
-			scanner.Buffer(nil, s.maxBuf)
+		if s.conf.maxBuf != bufio.MaxScanTokenSize {
+			scanner.Buffer(nil, s.conf.maxBuf)
 		}
 		for scanner.Scan() {
-			stderrChan <- scanner.Bytes()
+			if s.conf.logStderr {
+				s.logger.WithFields(map[string]string{
+					"stderr": scanner.Text(),
+				}).Warnln("Subprocess wrote to stderr")
+				continue
+			}
+			stderrChan <- append([]byte{}, scanner.Bytes()...)
 		}
 		if err := scanner.Err(); err != nil {
 			s.logger.Errorf("Failed to read subprocess error output: %v\n", err)
@@ -278,6 +406,7 @@ func (s *subprocWrapper) start() error {
 	s.cmdExitChan = cmdExitChan
 	s.stdoutChan = stdoutChan
 	s.stderrChan = stderrChan
+	s.startedAt = time.Now()
 	s.logger.Infoln("Subprocess started")
 	return nil
 }
@@ -296,7 +425,18 @@ func (s *subprocWrapper) stop() error {
 	return err
 }
 
-func (s *subprocWrapper) Send(line []byte) ([]byte, error) {
+// kill terminates the running subprocess, which is then restarted. Messages
+// are rejected until the restart so that they cannot receive stale responses.
+func (s *subprocWrapper) kill() {
+	s.cmdMut.Lock()
+	if s.cmd != nil {
+		s.cmdCancelFn()
+		s.cmdStdin = nil
+	}
+	s.cmdMut.Unlock()
+}
+
+func (s *subprocWrapper) Send(payload []byte) ([]byte, error) {
 	s.cmdMut.Lock()
 	stdin := s.cmdStdin
 	outChan := s.stdoutChan
@@ -306,11 +446,27 @@ func (s *subprocWrapper) Send(line []byte) ([]byte, error) {
 	if stdin == nil {
 		return nil, types.ErrTypeClosed
 	}
-	if _, err := stdin.Write(line); err != nil {
-		return nil, err
+	if s.conf.lengthPrefixed {
+		var lengthBytes [4]byte
+		binary.BigEndian.PutUint32(lengthBytes[:], uint32(len(payload)))
+		if _, err := stdin.Write(lengthBytes[:]); err != nil {
+			return nil, err
+		}
+		if _, err := stdin.Write(payload); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := stdin.Write(payload); err != nil {
+			return nil, err
+		}
+		if _, err := stdin.Write([]byte("\n")); err != nil {
+			return nil, err
+		}
 	}
-	if _, err := stdin.Write([]byte("\n")); err != nil {
-		return nil, err
+
+	var timeoutChan <-chan time.Time
+	if s.conf.timeout > 0 {
+		timeoutChan = time.After(s.conf.timeout)
 	}
 
 	var outBytes, errBytes []byte
@@ -331,6 +487,9 @@ func (s *subprocWrapper) Send(line []byte) ([]byte, error) {
 			}
 		}
 		errBytes = errBuf.Bytes()
+	case <-timeoutChan:
+		s.kill()
+		return nil, fmt.Errorf("timed out after %v waiting for a response from the subprocess", s.conf.timeout)
 	}
 
 	if !open {
@@ -356,6 +515,38 @@ func (e *Subprocess) ProcessMessage(msg types.Message) ([]types.Message, types.R
 		span := tracing.CreateChildSpan(TypeSubprocess, result.Get(i))
 		defer span.Finish()
 
+		fail := func(err error) {
+			e.log.Errorf("Failed to send message to subprocess: %v\n", err)
+			e.mErr.Incr(1)
+			span.LogFields(
+				olog.String("event", "error"),
+				olog.String("type", err.Error()),
+			)
+			FlagErr(result.Get(i), err)
+		}
+
+		if e.conf.Codec != "lines" {
+			payload := result.Get(i).Get()
+			if e.conf.Codec == "json_lines" {
+				var compacted bytes.Buffer
+				if err := json.Compact(&compacted, payload); err != nil {
+					fail(fmt.Errorf("failed to compact message as JSON: %w", err))
+					return nil
+				}
+				payload = compacted.Bytes()
+			}
+			res, err := e.subproc.Send(payload)
+			if err == nil && e.conf.Codec == "json_lines" && !json.Valid(res) {
+				err = errors.New("subprocess response was not valid JSON")
+			}
+			if err != nil {
+				fail(err)
+				return nil
+			}
+			result.Get(i).Set(res)
+			return nil
+		}
+
 		results := [][]byte{}
 		splitMsg := bytes.Split(result.Get(i).Get(), []byte("\n"))
 		for j, p := range splitMsg {
@@ -365,13 +556,7 @@ func (e *Subprocess) ProcessMessage(msg types.Message) ([]types.Message, types.R
 			}
 			res, err := e.subproc.Send(p)
 			if err != nil {
-				e.log.Errorf("Failed to send message to subprocess: %v\n", err)
-				e.mErr.Incr(1)
-				span.LogFields(
-					olog.String("event", "error"),
-					olog.String("type", err.Error()),
-				)
-				FlagErr(result.Get(i), err)
+				fail(err)
 				results = append(results, p)
 			} else {
 				results = append(results, res)
@@ -162,3 +162,192 @@ func TestSubprocessWithErrors(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestSubprocessLengthPrefixed(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSubprocess
+	conf.Subprocess.Name = "cat"
+	conf.Subprocess.Codec = "length_prefixed_uint32_be"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Skipf("Not sure if this is due to missing executable: %v", err)
+	}
+
+	exp := [][]byte{
+		[]byte("hello\nworld\n"),
+		[]byte(""),
+		[]byte("\x00\x01\x02"),
+	}
+	msgs, res := proc.ProcessMessage(message.New(exp))
+	if res != nil {
+		t.Fatalf("Non-nil result: %v", res.Error())
+	}
+	if act := message.GetAllBytes(msgs[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong results: %q != %q", act, exp)
+	}
+	for i := 0; i < msgs[0].Len(); i++ {
+		if HasFailed(msgs[0].Get(i)) {
+			t.Errorf("Unexpected failure at %v: %v", i, GetFail(msgs[0].Get(i)))
+		}
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubprocessJSONLines(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSubprocess
+	conf.Subprocess.Name = "cat"
+	conf.Subprocess.Codec = "json_lines"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Skipf("Not sure if this is due to missing executable: %v", err)
+	}
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte("{\n  \"foo\": \"bar\"\n}"),
+		[]byte("not json"),
+		[]byte(`["baz"]`),
+	}))
+	if res != nil {
+		t.Fatalf("Non-nil result: %v", res.Error())
+	}
+
+	exp := [][]byte{
+		[]byte(`{"foo":"bar"}`),
+		[]byte("not json"),
+		[]byte(`["baz"]`),
+	}
+	if act := message.GetAllBytes(msgs[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong results: %s != %s", act, exp)
+	}
+	if HasFailed(msgs[0].Get(0)) || !HasFailed(msgs[0].Get(1)) || HasFailed(msgs[0].Get(2)) {
+		t.Errorf("Wrong failure flags: %v, %v, %v", GetFail(msgs[0].Get(0)), GetFail(msgs[0].Get(1)), GetFail(msgs[0].Get(2)))
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubprocessTimeout(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSubprocess
+	conf.Subprocess.Name = "sh"
+	conf.Subprocess.Args = []string{"-c", `while read l; do if [ "$l" = "slow" ]; then read never; fi; echo "$l"; done`}
+	conf.Subprocess.Timeout = "50ms"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Skipf("Not sure if this is due to missing executable: %v", err)
+	}
+
+	msgs, _ := proc.ProcessMessage(message.New([][]byte{[]byte("slow")}))
+	if !HasFailed(msgs[0].Get(0)) {
+		t.Error("Expected slow message to fail")
+	}
+
+	// The subprocess is restarted and therefore the late response must not be
+	// attributed to the next message.
+	var act string
+	for i := 0; i < 50; i++ {
+		msgs, _ = proc.ProcessMessage(message.New([][]byte{[]byte("fast")}))
+		if !HasFailed(msgs[0].Get(0)) {
+			act = string(msgs[0].Get(0).Get())
+			break
+		}
+		<-time.After(time.Millisecond * 50)
+	}
+	if exp := "fast"; act != exp {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubprocessRestart(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSubprocess
+	conf.Subprocess.Name = "sh"
+	conf.Subprocess.Args = []string{"-c", `read l; echo "$l"`}
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Skipf("Not sure if this is due to missing executable: %v", err)
+	}
+
+	// The subprocess exits after each message and is therefore restarted with
+	// a growing backoff.
+	for _, input := range []string{"foo", "bar"} {
+		var act string
+		for i := 0; i < 100; i++ {
+			msgs, _ := proc.ProcessMessage(message.New([][]byte{[]byte(input)}))
+			if !HasFailed(msgs[0].Get(0)) {
+				act = string(msgs[0].Get(0).Get())
+				break
+			}
+			<-time.After(time.Millisecond * 50)
+		}
+		if act != input {
+			t.Errorf("Wrong result: %v != %v", act, input)
+		}
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubprocessLogStderr(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSubprocess
+	conf.Subprocess.Name = "sh"
+	conf.Subprocess.Args = []string{"-c", `while read l; do echo "warning: $l" 1>&2; echo "$l"; done`}
+	conf.Subprocess.LogStderr = true
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Skipf("Not sure if this is due to missing executable: %v", err)
+	}
+
+	exp := [][]byte{[]byte("foo"), []byte("bar")}
+	msgs, _ := proc.ProcessMessage(message.New(exp))
+	if act := message.GetAllBytes(msgs[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong results: %s != %s", act, exp)
+	}
+	for i := 0; i < msgs[0].Len(); i++ {
+		if HasFailed(msgs[0].Get(i)) {
+			t.Errorf("Unexpected failure at %v: %v", i, GetFail(msgs[0].Get(i)))
+		}
+	}
+
+	proc.CloseAsync()
+	if err := proc.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubprocessBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSubprocess
+	conf.Subprocess.Codec = "nope"
+	if _, err := New(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad codec")
+	}
+
+	conf.Subprocess.Codec = "lines"
+	conf.Subprocess.Timeout = "nope"
+	if _, err := New(conf, nil, log.Noop(), metrics.Noop()); err == nil {
+		t.Error("Expected error from bad timeout")
+	}
+}
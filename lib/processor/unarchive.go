@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
@@ -35,12 +36,16 @@ the batch. Messages that are selected but fail to unarchive (invalid format)
 will remain unchanged in the message batch but will be flagged as having failed,
 allowing you to [error handle them](/docs/configuration/error_handling).
 
-For the unarchive formats that contain file information (tar, zip), a metadata
-field is added to each message called ` + "`archive_filename`" + ` with the
-extracted filename.`,
+For the unarchive formats that contain file information (tar, tar.zst, zip) the
+following metadata fields are added to each message, and directories within
+archives are skipped:
+
+- ` + "`archive_filename`" + `: The path of the file.
+- ` + "`archive_mode`" + `: The permission bits of the file in octal, e.g. ` + "`0644`" + `.
+- ` + "`archive_mtime`" + `: The modification time of the file in RFC 3339 format.`,
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("format", "The unarchive [format](#formats) to use.").HasOptions(
-				"tar", "zip", "binary", "lines", "json_documents", "json_array", "json_map",
+				"tar", "tar.zst", "zip", "binary", "lines", "json_documents", "json_array", "json_map",
 			),
 			partsFieldSpec,
 		},
@@ -51,6 +56,10 @@ extracted filename.`,
 
 Extract messages from a unix standard tape archive.
 
+### ` + "`tar.zst`" + `
+
+Extract messages from a unix standard tape archive compressed with zstd.
+
 ### ` + "`zip`" + `
 
 Extract messages from a zip file.
@@ -107,8 +116,27 @@ func NewUnarchiveConfig() UnarchiveConfig {
 
 type unarchiveFunc func(part types.Part) ([]types.Part, error)
 
+func setArchiveFileMetadata(part types.Part, info os.FileInfo, name string) {
+	meta := part.Metadata()
+	meta.Set("archive_filename", name)
+	meta.Set("archive_mode", fmt.Sprintf("%04o", info.Mode().Perm()))
+	meta.Set("archive_mtime", info.ModTime().Format(time.RFC3339))
+}
+
 func tarUnarchive(part types.Part) ([]types.Part, error) {
-	buf := bytes.NewBuffer(part.Get())
+	return tarUnarchiveBytes(part, part.Get())
+}
+
+func tarZstdUnarchive(part types.Part) ([]types.Part, error) {
+	b, err := newZSTDDecompressor()(part.Get())
+	if err != nil {
+		return nil, err
+	}
+	return tarUnarchiveBytes(part, b)
+}
+
+func tarUnarchiveBytes(part types.Part, b []byte) ([]types.Part, error) {
+	buf := bytes.NewBuffer(b)
 	tr := tar.NewReader(buf)
 
 	var newParts []types.Part
@@ -123,6 +151,9 @@ func tarUnarchive(part types.Part) ([]types.Part, error) {
 		if err != nil {
 			return nil, err
 		}
+		if h.FileInfo().IsDir() {
+			continue
+		}
 
 		newPartBuf := bytes.Buffer{}
 		if _, err = newPartBuf.ReadFrom(tr); err != nil {
@@ -131,7 +162,7 @@ func tarUnarchive(part types.Part) ([]types.Part, error) {
 
 		newPart := part.Copy()
 		newPart.Set(newPartBuf.Bytes())
-		newPart.Metadata().Set("archive_filename", h.Name)
+		setArchiveFileMetadata(newPart, h.FileInfo(), h.Name)
 		newParts = append(newParts, newPart)
 	}
 
@@ -149,6 +180,9 @@ func zipUnarchive(part types.Part) ([]types.Part, error) {
 
 	// Iterate through the files in the archive.
 	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
 		fr, err := f.Open()
 		if err != nil {
 			return nil, err
@@ -161,7 +195,7 @@ func zipUnarchive(part types.Part) ([]types.Part, error) {
 
 		newPart := part.Copy()
 		newPart.Set(newPartBuf.Bytes())
-		newPart.Metadata().Set("archive_filename", f.Name)
+		setArchiveFileMetadata(newPart, f.FileInfo(), f.Name)
 		newParts = append(newParts, newPart)
 	}
 
@@ -265,6 +299,8 @@ func strToUnarchiver(str string) (unarchiveFunc, error) {
 	switch str {
 	case "tar":
 		return tarUnarchive, nil
+	case "tar.zst":
+		return tarZstdUnarchive, nil
 	case "zip":
 		return zipUnarchive, nil
 	case "binary":
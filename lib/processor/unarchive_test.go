@@ -8,10 +8,13 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUnarchiveBadAlgo(t *testing.T) {
@@ -458,3 +461,91 @@ func TestUnarchiveIndexBounds(t *testing.T) {
 		}
 	}
 }
+
+func TestUnarchiveFileMetadata(t *testing.T) {
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "foo/",
+		Typeflag: tar.TypeDir,
+		Mode:     0755,
+		ModTime:  mtime,
+	}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:    "foo/bar.txt",
+		Mode:    0640,
+		Size:    5,
+		ModTime: mtime,
+	}))
+	_, err := tw.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	_, err = zw.CreateHeader(&zip.FileHeader{Name: "foo/"})
+	require.NoError(t, err)
+	zh := &zip.FileHeader{Name: "foo/bar.txt", Modified: mtime}
+	zh.SetMode(0640)
+	w, err := zw.CreateHeader(zh)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	tarZst, err := zstdCompress(-1, tarBuf.Bytes())
+	require.NoError(t, err)
+
+	for format, input := range map[string][]byte{
+		"tar":     tarBuf.Bytes(),
+		"tar.zst": tarZst,
+		"zip":     zipBuf.Bytes(),
+	} {
+		conf := NewConfig()
+		conf.Unarchive.Format = format
+
+		proc, err := NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		msgs, res := proc.ProcessMessage(message.New([][]byte{input}))
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+		require.Equal(t, 1, msgs[0].Len(), format)
+
+		part := msgs[0].Get(0)
+		assert.Equal(t, "hello", string(part.Get()), format)
+		assert.Equal(t, "foo/bar.txt", part.Metadata().Get("archive_filename"), format)
+		assert.Equal(t, "0640", part.Metadata().Get("archive_mode"), format)
+		assert.Equal(t, "2020-01-02T03:04:05Z", part.Metadata().Get("archive_mtime"), format)
+	}
+}
+
+func TestArchiveUnarchiveTarZstd(t *testing.T) {
+	conf := NewConfig()
+	conf.Archive.Format = "tar.zst"
+	conf.Archive.Path = `${! json("id") }.json`
+	archive, err := NewArchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conf.Unarchive.Format = "tar.zst"
+	unarchive, err := NewUnarchive(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	input := [][]byte{[]byte(`{"id":"foo"}`), []byte(`{"id":"bar"}`)}
+	msgs, res := archive.ProcessMessage(message.New(input))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+
+	msgs, res = unarchive.ProcessMessage(msgs[0])
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, input, message.GetAllBytes(msgs[0]))
+	assert.Equal(t, "foo.json", msgs[0].Get(0).Metadata().Get("archive_filename"))
+	assert.Equal(t, "bar.json", msgs[0].Get(1).Metadata().Get("archive_filename"))
+
+	msgs, _ = unarchive.ProcessMessage(message.New([][]byte{[]byte("not zstd")}))
+	assert.True(t, HasFailed(msgs[0].Get(0)))
+}
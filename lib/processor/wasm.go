@@ -0,0 +1,447 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/opentracing/opentracing-go"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeWASM] = TypeSpec{
+		constructor: NewWASM,
+		Categories: []Category{
+			CategoryMapping,
+			CategoryUtility,
+		},
+		Summary: `
+Executes a function exported by a WebAssembly module for each message, where the
+result of the function replaces the contents of the message.`,
+		Beta: true,
+		Description: `
+This processor allows processing logic to be written in any language that
+compiles to WebAssembly, such as Rust or TinyGo, and to be deployed without
+rebuilding Benthos. Modules are executed with a runtime implemented in pure Go
+and therefore do not require cgo. Modules may also import the
+[WASI](https://wasi.dev/) preview 1 functions, which are provided without access
+to the filesystem or environment.
+
+The module file is checked for changes periodically, and when it is modified
+the new module is loaded and swapped in without interrupting the pipeline. Any
+state held by the previous module is lost when it is swapped.
+
+### ABI
+
+The module must export a memory along with the following functions, where
+pointers and lengths are offsets and sizes within that memory:
+
+- ` + "`allocate(size: i32) -> i32`" + ` is called in order to obtain a
+  pointer to a buffer of a given size, where the contents of a message are then
+  written.
+- ` + "`process(ptr: i32, len: i32) -> i64`" + ` is called with the contents of
+  a message and returns the pointer to the result in the upper 32 bits and its
+  length in the lower 32 bits.
+- ` + "`deallocate(ptr: i32, len: i32)`" + ` is optional, and when exported it
+  is called with the input buffer and the result buffer once the result has been
+  read.
+
+A message can be flagged as failed by calling the function
+` + "`set_error(ptr: i32, len: i32)`" + ` imported from the module
+` + "`benthos`" + ` with the error message, in which case the result of
+` + "`process`" + ` is ignored and the message is left unchanged. Failed
+messages, including those where the module traps, can be handled with
+[error handling patterns](/docs/configuration/error_handling).
+
+Module instances are not shared across threads, and therefore calls to a
+module are processed one at a time for each processor.
+
+For example, a Rust module built with
+` + "`cargo build --target wasm32-unknown-unknown --release`" + ` that
+converts messages to uppercase might look like this:
+
+` + "```rust" + `
+#[no_mangle]
+pub extern "C" fn allocate(size: u32) -> *mut u8 {
+    let mut buf = Vec::with_capacity(size as usize);
+    let ptr = buf.as_mut_ptr();
+    std::mem::forget(buf);
+    ptr
+}
+
+#[no_mangle]
+pub extern "C" fn deallocate(ptr: *mut u8, size: u32) {
+    unsafe { drop(Vec::from_raw_parts(ptr, 0, size as usize)) }
+}
+
+#[no_mangle]
+pub extern "C" fn process(ptr: *mut u8, len: u32) -> u64 {
+    let input = unsafe { std::slice::from_raw_parts(ptr, len as usize) };
+    let mut output = input.to_ascii_uppercase().into_boxed_slice();
+    let (out_ptr, out_len) = (output.as_mut_ptr(), output.len());
+    std::mem::forget(output);
+    ((out_ptr as u64) << 32) | out_len as u64
+}
+` + "```" + `
+
+## Metrics
+
+The number of times the module has been swapped is counted with the metric
+` + "`reload`" + `, and failed attempts to load a modified module are counted
+with the metric ` + "`reload.error`" + `.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("module_path", "The path of a WebAssembly module file."),
+			docs.FieldAdvanced("reload_interval", "How often to check the module file for changes, set to an empty string in order to disable reloading."),
+			partsFieldSpec,
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Custom Transformation",
+				Summary: `
+Here messages are processed with a module built from the Rust example above,
+and any messages that fail are logged and then dropped:`,
+				Config: `
+pipeline:
+  processors:
+    - wasm:
+        module_path: ./target/wasm32-unknown-unknown/release/uppercase.wasm
+    - catch:
+        - log:
+            message: 'Module failed: ${! error() }'
+        - bloblang: root = deleted()
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// WASMConfig contains configuration fields for the WASM processor.
+type WASMConfig struct {
+	ModulePath     string `json:"module_path" yaml:"module_path"`
+	ReloadInterval string `json:"reload_interval" yaml:"reload_interval"`
+	Parts          []int  `json:"parts" yaml:"parts"`
+}
+
+// NewWASMConfig returns a WASMConfig with default values.
+func NewWASMConfig() WASMConfig {
+	return WASMConfig{
+		ModulePath:     "",
+		ReloadInterval: "1m",
+		Parts:          []int{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// wasmModule is an instance of a WebAssembly module along with the runtime it
+// was instantiated within.
+type wasmModule struct {
+	runtime wazero.Runtime
+	mod     api.Module
+
+	allocate   api.Function
+	process    api.Function
+	deallocate api.Function
+
+	failed  bool
+	failure string
+}
+
+func newWASMModule(ctx context.Context, source []byte) (*wasmModule, error) {
+	m := &wasmModule{
+		runtime: wazero.NewRuntime(ctx),
+	}
+
+	err := m.init(ctx, source)
+	if err != nil {
+		m.runtime.Close(ctx)
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *wasmModule) init(ctx context.Context, source []byte) error {
+	_, err := m.runtime.NewHostModuleBuilder("benthos").
+		NewFunctionBuilder().
+		WithFunc(m.setError).
+		Export("set_error").
+		Instantiate(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate host module: %v", err)
+	}
+	if _, err = wasi_snapshot_preview1.Instantiate(ctx, m.runtime); err != nil {
+		return fmt.Errorf("failed to instantiate wasi module: %v", err)
+	}
+
+	if m.mod, err = m.runtime.Instantiate(ctx, source); err != nil {
+		return fmt.Errorf("failed to instantiate module: %v", err)
+	}
+	if len(m.mod.ExportedMemoryDefinitions()) == 0 {
+		return errors.New("module does not export a memory")
+	}
+	if m.allocate = m.mod.ExportedFunction("allocate"); m.allocate == nil {
+		return errors.New("module does not export function: allocate")
+	}
+	if m.process = m.mod.ExportedFunction("process"); m.process == nil {
+		return errors.New("module does not export function: process")
+	}
+	m.deallocate = m.mod.ExportedFunction("deallocate")
+	return nil
+}
+
+func (m *wasmModule) setError(ctx context.Context, mod api.Module, ptr, length uint32) {
+	m.failed = true
+	msgBytes, ok := mod.Memory().Read(ptr, length)
+	if !ok {
+		m.failure = "failed to read error message: out of range"
+		return
+	}
+	m.failure = string(msgBytes)
+}
+
+// call applies the process function of the module to the contents of a
+// message and returns the result.
+func (m *wasmModule) call(ctx context.Context, content []byte) ([]byte, error) {
+	m.failed, m.failure = false, ""
+
+	res, err := m.allocate.Call(ctx, uint64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate memory: %v", err)
+	}
+	inPtr, inLen := uint32(res[0]), uint32(len(content))
+	if !m.mod.Memory().Write(inPtr, content) {
+		return nil, errors.New("failed to write message: allocated memory out of range")
+	}
+
+	if res, err = m.process.Call(ctx, uint64(inPtr), uint64(inLen)); err != nil {
+		return nil, err
+	}
+	outPtr, outLen := uint32(res[0]>>32), uint32(res[0])
+
+	var result []byte
+	if !m.failed {
+		resBytes, ok := m.mod.Memory().Read(outPtr, outLen)
+		if !ok {
+			return nil, errors.New("failed to read result: out of range")
+		}
+		// Memory reads are views of the module memory, which can be modified
+		// by subsequent calls.
+		result = append([]byte{}, resBytes...)
+	}
+
+	if m.deallocate != nil {
+		if _, err = m.deallocate.Call(ctx, uint64(inPtr), uint64(inLen)); err != nil {
+			return nil, fmt.Errorf("failed to deallocate memory: %v", err)
+		}
+		if !m.failed && outLen > 0 && outPtr != inPtr {
+			if _, err = m.deallocate.Call(ctx, uint64(outPtr), uint64(outLen)); err != nil {
+				return nil, fmt.Errorf("failed to deallocate memory: %v", err)
+			}
+		}
+	}
+
+	if m.failed {
+		return nil, errors.New(m.failure)
+	}
+	return result, nil
+}
+
+func (m *wasmModule) close(ctx context.Context) {
+	m.runtime.Close(ctx)
+}
+
+//------------------------------------------------------------------------------
+
+// WASM is a processor that executes a WebAssembly module for each message.
+type WASM struct {
+	parts []int
+	file  string
+
+	modMux  sync.Mutex
+	mod     *wasmModule
+	modTime time.Time
+
+	log log.Modular
+
+	mCount     metrics.StatCounter
+	mReload    metrics.StatCounter
+	mReloadErr metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewWASM returns a WASM processor.
+func NewWASM(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	if conf.WASM.ModulePath == "" {
+		return nil, errors.New("a module_path must be specified")
+	}
+
+	var reloadInterval time.Duration
+	if conf.WASM.ReloadInterval != "" {
+		var err error
+		if reloadInterval, err = time.ParseDuration(conf.WASM.ReloadInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse reload_interval: %v", err)
+		}
+	}
+
+	w := &WASM{
+		parts: conf.WASM.Parts,
+		file:  conf.WASM.ModulePath,
+
+		log: log,
+
+		mCount:     stats.GetCounter("count"),
+		mReload:    stats.GetCounter("reload"),
+		mReloadErr: stats.GetCounter("reload.error"),
+		mErr:       stats.GetCounter("error"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+
+	if _, err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	go w.loop(reloadInterval)
+	return w, nil
+}
+
+//------------------------------------------------------------------------------
+
+// reload loads the module file when it has been modified since it was last
+// loaded, and returns true if the module was replaced.
+func (w *WASM) reload() (bool, error) {
+	info, err := os.Stat(w.file)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat module file: %v", err)
+	}
+
+	w.modMux.Lock()
+	unchanged := w.mod != nil && info.ModTime().Equal(w.modTime)
+	w.modMux.Unlock()
+	if unchanged {
+		return false, nil
+	}
+
+	source, err := ioutil.ReadFile(w.file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read module file: %v", err)
+	}
+
+	mod, err := newWASMModule(context.Background(), source)
+	if err != nil {
+		return false, err
+	}
+
+	w.modMux.Lock()
+	prev := w.mod
+	w.mod, w.modTime = mod, info.ModTime()
+	w.modMux.Unlock()
+
+	if prev != nil {
+		prev.close(context.Background())
+	}
+	return true, nil
+}
+
+func (w *WASM) loop(reloadInterval time.Duration) {
+	defer func() {
+		w.modMux.Lock()
+		w.mod.close(context.Background())
+		w.modMux.Unlock()
+		close(w.closedChan)
+	}()
+	if reloadInterval <= 0 {
+		<-w.closeChan
+		return
+	}
+
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			reloaded, err := w.reload()
+			if err != nil {
+				w.mReloadErr.Incr(1)
+				w.log.Errorf("Failed to reload module: %v\n", err)
+			} else if reloaded {
+				w.mReload.Incr(1)
+				w.log.Infof("Reloaded module file: %v\n", w.file)
+			}
+		case <-w.closeChan:
+			return
+		}
+	}
+}
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (w *WASM) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	w.mCount.Incr(1)
+	newMsg := msg.Copy()
+
+	proc := func(index int, span opentracing.Span, part types.Part) error {
+		w.modMux.Lock()
+		result, err := w.mod.call(context.Background(), part.Get())
+		w.modMux.Unlock()
+		if err != nil {
+			w.mErr.Incr(1)
+			w.log.Debugf("Failed to process message: %v\n", err)
+			return err
+		}
+		part.Set(result)
+		return nil
+	}
+
+	IteratePartsWithSpan(TypeWASM, w.parts, newMsg, proc)
+
+	w.mBatchSent.Incr(1)
+	w.mSent.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (w *WASM) CloseAsync() {
+	w.closeOnce.Do(func() {
+		close(w.closeChan)
+	})
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (w *WASM) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	case <-w.closedChan:
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,250 @@
+package processor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func wasmULEB(v uint32) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		if v >>= 7; v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+func wasmSLEB(v int32) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func wasmConcat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func wasmVec(items ...[]byte) []byte {
+	return wasmConcat(wasmULEB(uint32(len(items))), wasmConcat(items...))
+}
+
+func wasmSized(content []byte) []byte {
+	return wasmConcat(wasmULEB(uint32(len(content))), content)
+}
+
+func wasmName(name string) []byte {
+	return wasmSized([]byte(name))
+}
+
+// testWASMModule assembles a module that implements the processor ABI with a
+// bump allocator, and where process adds delta to each byte within the range
+// [from, from+25] in place. Messages beginning with '!' are failed with their
+// contents as the error.
+func testWASMModule(from, delta int32) []byte {
+	allocateBody := []byte{
+		0x23, 0x00, // global.get 0
+		0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, // global.set 0 (global.get 0 + local.get 0)
+		0x0b,
+	}
+
+	processBody := wasmConcat(
+		[]byte{
+			0x20, 0x01, 0x04, 0x40, // if len != 0
+			0x20, 0x00, 0x2d, 0x00, 0x00, 0x41, 0x21, 0x46, // i32.load8_u ptr == '!'
+			0x04, 0x40, // if
+			0x20, 0x00, 0x20, 0x01, 0x10, 0x00, // call set_error(ptr, len)
+			0x42, 0x00, 0x0f, // return 0
+			0x0b, 0x0b,
+			0x02, 0x40, 0x03, 0x40, // block loop
+			0x20, 0x02, 0x20, 0x01, 0x4f, 0x0d, 0x01, // br_if i >= len
+			0x20, 0x00, 0x20, 0x02, 0x6a, 0x2d, 0x00, 0x00, 0x21, 0x03, // b = load8_u(ptr + i)
+			0x20, 0x03, 0x41,
+		},
+		wasmSLEB(from),
+		[]byte{
+			0x6b, 0x41, 0x19, 0x4d, 0x04, 0x40, // if b - from <= 25
+			0x20, 0x00, 0x20, 0x02, 0x6a, 0x20, 0x03, 0x41,
+		},
+		wasmSLEB(-delta),
+		[]byte{
+			0x6b, 0x3a, 0x00, 0x00, // store8(ptr + i, b - -delta)
+			0x0b,
+			0x20, 0x02, 0x41, 0x01, 0x6a, 0x21, 0x02, // i++
+			0x0c, 0x00, // br loop
+			0x0b, 0x0b,
+			0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, // ptr << 32 | len
+			0x0b,
+		},
+	)
+
+	return wasmConcat(
+		[]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		[]byte{0x01}, wasmSized(wasmVec(
+			[]byte{0x60, 0x01, 0x7f, 0x01, 0x7f},       // (i32) -> i32
+			[]byte{0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e}, // (i32, i32) -> i64
+			[]byte{0x60, 0x02, 0x7f, 0x7f, 0x00},       // (i32, i32) -> ()
+		)),
+		[]byte{0x02}, wasmSized(wasmVec(
+			wasmConcat(wasmName("benthos"), wasmName("set_error"), []byte{0x00, 0x02}),
+		)),
+		[]byte{0x03}, wasmSized(wasmVec([]byte{0x00}, []byte{0x01})),
+		[]byte{0x05}, wasmSized(wasmVec([]byte{0x00, 0x01})),
+		[]byte{0x06}, wasmSized(wasmVec(
+			wasmConcat([]byte{0x7f, 0x01, 0x41}, wasmSLEB(1024), []byte{0x0b}),
+		)),
+		[]byte{0x07}, wasmSized(wasmVec(
+			wasmConcat(wasmName("memory"), []byte{0x02, 0x00}),
+			wasmConcat(wasmName("allocate"), []byte{0x00, 0x01}),
+			wasmConcat(wasmName("process"), []byte{0x00, 0x02}),
+		)),
+		[]byte{0x0a}, wasmSized(wasmVec(
+			wasmSized(wasmConcat([]byte{0x00}, allocateBody)),
+			wasmSized(wasmConcat([]byte{0x01, 0x02, 0x7f}, processBody)),
+		)),
+	)
+}
+
+func writeTestWASMModule(t *testing.T, path string, source []byte, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, ioutil.WriteFile(path, source, 0644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func newTestWASM(t *testing.T, source []byte) (*WASM, string) {
+	t.Helper()
+
+	tmpDir, err := ioutil.TempDir("", "benthos_wasm_test")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	path := filepath.Join(tmpDir, "test.wasm")
+	writeTestWASMModule(t, path, source, time.Now())
+
+	conf := NewConfig()
+	conf.Type = TypeWASM
+	conf.WASM.ModulePath = path
+	conf.WASM.ReloadInterval = ""
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		proc.CloseAsync()
+		assert.NoError(t, proc.WaitForClose(time.Second))
+	})
+	return proc.(*WASM), path
+}
+
+func TestWASMBasic(t *testing.T) {
+	proc, _ := newTestWASM(t, testWASMModule('a', -32))
+
+	input := message.New([][]byte{
+		[]byte("hello world"), []byte(""), []byte("!oops"), []byte("Foo Bar"),
+	})
+	msgs, res := proc.ProcessMessage(input)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{
+		[]byte("HELLO WORLD"), []byte(""), []byte("!oops"), []byte("FOO BAR"),
+	}, message.GetAllBytes(msgs[0]))
+	assert.False(t, HasFailed(msgs[0].Get(0)))
+	assert.Equal(t, "!oops", GetFail(msgs[0].Get(2)))
+	assert.False(t, HasFailed(msgs[0].Get(3)))
+
+	assert.Equal(t, "hello world", string(input.Get(0).Get()))
+}
+
+func TestWASMOutOfRange(t *testing.T) {
+	proc, _ := newTestWASM(t, testWASMModule('a', -32))
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		make([]byte, 70000), []byte("foo"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "failed to write message: allocated memory out of range", GetFail(msgs[0].Get(0)))
+	assert.True(t, HasFailed(msgs[0].Get(1)))
+}
+
+func TestWASMReload(t *testing.T) {
+	proc, path := newTestWASM(t, testWASMModule('a', -32))
+
+	msgs, _ := proc.ProcessMessage(message.New([][]byte{[]byte("Foo Bar")}))
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "FOO BAR", string(msgs[0].Get(0).Get()))
+
+	reloaded, err := proc.reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+
+	writeTestWASMModule(t, path, testWASMModule('A', 32), time.Now().Add(time.Minute))
+	reloaded, err = proc.reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+
+	msgs, _ = proc.ProcessMessage(message.New([][]byte{[]byte("Foo Bar")}))
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "foo bar", string(msgs[0].Get(0).Get()))
+
+	writeTestWASMModule(t, path, []byte("not a module"), time.Now().Add(time.Hour))
+	_, err = proc.reload()
+	require.Error(t, err)
+
+	msgs, _ = proc.ProcessMessage(message.New([][]byte{[]byte("Foo Bar")}))
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "foo bar", string(msgs[0].Get(0).Get()))
+}
+
+func TestWASMBadConfig(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "benthos_wasm_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	emptyPath := filepath.Join(tmpDir, "empty.wasm")
+	writeTestWASMModule(t, emptyPath, []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}, time.Now())
+
+	conf := NewConfig()
+	conf.Type = TypeWASM
+
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "a module_path must be specified")
+
+	conf.WASM.ModulePath = filepath.Join(tmpDir, "missing.wasm")
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to stat module file: ")
+
+	conf.WASM.ModulePath = emptyPath
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, "module does not export a memory")
+
+	conf.WASM.ReloadInterval = "nope"
+	_, err = New(conf, nil, log.Noop(), metrics.Noop())
+	assert.EqualError(t, err, `failed to parse reload_interval: time: invalid duration "nope"`)
+}
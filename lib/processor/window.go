@@ -0,0 +1,539 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/bloblang"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/field"
+	"github.com/Jeffail/benthos/v3/internal/bloblang/mapping"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors[TypeWindow] = TypeSpec{
+		constructor: NewWindow,
+		Categories: []Category{
+			CategoryComposition,
+		},
+		Summary: `
+Groups messages into tumbling, sliding or session windows of time, and emits
+each window as a batch, or an aggregate of the batch, once it closes.`,
+		Beta: true,
+		Description: `
+Each message is assigned a timestamp from ` + "`timestamp`" + `, which
+must resolve to either an RFC 3339 timestamp or a number of seconds since the
+unix epoch. When ` + "`timestamp`" + ` is empty the time at which the message
+is processed is used instead. Messages are grouped by the result of
+` + "`key`" + `, with each key having its own windows.
+
+The ` + "`type`" + ` of window determines which windows a message belongs to:
+
+- ` + "`tumbling`" + ` windows are consecutive and of a fixed ` + "`size`" + `,
+  and each message belongs to exactly one of them.
+- ` + "`sliding`" + ` windows are of a fixed ` + "`size`" + ` and start every
+  ` + "`slide`" + `, and so a message can belong to several of them.
+- ` + "`session`" + ` windows are as long as messages of the same key keep
+  arriving less than ` + "`gap`" + ` apart, and close after a period of
+  ` + "`gap`" + ` without any.
+
+Windows of tumbling and sliding types are aligned with the unix epoch, and so a
+tumbling window of size ` + "`1h`" + ` always starts on the hour.
+
+## Watermarks
+
+Windows close according to a watermark, which is the highest timestamp seen so
+far minus ` + "`watermark_delay`" + `. A window is emitted once the watermark
+passes its end, and so the watermark delay is the amount of time to wait for
+messages that arrive out of order.
+
+Windows are kept for ` + "`allowed_lateness`" + ` after they are emitted, and a
+message that arrives within that period is added to its window, which is
+emitted again with the metadata field ` + "`window_late`" + ` set to
+` + "`true`" + `. Messages that arrive later than that are dropped.
+
+Since the watermark only advances as messages are processed, windows are not
+emitted while the input is idle.
+
+## Emitted Windows
+
+Each window is emitted as a batch of its messages with the following metadata
+fields added to each message:
+
+- window_key
+- window_start
+- window_end
+- window_late
+
+When an ` + "`aggregate`" + ` mapping is set it is executed against the batch
+of each window in order to reduce it to a single message. Functions such as
+` + "[`from_all`](/docs/guides/bloblang/methods#from_all)" + ` and
+` + "[`batch_size`](/docs/guides/bloblang/functions#batch_size)" + ` can be
+used in order to aggregate the contents of all messages of the window.
+
+## Delivery Guarantees
+
+Messages are held in memory until their windows are emitted, and are therefore
+lost if Benthos stops before then. Memory usage grows with the number of keys
+and with the rate of messages within each window.`,
+		FieldSpecs: docs.FieldSpecs{
+			docs.FieldCommon("type", "The type of window.").HasOptions("tumbling", "sliding", "session"),
+			docs.FieldCommon("timestamp", "An optional timestamp for each message, which must resolve to either an RFC 3339 timestamp or a number of seconds since the unix epoch. When empty the time at which the message is processed is used.", `${! json("created_at") }`, `${! meta("kafka_timestamp_unix") }`).SupportsInterpolation(false),
+			docs.FieldCommon("key", "An optional key to group messages by, with each key having its own windows.", `${! json("user_id") }`).SupportsInterpolation(false),
+			docs.FieldCommon("size", "The size of `tumbling` and `sliding` windows.", "1m", "1h"),
+			docs.FieldCommon("slide", "The period after which a new `sliding` window starts.", "10s", "5m"),
+			docs.FieldCommon("gap", "The period of inactivity after which a `session` window closes.", "30s", "10m"),
+			docs.FieldAdvanced("watermark_delay", "An optional period by which the watermark trails the highest timestamp seen, which is the amount of time to wait for messages that arrive out of order before emitting a window.", "10s"),
+			docs.FieldAdvanced("allowed_lateness", "An optional period after a window is emitted during which messages that arrive late are still added to the window, causing it to be emitted again.", "1m"),
+			docs.FieldCommon(
+				"aggregate",
+				"An optional [Bloblang mapping](/docs/guides/bloblang/about) that reduces the batch of each window to a single message.",
+				`root.count = batch_size()
+root.total = json("amount").from_all().sum()`,
+			),
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title: "Counting Page Views",
+				Summary: `
+Page views can be counted per page over windows of one minute, where views are
+allowed to arrive up to ten seconds out of order:`,
+				Config: `
+pipeline:
+  processors:
+    - window:
+        type: tumbling
+        timestamp: ${! json("viewed_at") }
+        key: ${! json("page") }
+        size: 1m
+        watermark_delay: 10s
+        aggregate: |
+          root.page = meta("window_key")
+          root.window_start = meta("window_start")
+          root.views = batch_size()
+`,
+			},
+			{
+				Title: "User Sessions",
+				Summary: `
+The events of each user can be grouped into sessions that end after thirty
+minutes of inactivity:`,
+				Config: `
+pipeline:
+  processors:
+    - window:
+        type: session
+        timestamp: ${! json("timestamp") }
+        key: ${! json("user_id") }
+        gap: 30m
+        aggregate: |
+          root.user_id = meta("window_key")
+          root.started_at = meta("window_start")
+          root.events = json("event").from_all()
+`,
+			},
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// WindowConfig contains configuration fields for the Window processor.
+type WindowConfig struct {
+	Type            string `json:"type" yaml:"type"`
+	Timestamp       string `json:"timestamp" yaml:"timestamp"`
+	Key             string `json:"key" yaml:"key"`
+	Size            string `json:"size" yaml:"size"`
+	Slide           string `json:"slide" yaml:"slide"`
+	Gap             string `json:"gap" yaml:"gap"`
+	WatermarkDelay  string `json:"watermark_delay" yaml:"watermark_delay"`
+	AllowedLateness string `json:"allowed_lateness" yaml:"allowed_lateness"`
+	Aggregate       string `json:"aggregate" yaml:"aggregate"`
+}
+
+// NewWindowConfig returns a WindowConfig with default values.
+func NewWindowConfig() WindowConfig {
+	return WindowConfig{
+		Type:            "tumbling",
+		Timestamp:       "",
+		Key:             "",
+		Size:            "1m",
+		Slide:           "",
+		Gap:             "",
+		WatermarkDelay:  "",
+		AllowedLateness: "",
+		Aggregate:       "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type windowState struct {
+	key   string
+	start time.Time
+	end   time.Time
+	parts []types.Part
+
+	// Whether the window has been emitted before, and whether it has parts
+	// that have not yet been emitted.
+	emitted bool
+	pending bool
+}
+
+// Window is a processor that groups messages into windows of time.
+type Window struct {
+	timestamp field.Expression
+	key       field.Expression
+	aggregate *mapping.Executor
+
+	session  bool
+	size     time.Duration
+	slide    time.Duration
+	gap      time.Duration
+	delay    time.Duration
+	lateness time.Duration
+
+	windows   map[string][]*windowState
+	watermark time.Time
+	now       func() time.Time
+	mut       sync.Mutex
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mLate      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mBatchSent metrics.StatCounter
+	mOpen      metrics.StatGauge
+}
+
+// NewWindow returns a Window processor.
+func NewWindow(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	w := &Window{
+		windows: map[string][]*windowState{},
+		now:     time.Now,
+
+		log:   log,
+		stats: stats,
+
+		mCount:     stats.GetCounter("count"),
+		mErr:       stats.GetCounter("error"),
+		mLate:      stats.GetCounter("late"),
+		mSent:      stats.GetCounter("sent"),
+		mBatchSent: stats.GetCounter("batch.sent"),
+		mOpen:      stats.GetGauge("windows.open"),
+	}
+
+	var err error
+	for _, d := range []struct {
+		name  string
+		value string
+		dur   *time.Duration
+	}{
+		{"size", conf.Window.Size, &w.size},
+		{"slide", conf.Window.Slide, &w.slide},
+		{"gap", conf.Window.Gap, &w.gap},
+		{"watermark_delay", conf.Window.WatermarkDelay, &w.delay},
+		{"allowed_lateness", conf.Window.AllowedLateness, &w.lateness},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if *d.dur, err = time.ParseDuration(d.value); err != nil {
+			return nil, fmt.Errorf("failed to parse %v: %v", d.name, err)
+		}
+	}
+
+	switch conf.Window.Type {
+	case "tumbling":
+		if w.size <= 0 {
+			return nil, fmt.Errorf("a size must be specified for %v windows", conf.Window.Type)
+		}
+		w.slide = w.size
+	case "sliding":
+		if w.size <= 0 || w.slide <= 0 {
+			return nil, fmt.Errorf("a size and slide must be specified for %v windows", conf.Window.Type)
+		}
+	case "session":
+		if w.gap <= 0 {
+			return nil, fmt.Errorf("a gap must be specified for %v windows", conf.Window.Type)
+		}
+		w.session = true
+	default:
+		return nil, fmt.Errorf("window type not recognised: %v", conf.Window.Type)
+	}
+
+	if len(conf.Window.Timestamp) > 0 {
+		if w.timestamp, err = bloblang.NewField(conf.Window.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp expression: %v", err)
+		}
+	}
+	if w.key, err = bloblang.NewField(conf.Window.Key); err != nil {
+		return nil, fmt.Errorf("failed to parse key expression: %v", err)
+	}
+	if len(conf.Window.Aggregate) > 0 {
+		if w.aggregate, err = bloblang.NewMapping("", conf.Window.Aggregate); err != nil {
+			return nil, fmt.Errorf("failed to parse aggregate mapping: %w", err)
+		}
+	}
+	return w, nil
+}
+
+//------------------------------------------------------------------------------
+
+func parseWindowTimestamp(str string) (time.Time, error) {
+	if f, err := strconv.ParseFloat(str, 64); err == nil {
+		secs, frac := math.Modf(f)
+		return time.Unix(int64(secs), int64(frac*1e9)), nil
+	}
+	return time.Parse(time.RFC3339Nano, str)
+}
+
+// alignWindow returns the start of the latest window that starts at or before
+// t, where windows start every period from the unix epoch.
+func alignWindow(t time.Time, period time.Duration) time.Time {
+	nanos := t.UnixNano()
+	offset := nanos % int64(period)
+	if offset < 0 {
+		offset += int64(period)
+	}
+	return time.Unix(0, nanos-offset)
+}
+
+// isLate returns whether a window ending at end has already been discarded.
+func (w *Window) isLate(end time.Time) bool {
+	return !end.Add(w.lateness).After(w.watermark)
+}
+
+// add adds a part to the windows of its key that contain its timestamp,
+// returning false if they have all been discarded.
+func (w *Window) add(key string, ts time.Time, part types.Part) bool {
+	if w.session {
+		return w.addSession(key, ts, part)
+	}
+
+	added := false
+	for start := alignWindow(ts, w.slide); start.Add(w.size).After(ts); start = start.Add(-w.slide) {
+		end := start.Add(w.size)
+		if w.isLate(end) {
+			continue
+		}
+		var win *windowState
+		for _, existing := range w.windows[key] {
+			if existing.start.Equal(start) {
+				win = existing
+				break
+			}
+		}
+		if win == nil {
+			win = &windowState{key: key, start: start, end: end}
+			w.windows[key] = append(w.windows[key], win)
+		}
+		win.parts = append(win.parts, part)
+		win.pending = true
+		added = true
+	}
+	return added
+}
+
+// addSession adds a part to the session of its key that contains its
+// timestamp, merging any sessions that the part bridges.
+func (w *Window) addSession(key string, ts time.Time, part types.Part) bool {
+	merged := &windowState{
+		key:     key,
+		start:   ts,
+		end:     ts.Add(w.gap),
+		pending: true,
+	}
+	if w.isLate(merged.end) {
+		return false
+	}
+
+	remaining := w.windows[key][:0]
+	for _, win := range w.windows[key] {
+		if win.end.Before(merged.start) || merged.end.Before(win.start) {
+			remaining = append(remaining, win)
+			continue
+		}
+		if win.start.Before(merged.start) {
+			merged.start = win.start
+		}
+		if win.end.After(merged.end) {
+			merged.end = win.end
+		}
+		merged.parts = append(merged.parts, win.parts...)
+		merged.emitted = merged.emitted || win.emitted
+	}
+	merged.parts = append(merged.parts, part)
+	w.windows[key] = append(remaining, merged)
+	return true
+}
+
+// flush returns the windows that have closed and have pending parts, and
+// discards windows that are beyond the allowed lateness.
+func (w *Window) flush() []*windowState {
+	var closed []*windowState
+	for key, wins := range w.windows {
+		remaining := wins[:0]
+		for _, win := range wins {
+			if win.pending && !win.end.After(w.watermark) {
+				closed = append(closed, win)
+			}
+			if !w.isLate(win.end) {
+				remaining = append(remaining, win)
+			}
+		}
+		if len(remaining) == 0 {
+			delete(w.windows, key)
+		} else {
+			w.windows[key] = remaining
+		}
+	}
+
+	sort.Slice(closed, func(i, j int) bool {
+		if !closed[i].end.Equal(closed[j].end) {
+			return closed[i].end.Before(closed[j].end)
+		}
+		if !closed[i].start.Equal(closed[j].start) {
+			return closed[i].start.Before(closed[j].start)
+		}
+		return closed[i].key < closed[j].key
+	})
+	return closed
+}
+
+// emit creates a message from a closed window, which is nil if the aggregate
+// mapping deleted it.
+func (w *Window) emit(win *windowState) types.Message {
+	msg := message.New(nil)
+	for _, p := range win.parts {
+		p = p.Copy()
+		meta := p.Metadata()
+		meta.Set("window_key", win.key)
+		meta.Set("window_start", win.start.UTC().Format(time.RFC3339Nano))
+		meta.Set("window_end", win.end.UTC().Format(time.RFC3339Nano))
+		meta.Set("window_late", strconv.FormatBool(win.emitted))
+		msg.Append(p)
+	}
+	win.emitted = true
+	win.pending = false
+
+	if w.aggregate == nil {
+		return msg
+	}
+
+	p, err := w.aggregate.MapPart(0, msg)
+	if err != nil {
+		w.mErr.Incr(1)
+		w.log.Errorf("Failed to aggregate window: %v\n", err)
+		msg.Iter(func(i int, part types.Part) error {
+			FlagErr(part, err)
+			return nil
+		})
+		return msg
+	}
+	if p == nil {
+		return nil
+	}
+	aggMsg := message.New(nil)
+	aggMsg.Append(p)
+	return aggMsg
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the processor to a message, either creating >0
+// resulting messages or a response to be sent back to the message source.
+func (w *Window) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	w.mCount.Incr(1)
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	var failed types.Message
+	var held bool
+	var highest time.Time
+
+	msg.Iter(func(i int, part types.Part) error {
+		ts := w.now()
+		if w.timestamp != nil {
+			var err error
+			if ts, err = parseWindowTimestamp(w.timestamp.String(i, msg)); err != nil {
+				w.mErr.Incr(1)
+				w.log.Debugf("Failed to parse message timestamp: %v\n", err)
+				if failed == nil {
+					failed = message.New(nil)
+				}
+				part = part.Copy()
+				FlagErr(part, fmt.Errorf("failed to parse timestamp: %w", err))
+				failed.Append(part)
+				return nil
+			}
+		}
+		if ts.After(highest) {
+			highest = ts
+		}
+		if w.add(w.key.String(i, msg), ts, part.Copy()) {
+			held = true
+		} else {
+			w.mLate.Incr(1)
+		}
+		return nil
+	})
+
+	if watermark := highest.Add(-w.delay); watermark.After(w.watermark) {
+		w.watermark = watermark
+	}
+
+	var msgs []types.Message
+	if failed != nil {
+		msgs = append(msgs, failed)
+	}
+	for _, win := range w.flush() {
+		if m := w.emit(win); m != nil {
+			msgs = append(msgs, m)
+		}
+	}
+	open := 0
+	for _, wins := range w.windows {
+		open += len(wins)
+	}
+	w.mOpen.Set(int64(open))
+
+	if len(msgs) == 0 {
+		if held {
+			return nil, response.NewUnack()
+		}
+		return nil, response.NewAck()
+	}
+	for _, m := range msgs {
+		w.mSent.Incr(int64(m.Len()))
+	}
+	w.mBatchSent.Incr(int64(len(msgs)))
+	return msgs, nil
+}
+
+// CloseAsync shuts down the processor and stops processing requests.
+func (w *Window) CloseAsync() {
+}
+
+// WaitForClose blocks until the processor has closed down.
+func (w *Window) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
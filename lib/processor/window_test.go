@@ -0,0 +1,280 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type windowTestStep struct {
+	input  []string
+	output [][]string
+}
+
+func testWindowSteps(t *testing.T, conf Config, steps []windowTestStep) []types.Message {
+	t.Helper()
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var allMsgs []types.Message
+	for i, step := range steps {
+		input := message.New(nil)
+		for _, s := range step.input {
+			input.Append(message.NewPart([]byte(s)))
+		}
+
+		msgs, res := proc.ProcessMessage(input)
+		if len(step.output) == 0 {
+			require.Len(t, msgs, 0, i)
+			require.NotNil(t, res, i)
+			continue
+		}
+		require.Nil(t, res, i)
+
+		var actual [][]string
+		for _, m := range msgs {
+			var parts []string
+			m.Iter(func(_ int, p types.Part) error {
+				parts = append(parts, string(p.Get()))
+				return nil
+			})
+			actual = append(actual, parts)
+		}
+		assert.Equal(t, step.output, actual, i)
+		allMsgs = append(allMsgs, msgs...)
+	}
+	return allMsgs
+}
+
+func TestWindowTumbling(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeWindow
+	conf.Window.Timestamp = `${! json("ts") }`
+	conf.Window.Size = "10s"
+
+	msgs := testWindowSteps(t, conf, []windowTestStep{
+		{input: []string{`{"ts":1}`, `{"ts":5}`}},
+		{input: []string{`{"ts":9.5}`}},
+		{
+			input:  []string{`{"ts":12}`},
+			output: [][]string{{`{"ts":1}`, `{"ts":5}`, `{"ts":9.5}`}},
+		},
+		{
+			input: []string{`{"ts":"1970-01-01T00:00:35Z"}`},
+			output: [][]string{
+				{`{"ts":12}`},
+			},
+		},
+	})
+
+	meta := msgs[0].Get(0).Metadata()
+	assert.Equal(t, "", meta.Get("window_key"))
+	assert.Equal(t, "1970-01-01T00:00:00Z", meta.Get("window_start"))
+	assert.Equal(t, "1970-01-01T00:00:10Z", meta.Get("window_end"))
+	assert.Equal(t, "false", meta.Get("window_late"))
+
+	meta = msgs[1].Get(0).Metadata()
+	assert.Equal(t, "1970-01-01T00:00:10Z", meta.Get("window_start"))
+	assert.Equal(t, "1970-01-01T00:00:20Z", meta.Get("window_end"))
+}
+
+func TestWindowLateness(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeWindow
+	conf.Window.Timestamp = `${! json("ts") }`
+	conf.Window.Size = "10s"
+	conf.Window.WatermarkDelay = "5s"
+	conf.Window.AllowedLateness = "10s"
+
+	msgs := testWindowSteps(t, conf, []windowTestStep{
+		{input: []string{`{"ts":1}`}},
+		{input: []string{`{"ts":12}`}},
+		{input: []string{`{"ts":3}`}},
+		{
+			input:  []string{`{"ts":16}`},
+			output: [][]string{{`{"ts":1}`, `{"ts":3}`}},
+		},
+		{
+			input:  []string{`{"ts":4}`},
+			output: [][]string{{`{"ts":1}`, `{"ts":3}`, `{"ts":4}`}},
+		},
+		{
+			input:  []string{`{"ts":31}`},
+			output: [][]string{{`{"ts":12}`, `{"ts":16}`}},
+		},
+		{input: []string{`{"ts":5}`}},
+	})
+
+	assert.Equal(t, "false", msgs[0].Get(0).Metadata().Get("window_late"))
+	assert.Equal(t, "true", msgs[1].Get(0).Metadata().Get("window_late"))
+	assert.Equal(t, "false", msgs[2].Get(0).Metadata().Get("window_late"))
+}
+
+func TestWindowLateDropped(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeWindow
+	conf.Window.Timestamp = `${! json("ts") }`
+	conf.Window.Size = "10s"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	_, res := proc.ProcessMessage(message.New([][]byte{[]byte(`{"ts":5}`)}))
+	assert.Equal(t, response.NewUnack(), res)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(`{"ts":25}`)}))
+	assert.Nil(t, res)
+	assert.Len(t, msgs, 1)
+
+	msgs, res = proc.ProcessMessage(message.New([][]byte{[]byte(`{"ts":7}`)}))
+	assert.Len(t, msgs, 0)
+	assert.Equal(t, response.NewAck(), res)
+}
+
+func TestWindowSliding(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeWindow
+	conf.Window.Type = "sliding"
+	conf.Window.Timestamp = `${! json("ts") }`
+	conf.Window.Size = "10s"
+	conf.Window.Slide = "5s"
+
+	testWindowSteps(t, conf, []windowTestStep{
+		{input: []string{`{"ts":7}`}},
+		{
+			input:  []string{`{"ts":12}`},
+			output: [][]string{{`{"ts":7}`}},
+		},
+		{
+			input: []string{`{"ts":21}`},
+			output: [][]string{
+				{`{"ts":7}`, `{"ts":12}`},
+				{`{"ts":12}`},
+			},
+		},
+	})
+}
+
+func TestWindowSessions(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeWindow
+	conf.Window.Type = "session"
+	conf.Window.Timestamp = `${! json("ts") }`
+	conf.Window.Key = `${! json("key") }`
+	conf.Window.Gap = "10s"
+	conf.Window.WatermarkDelay = "15s"
+	conf.Window.Aggregate = `root.key = meta("window_key")
+root.start = meta("window_start")
+root.ts = json("ts").from_all()`
+
+	testWindowSteps(t, conf, []windowTestStep{
+		{input: []string{`{"key":"a","ts":0}`, `{"key":"b","ts":2}`}},
+		{input: []string{`{"key":"a","ts":8}`}},
+		{
+			input: []string{`{"key":"a","ts":35}`},
+			output: [][]string{
+				{`{"key":"b","start":"1970-01-01T00:00:02Z","ts":[2]}`},
+				{`{"key":"a","start":"1970-01-01T00:00:00Z","ts":[0,8]}`},
+			},
+		},
+		{input: []string{`{"key":"a","ts":52}`}},
+		{input: []string{`{"key":"a","ts":44}`}},
+		{
+			input: []string{`{"key":"b","ts":80}`},
+			output: [][]string{
+				{`{"key":"a","start":"1970-01-01T00:00:35Z","ts":[35,52,44]}`},
+			},
+		},
+	})
+}
+
+func TestWindowProcessingTime(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeWindow
+	conf.Window.Size = "1m"
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	now := time.Unix(30, 0)
+	proc.(*Window).now = func() time.Time {
+		return now
+	}
+
+	msgs, _ := proc.ProcessMessage(message.New([][]byte{[]byte(`foo`), []byte(`bar`)}))
+	assert.Len(t, msgs, 0)
+
+	now = now.Add(time.Minute)
+	msgs, _ = proc.ProcessMessage(message.New([][]byte{[]byte(`baz`)}))
+	require.Len(t, msgs, 1)
+	assert.Equal(t, [][]byte{[]byte(`foo`), []byte(`bar`)}, message.GetAllBytes(msgs[0]))
+}
+
+func TestWindowBadTimestamp(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeWindow
+	conf.Window.Timestamp = `${! json("ts") }`
+
+	proc, err := New(conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.New([][]byte{
+		[]byte(`{"ts":5}`),
+		[]byte(`{"ts":"nope"}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+
+	assert.Equal(t, `{"ts":"nope"}`, string(msgs[0].Get(0).Get()))
+	assert.Equal(t, `failed to parse timestamp: parsing time "nope" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "nope" as "2006"`, GetFail(msgs[0].Get(0)))
+}
+
+func TestWindowBadConfig(t *testing.T) {
+	tests := map[string]struct {
+		conf func(c *WindowConfig)
+		err  string
+	}{
+		"bad type": {
+			conf: func(c *WindowConfig) {
+				c.Type = "nope"
+			},
+			err: "window type not recognised: nope",
+		},
+		"missing slide": {
+			conf: func(c *WindowConfig) {
+				c.Type = "sliding"
+			},
+			err: "a size and slide must be specified for sliding windows",
+		},
+		"missing gap": {
+			conf: func(c *WindowConfig) {
+				c.Type = "session"
+			},
+			err: "a gap must be specified for session windows",
+		},
+		"bad duration": {
+			conf: func(c *WindowConfig) {
+				c.AllowedLateness = "nope"
+			},
+			err: `failed to parse allowed_lateness: time: invalid duration "nope"`,
+		},
+	}
+
+	for name, test := range tests {
+		conf := NewConfig()
+		conf.Type = TypeWindow
+		test.conf(&conf.Window)
+
+		_, err := New(conf, nil, log.Noop(), metrics.Noop())
+		assert.EqualError(t, err, test.err, name)
+	}
+}
@@ -88,6 +88,10 @@ The previous meta object will also be preserved in the field ` + "`<meta_path>.p
 
 If a field ` + "`<meta_path>.apply`" + ` exists in the meta object for a message and is an array then it will be used as an explicit list of stages to apply, all other stages will be skipped.
 
+### Status Metadata
+
+When the field ` + "`status_meta_prefix`" + ` is non-empty the status of each branch is also added to each message as a metadata field with the name of the branch appended to the prefix, and a value of either ` + "`succeeded`" + `, ` + "`skipped`" + ` or ` + "`failed`" + `. This can be used in order to record the outcome of a workflow for messages that aren't JSON objects, or alongside the structured metadata in order to route messages with [Bloblang queries][guides.bloblang] such as ` + "`meta(\"workflow_foo\") == \"failed\"`" + `.
+
 ## Resources
 
 It's common to configure processors (and other components) as resources in order to keep the pipeline configuration cleaner. With the workflow processor you can include branch processors configured as resources within your workflow by specifying them by name in the field ` + "`order`" + `, if Benthos doesn't find a branch within the workflow configuration of that name it'll refer to the resources.
@@ -232,6 +236,7 @@ resources:
 		},
 		FieldSpecs: docs.FieldSpecs{
 			docs.FieldCommon("meta_path", "A [dot path](/docs/configuration/field_paths) indicating where to store and reference [structured metadata](#structured-metadata) about the workflow execution."),
+			docs.FieldAdvanced("status_meta_prefix", "An optional prefix for metadata fields that record the [status of each branch](#status-metadata) of the workflow execution.", "workflow_"),
 			docs.FieldDeprecated("stages"),
 			docs.FieldCommon(
 				"order",
@@ -254,9 +259,10 @@ resources:
 				sanitBranches[k] = sanit
 			}
 			m := map[string]interface{}{
-				"meta_path": conf.Workflow.MetaPath,
-				"order":     conf.Workflow.Order,
-				"branches":  sanitBranches,
+				"meta_path":          conf.Workflow.MetaPath,
+				"status_meta_prefix": conf.Workflow.StatusMetaPrefix,
+				"order":              conf.Workflow.Order,
+				"branches":           sanitBranches,
 			}
 			if len(conf.Workflow.Stages) > 0 {
 				sanitChildren := map[string]interface{}{}
@@ -281,19 +287,21 @@ resources:
 // WorkflowConfig is a config struct containing fields for the Workflow
 // processor.
 type WorkflowConfig struct {
-	MetaPath string                         `json:"meta_path" yaml:"meta_path"`
-	Order    [][]string                     `json:"order" yaml:"order"`
-	Branches map[string]BranchConfig        `json:"branches" yaml:"branches"`
-	Stages   map[string]DepProcessMapConfig `json:"stages" yaml:"stages"`
+	MetaPath         string                         `json:"meta_path" yaml:"meta_path"`
+	StatusMetaPrefix string                         `json:"status_meta_prefix" yaml:"status_meta_prefix"`
+	Order            [][]string                     `json:"order" yaml:"order"`
+	Branches         map[string]BranchConfig        `json:"branches" yaml:"branches"`
+	Stages           map[string]DepProcessMapConfig `json:"stages" yaml:"stages"`
 }
 
 // NewWorkflowConfig returns a default WorkflowConfig.
 func NewWorkflowConfig() WorkflowConfig {
 	return WorkflowConfig{
-		MetaPath: "meta.workflow",
-		Order:    [][]string{},
-		Branches: map[string]BranchConfig{},
-		Stages:   map[string]DepProcessMapConfig{},
+		MetaPath:         "meta.workflow",
+		StatusMetaPrefix: "",
+		Order:            [][]string{},
+		Branches:         map[string]BranchConfig{},
+		Stages:           map[string]DepProcessMapConfig{},
 	}
 }
 
@@ -378,6 +386,8 @@ type Workflow struct {
 	allStages map[string]struct{}
 	metaPath  []string
 
+	statusMetaPrefix string
+
 	mCount           metrics.StatCounter
 	mSent            metrics.StatCounter
 	mSentParts       metrics.StatCounter
@@ -411,6 +421,8 @@ func NewWorkflow(
 		mSuccStages: map[string]metrics.StatCounter{},
 		metaPath:    nil,
 		allStages:   map[string]struct{}{},
+
+		statusMetaPrefix: conf.Workflow.StatusMetaPrefix,
 	}
 	if len(conf.Workflow.MetaPath) > 0 {
 		w.metaPath = gabs.DotPathToSlice(conf.Workflow.MetaPath)
@@ -674,6 +686,20 @@ func (r *resultTracker) ToObject() map[string]interface{} {
 	return m
 }
 
+// SetMetadata adds the status of each branch to metadata with the names of the
+// branches appended to a prefix.
+func (r *resultTracker) SetMetadata(meta types.Metadata, prefix string) {
+	for k := range r.succeeded {
+		meta.Set(prefix+k, "succeeded")
+	}
+	for k := range r.skipped {
+		meta.Set(prefix+k, "skipped")
+	}
+	for k := range r.failed {
+		meta.Set(prefix+k, "failed")
+	}
+}
+
 // Returns a map of enrichment IDs that should be skipped for this payload.
 func (w *Workflow) skipFromMeta(root interface{}) map[string]struct{} {
 	skipList := map[string]struct{}{}
@@ -821,6 +847,13 @@ func (w *Workflow) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 		}
 	}
 
+	if len(w.statusMetaPrefix) > 0 {
+		payload.Iter(func(i int, p types.Part) error {
+			records[i].SetMetadata(p.Metadata(), w.statusMetaPrefix)
+			return nil
+		})
+	}
+
 	// Finally, set the meta records of each document.
 	if len(w.metaPath) > 0 {
 		payload.Iter(func(i int, p types.Part) error {
@@ -604,3 +604,64 @@ func TestWorkflowsWithResources(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkflowStatusMetadata(t *testing.T) {
+	conf := NewConfig()
+	conf.Workflow.MetaPath = ""
+	conf.Workflow.StatusMetaPrefix = "workflow_"
+	for id, mappings := range map[string][3]string{
+		"a": {
+			`root = content()`,
+			`root = content().uppercase()`,
+			`meta upper = content()`,
+		},
+		"b": {
+			`root = if content() == "skip" { deleted() } else { content() }`,
+			`root = content()`,
+			`meta b = "done"`,
+		},
+		"c": {
+			`root = content()`,
+			`root = content()`,
+			`meta c = content().number()`,
+		},
+	} {
+		branchConf := NewBranchConfig()
+		branchConf.RequestMap = mappings[0]
+		branchConf.ResultMap = mappings[2]
+		proc := NewConfig()
+		proc.Type = TypeBloblang
+		proc.Bloblang = BloblangConfig(mappings[1])
+		branchConf.Processors = append(branchConf.Processors, proc)
+		conf.Workflow.Branches[id] = branchConf
+	}
+
+	p, err := NewWorkflow(conf, types.NoopMgr(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, res := p.ProcessMessage(message.New([][]byte{
+		[]byte("foo"),
+		[]byte("skip"),
+		[]byte("5"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	exp := []map[string]string{
+		{"workflow_a": "succeeded", "workflow_b": "succeeded", "workflow_c": "failed"},
+		{"workflow_a": "succeeded", "workflow_b": "skipped", "workflow_c": "failed"},
+		{"workflow_a": "succeeded", "workflow_b": "succeeded", "workflow_c": "succeeded"},
+	}
+	for i, e := range exp {
+		part := msgs[0].Get(i)
+		for k, v := range e {
+			assert.Equal(t, v, part.Metadata().Get(k), "%v: %v", i, k)
+		}
+		assert.Equal(t, e["workflow_c"] == "failed", HasFailed(part), i)
+	}
+	assert.Equal(t, "FOO", msgs[0].Get(0).Metadata().Get("upper"))
+	assert.Equal(t, "5", msgs[0].Get(2).Metadata().Get("c"))
+
+	p.CloseAsync()
+	assert.NoError(t, p.WaitForClose(time.Second))
+}
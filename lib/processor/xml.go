@@ -1,25 +1,27 @@
 package processor
 
 import (
+	"bytes"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
-	"github.com/clbanning/mxj"
 	"github.com/opentracing/opentracing-go"
 )
 
 //------------------------------------------------------------------------------
 
 func init() {
-	dec := xml.NewDecoder(nil)
-	dec.Strict = false
-	mxj.CustomDecoder = dec
-
 	Constructors[TypeXML] = TypeSpec{
 		constructor: NewXML,
 		Beta:        true,
@@ -73,9 +75,36 @@ The resulting JSON structure would look like this:
     ]
   }
 }
-` + "```" + ``,
+` + "```" + `
+
+With ` + "`cast`" + ` enabled the values of elements and attributes that are
+numbers or booleans are converted into JSON numbers and booleans. Elements named
+in ` + "`array_elements`" + ` are always converted into arrays, even when they
+appear once, so that the structure of documents doesn't depend on the number of
+repeated elements.
+
+Namespace prefixes of elements and attributes are removed unless
+` + "`preserve_namespaces`" + ` is enabled, in which case keys contain the prefix
+as written in the document, such as ` + "`soap:Envelope`" + `, and namespace
+declarations appear as attributes such as ` + "`-xmlns:soap`" + `.
+
+### ` + "`from_json`" + `
+
+Converts a JSON structure into an XML document following the same rules in
+reverse: keys beginning with the attribute prefix become attributes, the key
+` + "`#text`" + ` becomes the text of an element, and arrays become repeated
+elements. The keys of objects are written in alphabetical order.
+
+When ` + "`root`" + ` is set the document is written within a root element of
+that name, otherwise the document must be an object with a single key, which is
+used as the root element.`,
 		FieldSpecs: docs.FieldSpecs{
-			docs.FieldCommon("operator", "An XML [operation](#operators) to apply to messages.").HasOptions("to_json"),
+			docs.FieldCommon("operator", "An XML [operation](#operators) to apply to messages.").HasOptions("to_json", "from_json"),
+			docs.FieldAdvanced("attribute_prefix", "A prefix that distinguishes the keys of attributes from the keys of elements."),
+			docs.FieldAdvanced("cast", "Whether the `to_json` operator converts values that are numbers or booleans into JSON numbers and booleans."),
+			docs.FieldAdvanced("array_elements", "A list of element names that the `to_json` operator always converts into arrays.", []string{"item", "entry"}),
+			docs.FieldAdvanced("preserve_namespaces", "Whether the `to_json` operator keeps the namespace prefixes of elements and attributes."),
+			docs.FieldAdvanced("root", "The name of a root element that the `from_json` operator writes documents within.", "Envelope", "soap:Envelope"),
 			partsFieldSpec,
 		},
 	}
@@ -85,23 +114,279 @@ The resulting JSON structure would look like this:
 
 // XMLConfig contains configuration fields for the XML processor.
 type XMLConfig struct {
-	Parts    []int  `json:"parts" yaml:"parts"`
-	Operator string `json:"operator" yaml:"operator"`
+	Parts              []int    `json:"parts" yaml:"parts"`
+	Operator           string   `json:"operator" yaml:"operator"`
+	AttributePrefix    string   `json:"attribute_prefix" yaml:"attribute_prefix"`
+	Cast               bool     `json:"cast" yaml:"cast"`
+	ArrayElements      []string `json:"array_elements" yaml:"array_elements"`
+	PreserveNamespaces bool     `json:"preserve_namespaces" yaml:"preserve_namespaces"`
+	Root               string   `json:"root" yaml:"root"`
 }
 
 // NewXMLConfig returns a XMLConfig with default values.
 func NewXMLConfig() XMLConfig {
 	return XMLConfig{
-		Parts:    []int{},
-		Operator: "to_json",
+		Parts:              []int{},
+		Operator:           "to_json",
+		AttributePrefix:    "-",
+		Cast:               false,
+		ArrayElements:      []string{},
+		PreserveNamespaces: false,
+		Root:               "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// xmlNamespaceURL is the namespace that the xml prefix is bound to.
+const xmlNamespaceURL = "http://www.w3.org/XML/1998/namespace"
+
+// xmlToJSON parses an XML document into a JSON structure.
+func (p *XML) xmlToJSON(data []byte) (interface{}, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	// The prefixes of namespaces declared within the document, as elements
+	// and attributes are named by the URL of their namespace once parsed.
+	prefixes := map[string]string{xmlNamespaceURL: "xml"}
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("document does not contain an element")
+			}
+			return nil, err
+		}
+		if start, ok := t.(xml.StartElement); ok {
+			v, err := p.xmlElementToJSON(dec, start, prefixes)
+			if err != nil {
+				return nil, err
+			}
+			root := map[string]interface{}{}
+			p.xmlSetChild(root, p.xmlName(start.Name, prefixes), v)
+			return root, nil
+		}
+	}
+}
+
+func (p *XML) xmlName(name xml.Name, prefixes map[string]string) string {
+	if !p.conf.XML.PreserveNamespaces || name.Space == "" {
+		return name.Local
+	}
+	prefix, exists := prefixes[name.Space]
+	if !exists {
+		// Prefixes that aren't declared remain as they're written.
+		prefix = name.Space
+	}
+	if prefix == "" {
+		return name.Local
+	}
+	return prefix + ":" + name.Local
+}
+
+func (p *XML) xmlAttrName(name xml.Name, prefixes map[string]string) string {
+	if p.conf.XML.PreserveNamespaces && name.Space == "xmlns" {
+		return "xmlns:" + name.Local
+	}
+	return p.xmlName(name, prefixes)
+}
+
+func (p *XML) xmlValue(s string) interface{} {
+	if !p.conf.XML.Cast {
+		return s
+	}
+	switch strings.ToLower(s) {
+	case "nan", "inf", "+inf", "-inf", "infinity", "+infinity", "-infinity":
+		return s
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	if b, err := strconv.ParseBool(s); err == nil {
+		return b
+	}
+	return s
+}
+
+func (p *XML) xmlSetChild(obj map[string]interface{}, key string, v interface{}) {
+	existing, exists := obj[key]
+	if !exists {
+		if _, isArray := p.arrayElements[key]; isArray {
+			v = []interface{}{v}
+		}
+		obj[key] = v
+		return
+	}
+	if arr, ok := existing.([]interface{}); ok {
+		obj[key] = append(arr, v)
+	} else {
+		obj[key] = []interface{}{existing, v}
+	}
+}
+
+func (p *XML) xmlElementToJSON(dec *xml.Decoder, start xml.StartElement, prefixes map[string]string) (interface{}, error) {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" {
+			prefixes[attr.Value] = attr.Name.Local
+		} else if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			prefixes[attr.Value] = ""
+		}
+	}
+
+	obj := map[string]interface{}{}
+	for _, attr := range start.Attr {
+		obj[p.conf.XML.AttributePrefix+p.xmlAttrName(attr.Name, prefixes)] = p.xmlValue(attr.Value)
+	}
+
+	var text string
+	var hasText bool
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tt := t.(type) {
+		case xml.StartElement:
+			v, err := p.xmlElementToJSON(dec, tt, prefixes)
+			if err != nil {
+				return nil, err
+			}
+			p.xmlSetChild(obj, p.xmlName(tt.Name, prefixes), v)
+		case xml.CharData:
+			if trimmed := strings.Trim(string(tt), "\t\r\b\n "); len(trimmed) > 0 {
+				text, hasText = trimmed, true
+			}
+		case xml.EndElement:
+			if len(obj) == 0 {
+				if hasText {
+					return p.xmlValue(text), nil
+				}
+				return "", nil
+			}
+			if hasText {
+				obj["#text"] = p.xmlValue(text)
+			}
+			return obj, nil
+		}
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// jsonToXML serializes a JSON structure as an XML document.
+func (p *XML) jsonToXML(jObj interface{}) ([]byte, error) {
+	root := p.conf.XML.Root
+	if root == "" {
+		obj, ok := jObj.(map[string]interface{})
+		if !ok || len(obj) != 1 {
+			return nil, errors.New("a root must be set for documents that aren't an object with a single key")
+		}
+		for k, v := range obj {
+			root, jObj = k, v
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	if err := p.jsonToXMLElement(enc, root, jObj); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func xmlText(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), nil
+	case json.Number:
+		return t.String(), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("expected a value, received %T", v)
+}
+
+func (p *XML) jsonToXMLElement(enc *xml.Encoder, name string, v interface{}) error {
+	if arr, ok := v.([]interface{}); ok {
+		for _, e := range arr {
+			if _, nested := e.([]interface{}); nested {
+				return fmt.Errorf("element %v: nested arrays cannot be converted into XML", name)
+			}
+			if err := p.jsonToXMLElement(enc, name, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	obj, isObj := v.(map[string]interface{})
+	if !isObj {
+		text, err := xmlText(v)
+		if err != nil {
+			return fmt.Errorf("element %v: %v", name, err)
+		}
+		return enc.EncodeElement(text, start)
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	prefix := p.conf.XML.AttributePrefix
+	var children []string
+	for _, k := range keys {
+		if prefix == "" || !strings.HasPrefix(k, prefix) || k == "#text" {
+			children = append(children, k)
+			continue
+		}
+		value, err := xmlText(obj[k])
+		if err != nil {
+			return fmt.Errorf("attribute %v: %v", k, err)
+		}
+		start.Attr = append(start.Attr, xml.Attr{
+			Name:  xml.Name{Local: strings.TrimPrefix(k, prefix)},
+			Value: value,
+		})
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, k := range children {
+		if k == "#text" {
+			text, err := xmlText(obj[k])
+			if err != nil {
+				return fmt.Errorf("element %v text: %v", name, err)
+			}
+			if err = enc.EncodeToken(xml.CharData(text)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := p.jsonToXMLElement(enc, k, obj[k]); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+//------------------------------------------------------------------------------
+
 // XML is a processor that performs an operation on a XML payload.
 type XML struct {
-	parts []int
+	parts         []int
+	arrayElements map[string]struct{}
+	operator      func(part types.Part) error
 
 	conf  Config
 	log   log.Modular
@@ -117,21 +402,50 @@ type XML struct {
 func NewXML(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
-	if conf.XML.Operator != "to_json" {
-		return nil, fmt.Errorf("operator not recognised: %v", conf.XML.Operator)
-	}
-
 	j := &XML{
-		parts: conf.XML.Parts,
-		conf:  conf,
-		log:   log,
-		stats: stats,
+		parts:         conf.XML.Parts,
+		arrayElements: map[string]struct{}{},
+		conf:          conf,
+		log:           log,
+		stats:         stats,
 
 		mCount:     stats.GetCounter("count"),
 		mErr:       stats.GetCounter("error"),
 		mSent:      stats.GetCounter("sent"),
 		mBatchSent: stats.GetCounter("batch.sent"),
 	}
+	for _, name := range conf.XML.ArrayElements {
+		j.arrayElements[name] = struct{}{}
+	}
+
+	switch conf.XML.Operator {
+	case "to_json":
+		j.operator = func(part types.Part) error {
+			root, err := j.xmlToJSON(part.Get())
+			if err != nil {
+				return fmt.Errorf("failed to parse part as XML: %v", err)
+			}
+			if err = part.SetJSON(root); err != nil {
+				return fmt.Errorf("failed to marshal XML as JSON: %v", err)
+			}
+			return nil
+		}
+	case "from_json":
+		j.operator = func(part types.Part) error {
+			jObj, err := part.JSON()
+			if err != nil {
+				return fmt.Errorf("failed to parse part as JSON: %v", err)
+			}
+			doc, err := j.jsonToXML(jObj)
+			if err != nil {
+				return fmt.Errorf("failed to convert JSON to XML: %v", err)
+			}
+			part.Set(doc)
+			return nil
+		}
+	default:
+		return nil, fmt.Errorf("operator not recognised: %v", conf.XML.Operator)
+	}
 	return j, nil
 }
 
@@ -144,15 +458,9 @@ func (p *XML) ProcessMessage(msg types.Message) ([]types.Message, types.Response
 	newMsg := msg.Copy()
 
 	proc := func(index int, span opentracing.Span, part types.Part) error {
-		root, err := mxj.NewMapXml(part.Get())
-		if err != nil {
-			p.mErr.Incr(1)
-			p.log.Debugf("Failed to parse part as XML: %v\n", err)
-			return err
-		}
-		if err = part.SetJSON(map[string]interface{}(root)); err != nil {
+		if err := p.operator(part); err != nil {
 			p.mErr.Incr(1)
-			p.log.Debugf("Failed to marshal XML as JSON: %v\n", err)
+			p.log.Debugf("Operator failed: %v\n", err)
 			return err
 		}
 		return nil
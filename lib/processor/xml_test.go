@@ -6,6 +6,8 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/log"
 	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestXMLCases(t *testing.T) {
@@ -98,3 +100,143 @@ func TestXMLCases(t *testing.T) {
 		})
 	}
 }
+
+func TestXMLToJSONOptions(t *testing.T) {
+	input := `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns="urn:example">
+  <soap:Body>
+    <order id="12" paid="true">
+      <item>foo</item>
+      <price>10.5</price>
+    </order>
+  </soap:Body>
+</soap:Envelope>`
+
+	tests := []struct {
+		name   string
+		conf   func(c *XMLConfig)
+		output string
+	}{
+		{
+			name:   "defaults",
+			conf:   func(c *XMLConfig) {},
+			output: `{"Envelope":{"-soap":"http://www.w3.org/2003/05/soap-envelope","-xmlns":"urn:example","Body":{"order":{"-id":"12","-paid":"true","item":"foo","price":"10.5"}}}}`,
+		},
+		{
+			name: "attribute prefix",
+			conf: func(c *XMLConfig) {
+				c.AttributePrefix = "@"
+			},
+			output: `{"Envelope":{"@soap":"http://www.w3.org/2003/05/soap-envelope","@xmlns":"urn:example","Body":{"order":{"@id":"12","@paid":"true","item":"foo","price":"10.5"}}}}`,
+		},
+		{
+			name: "cast and arrays",
+			conf: func(c *XMLConfig) {
+				c.Cast = true
+				c.ArrayElements = []string{"item"}
+			},
+			output: `{"Envelope":{"-soap":"http://www.w3.org/2003/05/soap-envelope","-xmlns":"urn:example","Body":{"order":{"-id":12,"-paid":true,"item":["foo"],"price":10.5}}}}`,
+		},
+		{
+			name: "preserve namespaces",
+			conf: func(c *XMLConfig) {
+				c.PreserveNamespaces = true
+			},
+			output: `{"soap:Envelope":{"-xmlns":"urn:example","-xmlns:soap":"http://www.w3.org/2003/05/soap-envelope","soap:Body":{"order":{"-id":"12","-paid":"true","item":"foo","price":"10.5"}}}}`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+			test.conf(&conf.XML)
+
+			proc, err := NewXML(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(input)}))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			assert.Equal(t, test.output, string(msgs[0].Get(0).Get()))
+		})
+	}
+}
+
+func TestXMLFromJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   func(c *XMLConfig)
+		input  string
+		output string
+		err    string
+	}{
+		{
+			name:   "single key root",
+			conf:   func(c *XMLConfig) {},
+			input:  `{"root":{"-id":"1","b":[1,2.5],"a":{"#text":"foo & bar","-lang":"en"},"c":null}}`,
+			output: `<root id="1"><a lang="en">foo &amp; bar</a><b>1</b><b>2.5</b><c></c></root>`,
+		},
+		{
+			name: "configured root",
+			conf: func(c *XMLConfig) {
+				c.Root = "soap:Envelope"
+				c.AttributePrefix = "@"
+			},
+			input:  `{"@xmlns:soap":"http://www.w3.org/2003/05/soap-envelope","soap:Body":{"value":true}}`,
+			output: `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><value>true</value></soap:Body></soap:Envelope>`,
+		},
+		{
+			name:  "missing root",
+			conf:  func(c *XMLConfig) {},
+			input: `{"a":"foo","b":"bar"}`,
+			err:   "failed to convert JSON to XML: a root must be set for documents that aren't an object with a single key",
+		},
+		{
+			name:  "nested arrays",
+			conf:  func(c *XMLConfig) {},
+			input: `{"root":{"a":[[1]]}}`,
+			err:   "failed to convert JSON to XML: element a: nested arrays cannot be converted into XML",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.XML.Operator = "from_json"
+			test.conf(&conf.XML)
+
+			proc, err := NewXML(conf, nil, log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			msgs, res := proc.ProcessMessage(message.New([][]byte{[]byte(test.input)}))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			if test.err != "" {
+				assert.Equal(t, test.err, GetFail(msgs[0].Get(0)))
+				return
+			}
+			assert.False(t, HasFailed(msgs[0].Get(0)), GetFail(msgs[0].Get(0)))
+			assert.Equal(t, test.output, string(msgs[0].Get(0).Get()))
+		})
+	}
+}
+
+func TestXMLRoundTrip(t *testing.T) {
+	input := `<root id="1"><a lang="en">foo</a><b>1</b><b>2</b></root>`
+
+	toConf := NewConfig()
+	toJSON, err := NewXML(toConf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	fromConf := NewConfig()
+	fromConf.XML.Operator = "from_json"
+	fromJSON, err := NewXML(fromConf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, _ := toJSON.ProcessMessage(message.New([][]byte{[]byte(input)}))
+	require.Len(t, msgs, 1)
+	msgs, _ = fromJSON.ProcessMessage(msgs[0])
+	require.Len(t, msgs, 1)
+	assert.Equal(t, input, string(msgs[0].Get(0).Get()))
+}
@@ -1,6 +1,9 @@
 package response
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 //------------------------------------------------------------------------------
 
@@ -39,6 +42,64 @@ func NewError(err error) Error {
 
 //------------------------------------------------------------------------------
 
+// DelayedError is an error that indicates a message failed to reach a target
+// destination and should be redelivered after a delay. Inputs that support
+// redelivery delays will honor the delay rather than requeuing the message
+// immediately, other inputs treat it as a regular error.
+type DelayedError struct {
+	err   error
+	delay time.Duration
+}
+
+// NewDelayedError returns an error that wraps an error and requests the
+// message to be redelivered after a delay. A nil error is replaced with
+// ErrNoAck.
+func NewDelayedError(err error, delay time.Duration) *DelayedError {
+	if err == nil {
+		err = ErrNoAck
+	}
+	return &DelayedError{
+		err:   err,
+		delay: delay,
+	}
+}
+
+// Error returns a human readable error string.
+func (d *DelayedError) Error() string {
+	return d.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (d *DelayedError) Unwrap() error {
+	return d.err
+}
+
+// Delay returns the requested redelivery delay.
+func (d *DelayedError) Delay() time.Duration {
+	return d.delay
+}
+
+// NewErrorWithDelay returns a response that wraps an error and requests the
+// message to be redelivered after a delay.
+func NewErrorWithDelay(err error, delay time.Duration) Error {
+	return Error{
+		err: NewDelayedError(err, delay),
+	}
+}
+
+// RedeliveryDelay returns the redelivery delay requested by an error, which is
+// found by unwrapping the error, and a boolean indicating whether a delay was
+// requested.
+func RedeliveryDelay(err error) (time.Duration, bool) {
+	var dErr *DelayedError
+	if errors.As(err, &dErr) {
+		return dErr.delay, true
+	}
+	return 0, false
+}
+
+//------------------------------------------------------------------------------
+
 // Ack is a response type that indicates the message has reached a destination
 // and can be acknowledged upstream.
 type Ack struct{}
@@ -2,7 +2,9 @@ package response
 
 import (
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 )
 
 func TestError(t *testing.T) {
@@ -17,6 +19,39 @@ func TestError(t *testing.T) {
 	}
 }
 
+func TestErrorWithDelay(t *testing.T) {
+	err := errors.New("test error")
+	res := NewErrorWithDelay(err, time.Second)
+
+	if res.Error() == nil {
+		t.Fatal("Should have received error on delayed response")
+	}
+	if exp, act := err.Error(), res.Error().Error(); exp != act {
+		t.Errorf("Wrong error: %v != %v", exp, act)
+	}
+	if !errors.Is(res.Error(), err) {
+		t.Error("Expected delayed error to wrap the original error")
+	}
+	if res.SkipAck() {
+		t.Error("Should not received skip ack on delayed response")
+	}
+
+	delay, ok := RedeliveryDelay(fmt.Errorf("wrapped: %w", res.Error()))
+	if !ok {
+		t.Fatal("Expected redelivery delay")
+	}
+	if exp, act := time.Second, delay; exp != act {
+		t.Errorf("Wrong delay: %v != %v", exp, act)
+	}
+
+	if _, ok = RedeliveryDelay(err); ok {
+		t.Error("Unexpected redelivery delay")
+	}
+	if exp, act := ErrNoAck, NewDelayedError(nil, time.Second).Unwrap(); exp != act {
+		t.Errorf("Wrong error: %v != %v", exp, act)
+	}
+}
+
 func TestNoack(t *testing.T) {
 	res := NewNoack()
 
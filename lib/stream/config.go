@@ -10,21 +10,23 @@ import (
 //------------------------------------------------------------------------------
 
 // Config is a configuration struct representing all four layers of a Benthos
-// stream.
+// stream, and an optional dead letter output.
 type Config struct {
-	Input    input.Config    `json:"input" yaml:"input"`
-	Buffer   buffer.Config   `json:"buffer" yaml:"buffer"`
-	Pipeline pipeline.Config `json:"pipeline" yaml:"pipeline"`
-	Output   output.Config   `json:"output" yaml:"output"`
+	Input      input.Config     `json:"input" yaml:"input"`
+	Buffer     buffer.Config    `json:"buffer" yaml:"buffer"`
+	Pipeline   pipeline.Config  `json:"pipeline" yaml:"pipeline"`
+	Output     output.Config    `json:"output" yaml:"output"`
+	DeadLetter DeadLetterConfig `json:"dead_letter" yaml:"dead_letter"`
 }
 
 // NewConfig returns a new configuration with default values.
 func NewConfig() Config {
 	return Config{
-		Input:    input.NewConfig(),
-		Buffer:   buffer.NewConfig(),
-		Pipeline: pipeline.NewConfig(),
-		Output:   output.NewConfig(),
+		Input:      input.NewConfig(),
+		Buffer:     buffer.NewConfig(),
+		Pipeline:   pipeline.NewConfig(),
+		Output:     output.NewConfig(),
+		DeadLetter: NewDeadLetterConfig(),
 	}
 }
 
@@ -55,16 +57,24 @@ func (c Config) Sanitised() (interface{}, error) {
 		return nil, err
 	}
 
+	var deadConf interface{}
+	deadConf, err = c.DeadLetter.Sanitised()
+	if err != nil {
+		return nil, err
+	}
+
 	return struct {
-		Input    interface{} `json:"input" yaml:"input"`
-		Buffer   interface{} `json:"buffer" yaml:"buffer"`
-		Pipeline interface{} `json:"pipeline" yaml:"pipeline"`
-		Output   interface{} `json:"output" yaml:"output"`
+		Input      interface{} `json:"input" yaml:"input"`
+		Buffer     interface{} `json:"buffer" yaml:"buffer"`
+		Pipeline   interface{} `json:"pipeline" yaml:"pipeline"`
+		Output     interface{} `json:"output" yaml:"output"`
+		DeadLetter interface{} `json:"dead_letter,omitempty" yaml:"dead_letter,omitempty"`
 	}{
-		Input:    inConf,
-		Buffer:   bufConf,
-		Pipeline: pipeConf,
-		Output:   outConf,
+		Input:      inConf,
+		Buffer:     bufConf,
+		Pipeline:   pipeConf,
+		Output:     outConf,
+		DeadLetter: deadConf,
 	}, nil
 }
 
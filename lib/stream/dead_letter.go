@@ -0,0 +1,313 @@
+package stream
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/retries"
+	"github.com/cenkalti/backoff/v4"
+)
+
+//------------------------------------------------------------------------------
+
+// Metadata keys added to messages sent to a dead letter output.
+const (
+	DeadLetterErrorKey     = "dead_letter_error"
+	DeadLetterComponentKey = "dead_letter_component"
+	DeadLetterAttemptsKey  = "dead_letter_attempts"
+)
+
+// DeadLetterConfig contains configuration fields for the dead letter output of
+// a stream, which receives messages that failed processing or could not be
+// delivered to the output of the stream.
+type DeadLetterConfig struct {
+	Output         *output.Config `json:"output" yaml:"output"`
+	retries.Config `json:",inline" yaml:",inline"`
+}
+
+// NewDeadLetterConfig returns a DeadLetterConfig with default values, where no
+// output is set and therefore the dead letter output is disabled.
+func NewDeadLetterConfig() DeadLetterConfig {
+	rConf := retries.NewConfig()
+	rConf.MaxRetries = 3
+	return DeadLetterConfig{
+		Output: nil,
+		Config: rConf,
+	}
+}
+
+// Sanitised returns a sanitised copy of the dead letter config, or nil when
+// the dead letter output is disabled.
+func (c DeadLetterConfig) Sanitised() (interface{}, error) {
+	if c.Output == nil {
+		return nil, nil
+	}
+	outConf, err := output.SanitiseConfig(*c.Output)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		Output     interface{}     `json:"output" yaml:"output"`
+		MaxRetries uint64          `json:"max_retries" yaml:"max_retries"`
+		Backoff    retries.Backoff `json:"backoff" yaml:"backoff"`
+	}{
+		Output:     outConf,
+		MaxRetries: c.MaxRetries,
+		Backoff:    c.Backoff,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// deadLetter is an output that wraps the output of a stream. Messages that have
+// failed processing are sent straight to the dead letter output, and messages
+// that the stream output rejects are retried and eventually sent to the dead
+// letter output, annotated with metadata describing the failure.
+type deadLetter struct {
+	out        types.Output
+	dlq        types.Output
+	outChan    chan types.Transaction
+	dlqChan    chan types.Transaction
+	newBackoff func() backoff.BackOff
+
+	maxInFlight  int
+	transactions <-chan types.Transaction
+
+	mPipelineFailed metrics.StatCounter
+	mOutputFailed   metrics.StatCounter
+	mRetry          metrics.StatCounter
+	mDLQError       metrics.StatCounter
+
+	ctx        context.Context
+	close      func()
+	closedChan chan struct{}
+}
+
+func newDeadLetter(conf DeadLetterConfig, out, dlq types.Output, stats metrics.Type) (*deadLetter, error) {
+	newBackoff, err := conf.GetCtor()
+	if err != nil {
+		return nil, err
+	}
+	ctx, done := context.WithCancel(context.Background())
+	d := &deadLetter{
+		out:             out,
+		dlq:             dlq,
+		outChan:         make(chan types.Transaction),
+		dlqChan:         make(chan types.Transaction),
+		newBackoff:      newBackoff,
+		maxInFlight:     50,
+		mPipelineFailed: stats.GetCounter("dead_letter.pipeline_failed"),
+		mOutputFailed:   stats.GetCounter("dead_letter.output_failed"),
+		mRetry:          stats.GetCounter("dead_letter.retry"),
+		mDLQError:       stats.GetCounter("dead_letter.error"),
+		ctx:             ctx,
+		close:           done,
+		closedChan:      make(chan struct{}),
+	}
+	if err := d.out.Consume(d.outChan); err != nil {
+		return nil, err
+	}
+	if err := d.dlq.Consume(d.dlqChan); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Consume assigns a new transactions channel for the output to read.
+func (d *deadLetter) Consume(ts <-chan types.Transaction) error {
+	if d.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	d.transactions = ts
+	go d.loop()
+	return nil
+}
+
+// Connected returns a boolean indicating whether both the stream output and
+// the dead letter output are connected to their targets.
+func (d *deadLetter) Connected() bool {
+	return d.out.Connected() && d.dlq.Connected()
+}
+
+//------------------------------------------------------------------------------
+
+// send attempts to send a payload to an output and returns the response,
+// or false if the output was closed before a response was received.
+func (d *deadLetter) send(c chan<- types.Transaction, payload types.Message) (types.Response, bool) {
+	rChan := make(chan types.Response)
+	select {
+	case c <- types.NewTransaction(payload, rChan):
+	case <-d.ctx.Done():
+		return nil, false
+	}
+	select {
+	case res, open := <-rChan:
+		return res, open
+	case <-d.ctx.Done():
+		return nil, false
+	}
+}
+
+func subset(payload types.Message, indexes []int) types.Message {
+	if len(indexes) == payload.Len() {
+		return payload
+	}
+	next := message.New(nil)
+	for _, i := range indexes {
+		next.Append(payload.Get(i))
+	}
+	return next
+}
+
+// deadPart returns a copy of a message annotated with metadata describing its
+// failure.
+func deadPart(part types.Part, err, component string, attempts int) types.Part {
+	part = part.Copy()
+	part.Metadata().
+		Set(DeadLetterErrorKey, err).
+		Set(DeadLetterComponentKey, component).
+		Set(DeadLetterAttemptsKey, strconv.Itoa(attempts))
+	return part
+}
+
+// deliver sends the messages of a payload that haven't failed processing to
+// the stream output until they're delivered or the retries are exhausted, and
+// returns the messages that should be sent to the dead letter output.
+func (d *deadLetter) deliver(payload types.Message) (types.Message, bool) {
+	dead := message.New(nil)
+
+	var indexes []int
+	payload.Iter(func(i int, part types.Part) error {
+		if processor.HasFailed(part) {
+			d.mPipelineFailed.Incr(1)
+			dead.Append(deadPart(part, processor.GetFail(part), "pipeline", 0))
+		} else {
+			indexes = append(indexes, i)
+		}
+		return nil
+	})
+	if len(indexes) == 0 {
+		return dead, true
+	}
+
+	remaining := subset(payload, indexes)
+	boff := d.newBackoff()
+	for attempts := 1; ; attempts++ {
+		res, ok := d.send(d.outChan, remaining)
+		if !ok {
+			return nil, false
+		}
+		err := res.Error()
+		if err == nil {
+			return dead, true
+		}
+
+		failed, errs := batch.FailedIndexes(remaining, err)
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			for i, index := range failed {
+				d.mOutputFailed.Incr(1)
+				dead.Append(deadPart(remaining.Get(index), errs[i].Error(), "output", attempts))
+			}
+			return dead, true
+		}
+
+		remaining = subset(remaining, failed)
+		d.mRetry.Incr(1)
+		select {
+		case <-time.After(wait):
+		case <-d.ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// loop is an internal loop that brokers transactions to the stream output and
+// the dead letter output.
+func (d *deadLetter) loop() {
+	wg := sync.WaitGroup{}
+	defer func() {
+		wg.Wait()
+		close(d.outChan)
+		close(d.dlqChan)
+		close(d.closedChan)
+	}()
+
+	sendLoop := func() {
+		defer wg.Done()
+		for {
+			var tran types.Transaction
+			var open bool
+			select {
+			case tran, open = <-d.transactions:
+				if !open {
+					return
+				}
+			case <-d.ctx.Done():
+				return
+			}
+
+			dead, ok := d.deliver(tran.Payload)
+			if !ok {
+				return
+			}
+
+			var res types.Response = response.NewAck()
+			if dead.Len() > 0 {
+				dRes, ok := d.send(d.dlqChan, dead)
+				if !ok {
+					return
+				}
+				if err := dRes.Error(); err != nil {
+					d.mDLQError.Incr(1)
+					res = response.NewError(err)
+				}
+			}
+
+			select {
+			case tran.ResponseChan <- res:
+			case <-d.ctx.Done():
+				return
+			}
+		}
+	}
+
+	for i := 0; i < d.maxInFlight; i++ {
+		wg.Add(1)
+		go sendLoop()
+	}
+}
+
+// CloseAsync shuts down the output and its children.
+func (d *deadLetter) CloseAsync() {
+	d.close()
+	d.out.CloseAsync()
+	d.dlq.CloseAsync()
+}
+
+// WaitForClose blocks until the output and its children have closed down.
+func (d *deadLetter) WaitForClose(timeout time.Duration) error {
+	started := time.Now()
+	select {
+	case <-d.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	if err := d.out.WaitForClose(timeout - time.Since(started)); err != nil {
+		return err
+	}
+	return d.dlq.WaitForClose(timeout - time.Since(started))
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,260 @@
+package stream
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/internal/batch"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/output"
+	"github.com/Jeffail/benthos/v3/lib/processor"
+	"github.com/Jeffail/benthos/v3/lib/response"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+
+type mockDeadLetterOutput struct {
+	mut      sync.Mutex
+	received []types.Message
+	respond  func(n int, msg types.Message) error
+
+	ts     <-chan types.Transaction
+	closed chan struct{}
+}
+
+func newMockDeadLetterOutput(respond func(n int, msg types.Message) error) *mockDeadLetterOutput {
+	return &mockDeadLetterOutput{
+		respond: respond,
+		closed:  make(chan struct{}),
+	}
+}
+
+func (m *mockDeadLetterOutput) Consume(ts <-chan types.Transaction) error {
+	m.ts = ts
+	go func() {
+		defer close(m.closed)
+		for tran := range m.ts {
+			m.mut.Lock()
+			m.received = append(m.received, tran.Payload.DeepCopy())
+			n := len(m.received)
+			m.mut.Unlock()
+
+			var err error
+			if m.respond != nil {
+				err = m.respond(n, tran.Payload)
+			}
+			tran.ResponseChan <- response.NewError(err)
+		}
+	}()
+	return nil
+}
+
+func (m *mockDeadLetterOutput) messages() []types.Message {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	return append([]types.Message(nil), m.received...)
+}
+
+func (m *mockDeadLetterOutput) Connected() bool {
+	return true
+}
+
+func (m *mockDeadLetterOutput) CloseAsync() {
+}
+
+func (m *mockDeadLetterOutput) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-m.closed:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+func newTestDeadLetter(t *testing.T, out, dlq types.Output) (*deadLetter, chan types.Transaction) {
+	t.Helper()
+
+	conf := NewDeadLetterConfig()
+	conf.MaxRetries = 2
+	conf.Backoff.InitialInterval = "1ms"
+	conf.Backoff.MaxInterval = "1ms"
+
+	d, err := newDeadLetter(conf, out, dlq, metrics.Noop())
+	require.NoError(t, err)
+
+	tChan := make(chan types.Transaction)
+	require.NoError(t, d.Consume(tChan))
+	t.Cleanup(func() {
+		close(tChan)
+		assert.NoError(t, d.WaitForClose(time.Second))
+	})
+	return d, tChan
+}
+
+func sendDeadLetterTest(t *testing.T, tChan chan<- types.Transaction, msg types.Message) error {
+	t.Helper()
+
+	rChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(msg, rChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case res := <-rChan:
+		return res.Error()
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func TestDeadLetterConfig(t *testing.T) {
+	conf := NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+dead_letter:
+  max_retries: 5
+  output:
+    drop: {}
+`), &conf))
+
+	require.NotNil(t, conf.DeadLetter.Output)
+	assert.Equal(t, output.TypeDrop, conf.DeadLetter.Output.Type)
+	assert.Equal(t, uint64(5), conf.DeadLetter.MaxRetries)
+	assert.Equal(t, "500ms", conf.DeadLetter.Backoff.InitialInterval)
+
+	sanit, err := NewConfig().Sanitised()
+	require.NoError(t, err)
+	sanitBytes, err := yaml.Marshal(sanit)
+	require.NoError(t, err)
+	assert.NotContains(t, string(sanitBytes), "dead_letter")
+
+	sanit, err = conf.Sanitised()
+	require.NoError(t, err)
+	sanitBytes, err = yaml.Marshal(sanit)
+	require.NoError(t, err)
+	assert.Contains(t, string(sanitBytes), "dead_letter:")
+}
+
+func TestDeadLetterProcessingFailures(t *testing.T) {
+	out := newMockDeadLetterOutput(nil)
+	dlq := newMockDeadLetterOutput(nil)
+	_, tChan := newTestDeadLetter(t, out, dlq)
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+	processor.FlagErr(msg.Get(1), errors.New("bad thing"))
+	require.NoError(t, sendDeadLetterTest(t, tChan, msg))
+
+	outMsgs := out.messages()
+	require.Len(t, outMsgs, 1)
+	assert.Equal(t, [][]byte{[]byte("foo"), []byte("baz")}, message.GetAllBytes(outMsgs[0]))
+
+	dlqMsgs := dlq.messages()
+	require.Len(t, dlqMsgs, 1)
+	require.Equal(t, 1, dlqMsgs[0].Len())
+	part := dlqMsgs[0].Get(0)
+	assert.Equal(t, "bar", string(part.Get()))
+	assert.Equal(t, "bad thing", part.Metadata().Get(DeadLetterErrorKey))
+	assert.Equal(t, "pipeline", part.Metadata().Get(DeadLetterComponentKey))
+	assert.Equal(t, "0", part.Metadata().Get(DeadLetterAttemptsKey))
+
+	// The original message is not modified.
+	assert.Equal(t, "", msg.Get(1).Metadata().Get(DeadLetterErrorKey))
+}
+
+func TestDeadLetterOutputRetries(t *testing.T) {
+	out := newMockDeadLetterOutput(func(n int, msg types.Message) error {
+		return errors.New("nope")
+	})
+	dlq := newMockDeadLetterOutput(nil)
+	_, tChan := newTestDeadLetter(t, out, dlq)
+
+	require.NoError(t, sendDeadLetterTest(t, tChan, message.New([][]byte{[]byte("foo")})))
+
+	assert.Len(t, out.messages(), 3)
+
+	dlqMsgs := dlq.messages()
+	require.Len(t, dlqMsgs, 1)
+	part := dlqMsgs[0].Get(0)
+	assert.Equal(t, "foo", string(part.Get()))
+	assert.Equal(t, "nope", part.Metadata().Get(DeadLetterErrorKey))
+	assert.Equal(t, "output", part.Metadata().Get(DeadLetterComponentKey))
+	assert.Equal(t, "3", part.Metadata().Get(DeadLetterAttemptsKey))
+}
+
+func TestDeadLetterOutputRecovers(t *testing.T) {
+	out := newMockDeadLetterOutput(func(n int, msg types.Message) error {
+		if n == 1 {
+			return errors.New("nope")
+		}
+		return nil
+	})
+	dlq := newMockDeadLetterOutput(nil)
+	_, tChan := newTestDeadLetter(t, out, dlq)
+
+	require.NoError(t, sendDeadLetterTest(t, tChan, message.New([][]byte{[]byte("foo")})))
+
+	assert.Len(t, out.messages(), 2)
+	assert.Len(t, dlq.messages(), 0)
+}
+
+func TestDeadLetterOutputBatchErrors(t *testing.T) {
+	out := newMockDeadLetterOutput(func(n int, msg types.Message) error {
+		var bErr *batch.Error
+		msg.Iter(func(i int, part types.Part) error {
+			if string(part.Get()) == "bar" {
+				err := errors.New("bar is bad")
+				if bErr == nil {
+					bErr = batch.NewError(msg, err)
+				}
+				bErr.Failed(i, err)
+			}
+			return nil
+		})
+		if bErr != nil {
+			return bErr
+		}
+		return nil
+	})
+	dlq := newMockDeadLetterOutput(nil)
+	_, tChan := newTestDeadLetter(t, out, dlq)
+
+	msg := message.New([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+	require.NoError(t, sendDeadLetterTest(t, tChan, msg))
+
+	outMsgs := out.messages()
+	require.Len(t, outMsgs, 3)
+	assert.Equal(t, 3, outMsgs[0].Len())
+	assert.Equal(t, [][]byte{[]byte("bar")}, message.GetAllBytes(outMsgs[1]))
+	assert.Equal(t, [][]byte{[]byte("bar")}, message.GetAllBytes(outMsgs[2]))
+
+	dlqMsgs := dlq.messages()
+	require.Len(t, dlqMsgs, 1)
+	require.Equal(t, 1, dlqMsgs[0].Len())
+	part := dlqMsgs[0].Get(0)
+	assert.Equal(t, "bar", string(part.Get()))
+	assert.Equal(t, "bar is bad", part.Metadata().Get(DeadLetterErrorKey))
+}
+
+func TestDeadLetterQueueError(t *testing.T) {
+	out := newMockDeadLetterOutput(nil)
+	dlq := newMockDeadLetterOutput(func(n int, msg types.Message) error {
+		return errors.New("dlq is down")
+	})
+	_, tChan := newTestDeadLetter(t, out, dlq)
+
+	msg := message.New([][]byte{[]byte("foo")})
+	processor.FlagFail(msg.Get(0))
+	assert.EqualError(t, sendDeadLetterTest(t, tChan, msg), "dlq is down")
+}
+
+//------------------------------------------------------------------------------
@@ -245,24 +245,27 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		type aliasedOut output.Config
 
 		aliasedConf := struct {
-			Input    aliasedIn   `json:"input"`
-			Buffer   aliasedBuf  `json:"buffer"`
-			Pipeline aliasedPipe `json:"pipeline"`
-			Output   aliasedOut  `json:"output"`
+			Input      aliasedIn               `json:"input"`
+			Buffer     aliasedBuf              `json:"buffer"`
+			Pipeline   aliasedPipe             `json:"pipeline"`
+			Output     aliasedOut              `json:"output"`
+			DeadLetter stream.DeadLetterConfig `json:"dead_letter"`
 		}{
-			Input:    aliasedIn(confIn.Input),
-			Buffer:   aliasedBuf(confIn.Buffer),
-			Pipeline: aliasedPipe(confIn.Pipeline),
-			Output:   aliasedOut(confIn.Output),
+			Input:      aliasedIn(confIn.Input),
+			Buffer:     aliasedBuf(confIn.Buffer),
+			Pipeline:   aliasedPipe(confIn.Pipeline),
+			Output:     aliasedOut(confIn.Output),
+			DeadLetter: confIn.DeadLetter,
 		}
 		if err = yaml.Unmarshal(patchBytes, &aliasedConf); err != nil {
 			return
 		}
 		confOut = stream.Config{
-			Input:    input.Config(aliasedConf.Input),
-			Buffer:   buffer.Config(aliasedConf.Buffer),
-			Pipeline: pipeline.Config(aliasedConf.Pipeline),
-			Output:   output.Config(aliasedConf.Output),
+			Input:      input.Config(aliasedConf.Input),
+			Buffer:     buffer.Config(aliasedConf.Buffer),
+			Pipeline:   pipeline.Config(aliasedConf.Pipeline),
+			Output:     output.Config(aliasedConf.Output),
+			DeadLetter: aliasedConf.DeadLetter,
 		}
 		return
 	}
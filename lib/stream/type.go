@@ -164,6 +164,18 @@ func (t *Type) start() (err error) {
 	); err != nil {
 		return
 	}
+	if dConf := t.conf.DeadLetter; dConf.Output != nil {
+		var dlq output.Type
+		if dlq, err = output.New(
+			*dConf.Output, t.manager,
+			t.logger.NewModule(".dead_letter"), metrics.Namespaced(t.stats, "dead_letter"),
+		); err != nil {
+			return
+		}
+		if t.outputLayer, err = newDeadLetter(dConf, t.outputLayer, dlq, t.stats); err != nil {
+			return
+		}
+	}
 
 	// Start chaining components
 	var nextTranChan <-chan types.Transaction
@@ -75,6 +75,9 @@ func TestRedisStreamsIntegration(t *testing.T) {
 	t.Run("TestRedisStreamsDisconnect", func(te *testing.T) {
 		testRedisStreamsDisconnect(url, te)
 	})
+	t.Run("TestRedisStreamsAutoClaim", func(te *testing.T) {
+		testRedisStreamsAutoClaim(url, te)
+	})
 }
 
 func createRedisStreamsInputOutput(
@@ -386,3 +389,93 @@ func testRedisStreamsDisconnect(url string, t *testing.T) {
 
 	wg.Wait()
 }
+
+func testRedisStreamsAutoClaim(url string, t *testing.T) {
+	inConf := reader.NewRedisStreamsConfig()
+	inConf.URL = url
+	inConf.Streams = []string{"benthos_test_streams_auto_claim"}
+	inConf.StartFromOldest = false
+	inConf.ClientID = "benthos_dead_consumer"
+
+	outConf := writer.NewRedisStreamsConfig()
+	outConf.URL = url
+	outConf.Stream = "benthos_test_streams_auto_claim"
+
+	mInput, mOutput, err := createRedisStreamsInputOutput(inConf, outConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		mOutput.CloseAsync()
+		if cErr := mOutput.WaitForClose(time.Second); cErr != nil {
+			t.Error(cErr)
+		}
+	}()
+
+	N := 5
+	testMsgs := map[string]struct{}{}
+	for i := 0; i < N; i++ {
+		str := fmt.Sprintf("hello world: %v", i)
+		testMsgs[str] = struct{}{}
+		if err = mOutput.Write(message.New([][]byte{[]byte(str)})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Read all messages without acknowledging them before dying.
+	for read := 0; read < N; {
+		var actM types.Message
+		if actM, err = mInput.Read(); err == types.ErrTimeout {
+			continue
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		read += actM.Len()
+	}
+	mInput.CloseAsync()
+	if err = mInput.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+
+	inConf.ClientID = "benthos_live_consumer"
+	inConf.AutoClaim.Enabled = true
+	inConf.AutoClaim.MinIdle = "100ms"
+	inConf.AutoClaim.Period = "100ms"
+	inConf.AutoClaim.DrainOnStart = true
+
+	<-time.After(time.Millisecond * 200)
+
+	var claimInput reader.Type
+	if claimInput, err = reader.NewRedisStreams(inConf, log.Noop(), metrics.Noop()); err != nil {
+		t.Fatal(err)
+	}
+	if err = claimInput.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		claimInput.CloseAsync()
+		if cErr := claimInput.WaitForClose(time.Second); cErr != nil {
+			t.Error(cErr)
+		}
+	}()
+
+	for len(testMsgs) > 0 {
+		var actM types.Message
+		if actM, err = claimInput.Read(); err == types.ErrTimeout {
+			continue
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		actM.Iter(func(i int, part types.Part) error {
+			act := string(part.Get())
+			if _, exists := testMsgs[act]; !exists {
+				t.Errorf("Unexpected message: %v", act)
+			}
+			delete(testMsgs, act)
+			return nil
+		})
+		if err = claimInput.Acknowledge(nil); err != nil {
+			t.Error(err)
+		}
+	}
+}
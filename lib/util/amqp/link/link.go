@@ -0,0 +1,92 @@
+// Package link provides Benthos configuration fields for the links of AMQP 1.0
+// components.
+package link
+
+import (
+	"fmt"
+
+	"github.com/Azure/go-amqp"
+	"github.com/Jeffail/benthos/v3/internal/docs"
+)
+
+//------------------------------------------------------------------------------
+
+// SettleModeFieldSpecs returns specs for the settlement mode fields of a link.
+func SettleModeFieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldAdvanced(
+			"sender_settle_mode",
+			"The settlement mode to request for the sender of the link. With `unsettled` the sender settles a message once the receiver has accepted it, with `settled` messages are settled before they are sent, which results in at-most-once delivery, and with `mixed` either is allowed. When empty the mode chosen by the server is used, and when the server does not honour a requested mode the link fails to attach.",
+		).HasOptions("unsettled", "settled", "mixed"),
+		docs.FieldAdvanced(
+			"receiver_settle_mode",
+			"The settlement mode to request for the receiver of the link. With `first` the receiver settles a message as soon as it accepts it, and with `second` the receiver settles a message only once the sender has settled it, which is the peek-lock mode of Azure Service Bus. When empty the mode chosen by the server is used.",
+		).HasOptions("first", "second"),
+	}
+}
+
+// SettleModes returns link options that request the settlement modes of a
+// link, an empty mode results in no option.
+func SettleModes(senderMode, receiverMode string) ([]amqp.LinkOption, error) {
+	var opts []amqp.LinkOption
+	switch senderMode {
+	case "":
+	case "unsettled":
+		opts = append(opts, amqp.LinkSenderSettle(amqp.ModeUnsettled))
+	case "settled":
+		opts = append(opts, amqp.LinkSenderSettle(amqp.ModeSettled))
+	case "mixed":
+		opts = append(opts, amqp.LinkSenderSettle(amqp.ModeMixed))
+	default:
+		return nil, fmt.Errorf("sender settle mode not recognised: %v", senderMode)
+	}
+	switch receiverMode {
+	case "":
+	case "first":
+		opts = append(opts, amqp.LinkReceiverSettle(amqp.ModeFirst))
+	case "second":
+		opts = append(opts, amqp.LinkReceiverSettle(amqp.ModeSecond))
+	default:
+		return nil, fmt.Errorf("receiver settle mode not recognised: %v", receiverMode)
+	}
+	return opts, nil
+}
+
+//------------------------------------------------------------------------------
+
+// TerminusFieldSpecs returns specs for the durability fields of the terminus
+// of a link.
+func TerminusFieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldAdvanced(
+			"durability",
+			"The durability to request for the terminus of the link. With `configuration` the terminus is retained by the server after the link is detached, and with `unsettled_state` the state of unsettled messages is also retained.",
+		).HasOptions("none", "configuration", "unsettled_state"),
+		docs.FieldAdvanced(
+			"expiry_policy",
+			"The event that begins the expiry of the terminus of the link, after which the server discards it.",
+		).HasOptions("link-detach", "session-end", "connection-close", "never"),
+	}
+}
+
+// Terminus parses the durability and expiry policy of the terminus of a link.
+func Terminus(durability, expiryPolicy string) (amqp.Durability, amqp.ExpiryPolicy, error) {
+	var d amqp.Durability
+	switch durability {
+	case "none":
+		d = amqp.DurabilityNone
+	case "configuration":
+		d = amqp.DurabilityConfiguration
+	case "unsettled_state":
+		d = amqp.DurabilityUnsettledState
+	default:
+		return 0, "", fmt.Errorf("durability not recognised: %v", durability)
+	}
+	switch p := amqp.ExpiryPolicy(expiryPolicy); p {
+	case amqp.ExpiryLinkDetach, amqp.ExpirySessionEnd, amqp.ExpiryConnectionClose, amqp.ExpiryNever:
+		return d, p, nil
+	}
+	return 0, "", fmt.Errorf("expiry policy not recognised: %v", expiryPolicy)
+}
+
+//------------------------------------------------------------------------------
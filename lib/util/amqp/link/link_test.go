@@ -0,0 +1,38 @@
+package link
+
+import (
+	"testing"
+
+	"github.com/Azure/go-amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettleModes(t *testing.T) {
+	opts, err := SettleModes("", "")
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+
+	opts, err = SettleModes("unsettled", "second")
+	require.NoError(t, err)
+	assert.Len(t, opts, 2)
+
+	_, err = SettleModes("nope", "")
+	assert.Error(t, err)
+
+	_, err = SettleModes("", "nope")
+	assert.Error(t, err)
+}
+
+func TestTerminus(t *testing.T) {
+	d, p, err := Terminus("unsettled_state", "never")
+	require.NoError(t, err)
+	assert.Equal(t, amqp.DurabilityUnsettledState, d)
+	assert.Equal(t, amqp.ExpiryNever, p)
+
+	_, _, err = Terminus("nope", "never")
+	assert.Error(t, err)
+
+	_, _, err = Terminus("none", "nope")
+	assert.Error(t, err)
+}
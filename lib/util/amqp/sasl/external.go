@@ -0,0 +1,336 @@
+package sasl
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/Azure/go-amqp"
+)
+
+//------------------------------------------------------------------------------
+
+// The protocol header that begins the SASL layer of an AMQP 1.0 connection.
+var saslProtoHeader = []byte{'A', 'M', 'Q', 'P', 3, 1, 0, 0}
+
+const (
+	saslFrameType = 0x01
+
+	saslMechanismsCode = 0x40
+	saslInitCode       = 0x41
+	saslOutcomeCode    = 0x44
+
+	// The largest SASL frame that is accepted from a server.
+	saslMaxFrameSize = 64 * 1024
+)
+
+var errSASLMalformed = errors.New("malformed SASL frame")
+
+// dialExternal connects to an AMQP 1.0 server and negotiates the SASL EXTERNAL
+// mechanism, where the identity of the client is established outside of SASL,
+// usually from the certificate presented during the TLS handshake. The
+// go-amqp library does not support this mechanism and therefore the SASL layer
+// is completed before the connection is handed over to it.
+func dialExternal(addr string, tlsConf *tls.Config, opts ...amqp.ConnOption) (*amqp.Client, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	host, port := u.Hostname(), u.Port()
+	if port == "" {
+		port = "5672"
+		if u.Scheme == "amqps" {
+			port = "5671"
+		}
+	}
+
+	switch u.Scheme {
+	case "amqp", "":
+	case "amqps":
+		if tlsConf == nil {
+			tlsConf = &tls.Config{}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	dialer := &net.Dialer{Timeout: time.Minute}
+	conn, err := dialer.Dial("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+	if tlsConf != nil {
+		if tlsConf.ServerName == "" && !tlsConf.InsecureSkipVerify {
+			tlsConf = tlsConf.Clone()
+			tlsConf.ServerName = host
+		}
+		conn = tls.Client(conn, tlsConf)
+	}
+
+	conn.SetDeadline(time.Now().Add(time.Minute))
+	if err = negotiateExternal(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	return amqp.New(conn, append([]amqp.ConnOption{
+		amqp.ConnServerHostname(host),
+	}, opts...)...)
+}
+
+// negotiateExternal performs the SASL layer of a connection with the EXTERNAL
+// mechanism.
+func negotiateExternal(conn io.ReadWriter) error {
+	if _, err := conn.Write(saslProtoHeader); err != nil {
+		return err
+	}
+
+	header := make([]byte, len(saslProtoHeader))
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if string(header) != string(saslProtoHeader) {
+		return fmt.Errorf("unexpected protocol header from server: %q", header)
+	}
+
+	code, body, err := readSASLFrame(conn)
+	if err != nil {
+		return err
+	}
+	if code != saslMechanismsCode {
+		return fmt.Errorf("expected SASL mechanisms from server, received frame with code %#x", code)
+	}
+	mechanisms, err := body.symbols()
+	if err != nil {
+		return err
+	}
+	supported := false
+	for _, m := range mechanisms {
+		if m == "EXTERNAL" {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("server does not support SASL mechanism EXTERNAL, supported mechanisms: %v", mechanisms)
+	}
+
+	// The initial response of EXTERNAL is an authorization identity, which is
+	// left empty in order to use the identity established by the transport.
+	init := []byte{
+		0x00, 0x53, saslInitCode,
+		0xc0, 13, 2,
+		0xa3, 8, 'E', 'X', 'T', 'E', 'R', 'N', 'A', 'L',
+		0xa0, 0,
+	}
+	frame := make([]byte, 8, 8+len(init))
+	binary.BigEndian.PutUint32(frame, uint32(8+len(init)))
+	frame[4], frame[5] = 2, saslFrameType
+	if _, err = conn.Write(append(frame, init...)); err != nil {
+		return err
+	}
+
+	if code, body, err = readSASLFrame(conn); err != nil {
+		return err
+	}
+	if code != saslOutcomeCode {
+		return fmt.Errorf("expected SASL outcome from server, received frame with code %#x", code)
+	}
+	outcome, err := body.ubyte()
+	if err != nil {
+		return err
+	}
+	if outcome != 0 {
+		return fmt.Errorf("SASL EXTERNAL authentication failed with code %v", outcome)
+	}
+	return nil
+}
+
+// readSASLFrame reads the next SASL frame from a server, returning the code of
+// the performative and a decoder positioned at its first field.
+func readSASLFrame(r io.Reader) (uint64, *saslDecoder, error) {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return 0, nil, err
+		}
+		size := binary.BigEndian.Uint32(header)
+		doff := uint32(header[4]) * 4
+		if size > saslMaxFrameSize || doff < 8 || size < doff {
+			return 0, nil, errSASLMalformed
+		}
+		if header[5] != saslFrameType {
+			return 0, nil, fmt.Errorf("expected SASL frame, received frame type %#x", header[5])
+		}
+		frame := make([]byte, size-8)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return 0, nil, err
+		}
+		if size == doff {
+			// Empty frames are heartbeats.
+			continue
+		}
+
+		d := &saslDecoder{b: frame[doff-8:]}
+		code, err := d.performative()
+		return code, d, err
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// saslDecoder decodes the few AMQP types that appear within the SASL frames
+// sent by a server.
+type saslDecoder struct {
+	b []byte
+}
+
+func (d *saslDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.b) < n {
+		return nil, errSASLMalformed
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b, nil
+}
+
+func (d *saslDecoder) length(width int) (int, error) {
+	b, err := d.next(width)
+	if err != nil {
+		return 0, err
+	}
+	if width == 1 {
+		return int(b[0]), nil
+	}
+	return int(binary.BigEndian.Uint32(b)), nil
+}
+
+// performative reads the descriptor of a performative and the header of its
+// field list.
+func (d *saslDecoder) performative() (uint64, error) {
+	b, err := d.next(2)
+	if err != nil {
+		return 0, err
+	}
+	if b[0] != 0x00 {
+		return 0, errSASLMalformed
+	}
+
+	var code uint64
+	switch b[1] {
+	case 0x53:
+		if b, err = d.next(1); err != nil {
+			return 0, err
+		}
+		code = uint64(b[0])
+	case 0x80:
+		if b, err = d.next(8); err != nil {
+			return 0, err
+		}
+		code = binary.BigEndian.Uint64(b)
+	default:
+		return 0, errSASLMalformed
+	}
+
+	if b, err = d.next(1); err != nil {
+		return 0, err
+	}
+	switch b[0] {
+	case 0x45:
+		d.b = nil
+	case 0xc0:
+		_, err = d.next(2)
+	case 0xd0:
+		_, err = d.next(8)
+	default:
+		err = errSASLMalformed
+	}
+	return code, err
+}
+
+// symbols reads a field that is either a single symbol or an array of them.
+func (d *saslDecoder) symbols() ([]string, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	var count, width int
+	switch b[0] {
+	case 0xa3, 0xb3:
+		s, err := d.symbol(b[0])
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	case 0xe0:
+		width = 1
+	case 0xf0:
+		width = 4
+	default:
+		return nil, errSASLMalformed
+	}
+
+	if _, err = d.length(width); err != nil {
+		return nil, err
+	}
+	if count, err = d.length(width); err != nil {
+		return nil, err
+	}
+	if b, err = d.next(1); err != nil {
+		return nil, err
+	}
+	constructor := b[0]
+
+	symbols := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		s, err := d.symbol(constructor)
+		if err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, s)
+	}
+	return symbols, nil
+}
+
+func (d *saslDecoder) symbol(constructor byte) (string, error) {
+	width := 1
+	switch constructor {
+	case 0xa3:
+	case 0xb3:
+		width = 4
+	default:
+		return "", errSASLMalformed
+	}
+	n, err := d.length(width)
+	if err != nil {
+		return "", err
+	}
+	b, err := d.next(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (d *saslDecoder) ubyte() (uint8, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+	if b[0] != 0x50 {
+		return 0, errSASLMalformed
+	}
+	if b, err = d.next(1); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+//------------------------------------------------------------------------------
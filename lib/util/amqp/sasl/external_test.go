@@ -0,0 +1,136 @@
+package sasl
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saslTestFrame(body ...byte) []byte {
+	frame := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(frame, uint32(8+len(body)))
+	frame[4], frame[5] = 2, saslFrameType
+	return append(frame, body...)
+}
+
+func saslTestServer(conn net.Conn, mechanisms, outcome []byte) <-chan []byte {
+	initC := make(chan []byte, 1)
+	go func() {
+		defer conn.Close()
+
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		if _, err := conn.Write(saslProtoHeader); err != nil {
+			return
+		}
+
+		// A heartbeat precedes the mechanisms.
+		if _, err := conn.Write(append(saslTestFrame(), mechanisms...)); err != nil {
+			return
+		}
+
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		init := make([]byte, binary.BigEndian.Uint32(header)-8)
+		if _, err := io.ReadFull(conn, init); err != nil {
+			return
+		}
+		initC <- init
+		if outcome != nil {
+			conn.Write(outcome)
+		}
+	}()
+	return initC
+}
+
+func TestNegotiateExternal(t *testing.T) {
+	tests := map[string]struct {
+		mechanisms  []byte
+		outcome     []byte
+		errContains string
+	}{
+		"array of mechanisms": {
+			mechanisms: saslTestFrame(
+				0x00, 0x53, 0x40, 0xc0, 23, 1,
+				0xe0, 20, 2, 0xa3,
+				5, 'P', 'L', 'A', 'I', 'N',
+				8, 'E', 'X', 'T', 'E', 'R', 'N', 'A', 'L',
+			),
+			outcome: saslTestFrame(0x00, 0x53, 0x44, 0xc0, 3, 1, 0x50, 0),
+		},
+		"single mechanism": {
+			mechanisms: saslTestFrame(
+				0x00, 0x53, 0x40, 0xc0, 11, 1,
+				0xa3, 8, 'E', 'X', 'T', 'E', 'R', 'N', 'A', 'L',
+			),
+			outcome: saslTestFrame(0x00, 0x53, 0x44, 0xc0, 3, 1, 0x50, 0),
+		},
+		"authentication failed": {
+			mechanisms: saslTestFrame(
+				0x00, 0x53, 0x40, 0xc0, 11, 1,
+				0xa3, 8, 'E', 'X', 'T', 'E', 'R', 'N', 'A', 'L',
+			),
+			outcome:     saslTestFrame(0x00, 0x53, 0x44, 0xc0, 3, 1, 0x50, 1),
+			errContains: "failed with code 1",
+		},
+		"not supported": {
+			mechanisms: saslTestFrame(
+				0x00, 0x53, 0x40, 0xc0, 8, 1,
+				0xa3, 5, 'P', 'L', 'A', 'I', 'N',
+			),
+			errContains: "does not support",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+
+			initC := saslTestServer(server, test.mechanisms, test.outcome)
+			err := negotiateExternal(client)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+
+			init := <-initC
+			d := &saslDecoder{b: init}
+			code, err := d.performative()
+			require.NoError(t, err)
+			assert.Equal(t, uint64(saslInitCode), code)
+
+			mechanisms, err := d.symbols()
+			require.NoError(t, err)
+			assert.Equal(t, []string{"EXTERNAL"}, mechanisms)
+		})
+	}
+}
+
+func TestSASLDecoderMalformed(t *testing.T) {
+	for _, b := range [][]byte{
+		{},
+		{0x01, 0x53, 0x40, 0x45},
+		{0x00, 0x53},
+		{0x00, 0x53, 0x40, 0xc0},
+	} {
+		_, err := (&saslDecoder{b: b}).performative()
+		assert.Error(t, err, "%v", b)
+	}
+
+	_, err := (&saslDecoder{b: []byte{0xe0, 20, 5, 0xa3, 3, 'f'}}).symbols()
+	assert.Error(t, err)
+
+	_, err = (&saslDecoder{b: []byte{0x51, 0}}).ubyte()
+	assert.Error(t, err)
+}
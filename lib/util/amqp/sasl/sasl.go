@@ -1,6 +1,7 @@
 package sasl
 
 import (
+	"crypto/tls"
 	"fmt"
 
 	"github.com/Azure/go-amqp"
@@ -32,21 +33,43 @@ func NewConfig() Config {
 // FieldSpec returns specs for SASL fields.
 func FieldSpec() docs.FieldSpec {
 	return docs.FieldAdvanced("sasl", "Enables SASL authentication.").WithChildren(
-		docs.FieldCommon("mechanism", "The SASL authentication mechanism to use.").HasOptions("none", "plain"),
+		docs.FieldCommon("mechanism", "The SASL authentication mechanism to use. The `external` mechanism authenticates with the identity established by the transport, usually the client certificate configured within the `tls` field.").HasOptions("none", "plain", "anonymous", "external"),
 		docs.FieldCommon("user", "A SASL plain text username. It is recommended that you use environment variables to populate this field.", "${USER}"),
 		docs.FieldCommon("password", "A SASL plain text password. It is recommended that you use environment variables to populate this field.", "${PASSWORD}"),
 	)
 }
 
-// ToOptFns renders the sasl.Config options into amqp.ConnOption fns.
+// ToOptFns renders the sasl.Config options into amqp.ConnOption fns. The
+// external mechanism has no options as it is negotiated by Dial.
 func (s Config) ToOptFns() ([]amqp.ConnOption, error) {
 	switch s.Mechanism {
 	case "plain":
 		return []amqp.ConnOption{
 			amqp.ConnSASLPlain(s.User, s.Password),
 		}, nil
-	case "none":
+	case "anonymous":
+		return []amqp.ConnOption{
+			amqp.ConnSASLAnonymous(),
+		}, nil
+	case "none", "external":
 		return nil, nil
 	}
 	return nil, ErrMechanismNotSupported(s.Mechanism)
 }
+
+// Dial connects to an AMQP 1.0 server at a URL and authenticates with the
+// configured mechanism. When tlsConf is not nil the connection is made with
+// TLS.
+func (s Config) Dial(url string, tlsConf *tls.Config, opts ...amqp.ConnOption) (*amqp.Client, error) {
+	saslOpts, err := s.ToOptFns()
+	if err != nil {
+		return nil, err
+	}
+	if s.Mechanism == "external" {
+		return dialExternal(url, tlsConf, opts...)
+	}
+	if tlsConf != nil {
+		opts = append(opts, amqp.ConnTLS(true), amqp.ConnTLSConfig(tlsConf))
+	}
+	return amqp.Dial(url, append(saslOpts, opts...)...)
+}
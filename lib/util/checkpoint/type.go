@@ -1,6 +1,9 @@
 package checkpoint
 
-import "errors"
+import (
+	"errors"
+	"sort"
+)
 
 // ErrOutOfSync is returned when an offset to be tracked is less than or equal
 // to the current highest tracked offset.
@@ -15,11 +18,10 @@ var ErrResolvedOffsetNotTracked = errors.New("resolved offset was not tracked")
 // return the highest offset currently able to be committed such that an
 // unresolved offset is never committed.
 type Type struct {
-	base    int
-	edge    int
-	pending []int
+	edge     int
+	tracked  []int
+	resolved []bool
 
-	highestBlocked  int
 	highestResolved int
 }
 
@@ -27,6 +29,7 @@ type Type struct {
 // returned when no checkpoints have yet been resolved. The base can be zero.
 func New(base int) *Type {
 	return &Type{
+		edge:            base,
 		highestResolved: base,
 	}
 }
@@ -36,6 +39,17 @@ func (t *Type) Highest() int {
 	return t.highestResolved
 }
 
+// Pending returns the number of tracked offsets that are yet to be resolved.
+func (t *Type) Pending() int {
+	var n int
+	for _, r := range t.resolved {
+		if !r {
+			n++
+		}
+	}
+	return n
+}
+
 // Track a new unresolved integer offset. This offset will be cached until it is
 // marked as resolved. While it is cached no higher valued offset will ever be
 // committed. If the provided value is lower than an already provided value an
@@ -44,11 +58,8 @@ func (t *Type) Track(i int) error {
 	if t.edge >= i {
 		return ErrOutOfSync
 	}
-	if len(t.pending) == 0 && t.base == 0 {
-		t.base = i
-	} else {
-		t.pending = append(t.pending, i-t.edge)
-	}
+	t.tracked = append(t.tracked, i)
+	t.resolved = append(t.resolved, false)
 	t.edge = i
 	return nil
 }
@@ -64,67 +75,22 @@ func (t *Type) MustTrack(i int) {
 // offset to be committed is returned, or an error if the provided offset was
 // not recognised.
 func (t *Type) Resolve(offset int) (int, error) {
-	// If our resolved offset is also the base then it can be immediately
-	// resolved as there are no pending lower offsets.
-	if offset == t.base {
-		t.highestResolved = offset
-		if len(t.pending) == 0 {
-			t.base = 0
-			t.edge = 0
-			if t.highestBlocked > t.highestResolved {
-				t.highestResolved = t.highestBlocked
-			}
-		} else {
-			t.base = t.base + t.pending[0]
-			for i := 0; i < len(t.pending)-1; i++ {
-				t.pending[i] = t.pending[i+1]
-			}
-			t.pending = t.pending[:len(t.pending)-1]
-		}
-		return t.highestResolved, nil
+	i := sort.SearchInts(t.tracked, offset)
+	if i == len(t.tracked) || t.tracked[i] != offset || t.resolved[i] {
+		return 0, ErrResolvedOffsetNotTracked
 	}
-
-	// Otherwise we cannot commit this resolved offset and must only remove it
-	// from our pending list.
-
-	// Best case scenario, our resolved offset is our highest pending offset, so
-	// we can simply remove it from the edge.
-	if offset == t.edge {
-		t.edge = t.edge - t.pending[len(t.pending)]
-
-		// Second best case scenario, all pending offsets are exactly 1 larger
-		// than the previous, so we can avoid iterating all values to find our
-		// resolving offset.
-	} else if t.edge == t.base+len(t.pending) {
-		t.edge = t.edge - 1
-		t.pending = t.pending[:len(t.pending)-1]
-
-		// Worst case scenario as we're forced to iterate the pending slice
-		// until we reach our target offset. Then we must remove it and adjust
-		// all following values.
-	} else {
-		currentPendingValue := t.base
-		var i int
-		for ; i < len(t.pending)-1; i++ {
-			currentPendingValue += t.pending[i]
-			if offset == currentPendingValue {
-				// We found the element representing our offset, replace it with
-				// the next value.
-				t.pending[i] = t.pending[i+1] + t.pending[i]
-				break
-			}
-		}
-		if offset == currentPendingValue {
-			return 0, ErrResolvedOffsetNotTracked
-		}
-		for i++; i < len(t.pending)-1; i++ {
-			t.pending[i] = t.pending[i+1]
-		}
-		t.pending = t.pending[:len(t.pending)-1]
+	t.resolved[i] = true
+
+	// Pop all resolved offsets from the front of the tracked list, the last of
+	// which is our new highest checkpoint.
+	var n int
+	for n < len(t.resolved) && t.resolved[n] {
+		t.highestResolved = t.tracked[n]
+		n++
 	}
-
-	if t.highestBlocked < offset {
-		t.highestBlocked = offset
+	if n > 0 {
+		t.tracked = t.tracked[n:]
+		t.resolved = t.resolved[n:]
 	}
 	return t.highestResolved, nil
 }
@@ -1 +1,72 @@
 package checkpoint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointSequential(t *testing.T) {
+	c := New(0)
+	assert.Equal(t, 0, c.Highest())
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, c.Track(i))
+	}
+	assert.Equal(t, 5, c.Pending())
+
+	for i := 1; i <= 5; i++ {
+		highest, err := c.Resolve(i)
+		require.NoError(t, err)
+		assert.Equal(t, i, highest)
+	}
+	assert.Equal(t, 0, c.Pending())
+	assert.Equal(t, 5, c.Highest())
+}
+
+func TestCheckpointOutOfOrder(t *testing.T) {
+	c := New(10)
+
+	for _, i := range []int{11, 13, 20, 21} {
+		require.NoError(t, c.Track(i))
+	}
+
+	steps := []struct {
+		resolve int
+		highest int
+	}{
+		{resolve: 20, highest: 10},
+		{resolve: 13, highest: 10},
+		{resolve: 11, highest: 20},
+		{resolve: 21, highest: 21},
+	}
+	for _, step := range steps {
+		highest, err := c.Resolve(step.resolve)
+		require.NoError(t, err, step.resolve)
+		assert.Equal(t, step.highest, highest, step.resolve)
+	}
+	assert.Equal(t, 0, c.Pending())
+}
+
+func TestCheckpointErrors(t *testing.T) {
+	c := New(0)
+
+	assert.Equal(t, ErrOutOfSync, c.Track(0))
+	require.NoError(t, c.Track(5))
+	assert.Equal(t, ErrOutOfSync, c.Track(5))
+	assert.Equal(t, ErrOutOfSync, c.Track(3))
+
+	_, err := c.Resolve(4)
+	assert.Equal(t, ErrResolvedOffsetNotTracked, err)
+
+	require.NoError(t, c.Track(6))
+	c.MustResolve(6)
+
+	_, err = c.Resolve(6)
+	assert.Equal(t, ErrResolvedOffsetNotTracked, err)
+	assert.Equal(t, 0, c.Highest())
+
+	assert.Equal(t, 6, c.MustResolve(5))
+	assert.Equal(t, ErrOutOfSync, c.Track(6))
+}
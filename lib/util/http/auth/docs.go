@@ -14,6 +14,21 @@ func BasicAuthFieldSpec() docs.FieldSpec {
 	)
 }
 
+// OAuth2FieldSpec returns a field spec for OAuth2 client credentials
+// authentication.
+func OAuth2FieldSpec() docs.FieldSpec {
+	return docs.FieldAdvanced("oauth2",
+		"Allows you to specify OAuth2 authentication using the client credentials flow. Access tokens are obtained from the `token_url` and are refreshed automatically once they expire.",
+		map[string]interface{}{
+			"enabled":       true,
+			"client_key":    "foo",
+			"client_secret": "bar",
+			"token_url":     "https://example.com/oauth2/token",
+			"scopes":        []string{"read"},
+		},
+	)
+}
+
 // FieldSpecs returns a map of field specs for an auth type.
 func FieldSpecs() docs.FieldSpecs {
 	return docs.FieldSpecs{
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+//------------------------------------------------------------------------------
+
+// OAuth2Config holds the configuration parameters for an OAuth2 client
+// credentials exchange.
+type OAuth2Config struct {
+	Enabled        bool                `json:"enabled" yaml:"enabled"`
+	ClientKey      string              `json:"client_key" yaml:"client_key"`
+	ClientSecret   string              `json:"client_secret" yaml:"client_secret"`
+	TokenURL       string              `json:"token_url" yaml:"token_url"`
+	Scopes         []string            `json:"scopes" yaml:"scopes"`
+	EndpointParams map[string][]string `json:"endpoint_params" yaml:"endpoint_params"`
+}
+
+// NewOAuth2Config returns a new OAuth2Config with default values.
+func NewOAuth2Config() OAuth2Config {
+	return OAuth2Config{
+		Enabled:        false,
+		ClientKey:      "",
+		ClientSecret:   "",
+		TokenURL:       "",
+		Scopes:         []string{},
+		EndpointParams: map[string][]string{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// TokenSource returns a source of access tokens obtained with the client
+// credentials flow, where tokens are cached and automatically refreshed once
+// they expire. Token requests are made with base, which when nil is
+// http.DefaultTransport.
+func (oauth OAuth2Config) TokenSource(base http.RoundTripper) oauth2.TokenSource {
+	conf := &clientcredentials.Config{
+		ClientID:       oauth.ClientKey,
+		ClientSecret:   oauth.ClientSecret,
+		TokenURL:       oauth.TokenURL,
+		Scopes:         oauth.Scopes,
+		EndpointParams: url.Values(oauth.EndpointParams),
+	}
+
+	ctx := context.Background()
+	if base != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: base})
+	}
+	return conf.TokenSource(ctx)
+}
+
+// RoundTripper returns a round tripper that adds an access token from
+// TokenSource to each request before passing it to base.
+func (oauth OAuth2Config) RoundTripper(base http.RoundTripper) http.RoundTripper {
+	if !oauth.Enabled {
+		return base
+	}
+	return &oauth2.Transport{
+		Source: oauth.TokenSource(base),
+		Base:   base,
+	}
+}
+
+//------------------------------------------------------------------------------
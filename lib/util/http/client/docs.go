@@ -16,9 +16,14 @@ func FieldSpecs() docs.FieldSpecs {
 		}).HasType("object").SupportsInterpolation(false),
 	}
 	httpSpecs = append(httpSpecs, auth.FieldSpecs()...)
+	httpSpecs = append(httpSpecs, auth.OAuth2FieldSpec())
 	httpSpecs = append(httpSpecs, tls.FieldSpec())
 	httpSpecs = append(httpSpecs,
 		docs.FieldAdvanced("copy_response_headers", "Sets whether to copy the headers from the response to the resulting payload.").HasType("bool"),
+		docs.FieldAdvanced("extract_headers", "Specify rules for selecting the headers from the response that are copied to the resulting payload as metadata, which is useful when only some headers are needed. Header names are lower cased before they are matched.").WithChildren(
+			docs.FieldCommon("include_prefixes", "A list of header name prefixes to match against, which are case insensitive.", []string{"x-ratelimit-"}).HasType("array"),
+			docs.FieldCommon("include_patterns", "A list of regular expressions to match header names against.", []string{"^x-request-id$", "^etag$"}).HasType("array"),
+		).HasType("object"),
 		docs.FieldCommon("rate_limit", "An optional [rate limit](/docs/components/rate_limits/about) to throttle requests by.").HasType("string"),
 		docs.FieldCommon("timeout", "A static timeout to apply to requests.").HasType("string"),
 		docs.FieldAdvanced("retry_period", "The base period to wait between failed requests.").HasType("string"),
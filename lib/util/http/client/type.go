@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/textproto"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,22 +31,40 @@ import (
 
 //------------------------------------------------------------------------------
 
+// ExtractHeadersConfig contains configuration fields for selecting the headers
+// of responses that are copied to the resulting payload as metadata.
+type ExtractHeadersConfig struct {
+	IncludePrefixes []string `json:"include_prefixes" yaml:"include_prefixes"`
+	IncludePatterns []string `json:"include_patterns" yaml:"include_patterns"`
+}
+
+// NewExtractHeadersConfig creates a new ExtractHeadersConfig with default
+// values.
+func NewExtractHeadersConfig() ExtractHeadersConfig {
+	return ExtractHeadersConfig{
+		IncludePrefixes: []string{},
+		IncludePatterns: []string{},
+	}
+}
+
 // Config is a configuration struct for an HTTP client.
 type Config struct {
-	URL                 string            `json:"url" yaml:"url"`
-	Verb                string            `json:"verb" yaml:"verb"`
-	Headers             map[string]string `json:"headers" yaml:"headers"`
-	CopyResponseHeaders bool              `json:"copy_response_headers" yaml:"copy_response_headers"`
-	RateLimit           string            `json:"rate_limit" yaml:"rate_limit"`
-	Timeout             string            `json:"timeout" yaml:"timeout"`
-	Retry               string            `json:"retry_period" yaml:"retry_period"`
-	MaxBackoff          string            `json:"max_retry_backoff" yaml:"max_retry_backoff"`
-	NumRetries          int               `json:"retries" yaml:"retries"`
-	BackoffOn           []int             `json:"backoff_on" yaml:"backoff_on"`
-	DropOn              []int             `json:"drop_on" yaml:"drop_on"`
-	SuccessfulOn        []int             `json:"successful_on" yaml:"successful_on"`
-	TLS                 tls.Config        `json:"tls" yaml:"tls"`
-	ProxyURL            string            `json:"proxy_url" yaml:"proxy_url"`
+	URL                 string               `json:"url" yaml:"url"`
+	Verb                string               `json:"verb" yaml:"verb"`
+	Headers             map[string]string    `json:"headers" yaml:"headers"`
+	CopyResponseHeaders bool                 `json:"copy_response_headers" yaml:"copy_response_headers"`
+	ExtractHeaders      ExtractHeadersConfig `json:"extract_headers" yaml:"extract_headers"`
+	RateLimit           string               `json:"rate_limit" yaml:"rate_limit"`
+	Timeout             string               `json:"timeout" yaml:"timeout"`
+	Retry               string               `json:"retry_period" yaml:"retry_period"`
+	MaxBackoff          string               `json:"max_retry_backoff" yaml:"max_retry_backoff"`
+	NumRetries          int                  `json:"retries" yaml:"retries"`
+	BackoffOn           []int                `json:"backoff_on" yaml:"backoff_on"`
+	DropOn              []int                `json:"drop_on" yaml:"drop_on"`
+	SuccessfulOn        []int                `json:"successful_on" yaml:"successful_on"`
+	TLS                 tls.Config           `json:"tls" yaml:"tls"`
+	ProxyURL            string               `json:"proxy_url" yaml:"proxy_url"`
+	OAuth2              auth.OAuth2Config    `json:"oauth2" yaml:"oauth2"`
 	auth.Config         `json:",inline" yaml:",inline"`
 }
 
@@ -58,6 +77,7 @@ func NewConfig() Config {
 			"Content-Type": "application/octet-stream",
 		},
 		CopyResponseHeaders: false,
+		ExtractHeaders:      NewExtractHeadersConfig(),
 		RateLimit:           "",
 		Timeout:             "5s",
 		Retry:               "1s",
@@ -67,6 +87,7 @@ func NewConfig() Config {
 		DropOn:              []int{},
 		SuccessfulOn:        []int{},
 		TLS:                 tls.NewConfig(),
+		OAuth2:              auth.NewOAuth2Config(),
 		Config:              auth.NewConfig(),
 	}
 }
@@ -85,6 +106,9 @@ type Type struct {
 	headers map[string]field.Expression
 	host    field.Expression
 
+	extractPrefixes []string
+	extractPatterns []*regexp.Regexp
+
 	conf          Config
 	retryThrottle *throttle.Type
 	rateLimit     types.RateLimit
@@ -129,6 +153,17 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		host:      nil,
 	}
 
+	for _, prefix := range conf.ExtractHeaders.IncludePrefixes {
+		h.extractPrefixes = append(h.extractPrefixes, strings.ToLower(prefix))
+	}
+	for _, pattern := range conf.ExtractHeaders.IncludePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile header pattern '%v': %v", pattern, err)
+		}
+		h.extractPatterns = append(h.extractPatterns, re)
+	}
+
 	if tout := conf.Timeout; len(tout) > 0 {
 		var err error
 		if h.client.Timeout, err = time.ParseDuration(tout); err != nil {
@@ -164,6 +199,13 @@ func New(conf Config, opts ...func(*Type)) (*Type, error) {
 		}
 	}
 
+	if h.conf.OAuth2.Enabled {
+		if len(h.conf.OAuth2.TokenURL) == 0 {
+			return nil, fmt.Errorf("an oauth2 token_url must be specified")
+		}
+		h.client.Transport = h.conf.OAuth2.RoundTripper(h.client.Transport)
+	}
+
 	for _, c := range conf.BackoffOn {
 		h.backoffOn[c] = struct{}{}
 	}
@@ -332,8 +374,10 @@ func (h *Type) waitForAccess() bool {
 
 // CreateRequest creates an HTTP request out of a single message.
 func (h *Type) CreateRequest(msg types.Message) (req *http.Request, err error) {
-	url := h.url.String(0, msg)
+	return h.createRequest(h.url.String(0, msg), msg)
+}
 
+func (h *Type) createRequest(url string, msg types.Message) (req *http.Request, err error) {
 	if msg == nil || msg.Len() == 0 {
 		if req, err = http.NewRequest(h.conf.Verb, url, nil); err == nil {
 			for k, v := range h.headers {
@@ -439,14 +483,7 @@ func (h *Type) ParseResponse(res *http.Response) (resMsg types.Message, err erro
 				index := resMsg.Append(message.NewPart(buffer.Bytes()[bufferIndex : bufferIndex+bytesRead]))
 				bufferIndex += bytesRead
 
-				if h.conf.CopyResponseHeaders {
-					meta := resMsg.Get(index).Metadata()
-					for k, values := range p.Header {
-						if len(values) > 0 {
-							meta.Set(strings.ToLower(k), values[0])
-						}
-					}
-				}
+				h.extractHeaders(resMsg.Get(index).Metadata(), p.Header)
 			}
 		} else {
 			var bytesRead int64
@@ -461,14 +498,7 @@ func (h *Type) ParseResponse(res *http.Response) (resMsg types.Message, err erro
 			} else {
 				resMsg.Append(message.NewPart(nil))
 			}
-			if h.conf.CopyResponseHeaders {
-				meta := resMsg.Get(0).Metadata()
-				for k, values := range res.Header {
-					if len(values) > 0 {
-						meta.Set(strings.ToLower(k), values[0])
-					}
-				}
-			}
+			h.extractHeaders(resMsg.Get(0).Metadata(), res.Header)
 		}
 	} else {
 		resMsg.Append(message.NewPart(nil))
@@ -481,6 +511,34 @@ func (h *Type) ParseResponse(res *http.Response) (resMsg types.Message, err erro
 	return
 }
 
+// extractHeaders copies the headers of a response that are selected for
+// extraction to the metadata of a resulting message.
+func (h *Type) extractHeaders(meta types.Metadata, header map[string][]string) {
+	for k, values := range header {
+		if len(values) == 0 {
+			continue
+		}
+		key := strings.ToLower(k)
+		if h.conf.CopyResponseHeaders || h.shouldExtract(key) {
+			meta.Set(key, values[0])
+		}
+	}
+}
+
+func (h *Type) shouldExtract(key string) bool {
+	for _, prefix := range h.extractPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	for _, re := range h.extractPatterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
 type retryStrategy int
 
 const (
@@ -512,8 +570,22 @@ func (h *Type) checkStatus(code int) (succeeded bool, retStrat retryStrategy) {
 // This attempt may include retries, and if all retries fail an error is
 // returned.
 func (h *Type) Do(msg types.Message) (res *http.Response, err error) {
+	return h.DoWithURL("", msg)
+}
+
+// DoWithURL attempts to create and perform an HTTP request from a message
+// payload the same as Do, but the request is made to a URL that overrides the
+// configured one. If the URL is empty the configured one is used.
+func (h *Type) DoWithURL(url string, msg types.Message) (res *http.Response, err error) {
 	h.mCount.Incr(1)
 
+	createRequest := h.CreateRequest
+	if len(url) > 0 {
+		createRequest = func(msg types.Message) (*http.Request, error) {
+			return h.createRequest(url, msg)
+		}
+	}
+
 	var spans []opentracing.Span
 	if msg != nil {
 		spans = make([]opentracing.Span, msg.Len())
@@ -537,7 +609,7 @@ func (h *Type) Do(msg types.Message) (res *http.Response, err error) {
 	}
 
 	var req *http.Request
-	if req, err = h.CreateRequest(msg); err != nil {
+	if req, err = createRequest(msg); err != nil {
 		h.mErrReq.Incr(1)
 		h.mErr.Incr(1)
 		logErr(err)
@@ -574,7 +646,7 @@ func (h *Type) Do(msg types.Message) (res *http.Response, err error) {
 		h.mErr.Incr(1)
 		logErr(err)
 
-		req, err = h.CreateRequest(msg)
+		req, err = createRequest(msg)
 		if err != nil {
 			h.mErrReq.Incr(1)
 			h.mErr.Incr(1)
@@ -576,3 +576,61 @@ func TestHTTPClientReceiveMultipartWithHeaders(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestHTTPClientOAuth2(t *testing.T) {
+	var tokenCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if exp, act := "client_credentials", r.FormValue("grant_type"); exp != act {
+				t.Errorf("Wrong grant type: %v != %v", act, exp)
+			}
+			if user, pass, _ := r.BasicAuth(); user != "foo" || pass != "bar" {
+				t.Errorf("Wrong client credentials: %v:%v", user, pass)
+			}
+			n := atomic.AddUint32(&tokenCount, 1)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"token%v","token_type":"bearer","expires_in":11}`, n)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.OAuth2.Enabled = true
+	conf.OAuth2.ClientKey = "foo"
+	conf.OAuth2.ClientSecret = "bar"
+	conf.OAuth2.TokenURL = ts.URL + "/token"
+
+	h, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{"Bearer token1", "Bearer token1"} {
+		resMsg, err := h.Send(message.New([][]byte{[]byte("test")}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := string(resMsg.Get(0).Get()); exp != act {
+			t.Errorf("Wrong authorization header: %v != %v", act, exp)
+		}
+	}
+
+	// Tokens are refreshed ten seconds before they expire.
+	<-time.After(time.Millisecond * 1100)
+
+	resMsg, err := h.Send(message.New([][]byte{[]byte("test")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "Bearer token2", string(resMsg.Get(0).Get()); exp != act {
+		t.Errorf("Wrong authorization header: %v != %v", act, exp)
+	}
+
+	conf.OAuth2.TokenURL = ""
+	if _, err = New(conf); err == nil {
+		t.Error("Expected error from missing token URL")
+	}
+}
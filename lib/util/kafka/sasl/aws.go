@@ -0,0 +1,78 @@
+package sasl
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/util/aws/session"
+	"github.com/Shopify/sarama"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// SASLTypeAWSMSKIAM is a SASL mechanism that authenticates against AWS MSK
+// clusters using IAM credentials. Signed tokens are delivered to the brokers
+// via the OAUTHBEARER mechanism.
+const SASLTypeAWSMSKIAM = "AWS_MSK_IAM"
+
+const (
+	mskIAMService     = "kafka-cluster"
+	mskIAMAction      = "kafka-cluster:Connect"
+	mskIAMUserAgent   = "benthos"
+	mskIAMTokenExpiry = time.Minute * 15
+)
+
+//------------------------------------------------------------------------------
+
+// mskIAMAccessTokenProvider generates SASL OAUTHBEARER access tokens for AWS
+// MSK IAM authentication. A fresh token is signed each time one is requested,
+// which happens each time a new broker connection is established.
+type mskIAMAccessTokenProvider struct {
+	region string
+	signer *v4.Signer
+	now    func() time.Time
+}
+
+func newMSKIAMAccessTokenProvider(conf session.Config) (*mskIAMAccessTokenProvider, error) {
+	sess, err := conf.GetSession()
+	if err != nil {
+		return nil, err
+	}
+	if sess.Config.Region == nil || *sess.Config.Region == "" {
+		return nil, fmt.Errorf("an AWS region is required for the %v mechanism", SASLTypeAWSMSKIAM)
+	}
+	return &mskIAMAccessTokenProvider{
+		region: *sess.Config.Region,
+		signer: v4.NewSigner(sess.Config.Credentials),
+		now:    time.Now,
+	}, nil
+}
+
+func (m *mskIAMAccessTokenProvider) Token() (*sarama.AccessToken, error) {
+	endpoint := &url.URL{
+		Scheme:   "https",
+		Host:     fmt.Sprintf("kafka.%v.amazonaws.com", m.region),
+		Path:     "/",
+		RawQuery: url.Values{"Action": []string{mskIAMAction}}.Encode(),
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = m.signer.Presign(req, nil, mskIAMService, m.region, mskIAMTokenExpiry, m.now().UTC()); err != nil {
+		return nil, fmt.Errorf("failed to sign MSK IAM token: %w", err)
+	}
+
+	query := req.URL.Query()
+	query.Set("User-Agent", mskIAMUserAgent)
+	req.URL.RawQuery = query.Encode()
+
+	return &sarama.AccessToken{
+		Token: base64.RawURLEncoding.EncodeToString([]byte(req.URL.String())),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
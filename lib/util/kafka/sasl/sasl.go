@@ -5,6 +5,7 @@ import (
 
 	"github.com/Jeffail/benthos/v3/internal/docs"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	"github.com/Jeffail/benthos/v3/lib/util/aws/session"
 	"github.com/Shopify/sarama"
 )
 
@@ -16,30 +17,34 @@ var (
 // Config contains configuration for SASL based authentication.
 // TODO: V4 Remove "enabled" and set a default mechanism
 type Config struct {
-	Enabled     bool   `json:"enabled" yaml:"enabled"` // DEPRECATED
-	Mechanism   string `json:"mechanism" yaml:"mechanism"`
-	User        string `json:"user" yaml:"user"`
-	Password    string `json:"password" yaml:"password"`
-	AccessToken string `json:"access_token" yaml:"access_token"`
-	TokenCache  string `json:"token_cache" yaml:"token_cache"`
-	TokenKey    string `json:"token_key" yaml:"token_key"`
+	Enabled     bool           `json:"enabled" yaml:"enabled"` // DEPRECATED
+	Mechanism   string         `json:"mechanism" yaml:"mechanism"`
+	User        string         `json:"user" yaml:"user"`
+	Password    string         `json:"password" yaml:"password"`
+	AccessToken string         `json:"access_token" yaml:"access_token"`
+	TokenCache  string         `json:"token_cache" yaml:"token_cache"`
+	TokenKey    string         `json:"token_key" yaml:"token_key"`
+	AWS         session.Config `json:"aws" yaml:"aws"`
 }
 
 // NewConfig returns a new SASL config for Kafka with default values.
 func NewConfig() Config {
-	return Config{} // Well, that seemed pointless.
+	return Config{
+		AWS: session.NewConfig(),
+	}
 }
 
 // FieldSpec returns specs for SASL fields.
 func FieldSpec() docs.FieldSpec {
 	return docs.FieldAdvanced("sasl", "Enables SASL authentication.").WithChildren(
 		docs.FieldDeprecated("enabled"),
-		docs.FieldCommon("mechanism", "The SASL authentication mechanism, if left empty SASL authentication is not used. Warning: SCRAM based methods within Benthos have not received a security audit.").HasOptions(sarama.SASLTypePlaintext, sarama.SASLTypeOAuth, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512),
+		docs.FieldCommon("mechanism", "The SASL authentication mechanism, if left empty SASL authentication is not used. Warning: SCRAM based methods within Benthos have not received a security audit.").HasOptions(sarama.SASLTypePlaintext, sarama.SASLTypeOAuth, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512, SASLTypeAWSMSKIAM),
 		docs.FieldCommon("user", "A `"+sarama.SASLTypePlaintext+"` username. It is recommended that you use environment variables to populate this field.", "${USER}"),
 		docs.FieldCommon("password", "A `"+sarama.SASLTypePlaintext+"` password. It is recommended that you use environment variables to populate this field.", "${PASSWORD}"),
 		docs.FieldAdvanced("access_token", "A static `"+sarama.SASLTypeOAuth+"` access token"),
-		docs.FieldAdvanced("token_cache", "Instead of using a static `access_token` allows you to query a [`cache`](/docs/components/caches/about) resource to fetch `"+sarama.SASLTypeOAuth+"` tokens from"),
+		docs.FieldAdvanced("token_cache", "Instead of using a static `access_token` allows you to query a [`cache`](/docs/components/caches/about) resource to fetch `"+sarama.SASLTypeOAuth+"` tokens from. The cache is queried each time a new broker connection is established, and therefore tokens can be refreshed by updating the cache entry."),
 		docs.FieldAdvanced("token_key", "Required when using a `token_cache`, the key to query the cache with for tokens."),
+		docs.FieldAdvanced("aws", "AWS configuration used by the `"+SASLTypeAWSMSKIAM+"` mechanism in order to sign authentication tokens. A fresh token is signed for each new broker connection.").WithChildren(session.FieldSpecs()...),
 	)
 }
 
@@ -65,6 +70,15 @@ func (s Config) Apply(mgr types.Manager, conf *sarama.Config) error {
 			}
 		}
 		conf.Net.SASL.TokenProvider = tp
+	case SASLTypeAWSMSKIAM:
+		tp, err := newMSKIAMAccessTokenProvider(s.AWS)
+		if err != nil {
+			return err
+		}
+		conf.Net.SASL.TokenProvider = tp
+		conf.Net.SASL.Enable = true
+		conf.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+		return nil
 	case sarama.SASLTypeSCRAMSHA256:
 		conf.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
 			return &XDGSCRAMClient{HashGeneratorFcn: SHA256}
@@ -1,6 +1,9 @@
 package sasl
 
 import (
+	"encoding/base64"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/Jeffail/benthos/v3/lib/types"
@@ -174,6 +177,65 @@ func TestApplyOAuthBearerCacheProvider(t *testing.T) {
 	}
 }
 
+func TestApplyAWSMSKIAM(t *testing.T) {
+	conf := &sarama.Config{}
+
+	saslConf := NewConfig()
+	saslConf.Mechanism = SASLTypeAWSMSKIAM
+	saslConf.AWS.Region = "us-east-1"
+	saslConf.AWS.Credentials.ID = "foo"
+	saslConf.AWS.Credentials.Secret = "bar"
+
+	err := saslConf.Apply(types.NoopMgr(), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !conf.Net.SASL.Enable {
+		t.Errorf("SASL not enabled")
+	}
+
+	if conf.Net.SASL.Mechanism != sarama.SASLTypeOAuth {
+		t.Errorf("Wrong SASL mechanism: %v != %v", conf.Net.SASL.Mechanism, sarama.SASLTypeOAuth)
+	}
+
+	token, err := conf.Net.SASL.TokenProvider.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawURL, err := base64.RawURLEncoding.DecodeString(token.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signedURL, err := url.Parse(string(rawURL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp, act := "kafka.us-east-1.amazonaws.com", signedURL.Host; exp != act {
+		t.Errorf("Wrong host: %v != %v", act, exp)
+	}
+
+	query := signedURL.Query()
+	if exp, act := "kafka-cluster:Connect", query.Get("Action"); exp != act {
+		t.Errorf("Wrong action: %v != %v", act, exp)
+	}
+	if exp, act := "900", query.Get("X-Amz-Expires"); exp != act {
+		t.Errorf("Wrong expiry: %v != %v", act, exp)
+	}
+	if act := query.Get("X-Amz-Credential"); !strings.HasPrefix(act, "foo/") || !strings.HasSuffix(act, "/us-east-1/kafka-cluster/aws4_request") {
+		t.Errorf("Wrong credential scope: %v", act)
+	}
+	if query.Get("X-Amz-Signature") == "" {
+		t.Error("Expected token to be signed")
+	}
+	if query.Get("User-Agent") == "" {
+		t.Error("Expected user agent to be set")
+	}
+}
+
 func TestApplyUnknownMechanism(t *testing.T) {
 	conf := &sarama.Config{}
 
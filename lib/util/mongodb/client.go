@@ -0,0 +1,41 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	btls "github.com/Jeffail/benthos/v3/lib/util/tls"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ClientOptions returns the options of a client that connects to the
+// deployment of a connection string, where a username and password override
+// any credentials of the connection string and an enabled TLS config overrides
+// any TLS options of the connection string.
+func ClientOptions(url, username, password string, tlsConf btls.Config) (*options.ClientOptions, error) {
+	if !strings.HasPrefix(url, "mongodb://") && !strings.HasPrefix(url, "mongodb+srv://") {
+		return nil, errors.New("url must begin with mongodb:// or mongodb+srv://")
+	}
+
+	opts := options.Client().ApplyURI(url)
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	if username != "" {
+		var cred options.Credential
+		if opts.Auth != nil {
+			cred = *opts.Auth
+		}
+		cred.Username, cred.Password = username, password
+		opts.SetAuth(cred)
+	}
+	if tlsConf.Enabled {
+		tlsConfig, err := tlsConf.Get()
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+	return opts, nil
+}
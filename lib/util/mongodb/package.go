@@ -0,0 +1,3 @@
+// Package mongodb provides shared utilities for components that connect to
+// MongoDB deployments.
+package mongodb
@@ -0,0 +1,92 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// Config contains configuration fields for connecting to Redis.
+type Config struct {
+	URL    string `json:"url" yaml:"url"`
+	Kind   string `json:"kind" yaml:"kind"`
+	Master string `json:"master" yaml:"master"`
+}
+
+// NewConfig returns a Config with default values.
+func NewConfig() Config {
+	return Config{
+		URL:    "tcp://localhost:6379",
+		Kind:   "simple",
+		Master: "",
+	}
+}
+
+// parseURLs splits the URL field by commas and parses each resulting URL.
+func (c Config) parseURLs() ([]*url.URL, error) {
+	var urls []*url.URL
+	for _, rawURL := range strings.Split(c.URL, ",") {
+		if rawURL = strings.TrimSpace(rawURL); len(rawURL) == 0 {
+			continue
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse url '%v': %w", rawURL, err)
+		}
+		urls = append(urls, u)
+	}
+	if len(urls) == 0 {
+		return nil, errors.New("a url must be provided")
+	}
+	return urls, nil
+}
+
+// Client creates a Redis client of the configured kind. Clients of the kind
+// simple connect to a single server, cluster clients discover the topology of
+// a Redis Cluster from the servers provided and failover clients obtain the
+// address of the current master of a group from the provided sentinels.
+func (c Config) Client() (redis.UniversalClient, error) {
+	urls, err := c.parseURLs()
+	if err != nil {
+		return nil, err
+	}
+
+	var pass string
+	if urls[0].User != nil {
+		pass, _ = urls[0].User.Password()
+	}
+	addrs := make([]string, len(urls))
+	for i, u := range urls {
+		addrs[i] = u.Host
+	}
+
+	switch c.Kind {
+	case "simple":
+		if len(urls) > 1 {
+			return nil, errors.New("kind simple only supports a single url")
+		}
+		return redis.NewClient(&redis.Options{
+			Addr:     urls[0].Host,
+			Network:  urls[0].Scheme,
+			Password: pass,
+		}), nil
+	case "cluster":
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    addrs,
+			Password: pass,
+		}), nil
+	case "failover":
+		if len(c.Master) == 0 {
+			return nil, errors.New("a master name must be provided for kind failover")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    c.Master,
+			SentinelAddrs: addrs,
+			Password:      pass,
+		}), nil
+	}
+	return nil, fmt.Errorf("kind not recognised: %v", c.Kind)
+}
@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigClient(t *testing.T) {
+	conf := NewConfig()
+	client, err := conf.Client()
+	require.NoError(t, err)
+	simple, ok := client.(*redis.Client)
+	require.True(t, ok)
+	assert.Equal(t, "localhost:6379", simple.Options().Addr)
+	client.Close()
+
+	conf.URL = "tcp://:foo@node1:6379, tcp://node2:6379"
+	conf.Kind = "cluster"
+	client, err = conf.Client()
+	require.NoError(t, err)
+	cluster, ok := client.(*redis.ClusterClient)
+	require.True(t, ok)
+	assert.Equal(t, []string{"node1:6379", "node2:6379"}, cluster.Options().Addrs)
+	assert.Equal(t, "foo", cluster.Options().Password)
+	client.Close()
+
+	conf.Kind = "failover"
+	conf.Master = "mymaster"
+	client, err = conf.Client()
+	require.NoError(t, err)
+	_, ok = client.(*redis.Client)
+	assert.True(t, ok)
+	client.Close()
+}
+
+func TestConfigClientErrors(t *testing.T) {
+	tests := map[string]Config{
+		"no url":          {URL: " , ", Kind: "simple"},
+		"bad url":         {URL: "tcp://%%", Kind: "simple"},
+		"multiple simple": {URL: "tcp://foo:6379,tcp://bar:6379", Kind: "simple"},
+		"no master":       {URL: "tcp://foo:26379", Kind: "failover"},
+		"bad kind":        {URL: "tcp://foo:6379", Kind: "nope"},
+	}
+	for name, conf := range tests {
+		_, err := conf.Client()
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,15 @@
+package redis
+
+import "github.com/Jeffail/benthos/v3/internal/docs"
+
+// ConfigDocs returns the field specs of the Redis connection fields.
+func ConfigDocs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldCommon(
+			"url", "The URL of the target Redis server. For the kinds cluster and failover multiple comma separated URLs can be specified, which are the seed nodes of a cluster or the sentinels of a failover group respectively.",
+			"tcp://localhost:6379", "tcp://node1:6379,tcp://node2:6379",
+		),
+		docs.FieldAdvanced("kind", "Specifies a simple, cluster-aware, or failover-aware Redis client.").HasOptions("simple", "cluster", "failover"),
+		docs.FieldAdvanced("master", "The name of the Redis master group when using the kind failover.", "mymaster"),
+	}
+}
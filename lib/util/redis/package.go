@@ -0,0 +1,3 @@
+// Package redis provides shared utilities for components that connect to Redis
+// servers, clusters or sentinel monitored failover groups.
+package redis